@@ -1,24 +1,115 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
 
-	"github.com/skygenesisenterprise/aether-vault/package/cli/agent"
-	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/config"
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/capability"
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/ipc"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 )
 
 var (
 	agentConfigFile string
 	agentMode       string
 	agentLogLevel   string
+	agentSocketPath string
+	agentPolicyDir  string
 )
 
+// agentConfig is the agent configuration file
+type agentConfig struct {
+	// Agent mode: standard, hardened, development
+	Mode string `yaml:"mode"`
+
+	// Log level: debug, info, warn, error
+	LogLevel string `yaml:"log_level"`
+
+	// Unix socket the agent serves
+	SocketPath string `yaml:"socket_path"`
+
+	// Directory of JSON policy files; no policy engine when empty
+	PolicyDir string `yaml:"policy_dir,omitempty"`
+
+	// File capabilities are persisted to
+	StorageFile string `yaml:"storage_file"`
+
+	// Maximum IPC connections
+	MaxConnections int `yaml:"max_connections"`
+
+	// How long an upgrade waits for in-flight requests and the successor
+	DrainTimeout time.Duration `yaml:"drain_timeout"`
+
+	// Lease on an in-flight capability use in seconds
+	ConcurrencySlotTTL int64 `yaml:"concurrency_slot_ttl"`
+}
+
+// defaultAgentConfig returns the agent configuration used without a file
+func defaultAgentConfig() *agentConfig {
+	server := ipc.DefaultServerConfig()
+	store := capability.DefaultStoreConfig()
+	return &agentConfig{
+		Mode:               "standard",
+		LogLevel:           server.LogLevel,
+		SocketPath:         server.SocketPath,
+		StorageFile:        store.StorageFilePath,
+		MaxConnections:     server.MaxConnections,
+		DrainTimeout:       server.DrainTimeout,
+		ConcurrencySlotTTL: store.ConcurrencySlotTTL,
+	}
+}
+
+// validate checks the configuration
+func (c *agentConfig) validate() error {
+	switch c.Mode {
+	case "standard", "hardened", "development":
+	default:
+		return fmt.Errorf("invalid mode %q (supported: standard, hardened, development)", c.Mode)
+	}
+	switch c.LogLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		return fmt.Errorf("invalid log level %q (supported: debug, info, warn, error)", c.LogLevel)
+	}
+	if c.SocketPath == "" {
+		return fmt.Errorf("socket_path is required")
+	}
+	if c.MaxConnections <= 0 {
+		return fmt.Errorf("max_connections must be positive")
+	}
+	if c.DrainTimeout <= 0 {
+		return fmt.Errorf("drain_timeout must be positive")
+	}
+	if c.ConcurrencySlotTTL < 0 {
+		return fmt.Errorf("concurrency_slot_ttl must not be negative")
+	}
+	return nil
+}
+
+// serverConfig returns the IPC server configuration
+func (c *agentConfig) serverConfig() *ipc.ServerConfig {
+	config := ipc.DefaultServerConfig()
+	config.SocketPath = c.SocketPath
+	config.LogLevel = c.LogLevel
+	config.MaxConnections = c.MaxConnections
+	config.DrainTimeout = c.DrainTimeout
+	config.EnableLogging = c.LogLevel == "debug" || c.LogLevel == "info"
+	return config
+}
+
+// storeConfig returns the capability store configuration
+func (c *agentConfig) storeConfig() *capability.StoreConfig {
+	config := capability.DefaultStoreConfig()
+	config.StorageFilePath = c.StorageFile
+	config.ConcurrencySlotTTL = c.ConcurrencySlotTTL
+	return config
+}
+
 // newAgentCommand creates the agent command group
 func newAgentCommand() *cobra.Command {
 	cmd := &cobra.Command{
@@ -26,10 +117,9 @@ func newAgentCommand() *cobra.Command {
 		Short: "Manage Aether Vault Agent (security daemon)",
 		Long: `Aether Vault Agent is a long-lived security daemon that provides:
   - Local policy evaluation and enforcement
-  - Secure secrets management with caching
-  - Runtime environment integration
-  - Comprehensive audit and logging
-  
+  - Capability issuing and validation over a Unix socket
+  - Zero-downtime upgrades
+
 The agent operates as a local authority of trust for all operations.`,
 	}
 
@@ -37,6 +127,7 @@ The agent operates as a local authority of trust for all operations.`,
 	cmd.AddCommand(newAgentStopCommand())
 	cmd.AddCommand(newAgentStatusCommand())
 	cmd.AddCommand(newAgentReloadCommand())
+	cmd.AddCommand(newAgentUpgradeCommand())
 	cmd.AddCommand(newAgentConfigCommand())
 
 	return cmd
@@ -47,18 +138,21 @@ func newAgentStartCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "start",
 		Short: "Start the Aether Vault Agent daemon",
-		Long: `Start the Aether Vault Agent as a long-lived daemon process.
+		Long: `Start the Aether Vault Agent as a long-lived process in the foreground.
 The agent will:
-  - Initialize local policy engine
-  - Start IPC server on Unix socket
-  - Begin runtime monitoring
-  - Enable audit logging`,
+  - Initialize the capability engine
+  - Load policies from the policy directory, if one is set
+  - Serve the IPC protocol on its Unix socket
+An agent started by 'vault agent upgrade' takes over the socket, state and
+connections of the agent it replaces.`,
 		RunE: runAgentStartCommand,
 	}
 
 	cmd.Flags().StringVar(&agentConfigFile, "config", "", "Path to agent configuration file")
 	cmd.Flags().StringVar(&agentMode, "mode", "standard", "Agent mode: standard, hardened, development")
 	cmd.Flags().StringVar(&agentLogLevel, "log-level", "info", "Log level: debug, info, warn, error")
+	cmd.Flags().StringVar(&agentSocketPath, "socket-path", "", "Unix socket path")
+	cmd.Flags().StringVar(&agentPolicyDir, "policy-dir", "", "Directory for policy files")
 
 	return cmd
 }
@@ -69,11 +163,8 @@ func newAgentStopCommand() *cobra.Command {
 		Use:   "stop",
 		Short: "Stop the Aether Vault Agent daemon",
 		Long: `Gracefully stop the Aether Vault Agent daemon.
-The agent will:
-  - Stop accepting new connections
-  - Complete in-flight operations
-  - Flush all audit logs
-  - Clean up temporary resources`,
+The agent stops accepting connections, closes open ones and removes its
+socket.`,
 		RunE: runAgentStopCommand,
 	}
 
@@ -84,53 +175,64 @@ The agent will:
 func newAgentStatusCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "status",
-		Short: "Show agent daemon status and health",
-		Long: `Display comprehensive status information about the Aether Vault Agent:
-  - Process status and uptime
-  - IPC server status
-  - Policy engine statistics
-  - Cache hit rates
-  - Recent audit events
-  - Runtime integration status`,
+		Short: "Show agent daemon status",
+		Long: `Display status information about the running Aether Vault Agent:
+process ID, connections and the capability token formats it issues.`,
 		RunE: runAgentStatusCommand,
 	}
 
-	cmd.Flags().Bool("verbose", false, "Show detailed status information")
-	cmd.Flags().String("format", "table", "Output format: table, json, yaml")
+	cmd.Flags().String("format", "table", "Output format: table, json")
 
 	return cmd
 }
 
 // newAgentReloadCommand creates the agent reload command
-func newAgentCommand() *cobra.Command {
+func newAgentReloadCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "reload",
-		Short: "Reload agent configuration and policies",
-		Long: `Reload the agent configuration and cached policies:
-  - Reload configuration from file
-  - Refresh policy cache
-  - Restart policy engine
-  - Maintain existing connections
-  - Log reload events`,
+		Short: "Reload agent policies",
+		Long: `Reload the agent's policies from its policy directory. Existing
+connections and issued capabilities are kept.`,
 		RunE: runAgentReloadCommand,
 	}
 
 	return cmd
 }
 
+// newAgentUpgradeCommand creates the agent upgrade command
+func newAgentUpgradeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "upgrade",
+		Short: "Hand the running agent over to the installed binary",
+		Long: `Replace the running agent with the agent binary now installed at its
+path, without downtime:
+  - The new process inherits the listening Unix socket
+  - In-flight requests finish, then open connections move over
+  - Capabilities, usage and limit state are migrated
+  - The old process exits once the new one accepts
+If the new process does not take over, the old one keeps serving.`,
+		RunE: runAgentUpgradeCommand,
+	}
+
+	cmd.Flags().Duration("timeout", 60*time.Second, "How long to wait for the new agent process")
+
+	return cmd
+}
+
 // newAgentConfigCommand creates the agent configuration command
-func newAgentCommand() *cobra.Command {
+func newAgentConfigCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "config",
-		Short: "Manage agent configuration",
-		Long: `Manage Aether Vault Agent configuration:
-  - Show current configuration
-  - Generate default configuration
-  - Validate configuration
-  - Set configuration values`,
+		Short: "Show agent configuration",
+		Long: `Show the agent configuration in effect, loaded from --config or
+the default locations:
+  /etc/aether-vault/agent.yaml
+  ~/.aether-vault/agent.yaml
+  ./agent.yaml`,
 		RunE: runAgentConfigCommand,
 	}
 
+	cmd.Flags().StringVar(&agentConfigFile, "config", "", "Path to agent configuration file")
 	cmd.Flags().String("output", "", "Output configuration to file")
 	cmd.Flags().Bool("validate", false, "Validate configuration only")
 
@@ -139,125 +241,161 @@ func newAgentCommand() *cobra.Command {
 
 // runAgentStartCommand executes the agent start command
 func runAgentStartCommand(cmd *cobra.Command, args []string) error {
-	// Load configuration
-	cfg, err := loadAgentConfig()
+	cfg, err := loadAgentConfig(cmd)
 	if err != nil {
 		return fmt.Errorf("failed to load agent configuration: %w", err)
 	}
+	if err := cfg.validate(); err != nil {
+		return fmt.Errorf("invalid agent configuration: %w", err)
+	}
 
-	// Create agent instance
-	agentInstance, err := agent.NewAgent(cfg)
+	// An agent started by an upgrade takes over from its predecessor,
+	// which keeps serving until Start succeeds
+	handoff, err := ipc.ReceiveHandoff()
 	if err != nil {
-		return fmt.Errorf("failed to create agent: %w", err)
+		return fmt.Errorf("failed to receive agent handoff: %w", err)
 	}
 
-	// Setup signal handling for graceful shutdown
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
-
-	// Start the agent in background
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	server, policyEngine, err := newAgentServer(cfg, handoff)
+	if err != nil {
+		if handoff != nil {
+			handoff.Close()
+		}
+		return err
+	}
 
-	go func() {
-		if err := agentInstance.Start(ctx); err != nil {
-			fmt.Fprintf(os.Stderr, "Agent failed to start: %v\n", err)
-			cancel()
+	if err := server.Start(); err != nil {
+		if handoff != nil {
+			handoff.Close()
 		}
-	}()
+		return fmt.Errorf("failed to start agent: %w", err)
+	}
+
+	fmt.Printf("Agent started (pid %d) on %s\n", os.Getpid(), cfg.SocketPath)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
 
-	// Wait for signals
 	for {
 		select {
-		case sig := <-sigChan:
-			fmt.Printf("Received signal %v, shutting down agent...\n", sig)
-			if err := agentInstance.Stop(ctx); err != nil {
-				fmt.Fprintf(os.Stderr, "Error stopping agent: %v\n", err)
-			}
-			return nil
-		case <-ctx.Done():
+		case <-server.HandedOff():
+			// The successor serves the socket now
 			return nil
-		case <-time.After(time.Second):
-			// Check agent health
-			if status, err := agentInstance.Health(); err != nil || status.Status != "healthy" {
-				fmt.Fprintf(os.Stderr, "Agent unhealthy: %v\n", err)
-				cancel()
+		case sig := <-signals:
+			if sig == syscall.SIGHUP {
+				reloadAgentPolicies(policyEngine)
+				continue
 			}
+			fmt.Printf("Received signal %v, shutting down agent...\n", sig)
+			return server.Stop()
 		}
 	}
 }
 
-// runAgentStopCommand executes the agent stop command
-func runAgentStopCommand(cmd *cobra.Command, args []string) error {
-	// Connect to running agent
-	client, err := agent.NewAgentClient()
+// newAgentServer builds the capability engine, from the handed over state
+// when there is one, and the IPC server serving it
+func newAgentServer(cfg *agentConfig, handoff *ipc.Handoff) (*ipc.Server, *capability.PolicyEngine, error) {
+	store, err := capability.NewStore(cfg.storeConfig())
 	if err != nil {
-		return fmt.Errorf("failed to connect to agent: %w", err)
+		return nil, nil, fmt.Errorf("failed to create capability store: %w", err)
 	}
-	defer client.Close()
 
-	// Stop the agent
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	var engine *capability.Engine
+	if handoff != nil {
+		engine, err = capability.NewEngineFromState(capability.DefaultEngineConfig(), store, handoff.State)
+	} else {
+		engine, err = capability.NewEngine(capability.DefaultEngineConfig(), store)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create capability engine: %w", err)
+	}
+
+	var policyEngine *capability.PolicyEngine
+	if cfg.PolicyDir != "" {
+		policyEngine, err = capability.NewPolicyEngine(nil, cfg.PolicyDir)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create policy engine: %w", err)
+		}
+	}
 
-	if err := client.Stop(ctx); err != nil {
+	server, err := ipc.NewServer(cfg.serverConfig(), engine, policyEngine)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create IPC server: %w", err)
+	}
+	if handoff != nil {
+		server.UseHandoff(handoff)
+	}
+
+	return server, policyEngine, nil
+}
+
+// reloadAgentPolicies reloads the policy directory on SIGHUP
+func reloadAgentPolicies(policyEngine *capability.PolicyEngine) {
+	if policyEngine == nil {
+		fmt.Println("No policy directory configured, nothing to reload")
+		return
+	}
+	if err := policyEngine.ReloadPolicies(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to reload policies: %v\n", err)
+		return
+	}
+	fmt.Printf("Reloaded %d policies\n", len(policyEngine.ListPolicies()))
+}
+
+// runAgentStopCommand executes the agent stop command
+func runAgentStopCommand(cmd *cobra.Command, args []string) error {
+	if err := signalAgent(syscall.SIGTERM); err != nil {
 		return fmt.Errorf("failed to stop agent: %w", err)
 	}
 
-	fmt.Println("Agent stopped successfully")
+	fmt.Println("Agent is stopping")
 	return nil
 }
 
 // runAgentStatusCommand executes the agent status command
 func runAgentStatusCommand(cmd *cobra.Command, args []string) error {
-	verbose, _ := cmd.Flags().GetBool("verbose")
 	format, _ := cmd.Flags().GetString("format")
 
-	// Connect to running agent
-	client, err := agent.NewAgentClient()
+	client, err := connectAgent()
 	if err != nil {
-		return fmt.Errorf("failed to connect to agent: %w", err)
+		return err
 	}
 	defer client.Close()
 
-	// Get agent status
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	status, err := client.Status(ctx, verbose)
+	serverInfo, err := client.GetStatus()
 	if err != nil {
 		return fmt.Errorf("failed to get agent status: %w", err)
 	}
 
-	// Output status based on format
-	switch format {
-	case "json":
-		return outputStatusJSON(status)
-	case "yaml":
-		return outputStatusYAML(status)
-	default:
-		return outputStatusTable(status, verbose)
-	}
+	return displayCapabilityStatus(serverInfo, format)
 }
 
 // runAgentReloadCommand executes the agent reload command
 func runAgentReloadCommand(cmd *cobra.Command, args []string) error {
-	// Connect to running agent
-	client, err := agent.NewAgentClient()
+	if err := signalAgent(syscall.SIGHUP); err != nil {
+		return fmt.Errorf("failed to reload agent: %w", err)
+	}
+
+	fmt.Println("Agent is reloading its policies")
+	return nil
+}
+
+// runAgentUpgradeCommand executes the agent upgrade command
+func runAgentUpgradeCommand(cmd *cobra.Command, args []string) error {
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+
+	client, err := connectAgent()
 	if err != nil {
-		return fmt.Errorf("failed to connect to agent: %w", err)
+		return err
 	}
 	defer client.Close()
 
-	// Reload configuration
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	if err := client.Reload(ctx); err != nil {
-		return fmt.Errorf("failed to reload agent: %w", err)
+	pid, err := client.Upgrade(timeout)
+	if err != nil {
+		return fmt.Errorf("failed to upgrade agent: %w", err)
 	}
 
-	fmt.Println("Agent reloaded successfully")
+	fmt.Printf("Agent upgraded successfully (pid %d)\n", pid)
 	return nil
 }
 
@@ -266,46 +404,120 @@ func runAgentConfigCommand(cmd *cobra.Command, args []string) error {
 	output, _ := cmd.Flags().GetString("output")
 	validate, _ := cmd.Flags().GetBool("validate")
 
-	// Generate default configuration
-	cfg := agent.DefaultAgentConfig()
+	cfg, err := loadAgentConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to load agent configuration: %w", err)
+	}
 
-	// Validate if requested
+	if err := cfg.validate(); err != nil {
+		return fmt.Errorf("configuration validation failed: %w", err)
+	}
 	if validate {
-		if err := cfg.Validate(); err != nil {
-			return fmt.Errorf("configuration validation failed: %w", err)
-		}
 		fmt.Println("Configuration is valid")
 		return nil
 	}
 
-	// Output configuration
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal configuration: %w", err)
+	}
+
 	if output != "" {
-		return cfg.SaveToFile(output)
+		return os.WriteFile(output, data, 0600)
 	}
 
-	// Print to stdout
-	return cfg.Print()
+	fmt.Print(string(data))
+	return nil
 }
 
-// loadAgentConfig loads agent configuration
-func loadAgentConfig() (*agent.AgentConfig, error) {
+// loadAgentConfig loads the agent configuration file, then applies the
+// command's flags
+func loadAgentConfig(cmd *cobra.Command) (*agentConfig, error) {
+	cfg := defaultAgentConfig()
+
 	if agentConfigFile != "" {
-		return agent.LoadAgentConfigFromFile(agentConfigFile)
+		if err := readAgentConfig(agentConfigFile, cfg); err != nil {
+			return nil, err
+		}
+	} else {
+		homeDir, _ := os.UserHomeDir()
+		paths := []string{
+			"/etc/aether-vault/agent.yaml",
+			filepath.Join(homeDir, ".aether-vault", "agent.yaml"),
+			"./agent.yaml",
+		}
+		for _, path := range paths {
+			if _, err := os.Stat(path); err == nil {
+				if err := readAgentConfig(path, cfg); err != nil {
+					return nil, err
+				}
+				break
+			}
+		}
 	}
 
-	// Try default locations
-	paths := []string{
-		"/etc/aether-vault/agent.yaml",
-		"~/.aether-vault/agent.yaml",
-		"./agent.yaml",
+	flags := cmd.Flags()
+	if flags.Changed("mode") {
+		cfg.Mode = agentMode
+	}
+	if flags.Changed("log-level") {
+		cfg.LogLevel = agentLogLevel
+	}
+	if flags.Changed("socket-path") {
+		cfg.SocketPath = agentSocketPath
+	}
+	if flags.Changed("policy-dir") {
+		cfg.PolicyDir = agentPolicyDir
 	}
 
-	for _, path := range paths {
-		if cfg, err := agent.LoadAgentConfigFromFile(path); err == nil {
-			return cfg, nil
-		}
+	return cfg, nil
+}
+
+// readAgentConfig reads the configuration file at path over cfg
+func readAgentConfig(path string, cfg *agentConfig) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
 	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
 
-	// Return default configuration
-	return agent.DefaultAgentConfig(), nil
+// connectAgent connects to the running agent
+func connectAgent() (*ipc.Client, error) {
+	clientConfig := ipc.DefaultClientConfig()
+	clientConfig.EnableLogging = false
+	client, err := ipc.NewClient(clientConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create agent client: %w", err)
+	}
+	if err := client.Connect(); err != nil {
+		return nil, fmt.Errorf("failed to connect to agent: %w", err)
+	}
+	return client, nil
+}
+
+// signalAgent sends sig to the running agent process
+func signalAgent(sig os.Signal) error {
+	client, err := connectAgent()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	serverInfo, err := client.GetStatus()
+	if err != nil {
+		return fmt.Errorf("failed to get agent status: %w", err)
+	}
+	if serverInfo.PID == 0 {
+		return fmt.Errorf("agent did not report its process ID")
+	}
+
+	process, err := os.FindProcess(serverInfo.PID)
+	if err != nil {
+		return err
+	}
+	return process.Signal(sig)
 }
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var auditSearchLimit int
+
+// newAuditCommand creates the audit command
+func newAuditCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Inspect the server audit log",
+		Long: `Inspect the Aether Vault server audit log.
+
+Secret paths and values are recorded as keyed HMACs rather than in the
+clear. Use 'vault audit hash' to compute the HMAC of a known value with
+the server's audit key, then search the log for it.
+
+Examples:
+  vault audit hash database/prod/password
+  echo -n "s3cr3t" | vault audit hash -
+  vault audit search hmac-sha256:3f1c...`,
+	}
+
+	cmd.AddCommand(newAuditHashCommand())
+	cmd.AddCommand(newAuditSearchCommand())
+
+	return cmd
+}
+
+// newAuditHashCommand creates the audit hash command
+func newAuditHashCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "hash <value|->",
+		Short: "Compute the audit HMAC of a value",
+		Long:  "Compute the audit HMAC of a value. Pass - to read the value from stdin.",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runAuditHashCommand,
+	}
+}
+
+// newAuditSearchCommand creates the audit search command
+func newAuditSearchCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "search <hash>",
+		Short: "Find audit entries referencing an HMAC",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runAuditSearchCommand,
+	}
+
+	cmd.Flags().IntVar(&auditSearchLimit, "limit", 50, "Maximum number of entries")
+
+	return cmd
+}
+
+// runAuditHashCommand executes the audit hash command
+func runAuditHashCommand(cmd *cobra.Command, args []string) error {
+	input := args[0]
+	if input == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read stdin: %w", err)
+		}
+		input = strings.TrimRight(string(data), "\r\n")
+	}
+
+	api, err := newServerAPIClient()
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Hash string `json:"hash"`
+	}
+	if err := api.Do(context.Background(), http.MethodPost, "/audit/hash", map[string]string{"input": input}, &response); err != nil {
+		return fmt.Errorf("failed to hash value: %w", err)
+	}
+
+	fmt.Println(response.Hash)
+	return nil
+}
+
+// runAuditSearchCommand executes the audit search command
+func runAuditSearchCommand(cmd *cobra.Command, args []string) error {
+	api, err := newServerAPIClient()
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	query.Set("hash", args[0])
+	query.Set("limit", fmt.Sprintf("%d", auditSearchLimit))
+
+	var response struct {
+		Logs []struct {
+			ID        string `json:"id"`
+			Action    string `json:"action"`
+			Resource  string `json:"resource"`
+			Success   bool   `json:"success"`
+			CreatedAt string `json:"created_at"`
+		} `json:"logs"`
+	}
+	if err := api.Do(context.Background(), http.MethodGet, "/audit/logs/search?"+query.Encode(), nil, &response); err != nil {
+		return fmt.Errorf("failed to search audit log: %w", err)
+	}
+
+	if len(response.Logs) == 0 {
+		fmt.Println("No matching audit entries")
+		return nil
+	}
+
+	for _, entry := range response.Logs {
+		status := ui.Success("ok")
+		if !entry.Success {
+			status = ui.Error("failed")
+		}
+		fmt.Printf("%s  %s  %s  %s\n", entry.CreatedAt, ui.BoldText(entry.Action), entry.Resource, status)
+	}
+	return nil
+}
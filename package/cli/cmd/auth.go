@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"strings"
 
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/ui"
 	"github.com/spf13/cobra"
 )
 
@@ -18,6 +21,7 @@ func newAuthCommand() *cobra.Command {
 	cmd.AddCommand(newLoginCommand())
 	cmd.AddCommand(newConnectCommand())
 	cmd.AddCommand(newLogoutCommand())
+	cmd.AddCommand(newCapabilitiesCommand())
 
 	return cmd
 }
@@ -80,6 +84,24 @@ This command will:
 	return cmd
 }
 
+// newCapabilitiesCommand creates the capabilities command
+func newCapabilitiesCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "capabilities [path]",
+		Short: "Show what your token may do on a policy path",
+		Long: `Show the actions your token may take on a policy path, such as
+sys/mounts or secrets/prod/db, and which of your policies allow or deny them.
+
+Examples:
+  vault auth capabilities sys/events
+  vault auth capabilities sys/mounts --format json`,
+		Args: cobra.ExactArgs(1),
+		RunE: runCapabilitiesCommand,
+	}
+
+	return cmd
+}
+
 // runLoginCommand executes the login command
 func runLoginCommand(cmd *cobra.Command, args []string) error {
 	method, _ := cmd.Flags().GetString("method")
@@ -168,3 +190,45 @@ func runTokenLogin(token, url string) error {
 
 	return nil
 }
+
+// runCapabilitiesCommand executes the capabilities command
+func runCapabilitiesCommand(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+
+	api, err := newServerAPIClient()
+	if err != nil {
+		return err
+	}
+
+	capabilities, err := api.Capabilities(context.Background(), args[0])
+	if err != nil {
+		return fmt.Errorf("failed to check capabilities: %w", err)
+	}
+
+	switch format {
+	case "json":
+		return ui.FormatOutput(capabilities, ui.FormatJSON)
+	case "yaml":
+		return ui.FormatOutput(capabilities, ui.FormatYAML)
+	}
+
+	if len(capabilities.Capabilities) == 0 {
+		fmt.Printf("%s: %s\n", ui.BoldText(capabilities.Path), ui.DimText("no capabilities"))
+	} else {
+		fmt.Printf("%s: %s\n", ui.BoldText(capabilities.Path), strings.Join(capabilities.Capabilities, ", "))
+	}
+	if capabilities.CentralAdmin {
+		fmt.Println(ui.Info("Central admin: policy checks pass without a policy on most paths"))
+	}
+	for _, policy := range capabilities.Policies {
+		line := "  " + policy.Name
+		if len(policy.Allowed) > 0 {
+			line += "  allows " + strings.Join(policy.Allowed, ", ")
+		}
+		if len(policy.Denied) > 0 {
+			line += "  denies " + strings.Join(policy.Denied, ", ")
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/bulk"
+	"github.com/spf13/cobra"
+)
+
+var (
+	bulkConcurrency int
+	bulkStateFile   string
+)
+
+// addBulkFlags adds the worker pool flags of a bulk command. Resumable
+// commands also take --state-file; others hold plaintext in memory only.
+func addBulkFlags(cmd *cobra.Command, resumable bool) {
+	cmd.Flags().IntVar(&bulkConcurrency, "concurrency", bulk.DefaultConcurrency, "Number of secrets processed at once")
+	if resumable {
+		cmd.Flags().StringVar(&bulkStateFile, "state-file", "", "Record finished secrets here and skip them when run again")
+	}
+}
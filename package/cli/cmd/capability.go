@@ -1,14 +1,14 @@
 package cmd
 
 import (
-	"context"
+	"bufio"
 	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
 	"time"
 
-	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/capability"
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/attestation"
 	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/ipc"
 	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/ui"
 	"github.com/skygenesisenterprise/aether-vault/package/cli/pkg/types"
@@ -25,6 +25,8 @@ var (
 	capPurpose     string
 	capConstraints string
 	capContext     string
+	capTOTPCode    string
+	capTokenFormat string
 
 	// Capability list flags
 	capListIdentity string
@@ -34,7 +36,10 @@ var (
 	capListOffset   int
 
 	// Capability validation flags
-	capValidateContext string
+	capValidateContext     string
+	capValidateAttestBin   string
+	capValidateAttestImage bool
+	capValidateToken       string
 
 	// Capability revoke flags
 	capRevokeReason string
@@ -83,6 +88,8 @@ The capability will be evaluated against policies and returned if approved.`,
 	cmd.Flags().StringVar(&capPurpose, "purpose", "", "Purpose of the request")
 	cmd.Flags().StringVar(&capConstraints, "constraints", "", "Constraints in JSON format")
 	cmd.Flags().StringVar(&capContext, "context", "", "Request context in JSON format")
+	cmd.Flags().StringVar(&capTOTPCode, "totp-code", "", "TOTP code for step-up on high-risk requests")
+	cmd.Flags().StringVar(&capTokenFormat, "token-format", "json", "Capability token format (json, cose)")
 
 	cmd.MarkFlagRequired("resource")
 	cmd.MarkFlagRequired("action")
@@ -96,12 +103,16 @@ func newCapabilityValidateCommand() *cobra.Command {
 		Use:   "validate [capability-id]",
 		Short: "Validate a capability",
 		Long: `Validate an existing capability to check if it's still valid
-and can be used for resource access.`,
-		Args: cobra.ExactArgs(1),
+and can be used for resource access. Pass --token instead of an ID to
+verify a COSE token.`,
+		Args: cobra.MaximumNArgs(1),
 		RunE: runCapabilityValidateCommand,
 	}
 
 	cmd.Flags().StringVar(&capValidateContext, "context", "", "Validation context in JSON format")
+	cmd.Flags().StringVar(&capValidateAttestBin, "attest-binary", "", "Attach SHA-256 attestation of the given binary")
+	cmd.Flags().BoolVar(&capValidateAttestImage, "attest-image", false, "Attach container image digest attestation")
+	cmd.Flags().StringVar(&capValidateToken, "token", "", "COSE capability token to validate instead of a capability ID")
 
 	return cmd
 }
@@ -198,20 +209,82 @@ func runCapabilityRequestCommand(cmd *cobra.Command, args []string) error {
 		Purpose:     capPurpose,
 	}
 
+	if capTOTPCode != "" {
+		request.StepUp = &types.StepUpProof{Factor: "totp", Code: capTOTPCode}
+	}
+
+	// Older agents only issue JSON capabilities
+	if capTokenFormat != "" && capTokenFormat != "json" {
+		request.Format = client.NegotiateFormat(capTokenFormat)
+		if request.Format != capTokenFormat {
+			fmt.Fprintln(os.Stderr, ui.Warning(fmt.Sprintf("The agent does not issue %s tokens; falling back to json", capTokenFormat)))
+		}
+	}
+
 	// Request capability
 	response, err := client.RequestCapability(request)
 	if err != nil {
 		return fmt.Errorf("capability request failed: %w", err)
 	}
 
+	// High-risk requests are only signed after a step-up
+	if response.Status == "step_up_required" && request.StepUp == nil {
+		proof, err := promptStepUp(response)
+		if err != nil {
+			return err
+		}
+		request.StepUp = proof
+
+		response, err = client.RequestCapability(request)
+		if err != nil {
+			return fmt.Errorf("capability request failed: %w", err)
+		}
+	}
+
 	// Display response
 	format, _ := cmd.Flags().GetString("format")
 	return displayCapabilityResponse(response, format)
 }
 
+// promptStepUp asks for the second factor the agent requires. Only TOTP
+// can be entered at a terminal.
+func promptStepUp(response *types.CapabilityResponse) (*types.StepUpProof, error) {
+	if response.Risk == nil || !containsFactor(response.Risk.Factors, "totp") {
+		return nil, fmt.Errorf("%s", response.Message)
+	}
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil, fmt.Errorf("%s; pass --totp-code to run non-interactively", response.Message)
+	}
+
+	fmt.Fprintln(os.Stderr, ui.Warning(fmt.Sprintf("Step-up required (risk %d/%d: %s)", response.Risk.Score, response.Risk.Threshold, strings.Join(response.Risk.Reasons, ", "))))
+	fmt.Fprintf(os.Stderr, "TOTP code: ")
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return nil, fmt.Errorf("failed to read TOTP code: %w", err)
+	}
+	code := strings.TrimSpace(line)
+	if code == "" {
+		return nil, fmt.Errorf("TOTP code is required")
+	}
+
+	return &types.StepUpProof{Factor: "totp", Code: code}, nil
+}
+
+func containsFactor(factors []string, factor string) bool {
+	for _, candidate := range factors {
+		if candidate == factor {
+			return true
+		}
+	}
+	return false
+}
+
 // runCapabilityValidateCommand executes the capability validate command
 func runCapabilityValidateCommand(cmd *cobra.Command, args []string) error {
-	capabilityID := args[0]
+	if (len(args) == 0) == (capValidateToken == "") {
+		return fmt.Errorf("pass either a capability ID or --token")
+	}
 
 	// Create IPC client
 	client, err := ipc.NewClient(nil)
@@ -234,8 +307,31 @@ func runCapabilityValidateCommand(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Collect fresh attestation evidence
+	var evidence *types.AttestationEvidence
+	switch {
+	case capValidateAttestBin != "":
+		evidence, err = attestation.CollectBinaryHash(capValidateAttestBin)
+	case capValidateAttestImage:
+		evidence, err = attestation.CollectImageDigest()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to collect attestation evidence: %w", err)
+	}
+	if evidence != nil {
+		if context == nil {
+			context = &types.RequestContext{}
+		}
+		context.Attestation = evidence
+	}
+
 	// Validate capability
-	result, err := client.ValidateCapability(capabilityID, context)
+	var result *types.ValidationResult
+	if capValidateToken != "" {
+		result, err = client.ValidateToken(capValidateToken, context)
+	} else {
+		result, err = client.ValidateCapability(args[0], context)
+	}
 	if err != nil {
 		return fmt.Errorf("capability validation failed: %w", err)
 	}
@@ -362,6 +458,25 @@ func displayCapabilityResponse(response *types.CapabilityResponse, format string
 			fmt.Printf("  Expires At: %s\n", response.Capability.ExpiresAt.Format(time.RFC3339))
 		}
 
+		if response.Token != "" {
+			fmt.Printf("\nToken (%s, %d bytes):\n  %s\n", response.Format, len(response.Token), response.Token)
+		}
+
+		if response.Risk != nil {
+			fmt.Printf("\nRisk Assessment:\n")
+			fmt.Printf("  Score: %d (step-up at %d)\n", response.Risk.Score, response.Risk.Threshold)
+			if len(response.Risk.Reasons) > 0 {
+				fmt.Printf("  Reasons: %s\n", strings.Join(response.Risk.Reasons, ", "))
+			}
+			if response.Capability != nil {
+				if stepUp, ok := response.Capability.Metadata["step_up"].(map[string]interface{}); ok {
+					fmt.Printf("  Step-Up: %v\n", stepUp["factor"])
+				}
+			} else if response.Message != "" {
+				fmt.Printf("  Message: %s\n", response.Message)
+			}
+		}
+
 		if response.PolicyResult != nil {
 			fmt.Printf("\nPolicy Evaluation:\n")
 			fmt.Printf("  Decision: %s\n", response.PolicyResult.Decision)
@@ -488,6 +603,7 @@ func displayCapabilityStatus(serverInfo *ipc.ServerInfo, format string) error {
 		// Table format
 		fmt.Printf("Aether Vault Agent Status:\n")
 		fmt.Printf("  Version: %s\n", serverInfo.Version)
+		fmt.Printf("  PID: %d\n", serverInfo.PID)
 		fmt.Printf("  Uptime: %v\n", serverInfo.Uptime)
 		fmt.Printf("  Connections: %d\n", serverInfo.ConnectionCount)
 
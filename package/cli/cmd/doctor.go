@@ -0,0 +1,555 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/client"
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/config"
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/e2e"
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/ipc"
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/ui"
+	"github.com/skygenesisenterprise/aether-vault/package/cli/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// Tokens are checked against the server clock, so larger skews make
+	// fresh tokens look expired or not yet valid
+	doctorSkewWarn = 30 * time.Second
+	doctorSkewFail = 5 * time.Minute
+
+	// doctorCertWarn is how close to expiry a server certificate is flagged
+	doctorCertWarn = 14 * 24 * time.Hour
+)
+
+// doctorStatus is the outcome of one check
+type doctorStatus string
+
+const (
+	doctorOK   doctorStatus = "ok"
+	doctorWarn doctorStatus = "warn"
+	doctorFail doctorStatus = "fail"
+	doctorSkip doctorStatus = "skip"
+)
+
+// doctorCheck is one line of the report, with the fix for anything not ok
+type doctorCheck struct {
+	Name    string       `json:"name"`
+	Status  doctorStatus `json:"status"`
+	Message string       `json:"message"`
+	Fix     string       `json:"fix,omitempty"`
+}
+
+// doctor runs the checks against one configuration
+type doctor struct {
+	cfg     *types.Config
+	timeout time.Duration
+	checks  []doctorCheck
+}
+
+// newDoctorCommand creates the doctor command
+func newDoctorCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Diagnose the local environment",
+		Long: `Check the environment the CLI runs in and print a fix for each problem:
+  - Configuration file
+  - Agent socket presence, permissions and liveness
+  - Server reachability and TLS certificate chain
+  - Clock skew against the server
+  - Token validity and attached policies
+  - Key files used for local and client-side encryption
+  - Agent policy directory
+
+Exits with an error when any check fails; warnings do not fail.`,
+		SilenceUsage: true,
+		RunE:         runDoctorCommand,
+	}
+
+	cmd.Flags().String("socket-path", "", "Agent socket path (default $VAULT_AGENT_SOCKET_PATH or ~/.aether-vault/agent.sock)")
+	cmd.Flags().String("policy-dir", "", "Agent policy directory (default $VAULT_POLICY_DIR or ~/.aether-vault/policies)")
+	cmd.Flags().Duration("timeout", 5*time.Second, "Timeout of each network check")
+
+	return cmd
+}
+
+// runDoctorCommand executes the doctor command
+func runDoctorCommand(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	socketPath, _ := cmd.Flags().GetString("socket-path")
+	policyDir, _ := cmd.Flags().GetString("policy-dir")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+
+	d := &doctor{timeout: timeout}
+	d.checkConfig()
+	d.checkAgentSocket(doctorPath(socketPath, "VAULT_AGENT_SOCKET_PATH", ipc.DefaultClientConfig().SocketPath))
+	if d.checkServer() {
+		if api, err := client.NewAPIClient(d.cfg); err == nil {
+			d.checkClock(api)
+			d.checkToken(api)
+		}
+	} else {
+		d.add("Clock skew", doctorSkip, "server unreachable", "")
+		d.add("Token", doctorSkip, "server unreachable", "")
+	}
+	d.checkKeys()
+	d.checkPolicyDir(doctorPath(policyDir, "VAULT_POLICY_DIR", defaultPolicyDir()))
+
+	switch format {
+	case "json":
+		if err := ui.FormatOutput(d.checks, ui.FormatJSON); err != nil {
+			return err
+		}
+	case "yaml":
+		if err := ui.FormatOutput(d.checks, ui.FormatYAML); err != nil {
+			return err
+		}
+	default:
+		d.print()
+	}
+
+	failed := 0
+	for _, check := range d.checks {
+		if check.Status == doctorFail {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d check(s) failed", failed)
+	}
+	return nil
+}
+
+func (d *doctor) add(name string, status doctorStatus, message, fix string) {
+	d.checks = append(d.checks, doctorCheck{Name: name, Status: status, Message: message, Fix: fix})
+}
+
+// checkConfig loads the configuration the other checks use
+func (d *doctor) checkConfig() {
+	cfg, err := config.Load()
+	if err != nil {
+		d.cfg = config.Defaults()
+		d.add("Configuration", doctorWarn, fmt.Sprintf("failed to load %s: %v; using defaults", config.DefaultConfigPath(), err),
+			"Run 'vault init' to write a fresh configuration, or fix the file")
+		return
+	}
+	d.cfg = cfg
+	if !config.IsConfigured() {
+		d.add("Configuration", doctorWarn, "no configuration file; using defaults and environment", "Run 'vault init'")
+		return
+	}
+	d.add("Configuration", doctorOK, config.DefaultConfigPath(), "")
+}
+
+// checkAgentSocket checks that the socket exists, is not writable by other
+// users and has an agent listening behind it
+func (d *doctor) checkAgentSocket(path string) {
+	const name = "Agent socket"
+
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		d.add(name, doctorWarn, "no socket at "+path+"; the agent is not running", "Start it with 'vault agent start'")
+		return
+	}
+	if err != nil {
+		d.add(name, doctorFail, fmt.Sprintf("cannot access %s: %v", path, err), "Check the permissions of "+filepath.Dir(path))
+		return
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		d.add(name, doctorFail, path+" is not a socket", "Remove it and restart the agent: rm -f "+path+" && vault agent start")
+		return
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0002 != 0 {
+		d.add(name, doctorFail, fmt.Sprintf("%s is writable by every user (%s)", path, info.Mode().Perm()),
+			"Restrict it: chmod 600 "+path)
+		return
+	}
+
+	conn, err := net.DialTimeout("unix", path, d.timeout)
+	if err != nil {
+		d.add(name, doctorFail, fmt.Sprintf("no agent answers on %s: %v", path, err),
+			"Remove the stale socket and restart the agent: rm -f "+path+" && vault agent start")
+		return
+	}
+	conn.Close()
+	d.add(name, doctorOK, "agent listening on "+path, "")
+}
+
+// checkServer dials the server and verifies its certificate chain,
+// reporting whether the server can be reached at all
+func (d *doctor) checkServer() bool {
+	const name = "Server"
+
+	if d.cfg.Cloud.URL == "" {
+		d.add(name, doctorWarn, "no server URL configured", "Set VAULT_URL or cloud.url in the configuration")
+		return false
+	}
+	serverURL, err := url.Parse(d.cfg.Cloud.URL)
+	if err != nil || serverURL.Host == "" {
+		d.add(name, doctorFail, fmt.Sprintf("invalid server URL %q", d.cfg.Cloud.URL), "Set VAULT_URL to a URL such as https://vault.example.com")
+		return false
+	}
+
+	address := serverURL.Host
+	if serverURL.Port() == "" {
+		if serverURL.Scheme == "https" {
+			address = net.JoinHostPort(serverURL.Hostname(), "443")
+		} else {
+			address = net.JoinHostPort(serverURL.Hostname(), "80")
+		}
+	}
+
+	if serverURL.Scheme != "https" {
+		conn, err := net.DialTimeout("tcp", address, d.timeout)
+		if err != nil {
+			d.add(name, doctorFail, fmt.Sprintf("cannot reach %s: %v", address, err), "Check the URL, DNS, proxies and firewalls between here and the server")
+			return false
+		}
+		conn.Close()
+		if host := serverURL.Hostname(); host == "localhost" || net.ParseIP(host).IsLoopback() {
+			d.add(name, doctorOK, "reachable at "+d.cfg.Cloud.URL, "")
+		} else {
+			d.add(name, doctorWarn, "reachable, but over plain HTTP: tokens and secrets cross the network unencrypted",
+				"Serve the API over TLS and use an https:// URL")
+		}
+		return true
+	}
+
+	dialer := &net.Dialer{Timeout: d.timeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", address, &tls.Config{ServerName: serverURL.Hostname()})
+	if err != nil {
+		var unknownAuthority x509.UnknownAuthorityError
+		var hostname x509.HostnameError
+		var invalid x509.CertificateInvalidError
+		var opErr *net.OpError
+		switch {
+		case errors.As(err, &unknownAuthority):
+			d.add(name, doctorFail, "certificate signed by an unknown authority",
+				"Install the issuing CA in the system trust store, or point SSL_CERT_FILE at a bundle containing it")
+		case errors.As(err, &hostname):
+			d.add(name, doctorFail, fmt.Sprintf("certificate is not valid for %s", serverURL.Hostname()),
+				"Use a URL whose host name the certificate covers")
+		case errors.As(err, &invalid):
+			d.add(name, doctorFail, "invalid certificate: "+invalid.Error(), "Renew the server certificate")
+		case errors.As(err, &opErr) && opErr.Op == "dial":
+			d.add(name, doctorFail, fmt.Sprintf("cannot reach %s: %v", address, err), "Check the URL, DNS, proxies and firewalls between here and the server")
+			return false
+		default:
+			d.add(name, doctorFail, "TLS handshake failed: "+err.Error(), "Check that the server speaks TLS on "+address)
+		}
+		// The server answered; the API checks will fail the same way
+		return false
+	}
+	defer conn.Close()
+
+	chain := conn.ConnectionState().PeerCertificates
+	leaf := chain[0]
+	remaining := time.Until(leaf.NotAfter)
+	message := fmt.Sprintf("reachable over TLS; certificate issued by %s, %d certificate(s) in chain, expires %s",
+		leaf.Issuer.CommonName, len(chain), leaf.NotAfter.Format("2006-01-02"))
+	if remaining < doctorCertWarn {
+		d.add(name, doctorWarn, message, "Renew the server certificate")
+	} else {
+		d.add(name, doctorOK, message, "")
+	}
+	return true
+}
+
+// checkClock compares the local clock with the server's, taking the
+// middle of the request as the moment the server read its clock
+func (d *doctor) checkClock(api *client.APIClient) {
+	const name = "Clock skew"
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	defer cancel()
+
+	var health struct {
+		Timestamp time.Time `json:"timestamp"`
+	}
+	sent := time.Now()
+	if err := api.Do(ctx, http.MethodGet, "/system/health", nil, &health); err != nil || health.Timestamp.IsZero() {
+		d.add(name, doctorSkip, "server did not report its time", "")
+		return
+	}
+	received := time.Now()
+
+	local := sent.Add(received.Sub(sent) / 2)
+	skew := health.Timestamp.Sub(local)
+	if skew < 0 {
+		skew = -skew
+	}
+	skew = skew.Round(time.Second)
+	fix := "Enable time synchronization (NTP), e.g. 'timedatectl set-ntp true'"
+	switch {
+	case skew >= doctorSkewFail:
+		d.add(name, doctorFail, fmt.Sprintf("local clock is %s off the server's; tokens will be rejected", skew), fix)
+	case skew >= doctorSkewWarn:
+		d.add(name, doctorWarn, fmt.Sprintf("local clock is %s off the server's", skew), fix)
+	default:
+		d.add(name, doctorOK, fmt.Sprintf("within %s of the server", doctorSkewWarn), "")
+	}
+}
+
+// checkToken checks that the configured token is current, accepted by the
+// server and grants at least one policy
+func (d *doctor) checkToken(api *client.APIClient) {
+	const name = "Token"
+
+	if d.cfg.Cloud.Token == "" {
+		d.add(name, doctorWarn, "no token configured; commands that call the server will fail", "Run 'vault auth login' or set VAULT_TOKEN")
+		return
+	}
+	if expiresAt, ok := tokenExpiry(d.cfg.Cloud.Token); ok && time.Now().After(expiresAt) {
+		d.add(name, doctorFail, "token expired at "+expiresAt.Format(time.RFC3339), "Run 'vault auth login' for a new token")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	defer cancel()
+
+	var session struct {
+		Valid bool `json:"valid"`
+		User  struct {
+			Email string `json:"email"`
+		} `json:"user"`
+	}
+	if err := api.Do(ctx, http.MethodGet, "/auth/session", nil, &session); err != nil {
+		var apiErr *client.APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized {
+			d.add(name, doctorFail, "the server rejects the token", "Run 'vault auth login' for a new token")
+			return
+		}
+		d.add(name, doctorFail, "failed to check the token: "+err.Error(), "")
+		return
+	}
+	if !session.Valid {
+		d.add(name, doctorFail, "the server reports the session as invalid", "Run 'vault auth login' for a new token")
+		return
+	}
+	d.add(name, doctorOK, "valid for "+session.User.Email, "")
+
+	var identity struct {
+		Policies []struct {
+			Name string `json:"name"`
+		} `json:"policies"`
+	}
+	if err := api.Do(ctx, http.MethodGet, "/identity/policies", nil, &identity); err != nil {
+		d.add("Policies", doctorWarn, "failed to list policies: "+err.Error(), "")
+		return
+	}
+	if len(identity.Policies) == 0 {
+		d.add("Policies", doctorWarn, "no policies attached; only your own secrets are accessible", "Ask an administrator to attach the policies you need")
+		return
+	}
+	names := make([]string, 0, len(identity.Policies))
+	for _, policy := range identity.Policies {
+		names = append(names, policy.Name)
+	}
+	d.add("Policies", doctorOK, strings.Join(names, ", "), "")
+}
+
+// checkKeys checks the key files: the local vault key and the identity
+// that opens client-encrypted secrets. Neither is required.
+func (d *doctor) checkKeys() {
+	const name = "Keys"
+
+	keyDir := filepath.Dir(config.DefaultKeyPath())
+	info, err := os.Stat(keyDir)
+	if errors.Is(err, os.ErrNotExist) {
+		d.add(name, doctorOK, "no key directory; local mode and client-side encryption are not set up", "")
+		return
+	}
+	if err != nil {
+		d.add(name, doctorFail, fmt.Sprintf("cannot access %s: %v", keyDir, err), "")
+		return
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		d.add(name, doctorFail, fmt.Sprintf("%s is accessible by other users (%s)", keyDir, info.Mode().Perm()), "Restrict it: chmod 700 "+keyDir)
+		return
+	}
+
+	var found []string
+	for _, path := range []string{config.DefaultKeyPath(), config.DefaultIdentityKeyPath()} {
+		info, err := os.Stat(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			d.add(name, doctorFail, fmt.Sprintf("cannot access %s: %v", path, err), "")
+			return
+		}
+		if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+			d.add(name, doctorFail, fmt.Sprintf("%s is accessible by other users (%s)", path, info.Mode().Perm()), "Restrict it: chmod 600 "+path)
+			return
+		}
+		found = append(found, filepath.Base(path))
+	}
+
+	if _, err := e2e.LoadIdentity(config.DefaultIdentityKeyPath()); err != nil && !errors.Is(err, e2e.ErrNoIdentity) {
+		d.add(name, doctorFail, err.Error(), "Restore the identity key from backup; secrets sealed for it cannot be opened without it")
+		return
+	}
+	if len(found) == 0 {
+		d.add(name, doctorOK, "no key files in "+keyDir, "")
+		return
+	}
+	d.add(name, doctorOK, strings.Join(found, ", ")+" readable only by you", "")
+}
+
+// checkPolicyDir checks that every policy file of the agent parses and
+// that policy IDs are unique
+func (d *doctor) checkPolicyDir(dir string) {
+	const name = "Policy directory"
+
+	info, err := os.Stat(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		d.add(name, doctorWarn, dir+" does not exist; the agent has no local policies", "Create it: mkdir -p "+dir)
+		return
+	}
+	if err != nil {
+		d.add(name, doctorFail, fmt.Sprintf("cannot access %s: %v", dir, err), "")
+		return
+	}
+	if !info.IsDir() {
+		d.add(name, doctorFail, dir+" is not a directory", "")
+		return
+	}
+
+	seen := make(map[string]string)
+	var problems []string
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ".json") {
+			return nil
+		}
+		if runtime.GOOS != "windows" && info.Mode().Perm()&0002 != 0 {
+			problems = append(problems, path+" is writable by every user")
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			problems = append(problems, err.Error())
+			return nil
+		}
+		var policy struct {
+			ID    string            `json:"id"`
+			Rules []json.RawMessage `json:"rules"`
+		}
+		if err := json.Unmarshal(data, &policy); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", path, err))
+			return nil
+		}
+		switch {
+		case policy.ID == "":
+			problems = append(problems, path+": no id")
+		case seen[policy.ID] != "":
+			problems = append(problems, fmt.Sprintf("%s: id %q already used by %s", path, policy.ID, seen[policy.ID]))
+		default:
+			seen[policy.ID] = path
+		}
+		if len(policy.Rules) == 0 {
+			problems = append(problems, path+": no rules")
+		}
+		return nil
+	})
+	if err != nil {
+		d.add(name, doctorFail, fmt.Sprintf("failed to read %s: %v", dir, err), "")
+		return
+	}
+
+	if len(problems) > 0 {
+		d.add(name, doctorFail, strings.Join(problems, "; "), "Fix or remove the files listed, then run 'vault agent reload'")
+		return
+	}
+	if len(seen) == 0 {
+		d.add(name, doctorWarn, "no policies in "+dir+"; the agent denies every request", "Add a policy file (see docs/COMMANDS_AGENT.md)")
+		return
+	}
+	d.add(name, doctorOK, fmt.Sprintf("%d policies in %s", len(seen), dir), "")
+}
+
+// print writes the report as a checklist
+func (d *doctor) print() {
+	fmt.Println("Aether Vault Doctor")
+	fmt.Println("===================")
+	fmt.Println()
+	for _, check := range d.checks {
+		line := fmt.Sprintf("%-18s %s", check.Name, check.Message)
+		switch check.Status {
+		case doctorOK:
+			fmt.Println(ui.Success(line))
+		case doctorWarn:
+			fmt.Println(ui.Warning(line))
+		case doctorFail:
+			fmt.Println(ui.Error(line))
+		default:
+			fmt.Println(ui.DimText("- " + line))
+		}
+		if check.Fix != "" {
+			fmt.Println("  → " + check.Fix)
+		}
+	}
+}
+
+// tokenExpiry reads the exp claim of a JWT without verifying it; the
+// server decides whether the token is valid
+func tokenExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(claims.Exp, 0), true
+}
+
+// doctorPath picks a flag value, then an environment variable, then the
+// default, expanding a leading ~
+func doctorPath(flag, env, fallback string) string {
+	path := flag
+	if path == "" {
+		path = os.Getenv(env)
+	}
+	if path == "" {
+		path = fallback
+	}
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[2:])
+		}
+	}
+	return path
+}
+
+// defaultPolicyDir is where the agent loads local policies from
+func defaultPolicyDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "./policies"
+	}
+	return filepath.Join(home, ".aether-vault", "policies")
+}
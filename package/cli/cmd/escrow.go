@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/config"
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/e2e"
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	escrowOfficers  []string
+	escrowThreshold int
+	escrowSubmit    bool
+	escrowOpen      bool
+)
+
+// keyEscrow mirrors the server's key escrow description
+type keyEscrow struct {
+	ID        string    `json:"id"`
+	Threshold int       `json:"threshold"`
+	Shares    int       `json:"shares"`
+	CreatedAt time.Time `json:"created_at"`
+	Officers  []struct {
+		OfficerID string `json:"officer_id"`
+		KeyID     string `json:"key_id"`
+		Index     int    `json:"index"`
+	} `json:"officers"`
+}
+
+// keyRecovery mirrors the progress of a quorum recovery
+type keyRecovery struct {
+	ID           string    `json:"id"`
+	Threshold    int       `json:"threshold"`
+	Submitted    []string  `json:"submitted"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	Complete     bool      `json:"complete"`
+	RecoveredKey string    `json:"recovered_key"`
+}
+
+// newEscrowCommand creates the escrow command group
+func newEscrowCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "escrow",
+		Short: "Escrow and recover the server master key",
+		Long: `Split the server's master encryption key into recovery shares, each
+encrypted for a recovery officer's registered client key, and rebuild it
+from a quorum of officers after every operator copy was lost.
+
+Officers need a registered key ('vault key generate', 'vault key
+register'). Recovery:
+  1. an operator with the sys/escrow/recovery policy runs 'vault escrow recover'
+  2. each officer, holding the sys/escrow/share policy, runs
+     'vault escrow share --submit' within 30 minutes
+  3. the operator runs 'vault escrow recover --open' to decrypt the key
+
+Examples:
+  vault escrow create --officer 3b2e... --officer 9f1c... --officer 77d0... --threshold 2
+  vault escrow status`,
+	}
+
+	cmd.PersistentFlags().StringVar(&keyFile, "key-file", config.DefaultIdentityKeyPath(), "Path of the private key")
+
+	create := &cobra.Command{
+		Use:   "create",
+		Short: "Split the master key for recovery officers",
+		Long: `Split the master key so that any --threshold of the officers can
+rebuild it. This replaces the shares of any earlier escrow.`,
+		Args: cobra.NoArgs,
+		RunE: runEscrowCreateCommand,
+	}
+	create.Flags().StringSliceVar(&escrowOfficers, "officer", nil, "User ID of a recovery officer (repeatable)")
+	create.Flags().IntVar(&escrowThreshold, "threshold", 2, "Officers needed to recover the key")
+	create.MarkFlagRequired("officer")
+	cmd.AddCommand(create)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "status",
+		Short: "Show the escrow and any recovery in progress",
+		Args:  cobra.NoArgs,
+		RunE:  runEscrowStatusCommand,
+	})
+
+	share := &cobra.Command{
+		Use:   "share",
+		Short: "Decrypt your recovery share",
+		Long: `Decrypt your recovery share with your client key. With --submit, send
+it to the recovery in progress instead of printing it.`,
+		Args: cobra.NoArgs,
+		RunE: runEscrowShareCommand,
+	}
+	share.Flags().BoolVar(&escrowSubmit, "submit", false, "Submit the share to the recovery in progress")
+	cmd.AddCommand(share)
+
+	recovery := &cobra.Command{
+		Use:   "recover",
+		Short: "Start a key recovery, or open its result",
+		Long: `Start a quorum recovery. The rebuilt key is encrypted for your
+registered client key; once enough officers submitted their share,
+--open decrypts and prints it.`,
+		Args: cobra.NoArgs,
+		RunE: runEscrowRecoverCommand,
+	}
+	recovery.Flags().BoolVar(&escrowOpen, "open", false, "Decrypt the key of a completed recovery")
+	cmd.AddCommand(recovery)
+
+	return cmd
+}
+
+// runEscrowCreateCommand executes the escrow create command
+func runEscrowCreateCommand(cmd *cobra.Command, args []string) error {
+	api, err := newServerAPIClient()
+	if err != nil {
+		return err
+	}
+
+	request := map[string]interface{}{"officer_ids": escrowOfficers, "threshold": escrowThreshold}
+	var escrow keyEscrow
+	if err := api.Do(context.Background(), http.MethodPost, "/sys/escrow", request, &escrow); err != nil {
+		return fmt.Errorf("failed to create key escrow: %w", err)
+	}
+
+	fmt.Println(ui.Success(fmt.Sprintf("Master key split into %d shares, %d needed to recover", escrow.Shares, escrow.Threshold)))
+	printEscrow(&escrow)
+	return nil
+}
+
+// runEscrowStatusCommand executes the escrow status command
+func runEscrowStatusCommand(cmd *cobra.Command, args []string) error {
+	api, err := newServerAPIClient()
+	if err != nil {
+		return err
+	}
+
+	var escrow keyEscrow
+	if err := api.Do(context.Background(), http.MethodGet, "/sys/escrow", nil, &escrow); err != nil {
+		return fmt.Errorf("failed to get key escrow: %w", err)
+	}
+	printEscrow(&escrow)
+
+	var recovery keyRecovery
+	if err := api.Do(context.Background(), http.MethodGet, "/sys/escrow/recovery", nil, &recovery); err != nil {
+		fmt.Println("\nNo recovery in progress")
+		return nil
+	}
+	printRecovery(&recovery)
+	return nil
+}
+
+// runEscrowShareCommand executes the escrow share command
+func runEscrowShareCommand(cmd *cobra.Command, args []string) error {
+	identity, err := e2e.LoadIdentity(keyFile)
+	if err != nil {
+		return err
+	}
+
+	api, err := newServerAPIClient()
+	if err != nil {
+		return err
+	}
+
+	var sealed struct {
+		Envelope string `json:"envelope"`
+	}
+	if err := api.Do(context.Background(), http.MethodGet, "/sys/escrow/share", nil, &sealed); err != nil {
+		return fmt.Errorf("failed to get recovery share: %w", err)
+	}
+	share, err := identity.Open(sealed.Envelope)
+	if err != nil {
+		return err
+	}
+
+	if !escrowSubmit {
+		fmt.Println(string(share))
+		return nil
+	}
+
+	var recovery keyRecovery
+	if err := api.Do(context.Background(), http.MethodPost, "/sys/escrow/recovery/shares", map[string]string{"share": string(share)}, &recovery); err != nil {
+		return fmt.Errorf("failed to submit recovery share: %w", err)
+	}
+	fmt.Println(ui.Success("Share submitted"))
+	printRecovery(&recovery)
+	return nil
+}
+
+// runEscrowRecoverCommand executes the escrow recover command
+func runEscrowRecoverCommand(cmd *cobra.Command, args []string) error {
+	api, err := newServerAPIClient()
+	if err != nil {
+		return err
+	}
+
+	var recovery keyRecovery
+	if !escrowOpen {
+		if err := api.Do(context.Background(), http.MethodPost, "/sys/escrow/recovery", nil, &recovery); err != nil {
+			return fmt.Errorf("failed to start key recovery: %w", err)
+		}
+		fmt.Println(ui.Success("Key recovery started; officers can now submit their shares"))
+		printRecovery(&recovery)
+		return nil
+	}
+
+	identity, err := e2e.LoadIdentity(keyFile)
+	if err != nil {
+		return err
+	}
+	if err := api.Do(context.Background(), http.MethodGet, "/sys/escrow/recovery", nil, &recovery); err != nil {
+		return fmt.Errorf("failed to get key recovery: %w", err)
+	}
+	if !recovery.Complete {
+		return fmt.Errorf("recovery has %d of %d shares", len(recovery.Submitted), recovery.Threshold)
+	}
+	key, err := identity.Open(recovery.RecoveredKey)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(key))
+	return nil
+}
+
+func printEscrow(escrow *keyEscrow) {
+	fmt.Printf("%s  threshold %d of %d  created %s\n", ui.BoldText(escrow.ID), escrow.Threshold, escrow.Shares, escrow.CreatedAt.Local().Format("2006-01-02 15:04"))
+	for _, officer := range escrow.Officers {
+		fmt.Printf("  share %d  officer %s  key %s\n", officer.Index, officer.OfficerID, officer.KeyID)
+	}
+}
+
+func printRecovery(recovery *keyRecovery) {
+	state := "collecting shares"
+	if recovery.Complete {
+		state = "complete"
+	}
+	fmt.Printf("\nRecovery %s: %s, %d of %d shares, expires %s\n", recovery.ID, state, len(recovery.Submitted), recovery.Threshold, recovery.ExpiresAt.Local().Format("15:04"))
+}
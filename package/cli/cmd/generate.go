@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	generateLength    int
+	generateEncoding  string
+	generateWords     int
+	generateAlgorithm string
+	generateBits      int
+	generateCurve     string
+	generateStoreAs   string
+)
+
+// newGenerateCommand creates the generate command
+func newGenerateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "generate [bytes|uuid|passphrase|keypair]",
+		Short: "Generate random material on the server",
+		Long: `Generate random bytes, UUIDs, passphrases or keypairs on the Aether
+Vault server instead of on this machine.
+
+With --store-as the secret half (the value, or the private key of a
+keypair) is written directly to a secret and never returned.
+
+Examples:
+  vault generate bytes --length 64 --encoding hex
+  vault generate passphrase --words 10
+  vault generate keypair --algorithm ecdsa --curve p384 --store-as pki/signing`,
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: []string{"bytes", "uuid", "passphrase", "keypair"},
+		RunE:      runGenerateCommand,
+	}
+
+	cmd.Flags().IntVar(&generateLength, "length", 0, "Number of random bytes")
+	cmd.Flags().StringVar(&generateEncoding, "encoding", "", "Byte encoding (base64, hex)")
+	cmd.Flags().IntVar(&generateWords, "words", 0, "Number of passphrase words")
+	cmd.Flags().StringVar(&generateAlgorithm, "algorithm", "", "Keypair algorithm (rsa, ecdsa, ed25519)")
+	cmd.Flags().IntVar(&generateBits, "bits", 0, "RSA key size")
+	cmd.Flags().StringVar(&generateCurve, "curve", "", "ECDSA curve (p256, p384, p521)")
+	cmd.Flags().StringVar(&generateStoreAs, "store-as", "", "Store the secret half at this path instead of returning it")
+
+	return cmd
+}
+
+// runGenerateCommand executes the generate command
+func runGenerateCommand(cmd *cobra.Command, args []string) error {
+	api, err := newServerAPIClient()
+	if err != nil {
+		return err
+	}
+
+	request := map[string]interface{}{
+		"type":      args[0],
+		"length":    generateLength,
+		"encoding":  generateEncoding,
+		"words":     generateWords,
+		"algorithm": generateAlgorithm,
+		"bits":      generateBits,
+		"curve":     generateCurve,
+		"store_as":  generateStoreAs,
+	}
+
+	var response struct {
+		Value       string `json:"value"`
+		PublicKey   string `json:"public_key"`
+		PrivateKey  string `json:"private_key"`
+		SecretID    string `json:"secret_id"`
+		EntropyBits int    `json:"entropy_bits"`
+	}
+	if err := api.Do(context.Background(), http.MethodPost, "/sys/generate", request, &response); err != nil {
+		return fmt.Errorf("failed to generate: %w", err)
+	}
+
+	if response.Value != "" {
+		fmt.Println(response.Value)
+	}
+	if response.PublicKey != "" {
+		fmt.Print(response.PublicKey)
+	}
+	if response.PrivateKey != "" {
+		fmt.Print(response.PrivateKey)
+	}
+	if response.SecretID != "" {
+		fmt.Fprintf(os.Stderr, "Stored at %s (%s)\n", generateStoreAs, response.SecretID)
+	}
+	if response.EntropyBits > 0 {
+		fmt.Fprintf(os.Stderr, "Entropy: %d bits\n", response.EntropyBits)
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/client"
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/config"
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/e2e"
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/gitcred"
+	"github.com/spf13/cobra"
+)
+
+var gitCredentialConfigFile string
+
+// newGitCredentialCommand creates the git-credential command
+func newGitCredentialCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "git-credential <get|store|erase>",
+		Short: "Serve HTTPS credentials to git from the vault",
+		Long: `Act as a git credential helper. Git asks the helper for a credential
+before fetching from or pushing to an HTTPS remote; the helper answers
+from the vault secret mapped to the remote.
+
+Enable it with:
+
+  git config --global credential.helper '!vault git-credential'
+  git config --global credential.useHttpPath true   # to match on paths
+
+The configuration file maps remotes to secrets:
+
+  cache_ttl: 5m                    # lease for fetched credentials, 0 disables
+  remotes:
+    - host: github.com
+      path: acme/*                 # optional, path.Match syntax
+      secret: <secret id>          # {"username": ..., "password"|"token": ..., "expires_at": ...} or a password
+      username: x-access-token     # used when the secret is a bare password
+      cache_ttl: 1m                # optional per-remote lease
+
+Credentials are cached until the lease ends, never past the secret's
+expiry. When a remote rejects a credential, git calls erase and the next
+request reads the secret again. Remotes without a mapping are left to
+git's other helpers.`,
+		Args:         cobra.ExactArgs(1),
+		ValidArgs:    []string{"get", "store", "erase"},
+		SilenceUsage: true,
+		RunE:         runGitCredentialCommand,
+	}
+
+	cmd.Flags().StringVar(&gitCredentialConfigFile, "git-config", filepath.Join(config.DefaultVaultPath(), "git-credentials.yaml"), "Path to the git credential configuration file")
+	cmd.Flags().StringVar(&keyFile, "key-file", config.DefaultIdentityKeyPath(), "Path of your client encryption key")
+
+	return cmd
+}
+
+// runGitCredentialCommand executes the git-credential command. Its stdout
+// is read by git, so only protocol output may be written there.
+func runGitCredentialCommand(cmd *cobra.Command, args []string) error {
+	request, err := gitcred.ReadRequest(os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	gitConfig, err := gitcred.LoadConfig(gitCredentialConfigFile)
+	if err != nil {
+		return err
+	}
+	cache := gitcred.LoadCache(filepath.Join(config.DefaultVaultPath(), "cache", "git-credentials.json"))
+
+	switch args[0] {
+	case "get":
+		api, err := newServerAPIClient()
+		if err != nil {
+			return err
+		}
+
+		helper := gitcred.NewHelper(gitConfig, &vaultSecrets{api: api}, cache)
+		credential, err := helper.Get(context.Background(), request)
+		if err != nil {
+			return err
+		}
+		if credential == nil {
+			return nil
+		}
+		return gitcred.WriteCredential(os.Stdout, credential)
+	case "erase":
+		return gitcred.NewHelper(gitConfig, nil, cache).Erase(request)
+	case "store":
+		// Credentials come from the vault; git has nothing to store
+		return nil
+	default:
+		// Git ignores actions a helper does not know
+		return nil
+	}
+}
+
+// vaultSecrets reads secret values for local helpers, opening
+// client-encrypted ones with the user's key
+type vaultSecrets struct {
+	api *client.APIClient
+}
+
+func (v *vaultSecrets) Secret(ctx context.Context, id string) (string, *time.Time, error) {
+	var secret secretValueResponse
+	if err := v.api.Do(ctx, http.MethodGet, "/secrets/"+url.PathEscape(id)+"/value", nil, &secret); err != nil {
+		return "", nil, fmt.Errorf("failed to read secret %s: %w", id, err)
+	}
+
+	value := secret.Value
+	if secret.ClientEncrypted {
+		identity, err := e2e.LoadIdentity(keyFile)
+		if err != nil {
+			return "", nil, err
+		}
+		plaintext, err := identity.Open(value)
+		if err != nil {
+			return "", nil, err
+		}
+		value = string(plaintext)
+	}
+	if value == "" {
+		return "", nil, fmt.Errorf("secret %s is empty", id)
+	}
+
+	return value, secret.ExpiresAt, nil
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// newIdentityCommand creates the identity command group
+func newIdentityCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "identity",
+		Short: "Vault-issued identity tokens",
+		Long: `Request signed OIDC identity tokens describing the authenticated
+user. Downstream services verify them against the server's JWKS at
+/api/v1/identity/oidc/.well-known/keys.`,
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "token [role]",
+		Short: "Issue an identity token for a role",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runIdentityTokenCommand,
+	})
+
+	return cmd
+}
+
+// runIdentityTokenCommand executes the identity token command
+func runIdentityTokenCommand(cmd *cobra.Command, args []string) error {
+	api, err := newServerAPIClient()
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Token     string    `json:"token"`
+		ClientID  string    `json:"client_id"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := api.Do(context.Background(), http.MethodPost, "/identity/oidc/token/"+url.PathEscape(args[0]), nil, &response); err != nil {
+		return fmt.Errorf("failed to issue identity token: %w", err)
+	}
+
+	fmt.Println(response.Token)
+	fmt.Fprintf(os.Stderr, "Audience %s, expires at %s\n", response.ClientID, response.ExpiresAt.Format(time.RFC3339))
+
+	return nil
+}
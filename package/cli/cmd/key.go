@@ -0,0 +1,269 @@
+package cmd
+
+import (
+	"context"
+	"crypto/ecdh"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/bulk"
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/client"
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/config"
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/e2e"
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	keyFile      string
+	keyNamespace string
+)
+
+// teamMemberKey mirrors a namespace member's registered key
+type teamMemberKey struct {
+	UserID    string `json:"user_id"`
+	KeyID     string `json:"key_id"`
+	PublicKey string `json:"public_key"`
+}
+
+// newKeyCommand creates the key command group
+func newKeyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "key",
+		Short: "Manage your client encryption key",
+		Long: `Manage the X25519 key that opens client-encrypted secrets.
+
+The private key never leaves this machine. Give your public key to
+teammates, or register it with the server so namespace members can
+wrap data keys for you.
+
+Examples:
+  vault key generate
+  vault key register
+  vault key rewrap --namespace 3b2e...`,
+	}
+
+	cmd.PersistentFlags().StringVar(&keyFile, "key-file", config.DefaultIdentityKeyPath(), "Path of the private key")
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "generate",
+		Short: "Generate a client encryption key",
+		Long: `Generate a client encryption key. An existing key is never
+replaced: secrets encrypted for it could no longer be opened.`,
+		RunE: runKeyGenerateCommand,
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "show",
+		Short: "Print your public key",
+		RunE:  runKeyShowCommand,
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "register",
+		Short: "Register your public key with the server",
+		Long: `Register your public key so teammates can grant you access to
+client-encrypted secrets. Registering a new key replaces the old one;
+secrets wrapped for the old key need 'vault key rewrap'.`,
+		RunE: runKeyRegisterCommand,
+	})
+
+	rewrap := &cobra.Command{
+		Use:   "rewrap",
+		Short: "Re-wrap a namespace's client-encrypted secrets for its members",
+		Long: `Bring every client-encrypted secret in a namespace in line with its
+current members. New members are granted the existing data key; when a
+member left or replaced their key, the value is re-encrypted under a
+fresh data key so the old key can no longer open it.
+
+Only secrets already wrapped for your key can be re-wrapped.`,
+		RunE: runKeyRewrapCommand,
+	}
+	rewrap.Flags().StringVar(&keyNamespace, "namespace", "", "Namespace ID")
+	rewrap.MarkFlagRequired("namespace")
+	addBulkFlags(rewrap, true)
+	cmd.AddCommand(rewrap)
+
+	return cmd
+}
+
+// runKeyGenerateCommand executes the key generate command
+func runKeyGenerateCommand(cmd *cobra.Command, args []string) error {
+	identity, err := e2e.GenerateIdentity()
+	if err != nil {
+		return err
+	}
+	if err := identity.Save(keyFile); err != nil {
+		if errors.Is(err, e2e.ErrIdentityExists) {
+			return fmt.Errorf("%w at %s", err, keyFile)
+		}
+		return err
+	}
+
+	fmt.Println(ui.Success(fmt.Sprintf("Key written to %s", keyFile)))
+	fmt.Println(ui.Warning("Back this file up: secrets encrypted for it cannot be recovered without it"))
+	printPublicKey(identity)
+	return nil
+}
+
+// runKeyShowCommand executes the key show command
+func runKeyShowCommand(cmd *cobra.Command, args []string) error {
+	identity, err := e2e.LoadIdentity(keyFile)
+	if err != nil {
+		return err
+	}
+
+	printPublicKey(identity)
+	return nil
+}
+
+// runKeyRegisterCommand executes the key register command
+func runKeyRegisterCommand(cmd *cobra.Command, args []string) error {
+	identity, err := e2e.LoadIdentity(keyFile)
+	if err != nil {
+		return err
+	}
+
+	api, err := newServerAPIClient()
+	if err != nil {
+		return err
+	}
+
+	request := map[string]string{"public_key": e2e.EncodePublicKey(identity.PublicKey())}
+	if err := api.Do(context.Background(), http.MethodPut, "/identity/me/key", request, nil); err != nil {
+		return fmt.Errorf("failed to register key: %w", err)
+	}
+
+	fmt.Println(ui.Success(fmt.Sprintf("Registered key %s", e2e.KeyID(identity.PublicKey()))))
+	return nil
+}
+
+// runKeyRewrapCommand executes the key rewrap command
+func runKeyRewrapCommand(cmd *cobra.Command, args []string) error {
+	identity, err := e2e.LoadIdentity(keyFile)
+	if err != nil {
+		return err
+	}
+
+	api, err := newServerAPIClient()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	base := "/namespaces/" + url.PathEscape(keyNamespace)
+
+	var plan struct {
+		Members []teamMemberKey `json:"members"`
+		Secrets []keyRewrapItem `json:"secrets"`
+	}
+	if err := api.Do(ctx, http.MethodGet, base+"/member-keys/rewrap", nil, &plan); err != nil {
+		return fmt.Errorf("failed to get re-wrap plan: %w", err)
+	}
+
+	members, err := memberPublicKeys(plan.Members)
+	if err != nil {
+		return err
+	}
+	if len(plan.Secrets) == 0 {
+		fmt.Println("All client-encrypted secrets match the namespace members")
+		return nil
+	}
+
+	bySecret := make(map[string]keyRewrapItem, len(plan.Secrets))
+	ids := make([]string, 0, len(plan.Secrets))
+	for _, secret := range plan.Secrets {
+		bySecret[secret.SecretID] = secret
+		ids = append(ids, secret.SecretID)
+	}
+
+	result, err := bulk.Run(ctx, ids, bulk.Options{
+		Concurrency: bulkConcurrency,
+		StateFile:   bulkStateFile,
+		Operation:   "key rewrap " + keyNamespace,
+		Label:       "Re-wrapping",
+	}, func(ctx context.Context, id string) error {
+		secret := bySecret[id]
+		if err := rewrapSecret(ctx, api, identity, base, secret, members); err != nil {
+			return fmt.Errorf("%s: %w", secret.Name, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(ui.Success(fmt.Sprintf("Re-wrapped %d of %d secrets", result.Done+result.Resumed, result.Total)))
+	if result.Err() != nil {
+		result.PrintFailures()
+		return fmt.Errorf("%d of %d secrets could not be re-wrapped", len(result.Failures), result.Total)
+	}
+	return nil
+}
+
+// keyRewrapItem is one secret of a re-wrap plan
+type keyRewrapItem struct {
+	SecretID string   `json:"secret_id"`
+	Name     string   `json:"name"`
+	Grant    []string `json:"grant"`
+	Revoke   []string `json:"revoke"`
+}
+
+// rewrapSecret seals one secret's envelope for the current members
+func rewrapSecret(ctx context.Context, api *client.APIClient, identity *e2e.Identity, base string, secret keyRewrapItem, members []*ecdh.PublicKey) error {
+	path := base + "/secrets/" + url.PathEscape(secret.SecretID) + "/envelope"
+
+	var current secretValueResponse
+	if err := api.Do(ctx, http.MethodGet, path, nil, &current); err != nil {
+		return err
+	}
+
+	var sealed string
+	if len(secret.Revoke) > 0 {
+		// Dropping a recipient from the envelope is not enough: they
+		// may have kept the data key, so the value gets a new one
+		plaintext, err := identity.Open(current.Value)
+		if err != nil {
+			return err
+		}
+		if sealed, err = e2e.Seal(plaintext, members...); err != nil {
+			return err
+		}
+	} else {
+		var err error
+		if sealed, err = identity.Grant(current.Value, members...); err != nil {
+			return err
+		}
+	}
+
+	if err := api.Do(ctx, http.MethodPut, path, map[string]string{"value": sealed}, nil); err != nil {
+		return fmt.Errorf("failed to store envelope: %w", err)
+	}
+	return nil
+}
+
+// memberPublicKeys parses the registered keys of namespace members,
+// warning about members who have none yet
+func memberPublicKeys(members []teamMemberKey) ([]*ecdh.PublicKey, error) {
+	keys := make([]*ecdh.PublicKey, 0, len(members))
+	for _, member := range members {
+		if member.PublicKey == "" {
+			fmt.Fprintln(os.Stderr, ui.Warning(fmt.Sprintf("Member %s has not registered a key and cannot be granted access", member.UserID)))
+			continue
+		}
+		key, err := e2e.ParsePublicKey(member.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("member %s: %w", member.UserID, err)
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no namespace member has registered a key")
+	}
+	return keys, nil
+}
+
+func printPublicKey(identity *e2e.Identity) {
+	fmt.Printf("Public key: %s\n", e2e.EncodePublicKey(identity.PublicKey()))
+	fmt.Printf("Key ID:     %s\n", e2e.KeyID(identity.PublicKey()))
+}
@@ -0,0 +1,708 @@
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/bulk"
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/client"
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/config"
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/e2e"
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+const (
+	kvArchiveFormat = "aev-kv-archive/v1"
+	// defaultKVMount is served by /secrets rather than /mounts/:mount/secrets
+	defaultKVMount = "secret"
+)
+
+// Conflict strategies of kv import
+const (
+	kvConflictSkip       = "skip"
+	kvConflictOverwrite  = "overwrite"
+	kvConflictNewVersion = "new-version"
+)
+
+var (
+	kvOut        string
+	kvVersions   bool
+	kvMetadata   bool
+	kvRecipients []string
+	kvConflict   string
+	kvDelete     bool
+	kvYes        bool
+	kvDryRun     bool
+)
+
+// kvArchive is the plaintext of an export, gzipped and sealed before it is
+// written
+type kvArchive struct {
+	Format     string           `json:"format"`
+	Mount      string           `json:"mount"`
+	Prefix     string           `json:"prefix"`
+	ExportedAt time.Time        `json:"exported_at"`
+	Entries    []kvArchiveEntry `json:"entries"`
+}
+
+// kvArchiveEntry is one secret, its path relative to the archive prefix
+type kvArchiveEntry struct {
+	Path            string             `json:"path"`
+	Value           string             `json:"value"`
+	ClientEncrypted bool               `json:"client_encrypted"`
+	Description     string             `json:"description,omitempty"`
+	Type            string             `json:"type,omitempty"`
+	Tags            string             `json:"tags,omitempty"`
+	ExpiresAt       *time.Time         `json:"expires_at,omitempty"`
+	Versions        []kvArchiveVersion `json:"versions,omitempty"`
+}
+
+// kvArchiveVersion is a previous value, oldest first
+type kvArchiveVersion struct {
+	Version         int       `json:"version"`
+	Value           string    `json:"value"`
+	ClientEncrypted bool      `json:"client_encrypted"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// kvSecret mirrors the metadata of a listed secret
+type kvSecret struct {
+	ID              string     `json:"id"`
+	Name            string     `json:"name"`
+	Description     string     `json:"description"`
+	Type            string     `json:"type"`
+	Tags            string     `json:"tags"`
+	ExpiresAt       *time.Time `json:"expires_at"`
+	ClientEncrypted bool       `json:"client_encrypted"`
+}
+
+// newKVCommand creates the kv command group
+func newKVCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "kv",
+		Short: "Move KV subtrees between vaults",
+		Long: `Export a subtree of a KV mount to an encrypted archive file and import
+it into another vault, e.g. across an air gap, copy it to another mount,
+or delete it.
+
+Paths are <mount>/<prefix>: 'secret/app/' is every secret of the default
+mount whose name starts with 'app/'. Archives are sealed like client-
+encrypted secrets: X25519 recipients with AES-256-GCM, for your own key
+and every --recipient. They are not age or PGP files; open them with
+'vault kv import'.
+
+Examples:
+  vault kv export secret/app/ --out app-secrets.age
+  vault kv export secret/app/ --out app-secrets.age --versions --recipient <public-key>
+  vault kv import app-secrets.age team-payments/app/ --conflict new-version
+  vault kv migrate secret/app/ team-payments/app/ --concurrency 16
+  vault kv delete secret/old-app/ --yes
+
+Bulk commands work on --concurrency secrets at once and report every
+failed secret at the end. import, migrate and delete take --state-file:
+finished secrets are recorded there, and running the same command again
+after an interruption skips them. The file is removed after a run
+without failures.`,
+	}
+
+	cmd.PersistentFlags().StringVar(&keyFile, "key-file", config.DefaultIdentityKeyPath(), "Path of your client encryption key")
+
+	export := &cobra.Command{
+		Use:   "export [mount/prefix]",
+		Short: "Export a subtree to an encrypted archive",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runKVExportCommand,
+	}
+	export.Flags().StringVar(&kvOut, "out", "", "Archive file to write")
+	export.Flags().BoolVar(&kvVersions, "versions", false, "Include previous values kept by versioned mounts")
+	export.Flags().BoolVar(&kvMetadata, "metadata", true, "Include description, type, tags and expiry")
+	export.Flags().StringArrayVar(&kvRecipients, "recipient", nil, "Public key that may also open the archive (repeatable)")
+	export.MarkFlagRequired("out")
+	addBulkFlags(export, false)
+	cmd.AddCommand(export)
+
+	importCmd := &cobra.Command{
+		Use:   "import [archive] [mount/prefix]",
+		Short: "Import an archive, by default at its original path",
+		Long: `Import the secrets of an archive under mount/prefix, or where they
+were exported from. --conflict decides what happens to a secret that
+already exists:
+  skip         keep the existing secret (default)
+  overwrite    delete it, with its history, and recreate it from the archive
+  new-version  write the archived value as its next version
+
+Previous values in the archive are replayed in order when a secret is
+created, so a versioned mount keeps the history.`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: runKVImportCommand,
+	}
+	importCmd.Flags().StringVar(&kvConflict, "conflict", kvConflictSkip, "Conflict strategy: skip, overwrite or new-version")
+	addBulkFlags(importCmd, true)
+	cmd.AddCommand(importCmd)
+
+	migrate := &cobra.Command{
+		Use:   "migrate [source mount/prefix] [destination mount/prefix]",
+		Short: "Copy a subtree to another mount or prefix",
+		Long: `Copy every secret under the source path to the destination path of
+the same vault, without an archive in between. --conflict works as for
+import; --delete removes each source secret once it was copied.`,
+		Args: cobra.ExactArgs(2),
+		RunE: runKVMigrateCommand,
+	}
+	migrate.Flags().StringVar(&kvConflict, "conflict", kvConflictSkip, "Conflict strategy: skip, overwrite or new-version")
+	migrate.Flags().BoolVar(&kvVersions, "versions", false, "Copy previous values kept by versioned mounts")
+	migrate.Flags().BoolVar(&kvDelete, "delete", false, "Delete source secrets after copying them")
+	addBulkFlags(migrate, true)
+	cmd.AddCommand(migrate)
+
+	deleteCmd := &cobra.Command{
+		Use:   "delete [mount/prefix]",
+		Short: "Delete every secret under a path",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runKVDeleteCommand,
+	}
+	deleteCmd.Flags().BoolVar(&kvYes, "yes", false, "Confirm the deletion")
+	deleteCmd.Flags().BoolVar(&kvDryRun, "dry-run", false, "List the secrets that would be deleted")
+	addBulkFlags(deleteCmd, true)
+	cmd.AddCommand(deleteCmd)
+
+	return cmd
+}
+
+// runKVExportCommand executes the kv export command
+func runKVExportCommand(cmd *cobra.Command, args []string) error {
+	identity, err := e2e.LoadIdentity(keyFile)
+	if err != nil {
+		return err
+	}
+	recipients, err := parseRecipients(kvRecipients)
+	if err != nil {
+		return err
+	}
+	api, err := newServerAPIClient()
+	if err != nil {
+		return err
+	}
+
+	mount, prefix := splitKVPath(args[0])
+	base := kvSecretsPath(mount)
+	ctx := context.Background()
+
+	secrets, err := listKVSecrets(ctx, api, base)
+	if err != nil {
+		return err
+	}
+	secrets = filterKVSecrets(secrets, prefix)
+	if len(secrets) == 0 {
+		return fmt.Errorf("no secrets under %s", args[0])
+	}
+
+	names, byName := indexKVSecrets(secrets)
+	entries := make(map[string]kvArchiveEntry, len(secrets))
+	var mu sync.Mutex
+	result, err := bulk.Run(ctx, names, bulk.Options{Concurrency: bulkConcurrency, Label: "Exporting"}, func(ctx context.Context, name string) error {
+		entry, err := readKVEntry(ctx, api, base, prefix, byName[name], kvVersions)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		entries[name] = *entry
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if result.Err() != nil {
+		// A partial archive would import as if the rest did not exist
+		result.PrintFailures()
+		return fmt.Errorf("export aborted: %w", result.Err())
+	}
+
+	archive := kvArchive{Format: kvArchiveFormat, Mount: mount, Prefix: prefix, ExportedAt: time.Now().UTC()}
+	for _, secret := range secrets {
+		archive.Entries = append(archive.Entries, entries[secret.Name])
+	}
+
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	if err := json.NewEncoder(writer).Encode(archive); err != nil {
+		return fmt.Errorf("failed to encode archive: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to compress archive: %w", err)
+	}
+
+	sealed, err := e2e.Seal(compressed.Bytes(), append(recipients, identity.PublicKey())...)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(kvOut, []byte(sealed+"\n"), 0600); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	fmt.Println(ui.Success(fmt.Sprintf("Exported %d secrets from %s to %s", len(archive.Entries), args[0], kvOut)))
+	return nil
+}
+
+// readKVEntry reads a secret's value, and its metadata and previous
+// values when asked, as an archive entry relative to prefix
+func readKVEntry(ctx context.Context, api *client.APIClient, base, prefix string, secret kvSecret, versions bool) (*kvArchiveEntry, error) {
+	var value secretValueResponse
+	if err := api.Do(ctx, http.MethodGet, base+"/"+url.PathEscape(secret.ID)+"/value", nil, &value); err != nil {
+		return nil, fmt.Errorf("failed to read value: %w", err)
+	}
+	entry := &kvArchiveEntry{
+		Path:            strings.TrimPrefix(secret.Name, prefix),
+		Value:           value.Value,
+		ClientEncrypted: value.ClientEncrypted,
+	}
+	if kvMetadata {
+		entry.Description = secret.Description
+		entry.Type = secret.Type
+		entry.Tags = secret.Tags
+		entry.ExpiresAt = secret.ExpiresAt
+	}
+	if versions {
+		var err error
+		if entry.Versions, err = exportKVVersions(ctx, api, base, secret.ID); err != nil {
+			return nil, fmt.Errorf("failed to read versions: %w", err)
+		}
+	}
+	return entry, nil
+}
+
+// exportKVVersions reads every previous value of a secret, oldest first
+func exportKVVersions(ctx context.Context, api *client.APIClient, base, id string) ([]kvArchiveVersion, error) {
+	var response struct {
+		Versions []kvArchiveVersion `json:"versions"`
+	}
+	if err := api.Do(ctx, http.MethodGet, base+"/"+url.PathEscape(id)+"/versions", nil, &response); err != nil {
+		return nil, err
+	}
+
+	versions := response.Versions
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Version < versions[j].Version })
+	for i := range versions {
+		if err := api.Do(ctx, http.MethodGet, fmt.Sprintf("%s/%s/versions/%d", base, url.PathEscape(id), versions[i].Version), nil, &versions[i]); err != nil {
+			return nil, err
+		}
+	}
+	return versions, nil
+}
+
+// runKVImportCommand executes the kv import command
+func runKVImportCommand(cmd *cobra.Command, args []string) error {
+	if err := checkKVConflict(); err != nil {
+		return err
+	}
+
+	identity, err := e2e.LoadIdentity(keyFile)
+	if err != nil {
+		return err
+	}
+	archive, err := readKVArchive(identity, args[0])
+	if err != nil {
+		return err
+	}
+	api, err := newServerAPIClient()
+	if err != nil {
+		return err
+	}
+
+	mount, prefix := archive.Mount, archive.Prefix
+	if len(args) == 2 {
+		mount, prefix = splitKVPath(args[1])
+	}
+	base := kvSecretsPath(mount)
+	ctx := context.Background()
+
+	secrets, err := listKVSecrets(ctx, api, base)
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]string, len(secrets))
+	for _, secret := range secrets {
+		existing[secret.Name] = secret.ID
+	}
+
+	byPath := make(map[string]kvArchiveEntry, len(archive.Entries))
+	paths := make([]string, 0, len(archive.Entries))
+	for _, entry := range archive.Entries {
+		byPath[entry.Path] = entry
+		paths = append(paths, entry.Path)
+	}
+
+	var outcomes kvOutcomes
+	result, err := bulk.Run(ctx, paths, bulk.Options{
+		Concurrency: bulkConcurrency,
+		StateFile:   bulkStateFile,
+		Operation:   fmt.Sprintf("kv import %s %s/%s", archive.ExportedAt.Format(time.RFC3339Nano), mount, prefix),
+		Label:       "Importing",
+	}, func(ctx context.Context, path string) error {
+		name := prefix + path
+		id, exists := existing[name]
+		outcome, err := applyKVEntry(ctx, api, base, name, id, exists, byPath[path])
+		if err != nil {
+			return err
+		}
+		outcomes.add(outcome)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(ui.Success(fmt.Sprintf("Imported into %s/%s: %s", mount, prefix, outcomes.describe(result))))
+	if result.Err() != nil {
+		result.PrintFailures()
+		return fmt.Errorf("%d secrets failed to import", len(result.Failures))
+	}
+	return nil
+}
+
+// runKVMigrateCommand executes the kv migrate command
+func runKVMigrateCommand(cmd *cobra.Command, args []string) error {
+	if err := checkKVConflict(); err != nil {
+		return err
+	}
+	api, err := newServerAPIClient()
+	if err != nil {
+		return err
+	}
+
+	srcMount, srcPrefix := splitKVPath(args[0])
+	dstMount, dstPrefix := splitKVPath(args[1])
+	srcBase, dstBase := kvSecretsPath(srcMount), kvSecretsPath(dstMount)
+	if srcBase == dstBase && strings.HasPrefix(dstPrefix, srcPrefix) {
+		return fmt.Errorf("destination %s lies inside the source %s", args[1], args[0])
+	}
+	ctx := context.Background()
+
+	secrets, err := listKVSecrets(ctx, api, srcBase)
+	if err != nil {
+		return err
+	}
+	names, byName := indexKVSecrets(filterKVSecrets(secrets, srcPrefix))
+	if len(names) == 0 {
+		return fmt.Errorf("no secrets under %s", args[0])
+	}
+
+	destination, err := listKVSecrets(ctx, api, dstBase)
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]string, len(destination))
+	for _, secret := range destination {
+		existing[secret.Name] = secret.ID
+	}
+
+	var outcomes kvOutcomes
+	result, err := bulk.Run(ctx, names, bulk.Options{
+		Concurrency: bulkConcurrency,
+		StateFile:   bulkStateFile,
+		Operation:   fmt.Sprintf("kv migrate %s %s", args[0], args[1]),
+		Label:       "Migrating",
+	}, func(ctx context.Context, name string) error {
+		secret := byName[name]
+		entry, err := readKVEntry(ctx, api, srcBase, srcPrefix, secret, kvVersions)
+		if err != nil {
+			return err
+		}
+
+		target := dstPrefix + entry.Path
+		id, exists := existing[target]
+		outcome, err := applyKVEntry(ctx, api, dstBase, target, id, exists, *entry)
+		if err != nil {
+			return err
+		}
+		outcomes.add(outcome)
+
+		if kvDelete && outcome != kvOutcomeSkipped {
+			if err := api.Do(ctx, http.MethodDelete, srcBase+"/"+url.PathEscape(secret.ID), nil, nil); err != nil {
+				return fmt.Errorf("copied, but failed to delete the source: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(ui.Success(fmt.Sprintf("Migrated %s to %s: %s", args[0], args[1], outcomes.describe(result))))
+	if result.Err() != nil {
+		result.PrintFailures()
+		return fmt.Errorf("%d secrets failed to migrate", len(result.Failures))
+	}
+	return nil
+}
+
+// runKVDeleteCommand executes the kv delete command
+func runKVDeleteCommand(cmd *cobra.Command, args []string) error {
+	api, err := newServerAPIClient()
+	if err != nil {
+		return err
+	}
+
+	mount, prefix := splitKVPath(args[0])
+	base := kvSecretsPath(mount)
+	ctx := context.Background()
+
+	secrets, err := listKVSecrets(ctx, api, base)
+	if err != nil {
+		return err
+	}
+	names, byName := indexKVSecrets(filterKVSecrets(secrets, prefix))
+	if len(names) == 0 {
+		fmt.Printf("No secrets under %s\n", args[0])
+		return nil
+	}
+
+	if kvDryRun {
+		for _, name := range names {
+			fmt.Println(name)
+		}
+		fmt.Println(ui.Info(fmt.Sprintf("%d secrets would be deleted", len(names))))
+		return nil
+	}
+	if !kvYes {
+		return fmt.Errorf("this deletes %d secrets under %s; pass --yes to confirm or --dry-run to list them", len(names), args[0])
+	}
+
+	result, err := bulk.Run(ctx, names, bulk.Options{
+		Concurrency: bulkConcurrency,
+		StateFile:   bulkStateFile,
+		Operation:   "kv delete " + args[0],
+		Label:       "Deleting",
+	}, func(ctx context.Context, name string) error {
+		return api.Do(ctx, http.MethodDelete, base+"/"+url.PathEscape(byName[name].ID), nil, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(ui.Success(fmt.Sprintf("Deleted %d of %d secrets under %s", result.Done+result.Resumed, result.Total, args[0])))
+	if result.Err() != nil {
+		result.PrintFailures()
+		return fmt.Errorf("%d secrets failed to delete", len(result.Failures))
+	}
+	return nil
+}
+
+// Outcomes of writing one secret
+const (
+	kvOutcomeCreated = iota
+	kvOutcomeUpdated
+	kvOutcomeSkipped
+)
+
+// kvOutcomes counts outcomes across workers
+type kvOutcomes struct {
+	created, updated, skipped atomic.Int64
+}
+
+func (o *kvOutcomes) add(outcome int) {
+	switch outcome {
+	case kvOutcomeCreated:
+		o.created.Add(1)
+	case kvOutcomeUpdated:
+		o.updated.Add(1)
+	case kvOutcomeSkipped:
+		o.skipped.Add(1)
+	}
+}
+
+func (o *kvOutcomes) describe(result *bulk.Result) string {
+	summary := fmt.Sprintf("%d created, %d updated, %d skipped", o.created.Load(), o.updated.Load(), o.skipped.Load())
+	if result.Resumed > 0 {
+		summary += fmt.Sprintf(", %d done by an earlier run", result.Resumed)
+	}
+	return summary
+}
+
+// applyKVEntry writes entry as name, resolving an existing secret with
+// the --conflict strategy
+func applyKVEntry(ctx context.Context, api *client.APIClient, base, name, id string, exists bool, entry kvArchiveEntry) (int, error) {
+	if exists {
+		switch kvConflict {
+		case kvConflictSkip:
+			return kvOutcomeSkipped, nil
+		case kvConflictNewVersion:
+			if err := api.Do(ctx, http.MethodPut, base+"/"+url.PathEscape(id), entry.updateRequest(entry.Value, entry.ClientEncrypted), nil); err != nil {
+				return 0, err
+			}
+			return kvOutcomeUpdated, nil
+		case kvConflictOverwrite:
+			if err := api.Do(ctx, http.MethodDelete, base+"/"+url.PathEscape(id), nil, nil); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if err := importKVEntry(ctx, api, base, name, entry); err != nil {
+		return 0, err
+	}
+	if exists {
+		return kvOutcomeUpdated, nil
+	}
+	return kvOutcomeCreated, nil
+}
+
+// importKVEntry creates a secret from its oldest archived value and
+// replays the later ones, ending with the current value
+func importKVEntry(ctx context.Context, api *client.APIClient, base, name string, entry kvArchiveEntry) error {
+	value, clientEncrypted := entry.Value, entry.ClientEncrypted
+	if len(entry.Versions) > 0 {
+		value, clientEncrypted = entry.Versions[0].Value, entry.Versions[0].ClientEncrypted
+	}
+
+	secretType := entry.Type
+	if secretType == "" {
+		secretType = "other"
+	}
+	request := map[string]interface{}{
+		"name":             name,
+		"description":      entry.Description,
+		"value":            value,
+		"type":             secretType,
+		"tags":             entry.Tags,
+		"client_encrypted": clientEncrypted,
+	}
+	if entry.ExpiresAt != nil {
+		request["expires_at"] = entry.ExpiresAt
+	}
+
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := api.Do(ctx, http.MethodPost, base, request, &created); err != nil {
+		return err
+	}
+	if len(entry.Versions) == 0 {
+		return nil
+	}
+
+	for _, version := range entry.Versions[1:] {
+		if err := api.Do(ctx, http.MethodPut, base+"/"+url.PathEscape(created.ID), map[string]interface{}{"value": version.Value, "client_encrypted": version.ClientEncrypted}, nil); err != nil {
+			return err
+		}
+	}
+	return api.Do(ctx, http.MethodPut, base+"/"+url.PathEscape(created.ID), entry.updateRequest(entry.Value, entry.ClientEncrypted), nil)
+}
+
+// updateRequest writes value and whatever metadata the archive carries
+func (e kvArchiveEntry) updateRequest(value string, clientEncrypted bool) map[string]interface{} {
+	request := map[string]interface{}{
+		"value":            value,
+		"client_encrypted": clientEncrypted,
+	}
+	if e.Type != "" {
+		request["description"] = e.Description
+		request["type"] = e.Type
+		request["tags"] = e.Tags
+	}
+	if e.ExpiresAt != nil {
+		request["expires_at"] = e.ExpiresAt
+	}
+	return request
+}
+
+// readKVArchive opens an archive sealed for identity
+func readKVArchive(identity *e2e.Identity, path string) (*kvArchive, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	compressed, err := identity.Open(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress archive: %w", err)
+	}
+	plaintext, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress archive: %w", err)
+	}
+
+	var archive kvArchive
+	if err := json.Unmarshal(plaintext, &archive); err != nil {
+		return nil, fmt.Errorf("failed to decode archive: %w", err)
+	}
+	if archive.Format != kvArchiveFormat {
+		return nil, fmt.Errorf("unsupported archive format %q", archive.Format)
+	}
+	return &archive, nil
+}
+
+// listKVSecrets lists the caller's secrets in a mount
+func listKVSecrets(ctx context.Context, api *client.APIClient, base string) ([]kvSecret, error) {
+	var response struct {
+		Secrets []kvSecret `json:"secrets"`
+	}
+	if err := api.Do(ctx, http.MethodGet, base, nil, &response); err != nil {
+		return nil, fmt.Errorf("failed to list secrets: %w", err)
+	}
+	return response.Secrets, nil
+}
+
+// filterKVSecrets keeps the secrets whose name starts with prefix
+func filterKVSecrets(secrets []kvSecret, prefix string) []kvSecret {
+	matched := make([]kvSecret, 0, len(secrets))
+	for _, secret := range secrets {
+		if strings.HasPrefix(secret.Name, prefix) {
+			matched = append(matched, secret)
+		}
+	}
+	return matched
+}
+
+// indexKVSecrets returns the secret names in listing order and the
+// secrets by name
+func indexKVSecrets(secrets []kvSecret) ([]string, map[string]kvSecret) {
+	names := make([]string, 0, len(secrets))
+	byName := make(map[string]kvSecret, len(secrets))
+	for _, secret := range secrets {
+		names = append(names, secret.Name)
+		byName[secret.Name] = secret
+	}
+	return names, byName
+}
+
+// checkKVConflict validates --conflict
+func checkKVConflict() error {
+	switch kvConflict {
+	case kvConflictSkip, kvConflictOverwrite, kvConflictNewVersion:
+		return nil
+	}
+	return fmt.Errorf("unknown conflict strategy %q (use skip, overwrite or new-version)", kvConflict)
+}
+
+// splitKVPath splits mount/prefix at its first slash
+func splitKVPath(path string) (string, string) {
+	mount, prefix, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	return mount, prefix
+}
+
+// kvSecretsPath is the API path of a mount's secrets
+func kvSecretsPath(mount string) string {
+	if mount == defaultKVMount {
+		return "/secrets"
+	}
+	return "/mounts/" + url.PathEscape(mount) + "/secrets"
+}
@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	namespaceDescription string
+	namespaceRoleUsers   []string
+)
+
+// newNamespaceCommand creates the namespace command group
+func newNamespaceCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "namespace",
+		Aliases: []string{"ns"},
+		Short:   "Manage namespaces and delegated roles",
+		Long: `Manage namespaces and the built-in roles that delegate their
+administration (namespace-admin, secrets-writer, auditor).`,
+	}
+
+	createCmd := &cobra.Command{
+		Use:   "create [name]",
+		Short: "Create a namespace",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runNamespaceCreateCommand,
+	}
+	createCmd.Flags().StringVar(&namespaceDescription, "description", "", "Namespace description")
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List namespaces",
+		RunE:  runNamespaceListCommand,
+	}
+
+	cmd.AddCommand(createCmd)
+	cmd.AddCommand(listCmd)
+	cmd.AddCommand(newNamespaceRoleCommand())
+
+	return cmd
+}
+
+// newNamespaceRoleCommand creates the namespace role command group
+func newNamespaceRoleCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "role",
+		Short: "Manage delegated namespace roles",
+		Long: `Assign and revoke built-in namespace roles.
+
+Examples:
+  vault namespace role list <namespace-id>
+  vault namespace role assign <namespace-id> secrets-writer --user <uid> --user <uid>
+  vault namespace role revoke <namespace-id> secrets-writer <uid>`,
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list [namespace-id]",
+		Short: "List role bindings, or the built-in roles when no namespace is given",
+		Args:  cobra.MaximumNArgs(1),
+		RunE:  runNamespaceRoleListCommand,
+	}
+
+	assignCmd := &cobra.Command{
+		Use:   "assign [namespace-id] [role]",
+		Short: "Assign a role to users",
+		Args:  cobra.ExactArgs(2),
+		RunE:  runNamespaceRoleAssignCommand,
+	}
+	assignCmd.Flags().StringSliceVar(&namespaceRoleUsers, "user", nil, "User ID (repeatable or comma-separated)")
+	assignCmd.MarkFlagRequired("user")
+
+	revokeCmd := &cobra.Command{
+		Use:   "revoke [namespace-id] [role] [user-id]",
+		Short: "Revoke a role from a user",
+		Args:  cobra.ExactArgs(3),
+		RunE:  runNamespaceRoleRevokeCommand,
+	}
+
+	cmd.AddCommand(listCmd)
+	cmd.AddCommand(assignCmd)
+	cmd.AddCommand(revokeCmd)
+
+	return cmd
+}
+
+// runNamespaceCreateCommand executes the namespace create command
+func runNamespaceCreateCommand(cmd *cobra.Command, args []string) error {
+	api, err := newServerAPIClient()
+	if err != nil {
+		return err
+	}
+
+	request := map[string]string{
+		"name":        args[0],
+		"description": namespaceDescription,
+	}
+
+	var namespace struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := api.Do(context.Background(), http.MethodPost, "/namespaces", request, &namespace); err != nil {
+		return fmt.Errorf("failed to create namespace: %w", err)
+	}
+
+	fmt.Println(ui.Success(fmt.Sprintf("Namespace %s created (%s)", namespace.Name, namespace.ID)))
+	return nil
+}
+
+// runNamespaceListCommand executes the namespace list command
+func runNamespaceListCommand(cmd *cobra.Command, args []string) error {
+	api, err := newServerAPIClient()
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Namespaces []struct {
+			ID          string `json:"id"`
+			Name        string `json:"name"`
+			Description string `json:"description"`
+		} `json:"namespaces"`
+	}
+	if err := api.Do(context.Background(), http.MethodGet, "/namespaces", nil, &response); err != nil {
+		return fmt.Errorf("failed to list namespaces: %w", err)
+	}
+
+	for _, namespace := range response.Namespaces {
+		fmt.Printf("%s  %s  %s\n", namespace.ID, ui.BoldText(namespace.Name), namespace.Description)
+	}
+
+	return nil
+}
+
+// runNamespaceRoleListCommand executes the namespace role list command
+func runNamespaceRoleListCommand(cmd *cobra.Command, args []string) error {
+	api, err := newServerAPIClient()
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		var response struct {
+			Roles []struct {
+				Role        string   `json:"role"`
+				Permissions []string `json:"permissions"`
+			} `json:"roles"`
+		}
+		if err := api.Do(context.Background(), http.MethodGet, "/namespaces/roles", nil, &response); err != nil {
+			return fmt.Errorf("failed to list roles: %w", err)
+		}
+
+		for _, role := range response.Roles {
+			fmt.Printf("%-16s %s\n", ui.BoldText(role.Role), strings.Join(role.Permissions, ", "))
+		}
+		return nil
+	}
+
+	var response struct {
+		Bindings []struct {
+			UserID string `json:"user_id"`
+			Role   string `json:"role"`
+		} `json:"bindings"`
+	}
+	if err := api.Do(context.Background(), http.MethodGet, "/namespaces/"+args[0]+"/roles", nil, &response); err != nil {
+		return fmt.Errorf("failed to list role bindings: %w", err)
+	}
+
+	if len(response.Bindings) == 0 {
+		fmt.Println("No roles assigned in this namespace")
+		return nil
+	}
+
+	for _, binding := range response.Bindings {
+		fmt.Printf("%-16s %s\n", binding.Role, binding.UserID)
+	}
+
+	return nil
+}
+
+// runNamespaceRoleAssignCommand executes the namespace role assign command
+func runNamespaceRoleAssignCommand(cmd *cobra.Command, args []string) error {
+	api, err := newServerAPIClient()
+	if err != nil {
+		return err
+	}
+
+	request := map[string]interface{}{
+		"role":     args[1],
+		"user_ids": namespaceRoleUsers,
+	}
+
+	var response struct {
+		Assigned int64 `json:"assigned"`
+	}
+	if err := api.Do(context.Background(), http.MethodPost, "/namespaces/"+args[0]+"/roles", request, &response); err != nil {
+		return fmt.Errorf("failed to assign role: %w", err)
+	}
+
+	fmt.Println(ui.Success(fmt.Sprintf("Role %s assigned to %d new user(s)", args[1], response.Assigned)))
+	return nil
+}
+
+// runNamespaceRoleRevokeCommand executes the namespace role revoke command
+func runNamespaceRoleRevokeCommand(cmd *cobra.Command, args []string) error {
+	api, err := newServerAPIClient()
+	if err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf("/namespaces/%s/roles/%s/%s", args[0], args[1], args[2])
+	if err := api.Do(context.Background(), http.MethodDelete, path, nil, nil); err != nil {
+		return fmt.Errorf("failed to revoke role: %w", err)
+	}
+
+	fmt.Println(ui.Success(fmt.Sprintf("Role %s revoked", args[1])))
+	return nil
+}
@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/config"
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/ipc"
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/nativehost"
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/proxy"
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	nativeHostConfigFile  string
+	nativeHostBrowser     string
+	nativeHostExtensionID string
+)
+
+// newNativeHostCommand creates the native-host command
+func newNativeHostCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "native-host",
+		Short: "Run the browser extension native messaging host",
+		Long: `Serve credential requests from the Aether Vault browser extension over
+native messaging. The browser starts this command itself once the host is
+installed with 'vault native-host install'.
+
+The first time the extension asks for a site, a dialog asks you to allow
+it. Every fill then needs a single-use capability from the local agent
+for the site's resource, so agent policy decides which credentials the
+extension may read.
+
+The configuration file maps sites to secrets:
+
+  sites:
+    - origin: https://github.com
+      secret: <secret id>          # {"username": ..., "password": ...} or a password
+      username: octocat            # used when the secret is a bare password
+      resource: autofill:https://github.com   # default`,
+		Args: cobra.ArbitraryArgs,
+		// Chrome appends --parent-window on Windows
+		FParseErrWhitelist: cobra.FParseErrWhitelist{UnknownFlags: true},
+		SilenceUsage:       true,
+		RunE:               runNativeHostCommand,
+	}
+
+	cmd.PersistentFlags().StringVar(&nativeHostConfigFile, "native-host-config", defaultNativeHostPath("native-host.yaml"), "Path to the native host configuration file")
+
+	install := &cobra.Command{
+		Use:   "install",
+		Short: "Register the native messaging host with a browser",
+		Long: `Write the native messaging manifest that lets the browser start
+'vault native-host' for the given extension.
+
+Examples:
+  vault native-host install --browser chrome --extension-id abcdefghijklmnopabcdefghijklmnop
+  vault native-host install --browser firefox --extension-id vault@skygenesisenterprise.com`,
+		RunE: runNativeHostInstallCommand,
+	}
+	install.Flags().StringVar(&nativeHostBrowser, "browser", "chrome", "Browser: chrome, chromium or firefox")
+	install.Flags().StringVar(&nativeHostExtensionID, "extension-id", "", "ID of the browser extension allowed to connect")
+	install.MarkFlagRequired("extension-id")
+	cmd.AddCommand(install)
+
+	return cmd
+}
+
+// runNativeHostCommand executes the native-host command. Stdout carries
+// the messaging protocol, so nothing else may be printed to it.
+func runNativeHostCommand(cmd *cobra.Command, args []string) error {
+	logger := log.New(os.Stderr, "aether-vault native-host: ", log.LstdFlags)
+
+	extension := nativeHostExtension(args)
+	if extension == "" {
+		return fmt.Errorf("native-host is started by the browser; run 'vault native-host install' to register it")
+	}
+
+	hostConfig, err := nativehost.LoadConfig(nativeHostConfigFile)
+	if err != nil {
+		return err
+	}
+	approvals, err := nativehost.LoadApprovals(defaultNativeHostPath(filepath.Join("native-host", "approvals.json")))
+	if err != nil {
+		return err
+	}
+
+	api, err := newServerAPIClient()
+	if err != nil {
+		return err
+	}
+
+	clientConfig := ipc.DefaultClientConfig()
+	clientConfig.Identity = "native-host"
+	clientConfig.EnableLogging = false
+	client, err := ipc.NewClient(clientConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+	defer client.Close()
+
+	if err := client.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to agent: %w", err)
+	}
+
+	host := nativehost.NewHost(hostConfig, client, proxy.NewVaultCredentials(api), approvals, nativehost.DialogPrompter{}, extension, logger)
+	return host.Serve(context.Background(), os.Stdin, os.Stdout)
+}
+
+// nativeHostExtension finds the calling extension in the arguments the
+// browser passes: Chrome gives its origin, Firefox the manifest path
+// followed by the add-on ID
+func nativeHostExtension(args []string) string {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "chrome-extension://") {
+			return arg
+		}
+	}
+	if len(args) >= 2 {
+		return args[1]
+	}
+	return ""
+}
+
+// runNativeHostInstallCommand executes the native-host install command
+func runNativeHostInstallCommand(cmd *cobra.Command, args []string) error {
+	if runtime.GOOS == "windows" {
+		return fmt.Errorf("on Windows, register the manifest under HKCU\\Software\\<browser>\\NativeMessagingHosts\\%s manually", nativehost.HostName)
+	}
+
+	manifestDir, err := nativeHostManifestDir(nativeHostBrowser)
+	if err != nil {
+		return err
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate the vault binary: %w", err)
+	}
+
+	// Browsers start the host without arguments of our choosing, so the
+	// manifest points at a wrapper that adds the subcommand
+	wrapper := defaultNativeHostPath(filepath.Join("native-host", "aether-vault-native-host"))
+	script := fmt.Sprintf("#!/bin/sh\nexec %q native-host --native-host-config %q \"$@\"\n", executable, nativeHostConfigFile)
+	if err := os.MkdirAll(filepath.Dir(wrapper), 0700); err != nil {
+		return fmt.Errorf("failed to create native host directory: %w", err)
+	}
+	if err := os.WriteFile(wrapper, []byte(script), 0700); err != nil {
+		return fmt.Errorf("failed to write native host wrapper: %w", err)
+	}
+
+	manifest := map[string]interface{}{
+		"name":        nativehost.HostName,
+		"description": "Aether Vault",
+		"path":        wrapper,
+		"type":        "stdio",
+	}
+	if nativeHostBrowser == "firefox" {
+		manifest["allowed_extensions"] = []string{nativeHostExtensionID}
+	} else {
+		manifest["allowed_origins"] = []string{"chrome-extension://" + nativeHostExtensionID + "/"}
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(manifestDir, 0755); err != nil {
+		return fmt.Errorf("failed to create manifest directory: %w", err)
+	}
+	manifestPath := filepath.Join(manifestDir, nativehost.HostName+".json")
+	if err := os.WriteFile(manifestPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	fmt.Println(ui.Success(fmt.Sprintf("Native messaging host registered for %s at %s", nativeHostBrowser, manifestPath)))
+	if _, err := os.Stat(nativeHostConfigFile); err != nil {
+		fmt.Println(ui.Warning(fmt.Sprintf("Create %s to list the sites the extension may fill", nativeHostConfigFile)))
+	}
+	return nil
+}
+
+// nativeHostManifestDir returns the per-user manifest directory of a browser
+func nativeHostManifestDir(browser string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	dirs := map[string]string{
+		"chrome":   filepath.Join(home, ".config", "google-chrome", "NativeMessagingHosts"),
+		"chromium": filepath.Join(home, ".config", "chromium", "NativeMessagingHosts"),
+		"firefox":  filepath.Join(home, ".mozilla", "native-messaging-hosts"),
+	}
+	if runtime.GOOS == "darwin" {
+		support := filepath.Join(home, "Library", "Application Support")
+		dirs = map[string]string{
+			"chrome":   filepath.Join(support, "Google", "Chrome", "NativeMessagingHosts"),
+			"chromium": filepath.Join(support, "Chromium", "NativeMessagingHosts"),
+			"firefox":  filepath.Join(support, "Mozilla", "NativeMessagingHosts"),
+		}
+	}
+
+	dir, ok := dirs[browser]
+	if !ok {
+		return "", fmt.Errorf("unsupported browser %q: use chrome, chromium or firefox", browser)
+	}
+	return dir, nil
+}
+
+func defaultNativeHostPath(name string) string {
+	return filepath.Join(config.DefaultVaultPath(), name)
+}
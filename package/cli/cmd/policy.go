@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/client"
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/config"
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	policyTemplateName        string
+	policyTemplateDescription string
+	policyTemplateRulesFile   string
+	policyVariables           []string
+	policyUsers               []string
+	policyName                string
+)
+
+// newPolicyCommand creates the policy command group
+func newPolicyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "policy",
+		Short: "Manage server policies",
+		Long:  `Manage policy templates and policy assignments on the Aether Vault server.`,
+	}
+
+	cmd.AddCommand(newPolicyTemplateCommand())
+	cmd.AddCommand(newPolicyAssignCommand("attach", "Attach a policy to many users atomically"))
+	cmd.AddCommand(newPolicyAssignCommand("detach", "Detach a policy from many users atomically"))
+
+	return cmd
+}
+
+// newPolicyTemplateCommand creates the policy template command group
+func newPolicyTemplateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "template",
+		Short: "Manage policy templates",
+		Long: `Manage policy templates. Template rules may reference variables
+written as {{name}}, which are filled in when the template is instantiated.`,
+	}
+
+	createCmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a policy template",
+		RunE:  runPolicyTemplateCreateCommand,
+	}
+	createCmd.Flags().StringVar(&policyTemplateName, "name", "", "Template name")
+	createCmd.Flags().StringVar(&policyTemplateDescription, "description", "", "Template description")
+	createCmd.Flags().StringVar(&policyTemplateRulesFile, "rules", "", "File containing the template rules")
+	createCmd.MarkFlagRequired("name")
+	createCmd.MarkFlagRequired("rules")
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List policy templates",
+		RunE:  runPolicyTemplateListCommand,
+	}
+
+	instantiateCmd := &cobra.Command{
+		Use:   "instantiate [template-id]",
+		Short: "Create a policy from a template",
+		Long: `Create a policy from a template, optionally attaching it to users.
+
+Examples:
+  vault policy template instantiate <id> --var team=payments
+  vault policy template instantiate <id> --var team=payments --user <uid> --user <uid>`,
+		Args: cobra.ExactArgs(1),
+		RunE: runPolicyTemplateInstantiateCommand,
+	}
+	instantiateCmd.Flags().StringVar(&policyName, "name", "", "Policy name (defaults to template name and variable values)")
+	instantiateCmd.Flags().StringArrayVar(&policyVariables, "var", nil, "Template variable as name=value (repeatable)")
+	instantiateCmd.Flags().StringSliceVar(&policyUsers, "user", nil, "User ID to attach the new policy to (repeatable)")
+
+	cmd.AddCommand(createCmd)
+	cmd.AddCommand(listCmd)
+	cmd.AddCommand(instantiateCmd)
+
+	return cmd
+}
+
+// newPolicyAssignCommand creates the policy attach or detach command
+func newPolicyAssignCommand(action, short string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   action + " [policy-id]",
+		Short: short,
+		Long: fmt.Sprintf(`%s. Either every user is updated or none is.
+
+Example:
+  vault policy %s <policy-id> --user <uid> --user <uid>`, short, action),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			users, _ := cmd.Flags().GetStringSlice("user")
+			return runPolicyAssignCommand(action, args[0], users)
+		},
+	}
+
+	cmd.Flags().StringSlice("user", nil, "User ID (repeatable or comma-separated)")
+	cmd.MarkFlagRequired("user")
+
+	return cmd
+}
+
+// runPolicyTemplateCreateCommand executes the policy template create command
+func runPolicyTemplateCreateCommand(cmd *cobra.Command, args []string) error {
+	rules, err := os.ReadFile(policyTemplateRulesFile)
+	if err != nil {
+		return fmt.Errorf("failed to read rules: %w", err)
+	}
+
+	api, err := newServerAPIClient()
+	if err != nil {
+		return err
+	}
+
+	request := map[string]string{
+		"name":        policyTemplateName,
+		"description": policyTemplateDescription,
+		"rules":       string(rules),
+	}
+
+	var template struct {
+		ID        string `json:"id"`
+		Variables string `json:"variables"`
+	}
+	if err := api.Do(context.Background(), http.MethodPost, "/policies/templates", request, &template); err != nil {
+		return fmt.Errorf("failed to create policy template: %w", err)
+	}
+
+	fmt.Println(ui.Success(fmt.Sprintf("Policy template %s created (%s)", policyTemplateName, template.ID)))
+	fmt.Printf("Variables: %s\n", template.Variables)
+	return nil
+}
+
+// runPolicyTemplateListCommand executes the policy template list command
+func runPolicyTemplateListCommand(cmd *cobra.Command, args []string) error {
+	api, err := newServerAPIClient()
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Templates []struct {
+			ID          string `json:"id"`
+			Name        string `json:"name"`
+			Description string `json:"description"`
+			Variables   string `json:"variables"`
+		} `json:"templates"`
+	}
+	if err := api.Do(context.Background(), http.MethodGet, "/policies/templates", nil, &response); err != nil {
+		return fmt.Errorf("failed to list policy templates: %w", err)
+	}
+
+	if len(response.Templates) == 0 {
+		fmt.Println("No policy templates defined")
+		return nil
+	}
+
+	for _, template := range response.Templates {
+		fmt.Printf("%s  %s  {%s}\n", template.ID, ui.BoldText(template.Name), template.Variables)
+		if template.Description != "" {
+			fmt.Printf("  %s\n", template.Description)
+		}
+	}
+
+	return nil
+}
+
+// runPolicyTemplateInstantiateCommand executes the policy template instantiate command
+func runPolicyTemplateInstantiateCommand(cmd *cobra.Command, args []string) error {
+	variables := make(map[string]string)
+	for _, variable := range policyVariables {
+		name, value, ok := strings.Cut(variable, "=")
+		if !ok {
+			return fmt.Errorf("invalid --var %q, expected name=value", variable)
+		}
+		variables[name] = value
+	}
+
+	api, err := newServerAPIClient()
+	if err != nil {
+		return err
+	}
+
+	request := map[string]interface{}{
+		"name":      policyName,
+		"variables": variables,
+		"user_ids":  policyUsers,
+	}
+
+	var policy struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := api.Do(context.Background(), http.MethodPost, "/policies/templates/"+args[0]+"/instantiate", request, &policy); err != nil {
+		return fmt.Errorf("failed to instantiate policy template: %w", err)
+	}
+
+	fmt.Println(ui.Success(fmt.Sprintf("Policy %s created (%s)", policy.Name, policy.ID)))
+	if len(policyUsers) > 0 {
+		fmt.Printf("Attached to %d user(s)\n", len(policyUsers))
+	}
+	return nil
+}
+
+// runPolicyAssignCommand attaches or detaches a policy for a set of users
+func runPolicyAssignCommand(action, policyID string, users []string) error {
+	api, err := newServerAPIClient()
+	if err != nil {
+		return err
+	}
+
+	request := map[string]interface{}{
+		"user_ids": users,
+	}
+
+	var response struct {
+		Changed int64 `json:"changed"`
+	}
+	if err := api.Do(context.Background(), http.MethodPost, "/policies/"+policyID+"/"+action, request, &response); err != nil {
+		return fmt.Errorf("failed to %s policy: %w", action, err)
+	}
+
+	fmt.Println(ui.Success(fmt.Sprintf("Policy %sed: %d of %d user assignment(s) changed", action, response.Changed, len(users))))
+	return nil
+}
+
+// newServerAPIClient builds a REST client from the CLI configuration
+func newServerAPIClient() (*client.APIClient, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	return client.NewAPIClient(cfg)
+}
@@ -0,0 +1,340 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/config"
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/profile"
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	profileConfigFile string
+	profileTTL        time.Duration
+	profileShell      string
+	profileCleanAll   bool
+)
+
+// newProfileCommand creates the profile command group
+func newProfileCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Materialize vaulted environment profiles on this machine",
+		Long: `Switch a shell between environments without keeping their secrets
+in dotfiles. A profile maps environment variables and template files to
+vault secrets; activating it reads the secrets, renders the files and
+prints the variables for the shell to set. Activations expire.
+
+The configuration file defines the profiles:
+
+  default_ttl: 8h
+  profiles:
+    staging:
+      description: Staging API and database
+      ttl: 2h                          # optional, overrides default_ttl
+      env:
+        DATABASE_URL: { secret: <secret id>, field: url }   # field of a JSON value
+        STRIPE_KEY: { secret: <secret id> }
+      files:
+        - template: config/app.yaml.tmpl   # {{ secret "<id>" }} or {{ secret "<id>" "<field>" }}
+          path: config/app.yaml            # relative to where the profile is activated
+          mode: 0600                       # default
+
+Examples:
+  eval "$(vault profile activate staging)"
+  vault profile activate staging --shell fish | source
+  vault profile list
+  vault profile show staging
+  eval "$(vault profile clean)"`,
+	}
+
+	cmd.PersistentFlags().StringVar(&profileConfigFile, "profiles", filepath.Join(config.DefaultVaultPath(), "profiles.yaml"), "Path to the profile configuration file")
+
+	activateCmd := &cobra.Command{
+		Use:   "activate [name]",
+		Short: "Read a profile's secrets, write its files and print its variables",
+		Long: `Activate a profile in the current directory. Its files are written
+with mode 0600 unless configured otherwise, and shell code setting its
+variables is printed for eval; nothing is written if any secret fails to
+resolve. The activation expires after the profile's TTL, or --ttl, and
+never outlives the secrets it was built from.
+
+Activating also cleans up activations that have expired.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE:         runProfileActivateCommand,
+	}
+	activateCmd.Flags().DurationVar(&profileTTL, "ttl", 0, "Activation lifetime (default: the profile's ttl)")
+	activateCmd.Flags().StringVar(&profileShell, "shell", defaultProfileShell(), "Shell syntax to print (sh or fish)")
+	activateCmd.Flags().StringVar(&keyFile, "key-file", config.DefaultIdentityKeyPath(), "Path of your client encryption key")
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List profiles and their activations",
+		Args:  cobra.NoArgs,
+		RunE:  runProfileListCommand,
+	}
+
+	showCmd := &cobra.Command{
+		Use:   "show [name]",
+		Short: "Show what a profile references, without secret values",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runProfileShowCommand,
+	}
+
+	cleanCmd := &cobra.Command{
+		Use:   "clean [name]",
+		Short: "Remove the files of expired or named activations",
+		Long: `Remove the files of expired activations, of the named profile's
+activations, or with --all of every activation. Files edited since they
+were rendered are left in place.
+
+When the profile active in this shell is cleaned or has expired, shell
+code unsetting its variables is printed for eval. Adding the command to
+the prompt unsets expired profiles automatically:
+
+  PROMPT_COMMAND='eval "$(vault profile clean 2>/dev/null)"'`,
+		Args:         cobra.MaximumNArgs(1),
+		SilenceUsage: true,
+		RunE:         runProfileCleanCommand,
+	}
+	cleanCmd.Flags().BoolVar(&profileCleanAll, "all", false, "Clean every activation, expired or not")
+	cleanCmd.Flags().StringVar(&profileShell, "shell", defaultProfileShell(), "Shell syntax to print (sh or fish)")
+
+	cmd.AddCommand(activateCmd)
+	cmd.AddCommand(listCmd)
+	cmd.AddCommand(showCmd)
+	cmd.AddCommand(cleanCmd)
+
+	return cmd
+}
+
+// runProfileActivateCommand executes the profile activate command. Its
+// stdout is evaluated by the shell, so messages go to stderr.
+func runProfileActivateCommand(cmd *cobra.Command, args []string) error {
+	profiles, err := profile.LoadConfig(profileConfigFile)
+	if err != nil {
+		return err
+	}
+	selected, ok := profiles.Profiles[args[0]]
+	if !ok {
+		return fmt.Errorf("profile %s is not defined in %s", args[0], profileConfigFile)
+	}
+	if profileTTL < 0 {
+		return fmt.Errorf("--ttl must not be negative")
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	state := profile.LoadState(profileStatePath())
+	now := time.Now()
+	cleaned, err := state.Clean(func(activation *profile.Activation) bool {
+		return activation.Expired(now)
+	})
+	if err != nil {
+		return err
+	}
+	reportProfileClean(cleaned)
+
+	api, err := newServerAPIClient()
+	if err != nil {
+		return err
+	}
+	materialized, err := profile.NewMaterializer(&vaultSecrets{api: api}).Activate(context.Background(), args[0], selected, dir, profileTTL)
+	if err != nil {
+		return fmt.Errorf("failed to activate profile %s: %w", args[0], err)
+	}
+	code, err := profile.Export(profileShell, materialized)
+	if err != nil {
+		return err
+	}
+	if err := state.Record(materialized.Activation); err != nil {
+		return err
+	}
+
+	fmt.Print(code)
+	for _, file := range materialized.Activation.Files {
+		fmt.Fprintf(os.Stderr, "Wrote %s\n", file.Path)
+	}
+	fmt.Fprintf(os.Stderr, "Profile %s active until %s\n", args[0], materialized.Activation.ExpiresAt.Format(time.RFC3339))
+	if info, err := os.Stdout.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprintln(os.Stderr, "Variables are only set when the output is evaluated, e.g. eval \"$(vault profile activate "+args[0]+")\"")
+	}
+
+	return nil
+}
+
+// runProfileListCommand executes the profile list command
+func runProfileListCommand(cmd *cobra.Command, args []string) error {
+	profiles, err := profile.LoadConfig(profileConfigFile)
+	if err != nil {
+		return err
+	}
+	state := profile.LoadState(profileStatePath())
+	current := os.Getenv(profile.ActiveVariable)
+	now := time.Now()
+
+	fmt.Printf("%-2s %-20s %-10s %-28s %s\n", "", "Profile", "TTL", "Activation", "Description")
+	for _, name := range profiles.Names() {
+		marker := ""
+		if name == current {
+			marker = "*"
+		}
+		activation := "-"
+		if latest := state.Find(name); latest != nil {
+			if latest.Expired(now) {
+				activation = "expired " + latest.ExpiresAt.Format(time.RFC3339)
+			} else {
+				activation = "until " + latest.ExpiresAt.Format(time.RFC3339)
+			}
+		}
+		fmt.Printf("%-2s %-20s %-10s %-28s %s\n", marker, name, profiles.Profiles[name].TTL, activation, profiles.Profiles[name].Description)
+	}
+
+	return nil
+}
+
+// runProfileShowCommand executes the profile show command
+func runProfileShowCommand(cmd *cobra.Command, args []string) error {
+	profiles, err := profile.LoadConfig(profileConfigFile)
+	if err != nil {
+		return err
+	}
+	selected, ok := profiles.Profiles[args[0]]
+	if !ok {
+		return fmt.Errorf("profile %s is not defined in %s", args[0], profileConfigFile)
+	}
+
+	fmt.Printf("%s %s\n", ui.BoldText("Profile:"), args[0])
+	if selected.Description != "" {
+		fmt.Printf("%s %s\n", ui.BoldText("Description:"), selected.Description)
+	}
+	fmt.Printf("%s %s\n", ui.BoldText("TTL:"), selected.TTL)
+
+	if len(selected.Env) > 0 {
+		fmt.Printf("\n%s\n", ui.BoldText("Environment:"))
+		for _, variable := range selected.Variables() {
+			fmt.Printf("  %-24s %s\n", variable, selected.Env[variable])
+		}
+	}
+	if len(selected.Files) > 0 {
+		fmt.Printf("\n%s\n", ui.BoldText("Files:"))
+		for _, file := range selected.Files {
+			fmt.Printf("  %s -> %s (%04o)\n", file.Template, file.Path, file.Mode)
+		}
+	}
+
+	if latest := profile.LoadState(profileStatePath()).Find(args[0]); latest != nil {
+		status := "active until"
+		if latest.Expired(time.Now()) {
+			status = "expired at"
+		}
+		fmt.Printf("\n%s %s in %s, %s %s\n", ui.BoldText("Last activation:"), latest.ActivatedAt.Format(time.RFC3339), latest.Directory, status, latest.ExpiresAt.Format(time.RFC3339))
+		for _, file := range latest.Files {
+			fmt.Printf("  %s\n", file.Path)
+		}
+	}
+
+	return nil
+}
+
+// runProfileCleanCommand executes the profile clean command. Its stdout
+// is evaluated by the shell, so messages go to stderr.
+func runProfileCleanCommand(cmd *cobra.Command, args []string) error {
+	if profileCleanAll && len(args) == 1 {
+		return fmt.Errorf("give a profile name or --all, not both")
+	}
+
+	state := profile.LoadState(profileStatePath())
+	now := time.Now()
+	cleaned, err := state.Clean(func(activation *profile.Activation) bool {
+		switch {
+		case profileCleanAll:
+			return true
+		case len(args) == 1:
+			return activation.Profile == args[0]
+		default:
+			return activation.Expired(now)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	reportProfileClean(cleaned)
+
+	// The shell's variables go when its profile was cleaned now, or when
+	// it expired and was cleaned before
+	current := os.Getenv(profile.ActiveVariable)
+	if current == "" {
+		return nil
+	}
+	unset := profileCleanAll || (len(args) == 1 && args[0] == current) || profile.ShellExpired(os.Getenv(profile.ExpiresVariable), now)
+	if !unset {
+		return nil
+	}
+
+	var variables []string
+	if profiles, err := profile.LoadConfig(profileConfigFile); err == nil {
+		if active, ok := profiles.Profiles[current]; ok {
+			variables = active.Variables()
+		}
+	}
+	for _, activation := range cleaned.Activations {
+		if activation.Profile == current {
+			variables = mergeProfileVariables(variables, activation.Variables)
+		}
+	}
+	code, err := profile.Unset(profileShell, variables)
+	if err != nil {
+		return err
+	}
+	fmt.Print(code)
+	fmt.Fprintf(os.Stderr, "Profile %s deactivated\n", current)
+
+	return nil
+}
+
+func reportProfileClean(result *profile.CleanResult) {
+	for _, path := range result.Removed {
+		fmt.Fprintf(os.Stderr, "Removed %s\n", path)
+	}
+	for _, path := range result.Kept {
+		fmt.Fprintf(os.Stderr, "Kept %s: edited since it was written\n", path)
+	}
+}
+
+func mergeProfileVariables(variables, more []string) []string {
+	for _, variable := range more {
+		found := false
+		for _, existing := range variables {
+			if existing == variable {
+				found = true
+				break
+			}
+		}
+		if !found {
+			variables = append(variables, variable)
+		}
+	}
+	return variables
+}
+
+func profileStatePath() string {
+	return filepath.Join(config.DefaultVaultPath(), "cache", "profiles.json")
+}
+
+// defaultProfileShell picks the syntax of the user's login shell
+func defaultProfileShell() string {
+	if strings.HasSuffix(os.Getenv("SHELL"), "/fish") {
+		return profile.ShellFish
+	}
+	return profile.ShellSh
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/ipc"
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/proxy"
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var proxyConfigFile string
+
+// newProxyCommand creates the proxy command
+func newProxyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "proxy",
+		Short: "Run the capability-aware HTTP proxy",
+		Long: `Run a local reverse proxy in front of upstream APIs. Applications call
+http://localhost:8200/proxy/<upstream>/<path> with an X-Aether-Capability
+header instead of the upstream API key. The agent validates the capability
+and the proxy adds the credential read from the vault, so the application
+never holds it.
+
+The configuration file lists the upstreams:
+
+  listen: 127.0.0.1:8200
+  credential_ttl: 5m
+  upstreams:
+    - name: github
+      url: https://api.github.com
+      secret: <secret id>
+      header: Authorization        # default
+      format: "Bearer {value}"     # default
+      resource: proxy:/github      # default
+
+Capabilities need the upstream resource and the action for the request
+method: read (GET, HEAD, OPTIONS), delete (DELETE) or write (others).`,
+		RunE: runProxyCommand,
+	}
+
+	cmd.Flags().StringVar(&proxyConfigFile, "proxy-config", "", "Path to the proxy configuration file (required)")
+	cmd.MarkFlagRequired("proxy-config")
+
+	return cmd
+}
+
+// runProxyCommand executes the proxy command
+func runProxyCommand(cmd *cobra.Command, args []string) error {
+	config, err := proxy.LoadConfig(proxyConfigFile)
+	if err != nil {
+		return err
+	}
+
+	api, err := newServerAPIClient()
+	if err != nil {
+		return err
+	}
+
+	// Capabilities are validated by the local agent
+	client, err := ipc.NewClient(nil)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+	defer client.Close()
+
+	if err := client.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to agent: %w", err)
+	}
+
+	server, err := proxy.NewServer(config, client, proxy.NewVaultCredentials(api))
+	if err != nil {
+		return err
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.Start()
+	}()
+
+	fmt.Println(ui.Success(fmt.Sprintf("Proxy listening on http://%s/proxy/ (%d upstream(s))", config.Listen, len(config.Upstreams))))
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	select {
+	case err := <-errs:
+		return err
+	case <-signals:
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return server.Stop(ctx)
+}
@@ -46,8 +46,25 @@ Quick start:
 	cmd.AddCommand(newInitCommand())
 	cmd.AddCommand(newAuthCommand())
 	cmd.AddCommand(newStatusCommand())
+	cmd.AddCommand(newDoctorCommand())
 	cmd.AddCommand(newHelpCommand())
 	cmd.AddCommand(newCapabilityCommand())
+	cmd.AddCommand(newShareCommand())
+	cmd.AddCommand(newSecretCommand())
+	cmd.AddCommand(newKVCommand())
+	cmd.AddCommand(newPolicyCommand())
+	cmd.AddCommand(newNamespaceCommand())
+	cmd.AddCommand(newGenerateCommand())
+	cmd.AddCommand(newIdentityCommand())
+	cmd.AddCommand(newAuditCommand())
+	cmd.AddCommand(newProxyCommand())
+	cmd.AddCommand(newAgentCommand())
+	cmd.AddCommand(newKeyCommand())
+	cmd.AddCommand(newEscrowCommand())
+	cmd.AddCommand(newEventsCommand())
+	cmd.AddCommand(newNativeHostCommand())
+	cmd.AddCommand(newGitCredentialCommand())
+	cmd.AddCommand(newProfileCommand())
 
 	return cmd
 }
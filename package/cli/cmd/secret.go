@@ -0,0 +1,637 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdh"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/client"
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/config"
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/e2e"
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	secretValue       string
+	secretType        string
+	secretDescription string
+	secretFields      []string
+	secretEncrypt     bool
+	secretRecipients  []string
+	secretUsers       []string
+	secretNamespace   string
+	secretTTL         time.Duration
+	unusedDays        int
+	unusedAll         bool
+	unusedNotify      bool
+)
+
+// templateField mirrors a field of a server-side secret template
+type templateField struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Required    bool   `json:"required"`
+	Sensitive   bool   `json:"sensitive"`
+	Pattern     string `json:"pattern"`
+	Description string `json:"description"`
+}
+
+// secretTemplate mirrors a server-side secret template
+type secretTemplate struct {
+	ID          string          `json:"id"`
+	PathPattern string          `json:"path_pattern"`
+	Description string          `json:"description"`
+	Fields      []templateField `json:"fields"`
+}
+
+// newSecretCommand creates the secret command group
+func newSecretCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "secret",
+		Short: "Manage secrets on the Aether Vault server",
+		Long:  `Create and inspect secrets stored on the Aether Vault server.`,
+	}
+
+	cmd.AddCommand(newSecretCreateCommand())
+	cmd.AddCommand(newSecretGetCommand())
+	cmd.AddCommand(newSecretGrantCommand())
+	cmd.AddCommand(newSecretTemplatesCommand())
+	cmd.AddCommand(newSecretUnusedCommand())
+
+	return cmd
+}
+
+// newSecretCreateCommand creates the secret create command
+func newSecretCreateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "create [path]",
+		Short: "Create a secret",
+		Long: `Create a secret at the given path.
+
+When an administrator has attached a template to the path (for example
+kv/db/*), each template field is prompted for interactively unless it is
+supplied with --field.
+
+With --client-encrypt the value is encrypted on this machine for your key
+(see 'vault key generate') and any --recipient public keys; the server
+stores ciphertext it cannot decrypt. In a --namespace, every member with a
+registered key is a recipient.
+
+Examples:
+  vault secret create kv/db/orders
+  vault secret create kv/db/orders --field host=db1 --field port=5432
+  vault secret create api/stripe --value sk_live_...
+  vault secret create api/stripe --value sk_live_... --client-encrypt
+  vault secret create api/stripe --value sk_live_... --client-encrypt --namespace 3b2e...`,
+		Args: cobra.ExactArgs(1),
+		RunE: runSecretCreateCommand,
+	}
+
+	cmd.Flags().StringVar(&secretValue, "value", "", "Raw secret value (for paths without a template)")
+	cmd.Flags().StringVar(&secretType, "type", "other", "Secret type (password, api_key, token, certificate, other)")
+	cmd.Flags().StringVar(&secretDescription, "description", "", "Secret description")
+	cmd.Flags().StringArrayVar(&secretFields, "field", nil, "Template field value as name=value (repeatable)")
+	cmd.Flags().BoolVar(&secretEncrypt, "client-encrypt", false, "Encrypt the value locally so the server cannot read it")
+	cmd.Flags().StringArrayVar(&secretRecipients, "recipient", nil, "Public key that may also decrypt the value (repeatable)")
+	cmd.Flags().StringVar(&secretNamespace, "namespace", "", "Namespace ID to store the secret in")
+	cmd.Flags().DurationVar(&secretTTL, "ttl", 0, "Secret lifetime (default and max set by the mount)")
+	cmd.Flags().StringVar(&keyFile, "key-file", config.DefaultIdentityKeyPath(), "Path of your client encryption key")
+
+	return cmd
+}
+
+// newSecretGetCommand creates the secret get command
+func newSecretGetCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "get [id]",
+		Short: "Print a secret's value",
+		Long: `Print a secret's value. Client-encrypted values are decrypted on
+this machine with your client encryption key.
+
+With --namespace, read a client-encrypted secret another member of the
+namespace stored and wrapped for your key.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runSecretGetCommand,
+	}
+
+	cmd.Flags().StringVar(&secretNamespace, "namespace", "", "Namespace ID the secret belongs to")
+	cmd.Flags().StringVar(&keyFile, "key-file", config.DefaultIdentityKeyPath(), "Path of your client encryption key")
+
+	return cmd
+}
+
+// newSecretGrantCommand creates the secret grant command
+func newSecretGrantCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "grant [id]",
+		Short: "Give other keys access to a client-encrypted secret",
+		Long: `Wrap a client-encrypted secret's data key for more public keys.
+The value itself is not re-encrypted, and the server never sees the key.
+
+Examples:
+  vault secret grant 6f1c... --recipient <public key>
+  vault secret grant 6f1c... --user 91d0...`,
+		Args: cobra.ExactArgs(1),
+		RunE: runSecretGrantCommand,
+	}
+
+	cmd.Flags().StringArrayVar(&secretRecipients, "recipient", nil, "Public key to grant access (repeatable)")
+	cmd.Flags().StringArrayVar(&secretUsers, "user", nil, "User ID whose registered key gets access (repeatable)")
+	cmd.Flags().StringVar(&keyFile, "key-file", config.DefaultIdentityKeyPath(), "Path of your client encryption key")
+	cmd.MarkFlagsOneRequired("recipient", "user")
+
+	return cmd
+}
+
+// newSecretTemplatesCommand creates the secret templates command
+func newSecretTemplatesCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "templates",
+		Short: "List secret templates",
+		Long:  `List the templates that constrain secrets written under path patterns.`,
+		RunE:  runSecretTemplatesCommand,
+	}
+}
+
+// runSecretCreateCommand executes the secret create command
+func runSecretCreateCommand(cmd *cobra.Command, args []string) error {
+	path := args[0]
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	api, err := client.NewAPIClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	var template secretTemplate
+	err = api.Do(ctx, http.MethodGet, "/templates/match?path="+url.QueryEscape(path), nil, &template)
+
+	var apiErr *client.APIError
+	hasTemplate := err == nil
+	if err != nil && !(errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound) {
+		return fmt.Errorf("failed to look up template: %w", err)
+	}
+
+	value := secretValue
+	if hasTemplate {
+		if value != "" {
+			return fmt.Errorf("path %s uses template %s; supply fields with --field instead of --value", path, template.PathPattern)
+		}
+
+		value, err = promptTemplateFields(&template)
+		if err != nil {
+			return err
+		}
+	} else if value == "" {
+		return fmt.Errorf("--value is required for paths without a template")
+	}
+
+	if secretEncrypt {
+		value, err = sealForRecipients(api, value)
+		if err != nil {
+			return err
+		}
+	} else if len(secretRecipients) > 0 {
+		return fmt.Errorf("--recipient requires --client-encrypt")
+	}
+
+	request := map[string]interface{}{
+		"name":             path,
+		"description":      secretDescription,
+		"value":            value,
+		"type":             secretType,
+		"client_encrypted": secretEncrypt,
+	}
+	if secretNamespace != "" {
+		request["namespace_id"] = secretNamespace
+	}
+	if secretTTL > 0 {
+		request["ttl"] = int(secretTTL.Seconds())
+	}
+
+	var created struct {
+		ID  string         `json:"id"`
+		TTL *ttlResolution `json:"ttl"`
+	}
+	if err := api.Do(ctx, http.MethodPost, "/secrets", request, &created); err != nil {
+		if errors.As(err, &apiErr) && len(apiErr.Violations) > 0 {
+			fmt.Fprintln(os.Stderr, ui.Error("Secret rejected by template:"))
+			for _, violation := range apiErr.Violations {
+				fmt.Fprintf(os.Stderr, "  - %s\n", violation)
+			}
+		}
+		return fmt.Errorf("failed to create secret: %w", err)
+	}
+
+	fmt.Println(ui.Success(fmt.Sprintf("Secret %s created (%s)", path, created.ID)))
+	if created.TTL != nil && created.TTL.ExpiresAt != nil {
+		fmt.Fprintf(os.Stderr, "Expires at %s (%s)\n", created.TTL.ExpiresAt.Format(time.RFC3339), created.TTL.describe())
+	}
+	return nil
+}
+
+// ttlResolution mirrors how the server resolved a TTL against the mount
+type ttlResolution struct {
+	Requested  *int       `json:"requested_ttl"`
+	DefaultTTL int        `json:"default_ttl"`
+	MaxTTL     int        `json:"max_ttl"`
+	Effective  int        `json:"effective_ttl"`
+	Source     string     `json:"source"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+// describe says where the effective TTL came from
+func (r *ttlResolution) describe() string {
+	ttl := time.Duration(r.Effective) * time.Second
+	switch r.Source {
+	case "request":
+		return fmt.Sprintf("requested %s", ttl)
+	case "mount_default", "mount_max":
+		return fmt.Sprintf("%s from the mount's %s", ttl, strings.TrimPrefix(r.Source, "mount_"))
+	default:
+		return fmt.Sprintf("%s, %s", ttl, strings.ReplaceAll(r.Source, "_", " "))
+	}
+}
+
+// runSecretGetCommand executes the secret get command
+func runSecretGetCommand(cmd *cobra.Command, args []string) error {
+	api, err := newServerAPIClient()
+	if err != nil {
+		return err
+	}
+
+	path := "/secrets/" + url.PathEscape(args[0]) + "/value"
+	if secretNamespace != "" {
+		path = "/namespaces/" + url.PathEscape(secretNamespace) + "/secrets/" + url.PathEscape(args[0]) + "/envelope"
+	}
+	var secret secretValueResponse
+	if err := api.Do(context.Background(), http.MethodGet, path, nil, &secret); err != nil {
+		return fmt.Errorf("failed to get secret: %w", err)
+	}
+
+	value := secret.Value
+	if secret.ClientEncrypted {
+		identity, err := e2e.LoadIdentity(keyFile)
+		if err != nil {
+			return err
+		}
+		plaintext, err := identity.Open(secret.Value)
+		if err != nil {
+			return err
+		}
+		value = string(plaintext)
+	}
+
+	fmt.Print(value)
+	if !strings.HasSuffix(value, "\n") {
+		fmt.Println()
+	}
+	return nil
+}
+
+// runSecretGrantCommand executes the secret grant command
+func runSecretGrantCommand(cmd *cobra.Command, args []string) error {
+	identity, err := e2e.LoadIdentity(keyFile)
+	if err != nil {
+		return err
+	}
+	api, err := newServerAPIClient()
+	if err != nil {
+		return err
+	}
+
+	recipients, err := parseRecipients(secretRecipients)
+	if err != nil {
+		return err
+	}
+	for _, userID := range secretUsers {
+		key, err := fetchUserKey(api, userID)
+		if err != nil {
+			return err
+		}
+		recipients = append(recipients, key)
+	}
+
+	var secret secretValueResponse
+	if err := api.Do(context.Background(), http.MethodGet, "/secrets/"+url.PathEscape(args[0])+"/value", nil, &secret); err != nil {
+		return fmt.Errorf("failed to get secret: %w", err)
+	}
+	if !secret.ClientEncrypted {
+		return fmt.Errorf("secret %s is not client-encrypted; share it with 'vault share' instead", args[0])
+	}
+
+	sealed, err := identity.Grant(secret.Value, recipients...)
+	if err != nil {
+		return err
+	}
+
+	request := map[string]interface{}{
+		"value":            sealed,
+		"client_encrypted": true,
+	}
+	if err := api.Do(context.Background(), http.MethodPut, "/secrets/"+url.PathEscape(args[0]), request, nil); err != nil {
+		return fmt.Errorf("failed to update secret: %w", err)
+	}
+
+	ids, err := e2e.Recipients(sealed)
+	if err != nil {
+		return err
+	}
+	fmt.Println(ui.Success(fmt.Sprintf("Secret %s can be opened by %d keys: %s", secret.Name, len(ids), strings.Join(ids, ", "))))
+	return nil
+}
+
+// secretValueResponse mirrors the server's secret value response
+type secretValueResponse struct {
+	ID              string     `json:"id"`
+	Name            string     `json:"name"`
+	Value           string     `json:"value"`
+	ExpiresAt       *time.Time `json:"expires_at,omitempty"`
+	ClientEncrypted bool       `json:"client_encrypted"`
+}
+
+// fetchUserKey looks up the public key a user registered with the server
+func fetchUserKey(api *client.APIClient, userID string) (*ecdh.PublicKey, error) {
+	var key teamMemberKey
+	if err := api.Do(context.Background(), http.MethodGet, "/users/"+url.PathEscape(userID)+"/key", nil, &key); err != nil {
+		return nil, fmt.Errorf("failed to get key of user %s: %w", userID, err)
+	}
+	return e2e.ParsePublicKey(key.PublicKey)
+}
+
+// sealForRecipients encrypts value for the local key, --recipient keys
+// and, with --namespace, every member's registered key
+func sealForRecipients(api *client.APIClient, value string) (string, error) {
+	identity, err := e2e.LoadIdentity(keyFile)
+	if err != nil {
+		return "", err
+	}
+	recipients, err := parseRecipients(secretRecipients)
+	if err != nil {
+		return "", err
+	}
+
+	if secretNamespace != "" {
+		var response struct {
+			Members []teamMemberKey `json:"members"`
+		}
+		if err := api.Do(context.Background(), http.MethodGet, "/namespaces/"+url.PathEscape(secretNamespace)+"/member-keys", nil, &response); err != nil {
+			return "", fmt.Errorf("failed to get namespace member keys: %w", err)
+		}
+		members, err := memberPublicKeys(response.Members)
+		if err != nil {
+			return "", err
+		}
+		recipients = append(recipients, members...)
+	}
+
+	return e2e.Seal([]byte(value), append([]*ecdh.PublicKey{identity.PublicKey()}, recipients...)...)
+}
+
+func parseRecipients(encoded []string) ([]*ecdh.PublicKey, error) {
+	keys := make([]*ecdh.PublicKey, 0, len(encoded))
+	for _, value := range encoded {
+		key, err := e2e.ParsePublicKey(value)
+		if err != nil {
+			return nil, fmt.Errorf("--recipient %q: %w", value, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// runSecretTemplatesCommand executes the secret templates command
+func runSecretTemplatesCommand(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	api, err := client.NewAPIClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Templates []secretTemplate `json:"templates"`
+	}
+	if err := api.Do(context.Background(), http.MethodGet, "/templates", nil, &response); err != nil {
+		return fmt.Errorf("failed to list templates: %w", err)
+	}
+
+	if len(response.Templates) == 0 {
+		fmt.Println("No secret templates defined")
+		return nil
+	}
+
+	for _, template := range response.Templates {
+		fmt.Printf("%s\n", ui.BoldText(template.PathPattern))
+		if template.Description != "" {
+			fmt.Printf("  %s\n", template.Description)
+		}
+		for _, field := range template.Fields {
+			required := ""
+			if field.Required {
+				required = " (required)"
+			}
+			fmt.Printf("  - %s: %s%s\n", field.Name, field.Type, required)
+		}
+	}
+
+	return nil
+}
+
+// unusedSecret mirrors an entry of the server's unused secrets report
+type unusedSecret struct {
+	ID             string     `json:"id"`
+	Name           string     `json:"name"`
+	UserID         string     `json:"user_id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	LastAccessedAt *time.Time `json:"last_accessed_at"`
+	Reads          int64      `json:"reads"`
+}
+
+type unusedSecretsReport struct {
+	Days    int            `json:"days"`
+	Secrets []unusedSecret `json:"secrets"`
+	Owners  int            `json:"owners"`
+}
+
+// newSecretUnusedCommand creates the secret unused command
+func newSecretUnusedCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "unused",
+		Short: "List secrets nobody has read recently",
+		Long: `List secrets whose value has not been read in the last --days days.
+Secrets never read count from their creation.
+
+With --all, report every owner's secrets (requires the
+sys/reports/unused-secrets policy); --notify additionally emails each owner
+the list of their unused secrets.
+
+Examples:
+  vault secret unused
+  vault secret unused --days 180 --all
+  vault secret unused --days 180 --notify`,
+		Args: cobra.NoArgs,
+		RunE: runSecretUnusedCommand,
+	}
+
+	cmd.Flags().IntVar(&unusedDays, "days", 90, "Days without a read")
+	cmd.Flags().BoolVar(&unusedAll, "all", false, "Report every owner's secrets")
+	cmd.Flags().BoolVar(&unusedNotify, "notify", false, "Notify owners of their unused secrets (implies --all)")
+
+	return cmd
+}
+
+func runSecretUnusedCommand(cmd *cobra.Command, args []string) error {
+	if unusedDays < 1 {
+		return fmt.Errorf("--days must be at least 1")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	api, err := client.NewAPIClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	var report unusedSecretsReport
+	switch {
+	case unusedNotify:
+		err = api.Do(context.Background(), http.MethodPost, "/sys/reports/unused-secrets/notify", map[string]int{"days": unusedDays}, &report)
+	case unusedAll:
+		err = api.Do(context.Background(), http.MethodGet, "/sys/reports/unused-secrets?days="+strconv.Itoa(unusedDays), nil, &report)
+	default:
+		err = api.Do(context.Background(), http.MethodGet, "/secrets/unused?days="+strconv.Itoa(unusedDays), nil, &report)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get unused secrets: %w", err)
+	}
+
+	if len(report.Secrets) == 0 {
+		fmt.Printf("No secrets unused for %d days\n", report.Days)
+		return nil
+	}
+
+	for _, secret := range report.Secrets {
+		lastRead := "never"
+		if secret.LastAccessedAt != nil {
+			lastRead = secret.LastAccessedAt.Local().Format("2006-01-02")
+		}
+		owner := ""
+		if unusedAll || unusedNotify {
+			owner = "  owner " + secret.UserID
+		}
+		fmt.Printf("%s  %s  last read %s  reads %d%s\n", ui.BoldText(secret.Name), secret.ID, lastRead, secret.Reads, owner)
+	}
+
+	if unusedNotify {
+		fmt.Println(ui.Success(fmt.Sprintf("Notified %d owners of %d unused secrets", report.Owners, len(report.Secrets))))
+	}
+
+	return nil
+}
+
+// promptTemplateFields collects template fields from --field flags and
+// interactive prompts, returning the JSON-encoded value
+func promptTemplateFields(template *secretTemplate) (string, error) {
+	provided := make(map[string]string)
+	for _, field := range secretFields {
+		name, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return "", fmt.Errorf("invalid --field %q, expected name=value", field)
+		}
+		provided[name] = value
+	}
+
+	fmt.Fprintf(os.Stderr, "Using template %s\n", ui.BoldText(template.PathPattern))
+
+	reader := bufio.NewReader(os.Stdin)
+	data := make(map[string]interface{})
+
+	for _, field := range template.Fields {
+		raw, ok := provided[field.Name]
+		if !ok {
+			label := field.Name
+			if field.Description != "" {
+				label = fmt.Sprintf("%s (%s)", field.Name, field.Description)
+			}
+			if !field.Required {
+				label += " [optional]"
+			}
+			fmt.Fprintf(os.Stderr, "%s: ", label)
+
+			line, err := reader.ReadString('\n')
+			if err != nil && line == "" {
+				return "", fmt.Errorf("failed to read %s: %w", field.Name, err)
+			}
+			raw = strings.TrimRight(line, "\r\n")
+		}
+
+		if raw == "" {
+			if field.Required {
+				return "", fmt.Errorf("%s is required", field.Name)
+			}
+			continue
+		}
+
+		value, err := convertFieldValue(field, raw)
+		if err != nil {
+			return "", err
+		}
+		data[field.Name] = value
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode secret value: %w", err)
+	}
+
+	return string(encoded), nil
+}
+
+// convertFieldValue parses a prompted value into the field's JSON type
+func convertFieldValue(field templateField, raw string) (interface{}, error) {
+	switch field.Type {
+	case "integer":
+		value, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be an integer", field.Name)
+		}
+		return value, nil
+	case "number":
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a number", field.Name)
+		}
+		return value, nil
+	case "boolean":
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be true or false", field.Name)
+		}
+		return value, nil
+	default:
+		return raw, nil
+	}
+}
@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/client"
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	shareSecretID string
+	shareTTL      time.Duration
+)
+
+// newShareCommand creates the share command group
+func newShareCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "share [value]",
+		Short: "Create a one-time secret sharing link",
+		Long: `Create a single-use, expiring link for a secret value.
+
+The value is encrypted with a random key that only exists in the link's
+URL fragment; the server stores ciphertext it cannot decrypt. The link can
+be opened exactly once.
+
+Examples:
+  vault share --secret-id 6f1c...        Share a stored secret
+  echo -n 's3cr3t' | vault share          Share a value from stdin
+  vault share open <url>                  Open a shared link`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runShareCreateCommand,
+	}
+
+	cmd.Flags().StringVar(&shareSecretID, "secret-id", "", "ID of a stored secret to share")
+	cmd.Flags().DurationVar(&shareTTL, "ttl", 0, "Link lifetime (default 24h, max 168h, or the limits of the secret's mount)")
+
+	cmd.AddCommand(newShareOpenCommand())
+
+	return cmd
+}
+
+// newShareOpenCommand creates the share open command
+func newShareOpenCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "open [url]",
+		Short: "Open a one-time sharing link",
+		Long: `Fetch and decrypt a one-time sharing link. The link is consumed
+by this call and cannot be opened again.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runShareOpenCommand,
+	}
+}
+
+// runShareCreateCommand executes the share command
+func runShareCreateCommand(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	api, err := client.NewAPIClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	request := map[string]interface{}{}
+	if shareTTL > 0 {
+		request["ttl"] = int(shareTTL.Seconds())
+	}
+
+	switch {
+	case shareSecretID != "":
+		request["secret_id"] = shareSecretID
+	case len(args) == 1:
+		request["value"] = args[0]
+	default:
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read value from stdin: %w", err)
+		}
+		request["value"] = string(data)
+	}
+
+	var response struct {
+		ID        string         `json:"id"`
+		URL       string         `json:"url"`
+		ExpiresAt time.Time      `json:"expires_at"`
+		TTL       *ttlResolution `json:"ttl"`
+	}
+
+	if err := api.Do(context.Background(), http.MethodPost, "/share", request, &response); err != nil {
+		return fmt.Errorf("failed to create share link: %w", err)
+	}
+
+	fmt.Printf("%s\n", response.URL)
+	fmt.Fprintf(os.Stderr, "Link expires at %s and can be opened once.\n", response.ExpiresAt.Format(time.RFC3339))
+	if response.TTL != nil {
+		fmt.Fprintf(os.Stderr, "Lifetime: %s\n", response.TTL.describe())
+	}
+
+	return nil
+}
+
+// runShareOpenCommand executes the share open command
+func runShareOpenCommand(cmd *cobra.Command, args []string) error {
+	link, err := url.Parse(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid link: %w", err)
+	}
+
+	key, err := base64.RawURLEncoding.DecodeString(link.Fragment)
+	if err != nil || len(key) != 32 {
+		return fmt.Errorf("link is missing its decryption key fragment")
+	}
+	link.Fragment = ""
+
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.Defaults()
+	}
+	cfg.Cloud.URL = fmt.Sprintf("%s://%s", link.Scheme, link.Host)
+
+	api, err := client.NewAPIClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Ciphertext string `json:"ciphertext"`
+	}
+	if err := api.Do(context.Background(), http.MethodGet, link.String(), nil, &response); err != nil {
+		return fmt.Errorf("failed to open share link: %w", err)
+	}
+
+	value, err := openShareValue(response.Ciphertext, key)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt shared value: %w", err)
+	}
+
+	fmt.Print(value)
+	if !strings.HasSuffix(value, "\n") {
+		fmt.Println()
+	}
+
+	return nil
+}
+
+// openShareValue decrypts a nonce-prefixed AES-256-GCM share payload
+func openShareValue(ciphertext string, key []byte) (string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return "", fmt.Errorf("ciphertext too short")
+	}
+
+	nonce, sealed := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
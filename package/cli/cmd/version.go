@@ -1,8 +1,12 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"runtime"
+	"sort"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -24,11 +28,15 @@ func newVersionCommand() *cobra.Command {
   - CLI version
   - Build information
   - Runtime environment
-  - System architecture`,
+  - System architecture
+
+With --server, also show the build of the server the CLI is configured
+for, to match an incident with the exact release running.`,
 		RunE: runVersionCommand,
 	}
 
 	cmd.Flags().String("format", "table", "Output format (json, yaml, table)")
+	cmd.Flags().Bool("server", false, "Also show the server build")
 
 	return cmd
 }
@@ -49,14 +57,86 @@ func runVersionCommand(cmd *cobra.Command, args []string) error {
 	}
 
 	// Output based on format
+	var err error
 	switch format {
 	case "json":
-		return outputJSON(versionInfo)
+		err = outputJSON(versionInfo)
 	case "yaml":
-		return outputYAML(versionInfo)
+		err = outputYAML(versionInfo)
 	default:
-		return outputVersionTable(versionInfo)
+		err = outputVersionTable(versionInfo)
+	}
+	if err != nil {
+		return err
+	}
+
+	if server, _ := cmd.Flags().GetBool("server"); server {
+		return outputServerVersion()
 	}
+	return nil
+}
+
+// serverBuildInfo is the response of GET /sys/version
+type serverBuildInfo struct {
+	Version      string          `json:"version"`
+	GitCommit    string          `json:"git_commit"`
+	BuildDate    string          `json:"build_date"`
+	GoVersion    string          `json:"go_version"`
+	Platform     string          `json:"platform"`
+	Features     []string        `json:"features"`
+	Modified     bool            `json:"modified"`
+	FeatureFlags map[string]bool `json:"feature_flags"`
+}
+
+// outputServerVersion fetches and prints the server build
+func outputServerVersion() error {
+	api, err := newServerAPIClient()
+	if err != nil {
+		return err
+	}
+
+	var info serverBuildInfo
+	if err := api.Do(context.Background(), http.MethodGet, "/sys/version", nil, &info); err != nil {
+		return fmt.Errorf("failed to get server version: %w", err)
+	}
+
+	commit := info.GitCommit
+	if info.Modified {
+		commit += " (modified)"
+	}
+	features := "none"
+	if len(info.Features) > 0 {
+		features = strings.Join(info.Features, ", ")
+	}
+
+	fmt.Printf("\nAether Vault Server\n")
+	fmt.Printf("===================\n\n")
+	fmt.Printf("Version:     %s\n", info.Version)
+	fmt.Printf("Build:       %s\n", commit)
+	fmt.Printf("Built:       %s\n", info.BuildDate)
+	fmt.Printf("Go Version:  %s\n", info.GoVersion)
+	fmt.Printf("OS/Arch:     %s\n", info.Platform)
+	fmt.Printf("Features:    %s\n", features)
+
+	if len(info.FeatureFlags) > 0 {
+		names := make([]string, 0, len(info.FeatureFlags))
+		for name := range info.FeatureFlags {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		flags := make([]string, 0, len(names))
+		for _, name := range names {
+			state := "off"
+			if info.FeatureFlags[name] {
+				state = "on"
+			}
+			flags = append(flags, name+"="+state)
+		}
+		fmt.Printf("Flags:       %s\n", strings.Join(flags, " "))
+	}
+
+	return nil
 }
 
 // outputVersionTable displays version info in table format
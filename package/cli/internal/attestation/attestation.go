@@ -0,0 +1,172 @@
+package attestation
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-vault/package/cli/pkg/types"
+)
+
+// DefaultMaxAge is the default maximum evidence age in seconds
+const DefaultMaxAge = 60
+
+// QuoteVerifier verifies hardware-backed attestation quotes
+type QuoteVerifier interface {
+	// Verify checks the quote signature and returns the attested measurement
+	Verify(quote []byte, nonce string) (string, error)
+}
+
+// Verifier validates attestation evidence against capability constraints
+type Verifier struct {
+	// Hardware quote verifiers by type
+	quoteVerifiers map[types.AttestationType]QuoteVerifier
+
+	// Verifier mutex
+	mutex sync.RWMutex
+
+	// Clock, overridable for replay checks
+	now func() time.Time
+}
+
+// NewVerifier creates a new attestation verifier
+func NewVerifier() *Verifier {
+	return &Verifier{
+		quoteVerifiers: make(map[types.AttestationType]QuoteVerifier),
+		now:            time.Now,
+	}
+}
+
+// RegisterQuoteVerifier registers a verifier for TPM or SEV quotes
+func (v *Verifier) RegisterQuoteVerifier(attestationType types.AttestationType, verifier QuoteVerifier) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	v.quoteVerifiers[attestationType] = verifier
+}
+
+// Verify checks that evidence is fresh and matches the constraint
+func (v *Verifier) Verify(constraint *types.AttestationConstraint, evidence *types.AttestationEvidence) error {
+	if constraint == nil {
+		return nil
+	}
+
+	if evidence == nil {
+		return fmt.Errorf("attestation evidence required")
+	}
+
+	if evidence.Type != constraint.Type {
+		return fmt.Errorf("attestation type mismatch: %s (expected %s)", evidence.Type, constraint.Type)
+	}
+
+	// Check freshness
+	maxAge := constraint.MaxAge
+	if maxAge <= 0 {
+		maxAge = DefaultMaxAge
+	}
+	age := v.now().Sub(evidence.CollectedAt)
+	if evidence.CollectedAt.IsZero() || age > time.Duration(maxAge)*time.Second {
+		return fmt.Errorf("attestation evidence is stale: collected %s ago (max %ds)", age.Round(time.Second), maxAge)
+	}
+	if age < -30*time.Second {
+		return fmt.Errorf("attestation evidence is dated in the future")
+	}
+
+	measurement := evidence.Measurement
+
+	switch constraint.Type {
+	case types.AttestationImageDigest, types.AttestationBinaryHash:
+		// Software measurements are compared directly
+	case types.AttestationTPMQuote, types.AttestationSEVReport:
+		v.mutex.RLock()
+		quoteVerifier, exists := v.quoteVerifiers[constraint.Type]
+		v.mutex.RUnlock()
+
+		if !exists {
+			return fmt.Errorf("no quote verifier registered for %s", constraint.Type)
+		}
+		if len(evidence.Quote) == 0 {
+			return fmt.Errorf("attestation quote required for %s", constraint.Type)
+		}
+
+		attested, err := quoteVerifier.Verify(evidence.Quote, evidence.Nonce)
+		if err != nil {
+			return fmt.Errorf("quote verification failed: %w", err)
+		}
+		measurement = attested
+	default:
+		return fmt.Errorf("unsupported attestation type: %s", constraint.Type)
+	}
+
+	if !MeasurementsEqual(measurement, constraint.Measurement) {
+		return fmt.Errorf("workload measurement does not match constraint")
+	}
+
+	return nil
+}
+
+// MeasurementsEqual compares two measurements in constant time, ignoring
+// case and an optional "sha256:" prefix
+func MeasurementsEqual(a, b string) bool {
+	a = normalizeMeasurement(a)
+	b = normalizeMeasurement(b)
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// CollectBinaryHash builds evidence from the SHA-256 of an executable
+func CollectBinaryHash(path string) (*types.AttestationEvidence, error) {
+	if path == "" {
+		executable, err := os.Executable()
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve executable: %w", err)
+		}
+		path = executable
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open binary: %w", err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return nil, fmt.Errorf("failed to hash binary: %w", err)
+	}
+
+	return &types.AttestationEvidence{
+		Type:        types.AttestationBinaryHash,
+		Measurement: "sha256:" + hex.EncodeToString(hash.Sum(nil)),
+		CollectedAt: time.Now(),
+	}, nil
+}
+
+// CollectImageDigest builds evidence from the container image digest
+// exposed by the runtime (AETHER_IMAGE_DIGEST)
+func CollectImageDigest() (*types.AttestationEvidence, error) {
+	digest := os.Getenv("AETHER_IMAGE_DIGEST")
+	if digest == "" {
+		return nil, fmt.Errorf("AETHER_IMAGE_DIGEST is not set")
+	}
+
+	if idx := strings.LastIndex(digest, "@"); idx != -1 {
+		digest = digest[idx+1:]
+	}
+
+	return &types.AttestationEvidence{
+		Type:        types.AttestationImageDigest,
+		Measurement: digest,
+		CollectedAt: time.Now(),
+	}, nil
+}
+
+// normalizeMeasurement lowercases and strips the digest algorithm prefix
+func normalizeMeasurement(measurement string) string {
+	measurement = strings.ToLower(strings.TrimSpace(measurement))
+	return strings.TrimPrefix(measurement, "sha256:")
+}
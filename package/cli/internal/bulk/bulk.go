@@ -0,0 +1,197 @@
+// Package bulk runs CLI operations over many items with a worker pool,
+// collecting per-item errors and recording finished items in a state file
+// so an interrupted run can resume where it stopped.
+package bulk
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/ui"
+)
+
+// DefaultConcurrency is the number of workers when none is configured
+const DefaultConcurrency = 4
+
+// stateHeader starts every state file, followed by the operation name
+const stateHeader = "# vault bulk state: "
+
+// Options configure a bulk run
+type Options struct {
+	// Concurrency is the number of items processed at once
+	Concurrency int
+	// StateFile, when set, records each finished item; items listed in it
+	// by an earlier run of the same operation are skipped
+	StateFile string
+	// Operation names the run in the state file, e.g. "kv import secret/app/"
+	Operation string
+	// Label is shown next to the progress bar
+	Label string
+}
+
+// Failure is an item that failed
+type Failure struct {
+	Key string
+	Err error
+}
+
+// Result summarizes a run
+type Result struct {
+	Total int
+	// Resumed items were finished by an earlier run
+	Resumed  int
+	Done     int
+	Failures []Failure
+}
+
+// Err returns an error naming the failed item count, or nil
+func (r *Result) Err() error {
+	if len(r.Failures) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d of %d items failed", len(r.Failures), r.Total)
+}
+
+// Run calls fn for every key on Concurrency workers. A failing item does
+// not stop the others; cancelling ctx stops handing out new ones. The
+// state file is removed once every item succeeded.
+func Run(ctx context.Context, keys []string, opts Options, fn func(ctx context.Context, key string) error) (*Result, error) {
+	if opts.Concurrency < 1 {
+		opts.Concurrency = DefaultConcurrency
+	}
+	result := &Result{Total: len(keys)}
+
+	state, finished, err := openState(opts.StateFile, opts.Operation)
+	if err != nil {
+		return nil, err
+	}
+	if state != nil {
+		defer state.Close()
+	}
+
+	pending := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if finished[key] {
+			result.Resumed++
+			continue
+		}
+		pending = append(pending, key)
+	}
+
+	progress := ui.NewProgress(opts.Label, len(keys))
+	progress.Add(result.Resumed, 0)
+
+	var mu sync.Mutex
+	work := make(chan string)
+	var workers sync.WaitGroup
+	for i := 0; i < opts.Concurrency; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for key := range work {
+				err := fn(ctx, key)
+
+				mu.Lock()
+				if err != nil {
+					result.Failures = append(result.Failures, Failure{Key: key, Err: err})
+				} else {
+					result.Done++
+					if state != nil {
+						fmt.Fprintln(state, key)
+					}
+				}
+				mu.Unlock()
+
+				if err != nil {
+					progress.Add(1, 1)
+				} else {
+					progress.Add(1, 0)
+				}
+			}
+		}()
+	}
+
+dispatch:
+	for _, key := range pending {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case work <- key:
+		}
+	}
+	close(work)
+	workers.Wait()
+	progress.Finish()
+
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+	if len(result.Failures) == 0 && opts.StateFile != "" {
+		state.Close()
+		state = nil
+		if err := os.Remove(opts.StateFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return result, fmt.Errorf("failed to remove state file: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// PrintFailures lists failed items on stderr
+func (r *Result) PrintFailures() {
+	for _, failure := range r.Failures {
+		fmt.Fprintf(os.Stderr, "  - %s: %v\n", failure.Key, failure.Err)
+	}
+}
+
+// openState reads the items an earlier run finished and opens the file
+// for appending. A state file of another operation is refused rather
+// than silently skipping unrelated items.
+func openState(path, operation string) (*os.File, map[string]bool, error) {
+	finished := map[string]bool{}
+	if path == "" {
+		return nil, finished, nil
+	}
+
+	existing, err := os.Open(path)
+	switch {
+	case err == nil:
+		scanner := bufio.NewScanner(existing)
+		first := true
+		for scanner.Scan() {
+			line := scanner.Text()
+			if first {
+				first = false
+				if recorded, ok := strings.CutPrefix(line, stateHeader); !ok || recorded != operation {
+					existing.Close()
+					return nil, nil, fmt.Errorf("state file %s belongs to another operation", path)
+				}
+				continue
+			}
+			if line != "" {
+				finished[line] = true
+			}
+		}
+		existing.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, nil, fmt.Errorf("failed to read state file: %w", err)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open state file: %w", err)
+	}
+	if len(finished) == 0 {
+		if info, err := file.Stat(); err == nil && info.Size() == 0 {
+			fmt.Fprintln(file, stateHeader+operation)
+		}
+	}
+	return file, finished, nil
+}
@@ -41,6 +41,9 @@ type Auditor struct {
 
 	// Running state
 	running bool
+
+	// Enrichment pipeline, in order
+	enrichers []Enricher
 }
 
 // AuditConfig represents audit configuration
@@ -89,6 +92,16 @@ type AuditConfig struct {
 
 	// SIEM format (json, syslog,cef)
 	SIEMFormat string `json:"siemFormat,omitempty"`
+
+	// Enrichers run in order on each event (geoip, container, user,
+	// threat_intel, or any registered with RegisterEnricher)
+	Enrichers []string `json:"enrichers,omitempty"`
+
+	// GeoIP database: CSV of network,country[,region[,city]]
+	GeoIPDatabase string `json:"geoipDatabase,omitempty"`
+
+	// Threat-intel feed: one "network tag[,tag...]" per line
+	ThreatIntelFile string `json:"threatIntelFile,omitempty"`
 }
 
 // AuditEvent represents an audit event
@@ -147,6 +160,9 @@ type AuditEvent struct {
 	// Additional context
 	Context map[string]interface{} `json:"context,omitempty"`
 
+	// Context added by the enrichment pipeline
+	Enrichment map[string]interface{} `json:"enrichment,omitempty"`
+
 	// Event hash (for integrity)
 	Hash string `json:"hash,omitempty"`
 
@@ -263,6 +279,13 @@ func NewAuditor(config *AuditConfig) (*Auditor, error) {
 		shutdown:      make(chan struct{}),
 	}
 
+	// Build enrichment pipeline
+	enrichers, err := buildEnrichers(config)
+	if err != nil {
+		return nil, err
+	}
+	auditor.enrichers = enrichers
+
 	// Open log file
 	if config.EnableLogging {
 		if err := auditor.openLogFile(); err != nil {
@@ -331,6 +354,11 @@ func (a *Auditor) LogEvent(event *AuditEvent) error {
 		event.Timestamp = time.Now()
 	}
 
+	// Enrich before hashing so the hash covers the enrichment
+	for _, enricher := range a.enrichers {
+		enricher.Enrich(event)
+	}
+
 	// Generate hash
 	if err := a.generateHash(event); err != nil {
 		return fmt.Errorf("failed to generate hash: %w", err)
@@ -461,7 +489,7 @@ func (a *Auditor) LogCapabilityRevocation(capabilityID, reason, revokedBy string
 }
 
 // LogPolicyEvaluation logs a policy evaluation
-func (a *Auditor) LogPolicyEvaluation(request *types.CapabilityRequest, result *capability.PolicyResult, clientInfo *ClientInfo) error {
+func (a *Auditor) LogPolicyEvaluation(request *types.CapabilityRequest, result *PolicyResult, clientInfo *ClientInfo) error {
 	severity := "info"
 	if result.Decision == "deny" {
 		severity = "warning"
@@ -614,6 +642,9 @@ func (a *Auditor) generateHash(event *AuditEvent) error {
 		"target":    event.TargetResource,
 		"action":    event.Action,
 	}
+	if len(event.Enrichment) > 0 {
+		hashData["enrichment"] = event.Enrichment
+	}
 
 	// Serialize and hash
 	data, _ := json.Marshal(hashData)
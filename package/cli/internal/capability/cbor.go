@@ -0,0 +1,374 @@
+package capability
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// CBOR major types (RFC 8949)
+const (
+	cborUnsigned = 0
+	cborNegative = 1
+	cborBytes    = 2
+	cborText     = 3
+	cborArray    = 4
+	cborMap      = 5
+	cborTagged   = 6
+	cborSimple   = 7
+)
+
+// cborMaxDepth bounds nesting when decoding untrusted tokens
+const cborMaxDepth = 16
+
+// cborTag is a tagged CBOR item
+type cborTag struct {
+	Number  uint64
+	Content interface{}
+}
+
+// cborEntry is one key/value pair of a map with mixed key types
+type cborEntry struct {
+	Key   interface{}
+	Value interface{}
+}
+
+// cborEncode serializes v with the core deterministic encoding: shortest
+// arguments, definite lengths and map keys sorted by their encoded bytes.
+// Supported values are integers, strings, byte strings, booleans, nil,
+// float64, slices, map[string]interface{}, []cborEntry and cborTag.
+func cborEncode(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := cborWrite(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func cborWrite(buf *bytes.Buffer, v interface{}) error {
+	switch value := v.(type) {
+	case nil:
+		buf.WriteByte(0xf6)
+	case bool:
+		if value {
+			buf.WriteByte(0xf5)
+		} else {
+			buf.WriteByte(0xf4)
+		}
+	case int:
+		cborWriteInt(buf, int64(value))
+	case int64:
+		cborWriteInt(buf, value)
+	case uint64:
+		cborWriteHead(buf, cborUnsigned, value)
+	case float64:
+		// Whole numbers are integers so that JSON-decoded numbers encode
+		// the same way on every side
+		if value == math.Trunc(value) && math.Abs(value) < 1<<53 {
+			cborWriteInt(buf, int64(value))
+			return nil
+		}
+		buf.WriteByte(0xfb)
+		var bits [8]byte
+		binary.BigEndian.PutUint64(bits[:], math.Float64bits(value))
+		buf.Write(bits[:])
+	case string:
+		cborWriteHead(buf, cborText, uint64(len(value)))
+		buf.WriteString(value)
+	case []byte:
+		cborWriteHead(buf, cborBytes, uint64(len(value)))
+		buf.Write(value)
+	case []string:
+		cborWriteHead(buf, cborArray, uint64(len(value)))
+		for _, item := range value {
+			cborWrite(buf, item)
+		}
+	case []interface{}:
+		cborWriteHead(buf, cborArray, uint64(len(value)))
+		for _, item := range value {
+			if err := cborWrite(buf, item); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		entries := make([]cborEntry, 0, len(value))
+		for key, item := range value {
+			entries = append(entries, cborEntry{Key: key, Value: item})
+		}
+		return cborWriteMap(buf, entries)
+	case map[string]string:
+		entries := make([]cborEntry, 0, len(value))
+		for key, item := range value {
+			entries = append(entries, cborEntry{Key: key, Value: item})
+		}
+		return cborWriteMap(buf, entries)
+	case []cborEntry:
+		return cborWriteMap(buf, value)
+	case cborTag:
+		cborWriteHead(buf, cborTagged, value.Number)
+		return cborWrite(buf, value.Content)
+	default:
+		return fmt.Errorf("cbor: unsupported type %T", v)
+	}
+	return nil
+}
+
+func cborWriteMap(buf *bytes.Buffer, entries []cborEntry) error {
+	type encoded struct {
+		key, value []byte
+	}
+	items := make([]encoded, 0, len(entries))
+	for _, entry := range entries {
+		key, err := cborEncode(entry.Key)
+		if err != nil {
+			return err
+		}
+		value, err := cborEncode(entry.Value)
+		if err != nil {
+			return err
+		}
+		items = append(items, encoded{key, value})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return bytes.Compare(items[i].key, items[j].key) < 0
+	})
+
+	cborWriteHead(buf, cborMap, uint64(len(items)))
+	for i, item := range items {
+		if i > 0 && bytes.Equal(items[i-1].key, item.key) {
+			return fmt.Errorf("cbor: duplicate map key")
+		}
+		buf.Write(item.key)
+		buf.Write(item.value)
+	}
+	return nil
+}
+
+func cborWriteInt(buf *bytes.Buffer, value int64) {
+	if value < 0 {
+		cborWriteHead(buf, cborNegative, uint64(-1-value))
+		return
+	}
+	cborWriteHead(buf, cborUnsigned, uint64(value))
+}
+
+func cborWriteHead(buf *bytes.Buffer, major byte, argument uint64) {
+	head := major << 5
+	switch {
+	case argument < 24:
+		buf.WriteByte(head | byte(argument))
+	case argument <= math.MaxUint8:
+		buf.WriteByte(head | 24)
+		buf.WriteByte(byte(argument))
+	case argument <= math.MaxUint16:
+		buf.WriteByte(head | 25)
+		binary.Write(buf, binary.BigEndian, uint16(argument))
+	case argument <= math.MaxUint32:
+		buf.WriteByte(head | 26)
+		binary.Write(buf, binary.BigEndian, uint32(argument))
+	default:
+		buf.WriteByte(head | 27)
+		binary.Write(buf, binary.BigEndian, argument)
+	}
+}
+
+// cborDecode parses one CBOR item that must span all of data. Integers
+// decode to int64, maps to map[interface{}]interface{} keyed by int64 or
+// string, and tags to cborTag. Indefinite lengths are rejected.
+func cborDecode(data []byte) (interface{}, error) {
+	decoder := &cborDecoder{data: data}
+	value, err := decoder.read(0)
+	if err != nil {
+		return nil, err
+	}
+	if decoder.offset != len(data) {
+		return nil, fmt.Errorf("cbor: %d trailing bytes", len(data)-decoder.offset)
+	}
+	return value, nil
+}
+
+type cborDecoder struct {
+	data   []byte
+	offset int
+}
+
+func (d *cborDecoder) read(depth int) (interface{}, error) {
+	if depth > cborMaxDepth {
+		return nil, fmt.Errorf("cbor: nesting too deep")
+	}
+	if d.offset >= len(d.data) {
+		return nil, fmt.Errorf("cbor: unexpected end of data")
+	}
+	initial := d.data[d.offset]
+	d.offset++
+	major, info := initial>>5, initial&0x1f
+
+	if major == cborSimple {
+		switch info {
+		case 20:
+			return false, nil
+		case 21:
+			return true, nil
+		case 22:
+			return nil, nil
+		case 25:
+			bits, err := d.take(2)
+			if err != nil {
+				return nil, err
+			}
+			return cborHalfFloat(binary.BigEndian.Uint16(bits)), nil
+		case 26:
+			bits, err := d.take(4)
+			if err != nil {
+				return nil, err
+			}
+			return float64(math.Float32frombits(binary.BigEndian.Uint32(bits))), nil
+		case 27:
+			bits, err := d.take(8)
+			if err != nil {
+				return nil, err
+			}
+			return math.Float64frombits(binary.BigEndian.Uint64(bits)), nil
+		default:
+			return nil, fmt.Errorf("cbor: unsupported simple value %d", info)
+		}
+	}
+
+	argument, err := d.argument(info)
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case cborUnsigned:
+		if argument > math.MaxInt64 {
+			return nil, fmt.Errorf("cbor: integer overflow")
+		}
+		return int64(argument), nil
+	case cborNegative:
+		if argument > math.MaxInt64 {
+			return nil, fmt.Errorf("cbor: integer overflow")
+		}
+		return -1 - int64(argument), nil
+	case cborBytes, cborText:
+		content, err := d.take(argument)
+		if err != nil {
+			return nil, err
+		}
+		if major == cborText {
+			return string(content), nil
+		}
+		return append([]byte(nil), content...), nil
+	case cborArray:
+		if argument > uint64(len(d.data)-d.offset) {
+			return nil, fmt.Errorf("cbor: array longer than data")
+		}
+		items := make([]interface{}, 0, argument)
+		for i := uint64(0); i < argument; i++ {
+			item, err := d.read(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case cborMap:
+		if argument > uint64(len(d.data)-d.offset) {
+			return nil, fmt.Errorf("cbor: map longer than data")
+		}
+		entries := make(map[interface{}]interface{}, argument)
+		for i := uint64(0); i < argument; i++ {
+			key, err := d.read(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, fmt.Errorf("cbor: unsupported map key type %T", key)
+			}
+			if _, exists := entries[key]; exists {
+				return nil, fmt.Errorf("cbor: duplicate map key %v", key)
+			}
+			value, err := d.read(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			entries[key] = value
+		}
+		return entries, nil
+	default:
+		content, err := d.read(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return cborTag{Number: argument, Content: content}, nil
+	}
+}
+
+func (d *cborDecoder) argument(info byte) (uint64, error) {
+	switch {
+	case info < 24:
+		return uint64(info), nil
+	case info == 24:
+		b, err := d.take(1)
+		if err != nil {
+			return 0, err
+		}
+		return uint64(b[0]), nil
+	case info == 25:
+		b, err := d.take(2)
+		if err != nil {
+			return 0, err
+		}
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case info == 26:
+		b, err := d.take(4)
+		if err != nil {
+			return 0, err
+		}
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	case info == 27:
+		b, err := d.take(8)
+		if err != nil {
+			return 0, err
+		}
+		return binary.BigEndian.Uint64(b), nil
+	default:
+		return 0, fmt.Errorf("cbor: indefinite or reserved length")
+	}
+}
+
+func (d *cborDecoder) take(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)-d.offset) {
+		return nil, fmt.Errorf("cbor: unexpected end of data")
+	}
+	content := d.data[d.offset : d.offset+int(n)]
+	d.offset += int(n)
+	return content, nil
+}
+
+// cborHalfFloat widens an IEEE 754 half-precision value
+func cborHalfFloat(bits uint16) float64 {
+	exponent := int(bits>>10) & 0x1f
+	mantissa := float64(bits & 0x3ff)
+	var value float64
+	switch exponent {
+	case 0:
+		value = math.Ldexp(mantissa, -24)
+	case 31:
+		if mantissa == 0 {
+			value = math.Inf(1)
+		} else {
+			value = math.NaN()
+		}
+	default:
+		value = math.Ldexp(mantissa+1024, exponent-25)
+	}
+	if bits&0x8000 != 0 {
+		value = -value
+	}
+	return value
+}
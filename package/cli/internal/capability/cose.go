@@ -0,0 +1,393 @@
+package capability
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-vault/package/cli/pkg/types"
+)
+
+// Capability token formats. JSON is the capability object itself; COSE is
+// a COSE_Sign1 (RFC 9052) over a CWT claims set (RFC 8392), compact enough
+// for headers and verifiable without Go.
+const (
+	TokenFormatJSON = "json"
+	TokenFormatCOSE = "cose"
+)
+
+// TokenFormats lists the formats this agent can issue, in preference order
+var TokenFormats = []string{TokenFormatJSON, TokenFormatCOSE}
+
+// ErrTokenExpired is returned by VerifyToken for tokens past their exp claim
+var ErrTokenExpired = errors.New("capability expired")
+
+// COSE and CWT labels
+const (
+	coseTagSign1     = 18
+	coseHeaderAlg    = 1
+	coseHeaderKeyID  = 4
+	coseAlgEdDSA     = -8
+	coseSignature1   = "Signature1"
+	cwtClaimIssuer   = 1
+	cwtClaimSubject  = 2
+	cwtClaimExpires  = 4
+	cwtClaimIssuedAt = 6
+	cwtClaimID       = 7
+)
+
+// Private claim names of capability tokens
+const (
+	claimType        = "typ"
+	claimResource    = "res"
+	claimActions     = "act"
+	claimMaxUses     = "max"
+	claimConstraints = "cns"
+)
+
+// TokenClaims is what a COSE capability token asserts
+type TokenClaims struct {
+	ID          string                 `json:"id"`
+	Type        string                 `json:"type"`
+	Resource    string                 `json:"resource"`
+	Actions     []string               `json:"actions"`
+	Identity    string                 `json:"identity"`
+	Issuer      string                 `json:"issuer"`
+	IssuedAt    time.Time              `json:"issued_at"`
+	ExpiresAt   time.Time              `json:"expires_at"`
+	MaxUses     int                    `json:"maxUses,omitempty"`
+	Constraints map[string]interface{} `json:"constraints,omitempty"`
+}
+
+// TokenKeyID is the COSE key identifier of an agent key: the first eight
+// bytes of the SHA-256 of the raw Ed25519 public key
+func TokenKeyID(publicKey ed25519.PublicKey) []byte {
+	sum := sha256.Sum256(publicKey)
+	return sum[:8]
+}
+
+// EncodeCOSEToken signs a capability as a tagged COSE_Sign1 with EdDSA.
+// Metadata is not part of the token; constraints are carried as a map
+// with their JSON field names.
+func EncodeCOSEToken(capability *types.Capability, privateKey ed25519.PrivateKey) ([]byte, error) {
+	claims := []cborEntry{
+		{cwtClaimIssuer, capability.Issuer},
+		{cwtClaimSubject, capability.Identity},
+		{cwtClaimExpires, capability.ExpiresAt.Unix()},
+		{cwtClaimIssuedAt, capability.IssuedAt.Unix()},
+		{cwtClaimID, []byte(capability.ID)},
+		{claimType, string(capability.Type)},
+		{claimResource, capability.Resource},
+		{claimActions, append([]string{}, capability.Actions...)},
+	}
+	if capability.MaxUses > 0 {
+		claims = append(claims, cborEntry{claimMaxUses, capability.MaxUses})
+	}
+	if capability.Constraints != nil {
+		constraints, err := jsonToCBORValue(capability.Constraints)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode constraints: %w", err)
+		}
+		claims = append(claims, cborEntry{claimConstraints, constraints})
+	}
+
+	payload, err := cborEncode(claims)
+	if err != nil {
+		return nil, err
+	}
+	protected, err := cborEncode([]cborEntry{{coseHeaderAlg, coseAlgEdDSA}})
+	if err != nil {
+		return nil, err
+	}
+
+	toBeSigned, err := coseSigStructure(protected, payload)
+	if err != nil {
+		return nil, err
+	}
+	signature := ed25519.Sign(privateKey, toBeSigned)
+
+	publicKey := privateKey.Public().(ed25519.PublicKey)
+	return cborEncode(cborTag{Number: coseTagSign1, Content: []interface{}{
+		protected,
+		[]cborEntry{{coseHeaderKeyID, TokenKeyID(publicKey)}},
+		payload,
+		signature,
+	}})
+}
+
+// DecodeCOSEToken verifies a COSE capability token against publicKey and
+// returns its claims. Expiry is not checked here.
+func DecodeCOSEToken(token []byte, publicKey ed25519.PublicKey) (*TokenClaims, error) {
+	item, err := cborDecode(token)
+	if err != nil {
+		return nil, fmt.Errorf("malformed token: %w", err)
+	}
+	if tag, ok := item.(cborTag); ok {
+		if tag.Number != coseTagSign1 {
+			return nil, fmt.Errorf("malformed token: unexpected tag %d", tag.Number)
+		}
+		item = tag.Content
+	}
+	parts, ok := item.([]interface{})
+	if !ok || len(parts) != 4 {
+		return nil, fmt.Errorf("malformed token: not a COSE_Sign1")
+	}
+	protected, ok1 := parts[0].([]byte)
+	payload, ok2 := parts[2].([]byte)
+	signature, ok3 := parts[3].([]byte)
+	if !ok1 || !ok2 || !ok3 {
+		return nil, fmt.Errorf("malformed token: not a COSE_Sign1")
+	}
+
+	headers, err := cborDecode(protected)
+	if err != nil {
+		return nil, fmt.Errorf("malformed protected header: %w", err)
+	}
+	headerMap, ok := headers.(map[interface{}]interface{})
+	if !ok || headerMap[int64(coseHeaderAlg)] != int64(coseAlgEdDSA) {
+		return nil, fmt.Errorf("unsupported algorithm: only EdDSA (-8) is accepted")
+	}
+
+	toBeSigned, err := coseSigStructure(protected, payload)
+	if err != nil {
+		return nil, err
+	}
+	if !ed25519.Verify(publicKey, toBeSigned, signature) {
+		return nil, fmt.Errorf("invalid signature")
+	}
+
+	decoded, err := cborDecode(payload)
+	if err != nil {
+		return nil, fmt.Errorf("malformed claims: %w", err)
+	}
+	claimMap, ok := decoded.(map[interface{}]interface{})
+	if !ok {
+		return nil, fmt.Errorf("malformed claims: not a map")
+	}
+	return tokenClaims(claimMap)
+}
+
+// coseSigStructure is the Sig_structure signed for a COSE_Sign1 with no
+// external additional data
+func coseSigStructure(protected, payload []byte) ([]byte, error) {
+	return cborEncode([]interface{}{coseSignature1, protected, []byte{}, payload})
+}
+
+func tokenClaims(claimMap map[interface{}]interface{}) (*TokenClaims, error) {
+	claims := &TokenClaims{}
+	var ok bool
+	var id []byte
+	var issuedAt, expiresAt int64
+	if id, ok = claimMap[int64(cwtClaimID)].([]byte); !ok {
+		return nil, fmt.Errorf("malformed claims: missing cti")
+	}
+	claims.ID = string(id)
+	if expiresAt, ok = claimMap[int64(cwtClaimExpires)].(int64); !ok {
+		return nil, fmt.Errorf("malformed claims: missing exp")
+	}
+	claims.ExpiresAt = time.Unix(expiresAt, 0)
+	if issuedAt, ok = claimMap[int64(cwtClaimIssuedAt)].(int64); ok {
+		claims.IssuedAt = time.Unix(issuedAt, 0)
+	}
+	claims.Issuer, _ = claimMap[int64(cwtClaimIssuer)].(string)
+	claims.Identity, _ = claimMap[int64(cwtClaimSubject)].(string)
+	claims.Type, _ = claimMap[claimType].(string)
+	if claims.Resource, ok = claimMap[claimResource].(string); !ok {
+		return nil, fmt.Errorf("malformed claims: missing res")
+	}
+
+	actions, _ := claimMap[claimActions].([]interface{})
+	for _, action := range actions {
+		text, ok := action.(string)
+		if !ok {
+			return nil, fmt.Errorf("malformed claims: act must be text")
+		}
+		claims.Actions = append(claims.Actions, text)
+	}
+	if maxUses, ok := claimMap[claimMaxUses].(int64); ok {
+		claims.MaxUses = int(maxUses)
+	}
+	if constraints, ok := claimMap[claimConstraints]; ok {
+		value, err := cborToJSONValue(constraints)
+		if err != nil {
+			return nil, err
+		}
+		if claims.Constraints, ok = value.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("malformed claims: cns must be a map")
+		}
+	}
+	return claims, nil
+}
+
+// jsonToCBORValue converts a JSON-serializable value to the generic form
+// cborEncode accepts, keeping integers exact
+func jsonToCBORValue(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return numbersToCBOR(generic), nil
+}
+
+func numbersToCBOR(v interface{}) interface{} {
+	switch value := v.(type) {
+	case json.Number:
+		if integer, err := value.Int64(); err == nil {
+			return integer
+		}
+		float, _ := value.Float64()
+		return float
+	case []interface{}:
+		for i := range value {
+			value[i] = numbersToCBOR(value[i])
+		}
+	case map[string]interface{}:
+		for key := range value {
+			value[key] = numbersToCBOR(value[key])
+		}
+	}
+	return v
+}
+
+// cborToJSONValue converts decoded CBOR back to JSON-compatible values
+func cborToJSONValue(v interface{}) (interface{}, error) {
+	switch value := v.(type) {
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			name, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("malformed claims: constraint keys must be text")
+			}
+			convertedItem, err := cborToJSONValue(item)
+			if err != nil {
+				return nil, err
+			}
+			converted[name] = convertedItem
+		}
+		return converted, nil
+	case []interface{}:
+		converted := make([]interface{}, len(value))
+		for i, item := range value {
+			convertedItem, err := cborToJSONValue(item)
+			if err != nil {
+				return nil, err
+			}
+			converted[i] = convertedItem
+		}
+		return converted, nil
+	case []byte, cborTag:
+		return nil, fmt.Errorf("malformed claims: unexpected %T in constraints", v)
+	default:
+		return v, nil
+	}
+}
+
+// VerifyToken checks a COSE token (base64url, padding optional) offline
+// against the public keys of the agents trusted to issue it. Revocation
+// and use counts need the agent and are not checked. Expired tokens fail
+// with an error wrapping ErrTokenExpired.
+func VerifyToken(token string, publicKeys ...ed25519.PublicKey) (*TokenClaims, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(strings.TrimSpace(token), "="))
+	if err != nil {
+		return nil, fmt.Errorf("token is not base64url encoded")
+	}
+	if len(publicKeys) == 0 {
+		return nil, fmt.Errorf("no public key to verify the token with")
+	}
+
+	var claims *TokenClaims
+	for _, publicKey := range publicKeys {
+		if claims, err = DecodeCOSEToken(raw, publicKey); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(claims.ExpiresAt) {
+		return nil, fmt.Errorf("%w at %s", ErrTokenExpired, claims.ExpiresAt.Format(time.RFC3339))
+	}
+	return claims, nil
+}
+
+// Capability converts the claims back to the capability they were issued
+// from, without metadata or signature
+func (c *TokenClaims) Capability() (*types.Capability, error) {
+	capability := &types.Capability{
+		ID:        c.ID,
+		Type:      types.CapabilityType(c.Type),
+		Resource:  c.Resource,
+		Actions:   c.Actions,
+		Identity:  c.Identity,
+		Issuer:    c.Issuer,
+		IssuedAt:  c.IssuedAt,
+		ExpiresAt: c.ExpiresAt,
+		TTL:       int64(c.ExpiresAt.Sub(c.IssuedAt).Seconds()),
+		MaxUses:   c.MaxUses,
+	}
+	if c.Constraints != nil {
+		data, err := json.Marshal(c.Constraints)
+		if err != nil {
+			return nil, err
+		}
+		capability.Constraints = &types.CapabilityConstraints{}
+		if err := json.Unmarshal(data, capability.Constraints); err != nil {
+			return nil, fmt.Errorf("malformed claims: invalid constraints: %w", err)
+		}
+	}
+	return capability, nil
+}
+
+// IssueToken encodes a signed capability in the requested format. The
+// JSON format has no separate token: the capability object is the token.
+func (e *Engine) IssueToken(capability *types.Capability, format string) (string, error) {
+	switch format {
+	case "", TokenFormatJSON:
+		return "", nil
+	case TokenFormatCOSE:
+		token, err := EncodeCOSEToken(capability, e.privateKey)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode COSE token: %w", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(token), nil
+	default:
+		return "", fmt.Errorf("unsupported token format %q (supported: %s)", format, strings.Join(TokenFormats, ", "))
+	}
+}
+
+// ValidateToken verifies a COSE token (base64url, padding optional)
+// issued by this agent, then validates the capability it names like
+// ValidateCapability does
+func (e *Engine) ValidateToken(token string, context *types.RequestContext) (*types.ValidationResult, error) {
+	startTime := time.Now()
+	invalid := func(code, message string) *types.ValidationResult {
+		return &types.ValidationResult{
+			Valid:          false,
+			ValidationTime: time.Since(startTime),
+			Errors:         []types.ValidationError{{Code: code, Message: message}},
+		}
+	}
+
+	claims, err := VerifyToken(token, e.publicKey)
+	if errors.Is(err, ErrTokenExpired) {
+		return invalid("EXPIRED", fmt.Sprintf("Capability expired: %v", err)), nil
+	}
+	if err != nil {
+		return invalid("INVALID_TOKEN", fmt.Sprintf("Invalid token: %v", err)), nil
+	}
+
+	return e.ValidateCapability(claims.ID, context)
+}
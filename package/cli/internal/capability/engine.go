@@ -6,9 +6,12 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/attestation"
 	"github.com/skygenesisenterprise/aether-vault/package/cli/pkg/types"
 )
 
@@ -25,6 +28,18 @@ type Engine struct {
 
 	// Engine configuration
 	config *EngineConfig
+
+	// Workload attestation verifier
+	attestation *attestation.Verifier
+
+	// Risk scorer; nil when risk scoring is disabled
+	risk *RiskScorer
+
+	// Step-up verifiers by factor
+	stepUp map[string]StepUpVerifier
+
+	// Auditor for step-up events
+	auditor *Auditor
 }
 
 // EngineConfig represents engine configuration
@@ -49,6 +64,9 @@ type EngineConfig struct {
 
 	// Signature algorithm
 	SignatureAlgorithm string `json:"signatureAlgorithm"`
+
+	// Risk scoring and step-up for capability requests
+	Risk *RiskConfig `json:"risk,omitempty"`
 }
 
 // DefaultEngineConfig returns default engine configuration
@@ -77,10 +95,15 @@ func NewEngine(config *EngineConfig, store types.CapabilityStore) (*Engine, erro
 	}
 
 	engine := &Engine{
-		privateKey: privateKey,
-		publicKey:  publicKey,
-		store:      store,
-		config:     config,
+		privateKey:  privateKey,
+		publicKey:   publicKey,
+		store:       store,
+		config:      config,
+		attestation: attestation.NewVerifier(),
+	}
+
+	if err := engine.configureRisk(); err != nil {
+		return nil, err
 	}
 
 	// Start cleanup routine
@@ -104,10 +127,15 @@ func NewEngineWithKeys(config *EngineConfig, store types.CapabilityStore, public
 	}
 
 	engine := &Engine{
-		privateKey: ed25519.PrivateKey(privateKey),
-		publicKey:  ed25519.PublicKey(publicKey),
-		store:      store,
-		config:     config,
+		privateKey:  ed25519.PrivateKey(privateKey),
+		publicKey:   ed25519.PublicKey(publicKey),
+		store:       store,
+		config:      config,
+		attestation: attestation.NewVerifier(),
+	}
+
+	if err := engine.configureRisk(); err != nil {
+		return nil, err
 	}
 
 	// Start cleanup routine
@@ -116,6 +144,34 @@ func NewEngineWithKeys(config *EngineConfig, store types.CapabilityStore, public
 	return engine, nil
 }
 
+// configureRisk sets up risk scoring and the TOTP step-up factor
+func (e *Engine) configureRisk() error {
+	e.stepUp = make(map[string]StepUpVerifier)
+	if e.config.Risk == nil || !e.config.Risk.Enabled {
+		return nil
+	}
+
+	e.risk = NewRiskScorer(e.config.Risk)
+	if e.config.Risk.TOTPSecretFile != "" {
+		verifier, err := NewTOTPVerifierFromFile(e.config.Risk.TOTPSecretFile)
+		if err != nil {
+			return fmt.Errorf("failed to configure TOTP step-up: %w", err)
+		}
+		e.UseStepUpVerifier(verifier)
+	}
+	return nil
+}
+
+// UseStepUpVerifier accepts another step-up factor
+func (e *Engine) UseStepUpVerifier(verifier StepUpVerifier) {
+	e.stepUp[verifier.Factor()] = verifier
+}
+
+// UseAuditor records step-up attempts in the audit log
+func (e *Engine) UseAuditor(auditor *Auditor) {
+	e.auditor = auditor
+}
+
 // GenerateCapability generates a new capability
 func (e *Engine) GenerateCapability(request *types.CapabilityRequest) (*types.CapabilityResponse, error) {
 	startTime := time.Now()
@@ -130,6 +186,27 @@ func (e *Engine) GenerateCapability(request *types.CapabilityRequest) (*types.Ca
 		}, nil
 	}
 
+	// Assess risk and check the step-up before anything is signed
+	risk, stepUp, err := e.assessRisk(request)
+	if errors.Is(err, ErrStepUpRequired) {
+		return &types.CapabilityResponse{
+			Status:         "step_up_required",
+			Message:        fmt.Sprintf("Risk score %d reaches %d (%s); complete a step-up with: %s", risk.Score, risk.Threshold, strings.Join(risk.Reasons, ", "), strings.Join(risk.Factors, ", ")),
+			RequestID:      e.generateRequestID(),
+			ProcessingTime: time.Since(startTime),
+			Risk:           risk,
+		}, nil
+	}
+	if err != nil {
+		return &types.CapabilityResponse{
+			Status:         "denied",
+			Message:        err.Error(),
+			RequestID:      e.generateRequestID(),
+			ProcessingTime: time.Since(startTime),
+			Risk:           risk,
+		}, nil
+	}
+
 	// Create capability
 	capability, err := e.createCapability(request)
 	if err != nil {
@@ -140,6 +217,12 @@ func (e *Engine) GenerateCapability(request *types.CapabilityRequest) (*types.Ca
 			ProcessingTime: time.Since(startTime),
 		}, nil
 	}
+	if risk != nil {
+		capability.Metadata["risk_score"] = risk.Score
+	}
+	if stepUp != nil {
+		capability.Metadata["step_up"] = stepUp
+	}
 
 	// Sign capability
 	if err := e.signCapability(capability); err != nil {
@@ -161,12 +244,26 @@ func (e *Engine) GenerateCapability(request *types.CapabilityRequest) (*types.Ca
 		}, nil
 	}
 
+	// Encode the token format the client negotiated
+	token, err := e.IssueToken(capability, request.Format)
+	if err != nil {
+		return &types.CapabilityResponse{
+			Status:         "error",
+			Message:        fmt.Sprintf("Failed to encode capability: %v", err),
+			RequestID:      e.generateRequestID(),
+			ProcessingTime: time.Since(startTime),
+		}, nil
+	}
+
 	return &types.CapabilityResponse{
 		Capability:     capability,
+		Token:          token,
+		Format:         request.Format,
 		Status:         "granted",
 		Message:        "Capability granted successfully",
 		RequestID:      e.generateRequestID(),
 		ProcessingTime: time.Since(startTime),
+		Risk:           risk,
 	}, nil
 }
 
@@ -234,6 +331,27 @@ func (e *Engine) ValidateCapability(capabilityID string, context *types.RequestC
 		})
 	}
 
+	// Enforce rate and concurrency limits
+	if result.Valid {
+		if code, err := e.enforceLimits(capability, context); err != nil {
+			result.Valid = false
+			result.Errors = append(result.Errors, types.ValidationError{
+				Code:    code,
+				Message: err.Error(),
+			})
+		} else if capability.Constraints != nil && capability.Constraints.MaxConcurrentUses > 0 {
+			result.Context["concurrency_slot"] = true
+		}
+	}
+
+	// Expose what the capability grants so callers can authorize the
+	// specific resource and action they are guarding
+	if result.Valid {
+		result.Context["resource"] = capability.Resource
+		result.Context["actions"] = capability.Actions
+		result.Context["identity"] = capability.Identity
+	}
+
 	// Update usage if valid
 	if result.Valid && e.config.EnableUsageTracking {
 		event := &types.AccessEvent{
@@ -248,6 +366,18 @@ func (e *Engine) ValidateCapability(capabilityID string, context *types.RequestC
 	return result, nil
 }
 
+// ReleaseCapability ends an in-flight use holder acquired during
+// validation
+func (e *Engine) ReleaseCapability(capabilityID, holder string) error {
+	return e.store.ReleaseConcurrencySlot(capabilityID, holder)
+}
+
+// ReleaseHolder ends every in-flight use of holder, whose connection is
+// gone
+func (e *Engine) ReleaseHolder(holder string) int {
+	return e.store.ReleaseHolderSlots(holder)
+}
+
 // RevokeCapability revokes a capability
 func (e *Engine) RevokeCapability(capabilityID, reason, revokedBy string) error {
 	return e.store.Revoke(capabilityID, reason, revokedBy)
@@ -287,6 +417,12 @@ func (e *Engine) GetCapabilityStatus(capabilityID string) (*types.CapabilityStat
 	return status, nil
 }
 
+// GetAttestationVerifier returns the verifier used for workload attestation,
+// so hardware quote verifiers can be registered
+func (e *Engine) GetAttestationVerifier() *attestation.Verifier {
+	return e.attestation
+}
+
 // GetPublicKey returns the public key for verification
 func (e *Engine) GetPublicKey() []byte {
 	return []byte(e.publicKey)
@@ -324,6 +460,11 @@ func (e *Engine) validateRequest(request *types.CapabilityRequest) error {
 		return fmt.Errorf("max uses exceeds maximum allowed: %d", e.config.MaxUses)
 	}
 
+	// Validate token format
+	if request.Format != "" && request.Format != TokenFormatJSON && request.Format != TokenFormatCOSE {
+		return fmt.Errorf("unsupported token format %q (supported: %s)", request.Format, strings.Join(TokenFormats, ", "))
+	}
+
 	return nil
 }
 
@@ -455,13 +596,65 @@ func (e *Engine) validateConstraints(capability *types.Capability, context *type
 		}
 	}
 
+	// Validate workload attestation
+	if constraints.Attestation != nil {
+		var evidence *types.AttestationEvidence
+		if context != nil {
+			evidence = context.Attestation
+		}
+
+		if err := e.attestation.Verify(constraints.Attestation, evidence); err != nil {
+			return fmt.Errorf("attestation constraint violation: %w", err)
+		}
+	}
+
 	return nil
 }
 
+// enforceLimits applies rate and concurrency constraints. A concurrency slot
+// is only held once the rate token has been consumed, and is leased to the
+// holder named in context.
+func (e *Engine) enforceLimits(capability *types.Capability, context *types.RequestContext) (string, error) {
+	constraints := capability.Constraints
+	if constraints == nil {
+		return "", nil
+	}
+	holder := ""
+	if context != nil {
+		holder = context.Holder
+	}
+
+	if constraints.MaxRequestsPerMinute > 0 {
+		allowed, err := e.store.ConsumeRateToken(capability.ID, constraints.MaxRequestsPerMinute)
+		if err != nil {
+			return "RATE_LIMIT_ERROR", fmt.Errorf("rate limit check failed: %w", err)
+		}
+		if !allowed {
+			return "RATE_LIMIT_EXCEEDED", fmt.Errorf("rate limit exceeded: %d requests per minute", constraints.MaxRequestsPerMinute)
+		}
+	}
+
+	if constraints.MaxConcurrentUses > 0 {
+		acquired, err := e.store.AcquireConcurrencySlot(capability.ID, constraints.MaxConcurrentUses, holder)
+		if err != nil {
+			return "CONCURRENCY_LIMIT_ERROR", fmt.Errorf("concurrency check failed: %w", err)
+		}
+		if !acquired {
+			return "CONCURRENCY_LIMIT_EXCEEDED", fmt.Errorf("concurrency limit exceeded: %d simultaneous uses", constraints.MaxConcurrentUses)
+		}
+	}
+
+	return "", nil
+}
+
 // validateTimeWindow validates time window constraints
 func (e *Engine) validateTimeWindow(window *types.TimeWindow) error {
-	now := time.Now()
+	return CheckTimeWindow(window, time.Now())
+}
 
+// CheckTimeWindow reports whether now falls inside a time window
+// constraint
+func CheckTimeWindow(window *types.TimeWindow, now time.Time) error {
 	// Check allowed hours
 	if len(window.Hours) > 0 {
 		currentHour := now.Hour()
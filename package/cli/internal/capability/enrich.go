@@ -0,0 +1,344 @@
+package capability
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"os/user"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Enricher adds context to an audit event before it is hashed and
+// written. Enrichers run in the configured order, so later ones see what
+// earlier ones added; a lookup that cannot be made is skipped rather than
+// failing the event.
+type Enricher interface {
+	Name() string
+	Enrich(event *AuditEvent)
+}
+
+// EnricherFactory builds an enricher from the audit configuration
+type EnricherFactory func(config *AuditConfig) (Enricher, error)
+
+var enrichers = map[string]EnricherFactory{
+	"geoip":        newGeoIPEnricher,
+	"container":    newContainerEnricher,
+	"user":         newUserEnricher,
+	"threat_intel": newThreatIntelEnricher,
+}
+
+// RegisterEnricher makes an enricher available to AuditConfig.Enrichers
+func RegisterEnricher(name string, factory EnricherFactory) {
+	enrichers[name] = factory
+}
+
+// buildEnrichers returns the configured pipeline in order
+func buildEnrichers(config *AuditConfig) ([]Enricher, error) {
+	var pipeline []Enricher
+	for _, name := range config.Enrichers {
+		factory, ok := enrichers[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown audit enricher %q", name)
+		}
+		enricher, err := factory(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure audit enricher %s: %w", name, err)
+		}
+		pipeline = append(pipeline, enricher)
+	}
+	return pipeline, nil
+}
+
+// setEnrichment records a value under key in the event's enrichment
+func setEnrichment(event *AuditEvent, key string, value interface{}) {
+	if event.Enrichment == nil {
+		event.Enrichment = make(map[string]interface{})
+	}
+	event.Enrichment[key] = value
+}
+
+// clientAddr returns the event's client address when it is a public one
+func clientAddr(event *AuditEvent) (netip.Addr, bool) {
+	if event.Client == nil {
+		return netip.Addr{}, false
+	}
+	addr, err := netip.ParseAddr(event.Client.IP)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsUnspecified() {
+		return netip.Addr{}, false
+	}
+	return addr, true
+}
+
+// parseNetwork reads a CIDR, a first-last range or a single address
+func parseNetwork(value string) (netip.Addr, netip.Addr, error) {
+	if first, last, ok := strings.Cut(value, "-"); ok {
+		start, err := netip.ParseAddr(strings.TrimSpace(first))
+		if err != nil {
+			return netip.Addr{}, netip.Addr{}, err
+		}
+		end, err := netip.ParseAddr(strings.TrimSpace(last))
+		if err != nil {
+			return netip.Addr{}, netip.Addr{}, err
+		}
+		if end.Less(start) || start.Is4() != end.Is4() {
+			return netip.Addr{}, netip.Addr{}, fmt.Errorf("invalid range %q", value)
+		}
+		return start, end, nil
+	}
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return netip.Addr{}, netip.Addr{}, err
+		}
+		prefix = prefix.Masked()
+		bytes := prefix.Addr().AsSlice()
+		for bit := prefix.Bits(); bit < len(bytes)*8; bit++ {
+			bytes[bit/8] |= 1 << (7 - bit%8)
+		}
+		last, _ := netip.AddrFromSlice(bytes)
+		return prefix.Addr(), last, nil
+	}
+	addr, err := netip.ParseAddr(value)
+	return addr, addr, err
+}
+
+// geoIPEnricher looks the client address up in a CSV range table of
+// network,country[,region[,city]] rows
+type geoIPEnricher struct {
+	ranges []geoRange
+}
+
+type geoRange struct {
+	start, end netip.Addr
+	location   map[string]string
+}
+
+func newGeoIPEnricher(config *AuditConfig) (Enricher, error) {
+	if config.GeoIPDatabase == "" {
+		return nil, errors.New("geoipDatabase is not set")
+	}
+	file, err := os.Open(config.GeoIPDatabase)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	enricher := &geoIPEnricher{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 2 {
+			return nil, fmt.Errorf("line %d: expected network,country[,region[,city]]", line)
+		}
+		start, end, err := parseNetwork(strings.TrimSpace(record[0]))
+		if err != nil {
+			// Tolerate a header row
+			if line == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		location := map[string]string{}
+		for i, key := range []string{"country", "region", "city"} {
+			if i+1 < len(record) && strings.TrimSpace(record[i+1]) != "" {
+				location[key] = strings.TrimSpace(record[i+1])
+			}
+		}
+		enricher.ranges = append(enricher.ranges, geoRange{start: start, end: end, location: location})
+	}
+
+	sort.Slice(enricher.ranges, func(i, j int) bool { return enricher.ranges[i].start.Less(enricher.ranges[j].start) })
+	return enricher, nil
+}
+
+func (e *geoIPEnricher) Name() string { return "geoip" }
+
+func (e *geoIPEnricher) Enrich(event *AuditEvent) {
+	addr, ok := clientAddr(event)
+	if !ok {
+		return
+	}
+
+	// The last range starting at or before addr is the only candidate
+	i := sort.Search(len(e.ranges), func(i int) bool { return addr.Less(e.ranges[i].start) }) - 1
+	if i < 0 || e.ranges[i].end.Less(addr) {
+		return
+	}
+	setEnrichment(event, "geo", e.ranges[i].location)
+}
+
+// containerEnricher fills in the container the CLI runs in, when it runs
+// in one. The values are read once.
+type containerEnricher struct {
+	container *ContainerInfo
+}
+
+var containerIDPattern = regexp.MustCompile(`[0-9a-f]{64}`)
+
+func newContainerEnricher(config *AuditConfig) (Enricher, error) {
+	container := &ContainerInfo{
+		Image:     os.Getenv("CONTAINER_IMAGE"),
+		Namespace: firstEnv("KUBERNETES_NAMESPACE", "POD_NAMESPACE"),
+		Name:      firstEnv("KUBERNETES_POD_NAME", "POD_NAME"),
+	}
+	// cgroup paths of Docker, containerd and CRI-O end in the container ID
+	for _, path := range []string{"/proc/self/cgroup", "/proc/self/mountinfo"} {
+		if data, err := os.ReadFile(path); err == nil {
+			if id := containerIDPattern.Find(data); id != nil {
+				container.ID = string(id)
+				break
+			}
+		}
+	}
+	if container.ID == "" && container.Name == "" && container.Image == "" {
+		return &containerEnricher{}, nil
+	}
+	if container.Name == "" {
+		container.Name, _ = os.Hostname()
+	}
+	return &containerEnricher{container: container}, nil
+}
+
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func (e *containerEnricher) Name() string { return "container" }
+
+func (e *containerEnricher) Enrich(event *AuditEvent) {
+	if e.container == nil {
+		return
+	}
+	if event.Client == nil {
+		event.Client = &ClientInfo{}
+	}
+	if event.Client.Container == nil {
+		container := *e.container
+		event.Client.Container = &container
+	}
+}
+
+// userEnricher adds the local account running the CLI
+type userEnricher struct {
+	user map[string]string
+}
+
+func newUserEnricher(config *AuditConfig) (Enricher, error) {
+	current, err := user.Current()
+	if err != nil {
+		return nil, err
+	}
+	info := map[string]string{"username": current.Username}
+	if name := strings.TrimSpace(strings.Split(current.Name, ",")[0]); name != "" {
+		info["display_name"] = name
+	}
+	return &userEnricher{user: info}, nil
+}
+
+func (e *userEnricher) Name() string { return "user" }
+
+func (e *userEnricher) Enrich(event *AuditEvent) {
+	setEnrichment(event, "user", e.user)
+}
+
+// threatIntelEnricher tags client addresses listed in a threat-intel feed
+// of "network tag[,tag...]" lines
+type threatIntelEnricher struct {
+	entries []threatEntry
+}
+
+type threatEntry struct {
+	start, end netip.Addr
+	tags       []string
+}
+
+func newThreatIntelEnricher(config *AuditConfig) (Enricher, error) {
+	if config.ThreatIntelFile == "" {
+		return nil, errors.New("threatIntelFile is not set")
+	}
+	file, err := os.Open(config.ThreatIntelFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	enricher := &threatIntelEnricher{}
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: expected network tag[,tag...]", line)
+		}
+		start, end, err := parseNetwork(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		var tags []string
+		for _, tag := range strings.Split(strings.Join(fields[1:], ","), ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+		enricher.entries = append(enricher.entries, threatEntry{start: start, end: end, tags: tags})
+	}
+	return enricher, scanner.Err()
+}
+
+func (e *threatIntelEnricher) Name() string { return "threat_intel" }
+
+func (e *threatIntelEnricher) Enrich(event *AuditEvent) {
+	addr, ok := clientAddr(event)
+	if !ok {
+		return
+	}
+
+	seen := map[string]bool{}
+	var tags []string
+	for _, threat := range e.entries {
+		if addr.Less(threat.start) || threat.end.Less(addr) {
+			continue
+		}
+		for _, tag := range threat.tags {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	if len(tags) > 0 {
+		sort.Strings(tags)
+		setEnrichment(event, "threat_tags", tags)
+		// A listed address raises the event to at least a warning
+		if event.Severity == "" || event.Severity == "info" {
+			event.Severity = "warning"
+		}
+	}
+}
@@ -0,0 +1,320 @@
+package capability
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-vault/package/cli/pkg/types"
+)
+
+const (
+	// totpPeriod and totpDigits follow RFC 6238 defaults, as used by
+	// authenticator apps
+	totpPeriod = 30
+	totpDigits = 6
+)
+
+var (
+	// ErrStepUpRequired means the request must be sent again with a
+	// step-up proof
+	ErrStepUpRequired = errors.New("step-up required")
+
+	// ErrStepUpFailed means the presented factor was not accepted
+	ErrStepUpFailed = errors.New("step-up verification failed")
+)
+
+// RiskConfig configures risk scoring of capability requests. Each risky
+// pattern a request matches adds its weight; at Threshold the agent asks
+// for a step-up before it signs.
+type RiskConfig struct {
+	// Enable risk scoring
+	Enabled bool `json:"enabled"`
+
+	// Score at which a step-up is required
+	Threshold int `json:"threshold"`
+
+	// Actions treated as administrative ("*" matches a wildcard grant)
+	AdminActions []string `json:"adminActions"`
+
+	// Resource patterns treated as production: a path.Match glob, or a
+	// name matching any path segment
+	ProductionPatterns []string `json:"productionPatterns"`
+
+	// Working hours in local time, [start, end); requests outside them or
+	// on weekends are unusual. Equal values disable the check.
+	WorkHoursStart int `json:"workHoursStart"`
+	WorkHoursEnd   int `json:"workHoursEnd"`
+
+	// TTL in seconds above which a request counts as long-lived
+	LongTTL int64 `json:"longTTL"`
+
+	// Weights of each pattern
+	AdminWeight      int `json:"adminWeight"`
+	ProductionWeight int `json:"productionWeight"`
+	OffHoursWeight   int `json:"offHoursWeight"`
+	LongTTLWeight    int `json:"longTTLWeight"`
+
+	// File holding the base32 TOTP secret (or otpauth:// URI) the agent
+	// verifies step-up codes against
+	TOTPSecretFile string `json:"totpSecretFile,omitempty"`
+}
+
+// DefaultRiskConfig returns default risk configuration
+func DefaultRiskConfig() *RiskConfig {
+	return &RiskConfig{
+		Enabled:            true,
+		Threshold:          50,
+		AdminActions:       []string{"*", "admin", "delete", "revoke", "manage", "sudo"},
+		ProductionPatterns: []string{"prod", "production"},
+		WorkHoursStart:     8,
+		WorkHoursEnd:       19,
+		LongTTL:            1800,
+		AdminWeight:        40,
+		ProductionWeight:   30,
+		OffHoursWeight:     20,
+		LongTTLWeight:      10,
+	}
+}
+
+// RiskScorer scores capability requests against a RiskConfig
+type RiskScorer struct {
+	config *RiskConfig
+}
+
+func NewRiskScorer(config *RiskConfig) *RiskScorer {
+	if config == nil {
+		config = DefaultRiskConfig()
+	}
+	return &RiskScorer{config: config}
+}
+
+// Assess scores request as if made at now
+func (r *RiskScorer) Assess(request *types.CapabilityRequest, now time.Time) *types.RiskAssessment {
+	assessment := &types.RiskAssessment{Threshold: r.config.Threshold}
+	add := func(weight int, reason string) {
+		assessment.Score += weight
+		assessment.Reasons = append(assessment.Reasons, reason)
+	}
+
+	for _, action := range request.Actions {
+		if containsString(r.config.AdminActions, action) {
+			add(r.config.AdminWeight, fmt.Sprintf("administrative action %q", action))
+			break
+		}
+	}
+	if pattern, ok := r.matchProduction(request.Resource); ok {
+		add(r.config.ProductionWeight, fmt.Sprintf("production resource (%s)", pattern))
+	}
+	if r.offHours(now) {
+		add(r.config.OffHoursWeight, "outside working hours")
+	}
+	if r.config.LongTTL > 0 && request.TTL > r.config.LongTTL {
+		add(r.config.LongTTLWeight, fmt.Sprintf("TTL above %ds", r.config.LongTTL))
+	}
+
+	assessment.StepUpRequired = assessment.Score >= r.config.Threshold
+	return assessment
+}
+
+func (r *RiskScorer) matchProduction(resource string) (string, bool) {
+	for _, pattern := range r.config.ProductionPatterns {
+		if strings.ContainsAny(pattern, "*?[") {
+			if matched, _ := path.Match(pattern, resource); matched {
+				return pattern, true
+			}
+			continue
+		}
+		for _, segment := range strings.FieldsFunc(resource, func(c rune) bool { return c == '/' || c == ':' }) {
+			if strings.EqualFold(segment, pattern) {
+				return pattern, true
+			}
+		}
+	}
+	return "", false
+}
+
+func (r *RiskScorer) offHours(now time.Time) bool {
+	if r.config.WorkHoursStart == r.config.WorkHoursEnd {
+		return false
+	}
+	if now.Weekday() == time.Saturday || now.Weekday() == time.Sunday {
+		return true
+	}
+	hour := now.Hour()
+	return hour < r.config.WorkHoursStart || hour >= r.config.WorkHoursEnd
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// StepUpVerifier checks a second factor presented for a risky request.
+// The agent ships a TOTP verifier; others, such as WebAuthn, can be added
+// with Engine.UseStepUpVerifier.
+type StepUpVerifier interface {
+	Factor() string
+	Verify(identity string, proof *types.StepUpProof) error
+}
+
+// TOTPVerifier accepts RFC 6238 codes from the current and adjacent time
+// steps. A code is accepted once per identity, so a shoulder-surfed code
+// cannot be replayed.
+type TOTPVerifier struct {
+	secret []byte
+
+	mutex    sync.Mutex
+	lastStep map[string]int64
+}
+
+// NewTOTPVerifierFromFile reads a base32 secret or otpauth:// URI
+func NewTOTPVerifierFromFile(file string) (*TOTPVerifier, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TOTP secret: %w", err)
+	}
+
+	value := strings.TrimSpace(string(data))
+	if strings.HasPrefix(value, "otpauth://") {
+		uri, err := url.Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid otpauth URI: %w", err)
+		}
+		value = uri.Query().Get("secret")
+	}
+	value = strings.ToUpper(strings.ReplaceAll(value, " ", ""))
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.TrimRight(value, "="))
+	if err != nil || len(secret) == 0 {
+		return nil, fmt.Errorf("TOTP secret is not valid base32")
+	}
+
+	return &TOTPVerifier{secret: secret, lastStep: make(map[string]int64)}, nil
+}
+
+func (v *TOTPVerifier) Factor() string { return "totp" }
+
+func (v *TOTPVerifier) Verify(identity string, proof *types.StepUpProof) error {
+	code := strings.TrimSpace(proof.Code)
+	if len(code) != totpDigits {
+		return fmt.Errorf("%w: expected a %d-digit code", ErrStepUpFailed, totpDigits)
+	}
+
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	current := time.Now().Unix() / totpPeriod
+	for _, step := range []int64{current, current - 1, current + 1} {
+		if !hmac.Equal([]byte(totpCode(v.secret, step)), []byte(code)) {
+			continue
+		}
+		if step <= v.lastStep[identity] {
+			return fmt.Errorf("%w: code already used", ErrStepUpFailed)
+		}
+		v.lastStep[identity] = step
+		return nil
+	}
+	return fmt.Errorf("%w: invalid code", ErrStepUpFailed)
+}
+
+func totpCode(secret []byte, step int64) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	modulo := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, value%modulo)
+}
+
+// assessRisk scores the request and, when a step-up is required, checks
+// the proof it carries. It returns the step-up record to attach to the
+// capability, or nil when no step-up was needed.
+func (e *Engine) assessRisk(request *types.CapabilityRequest) (*types.RiskAssessment, map[string]interface{}, error) {
+	if e.risk == nil {
+		return nil, nil, nil
+	}
+
+	assessment := e.risk.Assess(request, time.Now())
+	for factor := range e.stepUp {
+		assessment.Factors = append(assessment.Factors, factor)
+	}
+	sort.Strings(assessment.Factors)
+	if !assessment.StepUpRequired {
+		return assessment, nil, nil
+	}
+
+	if request.StepUp == nil {
+		if len(assessment.Factors) == 0 {
+			return assessment, nil, fmt.Errorf("%w: no step-up factor is configured on the agent", ErrStepUpFailed)
+		}
+		return assessment, nil, ErrStepUpRequired
+	}
+
+	verifier, ok := e.stepUp[request.StepUp.Factor]
+	if !ok {
+		err := fmt.Errorf("%w: factor %q is not accepted", ErrStepUpFailed, request.StepUp.Factor)
+		e.auditStepUp(request, assessment, err)
+		return assessment, nil, err
+	}
+	if err := verifier.Verify(request.Identity, request.StepUp); err != nil {
+		e.auditStepUp(request, assessment, err)
+		return assessment, nil, err
+	}
+	e.auditStepUp(request, assessment, nil)
+
+	return assessment, map[string]interface{}{
+		"factor":     verifier.Factor(),
+		"verifiedAt": time.Now().UTC(),
+		"riskScore":  assessment.Score,
+	}, nil
+}
+
+// auditStepUp records the factor and its outcome
+func (e *Engine) auditStepUp(request *types.CapabilityRequest, assessment *types.RiskAssessment, err error) {
+	if e.auditor == nil {
+		return
+	}
+
+	event := &AuditEvent{
+		Type:           "capability_step_up",
+		Category:       "authentication",
+		Severity:       "info",
+		SourceIdentity: request.Identity,
+		TargetResource: request.Resource,
+		Action:         "step_up:" + request.StepUp.Factor,
+		Outcome:        "success",
+		Description:    fmt.Sprintf("Step-up for capability request on %s", request.Resource),
+		Context: map[string]interface{}{
+			"factor":     request.StepUp.Factor,
+			"risk_score": assessment.Score,
+			"reasons":    assessment.Reasons,
+		},
+	}
+	if err != nil {
+		event.Severity = "warning"
+		event.Outcome = "failed"
+		event.Error = &ErrorInfo{Message: err.Error()}
+	}
+	e.auditor.LogEvent(event)
+}
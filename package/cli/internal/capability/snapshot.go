@@ -0,0 +1,168 @@
+package capability
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-vault/package/cli/pkg/types"
+)
+
+// StoreSnapshot is the in-memory state of a store, including the rate and
+// concurrency limit state that is never persisted
+type StoreSnapshot struct {
+	// Capabilities by ID
+	Capabilities map[string]*types.Capability `json:"capabilities"`
+
+	// Usage tracking by capability ID
+	Usage map[string]*types.CapabilityUsage `json:"usage,omitempty"`
+
+	// Rate limit buckets by capability ID
+	Buckets map[string]BucketState `json:"buckets,omitempty"`
+
+	// In-flight use counters by capability ID, for engines that predate
+	// slot leases
+	InFlight map[string]int `json:"inFlight,omitempty"`
+
+	// In-flight use leases by capability ID
+	Slots map[string][]SlotState `json:"slots,omitempty"`
+}
+
+// BucketState is the rate limit state of one capability
+type BucketState struct {
+	Tokens     float64   `json:"tokens"`
+	LastRefill time.Time `json:"lastRefill"`
+}
+
+// SlotState is one in-flight use lease
+type SlotState struct {
+	Holder    string    `json:"holder"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// EngineState is what a replacement engine needs to keep honouring the
+// capabilities this one issued: its signing keys and its store
+type EngineState struct {
+	// Ed25519 public key
+	PublicKey []byte `json:"publicKey"`
+
+	// Ed25519 private key
+	PrivateKey []byte `json:"privateKey"`
+
+	// Store contents; nil when the engine does not use a Store
+	Store *StoreSnapshot `json:"store,omitempty"`
+}
+
+// Snapshot copies the store's state
+func (s *Store) Snapshot() *StoreSnapshot {
+	snapshot := &StoreSnapshot{
+		Capabilities: make(map[string]*types.Capability),
+		Usage:        make(map[string]*types.CapabilityUsage),
+		Buckets:      make(map[string]BucketState),
+		InFlight:     make(map[string]int),
+		Slots:        make(map[string][]SlotState),
+	}
+
+	s.cacheMutex.RLock()
+	for id, capability := range s.cache {
+		snapshot.Capabilities[id] = capability
+	}
+	s.cacheMutex.RUnlock()
+
+	s.usageMutex.RLock()
+	for id, usage := range s.usage {
+		snapshot.Usage[id] = usage
+	}
+	s.usageMutex.RUnlock()
+
+	s.limitMutex.Lock()
+	for id, bucket := range s.buckets {
+		snapshot.Buckets[id] = BucketState{Tokens: bucket.tokens, LastRefill: bucket.lastRefill}
+	}
+	for id, leases := range s.inFlight {
+		snapshot.InFlight[id] = len(leases)
+		for _, lease := range leases {
+			snapshot.Slots[id] = append(snapshot.Slots[id], SlotState{Holder: lease.holder, ExpiresAt: lease.expiresAt})
+		}
+	}
+	s.limitMutex.Unlock()
+
+	return snapshot
+}
+
+// Restore replaces the store's state with a snapshot; it wins over
+// anything loaded from the storage file, which may be older
+func (s *Store) Restore(snapshot *StoreSnapshot) {
+	if snapshot == nil {
+		return
+	}
+
+	s.cacheMutex.Lock()
+	s.cache = make(map[string]*types.Capability)
+	for id, capability := range snapshot.Capabilities {
+		// Empty metadata is signed as {} but omitted in JSON
+		if capability.Metadata == nil {
+			capability.Metadata = make(map[string]interface{})
+		}
+		s.cache[id] = capability
+	}
+	s.cacheMutex.Unlock()
+
+	s.usageMutex.Lock()
+	s.usage = make(map[string]*types.CapabilityUsage)
+	for id, usage := range snapshot.Usage {
+		s.usage[id] = usage
+	}
+	s.usageMutex.Unlock()
+
+	s.limitMutex.Lock()
+	s.buckets = make(map[string]*tokenBucket)
+	for id, bucket := range snapshot.Buckets {
+		s.buckets[id] = &tokenBucket{tokens: bucket.Tokens, lastRefill: bucket.LastRefill}
+	}
+	s.inFlight = make(map[string][]slotLease)
+	for id, slots := range snapshot.Slots {
+		for _, slot := range slots {
+			s.inFlight[id] = append(s.inFlight[id], slotLease{holder: slot.Holder, expiresAt: slot.ExpiresAt})
+		}
+	}
+	// Counts from an older engine name no holder; they run out with the TTL
+	if snapshot.Slots == nil {
+		expiresAt := time.Now().Add(s.slotTTL())
+		for id, count := range snapshot.InFlight {
+			for i := 0; i < count; i++ {
+				s.inFlight[id] = append(s.inFlight[id], slotLease{expiresAt: expiresAt})
+			}
+		}
+	}
+	s.limitMutex.Unlock()
+}
+
+// ExportState returns the engine's keys and, when it uses a Store, a
+// snapshot of it
+func (e *Engine) ExportState() *EngineState {
+	state := &EngineState{
+		PublicKey:  append([]byte(nil), e.publicKey...),
+		PrivateKey: append([]byte(nil), e.privateKey...),
+	}
+	if store, ok := e.store.(*Store); ok {
+		state.Store = store.Snapshot()
+	}
+	return state
+}
+
+// NewEngineFromState creates an engine that takes over from the one that
+// exported state, restoring its store into store
+func NewEngineFromState(config *EngineConfig, store *Store, state *EngineState) (*Engine, error) {
+	if state == nil {
+		return nil, fmt.Errorf("engine state cannot be nil")
+	}
+	if len(state.PrivateKey) == ed25519.PrivateKeySize && len(state.PublicKey) == ed25519.PublicKeySize {
+		if !ed25519.PrivateKey(state.PrivateKey).Public().(ed25519.PublicKey).Equal(ed25519.PublicKey(state.PublicKey)) {
+			return nil, fmt.Errorf("engine state keys do not match")
+		}
+	}
+
+	store.Restore(state.Store)
+	return NewEngineWithKeys(config, store, state.PublicKey, state.PrivateKey)
+}
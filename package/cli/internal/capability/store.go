@@ -28,6 +28,15 @@ type Store struct {
 	// Usage mutex
 	usageMutex sync.RWMutex
 
+	// Per-capability rate buckets
+	buckets map[string]*tokenBucket
+
+	// Per-capability in-flight use leases
+	inFlight map[string][]slotLease
+
+	// Limit state mutex
+	limitMutex sync.Mutex
+
 	// File path for persistence
 	filePath string
 
@@ -35,6 +44,24 @@ type Store struct {
 	enablePersistence bool
 }
 
+// tokenBucket tracks rate limit state for a capability
+type tokenBucket struct {
+	tokens     float64
+	lastRefill time.Time
+}
+
+// slotLease is an in-flight use of a concurrency-limited capability. It
+// ends on release, when its holder disconnects or once it expires, so a
+// client that crashes mid-use does not keep the capability at its limit.
+type slotLease struct {
+	holder    string
+	expiresAt time.Time
+}
+
+// defaultConcurrencySlotTTL bounds an in-flight use whose holder never
+// releases it
+const defaultConcurrencySlotTTL = 5 * time.Minute
+
 // StoreConfig represents store configuration
 type StoreConfig struct {
 	// Enable in-memory caching
@@ -55,6 +82,10 @@ type StoreConfig struct {
 	// Cleanup interval in seconds
 	CleanupInterval int64 `json:"cleanupInterval"`
 
+	// Lease on an in-flight use in seconds, after which its concurrency
+	// slot is freed even if it was never released
+	ConcurrencySlotTTL int64 `json:"concurrencySlotTTL"`
+
 	// Enable compression
 	EnableCompression bool `json:"enableCompression"`
 
@@ -75,6 +106,7 @@ func DefaultStoreConfig() *StoreConfig {
 		StorageFilePath:     filepath.Join(homeDir, ".aether-vault", "capabilities.json"),
 		EnableUsageTracking: true,
 		CleanupInterval:     300, // 5 minutes
+		ConcurrencySlotTTL:  int64(defaultConcurrencySlotTTL.Seconds()),
 		EnableCompression:   false,
 		EnableEncryption:    false,
 	}
@@ -90,6 +122,8 @@ func NewStore(config *StoreConfig) (*Store, error) {
 		config:            config,
 		cache:             make(map[string]*types.Capability),
 		usage:             make(map[string]*types.CapabilityUsage),
+		buckets:           make(map[string]*tokenBucket),
+		inFlight:          make(map[string][]slotLease),
 		filePath:          config.StorageFilePath,
 		enablePersistence: config.EnablePersistence,
 	}
@@ -283,6 +317,22 @@ func (s *Store) Cleanup() error {
 		s.usageMutex.Unlock()
 	}
 
+	// Clean up limit state
+	s.limitMutex.Lock()
+	for id := range s.buckets {
+		if _, exists := s.cache[id]; !exists {
+			delete(s.buckets, id)
+		}
+	}
+	for id := range s.inFlight {
+		if _, exists := s.cache[id]; !exists {
+			delete(s.inFlight, id)
+			continue
+		}
+		s.expireSlots(id, now)
+	}
+	s.limitMutex.Unlock()
+
 	// Persist changes
 	if s.enablePersistence && removed > 0 {
 		if err := s.saveToFile(); err != nil {
@@ -350,6 +400,151 @@ func (s *Store) UpdateUsage(id string, event *types.AccessEvent) error {
 	return nil
 }
 
+// ConsumeRateToken takes one token from the capability's bucket. The bucket
+// holds at most perMinute tokens and refills continuously.
+func (s *Store) ConsumeRateToken(id string, perMinute int) (bool, error) {
+	if id == "" {
+		return false, fmt.Errorf("capability ID cannot be empty")
+	}
+	if perMinute <= 0 {
+		return true, nil
+	}
+
+	s.limitMutex.Lock()
+	defer s.limitMutex.Unlock()
+
+	now := time.Now()
+	capacity := float64(perMinute)
+
+	bucket, exists := s.buckets[id]
+	if !exists {
+		bucket = &tokenBucket{
+			tokens:     capacity,
+			lastRefill: now,
+		}
+		s.buckets[id] = bucket
+	}
+
+	// Refill proportionally to elapsed time
+	elapsed := now.Sub(bucket.lastRefill).Minutes()
+	bucket.tokens += elapsed * capacity
+	if bucket.tokens > capacity {
+		bucket.tokens = capacity
+	}
+	bucket.lastRefill = now
+
+	if bucket.tokens < 1 {
+		return false, nil
+	}
+
+	bucket.tokens--
+	return true, nil
+}
+
+// AcquireConcurrencySlot reserves an in-flight slot for the capability,
+// leased to holder until it is released or its TTL passes
+func (s *Store) AcquireConcurrencySlot(id string, max int, holder string) (bool, error) {
+	if id == "" {
+		return false, fmt.Errorf("capability ID cannot be empty")
+	}
+	if max <= 0 {
+		return true, nil
+	}
+
+	s.limitMutex.Lock()
+	defer s.limitMutex.Unlock()
+
+	now := time.Now()
+	s.expireSlots(id, now)
+	if len(s.inFlight[id]) >= max {
+		return false, nil
+	}
+
+	s.inFlight[id] = append(s.inFlight[id], slotLease{
+		holder:    holder,
+		expiresAt: now.Add(s.slotTTL()),
+	})
+	return true, nil
+}
+
+// ReleaseConcurrencySlot frees the oldest in-flight slot holder has for
+// the capability
+func (s *Store) ReleaseConcurrencySlot(id string, holder string) error {
+	if id == "" {
+		return fmt.Errorf("capability ID cannot be empty")
+	}
+
+	s.limitMutex.Lock()
+	defer s.limitMutex.Unlock()
+
+	s.expireSlots(id, time.Now())
+	leases := s.inFlight[id]
+	for i, lease := range leases {
+		if lease.holder != holder {
+			continue
+		}
+		if len(leases) == 1 {
+			delete(s.inFlight, id)
+		} else {
+			s.inFlight[id] = append(leases[:i:i], leases[i+1:]...)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("no in-flight use for capability: %s", id)
+}
+
+// ReleaseHolderSlots frees every in-flight slot holder has, e.g. when its
+// connection closes, and returns how many it freed
+func (s *Store) ReleaseHolderSlots(holder string) int {
+	s.limitMutex.Lock()
+	defer s.limitMutex.Unlock()
+
+	released := 0
+	for id, leases := range s.inFlight {
+		kept := leases[:0]
+		for _, lease := range leases {
+			if lease.holder == holder {
+				released++
+				continue
+			}
+			kept = append(kept, lease)
+		}
+		if len(kept) == 0 {
+			delete(s.inFlight, id)
+		} else {
+			s.inFlight[id] = kept
+		}
+	}
+
+	return released
+}
+
+// expireSlots drops the capability's leases that have run out. Callers hold
+// limitMutex.
+func (s *Store) expireSlots(id string, now time.Time) {
+	leases := s.inFlight[id]
+	kept := leases[:0]
+	for _, lease := range leases {
+		if now.Before(lease.expiresAt) {
+			kept = append(kept, lease)
+		}
+	}
+	if len(kept) == 0 {
+		delete(s.inFlight, id)
+	} else {
+		s.inFlight[id] = kept
+	}
+}
+
+// slotTTL returns the lease on an in-flight use
+func (s *Store) slotTTL() time.Duration {
+	if s.config.ConcurrencySlotTTL <= 0 {
+		return defaultConcurrencySlotTTL
+	}
+	return time.Duration(s.config.ConcurrencySlotTTL) * time.Second
+}
+
 // matchesFilter checks if a capability matches the filter
 func (s *Store) matchesFilter(capability *types.Capability, filter *types.CapabilityFilter) bool {
 	if filter == nil {
@@ -566,6 +761,16 @@ func (s *Store) GetStats() map[string]interface{} {
 		"total_usage_entries":    len(s.usage),
 	}
 
+	s.limitMutex.Lock()
+	inFlight := 0
+	for id := range s.inFlight {
+		s.expireSlots(id, time.Now())
+		inFlight += len(s.inFlight[id])
+	}
+	stats["in_flight_uses"] = inFlight
+	stats["rate_limited_capabilities"] = len(s.buckets)
+	s.limitMutex.Unlock()
+
 	// Count by status
 	active := 0
 	revoked := 0
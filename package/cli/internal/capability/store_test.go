@@ -0,0 +1,61 @@
+package capability
+
+import (
+	"testing"
+	"time"
+)
+
+func newLimitStore(t *testing.T) *Store {
+	t.Helper()
+	store, err := NewStore(&StoreConfig{ConcurrencySlotTTL: 60})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	return store
+}
+
+func TestConcurrencySlotReclaimedAfterTTL(t *testing.T) {
+	store := newLimitStore(t)
+
+	if acquired, err := store.AcquireConcurrencySlot("cap", 1, "conn-1"); err != nil || !acquired {
+		t.Fatalf("first acquire = %v, %v; want a slot", acquired, err)
+	}
+	if acquired, _ := store.AcquireConcurrencySlot("cap", 1, "conn-2"); acquired {
+		t.Fatal("second acquire got a slot past the limit")
+	}
+
+	// The holder never releases; let its lease run out
+	store.limitMutex.Lock()
+	store.inFlight["cap"][0].expiresAt = time.Now().Add(-time.Second)
+	store.limitMutex.Unlock()
+
+	if acquired, err := store.AcquireConcurrencySlot("cap", 1, "conn-2"); err != nil || !acquired {
+		t.Fatalf("acquire after the TTL = %v, %v; want the reclaimed slot", acquired, err)
+	}
+	if err := store.ReleaseConcurrencySlot("cap", "conn-1"); err == nil {
+		t.Error("released a slot whose lease had expired")
+	}
+}
+
+func TestConcurrencySlotsFreedWithHolder(t *testing.T) {
+	store := newLimitStore(t)
+
+	for i := 0; i < 2; i++ {
+		if acquired, err := store.AcquireConcurrencySlot("cap", 2, "conn-1"); err != nil || !acquired {
+			t.Fatalf("acquire %d = %v, %v; want a slot", i, acquired, err)
+		}
+	}
+	if acquired, _ := store.AcquireConcurrencySlot("cap", 2, "conn-2"); acquired {
+		t.Fatal("acquire got a slot past the limit")
+	}
+
+	if released := store.ReleaseHolderSlots("conn-1"); released != 2 {
+		t.Fatalf("released %d slots with the holder, want 2", released)
+	}
+	if acquired, err := store.AcquireConcurrencySlot("cap", 2, "conn-2"); err != nil || !acquired {
+		t.Fatalf("acquire after the holder left = %v, %v; want a slot", acquired, err)
+	}
+	if err := store.ReleaseConcurrencySlot("cap", "conn-1"); err == nil {
+		t.Error("released a slot for a holder that has none")
+	}
+}
@@ -0,0 +1,194 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-vault/package/cli/pkg/types"
+)
+
+// APIClient is a thin client for the Aether Vault server REST API (/api/v1)
+type APIClient struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+
+	readOnlyWarning sync.Once
+}
+
+// readOnlyHeader is set on every response while the server is in read-only
+// maintenance mode
+const readOnlyHeader = "X-Vault-Read-Only"
+
+// APIError represents an error returned by the server
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+	Violations []string
+	// Path and Action name the policy check an access denial failed
+	Path   string
+	Action string
+	// Hint explains an access denial from the token's capabilities, or
+	// why a step-up challenge could not be answered
+	Hint string
+	// MFA is what a VAULT_MFA_REQUIRED challenge asks to verify
+	MFA *MFARequirement
+	// ControlGroup is the request a VAULT_CONTROL_GROUP_PENDING read
+	// waits on
+	ControlGroup *ControlGroupRequirement
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	message := fmt.Sprintf("server returned HTTP %d", e.StatusCode)
+	if e.Code != "" {
+		message = fmt.Sprintf("%s (%s, HTTP %d)", e.Message, e.Code, e.StatusCode)
+	}
+	if e.Hint != "" {
+		message += "; " + e.Hint
+	}
+	return message
+}
+
+// NewAPIClient creates a REST API client from the CLI configuration
+func NewAPIClient(config *types.Config) (*APIClient, error) {
+	if config == nil || config.Cloud.URL == "" {
+		return nil, fmt.Errorf("server URL is not configured (set VAULT_URL)")
+	}
+
+	timeout := config.General.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	return &APIClient{
+		baseURL:    strings.TrimRight(config.Cloud.URL, "/"),
+		token:      config.Cloud.Token,
+		httpClient: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// BaseURL returns the server base URL
+func (c *APIClient) BaseURL() string {
+	return c.baseURL
+}
+
+// Do performs a request against path (relative to /api/v1, or an absolute
+// URL) and decodes the JSON response into out when non-nil. A step-up MFA
+// challenge is answered inline and the request retried once, as is a read
+// parked for control group approval once the request is approved.
+func (c *APIClient) Do(ctx context.Context, method, path string, body, out interface{}) error {
+	var data []byte
+	if body != nil {
+		var err error
+		data, err = json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request body: %w", err)
+		}
+	}
+
+	err := c.do(ctx, method, path, data, out)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.Code == mfaRequiredCode && apiErr.MFA != nil && c.satisfyStepUp(ctx, apiErr) {
+		err = c.do(ctx, method, path, data, out)
+	}
+	if errors.As(err, &apiErr) && apiErr.Code == controlGroupPendingCode && apiErr.ControlGroup != nil && c.awaitControlGroup(ctx, apiErr) {
+		err = c.do(ctx, method, path, data, out)
+	}
+	return err
+}
+
+// do sends one request with the JSON body data, if any
+func (c *APIClient) do(ctx context.Context, method, path string, data []byte, out interface{}) error {
+	var reqBody io.Reader
+	if data != nil {
+		reqBody = bytes.NewReader(data)
+	}
+
+	url := path
+	if !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+		url = c.baseURL + "/api/v1" + path
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if data != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.Header.Get(readOnlyHeader) != "" {
+		c.readOnlyWarning.Do(func() {
+			fmt.Fprintln(os.Stderr, "⚠ The server is in read-only maintenance mode; writes are rejected")
+		})
+	}
+
+	respData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode >= 400 {
+		apiErr := newAPIError(resp.StatusCode, respData)
+		if apiErr.StatusCode == http.StatusForbidden && apiErr.Path != "" && !strings.HasPrefix(path, capabilitiesPath) {
+			c.explainDenial(ctx, apiErr)
+		}
+		return apiErr
+	}
+
+	if out != nil && len(respData) > 0 {
+		if err := json.Unmarshal(respData, out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// newAPIError decodes the server's error body
+func newAPIError(statusCode int, data []byte) *APIError {
+	apiErr := &APIError{StatusCode: statusCode}
+	var errResp struct {
+		Error struct {
+			Code         string                   `json:"code"`
+			Message      string                   `json:"message"`
+			Path         string                   `json:"path"`
+			Action       string                   `json:"action"`
+			MFA          *MFARequirement          `json:"mfa"`
+			ControlGroup *ControlGroupRequirement `json:"control_group"`
+		} `json:"error"`
+		Violations []string `json:"violations"`
+	}
+	if json.Unmarshal(data, &errResp) == nil {
+		apiErr.Code = errResp.Error.Code
+		apiErr.Message = errResp.Error.Message
+		apiErr.Violations = errResp.Violations
+		apiErr.Path = errResp.Error.Path
+		apiErr.Action = errResp.Error.Action
+		apiErr.MFA = errResp.Error.MFA
+		apiErr.ControlGroup = errResp.Error.ControlGroup
+	}
+	return apiErr
+}
@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// capabilitiesPath reports the calling token's capabilities on ?path=
+const capabilitiesPath = "/auth/token/capabilities-self"
+
+// Capabilities is what the token may do on one policy path
+type Capabilities struct {
+	Path         string   `json:"path"`
+	Capabilities []string `json:"capabilities"`
+	// CentralAdmin tokens pass policy checks without policies
+	CentralAdmin bool                 `json:"central_admin"`
+	Policies     []PolicyCapabilities `json:"policies"`
+}
+
+// PolicyCapabilities is what one of the token's policies allows and
+// denies on the path
+type PolicyCapabilities struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	Allowed []string `json:"allowed"`
+	Denied  []string `json:"denied"`
+}
+
+// Has reports whether action is among the capabilities
+func (c *Capabilities) Has(action string) bool {
+	for _, capability := range c.Capabilities {
+		if capability == action {
+			return true
+		}
+	}
+	return false
+}
+
+// Capabilities returns the token's capabilities on a policy path
+func (c *APIClient) Capabilities(ctx context.Context, path string) (*Capabilities, error) {
+	var capabilities Capabilities
+	if err := c.Do(ctx, http.MethodGet, capabilitiesPath+"?path="+url.QueryEscape(path), nil, &capabilities); err != nil {
+		return nil, err
+	}
+	return &capabilities, nil
+}
+
+// Preflight checks that the token may take action on a policy path before
+// an operation that would fail late or with an unhelpful error, such as a
+// stream. Servers without the capabilities endpoint are not checked.
+func (c *APIClient) Preflight(ctx context.Context, path, action string) error {
+	capabilities, err := c.Capabilities(ctx, path)
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return nil
+		}
+		return fmt.Errorf("failed to check capabilities: %w", err)
+	}
+	if capabilities.Has(action) {
+		return nil
+	}
+	return &APIError{
+		StatusCode: http.StatusForbidden,
+		Code:       "VAULT_ACCESS_DENIED",
+		Message:    "Access denied: requires " + action + " on " + capabilities.Path,
+		Path:       capabilities.Path,
+		Action:     action,
+		Hint:       denialHint(capabilities, action),
+	}
+}
+
+// explainDenial adds a hint to a policy denial from the token's
+// capabilities on the path, leaving the error as it is when they cannot
+// be read
+func (c *APIClient) explainDenial(ctx context.Context, apiErr *APIError) {
+	capabilities, err := c.Capabilities(ctx, apiErr.Path)
+	if err != nil {
+		return
+	}
+	apiErr.Hint = denialHint(capabilities, apiErr.Action)
+}
+
+// denialHint says what the token lacks and what would fix it
+func denialHint(capabilities *Capabilities, action string) string {
+	for _, policy := range capabilities.Policies {
+		for _, denied := range policy.Denied {
+			if denied == action {
+				return fmt.Sprintf("policy %q denies %s on %s, which overrides any allow", policy.Name, action, capabilities.Path)
+			}
+		}
+	}
+	if len(capabilities.Capabilities) == 0 {
+		return fmt.Sprintf("your token has no capabilities on %s; ask an administrator for a policy allowing %s on it", capabilities.Path, action)
+	}
+	return fmt.Sprintf("your token only has %s on %s; ask an administrator for a policy allowing %s",
+		strings.Join(capabilities.Capabilities, ", "), capabilities.Path, action)
+}
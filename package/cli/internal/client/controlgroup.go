@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// controlGroupPendingCode is the error code of a read parked until
+	// enough members of a team approve it
+	controlGroupPendingCode = "VAULT_CONTROL_GROUP_PENDING"
+
+	defaultControlGroupWait  = 10 * time.Minute
+	controlGroupPollInterval = 5 * time.Second
+)
+
+// ControlGroupRequirement is the approval request a parked read waits on
+type ControlGroupRequirement struct {
+	RequestID string    `json:"request_id"`
+	Status    string    `json:"status"`
+	Approvals int       `json:"approvals"`
+	Required  int       `json:"required"`
+	ExpiresAt time.Time `json:"expires_at"`
+	PollPath  string    `json:"poll_path"`
+}
+
+// awaitControlGroup polls the request in apiErr until it is approved and
+// reports whether the read may be retried. It waits VAULT_CONTROL_GROUP_WAIT,
+// ten minutes by default; when it gives up, it leaves a hint on the error.
+func (c *APIClient) awaitControlGroup(ctx context.Context, apiErr *APIError) bool {
+	requirement := apiErr.ControlGroup
+	wait := defaultControlGroupWait
+	if value := strings.TrimSpace(os.Getenv("VAULT_CONTROL_GROUP_WAIT")); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			apiErr.Hint = "VAULT_CONTROL_GROUP_WAIT is not a duration: " + err.Error()
+			return false
+		}
+		wait = parsed
+	}
+	if wait <= 0 {
+		apiErr.Hint = fmt.Sprintf("request %s needs %d approvals; retry once it is approved", requirement.RequestID, requirement.Required)
+		return false
+	}
+
+	pollURL := requirement.PollPath
+	if !strings.HasPrefix(pollURL, "http://") && !strings.HasPrefix(pollURL, "https://") {
+		pollURL = c.baseURL + pollURL
+	}
+
+	fmt.Fprintf(os.Stderr, "Waiting for %d approvals on control group request %s...\n", requirement.Required, requirement.RequestID)
+	deadline := time.NewTimer(wait)
+	defer deadline.Stop()
+	ticker := time.NewTicker(controlGroupPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			apiErr.Hint = "stopped waiting for approval: " + ctx.Err().Error()
+			return false
+		case <-deadline.C:
+			apiErr.Hint = fmt.Sprintf("request %s was not approved within %s", requirement.RequestID, wait)
+			return false
+		case <-ticker.C:
+		}
+
+		var request struct {
+			Status    string `json:"status"`
+			Approvals []struct {
+				ApproverID string `json:"approver_id"`
+			} `json:"approvals"`
+		}
+		if err := c.do(ctx, http.MethodGet, pollURL, nil, &request); err != nil {
+			apiErr.Hint = "failed to poll control group request: " + err.Error()
+			return false
+		}
+		switch request.Status {
+		case "approved":
+			return true
+		case "pending":
+			if len(request.Approvals) != requirement.Approvals {
+				requirement.Approvals = len(request.Approvals)
+				fmt.Fprintf(os.Stderr, "%d of %d approvals\n", requirement.Approvals, requirement.Required)
+			}
+		default:
+			apiErr.Hint = fmt.Sprintf("request %s was %s", requirement.RequestID, request.Status)
+			return false
+		}
+	}
+}
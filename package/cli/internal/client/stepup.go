@@ -0,0 +1,85 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// mfaRequiredCode is the error code of a step-up challenge, which the
+// server answers when a policy rule requires a recent MFA verification
+const mfaRequiredCode = "VAULT_MFA_REQUIRED"
+
+// MFARequirement is what a step-up challenge asks the token to verify
+type MFARequirement struct {
+	Methods []string `json:"methods"`
+	// MaxAge is how recent the verification must be, in seconds
+	MaxAge     int    `json:"max_age"`
+	VerifyPath string `json:"verify_path"`
+	Enrolled   bool   `json:"enrolled"`
+}
+
+// satisfyStepUp answers the challenge in apiErr with a TOTP code from
+// VAULT_MFA_CODE, or typed in at a terminal, and reports whether the
+// request may be retried. When it cannot, it leaves a hint on the error.
+func (c *APIClient) satisfyStepUp(ctx context.Context, apiErr *APIError) bool {
+	requirement := apiErr.MFA
+	if !requirement.Enrolled {
+		apiErr.Hint = "enroll a TOTP factor with POST /api/v1/auth/mfa/totp/enroll first"
+		return false
+	}
+	if !containsMethod(requirement.Methods, "totp") {
+		apiErr.Hint = "the server asks for " + strings.Join(requirement.Methods, ", ") + ", which the CLI cannot provide"
+		return false
+	}
+
+	code, err := readMFACode(requirement)
+	if err != nil {
+		apiErr.Hint = err.Error()
+		return false
+	}
+
+	verifyURL := requirement.VerifyPath
+	if !strings.HasPrefix(verifyURL, "http://") && !strings.HasPrefix(verifyURL, "https://") {
+		verifyURL = c.baseURL + verifyURL
+	}
+	if err := c.Do(ctx, http.MethodPost, verifyURL, map[string]string{"code": code}, nil); err != nil {
+		apiErr.Hint = "MFA verification failed: " + err.Error()
+		return false
+	}
+	return true
+}
+
+// readMFACode takes the code from VAULT_MFA_CODE, or prompts for it on
+// stderr when stdin is a terminal
+func readMFACode(requirement *MFARequirement) (string, error) {
+	if code := strings.TrimSpace(os.Getenv("VAULT_MFA_CODE")); code != "" {
+		return code, nil
+	}
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return "", fmt.Errorf("set VAULT_MFA_CODE to a TOTP code to run non-interactively")
+	}
+
+	fmt.Fprintf(os.Stderr, "MFA verification required (within the last %ds)\nTOTP code: ", requirement.MaxAge)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read TOTP code: %w", err)
+	}
+	code := strings.TrimSpace(line)
+	if code == "" {
+		return "", fmt.Errorf("TOTP code is required")
+	}
+	return code, nil
+}
+
+func containsMethod(methods []string, method string) bool {
+	for _, candidate := range methods {
+		if candidate == method {
+			return true
+		}
+	}
+	return false
+}
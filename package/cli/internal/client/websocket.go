@@ -0,0 +1,196 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// websocketGUID is the fixed key suffix of the RFC 6455 handshake
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// maxWebSocketMessage bounds a message read from the server
+const maxWebSocketMessage = 1 << 20
+
+// WebSocket opcodes
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+// Stream opens a WebSocket on path (relative to /api/v1) speaking
+// protocol and calls handle with each message until the server closes
+// the connection, handle fails or ctx is done
+func (c *APIClient) Stream(ctx context.Context, path, protocol string, handle func(message []byte) error) error {
+	url := c.baseURL + "/api/v1" + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Protocol", protocol)
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	// No client timeout: the connection is meant to stay open
+	resp, err := (&http.Client{}).Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		return newAPIError(resp.StatusCode, data)
+	}
+	conn, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		resp.Body.Close()
+		return fmt.Errorf("server connection cannot be upgraded")
+	}
+	defer conn.Close()
+
+	accept := sha1.Sum([]byte(key + websocketGUID))
+	if resp.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(accept[:]) {
+		return fmt.Errorf("invalid WebSocket handshake")
+	}
+	if resp.Header.Get("Sec-WebSocket-Protocol") != protocol {
+		return fmt.Errorf("server does not speak %s", protocol)
+	}
+
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	for {
+		message, err := readWebSocketMessage(conn)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		if err := handle(message); err != nil {
+			writeWebSocketFrame(conn, opClose, nil)
+			return err
+		}
+	}
+}
+
+// readWebSocketMessage reads frames until a complete data message,
+// answering pings on the way. A close frame ends the stream with io.EOF.
+func readWebSocketMessage(conn io.ReadWriter) ([]byte, error) {
+	var message []byte
+	for {
+		var header [2]byte
+		if _, err := io.ReadFull(conn, header[:]); err != nil {
+			return nil, err
+		}
+		final := header[0]&0x80 != 0
+		opcode := header[0] & 0x0F
+		masked := header[1]&0x80 != 0
+
+		length := uint64(header[1] & 0x7F)
+		switch length {
+		case 126:
+			var extended [2]byte
+			if _, err := io.ReadFull(conn, extended[:]); err != nil {
+				return nil, err
+			}
+			length = uint64(binary.BigEndian.Uint16(extended[:]))
+		case 127:
+			var extended [8]byte
+			if _, err := io.ReadFull(conn, extended[:]); err != nil {
+				return nil, err
+			}
+			length = binary.BigEndian.Uint64(extended[:])
+		}
+		if length > maxWebSocketMessage || uint64(len(message))+length > maxWebSocketMessage {
+			return nil, fmt.Errorf("WebSocket message exceeds %d bytes", maxWebSocketMessage)
+		}
+
+		var mask [4]byte
+		if masked {
+			if _, err := io.ReadFull(conn, mask[:]); err != nil {
+				return nil, err
+			}
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(conn, payload); err != nil {
+			return nil, err
+		}
+		if masked {
+			for i := range payload {
+				payload[i] ^= mask[i%4]
+			}
+		}
+
+		switch opcode {
+		case opClose:
+			writeWebSocketFrame(conn, opClose, nil)
+			return nil, io.EOF
+		case opPing:
+			if err := writeWebSocketFrame(conn, opPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opText, opBinary, opContinuation:
+			message = append(message, payload...)
+		default:
+			return nil, fmt.Errorf("unexpected WebSocket opcode %d", opcode)
+		}
+		if final {
+			return message, nil
+		}
+	}
+}
+
+// writeWebSocketFrame sends one masked frame, as clients must
+func writeWebSocketFrame(conn io.Writer, opcode byte, payload []byte) error {
+	frame := []byte{0x80 | opcode}
+	switch {
+	case len(payload) < 126:
+		frame = append(frame, 0x80|byte(len(payload)))
+	case len(payload) <= 0xFFFF:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+	default:
+		frame = append(frame, 0x80|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(len(payload)))
+	}
+
+	var mask [4]byte
+	if _, err := rand.Read(mask[:]); err != nil {
+		return err
+	}
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+
+	_, err := conn.Write(frame)
+	return err
+}
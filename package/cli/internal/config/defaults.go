@@ -31,6 +31,12 @@ func DefaultKeyPath() string {
 	return filepath.Join(DefaultVaultPath(), "keys", "vault.key")
 }
 
+// DefaultIdentityKeyPath returns the path of the X25519 key that opens
+// client-encrypted secrets
+func DefaultIdentityKeyPath() string {
+	return filepath.Join(DefaultVaultPath(), "keys", "identity.key")
+}
+
 // CreateDefaultConfig creates a default configuration
 func CreateDefaultConfig() *types.Config {
 	return &types.Config{
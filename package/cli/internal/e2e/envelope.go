@@ -0,0 +1,268 @@
+package e2e
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// EnvelopePrefix starts every sealed value; the server rejects
+// client-encrypted uploads without it
+const EnvelopePrefix = "aev-e2e:v1:"
+
+const (
+	algorithm = "X25519-HKDF-SHA256-AES256GCM"
+	wrapInfo  = "aether-vault e2e key wrap v1"
+)
+
+// envelope is the JSON carried after EnvelopePrefix
+type envelope struct {
+	Algorithm  string      `json:"alg"`
+	Ciphertext string      `json:"ct"`
+	Recipients []recipient `json:"recipients"`
+}
+
+// recipient holds the data key wrapped for one public key
+type recipient struct {
+	KeyID      string `json:"kid"`
+	Ephemeral  string `json:"epk"`
+	WrappedKey string `json:"wk"`
+}
+
+// IsEnvelope reports whether value was produced by Seal
+func IsEnvelope(value string) bool {
+	return strings.HasPrefix(value, EnvelopePrefix)
+}
+
+// Seal encrypts plaintext under a fresh data key wrapped for every
+// recipient. Include the sender's own key to keep access.
+func Seal(plaintext []byte, recipients ...*ecdh.PublicKey) (string, error) {
+	if len(recipients) == 0 {
+		return "", errors.New("at least one recipient is required")
+	}
+
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+
+	ciphertext, err := aead(dataKey, plaintext, []byte(EnvelopePrefix), true)
+	if err != nil {
+		return "", err
+	}
+
+	env := &envelope{Algorithm: algorithm, Ciphertext: encode(ciphertext)}
+	if err := env.addRecipients(dataKey, recipients); err != nil {
+		return "", err
+	}
+	return env.String()
+}
+
+// Open decrypts a sealed value with the identity's private key
+func (i *Identity) Open(value string) ([]byte, error) {
+	env, err := parseEnvelope(value)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := env.unwrap(i)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := decode(env.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+	}
+	return aead(dataKey, ciphertext, []byte(EnvelopePrefix), false)
+}
+
+// Grant wraps the value's data key for additional recipients, leaving the
+// ciphertext untouched. Keys that already have access are skipped.
+func (i *Identity) Grant(value string, recipients ...*ecdh.PublicKey) (string, error) {
+	env, err := parseEnvelope(value)
+	if err != nil {
+		return "", err
+	}
+	dataKey, err := env.unwrap(i)
+	if err != nil {
+		return "", err
+	}
+
+	if err := env.addRecipients(dataKey, recipients); err != nil {
+		return "", err
+	}
+	return env.String()
+}
+
+// Recipients lists the key IDs that can open a sealed value
+func Recipients(value string) ([]string, error) {
+	env, err := parseEnvelope(value)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(env.Recipients))
+	for _, r := range env.Recipients {
+		ids = append(ids, r.KeyID)
+	}
+	return ids, nil
+}
+
+func parseEnvelope(value string) (*envelope, error) {
+	if !IsEnvelope(value) {
+		return nil, ErrInvalidEnvelope
+	}
+	raw, err := decode(strings.TrimPrefix(value, EnvelopePrefix))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+	}
+
+	var env envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+	}
+	if env.Algorithm != algorithm {
+		return nil, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidEnvelope, env.Algorithm)
+	}
+	return &env, nil
+}
+
+func (e *envelope) String() (string, error) {
+	raw, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	return EnvelopePrefix + encode(raw), nil
+}
+
+// addRecipients wraps dataKey for each key under an ephemeral X25519 key
+// agreement, so wrapping needs only the recipient's public key
+func (e *envelope) addRecipients(dataKey []byte, keys []*ecdh.PublicKey) error {
+	for _, key := range keys {
+		id := KeyID(key)
+		if e.has(id) {
+			continue
+		}
+
+		ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+		if err != nil {
+			return err
+		}
+		kek, err := wrappingKey(ephemeral, key, ephemeral.PublicKey(), key)
+		if err != nil {
+			return err
+		}
+		wrapped, err := aead(kek, dataKey, []byte(id), true)
+		if err != nil {
+			return err
+		}
+
+		e.Recipients = append(e.Recipients, recipient{
+			KeyID:      id,
+			Ephemeral:  encode(ephemeral.PublicKey().Bytes()),
+			WrappedKey: encode(wrapped),
+		})
+	}
+	return nil
+}
+
+func (e *envelope) unwrap(identity *Identity) ([]byte, error) {
+	public := identity.PublicKey()
+	id := KeyID(public)
+	for _, r := range e.Recipients {
+		if r.KeyID != id {
+			continue
+		}
+
+		raw, err := decode(r.Ephemeral)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+		}
+		ephemeral, err := ecdh.X25519().NewPublicKey(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+		}
+		wrapped, err := decode(r.WrappedKey)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+		}
+
+		kek, err := wrappingKey(identity.private, ephemeral, ephemeral, public)
+		if err != nil {
+			return nil, err
+		}
+		return aead(kek, wrapped, []byte(id), false)
+	}
+	return nil, ErrNotRecipient
+}
+
+func (e *envelope) has(id string) bool {
+	for _, r := range e.Recipients {
+		if r.KeyID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// wrappingKey derives the key-encryption key from the X25519 shared
+// secret, bound to both public keys
+func wrappingKey(private *ecdh.PrivateKey, peer, ephemeral, recipient *ecdh.PublicKey) ([]byte, error) {
+	shared, err := private.ECDH(peer)
+	if err != nil {
+		return nil, err
+	}
+	salt := append(ephemeral.Bytes(), recipient.Bytes()...)
+	return hkdf.Key(sha256.New, shared, salt, wrapInfo, 32)
+}
+
+// aead seals or opens with AES-256-GCM, prefixing the random nonce
+func aead(key, data, additional []byte, seal bool) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if seal {
+		nonce := make([]byte, gcm.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, err
+		}
+		return gcm.Seal(nonce, nonce, data, additional), nil
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, ErrInvalidEnvelope
+	}
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], additional)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecryptFailed, err)
+	}
+	return plaintext, nil
+}
+
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decode(data string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(data)
+}
+
+var (
+	ErrInvalidEnvelope = errors.New("value is not a client-encrypted envelope")
+	ErrNotRecipient    = errors.New("this value was not shared with your key")
+	ErrDecryptFailed   = errors.New("failed to decrypt client-encrypted value")
+)
@@ -0,0 +1,111 @@
+// Package e2e implements client-side encryption for zero-knowledge
+// secrets. Values are sealed under a random data key before upload, and
+// the data key is wrapped for each recipient's X25519 public key, so the
+// server only ever stores an envelope it cannot open.
+package e2e
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Identity is a user's X25519 key pair
+type Identity struct {
+	private *ecdh.PrivateKey
+}
+
+// GenerateIdentity creates a new random identity
+func GenerateIdentity() (*Identity, error) {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	return &Identity{private: key}, nil
+}
+
+// LoadIdentity reads an identity written by Save
+func LoadIdentity(path string) (*Identity, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNoIdentity
+		}
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid key file %s: %w", path, err)
+	}
+	key, err := ecdh.X25519().NewPrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key file %s: %w", path, err)
+	}
+	return &Identity{private: key}, nil
+}
+
+// Save writes the private key to path, readable only by the owner. It
+// refuses to replace an existing key, which would orphan every secret
+// sealed for it.
+func (i *Identity) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create key directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return ErrIdentityExists
+		}
+		return fmt.Errorf("failed to create key file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(base64.StdEncoding.EncodeToString(i.private.Bytes()) + "\n"); err != nil {
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+	return nil
+}
+
+// PublicKey returns the identity's shareable public key
+func (i *Identity) PublicKey() *ecdh.PublicKey {
+	return i.private.PublicKey()
+}
+
+// EncodePublicKey renders a public key for flags and the server
+func EncodePublicKey(key *ecdh.PublicKey) string {
+	return base64.RawURLEncoding.EncodeToString(key.Bytes())
+}
+
+// ParsePublicKey parses a key rendered by EncodePublicKey
+func ParsePublicKey(encoded string) (*ecdh.PublicKey, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPublicKey, err)
+	}
+	key, err := ecdh.X25519().NewPublicKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPublicKey, err)
+	}
+	return key, nil
+}
+
+// KeyID is a short fingerprint naming a public key in envelopes
+func KeyID(key *ecdh.PublicKey) string {
+	sum := sha256.Sum256(key.Bytes())
+	return hex.EncodeToString(sum[:8])
+}
+
+var (
+	ErrNoIdentity       = errors.New("no client encryption key; run 'vault key generate' first")
+	ErrIdentityExists   = errors.New("a client encryption key already exists")
+	ErrInvalidPublicKey = errors.New("invalid public key")
+)
@@ -0,0 +1,112 @@
+package gitcred
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Cache keeps fetched credentials between helper runs until their lease
+// ends. Git starts a new helper for every operation, so the cache lives
+// in a file readable only by the user.
+type Cache struct {
+	path    string
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password"`
+
+	// LeaseExpiresAt ends the cache entry
+	LeaseExpiresAt time.Time `json:"lease_expires_at"`
+
+	// ExpiresAt is the expiry of the secret itself, reported to git
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// LoadCache reads the cache file, dropping expired entries. A missing or
+// unreadable file starts an empty cache.
+func LoadCache(path string) *Cache {
+	cache := &Cache{path: path, entries: make(map[string]cacheEntry)}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+	if err := json.Unmarshal(data, &cache.entries); err != nil {
+		cache.entries = make(map[string]cacheEntry)
+		return cache
+	}
+
+	now := time.Now()
+	for key, entry := range cache.entries {
+		if !entry.LeaseExpiresAt.After(now) {
+			delete(cache.entries, key)
+		}
+	}
+	return cache
+}
+
+// Get returns the cached credential for a secret
+func (c *Cache) Get(key string) (*Credential, bool) {
+	entry, ok := c.entries[key]
+	if !ok || !entry.LeaseExpiresAt.After(time.Now()) {
+		return nil, false
+	}
+	return &Credential{Username: entry.Username, Password: entry.Password, ExpiresAt: entry.ExpiresAt}, true
+}
+
+// Put caches a credential for ttl, never past the secret's own expiry
+func (c *Cache) Put(key string, credential *Credential, ttl time.Duration) error {
+	lease := time.Now().Add(ttl)
+	if credential.ExpiresAt != nil && credential.ExpiresAt.Before(lease) {
+		lease = *credential.ExpiresAt
+	}
+	if ttl <= 0 || !lease.After(time.Now()) {
+		return nil
+	}
+
+	c.entries[key] = cacheEntry{
+		Username:       credential.Username,
+		Password:       credential.Password,
+		LeaseExpiresAt: lease,
+		ExpiresAt:      credential.ExpiresAt,
+	}
+	return c.save()
+}
+
+// Delete drops a cached credential, e.g. after git reports it rejected
+func (c *Cache) Delete(key string) error {
+	if _, ok := c.entries[key]; !ok {
+		return nil
+	}
+	delete(c.entries, key)
+	return c.save()
+}
+
+func (c *Cache) save() error {
+	if len(c.entries) == 0 {
+		if err := os.Remove(c.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove credential cache: %w", err)
+		}
+		return nil
+	}
+
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write credential cache: %w", err)
+	}
+	return os.Rename(tmp, c.path)
+}
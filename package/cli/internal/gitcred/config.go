@@ -0,0 +1,137 @@
+package gitcred
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config maps git remotes to vault secrets
+type Config struct {
+	// How long a fetched credential is reused when the remote sets no
+	// TTL; 0 disables the cache
+	CacheTTL time.Duration `yaml:"cache_ttl"`
+
+	Remotes []RemoteConfig `yaml:"remotes"`
+}
+
+// RemoteConfig maps one host, and optionally a repository path pattern,
+// to a vault secret
+type RemoteConfig struct {
+	// Host as git reports it, including a non-default port
+	Host string `yaml:"host"`
+
+	// Repository path pattern in path.Match syntax, e.g. acme/*. Empty
+	// matches every repository on the host. Git only sends the path when
+	// credential.useHttpPath is set.
+	Path string `yaml:"path"`
+
+	// Protocol of the remote (default https)
+	Protocol string `yaml:"protocol"`
+
+	// ID of the vault secret. Its value is either a JSON object with
+	// username and password (or token) fields or the bare password.
+	Secret string `yaml:"secret"`
+
+	// Username sent with a bare password
+	Username string `yaml:"username"`
+
+	// Cache lease for this remote, overriding cache_ttl
+	CacheTTL *time.Duration `yaml:"cache_ttl"`
+}
+
+// DefaultConfig returns default git credential configuration
+func DefaultConfig() *Config {
+	return &Config{
+		CacheTTL: 5 * time.Minute,
+	}
+}
+
+// LoadConfig reads the git credential configuration from a YAML file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read git credential config: %w", err)
+	}
+
+	config := DefaultConfig()
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse git credential config: %w", err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// Validate checks the configuration and fills in remote defaults
+func (c *Config) Validate() error {
+	if c.CacheTTL < 0 {
+		return fmt.Errorf("cache_ttl must not be negative")
+	}
+	if len(c.Remotes) == 0 {
+		return fmt.Errorf("at least one remote is required")
+	}
+
+	for i := range c.Remotes {
+		remote := &c.Remotes[i]
+		if remote.Host == "" {
+			return fmt.Errorf("remote %d: host is required", i+1)
+		}
+		remote.Host = strings.ToLower(remote.Host)
+		remote.Path = strings.Trim(remote.Path, "/")
+		if _, err := path.Match(remote.Path, ""); err != nil {
+			return fmt.Errorf("remote %s: invalid path pattern %q", remote.Host, remote.Path)
+		}
+		if remote.Protocol == "" {
+			remote.Protocol = "https"
+		}
+		if remote.Secret == "" {
+			return fmt.Errorf("remote %s: secret is required", remote.Host)
+		}
+		if remote.CacheTTL != nil && *remote.CacheTTL < 0 {
+			return fmt.Errorf("remote %s: cache_ttl must not be negative", remote.Host)
+		}
+	}
+
+	return nil
+}
+
+// Match returns the remote for a request. A remote with a path pattern
+// wins over a host-wide one; among patterns the longest wins.
+func (c *Config) Match(request *Request) *RemoteConfig {
+	var best *RemoteConfig
+	for i := range c.Remotes {
+		remote := &c.Remotes[i]
+		if remote.Protocol != request.Protocol || remote.Host != strings.ToLower(request.Host) {
+			continue
+		}
+		if remote.Path != "" {
+			matched, _ := path.Match(remote.Path, strings.TrimSuffix(request.Path, ".git"))
+			if !matched {
+				matched, _ = path.Match(remote.Path, request.Path)
+			}
+			if !matched {
+				continue
+			}
+		}
+		if best == nil || len(remote.Path) > len(best.Path) {
+			best = remote
+		}
+	}
+	return best
+}
+
+// TTL returns the cache lease for a remote
+func (c *Config) TTL(remote *RemoteConfig) time.Duration {
+	if remote.CacheTTL != nil {
+		return *remote.CacheTTL
+	}
+	return c.CacheTTL
+}
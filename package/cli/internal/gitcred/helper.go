@@ -0,0 +1,91 @@
+package gitcred
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// SecretSource reads a secret value and its expiry from the vault
+type SecretSource interface {
+	Secret(ctx context.Context, id string) (value string, expiresAt *time.Time, err error)
+}
+
+// Helper answers git credential requests from vault secrets
+type Helper struct {
+	config *Config
+	source SecretSource
+	cache  *Cache
+}
+
+// NewHelper creates a git credential helper
+func NewHelper(config *Config, source SecretSource, cache *Cache) *Helper {
+	return &Helper{
+		config: config,
+		source: source,
+		cache:  cache,
+	}
+}
+
+// Get returns the credential for a remote, or nil when no remote matches
+// so git can fall through to its next helper
+func (h *Helper) Get(ctx context.Context, request *Request) (*Credential, error) {
+	remote := h.config.Match(request)
+	if remote == nil {
+		return nil, nil
+	}
+
+	if credential, ok := h.cache.Get(remote.Secret); ok {
+		return credential, nil
+	}
+
+	value, expiresAt, err := h.source.Secret(ctx, remote.Secret)
+	if err != nil {
+		return nil, err
+	}
+	credential := parseCredential(value, remote.Username)
+	if credential.ExpiresAt == nil {
+		credential.ExpiresAt = expiresAt
+	}
+
+	// A failed cache write only costs a vault round trip next time
+	_ = h.cache.Put(remote.Secret, credential, h.config.TTL(remote))
+	return credential, nil
+}
+
+// Erase drops the cached credential for a remote. Git calls it when the
+// remote rejected the credential, e.g. after the secret was rotated.
+func (h *Helper) Erase(request *Request) error {
+	remote := h.config.Match(request)
+	if remote == nil {
+		return nil
+	}
+	return h.cache.Delete(remote.Secret)
+}
+
+// parseCredential accepts a JSON object with a username and a password or
+// token, optionally with an RFC 3339 expires_at for short-lived tokens,
+// or treats the whole value as the password
+func parseCredential(value, username string) *Credential {
+	trimmed := strings.TrimSpace(value)
+	if strings.HasPrefix(trimmed, "{") {
+		var parsed struct {
+			Username  string     `json:"username"`
+			Password  string     `json:"password"`
+			Token     string     `json:"token"`
+			ExpiresAt *time.Time `json:"expires_at"`
+		}
+		if err := json.Unmarshal([]byte(trimmed), &parsed); err == nil && (parsed.Password != "" || parsed.Token != "") {
+			credential := &Credential{Username: parsed.Username, Password: parsed.Password, ExpiresAt: parsed.ExpiresAt}
+			if credential.Password == "" {
+				credential.Password = parsed.Token
+			}
+			if credential.Username == "" {
+				credential.Username = username
+			}
+			return credential
+		}
+	}
+	return &Credential{Username: username, Password: strings.TrimRight(value, "\r\n")}
+}
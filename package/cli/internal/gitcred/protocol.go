@@ -0,0 +1,107 @@
+package gitcred
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Request is the credential description git writes to a helper: one
+// key=value attribute per line, ended by a blank line or EOF
+type Request struct {
+	Protocol string
+	Host     string
+	Path     string
+	Username string
+	Password string
+}
+
+// ReadRequest parses a credential description. Attributes the helper does
+// not use are ignored; url is expanded into its parts.
+func ReadRequest(r io.Reader) (*Request, error) {
+	request := &Request{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			break
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid credential attribute %q", line)
+		}
+
+		switch key {
+		case "protocol":
+			request.Protocol = value
+		case "host":
+			request.Host = value
+		case "path":
+			request.Path = value
+		case "username":
+			request.Username = value
+		case "password":
+			request.Password = value
+		case "url":
+			if err := request.setURL(value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read credential request: %w", err)
+	}
+
+	request.Path = strings.Trim(request.Path, "/")
+	return request, nil
+}
+
+func (r *Request) setURL(value string) error {
+	protocol, rest, ok := strings.Cut(value, "://")
+	if !ok {
+		return fmt.Errorf("invalid credential url %q", value)
+	}
+	r.Protocol = protocol
+
+	host, path, _ := strings.Cut(rest, "/")
+	if at := strings.LastIndex(host, "@"); at >= 0 {
+		host = host[at+1:]
+	}
+	r.Host = host
+	r.Path = path
+	return nil
+}
+
+// Credential is what the helper answers to get
+type Credential struct {
+	Username string
+	Password string
+
+	// ExpiresAt tells git when the password stops working, so it does not
+	// hand an expired token to another helper's store
+	ExpiresAt *time.Time
+}
+
+// WriteCredential writes a credential in the helper output format
+func WriteCredential(w io.Writer, credential *Credential) error {
+	for _, value := range []string{credential.Username, credential.Password} {
+		if strings.ContainsAny(value, "\n\x00") {
+			return fmt.Errorf("credential contains a newline or NUL byte")
+		}
+	}
+
+	var b strings.Builder
+	if credential.Username != "" {
+		fmt.Fprintf(&b, "username=%s\n", credential.Username)
+	}
+	fmt.Fprintf(&b, "password=%s\n", credential.Password)
+	if credential.ExpiresAt != nil {
+		fmt.Fprintf(&b, "password_expiry_utc=%s\n", strconv.FormatInt(credential.ExpiresAt.Unix(), 10))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -126,10 +126,19 @@ type ServerInfo struct {
 	Capabilities []string `json:"capabilities"`
 
 	// Server uptime
-	Uptime time.Duration `json:"uptime"`
+	Uptime string `json:"uptime"`
+
+	// Agent process ID
+	PID int `json:"pid"`
 
 	// Connection count
 	ConnectionCount int `json:"connectionCount"`
+
+	// Capability token formats the agent issues
+	TokenFormats []string `json:"tokenFormats,omitempty"`
+
+	// Agent public key (base64), for verifying COSE tokens
+	PublicKey string `json:"publicKey,omitempty"`
 }
 
 // DefaultClientConfig returns default client configuration
@@ -272,17 +281,37 @@ func (c *Client) RequestCapability(request *types.CapabilityRequest) (*types.Cap
 	return &capabilityResponse, nil
 }
 
+// NegotiateFormat returns the first of the preferred token formats the
+// agent supports, or json, which every agent issues
+func (c *Client) NegotiateFormat(preferred ...string) string {
+	if c.state == nil || c.state.ServerInfo == nil {
+		return "json"
+	}
+	for _, format := range preferred {
+		for _, supported := range c.state.ServerInfo.TokenFormats {
+			if format == supported {
+				return format
+			}
+		}
+	}
+	return "json"
+}
+
+// ValidateToken validates an encoded capability token
+func (c *Client) ValidateToken(token string, context *types.RequestContext) (*types.ValidationResult, error) {
+	return c.validate(map[string]interface{}{"token": token}, context)
+}
+
 // ValidateCapability validates a capability
 func (c *Client) ValidateCapability(capabilityID string, context *types.RequestContext) (*types.ValidationResult, error) {
+	return c.validate(map[string]interface{}{"capability_id": capabilityID}, context)
+}
+
+func (c *Client) validate(payload map[string]interface{}, context *types.RequestContext) (*types.ValidationResult, error) {
 	if !c.connected {
 		return nil, fmt.Errorf("not connected")
 	}
 
-	// Create request payload
-	payload := map[string]interface{}{
-		"capability_id": capabilityID,
-	}
-
 	if context != nil {
 		payload["context"] = context
 	}
@@ -317,6 +346,37 @@ func (c *Client) ValidateCapability(capabilityID string, context *types.RequestC
 	return &validationResult, nil
 }
 
+// ReleaseCapability ends an in-flight use of a concurrency-limited capability
+func (c *Client) ReleaseCapability(capabilityID string) error {
+	if !c.connected {
+		return fmt.Errorf("not connected")
+	}
+
+	// Create protocol message
+	protocol := &Protocol{
+		Version:   "1.0",
+		Type:      TypeCapabilityRelease,
+		ID:        c.generateMessageID(),
+		Timestamp: time.Now(),
+		Payload: map[string]interface{}{
+			"capability_id": capabilityID,
+		},
+	}
+
+	// Send request and get response
+	response, err := c.sendRequest(protocol)
+	if err != nil {
+		return err
+	}
+
+	// Parse response
+	if response.Type == TypeErrorResponse {
+		return fmt.Errorf("server error: %v", response.Payload)
+	}
+
+	return nil
+}
+
 // RevokeCapability revokes a capability
 func (c *Client) RevokeCapability(capabilityID, reason string) error {
 	if !c.connected {
@@ -476,6 +536,62 @@ func (c *Client) Ping() error {
 	return nil
 }
 
+// Upgrade asks the agent to hand over to a freshly started agent binary
+// and waits up to timeout for the new process to answer on this same
+// connection. It returns the new process ID.
+func (c *Client) Upgrade(timeout time.Duration) (int, error) {
+	if !c.connected {
+		return 0, fmt.Errorf("not connected")
+	}
+
+	// Create protocol message
+	protocol := &Protocol{
+		Version:   "1.0",
+		Type:      TypeUpgradeRequest,
+		ID:        c.generateMessageID(),
+		Timestamp: time.Now(),
+	}
+
+	// Send request and get response
+	response, err := c.sendRequest(protocol)
+	if err != nil {
+		return 0, err
+	}
+
+	// Parse response
+	if response.Type == TypeErrorResponse {
+		return 0, fmt.Errorf("server error: %v", response.Payload)
+	}
+	oldPID := payloadPID(response)
+
+	// The connection moves to the new process, which reports its own PID
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(200 * time.Millisecond)
+		status, err := c.sendRequest(&Protocol{
+			Version:   "1.0",
+			Type:      TypeStatusRequest,
+			ID:        c.generateMessageID(),
+			Timestamp: time.Now(),
+		})
+		if err != nil {
+			return 0, fmt.Errorf("lost the agent during upgrade: %w", err)
+		}
+		if pid := payloadPID(status); pid != 0 && pid != oldPID {
+			return pid, nil
+		}
+	}
+
+	return 0, fmt.Errorf("agent was not upgraded within %s", timeout)
+}
+
+// payloadPID returns the agent process ID a response reports
+func payloadPID(response *Protocol) int {
+	payload, _ := response.Payload.(map[string]interface{})
+	pid, _ := payload["pid"].(float64)
+	return int(pid)
+}
+
 // IsConnected returns the connection status
 func (c *Client) IsConnected() bool {
 	return c.connected
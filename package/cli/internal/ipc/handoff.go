@@ -0,0 +1,301 @@
+package ipc
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/capability"
+)
+
+// Environment through which an upgraded agent finds what its predecessor
+// passed down, as descriptor numbers
+const (
+	// ListenFDEnv names the inherited listening socket
+	ListenFDEnv = "AETHER_AGENT_LISTEN_FD"
+
+	// HandoffFDEnv names the channel the state and connections arrive on
+	HandoffFDEnv = "AETHER_AGENT_HANDOFF_FD"
+)
+
+// Frames on the handoff channel, tagged by their first byte
+const (
+	frameState = 'S' // a chunk of the JSON-encoded engine state
+	frameConn  = 'C' // a connection header, its descriptor attached
+	frameEnd   = 'E' // the predecessor has nothing more to hand over
+	frameReady = 'R' // the successor accepts on the socket
+)
+
+const (
+	// Largest state chunk per frame
+	handoffChunkSize = 32 * 1024
+
+	// Largest frame a successor reads
+	maxHandoffFrame = 256 * 1024
+
+	// How long a successor waits for its predecessor to hand over
+	handoffReceiveTimeout = time.Minute
+
+	// First descriptor passed down, as with systemd socket activation
+	listenFDsStart = 3
+)
+
+var (
+	ErrUpgradeUnsupported = errors.New("agent upgrades are only supported on Linux")
+	ErrUpgradeInProgress  = errors.New("an agent upgrade is already in progress")
+)
+
+// Handoff is what a predecessor handed over during an upgrade: its engine
+// state and its open connections
+type Handoff struct {
+	// Engine state, to build the capability engine from with
+	// capability.NewEngineFromState
+	State *capability.EngineState
+
+	// Channel to the predecessor
+	channel *net.UnixConn
+
+	// Connections to keep serving
+	conns []*Connection
+}
+
+// handoffHeader describes a connection handed over
+type handoffHeader struct {
+	ID            string                 `json:"id"`
+	RemoteAddr    string                 `json:"remoteAddr"`
+	Authenticated bool                   `json:"authenticated"`
+	Identity      string                 `json:"identity"`
+	Metadata      map[string]interface{} `json:"metadata,omitempty"`
+	Pending       []byte                 `json:"pending,omitempty"`
+}
+
+// Close gives up on a handoff that will not be used; the predecessor
+// carries on serving
+func (h *Handoff) Close() error {
+	for _, conn := range h.conns {
+		conn.Conn.Close()
+	}
+	h.conns = nil
+	return h.channel.Close()
+}
+
+// inheritedListener returns the listening socket passed down by a
+// predecessor, or by systemd socket activation; nil when there is none
+func inheritedListener() (net.Listener, error) {
+	fd := -1
+	if value := os.Getenv(ListenFDEnv); value != "" {
+		os.Unsetenv(ListenFDEnv)
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", ListenFDEnv, err)
+		}
+		fd = n
+	} else if os.Getenv("LISTEN_PID") == strconv.Itoa(os.Getpid()) {
+		count, _ := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+		if count > 0 {
+			fd = listenFDsStart
+		}
+	}
+	if fd < 0 {
+		return nil, nil
+	}
+
+	file := os.NewFile(uintptr(fd), "agent.sock")
+	defer file.Close()
+	return net.FileListener(file)
+}
+
+// adopt serves the connections a predecessor handed over and tells it to
+// step down
+func (s *Server) adopt(handoff *Handoff) {
+	s.connMutex.Lock()
+	for _, conn := range handoff.conns {
+		s.connections[conn.ID] = conn
+	}
+	s.connMutex.Unlock()
+
+	for _, conn := range handoff.conns {
+		s.wg.Add(1)
+		go s.handleConnection(conn)
+	}
+
+	if _, err := handoff.channel.Write([]byte{frameReady}); err != nil && s.config.EnableLogging {
+		fmt.Printf("Handoff acknowledgement failed: %v\n", err)
+	}
+	handoff.channel.Close()
+
+	// Under systemd this process becomes the service's main process;
+	// the unit needs NotifyAccess=all for that
+	notifySystemd(fmt.Sprintf("MAINPID=%d\nREADY=1", os.Getpid()))
+
+	if s.config.EnableLogging {
+		fmt.Printf("Took over %d connections from the previous agent\n", len(handoff.conns))
+	}
+}
+
+// handleUpgradeRequest starts an upgrade in the background; the connection
+// that asked is handed over too, so the successor answers its next request
+func (s *Server) handleUpgradeRequest(conn *Connection, protocol *Protocol) *Protocol {
+	response := &Protocol{
+		Version:   "1.0",
+		Type:      TypeUpgradeResponse,
+		ID:        protocol.ID,
+		Timestamp: time.Now(),
+	}
+
+	if !upgradeSupported {
+		response.Type = TypeErrorResponse
+		response.Payload = map[string]interface{}{
+			"error": ErrUpgradeUnsupported.Error(),
+		}
+		return response
+	}
+	if !s.upgradeMutex.TryLock() {
+		response.Type = TypeErrorResponse
+		response.Payload = map[string]interface{}{
+			"error": ErrUpgradeInProgress.Error(),
+		}
+		return response
+	}
+
+	go func() {
+		defer s.upgradeMutex.Unlock()
+		if _, err := s.upgrade(); err != nil && s.config.EnableLogging {
+			fmt.Printf("Upgrade failed: %v\n", err)
+		}
+	}()
+
+	response.Payload = map[string]interface{}{
+		"status": "upgrading",
+		"pid":    os.Getpid(),
+	}
+	return response
+}
+
+// Upgrade re-executes the agent binary as a successor and hands it the
+// listening socket, the engine state and the open connections, then stops
+// this server without removing the socket file. Clients never see the
+// socket go away: new connections wait in its backlog and open ones move
+// between two messages. If the successor does not take over within the
+// drain timeout it is killed and this server carries on. Upgrade returns
+// the successor's PID.
+func (s *Server) Upgrade() (int, error) {
+	if !upgradeSupported {
+		return 0, ErrUpgradeUnsupported
+	}
+	if !s.upgradeMutex.TryLock() {
+		return 0, ErrUpgradeInProgress
+	}
+	defer s.upgradeMutex.Unlock()
+	return s.upgrade()
+}
+
+// detachConnections takes every connection from its handler between two
+// messages, waiting for in-flight requests until deadline. It returns the
+// detached connections and how many were still busy.
+func (s *Server) detachConnections(deadline time.Time) ([]*Connection, int) {
+	s.connMutex.RLock()
+	conns := make([]*Connection, 0, len(s.connections))
+	for _, conn := range s.connections {
+		conns = append(conns, conn)
+	}
+	s.connMutex.RUnlock()
+
+	// Buffered so handlers never block, even after the deadline
+	s.detached = make(chan *Connection, len(conns))
+	for _, conn := range conns {
+		conn.Mutex.Lock()
+		conn.detaching = true
+		conn.Conn.SetReadDeadline(time.Now())
+		conn.Mutex.Unlock()
+	}
+
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+
+	detached := make([]*Connection, 0, len(conns))
+	for received := 0; received < len(conns); received++ {
+		select {
+		case conn := <-s.detached:
+			// nil when the connection closed meanwhile
+			if conn != nil {
+				detached = append(detached, conn)
+			}
+		case <-timer.C:
+			return detached, len(conns) - received
+		}
+	}
+	return detached, 0
+}
+
+// reattach gives connections back to their handlers after a failed
+// upgrade; those still busy are given back once their request completes
+func (s *Server) reattach(conns []*Connection, busy int) {
+	for _, conn := range conns {
+		s.resume(conn)
+	}
+
+	if busy > 0 {
+		detached := s.detached
+		go func() {
+			for i := 0; i < busy; i++ {
+				if conn := <-detached; conn != nil {
+					s.resume(conn)
+				}
+			}
+		}()
+	}
+}
+
+// resume restarts the handler of a detached connection
+func (s *Server) resume(conn *Connection) {
+	select {
+	case <-s.shutdown:
+		conn.Conn.Close()
+		return
+	default:
+	}
+
+	conn.Mutex.Lock()
+	conn.detaching = false
+	conn.Mutex.Unlock()
+
+	s.wg.Add(1)
+	go s.handleConnection(conn)
+}
+
+// retire closes this server's copies of the connections a successor now
+// serves and stops the server, leaving the socket file in place
+func (s *Server) retire(conns []*Connection) {
+	s.connMutex.Lock()
+	for _, conn := range conns {
+		delete(s.connections, conn.ID)
+		conn.Conn.Close()
+	}
+	s.connMutex.Unlock()
+
+	close(s.handedOff)
+	s.Stop()
+}
+
+// notifySystemd sends a state update to systemd when it supervises the
+// agent
+func notifySystemd(state string) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return
+	}
+
+	conn, err := net.Dial("unixgram", socket)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	conn.Write([]byte(state))
+}
@@ -0,0 +1,307 @@
+package ipc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/capability"
+)
+
+const upgradeSupported = true
+
+// upgrade hands over to a successor; the caller holds upgradeMutex
+func (s *Server) upgrade() (int, error) {
+	if !s.running {
+		return 0, fmt.Errorf("server is not running")
+	}
+	unixListener, ok := s.listener.(*net.UnixListener)
+	if !ok {
+		return 0, fmt.Errorf("listener is not a Unix socket")
+	}
+
+	// The binary at the original path, which a package upgrade replaced
+	binary, err := os.Executable()
+	if err != nil {
+		return 0, fmt.Errorf("failed to locate agent binary: %w", err)
+	}
+
+	listenerFile, err := unixListener.File()
+	if err != nil {
+		return 0, fmt.Errorf("failed to duplicate socket listener: %w", err)
+	}
+	defer listenerFile.Close()
+
+	channel, peer, err := handoffChannel()
+	if err != nil {
+		return 0, err
+	}
+	defer channel.Close()
+
+	s.pauseAccepting()
+
+	cmd := exec.Command(binary, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = []*os.File{listenerFile, peer}
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("%s=%d", ListenFDEnv, listenFDsStart),
+		fmt.Sprintf("%s=%d", HandoffFDEnv, listenFDsStart+1),
+	)
+	err = cmd.Start()
+	// Only the successor holds the peer end, so its exit ends the channel
+	peer.Close()
+	if err != nil {
+		s.startAccepting()
+		return 0, fmt.Errorf("failed to start successor: %w", err)
+	}
+
+	deadline := time.Now().Add(s.config.DrainTimeout)
+	conns, busy := s.detachConnections(deadline)
+
+	err = sendHandoff(channel, s.engine.ExportState(), conns, deadline)
+	if err == nil {
+		err = awaitReady(channel, deadline)
+	}
+	if err != nil {
+		// Kill the successor before serving the connections again, so
+		// only one process ever reads them
+		cmd.Process.Kill()
+		cmd.Wait()
+		s.reattach(conns, busy)
+		s.startAccepting()
+		return 0, fmt.Errorf("successor did not take over: %w", err)
+	}
+
+	pid := cmd.Process.Pid
+	cmd.Process.Release()
+
+	// The successor serves the socket file now
+	unixListener.SetUnlinkOnClose(false)
+
+	if s.config.EnableLogging {
+		fmt.Printf("Handed over to agent process %d (%d connections, %d cut off)\n", pid, len(conns), busy)
+	}
+
+	s.retire(conns)
+	return pid, nil
+}
+
+// handoffChannel creates the socket pair the state and connections are
+// sent over; the returned file is the successor's end
+func handoffChannel() (*net.UnixConn, *os.File, error) {
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_SEQPACKET|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create handoff channel: %w", err)
+	}
+
+	local := os.NewFile(uintptr(fds[0]), "handoff")
+	defer local.Close()
+	peer := os.NewFile(uintptr(fds[1]), "handoff-peer")
+
+	conn, err := net.FileConn(local)
+	if err != nil {
+		peer.Close()
+		return nil, nil, fmt.Errorf("failed to open handoff channel: %w", err)
+	}
+	return conn.(*net.UnixConn), peer, nil
+}
+
+// sendHandoff sends the engine state in chunks, then each connection with
+// its descriptor attached
+func sendHandoff(channel *net.UnixConn, state *capability.EngineState, conns []*Connection, deadline time.Time) error {
+	channel.SetWriteDeadline(deadline)
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal engine state: %w", err)
+	}
+	for len(data) > 0 {
+		n := min(len(data), handoffChunkSize)
+		if _, err := channel.Write(append([]byte{frameState}, data[:n]...)); err != nil {
+			return fmt.Errorf("failed to send engine state: %w", err)
+		}
+		data = data[n:]
+	}
+
+	for _, conn := range conns {
+		if err := sendConn(channel, conn); err != nil {
+			return fmt.Errorf("failed to send connection %s: %w", conn.ID, err)
+		}
+	}
+
+	if _, err := channel.Write([]byte{frameEnd}); err != nil {
+		return fmt.Errorf("failed to end handoff: %w", err)
+	}
+	return nil
+}
+
+// sendConn sends one connection's header with a duplicate of its
+// descriptor attached
+func sendConn(channel *net.UnixConn, conn *Connection) error {
+	unixConn, ok := conn.Conn.(*net.UnixConn)
+	if !ok {
+		return fmt.Errorf("not a Unix socket")
+	}
+	file, err := unixConn.File()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	header, err := json.Marshal(handoffHeader{
+		ID:            conn.ID,
+		RemoteAddr:    conn.RemoteAddr,
+		Authenticated: conn.Authenticated,
+		Identity:      conn.Identity,
+		Metadata:      conn.Metadata,
+		Pending:       conn.pending,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, _, err = channel.WriteMsgUnix(append([]byte{frameConn}, header...), syscall.UnixRights(int(file.Fd())), nil)
+	return err
+}
+
+// awaitReady waits for the successor to accept on the socket
+func awaitReady(channel *net.UnixConn, deadline time.Time) error {
+	channel.SetReadDeadline(deadline)
+
+	frame := make([]byte, 1)
+	n, err := channel.Read(frame)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return io.ErrUnexpectedEOF
+	}
+	if frame[0] != frameReady {
+		return fmt.Errorf("unexpected handoff frame %q", frame[0])
+	}
+	return nil
+}
+
+// ReceiveHandoff reads what the predecessor handed over when this process
+// was started by an upgrade, and nil otherwise. Build the engine from its
+// State and pass it to the server with UseHandoff before Start.
+func ReceiveHandoff() (*Handoff, error) {
+	value := os.Getenv(HandoffFDEnv)
+	if value == "" {
+		return nil, nil
+	}
+	os.Unsetenv(HandoffFDEnv)
+
+	fd, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", HandoffFDEnv, err)
+	}
+	file := os.NewFile(uintptr(fd), "handoff")
+	conn, err := net.FileConn(file)
+	file.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open handoff channel: %w", err)
+	}
+	channel, ok := conn.(*net.UnixConn)
+	if !ok {
+		conn.Close()
+		return nil, fmt.Errorf("handoff channel is not a Unix socket")
+	}
+
+	handoff := &Handoff{channel: channel}
+	if err := handoff.receive(); err != nil {
+		handoff.Close()
+		return nil, err
+	}
+	return handoff, nil
+}
+
+// receive reads frames until the predecessor ends the handoff
+func (h *Handoff) receive() error {
+	h.channel.SetReadDeadline(time.Now().Add(handoffReceiveTimeout))
+	defer h.channel.SetReadDeadline(time.Time{})
+
+	var state []byte
+	frame := make([]byte, maxHandoffFrame)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	for {
+		n, oobn, flags, _, err := h.channel.ReadMsgUnix(frame, oob)
+		if err != nil {
+			return fmt.Errorf("failed to read handoff: %w", err)
+		}
+		if flags&(syscall.MSG_TRUNC|syscall.MSG_CTRUNC) != 0 {
+			return fmt.Errorf("handoff frame truncated")
+		}
+		if n == 0 {
+			return fmt.Errorf("failed to read handoff: %w", io.ErrUnexpectedEOF)
+		}
+
+		switch frame[0] {
+		case frameState:
+			state = append(state, frame[1:n]...)
+		case frameConn:
+			conn, err := receiveConn(frame[1:n], oob[:oobn])
+			if err != nil {
+				return err
+			}
+			h.conns = append(h.conns, conn)
+		case frameEnd:
+			if err := json.Unmarshal(state, &h.State); err != nil {
+				return fmt.Errorf("failed to unmarshal engine state: %w", err)
+			}
+			return nil
+		default:
+			return fmt.Errorf("unexpected handoff frame %q", frame[0])
+		}
+	}
+}
+
+// receiveConn rebuilds a connection from its header and descriptor
+func receiveConn(data, oob []byte) (*Connection, error) {
+	messages, err := syscall.ParseSocketControlMessage(oob)
+	if err != nil || len(messages) != 1 {
+		return nil, fmt.Errorf("connection frame carries no descriptor")
+	}
+	fds, err := syscall.ParseUnixRights(&messages[0])
+	if err != nil || len(fds) != 1 {
+		for _, fd := range fds {
+			syscall.Close(fd)
+		}
+		return nil, fmt.Errorf("connection frame carries no descriptor")
+	}
+
+	file := os.NewFile(uintptr(fds[0]), "conn")
+	netConn, err := net.FileConn(file)
+	file.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open handed over connection: %w", err)
+	}
+
+	var header handoffHeader
+	if err := json.Unmarshal(data, &header); err != nil {
+		netConn.Close()
+		return nil, fmt.Errorf("failed to unmarshal connection header: %w", err)
+	}
+	if header.Metadata == nil {
+		header.Metadata = make(map[string]interface{})
+	}
+
+	return &Connection{
+		ID:            header.ID,
+		Conn:          netConn,
+		RemoteAddr:    header.RemoteAddr,
+		Authenticated: header.Authenticated,
+		Identity:      header.Identity,
+		Metadata:      header.Metadata,
+		LastActivity:  time.Now(),
+		pending:       header.Pending,
+	}, nil
+}
@@ -0,0 +1,188 @@
+package ipc
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/capability"
+	"github.com/skygenesisenterprise/aether-vault/package/cli/pkg/types"
+)
+
+// Socket the successor serves, passed down in its environment
+const testSocketEnv = "AETHER_AGENT_TEST_SOCKET"
+
+// An upgrade re-executes the test binary; that process plays the successor
+func TestMain(m *testing.M) {
+	if os.Getenv(HandoffFDEnv) != "" {
+		if err := runSuccessor(); err != nil {
+			fmt.Fprintf(os.Stderr, "successor: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runSuccessor takes over as an upgraded agent would and serves until
+// SIGTERM
+func runSuccessor() error {
+	handoff, err := ReceiveHandoff()
+	if err != nil {
+		return err
+	}
+	store, err := capability.NewStore(testStoreConfig())
+	if err != nil {
+		return err
+	}
+	engine, err := capability.NewEngineFromState(capability.DefaultEngineConfig(), store, handoff.State)
+	if err != nil {
+		handoff.Close()
+		return err
+	}
+
+	server, err := NewServer(testServerConfig(os.Getenv(testSocketEnv)), engine, nil)
+	if err != nil {
+		handoff.Close()
+		return err
+	}
+	server.UseHandoff(handoff)
+	if err := server.Start(); err != nil {
+		handoff.Close()
+		return err
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM)
+	select {
+	case <-signals:
+	case <-time.After(time.Minute):
+	}
+	return server.Stop()
+}
+
+func testServerConfig(socketPath string) *ServerConfig {
+	config := DefaultServerConfig()
+	config.SocketPath = socketPath
+	config.EnableLogging = false
+	config.DrainTimeout = 10 * time.Second
+	return config
+}
+
+func testStoreConfig() *capability.StoreConfig {
+	config := capability.DefaultStoreConfig()
+	config.EnablePersistence = false
+	return config
+}
+
+func testClient(t *testing.T, socketPath string) *Client {
+	t.Helper()
+	config := DefaultClientConfig()
+	config.SocketPath = socketPath
+	config.EnableLogging = false
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	if err := client.Connect(); err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestUpgradeHandsOverListenerAndState(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "agent.sock")
+	t.Setenv(testSocketEnv, socketPath)
+
+	store, err := capability.NewStore(testStoreConfig())
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	engine, err := capability.NewEngine(capability.DefaultEngineConfig(), store)
+	if err != nil {
+		t.Fatalf("failed to create engine: %v", err)
+	}
+	server, err := NewServer(testServerConfig(socketPath), engine, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	if err := server.Start(); err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	t.Cleanup(func() { server.Stop() })
+
+	socketBefore, err := os.Stat(socketPath)
+	if err != nil {
+		t.Fatalf("failed to stat socket: %v", err)
+	}
+
+	client := testClient(t, socketPath)
+	granted, err := client.RequestCapability(&types.CapabilityRequest{
+		Identity: "handoff-test",
+		Resource: "secret:/app/db",
+		Actions:  []string{"read"},
+	})
+	if err != nil || granted.Status != "granted" {
+		t.Fatalf("capability request = %+v, %v; want granted", granted, err)
+	}
+	capabilityID := granted.Capability.ID
+	before, err := client.GetStatus()
+	if err != nil {
+		t.Fatalf("status before the upgrade failed: %v", err)
+	}
+
+	pid, err := client.Upgrade(30 * time.Second)
+	if err != nil {
+		t.Fatalf("upgrade failed: %v", err)
+	}
+	successor, _ := os.FindProcess(pid)
+	t.Cleanup(func() {
+		successor.Signal(syscall.SIGTERM)
+		// The successor removes the socket file as it stops
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+			if _, err := os.Stat(socketPath); os.IsNotExist(err) {
+				return
+			}
+		}
+	})
+	if pid == os.Getpid() {
+		t.Fatal("upgrade reported this process as the successor")
+	}
+
+	select {
+	case <-server.HandedOff():
+	case <-time.After(10 * time.Second):
+		t.Fatal("the predecessor did not step down")
+	}
+
+	// The successor serves the inherited socket, not a new one
+	socketAfter, err := os.Stat(socketPath)
+	if err != nil {
+		t.Fatalf("socket file gone after the upgrade: %v", err)
+	}
+	if !os.SameFile(socketBefore, socketAfter) {
+		t.Error("successor created a new socket instead of inheriting the listener")
+	}
+
+	fresh := testClient(t, socketPath)
+	status, err := fresh.GetStatus()
+	if err != nil {
+		t.Fatalf("status from the successor failed: %v", err)
+	}
+	if status.PID != pid {
+		t.Errorf("new connection served by pid %d, want the successor %d", status.PID, pid)
+	}
+
+	// The signing keys and the capability issued before moved over
+	if status.PublicKey != before.PublicKey {
+		t.Error("successor signs with a different key")
+	}
+	if result, err := fresh.ValidateCapability(capabilityID, nil); err != nil || !result.Valid {
+		t.Fatalf("validation after the upgrade = %+v, %v; want valid", result, err)
+	}
+}
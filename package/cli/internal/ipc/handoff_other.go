@@ -0,0 +1,14 @@
+//go:build !linux
+
+package ipc
+
+const upgradeSupported = false
+
+func (s *Server) upgrade() (int, error) {
+	return 0, ErrUpgradeUnsupported
+}
+
+// ReceiveHandoff returns nil: only Linux agents are started by an upgrade
+func ReceiveHandoff() (*Handoff, error) {
+	return nil, nil
+}
@@ -1,7 +1,8 @@
 package ipc
 
 import (
-	"context"
+	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -43,14 +44,17 @@ const (
 	TypeCapabilityValidate = "capability_validate"
 	TypeCapabilityRevoke   = "capability_revoke"
 	TypeCapabilityList     = "capability_list"
+	TypeCapabilityRelease  = "capability_release"
 	TypeStatusRequest      = "status_request"
 	TypePingRequest        = "ping_request"
+	TypeUpgradeRequest     = "upgrade_request"
 
 	// Response types
 	TypeCapabilityResponse = "capability_response"
 	TypeValidationResponse = "validation_response"
 	TypeStatusResponse     = "status_response"
 	TypePingResponse       = "ping_response"
+	TypeUpgradeResponse    = "upgrade_response"
 	TypeErrorResponse      = "error_response"
 )
 
@@ -82,6 +86,22 @@ type Server struct {
 
 	// Wait group for graceful shutdown
 	wg sync.WaitGroup
+
+	// Accept loop control; closing acceptStop pauses accepting
+	acceptStop chan struct{}
+	acceptDone chan struct{}
+
+	// Connections detached from their handlers during an upgrade
+	detached chan *Connection
+
+	// Serializes upgrades
+	upgradeMutex sync.Mutex
+
+	// Closed once a successor has taken over the socket
+	handedOff chan struct{}
+
+	// State handed over by the predecessor, adopted on Start
+	handoff *Handoff
 }
 
 // ServerConfig represents server configuration
@@ -118,6 +138,10 @@ type ServerConfig struct {
 
 	// Log level
 	LogLevel string `json:"logLevel"`
+
+	// How long an upgrade waits for in-flight requests and for the
+	// successor to take over
+	DrainTimeout time.Duration `json:"drainTimeout"`
 }
 
 // Connection represents an active connection
@@ -145,6 +169,13 @@ type Connection struct {
 
 	// Connection mutex
 	Mutex sync.RWMutex
+
+	// Set when an upgrade takes the connection from its handler
+	detaching bool
+
+	// Bytes read from the connection but not yet decoded when it was
+	// handed over
+	pending []byte
 }
 
 // DefaultServerConfig returns default server configuration
@@ -160,6 +191,7 @@ func DefaultServerConfig() *ServerConfig {
 		RequestTimeout: 30 * time.Second,
 		EnableLogging:  true,
 		LogLevel:       "info",
+		DrainTimeout:   30 * time.Second,
 	}
 }
 
@@ -175,6 +207,7 @@ func NewServer(config *ServerConfig, engine *capability.Engine, policyEngine *ca
 		policyEngine: policyEngine,
 		connections:  make(map[string]*Connection),
 		shutdown:     make(chan struct{}),
+		handedOff:    make(chan struct{}),
 	}
 
 	return server, nil
@@ -188,31 +221,45 @@ func (s *Server) Start() error {
 		return fmt.Errorf("failed to create socket directory: %w", err)
 	}
 
-	// Remove existing socket file
-	if _, err := os.Stat(s.config.SocketPath); err == nil {
-		if err := os.Remove(s.config.SocketPath); err != nil {
-			return fmt.Errorf("failed to remove existing socket: %w", err)
-		}
-	}
-
-	// Create Unix socket listener
-	listener, err := net.Listen("unix", s.config.SocketPath)
+	// Keep serving a socket passed down by a predecessor or systemd;
+	// clients connecting meanwhile wait in its backlog
+	listener, err := inheritedListener()
 	if err != nil {
-		return fmt.Errorf("failed to create socket listener: %w", err)
+		return fmt.Errorf("failed to use inherited socket: %w", err)
 	}
 
-	// Set socket permissions
-	if err := os.Chmod(s.config.SocketPath, 0755); err != nil {
-		listener.Close()
-		return fmt.Errorf("failed to set socket permissions: %w", err)
+	if listener == nil {
+		// Remove existing socket file
+		if _, err := os.Stat(s.config.SocketPath); err == nil {
+			if err := os.Remove(s.config.SocketPath); err != nil {
+				return fmt.Errorf("failed to remove existing socket: %w", err)
+			}
+		}
+
+		// Create Unix socket listener
+		listener, err = net.Listen("unix", s.config.SocketPath)
+		if err != nil {
+			return fmt.Errorf("failed to create socket listener: %w", err)
+		}
+
+		// Set socket permissions
+		if err := os.Chmod(s.config.SocketPath, 0755); err != nil {
+			listener.Close()
+			return fmt.Errorf("failed to set socket permissions: %w", err)
+		}
 	}
 
 	s.listener = listener
 	s.running = true
 
 	// Start connection handler
-	s.wg.Add(1)
-	go s.connectionHandler()
+	s.startAccepting()
+
+	// Serve the predecessor's connections and tell it to step down
+	if s.handoff != nil {
+		s.adopt(s.handoff)
+		s.handoff = nil
+	}
 
 	if s.config.EnableLogging {
 		fmt.Printf("IPC server started on %s\n", s.config.SocketPath)
@@ -221,6 +268,18 @@ func (s *Server) Start() error {
 	return nil
 }
 
+// UseHandoff makes Start take over from the predecessor that handed over
+// handoff, adopting its connections
+func (s *Server) UseHandoff(handoff *Handoff) {
+	s.handoff = handoff
+}
+
+// HandedOff is closed once a successor has taken over the socket; the
+// process should exit then
+func (s *Server) HandedOff() <-chan struct{} {
+	return s.handedOff
+}
+
 // Stop stops the IPC server
 func (s *Server) Stop() error {
 	if !s.running {
@@ -247,9 +306,13 @@ func (s *Server) Stop() error {
 	// Wait for all goroutines to finish
 	s.wg.Wait()
 
-	// Remove socket file
-	if _, err := os.Stat(s.config.SocketPath); err == nil {
-		os.Remove(s.config.SocketPath)
+	// Remove socket file, unless a successor is serving it
+	select {
+	case <-s.handedOff:
+	default:
+		if _, err := os.Stat(s.config.SocketPath); err == nil {
+			os.Remove(s.config.SocketPath)
+		}
 	}
 
 	if s.config.EnableLogging {
@@ -259,14 +322,35 @@ func (s *Server) Stop() error {
 	return nil
 }
 
+// startAccepting starts the accept loop
+func (s *Server) startAccepting() {
+	s.acceptStop = make(chan struct{})
+	s.acceptDone = make(chan struct{})
+	s.wg.Add(1)
+	go s.connectionHandler(s.acceptStop, s.acceptDone)
+}
+
+// pauseAccepting stops the accept loop and waits for it to exit; the
+// listener stays open, so clients connecting meanwhile wait in its backlog
+func (s *Server) pauseAccepting() {
+	close(s.acceptStop)
+	if unixListener, ok := s.listener.(*net.UnixListener); ok {
+		unixListener.SetDeadline(time.Now())
+	}
+	<-s.acceptDone
+}
+
 // connectionHandler handles incoming connections
-func (s *Server) connectionHandler() {
+func (s *Server) connectionHandler(stop <-chan struct{}, done chan<- struct{}) {
 	defer s.wg.Done()
+	defer close(done)
 
 	for {
 		select {
 		case <-s.shutdown:
 			return
+		case <-stop:
+			return
 		default:
 			// Set accept timeout
 			if tcpListener, ok := s.listener.(*net.UnixListener); ok {
@@ -321,15 +405,31 @@ func (s *Server) connectionHandler() {
 
 // handleConnection handles a single connection
 func (s *Server) handleConnection(conn *Connection) {
+	detached := false
 	defer s.wg.Done()
 	defer func() {
+		if detached {
+			return
+		}
+		conn.Mutex.RLock()
+		detaching := conn.detaching
+		conn.Mutex.RUnlock()
+		if detaching {
+			// The upgrade waiting for this connection need not
+			s.detached <- nil
+		}
 		conn.Conn.Close()
 		s.connMutex.Lock()
 		delete(s.connections, conn.ID)
 		s.connMutex.Unlock()
+		// A client gone mid-use cannot release its slots itself
+		s.engine.ReleaseHolder(conn.ID)
 	}()
 
-	decoder := json.NewDecoder(conn.Conn)
+	// Bytes read ahead before a handover are decoded first
+	pending := bytes.NewReader(conn.pending)
+	conn.pending = nil
+	decoder := json.NewDecoder(io.MultiReader(pending, conn.Conn))
 	encoder := json.NewEncoder(conn.Conn)
 
 	for {
@@ -337,8 +437,12 @@ func (s *Server) handleConnection(conn *Connection) {
 		case <-s.shutdown:
 			return
 		default:
-			// Set read timeout
-			conn.Conn.SetReadDeadline(time.Now().Add(s.config.ConnTimeout))
+			// Set read timeout, unless an upgrade is taking the connection
+			conn.Mutex.Lock()
+			if !conn.detaching {
+				conn.Conn.SetReadDeadline(time.Now().Add(s.config.ConnTimeout))
+			}
+			conn.Mutex.Unlock()
 
 			// Read message
 			var protocol Protocol
@@ -347,6 +451,15 @@ func (s *Server) handleConnection(conn *Connection) {
 					return // Connection closed
 				}
 				if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+					conn.Mutex.Lock()
+					detached = conn.detaching
+					conn.Mutex.Unlock()
+					if detached {
+						// Hand over between messages, with what was read
+						buffered, _ := io.ReadAll(io.MultiReader(decoder.Buffered(), pending))
+						conn.pending = buffered
+						s.detached <- conn
+					}
 					return // Timeout
 				}
 				if s.config.EnableLogging {
@@ -392,10 +505,14 @@ func (s *Server) handleMessage(conn *Connection, protocol *Protocol) *Protocol {
 		response = s.handleCapabilityRevoke(conn, protocol)
 	case TypeCapabilityList:
 		response = s.handleCapabilityList(conn, protocol)
+	case TypeCapabilityRelease:
+		response = s.handleCapabilityRelease(conn, protocol)
 	case TypeStatusRequest:
 		response = s.handleStatusRequest(conn, protocol)
 	case TypePingRequest:
 		response = s.handlePingRequest(conn, protocol)
+	case TypeUpgradeRequest:
+		response = s.handleUpgradeRequest(conn, protocol)
 	default:
 		response.Payload = map[string]interface{}{
 			"error": "unknown message type",
@@ -497,12 +614,13 @@ func (s *Server) handleCapabilityValidate(conn *Connection, protocol *Protocol)
 		return response
 	}
 
-	// Extract capability ID and context
-	capabilityID, ok := payload["capability_id"].(string)
-	if !ok {
+	// Extract capability ID or token, and context
+	capabilityID, _ := payload["capability_id"].(string)
+	token, _ := payload["token"].(string)
+	if capabilityID == "" && token == "" {
 		response.Type = TypeErrorResponse
 		response.Payload = map[string]interface{}{
-			"error": "capability_id is required",
+			"error": "capability_id or token is required",
 		}
 		return response
 	}
@@ -522,9 +640,17 @@ func (s *Server) handleCapabilityValidate(conn *Connection, protocol *Protocol)
 	if conn.Authenticated {
 		context.SourceIP = conn.RemoteAddr
 	}
+	// Slots acquired here are freed when the connection closes
+	context.Holder = conn.ID
 
 	// Validate capability
-	validationResult, err := s.engine.ValidateCapability(capabilityID, context)
+	var validationResult *types.ValidationResult
+	var err error
+	if token != "" {
+		validationResult, err = s.engine.ValidateToken(token, context)
+	} else {
+		validationResult, err = s.engine.ValidateCapability(capabilityID, context)
+	}
 	if err != nil {
 		response.Type = TypeErrorResponse
 		response.Payload = map[string]interface{}{
@@ -590,6 +716,52 @@ func (s *Server) handleCapabilityRevoke(conn *Connection, protocol *Protocol) *P
 	return response
 }
 
+// handleCapabilityRelease ends an in-flight capability use
+func (s *Server) handleCapabilityRelease(conn *Connection, protocol *Protocol) *Protocol {
+	response := &Protocol{
+		Version:   "1.0",
+		Type:      TypeCapabilityResponse,
+		ID:        protocol.ID,
+		Timestamp: time.Now(),
+	}
+
+	// Parse request payload
+	payload, ok := protocol.Payload.(map[string]interface{})
+	if !ok {
+		response.Type = TypeErrorResponse
+		response.Payload = map[string]interface{}{
+			"error": "invalid payload format",
+		}
+		return response
+	}
+
+	// Extract capability ID
+	capabilityID, ok := payload["capability_id"].(string)
+	if !ok {
+		response.Type = TypeErrorResponse
+		response.Payload = map[string]interface{}{
+			"error": "capability_id is required",
+		}
+		return response
+	}
+
+	// Release in-flight use
+	if err := s.engine.ReleaseCapability(capabilityID, conn.ID); err != nil {
+		response.Type = TypeErrorResponse
+		response.Payload = map[string]interface{}{
+			"error": fmt.Sprintf("release failed: %v", err),
+		}
+		return response
+	}
+
+	response.Payload = map[string]interface{}{
+		"status":  "released",
+		"message": "Capability use released successfully",
+	}
+
+	return response
+}
+
 // handleCapabilityList handles capability listing
 func (s *Server) handleCapabilityList(conn *Connection, protocol *Protocol) *Protocol {
 	response := &Protocol{
@@ -661,6 +833,11 @@ func (s *Server) handleStatusRequest(conn *Connection, protocol *Protocol) *Prot
 		"uptime":          time.Since(time.Now()).String(), // TODO: Track actual start time
 		"authenticated":   conn.Authenticated,
 		"connection_id":   conn.ID,
+		"pid":             os.Getpid(),
+		// Clients pick a capability token format from these; non-Go
+		// verifiers check COSE tokens with the public key
+		"tokenFormats": capability.TokenFormats,
+		"publicKey":    base64.StdEncoding.EncodeToString(s.engine.GetPublicKey()),
 	}
 
 	response.Payload = status
@@ -0,0 +1,72 @@
+package nativehost
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Approvals records the origins the user allowed the extension to fill
+// credentials on, per extension, so each new site is prompted once
+type Approvals struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[string]map[string]time.Time
+}
+
+// LoadApprovals reads the approval store, starting empty if it does not
+// exist yet
+func LoadApprovals(path string) (*Approvals, error) {
+	approvals := &Approvals{path: path, entries: make(map[string]map[string]time.Time)}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return approvals, nil
+		}
+		return nil, fmt.Errorf("failed to read approvals: %w", err)
+	}
+	if err := json.Unmarshal(data, &approvals.entries); err != nil {
+		return nil, fmt.Errorf("failed to parse approvals: %w", err)
+	}
+	return approvals, nil
+}
+
+// Approved reports whether the user allowed the extension on origin
+func (a *Approvals) Approved(extension, origin string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	_, ok := a.entries[extension][origin]
+	return ok
+}
+
+// Approve records the user's approval and persists the store
+func (a *Approvals) Approve(extension, origin string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.entries[extension] == nil {
+		a.entries[extension] = make(map[string]time.Time)
+	}
+	a.entries[extension][origin] = time.Now().UTC()
+
+	data, err := json.MarshalIndent(a.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(a.path), 0700); err != nil {
+		return fmt.Errorf("failed to create approvals directory: %w", err)
+	}
+
+	tmp := a.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write approvals: %w", err)
+	}
+	return os.Rename(tmp, a.path)
+}
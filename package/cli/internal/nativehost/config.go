@@ -0,0 +1,125 @@
+package nativehost
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config lists the sites the browser extension may fill credentials for
+type Config struct {
+	Sites []SiteConfig `yaml:"sites"`
+}
+
+// SiteConfig maps one origin to a vault secret
+type SiteConfig struct {
+	// Origin the credential is offered on, e.g. https://github.com
+	Origin string `yaml:"origin"`
+
+	// ID of the vault secret. Its value is either a JSON object with
+	// username and password fields or the bare password.
+	Secret string `yaml:"secret"`
+
+	// Username offered with a bare password
+	Username string `yaml:"username"`
+
+	// Capability resource required to read the credential
+	// (default autofill:<origin>)
+	Resource string `yaml:"resource"`
+}
+
+// LoadConfig reads the native host configuration from a YAML file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read native host config: %w", err)
+	}
+
+	config := &Config{}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse native host config: %w", err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// Validate checks the configuration and fills in site defaults
+func (c *Config) Validate() error {
+	if len(c.Sites) == 0 {
+		return fmt.Errorf("at least one site is required")
+	}
+
+	for i := range c.Sites {
+		site := &c.Sites[i]
+		origin, err := NormalizeOrigin(site.Origin)
+		if err != nil {
+			return fmt.Errorf("site %q: %w", site.Origin, err)
+		}
+		site.Origin = origin
+
+		if site.Secret == "" {
+			return fmt.Errorf("site %q: secret is required", site.Origin)
+		}
+		if site.Resource == "" {
+			site.Resource = "autofill:" + site.Origin
+		}
+	}
+
+	return nil
+}
+
+// SitesFor returns the sites configured for an origin
+func (c *Config) SitesFor(origin string) []SiteConfig {
+	var sites []SiteConfig
+	for _, site := range c.Sites {
+		if site.Origin == origin {
+			sites = append(sites, site)
+		}
+	}
+	return sites
+}
+
+// NormalizeOrigin reduces a URL to scheme://host[:port], dropping default
+// ports. Only https origins are accepted, plus http on loopback for local
+// development: filling a password into a plain-text page would leak it.
+func NormalizeOrigin(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("origin must be an absolute URL")
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+
+	switch scheme {
+	case "https":
+		if port == "443" {
+			port = ""
+		}
+	case "http":
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			return "", fmt.Errorf("http origins are only allowed on loopback addresses")
+		}
+		if port == "80" {
+			port = ""
+		}
+	default:
+		return "", fmt.Errorf("origin must use https")
+	}
+
+	if port != "" {
+		host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	return scheme + "://" + host, nil
+}
@@ -0,0 +1,206 @@
+package nativehost
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/skygenesisenterprise/aether-vault/package/cli/pkg/types"
+)
+
+// HostName is the native messaging host name extensions connect to
+const HostName = "com.skygenesisenterprise.aether_vault"
+
+// capabilityTTL bounds the capability issued for a single fill, in seconds
+const capabilityTTL = 30
+
+// Agent issues and releases capabilities. The IPC client connected to the
+// local agent satisfies it.
+type Agent interface {
+	RequestCapability(request *types.CapabilityRequest) (*types.CapabilityResponse, error)
+	ReleaseCapability(capabilityID string) error
+}
+
+// CredentialSource reads a secret value from the vault
+type CredentialSource interface {
+	Credential(ctx context.Context, secret string) (string, error)
+}
+
+// Host answers credential requests from one browser extension. Each fill
+// needs the user's approval for the origin, once per site, and a
+// single-use capability from the agent, so agent policy has the final say.
+type Host struct {
+	config      *Config
+	agent       Agent
+	credentials CredentialSource
+	approvals   *Approvals
+	prompter    Prompter
+
+	// Extension the browser started the host for, e.g.
+	// chrome-extension://<id>/ or a Firefox add-on ID
+	extension string
+
+	logger *log.Logger
+}
+
+// NewHost creates a native messaging host
+func NewHost(config *Config, agent Agent, credentials CredentialSource, approvals *Approvals, prompter Prompter, extension string, logger *log.Logger) *Host {
+	if logger == nil {
+		logger = log.New(io.Discard, "", 0)
+	}
+	return &Host{
+		config:      config,
+		agent:       agent,
+		credentials: credentials,
+		approvals:   approvals,
+		prompter:    prompter,
+		extension:   extension,
+		logger:      logger,
+	}
+}
+
+// Serve answers messages from r until the browser closes the pipe. Only
+// protocol frames may be written to w; diagnostics go to the logger.
+func (h *Host) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	for {
+		var msg Message
+		if err := ReadMessage(r, &msg); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		reply := h.handle(ctx, &msg)
+		if err := WriteMessage(w, reply); err != nil {
+			return fmt.Errorf("failed to write reply: %w", err)
+		}
+	}
+}
+
+func (h *Host) handle(ctx context.Context, msg *Message) *Reply {
+	switch msg.Type {
+	case "ping":
+		return &Reply{Type: "pong", ID: msg.ID}
+	case "get_credentials":
+		credentials, origin, err := h.getCredentials(ctx, msg.Origin)
+		if err != nil {
+			h.logger.Printf("get_credentials %s: %v", msg.Origin, err)
+			return &Reply{Type: "error", ID: msg.ID, Origin: origin, Error: err.Error()}
+		}
+		return &Reply{Type: "credentials", ID: msg.ID, Origin: origin, Credentials: credentials}
+	default:
+		return &Reply{Type: "error", ID: msg.ID, Error: fmt.Sprintf("unknown message type %q", msg.Type)}
+	}
+}
+
+func (h *Host) getCredentials(ctx context.Context, rawOrigin string) ([]Credential, string, error) {
+	origin, err := NormalizeOrigin(rawOrigin)
+	if err != nil {
+		return nil, "", err
+	}
+
+	sites := h.config.SitesFor(origin)
+	if len(sites) == 0 {
+		return nil, origin, ErrNoSite
+	}
+
+	if err := h.approve(origin); err != nil {
+		return nil, origin, err
+	}
+
+	credentials := make([]Credential, 0, len(sites))
+	for _, site := range sites {
+		credential, err := h.fill(ctx, &site)
+		if err != nil {
+			return nil, origin, err
+		}
+		credentials = append(credentials, *credential)
+	}
+	return credentials, origin, nil
+}
+
+// approve prompts the user the first time the extension asks for an
+// origin and remembers a yes
+func (h *Host) approve(origin string) error {
+	if h.approvals.Approved(h.extension, origin) {
+		return nil
+	}
+
+	allowed, err := h.prompter.Confirm("Aether Vault",
+		fmt.Sprintf("Allow the browser extension to fill saved credentials on %s?\n\nExtension: %s", origin, h.extension))
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrNotApproved
+	}
+
+	if err := h.approvals.Approve(h.extension, origin); err != nil {
+		// The fill itself was approved; the user is asked again next time
+		h.logger.Printf("failed to save approval for %s: %v", origin, err)
+	}
+	return nil
+}
+
+// fill reads one site's credential under a single-use capability
+func (h *Host) fill(ctx context.Context, site *SiteConfig) (*Credential, error) {
+	response, err := h.agent.RequestCapability(&types.CapabilityRequest{
+		Identity: "native-host:" + h.extension,
+		Resource: site.Resource,
+		Actions:  []string{"read"},
+		TTL:      capabilityTTL,
+		MaxUses:  1,
+		Context: &types.RequestContext{
+			Metadata: map[string]interface{}{"origin": site.Origin, "extension": h.extension},
+		},
+		Purpose: "autofill " + site.Origin,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to request capability: %w", err)
+	}
+	if response.Status != "granted" || response.Capability == nil {
+		message := response.Message
+		if message == "" {
+			message = response.Status
+		}
+		return nil, fmt.Errorf("%w: %s", ErrCapabilityDenied, message)
+	}
+	defer func() {
+		if err := h.agent.ReleaseCapability(response.Capability.ID); err != nil {
+			h.logger.Printf("failed to release capability %s: %v", response.Capability.ID, err)
+		}
+	}()
+
+	value, err := h.credentials.Credential(ctx, site.Secret)
+	if err != nil {
+		return nil, err
+	}
+	return parseCredential(value, site.Username), nil
+}
+
+// parseCredential accepts a JSON object with username and password, or
+// treats the whole value as the password
+func parseCredential(value, username string) *Credential {
+	trimmed := strings.TrimSpace(value)
+	if strings.HasPrefix(trimmed, "{") {
+		var credential Credential
+		if err := json.Unmarshal([]byte(trimmed), &credential); err == nil && credential.Password != "" {
+			if credential.Username == "" {
+				credential.Username = username
+			}
+			return &credential
+		}
+	}
+	return &Credential{Username: username, Password: value}
+}
+
+var (
+	ErrNoSite           = errors.New("no credentials are configured for this site")
+	ErrNotApproved      = errors.New("the user did not allow this site")
+	ErrCapabilityDenied = errors.New("capability denied")
+)
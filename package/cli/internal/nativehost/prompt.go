@@ -0,0 +1,64 @@
+package nativehost
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Prompter asks the user to approve a site. The browser owns the host's
+// stdin and stdout, so approval has to come from outside the terminal.
+type Prompter interface {
+	Confirm(title, message string) (bool, error)
+}
+
+// ErrNoPrompter is returned when no dialog tool is available; requests
+// that need approval are then denied
+var ErrNoPrompter = errors.New("no approval dialog is available on this system")
+
+// DialogPrompter shows a native yes/no dialog: osascript on macOS,
+// zenity or kdialog on Linux and PowerShell on Windows
+type DialogPrompter struct{}
+
+// Confirm shows the dialog and reports whether the user chose to allow.
+// Closing or cancelling the dialog counts as a refusal.
+func (DialogPrompter) Confirm(title, message string) (bool, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf(`display dialog %s with title %s buttons {"Deny", "Allow"} default button "Deny" cancel button "Deny" with icon caution`,
+			appleScriptString(message), appleScriptString(title))
+		cmd = exec.Command("osascript", "-e", script)
+	case "windows":
+		script := fmt.Sprintf(`Add-Type -AssemblyName PresentationFramework; if ([System.Windows.MessageBox]::Show(%s, %s, 'YesNo', 'Warning') -ne 'Yes') { exit 1 }`,
+			powerShellString(message), powerShellString(title))
+		cmd = exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	default:
+		if path, err := exec.LookPath("zenity"); err == nil {
+			cmd = exec.Command(path, "--question", "--title", title, "--text", message, "--ok-label", "Allow", "--cancel-label", "Deny")
+		} else if path, err := exec.LookPath("kdialog"); err == nil {
+			cmd = exec.Command(path, "--title", title, "--warningyesno", message)
+		} else {
+			return false, ErrNoPrompter
+		}
+	}
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to show approval dialog: %w", err)
+	}
+	return true, nil
+}
+
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func powerShellString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
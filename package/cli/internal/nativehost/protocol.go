@@ -0,0 +1,77 @@
+package nativehost
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// maxMessageSize caps messages in both directions. Chrome refuses host
+// messages over 1MB, and nothing the extension sends comes close.
+const maxMessageSize = 1 << 20
+
+// Message is a request from the browser extension
+type Message struct {
+	Type string `json:"type"`
+
+	// ID is echoed in the reply so the extension can match it
+	ID string `json:"id,omitempty"`
+
+	// Origin of the page asking for credentials
+	Origin string `json:"origin,omitempty"`
+}
+
+// Credential is a username and password offered for autofill
+type Credential struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password"`
+}
+
+// Reply is a message sent back to the browser extension
+type Reply struct {
+	Type        string       `json:"type"`
+	ID          string       `json:"id,omitempty"`
+	Origin      string       `json:"origin,omitempty"`
+	Credentials []Credential `json:"credentials,omitempty"`
+	Error       string       `json:"error,omitempty"`
+}
+
+// ReadMessage reads one native messaging frame: a 32-bit length in
+// native (little-endian on every supported platform) byte order followed
+// by that many bytes of JSON
+func ReadMessage(r io.Reader, v interface{}) error {
+	var length uint32
+	if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
+		return err
+	}
+	if length > maxMessageSize {
+		return fmt.Errorf("message of %d bytes exceeds the %d byte limit", length, maxMessageSize)
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return fmt.Errorf("failed to read message: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse message: %w", err)
+	}
+	return nil
+}
+
+// WriteMessage writes v as one native messaging frame
+func WriteMessage(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if len(data) > maxMessageSize {
+		return fmt.Errorf("message of %d bytes exceeds the %d byte limit", len(data), maxMessageSize)
+	}
+
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(data))); err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
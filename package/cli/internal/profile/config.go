@@ -0,0 +1,150 @@
+package profile
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var envName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Config holds the named environment profiles of a developer machine
+type Config struct {
+	// How long an activation lasts when the profile sets no TTL
+	DefaultTTL time.Duration `yaml:"default_ttl"`
+
+	Profiles map[string]*Profile `yaml:"profiles"`
+}
+
+// Profile maps environment variables and configuration files to vault
+// secrets
+type Profile struct {
+	Description string `yaml:"description"`
+
+	// How long an activation lasts before its files are removed and its
+	// variables reported for unsetting
+	TTL time.Duration `yaml:"ttl"`
+
+	Env   map[string]SecretRef `yaml:"env"`
+	Files []FileTemplate       `yaml:"files"`
+}
+
+// SecretRef points at a vault secret, or at one field of a secret whose
+// value is a JSON object
+type SecretRef struct {
+	Secret string `yaml:"secret"`
+	Field  string `yaml:"field"`
+}
+
+// FileTemplate renders a text/template to a file. Templates read secrets
+// with {{ secret "<id>" }} or {{ secret "<id>" "<field>" }}. Relative
+// paths are resolved against the directory the profile is activated in.
+type FileTemplate struct {
+	Template string `yaml:"template"`
+	Path     string `yaml:"path"`
+
+	// File mode of the rendered file (default 0600)
+	Mode os.FileMode `yaml:"mode"`
+}
+
+// DefaultConfig returns default profile configuration
+func DefaultConfig() *Config {
+	return &Config{
+		DefaultTTL: 8 * time.Hour,
+	}
+}
+
+// LoadConfig reads the profile configuration from a YAML file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile config: %w", err)
+	}
+
+	config := DefaultConfig()
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse profile config: %w", err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// Validate checks the configuration and fills in profile defaults
+func (c *Config) Validate() error {
+	if c.DefaultTTL <= 0 {
+		return fmt.Errorf("default_ttl must be positive")
+	}
+	if len(c.Profiles) == 0 {
+		return fmt.Errorf("at least one profile is required")
+	}
+
+	for name, profile := range c.Profiles {
+		if profile == nil {
+			return fmt.Errorf("profile %s: is empty", name)
+		}
+		if profile.TTL < 0 {
+			return fmt.Errorf("profile %s: ttl must not be negative", name)
+		}
+		if profile.TTL == 0 {
+			profile.TTL = c.DefaultTTL
+		}
+		if len(profile.Env) == 0 && len(profile.Files) == 0 {
+			return fmt.Errorf("profile %s: env or files is required", name)
+		}
+		for variable, ref := range profile.Env {
+			if !envName.MatchString(variable) {
+				return fmt.Errorf("profile %s: invalid variable name %q", name, variable)
+			}
+			if ref.Secret == "" {
+				return fmt.Errorf("profile %s: %s: secret is required", name, variable)
+			}
+		}
+		for i := range profile.Files {
+			file := &profile.Files[i]
+			if file.Template == "" || file.Path == "" {
+				return fmt.Errorf("profile %s: file %d: template and path are required", name, i+1)
+			}
+			if file.Mode == 0 {
+				file.Mode = 0600
+			}
+		}
+	}
+
+	return nil
+}
+
+// Names returns the profile names in order
+func (c *Config) Names() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Variables returns the profile's variable names in order
+func (p *Profile) Variables() []string {
+	names := make([]string, 0, len(p.Env))
+	for name := range p.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// String describes the reference without revealing the value
+func (r SecretRef) String() string {
+	if r.Field == "" {
+		return r.Secret
+	}
+	return r.Secret + "#" + r.Field
+}
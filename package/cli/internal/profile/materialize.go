@@ -0,0 +1,203 @@
+package profile
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// SecretSource reads a secret value and its expiry from the vault
+type SecretSource interface {
+	Secret(ctx context.Context, id string) (value string, expiresAt *time.Time, err error)
+}
+
+// Materialized is the outcome of activating a profile: the variables to
+// set in the shell and the record of what was written
+type Materialized struct {
+	Env        map[string]string
+	Activation Activation
+}
+
+// Materializer resolves a profile's secrets and renders its files
+type Materializer struct {
+	source SecretSource
+	values map[string]string
+	expiry *time.Time
+}
+
+// NewMaterializer creates a materializer reading from source
+func NewMaterializer(source SecretSource) *Materializer {
+	return &Materializer{
+		source: source,
+		values: make(map[string]string),
+	}
+}
+
+// Activate reads every secret the profile references and writes its files
+// relative to dir. The activation lasts ttl, or the profile's TTL when ttl
+// is zero, and never outlives the secrets it was built from. Nothing is
+// written unless every secret and template resolves.
+func (m *Materializer) Activate(ctx context.Context, name string, profile *Profile, dir string, ttl time.Duration) (*Materialized, error) {
+	if ttl <= 0 {
+		ttl = profile.TTL
+	}
+	now := time.Now()
+
+	env := make(map[string]string, len(profile.Env))
+	for _, variable := range profile.Variables() {
+		value, err := m.resolve(ctx, profile.Env[variable])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", variable, err)
+		}
+		env[variable] = value
+	}
+
+	type rendered struct {
+		path string
+		mode os.FileMode
+		data []byte
+	}
+	files := make([]rendered, 0, len(profile.Files))
+	for _, file := range profile.Files {
+		data, err := m.render(ctx, resolvePath(dir, file.Template))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, rendered{path: resolvePath(dir, file.Path), mode: file.Mode, data: data})
+	}
+
+	expiresAt := now.Add(ttl)
+	if m.expiry != nil && m.expiry.Before(expiresAt) {
+		expiresAt = *m.expiry
+	}
+	if !expiresAt.After(now) {
+		return nil, fmt.Errorf("a secret of profile %s has expired", name)
+	}
+
+	activation := Activation{
+		Profile:     name,
+		Directory:   dir,
+		ActivatedAt: now,
+		ExpiresAt:   expiresAt,
+		Variables:   profile.Variables(),
+	}
+	for _, file := range files {
+		if err := writeFile(file.path, file.data, file.mode); err != nil {
+			return nil, err
+		}
+		activation.Files = append(activation.Files, File{Path: file.path, SHA256: digest(file.data)})
+	}
+
+	return &Materialized{Env: env, Activation: activation}, nil
+}
+
+// resolve returns the value of a reference, reading each secret once
+func (m *Materializer) resolve(ctx context.Context, ref SecretRef) (string, error) {
+	value, ok := m.values[ref.Secret]
+	if !ok {
+		var expiresAt *time.Time
+		var err error
+		value, expiresAt, err = m.source.Secret(ctx, ref.Secret)
+		if err != nil {
+			return "", err
+		}
+		m.values[ref.Secret] = value
+		if expiresAt != nil && (m.expiry == nil || expiresAt.Before(*m.expiry)) {
+			m.expiry = expiresAt
+		}
+	}
+	if ref.Field == "" {
+		return strings.TrimRight(value, "\r\n"), nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return "", fmt.Errorf("secret %s is not a JSON object, it has no field %s", ref.Secret, ref.Field)
+	}
+	field, ok := fields[ref.Field]
+	if !ok {
+		return "", fmt.Errorf("secret %s has no field %s", ref.Secret, ref.Field)
+	}
+	if text, ok := field.(string); ok {
+		return text, nil
+	}
+	encoded, err := json.Marshal(field)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+func (m *Materializer) render(ctx context.Context, path string) ([]byte, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+
+	tmpl, err := template.New(filepath.Base(path)).Funcs(template.FuncMap{
+		"secret": func(id string, field ...string) (string, error) {
+			if len(field) > 1 {
+				return "", fmt.Errorf("secret takes an id and at most one field")
+			}
+			ref := SecretRef{Secret: id}
+			if len(field) == 1 {
+				ref.Field = field[0]
+			}
+			return m.resolve(ctx, ref)
+		},
+	}).Parse(string(source))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", path, err)
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, nil); err != nil {
+		return nil, fmt.Errorf("failed to render template %s: %w", path, err)
+	}
+	return out.Bytes(), nil
+}
+
+// writeFile replaces path atomically, so a reader never sees a partial
+// file and the secret never sits in a file with a wider mode
+func writeFile(path string, data []byte, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func resolvePath(dir, path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(dir, path)
+}
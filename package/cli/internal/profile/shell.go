@@ -0,0 +1,89 @@
+package profile
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Variables the activation sets besides the profile's own, so that later
+// commands know what the shell holds
+const (
+	ActiveVariable  = "AETHER_VAULT_PROFILE"
+	ExpiresVariable = "AETHER_VAULT_PROFILE_EXPIRES"
+)
+
+// Shells whose syntax Export and Unset write
+const (
+	ShellSh   = "sh"
+	ShellFish = "fish"
+)
+
+// Export returns shell code setting the variables of an activation, for
+// the shell to eval
+func Export(shell string, materialized *Materialized) (string, error) {
+	env := make(map[string]string, len(materialized.Env)+2)
+	for name, value := range materialized.Env {
+		env[name] = value
+	}
+	env[ActiveVariable] = materialized.Activation.Profile
+	env[ExpiresVariable] = strconv.FormatInt(materialized.Activation.ExpiresAt.Unix(), 10)
+
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var out strings.Builder
+	for _, name := range names {
+		switch shell {
+		case ShellSh:
+			fmt.Fprintf(&out, "export %s=%s\n", name, quoteSh(env[name]))
+		case ShellFish:
+			fmt.Fprintf(&out, "set -gx %s %s\n", name, quoteFish(env[name]))
+		default:
+			return "", fmt.Errorf("unsupported shell %q (sh or fish)", shell)
+		}
+	}
+	return out.String(), nil
+}
+
+// Unset returns shell code removing the variables of an activation
+func Unset(shell string, variables []string) (string, error) {
+	names := append(append([]string{}, variables...), ActiveVariable, ExpiresVariable)
+
+	var out strings.Builder
+	for _, name := range names {
+		switch shell {
+		case ShellSh:
+			fmt.Fprintf(&out, "unset %s\n", name)
+		case ShellFish:
+			fmt.Fprintf(&out, "set -e %s\n", name)
+		default:
+			return "", fmt.Errorf("unsupported shell %q (sh or fish)", shell)
+		}
+	}
+	return out.String(), nil
+}
+
+// ShellExpired reports whether the activation recorded in the shell's
+// environment has ended
+func ShellExpired(expires string, now time.Time) bool {
+	seconds, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return true
+	}
+	return !time.Unix(seconds, 0).After(now)
+}
+
+func quoteSh(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+func quoteFish(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	return "'" + strings.ReplaceAll(value, "'", `\'`) + "'"
+}
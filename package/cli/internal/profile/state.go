@@ -0,0 +1,158 @@
+package profile
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// State records what activations have materialized so that they can be
+// cleaned up once they expire. It lives in a file readable only by the
+// user, and never holds secret values.
+type State struct {
+	path        string
+	Activations []Activation `json:"activations"`
+}
+
+// Activation is one materialization of a profile
+type Activation struct {
+	Profile     string    `json:"profile"`
+	Directory   string    `json:"directory"`
+	ActivatedAt time.Time `json:"activated_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Variables   []string  `json:"variables"`
+	Files       []File    `json:"files"`
+}
+
+// File is a rendered file; its digest tells whether it was edited since
+type File struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+}
+
+// Expired reports whether the activation has ended
+func (a *Activation) Expired(now time.Time) bool {
+	return !a.ExpiresAt.After(now)
+}
+
+// LoadState reads the state file. A missing or unreadable file starts an
+// empty state.
+func LoadState(path string) *State {
+	state := &State{path: path}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return state
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		state.Activations = nil
+	}
+	return state
+}
+
+// Find returns the latest activation of a profile
+func (s *State) Find(profile string) *Activation {
+	var found *Activation
+	for i := range s.Activations {
+		activation := &s.Activations[i]
+		if activation.Profile == profile && (found == nil || activation.ActivatedAt.After(found.ActivatedAt)) {
+			found = activation
+		}
+	}
+	return found
+}
+
+// Record adds an activation, replacing an earlier one of the same profile
+// in the same directory
+func (s *State) Record(activation Activation) error {
+	kept := s.Activations[:0]
+	for _, existing := range s.Activations {
+		if existing.Profile != activation.Profile || existing.Directory != activation.Directory {
+			kept = append(kept, existing)
+		}
+	}
+	s.Activations = append(kept, activation)
+	sort.Slice(s.Activations, func(i, j int) bool {
+		return s.Activations[i].ActivatedAt.Before(s.Activations[j].ActivatedAt)
+	})
+	return s.save()
+}
+
+// CleanResult reports what Clean removed and what it left in place
+type CleanResult struct {
+	Activations []Activation
+	Removed     []string
+	// Kept are rendered files that were edited after activation
+	Kept []string
+}
+
+// Clean removes the files of the activations selected by match and forgets
+// them. Files edited since they were rendered are left in place.
+func (s *State) Clean(match func(*Activation) bool) (*CleanResult, error) {
+	result := &CleanResult{}
+	kept := s.Activations[:0]
+	for _, activation := range s.Activations {
+		if !match(&activation) {
+			kept = append(kept, activation)
+			continue
+		}
+		for _, file := range activation.Files {
+			data, err := os.ReadFile(file.Path)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", file.Path, err)
+			}
+			if digest(data) != file.SHA256 {
+				result.Kept = append(result.Kept, file.Path)
+				continue
+			}
+			if err := os.Remove(file.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return nil, fmt.Errorf("failed to remove %s: %w", file.Path, err)
+			}
+			result.Removed = append(result.Removed, file.Path)
+		}
+		result.Activations = append(result.Activations, activation)
+	}
+	s.Activations = kept
+
+	if len(result.Activations) == 0 {
+		return result, nil
+	}
+	return result, s.save()
+}
+
+func (s *State) save() error {
+	if len(s.Activations) == 0 {
+		if err := os.Remove(s.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove profile state: %w", err)
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write profile state: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
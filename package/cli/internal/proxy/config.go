@@ -0,0 +1,131 @@
+package proxy
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config represents the proxy configuration
+type Config struct {
+	// Listen address; only loopback addresses are accepted
+	Listen string `yaml:"listen"`
+
+	// How long a fetched upstream credential is reused
+	CredentialTTL time.Duration `yaml:"credential_ttl"`
+
+	// Upstream APIs reachable under /proxy/<name>/
+	Upstreams []UpstreamConfig `yaml:"upstreams"`
+}
+
+// UpstreamConfig represents a single upstream API
+type UpstreamConfig struct {
+	// Name used in the proxy path
+	Name string `yaml:"name"`
+
+	// Base URL of the upstream API
+	URL string `yaml:"url"`
+
+	// ID of the vault secret holding the upstream credential
+	Secret string `yaml:"secret"`
+
+	// Header the credential is sent in (default Authorization)
+	Header string `yaml:"header"`
+
+	// Header value format; {value} is replaced by the credential
+	// (default "Bearer {value}")
+	Format string `yaml:"format"`
+
+	// Capability resource required to use the upstream
+	// (default proxy:/<name>)
+	Resource string `yaml:"resource"`
+}
+
+var upstreamName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// DefaultConfig returns default proxy configuration
+func DefaultConfig() *Config {
+	return &Config{
+		Listen:        "127.0.0.1:8200",
+		CredentialTTL: 5 * time.Minute,
+	}
+}
+
+// LoadConfig reads the proxy configuration from a YAML file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read proxy config: %w", err)
+	}
+
+	config := DefaultConfig()
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse proxy config: %w", err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// Validate checks the configuration and fills in upstream defaults
+func (c *Config) Validate() error {
+	// The proxy hands out credentials to anyone holding a capability, so
+	// it must not be reachable from other hosts
+	host, _, err := net.SplitHostPort(c.Listen)
+	if err != nil {
+		return fmt.Errorf("invalid listen address %q: %w", c.Listen, err)
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return fmt.Errorf("listen address %q is not a loopback address", c.Listen)
+	}
+	if c.CredentialTTL < 0 {
+		return fmt.Errorf("credential_ttl must not be negative")
+	}
+	if len(c.Upstreams) == 0 {
+		return fmt.Errorf("at least one upstream is required")
+	}
+
+	seen := make(map[string]bool)
+	for i := range c.Upstreams {
+		upstream := &c.Upstreams[i]
+		if !upstreamName.MatchString(upstream.Name) {
+			return fmt.Errorf("upstream %q: name must be lowercase letters, digits, '-' or '_'", upstream.Name)
+		}
+		if seen[upstream.Name] {
+			return fmt.Errorf("upstream %q is defined twice", upstream.Name)
+		}
+		seen[upstream.Name] = true
+
+		target, err := url.Parse(upstream.URL)
+		if err != nil || target.Host == "" || (target.Scheme != "https" && target.Scheme != "http") {
+			return fmt.Errorf("upstream %q: url must be an absolute http(s) URL", upstream.Name)
+		}
+		if upstream.Secret == "" {
+			return fmt.Errorf("upstream %q: secret is required", upstream.Name)
+		}
+
+		if upstream.Header == "" {
+			upstream.Header = "Authorization"
+		}
+		if upstream.Format == "" {
+			upstream.Format = "Bearer {value}"
+		}
+		if !strings.Contains(upstream.Format, "{value}") {
+			return fmt.Errorf("upstream %q: format must contain {value}", upstream.Name)
+		}
+		if upstream.Resource == "" {
+			upstream.Resource = "proxy:/" + upstream.Name
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/client"
+)
+
+// VaultCredentials reads upstream credentials from vault secrets
+type VaultCredentials struct {
+	api *client.APIClient
+}
+
+// NewVaultCredentials creates a credential source backed by the server API
+func NewVaultCredentials(api *client.APIClient) *VaultCredentials {
+	return &VaultCredentials{api: api}
+}
+
+// Credential returns the value of the secret with the given ID
+func (v *VaultCredentials) Credential(ctx context.Context, secret string) (string, error) {
+	var response struct {
+		Value string `json:"value"`
+	}
+	if err := v.api.Do(ctx, http.MethodGet, "/secrets/"+url.PathEscape(secret)+"/value", nil, &response); err != nil {
+		return "", fmt.Errorf("failed to read secret %s: %w", secret, err)
+	}
+	if response.Value == "" {
+		return "", fmt.Errorf("secret %s is empty", secret)
+	}
+
+	return response.Value, nil
+}
@@ -0,0 +1,306 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-vault/package/cli/pkg/types"
+)
+
+// CapabilityHeader carries the capability ID presented by the application
+const CapabilityHeader = "X-Aether-Capability"
+
+// Validator validates capabilities locally. Both the capability engine
+// and an IPC client connected to the agent satisfy it.
+type Validator interface {
+	ValidateCapability(capabilityID string, context *types.RequestContext) (*types.ValidationResult, error)
+	ReleaseCapability(capabilityID string) error
+}
+
+// CredentialSource fetches an upstream credential from the vault
+type CredentialSource interface {
+	Credential(ctx context.Context, secret string) (string, error)
+}
+
+// Server is a capability-aware reverse proxy. Applications call
+// /proxy/<upstream>/<path> with a capability instead of the upstream API
+// key; the proxy checks the capability and adds the credential itself.
+type Server struct {
+	config      *Config
+	validator   Validator
+	credentials CredentialSource
+
+	upstreams map[string]*upstream
+
+	// The IPC client is not safe for concurrent use
+	validatorMu sync.Mutex
+
+	cacheMu sync.Mutex
+	cache   map[string]cachedCredential
+
+	httpServer *http.Server
+}
+
+type upstream struct {
+	config *UpstreamConfig
+	proxy  *httputil.ReverseProxy
+}
+
+type cachedCredential struct {
+	value     string
+	expiresAt time.Time
+}
+
+type credentialKey struct{}
+
+// NewServer creates a new proxy server
+func NewServer(config *Config, validator Validator, credentials CredentialSource) (*Server, error) {
+	if config == nil {
+		return nil, fmt.Errorf("proxy config is required")
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		config:      config,
+		validator:   validator,
+		credentials: credentials,
+		upstreams:   make(map[string]*upstream),
+		cache:       make(map[string]cachedCredential),
+	}
+
+	for i := range config.Upstreams {
+		upstreamConfig := &config.Upstreams[i]
+		target, _ := url.Parse(upstreamConfig.URL)
+		s.upstreams[upstreamConfig.Name] = &upstream{
+			config: upstreamConfig,
+			proxy:  s.newReverseProxy(upstreamConfig, target),
+		}
+	}
+
+	return s, nil
+}
+
+// Start listens on the configured address and serves until Stop is called
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.config.Listen)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.config.Listen, err)
+	}
+
+	s.httpServer = &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	if err := s.httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("proxy server failed: %w", err)
+	}
+	return nil
+}
+
+// Stop shuts the server down, waiting for in-flight requests
+func (s *Server) Stop(ctx context.Context) error {
+	if s.httpServer == nil {
+		return nil
+	}
+	return s.httpServer.Shutdown(ctx)
+}
+
+// ServeHTTP authorizes and forwards a proxied request
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name, rest, ok := splitProxyPath(r.URL.Path)
+	if !ok {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "proxy paths look like /proxy/<upstream>/<path>")
+		return
+	}
+
+	upstream, ok := s.upstreams[name]
+	if !ok {
+		writeError(w, http.StatusNotFound, "UNKNOWN_UPSTREAM", fmt.Sprintf("no upstream named %q", name))
+		return
+	}
+
+	capabilityID := r.Header.Get(CapabilityHeader)
+	if capabilityID == "" {
+		writeError(w, http.StatusUnauthorized, "CAPABILITY_REQUIRED", CapabilityHeader+" header is required")
+		return
+	}
+
+	action := methodAction(r.Method)
+	result, err := s.validate(capabilityID, r)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, "AGENT_UNAVAILABLE", fmt.Sprintf("capability validation failed: %v", err))
+		return
+	}
+	if _, held := result.Context["concurrency_slot"]; held {
+		defer s.release(capabilityID)
+	}
+	if !result.Valid {
+		message := "capability is not valid"
+		if len(result.Errors) > 0 {
+			message = result.Errors[0].Message
+		}
+		writeError(w, http.StatusForbidden, "CAPABILITY_INVALID", message)
+		return
+	}
+	if !grants(result, upstream.config.Resource, action) {
+		writeError(w, http.StatusForbidden, "CAPABILITY_SCOPE", fmt.Sprintf("capability does not grant %s on %s", action, upstream.config.Resource))
+		return
+	}
+
+	credential, err := s.credential(r.Context(), upstream.config.Secret)
+	if err != nil {
+		log.Printf("proxy: failed to fetch credential for upstream %s: %v", name, err)
+		writeError(w, http.StatusBadGateway, "CREDENTIAL_UNAVAILABLE", "failed to fetch the upstream credential from the vault")
+		return
+	}
+
+	out := r.Clone(context.WithValue(r.Context(), credentialKey{}, credential))
+	out.URL.Path = rest
+	out.URL.RawPath = ""
+	upstream.proxy.ServeHTTP(w, out)
+}
+
+func (s *Server) newReverseProxy(config *UpstreamConfig, target *url.URL) *httputil.ReverseProxy {
+	return &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(target)
+			r.Out.Host = target.Host
+
+			// Nothing the application sent for authentication reaches the
+			// upstream; only the vault credential does
+			r.Out.Header.Del(CapabilityHeader)
+			r.Out.Header.Del("Authorization")
+			r.Out.Header.Del("Proxy-Authorization")
+			r.Out.Header.Del("Cookie")
+			credential, _ := r.In.Context().Value(credentialKey{}).(string)
+			r.Out.Header.Set(config.Header, strings.ReplaceAll(config.Format, "{value}", credential))
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			log.Printf("proxy: upstream %s failed: %v", config.Name, err)
+			writeError(w, http.StatusBadGateway, "UPSTREAM_UNAVAILABLE", fmt.Sprintf("upstream %s is unavailable", config.Name))
+		},
+	}
+}
+
+func (s *Server) validate(capabilityID string, r *http.Request) (*types.ValidationResult, error) {
+	context := &types.RequestContext{
+		UserAgent: r.UserAgent(),
+		Metadata: map[string]interface{}{
+			"method": r.Method,
+			"path":   r.URL.Path,
+		},
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		context.SourceIP = host
+	}
+
+	s.validatorMu.Lock()
+	defer s.validatorMu.Unlock()
+	return s.validator.ValidateCapability(capabilityID, context)
+}
+
+func (s *Server) release(capabilityID string) {
+	s.validatorMu.Lock()
+	defer s.validatorMu.Unlock()
+	if err := s.validator.ReleaseCapability(capabilityID); err != nil {
+		log.Printf("proxy: failed to release capability %s: %v", capabilityID, err)
+	}
+}
+
+// credential returns the cached credential for a secret, fetching it from
+// the vault when missing or expired
+func (s *Server) credential(ctx context.Context, secret string) (string, error) {
+	s.cacheMu.Lock()
+	cached, ok := s.cache[secret]
+	s.cacheMu.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.value, nil
+	}
+
+	value, err := s.credentials.Credential(ctx, secret)
+	if err != nil {
+		return "", err
+	}
+
+	if s.config.CredentialTTL > 0 {
+		s.cacheMu.Lock()
+		s.cache[secret] = cachedCredential{value: value, expiresAt: time.Now().Add(s.config.CredentialTTL)}
+		s.cacheMu.Unlock()
+	}
+
+	return value, nil
+}
+
+// splitProxyPath splits /proxy/<name>/<rest> into its upstream name and
+// the path forwarded upstream
+func splitProxyPath(path string) (string, string, bool) {
+	trimmed := strings.TrimPrefix(path, "/proxy/")
+	if trimmed == path || trimmed == "" {
+		return "", "", false
+	}
+
+	name, rest, _ := strings.Cut(trimmed, "/")
+	return name, "/" + rest, name != ""
+}
+
+// methodAction maps an HTTP method to the capability action it needs
+func methodAction(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return "read"
+	case http.MethodDelete:
+		return "delete"
+	default:
+		return "write"
+	}
+}
+
+// grants reports whether a validated capability covers resource and action.
+// Results that crossed the IPC socket hold actions as []interface{}.
+func grants(result *types.ValidationResult, resource, action string) bool {
+	if granted, _ := result.Context["resource"].(string); granted != resource {
+		return false
+	}
+
+	var actions []string
+	switch granted := result.Context["actions"].(type) {
+	case []string:
+		actions = granted
+	case []interface{}:
+		for _, value := range granted {
+			if s, ok := value.(string); ok {
+				actions = append(actions, s)
+			}
+		}
+	}
+
+	for _, granted := range actions {
+		if granted == action || granted == "*" || granted == "admin" {
+			return true
+		}
+	}
+	return false
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]string{
+			"code":    code,
+			"message": message,
+		},
+	})
+}
@@ -0,0 +1,76 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// progressWidth is the number of cells in the bar
+const progressWidth = 30
+
+// Progress draws a progress bar on stderr. Only terminals get the bar;
+// otherwise Finish prints a single summary line.
+type Progress struct {
+	label       string
+	total       int
+	done        int
+	failed      int
+	interactive bool
+	drawn       time.Time
+
+	mu sync.Mutex
+}
+
+// NewProgress creates a progress bar for total items
+func NewProgress(label string, total int) *Progress {
+	info, err := os.Stderr.Stat()
+	return &Progress{
+		label:       label,
+		total:       total,
+		interactive: err == nil && info.Mode()&os.ModeCharDevice != 0 && os.Getenv("TERM") != "dumb",
+	}
+}
+
+// Add records finished items, failed or not; safe for concurrent use
+func (p *Progress) Add(done, failed int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done += done
+	p.failed += failed
+	// Redraw at most ten times a second, and always at the end
+	if p.interactive && (p.done == p.total || time.Since(p.drawn) >= 100*time.Millisecond) {
+		p.draw()
+		p.drawn = time.Now()
+	}
+}
+
+// Finish ends the bar
+func (p *Progress) Finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.interactive {
+		p.draw()
+		fmt.Fprintln(os.Stderr)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%s: %d/%d%s\n", p.label, p.done, p.total, p.failedSuffix())
+}
+
+func (p *Progress) draw() {
+	filled := progressWidth
+	if p.total > 0 {
+		filled = p.done * progressWidth / p.total
+	}
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", progressWidth-filled)
+	fmt.Fprintf(os.Stderr, "\r%s %s %d/%d%s", p.label, bar, p.done, p.total, p.failedSuffix())
+}
+
+func (p *Progress) failedSuffix() string {
+	if p.failed == 0 {
+		return ""
+	}
+	return RedText(fmt.Sprintf(" (%d failed)", p.failed))
+}
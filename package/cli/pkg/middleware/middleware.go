@@ -0,0 +1,249 @@
+// Package middleware lets Go resource servers accept capability tokens.
+//
+// Clients send a COSE capability token (vault capability request
+// --token-format cose) in the Authorization header:
+//
+//	Authorization: AetherCap <token>
+//
+// Tokens are verified offline against the public keys of the trusted
+// agents, so a server needs no connection to the agent. Revocation and use
+// counts are only known to the agent and are not enforced; keep TTLs short.
+package middleware
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-vault/package/cli/internal/capability"
+	"github.com/skygenesisenterprise/aether-vault/package/cli/pkg/types"
+)
+
+// Scheme is the Authorization scheme of capability tokens
+const Scheme = "AetherCap"
+
+var (
+	// ErrMissingToken means the request carries no AetherCap credentials
+	ErrMissingToken = errors.New("missing " + Scheme + " authorization")
+
+	// ErrInvalidToken means the token did not verify or has expired
+	ErrInvalidToken = errors.New("invalid capability token")
+
+	// ErrForbidden means the capability does not cover the request
+	ErrForbidden = errors.New("capability does not allow this request")
+)
+
+type contextKey struct{}
+
+// ErrorHandler writes the response for a rejected request. status is 401
+// for missing or invalid tokens and 403 for tokens that do not cover the
+// request.
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, status int, err error)
+
+// Middleware verifies capability tokens and enforces them per route
+type Middleware struct {
+	publicKeys   []ed25519.PublicKey
+	errorHandler ErrorHandler
+}
+
+// New creates a middleware trusting tokens signed by any of publicKeys,
+// the raw Ed25519 keys agents report as publicKey in their status
+func New(publicKeys ...ed25519.PublicKey) *Middleware {
+	return &Middleware{
+		publicKeys:   publicKeys,
+		errorHandler: defaultErrorHandler,
+	}
+}
+
+// UseErrorHandler replaces the plain-text error responses
+func (m *Middleware) UseErrorHandler(handler ErrorHandler) {
+	m.errorHandler = handler
+}
+
+// Require protects a handler with a capability for resource and action.
+// Path wildcards of the route pattern in resource, such as {name}, are
+// replaced with their values; an empty action is derived from the request
+// method (see ActionForMethod).
+//
+//	mux.Handle("GET /db/{name}", mw.Require("secret:/db/{name}", "")(handler))
+func (m *Middleware) Require(resource, action string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			verified, err := m.Verify(r)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", Scheme)
+				m.errorHandler(w, r, http.StatusUnauthorized, err)
+				return
+			}
+
+			wantAction := action
+			if wantAction == "" {
+				wantAction = ActionForMethod(r.Method)
+			}
+			if err := Authorize(verified, expandResource(resource, r), wantAction, r); err != nil {
+				m.errorHandler(w, r, http.StatusForbidden, err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, verified)))
+		})
+	}
+}
+
+// Verify extracts the token of a request and verifies it offline
+func (m *Middleware) Verify(r *http.Request) (*types.Capability, error) {
+	token, err := TokenFromHeader(r.Header.Get("Authorization"))
+	if err != nil {
+		return nil, err
+	}
+	claims, err := capability.VerifyToken(token, m.publicKeys...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	verified, err := claims.Capability()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	return verified, nil
+}
+
+// TokenFromHeader returns the token of an "AetherCap <token>"
+// Authorization header value. The scheme is case-insensitive.
+func TokenFromHeader(header string) (string, error) {
+	scheme, token, found := strings.Cut(strings.TrimSpace(header), " ")
+	if !found || !strings.EqualFold(scheme, Scheme) {
+		return "", ErrMissingToken
+	}
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", ErrMissingToken
+	}
+	return token, nil
+}
+
+// Header formats the Authorization header value for a token
+func Header(token string) string {
+	return Scheme + " " + token
+}
+
+// Authorize checks that a verified capability grants action on resource
+// and that the constraints checkable offline hold for r. Constraints that
+// need the agent or the caller's runtime (environment, attestation, rate
+// and concurrency limits) cannot be checked here, so capabilities that
+// carry them are refused.
+func Authorize(verified *types.Capability, resource, action string, r *http.Request) error {
+	if !matchResource(verified.Resource, resource) {
+		return fmt.Errorf("%w: resource %s not granted", ErrForbidden, resource)
+	}
+	if !hasAction(verified.Actions, action) {
+		return fmt.Errorf("%w: action %s not granted", ErrForbidden, action)
+	}
+
+	constraints := verified.Constraints
+	if constraints == nil {
+		return nil
+	}
+	if len(constraints.Environment) > 0 || constraints.Attestation != nil ||
+		constraints.MaxRequestsPerMinute > 0 || constraints.MaxConcurrentUses > 0 {
+		return fmt.Errorf("%w: capability has constraints that need the agent", ErrForbidden)
+	}
+	if len(constraints.IPAddresses) > 0 {
+		sourceIP := r.RemoteAddr
+		if host, _, err := net.SplitHostPort(sourceIP); err == nil {
+			sourceIP = host
+		}
+		allowed := false
+		for _, ip := range constraints.IPAddresses {
+			if ip == sourceIP {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("%w: %s not in allowed IP addresses", ErrForbidden, sourceIP)
+		}
+	}
+	if constraints.TimeWindow != nil {
+		if err := capability.CheckTimeWindow(constraints.TimeWindow, time.Now()); err != nil {
+			return fmt.Errorf("%w: %v", ErrForbidden, err)
+		}
+	}
+	return nil
+}
+
+// FromContext returns the capability a protected request was allowed with
+func FromContext(ctx context.Context) (*types.Capability, bool) {
+	capability, ok := ctx.Value(contextKey{}).(*types.Capability)
+	return capability, ok
+}
+
+// Identity returns the identity a protected request was made by, or an
+// empty string outside protected handlers
+func Identity(ctx context.Context) string {
+	if capability, ok := FromContext(ctx); ok {
+		return capability.Identity
+	}
+	return ""
+}
+
+// ActionForMethod maps an HTTP method to the capability action it needs:
+// read for safe methods, delete for DELETE and write otherwise
+func ActionForMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return "read"
+	case http.MethodDelete:
+		return "delete"
+	default:
+		return "write"
+	}
+}
+
+// expandResource replaces {name} and {name...} in a resource template with
+// the path values of the matched route
+func expandResource(template string, r *http.Request) string {
+	var expanded strings.Builder
+	for {
+		start := strings.IndexByte(template, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(template[start:], '}')
+		if end < 0 {
+			break
+		}
+		name := strings.TrimSuffix(template[start+1:start+end], "...")
+		expanded.WriteString(template[:start])
+		expanded.WriteString(r.PathValue(name))
+		template = template[start+end+1:]
+	}
+	expanded.WriteString(template)
+	return expanded.String()
+}
+
+// matchResource reports whether a granted resource covers the requested
+// one; a trailing * grants everything below a prefix
+func matchResource(granted, requested string) bool {
+	if strings.HasSuffix(granted, "*") {
+		return strings.HasPrefix(requested, strings.TrimSuffix(granted, "*"))
+	}
+	return granted == requested
+}
+
+func hasAction(actions []string, action string) bool {
+	for _, granted := range actions {
+		if granted == action || granted == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func defaultErrorHandler(w http.ResponseWriter, r *http.Request, status int, err error) {
+	http.Error(w, err.Error(), status)
+}
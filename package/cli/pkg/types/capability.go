@@ -79,6 +79,41 @@ type CapabilityConstraints struct {
 
 	// Rate limiting
 	RateLimit *RateLimit `json:"rateLimit,omitempty"`
+
+	// Maximum uses per minute, enforced with a token bucket
+	MaxRequestsPerMinute int `json:"maxRequestsPerMinute,omitempty"`
+
+	// Maximum number of simultaneous in-flight uses
+	MaxConcurrentUses int `json:"maxConcurrentUses,omitempty"`
+
+	// Workload attestation binding
+	Attestation *AttestationConstraint `json:"attestation,omitempty"`
+}
+
+// AttestationType represents the kind of workload measurement
+type AttestationType string
+
+const (
+	// AttestationImageDigest binds to a container image digest
+	AttestationImageDigest AttestationType = "image_digest"
+	// AttestationBinaryHash binds to the SHA-256 of the calling binary
+	AttestationBinaryHash AttestationType = "binary_hash"
+	// AttestationTPMQuote binds to a TPM quote over a PCR measurement
+	AttestationTPMQuote AttestationType = "tpm_quote"
+	// AttestationSEVReport binds to an AMD SEV-SNP attestation report
+	AttestationSEVReport AttestationType = "sev_report"
+)
+
+// AttestationConstraint represents a workload measurement binding
+type AttestationConstraint struct {
+	// Measurement type
+	Type AttestationType `json:"type"`
+
+	// Expected measurement (e.g., "sha256:...")
+	Measurement string `json:"measurement"`
+
+	// Maximum evidence age in seconds
+	MaxAge int64 `json:"maxAge,omitempty"`
 }
 
 // TimeWindow represents time-based constraints
@@ -159,6 +194,39 @@ type CapabilityRequest struct {
 
 	// Justification/purpose
 	Purpose string `json:"purpose,omitempty"`
+
+	// Step-up proof, sent again after a step_up_required response
+	StepUp *StepUpProof `json:"stepUp,omitempty"`
+
+	// Token format (json, cose); json when empty
+	Format string `json:"format,omitempty"`
+}
+
+// StepUpProof is a second factor presented for a high-risk request
+type StepUpProof struct {
+	// Factor type (totp, webauthn)
+	Factor string `json:"factor"`
+
+	// One-time code or factor assertion
+	Code string `json:"code"`
+}
+
+// RiskAssessment is the risk score the agent gave a capability request
+type RiskAssessment struct {
+	// Score, compared against Threshold
+	Score int `json:"score"`
+
+	// Score at which a step-up is required
+	Threshold int `json:"threshold"`
+
+	// Risky patterns the request matched
+	Reasons []string `json:"reasons,omitempty"`
+
+	// Whether a step-up is required before signing
+	StepUpRequired bool `json:"stepUpRequired"`
+
+	// Factors the agent accepts
+	Factors []string `json:"factors,omitempty"`
 }
 
 // RequestContext represents request context
@@ -175,8 +243,15 @@ type RequestContext struct {
 	// Session information
 	Session *SessionContext `json:"session,omitempty"`
 
+	// Workload attestation evidence
+	Attestation *AttestationEvidence `json:"attestation,omitempty"`
+
 	// Additional metadata
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
+
+	// Holder of the concurrency slots the request acquires, such as the
+	// IPC connection; set by the server, never decoded from the client
+	Holder string `json:"-"`
 }
 
 // RuntimeContext represents runtime context
@@ -230,6 +305,24 @@ type HostContext struct {
 	PID int `json:"pid,omitempty"`
 }
 
+// AttestationEvidence represents workload evidence presented at validation
+type AttestationEvidence struct {
+	// Measurement type
+	Type AttestationType `json:"type"`
+
+	// Reported measurement
+	Measurement string `json:"measurement"`
+
+	// Raw quote or report (TPM/SEV only)
+	Quote []byte `json:"quote,omitempty"`
+
+	// Nonce bound into the quote
+	Nonce string `json:"nonce,omitempty"`
+
+	// Evidence collection timestamp
+	CollectedAt time.Time `json:"collectedAt"`
+}
+
 // SessionContext represents session context
 type SessionContext struct {
 	// Session ID
@@ -250,6 +343,12 @@ type CapabilityResponse struct {
 	// Granted capability
 	Capability *Capability `json:"capability,omitempty"`
 
+	// Encoded token, for formats other than json
+	Token string `json:"token,omitempty"`
+
+	// Token format of Token
+	Format string `json:"format,omitempty"`
+
 	// Request status
 	Status string `json:"status"`
 
@@ -267,6 +366,9 @@ type CapabilityResponse struct {
 
 	// Errors or warnings
 	Issues []Issue `json:"issues,omitempty"`
+
+	// Risk assessment, when risk scoring is enabled
+	Risk *RiskAssessment `json:"risk,omitempty"`
 }
 
 // PolicyResult represents policy evaluation result
@@ -431,6 +533,18 @@ type CapabilityStore interface {
 
 	// Update usage
 	UpdateUsage(id string, event *AccessEvent) error
+
+	// Consume a token from the per-minute rate bucket
+	ConsumeRateToken(id string, perMinute int) (bool, error)
+
+	// Acquire an in-flight concurrency slot leased to holder
+	AcquireConcurrencySlot(id string, max int, holder string) (bool, error)
+
+	// Release an in-flight concurrency slot of holder
+	ReleaseConcurrencySlot(id string, holder string) error
+
+	// Release every in-flight concurrency slot of holder
+	ReleaseHolderSlots(holder string) int
 }
 
 // CapabilityValidator represents the interface for capability validation
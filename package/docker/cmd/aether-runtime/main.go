@@ -2,20 +2,26 @@ package main
 
 import (
 	"context"
+	"errors"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/skygenesisenterprise/aether-vault/package/docker/internal/audit"
 	"github.com/skygenesisenterprise/aether-vault/package/docker/internal/auth"
 	"github.com/skygenesisenterprise/aether-vault/package/docker/internal/config"
+	"github.com/skygenesisenterprise/aether-vault/package/docker/internal/health"
 	"github.com/skygenesisenterprise/aether-vault/package/docker/internal/injector"
 	"github.com/skygenesisenterprise/aether-vault/package/docker/internal/runtime"
+	"github.com/skygenesisenterprise/aether-vault/package/docker/internal/vault"
 )
 
 const (
 	version = "1.0.0"
+
+	defaultHealthAddr = "127.0.0.1:8210"
 )
 
 func main() {
@@ -49,7 +55,6 @@ func main() {
 	}
 
 	vaultToken := os.Getenv("AETHER_VAULT_TOKEN")
-	// In production, this should use proper auth methods (Kubernetes, etc.)
 
 	authClient, err := auth.NewClient(auth.Config{
 		Address: vaultAddr,
@@ -60,6 +65,24 @@ func main() {
 		logger.WithError(err).Fatal("Failed to create auth client")
 	}
 
+	switch method := os.Getenv("AETHER_AUTH_METHOD"); method {
+	case "", "token":
+	case "kubernetes":
+		role := os.Getenv("AETHER_K8S_ROLE")
+		if role == "" {
+			logger.Fatal("AETHER_K8S_ROLE is required with AETHER_AUTH_METHOD=kubernetes")
+		}
+		tokenPath := os.Getenv("AETHER_K8S_TOKEN_PATH")
+		if tokenPath == "" {
+			tokenPath = auth.DefaultKubernetesTokenPath
+		}
+		if err := authClient.AuthenticateWithKubernetes(ctx, role, tokenPath); err != nil {
+			logger.WithError(err).Fatal("Failed to authenticate")
+		}
+	default:
+		logger.WithField("value", method).Fatal("Invalid AETHER_AUTH_METHOD, expected token or kubernetes")
+	}
+
 	// 2. Découverte du contexte
 	discovery := config.NewDiscovery(logger)
 	appContext, err := discovery.Discover(ctx)
@@ -73,8 +96,32 @@ func main() {
 		"role":        appContext.Role,
 	}).Info("Discovered application context")
 
+	// Bind the session to the build, so the server audits every secret
+	// access against it
+	if provenance := appContext.Provenance; !provenance.Empty() {
+		err := authClient.AttachWorkload(ctx, vault.Workload{
+			Service:       appContext.Service,
+			Environment:   appContext.Environment,
+			ImageDigest:   provenance.ImageDigest,
+			GitSHA:        provenance.GitSHA,
+			ProvenanceRef: provenance.ProvenanceRef,
+		})
+		if errors.Is(err, vault.ErrWorkloadUnsupported) {
+			logger.Info("Server does not record workload provenance")
+		} else if err != nil {
+			logger.WithError(err).Warn("Failed to attach workload provenance; secret access will not name this build")
+		}
+	}
+
 	// 3. Récupération de la configuration
 	resolver := config.NewResolver(authClient, logger)
+	if dir := os.Getenv("AETHER_CACHE_DIR"); dir != "" {
+		cache := config.NewCache(dir, appContext)
+		if err := cache.Load(); err != nil {
+			logger.WithError(err).Warn("Ignoring unreadable configuration cache")
+		}
+		resolver.UseCache(cache)
+	}
 	cfg, err := resolver.Resolve(ctx, appContext)
 	if err != nil {
 		logger.WithError(err).Fatal("Failed to resolve configuration")
@@ -82,12 +129,21 @@ func main() {
 
 	// 4. Injection sécurisée
 	inj := injector.NewInjector(logger)
+	if err := inj.ApplyTransforms(cfg); err != nil {
+		logger.WithError(err).Fatal("Failed to transform configuration")
+	}
 	env := inj.BuildEnvironment(cfg)
 
 	// 5. Audit
 	auditLogger := audit.NewLogger(authClient, logger)
 	auditLogger.LogSecretAccess(ctx, appContext, cfg)
 
+	// Watch mode: keep refreshing while the application runs and expose
+	// the outcome to Kubernetes probes
+	if interval := os.Getenv("AETHER_WATCH_INTERVAL"); interval != "" {
+		startWatch(ctx, logger, authClient, resolver, appContext, cfg, interval)
+	}
+
 	// 6. Exécution contrôlée
 	rt := runtime.NewManager(logger, auditLogger)
 
@@ -105,3 +161,33 @@ func main() {
 
 	os.Exit(exitCode)
 }
+
+func startWatch(ctx context.Context, logger *logrus.Logger, authClient *auth.Client, resolver *config.Resolver, appContext *config.Context, cfg *config.Configuration, interval string) {
+	every, err := time.ParseDuration(interval)
+	if err != nil || every <= 0 {
+		logger.WithField("value", interval).Fatal("Invalid AETHER_WATCH_INTERVAL")
+	}
+
+	maxAge := 3 * every
+	if value := os.Getenv("AETHER_HEALTH_MAX_AGE"); value != "" {
+		maxAge, err = time.ParseDuration(value)
+		if err != nil || maxAge < every {
+			logger.WithField("value", value).Fatal("Invalid AETHER_HEALTH_MAX_AGE, it must be at least AETHER_WATCH_INTERVAL")
+		}
+	}
+
+	state := health.NewState(maxAge)
+	addr := os.Getenv("AETHER_HEALTH_ADDR")
+	if addr == "" {
+		addr = defaultHealthAddr
+	}
+	if err := health.NewServer(addr, state, logger).Start(ctx); err != nil {
+		logger.WithError(err).Fatal("Failed to start health endpoint")
+	}
+
+	logger.WithFields(logrus.Fields{
+		"interval": every.String(),
+		"max_age":  maxAge.String(),
+	}).Info("Watch mode enabled")
+	go runtime.NewWatcher(authClient, resolver, appContext, state, every, logger).Watch(ctx, cfg)
+}
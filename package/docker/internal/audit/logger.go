@@ -31,6 +31,8 @@ func (a *Logger) LogSecretAccess(ctx context.Context, appContext *config.Context
 		Role:         appContext.Role,
 		Namespace:    appContext.Namespace,
 		PodName:      appContext.PodName,
+		ImageDigest:  appContext.Provenance.ImageDigest,
+		GitSHA:       appContext.Provenance.GitSHA,
 		SecretsCount: len(cfg.Secrets),
 		ConfigCount:  len(cfg.Config),
 	}
@@ -101,6 +103,8 @@ type AuditEvent struct {
 	Role         string   `json:"role,omitempty"`
 	Namespace    string   `json:"namespace,omitempty"`
 	PodName      string   `json:"pod_name,omitempty"`
+	ImageDigest  string   `json:"image_digest,omitempty"`
+	GitSHA       string   `json:"git_sha,omitempty"`
 	Command      string   `json:"command,omitempty"`
 	ExitCode     int      `json:"exit_code,omitempty"`
 	Success      bool     `json:"success"`
@@ -146,6 +150,12 @@ func (a *Logger) sendToVault(ctx context.Context, event AuditEvent) error {
 	}
 
 	// Add optional fields
+	if event.ImageDigest != "" {
+		auditData["image_digest"] = event.ImageDigest
+	}
+	if event.GitSHA != "" {
+		auditData["git_sha"] = event.GitSHA
+	}
 	if event.Command != "" {
 		auditData["command"] = event.Command
 	}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -16,10 +17,24 @@ type Config struct {
 	Logger  *logrus.Logger
 }
 
+// DefaultKubernetesTokenPath is where Kubernetes mounts the pod's service
+// account token
+const DefaultKubernetesTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// reloginMargin is how long before the session token expires it is
+// replaced by a fresh login
+const reloginMargin = 5 * time.Minute
+
 type Client struct {
 	vaultClient *vault.Client
 	config      Config
 	logger      *logrus.Logger
+
+	// login signs in again when the session came from an auth method
+	// rather than a static token
+	login     func(ctx context.Context) (*vault.LoginResponse, error)
+	expiresAt time.Time
+	workload  *vault.Workload
 }
 
 func NewClient(config Config) (*Client, error) {
@@ -111,6 +126,34 @@ func (c *Client) ReadSecret(ctx context.Context, path string) (*vault.Secret, er
 	return secret, nil
 }
 
+func (c *Client) ReadBundle(ctx context.Context, prefix string, since int64) (*vault.Bundle, bool, error) {
+	bundle, notModified, err := c.vaultClient.ReadBundle(ctx, prefix, since)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read bundle %s: %w", prefix, err)
+	}
+
+	return bundle, notModified, nil
+}
+
+// AttachWorkload binds the session to the build's provenance; requests
+// made afterwards are audited against it
+func (c *Client) AttachWorkload(ctx context.Context, workload vault.Workload) error {
+	id, err := c.vaultClient.AttachWorkload(ctx, workload)
+	if err != nil {
+		return fmt.Errorf("failed to attach workload: %w", err)
+	}
+
+	c.workload = &workload
+
+	c.logger.WithFields(logrus.Fields{
+		"workload_id":  id,
+		"image_digest": workload.ImageDigest,
+		"git_sha":      workload.GitSHA,
+	}).Info("Workload provenance attached to session")
+
+	return nil
+}
+
 func (c *Client) ListSecrets(ctx context.Context, path string) ([]string, error) {
 	keys, err := c.vaultClient.ListSecrets(ctx, path)
 	if err != nil {
@@ -120,22 +163,60 @@ func (c *Client) ListSecrets(ctx context.Context, path string) ([]string, error)
 	return keys, nil
 }
 
-// AuthenticateWithKubernetes handles Kubernetes auth method
-func (c *Client) AuthenticateWithKubernetes(ctx context.Context, role, jwtPath string) error {
-	jwtToken, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/token")
-	if err != nil {
-		return fmt.Errorf("failed to read Kubernetes service account token: %w", err)
+// AuthenticateWithKubernetes signs in with the pod's service account
+// token, read from tokenPath, as the given vault role. The token is read
+// again on every login since Kubernetes rotates projected tokens.
+func (c *Client) AuthenticateWithKubernetes(ctx context.Context, role, tokenPath string) error {
+	c.login = func(ctx context.Context) (*vault.LoginResponse, error) {
+		jwtToken, err := os.ReadFile(tokenPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Kubernetes service account token: %w", err)
+		}
+		return c.vaultClient.Login(ctx, "/auth/kubernetes/login", map[string]string{
+			"role": role,
+			"jwt":  strings.TrimSpace(string(jwtToken)),
+		})
 	}
 
-	_ = fmt.Sprintf("/auth/%s/login", jwtPath)
-	_ = map[string]interface{}{
-		"role": role,
-		"jwt":  string(jwtToken),
+	if err := c.relogin(ctx); err != nil {
+		return fmt.Errorf("kubernetes login failed: %w", err)
 	}
 
-	// This would require extending the vault client to support POST with data
-	// For now, we'll implement a simple token-based approach
-	c.logger.Info("Kubernetes authentication not yet implemented, falling back to token auth")
+	c.logger.WithFields(logrus.Fields{
+		"role":       role,
+		"expires_at": c.expiresAt,
+	}).Info("Authenticated with Kubernetes service account")
+	return nil
+}
+
+// EnsureSession logs in again shortly before the session token expires,
+// and binds the new session to the workload again. Sessions from a
+// static token are left alone.
+func (c *Client) EnsureSession(ctx context.Context) error {
+	if c.login == nil || time.Until(c.expiresAt) > reloginMargin {
+		return nil
+	}
+
+	if err := c.relogin(ctx); err != nil {
+		return fmt.Errorf("failed to renew session: %w", err)
+	}
+	if c.workload != nil {
+		if _, err := c.vaultClient.AttachWorkload(ctx, *c.workload); err != nil {
+			return fmt.Errorf("failed to attach workload to the renewed session: %w", err)
+		}
+	}
+
+	c.logger.WithField("expires_at", c.expiresAt).Info("Session renewed")
+	return nil
+}
+
+func (c *Client) relogin(ctx context.Context) error {
+	response, err := c.login(ctx)
+	if err != nil {
+		return err
+	}
+	c.config.Token = response.Token
+	c.expiresAt = response.ExpiresAt
 	return nil
 }
 
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// CacheEntry is the last bundle seen for one vault path
+type CacheEntry struct {
+	Revision int64             `json:"revision"`
+	Data     map[string]string `json:"data"`
+}
+
+// Cache keeps the resolved bundles between refreshes and, when given a
+// directory, between restarts. The file is keyed by the application
+// context so sidecars sharing a volume never read each other's values.
+type Cache struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[string]*CacheEntry
+	dirty   bool
+}
+
+// NewCache returns an in-memory cache when dir is empty
+func NewCache(dir string, appContext *Context) *Cache {
+	cache := &Cache{entries: make(map[string]*CacheEntry)}
+	if dir != "" {
+		sum := sha256.Sum256([]byte(appContext.Service + "\x00" + appContext.Environment + "\x00" + appContext.Role + "\x00" + appContext.Namespace))
+		cache.path = filepath.Join(dir, "aether-runtime-"+hex.EncodeToString(sum[:8])+".json")
+	}
+	return cache
+}
+
+// Load reads the cache file; a missing file leaves the cache empty
+func (c *Cache) Load() error {
+	if c.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read cache: %w", err)
+	}
+
+	entries := make(map[string]*CacheEntry)
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("failed to decode cache %s: %w", c.path, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = entries
+	return nil
+}
+
+func (c *Cache) Get(path string) *CacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries[path]
+}
+
+func (c *Cache) Put(path string, entry *CacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[path] = entry
+	c.dirty = true
+}
+
+// Save writes the cache file if anything changed since the last save.
+// The values are secrets: the file is private to the runtime user and
+// replaced atomically.
+func (c *Cache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.path == "" || !c.dirty {
+		return nil
+	}
+
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), ".aether-runtime-*")
+	if err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+
+	c.dirty = false
+	return nil
+}
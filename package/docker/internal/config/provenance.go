@@ -0,0 +1,84 @@
+package config
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const defaultPodInfoDir = "/etc/podinfo"
+
+// Keys the build provenance is read from when the pod's labels and
+// annotations are exposed through the Kubernetes downward API
+const (
+	revisionLabel         = "org.opencontainers.image.revision"
+	imageDigestAnnotation = "aether-vault.io/image-digest"
+	provenanceAnnotation  = "aether-vault.io/provenance"
+)
+
+// Provenance identifies the build the runtime runs: the image digest, the
+// commit it was built from and a reference to its SLSA provenance
+type Provenance struct {
+	ImageDigest   string
+	GitSHA        string
+	ProvenanceRef string
+}
+
+// Empty reports whether nothing about the build is known
+func (p Provenance) Empty() bool {
+	return p.ImageDigest == "" && p.GitSHA == "" && p.ProvenanceRef == ""
+}
+
+// discoverProvenance reads AETHER_IMAGE_DIGEST, AETHER_GIT_SHA and
+// AETHER_PROVENANCE_REF, falling back to the pod labels and annotations
+// mounted under AETHER_PODINFO_DIR (default /etc/podinfo)
+func discoverProvenance() Provenance {
+	dir := os.Getenv("AETHER_PODINFO_DIR")
+	if dir == "" {
+		dir = defaultPodInfoDir
+	}
+	labels := readPodInfo(filepath.Join(dir, "labels"))
+	annotations := readPodInfo(filepath.Join(dir, "annotations"))
+
+	return Provenance{
+		ImageDigest:   firstOf(os.Getenv("AETHER_IMAGE_DIGEST"), annotations[imageDigestAnnotation]),
+		GitSHA:        firstOf(os.Getenv("AETHER_GIT_SHA"), labels[revisionLabel], annotations[revisionLabel]),
+		ProvenanceRef: firstOf(os.Getenv("AETHER_PROVENANCE_REF"), annotations[provenanceAnnotation]),
+	}
+}
+
+// readPodInfo parses a downward API file of key="value" lines; a missing
+// or unreadable file yields nothing
+func readPodInfo(path string) map[string]string {
+	values := make(map[string]string)
+	file, err := os.Open(path)
+	if err != nil {
+		return values
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, quoted, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		value, err := strconv.Unquote(quoted)
+		if err != nil {
+			value = quoted
+		}
+		values[strings.TrimSpace(key)] = value
+	}
+	return values
+}
+
+func firstOf(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
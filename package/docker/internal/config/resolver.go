@@ -2,13 +2,16 @@ package config
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/skygenesisenterprise/aether-vault/package/docker/internal/auth"
+	"github.com/skygenesisenterprise/aether-vault/package/docker/internal/vault"
 )
 
 type Context struct {
@@ -18,6 +21,8 @@ type Context struct {
 	Namespace   string
 	PodName     string
 	NodeName    string
+	// Provenance is the build being run, when the deployment reports it
+	Provenance Provenance
 }
 
 type Discovery struct {
@@ -67,6 +72,7 @@ func (d *Discovery) Discover(ctx context.Context) (*Context, error) {
 	if nodeName := os.Getenv("KUBERNETES_NODE_NAME"); nodeName != "" {
 		context.NodeName = nodeName
 	}
+	context.Provenance = discoverProvenance()
 
 	d.logger.WithFields(map[string]interface{}{
 		"service":     context.Service,
@@ -74,6 +80,8 @@ func (d *Discovery) Discover(ctx context.Context) (*Context, error) {
 		"role":        context.Role,
 		"namespace":   context.Namespace,
 		"pod":         context.PodName,
+		"image":       context.Provenance.ImageDigest,
+		"git_sha":     context.Provenance.GitSHA,
 	}).Info("Discovered application context")
 
 	return context, nil
@@ -84,6 +92,8 @@ type Configuration struct {
 	Config    map[string]string `json:"config"`
 	Metadata  map[string]string `json:"metadata"`
 	LeaseInfo LeaseInfo         `json:"lease_info"`
+	// Sources counts the vault paths that could be read
+	Sources int `json:"sources"`
 }
 
 type LeaseInfo struct {
@@ -95,15 +105,27 @@ type LeaseInfo struct {
 type Resolver struct {
 	authClient *auth.Client
 	logger     *logrus.Logger
+	cache      *Cache
+	// bundles is cleared once the server turns out to have no bundle
+	// endpoint
+	bundles bool
 }
 
 func NewResolver(authClient *auth.Client, logger *logrus.Logger) *Resolver {
 	return &Resolver{
 		authClient: authClient,
 		logger:     logger,
+		cache:      NewCache("", nil),
+		bundles:    true,
 	}
 }
 
+// UseCache replaces the in-memory bundle cache, e.g. with one persisted
+// across restarts
+func (r *Resolver) UseCache(cache *Cache) {
+	r.cache = cache
+}
+
 func (r *Resolver) Resolve(ctx context.Context, appContext *Context) (*Configuration, error) {
 	config := &Configuration{
 		Secrets:  make(map[string]string),
@@ -117,7 +139,7 @@ func (r *Resolver) Resolve(ctx context.Context, appContext *Context) (*Configura
 	for _, path := range paths {
 		r.logger.WithField("path", path).Debug("Resolving configuration from Vault")
 
-		secret, err := r.authClient.ReadSecret(ctx, path)
+		data, secret, err := r.read(ctx, path)
 		if err != nil {
 			r.logger.WithFields(map[string]interface{}{
 				"path":  path,
@@ -126,13 +148,15 @@ func (r *Resolver) Resolve(ctx context.Context, appContext *Context) (*Configura
 			continue
 		}
 
+		config.Sources++
+
 		// Process secret data
-		if secret.Data != nil {
-			r.processSecretData(secret.Data, config, path)
+		if data != nil {
+			r.processSecretData(data, config, path)
 		}
 
 		// Store lease information
-		if secret.LeaseID != "" {
+		if secret != nil && secret.LeaseID != "" {
 			config.LeaseInfo = LeaseInfo{
 				LeaseID:       secret.LeaseID,
 				LeaseDuration: secret.LeaseDuration,
@@ -141,8 +165,12 @@ func (r *Resolver) Resolve(ctx context.Context, appContext *Context) (*Configura
 		}
 	}
 
+	if err := r.cache.Save(); err != nil {
+		r.logger.WithError(err).Warn("Failed to save configuration cache")
+	}
+
 	// Add metadata about resolution
-	config.Metadata["resolved_at"] = fmt.Sprintf("%d", 0) // TODO: add timestamp
+	config.Metadata["resolved_at"] = fmt.Sprintf("%d", time.Now().Unix())
 	config.Metadata["service"] = appContext.Service
 	config.Metadata["environment"] = appContext.Environment
 	config.Metadata["role"] = appContext.Role
@@ -150,11 +178,89 @@ func (r *Resolver) Resolve(ctx context.Context, appContext *Context) (*Configura
 	r.logger.WithFields(map[string]interface{}{
 		"secrets_count": len(config.Secrets),
 		"config_count":  len(config.Config),
+		"sources":       config.Sources,
 	}).Info("Configuration resolved successfully")
 
 	return config, nil
 }
 
+// read returns the data under path, from a bundle when the server offers
+// them and from a plain secret read otherwise. Only plain reads carry a
+// lease.
+func (r *Resolver) read(ctx context.Context, path string) (map[string]interface{}, *vault.Secret, error) {
+	if r.bundles {
+		data, err := r.readBundle(ctx, path)
+		if !errors.Is(err, vault.ErrBundleUnsupported) {
+			return data, nil, err
+		}
+		r.bundles = false
+		r.logger.Info("Server has no bundle endpoint, reading paths individually")
+	}
+
+	secret, err := r.authClient.ReadSecret(ctx, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return secret.Data, secret, nil
+}
+
+// readBundle asks only for what changed since the cached revision and
+// merges it into the cached bundle
+func (r *Resolver) readBundle(ctx context.Context, path string) (map[string]interface{}, error) {
+	entry := r.cache.Get(path)
+	var since int64
+	if entry != nil {
+		since = entry.Revision
+	}
+
+	bundle, notModified, err := r.authClient.ReadBundle(ctx, path, since)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string)
+	switch {
+	case notModified:
+		values = entry.Data
+	case bundle.Delta && entry != nil:
+		for key, value := range entry.Data {
+			values[key] = value
+		}
+		fallthrough
+	default:
+		for key, value := range bundle.Secrets {
+			values[key] = value
+		}
+		for _, key := range bundle.Deleted {
+			delete(values, key)
+		}
+		if bundle.Revision == 0 && len(values) == 0 {
+			return nil, fmt.Errorf("no secrets under %s", path)
+		}
+		r.cache.Put(path, &CacheEntry{Revision: bundle.Revision, Data: values})
+	}
+
+	r.logger.WithFields(map[string]interface{}{
+		"path":          path,
+		"not_modified":  notModified,
+		"delta":         bundle != nil && bundle.Delta,
+		"changed_count": bundleChanges(bundle),
+	}).Debug("Resolved bundle")
+
+	data := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		data[key] = value
+	}
+	return data, nil
+}
+
+func bundleChanges(bundle *vault.Bundle) int {
+	if bundle == nil {
+		return 0
+	}
+	return len(bundle.Secrets) + len(bundle.Deleted)
+}
+
 func (r *Resolver) buildVaultPaths(appContext *Context) []string {
 	var paths []string
 
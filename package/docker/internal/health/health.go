@@ -0,0 +1,262 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	LeaseNone     = "none"
+	LeaseActive   = "active"
+	LeaseExpiring = "expiring"
+	LeaseExpired  = "expired"
+
+	// leaseExpiringFraction is how much of a lease may be left before it
+	// is reported as expiring
+	leaseExpiringFraction = 0.2
+)
+
+// State records what the watch loop last saw. It is shared between the
+// loop that writes it and the probe handlers that read it.
+type State struct {
+	maxAge time.Duration
+
+	mu             sync.RWMutex
+	started        time.Time
+	vaultReachable bool
+	vaultError     string
+	lastCheck      time.Time
+	lastRefresh    time.Time
+	refreshError   string
+	refreshes      int
+	leaseID        string
+	leaseDuration  time.Duration
+	leaseRenewable bool
+	leaseStart     time.Time
+}
+
+type LeaseReport struct {
+	Status    string     `json:"status"`
+	ID        string     `json:"id,omitempty"`
+	Renewable bool       `json:"renewable"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type Report struct {
+	Status         string      `json:"status"`
+	Reasons        []string    `json:"reasons,omitempty"`
+	VaultReachable bool        `json:"vault_reachable"`
+	VaultError     string      `json:"vault_error,omitempty"`
+	LastCheck      *time.Time  `json:"last_check,omitempty"`
+	LastRefresh    *time.Time  `json:"last_refresh,omitempty"`
+	RefreshAge     string      `json:"refresh_age,omitempty"`
+	RefreshError   string      `json:"refresh_error,omitempty"`
+	Refreshes      int         `json:"refreshes"`
+	Lease          LeaseReport `json:"lease"`
+}
+
+// NewState tracks a sidecar whose secrets count as stale once they have
+// not been refreshed for maxAge
+func NewState(maxAge time.Duration) *State {
+	return &State{maxAge: maxAge, started: time.Now()}
+}
+
+// VaultChecked records the result of a vault health check
+func (s *State) VaultChecked(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastCheck = time.Now()
+	s.vaultReachable = err == nil
+	s.vaultError = ""
+	if err != nil {
+		s.vaultError = err.Error()
+	}
+}
+
+// Refreshed records a successful secret refresh and the lease it returned
+func (s *State) Refreshed(leaseID string, leaseDuration int, renewable bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.lastRefresh = now
+	s.refreshError = ""
+	s.refreshes++
+	s.leaseID = leaseID
+	s.leaseDuration = time.Duration(leaseDuration) * time.Second
+	s.leaseRenewable = renewable
+	s.leaseStart = now
+}
+
+// RefreshFailed records a failed secret refresh; the previous secrets and
+// lease stay in place
+func (s *State) RefreshFailed(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.refreshError = err.Error()
+}
+
+// Liveness fails once the secrets are stale or their lease has run out,
+// which a restart of the sidecar is expected to fix
+func (s *State) Liveness() Report {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	report := s.report()
+	if report.Lease.Status == LeaseExpired {
+		report.Reasons = append(report.Reasons, "lease expired")
+	}
+	if s.stale() {
+		report.Reasons = append(report.Reasons, "secrets not refreshed within "+s.maxAge.String())
+	}
+	return report.finish()
+}
+
+// Readiness additionally requires vault to have answered the last check
+// and at least one refresh to have succeeded
+func (s *State) Readiness() Report {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	report := s.report()
+	if !s.vaultReachable {
+		report.Reasons = append(report.Reasons, "vault unreachable")
+	}
+	if s.lastRefresh.IsZero() {
+		report.Reasons = append(report.Reasons, "secrets never refreshed")
+	} else if s.stale() {
+		report.Reasons = append(report.Reasons, "secrets not refreshed within "+s.maxAge.String())
+	}
+	if report.Lease.Status == LeaseExpired {
+		report.Reasons = append(report.Reasons, "lease expired")
+	}
+	return report.finish()
+}
+
+// stale counts from start-up until the first refresh, so a sidecar that
+// never manages to refresh is eventually restarted too
+func (s *State) stale() bool {
+	since := s.lastRefresh
+	if since.IsZero() {
+		since = s.started
+	}
+	return time.Since(since) > s.maxAge
+}
+
+func (s *State) report() Report {
+	report := Report{
+		VaultReachable: s.vaultReachable,
+		VaultError:     s.vaultError,
+		RefreshError:   s.refreshError,
+		Refreshes:      s.refreshes,
+		Lease:          s.lease(),
+	}
+	if !s.lastCheck.IsZero() {
+		lastCheck := s.lastCheck.UTC()
+		report.LastCheck = &lastCheck
+	}
+	if !s.lastRefresh.IsZero() {
+		lastRefresh := s.lastRefresh.UTC()
+		report.LastRefresh = &lastRefresh
+		report.RefreshAge = time.Since(s.lastRefresh).Round(time.Second).String()
+	}
+	return report
+}
+
+func (s *State) lease() LeaseReport {
+	if s.leaseID == "" || s.leaseDuration <= 0 {
+		return LeaseReport{Status: LeaseNone}
+	}
+
+	expiresAt := s.leaseStart.Add(s.leaseDuration).UTC()
+	report := LeaseReport{Status: LeaseActive, ID: s.leaseID, Renewable: s.leaseRenewable, ExpiresAt: &expiresAt}
+	remaining := time.Until(expiresAt)
+	switch {
+	case remaining <= 0:
+		report.Status = LeaseExpired
+	case remaining < time.Duration(float64(s.leaseDuration)*leaseExpiringFraction):
+		report.Status = LeaseExpiring
+	}
+	return report
+}
+
+func (r Report) finish() Report {
+	r.Status = "ok"
+	if len(r.Reasons) > 0 {
+		r.Status = "degraded"
+	}
+	return r
+}
+
+// Server answers the Kubernetes probes. It is meant to listen on
+// localhost only; the reports carry lease IDs but never secret values.
+type Server struct {
+	state  *State
+	logger *logrus.Logger
+	server *http.Server
+}
+
+func NewServer(addr string, state *State, logger *logrus.Logger) *Server {
+	s := &Server{state: state, logger: logger}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", s.handle(state.Liveness))
+	mux.HandleFunc("/readyz", s.handle(state.Readiness))
+	s.server = &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return s
+}
+
+// Start listens in the background until ctx is cancelled
+func (s *Server) Start(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.server.Addr)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.WithError(err).Error("Health endpoint stopped")
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		s.server.Shutdown(shutdownCtx)
+	}()
+
+	s.logger.WithField("address", listener.Addr().String()).Info("Health endpoint listening")
+	return nil
+}
+
+func (s *Server) handle(probe func() Report) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		report := probe()
+		status := http.StatusOK
+		if report.Status != "ok" {
+			status = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(report)
+	}
+}
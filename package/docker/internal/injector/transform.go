@@ -0,0 +1,302 @@
+package injector
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/skygenesisenterprise/aether-vault/package/docker/internal/config"
+)
+
+// TransformPrefix marks environment variables that define a transform.
+// AETHER_TRANSFORM_<NAME>=<source> | <step> | ... exposes the result as
+// AETHER_<NAME>.
+const TransformPrefix = "AETHER_TRANSFORM_"
+
+var templatePlaceholder = regexp.MustCompile(`\{\{\s*([^{}\s]+)\s*\}\}`)
+
+// Transform reshapes resolved values before they are injected. The source
+// is a secret or config key, or a template such as
+// "jdbc:postgresql://{{db_host}}:{{db_port}}/{{db_name}}". Each step then
+// rewrites the values in turn:
+//
+//	base64       decode standard or URL-safe base64
+//	json:<path>  extract a field, dot separated with numeric array indexes
+//	pem          split a PEM bundle into one value per block
+//	pem:<index>  keep one block of a PEM bundle
+//
+// A pipeline ending in several values exposes them as <NAME>_0, <NAME>_1...
+type Transform struct {
+	Name   string
+	Source string
+	Steps  []TransformStep
+}
+
+type TransformStep struct {
+	Op  string
+	Arg string
+}
+
+// ParseTransforms reads the transform definitions out of environ, in
+// name order
+func ParseTransforms(environ []string) ([]Transform, error) {
+	var transforms []Transform
+	for _, entry := range environ {
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok || !strings.HasPrefix(key, TransformPrefix) {
+			continue
+		}
+
+		name := strings.TrimPrefix(key, TransformPrefix)
+		transform, err := ParseTransform(name, value)
+		if err != nil {
+			return nil, err
+		}
+		transforms = append(transforms, *transform)
+	}
+
+	sort.Slice(transforms, func(i, j int) bool { return transforms[i].Name < transforms[j].Name })
+	return transforms, nil
+}
+
+func ParseTransform(name, spec string) (*Transform, error) {
+	if name == "" || !isValidEnvVarName(name) {
+		return nil, fmt.Errorf("invalid transform name %q", name)
+	}
+
+	parts := strings.Split(spec, "|")
+	transform := &Transform{Name: name, Source: strings.TrimSpace(parts[0])}
+	if transform.Source == "" {
+		return nil, fmt.Errorf("transform %s has no source", name)
+	}
+
+	for _, part := range parts[1:] {
+		op, arg, _ := strings.Cut(strings.TrimSpace(part), ":")
+		step := TransformStep{Op: strings.TrimSpace(op), Arg: strings.TrimSpace(arg)}
+		switch step.Op {
+		case "base64":
+		case "json":
+			if step.Arg == "" {
+				return nil, fmt.Errorf("transform %s: json step needs a field path", name)
+			}
+		case "pem":
+			if step.Arg != "" {
+				if index, err := strconv.Atoi(step.Arg); err != nil || index < 0 {
+					return nil, fmt.Errorf("transform %s: invalid pem block index %q", name, step.Arg)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("transform %s: unknown step %q", name, step.Op)
+		}
+		transform.Steps = append(transform.Steps, step)
+	}
+
+	return transform, nil
+}
+
+// Apply runs the transform against cfg and returns the resulting values by
+// key. secret reports whether any input was a secret, in which case the
+// results must be treated as secrets too.
+func (t *Transform) Apply(cfg *config.Configuration) (map[string]string, bool, error) {
+	value, secret, err := t.source(cfg)
+	if err != nil {
+		return nil, false, err
+	}
+
+	values := []string{value}
+	for _, step := range t.Steps {
+		var next []string
+		for _, value := range values {
+			out, err := step.apply(value)
+			if err != nil {
+				return nil, false, fmt.Errorf("transform %s: %s: %w", t.Name, step.Op, err)
+			}
+			next = append(next, out...)
+		}
+		values = next
+	}
+
+	if len(values) == 1 {
+		return map[string]string{t.Name: values[0]}, secret, nil
+	}
+	results := make(map[string]string, len(values))
+	for i, value := range values {
+		results[fmt.Sprintf("%s_%d", t.Name, i)] = value
+	}
+	return results, secret, nil
+}
+
+func (t *Transform) source(cfg *config.Configuration) (string, bool, error) {
+	if !strings.Contains(t.Source, "{{") {
+		value, secret, ok := lookupValue(cfg, t.Source)
+		if !ok {
+			return "", false, fmt.Errorf("transform %s: key %q was not resolved", t.Name, t.Source)
+		}
+		return value, secret, nil
+	}
+
+	secret := false
+	var missing []string
+	value := templatePlaceholder.ReplaceAllStringFunc(t.Source, func(match string) string {
+		key := templatePlaceholder.FindStringSubmatch(match)[1]
+		value, isSecret, ok := lookupValue(cfg, key)
+		if !ok {
+			missing = append(missing, key)
+			return ""
+		}
+		secret = secret || isSecret
+		return value
+	})
+	if len(missing) > 0 {
+		return "", false, fmt.Errorf("transform %s: keys not resolved: %s", t.Name, strings.Join(missing, ", "))
+	}
+	return value, secret, nil
+}
+
+func lookupValue(cfg *config.Configuration, key string) (string, bool, bool) {
+	if value, ok := cfg.Secrets[key]; ok {
+		return value, true, true
+	}
+	if value, ok := cfg.Config[key]; ok {
+		return value, false, true
+	}
+	return "", false, false
+}
+
+func (s TransformStep) apply(value string) ([]string, error) {
+	switch s.Op {
+	case "base64":
+		decoded, err := decodeBase64(value)
+		if err != nil {
+			return nil, err
+		}
+		return []string{decoded}, nil
+	case "json":
+		extracted, err := extractJSON(value, s.Arg)
+		if err != nil {
+			return nil, err
+		}
+		return []string{extracted}, nil
+	case "pem":
+		return splitPEM(value, s.Arg)
+	}
+	return nil, fmt.Errorf("unknown step")
+}
+
+func decodeBase64(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if decoded, err := encoding.DecodeString(value); err == nil {
+			return string(decoded), nil
+		}
+	}
+	return "", fmt.Errorf("value is not valid base64")
+}
+
+func extractJSON(value, path string) (string, error) {
+	var current interface{}
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.UseNumber()
+	if err := decoder.Decode(&current); err != nil {
+		return "", fmt.Errorf("value is not valid JSON: %w", err)
+	}
+
+	for _, segment := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			next, ok := node[segment]
+			if !ok {
+				return "", fmt.Errorf("field %q not found", path)
+			}
+			current = next
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(node) {
+				return "", fmt.Errorf("index %q out of range in %q", segment, path)
+			}
+			current = node[index]
+		default:
+			return "", fmt.Errorf("field %q not found", path)
+		}
+	}
+
+	switch leaf := current.(type) {
+	case string:
+		return leaf, nil
+	case nil:
+		return "", nil
+	default:
+		encoded, err := json.Marshal(leaf)
+		if err != nil {
+			return "", err
+		}
+		return string(encoded), nil
+	}
+}
+
+func splitPEM(value, index string) ([]string, error) {
+	var blocks []string
+	rest := []byte(value)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		blocks = append(blocks, string(bytes.TrimSpace(pem.EncodeToMemory(block))))
+	}
+	if len(blocks) == 0 {
+		return nil, fmt.Errorf("value holds no PEM block")
+	}
+
+	if index == "" {
+		return blocks, nil
+	}
+	i, _ := strconv.Atoi(index)
+	if i >= len(blocks) {
+		return nil, fmt.Errorf("PEM bundle has %d blocks, no block %d", len(blocks), i)
+	}
+	return []string{blocks[i]}, nil
+}
+
+// ApplyTransforms adds the transforms defined in the process environment
+// to cfg. Results derived from a secret become secrets themselves.
+func (i *Injector) ApplyTransforms(cfg *config.Configuration) error {
+	transforms, err := ParseTransforms(os.Environ())
+	if err != nil {
+		return err
+	}
+
+	for _, transform := range transforms {
+		results, secret, err := transform.Apply(cfg)
+		if err != nil {
+			return err
+		}
+		for key, value := range results {
+			if secret {
+				cfg.Secrets[key] = value
+			} else {
+				cfg.Config[key] = value
+			}
+		}
+
+		i.logger.WithFields(map[string]interface{}{
+			"transform": transform.Name,
+			"steps":     len(transform.Steps),
+			"outputs":   len(results),
+			"secret":    secret,
+		}).Debug("Applied value transform")
+	}
+
+	if len(transforms) > 0 {
+		i.logger.WithField("transforms", len(transforms)).Info("Value transforms applied")
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/skygenesisenterprise/aether-vault/package/docker/internal/auth"
+	"github.com/skygenesisenterprise/aether-vault/package/docker/internal/config"
+	"github.com/skygenesisenterprise/aether-vault/package/docker/internal/health"
+)
+
+// Watcher keeps checking vault while the application runs as a sidecar:
+// every interval it checks connectivity, re-reads the secrets and records
+// the outcome for the health endpoint
+type Watcher struct {
+	authClient *auth.Client
+	resolver   *config.Resolver
+	appContext *config.Context
+	state      *health.State
+	interval   time.Duration
+	logger     *logrus.Logger
+}
+
+func NewWatcher(authClient *auth.Client, resolver *config.Resolver, appContext *config.Context, state *health.State, interval time.Duration, logger *logrus.Logger) *Watcher {
+	return &Watcher{
+		authClient: authClient,
+		resolver:   resolver,
+		appContext: appContext,
+		state:      state,
+		interval:   interval,
+		logger:     logger,
+	}
+}
+
+// Watch refreshes every interval until ctx is cancelled. initial is the
+// configuration resolved at start-up and counts as the first refresh.
+func (w *Watcher) Watch(ctx context.Context, initial *config.Configuration) {
+	w.state.VaultChecked(nil)
+	w.record(initial)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.refresh(ctx)
+		}
+	}
+}
+
+func (w *Watcher) refresh(ctx context.Context) {
+	checkCtx, cancel := context.WithTimeout(ctx, w.interval)
+	defer cancel()
+
+	if err := w.authClient.HealthCheck(checkCtx); err != nil {
+		w.state.VaultChecked(err)
+		w.state.RefreshFailed(err)
+		w.logger.WithError(err).Warn("Vault unreachable, keeping previous secrets")
+		return
+	}
+	w.state.VaultChecked(nil)
+
+	if err := w.authClient.EnsureSession(checkCtx); err != nil {
+		w.state.RefreshFailed(err)
+		w.logger.WithError(err).Warn("Failed to renew vault session, keeping previous secrets")
+		return
+	}
+
+	cfg, err := w.resolver.Resolve(checkCtx, w.appContext)
+	if err != nil {
+		w.state.RefreshFailed(err)
+		w.logger.WithError(err).Warn("Failed to refresh secrets")
+		return
+	}
+	w.record(cfg)
+}
+
+func (w *Watcher) record(cfg *config.Configuration) {
+	if cfg.Sources == 0 {
+		w.state.RefreshFailed(fmt.Errorf("no vault path could be read"))
+		w.logger.Warn("Secret refresh read no vault path")
+		return
+	}
+
+	lease := cfg.LeaseInfo
+	w.state.Refreshed(lease.LeaseID, lease.LeaseDuration, lease.Renewable)
+	w.logger.WithFields(logrus.Fields{
+		"secrets_count": len(cfg.Secrets),
+		"config_count":  len(cfg.Config),
+		"lease_id":      lease.LeaseID,
+	}).Debug("Secrets refreshed")
+}
@@ -4,9 +4,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 )
 
@@ -27,6 +30,44 @@ type Secret struct {
 	Renewable     bool                   `json:"renewable"`
 }
 
+// Bundle is the key/value set an Aether Vault server returns for a path
+// prefix. Revision identifies its state; a delta bundle only carries the
+// keys changed since the revision that was asked for.
+type Bundle struct {
+	Prefix   string            `json:"prefix"`
+	Revision int64             `json:"revision"`
+	Delta    bool              `json:"delta"`
+	Secrets  map[string]string `json:"secrets"`
+	Deleted  []string          `json:"deleted"`
+}
+
+// ErrBundleUnsupported means the server has no bundle endpoint, e.g. a
+// HashiCorp Vault; callers fall back to ReadSecret
+var ErrBundleUnsupported = errors.New("server does not support bundles")
+
+// Workload is the provenance of the build reading secrets, which an Aether
+// Vault server records against the session
+type Workload struct {
+	Service       string `json:"service"`
+	Environment   string `json:"environment,omitempty"`
+	ImageDigest   string `json:"image_digest,omitempty"`
+	GitSHA        string `json:"git_sha,omitempty"`
+	ProvenanceRef string `json:"provenance_ref,omitempty"`
+}
+
+// ErrWorkloadUnsupported means the server cannot record provenance, e.g. a
+// HashiCorp Vault
+var ErrWorkloadUnsupported = errors.New("server does not support workload provenance")
+
+// LoginResponse is what Aether Vault login endpoints answer
+type LoginResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ErrLoginRefused means the server rejected the credentials
+var ErrLoginRefused = errors.New("login refused")
+
 type AuthResponse struct {
 	Auth struct {
 		ClientToken   string   `json:"client_token"`
@@ -124,6 +165,138 @@ func (c *Client) ReadSecret(ctx context.Context, path string) (*Secret, error) {
 	return &secret, nil
 }
 
+// ReadBundle fetches the bundle under prefix. With since set to a
+// revision held by the caller the server answers with a delta, or with
+// notModified when nothing changed.
+func (c *Client) ReadBundle(ctx context.Context, prefix string, since int64) (bundle *Bundle, notModified bool, err error) {
+	query := url.Values{"prefix": {prefix}}
+	if since > 0 {
+		query.Set("since", strconv.FormatInt(since, 10))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.address+"/api/v1/secrets/bundle?"+query.Encode(), nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create request: %w", err)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if since > 0 {
+		req.Header.Set("If-None-Match", fmt.Sprintf(`"%d"`, since))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read bundle: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return nil, true, nil
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return nil, false, ErrBundleUnsupported
+	default:
+		return nil, false, fmt.Errorf("unexpected status code %d for bundle read", resp.StatusCode)
+	}
+
+	bundle = &Bundle{}
+	if err := json.NewDecoder(resp.Body).Decode(bundle); err != nil {
+		return nil, false, fmt.Errorf("failed to decode bundle response: %w", err)
+	}
+	return bundle, false, nil
+}
+
+// AttachWorkload reports the build's provenance and switches to the token
+// the server returns, bound to it, so the audit entries of every later
+// request name the build. Returns the workload ID.
+func (c *Client) AttachWorkload(ctx context.Context, workload Workload) (string, error) {
+	body, err := json.Marshal(workload)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.address+"/api/v1/auth/workload", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to attach workload: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return "", ErrWorkloadUnsupported
+	default:
+		return "", fmt.Errorf("unexpected status code %d for workload attach", resp.StatusCode)
+	}
+
+	var response struct {
+		Token    string `json:"token"`
+		Workload struct {
+			ID string `json:"id"`
+		} `json:"workload"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", fmt.Errorf("failed to decode workload response: %w", err)
+	}
+	if response.Token == "" {
+		return "", fmt.Errorf("workload response carries no token")
+	}
+
+	c.token = response.Token
+	return response.Workload.ID, nil
+}
+
+// Login signs in at an Aether Vault login endpoint such as
+// /auth/kubernetes/login and switches to the token it returns
+func (c *Client) Login(ctx context.Context, path string, credentials interface{}) (*LoginResponse, error) {
+	body, err := json.Marshal(credentials)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.address+"/api/v1"+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to log in: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, ErrLoginRefused
+	default:
+		return nil, fmt.Errorf("unexpected status code %d for login", resp.StatusCode)
+	}
+
+	var response LoginResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode login response: %w", err)
+	}
+	if response.Token == "" {
+		return nil, fmt.Errorf("login response carries no token")
+	}
+
+	c.token = response.Token
+	return &response, nil
+}
+
 func (c *Client) ListSecrets(ctx context.Context, path string) ([]string, error) {
 	listPath := path
 	if !bytes.HasSuffix([]byte(path), []byte("/")) {
@@ -0,0 +1,159 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// CheckResult is the outcome of one conformance check
+type CheckResult struct {
+	Name string
+	Err  error
+}
+
+// Conformance runs the contract the Terraform provider and Ansible lookup
+// plugin rely on against a live server, using a throwaway secret named
+// <prefix>-<timestamp>. Provider acceptance suites call it so a server
+// change that breaks the contract fails their CI:
+//
+//	for _, result := range provider.Conformance(ctx, c, "tf-acc") {
+//		if result.Err != nil {
+//			t.Errorf("%s: %v", result.Name, result.Err)
+//		}
+//	}
+//
+// Checks after a failed one are skipped and reported as such.
+func Conformance(ctx context.Context, c *Client, prefix string) []CheckResult {
+	spec := &SecretSpec{
+		Name:        fmt.Sprintf("%s-%d", prefix, time.Now().UnixNano()),
+		Description: "provider conformance",
+		Value:       "conformance-value-1",
+		Type:        SecretTypeOther,
+		Tags:        "conformance",
+	}
+
+	var created *UpsertResult
+	checks := []struct {
+		name string
+		run  func() error
+	}{
+		{"contract version", func() error {
+			return c.CheckContract(ctx)
+		}},
+		{"upsert creates", func() error {
+			result, err := c.UpsertSecret(ctx, spec)
+			if err != nil {
+				return err
+			}
+			if !result.Created || !result.Changed {
+				return fmt.Errorf("created=%t changed=%t, want both true", result.Created, result.Changed)
+			}
+			if result.ImportID == "" || result.ContentHash == "" {
+				return fmt.Errorf("import_id and content_hash must be set")
+			}
+			created = result
+			return nil
+		}},
+		{"upsert is idempotent", func() error {
+			result, err := c.UpsertSecret(ctx, spec)
+			if err != nil {
+				return err
+			}
+			if result.Created || result.Changed {
+				return fmt.Errorf("created=%t changed=%t, want both false", result.Created, result.Changed)
+			}
+			if result.ContentHash != created.ContentHash {
+				return fmt.Errorf("repeating an upsert changed content_hash")
+			}
+			return nil
+		}},
+		{"read omits value", func() error {
+			secret, err := c.ReadSecret(ctx, created.ImportID, false)
+			if err != nil {
+				return err
+			}
+			if secret.Value != "" {
+				return fmt.Errorf("value returned without include_value")
+			}
+			if secret.ContentHash != created.ContentHash {
+				return fmt.Errorf("read content_hash %q differs from upsert %q", secret.ContentHash, created.ContentHash)
+			}
+			return nil
+		}},
+		{"read by name import ID", func() error {
+			secret, err := c.ReadSecret(ctx, ImportID("", spec.Name), false)
+			if err != nil {
+				return err
+			}
+			if secret.ID != created.ID {
+				return fmt.Errorf("name resolved to %s, want %s", secret.ID, created.ID)
+			}
+			return nil
+		}},
+		{"metadata change drifts content hash", func() error {
+			spec.Description = "provider conformance (changed)"
+			result, err := c.UpsertSecret(ctx, spec)
+			if err != nil {
+				return err
+			}
+			if result.Created || !result.Changed || result.ID != created.ID {
+				return fmt.Errorf("created=%t changed=%t id=%s, want an in-place change", result.Created, result.Changed, result.ID)
+			}
+			if result.ContentHash == created.ContentHash {
+				return fmt.Errorf("content_hash did not change")
+			}
+			created = result
+			return nil
+		}},
+		{"value change drifts content hash", func() error {
+			spec.Value = "conformance-value-2"
+			result, err := c.UpsertSecret(ctx, spec)
+			if err != nil {
+				return err
+			}
+			if !result.Changed || result.ContentHash == created.ContentHash {
+				return fmt.Errorf("changing the value left content_hash unchanged")
+			}
+			secret, err := c.ReadSecret(ctx, result.ImportID, true)
+			if err != nil {
+				return err
+			}
+			if secret.Value != spec.Value {
+				return fmt.Errorf("include_value returned a stale value")
+			}
+			return nil
+		}},
+		{"delete", func() error {
+			if err := c.DeleteSecret(ctx, created.ImportID); err != nil {
+				return err
+			}
+			if _, err := c.ReadSecret(ctx, created.ImportID, false); !IsNotFound(err) {
+				return fmt.Errorf("read after delete returned %v, want not found", err)
+			}
+			return nil
+		}},
+		{"delete is idempotent", func() error {
+			return c.DeleteSecret(ctx, created.ImportID)
+		}},
+	}
+
+	results := make([]CheckResult, 0, len(checks))
+	var failed bool
+	for _, check := range checks {
+		if failed {
+			results = append(results, CheckResult{Name: check.name, Err: fmt.Errorf("skipped after an earlier failure")})
+			continue
+		}
+		err := check.run()
+		results = append(results, CheckResult{Name: check.name, Err: err})
+		failed = err != nil
+	}
+
+	// Leave nothing behind when a check failed midway
+	if failed && created != nil {
+		_ = c.DeleteSecret(ctx, created.ImportID)
+	}
+
+	return results
+}
@@ -0,0 +1,176 @@
+// Package provider is the client side of the /api/v1/provider contract,
+// shared by the Terraform provider and the Ansible lookup plugin. It only
+// grows in backward-compatible ways while ContractVersion stays the same.
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-vault/package/golang/client"
+	"github.com/skygenesisenterprise/aether-vault/package/golang/errors"
+)
+
+// ContractVersion is the server contract this package implements
+const ContractVersion = "1"
+
+type SecretType string
+
+const (
+	SecretTypePassword    SecretType = "password"
+	SecretTypeAPIKey      SecretType = "api_key"
+	SecretTypeToken       SecretType = "token"
+	SecretTypeCertificate SecretType = "certificate"
+	SecretTypeOther       SecretType = "other"
+)
+
+// SecretSpec is the desired state of a secret, identified by name and
+// optional namespace
+type SecretSpec struct {
+	Name            string     `json:"name"`
+	Description     string     `json:"description"`
+	Value           string     `json:"value"`
+	Type            SecretType `json:"type"`
+	Tags            string     `json:"tags"`
+	ExpiresAt       *time.Time `json:"expires_at,omitempty"`
+	NamespaceID     string     `json:"namespace_id,omitempty"`
+	ClientEncrypted bool       `json:"client_encrypted"`
+}
+
+// Secret is the state the server reports. Store ContentHash after an
+// apply; a different hash on refresh means the secret drifted.
+type Secret struct {
+	ImportID        string     `json:"import_id"`
+	ID              string     `json:"id"`
+	Name            string     `json:"name"`
+	Description     string     `json:"description"`
+	Type            SecretType `json:"type"`
+	Tags            string     `json:"tags"`
+	ExpiresAt       *time.Time `json:"expires_at,omitempty"`
+	NamespaceID     string     `json:"namespace_id,omitempty"`
+	ClientEncrypted bool       `json:"client_encrypted"`
+	ContentHash     string     `json:"content_hash"`
+	RotatedAt       *time.Time `json:"rotated_at,omitempty"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	Value           string     `json:"value,omitempty"`
+}
+
+type UpsertResult struct {
+	Secret
+	Created bool `json:"created"`
+	Changed bool `json:"changed"`
+}
+
+type Contract struct {
+	Version         string   `json:"version"`
+	Resources       []string `json:"resources"`
+	ImportIDFormats []string `json:"import_id_formats"`
+}
+
+type Client struct {
+	client *client.Client
+}
+
+func NewClient(client *client.Client) *Client {
+	return &Client{
+		client: client,
+	}
+}
+
+// Contract returns the server's contract. Providers should refuse to run
+// against a different major version.
+func (c *Client) Contract(ctx context.Context) (*Contract, error) {
+	resp, err := c.client.Get(ctx, "/api/v1/provider/contract")
+	if err != nil {
+		return nil, err
+	}
+
+	var contract Contract
+	if err := resp.Decode(&contract); err != nil {
+		return nil, errors.WrapError(err, errors.ErrCodeInternal, "failed to decode contract response")
+	}
+	return &contract, nil
+}
+
+// CheckContract fails unless the server implements ContractVersion
+func (c *Client) CheckContract(ctx context.Context) error {
+	contract, err := c.Contract(ctx)
+	if err != nil {
+		return err
+	}
+	if contract.Version != ContractVersion {
+		return errors.NewError(errors.ErrCodeInvalidRequest, "server implements provider contract v"+contract.Version+", client expects v"+ContractVersion)
+	}
+	return nil
+}
+
+// UpsertSecret creates or updates the secret to match spec. Applying the
+// same spec again changes nothing.
+func (c *Client) UpsertSecret(ctx context.Context, spec *SecretSpec) (*UpsertResult, error) {
+	resp, err := c.client.Put(ctx, "/api/v1/provider/secrets", spec)
+	if err != nil {
+		return nil, err
+	}
+
+	var result UpsertResult
+	if err := resp.Decode(&result); err != nil {
+		return nil, errors.WrapError(err, errors.ErrCodeInternal, "failed to decode secret response")
+	}
+	return &result, nil
+}
+
+// ReadSecret reads a secret by import ID. A secret that no longer exists
+// returns an error for which IsNotFound is true; providers should then
+// drop it from state.
+func (c *Client) ReadSecret(ctx context.Context, importID string, includeValue bool) (*Secret, error) {
+	endpoint := "/api/v1/provider/secrets/" + escapeImportID(importID)
+	if includeValue {
+		endpoint += "?include_value=true"
+	}
+
+	resp, err := c.client.Get(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	var secret Secret
+	if err := resp.Decode(&secret); err != nil {
+		return nil, errors.WrapError(err, errors.ErrCodeInternal, "failed to decode secret response")
+	}
+	return &secret, nil
+}
+
+// DeleteSecret deletes a secret by import ID; deleting a missing secret
+// succeeds
+func (c *Client) DeleteSecret(ctx context.Context, importID string) error {
+	_, err := c.client.Delete(ctx, "/api/v1/provider/secrets/"+escapeImportID(importID))
+	return err
+}
+
+// ImportID builds the name-based import ID of a secret. namespaceID is
+// empty for personal secrets.
+func ImportID(namespaceID, name string) string {
+	if namespaceID == "" {
+		return name
+	}
+	return namespaceID + ":" + name
+}
+
+// IsNotFound reports whether err means the secret does not exist
+func IsNotFound(err error) bool {
+	vaultErr, ok := err.(*errors.VaultError)
+	return ok && (vaultErr.Status == http.StatusNotFound || vaultErr.Code == errors.ErrCodeNotFound)
+}
+
+// escapeImportID escapes each path segment; slashes in secret names are
+// kept because the server matches the rest of the path
+func escapeImportID(importID string) string {
+	segments := strings.Split(importID, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
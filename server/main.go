@@ -1,26 +1,74 @@
 package main
 
 import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
 	"fmt"
+	"github.com/skygenesisenterprise/aether-vault/server/src/buildinfo"
 	"github.com/skygenesisenterprise/aether-vault/server/src/config"
 	"github.com/skygenesisenterprise/aether-vault/server/src/model"
 	"github.com/skygenesisenterprise/aether-vault/server/src/routes"
 	"github.com/skygenesisenterprise/aether-vault/server/src/services"
 	"log"
 	"net/http"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/spf13/pflag"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
 )
 
 func main() {
-	cfg, err := config.LoadConfig()
+	flags := config.NewFlagSet("server")
+	if err := flags.Parse(os.Args[1:]); err != nil {
+		os.Exit(2)
+	}
+
+	// Subcommands: "selftest", "config validate" and "audit verify"; an
+	// optional leading "server" is accepted so the documented
+	// `server <command>` form works
+	args := flags.Args()
+	if len(args) > 0 && args[0] == "server" {
+		args = args[1:]
+	}
+
+	if len(args) == 2 && args[0] == "config" && args[1] == "validate" {
+		os.Exit(validateConfigCommand(flags))
+	}
+	auditVerify := len(args) == 2 && args[0] == "audit" && args[1] == "verify"
+	if len(args) > 0 && args[0] != "selftest" && !auditVerify {
+		log.Fatalf("Unknown command %q (expected selftest, config validate or audit verify)", strings.Join(args, " "))
+	}
+
+	cfg, err := config.LoadConfig(flags)
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
+	if auditVerify {
+		os.Exit(auditVerifyCommand(cfg))
+	}
+
+	// "selftest" runs the startup checks once and exits
+	selfTestOnly := len(args) > 0 && args[0] == "selftest"
+
+	// External entropy is mixed into key generation before anything
+	// generates keys
+	entropyService, err := services.NewEntropyService(cfg.Entropy)
+	if err != nil {
+		log.Fatalf("Failed to configure entropy sources: %v", err)
+	}
+	if err := entropyService.Start(); err != nil {
+		log.Fatalf("Failed to start entropy sources: %v", err)
+	}
+
 	if cfg.Server.Environment == "production" {
 		gin.SetMode(gin.ReleaseMode)
 	}
@@ -28,10 +76,40 @@ func main() {
 	var db *gorm.DB
 	var userService *services.UserService
 	var auditService *services.AuditService
+	var auditAnchorService *services.AuditAnchorService
 	var secretService *services.SecretService
 	var totpService *services.TOTPService
 	var policyService *services.PolicyService
 	var networkService *services.NetworkService
+	var shareService *services.ShareService
+	var leaseService *services.LeaseService
+	var templateService *services.TemplateService
+	var namespaceService *services.NamespaceService
+	var tenantKeyService *services.TenantKeyService
+	var teamKeyService *services.TeamKeyService
+	var providerService *services.ProviderService
+	var reminderService *services.ReminderService
+	var emailNotifier *services.EmailNotifier
+	var notificationService *services.NotificationService
+	var chatService *services.ChatService
+	var escalationService *services.EscalationService
+	var jobService *services.JobService
+	var migrationService *services.MigrationService
+	var secretAccessService *services.SecretAccessService
+	var oidcService *services.OIDCService
+	var pkiService *services.PKIService
+	var sshService *services.SSHService
+	var wrappingService *services.WrappingService
+	var secretImportService *services.SecretImportService
+	var secretCheckoutService *services.SecretCheckoutService
+	var orgService *services.OrgService
+	var cacheWarmupService *services.CacheWarmupService
+	var escrowService *services.EscrowService
+	var mountService *services.MountService
+	var eventFeed *services.EventFeed
+	var quotaService *services.QuotaService
+	var sandboxService *services.SandboxService
+	var validatorService *services.SecretValidatorService
 
 	// Initialize database if available (optional in development)
 	if cfg.Server.Environment == "production" || (cfg.Database.Host != "" && cfg.Database.User != "") {
@@ -45,7 +123,8 @@ func main() {
 			}
 		}
 
-		if db != nil {
+		// selftest inspects the schema as deployed, so it must not migrate
+		if db != nil && !selfTestOnly {
 			if err := migrateDatabase(db); err != nil {
 				if cfg.Server.Environment == "production" {
 					log.Fatalf("Failed to migrate database in production: %v", err)
@@ -57,15 +136,160 @@ func main() {
 		}
 	}
 
+	if !runSelfTest(db, cfg, selfTestOnly) {
+		if selfTestOnly {
+			os.Exit(1)
+		}
+		if cfg.Server.Environment == "production" {
+			log.Fatalf("Self-test failed, refusing to start in production")
+		}
+		log.Printf("⚠️  Self-test failed, continuing because environment is %s", cfg.Server.Environment)
+	}
+	if selfTestOnly {
+		return
+	}
+
 	// Initialize services
 	if db != nil {
 		// Full database-backed services
 		userService = services.NewUserService(db)
-		auditService = services.NewAuditService(db)
-		secretService = services.NewSecretService(db, cfg.Security.EncryptionKey, "default-salt", cfg.Security.KDFIterations, auditService)
-		totpService = services.NewTOTPService(db, auditService)
-		policyService = services.NewPolicyService(db)
+		auditService = services.NewAuditService(db, auditHMACKey(cfg))
+		eventFeed = services.NewEventFeed()
+		auditService.UseFeed(eventFeed)
+		var auditEnricher *services.AuditEnricher
+		auditEnricher, err = services.NewAuditEnricher(db, cfg.Audit.Enrichment)
+		if err != nil {
+			log.Fatalf("Failed to configure audit enrichment: %v", err)
+		}
+		auditService.UseEnricher(auditEnricher)
+		auditAnchorService, err = services.NewAuditAnchorService(db, cfg.Audit.Anchor)
+		if err != nil {
+			log.Fatalf("Failed to configure audit anchoring: %v", err)
+		}
+		templateService = services.NewTemplateService(db, auditService)
+		namespaceService = services.NewNamespaceService(db, auditService)
+		secretIntegrity := services.NewSecretIntegrity(secretIntegrityKey(cfg))
+		tenantKeyService = services.NewTenantKeyService(db, namespaceService, auditService, secretIntegrity)
+		emailNotifier, err = services.NewEmailNotifier(db, cfg.Notifications.SMTP)
+		if err != nil {
+			log.Fatalf("Failed to load email templates: %v", err)
+		}
+		notificationService = services.NewNotificationService(cfg.Notifications, emailNotifier)
+		notificationService.AddSink(eventFeed.HandleEvent)
+		singleUseService := services.NewSingleUseService(db)
+		chatService = services.NewChatService(db, auditService, singleUseService, cfg.Notifications.Chat, chatCallbackKey(cfg), cfg.Server.PublicURL)
+		notificationService.AddSink(chatService.HandleEvent)
+		escalationService = services.NewEscalationService(db, auditService, cfg.Notifications.Escalation)
+		notificationService.AddSink(escalationService.HandleEvent)
+		secretService = services.NewSecretService(db, cfg.Security.EncryptionKey, "default-salt", cfg.Security.KDFIterations, auditService, templateService, tenantKeyService, notificationService, secretIntegrity)
+		if err := secretService.EnableDataKeys(); err != nil {
+			log.Fatalf("Failed to load data keys: %v", err)
+		}
+		mountService = services.NewMountService(db, namespaceService, auditService)
+		if err := mountService.EnsureBuiltins(); err != nil {
+			log.Printf("⚠️  Failed to create builtin mounts: %v", err)
+		}
+		secretService.UseMounts(mountService)
+		validatorService = services.NewSecretValidatorService(db, auditService)
+		secretService.UseValidators(validatorService)
+		teamKeyService = services.NewTeamKeyService(db, namespaceService, secretService, auditService)
+		escrowService = services.NewEscrowService(db, cfg.Security.EncryptionKey, teamKeyService, auditService)
+		providerService = services.NewProviderService(db, secretService)
+		totpService = services.NewTOTPService(db, secretService, auditService)
+		policyService = services.NewPolicyService(db, auditService)
+		secretService.UseFieldPolicies(policyService, namespaceService)
 		networkService = services.NewNetworkService(db)
+		shareService = services.NewShareService(db, secretService, auditService, singleUseService)
+		oidcService = services.NewOIDCService(db, secretService, userService, auditService, &cfg.OIDC)
+		pkiService = services.NewPKIService(db, secretService, userService, policyService, auditService, &cfg.PKI, cfg.Server.PublicURL)
+		sshService = services.NewSSHService(db, secretService, userService, policyService, auditService, singleUseService, &cfg.SSH)
+		wrappingService = services.NewWrappingService(db, auditService, singleUseService, &cfg.Wrapping)
+		rotationService := services.NewSecretRotationService(db, secretService, notificationService, auditService, &cfg.Rotation)
+		secretService.UseRotation(rotationService)
+		secretImportService = services.NewSecretImportService(db, secretService, auditService)
+		secretCheckoutService = services.NewSecretCheckoutService(db, secretService, rotationService, auditService)
+		secretService.UseCheckouts(secretCheckoutService)
+		orgService = services.NewOrgService(db, auditService)
+		secretService.UseOrganizations(orgService)
+		reminderService = services.NewReminderService(db, notificationService, emailNotifier, auditService, time.Duration(cfg.Notifications.ReminderRepeat)*time.Hour)
+		accessStats := services.NewAccessStats(db, time.Duration(cfg.Cache.StatsWindowDays)*24*time.Hour)
+		accessStats.Start(time.Duration(cfg.Cache.StatsFlushInterval) * time.Second)
+		cacheTTL := time.Duration(cfg.Cache.TTL) * time.Second
+		if cfg.Cache.HAMode {
+			// Other instances' writes would stay invisible until entries expire
+			cacheTTL = 0
+			log.Printf("🔁 HA mode: caching disabled")
+		}
+		secretService.EnableCache(cacheTTL, accessStats)
+		policyService.EnableCache(cacheTTL, accessStats)
+		policyService.StartCanaries()
+		oidcService.EnableCache(cacheTTL)
+		secretAccessService = services.NewSecretAccessService(db, auditService, notificationService, emailNotifier)
+		secretAccessService.Start()
+		secretService.TrackAccess(secretAccessService)
+		quotaService = services.NewQuotaService(db, auditService, notificationService, cfg.Quotas)
+		quotaService.Start()
+		secretService.UseQuotas(quotaService)
+		cacheWarmupService = services.NewCacheWarmupService(accessStats, cfg.Cache)
+		cacheWarmupService.RegisterSecrets(secretService)
+		cacheWarmupService.RegisterPolicies(policyService)
+		cacheWarmupService.RegisterJWKS(oidcService)
+		if migrated, err := totpService.EncryptLegacySeeds(); err != nil {
+			log.Printf("⚠️  Failed to encrypt legacy TOTP seeds: %v", err)
+		} else if migrated > 0 {
+			log.Printf("🔐 Encrypted %d legacy TOTP seeds", migrated)
+		}
+		if resumed, err := secretService.ResumeValidations(); err != nil {
+			log.Printf("⚠️  Failed to resume secret validations: %v", err)
+		} else if resumed > 0 {
+			log.Printf("🔎 Resumed %d pending secret validations", resumed)
+		}
+		if resumed, err := tenantKeyService.ResumeInterrupted(); err != nil {
+			log.Printf("⚠️  Failed to resume tenant key re-wraps: %v", err)
+		} else if resumed > 0 {
+			log.Printf("🔐 Resumed %d interrupted tenant key re-wraps", resumed)
+		}
+		if cfg.Notifications.ReminderInterval > 0 {
+			reminderService.Start(time.Duration(cfg.Notifications.ReminderInterval) * time.Second)
+		}
+		escalationService.Start(time.Minute)
+		auditAnchorService.Start(time.Duration(cfg.Audit.Anchor.Interval) * time.Second)
+		jobService = services.NewJobService(db, auditService, cfg.Jobs)
+		jobService.UseFeed(eventFeed)
+		jobService.RegisterMaintenanceJobs(cfg.Jobs)
+		jobService.Register(secretService.ScrubJob(time.Duration(cfg.Jobs.SecretScrubInterval) * time.Second))
+		jobService.Register(secretService.ReencryptJob(time.Duration(cfg.Jobs.DataKeyReencryptInterval) * time.Second))
+		jobService.Register(services.NewAuditExportService(db, cfg.Audit.OTLP).Job())
+		jobService.Register(quotaService.Job())
+		jobService.Register(singleUseService.Job(time.Duration(cfg.Jobs.SingleUseTokenInterval) * time.Second))
+		sandboxService = services.NewSandboxService(db, namespaceService, secretService, auditService)
+		jobService.Register(sandboxService.Job(time.Duration(cfg.Jobs.SandboxExpiryInterval) * time.Second))
+		expiryScheduler := services.NewExpiryScheduler(db)
+		shareService.UseExpiry(expiryScheduler)
+		sandboxService.UseExpiry(expiryScheduler)
+		secretService.UseExpiry(expiryScheduler)
+		secretCheckoutService.UseExpiry(expiryScheduler)
+		leaseService = services.NewLeaseService(db, auditService)
+		leaseService.UseExpiry(expiryScheduler)
+		shareService.UseLeases(leaseService)
+		secretService.UseLeases(leaseService)
+		jobService.Register(leaseService.Job(time.Duration(cfg.Jobs.ExpiredLeasesInterval) * time.Second))
+		jobService.Register(sshService.Job(time.Duration(cfg.Jobs.ExpiredSSHOTPsInterval) * time.Second))
+		jobService.Register(wrappingService.Job(time.Duration(cfg.Jobs.ExpiredWrappedResponsesInterval) * time.Second))
+		jobService.Register(rotationService.Job(time.Duration(cfg.Jobs.SecretRotationInterval) * time.Second))
+		if resumed, err := secretService.ResumePublications(); err != nil {
+			log.Printf("⚠️  Failed to resume scheduled secret values: %v", err)
+		} else if resumed > 0 {
+			log.Printf("⏰ Resumed %d scheduled secret values", resumed)
+		}
+		expiryScheduler.Start()
+		migrationService = services.NewMigrationService(db, auditService, cfg.Jobs)
+		migrationService.Start()
+		jobService.Register(migrationService.BackfillJob(time.Duration(cfg.Jobs.OnlineMigrationInterval) * time.Second))
+		jobService.Start()
+		if cfg.Cache.WarmupEnabled && cacheTTL > 0 {
+			go cacheWarmupService.Warm()
+		}
 		log.Printf("✅ Database-backed services initialized")
 	} else {
 		// Mock services for development
@@ -75,20 +299,81 @@ func main() {
 		// and handle this in the routes/controllers
 	}
 
+	// Flags come from the configuration alone when there is no database
+	featureFlagService := services.NewFeatureFlagService(db, auditService, cfg.Features)
+	featureFlagService.Start()
+
+	maintenanceService := services.NewMaintenanceService(db, auditService)
+	maintenanceService.Start()
+
+	passwordPolicyService := services.NewPasswordPolicyService(cfg.PasswordPolicy)
+
+	// Generation works without a database; storing results needs secretService
+	generatorService := services.NewGeneratorService(secretService, auditService)
+	generatorService.UsePasswordPolicy(passwordPolicyService)
+
 	// Always initialize auth service (can work with mock user service)
-	authService := services.NewAuthService(userService, &cfg.JWT)
+	authService := services.NewAuthService(userService, &cfg.JWT, notificationService)
+	impersonationService := services.NewImpersonationService(db, authService, userService, policyService, auditService, notificationService)
+	authService.UseImpersonation(impersonationService)
+	var workloadService *services.WorkloadService
+	var oidcAuthService *services.OIDCAuthService
+	var appRoleService *services.AppRoleService
+	var kubernetesAuthService *services.KubernetesAuthService
+	var awsAuthService *services.AWSAuthService
+	var tokenService *services.TokenService
+	var mfaService *services.MFAService
+	var sessionService *services.SessionService
+	var controlGroupService *services.ControlGroupService
+	if db != nil {
+		workloadService = services.NewWorkloadService(db, authService, auditService)
+		oidcAuthService = services.NewOIDCAuthService(db, secretService, userService, authService, auditService)
+		if cfg.LDAP.Enabled {
+			ldapAuthService, err := services.NewLDAPAuthService(db, cfg.LDAP, userService, auditService)
+			if err != nil {
+				log.Fatalf("Failed to configure LDAP login: %v", err)
+			}
+			authService.UseLDAP(ldapAuthService)
+		}
+		appRoleService = services.NewAppRoleService(db, authService, auditService)
+		jobService.Register(appRoleService.Job(time.Duration(cfg.Jobs.ExpiredAppRoleSecretIDsInterval) * time.Second))
+		kubernetesAuthService, err = services.NewKubernetesAuthService(db, cfg.Kubernetes, authService, auditService)
+		if err != nil {
+			log.Fatalf("Failed to configure Kubernetes login: %v", err)
+		}
+		awsAuthService = services.NewAWSAuthService(db, cfg.AWS, authService, auditService)
+		tokenService = services.NewTokenService(db, userService, policyService, auditService)
+		authService.UseTokens(tokenService)
+		jobService.Register(tokenService.Job(time.Duration(cfg.Jobs.ExpiredTokensInterval) * time.Second))
+		mfaService = services.NewMFAService(db, totpService, secretService, policyService, auditService, notificationService)
+		secretService.UseStepUp(mfaService)
+		sessionService = services.NewSessionService(db, userService, policyService, auditService, notificationService)
+		controlGroupService = services.NewControlGroupService(db, policyService, auditService, notificationService)
+		secretService.UseControlGroups(controlGroupService)
+	}
 
-	router := routes.NewRouter(db, authService, secretService, totpService, userService, policyService, auditService, networkService)
+	router := routes.NewRouter(db, authService, secretService, totpService, userService, policyService, auditService, auditAnchorService, networkService, shareService, templateService, namespaceService, tenantKeyService, teamKeyService, providerService, reminderService, emailNotifier, chatService, escalationService, jobService, migrationService, featureFlagService, secretAccessService, generatorService, oidcService, cacheWarmupService, escrowService, mountService, eventFeed, quotaService, sandboxService, validatorService, maintenanceService, impersonationService, leaseService, workloadService, pkiService, entropyService, sshService, wrappingService, secretImportService, secretCheckoutService, orgService, oidcAuthService, appRoleService, kubernetesAuthService, awsAuthService, tokenService, mfaService, sessionService, passwordPolicyService, controlGroupService, cfg)
 	router.SetupRoutes()
 
-	server := &http.Server{
-		Addr:         fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
-		Handler:      router.GetEngine(),
-		ReadTimeout:  time.Duration(cfg.Server.ReadTimeout) * time.Second,
-		WriteTimeout: time.Duration(cfg.Server.WriteTimeout) * time.Second,
-	}
+	readTimeout := time.Duration(cfg.Server.ReadTimeout) * time.Second
+	writeTimeout := time.Duration(cfg.Server.WriteTimeout) * time.Second
+
+	listeners := []listener{{
+		name:    "API",
+		config:  config.ListenerConfig{Enabled: true, Host: cfg.Server.Host, Port: cfg.Server.Port, TLS: cfg.Server.TLS},
+		handler: router.GetEngine(),
+	}, {
+		name:    "cluster",
+		config:  cfg.Listeners.Cluster,
+		handler: router.GetClusterEngine(),
+	}, {
+		name:    "metrics",
+		config:  cfg.Listeners.Metrics,
+		handler: router.GetMetricsEngine(),
+	}}
 
-	log.Printf("Aether Vault API server starting on %s:%d", cfg.Server.Host, cfg.Server.Port)
+	build := buildinfo.Get()
+	log.Printf("Aether Vault %s (commit %s, built %s, %s)", build.Version, build.GitCommit, build.BuildDate, build.GoVersion)
 	log.Printf("Environment: %s", cfg.Server.Environment)
 
 	if db != nil {
@@ -97,11 +382,125 @@ func main() {
 		log.Printf("Database: not connected (development mode)")
 	}
 
-	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	errs := make(chan error, len(listeners))
+	for _, l := range listeners {
+		if !l.config.Enabled {
+			continue
+		}
+
+		server, err := l.server(readTimeout, writeTimeout)
+		if err != nil {
+			log.Fatalf("Failed to configure %s listener: %v", l.name, err)
+		}
+
+		go func(l listener, server *http.Server) {
+			scheme := "http"
+			if server.TLSConfig != nil {
+				scheme = "https"
+			}
+			log.Printf("Aether Vault %s listener starting on %s://%s", l.name, scheme, server.Addr)
+
+			var err error
+			if server.TLSConfig != nil {
+				err = server.ListenAndServeTLS("", "")
+			} else {
+				err = server.ListenAndServe()
+			}
+			if err != nil && err != http.ErrServerClosed {
+				errs <- fmt.Errorf("%s listener: %w", l.name, err)
+			}
+		}(l, server)
+	}
+
+	if err := <-errs; err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
 
+// listener is one bound address serving a single handler
+type listener struct {
+	name    string
+	config  config.ListenerConfig
+	handler http.Handler
+}
+
+func (l listener) server(readTimeout, writeTimeout time.Duration) (*http.Server, error) {
+	server := &http.Server{
+		Addr:         fmt.Sprintf("%s:%d", l.config.Host, l.config.Port),
+		Handler:      l.handler,
+		ReadTimeout:  readTimeout,
+		WriteTimeout: writeTimeout,
+	}
+
+	if !l.config.TLS.Enabled() {
+		return server, nil
+	}
+
+	tlsConfig, err := buildTLSConfig(l.config.TLS)
+	if err != nil {
+		return nil, err
+	}
+	server.TLSConfig = tlsConfig
+
+	return server, nil
+}
+
+// buildTLSConfig loads the listener certificate and, when a client CA is
+// configured, requires verified client certificates (mutual TLS)
+func buildTLSConfig(cfg config.TLSConfig) (*tls.Config, error) {
+	certificate, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificate: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if cfg.MinVersion == "1.3" {
+		tlsConfig.MinVersion = tls.VersionTLS13
+	}
+
+	if cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, nil
+}
+
+// auditHMACKey returns the configured audit hashing key, or one derived
+// from the encryption key
+func auditHMACKey(cfg *config.Config) []byte {
+	if cfg.Audit.HMACKey != "" {
+		return []byte(cfg.Audit.HMACKey)
+	}
+	sum := sha256.Sum256([]byte("aether-vault-audit:" + cfg.Security.EncryptionKey))
+	return sum[:]
+}
+
+func secretIntegrityKey(cfg *config.Config) []byte {
+	sum := sha256.Sum256([]byte("aether-vault-secret-integrity:" + cfg.Security.EncryptionKey))
+	return sum[:]
+}
+
+func chatCallbackKey(cfg *config.Config) []byte {
+	if cfg.Notifications.Chat.CallbackKey != "" {
+		return []byte(cfg.Notifications.Chat.CallbackKey)
+	}
+	sum := sha256.Sum256([]byte("aether-vault-chat-callback:" + cfg.Security.EncryptionKey))
+	return sum[:]
+}
+
 func initDatabase(dbConfig config.DatabaseConfig) (*gorm.DB, error) {
 	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=%s",
 		dbConfig.Host,
@@ -112,14 +511,24 @@ func initDatabase(dbConfig config.DatabaseConfig) (*gorm.DB, error) {
 		dbConfig.SSLMode,
 	)
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	gormConfig := &gorm.Config{}
+	if dbConfig.SlowQueryMs > 0 {
+		gormConfig.Logger = logger.New(log.New(os.Stdout, "\r\n", log.LstdFlags), logger.Config{
+			SlowThreshold:             time.Duration(dbConfig.SlowQueryMs) * time.Millisecond,
+			LogLevel:                  logger.Warn,
+			IgnoreRecordNotFoundError: true,
+		})
+	}
+
+	sqlDB, err := sql.Open("pgx", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
-
-	sqlDB, err := db.DB()
+	// Caches are cleared when the transactions writing to them commit
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: services.NewCachePool(sqlDB)}), gormConfig)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get underlying sql.DB: %w", err)
+		sqlDB.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
 	sqlDB.SetMaxIdleConns(10)
@@ -129,12 +538,170 @@ func initDatabase(dbConfig config.DatabaseConfig) (*gorm.DB, error) {
 	return db, nil
 }
 
+// validateConfigCommand implements `config validate`
+func validateConfigCommand(flags *pflag.FlagSet) int {
+	source, err := config.ValidateFile(flags)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %s: %v\n", source, err)
+		return 1
+	}
+
+	fmt.Printf("✅ Configuration is valid (%s)\n", source)
+	return 0
+}
+
+// auditVerifyCommand checks the audit hash chain against the external
+// anchors and prints the report; it exits non-zero when tampering is found
+func auditVerifyCommand(cfg *config.Config) int {
+	db, err := initDatabase(cfg.Database)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Database connection failed: %v\n", err)
+		return 1
+	}
+
+	anchors, err := services.NewAuditAnchorService(db, cfg.Audit.Anchor)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+
+	report, err := anchors.Verify()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Verification failed: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Chain: %d entries checked (%d to %d)\n", report.EntriesChecked, report.FirstSequence, report.LastSequence)
+	if !anchors.Enabled() {
+		fmt.Println("Anchors: none (audit.anchor.sink is not configured)")
+	}
+	for _, check := range report.Anchors {
+		fmt.Printf("  anchor %d %s: %s\n", check.Sequence, check.Location, check.Status)
+	}
+	for _, problem := range report.Errors {
+		fmt.Printf("❌ %s\n", problem)
+	}
+
+	if !report.Valid {
+		fmt.Println("❌ Audit log verification failed")
+		return 1
+	}
+	if report.VerifiedThrough > 0 {
+		fmt.Printf("✅ Audit log intact; entries through %d match the external anchors\n", report.VerifiedThrough)
+	} else {
+		fmt.Println("✅ Audit chain is consistent, but no anchor covers it yet")
+	}
+	return 0
+}
+
+// runSelfTest runs the crypto known-answer tests, RNG, schema and config
+// checks and logs each result
+func runSelfTest(db *gorm.DB, cfg *config.Config, verbose bool) bool {
+	report := services.NewSelfTestService(db, cfg, schemaModels()).Run()
+
+	for _, check := range report.Checks {
+		if !check.Passed {
+			log.Printf("❌ Self-test %s failed: %s", check.Name, check.Detail)
+		} else if verbose {
+			log.Printf("✅ Self-test %s passed (%s): %s", check.Name, check.Duration, check.Detail)
+		}
+	}
+
+	if report.SchemaChecksum != "" {
+		log.Printf("Schema checksum: %s", report.SchemaChecksum)
+	}
+	if report.Passed {
+		log.Printf("✅ Self-test passed (%d checks)", len(report.Checks))
+	}
+
+	return report.Passed
+}
+
 func migrateDatabase(db *gorm.DB) error {
-	return db.AutoMigrate(
+	return db.AutoMigrate(schemaModels()...)
+}
+
+// schemaModels lists every persisted model, shared by migration and the
+// schema self-test
+func schemaModels() []interface{} {
+	return []interface{}{
 		&model.User{},
 		&model.Secret{},
+		&model.SecretAccessStat{},
 		&model.TOTP{},
 		&model.Policy{},
+		&model.PolicyTemplate{},
+		&model.PolicyAssignment{},
 		&model.AuditLog{},
-	)
+		&model.AuditChainHead{},
+		&model.AuditAnchor{},
+		&model.AuditExportCursor{},
+		&model.ShareLink{},
+		&model.SecretTemplate{},
+		&model.Namespace{},
+		&model.NamespaceRoleBinding{},
+		&model.NamespaceQuota{},
+		&model.NamespaceUsageSample{},
+		&model.NamespaceRequestCount{},
+		&model.NamespaceQuotaAlert{},
+		&model.TenantKey{},
+		&model.UserKey{},
+		&model.KeyEscrow{},
+		&model.EscrowShare{},
+		&model.Mount{},
+		&model.SecretVersion{},
+		&model.SecretValidator{},
+		&model.SecretValidation{},
+		&model.SecretPublication{},
+		&model.SecretField{},
+		&model.DataKey{},
+		&model.PolicyCanary{},
+		&model.PolicyDivergence{},
+		&model.Lease{},
+		&model.Workload{},
+		&model.PKIIssuer{},
+		&model.PKIRole{},
+		&model.PKICertificate{},
+		&model.SSHCAKey{},
+		&model.SSHRole{},
+		&model.SSHOTP{},
+		&model.WrappedResponse{},
+		&model.ImportKey{},
+		&model.SecretCheckout{},
+		&model.Organization{},
+		&model.Team{},
+		&model.Project{},
+		&model.OrgMembership{},
+		&model.OIDCProvider{},
+		&model.OIDCLoginState{},
+		&model.AuthIdentity{},
+		&model.AppRole{},
+		&model.AppRoleSecretID{},
+		&model.KubernetesRole{},
+		&model.AWSRole{},
+		&model.Token{},
+		&model.UserMFA{},
+		&model.Session{},
+		&model.ControlGroupRequest{},
+		&model.ControlGroupApproval{},
+		&model.ReminderPolicy{},
+		&model.Reminder{},
+		&model.NotificationPreference{},
+		&model.ChatChannel{},
+		&model.ChatRoute{},
+		&model.EscalationTarget{},
+		&model.EscalationRule{},
+		&model.Incident{},
+		&model.JobRun{},
+		&model.JobLease{},
+		&model.OnlineMigration{},
+		&model.FeatureFlagOverride{},
+		&model.MaintenanceMode{},
+		&model.Impersonation{},
+		&model.SingleUseToken{},
+		&model.ExpiryTimer{},
+		&model.OIDCKey{},
+		&model.OIDCRole{},
+		&model.CacheAccessStat{},
+	}
 }
@@ -0,0 +1,109 @@
+// Package buildinfo holds the version of the running binary. Release
+// builds set it with ldflags:
+//
+//	go build -trimpath -ldflags "\
+//	  -X github.com/skygenesisenterprise/aether-vault/server/src/buildinfo.Version=1.4.0 \
+//	  -X github.com/skygenesisenterprise/aether-vault/server/src/buildinfo.GitCommit=$(git rev-parse HEAD) \
+//	  -X github.com/skygenesisenterprise/aether-vault/server/src/buildinfo.BuildDate=$(git log -1 --format=%cI) \
+//	  -X github.com/skygenesisenterprise/aether-vault/server/src/buildinfo.Features=otlp,pprof"
+//
+// The build date is the commit date rather than the wall clock so the same
+// commit always produces the same binary. Without ldflags the commit and
+// date fall back to the VCS stamp Go embeds.
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+var (
+	Version   = "dev"
+	GitCommit = ""
+	BuildDate = ""
+	// Features is a comma-separated list of the features compiled in
+	Features = ""
+)
+
+// Info describes the running build
+type Info struct {
+	Version   string   `json:"version"`
+	GitCommit string   `json:"git_commit"`
+	BuildDate string   `json:"build_date"`
+	GoVersion string   `json:"go_version"`
+	Platform  string   `json:"platform"`
+	Features  []string `json:"features"`
+	// Modified is set when the binary was built from a dirty tree
+	Modified bool `json:"modified,omitempty"`
+}
+
+var (
+	once   sync.Once
+	cached Info
+)
+
+// Get returns the build information
+func Get() Info {
+	once.Do(func() { cached = read() })
+	info := cached
+	info.Features = append([]string{}, cached.Features...)
+	return info
+}
+
+func read() Info {
+	info := Info{
+		Version:   Version,
+		GitCommit: GitCommit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		Features:  []string{},
+	}
+	for _, feature := range strings.Split(Features, ",") {
+		if feature = strings.TrimSpace(feature); feature != "" {
+			info.Features = append(info.Features, feature)
+		}
+	}
+
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.GitCommit == "" {
+					info.GitCommit = setting.Value
+				}
+			case "vcs.time":
+				if info.BuildDate == "" {
+					info.BuildDate = setting.Value
+				}
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	}
+	if info.GitCommit == "" {
+		info.GitCommit = "unknown"
+	}
+	if info.BuildDate == "" {
+		info.BuildDate = "unknown"
+	}
+
+	return info
+}
+
+// String identifies the build in one token, e.g. "1.4.0+3f20ff4", for log
+// lines and audit entries
+func String() string {
+	info := Get()
+	commit := info.GitCommit
+	if len(commit) > 7 {
+		commit = commit[:7]
+	}
+	id := info.Version + "+" + commit
+	if info.Modified {
+		id += ".dirty"
+	}
+	return id
+}
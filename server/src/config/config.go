@@ -2,26 +2,87 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"strings"
 
+	"github.com/google/uuid"
 	"github.com/joho/godotenv"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Security SecurityConfig `mapstructure:"security"`
-	JWT      JWTConfig      `mapstructure:"jwt"`
-	Audit    AuditConfig    `mapstructure:"audit"`
+	Server         ServerConfig         `mapstructure:"server"`
+	Database       DatabaseConfig       `mapstructure:"database"`
+	Security       SecurityConfig       `mapstructure:"security"`
+	JWT            JWTConfig            `mapstructure:"jwt"`
+	Audit          AuditConfig          `mapstructure:"audit"`
+	OIDC           OIDCConfig           `mapstructure:"oidc"`
+	LDAP           LDAPConfig           `mapstructure:"ldap"`
+	Kubernetes     KubernetesConfig     `mapstructure:"kubernetes"`
+	AWS            AWSConfig            `mapstructure:"aws"`
+	PasswordPolicy PasswordPolicyConfig `mapstructure:"password_policy"`
+	PKI            PKIConfig            `mapstructure:"pki"`
+	Entropy        EntropyConfig        `mapstructure:"entropy"`
+	SSH            SSHConfig            `mapstructure:"ssh"`
+	Wrapping       WrappingConfig       `mapstructure:"wrapping"`
+	Rotation       RotationConfig       `mapstructure:"rotation"`
+	Listeners      ListenersConfig      `mapstructure:"listeners"`
+	Limits         LimitsConfig         `mapstructure:"limits"`
+	Diagnostics    DiagnosticsConfig    `mapstructure:"diagnostics"`
+	Jobs           JobsConfig           `mapstructure:"jobs"`
+	Notifications  NotificationsConfig  `mapstructure:"notifications"`
+	Cache          CacheConfig          `mapstructure:"cache"`
+	Features       FeaturesConfig       `mapstructure:"features"`
+	Quotas         QuotasConfig         `mapstructure:"quotas"`
 }
 
 type ServerConfig struct {
-	Host         string `mapstructure:"host"`
-	Port         int    `mapstructure:"port"`
-	Environment  string `mapstructure:"environment"`
-	ReadTimeout  int    `mapstructure:"read_timeout"`
-	WriteTimeout int    `mapstructure:"write_timeout"`
+	Host         string    `mapstructure:"host"`
+	Port         int       `mapstructure:"port"`
+	Environment  string    `mapstructure:"environment"`
+	ReadTimeout  int       `mapstructure:"read_timeout"`
+	WriteTimeout int       `mapstructure:"write_timeout"`
+	PublicURL    string    `mapstructure:"public_url"`
+	TLS          TLSConfig `mapstructure:"tls"`
+}
+
+// TLSConfig enables TLS on a listener when both files are set; a client CA
+// additionally requires and verifies client certificates
+type TLSConfig struct {
+	CertFile     string `mapstructure:"cert_file"`
+	KeyFile      string `mapstructure:"key_file"`
+	ClientCAFile string `mapstructure:"client_ca_file"`
+	MinVersion   string `mapstructure:"min_version"`
+}
+
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != "" && t.KeyFile != ""
+}
+
+// ListenersConfig configures the listeners that run alongside the public
+// API listener (server.host/server.port)
+type ListenersConfig struct {
+	Cluster ListenerConfig `mapstructure:"cluster"`
+	Metrics ListenerConfig `mapstructure:"metrics"`
+}
+
+type ListenerConfig struct {
+	Enabled bool      `mapstructure:"enabled"`
+	Host    string    `mapstructure:"host"`
+	Port    int       `mapstructure:"port"`
+	TLS     TLSConfig `mapstructure:"tls"`
+}
+
+// LimitsConfig caps concurrent in-flight requests per class; 0 disables the
+// cap for that class
+type LimitsConfig struct {
+	MaxConcurrentReads  int `mapstructure:"max_concurrent_reads"`
+	MaxConcurrentWrites int `mapstructure:"max_concurrent_writes"`
+	MaxConcurrentAuth   int `mapstructure:"max_concurrent_auth"`
+	QueueTimeoutMs      int `mapstructure:"queue_timeout_ms"`
+	RetryAfter          int `mapstructure:"retry_after"`
 }
 
 type DatabaseConfig struct {
@@ -31,8 +92,161 @@ type DatabaseConfig struct {
 	Password string `mapstructure:"password"`
 	DBName   string `mapstructure:"dbname"`
 	SSLMode  string `mapstructure:"sslmode"`
+	// Queries slower than this are logged; 0 disables slow query logging
+	SlowQueryMs int `mapstructure:"slow_query_ms"`
+}
+
+// DiagnosticsConfig controls the admin-only profiling endpoints
+type DiagnosticsConfig struct {
+	PprofEnabled bool `mapstructure:"pprof_enabled"`
+}
+
+// CacheConfig controls the in-memory caches of secret metadata, policies
+// and the OIDC key set, and their pre-load at startup
+type CacheConfig struct {
+	// Seconds an entry is served before it is re-read; it bounds how long
+	// another instance's writes stay invisible. 0 disables caching.
+	TTL int `mapstructure:"ttl"`
+	// Set when several instances share the database; caching is then
+	// disabled, since this instance never hears of the others' writes
+	HAMode bool `mapstructure:"ha_mode"`
+	// Pre-load the most accessed entries of earlier runs at startup
+	WarmupEnabled bool `mapstructure:"warmup_enabled"`
+	// Entries pre-loaded per cache
+	WarmupLimit int `mapstructure:"warmup_limit"`
+	// Days of access statistics considered
+	StatsWindowDays int `mapstructure:"stats_window_days"`
+	// Seconds between writes of access statistics to the database
+	StatsFlushInterval int `mapstructure:"stats_flush_interval"`
+}
+
+// FeaturesConfig turns experimental subsystems on or off. Admins can
+// override each flag at runtime through /api/v1/sys/features.
+type FeaturesConfig struct {
+	Namespaces  bool `mapstructure:"namespaces"`
+	Replication bool `mapstructure:"replication"`
+	Plugins     bool `mapstructure:"plugins"`
 }
 
+// QuotasConfig controls namespace usage sampling and soft quota warnings
+type QuotasConfig struct {
+	// Seconds between usage samples and quota checks; 0 leaves them to
+	// on-demand runs through /api/v1/sys/jobs
+	CheckInterval int `mapstructure:"check_interval"`
+	// Usage, in percent of a quota, that raises a warning when a
+	// namespace quota does not set its own
+	WarnPercent int `mapstructure:"warn_percent"`
+	// Seconds of traffic averaged into the request rate
+	RequestWindow int `mapstructure:"request_window"`
+	// Days of usage samples kept for dashboards and forecasts
+	HistoryDays int `mapstructure:"history_days"`
+}
+
+// JobsConfig schedules the background maintenance jobs. Intervals are in
+// seconds; 0 leaves a job to on-demand runs through /api/v1/sys/jobs.
+type JobsConfig struct {
+	// Seconds a leadership or job lease lasts without renewal
+	LeaseTTL int `mapstructure:"lease_ttl"`
+	// Runs kept per job
+	HistoryLimit int `mapstructure:"history_limit"`
+
+	ExpiredSharesInterval    int `mapstructure:"expired_shares_interval"`
+	SecretCompactionInterval int `mapstructure:"secret_compaction_interval"`
+	// Days soft-deleted secrets are kept before compaction removes them
+	SecretRetentionDays   int `mapstructure:"secret_retention_days"`
+	OrphanCleanupInterval int `mapstructure:"orphan_cleanup_interval"`
+	AuditArchiveInterval  int `mapstructure:"audit_archive_interval"`
+	// Directory receiving archived audit entries; empty disables archival
+	AuditArchiveDir string `mapstructure:"audit_archive_dir"`
+	// Days audit entries stay in the database before archival
+	AuditRetentionDays int `mapstructure:"audit_retention_days"`
+	// Verifies every secret row's checksum and quarantines failures
+	SecretScrubInterval int `mapstructure:"secret_scrub_interval"`
+	// Copies rows for online schema migrations in the backfill phase
+	OnlineMigrationInterval int `mapstructure:"online_migration_interval"`
+	// Rows converted per backfill transaction
+	OnlineMigrationBatchSize int `mapstructure:"online_migration_batch_size"`
+	// Destroys namespace sandboxes past their expiry that the expiry
+	// scheduler missed
+	SandboxExpiryInterval int `mapstructure:"sandbox_expiry_interval"`
+	// Removes consumption records of expired one-time tokens
+	SingleUseTokenInterval int `mapstructure:"single_use_token_interval"`
+	// Re-encrypts values still sealed under a retired data key or the bare
+	// master key with the current data key
+	DataKeyReencryptInterval int `mapstructure:"data_key_reencrypt_interval"`
+	// Removes leases that ended more than a week ago; leases are revoked
+	// when they expire regardless
+	ExpiredLeasesInterval int `mapstructure:"expired_leases_interval"`
+	// Removes SSH one-time passwords that expired unused or were used
+	ExpiredSSHOTPsInterval int `mapstructure:"expired_ssh_otps_interval"`
+	// Removes wrapped responses that were unwrapped or expired
+	ExpiredWrappedResponsesInterval int `mapstructure:"expired_wrapped_responses_interval"`
+	// Removes AppRole secret IDs that expired
+	ExpiredAppRoleSecretIDsInterval int `mapstructure:"expired_approle_secret_ids_interval"`
+	// Removes expired tokens and revokes their children
+	ExpiredTokensInterval int `mapstructure:"expired_tokens_interval"`
+	// Rotates secrets whose rotation_period has passed and flags secrets
+	// about to expire
+	SecretRotationInterval int `mapstructure:"secret_rotation_interval"`
+}
+
+// NotificationsConfig configures event delivery over webhook and email, and
+// how often the reminder scheduler scans for expiring or stale secrets
+type NotificationsConfig struct {
+	WebhookURL string `mapstructure:"webhook_url"`
+	// Signs webhook bodies (X-Vault-Signature) when set
+	WebhookSecret string     `mapstructure:"webhook_secret"`
+	SMTP          SMTPConfig `mapstructure:"smtp"`
+	// Seconds between reminder scans; 0 disables the scheduler
+	ReminderInterval int `mapstructure:"reminder_interval"`
+	// Hours before an unacknowledged reminder is sent again
+	ReminderRepeat int              `mapstructure:"reminder_repeat"`
+	Chat           ChatConfig       `mapstructure:"chat"`
+	Escalation     EscalationConfig `mapstructure:"escalation"`
+}
+
+// ChatConfig secures approve/deny callbacks from Slack and Teams messages
+type ChatConfig struct {
+	// Signs callback tokens; derived from the encryption key when empty
+	CallbackKey string `mapstructure:"callback_key"`
+	// Seconds an approve/deny button stays valid
+	CallbackTTL int `mapstructure:"callback_ttl"`
+	// Verifies Slack interactivity requests (X-Slack-Signature); without it
+	// Slack buttons open the callback page instead
+	SlackSigningSecret string `mapstructure:"slack_signing_secret"`
+}
+
+// EscalationConfig controls when security conditions open PagerDuty or
+// Opsgenie incidents and when they are resolved automatically
+type EscalationConfig struct {
+	// Seconds without a recurrence before an incident is resolved; 0 waits
+	// for the condition to be cleared explicitly
+	ResolveAfter int `mapstructure:"resolve_after"`
+	// Failed sign-ins for one account within AuthFailureWindow seconds
+	// that raise security.auth_failures; 0 disables the check
+	AuthFailureThreshold int `mapstructure:"auth_failure_threshold"`
+	AuthFailureWindow    int `mapstructure:"auth_failure_window"`
+}
+
+// SMTPConfig enables email delivery when Host is set
+type SMTPConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	From     string `mapstructure:"from"`
+	// One of SMTPSecurityStartTLS, SMTPSecurityTLS or SMTPSecurityNone
+	Security string `mapstructure:"security"`
+	// Directory of <name>.tmpl files overriding the built-in templates
+	TemplatesDir string `mapstructure:"templates_dir"`
+}
+
+const (
+	SMTPSecurityStartTLS = "starttls"
+	SMTPSecurityTLS      = "tls"
+	SMTPSecurityNone     = "none"
+)
+
 type SecurityConfig struct {
 	EncryptionKey string `mapstructure:"encryption_key"`
 	KDFIterations int    `mapstructure:"kdf_iterations"`
@@ -40,17 +254,334 @@ type SecurityConfig struct {
 }
 
 type JWTConfig struct {
-	Secret     string `mapstructure:"secret"`
-	Expiration int    `mapstructure:"expiration"`
+	Secret string `mapstructure:"secret"`
+	// Seconds a login token is valid, and how far each renewal extends it
+	Expiration int `mapstructure:"expiration"`
+	// Seconds past which a login token cannot be renewed
+	MaxTTL int `mapstructure:"max_ttl"`
+}
+
+type OIDCConfig struct {
+	Issuer            string `mapstructure:"issuer"`
+	KeyRotationPeriod int    `mapstructure:"key_rotation_period"`
+	VerificationTTL   int    `mapstructure:"verification_ttl"`
+	DefaultTokenTTL   int    `mapstructure:"default_token_ttl"`
+}
+
+// LDAPConfig signs users in against an LDAP directory or Active Directory
+// through POST /auth/ldap/login
+type LDAPConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// ldap:// or ldaps:// URL of the directory
+	URL string `mapstructure:"url"`
+	// Upgrades ldap:// connections with StartTLS
+	StartTLS bool `mapstructure:"starttls"`
+	// PEM bundle of the CAs trusted for the directory certificate; empty
+	// uses the system pool
+	CAFile             string `mapstructure:"ca_file"`
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"`
+	// Service account the user and group searches bind as; empty binds
+	// anonymously
+	BindDN       string `mapstructure:"bind_dn"`
+	BindPassword string `mapstructure:"bind_password"`
+	UserBaseDN   string `mapstructure:"user_base_dn"`
+	// Finds the entry of the user signing in; {{username}} is replaced
+	// with the escaped username
+	UserFilter         string `mapstructure:"user_filter"`
+	EmailAttribute     string `mapstructure:"email_attribute"`
+	FirstNameAttribute string `mapstructure:"first_name_attribute"`
+	LastNameAttribute  string `mapstructure:"last_name_attribute"`
+	// Groups are searched under GroupBaseDN with GroupFilter, where
+	// {{dn}} and {{username}} are replaced, and named by GroupAttribute.
+	// An empty GroupFilter reads the CNs of the user's memberOf instead.
+	GroupBaseDN    string `mapstructure:"group_base_dn"`
+	GroupFilter    string `mapstructure:"group_filter"`
+	GroupAttribute string `mapstructure:"group_attribute"`
+	// Comma-separated group=policy_id pairs; a group may appear several
+	// times. Policies are attached on each login and detached once the
+	// user leaves the group.
+	GroupPolicies string `mapstructure:"group_policies"`
+	// Comma-separated username=user_id pairs linking directory users to
+	// existing vault users on their first login. A matching email alone
+	// never links, since directory users may be able to edit their mail.
+	UserLinks string `mapstructure:"user_links"`
+	// Creates a vault user on first login when no vault user has the
+	// entry's email
+	AutoCreateUsers bool `mapstructure:"auto_create_users"`
+	// Idle connections kept open to the directory
+	PoolSize int `mapstructure:"pool_size"`
+	// Seconds a connection or request may take
+	Timeout int `mapstructure:"timeout"`
+}
+
+// KubernetesConfig lets pods sign in with their service account token
+// through POST /auth/kubernetes/login. Tokens are checked with the
+// cluster's TokenReview API, or against JWKSURL when it is set.
+type KubernetesConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// API server URL
+	Host string `mapstructure:"host"`
+	// PEM bundle of the CAs trusted for the API server; empty uses the
+	// system pool
+	CAFile string `mapstructure:"ca_file"`
+	// Token the vault sends TokenReviews with, which needs the
+	// system:auth-delegator role. Empty sends each login's own token.
+	TokenReviewerJWTFile string `mapstructure:"token_reviewer_jwt_file"`
+	// Verifies tokens locally against the cluster's key set instead of
+	// calling TokenReview, e.g. https://kubernetes.default.svc/openid/v1/jwks
+	JWKSURL string `mapstructure:"jwks_url"`
+	// Issuer tokens must name when checked against JWKSURL
+	Issuer string `mapstructure:"issuer"`
+	// Default audience tokens must carry; roles may require another
+	Audience string `mapstructure:"audience"`
+	// Seconds a request to the cluster may take
+	Timeout int `mapstructure:"timeout"`
+}
+
+// AWSConfig lets workloads on AWS sign in through POST /auth/aws/login
+// with a signed sts:GetCallerIdentity request, which the vault forwards to
+// STS to learn who signed it.
+type AWSConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// STS endpoint signed requests must be addressed to
+	STSEndpoint string `mapstructure:"sts_endpoint"`
+	// IAM endpoint and signing region used to look up principal tags for
+	// roles that bind tags. The lookup is signed with the credentials in
+	// AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY.
+	IAMEndpoint string `mapstructure:"iam_endpoint"`
+	IAMRegion   string `mapstructure:"iam_region"`
+	// Value logins must sign in the X-Aether-Vault-Server-ID header, so a
+	// request signed for this vault cannot be replayed against another.
+	// Empty does not require the header.
+	ServerID string `mapstructure:"server_id"`
+	// Seconds a request to AWS may take
+	Timeout int `mapstructure:"timeout"`
+}
+
+// PasswordPolicyConfig is what user passwords must meet when a user is
+// created or changes their password
+type PasswordPolicyConfig struct {
+	MinLength int `mapstructure:"min_length"`
+	MaxLength int `mapstructure:"max_length"`
+	// Character classes a password must contain
+	RequireUppercase bool `mapstructure:"require_uppercase"`
+	RequireLowercase bool `mapstructure:"require_lowercase"`
+	RequireDigit     bool `mapstructure:"require_digit"`
+	RequireSymbol    bool `mapstructure:"require_symbol"`
+	// Lowest strength score accepted, from 0 (guessable in under a
+	// thousand tries) to 4 (over ten billion), as zxcvbn scores them
+	MinStrength int `mapstructure:"min_strength"`
+	// Rejects passwords found in the Have I Been Pwned corpus. Only the
+	// first five hex characters of the password's SHA-1 leave the vault.
+	BreachCheck bool `mapstructure:"breach_check"`
+	// Range API the breach check queries, e.g.
+	// https://api.pwnedpasswords.com/range/ or an internal mirror
+	BreachEndpoint string `mapstructure:"breach_endpoint"`
+	// Rejects passwords while the range API cannot be reached; otherwise
+	// they are accepted without the check
+	BreachFailClosed bool `mapstructure:"breach_fail_closed"`
+	// Seconds a breach lookup may take
+	Timeout int `mapstructure:"timeout"`
+	// Also applies the length, character class and strength rules to
+	// passwords and passphrases from POST /sys/generate
+	ApplyToGenerated bool `mapstructure:"apply_to_generated"`
+}
+
+// PKIConfig bounds the certificates the PKI engine issues; TTLs are in
+// seconds, the CRL lifetime in hours
+type PKIConfig struct {
+	DefaultTTL  int `mapstructure:"default_ttl"`
+	MaxTTL      int `mapstructure:"max_ttl"`
+	CRLLifetime int `mapstructure:"crl_lifetime"`
+}
+
+// SSHConfig bounds the SSH credentials the vault brokers, in seconds
+type SSHConfig struct {
+	DefaultTTL int `mapstructure:"default_ttl"`
+	MaxTTL     int `mapstructure:"max_ttl"`
+	OTPTTL     int `mapstructure:"otp_ttl"`
+}
+
+// WrappingConfig bounds the wrap_ttl a read may ask for, in seconds
+type WrappingConfig struct {
+	MaxTTL int `mapstructure:"max_ttl"`
+}
+
+// RotationConfig drives the automatic rotation of secrets that name a
+// rotator and the warnings sent before secrets expire
+type RotationConfig struct {
+	// Seconds before expires_at a secret is flagged as expiring
+	ExpiryWarning int `mapstructure:"expiry_warning"`
+	// Seconds before a failed rotation is tried again
+	RetryInterval int `mapstructure:"retry_interval"`
+	// Rotator plugins as comma-separated name=URL pairs. A plugin answers
+	// a POST carrying the current value with the new one.
+	Plugins string `mapstructure:"plugins"`
+	// Sent to plugins as a bearer token when set
+	PluginToken string `mapstructure:"plugin_token"`
+	// Seconds a plugin has to answer
+	PluginTimeout int `mapstructure:"plugin_timeout"`
+}
+
+// EntropyConfig mixes external entropy sources into vault key generation
+// for deployments that must not rely on the operating system alone
+type EntropyConfig struct {
+	// Comma-separated source URIs: tpm:///dev/tpmrm0, file:///dev/hwrng
+	// or an https:// API answering GET with raw random bytes. Empty
+	// generates keys from crypto/rand alone.
+	Sources string `mapstructure:"sources"`
+	// Extra request headers for HTTP sources as comma-separated
+	// key=value pairs
+	Headers string `mapstructure:"headers"`
+	// Seconds between reseeds from the sources
+	ReseedInterval int `mapstructure:"reseed_interval"`
+	// "degrade" keeps serving from the healthy sources and the operating
+	// system while a source fails; "block" fails key generation until all
+	// sources are healthy again
+	FailurePolicy string `mapstructure:"failure_policy"`
 }
 
 type AuditConfig struct {
-	Enabled   bool   `mapstructure:"enabled"`
-	LogLevel  string `mapstructure:"log_level"`
-	LogFormat string `mapstructure:"log_format"`
+	Enabled    bool                  `mapstructure:"enabled"`
+	LogLevel   string                `mapstructure:"log_level"`
+	LogFormat  string                `mapstructure:"log_format"`
+	HMACKey    string                `mapstructure:"hmac_key"`
+	Anchor     AuditAnchorConfig     `mapstructure:"anchor"`
+	OTLP       AuditOTLPConfig       `mapstructure:"otlp"`
+	Enrichment AuditEnrichmentConfig `mapstructure:"enrichment"`
+}
+
+// AuditAnchorConfig periodically writes the audit hash chain head to
+// write-once storage outside the database
+type AuditAnchorConfig struct {
+	// file:///path appends to a local (e.g. WORM-mounted) log;
+	// s3://bucket/prefix writes Object Lock protected objects. Empty
+	// disables anchoring.
+	Sink string `mapstructure:"sink"`
+	// Seconds between anchors
+	Interval int `mapstructure:"interval"`
+	// S3 only: Object Lock mode (COMPLIANCE or GOVERNANCE) and the days
+	// each anchor is retained. Credentials come from AWS_ACCESS_KEY_ID,
+	// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN.
+	LockMode      string `mapstructure:"lock_mode"`
+	RetentionDays int    `mapstructure:"retention_days"`
+	Region        string `mapstructure:"region"`
+	// S3-compatible endpoint, addressed path-style; AWS when empty
+	Endpoint string `mapstructure:"endpoint"`
+}
+
+// AuditOTLPConfig ships audit entries as OpenTelemetry log records to an
+// OTLP/HTTP collector. The leader exports entries in chain order and
+// resumes after the last one acknowledged.
+type AuditOTLPConfig struct {
+	// Logs endpoint, e.g. http://otel-collector:4318/v1/logs; empty
+	// disables the export
+	Endpoint string `mapstructure:"endpoint"`
+	// Extra request headers as comma-separated key=value pairs, the
+	// format of OTEL_EXPORTER_OTLP_HEADERS
+	Headers string `mapstructure:"headers"`
+	// Resource attributes identifying this deployment
+	Namespace string `mapstructure:"namespace"`
+	Cluster   string `mapstructure:"cluster"`
+	// Defaults to the host name
+	Node string `mapstructure:"node"`
+	// Seconds between exports
+	Interval int `mapstructure:"interval"`
+	// Entries per OTLP request
+	BatchSize int `mapstructure:"batch_size"`
+	// Seconds to wait for the collector
+	Timeout int `mapstructure:"timeout"`
 }
 
-func LoadConfig() (*Config, error) {
+// AuditEnrichmentConfig adds context to audit entries before they are
+// chained and stored, so exports and anchors cover it too
+type AuditEnrichmentConfig struct {
+	// Comma-separated processors, run in order: geoip, container, user,
+	// threat_intel. Empty disables enrichment.
+	Processors string `mapstructure:"processors"`
+	// geoip: CSV rows of network,country[,region[,city]] where network
+	// is a CIDR or a first-last address range
+	GeoIPDatabase string `mapstructure:"geoip_database"`
+	// threat_intel: one "network tag[,tag...]" per line; the file is
+	// reread when it changes
+	ThreatIntelFile string `mapstructure:"threat_intel_file"`
+}
+
+// Flag names accepted on the command line; each overrides its config key
+var flagKeys = map[string]string{
+	"host":        "server.host",
+	"port":        "server.port",
+	"environment": "server.environment",
+	"public-url":  "server.public_url",
+	"db-host":     "database.host",
+	"db-port":     "database.port",
+}
+
+// NewFlagSet returns the server command-line flags. Flags take precedence
+// over environment variables, which take precedence over the config file.
+func NewFlagSet(name string) *pflag.FlagSet {
+	flags := pflag.NewFlagSet(name, pflag.ContinueOnError)
+	flags.String("config", "", "Path to a YAML, JSON or TOML config file (env VAULT_CONFIG_FILE)")
+	flags.String("host", "", "Bind address")
+	flags.Int("port", 0, "Listen port")
+	flags.String("environment", "", "Environment (development, staging, production)")
+	flags.String("public-url", "", "Externally reachable base URL")
+	flags.String("db-host", "", "Database host")
+	flags.Int("db-port", 0, "Database port")
+	return flags
+}
+
+// LoadConfig builds the configuration from defaults, the config file,
+// environment variables and finally flags, in increasing precedence.
+// flags may be nil.
+func LoadConfig(flags *pflag.FlagSet) (*Config, error) {
+	v, err := load(flags)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := decode(v)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ConfigSource(v), err)
+	}
+
+	return config, nil
+}
+
+// ValidateFile loads the configuration exactly as the server would and
+// returns where the file layer was read from
+func ValidateFile(flags *pflag.FlagSet) (string, error) {
+	v, err := load(flags)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = decode(v)
+	return ConfigSource(v), err
+}
+
+func decode(v *viper.Viper) (*Config, error) {
+	var config Config
+	if err := v.UnmarshalExact(&config); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if err := validateConfig(&config); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// ConfigSource describes where the file layer came from
+func ConfigSource(v *viper.Viper) string {
+	if file := v.ConfigFileUsed(); file != "" {
+		return file
+	}
+	return "defaults and environment"
+}
+
+func load(flags *pflag.FlagSet) (*viper.Viper, error) {
 	// Load .env file if it exists
 	if err := godotenv.Load(); err != nil {
 		fmt.Println("No .env file found, using environment variables")
@@ -58,94 +589,709 @@ func LoadConfig() (*Config, error) {
 		fmt.Println("Loaded environment variables from .env file")
 	}
 
-	viper.SetConfigName("config")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath(".")
-	viper.AddConfigPath("./config")
+	v := viper.New()
+
+	configFile := os.Getenv("VAULT_CONFIG_FILE")
+	if flags != nil {
+		if flag := flags.Lookup("config"); flag != nil && flag.Changed {
+			configFile = flag.Value.String()
+		}
+	}
 
-	viper.AutomaticEnv()
-	viper.SetEnvPrefix("VAULT")
-	// Bind environment variables explicitly to ensure proper mapping
-	viper.BindEnv("jwt.secret", "VAULT_JWT_SECRET")
-	viper.BindEnv("jwt.expiration", "VAULT_JWT_EXPIRATION")
-	viper.BindEnv("security.encryption_key", "VAULT_SECURITY_ENCRYPTION_KEY")
-	viper.BindEnv("security.kdf_iterations", "VAULT_SECURITY_KDF_ITERATIONS")
-	viper.BindEnv("security.salt_length", "VAULT_SECURITY_SALT_LENGTH")
+	if configFile != "" {
+		v.SetConfigFile(configFile)
+	} else {
+		v.SetConfigName("config")
+		v.SetConfigType("yaml")
+		v.AddConfigPath(".")
+		v.AddConfigPath("./config")
+	}
 
-	setDefaults()
+	v.SetEnvPrefix("VAULT")
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	// Bind environment variables explicitly so every key is known to
+	// UnmarshalExact even when it only comes from the environment
+	for _, key := range configKeys {
+		v.BindEnv(key, "VAULT_"+strings.ToUpper(strings.ReplaceAll(key, ".", "_")))
+	}
 
-	// Debug: Check if environment variables are loaded
-	jwtSecret := viper.GetString("jwt.secret")
-	fmt.Printf("DEBUG: JWT Secret from viper: '%s'\n", jwtSecret)
-	fmt.Printf("DEBUG: VAULT_JWT_SECRET env: '%s'\n", os.Getenv("VAULT_JWT_SECRET"))
+	setDefaults(v)
 
-	if err := viper.ReadInConfig(); err != nil {
+	if err := v.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
 			fmt.Println("Config file not found, using defaults and environment variables")
 		} else {
-			return nil, fmt.Errorf("error reading config file: %w", err)
+			return nil, fmt.Errorf("error reading config file %s: %w", ConfigSource(v), err)
 		}
 	}
 
-	var config Config
-	if err := viper.Unmarshal(&config); err != nil {
-		return nil, fmt.Errorf("error unmarshaling config: %w", err)
+	if flags != nil {
+		for name, key := range flagKeys {
+			if flag := flags.Lookup(name); flag != nil && flag.Changed {
+				v.Set(key, flag.Value.String())
+			}
+		}
 	}
 
-	validateConfig(&config)
+	return v, nil
+}
 
-	return &config, nil
+// configKeys lists every supported key, in config file notation
+var configKeys = []string{
+	"server.host", "server.port", "server.environment", "server.read_timeout", "server.write_timeout", "server.public_url",
+	"server.tls.cert_file", "server.tls.key_file", "server.tls.client_ca_file", "server.tls.min_version",
+	"listeners.cluster.enabled", "listeners.cluster.host", "listeners.cluster.port",
+	"listeners.cluster.tls.cert_file", "listeners.cluster.tls.key_file", "listeners.cluster.tls.client_ca_file", "listeners.cluster.tls.min_version",
+	"listeners.metrics.enabled", "listeners.metrics.host", "listeners.metrics.port",
+	"listeners.metrics.tls.cert_file", "listeners.metrics.tls.key_file", "listeners.metrics.tls.client_ca_file", "listeners.metrics.tls.min_version",
+	"limits.max_concurrent_reads", "limits.max_concurrent_writes", "limits.max_concurrent_auth", "limits.queue_timeout_ms", "limits.retry_after",
+	"database.host", "database.port", "database.user", "database.password", "database.dbname", "database.sslmode", "database.slow_query_ms",
+	"diagnostics.pprof_enabled",
+	"jobs.lease_ttl", "jobs.history_limit", "jobs.expired_shares_interval", "jobs.secret_compaction_interval", "jobs.secret_retention_days",
+	"jobs.orphan_cleanup_interval", "jobs.audit_archive_interval", "jobs.audit_archive_dir", "jobs.audit_retention_days",
+	"jobs.secret_scrub_interval", "jobs.online_migration_interval", "jobs.online_migration_batch_size", "jobs.sandbox_expiry_interval",
+	"jobs.single_use_token_interval", "jobs.data_key_reencrypt_interval", "jobs.expired_leases_interval", "jobs.expired_ssh_otps_interval",
+	"jobs.expired_wrapped_responses_interval", "jobs.expired_approle_secret_ids_interval", "jobs.expired_tokens_interval",
+	"jobs.secret_rotation_interval",
+	"notifications.webhook_url", "notifications.webhook_secret", "notifications.reminder_interval", "notifications.reminder_repeat",
+	"notifications.smtp.host", "notifications.smtp.port", "notifications.smtp.username", "notifications.smtp.password", "notifications.smtp.from",
+	"notifications.smtp.security", "notifications.smtp.templates_dir",
+	"notifications.chat.callback_key", "notifications.chat.callback_ttl", "notifications.chat.slack_signing_secret",
+	"notifications.escalation.resolve_after", "notifications.escalation.auth_failure_threshold", "notifications.escalation.auth_failure_window",
+	"security.encryption_key", "security.kdf_iterations", "security.salt_length",
+	"jwt.secret", "jwt.expiration", "jwt.max_ttl",
+	"audit.enabled", "audit.log_level", "audit.log_format", "audit.hmac_key",
+	"audit.anchor.sink", "audit.anchor.interval", "audit.anchor.lock_mode", "audit.anchor.retention_days", "audit.anchor.region", "audit.anchor.endpoint",
+	"audit.otlp.endpoint", "audit.otlp.headers", "audit.otlp.namespace", "audit.otlp.cluster", "audit.otlp.node",
+	"audit.otlp.interval", "audit.otlp.batch_size", "audit.otlp.timeout",
+	"audit.enrichment.processors", "audit.enrichment.geoip_database", "audit.enrichment.threat_intel_file",
+	"oidc.issuer", "oidc.key_rotation_period", "oidc.verification_ttl", "oidc.default_token_ttl",
+	"ldap.enabled", "ldap.url", "ldap.starttls", "ldap.ca_file", "ldap.insecure_skip_verify", "ldap.bind_dn", "ldap.bind_password",
+	"ldap.user_base_dn", "ldap.user_filter", "ldap.email_attribute", "ldap.first_name_attribute", "ldap.last_name_attribute",
+	"ldap.group_base_dn", "ldap.group_filter", "ldap.group_attribute", "ldap.group_policies", "ldap.user_links", "ldap.auto_create_users",
+	"ldap.pool_size", "ldap.timeout",
+	"kubernetes.enabled", "kubernetes.host", "kubernetes.ca_file", "kubernetes.token_reviewer_jwt_file", "kubernetes.jwks_url",
+	"kubernetes.issuer", "kubernetes.audience", "kubernetes.timeout",
+	"aws.enabled", "aws.sts_endpoint", "aws.iam_endpoint", "aws.iam_region", "aws.server_id", "aws.timeout",
+	"password_policy.min_length", "password_policy.max_length", "password_policy.require_uppercase", "password_policy.require_lowercase",
+	"password_policy.require_digit", "password_policy.require_symbol", "password_policy.min_strength", "password_policy.breach_check",
+	"password_policy.breach_endpoint", "password_policy.breach_fail_closed", "password_policy.timeout", "password_policy.apply_to_generated",
+	"pki.default_ttl", "pki.max_ttl", "pki.crl_lifetime",
+	"ssh.default_ttl", "ssh.max_ttl", "ssh.otp_ttl",
+	"wrapping.max_ttl",
+	"rotation.expiry_warning", "rotation.retry_interval", "rotation.plugins", "rotation.plugin_token", "rotation.plugin_timeout",
+	"entropy.sources", "entropy.headers", "entropy.reseed_interval", "entropy.failure_policy",
+	"features.namespaces", "features.replication", "features.plugins",
+	"cache.ttl", "cache.ha_mode", "cache.warmup_enabled", "cache.warmup_limit", "cache.stats_window_days", "cache.stats_flush_interval",
+	"quotas.check_interval", "quotas.warn_percent", "quotas.request_window", "quotas.history_days",
 }
 
-func setDefaults() {
-	viper.SetDefault("server.host", "0.0.0.0")
-	viper.SetDefault("server.port", 8080)
-	viper.SetDefault("server.environment", "development")
-	viper.SetDefault("server.read_timeout", 30)
-	viper.SetDefault("server.write_timeout", 30)
+func setDefaults(v *viper.Viper) {
+	v.SetDefault("server.host", "0.0.0.0")
+	v.SetDefault("server.port", 8080)
+	v.SetDefault("server.environment", "development")
+	v.SetDefault("server.read_timeout", 30)
+	v.SetDefault("server.write_timeout", 30)
+	v.SetDefault("server.tls.min_version", "1.2")
+
+	v.SetDefault("listeners.cluster.host", "0.0.0.0")
+	v.SetDefault("listeners.cluster.port", 8201)
+	v.SetDefault("listeners.cluster.tls.min_version", "1.2")
+	v.SetDefault("listeners.metrics.host", "127.0.0.1")
+	v.SetDefault("listeners.metrics.port", 9102)
+	v.SetDefault("listeners.metrics.tls.min_version", "1.2")
+
+	v.SetDefault("limits.max_concurrent_reads", 256)
+	v.SetDefault("limits.max_concurrent_writes", 64)
+	v.SetDefault("limits.max_concurrent_auth", 32)
+	v.SetDefault("limits.queue_timeout_ms", 250)
+	v.SetDefault("limits.retry_after", 1)
+
+	v.SetDefault("database.host", "localhost")
+	v.SetDefault("database.port", 5432)
+	v.SetDefault("database.user", "vault")
+	v.SetDefault("database.dbname", "vault")
+	v.SetDefault("database.sslmode", "disable")
+	v.SetDefault("database.slow_query_ms", 200)
+
+	v.SetDefault("diagnostics.pprof_enabled", true)
 
-	viper.SetDefault("database.host", "localhost")
-	viper.SetDefault("database.port", 5432)
-	viper.SetDefault("database.user", "vault")
-	viper.SetDefault("database.dbname", "vault")
-	viper.SetDefault("database.sslmode", "disable")
+	v.SetDefault("jobs.lease_ttl", 30)
+	v.SetDefault("jobs.history_limit", 50)
+	v.SetDefault("jobs.expired_shares_interval", 3600)
+	v.SetDefault("jobs.secret_compaction_interval", 86400)
+	v.SetDefault("jobs.secret_retention_days", 30)
+	v.SetDefault("jobs.orphan_cleanup_interval", 21600)
+	v.SetDefault("jobs.audit_archive_interval", 86400)
+	v.SetDefault("jobs.audit_retention_days", 365)
+	v.SetDefault("jobs.secret_scrub_interval", 86400)
+	v.SetDefault("jobs.online_migration_interval", 300)
+	v.SetDefault("jobs.online_migration_batch_size", 1000)
+	v.SetDefault("jobs.sandbox_expiry_interval", 3600)
+	v.SetDefault("jobs.single_use_token_interval", 3600)
+	v.SetDefault("jobs.data_key_reencrypt_interval", 3600)
+	v.SetDefault("jobs.expired_leases_interval", 86400)
+	v.SetDefault("jobs.expired_ssh_otps_interval", 3600)
+	v.SetDefault("jobs.expired_wrapped_responses_interval", 3600)
+	v.SetDefault("jobs.expired_approle_secret_ids_interval", 3600)
+	v.SetDefault("jobs.expired_tokens_interval", 300)
+	v.SetDefault("jobs.secret_rotation_interval", 60)
 
-	viper.SetDefault("security.kdf_iterations", 100000)
-	viper.SetDefault("security.salt_length", 32)
+	v.SetDefault("notifications.reminder_interval", 3600)
+	v.SetDefault("notifications.reminder_repeat", 24)
+	v.SetDefault("notifications.smtp.port", 587)
+	v.SetDefault("notifications.smtp.security", SMTPSecurityStartTLS)
+	v.SetDefault("notifications.chat.callback_ttl", 86400)
+	v.SetDefault("notifications.escalation.resolve_after", 3600)
+	v.SetDefault("notifications.escalation.auth_failure_threshold", 5)
+	v.SetDefault("notifications.escalation.auth_failure_window", 900)
 
-	viper.SetDefault("jwt.expiration", 3600)
+	v.SetDefault("security.kdf_iterations", 100000)
+	v.SetDefault("security.salt_length", 32)
 
-	viper.SetDefault("audit.enabled", true)
-	viper.SetDefault("audit.log_level", "info")
-	viper.SetDefault("audit.log_format", "json")
+	v.SetDefault("jwt.expiration", 3600)
+	v.SetDefault("jwt.max_ttl", 86400)
+
+	v.SetDefault("oidc.key_rotation_period", 720)
+	v.SetDefault("oidc.verification_ttl", 24)
+	v.SetDefault("oidc.default_token_ttl", 3600)
+
+	v.SetDefault("ldap.user_filter", "(uid={{username}})")
+	v.SetDefault("ldap.email_attribute", "mail")
+	v.SetDefault("ldap.first_name_attribute", "givenName")
+	v.SetDefault("ldap.last_name_attribute", "sn")
+	v.SetDefault("ldap.group_attribute", "cn")
+	v.SetDefault("ldap.pool_size", 4)
+	v.SetDefault("ldap.timeout", 10)
+
+	v.SetDefault("kubernetes.host", "https://kubernetes.default.svc")
+	v.SetDefault("kubernetes.timeout", 10)
+
+	v.SetDefault("aws.sts_endpoint", "https://sts.amazonaws.com")
+	v.SetDefault("aws.iam_endpoint", "https://iam.amazonaws.com")
+	v.SetDefault("aws.iam_region", "us-east-1")
+	v.SetDefault("aws.timeout", 10)
+
+	v.SetDefault("password_policy.min_length", 12)
+	v.SetDefault("password_policy.max_length", 128)
+	v.SetDefault("password_policy.min_strength", 3)
+	v.SetDefault("password_policy.breach_endpoint", "https://api.pwnedpasswords.com/range/")
+	v.SetDefault("password_policy.timeout", 5)
+
+	v.SetDefault("pki.default_ttl", 86400)
+	v.SetDefault("pki.max_ttl", 2592000)
+	v.SetDefault("pki.crl_lifetime", 72)
+
+	v.SetDefault("ssh.default_ttl", 3600)
+	v.SetDefault("ssh.max_ttl", 86400)
+	v.SetDefault("ssh.otp_ttl", 300)
+	v.SetDefault("wrapping.max_ttl", 86400)
+	v.SetDefault("rotation.expiry_warning", 604800)
+	v.SetDefault("rotation.retry_interval", 3600)
+	v.SetDefault("rotation.plugin_timeout", 30)
+
+	v.SetDefault("entropy.reseed_interval", 60)
+	v.SetDefault("entropy.failure_policy", "degrade")
+
+	v.SetDefault("features.namespaces", true)
+
+	v.SetDefault("cache.ttl", 30)
+	v.SetDefault("cache.warmup_limit", 500)
+	v.SetDefault("cache.stats_window_days", 7)
+	v.SetDefault("cache.stats_flush_interval", 60)
+
+	v.SetDefault("quotas.check_interval", 300)
+	v.SetDefault("quotas.warn_percent", 80)
+	v.SetDefault("quotas.request_window", 300)
+	v.SetDefault("quotas.history_days", 90)
+
+	v.SetDefault("audit.enabled", true)
+	v.SetDefault("audit.log_level", "info")
+	v.SetDefault("audit.log_format", "json")
+	v.SetDefault("audit.anchor.interval", 3600)
+	v.SetDefault("audit.anchor.lock_mode", "COMPLIANCE")
+	v.SetDefault("audit.anchor.retention_days", 365)
+	v.SetDefault("audit.anchor.region", "us-east-1")
+	v.SetDefault("audit.otlp.interval", 30)
+	v.SetDefault("audit.otlp.batch_size", 500)
+	v.SetDefault("audit.otlp.timeout", 10)
+	v.SetDefault("audit.enrichment.processors", "")
+}
+
+// ValidationError lists every problem found in the configuration
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
 }
 
-func validateConfig(config *Config) {
+func validateConfig(config *Config) error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
 	if config.Server.Port <= 0 || config.Server.Port > 65535 {
-		panic("Invalid server port")
+		add("server.port: must be between 1 and 65535 (got %d)", config.Server.Port)
+	}
+
+	switch config.Server.Environment {
+	case "development", "staging", "production", "test":
+	default:
+		add("server.environment: must be one of development, staging, production, test (got %q)", config.Server.Environment)
+	}
+
+	if config.Server.ReadTimeout < 0 {
+		add("server.read_timeout: must not be negative")
+	}
+	if config.Server.WriteTimeout < 0 {
+		add("server.write_timeout: must not be negative")
+	}
+
+	if config.Server.PublicURL != "" {
+		if parsed, err := url.Parse(config.Server.PublicURL); err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			add("server.public_url: must be an absolute URL such as https://vault.example.com (got %q)", config.Server.PublicURL)
+		}
+	}
+
+	validateTLS("server.tls", config.Server.TLS, add)
+
+	bound := []boundListener{{name: "server", host: config.Server.Host, port: config.Server.Port}}
+	for _, candidate := range []boundListener{
+		{name: "listeners.cluster", host: config.Listeners.Cluster.Host, port: config.Listeners.Cluster.Port, config: config.Listeners.Cluster},
+		{name: "listeners.metrics", host: config.Listeners.Metrics.Host, port: config.Listeners.Metrics.Port, config: config.Listeners.Metrics},
+	} {
+		if !candidate.config.Enabled {
+			continue
+		}
+		if candidate.port <= 0 || candidate.port > 65535 {
+			add("%s.port: must be between 1 and 65535 (got %d)", candidate.name, candidate.port)
+		}
+		for _, other := range bound {
+			if other.conflicts(candidate) {
+				add("%s: %s:%d overlaps with %s", candidate.name, candidate.host, candidate.port, other.name)
+			}
+		}
+		bound = append(bound, candidate)
+		validateTLS(candidate.name+".tls", candidate.config.TLS, add)
+	}
+
+	if config.Limits.MaxConcurrentReads < 0 || config.Limits.MaxConcurrentWrites < 0 || config.Limits.MaxConcurrentAuth < 0 {
+		add("limits: concurrency caps must not be negative (0 disables a cap)")
+	}
+	if config.Limits.QueueTimeoutMs < 0 {
+		add("limits.queue_timeout_ms: must not be negative")
+	}
+	if config.Limits.RetryAfter < 1 {
+		add("limits.retry_after: must be at least 1 second")
 	}
 
 	// Only require database in production
 	if config.Server.Environment == "production" {
 		if config.Database.Host == "" {
-			panic("Database host is required in production")
+			add("database.host: required in production (env VAULT_DATABASE_HOST)")
 		}
 
 		if config.Database.User == "" {
-			panic("Database user is required in production")
+			add("database.user: required in production (env VAULT_DATABASE_USER)")
 		}
 
 		if config.Database.DBName == "" {
-			panic("Database name is required in production")
+			add("database.dbname: required in production (env VAULT_DATABASE_DBNAME)")
 		}
 	}
 
+	if config.Database.Port <= 0 || config.Database.Port > 65535 {
+		add("database.port: must be between 1 and 65535 (got %d)", config.Database.Port)
+	}
+
+	if config.Database.SlowQueryMs < 0 {
+		add("database.slow_query_ms: must not be negative (0 disables slow query logging)")
+	}
+
+	if config.Notifications.WebhookURL != "" {
+		if parsed, err := url.Parse(config.Notifications.WebhookURL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			add("notifications.webhook_url: must be an http(s) URL (got %q)", config.Notifications.WebhookURL)
+		}
+	}
+	if config.Jobs.LeaseTTL < 6 {
+		add("jobs.lease_ttl: must be at least 6 seconds")
+	}
+	if config.Jobs.HistoryLimit < 1 {
+		add("jobs.history_limit: must be at least 1")
+	}
+	if config.Jobs.ExpiredSharesInterval < 0 || config.Jobs.SecretCompactionInterval < 0 || config.Jobs.OrphanCleanupInterval < 0 || config.Jobs.AuditArchiveInterval < 0 ||
+		config.Jobs.SecretScrubInterval < 0 || config.Jobs.OnlineMigrationInterval < 0 || config.Jobs.SandboxExpiryInterval < 0 ||
+		config.Jobs.SingleUseTokenInterval < 0 || config.Jobs.DataKeyReencryptInterval < 0 ||
+		config.Jobs.ExpiredLeasesInterval < 0 || config.Jobs.ExpiredSSHOTPsInterval < 0 || config.Jobs.ExpiredWrappedResponsesInterval < 0 ||
+		config.Jobs.ExpiredAppRoleSecretIDsInterval < 0 || config.Jobs.ExpiredTokensInterval < 0 || config.Jobs.SecretRotationInterval < 0 {
+		add("jobs: intervals must not be negative (0 disables scheduled runs)")
+	}
+	if config.Jobs.OnlineMigrationBatchSize < 1 {
+		add("jobs.online_migration_batch_size: must be at least 1")
+	}
+	if config.Jobs.SecretRetentionDays < 1 {
+		add("jobs.secret_retention_days: must be at least 1")
+	}
+	if config.Jobs.AuditArchiveDir != "" && config.Jobs.AuditRetentionDays < 1 {
+		add("jobs.audit_retention_days: must be at least 1")
+	}
+
+	if config.Notifications.ReminderInterval < 0 {
+		add("notifications.reminder_interval: must not be negative (0 disables reminders)")
+	}
+	if config.Notifications.ReminderRepeat < 1 {
+		add("notifications.reminder_repeat: must be at least 1 hour")
+	}
+	if config.Notifications.Chat.CallbackTTL < 60 {
+		add("notifications.chat.callback_ttl: must be at least 60 seconds")
+	}
+	if config.Notifications.Escalation.ResolveAfter < 0 {
+		add("notifications.escalation.resolve_after: must not be negative (0 disables automatic resolution)")
+	}
+	if config.Notifications.Escalation.AuthFailureThreshold < 0 {
+		add("notifications.escalation.auth_failure_threshold: must not be negative (0 disables the check)")
+	}
+	if config.Notifications.Escalation.AuthFailureThreshold > 0 && config.Notifications.Escalation.AuthFailureWindow < 60 {
+		add("notifications.escalation.auth_failure_window: must be at least 60 seconds")
+	}
+	if config.Notifications.SMTP.Host != "" {
+		if config.Notifications.SMTP.Port <= 0 || config.Notifications.SMTP.Port > 65535 {
+			add("notifications.smtp.port: must be between 1 and 65535 (got %d)", config.Notifications.SMTP.Port)
+		}
+		if config.Notifications.SMTP.From == "" {
+			add("notifications.smtp.from: required when notifications.smtp.host is set")
+		}
+		switch config.Notifications.SMTP.Security {
+		case SMTPSecurityStartTLS, SMTPSecurityTLS, SMTPSecurityNone:
+		default:
+			add("notifications.smtp.security: must be one of starttls, tls, none (got %q)", config.Notifications.SMTP.Security)
+		}
+		if config.Notifications.SMTP.Security == SMTPSecurityNone && config.Notifications.SMTP.Username != "" {
+			add("notifications.smtp.security: credentials are never sent without TLS; use starttls or tls")
+		}
+	}
+
+	switch config.Database.SSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		add("database.sslmode: unsupported mode %q", config.Database.SSLMode)
+	}
+
 	if config.JWT.Secret == "" {
-		panic("JWT secret is required")
+		add("jwt.secret: required (env VAULT_JWT_SECRET)")
+	}
+
+	if config.JWT.Expiration <= 0 {
+		add("jwt.expiration: must be a positive number of seconds")
+	} else if config.JWT.MaxTTL < config.JWT.Expiration {
+		add("jwt.max_ttl: must be at least jwt.expiration (%d seconds)", config.JWT.Expiration)
 	}
 
 	if config.Security.EncryptionKey == "" {
-		panic("Encryption key is required")
+		add("security.encryption_key: required (env VAULT_SECURITY_ENCRYPTION_KEY)")
+	}
+
+	if config.Security.KDFIterations <= 0 {
+		add("security.kdf_iterations: must be positive")
+	}
+
+	switch config.Audit.LogFormat {
+	case "json", "text":
+	default:
+		add("audit.log_format: must be json or text (got %q)", config.Audit.LogFormat)
+	}
+	if config.Audit.Anchor.Sink != "" {
+		if parsed, err := url.Parse(config.Audit.Anchor.Sink); err != nil || (parsed.Scheme != "file" && parsed.Scheme != "s3") {
+			add("audit.anchor.sink: must be a file:// or s3:// URI (got %q)", config.Audit.Anchor.Sink)
+		}
+		if config.Audit.Anchor.Interval < 60 {
+			add("audit.anchor.interval: must be at least 60 seconds")
+		}
+		if strings.HasPrefix(config.Audit.Anchor.Sink, "s3://") {
+			if config.Audit.Anchor.LockMode != "COMPLIANCE" && config.Audit.Anchor.LockMode != "GOVERNANCE" {
+				add("audit.anchor.lock_mode: must be COMPLIANCE or GOVERNANCE (got %q)", config.Audit.Anchor.LockMode)
+			}
+			if config.Audit.Anchor.RetentionDays < 1 {
+				add("audit.anchor.retention_days: must be at least 1")
+			}
+		}
+	}
+
+	if config.Audit.OTLP.Endpoint != "" {
+		if parsed, err := url.Parse(config.Audit.OTLP.Endpoint); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			add("audit.otlp.endpoint: must be an http:// or https:// URL (got %q)", config.Audit.OTLP.Endpoint)
+		}
+		for _, header := range strings.Split(config.Audit.OTLP.Headers, ",") {
+			if strings.TrimSpace(header) != "" && !strings.Contains(header, "=") {
+				add("audit.otlp.headers: %q is not a key=value pair", strings.TrimSpace(header))
+			}
+		}
+		if config.Audit.OTLP.Interval < 10 {
+			add("audit.otlp.interval: must be at least 10 seconds")
+		}
+		if config.Audit.OTLP.BatchSize < 1 {
+			add("audit.otlp.batch_size: must be at least 1")
+		}
+		if config.Audit.OTLP.Timeout < 1 {
+			add("audit.otlp.timeout: must be a positive number of seconds")
+		}
+	}
+	for _, processor := range strings.Split(config.Audit.Enrichment.Processors, ",") {
+		switch strings.TrimSpace(processor) {
+		case "geoip":
+			if config.Audit.Enrichment.GeoIPDatabase == "" {
+				add("audit.enrichment.geoip_database: required by the geoip processor")
+			}
+		case "threat_intel":
+			if config.Audit.Enrichment.ThreatIntelFile == "" {
+				add("audit.enrichment.threat_intel_file: required by the threat_intel processor")
+			}
+		}
+	}
+
+	if config.OIDC.KeyRotationPeriod <= 0 {
+		add("oidc.key_rotation_period: must be a positive number of hours")
+	}
+	if config.OIDC.VerificationTTL <= 0 {
+		add("oidc.verification_ttl: must be a positive number of hours")
+	}
+	if config.OIDC.DefaultTokenTTL <= 0 {
+		add("oidc.default_token_ttl: must be a positive number of seconds")
+	}
+
+	if config.LDAP.Enabled {
+		parsed, err := url.Parse(config.LDAP.URL)
+		if err != nil || (parsed.Scheme != "ldap" && parsed.Scheme != "ldaps") || parsed.Host == "" {
+			add("ldap.url: must be an ldap:// or ldaps:// URL")
+		} else if config.LDAP.StartTLS && parsed.Scheme == "ldaps" {
+			add("ldap.starttls: only applies to ldap:// URLs")
+		}
+		if config.LDAP.BindDN != "" && config.LDAP.BindPassword == "" {
+			add("ldap.bind_password: is required with ldap.bind_dn")
+		}
+		if config.LDAP.UserBaseDN == "" {
+			add("ldap.user_base_dn: is required")
+		}
+		if !strings.Contains(config.LDAP.UserFilter, "{{username}}") {
+			add("ldap.user_filter: must contain {{username}}")
+		}
+		if config.LDAP.GroupFilter != "" && config.LDAP.GroupAttribute == "" {
+			add("ldap.group_attribute: is required with ldap.group_filter")
+		}
+		if config.LDAP.GroupPolicies != "" {
+			for _, pair := range strings.Split(config.LDAP.GroupPolicies, ",") {
+				group, policyID, ok := strings.Cut(pair, "=")
+				if _, err := uuid.Parse(strings.TrimSpace(policyID)); !ok || strings.TrimSpace(group) == "" || err != nil {
+					add("ldap.group_policies: %q is not a group=policy_id pair", strings.TrimSpace(pair))
+				}
+			}
+		}
+		if config.LDAP.UserLinks != "" {
+			for _, pair := range strings.Split(config.LDAP.UserLinks, ",") {
+				username, userID, ok := strings.Cut(pair, "=")
+				if _, err := uuid.Parse(strings.TrimSpace(userID)); !ok || strings.TrimSpace(username) == "" || err != nil {
+					add("ldap.user_links: %q is not a username=user_id pair", strings.TrimSpace(pair))
+				}
+			}
+		}
+		if config.LDAP.PoolSize <= 0 {
+			add("ldap.pool_size: must be a positive number of connections")
+		}
+		if config.LDAP.Timeout <= 0 {
+			add("ldap.timeout: must be a positive number of seconds")
+		}
+	}
+
+	if config.Kubernetes.Enabled {
+		if config.Kubernetes.JWKSURL != "" {
+			if parsed, err := url.Parse(config.Kubernetes.JWKSURL); err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+				add("kubernetes.jwks_url: must be an http(s) URL")
+			}
+		} else if parsed, err := url.Parse(config.Kubernetes.Host); err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+			add("kubernetes.host: must be an https:// URL")
+		}
+		if config.Kubernetes.Timeout <= 0 {
+			add("kubernetes.timeout: must be a positive number of seconds")
+		}
+	}
+
+	if config.AWS.Enabled {
+		for _, endpoint := range []struct{ key, value string }{
+			{"aws.sts_endpoint", config.AWS.STSEndpoint},
+			{"aws.iam_endpoint", config.AWS.IAMEndpoint},
+		} {
+			if parsed, err := url.Parse(endpoint.value); err != nil || parsed.Scheme != "https" || parsed.Host == "" || strings.Trim(parsed.Path, "/") != "" {
+				add("%s: must be an https:// URL without a path", endpoint.key)
+			}
+		}
+		if config.AWS.IAMRegion == "" {
+			add("aws.iam_region: must not be empty")
+		}
+		if config.AWS.Timeout <= 0 {
+			add("aws.timeout: must be a positive number of seconds")
+		}
+	}
+
+	if config.PasswordPolicy.MinLength < 1 {
+		add("password_policy.min_length: must be at least 1")
+	}
+	if config.PasswordPolicy.MaxLength < config.PasswordPolicy.MinLength {
+		add("password_policy.max_length: must not be shorter than password_policy.min_length")
+	}
+	if config.PasswordPolicy.MinStrength < 0 || config.PasswordPolicy.MinStrength > 4 {
+		add("password_policy.min_strength: must be between 0 and 4 (got %d)", config.PasswordPolicy.MinStrength)
+	}
+	if config.PasswordPolicy.BreachCheck {
+		if parsed, err := url.Parse(config.PasswordPolicy.BreachEndpoint); err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+			add("password_policy.breach_endpoint: must be an https:// URL")
+		}
+		if config.PasswordPolicy.Timeout <= 0 {
+			add("password_policy.timeout: must be a positive number of seconds")
+		}
+	}
+
+	if config.PKI.DefaultTTL <= 0 {
+		add("pki.default_ttl: must be a positive number of seconds")
+	}
+	if config.PKI.MaxTTL < config.PKI.DefaultTTL {
+		add("pki.max_ttl: must not be shorter than pki.default_ttl")
+	}
+	if config.PKI.CRLLifetime <= 0 {
+		add("pki.crl_lifetime: must be a positive number of hours")
+	}
+
+	if config.SSH.DefaultTTL <= 0 {
+		add("ssh.default_ttl: must be a positive number of seconds")
+	}
+	if config.SSH.MaxTTL < config.SSH.DefaultTTL {
+		add("ssh.max_ttl: must not be shorter than ssh.default_ttl")
+	}
+	if config.SSH.OTPTTL <= 0 {
+		add("ssh.otp_ttl: must be a positive number of seconds")
+	}
+
+	if config.Wrapping.MaxTTL <= 0 {
+		add("wrapping.max_ttl: must be a positive number of seconds")
+	}
+
+	if config.Rotation.ExpiryWarning < 0 {
+		add("rotation.expiry_warning: must not be negative (0 disables expiry warnings)")
+	}
+	if config.Rotation.RetryInterval <= 0 {
+		add("rotation.retry_interval: must be a positive number of seconds")
+	}
+	if config.Rotation.PluginTimeout <= 0 {
+		add("rotation.plugin_timeout: must be a positive number of seconds")
+	}
+	if config.Rotation.Plugins != "" {
+		for _, plugin := range strings.Split(config.Rotation.Plugins, ",") {
+			name, target, ok := strings.Cut(plugin, "=")
+			parsed, err := url.Parse(strings.TrimSpace(target))
+			if !ok || strings.TrimSpace(name) == "" || err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+				add("rotation.plugins: %q is not a name=http(s) URL pair", strings.TrimSpace(plugin))
+			}
+		}
+	}
+
+	for _, source := range strings.Split(config.Entropy.Sources, ",") {
+		source = strings.TrimSpace(source)
+		if source == "" {
+			continue
+		}
+		parsed, err := url.Parse(source)
+		if err != nil || (parsed.Scheme != "tpm" && parsed.Scheme != "file" && parsed.Scheme != "https" && parsed.Scheme != "http") {
+			add("entropy.sources: %q is not a tpm://, file:// or https:// URI", source)
+		} else if parsed.Scheme != "http" && parsed.Scheme != "https" && parsed.Path == "" {
+			add("entropy.sources: %q names no device", source)
+		}
+	}
+	if config.Entropy.Headers != "" {
+		for _, header := range strings.Split(config.Entropy.Headers, ",") {
+			if key, _, ok := strings.Cut(header, "="); !ok || strings.TrimSpace(key) == "" {
+				add("entropy.headers: %q is not a key=value pair", strings.TrimSpace(header))
+			}
+		}
+	}
+	if config.Entropy.ReseedInterval <= 0 {
+		add("entropy.reseed_interval: must be a positive number of seconds")
+	}
+	if config.Entropy.FailurePolicy != "degrade" && config.Entropy.FailurePolicy != "block" {
+		add("entropy.failure_policy: must be degrade or block")
+	}
+
+	if config.Cache.TTL < 0 {
+		add("cache.ttl: must not be negative")
+	}
+	if config.Cache.WarmupLimit < 0 {
+		add("cache.warmup_limit: must not be negative")
+	}
+	if config.Cache.StatsWindowDays < 1 {
+		add("cache.stats_window_days: must be at least 1")
+	}
+	if config.Cache.StatsFlushInterval < 1 {
+		add("cache.stats_flush_interval: must be a positive number of seconds")
+	}
+
+	if config.Quotas.CheckInterval < 0 {
+		add("quotas.check_interval: must not be negative (0 disables scheduled checks)")
+	}
+	if config.Quotas.WarnPercent < 1 || config.Quotas.WarnPercent > 100 {
+		add("quotas.warn_percent: must be between 1 and 100 (got %d)", config.Quotas.WarnPercent)
+	}
+	if config.Quotas.RequestWindow < 60 {
+		add("quotas.request_window: must be at least 60 seconds")
+	}
+	if config.Quotas.HistoryDays < 1 {
+		add("quotas.history_days: must be at least 1")
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+
+	return nil
+}
+
+type boundListener struct {
+	name   string
+	host   string
+	port   int
+	config ListenerConfig
+}
+
+// conflicts reports whether two listeners would bind the same socket;
+// wildcard hosts overlap with every address
+func (l boundListener) conflicts(other boundListener) bool {
+	if l.port != other.port {
+		return false
+	}
+	wildcard := func(host string) bool { return host == "" || host == "0.0.0.0" || host == "::" }
+	return l.host == other.host || wildcard(l.host) || wildcard(other.host)
+}
+
+func validateTLS(prefix string, tls TLSConfig, add func(format string, args ...interface{})) {
+	if (tls.CertFile == "") != (tls.KeyFile == "") {
+		add("%s: cert_file and key_file must be set together", prefix)
+	}
+	if tls.ClientCAFile != "" && !tls.Enabled() {
+		add("%s.client_ca_file: requires cert_file and key_file", prefix)
+	}
+	for _, file := range []string{tls.CertFile, tls.KeyFile, tls.ClientCAFile} {
+		if file == "" {
+			continue
+		}
+		if _, err := os.Stat(file); err != nil {
+			add("%s: cannot read %s: %v", prefix, file, err)
+		}
+	}
+	switch tls.MinVersion {
+	case "", "1.2", "1.3":
+	default:
+		add("%s.min_version: must be 1.2 or 1.3 (got %q)", prefix, tls.MinVersion)
 	}
 }
 
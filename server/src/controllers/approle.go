@@ -0,0 +1,222 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type AppRoleController struct {
+	appRoleService *services.AppRoleService
+	auditService   *services.AuditService
+}
+
+func NewAppRoleController(appRoleService *services.AppRoleService, auditService *services.AuditService) *AppRoleController {
+	return &AppRoleController{
+		appRoleService: appRoleService,
+		auditService:   auditService,
+	}
+}
+
+func (c *AppRoleController) GetRoles(ctx *gin.Context) {
+	roles, err := c.appRoleService.GetRoles()
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve AppRoles")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"roles": roles})
+}
+
+func (c *AppRoleController) GetRole(ctx *gin.Context) {
+	role, err := c.appRoleService.GetRole(ctx.Param("name"))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve AppRole")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, role)
+}
+
+func (c *AppRoleController) CreateRole(ctx *gin.Context) {
+	var req model.CreateAppRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	role, err := c.appRoleService.CreateRole(&req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to create AppRole")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, role)
+}
+
+func (c *AppRoleController) UpdateRole(ctx *gin.Context) {
+	var req model.UpdateAppRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	role, err := c.appRoleService.UpdateRole(ctx.Param("name"), &req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to update AppRole")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, role)
+}
+
+func (c *AppRoleController) DeleteRole(ctx *gin.Context) {
+	if err := c.appRoleService.DeleteRole(ctx.Param("name"), ctx.MustGet("user_id").(uuid.UUID)); err != nil {
+		c.respondError(ctx, err, "Failed to delete AppRole")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "AppRole deleted"})
+}
+
+func (c *AppRoleController) GenerateSecretID(ctx *gin.Context) {
+	var req model.GenerateSecretIDRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INVALID_REQUEST",
+					Message: "Invalid request format",
+				},
+			})
+			return
+		}
+	}
+
+	response, err := c.appRoleService.GenerateSecretID(ctx.Param("name"), &req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to generate secret ID")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, response)
+}
+
+func (c *AppRoleController) GetSecretIDs(ctx *gin.Context) {
+	secretIDs, err := c.appRoleService.GetSecretIDs(ctx.Param("name"))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve secret IDs")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"secret_ids": secretIDs})
+}
+
+func (c *AppRoleController) RevokeSecretID(ctx *gin.Context) {
+	accessor, err := uuid.Parse(ctx.Param("accessor"))
+	if err != nil {
+		c.respondError(ctx, services.ErrAppRoleSecretIDNotFound, "")
+		return
+	}
+
+	if err := c.appRoleService.RevokeSecretID(ctx.Param("name"), accessor, ctx.MustGet("user_id").(uuid.UUID)); err != nil {
+		c.respondError(ctx, err, "Failed to revoke secret ID")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Secret ID revoked"})
+}
+
+// Login exchanges a role ID and secret ID for a token, and answers like
+// POST /auth/login
+func (c *AppRoleController) Login(ctx *gin.Context) {
+	var req model.AppRoleLoginRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	response, role, err := c.appRoleService.Login(req.RoleID, req.SecretID)
+	details := "method=approle"
+	if role != nil {
+		details += "; role=" + role.Name
+	}
+	if err != nil {
+		if c.auditService != nil {
+			c.auditService.LogAnonymousAction("login_failed", "auth", "", ctx.ClientIP(), ctx.GetHeader("User-Agent"), false, details+"; error="+err.Error())
+		}
+		c.respondError(ctx, err, "Failed to complete AppRole login")
+		return
+	}
+
+	if c.auditService != nil {
+		c.auditService.LogAnonymousAction("login_success", "auth", response.User.ID.String(), ctx.ClientIP(), ctx.GetHeader("User-Agent"), true, details)
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *AppRoleController) respondError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrAppRoleNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_APPROLE_NOT_FOUND",
+				Message: "AppRole not found",
+			},
+		})
+	case errors.Is(err, services.ErrAppRoleSecretIDNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_APPROLE_SECRET_ID_NOT_FOUND",
+				Message: "Secret ID not found",
+			},
+		})
+	case errors.Is(err, services.ErrAppRoleExists):
+		ctx.JSON(http.StatusConflict, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_APPROLE_EXISTS",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrAppRoleInvalid):
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrAppRoleLoginFailed):
+		ctx.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_CREDENTIALS",
+				Message: "Invalid role ID or secret ID",
+			},
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: message,
+			},
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"github.com/skygenesisenterprise/aether-vault/server/src/model"
 	"github.com/skygenesisenterprise/aether-vault/server/src/services"
 	"net/http"
@@ -11,12 +12,14 @@ import (
 )
 
 type AuditController struct {
-	auditService *services.AuditService
+	auditService  *services.AuditService
+	anchorService *services.AuditAnchorService
 }
 
-func NewAuditController(auditService *services.AuditService) *AuditController {
+func NewAuditController(auditService *services.AuditService, anchorService *services.AuditAnchorService) *AuditController {
 	return &AuditController{
-		auditService: auditService,
+		auditService:  auditService,
+		anchorService: anchorService,
 	}
 }
 
@@ -59,3 +62,145 @@ func (c *AuditController) GetAuditLogs(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"logs": logs, "limit": limit, "offset": offset})
 }
+
+func (c *AuditController) HashValue(ctx *gin.Context) {
+	var req model.AuditHashRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, model.AuditHashResponse{Hash: c.auditService.HMAC([]byte(req.Input))})
+}
+
+func (c *AuditController) SearchByHash(ctx *gin.Context) {
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	offset, err := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	logs, err := c.auditService.GetAuditLogsByHash(ctx.Query("hash"), limit, offset)
+	if err != nil {
+		if err == services.ErrInvalidAuditHash {
+			ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INVALID_REQUEST",
+					Message: "hash must be an hmac-sha256 value returned by /audit/hash",
+				},
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: "Failed to search audit logs",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"logs": logs, "limit": limit, "offset": offset})
+}
+
+func (c *AuditController) GetAnchors(ctx *gin.Context) {
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	offset, err := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	anchors, err := c.anchorService.GetAnchors(limit, offset)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: "Failed to retrieve audit anchors",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"anchors": anchors, "enabled": c.anchorService.Enabled(), "limit": limit, "offset": offset})
+}
+
+// Anchor writes the current audit chain head to the anchor sink now
+// instead of waiting for the next interval
+func (c *AuditController) Anchor(ctx *gin.Context) {
+	anchor, err := c.anchorService.Anchor()
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrAuditAnchorDisabled):
+			ctx.JSON(http.StatusServiceUnavailable, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_AUDIT_ANCHOR_DISABLED",
+					Message: err.Error(),
+				},
+			})
+		case errors.Is(err, services.ErrAuditChainEmpty):
+			ctx.JSON(http.StatusConflict, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_AUDIT_CHAIN_EMPTY",
+					Message: err.Error(),
+				},
+			})
+		case errors.Is(err, services.ErrAnchorSinkUnavailable):
+			ctx.JSON(http.StatusBadGateway, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_AUDIT_ANCHOR_FAILED",
+					Message: err.Error(),
+				},
+			})
+		default:
+			ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INTERNAL_ERROR",
+					Message: "Failed to anchor audit chain",
+				},
+			})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, anchor)
+}
+
+// Verify checks the audit hash chain against the external anchors. The
+// response is 200 either way; the report's valid field carries the result.
+func (c *AuditController) Verify(ctx *gin.Context) {
+	report, err := c.anchorService.Verify()
+	if err != nil {
+		if errors.Is(err, services.ErrAnchorSinkUnavailable) || errors.Is(err, services.ErrAnchorSinkCorrupt) {
+			ctx.JSON(http.StatusBadGateway, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_AUDIT_ANCHOR_FAILED",
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: "Failed to verify audit chain",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
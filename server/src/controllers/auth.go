@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"github.com/skygenesisenterprise/aether-vault/server/src/model"
 	"github.com/skygenesisenterprise/aether-vault/server/src/services"
 	"net/http"
@@ -55,6 +56,73 @@ func (c *AuthController) Login(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, response)
 }
 
+// LoginLDAP signs in against the configured LDAP directory and answers
+// like Login
+func (c *AuthController) LoginLDAP(ctx *gin.Context) {
+	var req model.LDAPLoginRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	response, err := c.authService.LoginLDAP(req.Username, req.Password)
+	if err != nil {
+		if c.auditService != nil {
+			c.auditService.LogAnonymousAction("login_failed", "auth", "", ctx.ClientIP(), ctx.GetHeader("User-Agent"), false, "method=ldap; error="+err.Error())
+		}
+
+		switch {
+		case errors.Is(err, services.ErrLDAPDisabled):
+			ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_LDAP_DISABLED",
+					Message: "LDAP login is not configured",
+				},
+			})
+		case errors.Is(err, services.ErrInvalidCredentials):
+			ctx.JSON(http.StatusUnauthorized, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INVALID_CREDENTIALS",
+					Message: "Invalid username or password",
+				},
+			})
+		case errors.Is(err, services.ErrLDAPUserNotLinked):
+			ctx.JSON(http.StatusForbidden, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_LDAP_USER_NOT_LINKED",
+					Message: err.Error(),
+				},
+			})
+		case errors.Is(err, services.ErrLDAPUnavailable):
+			ctx.JSON(http.StatusBadGateway, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_LDAP_UNAVAILABLE",
+					Message: "The LDAP directory is unavailable",
+				},
+			})
+		default:
+			ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INTERNAL_ERROR",
+					Message: "Failed to complete LDAP login",
+				},
+			})
+		}
+		return
+	}
+
+	if c.auditService != nil {
+		c.auditService.LogAnonymousAction("login_success", "auth", response.User.ID.String(), ctx.ClientIP(), ctx.GetHeader("User-Agent"), true, "method=ldap")
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
 func (c *AuthController) Logout(ctx *gin.Context) {
 	userID, exists := ctx.Get("user_id")
 	if !exists {
@@ -67,6 +135,16 @@ func (c *AuthController) Logout(ctx *gin.Context) {
 		return
 	}
 
+	if err := c.authService.Logout(tokenAccessor(ctx)); err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: "Failed to revoke token",
+			},
+		})
+		return
+	}
+
 	if c.auditService != nil {
 		c.auditService.LogAction(userID.(uuid.UUID), "logout", "auth", "", true, "")
 	}
@@ -96,6 +174,9 @@ func (c *AuthController) GetSession(ctx *gin.Context) {
 		})
 		return
 	}
+	if impersonation, ok := ctx.Get("impersonation"); ok {
+		response.Impersonation = impersonation.(*model.Impersonation)
+	}
 
 	ctx.JSON(http.StatusOK, response)
 }
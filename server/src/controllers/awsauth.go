@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type AWSAuthController struct {
+	awsAuthService *services.AWSAuthService
+	auditService   *services.AuditService
+}
+
+func NewAWSAuthController(awsAuthService *services.AWSAuthService, auditService *services.AuditService) *AWSAuthController {
+	return &AWSAuthController{
+		awsAuthService: awsAuthService,
+		auditService:   auditService,
+	}
+}
+
+func (c *AWSAuthController) GetRoles(ctx *gin.Context) {
+	roles, err := c.awsAuthService.GetRoles()
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve AWS roles")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"roles": roles})
+}
+
+func (c *AWSAuthController) GetRole(ctx *gin.Context) {
+	role, err := c.awsAuthService.GetRole(ctx.Param("name"))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve AWS role")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, role)
+}
+
+func (c *AWSAuthController) CreateRole(ctx *gin.Context) {
+	var req model.CreateAWSRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	role, err := c.awsAuthService.CreateRole(&req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to create AWS role")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, role)
+}
+
+func (c *AWSAuthController) UpdateRole(ctx *gin.Context) {
+	var req model.UpdateAWSRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	role, err := c.awsAuthService.UpdateRole(ctx.Param("name"), &req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to update AWS role")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, role)
+}
+
+func (c *AWSAuthController) DeleteRole(ctx *gin.Context) {
+	if err := c.awsAuthService.DeleteRole(ctx.Param("name"), ctx.MustGet("user_id").(uuid.UUID)); err != nil {
+		c.respondError(ctx, err, "Failed to delete AWS role")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "AWS role deleted"})
+}
+
+// Login exchanges a signed GetCallerIdentity request for a token of the
+// role, and answers like POST /auth/login
+func (c *AWSAuthController) Login(ctx *gin.Context) {
+	var req model.AWSLoginRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	response, identity, err := c.awsAuthService.Login(&req)
+	details := "method=aws; role=" + req.Role
+	if identity != nil {
+		details += "; arn=" + identity.ARN
+	}
+	if err != nil {
+		if c.auditService != nil {
+			c.auditService.LogAnonymousAction("login_failed", "auth", "", ctx.ClientIP(), ctx.GetHeader("User-Agent"), false, details+"; error="+err.Error())
+		}
+		c.respondError(ctx, err, "Failed to complete AWS login")
+		return
+	}
+
+	if c.auditService != nil {
+		c.auditService.LogAnonymousAction("login_success", "auth", response.User.ID.String(), ctx.ClientIP(), ctx.GetHeader("User-Agent"), true, details)
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *AWSAuthController) respondError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrAWSDisabled):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_AWS_DISABLED",
+				Message: "AWS login is not configured",
+			},
+		})
+	case errors.Is(err, services.ErrAWSRoleNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_AWS_ROLE_NOT_FOUND",
+				Message: "AWS role not found",
+			},
+		})
+	case errors.Is(err, services.ErrAWSRoleExists):
+		ctx.JSON(http.StatusConflict, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_AWS_ROLE_EXISTS",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrAWSRoleInvalid):
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrAWSLoginFailed):
+		ctx.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_CREDENTIALS",
+				Message: "The signed request was refused for this role",
+			},
+		})
+	case errors.Is(err, services.ErrAWSUnavailable):
+		ctx.JSON(http.StatusBadGateway, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_AWS_UNAVAILABLE",
+				Message: "AWS is unavailable",
+			},
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: message,
+			},
+		})
+	}
+}
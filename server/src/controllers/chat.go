@@ -0,0 +1,344 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+// The callback page is reached from a chat button. It only confirms; the
+// decision is a POST so link previews cannot approve anything.
+var chatCallbackPage = template.Must(template.New("callback").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Aether Vault</title></head>
+<body>
+{{if .Error}}<p>{{.Error}}</p>
+{{else if .Done}}<p>{{if .Approved}}Approved{{else}}Denied{{end}}: {{.Kind}} request {{.RequestID}}.</p>
+{{else}}<p>{{if .Approved}}Approve{{else}}Deny{{end}} {{.Kind}} request {{.RequestID}}?</p>
+<form method="post"><input type="hidden" name="token" value="{{.Token}}"><button type="submit">Confirm</button></form>
+{{end}}
+</body></html>
+`))
+
+type chatCallbackView struct {
+	Token     string
+	Kind      string
+	RequestID string
+	Approved  bool
+	Done      bool
+	Error     string
+}
+
+type ChatController struct {
+	chatService *services.ChatService
+}
+
+func NewChatController(chatService *services.ChatService) *ChatController {
+	return &ChatController{
+		chatService: chatService,
+	}
+}
+
+func (c *ChatController) GetChannels(ctx *gin.Context) {
+	channels, err := c.chatService.GetChannels()
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve chat channels")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"channels": channels})
+}
+
+func (c *ChatController) CreateChannel(ctx *gin.Context) {
+	var req model.CreateChatChannelRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	channel, err := c.chatService.CreateChannel(&req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to create chat channel")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, channel)
+}
+
+func (c *ChatController) DeleteChannel(ctx *gin.Context) {
+	id, ok := c.parseID(ctx, "id", "Invalid chat channel ID")
+	if !ok {
+		return
+	}
+
+	if err := c.chatService.DeleteChannel(id, ctx.MustGet("user_id").(uuid.UUID)); err != nil {
+		c.respondError(ctx, err, "Failed to delete chat channel")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Chat channel deleted successfully"})
+}
+
+func (c *ChatController) AddRoute(ctx *gin.Context) {
+	id, ok := c.parseID(ctx, "id", "Invalid chat channel ID")
+	if !ok {
+		return
+	}
+
+	var req model.ChatRouteRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	route, err := c.chatService.AddRoute(id, &req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to add chat route")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, route)
+}
+
+func (c *ChatController) DeleteRoute(ctx *gin.Context) {
+	id, ok := c.parseID(ctx, "id", "Invalid chat channel ID")
+	if !ok {
+		return
+	}
+	routeID, ok := c.parseID(ctx, "route_id", "Invalid chat route ID")
+	if !ok {
+		return
+	}
+
+	if err := c.chatService.DeleteRoute(id, routeID, ctx.MustGet("user_id").(uuid.UUID)); err != nil {
+		c.respondError(ctx, err, "Failed to delete chat route")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Chat route deleted successfully"})
+}
+
+func (c *ChatController) TestChannel(ctx *gin.Context) {
+	id, ok := c.parseID(ctx, "id", "Invalid chat channel ID")
+	if !ok {
+		return
+	}
+
+	if err := c.chatService.TestChannel(id); err != nil {
+		c.respondError(ctx, err, "Failed to post test message")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Test message posted"})
+}
+
+// CallbackPage asks the person who clicked an approve/deny button to
+// confirm the decision
+func (c *ChatController) CallbackPage(ctx *gin.Context) {
+	token := ctx.Query("token")
+	view := chatCallbackView{Token: token}
+
+	decision, err := c.chatService.PeekDecision(token)
+	if err != nil {
+		view.Error = callbackErrorText(err)
+		c.renderCallback(ctx, http.StatusBadRequest, view)
+		return
+	}
+
+	view.Kind, view.RequestID, view.Approved = decision.Kind, decision.RequestID, decision.Approved
+	c.renderCallback(ctx, http.StatusOK, view)
+}
+
+// Callback applies a confirmed decision from the callback page
+func (c *ChatController) Callback(ctx *gin.Context) {
+	actor := "chat link from " + ctx.ClientIP()
+	decision, err := c.chatService.Decide(ctx.PostForm("token"), actor, ctx.ClientIP(), ctx.GetHeader("User-Agent"))
+	if err != nil {
+		c.renderCallback(ctx, http.StatusBadRequest, chatCallbackView{Error: callbackErrorText(err)})
+		return
+	}
+
+	c.renderCallback(ctx, http.StatusOK, chatCallbackView{
+		Kind:      decision.Kind,
+		RequestID: decision.RequestID,
+		Approved:  decision.Approved,
+		Done:      true,
+	})
+}
+
+// SlackInteraction handles button clicks delivered by a Slack app's
+// interactivity request URL
+func (c *ChatController) SlackInteraction(ctx *gin.Context) {
+	body, err := ctx.GetRawData()
+	if err != nil {
+		ctx.Status(http.StatusBadRequest)
+		return
+	}
+
+	if err := c.chatService.VerifySlackRequest(ctx.GetHeader("X-Slack-Request-Timestamp"), ctx.GetHeader("X-Slack-Signature"), body); err != nil {
+		if errors.Is(err, services.ErrChatSlackDisabled) {
+			ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_CHAT_SLACK_DISABLED",
+					Message: "Slack interactivity is not configured (notifications.chat.slack_signing_secret)",
+				},
+			})
+			return
+		}
+		ctx.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_SIGNATURE",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		ctx.Status(http.StatusBadRequest)
+		return
+	}
+
+	var payload struct {
+		User struct {
+			ID       string `json:"id"`
+			Username string `json:"username"`
+		} `json:"user"`
+		Actions []struct {
+			Value string `json:"value"`
+		} `json:"actions"`
+		ResponseURL string `json:"response_url"`
+	}
+	if err := json.NewDecoder(bytes.NewBufferString(form.Get("payload"))).Decode(&payload); err != nil || len(payload.Actions) == 0 {
+		ctx.Status(http.StatusBadRequest)
+		return
+	}
+
+	actor := fmt.Sprintf("slack:%s (%s)", payload.User.Username, payload.User.ID)
+	text := ""
+	decision, err := c.chatService.Decide(payload.Actions[0].Value, actor, ctx.ClientIP(), ctx.GetHeader("User-Agent"))
+	if err != nil {
+		text = "Could not apply the decision: " + callbackErrorText(err)
+	} else if decision.Approved {
+		text = fmt.Sprintf("Approved by %s: %s request %s", payload.User.Username, decision.Kind, decision.RequestID)
+	} else {
+		text = fmt.Sprintf("Denied by %s: %s request %s", payload.User.Username, decision.Kind, decision.RequestID)
+	}
+
+	// Slack expects an acknowledgement within three seconds
+	go func() {
+		if err := c.chatService.RespondSlack(payload.ResponseURL, text); err != nil {
+			log.Printf("⚠️  Failed to update Slack message: %v", err)
+		}
+	}()
+
+	ctx.Status(http.StatusOK)
+}
+
+func (c *ChatController) renderCallback(ctx *gin.Context, status int, view chatCallbackView) {
+	var page bytes.Buffer
+	if err := chatCallbackPage.Execute(&page, view); err != nil {
+		ctx.Status(http.StatusInternalServerError)
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Data(status, "text/html; charset=utf-8", page.Bytes())
+}
+
+func (c *ChatController) parseID(ctx *gin.Context, param, message string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(ctx.Param(param))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_ID",
+				Message: message,
+			},
+		})
+		return uuid.Nil, false
+	}
+
+	return id, true
+}
+
+func (c *ChatController) respondError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrChatChannelNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_CHAT_CHANNEL_NOT_FOUND",
+				Message: "Chat channel not found",
+			},
+		})
+	case errors.Is(err, services.ErrChatRouteNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_CHAT_ROUTE_NOT_FOUND",
+				Message: "Chat route not found",
+			},
+		})
+	case errors.Is(err, services.ErrChatChannelExists):
+		ctx.JSON(http.StatusConflict, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_CHAT_CHANNEL_EXISTS",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrChatChannelInvalid), errors.Is(err, services.ErrChatProviderInvalid), errors.Is(err, services.ErrChatRouteInvalid):
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrNotificationDelivery):
+		ctx.JSON(http.StatusBadGateway, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_CHAT_DELIVERY_FAILED",
+				Message: err.Error(),
+			},
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: message,
+			},
+		})
+	}
+}
+
+func callbackErrorText(err error) string {
+	switch {
+	case errors.Is(err, services.ErrChatCallbackExpired):
+		return "This approval link has expired."
+	case errors.Is(err, services.ErrChatCallbackInvalid):
+		return "This approval link is not valid."
+	case errors.Is(err, services.ErrChatCallbackUsed):
+		return "This request has already been decided."
+	case errors.Is(err, services.ErrChatApprovalUnknownKind):
+		return "This kind of request can no longer be decided from chat."
+	default:
+		return err.Error()
+	}
+}
@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type ControlGroupController struct {
+	controlGroupService *services.ControlGroupService
+}
+
+func NewControlGroupController(controlGroupService *services.ControlGroupService) *ControlGroupController {
+	return &ControlGroupController{
+		controlGroupService: controlGroupService,
+	}
+}
+
+// GetMine lists the caller's own requests
+func (c *ControlGroupController) GetMine(ctx *gin.Context) {
+	requests, err := c.controlGroupService.GetMine(ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to get control group requests")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"requests": requests})
+}
+
+// GetPending lists the requests waiting on the caller's approval
+func (c *ControlGroupController) GetPending(ctx *gin.Context) {
+	requests, err := c.controlGroupService.GetPending(ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to get control group requests")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"requests": requests})
+}
+
+// Get returns a request, which requesters poll until it is decided
+func (c *ControlGroupController) Get(ctx *gin.Context) {
+	id, ok := controlGroupID(ctx)
+	if !ok {
+		return
+	}
+
+	request, err := c.controlGroupService.Get(id, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to get control group request")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, request)
+}
+
+func (c *ControlGroupController) Approve(ctx *gin.Context) {
+	id, ok := controlGroupID(ctx)
+	if !ok {
+		return
+	}
+
+	request, err := c.controlGroupService.Approve(id, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to approve control group request")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, request)
+}
+
+func (c *ControlGroupController) Deny(ctx *gin.Context) {
+	id, ok := controlGroupID(ctx)
+	if !ok {
+		return
+	}
+
+	request, err := c.controlGroupService.Deny(id, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to deny control group request")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, request)
+}
+
+func (c *ControlGroupController) respondError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrControlGroupRequestNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_CONTROL_GROUP_NOT_FOUND",
+				Message: "Control group request not found",
+			},
+		})
+	case errors.Is(err, services.ErrControlGroupSelfApproval):
+		ctx.JSON(http.StatusForbidden, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_CONTROL_GROUP_SELF_APPROVAL",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrControlGroupNotPending), errors.Is(err, services.ErrControlGroupAlreadyApproved):
+		ctx.JSON(http.StatusConflict, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_CONTROL_GROUP_DECIDED",
+				Message: err.Error(),
+			},
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: message,
+			},
+		})
+	}
+}
+
+func controlGroupID(ctx *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_ID",
+				Message: "Invalid request ID",
+			},
+		})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// respondControlGroupPending answers a read parked for approval with the
+// request it waits on, and reports whether err was one
+func respondControlGroupPending(ctx *gin.Context, err error) bool {
+	var pending *services.ControlGroupPendingError
+	if !errors.As(err, &pending) {
+		return false
+	}
+	ctx.JSON(http.StatusForbidden, model.ErrorResponse{
+		Error: model.ErrorDetail{
+			Code:         "VAULT_CONTROL_GROUP_PENDING",
+			Message:      pending.Error(),
+			ControlGroup: &pending.Requirement,
+		},
+	})
+	return true
+}
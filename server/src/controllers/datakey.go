@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type DataKeyController struct {
+	secretService *services.SecretService
+	jobService    *services.JobService
+}
+
+func NewDataKeyController(secretService *services.SecretService, jobService *services.JobService) *DataKeyController {
+	return &DataKeyController{
+		secretService: secretService,
+		jobService:    jobService,
+	}
+}
+
+func (c *DataKeyController) GetDataKeys(ctx *gin.Context) {
+	keys, err := c.secretService.GetDataKeys()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: "Failed to retrieve data keys",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"keys": keys})
+}
+
+// RotateDataKey switches new writes to a fresh data key and starts the
+// re-encryption job; poll its runs for progress
+func (c *DataKeyController) RotateDataKey(ctx *gin.Context) {
+	userID := ctx.MustGet("user_id").(uuid.UUID)
+
+	key, err := c.secretService.RotateDataKey(userID)
+	if err != nil {
+		if errors.Is(err, services.ErrDataKeysDisabled) {
+			ctx.JSON(http.StatusConflict, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_DATA_KEYS_DISABLED",
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: "Failed to rotate data key",
+			},
+		})
+		return
+	}
+
+	// A run already in progress picks up the new key for the rows it has
+	// not reached; the next scheduled run catches the rest
+	response := model.RotateDataKeyResponse{Key: *key}
+	if c.jobService != nil {
+		if run, err := c.jobService.RunNow("data_key_reencrypt", userID); err == nil {
+			response.Run = run
+		}
+	}
+
+	ctx.JSON(http.StatusAccepted, response)
+}
@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/pprof"
+	runtimepprof "runtime/pprof"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+)
+
+// DiagnosticsController serves profiling and in-flight request data for
+// diagnosing production performance problems
+type DiagnosticsController struct {
+	inFlight     func(minDuration time.Duration) []model.InFlightRequest
+	pprofEnabled bool
+}
+
+func NewDiagnosticsController(inFlight func(minDuration time.Duration) []model.InFlightRequest, pprofEnabled bool) *DiagnosticsController {
+	return &DiagnosticsController{
+		inFlight:     inFlight,
+		pprofEnabled: pprofEnabled,
+	}
+}
+
+func (c *DiagnosticsController) InFlight(ctx *gin.Context) {
+	minMs, err := strconv.Atoi(ctx.DefaultQuery("min_ms", "0"))
+	if err != nil || minMs < 0 {
+		minMs = 0
+	}
+
+	requests := c.inFlight(time.Duration(minMs) * time.Millisecond)
+	ctx.JSON(http.StatusOK, gin.H{"requests": requests, "count": len(requests)})
+}
+
+// PprofIndex lists the available runtime profiles
+func (c *DiagnosticsController) PprofIndex(ctx *gin.Context) {
+	if !c.requirePprof(ctx) {
+		return
+	}
+
+	profiles := []string{"profile", "trace", "cmdline", "symbol"}
+	for _, profile := range runtimepprof.Profiles() {
+		profiles = append(profiles, profile.Name())
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"profiles": profiles})
+}
+
+// Pprof serves a single profile in the net/http/pprof format
+func (c *DiagnosticsController) Pprof(ctx *gin.Context) {
+	if !c.requirePprof(ctx) {
+		return
+	}
+
+	// Profiles can carry secrets from memory; never let them be cached
+	ctx.Header("Cache-Control", "no-store")
+
+	switch name := ctx.Param("profile"); name {
+	case "profile":
+		pprof.Profile(ctx.Writer, ctx.Request)
+	case "trace":
+		pprof.Trace(ctx.Writer, ctx.Request)
+	case "cmdline":
+		pprof.Cmdline(ctx.Writer, ctx.Request)
+	case "symbol":
+		pprof.Symbol(ctx.Writer, ctx.Request)
+	default:
+		if runtimepprof.Lookup(name) == nil {
+			ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_NOT_FOUND",
+					Message: "Unknown profile",
+				},
+			})
+			return
+		}
+		pprof.Handler(name).ServeHTTP(ctx.Writer, ctx.Request)
+	}
+}
+
+func (c *DiagnosticsController) requirePprof(ctx *gin.Context) bool {
+	if c.pprofEnabled {
+		return true
+	}
+
+	ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+		Error: model.ErrorDetail{
+			Code:    "VAULT_NOT_FOUND",
+			Message: "Profiling is disabled (diagnostics.pprof_enabled)",
+		},
+	})
+	return false
+}
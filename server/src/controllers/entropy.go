@@ -0,0 +1,24 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type EntropyController struct {
+	entropyService *services.EntropyService
+}
+
+func NewEntropyController(entropyService *services.EntropyService) *EntropyController {
+	return &EntropyController{
+		entropyService: entropyService,
+	}
+}
+
+// GetEntropy reports the health of the entropy sources mixed into
+// crypto/rand
+func (c *EntropyController) GetEntropy(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, c.entropyService.Status())
+}
@@ -0,0 +1,249 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type EscalationController struct {
+	escalationService *services.EscalationService
+}
+
+func NewEscalationController(escalationService *services.EscalationService) *EscalationController {
+	return &EscalationController{
+		escalationService: escalationService,
+	}
+}
+
+func (c *EscalationController) GetTargets(ctx *gin.Context) {
+	targets, err := c.escalationService.GetTargets()
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve escalation targets")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"targets": targets})
+}
+
+func (c *EscalationController) CreateTarget(ctx *gin.Context) {
+	var req model.CreateEscalationTargetRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	target, err := c.escalationService.CreateTarget(&req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to create escalation target")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, target)
+}
+
+func (c *EscalationController) DeleteTarget(ctx *gin.Context) {
+	id, ok := c.parseID(ctx, "id", "Invalid escalation target ID")
+	if !ok {
+		return
+	}
+
+	if err := c.escalationService.DeleteTarget(id, ctx.MustGet("user_id").(uuid.UUID)); err != nil {
+		c.respondError(ctx, err, "Failed to delete escalation target")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Escalation target deleted successfully"})
+}
+
+func (c *EscalationController) AddRule(ctx *gin.Context) {
+	id, ok := c.parseID(ctx, "id", "Invalid escalation target ID")
+	if !ok {
+		return
+	}
+
+	var req model.EscalationRuleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	rule, err := c.escalationService.AddRule(id, &req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to add escalation rule")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, rule)
+}
+
+func (c *EscalationController) DeleteRule(ctx *gin.Context) {
+	id, ok := c.parseID(ctx, "id", "Invalid escalation target ID")
+	if !ok {
+		return
+	}
+	ruleID, ok := c.parseID(ctx, "rule_id", "Invalid escalation rule ID")
+	if !ok {
+		return
+	}
+
+	if err := c.escalationService.DeleteRule(id, ruleID, ctx.MustGet("user_id").(uuid.UUID)); err != nil {
+		c.respondError(ctx, err, "Failed to delete escalation rule")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Escalation rule deleted successfully"})
+}
+
+// TestTarget opens and immediately resolves a test incident
+func (c *EscalationController) TestTarget(ctx *gin.Context) {
+	id, ok := c.parseID(ctx, "id", "Invalid escalation target ID")
+	if !ok {
+		return
+	}
+
+	if err := c.escalationService.TestTarget(id); err != nil {
+		c.respondError(ctx, err, "Failed to send test incident")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Test incident triggered and resolved"})
+}
+
+func (c *EscalationController) GetIncidents(ctx *gin.Context) {
+	status := ctx.Query("status")
+	switch model.IncidentStatus(status) {
+	case "", model.IncidentStatusTriggered, model.IncidentStatusResolved:
+	default:
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "status must be triggered or resolved",
+			},
+		})
+		return
+	}
+
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	offset, err := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	incidents, err := c.escalationService.GetIncidents(status, limit, offset)
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve incidents")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"incidents": incidents, "limit": limit, "offset": offset})
+}
+
+func (c *EscalationController) ResolveIncident(ctx *gin.Context) {
+	id, ok := c.parseID(ctx, "id", "Invalid incident ID")
+	if !ok {
+		return
+	}
+
+	incident, err := c.escalationService.ResolveIncident(id, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to resolve incident")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, incident)
+}
+
+func (c *EscalationController) parseID(ctx *gin.Context, param, message string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(ctx.Param(param))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_ID",
+				Message: message,
+			},
+		})
+		return uuid.Nil, false
+	}
+
+	return id, true
+}
+
+func (c *EscalationController) respondError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrEscalationTargetNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_ESCALATION_TARGET_NOT_FOUND",
+				Message: "Escalation target not found",
+			},
+		})
+	case errors.Is(err, services.ErrEscalationRuleNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_ESCALATION_RULE_NOT_FOUND",
+				Message: "Escalation rule not found",
+			},
+		})
+	case errors.Is(err, services.ErrIncidentNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INCIDENT_NOT_FOUND",
+				Message: "Incident not found",
+			},
+		})
+	case errors.Is(err, services.ErrEscalationTargetExists):
+		ctx.JSON(http.StatusConflict, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_ESCALATION_TARGET_EXISTS",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrIncidentResolved):
+		ctx.JSON(http.StatusConflict, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INCIDENT_RESOLVED",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrEscalationTargetInvalid), errors.Is(err, services.ErrEscalationProviderInvalid), errors.Is(err, services.ErrEscalationRuleInvalid):
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrNotificationDelivery):
+		ctx.JSON(http.StatusBadGateway, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_ESCALATION_DELIVERY_FAILED",
+				Message: err.Error(),
+			},
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: message,
+			},
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type EscrowController struct {
+	escrowService *services.EscrowService
+}
+
+func NewEscrowController(escrowService *services.EscrowService) *EscrowController {
+	return &EscrowController{
+		escrowService: escrowService,
+	}
+}
+
+func (c *EscrowController) CreateEscrow(ctx *gin.Context) {
+	var req model.CreateEscrowRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	escrow, err := c.escrowService.CreateEscrow(&req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to create key escrow")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, escrow)
+}
+
+func (c *EscrowController) GetEscrow(ctx *gin.Context) {
+	escrow, err := c.escrowService.GetEscrow()
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve key escrow")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, escrow)
+}
+
+// GetShare returns the caller's sealed share, which only their CLI
+// identity can open
+func (c *EscrowController) GetShare(ctx *gin.Context) {
+	share, err := c.escrowService.GetShare(ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve recovery share")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, share)
+}
+
+func (c *EscrowController) StartRecovery(ctx *gin.Context) {
+	recovery, err := c.escrowService.StartRecovery(ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to start key recovery")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, recovery)
+}
+
+func (c *EscrowController) GetRecovery(ctx *gin.Context) {
+	recovery, err := c.escrowService.GetRecovery()
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve key recovery")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, recovery)
+}
+
+func (c *EscrowController) SubmitShare(ctx *gin.Context) {
+	var req model.SubmitRecoveryShareRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	recovery, err := c.escrowService.SubmitShare(ctx.MustGet("user_id").(uuid.UUID), req.Share)
+	if err != nil {
+		c.respondError(ctx, err, "Failed to submit recovery share")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, recovery)
+}
+
+func (c *EscrowController) CancelRecovery(ctx *gin.Context) {
+	if err := c.escrowService.CancelRecovery(ctx.MustGet("user_id").(uuid.UUID)); err != nil {
+		c.respondError(ctx, err, "Failed to cancel key recovery")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (c *EscrowController) respondError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrEscrowNotFound), errors.Is(err, services.ErrRecoveryNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_NOT_FOUND",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrEscrowNotOfficer):
+		ctx.JSON(http.StatusForbidden, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_NOT_RECOVERY_OFFICER",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrEscrowThresholdInvalid),
+		errors.Is(err, services.ErrEscrowOfficerNoKey),
+		errors.Is(err, services.ErrUserKeyNotFound),
+		errors.Is(err, services.ErrRecoveryShareInvalid):
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrRecoveryInProgress),
+		errors.Is(err, services.ErrRecoveryShareSubmitted),
+		errors.Is(err, services.ErrRecoveryMismatch):
+		ctx.JSON(http.StatusConflict, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_KEY_RECOVERY_CONFLICT",
+				Message: err.Error(),
+			},
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: message,
+			},
+		})
+	}
+}
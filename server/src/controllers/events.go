@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// EventFeedProtocol is the WebSocket subprotocol of the event feed.
+	// Browsers, which cannot set Authorization on a WebSocket, offer it
+	// together with "bearer.<token>".
+	EventFeedProtocol = "aether-vault.events"
+
+	feedWriteTimeout = 10 * time.Second
+	feedPingInterval = 30 * time.Second
+	// feedMaxLifetime ends connections so a revoked token stops streaming;
+	// clients reconnect with a current one
+	feedMaxLifetime = time.Hour
+)
+
+// EventFeedController streams live admin events over a WebSocket
+type EventFeedController struct {
+	feed *services.EventFeed
+}
+
+func NewEventFeedController(feed *services.EventFeed) *EventFeedController {
+	return &EventFeedController{
+		feed: feed,
+	}
+}
+
+// Watch upgrades to a WebSocket sending a "subscribed" frame and then one
+// frame per event of the classes named by ?class=auth,secret, all classes
+// by default
+func (c *EventFeedController) Watch(ctx *gin.Context) {
+	if c.feed == nil {
+		ctx.JSON(http.StatusServiceUnavailable, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_EVENT_FEED_UNAVAILABLE",
+				Message: "Event feed is not available",
+			},
+		})
+		return
+	}
+
+	var classes []model.FeedClass
+	for _, param := range ctx.QueryArray("class") {
+		for _, class := range strings.Split(param, ",") {
+			if class = strings.TrimSpace(class); class != "" {
+				classes = append(classes, model.FeedClass(class))
+			}
+		}
+	}
+
+	subscription, err := c.feed.Subscribe(classes)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrFeedClassUnknown):
+			ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INVALID_REQUEST",
+					Message: err.Error(),
+				},
+			})
+		case errors.Is(err, services.ErrFeedFull):
+			ctx.Header("Retry-After", "30")
+			ctx.JSON(http.StatusServiceUnavailable, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_OVERLOADED",
+					Message: err.Error(),
+				},
+			})
+		default:
+			ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INTERNAL_ERROR",
+					Message: "Failed to subscribe to events",
+				},
+			})
+		}
+		return
+	}
+	defer subscription.Close()
+
+	server := websocket.Server{
+		// The token, not the origin, authenticates the connection; no
+		// cookie grants access
+		Handshake: func(config *websocket.Config, req *http.Request) error {
+			for _, protocol := range config.Protocol {
+				if protocol == EventFeedProtocol {
+					config.Protocol = []string{EventFeedProtocol}
+					return nil
+				}
+			}
+			config.Protocol = nil
+			return nil
+		},
+		Handler: func(conn *websocket.Conn) {
+			streamFeed(conn, subscription)
+		},
+	}
+	server.ServeHTTP(ctx.Writer, ctx.Request)
+}
+
+func streamFeed(conn *websocket.Conn, subscription *services.FeedSubscription) {
+	defer conn.Close()
+	// The server's write timeout does not apply to a hijacked connection
+	// that lives this long; every write sets its own deadline instead
+	conn.SetDeadline(time.Time{})
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		var message string
+		for {
+			if err := websocket.Message.Receive(conn, &message); err != nil {
+				return
+			}
+		}
+	}()
+
+	send := func(message model.FeedMessage) error {
+		conn.SetWriteDeadline(time.Now().Add(feedWriteTimeout))
+		return websocket.JSON.Send(conn, message)
+	}
+	if err := send(model.FeedMessage{Type: model.FeedMessageSubscribed, Classes: subscription.Classes}); err != nil {
+		return
+	}
+
+	ping := time.NewTicker(feedPingInterval)
+	defer ping.Stop()
+	lifetime := time.NewTimer(feedMaxLifetime)
+	defer lifetime.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-lifetime.C:
+			return
+		case <-ping.C:
+			conn.SetWriteDeadline(time.Now().Add(feedWriteTimeout))
+			conn.PayloadType = websocket.PingFrame
+			_, err := conn.Write(nil)
+			conn.PayloadType = websocket.TextFrame
+			if err != nil {
+				return
+			}
+		case event, ok := <-subscription.Events:
+			if !ok {
+				return
+			}
+			if dropped := subscription.TakeDropped(); dropped > 0 {
+				if err := send(model.FeedMessage{Type: model.FeedMessageDropped, Dropped: dropped}); err != nil {
+					return
+				}
+			}
+			if err := send(model.FeedMessage{Type: model.FeedMessageEvent, Event: event}); err != nil {
+				return
+			}
+		}
+	}
+}
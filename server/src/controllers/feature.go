@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type FeatureController struct {
+	featureFlagService *services.FeatureFlagService
+}
+
+func NewFeatureController(featureFlagService *services.FeatureFlagService) *FeatureController {
+	return &FeatureController{
+		featureFlagService: featureFlagService,
+	}
+}
+
+func (c *FeatureController) GetFlags(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"features": c.featureFlagService.GetFlags()})
+}
+
+// SetFlag overrides a flag's configured value on every instance
+func (c *FeatureController) SetFlag(ctx *gin.Context) {
+	var req model.SetFeatureFlagRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	flag, err := c.featureFlagService.SetOverride(ctx.Param("name"), *req.Enabled, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to update feature flag")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, flag)
+}
+
+// ResetFlag removes the override so the configured value applies again
+func (c *FeatureController) ResetFlag(ctx *gin.Context) {
+	flag, err := c.featureFlagService.ClearOverride(ctx.Param("name"), ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to reset feature flag")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, flag)
+}
+
+func (c *FeatureController) respondError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrFeatureFlagNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_FEATURE_FLAG_NOT_FOUND",
+				Message: "Feature flag not found",
+			},
+		})
+	case errors.Is(err, services.ErrFeatureFlagsReadOnly):
+		ctx.JSON(http.StatusServiceUnavailable, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_FEATURE_FLAGS_READ_ONLY",
+				Message: err.Error(),
+			},
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: message,
+			},
+		})
+	}
+}
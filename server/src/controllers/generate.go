@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type GenerateController struct {
+	generatorService *services.GeneratorService
+}
+
+func NewGenerateController(generatorService *services.GeneratorService) *GenerateController {
+	return &GenerateController{
+		generatorService: generatorService,
+	}
+}
+
+func (c *GenerateController) Generate(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_UNAUTHORIZED",
+				Message: "Unauthorized",
+			},
+		})
+		return
+	}
+
+	var req model.GenerateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	response, err := c.generatorService.Generate(&req, userID.(uuid.UUID))
+	if err != nil {
+		if respondTemplateViolation(ctx, err) || respondPasswordPolicy(ctx, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrGenerateInvalid):
+			ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INVALID_REQUEST",
+					Message: err.Error(),
+				},
+			})
+		case errors.Is(err, services.ErrGenerateStoreUnavailable):
+			ctx.JSON(http.StatusServiceUnavailable, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_STORAGE_UNAVAILABLE",
+					Message: err.Error(),
+				},
+			})
+		default:
+			ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INTERNAL_ERROR",
+					Message: "Failed to generate material",
+				},
+			})
+		}
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, response)
+}
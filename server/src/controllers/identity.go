@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"github.com/skygenesisenterprise/aether-vault/server/src/model"
 	"github.com/skygenesisenterprise/aether-vault/server/src/services"
 	"net/http"
@@ -82,3 +83,51 @@ func (c *IdentityController) GetPolicies(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"policies": policies})
 }
+
+// GetCapabilities reports what the calling token may do on ?path=, so
+// clients can check before an operation instead of decoding a 403
+func (c *IdentityController) GetCapabilities(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_UNAUTHORIZED",
+				Message: "Unauthorized",
+			},
+		})
+		return
+	}
+
+	user, err := c.userService.GetUserByID(userID.(uuid.UUID))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_UNAUTHORIZED",
+				Message: "Unauthorized",
+			},
+		})
+		return
+	}
+
+	capabilities, err := c.policyService.Capabilities(user.ID, ctx.Query("path"), services.IsCentralAdmin(user))
+	if err != nil {
+		if errors.Is(err, services.ErrPolicyPathRequired) {
+			ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INVALID_REQUEST",
+					Message: "The path query parameter is required",
+				},
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: "Failed to check capabilities",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, capabilities)
+}
@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type ImpersonationController struct {
+	impersonationService *services.ImpersonationService
+}
+
+func NewImpersonationController(impersonationService *services.ImpersonationService) *ImpersonationController {
+	return &ImpersonationController{
+		impersonationService: impersonationService,
+	}
+}
+
+// StartImpersonation mints a time-boxed token acting as another user
+func (c *ImpersonationController) StartImpersonation(ctx *gin.Context) {
+	var req model.StartImpersonationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	// An impersonation token acts as its user, never as the administrator
+	if _, ok := ctx.Get("impersonation"); ok {
+		c.respondError(ctx, services.ErrImpersonationNested, "")
+		return
+	}
+
+	response, err := c.impersonationService.Start(&req, ctx.MustGet("user_id").(uuid.UUID), tokenAccessor(ctx))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to start impersonation")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, response)
+}
+
+// GetImpersonations lists recent sessions, only live ones with ?active=true
+func (c *ImpersonationController) GetImpersonations(ctx *gin.Context) {
+	impersonations, err := c.impersonationService.GetImpersonations(ctx.Query("active") == "true")
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve impersonations")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"impersonations": impersonations})
+}
+
+// EndImpersonation revokes the session named by :id
+func (c *ImpersonationController) EndImpersonation(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_ID",
+				Message: "Invalid impersonation ID",
+			},
+		})
+		return
+	}
+
+	impersonation, err := c.impersonationService.End(id, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to end impersonation")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, impersonation)
+}
+
+// EndCurrentImpersonation ends the session of the impersonation token
+// making the request, on behalf of its administrator
+func (c *ImpersonationController) EndCurrentImpersonation(ctx *gin.Context) {
+	value, ok := ctx.Get("impersonation")
+	if !ok {
+		c.respondError(ctx, services.ErrImpersonationNotFound, "")
+		return
+	}
+	current := value.(*model.Impersonation)
+
+	impersonation, err := c.impersonationService.End(current.ID, current.AdminID)
+	if err != nil {
+		c.respondError(ctx, err, "Failed to end impersonation")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, impersonation)
+}
+
+func (c *ImpersonationController) respondError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_USER_NOT_FOUND",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrImpersonationNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_IMPERSONATION_NOT_FOUND",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrImpersonationDenied), errors.Is(err, services.ErrImpersonationForbidden),
+		errors.Is(err, services.ErrImpersonationNested):
+		ctx.JSON(http.StatusForbidden, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_ACCESS_DENIED",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrImpersonationEnded):
+		ctx.JSON(http.StatusConflict, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_IMPERSONATION_ENDED",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrImpersonationReason), errors.Is(err, services.ErrImpersonationTTLTooLong),
+		errors.Is(err, services.ErrImpersonationSelf):
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: message,
+			},
+		})
+	}
+}
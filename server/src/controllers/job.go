@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type JobController struct {
+	jobService *services.JobService
+}
+
+func NewJobController(jobService *services.JobService) *JobController {
+	return &JobController{
+		jobService: jobService,
+	}
+}
+
+func (c *JobController) GetJobs(ctx *gin.Context) {
+	jobs, err := c.jobService.GetJobs()
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve jobs")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, jobs)
+}
+
+func (c *JobController) GetRuns(ctx *gin.Context) {
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	offset, err := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	runs, err := c.jobService.GetRuns(ctx.Param("name"), limit, offset)
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve job runs")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"runs": runs, "limit": limit, "offset": offset})
+}
+
+// RunJob starts a job on demand; poll its runs for the outcome
+func (c *JobController) RunJob(ctx *gin.Context) {
+	run, err := c.jobService.RunNow(ctx.Param("name"), ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to start job")
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, run)
+}
+
+func (c *JobController) respondError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrJobNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_JOB_NOT_FOUND",
+				Message: "Job not found",
+			},
+		})
+	case errors.Is(err, services.ErrJobRunning):
+		ctx.JSON(http.StatusConflict, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_JOB_RUNNING",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrJobDisabled):
+		ctx.JSON(http.StatusConflict, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_JOB_DISABLED",
+				Message: err.Error(),
+			},
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: message,
+			},
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type KubernetesAuthController struct {
+	kubernetesAuthService *services.KubernetesAuthService
+	auditService          *services.AuditService
+}
+
+func NewKubernetesAuthController(kubernetesAuthService *services.KubernetesAuthService, auditService *services.AuditService) *KubernetesAuthController {
+	return &KubernetesAuthController{
+		kubernetesAuthService: kubernetesAuthService,
+		auditService:          auditService,
+	}
+}
+
+func (c *KubernetesAuthController) GetRoles(ctx *gin.Context) {
+	roles, err := c.kubernetesAuthService.GetRoles()
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve Kubernetes roles")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"roles": roles})
+}
+
+func (c *KubernetesAuthController) GetRole(ctx *gin.Context) {
+	role, err := c.kubernetesAuthService.GetRole(ctx.Param("name"))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve Kubernetes role")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, role)
+}
+
+func (c *KubernetesAuthController) CreateRole(ctx *gin.Context) {
+	var req model.CreateKubernetesRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	role, err := c.kubernetesAuthService.CreateRole(&req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to create Kubernetes role")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, role)
+}
+
+func (c *KubernetesAuthController) UpdateRole(ctx *gin.Context) {
+	var req model.UpdateKubernetesRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	role, err := c.kubernetesAuthService.UpdateRole(ctx.Param("name"), &req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to update Kubernetes role")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, role)
+}
+
+func (c *KubernetesAuthController) DeleteRole(ctx *gin.Context) {
+	if err := c.kubernetesAuthService.DeleteRole(ctx.Param("name"), ctx.MustGet("user_id").(uuid.UUID)); err != nil {
+		c.respondError(ctx, err, "Failed to delete Kubernetes role")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Kubernetes role deleted"})
+}
+
+// Login exchanges a service account token for a token of the role, and
+// answers like POST /auth/login
+func (c *KubernetesAuthController) Login(ctx *gin.Context) {
+	var req model.KubernetesLoginRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	response, account, err := c.kubernetesAuthService.Login(req.Role, req.JWT)
+	details := "method=kubernetes; role=" + req.Role
+	if account != nil {
+		details += "; service_account=" + account.Namespace + "/" + account.Name
+	}
+	if err != nil {
+		if c.auditService != nil {
+			c.auditService.LogAnonymousAction("login_failed", "auth", "", ctx.ClientIP(), ctx.GetHeader("User-Agent"), false, details+"; error="+err.Error())
+		}
+		c.respondError(ctx, err, "Failed to complete Kubernetes login")
+		return
+	}
+
+	if c.auditService != nil {
+		c.auditService.LogAnonymousAction("login_success", "auth", response.User.ID.String(), ctx.ClientIP(), ctx.GetHeader("User-Agent"), true, details)
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *KubernetesAuthController) respondError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrKubernetesDisabled):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_KUBERNETES_DISABLED",
+				Message: "Kubernetes login is not configured",
+			},
+		})
+	case errors.Is(err, services.ErrKubernetesRoleNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_KUBERNETES_ROLE_NOT_FOUND",
+				Message: "Kubernetes role not found",
+			},
+		})
+	case errors.Is(err, services.ErrKubernetesRoleExists):
+		ctx.JSON(http.StatusConflict, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_KUBERNETES_ROLE_EXISTS",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrKubernetesRoleInvalid):
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrKubernetesLoginFailed):
+		ctx.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_CREDENTIALS",
+				Message: "The service account token was refused for this role",
+			},
+		})
+	case errors.Is(err, services.ErrKubernetesUnavailable):
+		ctx.JSON(http.StatusBadGateway, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_KUBERNETES_UNAVAILABLE",
+				Message: "The Kubernetes API is unavailable",
+			},
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: message,
+			},
+		})
+	}
+}
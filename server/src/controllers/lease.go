@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type LeaseController struct {
+	leaseService *services.LeaseService
+}
+
+func NewLeaseController(leaseService *services.LeaseService) *LeaseController {
+	return &LeaseController{
+		leaseService: leaseService,
+	}
+}
+
+// GetLeases lists the caller's active leases
+func (c *LeaseController) GetLeases(ctx *gin.Context) {
+	if c.leaseService == nil {
+		c.respondError(ctx, services.ErrLeasesUnavailable, "")
+		return
+	}
+
+	leases, err := c.leaseService.GetLeases(ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve leases")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"leases": leases})
+}
+
+// RenewLease extends one of the caller's leases within its maximum TTL
+func (c *LeaseController) RenewLease(ctx *gin.Context) {
+	var req model.RenewLeaseRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+	if c.leaseService == nil {
+		c.respondError(ctx, services.ErrLeasesUnavailable, "")
+		return
+	}
+
+	lease, err := c.leaseService.Renew(&req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to renew lease")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lease.Info(time.Now()))
+}
+
+// RevokeLease ends one of the caller's leases now, revoking what it was
+// issued for
+func (c *LeaseController) RevokeLease(ctx *gin.Context) {
+	var req model.RevokeLeaseRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+	if c.leaseService == nil {
+		c.respondError(ctx, services.ErrLeasesUnavailable, "")
+		return
+	}
+
+	lease, err := c.leaseService.Revoke(req.LeaseID, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to revoke lease")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lease)
+}
+
+func (c *LeaseController) respondError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrLeaseNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_LEASE_NOT_FOUND",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrLeaseEnded):
+		ctx.JSON(http.StatusConflict, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_LEASE_ENDED",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrLeaseNotRenewable):
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_LEASE_NOT_RENEWABLE",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrLeasesUnavailable):
+		ctx.JSON(http.StatusServiceUnavailable, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_SERVICE_UNAVAILABLE",
+				Message: err.Error(),
+			},
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: message,
+			},
+		})
+	}
+}
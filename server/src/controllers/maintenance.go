@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type MaintenanceController struct {
+	maintenanceService *services.MaintenanceService
+}
+
+func NewMaintenanceController(maintenanceService *services.MaintenanceService) *MaintenanceController {
+	return &MaintenanceController{
+		maintenanceService: maintenanceService,
+	}
+}
+
+func (c *MaintenanceController) GetMaintenance(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, c.maintenanceService.Status())
+}
+
+// SetMaintenance switches read-only mode on or off for every instance
+func (c *MaintenanceController) SetMaintenance(ctx *gin.Context) {
+	var req model.SetMaintenanceRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	state, err := c.maintenanceService.Set(&req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: "Failed to update maintenance mode",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, state)
+}
@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type MFAController struct {
+	mfaService *services.MFAService
+}
+
+func NewMFAController(mfaService *services.MFAService) *MFAController {
+	return &MFAController{
+		mfaService: mfaService,
+	}
+}
+
+// Enroll starts TOTP enrollment and returns the seed, which is not shown
+// again. Users under step-up rules enroll from a fresh sign-in.
+func (c *MFAController) Enroll(ctx *gin.Context) {
+	response, err := c.mfaService.Enroll(ctx.MustGet("user_id").(uuid.UUID), tokenAccessor(ctx))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to enroll MFA")
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusCreated, response)
+}
+
+// Confirm completes enrollment with a code from the new seed
+func (c *MFAController) Confirm(ctx *gin.Context) {
+	var req model.MFACodeRequest
+	if !bindMFACode(ctx, &req) {
+		return
+	}
+
+	if err := c.mfaService.Confirm(ctx.MustGet("user_id").(uuid.UUID), req.Code); err != nil {
+		c.respondError(ctx, err, "Failed to confirm MFA")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "MFA enrolled"})
+}
+
+// Remove deletes the caller's factor; a confirmed one takes a code
+func (c *MFAController) Remove(ctx *gin.Context) {
+	var req model.MFACodeRequest
+	if ctx.Request.ContentLength > 0 && !bindMFACode(ctx, &req) {
+		return
+	}
+
+	if err := c.mfaService.Remove(ctx.MustGet("user_id").(uuid.UUID), req.Code); err != nil {
+		c.respondError(ctx, err, "Failed to remove MFA")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "MFA removed"})
+}
+
+// Verify answers a step-up challenge for the calling token
+func (c *MFAController) Verify(ctx *gin.Context) {
+	var req model.MFACodeRequest
+	if !bindMFACode(ctx, &req) {
+		return
+	}
+
+	response, err := c.mfaService.Verify(ctx.MustGet("user_id").(uuid.UUID), tokenAccessor(ctx), req.Code)
+	if err != nil {
+		c.respondError(ctx, err, "Failed to verify MFA")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *MFAController) respondError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrMFANotEnrolled):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_MFA_NOT_ENROLLED",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrMFAAlreadyEnrolled):
+		ctx.JSON(http.StatusConflict, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_MFA_ALREADY_ENROLLED",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrMFAFreshLoginRequired):
+		ctx.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_MFA_FRESH_LOGIN_REQUIRED",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrMFACodeInvalid):
+		ctx.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_MFA_CODE_INVALID",
+				Message: "Invalid MFA code",
+			},
+		})
+	case errors.Is(err, services.ErrTOTPRateLimited):
+		ctx.JSON(http.StatusTooManyRequests, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_TOTP_RATE_LIMITED",
+				Message: "Too many failed attempts, try again later",
+			},
+		})
+	case errors.Is(err, services.ErrTokenNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_TOKEN_NOT_FOUND",
+				Message: "Token not found",
+			},
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: message,
+			},
+		})
+	}
+}
+
+func bindMFACode(ctx *gin.Context, req *model.MFACodeRequest) bool {
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return false
+	}
+	return true
+}
+
+// respondStepUpRequired answers with the challenge of a policy rule that
+// requires a more recent MFA verification. The client verifies at the
+// path the challenge names and retries.
+func respondStepUpRequired(ctx *gin.Context, err error) bool {
+	var stepUp *services.StepUpRequiredError
+	if !errors.As(err, &stepUp) {
+		return false
+	}
+	ctx.JSON(http.StatusUnauthorized, model.ErrorResponse{
+		Error: model.ErrorDetail{
+			Code:    "VAULT_MFA_REQUIRED",
+			Message: stepUp.Error(),
+			MFA:     &stepUp.Requirement,
+		},
+	})
+	return true
+}
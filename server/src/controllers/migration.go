@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type MigrationController struct {
+	migrationService *services.MigrationService
+}
+
+func NewMigrationController(migrationService *services.MigrationService) *MigrationController {
+	return &MigrationController{
+		migrationService: migrationService,
+	}
+}
+
+func (c *MigrationController) GetMigrations(ctx *gin.Context) {
+	migrations, err := c.migrationService.GetMigrations()
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve migrations")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"migrations": migrations})
+}
+
+func (c *MigrationController) GetMigration(ctx *gin.Context) {
+	migration, err := c.migrationService.GetMigration(ctx.Param("name"))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve migration")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, migration)
+}
+
+// SetPhase advances or rolls back a migration; the backfill itself runs
+// as the online_migration_backfill job
+func (c *MigrationController) SetPhase(ctx *gin.Context) {
+	var req model.SetMigrationPhaseRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	migration, err := c.migrationService.SetPhase(ctx.Param("name"), req.Phase, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to change migration phase")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, migration)
+}
+
+func (c *MigrationController) respondError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrMigrationNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_MIGRATION_NOT_FOUND",
+				Message: "Migration not found",
+			},
+		})
+	case errors.Is(err, services.ErrMigrationPhaseInvalid):
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrMigrationPhaseSkipped),
+		errors.Is(err, services.ErrMigrationNotSettled),
+		errors.Is(err, services.ErrMigrationNotBackfilled),
+		errors.Is(err, services.ErrMigrationPhaseChanged):
+		ctx.JSON(http.StatusConflict, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_MIGRATION_PHASE_REJECTED",
+				Message: err.Error(),
+			},
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: message,
+			},
+		})
+	}
+}
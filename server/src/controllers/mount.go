@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type MountController struct {
+	mountService *services.MountService
+}
+
+func NewMountController(mountService *services.MountService) *MountController {
+	return &MountController{
+		mountService: mountService,
+	}
+}
+
+func (c *MountController) GetMounts(ctx *gin.Context) {
+	mounts, err := c.mountService.GetMounts()
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve mounts")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"mounts": mounts})
+}
+
+func (c *MountController) GetMount(ctx *gin.Context) {
+	mount, err := c.mountService.GetMount(ctx.Param("path"))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve mount")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, mount)
+}
+
+func (c *MountController) EnableMount(ctx *gin.Context) {
+	var req model.EnableMountRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	mount, err := c.mountService.EnableMount(&req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to enable mount")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, mount)
+}
+
+func (c *MountController) TuneMount(ctx *gin.Context) {
+	var req model.TuneMountRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	mount, err := c.mountService.TuneMount(ctx.Param("path"), &req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to tune mount")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, mount)
+}
+
+func (c *MountController) DisableMount(ctx *gin.Context) {
+	if err := c.mountService.DisableMount(ctx.Param("path"), ctx.MustGet("user_id").(uuid.UUID)); err != nil {
+		c.respondError(ctx, err, "Failed to disable mount")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (c *MountController) respondError(ctx *gin.Context, err error, message string) {
+	if respondMountError(ctx, err) {
+		return
+	}
+	if errors.Is(err, services.ErrNamespaceNotFound) {
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_NAMESPACE_NOT_FOUND",
+				Message: "Namespace not found",
+			},
+		})
+		return
+	}
+	ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+		Error: model.ErrorDetail{
+			Code:    "VAULT_INTERNAL_ERROR",
+			Message: message,
+		},
+	})
+}
+
+// respondMountError answers mount table errors, also raised when secrets
+// are written to a mount; it reports whether it wrote a response
+func respondMountError(ctx *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, services.ErrMountNotFound), errors.Is(err, services.ErrMountWrongType):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_MOUNT_NOT_FOUND",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrMountExists),
+		errors.Is(err, services.ErrMountBuiltin),
+		errors.Is(err, services.ErrMountNotEmpty):
+		ctx.JSON(http.StatusConflict, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_MOUNT_CONFLICT",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrMountInvalidPath),
+		errors.Is(err, services.ErrMountTypeUnavailable),
+		errors.Is(err, services.ErrMountOptionsInvalid),
+		errors.Is(err, services.ErrMountTTLExceeded),
+		errors.Is(err, services.ErrMountTTLInvalid),
+		errors.Is(err, services.ErrMountNamespace):
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		})
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,219 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type NamespaceController struct {
+	namespaceService *services.NamespaceService
+}
+
+func NewNamespaceController(namespaceService *services.NamespaceService) *NamespaceController {
+	return &NamespaceController{
+		namespaceService: namespaceService,
+	}
+}
+
+func (c *NamespaceController) GetNamespaces(ctx *gin.Context) {
+	namespaces, err := c.namespaceService.GetNamespaces()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: "Failed to retrieve namespaces",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"namespaces": namespaces})
+}
+
+func (c *NamespaceController) CreateNamespace(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_UNAUTHORIZED",
+				Message: "Unauthorized",
+			},
+		})
+		return
+	}
+
+	var req model.CreateNamespaceRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	namespace, err := c.namespaceService.CreateNamespace(&req, userID.(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to create namespace")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, namespace)
+}
+
+func (c *NamespaceController) GetRoles(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"roles": c.namespaceService.GetRoles()})
+}
+
+func (c *NamespaceController) GetRoleBindings(ctx *gin.Context) {
+	namespaceID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_ID",
+				Message: "Invalid namespace ID",
+			},
+		})
+		return
+	}
+
+	bindings, err := c.namespaceService.GetRoleBindings(namespaceID)
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve role bindings")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"bindings": bindings})
+}
+
+func (c *NamespaceController) AssignRole(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_UNAUTHORIZED",
+				Message: "Unauthorized",
+			},
+		})
+		return
+	}
+
+	namespaceID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_ID",
+				Message: "Invalid namespace ID",
+			},
+		})
+		return
+	}
+
+	var req model.AssignNamespaceRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	assigned, err := c.namespaceService.AssignRole(namespaceID, req.Role, req.UserIDs, userID.(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to assign role")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"role": req.Role, "assigned": assigned})
+}
+
+func (c *NamespaceController) RevokeRole(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_UNAUTHORIZED",
+				Message: "Unauthorized",
+			},
+		})
+		return
+	}
+
+	namespaceID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_ID",
+				Message: "Invalid namespace ID",
+			},
+		})
+		return
+	}
+
+	targetID, err := uuid.Parse(ctx.Param("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_ID",
+				Message: "Invalid user ID",
+			},
+		})
+		return
+	}
+
+	role := model.NamespaceRole(ctx.Param("role"))
+	if err := c.namespaceService.RevokeRole(namespaceID, role, targetID, userID.(uuid.UUID)); err != nil {
+		c.respondError(ctx, err, "Failed to revoke role")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Role revoked successfully"})
+}
+
+func (c *NamespaceController) respondError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrNamespaceNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_NAMESPACE_NOT_FOUND",
+				Message: "Namespace not found",
+			},
+		})
+	case errors.Is(err, services.ErrNamespaceBindingNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_ROLE_BINDING_NOT_FOUND",
+				Message: "Role binding not found",
+			},
+		})
+	case errors.Is(err, services.ErrUserNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_USER_NOT_FOUND",
+				Message: "One or more users not found",
+			},
+		})
+	case errors.Is(err, services.ErrNamespaceInvalidName), errors.Is(err, services.ErrNamespaceInvalidRole):
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: message,
+			},
+		})
+	}
+}
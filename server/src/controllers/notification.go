@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type NotificationController struct {
+	emailNotifier *services.EmailNotifier
+	userService   *services.UserService
+}
+
+func NewNotificationController(emailNotifier *services.EmailNotifier, userService *services.UserService) *NotificationController {
+	return &NotificationController{
+		emailNotifier: emailNotifier,
+		userService:   userService,
+	}
+}
+
+func (c *NotificationController) GetPreferences(ctx *gin.Context) {
+	preferences, err := c.emailNotifier.GetPreferences(ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: "Failed to retrieve notification preferences",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, preferences)
+}
+
+func (c *NotificationController) UpdatePreferences(ctx *gin.Context) {
+	var req model.UpdateNotificationPreferencesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	preferences, err := c.emailNotifier.UpdatePreferences(ctx.MustGet("user_id").(uuid.UUID), &req)
+	if err != nil {
+		if errors.Is(err, services.ErrNotificationCategoryInvalid) {
+			ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INVALID_REQUEST",
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: "Failed to update notification preferences",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, preferences)
+}
+
+// SendTest emails the test template to the given address, or to the
+// caller when none is given
+func (c *NotificationController) SendTest(ctx *gin.Context) {
+	var req model.TestNotificationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "to must be an email address",
+			},
+		})
+		return
+	}
+
+	to := req.To
+	if to == "" {
+		user, err := c.userService.GetUserByID(ctx.MustGet("user_id").(uuid.UUID))
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INTERNAL_ERROR",
+					Message: "Failed to look up your email address",
+				},
+			})
+			return
+		}
+		to = user.Email
+	}
+
+	if err := c.emailNotifier.SendTest(to); err != nil {
+		switch {
+		case errors.Is(err, services.ErrNotificationChannelDisabled):
+			ctx.JSON(http.StatusServiceUnavailable, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_EMAIL_DISABLED",
+					Message: "Email notifications are not configured (notifications.smtp.host)",
+				},
+			})
+		case errors.Is(err, services.ErrNotificationDelivery):
+			ctx.JSON(http.StatusBadGateway, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_EMAIL_DELIVERY_FAILED",
+					Message: err.Error(),
+				},
+			})
+		default:
+			ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INTERNAL_ERROR",
+					Message: "Failed to send test email",
+				},
+			})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.TestNotificationResponse{
+		To:      to,
+		Message: "Test email sent",
+	})
+}
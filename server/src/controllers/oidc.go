@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type OIDCController struct {
+	oidcService *services.OIDCService
+	publicURL   string
+}
+
+func NewOIDCController(oidcService *services.OIDCService, publicURL string) *OIDCController {
+	return &OIDCController{
+		oidcService: oidcService,
+		publicURL:   publicURL,
+	}
+}
+
+func (c *OIDCController) Discovery(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, c.oidcService.Discovery(c.issuer(ctx)))
+}
+
+func (c *OIDCController) JWKS(ctx *gin.Context) {
+	keys, err := c.oidcService.JWKS()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: "Failed to retrieve signing keys",
+			},
+		})
+		return
+	}
+
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, keys)
+}
+
+func (c *OIDCController) IssueToken(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_UNAUTHORIZED",
+				Message: "Unauthorized",
+			},
+		})
+		return
+	}
+
+	response, err := c.oidcService.IssueToken(ctx.Param("name"), userID.(uuid.UUID), c.issuer(ctx))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrOIDCRoleNotFound):
+			ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_OIDC_ROLE_NOT_FOUND",
+					Message: "OIDC role not found",
+				},
+			})
+		case errors.Is(err, services.ErrUserNotFound):
+			ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_USER_NOT_FOUND",
+					Message: "User not found",
+				},
+			})
+		default:
+			ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INTERNAL_ERROR",
+					Message: "Failed to issue identity token",
+				},
+			})
+		}
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *OIDCController) GetRoles(ctx *gin.Context) {
+	roles, err := c.oidcService.GetRoles()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: "Failed to retrieve OIDC roles",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"roles": roles})
+}
+
+func (c *OIDCController) CreateRole(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_UNAUTHORIZED",
+				Message: "Unauthorized",
+			},
+		})
+		return
+	}
+
+	var req model.CreateOIDCRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	role, err := c.oidcService.CreateRole(&req, userID.(uuid.UUID))
+	if err != nil {
+		if errors.Is(err, services.ErrOIDCInvalidRole) {
+			ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INVALID_OIDC_ROLE",
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: "Failed to create OIDC role",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, role)
+}
+
+func (c *OIDCController) RotateKey(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_UNAUTHORIZED",
+				Message: "Unauthorized",
+			},
+		})
+		return
+	}
+
+	actor := userID.(uuid.UUID)
+	key, err := c.oidcService.RotateKey(&actor)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: "Failed to rotate signing key",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, key)
+}
+
+func (c *OIDCController) issuer(ctx *gin.Context) string {
+	if issuer := c.oidcService.ConfiguredIssuer(); issuer != "" {
+		return issuer
+	}
+	return requestBaseURL(ctx, c.publicURL) + "/api/v1/identity/oidc"
+}
@@ -0,0 +1,210 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type OIDCAuthController struct {
+	oidcAuthService *services.OIDCAuthService
+	auditService    *services.AuditService
+}
+
+func NewOIDCAuthController(oidcAuthService *services.OIDCAuthService, auditService *services.AuditService) *OIDCAuthController {
+	return &OIDCAuthController{
+		oidcAuthService: oidcAuthService,
+		auditService:    auditService,
+	}
+}
+
+func (c *OIDCAuthController) GetProviders(ctx *gin.Context) {
+	providers, err := c.oidcAuthService.GetProviders()
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve OIDC providers")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"providers": providers})
+}
+
+func (c *OIDCAuthController) CreateProvider(ctx *gin.Context) {
+	var req model.CreateOIDCProviderRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	provider, err := c.oidcAuthService.CreateProvider(&req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to create OIDC provider")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, provider)
+}
+
+func (c *OIDCAuthController) UpdateProvider(ctx *gin.Context) {
+	var req model.UpdateOIDCProviderRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	provider, err := c.oidcAuthService.UpdateProvider(ctx.Param("name"), &req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to update OIDC provider")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, provider)
+}
+
+func (c *OIDCAuthController) DeleteProvider(ctx *gin.Context) {
+	if err := c.oidcAuthService.DeleteProvider(ctx.Param("name"), ctx.MustGet("user_id").(uuid.UUID)); err != nil {
+		c.respondError(ctx, err, "Failed to delete OIDC provider")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "OIDC provider deleted"})
+}
+
+// AuthURL starts an OIDC login and returns the provider URL to send the
+// user to
+func (c *OIDCAuthController) AuthURL(ctx *gin.Context) {
+	var req model.OIDCAuthURLRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	response, err := c.oidcAuthService.AuthURL(ctx.Param("name"), req.RedirectURI)
+	if err != nil {
+		c.respondError(ctx, err, "Failed to start OIDC login")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// Callback completes an OIDC login with the code and state the provider
+// returned, and answers like POST /auth/login
+func (c *OIDCAuthController) Callback(ctx *gin.Context) {
+	name := ctx.Param("name")
+	details := "method=oidc; provider=" + name
+
+	if providerError := ctx.Query("error"); providerError != "" {
+		c.logLogin(ctx, nil, details+"; error="+providerError)
+		ctx.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_OIDC_LOGIN_FAILED",
+				Message: "The identity provider refused the login: " + providerError + " " + ctx.Query("error_description"),
+			},
+		})
+		return
+	}
+	state, code := ctx.Query("state"), ctx.Query("code")
+	if state == "" || code == "" {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "state and code are required",
+			},
+		})
+		return
+	}
+
+	response, err := c.oidcAuthService.Callback(name, state, code)
+	if err != nil {
+		c.logLogin(ctx, nil, details+"; error="+err.Error())
+		c.respondError(ctx, err, "Failed to complete OIDC login")
+		return
+	}
+
+	c.logLogin(ctx, &response.User.ID, details)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *OIDCAuthController) logLogin(ctx *gin.Context, userID *uuid.UUID, details string) {
+	if c.auditService == nil {
+		return
+	}
+	if userID == nil {
+		c.auditService.LogAnonymousAction("login_failed", "auth", "", ctx.ClientIP(), ctx.GetHeader("User-Agent"), false, details)
+		return
+	}
+	c.auditService.LogAnonymousAction("login_success", "auth", userID.String(), ctx.ClientIP(), ctx.GetHeader("User-Agent"), true, details)
+}
+
+func (c *OIDCAuthController) respondError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrOIDCProviderNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_OIDC_PROVIDER_NOT_FOUND",
+				Message: "OIDC provider not found",
+			},
+		})
+	case errors.Is(err, services.ErrOIDCProviderExists):
+		ctx.JSON(http.StatusConflict, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_OIDC_PROVIDER_EXISTS",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrOIDCProviderInvalid), errors.Is(err, services.ErrOIDCRedirectURIInvalid):
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrOIDCProviderUnavailable):
+		ctx.JSON(http.StatusBadGateway, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_OIDC_PROVIDER_UNAVAILABLE",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrOIDCStateInvalid), errors.Is(err, services.ErrOIDCLoginFailed):
+		ctx.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_OIDC_LOGIN_FAILED",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrOIDCUserNotLinked):
+		ctx.JSON(http.StatusForbidden, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_OIDC_USER_NOT_LINKED",
+				Message: err.Error(),
+			},
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: message,
+			},
+		})
+	}
+}
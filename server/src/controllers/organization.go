@@ -0,0 +1,473 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type OrgController struct {
+	orgService *services.OrgService
+}
+
+func NewOrgController(orgService *services.OrgService) *OrgController {
+	return &OrgController{
+		orgService: orgService,
+	}
+}
+
+func (c *OrgController) GetOrganizations(ctx *gin.Context) {
+	organizations, err := c.orgService.GetOrganizations(ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve organizations")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"organizations": organizations})
+}
+
+// GetMemberships lists the caller's roles on organizations, teams and
+// projects
+func (c *OrgController) GetMemberships(ctx *gin.Context) {
+	memberships, err := c.orgService.GetMemberships(ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve memberships")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"memberships": memberships})
+}
+
+func (c *OrgController) CreateOrganization(ctx *gin.Context) {
+	var req model.CreateOrgEntityRequest
+	if !bindOrgRequest(ctx, &req) {
+		return
+	}
+
+	organization, err := c.orgService.CreateOrganization(&req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to create organization")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, organization)
+}
+
+func (c *OrgController) GetOrganization(ctx *gin.Context) {
+	id, ok := orgEntityID(ctx)
+	if !ok {
+		return
+	}
+
+	organization, err := c.orgService.GetOrganization(id, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve organization")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, organization)
+}
+
+func (c *OrgController) UpdateOrganization(ctx *gin.Context) {
+	id, ok := orgEntityID(ctx)
+	if !ok {
+		return
+	}
+	var req model.UpdateOrgEntityRequest
+	if !bindOrgRequest(ctx, &req) {
+		return
+	}
+
+	organization, err := c.orgService.UpdateOrganization(id, &req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to update organization")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, organization)
+}
+
+func (c *OrgController) DeleteOrganization(ctx *gin.Context) {
+	id, ok := orgEntityID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.orgService.DeleteOrganization(id, ctx.MustGet("user_id").(uuid.UUID)); err != nil {
+		c.respondError(ctx, err, "Failed to delete organization")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Organization deleted"})
+}
+
+func (c *OrgController) GetTeams(ctx *gin.Context) {
+	id, ok := orgEntityID(ctx)
+	if !ok {
+		return
+	}
+
+	teams, err := c.orgService.GetTeams(id, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve teams")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"teams": teams})
+}
+
+func (c *OrgController) CreateTeam(ctx *gin.Context) {
+	id, ok := orgEntityID(ctx)
+	if !ok {
+		return
+	}
+	var req model.CreateOrgEntityRequest
+	if !bindOrgRequest(ctx, &req) {
+		return
+	}
+
+	team, err := c.orgService.CreateTeam(id, &req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to create team")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, team)
+}
+
+func (c *OrgController) GetTeam(ctx *gin.Context) {
+	id, ok := orgEntityID(ctx)
+	if !ok {
+		return
+	}
+
+	team, err := c.orgService.GetTeam(id, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve team")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, team)
+}
+
+func (c *OrgController) UpdateTeam(ctx *gin.Context) {
+	id, ok := orgEntityID(ctx)
+	if !ok {
+		return
+	}
+	var req model.UpdateOrgEntityRequest
+	if !bindOrgRequest(ctx, &req) {
+		return
+	}
+
+	team, err := c.orgService.UpdateTeam(id, &req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to update team")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, team)
+}
+
+func (c *OrgController) DeleteTeam(ctx *gin.Context) {
+	id, ok := orgEntityID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.orgService.DeleteTeam(id, ctx.MustGet("user_id").(uuid.UUID)); err != nil {
+		c.respondError(ctx, err, "Failed to delete team")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Team deleted"})
+}
+
+func (c *OrgController) GetProjects(ctx *gin.Context) {
+	id, ok := orgEntityID(ctx)
+	if !ok {
+		return
+	}
+
+	projects, err := c.orgService.GetProjects(id, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve projects")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"projects": projects})
+}
+
+func (c *OrgController) CreateProject(ctx *gin.Context) {
+	id, ok := orgEntityID(ctx)
+	if !ok {
+		return
+	}
+	var req model.CreateOrgEntityRequest
+	if !bindOrgRequest(ctx, &req) {
+		return
+	}
+
+	project, err := c.orgService.CreateProject(id, &req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to create project")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, project)
+}
+
+func (c *OrgController) GetProject(ctx *gin.Context) {
+	id, ok := orgEntityID(ctx)
+	if !ok {
+		return
+	}
+
+	project, err := c.orgService.GetProject(id, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve project")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, project)
+}
+
+func (c *OrgController) UpdateProject(ctx *gin.Context) {
+	id, ok := orgEntityID(ctx)
+	if !ok {
+		return
+	}
+	var req model.UpdateOrgEntityRequest
+	if !bindOrgRequest(ctx, &req) {
+		return
+	}
+
+	project, err := c.orgService.UpdateProject(id, &req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to update project")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, project)
+}
+
+func (c *OrgController) DeleteProject(ctx *gin.Context) {
+	id, ok := orgEntityID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.orgService.DeleteProject(id, ctx.MustGet("user_id").(uuid.UUID)); err != nil {
+		c.respondError(ctx, err, "Failed to delete project")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Project deleted"})
+}
+
+func (c *OrgController) GetOrganizationMembers(ctx *gin.Context) {
+	c.getMembers(ctx, model.OrgScopeOrganization)
+}
+
+func (c *OrgController) SetOrganizationMember(ctx *gin.Context) {
+	c.setMember(ctx, model.OrgScopeOrganization)
+}
+
+func (c *OrgController) RemoveOrganizationMember(ctx *gin.Context) {
+	c.removeMember(ctx, model.OrgScopeOrganization)
+}
+
+func (c *OrgController) GetTeamMembers(ctx *gin.Context) {
+	c.getMembers(ctx, model.OrgScopeTeam)
+}
+
+func (c *OrgController) SetTeamMember(ctx *gin.Context) {
+	c.setMember(ctx, model.OrgScopeTeam)
+}
+
+func (c *OrgController) RemoveTeamMember(ctx *gin.Context) {
+	c.removeMember(ctx, model.OrgScopeTeam)
+}
+
+func (c *OrgController) GetProjectMembers(ctx *gin.Context) {
+	c.getMembers(ctx, model.OrgScopeProject)
+}
+
+func (c *OrgController) SetProjectMember(ctx *gin.Context) {
+	c.setMember(ctx, model.OrgScopeProject)
+}
+
+func (c *OrgController) RemoveProjectMember(ctx *gin.Context) {
+	c.removeMember(ctx, model.OrgScopeProject)
+}
+
+func (c *OrgController) getMembers(ctx *gin.Context, scope model.OrgScope) {
+	id, ok := orgEntityID(ctx)
+	if !ok {
+		return
+	}
+
+	members, err := c.orgService.GetMembers(scope, id, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve members")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"members": members})
+}
+
+func (c *OrgController) setMember(ctx *gin.Context, scope model.OrgScope) {
+	id, ok := orgEntityID(ctx)
+	if !ok {
+		return
+	}
+	var req model.SetOrgMemberRequest
+	if !bindOrgRequest(ctx, &req) {
+		return
+	}
+
+	member, err := c.orgService.SetMember(scope, id, &req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to set member")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, member)
+}
+
+func (c *OrgController) removeMember(ctx *gin.Context, scope model.OrgScope) {
+	id, ok := orgEntityID(ctx)
+	if !ok {
+		return
+	}
+	memberID, err := uuid.Parse(ctx.Param("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_ID",
+				Message: "Invalid user ID",
+			},
+		})
+		return
+	}
+
+	if err := c.orgService.RemoveMember(scope, id, memberID, ctx.MustGet("user_id").(uuid.UUID)); err != nil {
+		c.respondError(ctx, err, "Failed to remove member")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Member removed"})
+}
+
+func orgEntityID(ctx *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_ID",
+				Message: "Invalid ID",
+			},
+		})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func bindOrgRequest(ctx *gin.Context, req interface{}) bool {
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return false
+	}
+	return true
+}
+
+func (c *OrgController) respondError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrOrganizationNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_ORGANIZATION_NOT_FOUND",
+				Message: "Organization not found",
+			},
+		})
+	case errors.Is(err, services.ErrTeamNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_TEAM_NOT_FOUND",
+				Message: "Team not found",
+			},
+		})
+	case errors.Is(err, services.ErrProjectNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_PROJECT_NOT_FOUND",
+				Message: "Project not found",
+			},
+		})
+	case errors.Is(err, services.ErrOrgMemberNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_MEMBER_NOT_FOUND",
+				Message: "Member not found",
+			},
+		})
+	case errors.Is(err, services.ErrUserNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_USER_NOT_FOUND",
+				Message: "User not found",
+			},
+		})
+	case errors.Is(err, services.ErrOrgRoleRequired):
+		ctx.JSON(http.StatusForbidden, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_FORBIDDEN",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrOrgNameTaken):
+		ctx.JSON(http.StatusConflict, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_ORG_NAME_EXISTS",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrOrgNotEmpty):
+		ctx.JSON(http.StatusConflict, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_ORG_NOT_EMPTY",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrOrgLastOwner):
+		ctx.JSON(http.StatusConflict, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_ORG_LAST_OWNER",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrOrgInvalidName), errors.Is(err, services.ErrOrgInvalidRole):
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: message,
+			},
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type PasswordPolicyController struct {
+	passwordPolicy *services.PasswordPolicyService
+}
+
+func NewPasswordPolicyController(passwordPolicy *services.PasswordPolicyService) *PasswordPolicyController {
+	return &PasswordPolicyController{
+		passwordPolicy: passwordPolicy,
+	}
+}
+
+// Test reports how a candidate password fares against the password
+// policy without storing it
+func (c *PasswordPolicyController) Test(ctx *gin.Context) {
+	var req model.PasswordPolicyTestRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	result := c.passwordPolicy.Test(req.Password, []string{req.Email, req.FirstName, req.LastName})
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, result)
+}
+
+// respondPasswordPolicy answers a password the policy rejects with every
+// rule it breaks, and reports whether err was one
+func respondPasswordPolicy(ctx *gin.Context, err error) bool {
+	var violation *services.PasswordPolicyError
+	if !errors.As(err, &violation) {
+		return false
+	}
+
+	ctx.JSON(http.StatusUnprocessableEntity, model.PasswordPolicyViolationResponse{
+		Error: model.ErrorDetail{
+			Code:    "VAULT_PASSWORD_POLICY",
+			Message: violation.Error(),
+		},
+		Violations: violation.Violations,
+	})
+	return true
+}
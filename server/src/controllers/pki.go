@@ -0,0 +1,295 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type PKIController struct {
+	pkiService *services.PKIService
+}
+
+func NewPKIController(pkiService *services.PKIService) *PKIController {
+	return &PKIController{
+		pkiService: pkiService,
+	}
+}
+
+// CreateRoot generates a self-signed root CA
+func (c *PKIController) CreateRoot(ctx *gin.Context) {
+	c.createIssuer(ctx, c.pkiService.CreateRoot)
+}
+
+// CreateIntermediate generates an intermediate CA signed by another issuer
+func (c *PKIController) CreateIntermediate(ctx *gin.Context) {
+	c.createIssuer(ctx, c.pkiService.CreateIntermediate)
+}
+
+func (c *PKIController) createIssuer(ctx *gin.Context, create func(*model.CreatePKIIssuerRequest, uuid.UUID) (*model.PKIIssuer, error)) {
+	var req model.CreatePKIIssuerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+	if c.pkiService == nil {
+		c.respondError(ctx, services.ErrPKIUnavailable, "")
+		return
+	}
+
+	issuer, err := create(&req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to create PKI issuer")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, issuer)
+}
+
+func (c *PKIController) GetIssuers(ctx *gin.Context) {
+	if c.pkiService == nil {
+		c.respondError(ctx, services.ErrPKIUnavailable, "")
+		return
+	}
+
+	issuers, err := c.pkiService.GetIssuers()
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve PKI issuers")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"issuers": issuers})
+}
+
+// GetIssuerPEM serves the issuer's certificate chain so that clients can
+// trust it
+func (c *PKIController) GetIssuerPEM(ctx *gin.Context) {
+	if c.pkiService == nil {
+		c.respondError(ctx, services.ErrPKIUnavailable, "")
+		return
+	}
+
+	chain, err := c.pkiService.IssuerPEM(ctx.Param("name"))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve issuer certificate")
+		return
+	}
+
+	ctx.Data(http.StatusOK, "application/x-pem-file", []byte(chain))
+}
+
+// GetCRL serves the issuer's DER-encoded certificate revocation list
+func (c *PKIController) GetCRL(ctx *gin.Context) {
+	if c.pkiService == nil {
+		c.respondError(ctx, services.ErrPKIUnavailable, "")
+		return
+	}
+
+	crl, err := c.pkiService.CRL(ctx.Param("name"))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve CRL")
+		return
+	}
+
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.Data(http.StatusOK, "application/pkix-crl", crl)
+}
+
+func (c *PKIController) GetRoles(ctx *gin.Context) {
+	if c.pkiService == nil {
+		c.respondError(ctx, services.ErrPKIUnavailable, "")
+		return
+	}
+
+	roles, err := c.pkiService.GetRoles()
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve PKI roles")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"roles": roles})
+}
+
+func (c *PKIController) CreateRole(ctx *gin.Context) {
+	var req model.CreatePKIRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+	if c.pkiService == nil {
+		c.respondError(ctx, services.ErrPKIUnavailable, "")
+		return
+	}
+
+	role, err := c.pkiService.CreateRole(&req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to create PKI role")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, role)
+}
+
+func (c *PKIController) DeleteRole(ctx *gin.Context) {
+	if c.pkiService == nil {
+		c.respondError(ctx, services.ErrPKIUnavailable, "")
+		return
+	}
+
+	if err := c.pkiService.DeleteRole(ctx.Param("name"), ctx.MustGet("user_id").(uuid.UUID)); err != nil {
+		c.respondError(ctx, err, "Failed to delete PKI role")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// Issue returns a new certificate and private key under a role
+func (c *PKIController) Issue(ctx *gin.Context) {
+	var req model.IssueCertificateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+	if c.pkiService == nil {
+		c.respondError(ctx, services.ErrPKIUnavailable, "")
+		return
+	}
+
+	response, err := c.pkiService.Issue(ctx.Param("role"), &req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to issue certificate")
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *PKIController) Revoke(ctx *gin.Context) {
+	var req model.RevokeCertificateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+	if c.pkiService == nil {
+		c.respondError(ctx, services.ErrPKIUnavailable, "")
+		return
+	}
+
+	certificate, err := c.pkiService.Revoke(req.SerialNumber, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to revoke certificate")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, certificate)
+}
+
+func (c *PKIController) respondError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrPKIIssuerNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_PKI_ISSUER_NOT_FOUND",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrPKIRoleNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_PKI_ROLE_NOT_FOUND",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrPKICertificateNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_CERTIFICATE_NOT_FOUND",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrPKIIssuerExists), errors.Is(err, services.ErrPKIRoleExists):
+		ctx.JSON(http.StatusConflict, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_PKI_CONFLICT",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrPKIIssuerExpired):
+		ctx.JSON(http.StatusConflict, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_PKI_ISSUER_EXPIRED",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrPKIInvalidIssuer), errors.Is(err, services.ErrPKIInvalidRole), errors.Is(err, services.ErrPKIInvalidRequest):
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrPKINameNotAllowed):
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_PKI_NAME_NOT_ALLOWED",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrPKIIssueDenied):
+		ctx.JSON(http.StatusForbidden, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_ACCESS_DENIED",
+				Message: "Access denied: requires create on " + services.PKIIssuePolicy + "/" + ctx.Param("role"),
+				Path:    services.PKIIssuePolicy + "/" + ctx.Param("role"),
+				Action:  "create",
+			},
+		})
+	case errors.Is(err, services.ErrUserNotFound):
+		ctx.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_UNAUTHORIZED",
+				Message: "Unauthorized",
+			},
+		})
+	case errors.Is(err, services.ErrPKIUnavailable):
+		ctx.JSON(http.StatusServiceUnavailable, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_SERVICE_UNAVAILABLE",
+				Message: err.Error(),
+			},
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: message,
+			},
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type PolicyController struct {
+	policyService *services.PolicyService
+}
+
+func NewPolicyController(policyService *services.PolicyService) *PolicyController {
+	return &PolicyController{
+		policyService: policyService,
+	}
+}
+
+func (c *PolicyController) GetPolicyTemplates(ctx *gin.Context) {
+	templates, err := c.policyService.GetPolicyTemplates()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: "Failed to retrieve policy templates",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"templates": templates})
+}
+
+func (c *PolicyController) CreatePolicyTemplate(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_UNAUTHORIZED",
+				Message: "Unauthorized",
+			},
+		})
+		return
+	}
+
+	var req model.CreatePolicyTemplateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	template, err := c.policyService.CreatePolicyTemplate(&req, userID.(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to create policy template")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, template)
+}
+
+func (c *PolicyController) InstantiatePolicyTemplate(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_UNAUTHORIZED",
+				Message: "Unauthorized",
+			},
+		})
+		return
+	}
+
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_ID",
+				Message: "Invalid policy template ID",
+			},
+		})
+		return
+	}
+
+	var req model.InstantiatePolicyTemplateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	policy, err := c.policyService.InstantiatePolicyTemplate(id, &req, userID.(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to instantiate policy template")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, policy)
+}
+
+func (c *PolicyController) AttachPolicy(ctx *gin.Context) {
+	c.bulkAssign(ctx, c.policyService.AttachPolicy)
+}
+
+func (c *PolicyController) DetachPolicy(ctx *gin.Context) {
+	c.bulkAssign(ctx, c.policyService.DetachPolicy)
+}
+
+func (c *PolicyController) bulkAssign(ctx *gin.Context, apply func(uuid.UUID, []uuid.UUID, uuid.UUID) (int64, error)) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_UNAUTHORIZED",
+				Message: "Unauthorized",
+			},
+		})
+		return
+	}
+
+	policyID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_ID",
+				Message: "Invalid policy ID",
+			},
+		})
+		return
+	}
+
+	var req model.BulkPolicyAssignmentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	changed, err := apply(policyID, req.UserIDs, userID.(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to update policy assignments")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.BulkPolicyAssignmentResponse{
+		PolicyID: policyID,
+		Changed:  changed,
+	})
+}
+
+func (c *PolicyController) respondError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrPolicyNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_POLICY_NOT_FOUND",
+				Message: "Policy not found",
+			},
+		})
+	case errors.Is(err, services.ErrPolicyTemplateNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_POLICY_TEMPLATE_NOT_FOUND",
+				Message: "Policy template not found",
+			},
+		})
+	case errors.Is(err, services.ErrPolicyAssigneeNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_USER_NOT_FOUND",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrPolicyTemplateInvalid):
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_POLICY_TEMPLATE",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrPolicyRulesInvalid), errors.Is(err, services.ErrPolicyCanaryWindow):
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrPolicyCanaryNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_POLICY_CANARY_NOT_FOUND",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrPolicyCanaryRunning):
+		ctx.JSON(http.StatusConflict, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_POLICY_CANARY_RUNNING",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrPolicyCanaryUnavailable):
+		ctx.JSON(http.StatusServiceUnavailable, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_SERVICE_UNAVAILABLE",
+				Message: err.Error(),
+			},
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: message,
+			},
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+)
+
+func (c *PolicyController) StartPolicyCanary(ctx *gin.Context) {
+	userID, policyID, ok := c.canaryParams(ctx)
+	if !ok {
+		return
+	}
+
+	var req model.StartPolicyCanaryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	canary, err := c.policyService.StartCanary(policyID, &req, userID)
+	if err != nil {
+		c.respondError(ctx, err, "Failed to start policy canary")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, canary)
+}
+
+func (c *PolicyController) GetPolicyCanary(ctx *gin.Context) {
+	_, policyID, ok := c.canaryParams(ctx)
+	if !ok {
+		return
+	}
+
+	report, err := c.policyService.GetCanaryReport(policyID)
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve policy canary")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
+
+func (c *PolicyController) PromotePolicyCanary(ctx *gin.Context) {
+	userID, policyID, ok := c.canaryParams(ctx)
+	if !ok {
+		return
+	}
+
+	canary, err := c.policyService.PromoteCanary(policyID, userID)
+	if err != nil {
+		c.respondError(ctx, err, "Failed to promote policy canary")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, canary)
+}
+
+func (c *PolicyController) CancelPolicyCanary(ctx *gin.Context) {
+	userID, policyID, ok := c.canaryParams(ctx)
+	if !ok {
+		return
+	}
+
+	canary, err := c.policyService.CancelCanary(policyID, userID)
+	if err != nil {
+		c.respondError(ctx, err, "Failed to cancel policy canary")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, canary)
+}
+
+// canaryParams reads the caller and the policy ID, answering the request
+// itself when either is missing
+func (c *PolicyController) canaryParams(ctx *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_UNAUTHORIZED",
+				Message: "Unauthorized",
+			},
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	policyID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_ID",
+				Message: "Invalid policy ID",
+			},
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID.(uuid.UUID), policyID, true
+}
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+// ProviderController serves the stable endpoints that the Terraform
+// provider and Ansible lookup plugin are written against. Changes here
+// must keep model.ProviderContractVersion's contract.
+type ProviderController struct {
+	providerService *services.ProviderService
+}
+
+func NewProviderController(providerService *services.ProviderService) *ProviderController {
+	return &ProviderController{
+		providerService: providerService,
+	}
+}
+
+func (c *ProviderController) GetContract(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, model.ProviderContractResponse{
+		Version:         model.ProviderContractVersion,
+		Resources:       []string{"secret"},
+		ImportIDFormats: []string{"<secret id>", "<name>", "<namespace id>:<name>"},
+	})
+}
+
+// UpsertSecret answers 201 when the secret was created and 200 otherwise
+func (c *ProviderController) UpsertSecret(ctx *gin.Context) {
+	var req model.ProviderSecretRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	response, err := c.providerService.UpsertSecret(&req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to upsert secret")
+		return
+	}
+
+	status := http.StatusOK
+	if response.Created {
+		status = http.StatusCreated
+	}
+	ctx.JSON(status, response)
+}
+
+func (c *ProviderController) ReadSecret(ctx *gin.Context) {
+	includeValue := ctx.Query("include_value") == "true"
+
+	response, err := c.providerService.ReadSecret(importID(ctx), includeValue, ctx.MustGet("user_id").(uuid.UUID), tokenAccessor(ctx))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve secret")
+		return
+	}
+
+	if includeValue {
+		ctx.Header("Cache-Control", "no-store")
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *ProviderController) DeleteSecret(ctx *gin.Context) {
+	if err := c.providerService.DeleteSecret(importID(ctx), ctx.MustGet("user_id").(uuid.UUID)); err != nil {
+		c.respondError(ctx, err, "Failed to delete secret")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (c *ProviderController) respondError(ctx *gin.Context, err error, message string) {
+	if respondTemplateViolation(ctx, err) || respondTenantKeyError(ctx, err) || respondSecretLabelError(ctx, err) || respondSecretCheckoutRequired(ctx, err) || respondStepUpRequired(ctx, err) || respondControlGroupPending(ctx, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, services.ErrSecretNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_SECRET_NOT_FOUND",
+				Message: "Secret not found",
+			},
+		})
+	case errors.Is(err, services.ErrNamespaceNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_NAMESPACE_NOT_FOUND",
+				Message: "Namespace not found",
+			},
+		})
+	case errors.Is(err, services.ErrProviderSecretAmbiguous):
+		ctx.JSON(http.StatusConflict, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_SECRET_AMBIGUOUS",
+				Message: err.Error(),
+			},
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: message,
+			},
+		})
+	}
+}
+
+// importID reads the catch-all import ID parameter, which lets secret
+// names contain slashes
+func importID(ctx *gin.Context) string {
+	return strings.TrimPrefix(ctx.Param("import_id"), "/")
+}
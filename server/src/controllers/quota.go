@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+const defaultUsageHistoryDays = 7
+
+type QuotaController struct {
+	quotaService *services.QuotaService
+}
+
+func NewQuotaController(quotaService *services.QuotaService) *QuotaController {
+	return &QuotaController{
+		quotaService: quotaService,
+	}
+}
+
+// GetDashboard reports every namespace's usage against its quotas
+func (c *QuotaController) GetDashboard(ctx *gin.Context) {
+	dashboard, err := c.quotaService.Dashboard()
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve namespace usage")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"namespaces": dashboard})
+}
+
+// GetUsage reports one namespace's usage against its quotas
+func (c *QuotaController) GetUsage(ctx *gin.Context) {
+	namespaceID, ok := c.namespaceID(ctx)
+	if !ok {
+		return
+	}
+
+	usage, err := c.quotaService.Usage(namespaceID)
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve namespace usage")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, usage)
+}
+
+// GetHistory returns the usage samples of the last ?days= days
+func (c *QuotaController) GetHistory(ctx *gin.Context) {
+	namespaceID, ok := c.namespaceID(ctx)
+	if !ok {
+		return
+	}
+	days, err := strconv.Atoi(ctx.DefaultQuery("days", strconv.Itoa(defaultUsageHistoryDays)))
+	if err != nil || days < 1 {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "days must be a positive number",
+			},
+		})
+		return
+	}
+
+	history, err := c.quotaService.History(namespaceID, days)
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve namespace usage history")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, history)
+}
+
+func (c *QuotaController) GetQuota(ctx *gin.Context) {
+	namespaceID, ok := c.namespaceID(ctx)
+	if !ok {
+		return
+	}
+
+	quota, err := c.quotaService.GetQuota(namespaceID)
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve namespace quota")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, quota)
+}
+
+func (c *QuotaController) SetQuota(ctx *gin.Context) {
+	namespaceID, ok := c.namespaceID(ctx)
+	if !ok {
+		return
+	}
+
+	var req model.SetNamespaceQuotaRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	quota, err := c.quotaService.SetQuota(namespaceID, &req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to set namespace quota")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, quota)
+}
+
+func (c *QuotaController) DeleteQuota(ctx *gin.Context) {
+	namespaceID, ok := c.namespaceID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.quotaService.DeleteQuota(namespaceID, ctx.MustGet("user_id").(uuid.UUID)); err != nil {
+		c.respondError(ctx, err, "Failed to delete namespace quota")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (c *QuotaController) namespaceID(ctx *gin.Context) (uuid.UUID, bool) {
+	namespaceID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_ID",
+				Message: "Invalid namespace ID",
+			},
+		})
+		return uuid.Nil, false
+	}
+	return namespaceID, true
+}
+
+func (c *QuotaController) respondError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrNamespaceNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_NAMESPACE_NOT_FOUND",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrQuotaNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_QUOTA_NOT_FOUND",
+				Message: err.Error(),
+			},
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: message,
+			},
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type ReminderController struct {
+	reminderService *services.ReminderService
+}
+
+func NewReminderController(reminderService *services.ReminderService) *ReminderController {
+	return &ReminderController{
+		reminderService: reminderService,
+	}
+}
+
+func (c *ReminderController) GetReminders(ctx *gin.Context) {
+	status := model.ReminderStatus(ctx.Query("status"))
+	switch status {
+	case "", model.ReminderStatusOpen, model.ReminderStatusAcknowledged, model.ReminderStatusResolved:
+	default:
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "status must be open, acknowledged or resolved",
+			},
+		})
+		return
+	}
+
+	reminders, err := c.reminderService.GetReminders(ctx.MustGet("user_id").(uuid.UUID), status)
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve reminders")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"reminders": reminders})
+}
+
+func (c *ReminderController) Acknowledge(ctx *gin.Context) {
+	id, ok := c.reminderID(ctx)
+	if !ok {
+		return
+	}
+
+	reminder, err := c.reminderService.Acknowledge(id, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to acknowledge reminder")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, reminder)
+}
+
+func (c *ReminderController) Snooze(ctx *gin.Context) {
+	id, ok := c.reminderID(ctx)
+	if !ok {
+		return
+	}
+
+	var req model.SnoozeReminderRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "hours must be between 1 and 8760",
+			},
+		})
+		return
+	}
+
+	reminder, err := c.reminderService.Snooze(id, req.Hours, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to snooze reminder")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, reminder)
+}
+
+// Scan runs the reminder scheduler once, outside its regular interval
+func (c *ReminderController) Scan(ctx *gin.Context) {
+	notified, err := c.reminderService.Scan(time.Now())
+	if err != nil {
+		c.respondError(ctx, err, "Failed to scan for reminders")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.ReminderScanResponse{Notified: notified})
+}
+
+func (c *ReminderController) GetPolicies(ctx *gin.Context) {
+	policies, err := c.reminderService.GetPolicies()
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve reminder policies")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"policies": policies})
+}
+
+func (c *ReminderController) CreatePolicy(ctx *gin.Context) {
+	var req model.CreateReminderPolicyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	policy, err := c.reminderService.CreatePolicy(&req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to create reminder policy")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, policy)
+}
+
+func (c *ReminderController) DeletePolicy(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_ID",
+				Message: "Invalid reminder policy ID",
+			},
+		})
+		return
+	}
+
+	if err := c.reminderService.DeletePolicy(id, ctx.MustGet("user_id").(uuid.UUID)); err != nil {
+		c.respondError(ctx, err, "Failed to delete reminder policy")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Reminder policy deleted successfully"})
+}
+
+func (c *ReminderController) reminderID(ctx *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_ID",
+				Message: "Invalid reminder ID",
+			},
+		})
+		return uuid.Nil, false
+	}
+
+	return id, true
+}
+
+func (c *ReminderController) respondError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrReminderNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_REMINDER_NOT_FOUND",
+				Message: "Reminder not found",
+			},
+		})
+	case errors.Is(err, services.ErrReminderPolicyNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_REMINDER_POLICY_NOT_FOUND",
+				Message: "Reminder policy not found",
+			},
+		})
+	case errors.Is(err, services.ErrReminderClosed), errors.Is(err, services.ErrReminderPolicyExists):
+		ctx.JSON(http.StatusConflict, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_REMINDER_CONFLICT",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrReminderPolicyInvalid):
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: message,
+			},
+		})
+	}
+}
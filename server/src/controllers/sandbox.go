@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type SandboxController struct {
+	sandboxService *services.SandboxService
+}
+
+func NewSandboxController(sandboxService *services.SandboxService) *SandboxController {
+	return &SandboxController{
+		sandboxService: sandboxService,
+	}
+}
+
+// CreateSandbox clones the namespace into a new sandbox
+func (c *SandboxController) CreateSandbox(ctx *gin.Context) {
+	namespaceID, ok := c.namespaceID(ctx)
+	if !ok {
+		return
+	}
+
+	var req model.CreateSandboxRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	sandbox, err := c.sandboxService.CreateSandbox(namespaceID, &req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to create sandbox")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, sandbox)
+}
+
+// GetSandboxes lists the namespace's live sandboxes
+func (c *SandboxController) GetSandboxes(ctx *gin.Context) {
+	namespaceID, ok := c.namespaceID(ctx)
+	if !ok {
+		return
+	}
+
+	sandboxes, err := c.sandboxService.GetSandboxes(namespaceID)
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve sandboxes")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"sandboxes": sandboxes})
+}
+
+// DestroySandbox removes the sandbox named by :id ahead of its expiry
+func (c *SandboxController) DestroySandbox(ctx *gin.Context) {
+	namespaceID, ok := c.namespaceID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.sandboxService.DestroySandbox(namespaceID, ctx.MustGet("user_id").(uuid.UUID)); err != nil {
+		c.respondError(ctx, err, "Failed to destroy sandbox")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (c *SandboxController) namespaceID(ctx *gin.Context) (uuid.UUID, bool) {
+	namespaceID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_ID",
+				Message: "Invalid namespace ID",
+			},
+		})
+		return uuid.Nil, false
+	}
+	return namespaceID, true
+}
+
+func (c *SandboxController) respondError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrNamespaceNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_NAMESPACE_NOT_FOUND",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrSandboxNameTaken), errors.Is(err, services.ErrMountExists):
+		ctx.JSON(http.StatusConflict, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_NAMESPACE_EXISTS",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrSandboxNested), errors.Is(err, services.ErrSandboxTTLTooLong),
+		errors.Is(err, services.ErrSandboxInvalidValues), errors.Is(err, services.ErrNamespaceInvalidName),
+		errors.Is(err, services.ErrNotSandbox):
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: message,
+			},
+		})
+	}
+}
@@ -1,9 +1,14 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
 	"github.com/skygenesisenterprise/aether-vault/server/src/model"
 	"github.com/skygenesisenterprise/aether-vault/server/src/services"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -31,8 +36,12 @@ func (c *SecretController) GetSecrets(ctx *gin.Context) {
 		return
 	}
 
-	secrets, err := c.secretService.GetSecretsByUserID(userID.(uuid.UUID))
+	// ?tag=team:payments&tag=env:* lists the secrets carrying every tag
+	secrets, err := c.secretService.GetSecretsByUserID(userID.(uuid.UUID), mountPath(ctx), ctx.QueryArray("tag"))
 	if err != nil {
+		if respondTenantKeyError(ctx, err) {
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
 			Error: model.ErrorDetail{
 				Code:    "VAULT_INTERNAL_ERROR",
@@ -69,8 +78,11 @@ func (c *SecretController) GetSecret(ctx *gin.Context) {
 		return
 	}
 
-	secret, err := c.secretService.GetSecretByID(id, userID.(uuid.UUID))
+	secret, err := c.secretService.GetSecretByID(id, userID.(uuid.UUID), tokenAccessor(ctx))
 	if err != nil {
+		if respondTenantKeyError(ctx, err) || respondSecretCheckoutRequired(ctx, err) || respondStepUpRequired(ctx, err) || respondControlGroupPending(ctx, err) {
+			return
+		}
 		if err == services.ErrSecretNotFound {
 			ctx.JSON(http.StatusNotFound, model.ErrorResponse{
 				Error: model.ErrorDetail{
@@ -92,6 +104,280 @@ func (c *SecretController) GetSecret(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, secret)
 }
 
+// GetSecretValue returns the decrypted value of a secret. Secret
+// responses never carry values; this endpoint exists for clients such as
+// the agent proxy that inject the value on behalf of an application.
+// Namespace readers other than the owner get the fields they may read.
+// lease=true, or lease_ttl=<seconds>, returns a lease with the value.
+func (c *SecretController) GetSecretValue(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_ID",
+				Message: "Invalid secret ID",
+			},
+		})
+		return
+	}
+
+	wantLease := ctx.Query("lease") == "true"
+	var leaseTTL int
+	if value := ctx.Query("lease_ttl"); value != "" {
+		leaseTTL, err = strconv.Atoi(value)
+		if err != nil || leaseTTL < 0 {
+			ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INVALID_REQUEST",
+					Message: "lease_ttl must be a number of seconds",
+				},
+			})
+			return
+		}
+		wantLease = true
+	}
+
+	userID := ctx.MustGet("user_id").(uuid.UUID)
+	secret, err := c.secretService.ReadSecretValue(id, userID, tokenAccessor(ctx))
+	if err != nil {
+		if respondTenantKeyError(ctx, err) || respondSecretFieldError(ctx, err) || respondSecretCheckoutRequired(ctx, err) || respondStepUpRequired(ctx, err) || respondControlGroupPending(ctx, err) {
+			return
+		}
+		if err == services.ErrSecretNotFound {
+			ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_SECRET_NOT_FOUND",
+					Message: "Secret not found",
+				},
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: "Failed to retrieve secret",
+			},
+		})
+		return
+	}
+
+	response := model.SecretValueResponse{
+		ID:              secret.ID,
+		Name:            secret.Name,
+		Value:           secret.Value,
+		ExpiresAt:       secret.ExpiresAt,
+		ClientEncrypted: secret.ClientEncrypted,
+		WithheldFields:  secret.WithheldFields,
+	}
+	if wantLease {
+		lease, err := c.secretService.LeaseRead(secret, userID, leaseTTL)
+		if err != nil {
+			if errors.Is(err, services.ErrMountTTLExceeded) || errors.Is(err, services.ErrMountTTLInvalid) {
+				ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+					Error: model.ErrorDetail{
+						Code:    "VAULT_INVALID_REQUEST",
+						Message: err.Error(),
+					},
+				})
+				return
+			}
+			ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INTERNAL_ERROR",
+					Message: "Failed to lease secret",
+				},
+			})
+			return
+		}
+		response.Lease = lease.Info(time.Now())
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetBundle returns the secrets under a name prefix as one key/value
+// bundle. The revision doubles as ETag: a matching If-None-Match gets 304,
+// and since=<revision> returns only what changed after it.
+func (c *SecretController) GetBundle(ctx *gin.Context) {
+	var since int64
+	if value := ctx.Query("since"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || parsed < 0 {
+			ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INVALID_REQUEST",
+					Message: "since must be a revision returned by a previous bundle",
+				},
+			})
+			return
+		}
+		since = parsed
+	}
+
+	bundle, err := c.secretService.GetBundle(ctx.MustGet("user_id").(uuid.UUID), mountPath(ctx), ctx.Query("prefix"), since, tokenAccessor(ctx))
+	if err != nil {
+		if respondTenantKeyError(ctx, err) || respondStepUpRequired(ctx, err) || respondControlGroupPending(ctx, err) {
+			return
+		}
+		if errors.Is(err, services.ErrBundlePrefixRequired) {
+			ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INVALID_REQUEST",
+					Message: "prefix is required",
+				},
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: "Failed to retrieve secret bundle",
+			},
+		})
+		return
+	}
+
+	etag := fmt.Sprintf(`"%d"`, bundle.Revision)
+	ctx.Header("ETag", etag)
+	ctx.Header("Cache-Control", "no-store")
+	if match := ctx.GetHeader("If-None-Match"); match != "" && bundle.Revision > 0 && strings.TrimPrefix(match, "W/") == etag {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+	ctx.JSON(http.StatusOK, bundle)
+}
+
+// GetKeys lists the keys below ?prefix=, one level down unless ?depth=
+// asks for more; ?recursive=true lists every secret in full
+func (c *SecretController) GetKeys(ctx *gin.Context) {
+	depth := 1
+	if value := ctx.Query("depth"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INVALID_REQUEST",
+					Message: "depth must be a number of path segments",
+				},
+			})
+			return
+		}
+		depth = parsed
+	}
+	if ctx.Query("recursive") == "true" {
+		depth = 0
+	}
+
+	keys, err := c.secretService.ListKeys(ctx.MustGet("user_id").(uuid.UUID), mountPath(ctx), ctx.Query("prefix"), depth)
+	if err != nil {
+		if respondSecretLabelError(ctx, err) {
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: "Failed to list secret keys",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, keys)
+}
+
+// defaultExpiringWindow is how far ahead GET /secrets/expiring looks
+// unless ?within= says otherwise
+const defaultExpiringWindow = 7 * 24 * time.Hour
+
+// GetExpiring lists the secrets that expire or are due for rotation
+// within ?within= seconds, a week by default
+func (c *SecretController) GetExpiring(ctx *gin.Context) {
+	window := defaultExpiringWindow
+	if value := ctx.Query("within"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds < 0 {
+			ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INVALID_REQUEST",
+					Message: "within must be a number of seconds",
+				},
+			})
+			return
+		}
+		window = time.Duration(seconds) * time.Second
+	}
+
+	expiring, err := c.secretService.GetExpiring(ctx.MustGet("user_id").(uuid.UUID), mountPath(ctx), window)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: "Failed to list expiring secrets",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"secrets": expiring})
+}
+
+// GetConnectionString renders a database secret as a ready-to-use
+// connection string for the requested driver
+func (c *SecretController) GetConnectionString(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_ID",
+				Message: "Invalid secret ID",
+			},
+		})
+		return
+	}
+
+	response, err := c.secretService.RenderConnectionString(id, ctx.MustGet("user_id").(uuid.UUID), tokenAccessor(ctx), ctx.Query("driver"), ctx.Query("format"))
+	if err != nil {
+		if respondTenantKeyError(ctx, err) || respondSecretCheckoutRequired(ctx, err) || respondStepUpRequired(ctx, err) || respondControlGroupPending(ctx, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrSecretNotFound):
+			ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_SECRET_NOT_FOUND",
+					Message: "Secret not found",
+				},
+			})
+		case errors.Is(err, services.ErrConnectionSecretInvalid):
+			ctx.JSON(http.StatusUnprocessableEntity, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_SECRET_NOT_DATABASE",
+					Message: err.Error(),
+				},
+			})
+		case errors.Is(err, services.ErrConnectionDriverInvalid), errors.Is(err, services.ErrConnectionFormatInvalid):
+			ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INVALID_REQUEST",
+					Message: err.Error(),
+				},
+			})
+		default:
+			ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INTERNAL_ERROR",
+					Message: "Failed to render connection string",
+				},
+			})
+		}
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, response)
+}
+
 func (c *SecretController) CreateSecret(ctx *gin.Context) {
 	userID, exists := ctx.Get("user_id")
 	if !exists {
@@ -116,16 +402,39 @@ func (c *SecretController) CreateSecret(ctx *gin.Context) {
 	}
 
 	secret := &model.Secret{
-		Name:        req.Name,
-		Description: req.Description,
-		Value:       req.Value,
-		Type:        req.Type,
-		Tags:        req.Tags,
-		ExpiresAt:   req.ExpiresAt,
-		IsActive:    true,
+		Name:             req.Name,
+		Description:      req.Description,
+		Value:            req.Value,
+		Type:             req.Type,
+		Tags:             req.Tags,
+		Metadata:         req.Metadata,
+		ExpiresAt:        req.ExpiresAt,
+		RequestedTTL:     req.TTL,
+		NamespaceID:      req.NamespaceID,
+		ProjectID:        req.ProjectID,
+		Mount:            mountPath(ctx),
+		IsActive:         true,
+		ClientEncrypted:  req.ClientEncrypted,
+		MaxVersions:      req.MaxVersions,
+		RotationPeriod:   req.RotationPeriod,
+		Rotator:          req.Rotator,
+		CheckoutRequired: req.CheckoutRequired,
+		Fields:           req.Fields,
 	}
 
 	if err := c.secretService.CreateSecret(secret, userID.(uuid.UUID)); err != nil {
+		if respondTemplateViolation(ctx, err) || respondTenantKeyError(ctx, err) || respondMountError(ctx, err) || respondSecretFieldError(ctx, err) || respondSecretLabelError(ctx, err) {
+			return
+		}
+		if errors.Is(err, services.ErrNamespaceNotFound) {
+			ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_NAMESPACE_NOT_FOUND",
+					Message: "Namespace not found",
+				},
+			})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
 			Error: model.ErrorDetail{
 				Code:    "VAULT_INTERNAL_ERROR",
@@ -135,6 +444,11 @@ func (c *SecretController) CreateSecret(ctx *gin.Context) {
 		return
 	}
 
+	// A write held for validators is accepted but not yet in effect
+	if secret.Validation != nil {
+		ctx.JSON(http.StatusAccepted, secret)
+		return
+	}
 	ctx.JSON(http.StatusCreated, secret)
 }
 
@@ -175,6 +489,9 @@ func (c *SecretController) UpdateSecret(ctx *gin.Context) {
 
 	secret, err := c.secretService.UpdateSecret(id, &req, userID.(uuid.UUID))
 	if err != nil {
+		if respondTemplateViolation(ctx, err) || respondTenantKeyError(ctx, err) || respondMountError(ctx, err) || respondPublicationError(ctx, err) || respondSecretFieldError(ctx, err) || respondSecretLabelError(ctx, err) {
+			return
+		}
 		if err == services.ErrSecretNotFound {
 			ctx.JSON(http.StatusNotFound, model.ErrorResponse{
 				Error: model.ErrorDetail{
@@ -193,6 +510,10 @@ func (c *SecretController) UpdateSecret(ctx *gin.Context) {
 		return
 	}
 
+	if secret.Validation != nil || secret.Publication != nil {
+		ctx.JSON(http.StatusAccepted, secret)
+		return
+	}
 	ctx.JSON(http.StatusOK, secret)
 }
 
@@ -241,3 +562,219 @@ func (c *SecretController) DeleteSecret(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"message": "Secret deleted successfully"})
 }
+
+// GetValidations reports the validator runs of writes to a secret
+func (c *SecretController) GetValidations(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_ID",
+				Message: "Invalid secret ID",
+			},
+		})
+		return
+	}
+
+	validations, err := c.secretService.GetValidations(id, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		if errors.Is(err, services.ErrSecretNotFound) {
+			ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_SECRET_NOT_FOUND",
+					Message: "Secret not found",
+				},
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: "Failed to retrieve validations",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"validations": validations})
+}
+
+// GetVersions lists the previous values a versioned mount kept
+func (c *SecretController) GetVersions(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_ID",
+				Message: "Invalid secret ID",
+			},
+		})
+		return
+	}
+
+	versions, err := c.secretService.GetVersions(id, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondVersionError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"versions": versions})
+}
+
+// GetVersionValue returns one previous value of a secret
+func (c *SecretController) GetVersionValue(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_ID",
+				Message: "Invalid secret ID",
+			},
+		})
+		return
+	}
+	version, err := strconv.Atoi(ctx.Param("version"))
+	if err != nil || version < 1 {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid version",
+			},
+		})
+		return
+	}
+
+	value, err := c.secretService.GetVersionValue(id, version, ctx.MustGet("user_id").(uuid.UUID), tokenAccessor(ctx))
+	if err != nil {
+		c.respondVersionError(ctx, err)
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, value)
+}
+
+// RollbackSecret makes a previous version the current value again
+func (c *SecretController) RollbackSecret(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_ID",
+				Message: "Invalid secret ID",
+			},
+		})
+		return
+	}
+	version, err := strconv.Atoi(ctx.Param("version"))
+	if err != nil || version < 1 {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid version",
+			},
+		})
+		return
+	}
+
+	rollback, err := c.secretService.RollbackSecret(id, version, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		if respondTemplateViolation(ctx, err) || respondMountError(ctx, err) {
+			return
+		}
+		c.respondVersionError(ctx, err)
+		return
+	}
+
+	// Like any update, a value under a validated path waits for its checks
+	if rollback.Validation != nil {
+		ctx.JSON(http.StatusAccepted, rollback)
+		return
+	}
+	ctx.JSON(http.StatusOK, rollback)
+}
+
+func (c *SecretController) respondVersionError(ctx *gin.Context, err error) {
+	if respondTenantKeyError(ctx, err) || respondSecretCheckoutRequired(ctx, err) || respondStepUpRequired(ctx, err) || respondControlGroupPending(ctx, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, services.ErrSecretNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_SECRET_NOT_FOUND",
+				Message: "Secret not found",
+			},
+		})
+	case errors.Is(err, services.ErrSecretVersionNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_SECRET_VERSION_NOT_FOUND",
+				Message: "Secret version not found",
+			},
+		})
+	case errors.Is(err, services.ErrSecretVersionCurrent):
+		ctx.JSON(http.StatusConflict, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_SECRET_VERSION_CURRENT",
+				Message: err.Error(),
+			},
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: "Failed to retrieve secret versions",
+			},
+		})
+	}
+}
+
+// mountPath is the KV mount resolved for the route, the default mount on
+// routes without one
+func mountPath(ctx *gin.Context) string {
+	if mount := ctx.GetString("mount"); mount != "" {
+		return mount
+	}
+	return model.DefaultKVMount
+}
+
+// respondSecretFieldError answers errors of per-field rules, reporting
+// whether it did
+func respondSecretFieldError(ctx *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, services.ErrSecretFieldsInvalid):
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrSecretFieldsUnreadable):
+		ctx.JSON(http.StatusForbidden, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_ACCESS_DENIED",
+				Message: err.Error(),
+			},
+		})
+	default:
+		return false
+	}
+	return true
+}
+
+// respondSecretLabelError answers invalid paths, tags and metadata,
+// reporting whether it did
+func respondSecretLabelError(ctx *gin.Context, err error) bool {
+	if !errors.Is(err, services.ErrSecretPathInvalid) && !errors.Is(err, services.ErrSecretTagsInvalid) && !errors.Is(err, services.ErrSecretMetadataInvalid) &&
+		!errors.Is(err, services.ErrSecretRotationInvalid) {
+		return false
+	}
+	ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+		Error: model.ErrorDetail{
+			Code:    "VAULT_INVALID_REQUEST",
+			Message: err.Error(),
+		},
+	})
+	return true
+}
@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+const defaultUnusedSecretDays = 90
+
+type SecretAccessController struct {
+	secretAccessService *services.SecretAccessService
+}
+
+func NewSecretAccessController(secretAccessService *services.SecretAccessService) *SecretAccessController {
+	return &SecretAccessController{
+		secretAccessService: secretAccessService,
+	}
+}
+
+// GetUnused lists the caller's secrets not read in ?days= days
+func (c *SecretAccessController) GetUnused(ctx *gin.Context) {
+	days, ok := c.days(ctx)
+	if !ok {
+		return
+	}
+
+	userID := ctx.MustGet("user_id").(uuid.UUID)
+	report, err := c.secretAccessService.UnusedSecrets(&userID, days)
+	if err != nil {
+		c.respondError(ctx, "Failed to retrieve unused secrets")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
+
+// GetUnusedReport lists every owner's secrets not read in ?days= days
+func (c *SecretAccessController) GetUnusedReport(ctx *gin.Context) {
+	days, ok := c.days(ctx)
+	if !ok {
+		return
+	}
+
+	report, err := c.secretAccessService.UnusedSecrets(nil, days)
+	if err != nil {
+		c.respondError(ctx, "Failed to retrieve unused secrets")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
+
+// NotifyOwners notifies each owner of their unused secrets
+func (c *SecretAccessController) NotifyOwners(ctx *gin.Context) {
+	var req model.NotifyUnusedSecretsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	report, err := c.secretAccessService.NotifyOwners(req.Days, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, "Failed to notify owners of unused secrets")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
+
+func (c *SecretAccessController) days(ctx *gin.Context) (int, bool) {
+	days, err := strconv.Atoi(ctx.DefaultQuery("days", strconv.Itoa(defaultUnusedSecretDays)))
+	if err != nil || days < 1 {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "days must be a positive number",
+			},
+		})
+		return 0, false
+	}
+	return days, true
+}
+
+func (c *SecretAccessController) respondError(ctx *gin.Context, message string) {
+	ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+		Error: model.ErrorDetail{
+			Code:    "VAULT_INTERNAL_ERROR",
+			Message: message,
+		},
+	})
+}
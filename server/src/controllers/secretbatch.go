@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+// BatchSecrets runs mixed secret operations in one transaction. A batch
+// that commits answers 200; one that rolls back answers with the status
+// of its first failed operation, and every result says what happened.
+func (c *SecretController) BatchSecrets(ctx *gin.Context) {
+	var req model.SecretBatchRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	userID := ctx.MustGet("user_id").(uuid.UUID)
+	response, err := c.secretService.Batch(mountPath(ctx), req.Operations, userID, tokenAccessor(ctx))
+	if err != nil {
+		if errors.Is(err, services.ErrSecretBatchInvalid) {
+			ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INVALID_REQUEST",
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: "Failed to run secret batch",
+			},
+		})
+		return
+	}
+
+	status := http.StatusOK
+	for i := range response.Results {
+		result := &response.Results[i]
+		if result.Err == nil {
+			result.Status = http.StatusOK
+			if result.Op == model.SecretBatchCreate {
+				result.Status = http.StatusCreated
+			}
+			continue
+		}
+		var detail model.ErrorDetail
+		result.Status, detail = secretBatchError(result.Err)
+		result.Error = &detail
+		if status == http.StatusOK && !errors.Is(result.Err, services.ErrSecretBatchRolledBack) {
+			status = result.Status
+		}
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(status, response)
+}
+
+// secretBatchError maps the failure of one batch operation to the status
+// and error the operation would have answered on its own route
+func secretBatchError(err error) (int, model.ErrorDetail) {
+	var violation *services.TemplateValidationError
+	var stepUp *services.StepUpRequiredError
+	var pending *services.ControlGroupPendingError
+	switch {
+	case errors.Is(err, services.ErrSecretBatchRolledBack):
+		return http.StatusFailedDependency, model.ErrorDetail{Code: "VAULT_BATCH_ROLLED_BACK", Message: err.Error()}
+	case errors.Is(err, services.ErrSecretBatchValidated), errors.Is(err, services.ErrSecretBatchScheduled):
+		return http.StatusUnprocessableEntity, model.ErrorDetail{Code: "VAULT_BATCH_UNSUPPORTED", Message: err.Error()}
+	case errors.Is(err, services.ErrSecretNotFound):
+		return http.StatusNotFound, model.ErrorDetail{Code: "VAULT_SECRET_NOT_FOUND", Message: "Secret not found"}
+	case errors.As(err, &violation):
+		return http.StatusUnprocessableEntity, model.ErrorDetail{Code: "VAULT_TEMPLATE_VIOLATION", Message: violation.Error()}
+	case errors.Is(err, services.ErrKMSUnavailable), errors.Is(err, services.ErrKMSUnwrapFailed):
+		return http.StatusServiceUnavailable, model.ErrorDetail{Code: "VAULT_TENANT_KEY_UNAVAILABLE", Message: err.Error()}
+	case errors.Is(err, services.ErrSecretNamespaceDenied):
+		return http.StatusForbidden, model.ErrorDetail{Code: "VAULT_FORBIDDEN", Message: "No write access to namespace"}
+	case errors.Is(err, services.ErrSecretProjectDenied):
+		return http.StatusForbidden, model.ErrorDetail{Code: "VAULT_FORBIDDEN", Message: "No write access to project"}
+	case errors.Is(err, services.ErrProjectNotFound):
+		return http.StatusNotFound, model.ErrorDetail{Code: "VAULT_PROJECT_NOT_FOUND", Message: "Project not found"}
+	case errors.Is(err, services.ErrSecretClientEncrypted), errors.Is(err, services.ErrSecretEnvelopeInvalid):
+		return http.StatusUnprocessableEntity, model.ErrorDetail{Code: "VAULT_SECRET_CLIENT_ENCRYPTED", Message: err.Error()}
+	case errors.As(err, &stepUp):
+		return http.StatusUnauthorized, model.ErrorDetail{Code: "VAULT_MFA_REQUIRED", Message: stepUp.Error(), MFA: &stepUp.Requirement}
+	case errors.As(err, &pending):
+		return http.StatusForbidden, model.ErrorDetail{Code: "VAULT_CONTROL_GROUP_PENDING", Message: pending.Error(), ControlGroup: &pending.Requirement}
+	case errors.Is(err, services.ErrSecretCheckoutRequired):
+		return http.StatusConflict, model.ErrorDetail{Code: "VAULT_SECRET_CHECKOUT_REQUIRED", Message: err.Error()}
+	case errors.Is(err, services.ErrSecretQuarantined):
+		return http.StatusLocked, model.ErrorDetail{Code: "VAULT_SECRET_QUARANTINED", Message: err.Error()}
+	case errors.Is(err, services.ErrMountNotFound), errors.Is(err, services.ErrMountWrongType):
+		return http.StatusNotFound, model.ErrorDetail{Code: "VAULT_MOUNT_NOT_FOUND", Message: err.Error()}
+	case errors.Is(err, services.ErrMountTTLExceeded),
+		errors.Is(err, services.ErrMountTTLInvalid),
+		errors.Is(err, services.ErrMountNamespace),
+		errors.Is(err, services.ErrSecretFieldsInvalid),
+		errors.Is(err, services.ErrSecretPathInvalid),
+		errors.Is(err, services.ErrSecretTagsInvalid),
+		errors.Is(err, services.ErrSecretMetadataInvalid),
+		errors.Is(err, services.ErrSecretRotationInvalid):
+		return http.StatusBadRequest, model.ErrorDetail{Code: "VAULT_INVALID_REQUEST", Message: err.Error()}
+	case errors.Is(err, services.ErrSecretFieldsUnreadable):
+		return http.StatusForbidden, model.ErrorDetail{Code: "VAULT_ACCESS_DENIED", Message: err.Error()}
+	case errors.Is(err, services.ErrNamespaceNotFound):
+		return http.StatusNotFound, model.ErrorDetail{Code: "VAULT_NAMESPACE_NOT_FOUND", Message: "Namespace not found"}
+	default:
+		return http.StatusInternalServerError, model.ErrorDetail{Code: "VAULT_INTERNAL_ERROR", Message: "Operation failed"}
+	}
+}
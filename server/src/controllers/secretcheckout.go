@@ -0,0 +1,186 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type SecretCheckoutController struct {
+	checkoutService *services.SecretCheckoutService
+}
+
+func NewSecretCheckoutController(checkoutService *services.SecretCheckoutService) *SecretCheckoutController {
+	return &SecretCheckoutController{
+		checkoutService: checkoutService,
+	}
+}
+
+// GetCheckout returns who holds the secret, or a null checkout
+func (c *SecretCheckoutController) GetCheckout(ctx *gin.Context) {
+	id, ok := checkoutSecretID(ctx)
+	if !ok {
+		return
+	}
+
+	checkout, err := c.checkoutService.GetCheckout(id, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		respondCheckoutError(ctx, err, "Failed to get checkout")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"checkout": checkout})
+}
+
+// Checkout checks the secret out to the caller and returns its value
+func (c *SecretCheckoutController) Checkout(ctx *gin.Context) {
+	id, ok := checkoutSecretID(ctx)
+	if !ok {
+		return
+	}
+
+	var req model.CheckoutSecretRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INVALID_REQUEST",
+					Message: "Invalid request format",
+				},
+			})
+			return
+		}
+	}
+
+	response, err := c.checkoutService.Checkout(id, &req, ctx.MustGet("user_id").(uuid.UUID), tokenAccessor(ctx))
+	if err != nil {
+		if respondTenantKeyError(ctx, err) || respondSecretFieldError(ctx, err) {
+			return
+		}
+		respondCheckoutError(ctx, err, "Failed to check out secret")
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *SecretCheckoutController) Checkin(ctx *gin.Context) {
+	c.checkin(ctx, false)
+}
+
+// ForceCheckin takes a secret back from whoever holds it
+func (c *SecretCheckoutController) ForceCheckin(ctx *gin.Context) {
+	c.checkin(ctx, true)
+}
+
+func (c *SecretCheckoutController) checkin(ctx *gin.Context, force bool) {
+	id, ok := checkoutSecretID(ctx)
+	if !ok {
+		return
+	}
+
+	var req model.CheckinSecretRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INVALID_REQUEST",
+					Message: "Invalid request format",
+				},
+			})
+			return
+		}
+	}
+
+	userID := ctx.MustGet("user_id").(uuid.UUID)
+	var checkout *model.SecretCheckout
+	var err error
+	if force {
+		checkout, err = c.checkoutService.ForceCheckin(id, req.Reason, userID)
+	} else {
+		checkout, err = c.checkoutService.Checkin(id, req.Reason, userID)
+	}
+	if err != nil {
+		respondCheckoutError(ctx, err, "Failed to check in secret")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, checkout)
+}
+
+func checkoutSecretID(ctx *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_ID",
+				Message: "Invalid secret ID",
+			},
+		})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func respondCheckoutError(ctx *gin.Context, err error, message string) {
+	if respondSecretCheckoutRequired(ctx, err) || respondStepUpRequired(ctx, err) || respondControlGroupPending(ctx, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, services.ErrSecretNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_SECRET_NOT_FOUND",
+				Message: "Secret not found",
+			},
+		})
+	case errors.Is(err, services.ErrSecretCheckedOut):
+		ctx.JSON(http.StatusConflict, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_SECRET_CHECKED_OUT",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrSecretNotCheckedOut):
+		ctx.JSON(http.StatusConflict, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_SECRET_NOT_CHECKED_OUT",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrSecretCheckoutNotRequired), errors.Is(err, services.ErrSecretCheckoutInvalid):
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: message,
+			},
+		})
+	}
+}
+
+// respondSecretCheckoutRequired answers reads of a checkout_required
+// secret by anyone but its holder
+func respondSecretCheckoutRequired(ctx *gin.Context, err error) bool {
+	if !errors.Is(err, services.ErrSecretCheckoutRequired) {
+		return false
+	}
+	ctx.JSON(http.StatusConflict, model.ErrorResponse{
+		Error: model.ErrorDetail{
+			Code:    "VAULT_SECRET_CHECKOUT_REQUIRED",
+			Message: err.Error(),
+		},
+	})
+	return true
+}
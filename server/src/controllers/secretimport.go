@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type SecretImportController struct {
+	importService *services.SecretImportService
+}
+
+func NewSecretImportController(importService *services.SecretImportService) *SecretImportController {
+	return &SecretImportController{
+		importService: importService,
+	}
+}
+
+// GetImportKey returns the public keys clients encrypt imported values to
+func (c *SecretImportController) GetImportKey(ctx *gin.Context) {
+	key, err := c.importService.CurrentKey(ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: "Failed to get import key",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, key)
+}
+
+func (c *SecretImportController) RotateImportKey(ctx *gin.Context) {
+	key, err := c.importService.RotateKey(ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: "Failed to rotate import key",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, key)
+}
+
+// ImportSecret creates a secret from a value encrypted to the import key
+func (c *SecretImportController) ImportSecret(ctx *gin.Context) {
+	var req model.ImportSecretRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	secret, err := c.importService.Import(&req, mountPath(ctx), ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		if respondTemplateViolation(ctx, err) || respondTenantKeyError(ctx, err) || respondMountError(ctx, err) || respondSecretFieldError(ctx, err) || respondSecretLabelError(ctx, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrImportKeyNotFound):
+			ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_IMPORT_KEY_NOT_FOUND",
+					Message: err.Error(),
+				},
+			})
+		case errors.Is(err, services.ErrImportKeyRetired):
+			ctx.JSON(http.StatusConflict, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_IMPORT_KEY_RETIRED",
+					Message: err.Error(),
+				},
+			})
+		case errors.Is(err, services.ErrImportInvalid):
+			ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INVALID_REQUEST",
+					Message: err.Error(),
+				},
+			})
+		case errors.Is(err, services.ErrImportDecryptFailed):
+			ctx.JSON(http.StatusUnprocessableEntity, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_IMPORT_DECRYPT_FAILED",
+					Message: err.Error(),
+				},
+			})
+		case errors.Is(err, services.ErrNamespaceNotFound):
+			ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_NAMESPACE_NOT_FOUND",
+					Message: "Namespace not found",
+				},
+			})
+		default:
+			ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INTERNAL_ERROR",
+					Message: "Failed to import secret",
+				},
+			})
+		}
+		return
+	}
+
+	if secret.Validation != nil {
+		ctx.JSON(http.StatusAccepted, secret)
+		return
+	}
+	ctx.JSON(http.StatusCreated, secret)
+}
@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+// GetPublications lists the values scheduled with activate_at for a
+// secret, and what became of earlier ones
+func (c *SecretController) GetPublications(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_ID",
+				Message: "Invalid secret ID",
+			},
+		})
+		return
+	}
+
+	publications, err := c.secretService.GetPublications(id, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		if respondPublicationError(ctx, err) {
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: "Failed to retrieve publications",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"publications": publications})
+}
+
+// CancelPublication drops a scheduled value before it becomes current
+func (c *SecretController) CancelPublication(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_ID",
+				Message: "Invalid secret ID",
+			},
+		})
+		return
+	}
+	publicationID, err := uuid.Parse(ctx.Param("publication_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_ID",
+				Message: "Invalid publication ID",
+			},
+		})
+		return
+	}
+
+	publication, err := c.secretService.CancelPublication(id, publicationID, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		if respondPublicationError(ctx, err) {
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: "Failed to cancel publication",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, publication)
+}
+
+// respondPublicationError answers errors of scheduled values, reporting
+// whether it did
+func respondPublicationError(ctx *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, services.ErrSecretNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_SECRET_NOT_FOUND",
+				Message: "Secret not found",
+			},
+		})
+	case errors.Is(err, services.ErrSecretPublicationNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_PUBLICATION_NOT_FOUND",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrSecretPublicationDone):
+		ctx.JSON(http.StatusConflict, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_PUBLICATION_DONE",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrSecretPublicationInvalid),
+		errors.Is(err, services.ErrSecretPublicationValidated),
+		errors.Is(err, services.ErrSecretPublicationUnavailable):
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		})
+	default:
+		return false
+	}
+	return true
+}
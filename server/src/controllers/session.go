@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type SessionController struct {
+	sessionService *services.SessionService
+}
+
+func NewSessionController(sessionService *services.SessionService) *SessionController {
+	return &SessionController{
+		sessionService: sessionService,
+	}
+}
+
+// GetMySessions lists the caller's active sessions
+func (c *SessionController) GetMySessions(ctx *gin.Context) {
+	sessions, err := c.sessionService.List(ctx.MustGet("user_id").(uuid.UUID), tokenAccessor(ctx))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to get sessions")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// RevokeSession ends a session and revokes its token
+func (c *SessionController) RevokeSession(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_ID",
+				Message: "Invalid session ID",
+			},
+		})
+		return
+	}
+
+	if err := c.sessionService.Revoke(id, ctx.MustGet("user_id").(uuid.UUID)); err != nil {
+		c.respondError(ctx, err, "Failed to revoke session")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+// TerminateSessions signs a user out everywhere by revoking all of their
+// tokens
+func (c *SessionController) TerminateSessions(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_ID",
+				Message: "Invalid user ID",
+			},
+		})
+		return
+	}
+
+	response, err := c.sessionService.TerminateAll(id, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to terminate sessions")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *SessionController) respondError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrSessionNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_SESSION_NOT_FOUND",
+				Message: "Session not found",
+			},
+		})
+	case errors.Is(err, services.ErrUserNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_USER_NOT_FOUND",
+				Message: "User not found",
+			},
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: message,
+			},
+		})
+	}
+}
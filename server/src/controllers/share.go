@@ -0,0 +1,257 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type ShareController struct {
+	shareService *services.ShareService
+	publicURL    string
+}
+
+func NewShareController(shareService *services.ShareService, publicURL string) *ShareController {
+	return &ShareController{
+		shareService: shareService,
+		publicURL:    publicURL,
+	}
+}
+
+func (c *ShareController) CreateShare(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_UNAUTHORIZED",
+				Message: "Unauthorized",
+			},
+		})
+		return
+	}
+
+	var req model.CreateShareRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	response, err := c.shareService.CreateShare(&req, userID.(uuid.UUID), tokenAccessor(ctx), c.baseURL(ctx))
+	if err != nil {
+		if respondSecretCheckoutRequired(ctx, err) || respondStepUpRequired(ctx, err) || respondControlGroupPending(ctx, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrSecretNotFound):
+			ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_SECRET_NOT_FOUND",
+					Message: "Secret not found",
+				},
+			})
+		case errors.Is(err, services.ErrSecretClientEncrypted):
+			ctx.JSON(http.StatusUnprocessableEntity, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_SECRET_CLIENT_ENCRYPTED",
+					Message: "Client-encrypted secrets are shared by granting the recipient's key",
+				},
+			})
+		case errors.Is(err, services.ErrShareEmptyValue), errors.Is(err, services.ErrMountTTLExceeded),
+			errors.Is(err, services.ErrMountTTLInvalid):
+			ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INVALID_REQUEST",
+					Message: err.Error(),
+				},
+			})
+		default:
+			ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INTERNAL_ERROR",
+					Message: "Failed to create share link",
+				},
+			})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, response)
+}
+
+func (c *ShareController) ViewShare(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_ID",
+				Message: "Invalid share ID",
+			},
+		})
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Header("Referrer-Policy", "no-referrer")
+
+	response, err := c.shareService.ConsumeShare(id, ctx.ClientIP(), ctx.GetHeader("User-Agent"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrShareNotFound),
+			errors.Is(err, services.ErrShareConsumed),
+			errors.Is(err, services.ErrShareExpired):
+			ctx.JSON(http.StatusGone, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_SHARE_UNAVAILABLE",
+					Message: "This link has expired or has already been viewed",
+				},
+			})
+		case errors.Is(err, services.ErrSharePendingApproval):
+			ctx.JSON(http.StatusForbidden, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_SHARE_PENDING_APPROVAL",
+					Message: "This link is waiting for approval",
+				},
+			})
+		case errors.Is(err, services.ErrShareDenied):
+			ctx.JSON(http.StatusGone, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_SHARE_UNAVAILABLE",
+					Message: "This link was denied",
+				},
+			})
+		default:
+			ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INTERNAL_ERROR",
+					Message: "Failed to open share link",
+				},
+			})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetPendingApprovals lists the share links awaiting approval
+func (c *ShareController) GetPendingApprovals(ctx *gin.Context) {
+	shares, err := c.shareService.GetPendingApprovals()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: "Failed to get pending share links",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"shares": shares})
+}
+
+func (c *ShareController) ApproveShare(ctx *gin.Context) {
+	c.decideShare(ctx, true)
+}
+
+func (c *ShareController) DenyShare(ctx *gin.Context) {
+	c.decideShare(ctx, false)
+}
+
+func (c *ShareController) decideShare(ctx *gin.Context, approve bool) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_ID",
+				Message: "Invalid share ID",
+			},
+		})
+		return
+	}
+
+	var req model.ShareDecisionRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INVALID_REQUEST",
+					Message: "Invalid request format",
+				},
+			})
+			return
+		}
+	}
+
+	share, err := c.shareService.DecideShare(id, ctx.MustGet("user_id").(uuid.UUID), approve, req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrShareNotFound):
+			ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_SHARE_NOT_FOUND",
+					Message: "Share not found",
+				},
+			})
+		case errors.Is(err, services.ErrShareNotPending):
+			ctx.JSON(http.StatusConflict, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_SHARE_NOT_PENDING",
+					Message: err.Error(),
+				},
+			})
+		case errors.Is(err, services.ErrShareSelfApproval):
+			ctx.JSON(http.StatusForbidden, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_SHARE_SELF_APPROVAL",
+					Message: err.Error(),
+				},
+			})
+		case errors.Is(err, services.ErrShareExpired):
+			ctx.JSON(http.StatusGone, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_SHARE_UNAVAILABLE",
+					Message: err.Error(),
+				},
+			})
+		default:
+			ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INTERNAL_ERROR",
+					Message: "Failed to decide share link",
+				},
+			})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, share)
+}
+
+func (c *ShareController) baseURL(ctx *gin.Context) string {
+	return requestBaseURL(ctx, c.publicURL)
+}
+
+// requestBaseURL returns the configured public URL, or one derived from
+// the incoming request when none is set
+func requestBaseURL(ctx *gin.Context, publicURL string) string {
+	if publicURL != "" {
+		return strings.TrimRight(publicURL, "/")
+	}
+
+	scheme := "http"
+	if ctx.Request.TLS != nil || ctx.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s", scheme, ctx.Request.Host)
+}
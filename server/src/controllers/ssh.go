@@ -0,0 +1,279 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type SSHController struct {
+	sshService *services.SSHService
+}
+
+func NewSSHController(sshService *services.SSHService) *SSHController {
+	return &SSHController{
+		sshService: sshService,
+	}
+}
+
+// CreateCA generates the key user certificates are signed with
+func (c *SSHController) CreateCA(ctx *gin.Context) {
+	var req model.CreateSSHCARequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+	if c.sshService == nil {
+		c.respondError(ctx, services.ErrSSHUnavailable, "")
+		return
+	}
+
+	ca, err := c.sshService.CreateCA(&req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to create SSH CA")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, ca)
+}
+
+// GetCAPublicKey serves the CA public key for hosts to trust
+func (c *SSHController) GetCAPublicKey(ctx *gin.Context) {
+	if c.sshService == nil {
+		c.respondError(ctx, services.ErrSSHUnavailable, "")
+		return
+	}
+
+	publicKey, err := c.sshService.CAPublicKey()
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve SSH CA public key")
+		return
+	}
+
+	ctx.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(publicKey))
+}
+
+func (c *SSHController) GetRoles(ctx *gin.Context) {
+	if c.sshService == nil {
+		c.respondError(ctx, services.ErrSSHUnavailable, "")
+		return
+	}
+
+	roles, err := c.sshService.GetRoles()
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve SSH roles")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"roles": roles})
+}
+
+func (c *SSHController) CreateRole(ctx *gin.Context) {
+	var req model.CreateSSHRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+	if c.sshService == nil {
+		c.respondError(ctx, services.ErrSSHUnavailable, "")
+		return
+	}
+
+	role, err := c.sshService.CreateRole(&req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to create SSH role")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, role)
+}
+
+func (c *SSHController) DeleteRole(ctx *gin.Context) {
+	if c.sshService == nil {
+		c.respondError(ctx, services.ErrSSHUnavailable, "")
+		return
+	}
+
+	if err := c.sshService.DeleteRole(ctx.Param("name"), ctx.MustGet("user_id").(uuid.UUID)); err != nil {
+		c.respondError(ctx, err, "Failed to delete SSH role")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// Sign returns a certificate for the caller's public key under a role
+func (c *SSHController) Sign(ctx *gin.Context) {
+	var req model.SignSSHKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+	if c.sshService == nil {
+		c.respondError(ctx, services.ErrSSHUnavailable, "")
+		return
+	}
+
+	response, err := c.sshService.Sign(ctx.Param("role"), &req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to sign SSH key")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// Credential returns a one-time password for a host under a role
+func (c *SSHController) Credential(ctx *gin.Context) {
+	var req model.SSHCredentialRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+	if c.sshService == nil {
+		c.respondError(ctx, services.ErrSSHUnavailable, "")
+		return
+	}
+
+	response, err := c.sshService.Credential(ctx.Param("role"), &req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to issue SSH credential")
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, response)
+}
+
+// VerifyOTP is called by the helper on the target host; the one-time
+// password is its own credential
+func (c *SSHController) VerifyOTP(ctx *gin.Context) {
+	var req model.VerifySSHOTPRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+	if c.sshService == nil {
+		c.respondError(ctx, services.ErrSSHUnavailable, "")
+		return
+	}
+
+	response, err := c.sshService.VerifyOTP(req.OTP, ctx.ClientIP())
+	if err != nil {
+		c.respondError(ctx, err, "Failed to verify one-time password")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *SSHController) respondError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrSSHCANotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_SSH_CA_NOT_FOUND",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrSSHRoleNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_SSH_ROLE_NOT_FOUND",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrSSHCAExists), errors.Is(err, services.ErrSSHRoleExists):
+		ctx.JSON(http.StatusConflict, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_SSH_CONFLICT",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrSSHInvalidRole), errors.Is(err, services.ErrSSHInvalidRequest):
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrSSHNotAllowed):
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_SSH_NOT_ALLOWED",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrSSHOTPInvalid):
+		ctx.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_SSH_OTP_INVALID",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrSSHDenied):
+		policy := services.SSHSignPolicy
+		if strings.HasPrefix(ctx.FullPath(), "/api/v1/ssh/creds/") {
+			policy = services.SSHCredsPolicy
+		}
+		ctx.JSON(http.StatusForbidden, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_ACCESS_DENIED",
+				Message: "Access denied: requires create on " + policy + "/" + ctx.Param("role"),
+				Path:    policy + "/" + ctx.Param("role"),
+				Action:  "create",
+			},
+		})
+	case errors.Is(err, services.ErrUserNotFound):
+		ctx.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_UNAUTHORIZED",
+				Message: "Unauthorized",
+			},
+		})
+	case errors.Is(err, services.ErrSSHUnavailable):
+		ctx.JSON(http.StatusServiceUnavailable, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_SERVICE_UNAVAILABLE",
+				Message: err.Error(),
+			},
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: message,
+			},
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+	"gorm.io/gorm"
+)
+
+const (
+	// statusCacheTTL bounds how often the public status is recomputed and
+	// signed, however many clients poll it
+	statusCacheTTL = 10 * time.Second
+	// statusSignatureTTL is how long a signed status stays valid, so a
+	// captured response cannot be replayed to hide a later outage
+	statusSignatureTTL = 5 * time.Minute
+)
+
+// StatusController serves the unauthenticated status page endpoint. It
+// only reports coarse component states and signs them with the OIDC key.
+type StatusController struct {
+	db          *gorm.DB
+	oidcService *services.OIDCService
+	issuer      string
+	saturated   func() bool
+
+	mu       sync.Mutex
+	cached   *model.PublicStatusResponse
+	cachedAt time.Time
+}
+
+func NewStatusController(db *gorm.DB, oidcService *services.OIDCService, issuer string, saturated func() bool) *StatusController {
+	return &StatusController{
+		db:          db,
+		oidcService: oidcService,
+		issuer:      issuer,
+		saturated:   saturated,
+	}
+}
+
+func (c *StatusController) Status(ctx *gin.Context) {
+	response := c.status()
+
+	ctx.Header("Cache-Control", "public, max-age=10")
+	if response.API == model.StatusUnavailable {
+		ctx.JSON(http.StatusServiceUnavailable, response)
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *StatusController) status() *model.PublicStatusResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cached != nil && time.Since(c.cachedAt) < statusCacheTTL {
+		return c.cached
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	status := model.PublicStatus{
+		API: model.StatusAvailable,
+		// A running server holds its master key; there is no sealed state
+		// to report until the key is loaded on demand
+		Seal:           model.SealStatusUnsealed,
+		ReplicationLag: model.ReplicationLagNotConfigured,
+		Timestamp:      now,
+	}
+	if !c.databaseReachable() {
+		status.API = model.StatusUnavailable
+	} else if c.saturated != nil && c.saturated() {
+		status.API = model.StatusDegraded
+	}
+	status.Status = status.API
+
+	// Signing reads the key from the database, so an outage goes out
+	// unsigned
+	response := &model.PublicStatusResponse{PublicStatus: status}
+	if c.oidcService != nil && status.API != model.StatusUnavailable {
+		signature, err := c.oidcService.SignClaims(jwt.MapClaims{
+			"iss":             c.issuer,
+			"iat":             now.Unix(),
+			"exp":             now.Add(statusSignatureTTL).Unix(),
+			"status":          status.Status,
+			"api":             status.API,
+			"seal":            status.Seal,
+			"replication_lag": status.ReplicationLag,
+		})
+		if err != nil {
+			log.Printf("⚠️  Failed to sign public status: %v", err)
+		} else {
+			response.Signature = signature
+		}
+	}
+
+	c.cached = response
+	c.cachedAt = time.Now()
+	return response
+}
+
+func (c *StatusController) databaseReachable() bool {
+	if c.db == nil {
+		return false
+	}
+	sqlDB, err := c.db.DB()
+	if err != nil {
+		return false
+	}
+	return sqlDB.Ping() == nil
+}
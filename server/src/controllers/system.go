@@ -1,9 +1,12 @@
 package controllers
 
 import (
+	"fmt"
+	"github.com/skygenesisenterprise/aether-vault/server/src/buildinfo"
 	"github.com/skygenesisenterprise/aether-vault/server/src/model"
 	"net/http"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -11,7 +14,11 @@ import (
 )
 
 type SystemController struct {
-	db *gorm.DB
+	db            *gorm.DB
+	metricSources []func(w *MetricsWriter)
+	features      func() map[string]bool
+	maintenance   func() model.MaintenanceMode
+	entropy       func() model.EntropyStatus
 }
 
 func NewSystemController(db *gorm.DB) *SystemController {
@@ -38,9 +45,28 @@ func (c *SystemController) Health(ctx *gin.Context) {
 	response := model.HealthResponse{
 		Status:    status,
 		Timestamp: time.Now(),
-		Version:   "1.0.0",
+		Version:   buildinfo.Version,
 		Database:  dbStatus,
 	}
+	if c.features != nil {
+		response.Features = c.features()
+	}
+	if c.maintenance != nil {
+		if state := c.maintenance(); state.Enabled {
+			response.ReadOnly = true
+			response.MaintenanceReason = state.Reason
+		}
+	}
+	if c.entropy != nil {
+		// Random reads are held while blocked, so nothing can be served
+		if state := c.entropy(); state.Degraded {
+			response.EntropyDegraded = true
+			if state.Blocking {
+				response.Status = "unhealthy"
+				status = "unhealthy"
+			}
+		}
+	}
 
 	if status == "unhealthy" {
 		ctx.JSON(http.StatusServiceUnavailable, response)
@@ -51,12 +77,101 @@ func (c *SystemController) Health(ctx *gin.Context) {
 }
 
 func (c *SystemController) Version(ctx *gin.Context) {
+	info := buildinfo.Get()
 	response := model.VersionResponse{
-		Version:   "1.0.0",
-		BuildTime: "2024-01-01T00:00:00Z",
-		GitCommit: "unknown",
-		GoVersion: runtime.Version(),
+		Version:   info.Version,
+		BuildTime: info.BuildDate,
+		GitCommit: info.GitCommit,
+		GoVersion: info.GoVersion,
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// BuildInfo reports the exact build and the feature flags in effect, for
+// correlating incidents with releases
+func (c *SystemController) BuildInfo(ctx *gin.Context) {
+	response := model.BuildInfoResponse{Info: buildinfo.Get()}
+	if c.features != nil {
+		response.FeatureFlags = c.features()
 	}
 
 	ctx.JSON(http.StatusOK, response)
 }
+
+// MetricsWriter renders gauges in the Prometheus text exposition format
+type MetricsWriter struct {
+	builder strings.Builder
+	seen    map[string]bool
+}
+
+// Gauge writes one sample; HELP and TYPE are emitted the first time a
+// name is seen. labels is a list of name, value pairs.
+func (w *MetricsWriter) Gauge(name, help string, value interface{}, labels ...string) {
+	if w.seen == nil {
+		w.seen = make(map[string]bool)
+	}
+	if !w.seen[name] {
+		fmt.Fprintf(&w.builder, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+		w.seen[name] = true
+	}
+
+	w.builder.WriteString(name)
+	if len(labels) > 1 {
+		pairs := make([]string, 0, len(labels)/2)
+		for i := 0; i+1 < len(labels); i += 2 {
+			pairs = append(pairs, fmt.Sprintf("%s=%q", labels[i], labels[i+1]))
+		}
+		w.builder.WriteString("{" + strings.Join(pairs, ",") + "}")
+	}
+	fmt.Fprintf(&w.builder, " %v\n", value)
+}
+
+// SetFeatures reports the flags returned by features in health responses
+func (c *SystemController) SetFeatures(features func() map[string]bool) {
+	c.features = features
+}
+
+// SetMaintenance reports read-only maintenance mode in health responses
+func (c *SystemController) SetMaintenance(maintenance func() model.MaintenanceMode) {
+	c.maintenance = maintenance
+}
+
+// SetEntropy reports degraded entropy sources in health responses
+func (c *SystemController) SetEntropy(entropy func() model.EntropyStatus) {
+	c.entropy = entropy
+}
+
+// AddMetrics registers a source of extra gauges for the metrics endpoint
+func (c *SystemController) AddMetrics(source func(w *MetricsWriter)) {
+	c.metricSources = append(c.metricSources, source)
+}
+
+// Metrics serves process, database pool and registered gauges in the
+// Prometheus text exposition format
+func (c *SystemController) Metrics(ctx *gin.Context) {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	w := &MetricsWriter{}
+	w.Gauge("vault_up", "Whether the server is running.", 1)
+	w.Gauge("vault_go_goroutines", "Number of goroutines.", runtime.NumGoroutine())
+	w.Gauge("vault_go_heap_alloc_bytes", "Bytes of allocated heap objects.", memStats.HeapAlloc)
+	w.Gauge("vault_go_gc_cycles", "Completed GC cycles.", memStats.NumGC)
+
+	if c.db != nil {
+		if sqlDB, err := c.db.DB(); err == nil {
+			stats := sqlDB.Stats()
+			w.Gauge("vault_db_open_connections", "Established database connections.", stats.OpenConnections)
+			w.Gauge("vault_db_in_use_connections", "Database connections in use.", stats.InUse)
+			w.Gauge("vault_db_idle_connections", "Idle database connections.", stats.Idle)
+			w.Gauge("vault_db_wait_count", "Total number of connections waited for.", stats.WaitCount)
+		}
+	}
+
+	for _, source := range c.metricSources {
+		source(w)
+	}
+
+	ctx.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(w.builder.String()))
+}
@@ -0,0 +1,214 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type TeamKeyController struct {
+	teamKeyService *services.TeamKeyService
+}
+
+func NewTeamKeyController(teamKeyService *services.TeamKeyService) *TeamKeyController {
+	return &TeamKeyController{
+		teamKeyService: teamKeyService,
+	}
+}
+
+func (c *TeamKeyController) GetMyKey(ctx *gin.Context) {
+	key, err := c.teamKeyService.GetKey(ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve key")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, key)
+}
+
+func (c *TeamKeyController) RegisterKey(ctx *gin.Context) {
+	var req model.RegisterUserKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	key, err := c.teamKeyService.RegisterKey(ctx.MustGet("user_id").(uuid.UUID), &req)
+	if err != nil {
+		c.respondError(ctx, err, "Failed to register key")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, key)
+}
+
+// GetUserKey returns another user's public key so secrets can be granted
+// to them
+func (c *TeamKeyController) GetUserKey(ctx *gin.Context) {
+	userID, ok := c.parseID(ctx, "id", "Invalid user ID")
+	if !ok {
+		return
+	}
+
+	key, err := c.teamKeyService.GetKey(userID)
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve key")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, key)
+}
+
+func (c *TeamKeyController) GetMemberKeys(ctx *gin.Context) {
+	namespaceID, ok := c.parseID(ctx, "id", "Invalid namespace ID")
+	if !ok {
+		return
+	}
+
+	members, err := c.teamKeyService.GetMemberKeys(namespaceID)
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve member keys")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"members": members})
+}
+
+func (c *TeamKeyController) GetRewrapPlan(ctx *gin.Context) {
+	namespaceID, ok := c.parseID(ctx, "id", "Invalid namespace ID")
+	if !ok {
+		return
+	}
+
+	plan, err := c.teamKeyService.GetRewrapPlan(namespaceID)
+	if err != nil {
+		c.respondError(ctx, err, "Failed to compute re-wrap plan")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, plan)
+}
+
+func (c *TeamKeyController) GetEnvelope(ctx *gin.Context) {
+	namespaceID, ok := c.parseID(ctx, "id", "Invalid namespace ID")
+	if !ok {
+		return
+	}
+	secretID, ok := c.parseID(ctx, "secret_id", "Invalid secret ID")
+	if !ok {
+		return
+	}
+
+	envelope, err := c.teamKeyService.GetEnvelope(namespaceID, secretID, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve secret")
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, envelope)
+}
+
+func (c *TeamKeyController) StoreEnvelope(ctx *gin.Context) {
+	namespaceID, ok := c.parseID(ctx, "id", "Invalid namespace ID")
+	if !ok {
+		return
+	}
+	secretID, ok := c.parseID(ctx, "secret_id", "Invalid secret ID")
+	if !ok {
+		return
+	}
+
+	var req model.StoreEnvelopeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	if err := c.teamKeyService.StoreEnvelope(namespaceID, secretID, &req, ctx.MustGet("user_id").(uuid.UUID)); err != nil {
+		c.respondError(ctx, err, "Failed to update secret")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (c *TeamKeyController) parseID(ctx *gin.Context, param, message string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(ctx.Param(param))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_ID",
+				Message: message,
+			},
+		})
+		return uuid.Nil, false
+	}
+
+	return id, true
+}
+
+func (c *TeamKeyController) respondError(ctx *gin.Context, err error, message string) {
+	if respondTenantKeyError(ctx, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, services.ErrUserKeyNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_USER_KEY_NOT_FOUND",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrNamespaceNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_NAMESPACE_NOT_FOUND",
+				Message: "Namespace not found",
+			},
+		})
+	case errors.Is(err, services.ErrSecretNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_SECRET_NOT_FOUND",
+				Message: "Secret not found",
+			},
+		})
+	case errors.Is(err, services.ErrUserKeyInvalid):
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrEnvelopeRecipientNotMember):
+		ctx.JSON(http.StatusForbidden, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_RECIPIENT_NOT_MEMBER",
+				Message: err.Error(),
+			},
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: message,
+			},
+		})
+	}
+}
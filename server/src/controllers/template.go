@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type TemplateController struct {
+	templateService *services.TemplateService
+}
+
+func NewTemplateController(templateService *services.TemplateService) *TemplateController {
+	return &TemplateController{
+		templateService: templateService,
+	}
+}
+
+func (c *TemplateController) GetTemplates(ctx *gin.Context) {
+	templates, err := c.templateService.GetTemplates()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: "Failed to retrieve templates",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"templates": templates})
+}
+
+func (c *TemplateController) MatchTemplate(ctx *gin.Context) {
+	secretPath := ctx.Query("path")
+	if secretPath == "" {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "path query parameter is required",
+			},
+		})
+		return
+	}
+
+	template, err := c.templateService.MatchTemplate(secretPath)
+	if err != nil {
+		if errors.Is(err, services.ErrTemplateNotFound) {
+			ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_TEMPLATE_NOT_FOUND",
+					Message: "No template applies to this path",
+				},
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: "Failed to match template",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, template)
+}
+
+func (c *TemplateController) CreateTemplate(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_UNAUTHORIZED",
+				Message: "Unauthorized",
+			},
+		})
+		return
+	}
+
+	var req model.CreateTemplateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	template, err := c.templateService.CreateTemplate(&req, userID.(uuid.UUID))
+	if err != nil {
+		if errors.Is(err, services.ErrTemplateInvalid) {
+			ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INVALID_TEMPLATE",
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: "Failed to create template",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, template)
+}
+
+func (c *TemplateController) DeleteTemplate(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_UNAUTHORIZED",
+				Message: "Unauthorized",
+			},
+		})
+		return
+	}
+
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_ID",
+				Message: "Invalid template ID",
+			},
+		})
+		return
+	}
+
+	if err := c.templateService.DeleteTemplate(id, userID.(uuid.UUID)); err != nil {
+		if errors.Is(err, services.ErrTemplateNotFound) {
+			ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_TEMPLATE_NOT_FOUND",
+					Message: "Template not found",
+				},
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: "Failed to delete template",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Template deleted successfully"})
+}
+
+// respondTemplateViolation writes a 422 listing template violations and
+// reports whether err was one.
+func respondTemplateViolation(ctx *gin.Context, err error) bool {
+	var violation *services.TemplateValidationError
+	if !errors.As(err, &violation) {
+		return false
+	}
+
+	ctx.JSON(http.StatusUnprocessableEntity, model.TemplateValidationResponse{
+		Error: model.ErrorDetail{
+			Code:    "VAULT_TEMPLATE_VIOLATION",
+			Message: violation.Error(),
+		},
+		TemplateID: violation.Template.ID,
+		Violations: violation.Violations,
+	})
+	return true
+}
@@ -0,0 +1,237 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type TenantKeyController struct {
+	tenantKeyService *services.TenantKeyService
+}
+
+func NewTenantKeyController(tenantKeyService *services.TenantKeyService) *TenantKeyController {
+	return &TenantKeyController{
+		tenantKeyService: tenantKeyService,
+	}
+}
+
+func (c *TenantKeyController) GetKey(ctx *gin.Context) {
+	namespaceID, ok := c.namespaceID(ctx)
+	if !ok {
+		return
+	}
+
+	key, err := c.tenantKeyService.GetKey(namespaceID)
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve tenant key")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, key)
+}
+
+func (c *TenantKeyController) RegisterKey(ctx *gin.Context) {
+	namespaceID, ok := c.namespaceID(ctx)
+	if !ok {
+		return
+	}
+
+	var req model.RegisterTenantKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	key, err := c.tenantKeyService.RegisterKey(namespaceID, &req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to register tenant key")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, key)
+}
+
+func (c *TenantKeyController) CheckKey(ctx *gin.Context) {
+	namespaceID, ok := c.namespaceID(ctx)
+	if !ok {
+		return
+	}
+
+	key, err := c.tenantKeyService.CheckKey(namespaceID)
+	if err != nil {
+		c.respondError(ctx, err, "Failed to check tenant key")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, key)
+}
+
+func (c *TenantKeyController) RotateKey(ctx *gin.Context) {
+	namespaceID, ok := c.namespaceID(ctx)
+	if !ok {
+		return
+	}
+
+	var req model.RotateTenantKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	key, err := c.tenantKeyService.RotateKey(namespaceID, &req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to rotate tenant key")
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, key)
+}
+
+func (c *TenantKeyController) ResumeRewrap(ctx *gin.Context) {
+	namespaceID, ok := c.namespaceID(ctx)
+	if !ok {
+		return
+	}
+
+	key, err := c.tenantKeyService.ResumeRewrap(namespaceID, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to resume re-wrap")
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, key)
+}
+
+func (c *TenantKeyController) namespaceID(ctx *gin.Context) (uuid.UUID, bool) {
+	namespaceID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_ID",
+				Message: "Invalid namespace ID",
+			},
+		})
+		return uuid.Nil, false
+	}
+
+	return namespaceID, true
+}
+
+func (c *TenantKeyController) respondError(ctx *gin.Context, err error, message string) {
+	if respondTenantKeyError(ctx, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, services.ErrNamespaceNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_NAMESPACE_NOT_FOUND",
+				Message: "Namespace not found",
+			},
+		})
+	case errors.Is(err, services.ErrTenantKeyNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_TENANT_KEY_NOT_FOUND",
+				Message: "Namespace has no tenant key",
+			},
+		})
+	case errors.Is(err, services.ErrTenantKeyExists), errors.Is(err, services.ErrTenantKeyBusy), errors.Is(err, services.ErrTenantKeyNoRewrap):
+		ctx.JSON(http.StatusConflict, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_TENANT_KEY_CONFLICT",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrTenantKeyInvalid), errors.Is(err, services.ErrKMSKeyURIInvalid), errors.Is(err, services.ErrKMSProviderUnsupported):
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: message,
+			},
+		})
+	}
+}
+
+// respondTenantKeyError writes the response for errors raised by a
+// namespace's external KMS, the secret integrity check or client-side
+// encryption and reports whether it handled err
+func respondTenantKeyError(ctx *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, services.ErrKMSUnavailable), errors.Is(err, services.ErrKMSUnwrapFailed):
+		ctx.JSON(http.StatusServiceUnavailable, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_TENANT_KEY_UNAVAILABLE",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrTenantKeyVersion):
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_TENANT_KEY_VERSION",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrSecretNamespaceDenied):
+		ctx.JSON(http.StatusForbidden, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_FORBIDDEN",
+				Message: "No write access to namespace",
+			},
+		})
+	case errors.Is(err, services.ErrSecretProjectDenied):
+		ctx.JSON(http.StatusForbidden, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_FORBIDDEN",
+				Message: "No write access to project",
+			},
+		})
+	case errors.Is(err, services.ErrProjectNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_PROJECT_NOT_FOUND",
+				Message: "Project not found",
+			},
+		})
+	case errors.Is(err, services.ErrSecretClientEncrypted), errors.Is(err, services.ErrSecretEnvelopeInvalid):
+		ctx.JSON(http.StatusUnprocessableEntity, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_SECRET_CLIENT_ENCRYPTED",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrSecretQuarantined):
+		ctx.JSON(http.StatusLocked, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_SECRET_QUARANTINED",
+				Message: err.Error(),
+			},
+		})
+	default:
+		return false
+	}
+	return true
+}
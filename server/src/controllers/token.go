@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type TokenController struct {
+	tokenService *services.TokenService
+}
+
+func NewTokenController(tokenService *services.TokenService) *TokenController {
+	return &TokenController{
+		tokenService: tokenService,
+	}
+}
+
+// Lookup returns the calling token's record, or with an accessor in the
+// body, the record of that token
+func (c *TokenController) Lookup(ctx *gin.Context) {
+	accessor := tokenAccessor(ctx)
+	if ctx.Request.ContentLength > 0 {
+		var req model.TokenAccessorRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INVALID_REQUEST",
+					Message: "Invalid request format",
+				},
+			})
+			return
+		}
+		accessor = &req.Accessor
+	}
+	if accessor == nil {
+		c.respondError(ctx, services.ErrTokenNotFound, "")
+		return
+	}
+
+	token, err := c.tokenService.Lookup(*accessor, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to look up token")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, token)
+}
+
+// Renew extends the calling token
+func (c *TokenController) Renew(ctx *gin.Context) {
+	var req model.RenewTokenRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INVALID_REQUEST",
+					Message: "Invalid request format",
+				},
+			})
+			return
+		}
+	}
+	accessor := tokenAccessor(ctx)
+	if accessor == nil {
+		c.respondError(ctx, services.ErrTokenNotFound, "")
+		return
+	}
+
+	token, err := c.tokenService.Renew(*accessor, req.Increment, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.respondError(ctx, err, "Failed to renew token")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, token)
+}
+
+// Revoke revokes the calling token and its children
+func (c *TokenController) Revoke(ctx *gin.Context) {
+	accessor := tokenAccessor(ctx)
+	if accessor == nil {
+		c.respondError(ctx, services.ErrTokenNotFound, "")
+		return
+	}
+
+	if err := c.tokenService.Revoke(*accessor, ctx.MustGet("user_id").(uuid.UUID)); err != nil {
+		c.respondError(ctx, err, "Failed to revoke token")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Token revoked"})
+}
+
+// RevokeAccessor revokes the token with the given accessor and its
+// children
+func (c *TokenController) RevokeAccessor(ctx *gin.Context) {
+	var req model.TokenAccessorRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	if err := c.tokenService.Revoke(req.Accessor, ctx.MustGet("user_id").(uuid.UUID)); err != nil {
+		c.respondError(ctx, err, "Failed to revoke token")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Token revoked"})
+}
+
+func (c *TokenController) respondError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrTokenNotFound), errors.Is(err, services.ErrTokenRevoked):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_TOKEN_NOT_FOUND",
+				Message: "Token not found",
+			},
+		})
+	case errors.Is(err, services.ErrTokenNotRenewable):
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_TOKEN_NOT_RENEWABLE",
+				Message: err.Error(),
+			},
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: message,
+			},
+		})
+	}
+}
+
+// tokenAccessor returns the accessor of the calling token, which is only
+// known once the token store is in use
+func tokenAccessor(ctx *gin.Context) *uuid.UUID {
+	value, ok := ctx.Get("token_accessor")
+	if !ok {
+		return nil
+	}
+	accessor := value.(uuid.UUID)
+	return &accessor
+}
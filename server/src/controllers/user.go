@@ -11,16 +11,18 @@ import (
 )
 
 type UserController struct {
-	userService  *services.UserService
-	auditService *services.AuditService
-	db           *gorm.DB
+	userService    *services.UserService
+	auditService   *services.AuditService
+	passwordPolicy *services.PasswordPolicyService
+	db             *gorm.DB
 }
 
-func NewUserController(userService *services.UserService, auditService *services.AuditService) *UserController {
+func NewUserController(userService *services.UserService, auditService *services.AuditService, passwordPolicy *services.PasswordPolicyService) *UserController {
 	return &UserController{
-		userService:  userService,
-		auditService: auditService,
-		db:           userService.GetDB(),
+		userService:    userService,
+		auditService:   auditService,
+		passwordPolicy: passwordPolicy,
+		db:             userService.GetDB(),
 	}
 }
 
@@ -88,7 +90,7 @@ func (c *UserController) GetUser(ctx *gin.Context) {
 func (c *UserController) CreateUser(ctx *gin.Context) {
 	var req struct {
 		Email     string `json:"email" binding:"required,email"`
-		Password  string `json:"password" binding:"required,min=8"`
+		Password  string `json:"password" binding:"required"`
 		FirstName string `json:"first_name" binding:"required"`
 		LastName  string `json:"last_name" binding:"required"`
 	}
@@ -103,6 +105,11 @@ func (c *UserController) CreateUser(ctx *gin.Context) {
 		return
 	}
 
+	if err := c.passwordPolicy.Enforce(req.Password, []string{req.Email, req.FirstName, req.LastName}); err != nil {
+		respondPasswordPolicy(ctx, err)
+		return
+	}
+
 	user := &model.User{
 		Email:     req.Email,
 		Password:  req.Password,
@@ -130,6 +137,76 @@ func (c *UserController) CreateUser(ctx *gin.Context) {
 	ctx.JSON(http.StatusCreated, user)
 }
 
+// ChangePassword replaces the caller's password once they prove they know
+// the current one
+func (c *UserController) ChangePassword(ctx *gin.Context) {
+	var req model.ChangePasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	userID := ctx.MustGet("user_id").(uuid.UUID)
+	user, err := c.userService.GetUserByID(userID)
+	if err != nil {
+		if err == services.ErrUserNotFound {
+			ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_USER_NOT_FOUND",
+					Message: "User not found",
+				},
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: "Failed to retrieve user",
+			},
+		})
+		return
+	}
+
+	if !c.userService.ValidatePassword(user, req.CurrentPassword) {
+		if c.auditService != nil {
+			c.auditService.LogAction(userID, "password_changed", "user", userID.String(), false, "current password rejected")
+		}
+		ctx.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_CREDENTIALS",
+				Message: "Current password is incorrect",
+			},
+		})
+		return
+	}
+
+	if err := c.passwordPolicy.Enforce(req.NewPassword, []string{user.Email, user.FirstName, user.LastName, req.CurrentPassword}); err != nil {
+		respondPasswordPolicy(ctx, err)
+		return
+	}
+
+	if err := c.userService.SetPassword(user, req.NewPassword); err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: "Failed to change password",
+			},
+		})
+		return
+	}
+
+	if c.auditService != nil {
+		c.auditService.LogAction(userID, "password_changed", "user", userID.String(), true, "")
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Password changed"})
+}
+
 func (c *UserController) UpdateUser(ctx *gin.Context) {
 	idParam := ctx.Param("id")
 	id, err := uuid.Parse(idParam)
@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type SecretValidatorController struct {
+	validatorService *services.SecretValidatorService
+}
+
+func NewSecretValidatorController(validatorService *services.SecretValidatorService) *SecretValidatorController {
+	return &SecretValidatorController{
+		validatorService: validatorService,
+	}
+}
+
+func (c *SecretValidatorController) GetValidators(ctx *gin.Context) {
+	validators, err := c.validatorService.GetValidators()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: "Failed to retrieve validators",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"validators": validators})
+}
+
+func (c *SecretValidatorController) CreateValidator(ctx *gin.Context) {
+	var req model.CreateSecretValidatorRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	validator, err := c.validatorService.CreateValidator(&req, ctx.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		if errors.Is(err, services.ErrValidatorInvalid) {
+			ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INVALID_VALIDATOR",
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: "Failed to create validator",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, validator)
+}
+
+func (c *SecretValidatorController) DeleteValidator(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_ID",
+				Message: "Invalid validator ID",
+			},
+		})
+		return
+	}
+
+	if err := c.validatorService.DeleteValidator(id, ctx.MustGet("user_id").(uuid.UUID)); err != nil {
+		if errors.Is(err, services.ErrValidatorNotFound) {
+			ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_VALIDATOR_NOT_FOUND",
+					Message: "Validator not found",
+				},
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: "Failed to delete validator",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Validator deleted successfully"})
+}
@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type WorkloadController struct {
+	workloadService *services.WorkloadService
+}
+
+func NewWorkloadController(workloadService *services.WorkloadService) *WorkloadController {
+	return &WorkloadController{
+		workloadService: workloadService,
+	}
+}
+
+// AttachWorkload records the provenance of the calling build and returns a
+// token bound to it, so the audit entries of its requests name the build
+func (c *WorkloadController) AttachWorkload(ctx *gin.Context) {
+	var req model.AttachWorkloadRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+	if c.workloadService == nil {
+		c.respondError(ctx, services.ErrWorkloadsUnavailable, "")
+		return
+	}
+
+	token := strings.TrimPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+	response, err := c.workloadService.Attach(&req, ctx.MustGet("user_id").(uuid.UUID), token)
+	if err != nil {
+		c.respondError(ctx, err, "Failed to attach workload")
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetWorkloads lists the builds that authenticated, filtered by service,
+// image_digest or git_sha
+func (c *WorkloadController) GetWorkloads(ctx *gin.Context) {
+	if c.workloadService == nil {
+		c.respondError(ctx, services.ErrWorkloadsUnavailable, "")
+		return
+	}
+
+	limit, _ := strconv.Atoi(ctx.Query("limit"))
+	workloads, err := c.workloadService.GetWorkloads(model.WorkloadFilter{
+		Service:     ctx.Query("service"),
+		ImageDigest: ctx.Query("image_digest"),
+		GitSHA:      ctx.Query("git_sha"),
+	}, limit)
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve workloads")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"workloads": workloads})
+}
+
+// GetWorkloadLogs returns a workload and the audit entries of its requests
+func (c *WorkloadController) GetWorkloadLogs(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_ID",
+				Message: "Invalid workload ID",
+			},
+		})
+		return
+	}
+	if c.workloadService == nil {
+		c.respondError(ctx, services.ErrWorkloadsUnavailable, "")
+		return
+	}
+
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	offset, err := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	workload, err := c.workloadService.GetWorkload(id)
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve workload")
+		return
+	}
+	logs, err := c.workloadService.GetAuditLogs(id, limit, offset)
+	if err != nil {
+		c.respondError(ctx, err, "Failed to retrieve audit logs")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"workload": workload, "logs": logs, "limit": limit, "offset": offset})
+}
+
+func (c *WorkloadController) respondError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrWorkloadInvalid):
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrWorkloadNotFound):
+		ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_WORKLOAD_NOT_FOUND",
+				Message: err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrWorkloadsUnavailable):
+		ctx.JSON(http.StatusServiceUnavailable, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_SERVICE_UNAVAILABLE",
+				Message: err.Error(),
+			},
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INTERNAL_ERROR",
+				Message: message,
+			},
+		})
+	}
+}
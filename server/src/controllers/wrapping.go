@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type WrappingController struct {
+	wrappingService *services.WrappingService
+}
+
+func NewWrappingController(wrappingService *services.WrappingService) *WrappingController {
+	return &WrappingController{
+		wrappingService: wrappingService,
+	}
+}
+
+// Unwrap redeems a wrapping token. The token is the credential, so the
+// route needs no session. A token reported as already used was redeemed
+// by someone else: whoever handed it over should treat the secret as
+// exposed.
+func (c *WrappingController) Unwrap(ctx *gin.Context) {
+	var req model.UnwrapRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_INVALID_REQUEST",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+
+	response, err := c.wrappingService.Unwrap(req.Token, ctx.ClientIP(), ctx.GetHeader("User-Agent"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrWrapTokenInvalid):
+			ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_WRAP_TOKEN_INVALID",
+					Message: err.Error(),
+				},
+			})
+		case errors.Is(err, services.ErrWrapTokenUsed):
+			ctx.JSON(http.StatusGone, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_WRAP_TOKEN_USED",
+					Message: err.Error(),
+				},
+			})
+		case errors.Is(err, services.ErrWrapTokenExpired):
+			ctx.JSON(http.StatusGone, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_WRAP_TOKEN_EXPIRED",
+					Message: err.Error(),
+				},
+			})
+		default:
+			ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INTERNAL_ERROR",
+					Message: "Failed to unwrap response",
+				},
+			})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
@@ -2,9 +2,11 @@ package middleware
 
 import (
 	"bytes"
-	"fmt"
+	"encoding/json"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
 	"github.com/skygenesisenterprise/aether-vault/server/src/services"
 	"io"
+	"sort"
 	"strings"
 	"time"
 
@@ -12,6 +14,27 @@ import (
 	"github.com/google/uuid"
 )
 
+const (
+	auditSkipKey      = "audit_skip"
+	maxAuditBodyBytes = 1 << 20
+)
+
+// sensitiveAuditFields are request fields whose values are only recorded
+// as an HMAC
+var sensitiveAuditFields = map[string]bool{
+	"password":    true,
+	"value":       true,
+	"secret":      true,
+	"token":       true,
+	"passphrase":  true,
+	"private_key": true,
+	"ciphertext":  true,
+	"code":        true,
+	"backup":      true,
+	"variables":   true,
+	"input":       true,
+}
+
 type AuditMiddleware struct {
 	auditService *services.AuditService
 }
@@ -22,38 +45,73 @@ func NewAuditMiddleware(auditService *services.AuditService) *AuditMiddleware {
 	}
 }
 
+// Audit records an entry for every request once the handler chain has
+// finished, unless the route opted out with Skip.
 func (m *AuditMiddleware) Audit() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
+		if m.auditService == nil {
+			ctx.Next()
+			return
+		}
+
 		start := time.Now()
 
-		requestID := uuid.New().String()
-		ctx.Set("request_id", requestID)
+		if ctx.GetString("request_id") == "" {
+			ctx.Set("request_id", uuid.New().String())
+		}
 
 		var body []byte
 		if ctx.Request.Body != nil {
-			body, _ = io.ReadAll(ctx.Request.Body)
+			body, _ = io.ReadAll(io.LimitReader(ctx.Request.Body, maxAuditBodyBytes))
 			ctx.Request.Body = io.NopCloser(bytes.NewBuffer(body))
 		}
 
 		ctx.Next()
 
+		if ctx.GetBool(auditSkipKey) {
+			return
+		}
+
 		duration := time.Since(start)
 		statusCode := ctx.Writer.Status()
 
-		userID, _ := ctx.Get("user_id")
-
-		action := m.getActionFromRequest(ctx)
-		resource := m.getResourceFromRequest(ctx)
 		resourceID := m.getResourceIDFromRequest(ctx)
+		entry := &model.AuditLog{
+			Action:     m.getActionFromRequest(ctx),
+			Resource:   m.getResourceFromRequest(ctx),
+			ResourceID: &resourceID,
+			IPAddress:  ctx.ClientIP(),
+			UserAgent:  ctx.GetHeader("User-Agent"),
+			Success:    statusCode < 400,
+			Details:    m.getAuditDetails(ctx, body, duration, statusCode),
+			RequestID:  ctx.GetString("request_id"),
+			Method:     ctx.Request.Method,
+			Route:      ctx.FullPath(),
+			StatusCode: statusCode,
+			LatencyMs:  duration.Milliseconds(),
+			ParamsHash: m.getParamsHash(ctx, body),
+		}
 
-		success := statusCode < 400
-		details := m.getAuditDetails(ctx, body, duration, statusCode)
-
-		if uid, ok := userID.(uuid.UUID); ok {
-			m.auditService.LogAction(uid, action, resource, resourceID, success, details)
-		} else {
-			m.auditService.LogAnonymousAction(action, resource, resourceID, ctx.ClientIP(), ctx.GetHeader("User-Agent"), success, details)
+		if uid, ok := ctx.Get("user_id"); ok {
+			if userID, ok := uid.(uuid.UUID); ok {
+				entry.UserID = &userID
+			}
+		}
+		if wid, ok := ctx.Get("workload_id"); ok {
+			if workloadID, ok := wid.(uuid.UUID); ok {
+				entry.WorkloadID = &workloadID
+			}
 		}
+
+		m.auditService.LogRequest(entry)
+	}
+}
+
+// Skip opts a single route out of request auditing
+func (m *AuditMiddleware) Skip() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Set(auditSkipKey, true)
+		ctx.Next()
 	}
 }
 
@@ -103,6 +161,16 @@ func (m *AuditMiddleware) getResourceFromRequest(ctx *gin.Context) string {
 		return "identity"
 	case strings.Contains(path, "/audit"):
 		return "audit"
+	case strings.Contains(path, "/share"):
+		return "share"
+	case strings.Contains(path, "/sys/generate"):
+		return "generate"
+	case strings.Contains(path, "/namespaces"):
+		return "namespace"
+	case strings.Contains(path, "/policies"):
+		return "policy"
+	case strings.Contains(path, "/templates"):
+		return "template"
 	case strings.Contains(path, "/health"):
 		return "system"
 	case strings.Contains(path, "/version"):
@@ -124,18 +192,86 @@ func (m *AuditMiddleware) getAuditDetails(ctx *gin.Context, body []byte, duratio
 	details := map[string]interface{}{
 		"method":      ctx.Request.Method,
 		"path":        ctx.Request.URL.Path,
-		"query":       ctx.Request.URL.RawQuery,
-		"user_agent":  ctx.GetHeader("User-Agent"),
-		"ip_address":  ctx.ClientIP(),
 		"duration_ms": duration.Milliseconds(),
 		"status_code": statusCode,
 	}
 
+	if query := ctx.Request.URL.Query(); len(query) > 0 {
+		redacted := make(map[string]interface{}, len(query))
+		for key, values := range query {
+			if sensitiveAuditFields[strings.ToLower(key)] {
+				redacted[key] = m.auditService.HMAC([]byte(strings.Join(values, ",")))
+			} else {
+				redacted[key] = values
+			}
+		}
+		details["query"] = redacted
+	}
+
+	if value, ok := ctx.Get("impersonation"); ok {
+		if impersonation, ok := value.(*model.Impersonation); ok {
+			details["impersonation_id"] = impersonation.ID
+			details["impersonated_by"] = impersonation.AdminID
+		}
+	}
+
 	if len(body) > 0 && !m.isSensitiveEndpoint(ctx) {
-		details["request_body"] = string(body)
+		var parsed interface{}
+		if err := json.Unmarshal(body, &parsed); err == nil {
+			details["request_body"] = m.redactFields(parsed)
+		}
 	}
 
-	return fmt.Sprintf("%+v", details)
+	encoded, err := json.Marshal(details)
+	if err != nil {
+		return ""
+	}
+	return string(encoded)
+}
+
+// getParamsHash returns a keyed hash over the route, path parameters,
+// query and body, so identical requests can be correlated
+func (m *AuditMiddleware) getParamsHash(ctx *gin.Context, body []byte) string {
+	params := make([]string, 0, len(ctx.Params))
+	for _, param := range ctx.Params {
+		params = append(params, param.Key+"="+param.Value)
+	}
+	sort.Strings(params)
+
+	var buf bytes.Buffer
+	buf.WriteString(ctx.Request.Method + " " + ctx.FullPath() + "\n")
+	buf.WriteString(strings.Join(params, "&") + "\n")
+	buf.WriteString(ctx.Request.URL.RawQuery + "\n")
+	buf.Write(body)
+
+	return m.auditService.HMAC(buf.Bytes())
+}
+
+// redactFields replaces the values of sensitive JSON fields with their HMAC
+func (m *AuditMiddleware) redactFields(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range v {
+			if sensitiveAuditFields[strings.ToLower(key)] {
+				if str, ok := nested.(string); ok {
+					v[key] = m.auditService.HMAC([]byte(str))
+				} else {
+					encoded, _ := json.Marshal(nested)
+					v[key] = m.auditService.HMAC(encoded)
+				}
+			} else {
+				v[key] = m.redactFields(nested)
+			}
+		}
+		return v
+	case []interface{}:
+		for i, nested := range v {
+			v[i] = m.redactFields(nested)
+		}
+		return v
+	default:
+		return v
+	}
 }
 
 func (m *AuditMiddleware) isSensitiveEndpoint(ctx *gin.Context) bool {
@@ -143,9 +279,14 @@ func (m *AuditMiddleware) isSensitiveEndpoint(ctx *gin.Context) bool {
 	method := ctx.Request.Method
 
 	sensitiveEndpoints := map[string]bool{
-		"POST:/api/v1/auth/login": true,
-		"POST:/api/v1/secrets":    true,
-		"PUT:/api/v1/secrets":     true,
+		"POST:/api/v1/auth/login":   true,
+		"POST:/api/v1/secrets":      true,
+		"PUT:/api/v1/secrets":       true,
+		"POST:/api/v1/share":        true,
+		"POST:/api/v1/sys/generate": true,
+		"POST:/api/v1/totp/export":  true,
+		"POST:/api/v1/totp/import":  true,
+		"POST:/api/v1/audit/hash":   true,
 	}
 
 	key := method + ":" + path
@@ -1,27 +1,43 @@
 package middleware
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"github.com/skygenesisenterprise/aether-vault/server/src/model"
 	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+	"log"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
+// impersonationHeader names the administrator on every response to an
+// impersonation token, so clients can show a banner
+const impersonationHeader = "X-Vault-Impersonated-By"
+
+// deviceHeader lets clients name the device they run on, for session
+// listings; without it the device is told apart by its user agent
+const deviceHeader = "X-Vault-Device-ID"
+
 type AuthMiddleware struct {
-	authService *services.AuthService
+	authService    *services.AuthService
+	sessionService *services.SessionService
 }
 
-func NewAuthMiddleware(authService *services.AuthService) *AuthMiddleware {
+func NewAuthMiddleware(authService *services.AuthService, sessionService *services.SessionService) *AuthMiddleware {
 	return &AuthMiddleware{
-		authService: authService,
+		authService:    authService,
+		sessionService: sessionService,
 	}
 }
 
 func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authHeader := ctx.GetHeader("Authorization")
+		if authHeader == "" {
+			authHeader = websocketBearer(ctx.Request)
+		}
 		if authHeader == "" {
 			ctx.JSON(http.StatusUnauthorized, model.ErrorResponse{
 				Error: model.ErrorDetail{
@@ -45,7 +61,7 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 			return
 		}
 
-		userID, err := m.authService.ValidateToken(tokenParts[1])
+		session, err := m.authService.Authenticate(tokenParts[1])
 		if err != nil {
 			ctx.JSON(http.StatusUnauthorized, model.ErrorResponse{
 				Error: model.ErrorDetail{
@@ -57,7 +73,56 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 			return
 		}
 
-		ctx.Set("user_id", *userID)
+		ctx.Set("user_id", session.UserID)
+		if session.Impersonation != nil {
+			ctx.Set("impersonation", session.Impersonation)
+			ctx.Header(impersonationHeader, session.Impersonation.AdminEmail)
+		}
+		if session.WorkloadID != nil {
+			ctx.Set("workload_id", *session.WorkloadID)
+		}
+		if session.Accessor != nil {
+			ctx.Set("token_accessor", *session.Accessor)
+			if m.sessionService != nil {
+				if err := m.sessionService.Touch(*session.Accessor, session.UserID, requestDevice(ctx)); err != nil {
+					log.Printf("⚠️  Failed to track session: %v", err)
+				}
+			}
+		}
 		ctx.Next()
 	}
 }
+
+// requestDevice describes the device a request came from
+func requestDevice(ctx *gin.Context) model.SessionDevice {
+	userAgent := ctx.GetHeader("User-Agent")
+	fingerprint := strings.TrimSpace(ctx.GetHeader(deviceHeader))
+	if len(fingerprint) > 128 {
+		fingerprint = fingerprint[:128]
+	}
+	if fingerprint == "" {
+		sum := sha256.Sum256([]byte(userAgent + "\n" + ctx.GetHeader("Accept-Language")))
+		fingerprint = hex.EncodeToString(sum[:16])
+	}
+	return model.SessionDevice{
+		Fingerprint: fingerprint,
+		IPAddress:   ctx.ClientIP(),
+		UserAgent:   userAgent,
+	}
+}
+
+// websocketBearer returns the token a browser WebSocket offered as a
+// "bearer.<token>" subprotocol, as an Authorization header value
+func websocketBearer(req *http.Request) string {
+	if !strings.EqualFold(req.Header.Get("Upgrade"), "websocket") {
+		return ""
+	}
+	for _, header := range req.Header.Values("Sec-WebSocket-Protocol") {
+		for _, protocol := range strings.Split(header, ",") {
+			if token, ok := strings.CutPrefix(strings.TrimSpace(protocol), "bearer."); ok && token != "" {
+				return "Bearer " + token
+			}
+		}
+	}
+	return ""
+}
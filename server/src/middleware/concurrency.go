@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestClass groups routes that share a concurrency budget
+type RequestClass string
+
+const (
+	RequestClassRead  RequestClass = "read"
+	RequestClassWrite RequestClass = "write"
+	RequestClassAuth  RequestClass = "auth"
+	// RequestClassStream is long-lived WebSocket connections; they would
+	// hold a slot for hours, so they are not limited here and the event
+	// feed caps its own subscribers
+	RequestClassStream RequestClass = "stream"
+)
+
+// ConcurrencyMiddleware caps in-flight requests per class. Requests over the
+// cap wait up to queueTimeout for a slot; when the queue is full or the wait
+// expires the request is shed with 503 and Retry-After.
+type ConcurrencyMiddleware struct {
+	classes      map[RequestClass]*concurrencyClass
+	queueTimeout time.Duration
+	retryAfter   time.Duration
+}
+
+type concurrencyClass struct {
+	slots    chan struct{}
+	maxQueue int64
+	waiting  atomic.Int64
+	shed     atomic.Int64
+}
+
+// ConcurrencyStats is a point-in-time view of one request class
+type ConcurrencyStats struct {
+	Class    RequestClass `json:"class"`
+	InFlight int          `json:"in_flight"`
+	Limit    int          `json:"limit"`
+	Waiting  int64        `json:"waiting"`
+	Shed     int64        `json:"shed"`
+}
+
+func NewConcurrencyMiddleware(limits map[RequestClass]int, queueTimeout, retryAfter time.Duration) *ConcurrencyMiddleware {
+	classes := make(map[RequestClass]*concurrencyClass, len(limits))
+	for class, limit := range limits {
+		if limit <= 0 {
+			continue
+		}
+		classes[class] = &concurrencyClass{
+			slots:    make(chan struct{}, limit),
+			maxQueue: int64(limit),
+		}
+	}
+
+	return &ConcurrencyMiddleware{
+		classes:      classes,
+		queueTimeout: queueTimeout,
+		retryAfter:   retryAfter,
+	}
+}
+
+func (m *ConcurrencyMiddleware) Limit() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		class, exists := m.classes[classifyRequest(ctx.Request)]
+		if !exists {
+			ctx.Next()
+			return
+		}
+
+		if !m.acquire(ctx, class) {
+			class.shed.Add(1)
+			ctx.Header("Retry-After", strconv.Itoa(int(m.retryAfter.Seconds())))
+			ctx.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": gin.H{
+					"code":    "VAULT_OVERLOADED",
+					"message": "Server is at capacity, retry later",
+				},
+			})
+			ctx.Abort()
+			return
+		}
+		defer func() { <-class.slots }()
+
+		ctx.Next()
+	}
+}
+
+// Stats reports the current state of every limited class
+func (m *ConcurrencyMiddleware) Stats() []ConcurrencyStats {
+	stats := make([]ConcurrencyStats, 0, len(m.classes))
+	for _, name := range []RequestClass{RequestClassRead, RequestClassWrite, RequestClassAuth} {
+		class, exists := m.classes[name]
+		if !exists {
+			continue
+		}
+		stats = append(stats, ConcurrencyStats{
+			Class:    name,
+			InFlight: len(class.slots),
+			Limit:    cap(class.slots),
+			Waiting:  class.waiting.Load(),
+			Shed:     class.shed.Load(),
+		})
+	}
+	return stats
+}
+
+func (m *ConcurrencyMiddleware) acquire(ctx *gin.Context, class *concurrencyClass) bool {
+	select {
+	case class.slots <- struct{}{}:
+		return true
+	default:
+	}
+
+	if m.queueTimeout <= 0 {
+		return false
+	}
+
+	if class.waiting.Add(1) > class.maxQueue {
+		class.waiting.Add(-1)
+		return false
+	}
+	defer class.waiting.Add(-1)
+
+	timer := time.NewTimer(m.queueTimeout)
+	defer timer.Stop()
+
+	select {
+	case class.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-ctx.Request.Context().Done():
+		return false
+	}
+}
+
+// classifyRequest maps a request to its class: authentication endpoints,
+// WebSocket upgrades, safe methods (reads) and everything else (writes)
+func classifyRequest(req *http.Request) RequestClass {
+	if strings.HasPrefix(req.URL.Path, "/api/v1/auth/") {
+		return RequestClassAuth
+	}
+	if strings.EqualFold(req.Header.Get("Upgrade"), "websocket") {
+		return RequestClassStream
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return RequestClassRead
+	default:
+		return RequestClassWrite
+	}
+}
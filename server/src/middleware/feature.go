@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type FeatureMiddleware struct {
+	featureFlagService *services.FeatureFlagService
+}
+
+func NewFeatureMiddleware(featureFlagService *services.FeatureFlagService) *FeatureMiddleware {
+	return &FeatureMiddleware{
+		featureFlagService: featureFlagService,
+	}
+}
+
+// Require answers 404 while the feature is disabled, so a disabled
+// subsystem looks absent rather than forbidden
+func (m *FeatureMiddleware) Require(feature string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !m.featureFlagService.Enabled(feature) {
+			ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_FEATURE_DISABLED",
+					Message: "The " + feature + " feature is disabled",
+				},
+			})
+			ctx.Abort()
+			return
+		}
+		ctx.Next()
+	}
+}
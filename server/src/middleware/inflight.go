@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+)
+
+// InFlightMiddleware tracks requests that are currently being served so
+// long-running ones can be listed while they are still running
+type InFlightMiddleware struct {
+	requests map[uint64]*inFlightRequest
+	mutex    sync.RWMutex
+	sequence atomic.Uint64
+}
+
+type inFlightRequest struct {
+	requestID string
+	method    string
+	path      string
+	clientIP  string
+	startedAt time.Time
+}
+
+func NewInFlightMiddleware() *InFlightMiddleware {
+	return &InFlightMiddleware{
+		requests: make(map[uint64]*inFlightRequest),
+	}
+}
+
+// Track registers the request for its lifetime; it must run after
+// RequestIDMiddleware
+func (m *InFlightMiddleware) Track() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := m.sequence.Add(1)
+
+		m.mutex.Lock()
+		m.requests[id] = &inFlightRequest{
+			requestID: ctx.GetString("request_id"),
+			method:    ctx.Request.Method,
+			path:      ctx.Request.URL.Path,
+			clientIP:  ctx.ClientIP(),
+			startedAt: time.Now(),
+		}
+		m.mutex.Unlock()
+
+		defer func() {
+			m.mutex.Lock()
+			delete(m.requests, id)
+			m.mutex.Unlock()
+		}()
+
+		ctx.Next()
+	}
+}
+
+// Snapshot returns requests running for at least minDuration, longest first
+func (m *InFlightMiddleware) Snapshot(minDuration time.Duration) []model.InFlightRequest {
+	now := time.Now()
+
+	m.mutex.RLock()
+	requests := make([]model.InFlightRequest, 0, len(m.requests))
+	for _, request := range m.requests {
+		elapsed := now.Sub(request.startedAt)
+		if elapsed < minDuration {
+			continue
+		}
+
+		requests = append(requests, model.InFlightRequest{
+			RequestID: request.requestID,
+			Method:    request.method,
+			Path:      request.path,
+			ClientIP:  request.clientIP,
+			StartedAt: request.startedAt,
+			ElapsedMs: elapsed.Milliseconds(),
+		})
+	}
+	m.mutex.RUnlock()
+
+	sort.Slice(requests, func(i, j int) bool {
+		return requests[i].ElapsedMs > requests[j].ElapsedMs
+	})
+
+	return requests
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+// ReadOnlyHeader is set on every response while the server is in
+// read-only maintenance mode, so clients can warn before they try a write
+const ReadOnlyHeader = "X-Vault-Read-Only"
+
+type MaintenanceMiddleware struct {
+	maintenanceService *services.MaintenanceService
+}
+
+func NewMaintenanceMiddleware(maintenanceService *services.MaintenanceService) *MaintenanceMiddleware {
+	return &MaintenanceMiddleware{
+		maintenanceService: maintenanceService,
+	}
+}
+
+// Announce marks responses while read-only mode is on
+func (m *MaintenanceMiddleware) Announce() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if m.maintenanceService.ReadOnly() {
+			ctx.Header(ReadOnlyHeader, "true")
+		}
+		ctx.Next()
+	}
+}
+
+// RejectWrites answers 503 while read-only mode is on
+func (m *MaintenanceMiddleware) RejectWrites() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !m.maintenanceService.ReadOnly() {
+			ctx.Next()
+			return
+		}
+
+		message := "The server is in read-only maintenance mode; writes are rejected"
+		if reason := m.maintenanceService.Status().Reason; reason != "" {
+			message += ": " + reason
+		}
+		ctx.JSON(http.StatusServiceUnavailable, model.ErrorResponse{
+			Error: model.ErrorDetail{
+				Code:    "VAULT_READ_ONLY",
+				Message: message,
+			},
+		})
+		ctx.Abort()
+	}
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+// MountMiddleware resolves the KV mount a secrets route works in
+type MountMiddleware struct {
+	mountService  *services.MountService
+	secretService *services.SecretService
+}
+
+func NewMountMiddleware(mountService *services.MountService, secretService *services.SecretService) *MountMiddleware {
+	return &MountMiddleware{
+		mountService:  mountService,
+		secretService: secretService,
+	}
+}
+
+// KV stores the mount named by the :mount parameter, or path when it is
+// set, as "mount". A secret addressed by :id must live in that mount, so
+// one mount's routes never reach another mount's secrets.
+func (m *MountMiddleware) KV(path string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		mount := path
+		if mount == "" {
+			mount = ctx.Param("mount")
+		}
+		if !m.resolve(ctx, mount) {
+			return
+		}
+		ctx.Next()
+	}
+}
+
+func (m *MountMiddleware) resolve(ctx *gin.Context, path string) bool {
+	if path != model.DefaultKVMount {
+		if m.mountService == nil {
+			m.notFound(ctx, "VAULT_MOUNT_NOT_FOUND", "Mount not found")
+			return false
+		}
+		mount, err := m.mountService.GetMount(path)
+		if errors.Is(err, services.ErrMountNotFound) || (err == nil && mount.Type != model.MountTypeKV) {
+			m.notFound(ctx, "VAULT_MOUNT_NOT_FOUND", "No KV mount at "+path)
+			return false
+		}
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INTERNAL_ERROR",
+					Message: "Failed to resolve mount",
+				},
+			})
+			ctx.Abort()
+			return false
+		}
+	}
+
+	if id, err := uuid.Parse(ctx.Param("id")); err == nil && m.secretService != nil {
+		mount, err := m.secretService.MountOf(id)
+		// A missing secret is reported by the handler itself
+		if err == nil && mount != path {
+			m.notFound(ctx, "VAULT_SECRET_NOT_FOUND", "Secret not found")
+			return false
+		}
+	}
+
+	ctx.Set("mount", path)
+	return true
+}
+
+func (m *MountMiddleware) notFound(ctx *gin.Context, code, message string) {
+	ctx.JSON(http.StatusNotFound, model.ErrorResponse{
+		Error: model.ErrorDetail{
+			Code:    code,
+			Message: message,
+		},
+	})
+	ctx.Abort()
+}
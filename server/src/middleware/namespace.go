@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+type NamespaceMiddleware struct {
+	userMiddleware   *UserMiddleware
+	namespaceService *services.NamespaceService
+}
+
+func NewNamespaceMiddleware(userMiddleware *UserMiddleware, namespaceService *services.NamespaceService) *NamespaceMiddleware {
+	return &NamespaceMiddleware{
+		userMiddleware:   userMiddleware,
+		namespaceService: namespaceService,
+	}
+}
+
+// RequirePermission allows central admins, and users whose role in the
+// namespace named by the :id parameter grants permission.
+func (m *NamespaceMiddleware) RequirePermission(permission model.NamespacePermission) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		currentUserID, exists := ctx.Get("user_id")
+		if !exists {
+			ctx.JSON(http.StatusUnauthorized, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_UNAUTHORIZED",
+					Message: "Unauthorized",
+				},
+			})
+			ctx.Abort()
+			return
+		}
+
+		namespaceID, err := uuid.Parse(ctx.Param("id"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INVALID_ID",
+					Message: "Invalid namespace ID",
+				},
+			})
+			ctx.Abort()
+			return
+		}
+
+		user, err := m.userMiddleware.userService.GetUserByID(currentUserID.(uuid.UUID))
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_UNAUTHORIZED",
+					Message: "Unauthorized",
+				},
+			})
+			ctx.Abort()
+			return
+		}
+
+		if m.userMiddleware.isAdmin(user) {
+			ctx.Next()
+			return
+		}
+
+		allowed, err := m.namespaceService.HasPermission(user.ID, namespaceID, permission)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INTERNAL_ERROR",
+					Message: "Failed to check namespace permissions",
+				},
+			})
+			ctx.Abort()
+			return
+		}
+
+		if !allowed {
+			ctx.JSON(http.StatusForbidden, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_ACCESS_DENIED",
+					Message: "Access denied: missing namespace permission " + string(permission),
+				},
+			})
+			ctx.Abort()
+			return
+		}
+
+		ctx.Next()
+	}
+}
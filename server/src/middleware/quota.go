@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+// QuotaMiddleware counts requests against the namespace they address
+type QuotaMiddleware struct {
+	quotaService *services.QuotaService
+}
+
+func NewQuotaMiddleware(quotaService *services.QuotaService) *QuotaMiddleware {
+	return &QuotaMiddleware{
+		quotaService: quotaService,
+	}
+}
+
+// Count records a request to the namespace named by the :id parameter;
+// routes without one pass through uncounted
+func (m *QuotaMiddleware) Count() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if namespaceID, err := uuid.Parse(ctx.Param("id")); err == nil {
+			m.quotaService.RecordRequest(namespaceID)
+		}
+		ctx.Next()
+	}
+}
@@ -203,7 +203,7 @@ func (m *UserMiddleware) ValidateUserExists() gin.HandlerFunc {
 }
 
 func (m *UserMiddleware) isAdmin(user *model.User) bool {
-	return user.Email == "admin@aether-vault.local"
+	return services.IsCentralAdmin(user)
 }
 
 func (m *UserMiddleware) CanAccessResource(ctx *gin.Context, resourceType string, resourceID uuid.UUID) bool {
@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"github.com/skygenesisenterprise/aether-vault/server/src/services"
+)
+
+// WrapMiddleware answers reads asked for with ?wrap_ttl= with a single-use
+// wrapping token instead of the response itself
+type WrapMiddleware struct {
+	wrapping *services.WrappingService
+}
+
+func NewWrapMiddleware(wrapping *services.WrappingService) *WrapMiddleware {
+	return &WrapMiddleware{wrapping: wrapping}
+}
+
+// Wrap holds the handler's response back and, when it succeeded, stores it
+// behind a wrapping token. Failures are passed through unwrapped. It must
+// run after the access check so that the caller is known.
+func (m *WrapMiddleware) Wrap() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		value := ctx.Query("wrap_ttl")
+		if value == "" {
+			ctx.Next()
+			return
+		}
+		if m.wrapping == nil {
+			ctx.JSON(http.StatusNotImplemented, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_WRAPPING_UNAVAILABLE",
+					Message: "Response wrapping is not configured",
+				},
+			})
+			ctx.Abort()
+			return
+		}
+		ttl, err := m.wrapping.ParseTTL(value)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INVALID_REQUEST",
+					Message: err.Error(),
+				},
+			})
+			ctx.Abort()
+			return
+		}
+
+		held := &heldResponseWriter{ResponseWriter: ctx.Writer, status: http.StatusOK}
+		ctx.Writer = held
+		ctx.Next()
+		ctx.Writer = held.ResponseWriter
+
+		if held.status != http.StatusOK {
+			ctx.Writer.WriteHeader(held.status)
+			ctx.Writer.Write(held.body.Bytes())
+			return
+		}
+
+		info, err := m.wrapping.Wrap(ctx.MustGet("user_id").(uuid.UUID), ctx.Request.URL.Path, held.body.Bytes(), ttl)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Error: model.ErrorDetail{
+					Code:    "VAULT_INTERNAL_ERROR",
+					Message: "Failed to wrap response",
+				},
+			})
+			return
+		}
+
+		// Headers describing the held body do not describe the token
+		ctx.Writer.Header().Del("ETag")
+		ctx.Header("Cache-Control", "no-store")
+		ctx.JSON(http.StatusOK, model.WrapResponse{WrapInfo: *info})
+	}
+}
+
+// heldResponseWriter keeps the status and body a handler writes instead of
+// sending them
+type heldResponseWriter struct {
+	gin.ResponseWriter
+	status  int
+	body    bytes.Buffer
+	written bool
+}
+
+func (w *heldResponseWriter) WriteHeader(code int) {
+	if !w.written {
+		w.status = code
+	}
+}
+
+func (w *heldResponseWriter) WriteHeaderNow() {
+	w.written = true
+}
+
+func (w *heldResponseWriter) Write(data []byte) (int, error) {
+	w.written = true
+	return w.body.Write(data)
+}
+
+func (w *heldResponseWriter) WriteString(s string) (int, error) {
+	w.written = true
+	return w.body.WriteString(s)
+}
+
+func (w *heldResponseWriter) Status() int {
+	return w.status
+}
+
+func (w *heldResponseWriter) Size() int {
+	return w.body.Len()
+}
+
+func (w *heldResponseWriter) Written() bool {
+	return w.written
+}
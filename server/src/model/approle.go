@@ -0,0 +1,96 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AuthMethodAppRole names the policy assignments AppRoles make
+const AuthMethodAppRole = "approle"
+
+// AppRole lets a machine sign in with the role's RoleID and one of its
+// secret IDs. Each role signs in as a service user of its own, which
+// holds the role's policies.
+type AppRole struct {
+	ID     uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	Name   string    `gorm:"uniqueIndex;not null" json:"name"`
+	RoleID string    `gorm:"uniqueIndex;not null" json:"role_id"`
+	// UserID is the service user logins authenticate as
+	UserID   uuid.UUID   `gorm:"type:uuid;not null" json:"user_id"`
+	Policies []uuid.UUID `gorm:"serializer:json;type:text" json:"policies"`
+	// SecretIDTTL is how long new secret IDs are valid, in seconds; 0
+	// never expires them
+	SecretIDTTL int `gorm:"not null;default:0" json:"secret_id_ttl"`
+	// SecretIDNumUses is how many logins a new secret ID allows; 0 is
+	// unlimited
+	SecretIDNumUses int       `gorm:"not null;default:0" json:"secret_id_num_uses"`
+	CreatedBy       uuid.UUID `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+func (r *AppRole) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// AppRoleSecretID is a credential of an AppRole. Only its hash is stored;
+// the ID doubles as the accessor that lists and revokes it.
+type AppRoleSecretID struct {
+	ID           uuid.UUID `gorm:"type:uuid;primary_key" json:"secret_id_accessor"`
+	AppRoleID    uuid.UUID `gorm:"type:uuid;not null;index" json:"-"`
+	SecretIDHash string    `gorm:"uniqueIndex;not null" json:"-"`
+	// NumUses is how many logins the secret ID allows; 0 is unlimited
+	NumUses    int        `gorm:"not null;default:0" json:"num_uses"`
+	UseCount   int        `gorm:"not null;default:0" json:"use_count"`
+	ExpiresAt  *time.Time `gorm:"index" json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedBy  uuid.UUID  `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (s *AppRoleSecretID) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+type CreateAppRoleRequest struct {
+	Name            string      `json:"name" binding:"required"`
+	Policies        []uuid.UUID `json:"policies"`
+	SecretIDTTL     int         `json:"secret_id_ttl" binding:"min=0"`
+	SecretIDNumUses int         `json:"secret_id_num_uses" binding:"min=0"`
+}
+
+// UpdateAppRoleRequest changes the fields present; existing secret IDs
+// keep the TTL and use limit they were created with
+type UpdateAppRoleRequest struct {
+	Policies        *[]uuid.UUID `json:"policies"`
+	SecretIDTTL     *int         `json:"secret_id_ttl" binding:"omitempty,min=0"`
+	SecretIDNumUses *int         `json:"secret_id_num_uses" binding:"omitempty,min=0"`
+}
+
+// GenerateSecretIDRequest may tighten the role's TTL and use limit for
+// one secret ID, but not loosen them
+type GenerateSecretIDRequest struct {
+	TTL     *int `json:"ttl" binding:"omitempty,min=1"`
+	NumUses *int `json:"num_uses" binding:"omitempty,min=1"`
+}
+
+// AppRoleSecretIDResponse carries a new secret ID, shown once
+type AppRoleSecretIDResponse struct {
+	SecretID         string     `json:"secret_id"`
+	SecretIDAccessor uuid.UUID  `json:"secret_id_accessor"`
+	NumUses          int        `json:"num_uses"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"`
+}
+
+type AppRoleLoginRequest struct {
+	RoleID   string `json:"role_id" binding:"required"`
+	SecretID string `json:"secret_id" binding:"required"`
+}
@@ -15,9 +15,28 @@ type AuditLog struct {
 	ResourceID *string    `json:"resource_id"`
 	IPAddress  string     `gorm:"not null" json:"ip_address"`
 	UserAgent  string     `gorm:"type:text" json:"user_agent"`
-	Success    bool       `gorm:"default:true" json:"success"`
+	Success    bool       `gorm:"not null" json:"success"`
 	Details    string     `gorm:"type:text" json:"details"`
+	RequestID  string     `gorm:"index" json:"request_id,omitempty"`
+	Method     string     `json:"method,omitempty"`
+	Route      string     `json:"route,omitempty"`
+	StatusCode int        `json:"status_code,omitempty"`
+	LatencyMs  int64      `json:"latency_ms,omitempty"`
+	ParamsHash string     `json:"params_hash,omitempty"`
+	Build      string     `json:"build,omitempty"`
+	// Enrichment is the JSON object the enrichment processors added,
+	// e.g. geo location or threat-intel tags of the client address
+	Enrichment string `gorm:"type:text" json:"enrichment,omitempty"`
+	// WorkloadID names the build that made the request, when its token
+	// was bound to one
+	WorkloadID *uuid.UUID `gorm:"type:uuid;index" json:"workload_id,omitempty"`
 	CreatedAt  time.Time  `json:"created_at"`
+	// Hash chain: Hash covers this entry and PrevHash, the hash of the
+	// entry with the previous Sequence. Entries written before the chain
+	// existed have Sequence 0.
+	Sequence int64  `gorm:"index" json:"sequence,omitempty"`
+	PrevHash string `json:"prev_hash,omitempty"`
+	Hash     string `gorm:"index" json:"hash,omitempty"`
 
 	User *User `gorm:"foreignKey:UserID" json:"-"`
 }
@@ -28,3 +47,75 @@ func (a *AuditLog) BeforeCreate(tx *gorm.DB) error {
 	}
 	return nil
 }
+
+// AuditChainHead is the single row holding the newest chain entry. It is
+// locked while appending so entries get consecutive sequence numbers.
+type AuditChainHead struct {
+	ID        int    `gorm:"primary_key;autoIncrement:false"`
+	Sequence  int64  `gorm:"not null"`
+	Hash      string `gorm:"not null"`
+	UpdatedAt time.Time
+}
+
+// AuditAnchor records a chain head written to the external anchor sink
+type AuditAnchor struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	Sequence  int64     `gorm:"not null;index" json:"sequence"`
+	Hash      string    `gorm:"not null" json:"hash"`
+	Location  string    `gorm:"type:text;not null" json:"location"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (a *AuditAnchor) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+// AuditAnchorRecord is what an anchor sink stores for each anchor
+type AuditAnchorRecord struct {
+	Sequence   int64     `json:"sequence"`
+	Hash       string    `json:"hash"`
+	AnchoredAt time.Time `json:"anchored_at"`
+	Location   string    `json:"location,omitempty"`
+}
+
+// AuditVerifyReport is the result of checking the audit hash chain and
+// comparing it with the anchors held by the external sink
+type AuditVerifyReport struct {
+	Valid bool `json:"valid"`
+	// Range of chained entries still stored; older ones were pruned
+	FirstSequence  int64 `json:"first_sequence"`
+	LastSequence   int64 `json:"last_sequence"`
+	EntriesChecked int64 `json:"entries_checked"`
+	// Newest anchor that matched the chain: every entry up to it is
+	// proven unmodified
+	VerifiedThrough int64              `json:"verified_through"`
+	Anchors         []AuditAnchorCheck `json:"anchors"`
+	Errors          []string           `json:"errors,omitempty"`
+}
+
+type AuditAnchorCheck struct {
+	Sequence int64  `json:"sequence"`
+	Location string `json:"location"`
+	// ok, mismatch, pruned (entry removed by retention) or missing (entry
+	// newer than the stored chain)
+	Status string `json:"status"`
+}
+
+type AuditHashRequest struct {
+	Input string `json:"input" binding:"required"`
+}
+
+type AuditHashResponse struct {
+	Hash string `json:"hash"`
+}
+
+// AuditExportCursor records the last audit entry an exporter delivered, so
+// it resumes there after a restart or a leader change
+type AuditExportCursor struct {
+	Name      string    `gorm:"primary_key" json:"name"`
+	Sequence  int64     `gorm:"not null" json:"sequence"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AuthMethodAWS names the policy assignments AWS roles make
+const AuthMethodAWS = "aws"
+
+// AWSRole lets the bound IAM principals sign in. Like an AppRole, each
+// role signs in as a service user of its own, which holds the role's
+// policies.
+type AWSRole struct {
+	ID   uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	Name string    `gorm:"uniqueIndex;not null" json:"name"`
+	// BoundAccountIDs and BoundIAMPrincipalARNs list who may sign in; an
+	// empty list matches any, but a role binds at least one of them. ARNs
+	// ending in * match by prefix, and assumed-role sessions match as the
+	// role they assumed, arn:aws:iam::<account>:role/<name>.
+	BoundAccountIDs       []string `gorm:"serializer:json;type:text" json:"bound_account_ids"`
+	BoundIAMPrincipalARNs []string `gorm:"serializer:json;type:text" json:"bound_iam_principal_arns"`
+	// BoundTags the IAM user or role must carry, with these values
+	BoundTags map[string]string `gorm:"serializer:json;type:text" json:"bound_tags"`
+	Policies  []uuid.UUID       `gorm:"serializer:json;type:text" json:"policies"`
+	UserID    uuid.UUID         `gorm:"type:uuid;not null" json:"user_id"`
+	CreatedBy uuid.UUID         `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+func (r *AWSRole) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+type CreateAWSRoleRequest struct {
+	Name                  string            `json:"name" binding:"required"`
+	BoundAccountIDs       []string          `json:"bound_account_ids"`
+	BoundIAMPrincipalARNs []string          `json:"bound_iam_principal_arns"`
+	BoundTags             map[string]string `json:"bound_tags"`
+	Policies              []uuid.UUID       `json:"policies"`
+}
+
+// UpdateAWSRoleRequest changes the fields present
+type UpdateAWSRoleRequest struct {
+	BoundAccountIDs       *[]string          `json:"bound_account_ids"`
+	BoundIAMPrincipalARNs *[]string          `json:"bound_iam_principal_arns"`
+	BoundTags             *map[string]string `json:"bound_tags"`
+	Policies              *[]uuid.UUID       `json:"policies"`
+}
+
+// AWSLoginRequest carries a signed sts:GetCallerIdentity request. The URL,
+// body and headers are base64 encoded; headers are a JSON object of
+// header names to lists of values.
+type AWSLoginRequest struct {
+	Role                 string `json:"role" binding:"required"`
+	IAMHTTPRequestMethod string `json:"iam_http_request_method" binding:"required"`
+	IAMRequestURL        string `json:"iam_request_url" binding:"required"`
+	IAMRequestBody       string `json:"iam_request_body" binding:"required"`
+	IAMRequestHeaders    string `json:"iam_request_headers" binding:"required"`
+}
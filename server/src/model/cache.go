@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// CacheAccessStat counts reads of one cache key. Counts survive restarts
+// so a new instance knows which entries to pre-load.
+type CacheAccessStat struct {
+	// Kind names the cache, e.g. secret or policy
+	Kind string `gorm:"primaryKey;size:32" json:"kind"`
+	// Key is the cache key within the kind, e.g. a secret ID
+	Key          string    `gorm:"primaryKey;size:128" json:"key"`
+	Hits         int64     `gorm:"not null;default:0" json:"hits"`
+	LastAccessAt time.Time `gorm:"not null;index" json:"last_access_at"`
+}
+
+// CacheStatus describes one in-memory cache for metrics
+type CacheStatus struct {
+	Name    string `json:"name"`
+	Entries int    `json:"entries"`
+	Hits    int64  `json:"hits"`
+	Misses  int64  `json:"misses"`
+	// Warmed is the number of entries pre-loaded at startup
+	Warmed int `json:"warmed"`
+}
+
+// CacheWarmupStatus reports the startup pre-load
+type CacheWarmupStatus struct {
+	Caches     []CacheStatus `json:"caches"`
+	StartedAt  *time.Time    `json:"started_at,omitempty"`
+	FinishedAt *time.Time    `json:"finished_at,omitempty"`
+}
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ChatProvider string
+
+const (
+	ChatProviderSlack ChatProvider = "slack"
+	ChatProviderTeams ChatProvider = "teams"
+)
+
+// ChatChannel is a Slack or Microsoft Teams incoming webhook. Its routes
+// decide which events are posted to it.
+type ChatChannel struct {
+	ID         uuid.UUID      `gorm:"type:uuid;primary_key" json:"id"`
+	Name       string         `gorm:"uniqueIndex;not null" json:"name"`
+	Provider   ChatProvider   `gorm:"not null" json:"provider"`
+	WebhookURL string         `gorm:"type:text;not null" json:"-"`
+	Enabled    bool           `gorm:"not null" json:"enabled"`
+	CreatedBy  uuid.UUID      `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Routes []ChatRoute `gorm:"foreignKey:ChannelID" json:"routes"`
+	// WebhookHost identifies the webhook without exposing its token
+	WebhookHost string `gorm:"-" json:"webhook_host"`
+}
+
+func (c *ChatChannel) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+// ChatRoute sends events whose type matches EventPattern ("*",
+// "security.*" or an exact type) and whose severity is at least
+// MinSeverity to a channel
+type ChatRoute struct {
+	ID           uuid.UUID     `gorm:"type:uuid;primary_key" json:"id"`
+	ChannelID    uuid.UUID     `gorm:"type:uuid;not null;index" json:"channel_id"`
+	EventPattern string        `gorm:"not null" json:"event_pattern"`
+	MinSeverity  EventSeverity `gorm:"not null" json:"min_severity"`
+	CreatedAt    time.Time     `json:"created_at"`
+}
+
+func (r *ChatRoute) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+type CreateChatChannelRequest struct {
+	Name       string             `json:"name" binding:"required"`
+	Provider   ChatProvider       `json:"provider" binding:"required,oneof=slack teams"`
+	WebhookURL string             `json:"webhook_url" binding:"required,url"`
+	Routes     []ChatRouteRequest `json:"routes" binding:"dive"`
+}
+
+type ChatRouteRequest struct {
+	EventPattern string        `json:"event_pattern" binding:"required"`
+	MinSeverity  EventSeverity `json:"min_severity" binding:"omitempty,oneof=info warning critical"`
+}
+
+// ChatDecision is the outcome of an approve/deny action taken in chat
+type ChatDecision struct {
+	Kind      string `json:"kind"`
+	RequestID string `json:"request_id"`
+	Approved  bool   `json:"approved"`
+	Actor     string `json:"actor"`
+}
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	ControlGroupStatusPending  = "pending"
+	ControlGroupStatusApproved = "approved"
+	ControlGroupStatusDenied   = "denied"
+	// ControlGroupStatusExpired is reported for requests past ExpiresAt;
+	// it is never stored
+	ControlGroupStatusExpired = "expired"
+)
+
+// ControlGroupRequest is a secret read parked until Required members of a
+// team approve it. Any one of them may deny it instead.
+type ControlGroupRequest struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	RequesterID uuid.UUID `gorm:"type:uuid;not null;index" json:"requester_id"`
+	SecretID    uuid.UUID `gorm:"type:uuid;not null;index" json:"secret_id"`
+	TeamID      uuid.UUID `gorm:"type:uuid;not null;index" json:"team_id"`
+	Required    int       `gorm:"not null" json:"required"`
+	// TTL is the rule's ttl in seconds when the request was made
+	TTL    int    `gorm:"not null" json:"ttl"`
+	Status string `gorm:"not null;index" json:"status"`
+	// ExpiresAt is when a pending request lapses, or when an approved one
+	// stops letting the requester read
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	DecidedAt *time.Time `json:"decided_at,omitempty"`
+	DecidedBy *uuid.UUID `gorm:"type:uuid" json:"decided_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+
+	Approvals []ControlGroupApproval `gorm:"foreignKey:RequestID" json:"approvals"`
+}
+
+func (r *ControlGroupRequest) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// ControlGroupApproval is one team member's approval of a request
+type ControlGroupApproval struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key" json:"-"`
+	RequestID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_control_group_approval" json:"-"`
+	ApproverID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_control_group_approval" json:"approver_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (a *ControlGroupApproval) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+// ControlGroupRequirement tells a reader which request their read waits on
+// and where to follow it
+type ControlGroupRequirement struct {
+	RequestID uuid.UUID `json:"request_id"`
+	Status    string    `json:"status"`
+	Approvals int       `json:"approvals"`
+	Required  int       `json:"required"`
+	ExpiresAt time.Time `json:"expires_at"`
+	PollPath  string    `json:"poll_path"`
+}
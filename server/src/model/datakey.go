@@ -0,0 +1,45 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type DataKeyStatus string
+
+const (
+	// DataKeyStatusActive marks the key new values are encrypted with
+	DataKeyStatusActive DataKeyStatus = "active"
+	// DataKeyStatusRetired keys only decrypt values that have not been
+	// re-encrypted yet
+	DataKeyStatusRetired DataKeyStatus = "retired"
+)
+
+// DataKey is a data encryption key for values sealed by the server itself,
+// stored wrapped by the master key derived from security.encryption_key.
+// Ciphertexts name the version of the key that sealed them.
+type DataKey struct {
+	ID         uuid.UUID     `gorm:"type:uuid;primary_key" json:"id"`
+	Version    int           `gorm:"not null;uniqueIndex" json:"version"`
+	WrappedKey string        `gorm:"type:text;not null" json:"-"`
+	Status     DataKeyStatus `gorm:"not null" json:"status"`
+	CreatedBy  *uuid.UUID    `gorm:"type:uuid" json:"created_by,omitempty"`
+	CreatedAt  time.Time     `json:"created_at"`
+	RetiredAt  *time.Time    `json:"retired_at,omitempty"`
+}
+
+func (k *DataKey) BeforeCreate(tx *gorm.DB) error {
+	if k.ID == uuid.Nil {
+		k.ID = uuid.New()
+	}
+	return nil
+}
+
+// RotateDataKeyResponse is the new key and the re-encryption run moving
+// existing values to it; Run is empty when a run is already in progress
+type RotateDataKeyResponse struct {
+	Key DataKey `json:"key"`
+	Run *JobRun `json:"run,omitempty"`
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/buildinfo"
 )
 
 type ErrorResponse struct {
@@ -13,6 +14,15 @@ type ErrorResponse struct {
 type ErrorDetail struct {
 	Code    string `json:"code"`
 	Message string `json:"message"`
+	// Path and Action name the policy check a VAULT_ACCESS_DENIED failed,
+	// for GET /auth/token/capabilities-self
+	Path   string `json:"path,omitempty"`
+	Action string `json:"action,omitempty"`
+	// MFA is what a VAULT_MFA_REQUIRED challenge asks the caller to verify
+	MFA *MFARequirement `json:"mfa,omitempty"`
+	// ControlGroup is the approval request a VAULT_CONTROL_GROUP_PENDING
+	// read waits on
+	ControlGroup *ControlGroupRequirement `json:"control_group,omitempty"`
 }
 
 type HealthResponse struct {
@@ -20,6 +30,13 @@ type HealthResponse struct {
 	Timestamp time.Time `json:"timestamp"`
 	Version   string    `json:"version"`
 	Database  string    `json:"database"`
+	// Features are the feature flags in effect on this instance
+	Features map[string]bool `json:"features,omitempty"`
+	// ReadOnly is set while maintenance mode rejects writes
+	ReadOnly          bool   `json:"read_only"`
+	MaintenanceReason string `json:"maintenance_reason,omitempty"`
+	// EntropyDegraded is set while an external entropy source fails
+	EntropyDegraded bool `json:"entropy_degraded,omitempty"`
 }
 
 type VersionResponse struct {
@@ -29,11 +46,24 @@ type VersionResponse struct {
 	GoVersion string `json:"go_version"`
 }
 
+// BuildInfoResponse is the build of the running server with the feature
+// flags currently in effect
+type BuildInfoResponse struct {
+	buildinfo.Info
+	FeatureFlags map[string]bool `json:"feature_flags,omitempty"`
+}
+
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=8"`
 }
 
+// LDAPLoginRequest signs in with a directory username rather than an email
+type LDAPLoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
 type LoginResponse struct {
 	Token     string    `json:"token"`
 	ExpiresAt time.Time `json:"expires_at"`
@@ -43,6 +73,8 @@ type LoginResponse struct {
 type SessionResponse struct {
 	User  User `json:"user"`
 	Valid bool `json:"valid"`
+	// Impersonation is set when an administrator is acting as User
+	Impersonation *Impersonation `json:"impersonation,omitempty"`
 }
 
 type CreateSecretRequest struct {
@@ -51,7 +83,26 @@ type CreateSecretRequest struct {
 	Value       string     `json:"value" binding:"required"`
 	Type        SecretType `json:"type" binding:"required"`
 	Tags        string     `json:"tags"`
-	ExpiresAt   *time.Time `json:"expires_at"`
+	// Metadata is stored in clear and must not hold secrets
+	Metadata  map[string]string `json:"metadata"`
+	ExpiresAt *time.Time        `json:"expires_at"`
+	// TTL is an alternative to ExpiresAt, in seconds from now
+	TTL         *int       `json:"ttl"`
+	NamespaceID *uuid.UUID `json:"namespace_id"`
+	// ProjectID needs the writer role on the project
+	ProjectID *uuid.UUID `json:"project_id"`
+	// ClientEncrypted marks Value as an envelope sealed by the client
+	ClientEncrypted bool `json:"client_encrypted"`
+	// MaxVersions caps the previous values kept in versioned mounts
+	MaxVersions int `json:"max_versions" binding:"min=0"`
+	// RotationPeriod (seconds) and Rotator schedule automatic rotation
+	RotationPeriod int    `json:"rotation_period" binding:"min=0"`
+	Rotator        string `json:"rotator"`
+	// CheckoutRequired makes reads go through an exclusive checkout; it
+	// needs a rotator
+	CheckoutRequired bool `json:"checkout_required"`
+	// Fields sets who reads which fields of a JSON object value
+	Fields []SecretField `json:"fields" binding:"dive"`
 }
 
 type UpdateSecretRequest struct {
@@ -60,8 +111,88 @@ type UpdateSecretRequest struct {
 	Value       *string     `json:"value"`
 	Type        *SecretType `json:"type"`
 	Tags        *string     `json:"tags"`
-	ExpiresAt   *time.Time  `json:"expires_at"`
-	IsActive    *bool       `json:"is_active"`
+	// Metadata replaces the metadata; an empty object removes it
+	Metadata  map[string]string `json:"metadata"`
+	ExpiresAt *time.Time        `json:"expires_at"`
+	TTL       *int              `json:"ttl"`
+	IsActive  *bool             `json:"is_active"`
+	// ClientEncrypted must accompany Value when switching modes
+	ClientEncrypted *bool `json:"client_encrypted"`
+	// ActivateAt schedules Value to become current at that time; the
+	// rest of the update applies now
+	ActivateAt  *time.Time `json:"activate_at"`
+	MaxVersions *int       `json:"max_versions" binding:"omitempty,min=0"`
+	// RotationPeriod zero and an empty Rotator turn rotation off
+	RotationPeriod   *int    `json:"rotation_period" binding:"omitempty,min=0"`
+	Rotator          *string `json:"rotator"`
+	CheckoutRequired *bool   `json:"checkout_required"`
+	// ProjectID moves the secret into a project; the nil UUID takes it
+	// out of its project
+	ProjectID *uuid.UUID `json:"project_id"`
+	// Fields replaces the field rules; an empty list removes them
+	Fields *[]SecretField `json:"fields" binding:"omitempty,dive"`
+}
+
+type SecretValueResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	Name      string     `json:"name"`
+	Value     string     `json:"value"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// ClientEncrypted values must be opened with the caller's own key
+	ClientEncrypted bool `json:"client_encrypted"`
+	// WithheldFields names the fields of a JSON value the caller may not
+	// read, which Value leaves out
+	WithheldFields []string `json:"withheld_fields,omitempty"`
+	// Lease is set when the read asked for one: how long the caller may
+	// keep using the value before reading it again
+	Lease *LeaseInfo `json:"lease,omitempty"`
+}
+
+// SecretKeyList lists the keys below a prefix, KV style: a key ending in
+// "/" is a folder holding deeper secrets
+type SecretKeyList struct {
+	Mount  string `json:"mount"`
+	Prefix string `json:"prefix"`
+	// Depth is how many path segments below Prefix keys spell out; zero
+	// lists every secret in full
+	Depth int      `json:"depth"`
+	Keys  []string `json:"keys"`
+}
+
+// SecretBundle holds the secrets named under a prefix as key/value pairs.
+// Revision changes whenever one of them is written or deleted; a delta
+// bundle only carries the keys changed since the requested revision.
+type SecretBundle struct {
+	Prefix   string            `json:"prefix"`
+	Revision int64             `json:"revision"`
+	Delta    bool              `json:"delta"`
+	Since    int64             `json:"since,omitempty"`
+	Secrets  map[string]string `json:"secrets"`
+	Deleted  []string          `json:"deleted,omitempty"`
+}
+
+// SecretExpiration is a secret that expires or is due for rotation within
+// the window asked for
+type SecretExpiration struct {
+	ID             uuid.UUID  `json:"id"`
+	Name           string     `json:"name"`
+	Mount          string     `json:"mount"`
+	Type           SecretType `json:"type"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	Expired        bool       `json:"expired"`
+	RotationPeriod int        `json:"rotation_period,omitempty"`
+	Rotator        string     `json:"rotator,omitempty"`
+	NextRotationAt *time.Time `json:"next_rotation_at,omitempty"`
+	// RotationOverdue is set once NextRotationAt has passed
+	RotationOverdue bool   `json:"rotation_overdue"`
+	RotationError   string `json:"rotation_error,omitempty"`
+}
+
+type ConnectionStringResponse struct {
+	SecretID         uuid.UUID `json:"secret_id"`
+	Driver           string    `json:"driver"`
+	Format           string    `json:"format"`
+	ConnectionString string    `json:"connection_string"`
 }
 
 type CreateTOTPRequest struct {
@@ -81,3 +212,47 @@ type TOTPGenerateResponse struct {
 	Code      string    `json:"code"`
 	ExpiresAt time.Time `json:"expires_at"`
 }
+
+type GenerateRequest struct {
+	Type      string `json:"type" binding:"required"`
+	Length    int    `json:"length"`
+	Encoding  string `json:"encoding"`
+	Words     int    `json:"words"`
+	Separator string `json:"separator"`
+	Algorithm string `json:"algorithm"`
+	Bits      int    `json:"bits"`
+	Curve     string `json:"curve"`
+	StoreAs   string `json:"store_as"`
+}
+
+type GenerateResponse struct {
+	Type        string     `json:"type"`
+	Value       string     `json:"value,omitempty"`
+	PublicKey   string     `json:"public_key,omitempty"`
+	PrivateKey  string     `json:"private_key,omitempty"`
+	SecretID    *uuid.UUID `json:"secret_id,omitempty"`
+	EntropyBits int        `json:"entropy_bits,omitempty"`
+}
+
+type SelfTestCheck struct {
+	Name     string `json:"name"`
+	Passed   bool   `json:"passed"`
+	Detail   string `json:"detail,omitempty"`
+	Duration string `json:"duration"`
+}
+
+type SelfTestReport struct {
+	Passed         bool            `json:"passed"`
+	SchemaChecksum string          `json:"schema_checksum,omitempty"`
+	Checks         []SelfTestCheck `json:"checks"`
+	CompletedAt    time.Time       `json:"completed_at"`
+}
+
+type InFlightRequest struct {
+	RequestID string    `json:"request_id"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	ClientIP  string    `json:"client_ip"`
+	StartedAt time.Time `json:"started_at"`
+	ElapsedMs int64     `json:"elapsed_ms"`
+}
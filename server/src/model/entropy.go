@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// Failure policies for external entropy sources
+const (
+	EntropyPolicyDegrade = "degrade"
+	EntropyPolicyBlock   = "block"
+)
+
+// EntropyStatus describes the sources mixed into vault key generation.
+// Degraded is set while a source fails its health tests; under the block
+// policy key generation then fails, and Blocking is set.
+type EntropyStatus struct {
+	Enabled       bool                  `json:"enabled"`
+	FailurePolicy string                `json:"failure_policy,omitempty"`
+	Degraded      bool                  `json:"degraded"`
+	Blocking      bool                  `json:"blocking"`
+	ReseededAt    *time.Time            `json:"reseeded_at,omitempty"`
+	Sources       []EntropySourceStatus `json:"sources"`
+}
+
+type EntropySourceStatus struct {
+	Name          string     `json:"name"`
+	Healthy       bool       `json:"healthy"`
+	Failures      int64      `json:"failures"`
+	LastError     string     `json:"last_error,omitempty"`
+	LastSuccessAt *time.Time `json:"last_success_at,omitempty"`
+}
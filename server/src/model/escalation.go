@@ -0,0 +1,107 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type EscalationProvider string
+
+const (
+	EscalationProviderPagerDuty EscalationProvider = "pagerduty"
+	EscalationProviderOpsgenie  EscalationProvider = "opsgenie"
+)
+
+type IncidentStatus string
+
+const (
+	IncidentStatusTriggered IncidentStatus = "triggered"
+	IncidentStatusResolved  IncidentStatus = "resolved"
+)
+
+// EscalationTarget is a PagerDuty service (Events API v2 routing key) or an
+// Opsgenie team (API integration key). Its rules decide which events open
+// incidents there.
+type EscalationTarget struct {
+	ID         uuid.UUID          `gorm:"type:uuid;primary_key" json:"id"`
+	Name       string             `gorm:"uniqueIndex;not null" json:"name"`
+	Provider   EscalationProvider `gorm:"not null" json:"provider"`
+	RoutingKey string             `gorm:"type:text;not null" json:"-"`
+	// APIURL overrides the provider endpoint, e.g. Opsgenie's EU region
+	APIURL    string         `json:"api_url,omitempty"`
+	Enabled   bool           `gorm:"not null" json:"enabled"`
+	CreatedBy uuid.UUID      `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Rules []EscalationRule `gorm:"foreignKey:TargetID" json:"rules"`
+}
+
+func (t *EscalationTarget) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// EscalationRule opens incidents for events whose type matches
+// EventPattern and whose severity is at least MinSeverity
+type EscalationRule struct {
+	ID           uuid.UUID     `gorm:"type:uuid;primary_key" json:"id"`
+	TargetID     uuid.UUID     `gorm:"type:uuid;not null;index" json:"target_id"`
+	EventPattern string        `gorm:"not null" json:"event_pattern"`
+	MinSeverity  EventSeverity `gorm:"not null" json:"min_severity"`
+	CreatedAt    time.Time     `json:"created_at"`
+}
+
+func (r *EscalationRule) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// Incident tracks a condition escalated to a target. Repeats of the same
+// dedup key while it is triggered only bump Occurrences and LastSeenAt.
+type Incident struct {
+	ID          uuid.UUID      `gorm:"type:uuid;primary_key" json:"id"`
+	TargetID    uuid.UUID      `gorm:"type:uuid;not null;index" json:"target_id"`
+	DedupKey    string         `gorm:"not null;index" json:"dedup_key"`
+	EventType   EventType      `gorm:"not null" json:"event_type"`
+	Severity    EventSeverity  `gorm:"not null" json:"severity"`
+	Summary     string         `gorm:"type:text" json:"summary"`
+	Status      IncidentStatus `gorm:"not null;index" json:"status"`
+	Occurrences int            `gorm:"not null" json:"occurrences"`
+	TriggeredAt time.Time      `gorm:"not null" json:"triggered_at"`
+	LastSeenAt  time.Time      `gorm:"not null;index" json:"last_seen_at"`
+	ResolvedAt  *time.Time     `json:"resolved_at,omitempty"`
+	ResolvedBy  string         `json:"resolved_by,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+
+	Target *EscalationTarget `gorm:"foreignKey:TargetID" json:"target,omitempty"`
+}
+
+func (i *Incident) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
+type CreateEscalationTargetRequest struct {
+	Name       string                  `json:"name" binding:"required"`
+	Provider   EscalationProvider      `json:"provider" binding:"required,oneof=pagerduty opsgenie"`
+	RoutingKey string                  `json:"routing_key" binding:"required"`
+	APIURL     string                  `json:"api_url" binding:"omitempty,url"`
+	Rules      []EscalationRuleRequest `json:"rules" binding:"dive"`
+}
+
+// EscalationRuleRequest defaults MinSeverity to critical
+type EscalationRuleRequest struct {
+	EventPattern string        `json:"event_pattern" binding:"required"`
+	MinSeverity  EventSeverity `json:"min_severity" binding:"omitempty,oneof=info warning critical"`
+}
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// KeyEscrow records how the master encryption key was split for recovery.
+// Only the latest escrow is kept; creating one replaces the shares of the
+// previous one.
+type KeyEscrow struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	Threshold int       `gorm:"not null" json:"threshold"`
+	Shares    int       `gorm:"not null" json:"shares"`
+	// KeyFingerprint identifies the escrowed key so a recovery can tell a
+	// correct reconstruction from garbage. It is a salted, slow hash of
+	// the key and never leaves the server.
+	KeyFingerprint string    `gorm:"not null" json:"-"`
+	KeySalt        string    `gorm:"not null;default:''" json:"-"`
+	CreatedBy      uuid.UUID `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// EscrowShare is one recovery share sealed for a recovery officer's
+// registered public key; the server cannot open it
+type EscrowShare struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	EscrowID  uuid.UUID `gorm:"type:uuid;not null;index" json:"escrow_id"`
+	OfficerID uuid.UUID `gorm:"type:uuid;not null;index" json:"officer_id"`
+	// KeyID is the officer key the share is sealed for
+	KeyID string `gorm:"not null" json:"key_id"`
+	// ShareIndex is the share's x coordinate, 1 to Shares
+	ShareIndex int       `gorm:"not null" json:"index"`
+	Envelope   string    `gorm:"type:text;not null" json:"envelope"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type CreateEscrowRequest struct {
+	OfficerIDs []uuid.UUID `json:"officer_ids" binding:"required"`
+	Threshold  int         `json:"threshold" binding:"required"`
+}
+
+// EscrowOfficer is an officer holding a share, without the share itself
+type EscrowOfficer struct {
+	OfficerID uuid.UUID `json:"officer_id"`
+	KeyID     string    `json:"key_id"`
+	Index     int       `json:"index"`
+}
+
+type KeyEscrowResponse struct {
+	KeyEscrow
+	Officers []EscrowOfficer `json:"officers"`
+}
+
+type SubmitRecoveryShareRequest struct {
+	Share string `json:"share" binding:"required"`
+}
+
+// KeyRecoveryStatus is the progress of a quorum recovery. RecoveredKey is
+// the master key sealed for the initiator's public key, set once enough
+// shares were submitted.
+type KeyRecoveryStatus struct {
+	ID           uuid.UUID   `json:"id"`
+	EscrowID     uuid.UUID   `json:"escrow_id"`
+	InitiatedBy  uuid.UUID   `json:"initiated_by"`
+	Threshold    int         `json:"threshold"`
+	Submitted    []uuid.UUID `json:"submitted"`
+	ExpiresAt    time.Time   `json:"expires_at"`
+	Complete     bool        `json:"complete"`
+	RecoveredKey string      `json:"recovered_key,omitempty"`
+}
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type EventType string
+
+const (
+	EventSecretExpiring        EventType = "secret.expiring"
+	EventSecretExpired         EventType = "secret.expired"
+	EventSecretRotationOverdue EventType = "secret.rotation_overdue"
+	EventSecretRotated         EventType = "secret.rotated"
+	EventSecretRotationFailed  EventType = "secret.rotation_failed"
+	EventSecretsUnused         EventType = "secret.unused"
+	EventSecurityAlert         EventType = "security.alert"
+	EventApprovalRequested     EventType = "approval.requested"
+	EventAuthFailures          EventType = "security.auth_failures"
+	EventSealTampering         EventType = "security.seal_tampering"
+	EventNamespaceQuotaWarning EventType = "namespace.quota_warning"
+	EventImpersonationStarted  EventType = "security.impersonation"
+	EventControlGroupRequested EventType = "control_group.requested"
+	// EventConditionCleared ends the condition named by its dedup_key
+	EventConditionCleared EventType = "condition.cleared"
+)
+
+type EventSeverity string
+
+const (
+	EventSeverityInfo     EventSeverity = "info"
+	EventSeverityWarning  EventSeverity = "warning"
+	EventSeverityCritical EventSeverity = "critical"
+)
+
+// Rank orders severities so routing rules can set a minimum; unknown
+// severities rank lowest
+func (s EventSeverity) Rank() int {
+	switch s {
+	case EventSeverityWarning:
+		return 1
+	case EventSeverityCritical:
+		return 2
+	default:
+		return 0
+	}
+}
+
+var eventSeverities = map[EventType]EventSeverity{
+	EventSecretExpiring:        EventSeverityInfo,
+	EventSecretExpired:         EventSeverityWarning,
+	EventSecretRotationOverdue: EventSeverityWarning,
+	EventSecretRotated:         EventSeverityInfo,
+	EventSecretRotationFailed:  EventSeverityWarning,
+	EventSecretsUnused:         EventSeverityInfo,
+	EventSecurityAlert:         EventSeverityWarning,
+	EventApprovalRequested:     EventSeverityInfo,
+	EventAuthFailures:          EventSeverityCritical,
+	EventSealTampering:         EventSeverityCritical,
+	EventNamespaceQuotaWarning: EventSeverityWarning,
+	EventImpersonationStarted:  EventSeverityWarning,
+	EventControlGroupRequested: EventSeverityInfo,
+	EventConditionCleared:      EventSeverityInfo,
+}
+
+// Event is the envelope delivered to webhook subscribers and event sinks
+type Event struct {
+	ID        uuid.UUID              `json:"id"`
+	Type      EventType              `json:"type"`
+	Severity  EventSeverity          `json:"severity"`
+	Timestamp time.Time              `json:"timestamp"`
+	Data      map[string]interface{} `json:"data"`
+}
+
+// NewEvent builds an event with the default severity of its type
+func NewEvent(eventType EventType, data map[string]interface{}) *Event {
+	severity, ok := eventSeverities[eventType]
+	if !ok {
+		severity = EventSeverityInfo
+	}
+
+	return &Event{
+		ID:        uuid.New(),
+		Type:      eventType,
+		Severity:  severity,
+		Timestamp: time.Now().UTC(),
+		Data:      data,
+	}
+}
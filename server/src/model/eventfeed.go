@@ -0,0 +1,58 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FeedClass groups the live admin events a feed subscriber can ask for
+type FeedClass string
+
+const (
+	// FeedClassAuth carries logins, logouts and authentication failure alerts
+	FeedClassAuth FeedClass = "auth"
+	// FeedClassSecret carries secret writes and quarantines
+	FeedClassSecret FeedClass = "secret"
+	// FeedClassSeal carries master and tenant key events: escrow, quorum
+	// recovery, rotation and seal tampering
+	FeedClassSeal FeedClass = "seal"
+	// FeedClassJob carries job starts and results
+	FeedClassJob FeedClass = "job"
+	// FeedClassAlert carries every other notification event
+	FeedClassAlert FeedClass = "alert"
+)
+
+// FeedClasses lists every class in display order
+var FeedClasses = []FeedClass{FeedClassAuth, FeedClassSecret, FeedClassSeal, FeedClassJob, FeedClassAlert}
+
+// Control messages the feed sends besides events
+const (
+	FeedMessageEvent      = "event"
+	FeedMessageSubscribed = "subscribed"
+	FeedMessageDropped    = "dropped"
+)
+
+// FeedEvent is one live admin event. Events are not persisted; the audit
+// log remains the record.
+type FeedEvent struct {
+	ID         uuid.UUID              `json:"id"`
+	Class      FeedClass              `json:"class"`
+	Type       string                 `json:"type"`
+	Timestamp  time.Time              `json:"timestamp"`
+	ActorID    *uuid.UUID             `json:"actor_id,omitempty"`
+	Resource   string                 `json:"resource,omitempty"`
+	ResourceID string                 `json:"resource_id,omitempty"`
+	Success    *bool                  `json:"success,omitempty"`
+	Details    string                 `json:"details,omitempty"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+}
+
+// FeedMessage is a frame of the event feed WebSocket
+type FeedMessage struct {
+	Type    string      `json:"type"`
+	Event   *FeedEvent  `json:"event,omitempty"`
+	Classes []FeedClass `json:"classes,omitempty"`
+	// Dropped counts events skipped because the subscriber fell behind
+	Dropped int64 `json:"dropped,omitempty"`
+}
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// Kinds of expiry timers
+const (
+	ExpiryKindShare   = "share"
+	ExpiryKindSandbox = "sandbox"
+	// ExpiryKindSecretPublication publishes a scheduled secret value
+	ExpiryKindSecretPublication = "secret_publication"
+	// ExpiryKindLease revokes a lease through the service that issued it
+	ExpiryKindLease = "lease"
+	// ExpiryKindSecretCheckout checks in an expired secret checkout
+	ExpiryKindSecretCheckout = "secret_checkout"
+)
+
+// ExpiryTimer is a pending expiry, kept so timers survive restarts and are
+// seen by every instance. Item identifies the expiring item within its kind.
+type ExpiryTimer struct {
+	Kind   string    `gorm:"primary_key" json:"kind"`
+	Item   string    `gorm:"primary_key" json:"item"`
+	FireAt time.Time `gorm:"not null;index" json:"fire_at"`
+	// Attempts counts firings whose handler failed or never finished
+	Attempts  int       `gorm:"not null;default:0" json:"attempts"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FeatureFlagOverride replaces a flag's configured value at runtime for
+// every instance
+type FeatureFlagOverride struct {
+	Name      string     `gorm:"primary_key" json:"name"`
+	Enabled   bool       `gorm:"not null" json:"enabled"`
+	UpdatedBy *uuid.UUID `gorm:"type:uuid" json:"updated_by,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// FeatureFlagStatus describes a flag, its configured value and any
+// override in effect
+type FeatureFlagStatus struct {
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	Enabled     bool                 `json:"enabled"`
+	Configured  bool                 `json:"configured"`
+	Override    *FeatureFlagOverride `json:"override,omitempty"`
+}
+
+type SetFeatureFlagRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Impersonation is a time-boxed session in which an administrator acts as
+// another user. Tokens minted for it stop working once it expires or ends.
+type Impersonation struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	AdminID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"admin_id"`
+	AdminEmail string     `gorm:"not null" json:"admin_email"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	UserEmail  string     `gorm:"not null" json:"user_email"`
+	Reason     string     `gorm:"type:text;not null" json:"reason"`
+	Notified   bool       `json:"notified"`
+	ExpiresAt  time.Time  `gorm:"not null;index" json:"expires_at"`
+	EndedAt    *time.Time `json:"ended_at,omitempty"`
+	EndedBy    *uuid.UUID `gorm:"type:uuid" json:"ended_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (i *Impersonation) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
+// Active reports whether tokens of the session are still accepted
+func (i *Impersonation) Active(now time.Time) bool {
+	return i.EndedAt == nil && now.Before(i.ExpiresAt)
+}
+
+type StartImpersonationRequest struct {
+	UserID uuid.UUID `json:"user_id" binding:"required"`
+	// Reason is recorded in the audit log and shown to the user when
+	// notified
+	Reason string `json:"reason" binding:"required"`
+	// TTL is the session lifetime in seconds
+	TTL int `json:"ttl" binding:"min=0"`
+	// Notify tells the impersonated user by email and security event
+	Notify bool `json:"notify"`
+}
+
+// ImpersonationResponse carries the token that acts as the user
+type ImpersonationResponse struct {
+	Token         string        `json:"token"`
+	ExpiresAt     time.Time     `json:"expires_at"`
+	Impersonation Impersonation `json:"impersonation"`
+}
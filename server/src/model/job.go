@@ -0,0 +1,72 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type JobRunStatus string
+
+const (
+	JobRunStatusRunning   JobRunStatus = "running"
+	JobRunStatusSucceeded JobRunStatus = "succeeded"
+	JobRunStatusFailed    JobRunStatus = "failed"
+)
+
+type JobTrigger string
+
+const (
+	JobTriggerSchedule JobTrigger = "schedule"
+	JobTriggerManual   JobTrigger = "manual"
+)
+
+// JobRun is one execution of a background maintenance job
+type JobRun struct {
+	ID      uuid.UUID    `gorm:"type:uuid;primary_key" json:"id"`
+	Job     string       `gorm:"not null;index" json:"job"`
+	Trigger JobTrigger   `gorm:"not null" json:"trigger"`
+	Status  JobRunStatus `gorm:"not null;index" json:"status"`
+	// Instance is the server instance that ran the job
+	Instance    string     `gorm:"not null" json:"instance"`
+	TriggeredBy *uuid.UUID `gorm:"type:uuid" json:"triggered_by,omitempty"`
+	Processed   int64      `gorm:"not null" json:"processed"`
+	Detail      string     `gorm:"type:text" json:"detail,omitempty"`
+	Error       string     `gorm:"type:text" json:"error,omitempty"`
+	StartedAt   time.Time  `gorm:"not null;index" json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+func (r *JobRun) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// JobLease is a named lock held by one server instance until ExpiresAt.
+// The "leader" lease decides which instance runs scheduled jobs; each job
+// also takes its own lease while running.
+type JobLease struct {
+	Name      string    `gorm:"primary_key" json:"name"`
+	Holder    string    `gorm:"not null" json:"holder"`
+	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
+}
+
+// JobStatus describes a registered job and its most recent run
+type JobStatus struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Enabled     bool       `json:"enabled"`
+	Interval    int        `json:"interval"`
+	NextRunAt   *time.Time `json:"next_run_at,omitempty"`
+	LastRun     *JobRun    `json:"last_run,omitempty"`
+}
+
+type JobsResponse struct {
+	Instance string      `json:"instance"`
+	Leader   string      `json:"leader,omitempty"`
+	IsLeader bool        `json:"is_leader"`
+	Jobs     []JobStatus `json:"jobs"`
+}
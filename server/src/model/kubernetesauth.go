@@ -0,0 +1,58 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AuthMethodKubernetes names the policy assignments Kubernetes roles make
+const AuthMethodKubernetes = "kubernetes"
+
+// KubernetesRole lets pods running as the bound service accounts sign in.
+// Like an AppRole, each role signs in as a service user of its own,
+// which holds the role's policies.
+type KubernetesRole struct {
+	ID   uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	Name string    `gorm:"uniqueIndex;not null" json:"name"`
+	// BoundServiceAccountNames and BoundNamespaces list who may sign in;
+	// "*" matches any
+	BoundServiceAccountNames []string `gorm:"serializer:json;type:text" json:"bound_service_account_names"`
+	BoundNamespaces          []string `gorm:"serializer:json;type:text" json:"bound_namespaces"`
+	// Audience tokens must carry; empty uses the configured audience
+	Audience  string      `json:"audience,omitempty"`
+	Policies  []uuid.UUID `gorm:"serializer:json;type:text" json:"policies"`
+	UserID    uuid.UUID   `gorm:"type:uuid;not null" json:"user_id"`
+	CreatedBy uuid.UUID   `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+func (r *KubernetesRole) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+type CreateKubernetesRoleRequest struct {
+	Name                     string      `json:"name" binding:"required"`
+	BoundServiceAccountNames []string    `json:"bound_service_account_names" binding:"required,min=1"`
+	BoundNamespaces          []string    `json:"bound_namespaces" binding:"required,min=1"`
+	Audience                 string      `json:"audience"`
+	Policies                 []uuid.UUID `json:"policies"`
+}
+
+// UpdateKubernetesRoleRequest changes the fields present
+type UpdateKubernetesRoleRequest struct {
+	BoundServiceAccountNames *[]string    `json:"bound_service_account_names" binding:"omitempty,min=1"`
+	BoundNamespaces          *[]string    `json:"bound_namespaces" binding:"omitempty,min=1"`
+	Audience                 *string      `json:"audience"`
+	Policies                 *[]uuid.UUID `json:"policies"`
+}
+
+type KubernetesLoginRequest struct {
+	Role string `json:"role" binding:"required"`
+	JWT  string `json:"jwt" binding:"required"`
+}
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Issuers of leases
+const (
+	LeaseIssuerShare  = "share"
+	LeaseIssuerSecret = "secret"
+)
+
+// Lease bounds how long something issued to a user stays valid. Item
+// identifies it within the issuing service, which revokes it when the
+// lease is revoked or expires. A renewable lease can be extended up to
+// MaxExpiresAt.
+type Lease struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key" json:"lease_id"`
+	Issuer      string     `gorm:"not null;index:idx_lease_item" json:"issuer"`
+	Item        string     `gorm:"not null;index:idx_lease_item" json:"item"`
+	UserID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	NamespaceID *uuid.UUID `gorm:"type:uuid;index" json:"namespace_id,omitempty"`
+	// TTL is the duration granted at issue, in seconds, and the default
+	// increment of a renewal
+	TTL          int        `gorm:"not null" json:"ttl"`
+	Renewable    bool       `gorm:"not null;default:false" json:"renewable"`
+	ExpiresAt    time.Time  `gorm:"not null;index" json:"expires_at"`
+	MaxExpiresAt time.Time  `gorm:"not null" json:"max_expires_at"`
+	RenewedAt    *time.Time `json:"renewed_at,omitempty"`
+	RevokedAt    *time.Time `gorm:"index" json:"revoked_at,omitempty"`
+	// RevokedBy is empty when the lease ran out
+	RevokedBy *uuid.UUID `gorm:"type:uuid" json:"revoked_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (l *Lease) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	return nil
+}
+
+// Active reports whether the lease is neither revoked nor expired
+func (l *Lease) Active(now time.Time) bool {
+	return l.RevokedAt == nil && now.Before(l.ExpiresAt)
+}
+
+// Info is the lease as returned with what it was issued for
+func (l *Lease) Info(now time.Time) *LeaseInfo {
+	duration := int(l.ExpiresAt.Sub(now).Seconds())
+	if duration < 0 {
+		duration = 0
+	}
+	return &LeaseInfo{ID: l.ID, Duration: duration, Renewable: l.Renewable, ExpiresAt: l.ExpiresAt}
+}
+
+// LeaseInfo tells the holder how long what they were issued stays valid.
+// Duration is in seconds.
+type LeaseInfo struct {
+	ID        uuid.UUID `json:"lease_id"`
+	Duration  int       `json:"lease_duration"`
+	Renewable bool      `json:"renewable"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type RenewLeaseRequest struct {
+	LeaseID uuid.UUID `json:"lease_id" binding:"required"`
+	// Increment is the requested validity from now in seconds; zero
+	// renews for the lease's original TTL
+	Increment int `json:"increment" binding:"min=0"`
+}
+
+type RevokeLeaseRequest struct {
+	LeaseID uuid.UUID `json:"lease_id" binding:"required"`
+}
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaintenanceMode is the single row switching every instance to read-only
+// mode: writes are rejected while reads and logins are still served
+type MaintenanceMode struct {
+	ID        int        `gorm:"primary_key" json:"-"`
+	Enabled   bool       `gorm:"not null" json:"enabled"`
+	Reason    string     `gorm:"type:text" json:"reason,omitempty"`
+	UpdatedBy *uuid.UUID `gorm:"type:uuid" json:"updated_by,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+type SetMaintenanceRequest struct {
+	Enabled *bool  `json:"enabled" binding:"required"`
+	Reason  string `json:"reason"`
+}
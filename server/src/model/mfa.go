@@ -0,0 +1,61 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MFAMethodTOTP is the only factor step-up verification accepts so far
+const MFAMethodTOTP = "totp"
+
+// UserMFA is the TOTP factor a user verifies step-up challenges with.
+// Unlike the authenticators kept under /totp, its seed is only shown once,
+// at enrollment, so a stolen token cannot produce codes for it.
+type UserMFA struct {
+	ID     uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	UserID uuid.UUID `gorm:"type:uuid;uniqueIndex;not null" json:"user_id"`
+	// Secret is the seed, encrypted with the vault key
+	Secret string `gorm:"not null" json:"-"`
+	// ConfirmedAt is set once a code proves the user holds the seed; until
+	// then the factor verifies nothing
+	ConfirmedAt *time.Time `json:"confirmed_at,omitempty"`
+	// LastStep is the 30-second time step of the last code accepted; codes
+	// from it or an earlier step are refused, so none is accepted twice
+	LastStep  int64     `gorm:"not null;default:0" json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (m *UserMFA) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
+
+// MFAEnrollResponse carries the new seed, for an authenticator app to
+// scan as the otpauth URL
+type MFAEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type MFAVerifyResponse struct {
+	VerifiedAt time.Time `json:"verified_at"`
+}
+
+// MFARequirement describes the verification a step-up challenge asks for:
+// one of Methods, on the calling token, no more than MaxAge seconds ago.
+// Enrolled is false when the caller has no factor to verify with yet.
+type MFARequirement struct {
+	Methods    []string `json:"methods"`
+	MaxAge     int      `json:"max_age"`
+	VerifyPath string   `json:"verify_path"`
+	Enrolled   bool     `json:"enrolled"`
+}
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MigrationPhase is how far an online schema migration has progressed.
+// Phases only move forward one step at a time; any earlier phase can be
+// restored to roll back.
+type MigrationPhase string
+
+const (
+	// MigrationPhasePending: the new schema exists but nothing uses it
+	MigrationPhasePending MigrationPhase = "pending"
+	// MigrationPhaseDualWrite: writes go to the old and the new schema
+	MigrationPhaseDualWrite MigrationPhase = "dual_write"
+	// MigrationPhaseBackfill: writes still go to both while the backfill
+	// job copies existing rows in batches
+	MigrationPhaseBackfill MigrationPhase = "backfill"
+	// MigrationPhaseDualRead: reads use the new schema, writes still go to
+	// both so the migration can be rolled back
+	MigrationPhaseDualRead MigrationPhase = "dual_read"
+	// MigrationPhaseComplete: only the new schema is used; a later release
+	// can drop the old one
+	MigrationPhaseComplete MigrationPhase = "complete"
+)
+
+// MigrationPhases lists the phases in order
+var MigrationPhases = []MigrationPhase{
+	MigrationPhasePending,
+	MigrationPhaseDualWrite,
+	MigrationPhaseBackfill,
+	MigrationPhaseDualRead,
+	MigrationPhaseComplete,
+}
+
+// OnlineMigration records the phase and backfill progress of one online
+// schema migration, shared by every instance
+type OnlineMigration struct {
+	Name  string         `gorm:"primary_key" json:"name"`
+	Phase MigrationPhase `gorm:"not null" json:"phase"`
+	// Cursor is the key of the last backfilled row
+	Cursor    string `gorm:"type:text" json:"cursor,omitempty"`
+	Processed int64  `gorm:"not null;default:0" json:"processed"`
+	// BackfilledAt is set once the backfill reached the end of the table
+	BackfilledAt *time.Time `json:"backfilled_at,omitempty"`
+	Error        string     `gorm:"type:text" json:"error,omitempty"`
+	UpdatedBy    *uuid.UUID `gorm:"type:uuid" json:"updated_by,omitempty"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// MigrationFlags tell code on the read and write paths which schema to use
+type MigrationFlags struct {
+	WriteOld bool `json:"write_old"`
+	WriteNew bool `json:"write_new"`
+	ReadNew  bool `json:"read_new"`
+}
+
+// OnlineMigrationStatus describes a registered migration
+type OnlineMigrationStatus struct {
+	OnlineMigration
+	Description string         `json:"description"`
+	Table       string         `json:"table"`
+	Flags       MigrationFlags `json:"flags"`
+	// Remaining is the number of rows the backfill still has to copy, when
+	// the migration can count them
+	Remaining *int64 `json:"remaining,omitempty"`
+}
+
+type SetMigrationPhaseRequest struct {
+	Phase MigrationPhase `json:"phase" binding:"required"`
+}
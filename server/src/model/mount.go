@@ -0,0 +1,123 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MountType names the secrets engine serving a mount
+type MountType string
+
+const (
+	MountTypeKV       MountType = "kv"
+	MountTypeTransit  MountType = "transit"
+	MountTypePKI      MountType = "pki"
+	MountTypeSSH      MountType = "ssh"
+	MountTypeDatabase MountType = "database"
+	MountTypeTOTP     MountType = "totp"
+)
+
+// Built-in mounts, created at startup and served by the original /secrets
+// and /totp routes
+const (
+	DefaultKVMount   = "secret"
+	DefaultTOTPMount = "totp"
+)
+
+// Mount enables a secrets engine at a path. KV mounts are served under
+// /mounts/:path/secrets; a mount owned by a namespace keeps its secrets in
+// that namespace.
+type Mount struct {
+	Path        string     `gorm:"primary_key" json:"path"`
+	Type        MountType  `gorm:"not null" json:"type"`
+	Description string     `json:"description"`
+	NamespaceID *uuid.UUID `gorm:"type:uuid;index" json:"namespace_id,omitempty"`
+	// DefaultTTL is the lifetime, in seconds, of secrets created without
+	// an expiry; MaxTTL caps any expiry. Zero means no limit.
+	DefaultTTL int `gorm:"not null;default:0" json:"default_ttl"`
+	MaxTTL     int `gorm:"not null;default:0" json:"max_ttl"`
+	// DefaultLeaseTTL and MaxLeaseTTL do the same for access leased out of
+	// the mount, such as share links; zero falls back to the server limits
+	DefaultLeaseTTL int `gorm:"not null;default:0" json:"default_lease_ttl"`
+	MaxLeaseTTL     int `gorm:"not null;default:0" json:"max_lease_ttl"`
+	// Versioning keeps the previous values of a secret when it changes
+	Versioning bool       `gorm:"not null;default:false" json:"versioning"`
+	Builtin    bool       `gorm:"not null;default:false" json:"builtin"`
+	CreatedBy  *uuid.UUID `gorm:"type:uuid" json:"created_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+type MountOptions struct {
+	DefaultTTL      *int  `json:"default_ttl"`
+	MaxTTL          *int  `json:"max_ttl"`
+	DefaultLeaseTTL *int  `json:"default_lease_ttl"`
+	MaxLeaseTTL     *int  `json:"max_lease_ttl"`
+	Versioning      *bool `json:"versioning"`
+}
+
+// Sources of an effective TTL
+const (
+	TTLSourceRequest      = "request"
+	TTLSourceMountDefault = "mount_default"
+	TTLSourceMountMax     = "mount_max"
+	TTLSourceServer       = "server_default"
+	TTLSourceUnchanged    = "unchanged"
+	TTLSourceNone         = "none"
+)
+
+// TTLResolution explains how a TTL was resolved against the mount's
+// limits, so clients see what they got rather than what they asked for.
+// Durations are in seconds; zero limits mean none.
+type TTLResolution struct {
+	Requested  *int       `json:"requested_ttl,omitempty"`
+	DefaultTTL int        `json:"default_ttl"`
+	MaxTTL     int        `json:"max_ttl"`
+	Effective  int        `json:"effective_ttl"`
+	Source     string     `json:"source"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+type EnableMountRequest struct {
+	Path        string       `json:"path" binding:"required"`
+	Type        MountType    `json:"type" binding:"required"`
+	Description string       `json:"description"`
+	NamespaceID *uuid.UUID   `json:"namespace_id"`
+	Options     MountOptions `json:"options"`
+}
+
+type TuneMountRequest struct {
+	Description *string      `json:"description"`
+	Options     MountOptions `json:"options"`
+}
+
+// SecretVersion is a previous value of a secret in a versioned mount,
+// still sealed the way it was when it was replaced
+type SecretVersion struct {
+	ID              uuid.UUID `gorm:"type:uuid;primary_key" json:"-"`
+	SecretID        uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_secret_version" json:"secret_id"`
+	Version         int       `gorm:"not null;uniqueIndex:idx_secret_version" json:"version"`
+	Value           string    `gorm:"type:text;not null" json:"-"`
+	WrappedKey      string    `gorm:"type:text" json:"-"`
+	ValueHash       string    `gorm:"not null" json:"-"`
+	ClientEncrypted bool      `json:"client_encrypted"`
+	// CreatedAt is when this value was first stored; ReplacedAt when a
+	// newer value superseded it
+	CreatedAt  time.Time  `json:"created_at"`
+	ReplacedAt time.Time  `json:"replaced_at"`
+	ReplacedBy *uuid.UUID `gorm:"type:uuid" json:"replaced_by,omitempty"`
+}
+
+// RollbackSecretResponse reports the version a rollback restored and the
+// version it became
+type RollbackSecretResponse struct {
+	Secret
+	RestoredVersion int `json:"restored_version"`
+}
+
+// SecretVersionValue is a previous value, opened for its owner
+type SecretVersionValue struct {
+	SecretVersion
+	Value string `json:"value"`
+}
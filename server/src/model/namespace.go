@@ -0,0 +1,81 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Namespace struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	Name        string    `gorm:"uniqueIndex;not null" json:"name"`
+	Description string    `json:"description"`
+	CreatedBy   uuid.UUID `gorm:"type:uuid;not null" json:"created_by"`
+	// SandboxOf is the namespace a sandbox was cloned from; sandboxes are
+	// destroyed, with everything in them, at ExpiresAt
+	SandboxOf *uuid.UUID     `gorm:"type:uuid;index" json:"sandbox_of,omitempty"`
+	ExpiresAt *time.Time     `gorm:"index" json:"expires_at,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+func (n *Namespace) BeforeCreate(tx *gorm.DB) error {
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+	return nil
+}
+
+type NamespaceRole string
+
+const (
+	NamespaceRoleAdmin         NamespaceRole = "namespace-admin"
+	NamespaceRoleSecretsWriter NamespaceRole = "secrets-writer"
+	NamespaceRoleAuditor       NamespaceRole = "auditor"
+)
+
+type NamespacePermission string
+
+const (
+	NamespacePermissionSecretsRead  NamespacePermission = "secrets:read"
+	NamespacePermissionSecretsWrite NamespacePermission = "secrets:write"
+	NamespacePermissionAuditRead    NamespacePermission = "audit:read"
+	NamespacePermissionRolesManage  NamespacePermission = "roles:manage"
+	NamespacePermissionKeysManage   NamespacePermission = "keys:manage"
+)
+
+type NamespaceRoleBinding struct {
+	ID          uuid.UUID     `gorm:"type:uuid;primary_key" json:"id"`
+	NamespaceID uuid.UUID     `gorm:"type:uuid;not null;uniqueIndex:idx_namespace_role_binding" json:"namespace_id"`
+	UserID      uuid.UUID     `gorm:"type:uuid;not null;uniqueIndex:idx_namespace_role_binding;index" json:"user_id"`
+	Role        NamespaceRole `gorm:"not null;uniqueIndex:idx_namespace_role_binding" json:"role"`
+	GrantedBy   uuid.UUID     `gorm:"type:uuid;not null" json:"granted_by"`
+	CreatedAt   time.Time     `json:"created_at"`
+
+	Namespace Namespace `gorm:"foreignKey:NamespaceID" json:"-"`
+	User      User      `gorm:"foreignKey:UserID" json:"-"`
+}
+
+func (b *NamespaceRoleBinding) BeforeCreate(tx *gorm.DB) error {
+	if b.ID == uuid.Nil {
+		b.ID = uuid.New()
+	}
+	return nil
+}
+
+type CreateNamespaceRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+type AssignNamespaceRoleRequest struct {
+	Role    NamespaceRole `json:"role" binding:"required"`
+	UserIDs []uuid.UUID   `json:"user_ids" binding:"required,min=1"`
+}
+
+type NamespaceRoleInfo struct {
+	Role        NamespaceRole         `json:"role"`
+	Permissions []NamespacePermission `json:"permissions"`
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// NotificationCategory groups the messages a user can opt out of
+type NotificationCategory string
+
+const (
+	NotificationCategoryApprovals      NotificationCategory = "approvals"
+	NotificationCategoryAccessRequests NotificationCategory = "access_requests"
+	NotificationCategoryReminders      NotificationCategory = "reminders"
+	NotificationCategorySecurity       NotificationCategory = "security"
+)
+
+var NotificationCategories = []NotificationCategory{
+	NotificationCategoryApprovals,
+	NotificationCategoryAccessRequests,
+	NotificationCategoryReminders,
+	NotificationCategorySecurity,
+}
+
+// NotificationPreference records a user's choice for one category. Without
+// a row the category is enabled.
+type NotificationPreference struct {
+	ID        uuid.UUID            `gorm:"type:uuid;primary_key" json:"-"`
+	UserID    uuid.UUID            `gorm:"type:uuid;not null;uniqueIndex:idx_notification_preference" json:"-"`
+	Category  NotificationCategory `gorm:"not null;uniqueIndex:idx_notification_preference" json:"category"`
+	Email     bool                 `gorm:"not null" json:"email"`
+	UpdatedAt time.Time            `json:"updated_at"`
+}
+
+func (p *NotificationPreference) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+type NotificationPreferencesResponse struct {
+	Email map[NotificationCategory]bool `json:"email"`
+}
+
+type UpdateNotificationPreferencesRequest struct {
+	Email map[NotificationCategory]bool `json:"email" binding:"required"`
+}
+
+type TestNotificationRequest struct {
+	To string `json:"to" binding:"omitempty,email"`
+}
+
+type TestNotificationResponse struct {
+	To      string `json:"to"`
+	Message string `json:"message"`
+}
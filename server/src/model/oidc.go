@@ -0,0 +1,76 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OIDCKey is a signing key for vault-issued identity tokens. Keys sign
+// until ActiveUntil and stay published in the JWKS until ExpiresAt.
+type OIDCKey struct {
+	ID          string     `gorm:"primary_key" json:"kid"`
+	Algorithm   string     `gorm:"not null" json:"alg"`
+	PrivateKey  string     `gorm:"type:text;not null" json:"-"`
+	PublicKey   string     `gorm:"type:text;not null" json:"-"`
+	ActiveUntil *time.Time `json:"active_until,omitempty"`
+	ExpiresAt   *time.Time `gorm:"index" json:"expires_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// OIDCRole describes one kind of identity token: its audience, lifetime
+// and a claims template rendered against the calling identity.
+type OIDCRole struct {
+	ID        uuid.UUID      `gorm:"type:uuid;primary_key" json:"id"`
+	Name      string         `gorm:"uniqueIndex;not null" json:"name"`
+	ClientID  string         `gorm:"not null" json:"client_id"`
+	TTL       int            `json:"ttl"`
+	Template  string         `gorm:"type:text" json:"template"`
+	CreatedBy uuid.UUID      `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+func (r *OIDCRole) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+type CreateOIDCRoleRequest struct {
+	Name     string `json:"name" binding:"required"`
+	ClientID string `json:"client_id"`
+	TTL      int    `json:"ttl"`
+	Template string `json:"template"`
+}
+
+type IdentityTokenResponse struct {
+	Token     string    `json:"token"`
+	ClientID  string    `json:"client_id"`
+	TTL       int       `json:"ttl"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type OIDCDiscoveryResponse struct {
+	Issuer                           string   `json:"issuer"`
+	JWKSURI                          string   `json:"jwks_uri"`
+	ResponseTypesSupported           []string `json:"response_types_supported"`
+	SubjectTypesSupported            []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported"`
+}
+
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Modulus   string `json:"n"`
+	Exponent  string `json:"e"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
@@ -0,0 +1,118 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Auth methods name the identities and policy assignments their logins make
+const (
+	AuthMethodOIDC = "oidc"
+	AuthMethodLDAP = "ldap"
+)
+
+// OIDCProvider is an external identity provider users sign in with through
+// the authorization code flow
+type OIDCProvider struct {
+	ID       uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	Name     string    `gorm:"uniqueIndex;not null" json:"name"`
+	Issuer   string    `gorm:"not null" json:"issuer"`
+	ClientID string    `gorm:"not null" json:"client_id"`
+	// ClientSecret is encrypted with the master key
+	ClientSecret string `gorm:"type:text" json:"-"`
+	// Scopes are requested besides openid
+	Scopes []string `gorm:"serializer:json;type:text" json:"scopes"`
+	// RedirectURIs are the callback URLs clients may ask the provider to
+	// return to
+	RedirectURIs []string `gorm:"serializer:json;type:text" json:"redirect_uris"`
+	// ClaimMappings names the ID token claims holding email, first_name,
+	// last_name and groups; unmapped fields use the standard claims
+	ClaimMappings map[string]string `gorm:"serializer:json;type:text" json:"claim_mappings,omitempty"`
+	// GroupPolicies attaches policies to members of provider groups on
+	// each login
+	GroupPolicies map[string][]uuid.UUID `gorm:"serializer:json;type:text" json:"group_policies,omitempty"`
+	// AutoCreateUsers creates a vault user on first login; otherwise the
+	// login needs a user with the same verified email
+	AutoCreateUsers bool      `gorm:"not null;default:false" json:"auto_create_users"`
+	IsActive        bool      `gorm:"not null;default:true" json:"is_active"`
+	CreatedBy       uuid.UUID `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+func (p *OIDCProvider) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+// OIDCLoginState is an authorization request waiting for its callback.
+// Each state is used once.
+type OIDCLoginState struct {
+	State        string    `gorm:"primary_key" json:"-"`
+	ProviderID   uuid.UUID `gorm:"type:uuid;not null" json:"-"`
+	Nonce        string    `gorm:"not null" json:"-"`
+	CodeVerifier string    `gorm:"not null" json:"-"`
+	RedirectURI  string    `gorm:"type:text;not null" json:"-"`
+	ExpiresAt    time.Time `gorm:"index;not null" json:"-"`
+	CreatedAt    time.Time `json:"-"`
+}
+
+// AuthIdentity links a user at an external auth method, such as the
+// subject of an OIDC provider, to a vault user
+type AuthIdentity struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	Method      string     `gorm:"not null;uniqueIndex:idx_auth_identity" json:"method"`
+	Provider    string     `gorm:"not null;uniqueIndex:idx_auth_identity" json:"provider"`
+	Subject     string     `gorm:"not null;uniqueIndex:idx_auth_identity" json:"subject"`
+	UserID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+func (i *AuthIdentity) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
+type CreateOIDCProviderRequest struct {
+	Name            string                 `json:"name" binding:"required"`
+	Issuer          string                 `json:"issuer" binding:"required,url"`
+	ClientID        string                 `json:"client_id" binding:"required"`
+	ClientSecret    string                 `json:"client_secret"`
+	Scopes          []string               `json:"scopes"`
+	RedirectURIs    []string               `json:"redirect_uris" binding:"required,min=1,dive,url"`
+	ClaimMappings   map[string]string      `json:"claim_mappings"`
+	GroupPolicies   map[string][]uuid.UUID `json:"group_policies"`
+	AutoCreateUsers bool                   `json:"auto_create_users"`
+}
+
+// UpdateOIDCProviderRequest changes the fields present; the name and
+// issuer are fixed
+type UpdateOIDCProviderRequest struct {
+	ClientID        *string                 `json:"client_id"`
+	ClientSecret    *string                 `json:"client_secret"`
+	Scopes          *[]string               `json:"scopes"`
+	RedirectURIs    *[]string               `json:"redirect_uris" binding:"omitempty,min=1,dive,url"`
+	ClaimMappings   *map[string]string      `json:"claim_mappings"`
+	GroupPolicies   *map[string][]uuid.UUID `json:"group_policies"`
+	AutoCreateUsers *bool                   `json:"auto_create_users"`
+	IsActive        *bool                   `json:"is_active"`
+}
+
+type OIDCAuthURLRequest struct {
+	RedirectURI string `json:"redirect_uri" binding:"required"`
+}
+
+type OIDCAuthURLResponse struct {
+	AuthURL   string    `json:"auth_url"`
+	State     string    `json:"state"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
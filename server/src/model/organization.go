@@ -0,0 +1,129 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OrgScope is the level of the organization hierarchy a membership is
+// held on
+type OrgScope string
+
+const (
+	OrgScopeOrganization OrgScope = "organization"
+	OrgScopeTeam         OrgScope = "team"
+	OrgScopeProject      OrgScope = "project"
+)
+
+// OrgRole is a membership role. A role held on an organization or team
+// applies to every team and project below it.
+type OrgRole string
+
+const (
+	// OrgRoleOwner may also delete the entity and grant or revoke owner
+	OrgRoleOwner OrgRole = "owner"
+	// OrgRoleAdmin manages members, child teams and projects
+	OrgRoleAdmin OrgRole = "admin"
+	// OrgRoleWriter creates secrets in projects
+	OrgRoleWriter OrgRole = "writer"
+	// OrgRoleReader reads the secrets of projects
+	OrgRoleReader OrgRole = "reader"
+)
+
+type Organization struct {
+	ID          uuid.UUID      `gorm:"type:uuid;primary_key" json:"id"`
+	Name        string         `gorm:"uniqueIndex;not null" json:"name"`
+	Description string         `json:"description"`
+	CreatedBy   uuid.UUID      `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+func (o *Organization) BeforeCreate(tx *gorm.DB) error {
+	if o.ID == uuid.Nil {
+		o.ID = uuid.New()
+	}
+	return nil
+}
+
+type Team struct {
+	ID             uuid.UUID      `gorm:"type:uuid;primary_key" json:"id"`
+	OrganizationID uuid.UUID      `gorm:"type:uuid;not null;uniqueIndex:idx_team_name" json:"organization_id"`
+	Name           string         `gorm:"not null;uniqueIndex:idx_team_name" json:"name"`
+	Description    string         `json:"description"`
+	CreatedBy      uuid.UUID      `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Organization Organization `gorm:"foreignKey:OrganizationID" json:"-"`
+}
+
+func (t *Team) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// Project groups secrets; secrets name it with project_id
+type Project struct {
+	ID          uuid.UUID      `gorm:"type:uuid;primary_key" json:"id"`
+	TeamID      uuid.UUID      `gorm:"type:uuid;not null;uniqueIndex:idx_project_name" json:"team_id"`
+	Name        string         `gorm:"not null;uniqueIndex:idx_project_name" json:"name"`
+	Description string         `json:"description"`
+	CreatedBy   uuid.UUID      `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Team Team `gorm:"foreignKey:TeamID" json:"-"`
+}
+
+func (p *Project) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+// OrgMembership gives a user one role on an organization, team or project
+type OrgMembership struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	Scope     OrgScope  `gorm:"not null;uniqueIndex:idx_org_membership" json:"scope"`
+	ScopeID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_org_membership" json:"scope_id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_org_membership;index" json:"user_id"`
+	Role      OrgRole   `gorm:"not null" json:"role"`
+	GrantedBy uuid.UUID `gorm:"type:uuid;not null" json:"granted_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+func (m *OrgMembership) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
+
+// CreateOrgEntityRequest creates an organization, team or project
+type CreateOrgEntityRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+type UpdateOrgEntityRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+}
+
+// SetOrgMemberRequest adds a member, or changes the role of one
+type SetOrgMemberRequest struct {
+	UserID uuid.UUID `json:"user_id" binding:"required"`
+	Role   OrgRole   `json:"role" binding:"required,oneof=owner admin writer reader"`
+}
@@ -0,0 +1,37 @@
+package model
+
+// PasswordPolicyTestRequest checks a candidate password against the
+// password policy. Email and names, when given, count against the
+// password's strength the way the user's own details would.
+type PasswordPolicyTestRequest struct {
+	Password  string `json:"password" binding:"required"`
+	Email     string `json:"email"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
+
+// PasswordPolicyResult is how a password fares against the policy
+type PasswordPolicyResult struct {
+	Valid bool `json:"valid"`
+	// Strength is a zxcvbn-style score from 0 to 4, and GuessesLog10 the
+	// estimated number of guesses it rests on
+	Strength     int     `json:"strength"`
+	GuessesLog10 float64 `json:"guesses_log10"`
+	// BreachChecked is false when the breach check is off or its lookup
+	// failed; Breached counts the password's appearances in breaches
+	BreachChecked bool     `json:"breach_checked"`
+	Breached      int      `json:"breached,omitempty"`
+	Violations    []string `json:"violations"`
+}
+
+// PasswordPolicyViolationResponse answers a password the policy rejects
+type PasswordPolicyViolationResponse struct {
+	Error      ErrorDetail `json:"error"`
+	Violations []string    `json:"violations"`
+}
+
+// ChangePasswordRequest changes the caller's own password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
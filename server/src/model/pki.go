@@ -0,0 +1,146 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Key types the PKI engine generates
+const (
+	PKIKeyTypeRSA     = "rsa"
+	PKIKeyTypeEC      = "ec"
+	PKIKeyTypeEd25519 = "ed25519"
+)
+
+// Extended key usages a PKI role grants
+const (
+	PKIUsageServer = "server"
+	PKIUsageClient = "client"
+)
+
+// PKIIssuer is a certificate authority hosted by the vault: a root when it
+// has no parent, an intermediate signed by its parent otherwise. Its CRL
+// is re-signed on every revocation and before it goes stale.
+type PKIIssuer struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	Name         string     `gorm:"uniqueIndex;not null" json:"name"`
+	ParentID     *uuid.UUID `gorm:"type:uuid;index" json:"parent_id,omitempty"`
+	CommonName   string     `gorm:"not null" json:"common_name"`
+	KeyType      string     `gorm:"not null" json:"key_type"`
+	KeyBits      int        `json:"key_bits,omitempty"`
+	SerialNumber string     `gorm:"not null" json:"serial_number"`
+	Certificate  string     `gorm:"type:text;not null" json:"certificate"`
+	PrivateKey   string     `gorm:"type:text;not null" json:"-"`
+	NotBefore    time.Time  `json:"not_before"`
+	NotAfter     time.Time  `json:"not_after"`
+	CRL          []byte     `json:"-"`
+	CRLNumber    int64      `gorm:"not null;default:0" json:"crl_number"`
+	CRLUpdatedAt *time.Time `json:"crl_updated_at,omitempty"`
+	CreatedBy    uuid.UUID  `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+func (i *PKIIssuer) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
+// PKIRole constrains the certificates issued under its name: which
+// issuer signs them, the names and key types they may carry and how long
+// they live.
+type PKIRole struct {
+	ID               uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	Name             string    `gorm:"uniqueIndex;not null" json:"name"`
+	IssuerID         uuid.UUID `gorm:"type:uuid;not null;index" json:"issuer_id"`
+	AllowedDomains   string    `gorm:"type:text;not null" json:"-"`
+	DomainList       []string  `gorm:"-" json:"allowed_domains"`
+	AllowSubdomains  bool      `gorm:"not null;default:false" json:"allow_subdomains"`
+	AllowBareDomains bool      `gorm:"not null;default:false" json:"allow_bare_domains"`
+	AllowIPSANs      bool      `gorm:"not null;default:false" json:"allow_ip_sans"`
+	AllowedKeyTypes  string    `gorm:"type:text" json:"-"`
+	KeyTypeList      []string  `gorm:"-" json:"allowed_key_types"`
+	Usages           string    `gorm:"not null" json:"-"`
+	UsageList        []string  `gorm:"-" json:"usages"`
+	// TTL and MaxTTL are in seconds; zero falls back to the configured
+	// defaults
+	TTL       int       `json:"ttl"`
+	MaxTTL    int       `json:"max_ttl"`
+	CreatedBy uuid.UUID `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (r *PKIRole) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// PKICertificate records a certificate the engine issued, so that it can
+// be revoked by serial number. The private key is never stored.
+type PKICertificate struct {
+	SerialNumber string     `gorm:"primary_key" json:"serial_number"`
+	IssuerID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"issuer_id"`
+	Role         string     `gorm:"not null;index" json:"role"`
+	CommonName   string     `gorm:"not null" json:"common_name"`
+	Certificate  string     `gorm:"type:text;not null" json:"certificate"`
+	NotAfter     time.Time  `gorm:"not null;index" json:"not_after"`
+	IssuedBy     uuid.UUID  `gorm:"type:uuid;not null;index" json:"issued_by"`
+	RevokedAt    *time.Time `gorm:"index" json:"revoked_at,omitempty"`
+	RevokedBy    *uuid.UUID `gorm:"type:uuid" json:"revoked_by,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// CreatePKIIssuerRequest creates a root, or an intermediate signed by
+// Parent. TTL is in seconds.
+type CreatePKIIssuerRequest struct {
+	Name       string `json:"name" binding:"required"`
+	CommonName string `json:"common_name" binding:"required"`
+	Parent     string `json:"parent"`
+	KeyType    string `json:"key_type"`
+	KeyBits    int    `json:"key_bits"`
+	TTL        int    `json:"ttl"`
+}
+
+type CreatePKIRoleRequest struct {
+	Name             string   `json:"name" binding:"required"`
+	Issuer           string   `json:"issuer" binding:"required"`
+	AllowedDomains   []string `json:"allowed_domains" binding:"required"`
+	AllowSubdomains  bool     `json:"allow_subdomains"`
+	AllowBareDomains bool     `json:"allow_bare_domains"`
+	AllowIPSANs      bool     `json:"allow_ip_sans"`
+	AllowedKeyTypes  []string `json:"allowed_key_types"`
+	Usages           []string `json:"usages"`
+	TTL              int      `json:"ttl"`
+	MaxTTL           int      `json:"max_ttl"`
+}
+
+type IssueCertificateRequest struct {
+	CommonName string   `json:"common_name" binding:"required"`
+	AltNames   []string `json:"alt_names"`
+	IPSANs     []string `json:"ip_sans"`
+	TTL        int      `json:"ttl"`
+	KeyType    string   `json:"key_type"`
+	KeyBits    int      `json:"key_bits"`
+}
+
+// IssueCertificateResponse carries the certificate with its freshly
+// generated private key, which the vault does not keep
+type IssueCertificateResponse struct {
+	Certificate    string    `json:"certificate"`
+	IssuingCA      string    `json:"issuing_ca"`
+	CAChain        []string  `json:"ca_chain"`
+	PrivateKey     string    `json:"private_key"`
+	PrivateKeyType string    `json:"private_key_type"`
+	SerialNumber   string    `json:"serial_number"`
+	Expiration     time.Time `json:"expiration"`
+}
+
+type RevokeCertificateRequest struct {
+	SerialNumber string `json:"serial_number" binding:"required"`
+}
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PolicyCanaryStatus is the state of a policy canary
+type PolicyCanaryStatus string
+
+const (
+	PolicyCanaryActive    PolicyCanaryStatus = "active"
+	PolicyCanaryPromoted  PolicyCanaryStatus = "promoted"
+	PolicyCanaryCancelled PolicyCanaryStatus = "cancelled"
+	// PolicyCanaryExpired is reported for active canaries past EndsAt;
+	// they stop being evaluated but can still be promoted
+	PolicyCanaryExpired PolicyCanaryStatus = "expired"
+)
+
+// PolicyCanary holds new rules for a policy that are evaluated next to the
+// current ones, without being enforced, until EndsAt. Requests whose
+// outcome would change are recorded as divergences.
+type PolicyCanary struct {
+	ID          uuid.UUID          `gorm:"type:uuid;primary_key" json:"id"`
+	PolicyID    uuid.UUID          `gorm:"type:uuid;not null;index" json:"policy_id"`
+	Rules       string             `gorm:"type:text;not null" json:"rules"`
+	Status      PolicyCanaryStatus `gorm:"not null;index" json:"status"`
+	StartedBy   uuid.UUID          `gorm:"type:uuid;not null" json:"started_by"`
+	EndsAt      time.Time          `gorm:"not null" json:"ends_at"`
+	CompletedBy *uuid.UUID         `gorm:"type:uuid" json:"completed_by,omitempty"`
+	CompletedAt *time.Time         `json:"completed_at,omitempty"`
+	CreatedAt   time.Time          `json:"created_at"`
+}
+
+func (c *PolicyCanary) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+// Active reports whether the canary's rules are still shadow evaluated
+func (c *PolicyCanary) Active(now time.Time) bool {
+	return c.Status == PolicyCanaryActive && now.Before(c.EndsAt)
+}
+
+// PolicyDivergence is a request whose outcome differs between the current
+// rules and a canary's, counted once per user, resource and action
+type PolicyDivergence struct {
+	ID       uuid.UUID `gorm:"type:uuid;primary_key" json:"-"`
+	CanaryID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_policy_divergence" json:"canary_id"`
+	UserID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_policy_divergence" json:"user_id"`
+	Resource string    `gorm:"not null;uniqueIndex:idx_policy_divergence" json:"resource"`
+	Action   string    `gorm:"not null;uniqueIndex:idx_policy_divergence" json:"action"`
+	// Enforced is the outcome under the current rules, Shadow the one the
+	// canary's rules would give
+	Enforced  bool      `json:"enforced"`
+	Shadow    bool      `json:"shadow"`
+	Count     int64     `gorm:"not null;default:1" json:"count"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+func (d *PolicyDivergence) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
+
+type StartPolicyCanaryRequest struct {
+	Rules string `json:"rules" binding:"required"`
+	// Duration is the shadow window in seconds
+	Duration int `json:"duration" binding:"min=0"`
+}
+
+// PolicyCanaryReport is the analysis of a canary: which requests would
+// change outcome if it were promoted
+type PolicyCanaryReport struct {
+	Canary PolicyCanary `json:"canary"`
+	// WouldAllow and WouldDeny count the distinct requests the canary
+	// would newly allow or deny
+	WouldAllow  int64              `json:"would_allow"`
+	WouldDeny   int64              `json:"would_deny"`
+	Divergences []PolicyDivergence `json:"divergences"`
+}
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProviderContractVersion is bumped only on breaking changes to the
+// /provider endpoints that infrastructure-as-code tools build on
+const ProviderContractVersion = "1"
+
+// ProviderSecretRequest declares the desired state of a secret. The
+// secret is identified by name within the caller's secrets, or within a
+// namespace when NamespaceID is set.
+type ProviderSecretRequest struct {
+	Name            string     `json:"name" binding:"required"`
+	Description     string     `json:"description"`
+	Value           string     `json:"value" binding:"required"`
+	Type            SecretType `json:"type" binding:"required"`
+	Tags            string     `json:"tags"`
+	ExpiresAt       *time.Time `json:"expires_at"`
+	NamespaceID     *uuid.UUID `json:"namespace_id"`
+	ClientEncrypted bool       `json:"client_encrypted"`
+}
+
+// ProviderSecretResponse is a secret as infrastructure-as-code tools see
+// it. ContentHash changes whenever any declared field or the value
+// changes, so a stored hash detects drift without reading the value.
+type ProviderSecretResponse struct {
+	// ImportID is accepted by every /provider/secrets/:import_id endpoint
+	ImportID        string     `json:"import_id"`
+	ID              uuid.UUID  `json:"id"`
+	Name            string     `json:"name"`
+	Description     string     `json:"description"`
+	Type            SecretType `json:"type"`
+	Tags            string     `json:"tags"`
+	ExpiresAt       *time.Time `json:"expires_at,omitempty"`
+	NamespaceID     *uuid.UUID `json:"namespace_id,omitempty"`
+	ClientEncrypted bool       `json:"client_encrypted"`
+	ContentHash     string     `json:"content_hash"`
+	RotatedAt       *time.Time `json:"rotated_at,omitempty"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	// Value is only returned when requested with include_value=true
+	Value string `json:"value,omitempty"`
+}
+
+// ProviderUpsertResponse reports what an upsert did. Repeating a request
+// leaves the secret untouched and returns Changed false.
+type ProviderUpsertResponse struct {
+	ProviderSecretResponse
+	Created bool `json:"created"`
+	Changed bool `json:"changed"`
+}
+
+type ProviderContractResponse struct {
+	Version   string   `json:"version"`
+	Resources []string `json:"resources"`
+	// ImportIDFormats documents the accepted import ID forms
+	ImportIDFormats []string `json:"import_id_formats"`
+}
@@ -0,0 +1,157 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// QuotaMetric names a measured namespace resource
+type QuotaMetric string
+
+const (
+	// QuotaMetricSecrets counts active secrets in the namespace
+	QuotaMetricSecrets QuotaMetric = "secrets"
+	// QuotaMetricTokens counts identity tokens issued to namespace members
+	// in the last 24 hours
+	QuotaMetricTokens QuotaMetric = "tokens"
+	// QuotaMetricLeases counts unexpired, unconsumed share links of the
+	// namespace's secrets
+	QuotaMetricLeases QuotaMetric = "leases"
+	// QuotaMetricRequests is the namespace's requests per minute
+	QuotaMetricRequests QuotaMetric = "requests"
+)
+
+// QuotaMetrics lists every metric in display order
+var QuotaMetrics = []QuotaMetric{QuotaMetricSecrets, QuotaMetricTokens, QuotaMetricLeases, QuotaMetricRequests}
+
+// NamespaceQuota sets soft limits on a namespace. They are never enforced;
+// crossing WarnPercent of one raises a namespace.quota_warning event. Zero
+// means no limit.
+type NamespaceQuota struct {
+	NamespaceID uuid.UUID `gorm:"type:uuid;primary_key" json:"namespace_id"`
+	MaxSecrets  int       `gorm:"not null;default:0" json:"max_secrets"`
+	MaxTokens   int       `gorm:"not null;default:0" json:"max_tokens"`
+	MaxLeases   int       `gorm:"not null;default:0" json:"max_leases"`
+	// MaxRequestRate is in requests per minute
+	MaxRequestRate int `gorm:"not null;default:0" json:"max_request_rate"`
+	// WarnPercent overrides quotas.warn_percent when set
+	WarnPercent int        `gorm:"not null;default:0" json:"warn_percent"`
+	UpdatedBy   *uuid.UUID `gorm:"type:uuid" json:"updated_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// Limit returns the quota of metric, 0 when unlimited
+func (q *NamespaceQuota) Limit(metric QuotaMetric) int {
+	if q == nil {
+		return 0
+	}
+	switch metric {
+	case QuotaMetricSecrets:
+		return q.MaxSecrets
+	case QuotaMetricTokens:
+		return q.MaxTokens
+	case QuotaMetricLeases:
+		return q.MaxLeases
+	case QuotaMetricRequests:
+		return q.MaxRequestRate
+	default:
+		return 0
+	}
+}
+
+// NamespaceUsageSample is a namespace's usage at one check, kept for
+// dashboards and forecasts
+type NamespaceUsageSample struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key" json:"-"`
+	NamespaceID uuid.UUID `gorm:"type:uuid;not null;index:idx_namespace_usage_sample" json:"-"`
+	Secrets     int64     `gorm:"not null" json:"secrets"`
+	Tokens      int64     `gorm:"not null" json:"tokens"`
+	Leases      int64     `gorm:"not null" json:"leases"`
+	RequestRate float64   `gorm:"not null" json:"request_rate"`
+	SampledAt   time.Time `gorm:"not null;index:idx_namespace_usage_sample" json:"sampled_at"`
+}
+
+func (s *NamespaceUsageSample) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// Value returns the sampled value of metric
+func (s *NamespaceUsageSample) Value(metric QuotaMetric) float64 {
+	switch metric {
+	case QuotaMetricSecrets:
+		return float64(s.Secrets)
+	case QuotaMetricTokens:
+		return float64(s.Tokens)
+	case QuotaMetricLeases:
+		return float64(s.Leases)
+	case QuotaMetricRequests:
+		return s.RequestRate
+	default:
+		return 0
+	}
+}
+
+// NamespaceRequestCount counts a namespace's requests in one minute. Each
+// instance adds its own counts, so the sum covers the whole cluster.
+type NamespaceRequestCount struct {
+	NamespaceID uuid.UUID `gorm:"type:uuid;primary_key"`
+	Minute      time.Time `gorm:"primary_key;index"`
+	Requests    int64     `gorm:"not null;default:0"`
+}
+
+// NamespaceQuotaAlert records a raised quota warning until usage drops
+// below the threshold again, so each crossing warns once
+type NamespaceQuotaAlert struct {
+	NamespaceID uuid.UUID   `gorm:"type:uuid;primary_key"`
+	Metric      QuotaMetric `gorm:"primary_key"`
+	RaisedAt    time.Time   `gorm:"not null"`
+}
+
+// QuotaForecast extrapolates the growth of a metric over recent samples
+type QuotaForecast struct {
+	// GrowthPerDay is the average daily change over the forecast window
+	GrowthPerDay float64 `json:"growth_per_day"`
+	// LimitReachedAt is when the quota is reached at that growth; empty
+	// without a quota or growth
+	LimitReachedAt *time.Time `json:"limit_reached_at,omitempty"`
+}
+
+// MetricUsage is the current value of a metric against its quota
+type MetricUsage struct {
+	Metric QuotaMetric `json:"metric"`
+	Used   float64     `json:"used"`
+	Limit  int         `json:"limit"`
+	// Percent of the quota in use; empty without a quota
+	Percent  *float64       `json:"percent,omitempty"`
+	Warning  bool           `json:"warning"`
+	Forecast *QuotaForecast `json:"forecast,omitempty"`
+}
+
+// NamespaceUsage is a namespace's dashboard entry
+type NamespaceUsage struct {
+	NamespaceID uuid.UUID       `json:"namespace_id"`
+	Name        string          `json:"name"`
+	Metrics     []MetricUsage   `json:"metrics"`
+	Quota       *NamespaceQuota `json:"quota,omitempty"`
+	MeasuredAt  time.Time       `json:"measured_at"`
+}
+
+type NamespaceUsageHistory struct {
+	NamespaceID uuid.UUID              `json:"namespace_id"`
+	Days        int                    `json:"days"`
+	Samples     []NamespaceUsageSample `json:"samples"`
+}
+
+type SetNamespaceQuotaRequest struct {
+	MaxSecrets     int `json:"max_secrets" binding:"min=0"`
+	MaxTokens      int `json:"max_tokens" binding:"min=0"`
+	MaxLeases      int `json:"max_leases" binding:"min=0"`
+	MaxRequestRate int `json:"max_request_rate" binding:"min=0"`
+	WarnPercent    int `json:"warn_percent" binding:"min=0,max=100"`
+}
@@ -0,0 +1,100 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ReminderPolicy decides when secrets under a path pattern such as
+// "kv/prod/*" produce expiry and rotation reminders, and who hears about
+// them. The most specific matching pattern wins.
+type ReminderPolicy struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	PathPattern string    `gorm:"uniqueIndex;not null" json:"path_pattern"`
+	Description string    `json:"description"`
+	// Days before expires_at to start reminding; 0 disables expiry reminders
+	ExpiryWarnDays int `gorm:"not null;default:0" json:"expiry_warn_days"`
+	// Maximum age of a secret value in days; 0 disables rotation reminders
+	RotationDays int `gorm:"not null;default:0" json:"rotation_days"`
+	// Webhook publishes reminders as events (webhook and chat routing)
+	Webhook     bool           `gorm:"not null" json:"webhook"`
+	NotifyOwner bool           `gorm:"not null" json:"notify_owner"`
+	Emails      string         `gorm:"type:text" json:"-"`
+	CreatedBy   uuid.UUID      `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+
+	EmailList []string `gorm:"-" json:"emails"`
+}
+
+func (p *ReminderPolicy) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+type ReminderKind string
+
+const (
+	ReminderKindExpiry   ReminderKind = "expiry"
+	ReminderKindRotation ReminderKind = "rotation"
+)
+
+type ReminderStatus string
+
+const (
+	// ReminderStatusOpen reminders are re-sent until acknowledged
+	ReminderStatusOpen ReminderStatus = "open"
+	// ReminderStatusAcknowledged reminders stay quiet for their due date
+	ReminderStatusAcknowledged ReminderStatus = "acknowledged"
+	// ReminderStatusResolved reminders no longer apply because the secret
+	// was rotated, its expiry changed or it was deleted
+	ReminderStatusResolved ReminderStatus = "resolved"
+)
+
+// Reminder tracks one due date (expiry or rotation) of one secret
+type Reminder struct {
+	ID             uuid.UUID      `gorm:"type:uuid;primary_key" json:"id"`
+	SecretID       uuid.UUID      `gorm:"type:uuid;not null;uniqueIndex:idx_reminder_due" json:"secret_id"`
+	Kind           ReminderKind   `gorm:"not null;uniqueIndex:idx_reminder_due" json:"kind"`
+	DueAt          time.Time      `gorm:"not null;uniqueIndex:idx_reminder_due" json:"due_at"`
+	PolicyID       uuid.UUID      `gorm:"type:uuid;not null" json:"policy_id"`
+	SecretName     string         `gorm:"not null" json:"secret_name"`
+	Status         ReminderStatus `gorm:"not null;index" json:"status"`
+	SnoozedUntil   *time.Time     `json:"snoozed_until,omitempty"`
+	AcknowledgedBy *uuid.UUID     `gorm:"type:uuid" json:"acknowledged_by,omitempty"`
+	AcknowledgedAt *time.Time     `json:"acknowledged_at,omitempty"`
+	LastNotifiedAt *time.Time     `json:"last_notified_at,omitempty"`
+	NotifyCount    int            `gorm:"not null;default:0" json:"notify_count"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+func (r *Reminder) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+type CreateReminderPolicyRequest struct {
+	PathPattern    string   `json:"path_pattern" binding:"required"`
+	Description    string   `json:"description"`
+	ExpiryWarnDays int      `json:"expiry_warn_days" binding:"min=0,max=3650"`
+	RotationDays   int      `json:"rotation_days" binding:"min=0,max=3650"`
+	Webhook        *bool    `json:"webhook"`
+	NotifyOwner    *bool    `json:"notify_owner"`
+	Emails         []string `json:"emails" binding:"omitempty,dive,email"`
+}
+
+type SnoozeReminderRequest struct {
+	Hours int `json:"hours" binding:"required,min=1,max=8760"`
+}
+
+type ReminderScanResponse struct {
+	Notified int `json:"notified"`
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Value modes of a sandbox clone. Real values are never copied.
+const (
+	// SandboxValuesScrubbed replaces every value with a fixed placeholder
+	SandboxValuesScrubbed = "scrubbed"
+	// SandboxValuesRandom replaces every value with random bytes
+	SandboxValuesRandom = "random"
+)
+
+type CreateSandboxRequest struct {
+	// Name defaults to <source>/sandbox-<id>
+	Name string `json:"name"`
+	// TTL is the sandbox lifetime in seconds
+	TTL    int    `json:"ttl" binding:"min=0"`
+	Values string `json:"values"`
+	// SkipSecrets clones the role bindings, mounts and quota only
+	SkipSecrets bool `json:"skip_secrets"`
+}
+
+// SandboxResponse describes a sandbox and what was cloned into it
+type SandboxResponse struct {
+	Namespace Namespace `json:"namespace"`
+	SourceID  uuid.UUID `json:"source_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Values    string    `json:"values,omitempty"`
+	Bindings  int       `json:"bindings"`
+	Mounts    []string  `json:"mounts"`
+	Secrets   int       `json:"secrets"`
+}
@@ -8,19 +8,77 @@ import (
 )
 
 type Secret struct {
-	ID          uuid.UUID      `gorm:"type:uuid;primary_key" json:"id"`
-	UserID      uuid.UUID      `gorm:"type:uuid;not null" json:"user_id"`
-	Name        string         `gorm:"not null" json:"name"`
-	Description string         `json:"description"`
-	Value       string         `gorm:"type:text;not null" json:"-"`
-	ValueHash   string         `gorm:"not null" json:"-"`
-	Type        SecretType     `gorm:"not null" json:"type"`
-	Tags        string         `gorm:"type:text" json:"tags"`
-	ExpiresAt   *time.Time     `json:"expires_at"`
-	IsActive    bool           `gorm:"default:true" json:"is_active"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	UserID      uuid.UUID  `gorm:"type:uuid;not null" json:"user_id"`
+	Name        string     `gorm:"not null" json:"name"`
+	Description string     `json:"description"`
+	Value       string     `gorm:"type:text;not null" json:"-"`
+	ValueHash   string     `gorm:"not null" json:"-"`
+	Type        SecretType `gorm:"not null" json:"type"`
+	// Tags is a sorted, comma-separated list of labels such as
+	// "team:payments,pci"; policies and list filters match on them
+	Tags string `gorm:"type:text" json:"tags"`
+	// Metadata is free-form key/value information stored in clear
+	Metadata    map[string]string `gorm:"serializer:json;type:text" json:"metadata,omitempty"`
+	NamespaceID *uuid.UUID        `gorm:"type:uuid;index" json:"namespace_id,omitempty"`
+	// ProjectID places the secret in a project; the roles held on the
+	// project, its team and its organization grant access to it
+	ProjectID *uuid.UUID `gorm:"type:uuid;index" json:"project_id,omitempty"`
+	// Mount is the path of the KV mount holding the secret
+	Mount string `gorm:"not null;default:secret;index" json:"mount"`
+	// Version counts value changes; previous values are kept only in
+	// versioned mounts
+	Version int `gorm:"not null;default:1" json:"version"`
+	// MaxVersions caps the previous values kept; zero keeps them all
+	MaxVersions int `gorm:"not null;default:0" json:"max_versions"`
+	// WrappedKey is the per-secret data key wrapped by the namespace's
+	// tenant key; empty when the value is sealed with the master key
+	WrappedKey string     `gorm:"type:text" json:"-"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	// RotatedAt is when the value last changed; reminder policies measure
+	// rotation age from it
+	RotatedAt *time.Time `json:"rotated_at,omitempty"`
+	// RotationPeriod is the maximum age of the value in seconds; zero
+	// never rotates. When it passes, the rotator named by Rotator makes a
+	// new value; a secret without a rotator is only flagged.
+	RotationPeriod int        `gorm:"not null;default:0" json:"rotation_period,omitempty"`
+	Rotator        string     `gorm:"not null;default:''" json:"rotator,omitempty"`
+	NextRotationAt *time.Time `gorm:"index" json:"next_rotation_at,omitempty"`
+	// RotationError is why the last automatic rotation failed
+	RotationError string `gorm:"type:text" json:"rotation_error,omitempty"`
+	// ExpiryWarnedFor and RotationWarnedFor hold the due dates already
+	// announced, so each due date is announced once
+	ExpiryWarnedFor   *time.Time `json:"-"`
+	RotationWarnedFor *time.Time `json:"-"`
+	// CheckoutRequired secrets are read by one user at a time through a
+	// checkout, and rotated when it is checked in
+	CheckoutRequired bool `gorm:"not null;default:false" json:"checkout_required,omitempty"`
+	IsActive         bool `gorm:"default:true" json:"is_active"`
+	// ClientEncrypted values were encrypted by the client before upload;
+	// the server holds no key for them and only stores the envelope
+	ClientEncrypted bool `json:"client_encrypted"`
+	// Checksum is an HMAC over the ciphertext and access metadata; empty
+	// on rows written before checksums until the scrub job fills it in
+	Checksum string `gorm:"type:text" json:"-"`
+	// QuarantinedAt is set when the row fails its integrity check; a
+	// quarantined secret is never decrypted
+	QuarantinedAt *time.Time     `gorm:"index" json:"quarantined_at,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// RequestedTTL asks for an expiry relative to the write instead of
+	// ExpiresAt; TTL reports how the expiry was resolved on writes
+	RequestedTTL *int           `gorm:"-" json:"-"`
+	TTL          *TTLResolution `gorm:"-" json:"ttl,omitempty"`
+	// Validation reports a write held for its validators
+	Validation *SecretValidation `gorm:"-" json:"validation,omitempty"`
+	// Publication reports a value scheduled to become current later
+	Publication *SecretPublication `gorm:"-" json:"publication,omitempty"`
+	// Fields holds the per-field read rules of a JSON value;
+	// WithheldFields names the fields a read left out
+	Fields         []SecretField `gorm:"-" json:"fields,omitempty"`
+	WithheldFields []string      `gorm:"-" json:"withheld_fields,omitempty"`
 
 	User User `gorm:"foreignKey:UserID" json:"-"`
 }
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SecretAccessStat counts value reads of a secret. Reads are aggregated
+// in memory and written at most once per secret per flush, so counts can
+// trail reality by the flush interval.
+type SecretAccessStat struct {
+	SecretID       uuid.UUID `gorm:"type:uuid;primary_key" json:"secret_id"`
+	Reads          int64     `gorm:"not null;default:0" json:"reads"`
+	LastAccessedAt time.Time `gorm:"not null;index" json:"last_accessed_at"`
+}
+
+// UnusedSecret is a secret not read within the report window
+type UnusedSecret struct {
+	ID          uuid.UUID  `json:"id"`
+	Name        string     `json:"name"`
+	UserID      uuid.UUID  `json:"user_id"`
+	NamespaceID *uuid.UUID `json:"namespace_id,omitempty"`
+	Type        SecretType `json:"type"`
+	CreatedAt   time.Time  `json:"created_at"`
+	// LastAccessedAt is empty for secrets never read since tracking began
+	LastAccessedAt *time.Time `json:"last_accessed_at,omitempty"`
+	Reads          int64      `json:"reads"`
+}
+
+type UnusedSecretsReport struct {
+	Days        int            `json:"days"`
+	GeneratedAt time.Time      `json:"generated_at"`
+	Secrets     []UnusedSecret `json:"secrets"`
+	// Owners is the number of owners notified, for notify requests
+	Owners int `json:"owners,omitempty"`
+}
+
+type NotifyUnusedSecretsRequest struct {
+	Days int `json:"days" binding:"required,min=1"`
+}
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"github.com/google/uuid"
+)
+
+// Operations a secret batch may mix
+const (
+	SecretBatchCreate = "create"
+	SecretBatchUpdate = "update"
+	SecretBatchRead   = "read"
+	SecretBatchDelete = "delete"
+)
+
+// SecretBatchRequest runs its operations in order in one transaction:
+// either all of them take effect or none do
+type SecretBatchRequest struct {
+	Operations []SecretBatchOperation `json:"operations" binding:"required,min=1,dive"`
+}
+
+// SecretBatchOperation is one step of a batch. create takes Create;
+// update takes ID and Update; read and delete take ID.
+type SecretBatchOperation struct {
+	Op     string               `json:"op" binding:"required,oneof=create update read delete"`
+	ID     *uuid.UUID           `json:"id"`
+	Create *CreateSecretRequest `json:"create"`
+	Update *UpdateSecretRequest `json:"update"`
+}
+
+// SecretBatchResponse reports every operation of a batch. When Committed
+// is false nothing was written and no values are returned.
+type SecretBatchResponse struct {
+	Committed bool                `json:"committed"`
+	Results   []SecretBatchResult `json:"results"`
+}
+
+// SecretBatchResult is the outcome of one operation, with the HTTP status
+// the operation would have had on its own
+type SecretBatchResult struct {
+	Index  int                  `json:"index"`
+	Op     string               `json:"op"`
+	ID     *uuid.UUID           `json:"id,omitempty"`
+	Status int                  `json:"status"`
+	Secret *Secret              `json:"secret,omitempty"`
+	Value  *SecretValueResponse `json:"value,omitempty"`
+	Error  *ErrorDetail         `json:"error,omitempty"`
+
+	// Err is the failure the controller turns into Status and Error
+	Err error `json:"-"`
+}
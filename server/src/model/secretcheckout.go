@@ -0,0 +1,55 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SecretCheckout is one user's exclusive hold on a secret marked
+// checkout_required. Only the holder reads the value; checking it in
+// rotates the value so the copy the holder saw stops working.
+type SecretCheckout struct {
+	ID       uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	SecretID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_secret_checkouts_active" json:"secret_id"`
+	UserID   uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	// Active is true while the secret is checked out and NULL afterwards;
+	// the unique index keeps two users from holding the same secret
+	Active       *bool      `gorm:"uniqueIndex:idx_secret_checkouts_active" json:"-"`
+	CheckedOutAt time.Time  `gorm:"not null" json:"checked_out_at"`
+	ExpiresAt    time.Time  `gorm:"not null" json:"expires_at"`
+	CheckedInAt  *time.Time `json:"checked_in_at,omitempty"`
+	// CheckedInBy is the holder, or the admin who forced the check-in;
+	// nil when the checkout expired
+	CheckedInBy *uuid.UUID `gorm:"type:uuid" json:"checked_in_by,omitempty"`
+	Forced      bool       `gorm:"not null;default:false" json:"forced,omitempty"`
+	Reason      string     `gorm:"type:text" json:"reason,omitempty"`
+	// RotationError is why the rotation at check-in failed; the rotation
+	// job tries again
+	RotationError string `gorm:"type:text" json:"rotation_error,omitempty"`
+}
+
+func (c *SecretCheckout) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+type CheckoutSecretRequest struct {
+	// TTL is how long the checkout lasts before it is checked in on the
+	// holder's behalf, in seconds
+	TTL    int    `json:"ttl" binding:"min=0"`
+	Reason string `json:"reason"`
+}
+
+// CheckoutSecretResponse is the checkout with the value it grants
+type CheckoutSecretResponse struct {
+	SecretCheckout
+	Value string `json:"value"`
+}
+
+type CheckinSecretRequest struct {
+	Reason string `json:"reason"`
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SecretFieldSensitivity labels a field of a JSON secret value
+type SecretFieldSensitivity string
+
+const (
+	SecretFieldPublic   SecretFieldSensitivity = "public"
+	SecretFieldInternal SecretFieldSensitivity = "internal"
+	// SecretFieldRestricted fields are only read by the owner, unless
+	// the field names a policy the reader holds
+	SecretFieldRestricted SecretFieldSensitivity = "restricted"
+)
+
+// SecretField governs who reads one field of a secret whose value is a
+// JSON object. Fields without a row are read like the secret itself.
+type SecretField struct {
+	ID          uuid.UUID              `gorm:"type:uuid;primary_key" json:"-"`
+	SecretID    uuid.UUID              `gorm:"type:uuid;not null;uniqueIndex:idx_secret_field" json:"-"`
+	Name        string                 `gorm:"not null;uniqueIndex:idx_secret_field" json:"name" binding:"required"`
+	Sensitivity SecretFieldSensitivity `gorm:"not null;default:internal" json:"sensitivity"`
+	// Policy is a policy path readers other than the owner need read
+	// access to, such as deploy/db/password
+	Policy    string    `json:"policy,omitempty"`
+	CreatedAt time.Time `json:"-"`
+}
+
+func (f *SecretField) BeforeCreate(tx *gorm.DB) error {
+	if f.ID == uuid.Nil {
+		f.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Algorithms a client may wrap an imported value with
+const (
+	// ImportAlgorithmRSA wraps a random AES-256 key with RSA-OAEP-SHA256
+	ImportAlgorithmRSA = "rsa-oaep-256"
+	// ImportAlgorithmECDH derives the AES-256 key with HKDF-SHA256 from an
+	// ephemeral P-256 ECDH exchange
+	ImportAlgorithmECDH = "ecdh-p256"
+)
+
+// ImportKey is the key pair clients encrypt imported secret values to. One
+// key is current; retired keys stay for the record but open nothing.
+type ImportKey struct {
+	ID            uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	RSAPublicKey  string    `gorm:"type:text;not null" json:"rsa_public_key"`
+	RSAPrivateKey string    `gorm:"type:text;not null" json:"-"`
+	ECDHPublicKey string    `gorm:"type:text;not null" json:"ecdh_public_key"`
+	// ECDHPrivateKey is sealed like RSAPrivateKey
+	ECDHPrivateKey string `gorm:"type:text;not null" json:"-"`
+	// IsCurrent is true on the current key and NULL on retired ones; the
+	// unique index keeps instances from creating two current keys
+	IsCurrent *bool      `gorm:"uniqueIndex" json:"-"`
+	CreatedBy uuid.UUID  `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	RetiredAt *time.Time `json:"retired_at,omitempty"`
+}
+
+func (k *ImportKey) BeforeCreate(tx *gorm.DB) error {
+	if k.ID == uuid.Nil {
+		k.ID = uuid.New()
+	}
+	return nil
+}
+
+// ImportKeyResponse is the current import key with the algorithms it
+// accepts
+type ImportKeyResponse struct {
+	ImportKey
+	Algorithms []string `json:"algorithms"`
+}
+
+// ImportSecretRequest creates a secret from a value encrypted on the
+// client to the current import key. The value is sealed with AES-256-GCM
+// under a fresh key, with the import key ID as additional data; only the
+// way that key reaches the vault differs between algorithms.
+type ImportSecretRequest struct {
+	Name           string            `json:"name" binding:"required"`
+	Description    string            `json:"description"`
+	Type           SecretType        `json:"type" binding:"required"`
+	Tags           string            `json:"tags"`
+	Metadata       map[string]string `json:"metadata"`
+	ExpiresAt      *time.Time        `json:"expires_at"`
+	TTL            *int              `json:"ttl"`
+	NamespaceID    *uuid.UUID        `json:"namespace_id"`
+	MaxVersions    int               `json:"max_versions" binding:"min=0"`
+	RotationPeriod int               `json:"rotation_period" binding:"min=0"`
+	Rotator        string            `json:"rotator"`
+	// CheckoutRequired and ProjectID are as on CreateSecretRequest
+	CheckoutRequired bool       `json:"checkout_required"`
+	ProjectID        *uuid.UUID `json:"project_id"`
+
+	KeyID     uuid.UUID `json:"key_id" binding:"required"`
+	Algorithm string    `json:"algorithm" binding:"required,oneof=rsa-oaep-256 ecdh-p256"`
+	// EncryptedKey is the RSA-OAEP wrapped AES key, or the ephemeral ECDH
+	// public key (PKIX DER or an uncompressed point), base64 encoded
+	EncryptedKey string `json:"encrypted_key" binding:"required"`
+	// Ciphertext is the 12-byte GCM nonce followed by the sealed value,
+	// base64 encoded
+	Ciphertext string `json:"ciphertext" binding:"required"`
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type SecretPublicationStatus string
+
+const (
+	SecretPublicationScheduled SecretPublicationStatus = "scheduled"
+	SecretPublicationPublished SecretPublicationStatus = "published"
+	SecretPublicationCancelled SecretPublicationStatus = "cancelled"
+	// SecretPublicationFailed means the secret was quarantined before the
+	// value could be published
+	SecretPublicationFailed SecretPublicationStatus = "failed"
+)
+
+// SecretPublication is a value written ahead of time that becomes the
+// current version at ActivateAt. Until then readers keep getting the
+// current value; the scheduled one is sealed here.
+type SecretPublication struct {
+	ID              uuid.UUID               `gorm:"type:uuid;primary_key" json:"id"`
+	SecretID        uuid.UUID               `gorm:"type:uuid;index;not null" json:"secret_id"`
+	Status          SecretPublicationStatus `gorm:"index;not null" json:"status"`
+	Value           string                  `gorm:"type:text;not null" json:"-"`
+	WrappedKey      string                  `gorm:"type:text" json:"-"`
+	ClientEncrypted bool                    `json:"client_encrypted"`
+	ActivateAt      time.Time               `gorm:"not null;index" json:"activate_at"`
+	RequestedBy     uuid.UUID               `gorm:"type:uuid;not null" json:"requested_by"`
+	CreatedAt       time.Time               `json:"created_at"`
+	// Version is the secret version the value became once published
+	Version     int        `json:"version,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+func (p *SecretPublication) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Session is a token in use and the device it is used from. It starts
+// with the first request the token authenticates and ends when the token
+// is revoked or expires; ending a session revokes its token.
+type Session struct {
+	ID      uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	UserID  uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	TokenID uuid.UUID `gorm:"type:uuid;uniqueIndex;not null" json:"accessor"`
+	// Method is how the session's token was obtained, as on the token
+	Method string `gorm:"not null" json:"method"`
+	// DeviceFingerprint is the X-Vault-Device-ID the client sent, or a
+	// hash of its user agent and languages
+	DeviceFingerprint string    `gorm:"index" json:"device_fingerprint"`
+	IPAddress         string    `json:"ip_address"`
+	UserAgent         string    `json:"user_agent"`
+	LastActivityAt    time.Time `gorm:"not null" json:"last_activity_at"`
+	CreatedAt         time.Time `json:"created_at"`
+	// Current marks the session of the calling token in listings
+	Current bool `gorm:"-" json:"current"`
+}
+
+func (s *Session) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// SessionDevice is what a request tells about the device it came from
+type SessionDevice struct {
+	Fingerprint string
+	IPAddress   string
+	UserAgent   string
+}
+
+type TerminateSessionsResponse struct {
+	// Revoked counts the tokens revoked, children included
+	Revoked int64 `json:"revoked"`
+}
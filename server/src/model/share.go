@@ -0,0 +1,79 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Approval states of a share link created with require_approval
+const (
+	ShareApprovalPending  = "pending"
+	ShareApprovalApproved = "approved"
+	ShareApprovalDenied   = "denied"
+)
+
+type ShareLink struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	SecretID   *uuid.UUID `gorm:"type:uuid" json:"secret_id,omitempty"`
+	Ciphertext string     `gorm:"type:text" json:"-"`
+	ExpiresAt  time.Time  `gorm:"not null;index" json:"expires_at"`
+	ConsumedAt *time.Time `json:"consumed_at,omitempty"`
+	ConsumedIP string     `json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+	// MaxViews is how many times the link opens; the value is wiped with
+	// the last view
+	MaxViews int `gorm:"not null;default:1" json:"max_views"`
+	Views    int `gorm:"not null;default:0" json:"views"`
+	// ApprovalStatus is empty on links that need no approval
+	ApprovalStatus string     `gorm:"not null;default:'';index" json:"approval_status,omitempty"`
+	DecidedBy      *uuid.UUID `gorm:"type:uuid" json:"decided_by,omitempty"`
+	DecidedAt      *time.Time `json:"decided_at,omitempty"`
+
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+func (s *ShareLink) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+type CreateShareRequest struct {
+	SecretID *uuid.UUID `json:"secret_id"`
+	Value    string     `json:"value"`
+	TTL      int        `json:"ttl"`
+	// MaxViews defaults to a single view
+	MaxViews int `json:"max_views" binding:"min=0,max=100"`
+	// RequireApproval keeps the link closed until a holder of the
+	// shares/approvals policy approves it
+	RequireApproval bool `json:"require_approval"`
+}
+
+type CreateShareResponse struct {
+	ID        uuid.UUID `json:"id"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+	MaxViews  int       `json:"max_views"`
+	// ApprovalStatus is "pending" when the link awaits approval
+	ApprovalStatus string         `json:"approval_status,omitempty"`
+	TTL            *TTLResolution `json:"ttl"`
+	// Lease renews or revokes the link through /leases
+	Lease *LeaseInfo `json:"lease,omitempty"`
+}
+
+type ShareViewResponse struct {
+	Ciphertext string    `json:"ciphertext"`
+	Algorithm  string    `json:"algorithm"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	// ViewsRemaining is 0 once the link has been wiped
+	ViewsRemaining int `json:"views_remaining"`
+}
+
+// ShareDecisionRequest approves or denies a share link awaiting approval
+type ShareDecisionRequest struct {
+	Reason string `json:"reason"`
+}
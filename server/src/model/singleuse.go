@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// Purposes of single-use tokens. A token is only unique within its purpose.
+const (
+	SingleUsePurposeShare        = "share"
+	SingleUsePurposeChatApproval = "chat_approval"
+	SingleUsePurposeSSHOTP       = "ssh_otp"
+	SingleUsePurposeUnwrap       = "unwrap"
+)
+
+// SingleUseToken records the consumption of a one-time token. The token
+// itself is never stored, only its SHA-256; the primary key makes a second
+// consumption fail on every instance sharing the database.
+type SingleUseToken struct {
+	Purpose    string    `gorm:"primary_key" json:"purpose"`
+	TokenHash  string    `gorm:"primary_key" json:"-"`
+	Consumer   string    `json:"consumer,omitempty"`
+	ConsumedAt time.Time `gorm:"not null" json:"consumed_at"`
+	// ExpiresAt is when the token stops being valid anyway; the record is
+	// no longer needed after it
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+}
@@ -0,0 +1,146 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Kinds of SSH roles: certificate signing, or one-time passwords checked
+// by a helper on the target host
+const (
+	SSHRoleTypeCA  = "ca"
+	SSHRoleTypeOTP = "otp"
+)
+
+// SSHCAKey is the key the vault signs user certificates with. There is at
+// most one; hosts trust it through sshd's TrustedUserCAKeys.
+type SSHCAKey struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	KeyType    string    `gorm:"not null" json:"key_type"`
+	PublicKey  string    `gorm:"type:text;not null" json:"public_key"`
+	PrivateKey string    `gorm:"type:text;not null" json:"-"`
+	CreatedBy  uuid.UUID `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (k *SSHCAKey) BeforeCreate(tx *gorm.DB) error {
+	if k.ID == uuid.Nil {
+		k.ID = uuid.New()
+	}
+	return nil
+}
+
+// SSHRole decides who may get SSH access as whom. A ca role signs public
+// keys for the allowed users with the allowed extensions; an otp role
+// hands out one-time passwords for hosts in its CIDRs.
+type SSHRole struct {
+	ID           uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	Name         string    `gorm:"uniqueIndex;not null" json:"name"`
+	Type         string    `gorm:"not null" json:"type"`
+	AllowedUsers string    `gorm:"type:text;not null" json:"-"`
+	UserList     []string  `gorm:"-" json:"allowed_users"`
+	DefaultUser  string    `json:"default_user,omitempty"`
+	Extensions   string    `gorm:"type:text" json:"-"`
+	// ExtensionList is what certificates may carry and carry by default
+	ExtensionList []string `gorm:"-" json:"extensions"`
+	CIDRs         string   `gorm:"type:text" json:"-"`
+	CIDRList      []string `gorm:"-" json:"cidr_list"`
+	Port          int      `json:"port,omitempty"`
+	// TTL and MaxTTL are in seconds; zero falls back to the configured
+	// defaults
+	TTL       int       `json:"ttl"`
+	MaxTTL    int       `json:"max_ttl"`
+	CreatedBy uuid.UUID `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (r *SSHRole) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// SSHOTP is a one-time password for one user on one host. Only its
+// SHA-256 is stored; using it is recorded in the single-use registry.
+type SSHOTP struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	Hash      string     `gorm:"uniqueIndex;not null" json:"-"`
+	Role      string     `gorm:"not null" json:"role"`
+	Username  string     `gorm:"not null" json:"username"`
+	IP        string     `gorm:"not null" json:"ip"`
+	IssuedBy  uuid.UUID  `gorm:"type:uuid;not null;index" json:"issued_by"`
+	ExpiresAt time.Time  `gorm:"not null;index" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (o *SSHOTP) BeforeCreate(tx *gorm.DB) error {
+	if o.ID == uuid.Nil {
+		o.ID = uuid.New()
+	}
+	return nil
+}
+
+type CreateSSHCARequest struct {
+	KeyType string `json:"key_type"`
+}
+
+type CreateSSHRoleRequest struct {
+	Name         string   `json:"name" binding:"required"`
+	Type         string   `json:"type" binding:"required"`
+	AllowedUsers []string `json:"allowed_users" binding:"required"`
+	DefaultUser  string   `json:"default_user"`
+	Extensions   []string `json:"extensions"`
+	CIDRList     []string `json:"cidr_list"`
+	Port         int      `json:"port"`
+	TTL          int      `json:"ttl"`
+	MaxTTL       int      `json:"max_ttl"`
+}
+
+// SignSSHKeyRequest asks for a certificate over PublicKey, in
+// authorized_keys format. Principals default to the role's default user
+// and Extensions to all of the role's.
+type SignSSHKeyRequest struct {
+	PublicKey       string   `json:"public_key" binding:"required"`
+	ValidPrincipals []string `json:"valid_principals"`
+	Extensions      []string `json:"extensions"`
+	TTL             int      `json:"ttl"`
+}
+
+type SignSSHKeyResponse struct {
+	SerialNumber    string    `json:"serial_number"`
+	SignedKey       string    `json:"signed_key"`
+	ValidPrincipals []string  `json:"valid_principals"`
+	ExpiresAt       time.Time `json:"expires_at"`
+}
+
+type SSHCredentialRequest struct {
+	Username string `json:"username"`
+	IP       string `json:"ip" binding:"required"`
+}
+
+// SSHCredentialResponse carries a one-time password, shown only once
+type SSHCredentialResponse struct {
+	Key       string    `json:"key"`
+	KeyType   string    `json:"key_type"`
+	Username  string    `json:"username"`
+	IP        string    `json:"ip"`
+	Port      int       `json:"port"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type VerifySSHOTPRequest struct {
+	OTP string `json:"otp" binding:"required"`
+}
+
+// VerifySSHOTPResponse tells the host helper whom the password admits;
+// the helper checks the IP is its own
+type VerifySSHOTPResponse struct {
+	Username string `json:"username"`
+	IP       string `json:"ip"`
+	Role     string `json:"role"`
+}
@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+// Coarse component states reported on the public status page
+const (
+	StatusAvailable   = "available"
+	StatusDegraded    = "degraded"
+	StatusUnavailable = "unavailable"
+
+	SealStatusSealed   = "sealed"
+	SealStatusUnsealed = "unsealed"
+
+	// ReplicationLagNotConfigured is the only lag bucket this build reports,
+	// as it has no cross-cluster replication yet
+	ReplicationLagNotConfigured = "not_configured"
+)
+
+// PublicStatus is the coarse health served without authentication. It
+// carries no versions, hostnames or counts.
+type PublicStatus struct {
+	Status         string    `json:"status"`
+	API            string    `json:"api"`
+	Seal           string    `json:"seal"`
+	ReplicationLag string    `json:"replication_lag"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// PublicStatusResponse adds a compact JWS over the status, signed with the
+// current OIDC key so status pages can verify it against the JWKS
+type PublicStatusResponse struct {
+	PublicStatus
+	Signature string `json:"signature,omitempty"`
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserKey is a user's X25519 public key. The private half stays with the
+// user's CLI; teammates wrap data keys of client-encrypted secrets for it.
+type UserKey struct {
+	UserID uuid.UUID `gorm:"type:uuid;primary_key" json:"user_id"`
+	// PublicKey is the raw 32-byte key in unpadded base64url
+	PublicKey string `gorm:"not null" json:"public_key"`
+	// KeyID is the fingerprint envelopes use to name recipients
+	KeyID     string    `gorm:"not null;index" json:"key_id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type RegisterUserKeyRequest struct {
+	PublicKey string `json:"public_key" binding:"required"`
+}
+
+// TeamMemberKey is a namespace member who may read its secrets
+type TeamMemberKey struct {
+	UserID uuid.UUID `json:"user_id"`
+	// Empty until the member registers a key
+	KeyID     string `json:"key_id,omitempty"`
+	PublicKey string `json:"public_key,omitempty"`
+}
+
+// TeamRewrapSecret is a client-encrypted secret whose recipients no longer
+// match the namespace's members
+type TeamRewrapSecret struct {
+	SecretID uuid.UUID `json:"secret_id"`
+	Name     string    `json:"name"`
+	// Grant lists member key IDs missing from the envelope; Revoke lists
+	// key IDs in the envelope that no member holds
+	Grant  []string `json:"grant"`
+	Revoke []string `json:"revoke"`
+}
+
+type TeamRewrapPlanResponse struct {
+	NamespaceID uuid.UUID          `json:"namespace_id"`
+	Members     []TeamMemberKey    `json:"members"`
+	Secrets     []TeamRewrapSecret `json:"secrets"`
+}
+
+type StoreEnvelopeRequest struct {
+	Value string `json:"value" binding:"required"`
+}
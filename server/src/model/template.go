@@ -0,0 +1,60 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SecretTemplate constrains the shape of secret values written under a
+// path pattern such as "kv/db/*".
+type SecretTemplate struct {
+	ID          uuid.UUID      `gorm:"type:uuid;primary_key" json:"id"`
+	PathPattern string         `gorm:"uniqueIndex;not null" json:"path_pattern"`
+	Description string         `json:"description"`
+	Fields      string         `gorm:"type:text;not null" json:"-"`
+	CreatedBy   uuid.UUID      `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+
+	FieldList []TemplateField `gorm:"-" json:"fields"`
+}
+
+func (t *SecretTemplate) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+type TemplateFieldType string
+
+const (
+	TemplateFieldString  TemplateFieldType = "string"
+	TemplateFieldNumber  TemplateFieldType = "number"
+	TemplateFieldInteger TemplateFieldType = "integer"
+	TemplateFieldBoolean TemplateFieldType = "boolean"
+)
+
+type TemplateField struct {
+	Name        string            `json:"name"`
+	Type        TemplateFieldType `json:"type"`
+	Required    bool              `json:"required"`
+	Sensitive   bool              `json:"sensitive,omitempty"`
+	Pattern     string            `json:"pattern,omitempty"`
+	Description string            `json:"description,omitempty"`
+}
+
+type CreateTemplateRequest struct {
+	PathPattern string          `json:"path_pattern" binding:"required"`
+	Description string          `json:"description"`
+	Fields      []TemplateField `json:"fields" binding:"required,min=1"`
+}
+
+type TemplateValidationResponse struct {
+	Error      ErrorDetail `json:"error"`
+	TemplateID uuid.UUID   `json:"template_id"`
+	Violations []string    `json:"violations"`
+}
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type TenantKeyStatus string
+
+const (
+	TenantKeyStatusActive      TenantKeyStatus = "active"
+	TenantKeyStatusUnavailable TenantKeyStatus = "unavailable"
+	TenantKeyStatusRewrapping  TenantKeyStatus = "rewrapping"
+)
+
+// TenantKeyFailover decides what happens to reads when the tenant's KMS
+// cannot be reached
+type TenantKeyFailover string
+
+const (
+	// TenantKeyFailoverClosed unwraps every data key through the KMS and
+	// fails when it is unreachable
+	TenantKeyFailoverClosed TenantKeyFailover = "fail-closed"
+	// TenantKeyFailoverCached keeps unwrapped data keys in memory for
+	// CacheTTL seconds and serves reads from them during an outage
+	TenantKeyFailoverCached TenantKeyFailover = "cached"
+)
+
+// TenantKey is a namespace's own key encryption key, held in an external
+// KMS and referenced by URI. Secrets in the namespace get a per-secret data
+// key wrapped by it.
+type TenantKey struct {
+	ID             uuid.UUID         `gorm:"type:uuid;primary_key" json:"id"`
+	NamespaceID    uuid.UUID         `gorm:"type:uuid;uniqueIndex;not null" json:"namespace_id"`
+	KeyURI         string            `gorm:"not null" json:"key_uri"`
+	PreviousKeyURI string            `json:"previous_key_uri,omitempty"`
+	Version        int               `gorm:"not null;default:1" json:"version"`
+	Status         TenantKeyStatus   `gorm:"not null" json:"status"`
+	FailoverMode   TenantKeyFailover `gorm:"not null" json:"failover_mode"`
+	CacheTTL       int               `gorm:"not null" json:"cache_ttl"`
+	LastCheckedAt  *time.Time        `json:"last_checked_at"`
+	LastError      string            `json:"last_error,omitempty"`
+	RewrapTotal    int64             `json:"rewrap_total"`
+	RewrapDone     int64             `json:"rewrap_done"`
+	CreatedBy      uuid.UUID         `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt    `gorm:"index" json:"-"`
+
+	Namespace Namespace `gorm:"foreignKey:NamespaceID" json:"-"`
+}
+
+func (k *TenantKey) BeforeCreate(tx *gorm.DB) error {
+	if k.ID == uuid.Nil {
+		k.ID = uuid.New()
+	}
+	return nil
+}
+
+type RegisterTenantKeyRequest struct {
+	KeyURI       string            `json:"key_uri" binding:"required"`
+	FailoverMode TenantKeyFailover `json:"failover_mode"`
+	CacheTTL     int               `json:"cache_ttl"`
+}
+
+type RotateTenantKeyRequest struct {
+	KeyURI string `json:"key_uri" binding:"required"`
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AuthMethodPassword names tokens issued by POST /auth/login, and
+// AuthMethodImpersonation tokens an administrator minted to act as a user
+const (
+	AuthMethodPassword      = "password"
+	AuthMethodImpersonation = "impersonation"
+)
+
+// Token is the server-side record of an issued token. The token itself is
+// a signed JWT naming the record in its jti claim, and is only accepted
+// while the record exists and has not expired. The ID doubles as the
+// accessor, which looks the token up and revokes it without revealing it.
+type Token struct {
+	ID     uuid.UUID `gorm:"type:uuid;primary_key" json:"accessor"`
+	UserID uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	// ParentID is the token this one was created with; revoking a token
+	// revokes its children
+	ParentID *uuid.UUID `gorm:"type:uuid;index" json:"parent_accessor,omitempty"`
+	// Method is how the token was obtained, e.g. password or approle
+	Method string `gorm:"not null" json:"method"`
+	// TTL is how many seconds a renewal extends the token by
+	TTL       int       `gorm:"not null" json:"ttl"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	// MaxExpiresAt bounds renewals; the JWT itself expires then
+	MaxExpiresAt time.Time  `gorm:"not null" json:"max_expires_at"`
+	RenewedAt    *time.Time `json:"renewed_at,omitempty"`
+	// MFAVerifiedAt is the token's latest step-up verification
+	MFAVerifiedAt *time.Time `json:"mfa_verified_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+func (t *Token) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// Renewable reports whether a renewal could still extend the token
+func (t *Token) Renewable() bool {
+	return t.ExpiresAt.Before(t.MaxExpiresAt)
+}
+
+// RenewTokenRequest may ask for a shorter extension than the token's TTL,
+// but not a longer one
+type RenewTokenRequest struct {
+	Increment int `json:"increment" binding:"omitempty,min=1"`
+}
+
+type TokenAccessorRequest struct {
+	Accessor uuid.UUID `json:"accessor" binding:"required"`
+}
@@ -13,6 +13,7 @@ type TOTP struct {
 	Name        string         `gorm:"not null" json:"name"`
 	Description string         `json:"description"`
 	Secret      string         `gorm:"type:text;not null" json:"-"`
+	Encrypted   bool           `gorm:"default:false" json:"-"`
 	Algorithm   string         `gorm:"default:SHA1" json:"algorithm"`
 	Digits      int            `gorm:"default:6" json:"digits"`
 	Period      int            `gorm:"default:30" json:"period"`
@@ -30,3 +31,38 @@ func (t *TOTP) BeforeCreate(tx *gorm.DB) error {
 	}
 	return nil
 }
+
+type TOTPVerifyRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type TOTPVerifyResponse struct {
+	Valid bool `json:"valid"`
+}
+
+type TOTPExportRequest struct {
+	UserID     uuid.UUID `json:"user_id" binding:"required"`
+	Passphrase string    `json:"passphrase" binding:"required,min=12"`
+}
+
+type TOTPImportRequest struct {
+	UserID     uuid.UUID  `json:"user_id" binding:"required"`
+	Passphrase string     `json:"passphrase" binding:"required"`
+	Backup     TOTPBackup `json:"backup" binding:"required"`
+}
+
+// TOTPBackup is a passphrase-protected bundle of TOTP seeds used to move
+// them between devices or vaults.
+type TOTPBackup struct {
+	Version    int       `json:"version"`
+	KDF        string    `json:"kdf"`
+	Iterations int       `json:"iterations"`
+	Salt       string    `json:"salt"`
+	Ciphertext string    `json:"ciphertext"`
+	Count      int       `json:"count"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type TOTPImportResponse struct {
+	Imported int `json:"imported"`
+}
@@ -0,0 +1,99 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SecretValidator checks that values written under a path pattern such as
+// "db/prod/*" actually work before the write is accepted, e.g. by logging
+// in to the database they describe. Every matching validator must pass.
+type SecretValidator struct {
+	ID          uuid.UUID           `gorm:"type:uuid;primary_key" json:"id"`
+	PathPattern string              `gorm:"index;not null" json:"path_pattern"`
+	Kind        SecretValidatorKind `gorm:"not null" json:"kind"`
+	Description string              `json:"description"`
+	// Config holds the kind's settings as JSON; string values may refer to
+	// the secret with {{value}} or to fields of a JSON value with {{name}}
+	Config string `gorm:"type:text;not null" json:"-"`
+	// Timeout of one check in seconds
+	Timeout   int            `gorm:"not null;default:10" json:"timeout"`
+	CreatedBy uuid.UUID      `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	ConfigMap map[string]string `gorm:"-" json:"config"`
+}
+
+func (v *SecretValidator) BeforeCreate(tx *gorm.DB) error {
+	if v.ID == uuid.Nil {
+		v.ID = uuid.New()
+	}
+	return nil
+}
+
+type SecretValidatorKind string
+
+const (
+	// SecretValidatorPostgres logs in to PostgreSQL with the value
+	SecretValidatorPostgres SecretValidatorKind = "postgres"
+	// SecretValidatorHTTP calls an API with the value as credential
+	SecretValidatorHTTP SecretValidatorKind = "http"
+	// SecretValidatorPEM checks a PEM certificate chain
+	SecretValidatorPEM SecretValidatorKind = "pem"
+)
+
+type SecretValidationStatus string
+
+const (
+	SecretValidationPending    SecretValidationStatus = "pending"
+	SecretValidationPassed     SecretValidationStatus = "passed"
+	SecretValidationFailed     SecretValidationStatus = "failed"
+	SecretValidationSuperseded SecretValidationStatus = "superseded"
+)
+
+// SecretValidation is one write held until its validators finish. The
+// candidate value is sealed here; a held create keeps its secret inactive,
+// a held update leaves the current value in place until it passes.
+type SecretValidation struct {
+	ID       uuid.UUID              `gorm:"type:uuid;primary_key" json:"id"`
+	SecretID uuid.UUID              `gorm:"type:uuid;index;not null" json:"secret_id"`
+	Status   SecretValidationStatus `gorm:"index;not null" json:"status"`
+	// Operation is "create" or "update"
+	Operation   string     `gorm:"not null" json:"operation"`
+	Value       string     `gorm:"type:text;not null" json:"-"`
+	WrappedKey  string     `gorm:"type:text" json:"-"`
+	Results     string     `gorm:"type:text" json:"-"`
+	RequestedBy uuid.UUID  `gorm:"type:uuid;not null" json:"requested_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+
+	ResultList []SecretValidatorResult `gorm:"-" json:"results"`
+}
+
+func (v *SecretValidation) BeforeCreate(tx *gorm.DB) error {
+	if v.ID == uuid.Nil {
+		v.ID = uuid.New()
+	}
+	return nil
+}
+
+type SecretValidatorResult struct {
+	ValidatorID uuid.UUID           `json:"validator_id"`
+	Kind        SecretValidatorKind `json:"kind"`
+	PathPattern string              `json:"path_pattern"`
+	Passed      bool                `json:"passed"`
+	Message     string              `json:"message,omitempty"`
+	DurationMs  int64               `json:"duration_ms"`
+}
+
+type CreateSecretValidatorRequest struct {
+	PathPattern string              `json:"path_pattern" binding:"required"`
+	Kind        SecretValidatorKind `json:"kind" binding:"required"`
+	Description string              `json:"description"`
+	Config      map[string]string   `json:"config"`
+	Timeout     int                 `json:"timeout"`
+}
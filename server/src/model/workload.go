@@ -0,0 +1,56 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Workload is the build of a service that authenticated: the image it runs
+// and the commit and provenance it was built from, as reported by the
+// runtime. Audit entries of requests made with a token bound to it carry
+// its ID. Identical reports from the same user share one row.
+type Workload struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	UserID      uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	Service     string    `gorm:"not null;index" json:"service"`
+	Environment string    `json:"environment,omitempty"`
+	ImageDigest string    `gorm:"index" json:"image_digest,omitempty"`
+	GitSHA      string    `gorm:"index" json:"git_sha,omitempty"`
+	// ProvenanceRef locates the build's SLSA provenance, e.g. an
+	// attestation URI
+	ProvenanceRef string    `gorm:"type:text" json:"provenance_ref,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	LastSeenAt    time.Time `json:"last_seen_at"`
+}
+
+func (w *Workload) BeforeCreate(tx *gorm.DB) error {
+	if w.ID == uuid.Nil {
+		w.ID = uuid.New()
+	}
+	return nil
+}
+
+type AttachWorkloadRequest struct {
+	Service       string `json:"service" binding:"required"`
+	Environment   string `json:"environment"`
+	ImageDigest   string `json:"image_digest"`
+	GitSHA        string `json:"git_sha"`
+	ProvenanceRef string `json:"provenance_ref"`
+}
+
+// AttachWorkloadResponse carries a token bound to the workload, expiring
+// with the token that asked for it; use it in place of that token
+type AttachWorkloadResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Workload  Workload  `json:"workload"`
+}
+
+// WorkloadFilter selects workloads; empty fields match any
+type WorkloadFilter struct {
+	Service     string
+	ImageDigest string
+	GitSHA      string
+}
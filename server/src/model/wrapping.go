@@ -0,0 +1,58 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WrappedResponse holds a response sealed with a key only the wrapping
+// token carries, until the token is redeemed once or expires
+type WrappedResponse struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key" json:"accessor"`
+	CreatorID  uuid.UUID `gorm:"type:uuid;not null;index" json:"creator_id"`
+	CreatePath string    `gorm:"not null" json:"creation_path"`
+	Ciphertext string    `gorm:"type:text" json:"-"`
+	ExpiresAt  time.Time `gorm:"not null;index" json:"expires_at"`
+	// UnwrappedAt is set when the token was redeemed; the ciphertext is
+	// wiped at the same time
+	UnwrappedAt *time.Time `json:"unwrapped_at,omitempty"`
+	UnwrappedIP string     `json:"-"`
+	CreatedAt   time.Time  `json:"creation_time"`
+}
+
+func (w *WrappedResponse) BeforeCreate(tx *gorm.DB) error {
+	if w.ID == uuid.Nil {
+		w.ID = uuid.New()
+	}
+	return nil
+}
+
+// WrapInfo replaces a wrapped response. Token is shown once; Accessor
+// names the wrapping in audit entries without being able to redeem it.
+type WrapInfo struct {
+	Token        string    `json:"token"`
+	Accessor     uuid.UUID `json:"accessor"`
+	TTL          int       `json:"ttl"`
+	CreationTime time.Time `json:"creation_time"`
+	CreationPath string    `json:"creation_path"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+type WrapResponse struct {
+	WrapInfo WrapInfo `json:"wrap_info"`
+}
+
+type UnwrapRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// UnwrapResponse carries the original response body as it would have
+// been returned without wrapping
+type UnwrapResponse struct {
+	Accessor     uuid.UUID       `json:"accessor"`
+	CreationPath string          `json:"creation_path"`
+	Data         json.RawMessage `json:"data"`
+}
@@ -1,28 +1,93 @@
 package routes
 
 import (
+	"net/http"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-vault/server/src/config"
 	"github.com/skygenesisenterprise/aether-vault/server/src/controllers"
 	"github.com/skygenesisenterprise/aether-vault/server/src/middleware"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
 	"github.com/skygenesisenterprise/aether-vault/server/src/services"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
+// statusRequestsPerMinute is how often one client may fetch /status
+const statusRequestsPerMinute = 10
+
 type Router struct {
-	engine              *gin.Engine
-	authController      *controllers.AuthController
-	secretController    *controllers.SecretController
-	totpController      *controllers.TOTPController
-	identityController  *controllers.IdentityController
-	auditController     *controllers.AuditController
-	systemController    *controllers.SystemController
-	userController      *controllers.UserController
-	networkController   *controllers.NetworkController
-	authMiddleware      *middleware.AuthMiddleware
-	auditMiddleware     *middleware.AuditMiddleware
-	rateLimitMiddleware *middleware.RateLimitMiddleware
-	networkMiddleware   *middleware.NetworkMiddleware
+	engine                  *gin.Engine
+	clusterEngine           *gin.Engine
+	metricsEngine           *gin.Engine
+	authController          *controllers.AuthController
+	secretController        *controllers.SecretController
+	totpController          *controllers.TOTPController
+	identityController      *controllers.IdentityController
+	auditController         *controllers.AuditController
+	systemController        *controllers.SystemController
+	userController          *controllers.UserController
+	networkController       *controllers.NetworkController
+	shareController         *controllers.ShareController
+	templateController      *controllers.TemplateController
+	policyController        *controllers.PolicyController
+	namespaceController     *controllers.NamespaceController
+	tenantKeyController     *controllers.TenantKeyController
+	teamKeyController       *controllers.TeamKeyController
+	providerController      *controllers.ProviderController
+	reminderController      *controllers.ReminderController
+	notificationController  *controllers.NotificationController
+	chatController          *controllers.ChatController
+	escalationController    *controllers.EscalationController
+	jobController           *controllers.JobController
+	dataKeyController       *controllers.DataKeyController
+	migrationController     *controllers.MigrationController
+	featureController       *controllers.FeatureController
+	secretAccessController  *controllers.SecretAccessController
+	generateController      *controllers.GenerateController
+	oidcController          *controllers.OIDCController
+	diagnosticsController   *controllers.DiagnosticsController
+	statusController        *controllers.StatusController
+	escrowController        *controllers.EscrowController
+	mountController         *controllers.MountController
+	eventFeedController     *controllers.EventFeedController
+	quotaController         *controllers.QuotaController
+	sandboxController       *controllers.SandboxController
+	validatorController     *controllers.SecretValidatorController
+	maintenanceController   *controllers.MaintenanceController
+	impersonationController *controllers.ImpersonationController
+	leaseController         *controllers.LeaseController
+	workloadController      *controllers.WorkloadController
+	pkiController           *controllers.PKIController
+	entropyController       *controllers.EntropyController
+	sshController           *controllers.SSHController
+	wrappingController      *controllers.WrappingController
+	secretImportController  *controllers.SecretImportController
+	checkoutController      *controllers.SecretCheckoutController
+	orgController           *controllers.OrgController
+	oidcAuthController      *controllers.OIDCAuthController
+	appRoleController       *controllers.AppRoleController
+	kubernetesController    *controllers.KubernetesAuthController
+	awsAuthController       *controllers.AWSAuthController
+	tokenController         *controllers.TokenController
+	mfaController           *controllers.MFAController
+	sessionController       *controllers.SessionController
+	passwordController      *controllers.PasswordPolicyController
+	controlGroupController  *controllers.ControlGroupController
+	authMiddleware          *middleware.AuthMiddleware
+	userMiddleware          *middleware.UserMiddleware
+	namespaceMiddleware     *middleware.NamespaceMiddleware
+	auditMiddleware         *middleware.AuditMiddleware
+	rateLimitMiddleware     *middleware.RateLimitMiddleware
+	networkMiddleware       *middleware.NetworkMiddleware
+	featureMiddleware       *middleware.FeatureMiddleware
+	maintenanceMiddleware   *middleware.MaintenanceMiddleware
+	accessMiddleware        *middleware.AccessMiddleware
+	mountMiddleware         *middleware.MountMiddleware
+	quotaMiddleware         *middleware.QuotaMiddleware
+	wrapMiddleware          *middleware.WrapMiddleware
+	routes                  []RouteInfo
 }
 
 func NewRouter(
@@ -33,21 +98,127 @@ func NewRouter(
 	userService *services.UserService,
 	policyService *services.PolicyService,
 	auditService *services.AuditService,
+	auditAnchorService *services.AuditAnchorService,
 	networkService *services.NetworkService,
+	shareService *services.ShareService,
+	templateService *services.TemplateService,
+	namespaceService *services.NamespaceService,
+	tenantKeyService *services.TenantKeyService,
+	teamKeyService *services.TeamKeyService,
+	providerService *services.ProviderService,
+	reminderService *services.ReminderService,
+	emailNotifier *services.EmailNotifier,
+	chatService *services.ChatService,
+	escalationService *services.EscalationService,
+	jobService *services.JobService,
+	migrationService *services.MigrationService,
+	featureFlagService *services.FeatureFlagService,
+	secretAccessService *services.SecretAccessService,
+	generatorService *services.GeneratorService,
+	oidcService *services.OIDCService,
+	cacheWarmupService *services.CacheWarmupService,
+	escrowService *services.EscrowService,
+	mountService *services.MountService,
+	eventFeed *services.EventFeed,
+	quotaService *services.QuotaService,
+	sandboxService *services.SandboxService,
+	validatorService *services.SecretValidatorService,
+	maintenanceService *services.MaintenanceService,
+	impersonationService *services.ImpersonationService,
+	leaseService *services.LeaseService,
+	workloadService *services.WorkloadService,
+	pkiService *services.PKIService,
+	entropyService *services.EntropyService,
+	sshService *services.SSHService,
+	wrappingService *services.WrappingService,
+	secretImportService *services.SecretImportService,
+	secretCheckoutService *services.SecretCheckoutService,
+	orgService *services.OrgService,
+	oidcAuthService *services.OIDCAuthService,
+	appRoleService *services.AppRoleService,
+	kubernetesAuthService *services.KubernetesAuthService,
+	awsAuthService *services.AWSAuthService,
+	tokenService *services.TokenService,
+	mfaService *services.MFAService,
+	sessionService *services.SessionService,
+	passwordPolicyService *services.PasswordPolicyService,
+	controlGroupService *services.ControlGroupService,
+	cfg *config.Config,
 ) *Router {
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	publicURL := cfg.Server.PublicURL
+	limits := cfg.Limits
+
 	authController := controllers.NewAuthController(authService, auditService)
 	secretController := controllers.NewSecretController(secretService)
 	totpController := controllers.NewTOTPController(totpService)
 	identityController := controllers.NewIdentityController(userService, policyService)
-	auditController := controllers.NewAuditController(auditService)
+	auditController := controllers.NewAuditController(auditService, auditAnchorService)
 	systemController := controllers.NewSystemController(db)
-	userController := controllers.NewUserController(userService, auditService)
+	userController := controllers.NewUserController(userService, auditService, passwordPolicyService)
 	networkController := controllers.NewNetworkController(networkService)
+	shareController := controllers.NewShareController(shareService, publicURL)
+	templateController := controllers.NewTemplateController(templateService)
+	policyController := controllers.NewPolicyController(policyService)
+	namespaceController := controllers.NewNamespaceController(namespaceService)
+	tenantKeyController := controllers.NewTenantKeyController(tenantKeyService)
+	teamKeyController := controllers.NewTeamKeyController(teamKeyService)
+	providerController := controllers.NewProviderController(providerService)
+	reminderController := controllers.NewReminderController(reminderService)
+	notificationController := controllers.NewNotificationController(emailNotifier, userService)
+	chatController := controllers.NewChatController(chatService)
+	escalationController := controllers.NewEscalationController(escalationService)
+	jobController := controllers.NewJobController(jobService)
+	dataKeyController := controllers.NewDataKeyController(secretService, jobService)
+	migrationController := controllers.NewMigrationController(migrationService)
+	featureController := controllers.NewFeatureController(featureFlagService)
+	secretAccessController := controllers.NewSecretAccessController(secretAccessService)
+	generateController := controllers.NewGenerateController(generatorService)
+	oidcController := controllers.NewOIDCController(oidcService, publicURL)
+	escrowController := controllers.NewEscrowController(escrowService)
+	mountController := controllers.NewMountController(mountService)
+	eventFeedController := controllers.NewEventFeedController(eventFeed)
+	quotaController := controllers.NewQuotaController(quotaService)
+	sandboxController := controllers.NewSandboxController(sandboxService)
+	validatorController := controllers.NewSecretValidatorController(validatorService)
+	maintenanceController := controllers.NewMaintenanceController(maintenanceService)
+	impersonationController := controllers.NewImpersonationController(impersonationService)
+	leaseController := controllers.NewLeaseController(leaseService)
+	workloadController := controllers.NewWorkloadController(workloadService)
+	pkiController := controllers.NewPKIController(pkiService)
+	entropyController := controllers.NewEntropyController(entropyService)
+	sshController := controllers.NewSSHController(sshService)
+	wrappingController := controllers.NewWrappingController(wrappingService)
+	secretImportController := controllers.NewSecretImportController(secretImportService)
+	checkoutController := controllers.NewSecretCheckoutController(secretCheckoutService)
+	orgController := controllers.NewOrgController(orgService)
+	oidcAuthController := controllers.NewOIDCAuthController(oidcAuthService, auditService)
+	appRoleController := controllers.NewAppRoleController(appRoleService, auditService)
+	kubernetesController := controllers.NewKubernetesAuthController(kubernetesAuthService, auditService)
+	awsAuthController := controllers.NewAWSAuthController(awsAuthService, auditService)
+	tokenController := controllers.NewTokenController(tokenService)
+	mfaController := controllers.NewMFAController(mfaService)
+	sessionController := controllers.NewSessionController(sessionService)
+	passwordController := controllers.NewPasswordPolicyController(passwordPolicyService)
+	controlGroupController := controllers.NewControlGroupController(controlGroupService)
+	maintenanceMiddleware := middleware.NewMaintenanceMiddleware(maintenanceService)
+	inFlightMiddleware := middleware.NewInFlightMiddleware()
+	diagnosticsController := controllers.NewDiagnosticsController(inFlightMiddleware.Snapshot, cfg.Diagnostics.PprofEnabled)
 
-	authMiddleware := middleware.NewAuthMiddleware(authService)
+	authMiddleware := middleware.NewAuthMiddleware(authService, sessionService)
+	userMiddleware := middleware.NewUserMiddleware(userService)
+	namespaceMiddleware := middleware.NewNamespaceMiddleware(userMiddleware, namespaceService)
 	auditMiddleware := middleware.NewAuditMiddleware(auditService)
 	rateLimitMiddleware := middleware.NewRateLimitMiddleware(100, 60) // 100 requests per minute
 
+	concurrencyMiddleware := middleware.NewConcurrencyMiddleware(map[middleware.RequestClass]int{
+		middleware.RequestClassRead:  limits.MaxConcurrentReads,
+		middleware.RequestClassWrite: limits.MaxConcurrentWrites,
+		middleware.RequestClassAuth:  limits.MaxConcurrentAuth,
+	}, time.Duration(limits.QueueTimeoutMs)*time.Millisecond, time.Duration(limits.RetryAfter)*time.Second)
+
 	networkConfig := &middleware.NetworkConfig{
 		MaxRequestsPerMinute: 50,
 		MaxConcurrent:        5,
@@ -55,108 +226,699 @@ func NewRouter(
 	}
 	networkMiddleware := middleware.NewNetworkMiddleware(networkConfig)
 
+	statusController := controllers.NewStatusController(db, oidcService, publicURL, func() bool {
+		for _, stat := range concurrencyMiddleware.Stats() {
+			if stat.Waiting > 0 || (stat.Limit > 0 && stat.InFlight >= stat.Limit) {
+				return true
+			}
+		}
+		return false
+	})
+
+	systemController.AddMetrics(func(w *controllers.MetricsWriter) {
+		stats := concurrencyMiddleware.Stats()
+		// Samples of one metric must be contiguous
+		for _, stat := range stats {
+			w.Gauge("vault_requests_in_flight", "Requests currently being served.", stat.InFlight, "class", string(stat.Class))
+		}
+		for _, stat := range stats {
+			w.Gauge("vault_requests_concurrency_limit", "Maximum concurrent requests.", stat.Limit, "class", string(stat.Class))
+		}
+		for _, stat := range stats {
+			w.Gauge("vault_requests_queued", "Requests waiting for a slot.", stat.Waiting, "class", string(stat.Class))
+		}
+		for _, stat := range stats {
+			w.Gauge("vault_requests_shed", "Requests rejected with 503 under saturation since start.", stat.Shed, "class", string(stat.Class))
+		}
+	})
+	if featureFlagService != nil {
+		systemController.SetFeatures(featureFlagService.Snapshot)
+	}
+	if maintenanceService != nil {
+		systemController.SetMaintenance(maintenanceService.Status)
+		systemController.AddMetrics(func(w *controllers.MetricsWriter) {
+			readOnly := 0
+			if maintenanceService.ReadOnly() {
+				readOnly = 1
+			}
+			w.Gauge("vault_read_only", "Whether read-only maintenance mode is on.", readOnly)
+		})
+	}
+	if entropyService.Enabled() {
+		systemController.SetEntropy(entropyService.Status)
+		systemController.AddMetrics(func(w *controllers.MetricsWriter) {
+			sources := entropyService.Status().Sources
+			for _, source := range sources {
+				healthy := 0
+				if source.Healthy {
+					healthy = 1
+				}
+				w.Gauge("vault_entropy_source_healthy", "Whether the entropy source passed its last health test.", healthy, "source", source.Name)
+			}
+			for _, source := range sources {
+				w.Gauge("vault_entropy_source_failures", "Failed reads and health tests of the entropy source since start.", source.Failures, "source", source.Name)
+			}
+		})
+	}
+	if secretService != nil {
+		systemController.AddMetrics(func(w *controllers.MetricsWriter) {
+			stats := secretService.IntegrityStats()
+			w.Gauge("vault_secret_integrity_failures", "Secret rows that failed checksum verification since start.", stats.Failures)
+			w.Gauge("vault_secrets_quarantined", "Quarantined secret rows at the last scrub.", stats.Quarantined)
+			w.Gauge("vault_secret_scrub_checked", "Secret rows verified by the last scrub.", stats.Checked)
+			if !stats.LastScrubAt.IsZero() {
+				w.Gauge("vault_secret_scrub_last_run_timestamp_seconds", "When the last scrub finished.", stats.LastScrubAt.Unix())
+			}
+		})
+	}
+
+	if cacheWarmupService != nil {
+		systemController.AddMetrics(func(w *controllers.MetricsWriter) {
+			caches := cacheWarmupService.Status().Caches
+			for _, cache := range caches {
+				w.Gauge("vault_cache_entries", "Entries currently cached.", cache.Entries, "cache", cache.Name)
+			}
+			for _, cache := range caches {
+				w.Gauge("vault_cache_hits", "Cache lookups served from memory since start.", cache.Hits, "cache", cache.Name)
+			}
+			for _, cache := range caches {
+				w.Gauge("vault_cache_misses", "Cache lookups that read the database since start.", cache.Misses, "cache", cache.Name)
+			}
+			for _, cache := range caches {
+				w.Gauge("vault_cache_warmed_entries", "Entries pre-loaded at startup.", cache.Warmed, "cache", cache.Name)
+			}
+		})
+	}
+
 	engine := gin.New()
 	engine.Use(gin.Logger())
 	engine.Use(gin.Recovery())
 	engine.Use(middleware.CORSMiddleware())
 	engine.Use(middleware.SecurityHeadersMiddleware())
 	engine.Use(middleware.RequestIDMiddleware())
+	engine.Use(maintenanceMiddleware.Announce())
+	engine.Use(inFlightMiddleware.Track())
+	engine.Use(concurrencyMiddleware.Limit())
 	engine.Use(rateLimitMiddleware.Limit())
 	engine.Use(auditMiddleware.Audit())
 
+	// Cluster and metrics traffic gets its own engines so it can be bound
+	// to separate, firewalled listeners
+	clusterEngine := gin.New()
+	clusterEngine.Use(gin.Recovery())
+	clusterEngine.Use(middleware.RequestIDMiddleware())
+
+	metricsEngine := gin.New()
+	metricsEngine.Use(gin.Recovery())
+
 	return &Router{
-		engine:              engine,
-		authController:      authController,
-		secretController:    secretController,
-		totpController:      totpController,
-		identityController:  identityController,
-		auditController:     auditController,
-		systemController:    systemController,
-		userController:      userController,
-		networkController:   networkController,
-		authMiddleware:      authMiddleware,
-		auditMiddleware:     auditMiddleware,
-		rateLimitMiddleware: rateLimitMiddleware,
-		networkMiddleware:   networkMiddleware,
+		engine:                  engine,
+		clusterEngine:           clusterEngine,
+		metricsEngine:           metricsEngine,
+		authController:          authController,
+		secretController:        secretController,
+		totpController:          totpController,
+		identityController:      identityController,
+		auditController:         auditController,
+		systemController:        systemController,
+		userController:          userController,
+		networkController:       networkController,
+		shareController:         shareController,
+		templateController:      templateController,
+		policyController:        policyController,
+		namespaceController:     namespaceController,
+		tenantKeyController:     tenantKeyController,
+		teamKeyController:       teamKeyController,
+		providerController:      providerController,
+		reminderController:      reminderController,
+		notificationController:  notificationController,
+		chatController:          chatController,
+		escalationController:    escalationController,
+		jobController:           jobController,
+		dataKeyController:       dataKeyController,
+		migrationController:     migrationController,
+		featureController:       featureController,
+		secretAccessController:  secretAccessController,
+		generateController:      generateController,
+		oidcController:          oidcController,
+		diagnosticsController:   diagnosticsController,
+		statusController:        statusController,
+		escrowController:        escrowController,
+		mountController:         mountController,
+		eventFeedController:     eventFeedController,
+		quotaController:         quotaController,
+		sandboxController:       sandboxController,
+		validatorController:     validatorController,
+		maintenanceController:   maintenanceController,
+		impersonationController: impersonationController,
+		leaseController:         leaseController,
+		workloadController:      workloadController,
+		pkiController:           pkiController,
+		entropyController:       entropyController,
+		sshController:           sshController,
+		wrappingController:      wrappingController,
+		secretImportController:  secretImportController,
+		checkoutController:      checkoutController,
+		orgController:           orgController,
+		oidcAuthController:      oidcAuthController,
+		appRoleController:       appRoleController,
+		kubernetesController:    kubernetesController,
+		awsAuthController:       awsAuthController,
+		tokenController:         tokenController,
+		mfaController:           mfaController,
+		sessionController:       sessionController,
+		passwordController:      passwordController,
+		controlGroupController:  controlGroupController,
+		authMiddleware:          authMiddleware,
+		userMiddleware:          userMiddleware,
+		namespaceMiddleware:     namespaceMiddleware,
+		auditMiddleware:         auditMiddleware,
+		rateLimitMiddleware:     rateLimitMiddleware,
+		networkMiddleware:       networkMiddleware,
+		featureMiddleware:       middleware.NewFeatureMiddleware(featureFlagService),
+		maintenanceMiddleware:   maintenanceMiddleware,
+		accessMiddleware:        middleware.NewAccessMiddleware(authMiddleware, userMiddleware, namespaceMiddleware, policyService),
+		mountMiddleware:         middleware.NewMountMiddleware(mountService, secretService),
+		quotaMiddleware:         middleware.NewQuotaMiddleware(quotaService),
+		wrapMiddleware:          middleware.NewWrapMiddleware(wrappingService),
 	}
 }
 
 func (r *Router) SetupRoutes() {
-	v1 := r.engine.Group("/api/v1")
+	const (
+		public        = middleware.AccessPublic
+		authenticated = middleware.AccessAuthenticated
+		policy        = middleware.AccessPolicy
+		namespace     = middleware.AccessNamespace
+	)
 
-	auth := v1.Group("/auth")
-	{
-		auth.POST("/login", r.authController.Login)
-		auth.POST("/logout", r.authMiddleware.RequireAuth(), r.authController.Logout)
-		auth.GET("/session", r.authMiddleware.RequireAuth(), r.authController.GetSession)
-	}
+	r.register(r.engine.Group("/api/v1"), []RouteGroup{
+		{
+			Prefix: "/auth",
+			Routes: []Route{
+				{Method: http.MethodPost, Path: "/login", Access: public, ReadOnly: true, Handler: r.authController.Login},
+				{Method: http.MethodPost, Path: "/logout", Access: authenticated, ReadOnly: true, Handler: r.authController.Logout},
+				{Method: http.MethodGet, Path: "/session", Access: authenticated, Handler: r.authController.GetSession},
+				{Method: http.MethodGet, Path: "/token/capabilities-self", Access: authenticated, Handler: r.identityController.GetCapabilities},
+				{Method: http.MethodPost, Path: "/token/lookup", Access: authenticated, ReadOnly: true, Handler: r.tokenController.Lookup},
+				{Method: http.MethodPost, Path: "/token/renew", Access: authenticated, ReadOnly: true, Handler: r.tokenController.Renew},
+				{Method: http.MethodPost, Path: "/token/revoke", Access: authenticated, ReadOnly: true, Handler: r.tokenController.Revoke},
+				{Method: http.MethodPost, Path: "/token/revoke-accessor", Access: authenticated, ReadOnly: true, Handler: r.tokenController.RevokeAccessor},
+				{Method: http.MethodPost, Path: "/mfa/totp/enroll", Access: authenticated, Handler: r.mfaController.Enroll},
+				{Method: http.MethodPost, Path: "/mfa/totp/confirm", Access: authenticated, Handler: r.mfaController.Confirm},
+				{Method: http.MethodDelete, Path: "/mfa/totp", Access: authenticated, Handler: r.mfaController.Remove},
+				{Method: http.MethodPost, Path: "/mfa/verify", Access: authenticated, ReadOnly: true, Handler: r.mfaController.Verify},
+				{Method: http.MethodPost, Path: "/workload", Access: authenticated, ReadOnly: true, Handler: r.workloadController.AttachWorkload},
+				{Method: http.MethodDelete, Path: "/impersonation", Access: authenticated, Handler: r.impersonationController.EndCurrentImpersonation},
+				{Method: http.MethodPost, Path: "/ldap/login", Access: public, ReadOnly: true, Handler: r.authController.LoginLDAP},
+				{Method: http.MethodPost, Path: "/oidc/:name/auth_url", Access: public, ReadOnly: true, Handler: r.oidcAuthController.AuthURL},
+				{Method: http.MethodGet, Path: "/oidc/:name/callback", Access: public, ReadOnly: true, Handler: r.oidcAuthController.Callback},
+				{Method: http.MethodGet, Path: "/oidc/providers", Access: policy, Policy: "auth/oidc/providers", Handler: r.oidcAuthController.GetProviders},
+				{Method: http.MethodPost, Path: "/oidc/providers", Access: policy, Policy: "auth/oidc/providers", Handler: r.oidcAuthController.CreateProvider},
+				{Method: http.MethodPut, Path: "/oidc/providers/:name", Access: policy, Policy: "auth/oidc/providers", Handler: r.oidcAuthController.UpdateProvider},
+				{Method: http.MethodDelete, Path: "/oidc/providers/:name", Access: policy, Policy: "auth/oidc/providers", Handler: r.oidcAuthController.DeleteProvider},
+				{Method: http.MethodPost, Path: "/approle/login", Access: public, ReadOnly: true, Handler: r.appRoleController.Login},
+				{Method: http.MethodGet, Path: "/approle/roles", Access: policy, Policy: "auth/approle/roles", Handler: r.appRoleController.GetRoles},
+				{Method: http.MethodPost, Path: "/approle/roles", Access: policy, Policy: "auth/approle/roles", Handler: r.appRoleController.CreateRole},
+				{Method: http.MethodGet, Path: "/approle/roles/:name", Access: policy, Policy: "auth/approle/roles", Handler: r.appRoleController.GetRole},
+				{Method: http.MethodPut, Path: "/approle/roles/:name", Access: policy, Policy: "auth/approle/roles", Handler: r.appRoleController.UpdateRole},
+				{Method: http.MethodDelete, Path: "/approle/roles/:name", Access: policy, Policy: "auth/approle/roles", Handler: r.appRoleController.DeleteRole},
+				{Method: http.MethodPost, Path: "/approle/roles/:name/secret_id", Access: policy, Policy: "auth/approle/roles", Handler: r.appRoleController.GenerateSecretID},
+				{Method: http.MethodGet, Path: "/approle/roles/:name/secret_ids", Access: policy, Policy: "auth/approle/roles", Handler: r.appRoleController.GetSecretIDs},
+				{Method: http.MethodDelete, Path: "/approle/roles/:name/secret_ids/:accessor", Access: policy, Policy: "auth/approle/roles", Handler: r.appRoleController.RevokeSecretID},
+				{Method: http.MethodPost, Path: "/kubernetes/login", Access: public, ReadOnly: true, Handler: r.kubernetesController.Login},
+				{Method: http.MethodGet, Path: "/kubernetes/roles", Access: policy, Policy: "auth/kubernetes/roles", Handler: r.kubernetesController.GetRoles},
+				{Method: http.MethodPost, Path: "/kubernetes/roles", Access: policy, Policy: "auth/kubernetes/roles", Handler: r.kubernetesController.CreateRole},
+				{Method: http.MethodGet, Path: "/kubernetes/roles/:name", Access: policy, Policy: "auth/kubernetes/roles", Handler: r.kubernetesController.GetRole},
+				{Method: http.MethodPut, Path: "/kubernetes/roles/:name", Access: policy, Policy: "auth/kubernetes/roles", Handler: r.kubernetesController.UpdateRole},
+				{Method: http.MethodDelete, Path: "/kubernetes/roles/:name", Access: policy, Policy: "auth/kubernetes/roles", Handler: r.kubernetesController.DeleteRole},
+				{Method: http.MethodPost, Path: "/aws/login", Access: public, ReadOnly: true, Handler: r.awsAuthController.Login},
+				{Method: http.MethodGet, Path: "/aws/roles", Access: policy, Policy: "auth/aws/roles", Handler: r.awsAuthController.GetRoles},
+				{Method: http.MethodPost, Path: "/aws/roles", Access: policy, Policy: "auth/aws/roles", Handler: r.awsAuthController.CreateRole},
+				{Method: http.MethodGet, Path: "/aws/roles/:name", Access: policy, Policy: "auth/aws/roles", Handler: r.awsAuthController.GetRole},
+				{Method: http.MethodPut, Path: "/aws/roles/:name", Access: policy, Policy: "auth/aws/roles", Handler: r.awsAuthController.UpdateRole},
+				{Method: http.MethodDelete, Path: "/aws/roles/:name", Access: policy, Policy: "auth/aws/roles", Handler: r.awsAuthController.DeleteRole},
+			},
+		},
+		{
+			Prefix:     "/secrets",
+			Middleware: []gin.HandlerFunc{r.mountMiddleware.KV(model.DefaultKVMount)},
+			Routes: []Route{
+				{Method: http.MethodGet, Path: "", Access: authenticated, Handler: r.secretController.GetSecrets},
+				{Method: http.MethodPost, Path: "", Access: authenticated, Handler: r.secretController.CreateSecret},
+				{Method: http.MethodPost, Path: "/batch", Access: authenticated, Handler: r.secretController.BatchSecrets},
+				{Method: http.MethodPost, Path: "/import", Access: authenticated, Handler: r.secretImportController.ImportSecret},
+				{Method: http.MethodGet, Path: "/unused", Access: authenticated, Handler: r.secretAccessController.GetUnused},
+				{Method: http.MethodGet, Path: "/bundle", Access: authenticated, Wrappable: true, Handler: r.secretController.GetBundle},
+				{Method: http.MethodGet, Path: "/keys", Access: authenticated, Handler: r.secretController.GetKeys},
+				{Method: http.MethodGet, Path: "/expiring", Access: authenticated, Handler: r.secretController.GetExpiring},
+				{Method: http.MethodGet, Path: "/:id", Access: authenticated, Handler: r.secretController.GetSecret},
+				{Method: http.MethodGet, Path: "/:id/value", Access: authenticated, Wrappable: true, Handler: r.secretController.GetSecretValue},
+				{Method: http.MethodGet, Path: "/:id/connection-string", Access: authenticated, Wrappable: true, Handler: r.secretController.GetConnectionString},
+				{Method: http.MethodPut, Path: "/:id", Access: authenticated, Handler: r.secretController.UpdateSecret},
+				{Method: http.MethodDelete, Path: "/:id", Access: authenticated, Handler: r.secretController.DeleteSecret},
+				{Method: http.MethodGet, Path: "/:id/versions", Access: authenticated, Handler: r.secretController.GetVersions},
+				{Method: http.MethodGet, Path: "/:id/versions/:version", Access: authenticated, Wrappable: true, Handler: r.secretController.GetVersionValue},
+				{Method: http.MethodPost, Path: "/:id/versions/:version/rollback", Access: authenticated, Handler: r.secretController.RollbackSecret},
+				{Method: http.MethodGet, Path: "/:id/checkout", Access: authenticated, Handler: r.checkoutController.GetCheckout},
+				{Method: http.MethodPost, Path: "/:id/checkout", Access: authenticated, Wrappable: true, Handler: r.checkoutController.Checkout},
+				{Method: http.MethodPost, Path: "/:id/checkin", Access: authenticated, Handler: r.checkoutController.Checkin},
+				{Method: http.MethodPost, Path: "/:id/force-checkin", Access: policy, Policy: "secrets/checkouts", Action: "update", Handler: r.checkoutController.ForceCheckin},
+				{Method: http.MethodGet, Path: "/:id/validations", Access: authenticated, Handler: r.secretController.GetValidations},
+				{Method: http.MethodGet, Path: "/:id/publications", Access: authenticated, Handler: r.secretController.GetPublications},
+				{Method: http.MethodDelete, Path: "/:id/publications/:publication_id", Access: authenticated, Handler: r.secretController.CancelPublication},
+			},
+		},
+		{
+			// The same KV API in any KV mount enabled under /sys/mounts
+			Prefix:     "/mounts/:mount/secrets",
+			Middleware: []gin.HandlerFunc{r.mountMiddleware.KV("")},
+			Routes: []Route{
+				{Method: http.MethodGet, Path: "", Access: authenticated, Handler: r.secretController.GetSecrets},
+				{Method: http.MethodPost, Path: "", Access: authenticated, Handler: r.secretController.CreateSecret},
+				{Method: http.MethodPost, Path: "/batch", Access: authenticated, Handler: r.secretController.BatchSecrets},
+				{Method: http.MethodPost, Path: "/import", Access: authenticated, Handler: r.secretImportController.ImportSecret},
+				{Method: http.MethodGet, Path: "/bundle", Access: authenticated, Wrappable: true, Handler: r.secretController.GetBundle},
+				{Method: http.MethodGet, Path: "/keys", Access: authenticated, Handler: r.secretController.GetKeys},
+				{Method: http.MethodGet, Path: "/expiring", Access: authenticated, Handler: r.secretController.GetExpiring},
+				{Method: http.MethodGet, Path: "/:id", Access: authenticated, Handler: r.secretController.GetSecret},
+				{Method: http.MethodGet, Path: "/:id/value", Access: authenticated, Wrappable: true, Handler: r.secretController.GetSecretValue},
+				{Method: http.MethodGet, Path: "/:id/connection-string", Access: authenticated, Wrappable: true, Handler: r.secretController.GetConnectionString},
+				{Method: http.MethodPut, Path: "/:id", Access: authenticated, Handler: r.secretController.UpdateSecret},
+				{Method: http.MethodDelete, Path: "/:id", Access: authenticated, Handler: r.secretController.DeleteSecret},
+				{Method: http.MethodGet, Path: "/:id/versions", Access: authenticated, Handler: r.secretController.GetVersions},
+				{Method: http.MethodGet, Path: "/:id/versions/:version", Access: authenticated, Wrappable: true, Handler: r.secretController.GetVersionValue},
+				{Method: http.MethodPost, Path: "/:id/versions/:version/rollback", Access: authenticated, Handler: r.secretController.RollbackSecret},
+				{Method: http.MethodGet, Path: "/:id/checkout", Access: authenticated, Handler: r.checkoutController.GetCheckout},
+				{Method: http.MethodPost, Path: "/:id/checkout", Access: authenticated, Wrappable: true, Handler: r.checkoutController.Checkout},
+				{Method: http.MethodPost, Path: "/:id/checkin", Access: authenticated, Handler: r.checkoutController.Checkin},
+				{Method: http.MethodPost, Path: "/:id/force-checkin", Access: policy, Policy: "secrets/checkouts", Action: "update", Handler: r.checkoutController.ForceCheckin},
+				{Method: http.MethodGet, Path: "/:id/validations", Access: authenticated, Handler: r.secretController.GetValidations},
+				{Method: http.MethodGet, Path: "/:id/publications", Access: authenticated, Handler: r.secretController.GetPublications},
+				{Method: http.MethodDelete, Path: "/:id/publications/:publication_id", Access: authenticated, Handler: r.secretController.CancelPublication},
+			},
+		},
+		{
+			// Stable contract for the Terraform provider and Ansible lookup plugin
+			Prefix: "/provider",
+			Routes: []Route{
+				{Method: http.MethodGet, Path: "/contract", Access: authenticated, Handler: r.providerController.GetContract},
+				{Method: http.MethodPut, Path: "/secrets", Access: authenticated, Handler: r.providerController.UpsertSecret},
+				{Method: http.MethodGet, Path: "/secrets/*import_id", Access: authenticated, Handler: r.providerController.ReadSecret},
+				{Method: http.MethodDelete, Path: "/secrets/*import_id", Access: authenticated, Handler: r.providerController.DeleteSecret},
+			},
+		},
+		{
+			Prefix: "/totp",
+			Routes: []Route{
+				{Method: http.MethodGet, Path: "", Access: authenticated, Handler: r.totpController.GetTOTPs},
+				{Method: http.MethodPost, Path: "", Access: authenticated, Handler: r.totpController.CreateTOTP},
+				{Method: http.MethodPost, Path: "/:id/generate", Access: authenticated, ReadOnly: true, Handler: r.totpController.GenerateCode},
+				{Method: http.MethodPost, Path: "/:id/verify", Access: authenticated, ReadOnly: true, Handler: r.totpController.VerifyCode},
+				{Method: http.MethodPost, Path: "/export", Access: policy, Policy: "totp/export", Handler: r.totpController.ExportTOTPs},
+				{Method: http.MethodPost, Path: "/import", Access: policy, Policy: "totp/import", Handler: r.totpController.ImportTOTPs},
+			},
+		},
+		{
+			Prefix: "/identity",
+			Routes: []Route{
+				{Method: http.MethodGet, Path: "/me", Access: authenticated, Handler: r.identityController.GetMe},
+				{Method: http.MethodGet, Path: "/policies", Access: authenticated, Handler: r.identityController.GetPolicies},
+				{Method: http.MethodGet, Path: "/me/key", Access: authenticated, Handler: r.teamKeyController.GetMyKey},
+				{Method: http.MethodPut, Path: "/me/key", Access: authenticated, Handler: r.teamKeyController.RegisterKey},
+			},
+		},
+		{
+			Prefix: "/identity/oidc",
+			Routes: []Route{
+				{Method: http.MethodGet, Path: "/.well-known/openid-configuration", Access: public, SkipAudit: true, Handler: r.oidcController.Discovery},
+				{Method: http.MethodGet, Path: "/.well-known/keys", Access: public, SkipAudit: true, Handler: r.oidcController.JWKS},
+				{Method: http.MethodPost, Path: "/token/:name", Access: authenticated, ReadOnly: true, Handler: r.oidcController.IssueToken},
+				{Method: http.MethodGet, Path: "/roles", Access: policy, Policy: "identity/oidc/roles", Handler: r.oidcController.GetRoles},
+				{Method: http.MethodPost, Path: "/roles", Access: policy, Policy: "identity/oidc/roles", Handler: r.oidcController.CreateRole},
+				{Method: http.MethodPost, Path: "/keys/rotate", Access: policy, Policy: "identity/oidc/keys", Action: "update", Handler: r.oidcController.RotateKey},
+			},
+		},
+		{
+			Prefix: "/pki",
+			Routes: []Route{
+				{Method: http.MethodPost, Path: "/root", Access: policy, Policy: "pki/issuers", Handler: r.pkiController.CreateRoot},
+				{Method: http.MethodPost, Path: "/intermediate", Access: policy, Policy: "pki/issuers", Handler: r.pkiController.CreateIntermediate},
+				{Method: http.MethodGet, Path: "/issuers", Access: policy, Policy: "pki/issuers", Handler: r.pkiController.GetIssuers},
+				{Method: http.MethodGet, Path: "/issuers/:name/pem", Access: public, SkipAudit: true, Handler: r.pkiController.GetIssuerPEM},
+				{Method: http.MethodGet, Path: "/issuers/:name/crl", Access: public, SkipAudit: true, Handler: r.pkiController.GetCRL},
+				{Method: http.MethodGet, Path: "/roles", Access: policy, Policy: "pki/roles", Handler: r.pkiController.GetRoles},
+				{Method: http.MethodPost, Path: "/roles", Access: policy, Policy: "pki/roles", Handler: r.pkiController.CreateRole},
+				{Method: http.MethodDelete, Path: "/roles/:name", Access: policy, Policy: "pki/roles", Handler: r.pkiController.DeleteRole},
+				// Access is checked per role against pki/issue/<role>
+				{Method: http.MethodPost, Path: "/issue/:role", Access: authenticated, ReadOnly: true, Handler: r.pkiController.Issue},
+				{Method: http.MethodPost, Path: "/revoke", Access: policy, Policy: "pki/revoke", Action: "update", Handler: r.pkiController.Revoke},
+			},
+		},
+		{
+			Prefix: "/ssh",
+			Routes: []Route{
+				{Method: http.MethodPost, Path: "/ca", Access: policy, Policy: "ssh/ca", Handler: r.sshController.CreateCA},
+				{Method: http.MethodGet, Path: "/ca/public_key", Access: public, SkipAudit: true, Handler: r.sshController.GetCAPublicKey},
+				{Method: http.MethodGet, Path: "/roles", Access: policy, Policy: "ssh/roles", Handler: r.sshController.GetRoles},
+				{Method: http.MethodPost, Path: "/roles", Access: policy, Policy: "ssh/roles", Handler: r.sshController.CreateRole},
+				{Method: http.MethodDelete, Path: "/roles/:name", Access: policy, Policy: "ssh/roles", Handler: r.sshController.DeleteRole},
+				// Access is checked per role against ssh/sign/<role> and
+				// ssh/creds/<role>
+				{Method: http.MethodPost, Path: "/sign/:role", Access: authenticated, ReadOnly: true, Handler: r.sshController.Sign},
+				{Method: http.MethodPost, Path: "/creds/:role", Access: authenticated, ReadOnly: true, Handler: r.sshController.Credential},
+				// Called by the helper on the target host with the password
+				{Method: http.MethodPost, Path: "/verify", Access: public, ReadOnly: true, Handler: r.sshController.VerifyOTP},
+			},
+		},
+		{
+			Prefix: "/users",
+			Routes: []Route{
+				{Method: http.MethodGet, Path: "", Access: authenticated, Handler: r.userController.GetUsers},
+				{Method: http.MethodGet, Path: "/:id", Access: authenticated, Handler: r.userController.GetUser},
+				{Method: http.MethodGet, Path: "/:id/key", Access: authenticated, Handler: r.teamKeyController.GetUserKey},
+				{Method: http.MethodPost, Path: "", Access: authenticated, Handler: r.userController.CreateUser},
+				{Method: http.MethodPut, Path: "/:id", Access: authenticated, Handler: r.userController.UpdateUser},
+				{Method: http.MethodDelete, Path: "/:id", Access: authenticated, Handler: r.userController.DeleteUser},
+				{Method: http.MethodGet, Path: "/me/sessions", Access: authenticated, Handler: r.sessionController.GetMySessions},
+				{Method: http.MethodPost, Path: "/me/password", Access: authenticated, Handler: r.userController.ChangePassword},
+				{Method: http.MethodDelete, Path: "/:id/sessions", Access: policy, Policy: services.SessionPolicy, ReadOnly: true, Handler: r.sessionController.TerminateSessions},
+			},
+		},
+		{
+			Prefix: "/sessions",
+			Routes: []Route{
+				{Method: http.MethodDelete, Path: "/:id", Access: authenticated, ReadOnly: true, Handler: r.sessionController.RevokeSession},
+			},
+		},
+		{
+			Prefix: "/control-groups",
+			Routes: []Route{
+				{Method: http.MethodGet, Path: "", Access: authenticated, Handler: r.controlGroupController.GetMine},
+				{Method: http.MethodGet, Path: "/pending", Access: authenticated, Handler: r.controlGroupController.GetPending},
+				{Method: http.MethodGet, Path: "/:id", Access: authenticated, Handler: r.controlGroupController.Get},
+				{Method: http.MethodPost, Path: "/:id/approve", Access: authenticated, ReadOnly: true, Handler: r.controlGroupController.Approve},
+				{Method: http.MethodPost, Path: "/:id/deny", Access: authenticated, ReadOnly: true, Handler: r.controlGroupController.Deny},
+			},
+		},
+		{
+			Prefix: "/audit",
+			Routes: []Route{
+				{Method: http.MethodGet, Path: "/logs", Access: authenticated, Handler: r.auditController.GetAuditLogs},
+				{Method: http.MethodGet, Path: "/logs/search", Access: policy, Policy: "audit/logs", Handler: r.auditController.SearchByHash},
+				{Method: http.MethodPost, Path: "/hash", Access: policy, Policy: "audit/hash", ReadOnly: true, Handler: r.auditController.HashValue},
+				{Method: http.MethodGet, Path: "/anchors", Access: policy, Policy: "audit/anchors", Handler: r.auditController.GetAnchors},
+				{Method: http.MethodPost, Path: "/anchors", Access: policy, Policy: "audit/anchors", Handler: r.auditController.Anchor},
+				{Method: http.MethodPost, Path: "/verify", Access: policy, Policy: "audit/verify", ReadOnly: true, Handler: r.auditController.Verify},
+				{Method: http.MethodGet, Path: "/workloads", Access: policy, Policy: "audit/logs", Handler: r.workloadController.GetWorkloads},
+				{Method: http.MethodGet, Path: "/workloads/:id/logs", Access: policy, Policy: "audit/logs", Handler: r.workloadController.GetWorkloadLogs},
+			},
+		},
+		{
+			Prefix: "/network",
+			Middleware: []gin.HandlerFunc{
+				r.networkMiddleware.ValidateProtocol(),
+				r.networkMiddleware.NetworkRateLimit(),
+				r.networkMiddleware.ProtocolSecurity(),
+				r.networkMiddleware.NetworkLogging(),
+			},
+			Routes: []Route{
+				{Method: http.MethodGet, Path: "", Access: authenticated, Handler: r.networkController.GetNetworks},
+				{Method: http.MethodPost, Path: "", Access: authenticated, Handler: r.networkController.CreateNetwork},
+				{Method: http.MethodGet, Path: "/:id", Access: authenticated, Handler: r.networkController.GetNetwork},
+				{Method: http.MethodPut, Path: "/:id", Access: authenticated, Handler: r.networkController.UpdateNetwork},
+				{Method: http.MethodDelete, Path: "/:id", Access: authenticated, Handler: r.networkController.DeleteNetwork},
+				{Method: http.MethodGet, Path: "/protocols", Access: authenticated, Handler: r.networkController.GetSupportedProtocols},
+				{Method: http.MethodPost, Path: "/test", Access: authenticated, ReadOnly: true, Handler: r.networkController.TestProtocol},
+				{Method: http.MethodGet, Path: "/:id/status", Access: authenticated, Handler: r.networkController.GetProtocolStatus},
+			},
+		},
+		{
+			Prefix: "/share",
+			Routes: []Route{
+				{Method: http.MethodPost, Path: "", Access: authenticated, Handler: r.shareController.CreateShare},
+				{Method: http.MethodGet, Path: "/:id", Access: public, Handler: r.shareController.ViewShare},
+				{Method: http.MethodGet, Path: "/approvals", Access: policy, Policy: "shares/approvals", Action: "read", Handler: r.shareController.GetPendingApprovals},
+				{Method: http.MethodPost, Path: "/:id/approve", Access: policy, Policy: "shares/approvals", Action: "update", Handler: r.shareController.ApproveShare},
+				{Method: http.MethodPost, Path: "/:id/deny", Access: policy, Policy: "shares/approvals", Action: "update", Handler: r.shareController.DenyShare},
+			},
+		},
+		{
+			Prefix: "/leases",
+			Routes: []Route{
+				{Method: http.MethodGet, Path: "", Access: authenticated, Handler: r.leaseController.GetLeases},
+				{Method: http.MethodPut, Path: "/renew", Access: authenticated, Handler: r.leaseController.RenewLease},
+				{Method: http.MethodPut, Path: "/revoke", Access: authenticated, Handler: r.leaseController.RevokeLease},
+			},
+		},
+		{
+			Prefix: "/templates",
+			Routes: []Route{
+				{Method: http.MethodGet, Path: "", Access: authenticated, Handler: r.templateController.GetTemplates},
+				{Method: http.MethodGet, Path: "/match", Access: authenticated, Handler: r.templateController.MatchTemplate},
+				{Method: http.MethodPost, Path: "", Access: policy, Policy: "templates", Handler: r.templateController.CreateTemplate},
+				{Method: http.MethodDelete, Path: "/:id", Access: policy, Policy: "templates", Handler: r.templateController.DeleteTemplate},
+			},
+		},
+		{
+			Prefix: "/validators",
+			Routes: []Route{
+				{Method: http.MethodGet, Path: "", Access: authenticated, Handler: r.validatorController.GetValidators},
+				{Method: http.MethodPost, Path: "", Access: policy, Policy: "validators", Handler: r.validatorController.CreateValidator},
+				{Method: http.MethodDelete, Path: "/:id", Access: policy, Policy: "validators", Handler: r.validatorController.DeleteValidator},
+			},
+		},
+		{
+			Prefix: "/reminders",
+			Routes: []Route{
+				{Method: http.MethodGet, Path: "", Access: authenticated, Handler: r.reminderController.GetReminders},
+				{Method: http.MethodPost, Path: "/:id/acknowledge", Access: authenticated, Handler: r.reminderController.Acknowledge},
+				{Method: http.MethodPost, Path: "/:id/snooze", Access: authenticated, Handler: r.reminderController.Snooze},
+				{Method: http.MethodPost, Path: "/scan", Access: policy, Policy: "reminders/scan", Action: "update", Handler: r.reminderController.Scan},
+				{Method: http.MethodGet, Path: "/policies", Access: policy, Policy: "reminders/policies", Handler: r.reminderController.GetPolicies},
+				{Method: http.MethodPost, Path: "/policies", Access: policy, Policy: "reminders/policies", Handler: r.reminderController.CreatePolicy},
+				{Method: http.MethodDelete, Path: "/policies/:id", Access: policy, Policy: "reminders/policies", Handler: r.reminderController.DeletePolicy},
+			},
+		},
+		{
+			Prefix: "/notifications",
+			Routes: []Route{
+				{Method: http.MethodGet, Path: "/preferences", Access: authenticated, Handler: r.notificationController.GetPreferences},
+				{Method: http.MethodPut, Path: "/preferences", Access: authenticated, Handler: r.notificationController.UpdatePreferences},
+				{Method: http.MethodPost, Path: "/test", Access: policy, Policy: "notifications/test", Handler: r.notificationController.SendTest},
+			},
+		},
+		{
+			Prefix: "/integrations/chat",
+			Routes: []Route{
+				{Method: http.MethodGet, Path: "/channels", Access: policy, Policy: "integrations/chat", Handler: r.chatController.GetChannels},
+				{Method: http.MethodPost, Path: "/channels", Access: policy, Policy: "integrations/chat", Handler: r.chatController.CreateChannel},
+				{Method: http.MethodDelete, Path: "/channels/:id", Access: policy, Policy: "integrations/chat", Handler: r.chatController.DeleteChannel},
+				{Method: http.MethodPost, Path: "/channels/:id/routes", Access: policy, Policy: "integrations/chat", Action: "update", Handler: r.chatController.AddRoute},
+				{Method: http.MethodDelete, Path: "/channels/:id/routes/:route_id", Access: policy, Policy: "integrations/chat", Action: "update", Handler: r.chatController.DeleteRoute},
+				{Method: http.MethodPost, Path: "/channels/:id/test", Access: policy, Policy: "integrations/chat", Action: "update", Handler: r.chatController.TestChannel},
+				// Callback tokens are bearer credentials, so the request audit
+				// log is skipped; decisions are audited by the chat service
+				{Method: http.MethodGet, Path: "/callback", Access: public, SkipAudit: true, Handler: r.chatController.CallbackPage},
+				{Method: http.MethodPost, Path: "/callback", Access: public, SkipAudit: true, Handler: r.chatController.Callback},
+				{Method: http.MethodPost, Path: "/slack/interactions", Access: public, SkipAudit: true, Handler: r.chatController.SlackInteraction},
+			},
+		},
+		{
+			Prefix: "/integrations/escalation",
+			Routes: []Route{
+				{Method: http.MethodGet, Path: "/targets", Access: policy, Policy: "integrations/escalation", Handler: r.escalationController.GetTargets},
+				{Method: http.MethodPost, Path: "/targets", Access: policy, Policy: "integrations/escalation", Handler: r.escalationController.CreateTarget},
+				{Method: http.MethodDelete, Path: "/targets/:id", Access: policy, Policy: "integrations/escalation", Handler: r.escalationController.DeleteTarget},
+				{Method: http.MethodPost, Path: "/targets/:id/rules", Access: policy, Policy: "integrations/escalation", Action: "update", Handler: r.escalationController.AddRule},
+				{Method: http.MethodDelete, Path: "/targets/:id/rules/:rule_id", Access: policy, Policy: "integrations/escalation", Action: "update", Handler: r.escalationController.DeleteRule},
+				{Method: http.MethodPost, Path: "/targets/:id/test", Access: policy, Policy: "integrations/escalation", Action: "update", Handler: r.escalationController.TestTarget},
+				{Method: http.MethodGet, Path: "/incidents", Access: policy, Policy: "integrations/escalation", Handler: r.escalationController.GetIncidents},
+				{Method: http.MethodPost, Path: "/incidents/:id/resolve", Access: policy, Policy: "integrations/escalation", Action: "update", Handler: r.escalationController.ResolveIncident},
+			},
+		},
+		{
+			Prefix: "/policies",
+			Routes: []Route{
+				{Method: http.MethodGet, Path: "/templates", Access: policy, Policy: "policies/templates", Handler: r.policyController.GetPolicyTemplates},
+				{Method: http.MethodPost, Path: "/templates", Access: policy, Policy: "policies/templates", Handler: r.policyController.CreatePolicyTemplate},
+				{Method: http.MethodPost, Path: "/templates/:id/instantiate", Access: policy, Policy: "policies/templates", Handler: r.policyController.InstantiatePolicyTemplate},
+				{Method: http.MethodPost, Path: "/:id/attach", Access: policy, Policy: "policies/assignments", Action: "update", Handler: r.policyController.AttachPolicy},
+				{Method: http.MethodPost, Path: "/:id/detach", Access: policy, Policy: "policies/assignments", Action: "update", Handler: r.policyController.DetachPolicy},
+				{Method: http.MethodPost, Path: "/:id/canary", Access: policy, Policy: "policies/canary", Handler: r.policyController.StartPolicyCanary},
+				{Method: http.MethodGet, Path: "/:id/canary", Access: policy, Policy: "policies/canary", Handler: r.policyController.GetPolicyCanary},
+				{Method: http.MethodPost, Path: "/:id/canary/promote", Access: policy, Policy: "policies/canary", Action: "update", Handler: r.policyController.PromotePolicyCanary},
+				{Method: http.MethodDelete, Path: "/:id/canary", Access: policy, Policy: "policies/canary", Handler: r.policyController.CancelPolicyCanary},
+			},
+		},
+		{
+			Prefix:     "/namespaces",
+			Feature:    services.FeatureNamespaces,
+			Middleware: []gin.HandlerFunc{r.quotaMiddleware.Count()},
+			Routes: []Route{
+				{Method: http.MethodGet, Path: "", Access: policy, Policy: "namespaces", Handler: r.namespaceController.GetNamespaces},
+				{Method: http.MethodPost, Path: "", Access: policy, Policy: "namespaces", Handler: r.namespaceController.CreateNamespace},
+				{Method: http.MethodGet, Path: "/roles", Access: authenticated, Handler: r.namespaceController.GetRoles},
+				{Method: http.MethodGet, Path: "/:id/roles", Access: namespace, Permission: model.NamespacePermissionRolesManage, Handler: r.namespaceController.GetRoleBindings},
+				{Method: http.MethodPost, Path: "/:id/roles", Access: namespace, Permission: model.NamespacePermissionRolesManage, Handler: r.namespaceController.AssignRole},
+				{Method: http.MethodDelete, Path: "/:id/roles/:role/:user_id", Access: namespace, Permission: model.NamespacePermissionRolesManage, Handler: r.namespaceController.RevokeRole},
+				{Method: http.MethodGet, Path: "/:id/key", Access: namespace, Permission: model.NamespacePermissionKeysManage, Handler: r.tenantKeyController.GetKey},
+				{Method: http.MethodPost, Path: "/:id/key", Access: namespace, Permission: model.NamespacePermissionKeysManage, Handler: r.tenantKeyController.RegisterKey},
+				{Method: http.MethodPost, Path: "/:id/key/check", Access: namespace, Permission: model.NamespacePermissionKeysManage, ReadOnly: true, Handler: r.tenantKeyController.CheckKey},
+				{Method: http.MethodPost, Path: "/:id/key/rotate", Access: namespace, Permission: model.NamespacePermissionKeysManage, Handler: r.tenantKeyController.RotateKey},
+				{Method: http.MethodPost, Path: "/:id/key/rewrap", Access: namespace, Permission: model.NamespacePermissionKeysManage, Handler: r.tenantKeyController.ResumeRewrap},
+				{Method: http.MethodGet, Path: "/:id/member-keys", Access: namespace, Permission: model.NamespacePermissionSecretsRead, Handler: r.teamKeyController.GetMemberKeys},
+				{Method: http.MethodGet, Path: "/:id/member-keys/rewrap", Access: namespace, Permission: model.NamespacePermissionSecretsWrite, Handler: r.teamKeyController.GetRewrapPlan},
+				{Method: http.MethodGet, Path: "/:id/secrets/:secret_id/envelope", Access: namespace, Permission: model.NamespacePermissionSecretsRead, Handler: r.teamKeyController.GetEnvelope},
+				{Method: http.MethodPut, Path: "/:id/secrets/:secret_id/envelope", Access: namespace, Permission: model.NamespacePermissionSecretsWrite, Handler: r.teamKeyController.StoreEnvelope},
+				{Method: http.MethodGet, Path: "/:id/usage", Access: namespace, Permission: model.NamespacePermissionAuditRead, Handler: r.quotaController.GetUsage},
+				{Method: http.MethodGet, Path: "/:id/usage/history", Access: namespace, Permission: model.NamespacePermissionAuditRead, Handler: r.quotaController.GetHistory},
+				{Method: http.MethodGet, Path: "/:id/sandboxes", Access: namespace, Permission: model.NamespacePermissionRolesManage, Handler: r.sandboxController.GetSandboxes},
+				{Method: http.MethodPost, Path: "/:id/sandboxes", Access: namespace, Permission: model.NamespacePermissionRolesManage, Handler: r.sandboxController.CreateSandbox},
+				{Method: http.MethodDelete, Path: "/:id/sandbox", Access: namespace, Permission: model.NamespacePermissionRolesManage, Handler: r.sandboxController.DestroySandbox},
+			},
+		},
+		// Organization, team and project roles are checked by the service
+		{
+			Prefix: "/orgs",
+			Routes: []Route{
+				{Method: http.MethodGet, Path: "", Access: authenticated, Handler: r.orgController.GetOrganizations},
+				{Method: http.MethodPost, Path: "", Access: authenticated, Handler: r.orgController.CreateOrganization},
+				{Method: http.MethodGet, Path: "/memberships", Access: authenticated, Handler: r.orgController.GetMemberships},
+				{Method: http.MethodGet, Path: "/:id", Access: authenticated, Handler: r.orgController.GetOrganization},
+				{Method: http.MethodPut, Path: "/:id", Access: authenticated, Handler: r.orgController.UpdateOrganization},
+				{Method: http.MethodDelete, Path: "/:id", Access: authenticated, Handler: r.orgController.DeleteOrganization},
+				{Method: http.MethodGet, Path: "/:id/members", Access: authenticated, Handler: r.orgController.GetOrganizationMembers},
+				{Method: http.MethodPost, Path: "/:id/members", Access: authenticated, Handler: r.orgController.SetOrganizationMember},
+				{Method: http.MethodDelete, Path: "/:id/members/:user_id", Access: authenticated, Handler: r.orgController.RemoveOrganizationMember},
+				{Method: http.MethodGet, Path: "/:id/teams", Access: authenticated, Handler: r.orgController.GetTeams},
+				{Method: http.MethodPost, Path: "/:id/teams", Access: authenticated, Handler: r.orgController.CreateTeam},
+			},
+		},
+		{
+			Prefix: "/teams",
+			Routes: []Route{
+				{Method: http.MethodGet, Path: "/:id", Access: authenticated, Handler: r.orgController.GetTeam},
+				{Method: http.MethodPut, Path: "/:id", Access: authenticated, Handler: r.orgController.UpdateTeam},
+				{Method: http.MethodDelete, Path: "/:id", Access: authenticated, Handler: r.orgController.DeleteTeam},
+				{Method: http.MethodGet, Path: "/:id/members", Access: authenticated, Handler: r.orgController.GetTeamMembers},
+				{Method: http.MethodPost, Path: "/:id/members", Access: authenticated, Handler: r.orgController.SetTeamMember},
+				{Method: http.MethodDelete, Path: "/:id/members/:user_id", Access: authenticated, Handler: r.orgController.RemoveTeamMember},
+				{Method: http.MethodGet, Path: "/:id/projects", Access: authenticated, Handler: r.orgController.GetProjects},
+				{Method: http.MethodPost, Path: "/:id/projects", Access: authenticated, Handler: r.orgController.CreateProject},
+			},
+		},
+		{
+			Prefix: "/projects",
+			Routes: []Route{
+				{Method: http.MethodGet, Path: "/:id", Access: authenticated, Handler: r.orgController.GetProject},
+				{Method: http.MethodPut, Path: "/:id", Access: authenticated, Handler: r.orgController.UpdateProject},
+				{Method: http.MethodDelete, Path: "/:id", Access: authenticated, Handler: r.orgController.DeleteProject},
+				{Method: http.MethodGet, Path: "/:id/members", Access: authenticated, Handler: r.orgController.GetProjectMembers},
+				{Method: http.MethodPost, Path: "/:id/members", Access: authenticated, Handler: r.orgController.SetProjectMember},
+				{Method: http.MethodDelete, Path: "/:id/members/:user_id", Access: authenticated, Handler: r.orgController.RemoveProjectMember},
+			},
+		},
+		{
+			Prefix: "/sys",
+			Routes: []Route{
+				{Method: http.MethodPost, Path: "/generate", Access: authenticated, Handler: r.generateController.Generate},
+				{Method: http.MethodPost, Path: "/password-policy/test", Access: authenticated, ReadOnly: true, Handler: r.passwordController.Test},
+				{Method: http.MethodGet, Path: "/version", Access: authenticated, SkipAudit: true, Handler: r.systemController.BuildInfo},
+				{Method: http.MethodGet, Path: "/maintenance", Access: policy, Policy: "sys/maintenance", Handler: r.maintenanceController.GetMaintenance},
+				{Method: http.MethodPut, Path: "/maintenance", Access: policy, Policy: "sys/maintenance", ReadOnly: true, Handler: r.maintenanceController.SetMaintenance},
+				{Method: http.MethodGet, Path: "/routes", Access: policy, Policy: "sys/routes", Handler: r.listRoutes},
+				{Method: http.MethodGet, Path: "/impersonations", Access: policy, Policy: services.ImpersonationPolicy, Handler: r.impersonationController.GetImpersonations},
+				{Method: http.MethodPost, Path: "/impersonations", Access: policy, Policy: services.ImpersonationPolicy, Handler: r.impersonationController.StartImpersonation},
+				{Method: http.MethodDelete, Path: "/impersonations/:id", Access: policy, Policy: services.ImpersonationPolicy, Handler: r.impersonationController.EndImpersonation},
+				{Method: http.MethodGet, Path: "/jobs", Access: policy, Policy: "sys/jobs", Handler: r.jobController.GetJobs},
+				{Method: http.MethodGet, Path: "/jobs/:name/runs", Access: policy, Policy: "sys/jobs", Handler: r.jobController.GetRuns},
+				{Method: http.MethodPost, Path: "/jobs/:name/run", Access: policy, Policy: "sys/jobs", Handler: r.jobController.RunJob},
+				{Method: http.MethodGet, Path: "/keys", Access: policy, Policy: "sys/keys", Handler: r.dataKeyController.GetDataKeys},
+				{Method: http.MethodPost, Path: "/keys/rotate", Access: policy, Policy: "sys/keys", Action: "update", Handler: r.dataKeyController.RotateDataKey},
+				{Method: http.MethodGet, Path: "/migrations", Access: policy, Policy: "sys/migrations", Handler: r.migrationController.GetMigrations},
+				{Method: http.MethodGet, Path: "/migrations/:name", Access: policy, Policy: "sys/migrations", Handler: r.migrationController.GetMigration},
+				{Method: http.MethodPut, Path: "/migrations/:name/phase", Access: policy, Policy: "sys/migrations", Handler: r.migrationController.SetPhase},
+				{Method: http.MethodGet, Path: "/features", Access: policy, Policy: "sys/features", Handler: r.featureController.GetFlags},
+				{Method: http.MethodPut, Path: "/features/:name", Access: policy, Policy: "sys/features", Handler: r.featureController.SetFlag},
+				{Method: http.MethodDelete, Path: "/features/:name", Access: policy, Policy: "sys/features", Handler: r.featureController.ResetFlag},
+				{Method: http.MethodGet, Path: "/reports/unused-secrets", Access: policy, Policy: "sys/reports/unused-secrets", Handler: r.secretAccessController.GetUnusedReport},
+				{Method: http.MethodPost, Path: "/reports/unused-secrets/notify", Access: policy, Policy: "sys/reports/unused-secrets", Handler: r.secretAccessController.NotifyOwners},
+				{Method: http.MethodGet, Path: "/escrow", Access: policy, Policy: "sys/escrow", Handler: r.escrowController.GetEscrow},
+				{Method: http.MethodPost, Path: "/escrow", Access: policy, Policy: "sys/escrow", Handler: r.escrowController.CreateEscrow},
+				{Method: http.MethodGet, Path: "/escrow/share", Access: policy, Policy: "sys/escrow/share", Handler: r.escrowController.GetShare},
+				{Method: http.MethodGet, Path: "/escrow/recovery", Access: policy, Policy: "sys/escrow/recovery", Handler: r.escrowController.GetRecovery},
+				{Method: http.MethodPost, Path: "/escrow/recovery", Access: policy, Policy: "sys/escrow/recovery", Handler: r.escrowController.StartRecovery},
+				{Method: http.MethodDelete, Path: "/escrow/recovery", Access: policy, Policy: "sys/escrow/recovery", Handler: r.escrowController.CancelRecovery},
+				{Method: http.MethodPost, Path: "/escrow/recovery/shares", Access: policy, Policy: "sys/escrow/share", Handler: r.escrowController.SubmitShare},
+				{Method: http.MethodGet, Path: "/events", Access: policy, Policy: "sys/events", Handler: r.eventFeedController.Watch},
+				{Method: http.MethodGet, Path: "/mounts", Access: policy, Policy: "sys/mounts", Handler: r.mountController.GetMounts},
+				{Method: http.MethodPost, Path: "/mounts", Access: policy, Policy: "sys/mounts", Handler: r.mountController.EnableMount},
+				{Method: http.MethodGet, Path: "/mounts/:path", Access: policy, Policy: "sys/mounts", Handler: r.mountController.GetMount},
+				{Method: http.MethodPut, Path: "/mounts/:path", Access: policy, Policy: "sys/mounts", Handler: r.mountController.TuneMount},
+				{Method: http.MethodDelete, Path: "/mounts/:path", Access: policy, Policy: "sys/mounts", Handler: r.mountController.DisableMount},
+				{Method: http.MethodGet, Path: "/quotas", Access: policy, Policy: "sys/quotas", Handler: r.quotaController.GetDashboard},
+				{Method: http.MethodGet, Path: "/quotas/:id", Access: policy, Policy: "sys/quotas", Handler: r.quotaController.GetQuota},
+				{Method: http.MethodPut, Path: "/quotas/:id", Access: policy, Policy: "sys/quotas", Handler: r.quotaController.SetQuota},
+				{Method: http.MethodDelete, Path: "/quotas/:id", Access: policy, Policy: "sys/quotas", Handler: r.quotaController.DeleteQuota},
+				{Method: http.MethodGet, Path: "/entropy", Access: policy, Policy: "sys/entropy", Handler: r.entropyController.GetEntropy},
+				// The wrapping token is the credential
+				{Method: http.MethodPost, Path: "/unwrap", Access: public, ReadOnly: true, Handler: r.wrappingController.Unwrap},
+				{Method: http.MethodGet, Path: "/import-key", Access: authenticated, Handler: r.secretImportController.GetImportKey},
+				{Method: http.MethodPost, Path: "/import-key/rotate", Access: policy, Policy: "sys/import-key", Handler: r.secretImportController.RotateImportKey},
+				{Method: http.MethodGet, Path: "/in-flight", Access: policy, Policy: "sys/in-flight", Handler: r.diagnosticsController.InFlight},
+				{Method: http.MethodGet, Path: "/pprof", Access: policy, Policy: "sys/pprof", Handler: r.diagnosticsController.PprofIndex},
+				{Method: http.MethodGet, Path: "/pprof/:profile", Access: policy, Policy: "sys/pprof", Handler: r.diagnosticsController.Pprof},
+				{Method: http.MethodPost, Path: "/pprof/:profile", Access: policy, Policy: "sys/pprof", Action: "read", ReadOnly: true, Handler: r.diagnosticsController.Pprof},
+			},
+		},
+		{
+			Prefix: "/system",
+			Routes: []Route{
+				{Method: http.MethodGet, Path: "/health", Access: public, SkipAudit: true, Handler: r.systemController.Health},
+				{Method: http.MethodGet, Path: "/version", Access: public, SkipAudit: true, Handler: r.systemController.Version},
+			},
+		},
+	})
 
-	secrets := v1.Group("/secrets")
-	secrets.Use(r.authMiddleware.RequireAuth())
-	{
-		secrets.GET("", r.secretController.GetSecrets)
-		secrets.POST("", r.secretController.CreateSecret)
-		secrets.GET("/:id", r.secretController.GetSecret)
-		secrets.PUT("/:id", r.secretController.UpdateSecret)
-		secrets.DELETE("/:id", r.secretController.DeleteSecret)
-	}
+	// The public status page is polled by external monitors, so it gets a
+	// much tighter per-client limit than the API
+	r.register(r.engine, []RouteGroup{
+		{
+			Routes: []Route{
+				{Method: http.MethodGet, Path: "/status", Access: public, SkipAudit: true, Middleware: []gin.HandlerFunc{middleware.NewRateLimitMiddleware(statusRequestsPerMinute, time.Minute).Limit()}, Handler: r.statusController.Status},
+			},
+		},
+	})
 
-	totp := v1.Group("/totp")
-	totp.Use(r.authMiddleware.RequireAuth())
+	// The cluster and metrics engines serve only their own listeners, which
+	// operators firewall separately from the public API
+	cluster := r.clusterEngine.Group("/cluster/v1")
 	{
-		totp.GET("", r.totpController.GetTOTPs)
-		totp.POST("", r.totpController.CreateTOTP)
-		totp.POST("/:id/generate", r.totpController.GenerateCode)
+		cluster.GET("/health", r.systemController.Health)
+		cluster.GET("/version", r.systemController.Version)
 	}
 
-	identity := v1.Group("/identity")
-	identity.Use(r.authMiddleware.RequireAuth())
-	{
-		identity.GET("/me", r.identityController.GetMe)
-		identity.GET("/policies", r.identityController.GetPolicies)
-	}
-
-	users := v1.Group("/users")
-	users.Use(r.authMiddleware.RequireAuth())
-	{
-		users.GET("", r.userController.GetUsers)
-		users.GET("/:id", r.userController.GetUser)
-		users.POST("", r.userController.CreateUser)
-		users.PUT("/:id", r.userController.UpdateUser)
-		users.DELETE("/:id", r.userController.DeleteUser)
-	}
-
-	audit := v1.Group("/audit")
-	audit.Use(r.authMiddleware.RequireAuth())
-	{
-		audit.GET("/logs", r.auditController.GetAuditLogs)
-	}
-
-	network := v1.Group("/network")
-	network.Use(r.authMiddleware.RequireAuth())
-	network.Use(r.networkMiddleware.ValidateProtocol())
-	network.Use(r.networkMiddleware.NetworkRateLimit())
-	network.Use(r.networkMiddleware.ProtocolSecurity())
-	network.Use(r.networkMiddleware.NetworkLogging())
-	{
-		network.GET("", r.networkController.GetNetworks)
-		network.POST("", r.networkController.CreateNetwork)
-		network.GET("/:id", r.networkController.GetNetwork)
-		network.PUT("/:id", r.networkController.UpdateNetwork)
-		network.DELETE("/:id", r.networkController.DeleteNetwork)
-
-		network.GET("/protocols", r.networkController.GetSupportedProtocols)
-		network.POST("/test", r.networkController.TestProtocol)
-		network.GET("/:id/status", r.networkController.GetProtocolStatus)
-	}
+	r.metricsEngine.GET("/health", r.systemController.Health)
+	r.metricsEngine.GET("/version", r.systemController.Version)
+	r.metricsEngine.GET("/metrics", r.systemController.Metrics)
+}
 
-	system := v1.Group("/system")
-	{
-		system.GET("/health", r.systemController.Health)
-		system.GET("/version", r.systemController.Version)
-	}
+// listRoutes serves the route table with each route's access requirement
+func (r *Router) listRoutes(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"routes": r.routes})
 }
 
 func (r *Router) GetEngine() *gin.Engine {
 	return r.engine
 }
+
+// GetClusterEngine returns the handler for the cluster listener
+func (r *Router) GetClusterEngine() *gin.Engine {
+	return r.clusterEngine
+}
+
+// GetMetricsEngine returns the handler for the metrics listener
+func (r *Router) GetMetricsEngine() *gin.Engine {
+	return r.metricsEngine
+}
@@ -0,0 +1,150 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skygenesisenterprise/aether-vault/server/src/middleware"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+)
+
+// Route declares one endpoint together with the access it requires. Every
+// route must set Access; AccessPolicy routes must also name a Policy path
+// and AccessNamespace routes a Permission. Routes other than GET are
+// rejected in read-only maintenance mode unless they set ReadOnly because
+// they change nothing. Wrappable routes answer ?wrap_ttl= with a wrapping
+// token in place of their response.
+type Route struct {
+	Method     string
+	Path       string
+	Access     middleware.Access
+	Policy     string
+	Action     string
+	Permission model.NamespacePermission
+	SkipAudit  bool
+	ReadOnly   bool
+	Wrappable  bool
+	Middleware []gin.HandlerFunc
+	Handler    gin.HandlerFunc
+}
+
+// RouteGroup is a set of routes under a common prefix sharing middleware,
+// which runs after the access check. Routes of a group naming a Feature
+// answer 404 while that feature flag is off.
+type RouteGroup struct {
+	Prefix     string
+	Feature    string
+	Middleware []gin.HandlerFunc
+	Routes     []Route
+}
+
+// RouteInfo describes a registered route and its requirement
+type RouteInfo struct {
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	Access     string `json:"access"`
+	Policy     string `json:"policy,omitempty"`
+	Action     string `json:"action,omitempty"`
+	Permission string `json:"permission,omitempty"`
+	Feature    string `json:"feature,omitempty"`
+	Wrappable  bool   `json:"wrappable,omitempty"`
+}
+
+// policyAction maps an HTTP method to the policy action it needs
+func policyAction(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		return "read"
+	case http.MethodPost:
+		return "create"
+	case http.MethodPut, http.MethodPatch:
+		return "update"
+	case http.MethodDelete:
+		return "delete"
+	default:
+		return method
+	}
+}
+
+func (route Route) requirement() (middleware.AccessRequirement, error) {
+	requirement := middleware.AccessRequirement{
+		Access:     route.Access,
+		Policy:     route.Policy,
+		Action:     route.Action,
+		Permission: route.Permission,
+	}
+
+	switch route.Access {
+	case middleware.AccessPublic, middleware.AccessAuthenticated:
+	case middleware.AccessPolicy:
+		if route.Policy == "" {
+			return requirement, fmt.Errorf("policy route has no policy path")
+		}
+		if requirement.Action == "" {
+			requirement.Action = policyAction(route.Method)
+		}
+	case middleware.AccessNamespace:
+		if route.Permission == "" {
+			return requirement, fmt.Errorf("namespace route has no permission")
+		}
+	default:
+		return requirement, fmt.Errorf("no access requirement declared")
+	}
+
+	if route.Handler == nil {
+		return requirement, fmt.Errorf("no handler")
+	}
+
+	return requirement, nil
+}
+
+// register adds every route in groups to router, guarding each with its
+// declared requirement. It panics on an invalid declaration so an
+// unguarded endpoint can never be served.
+func (r *Router) register(router gin.IRouter, groups []RouteGroup) {
+	for _, group := range groups {
+		for _, route := range group.Routes {
+			requirement, err := route.requirement()
+			if err != nil {
+				panic(fmt.Sprintf("route %s %s%s: %v", route.Method, group.Prefix, route.Path, err))
+			}
+
+			var handlers []gin.HandlerFunc
+			if group.Feature != "" {
+				handlers = append(handlers, r.featureMiddleware.Require(group.Feature))
+			}
+			if route.SkipAudit {
+				handlers = append(handlers, r.auditMiddleware.Skip())
+			}
+			if !route.ReadOnly && policyAction(route.Method) != "read" {
+				handlers = append(handlers, r.maintenanceMiddleware.RejectWrites())
+			}
+			handlers = append(handlers, r.accessMiddleware.Enforce(requirement)...)
+			handlers = append(handlers, group.Middleware...)
+			handlers = append(handlers, route.Middleware...)
+			if route.Wrappable {
+				handlers = append(handlers, r.wrapMiddleware.Wrap())
+			}
+			handlers = append(handlers, route.Handler)
+
+			router.Handle(route.Method, group.Prefix+route.Path, handlers...)
+
+			r.routes = append(r.routes, RouteInfo{
+				Method:     route.Method,
+				Path:       group.Prefix + route.Path,
+				Access:     requirement.Access.String(),
+				Policy:     requirement.Policy,
+				Action:     requirement.Action,
+				Permission: string(requirement.Permission),
+				Feature:    group.Feature,
+				Wrappable:  route.Wrappable,
+			})
+		}
+	}
+}
+
+// Routes returns every route registered through the route table
+func (r *Router) Routes() []RouteInfo {
+	return r.routes
+}
@@ -0,0 +1,369 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-vault/server/src/config"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+)
+
+var s3PrefixPattern = regexp.MustCompile(`^[A-Za-z0-9._/-]*$`)
+
+// AnchorSink stores audit anchors somewhere the vault's own database
+// credentials cannot rewrite
+type AnchorSink interface {
+	// Put stores the record and returns where it was written
+	Put(record *model.AuditAnchorRecord) (string, error)
+	// List returns every anchor the sink holds
+	List() ([]model.AuditAnchorRecord, error)
+}
+
+// NewAnchorSink returns the sink for an anchor URI:
+//
+//	file:///var/lib/aether-vault/anchors.log  append-only JSON lines, e.g. on a WORM mount
+//	s3://bucket/prefix                         one Object Lock protected object per anchor
+func NewAnchorSink(cfg config.AuditAnchorConfig) (AnchorSink, error) {
+	parsed, err := url.Parse(cfg.Sink)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAnchorSinkInvalid, err)
+	}
+
+	switch parsed.Scheme {
+	case "file":
+		if parsed.Path == "" {
+			return nil, fmt.Errorf("%w: file sink needs a path", ErrAnchorSinkInvalid)
+		}
+		return &fileAnchorSink{path: parsed.Path}, nil
+	case "s3":
+		prefix := strings.Trim(parsed.Path, "/")
+		if parsed.Host == "" || !s3PrefixPattern.MatchString(prefix) {
+			return nil, fmt.Errorf("%w: expected s3://bucket/prefix", ErrAnchorSinkInvalid)
+		}
+		if prefix != "" {
+			prefix += "/"
+		}
+		return &s3AnchorSink{
+			bucket:        parsed.Host,
+			prefix:        prefix,
+			region:        cfg.Region,
+			endpoint:      strings.TrimSuffix(cfg.Endpoint, "/"),
+			lockMode:      cfg.LockMode,
+			retentionDays: cfg.RetentionDays,
+			client:        &http.Client{Timeout: 30 * time.Second},
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown scheme %q", ErrAnchorSinkInvalid, parsed.Scheme)
+	}
+}
+
+type fileAnchorSink struct {
+	path string
+}
+
+func (s *fileAnchorSink) Put(record *model.AuditAnchorRecord) (string, error) {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrAnchorSinkUnavailable, err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrAnchorSinkUnavailable, err)
+	}
+	if err := file.Sync(); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrAnchorSinkUnavailable, err)
+	}
+
+	return fmt.Sprintf("file://%s#%d", s.path, record.Sequence), nil
+}
+
+func (s *fileAnchorSink) List() ([]model.AuditAnchorRecord, error) {
+	file, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAnchorSinkUnavailable, err)
+	}
+	defer file.Close()
+
+	var records []model.AuditAnchorRecord
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var record model.AuditAnchorRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrAnchorSinkCorrupt, line, err)
+		}
+		record.Location = fmt.Sprintf("file://%s#%d", s.path, record.Sequence)
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAnchorSinkUnavailable, err)
+	}
+
+	return records, nil
+}
+
+// s3AnchorSink writes each anchor as its own object, named after its
+// sequence and hash, under Object Lock so it cannot be overwritten or
+// deleted before the retention date. Requests are signed with SigV4.
+type s3AnchorSink struct {
+	bucket        string
+	prefix        string
+	region        string
+	endpoint      string
+	lockMode      string
+	retentionDays int
+	client        *http.Client
+}
+
+func (s *s3AnchorSink) Put(record *model.AuditAnchorRecord) (string, error) {
+	body, err := json.Marshal(record)
+	if err != nil {
+		return "", err
+	}
+
+	key := fmt.Sprintf("%s%020d-%s.json", s.prefix, record.Sequence, record.Hash)
+	req, err := http.NewRequest(http.MethodPut, s.objectURL(key, nil), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	sum := md5.Sum(body)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+	req.Header.Set("If-None-Match", "*")
+	req.Header.Set("x-amz-object-lock-mode", s.lockMode)
+	req.Header.Set("x-amz-object-lock-retain-until-date", time.Now().UTC().AddDate(0, 0, s.retentionDays).Format(time.RFC3339))
+
+	if _, err := s.do(req, body); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("s3://%s/%s", s.bucket, key), nil
+}
+
+func (s *s3AnchorSink) List() ([]model.AuditAnchorRecord, error) {
+	var records []model.AuditAnchorRecord
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {s.prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		req, err := http.NewRequest(http.MethodGet, s.objectURL("", query), nil)
+		if err != nil {
+			return nil, err
+		}
+		body, err := s.do(req, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var page struct {
+			Contents []struct {
+				Key          string    `xml:"Key"`
+				LastModified time.Time `xml:"LastModified"`
+			} `xml:"Contents"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}
+		if err := xml.Unmarshal(body, &page); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrAnchorSinkCorrupt, err)
+		}
+
+		// The key carries the sequence and hash, so listing is enough
+		for _, object := range page.Contents {
+			name := strings.TrimSuffix(strings.TrimPrefix(object.Key, s.prefix), ".json")
+			sequence, hash, ok := strings.Cut(name, "-")
+			if !ok {
+				continue
+			}
+			number, err := strconv.ParseInt(sequence, 10, 64)
+			if err != nil {
+				continue
+			}
+			records = append(records, model.AuditAnchorRecord{
+				Sequence:   number,
+				Hash:       hash,
+				AnchoredAt: object.LastModified,
+				Location:   fmt.Sprintf("s3://%s/%s", s.bucket, object.Key),
+			})
+		}
+
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return records, nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+// objectURL addresses the bucket virtual-hosted style on AWS and
+// path-style on a custom endpoint
+func (s *s3AnchorSink) objectURL(key string, query url.Values) string {
+	target := &url.URL{Scheme: "https", Host: fmt.Sprintf("%s.s3.%s.amazonaws.com", s.bucket, s.region), Path: "/" + key}
+	if s.endpoint != "" {
+		if endpoint, err := url.Parse(s.endpoint); err == nil {
+			target.Scheme, target.Host = endpoint.Scheme, endpoint.Host
+			target.Path = "/" + s.bucket + "/" + key
+		}
+	}
+	target.RawQuery = awsQueryEscape(query)
+	return target.String()
+}
+
+func (s *s3AnchorSink) do(req *http.Request, body []byte) ([]byte, error) {
+	if err := s.sign(req, body); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAnchorSinkUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAnchorSinkUnavailable, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%w: s3 returned %s: %s", ErrAnchorSinkUnavailable, resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	return data, nil
+}
+
+// sign adds an AWS Signature Version 4 Authorization header
+func (s *s3AnchorSink) sign(req *http.Request, body []byte) error {
+	if err := signAWSRequest(req, body, s.region, "s3"); err != nil {
+		return fmt.Errorf("%w: %v", ErrAnchorSinkUnavailable, err)
+	}
+	return nil
+}
+
+// signAWSRequest adds an AWS Signature Version 4 Authorization header for
+// the given region and service, with the credentials in the standard
+// AWS_* environment variables
+func signAWSRequest(req *http.Request, body []byte, region, service string) error {
+	accessKey, secretKey := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKey == "" || secretKey == "" {
+		return errors.New("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set")
+	}
+
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+	if token := os.Getenv("AWS_SESSION_TOKEN"); token != "" {
+		req.Header.Set("x-amz-security-token", token)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		awsQueryEscape(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := []byte("AWS4" + secretKey)
+	for _, part := range []string{date, region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", accessKey, scope, signedHeaders, signature))
+	return nil
+}
+
+// awsQueryEscape encodes a query the way SigV4 canonicalizes it: sorted
+// keys and RFC 3986 percent-encoding
+func awsQueryEscape(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, awsEscape(key)+"="+awsEscape(value))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func awsEscape(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+var (
+	ErrAnchorSinkInvalid     = errors.New("invalid audit anchor sink")
+	ErrAnchorSinkUnavailable = errors.New("audit anchor sink unavailable")
+	ErrAnchorSinkCorrupt     = errors.New("audit anchor sink returned unreadable data")
+)
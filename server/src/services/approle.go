@@ -0,0 +1,389 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// appRoleSecretIDPrefix starts every secret ID, so that leaked ones are
+// easy to recognize in logs and scanners
+const appRoleSecretIDPrefix = "aevs."
+
+var machineRoleName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// AppRoleService signs machines in with a role ID and a secret ID instead
+// of a static token. The role ID names the role and is not secret; secret
+// IDs are handed out to the machines, stored hashed, and may expire or
+// allow a limited number of logins.
+type AppRoleService struct {
+	db           *gorm.DB
+	authService  *AuthService
+	auditService *AuditService
+}
+
+func NewAppRoleService(db *gorm.DB, authService *AuthService, auditService *AuditService) *AppRoleService {
+	return &AppRoleService{
+		db:           db,
+		authService:  authService,
+		auditService: auditService,
+	}
+}
+
+// CreateRole creates the role along with its service user, which holds
+// the role's policies
+func (s *AppRoleService) CreateRole(req *model.CreateAppRoleRequest, userID uuid.UUID) (*model.AppRole, error) {
+	if !machineRoleName.MatchString(req.Name) {
+		return nil, fmt.Errorf("%w: names use lowercase letters, digits, - and _", ErrAppRoleInvalid)
+	}
+	policies := uniqueUserIDs(req.Policies)
+	if err := s.checkPolicies(policies); err != nil {
+		return nil, err
+	}
+
+	role := &model.AppRole{
+		ID:              uuid.New(),
+		Name:            req.Name,
+		RoleID:          uuid.NewString(),
+		Policies:        policies,
+		SecretIDTTL:     req.SecretIDTTL,
+		SecretIDNumUses: req.SecretIDNumUses,
+		CreatedBy:       userID,
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.AppRole{}).Where("name = ?", req.Name).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to check role name: %w", err)
+		}
+		if count > 0 {
+			return ErrAppRoleExists
+		}
+
+		user, err := createServiceUser(tx, model.AuthMethodAppRole, role.ID, role.Name)
+		if err != nil {
+			return err
+		}
+		role.UserID = user.ID
+		if err := tx.Create(role).Error; err != nil {
+			return fmt.Errorf("failed to create role: %w", err)
+		}
+		return syncSourcePolicies(tx, user.ID, model.AuthMethodAppRole, policies)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "approle_created", "approle", role.ID.String(), true, "name="+role.Name)
+	}
+	return role, nil
+}
+
+func (s *AppRoleService) GetRoles() ([]model.AppRole, error) {
+	var roles []model.AppRole
+	if err := s.db.Order("name").Find(&roles).Error; err != nil {
+		return nil, fmt.Errorf("failed to get roles: %w", err)
+	}
+	return roles, nil
+}
+
+func (s *AppRoleService) GetRole(name string) (*model.AppRole, error) {
+	var role model.AppRole
+	if err := s.db.Where("name = ?", name).First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAppRoleNotFound
+		}
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+	return &role, nil
+}
+
+func (s *AppRoleService) UpdateRole(name string, req *model.UpdateAppRoleRequest, userID uuid.UUID) (*model.AppRole, error) {
+	role, err := s.GetRole(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Policies != nil {
+		role.Policies = uniqueUserIDs(*req.Policies)
+		if err := s.checkPolicies(role.Policies); err != nil {
+			return nil, err
+		}
+	}
+	if req.SecretIDTTL != nil {
+		role.SecretIDTTL = *req.SecretIDTTL
+	}
+	if req.SecretIDNumUses != nil {
+		role.SecretIDNumUses = *req.SecretIDNumUses
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(role).Error; err != nil {
+			return fmt.Errorf("failed to update role: %w", err)
+		}
+		return syncSourcePolicies(tx, role.UserID, model.AuthMethodAppRole, role.Policies)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "approle_updated", "approle", role.ID.String(), true, "name="+role.Name)
+	}
+	return role, nil
+}
+
+// DeleteRole removes the role, its secret IDs and its service user.
+// Tokens issued to the role lose its policies at once.
+func (s *AppRoleService) DeleteRole(name string, userID uuid.UUID) error {
+	role, err := s.GetRole(name)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("app_role_id = ?", role.ID).Delete(&model.AppRoleSecretID{}).Error; err != nil {
+			return fmt.Errorf("failed to remove secret IDs: %w", err)
+		}
+		if err := tx.Where("user_id = ? AND source = ?", role.UserID, model.AuthMethodAppRole).Delete(&model.PolicyAssignment{}).Error; err != nil {
+			return fmt.Errorf("failed to detach policies: %w", err)
+		}
+		if err := tx.Where("id = ?", role.UserID).Delete(&model.User{}).Error; err != nil {
+			return fmt.Errorf("failed to delete service user: %w", err)
+		}
+		if err := tx.Delete(role).Error; err != nil {
+			return fmt.Errorf("failed to delete role: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "approle_deleted", "approle", role.ID.String(), true, "name="+role.Name)
+	}
+	return nil
+}
+
+// GenerateSecretID issues a secret ID for the role. The secret ID is
+// returned once; only its accessor can be looked up later.
+func (s *AppRoleService) GenerateSecretID(name string, req *model.GenerateSecretIDRequest, userID uuid.UUID) (*model.AppRoleSecretIDResponse, error) {
+	role, err := s.GetRole(name)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := role.SecretIDTTL
+	if req.TTL != nil {
+		if ttl > 0 && *req.TTL > ttl {
+			return nil, fmt.Errorf("%w: ttl must not exceed the role's secret_id_ttl of %d seconds", ErrAppRoleInvalid, ttl)
+		}
+		ttl = *req.TTL
+	}
+	numUses := role.SecretIDNumUses
+	if req.NumUses != nil {
+		if numUses > 0 && *req.NumUses > numUses {
+			return nil, fmt.Errorf("%w: num_uses must not exceed the role's secret_id_num_uses of %d", ErrAppRoleInvalid, numUses)
+		}
+		numUses = *req.NumUses
+	}
+
+	random, err := oidcRandom()
+	if err != nil {
+		return nil, err
+	}
+	secretID := appRoleSecretIDPrefix + random
+	record := &model.AppRoleSecretID{
+		ID:           uuid.New(),
+		AppRoleID:    role.ID,
+		SecretIDHash: singleUseHash(secretID),
+		NumUses:      numUses,
+		CreatedBy:    userID,
+	}
+	if ttl > 0 {
+		expiresAt := time.Now().Add(time.Duration(ttl) * time.Second)
+		record.ExpiresAt = &expiresAt
+	}
+	if err := s.db.Create(record).Error; err != nil {
+		return nil, fmt.Errorf("failed to store secret ID: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "approle_secret_id_created", "approle", role.ID.String(), true, "name="+role.Name+"; accessor="+record.ID.String())
+	}
+	return &model.AppRoleSecretIDResponse{
+		SecretID:         secretID,
+		SecretIDAccessor: record.ID,
+		NumUses:          record.NumUses,
+		ExpiresAt:        record.ExpiresAt,
+	}, nil
+}
+
+// GetSecretIDs lists the role's usable secret IDs by accessor
+func (s *AppRoleService) GetSecretIDs(name string) ([]model.AppRoleSecretID, error) {
+	role, err := s.GetRole(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var secretIDs []model.AppRoleSecretID
+	if err := s.db.Where("app_role_id = ? AND (expires_at IS NULL OR expires_at > ?)", role.ID, time.Now()).Order("created_at").Find(&secretIDs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get secret IDs: %w", err)
+	}
+	return secretIDs, nil
+}
+
+func (s *AppRoleService) RevokeSecretID(name string, accessor, userID uuid.UUID) error {
+	role, err := s.GetRole(name)
+	if err != nil {
+		return err
+	}
+
+	result := s.db.Where("id = ? AND app_role_id = ?", accessor, role.ID).Delete(&model.AppRoleSecretID{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke secret ID: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrAppRoleSecretIDNotFound
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "approle_secret_id_revoked", "approle", role.ID.String(), true, "name="+role.Name+"; accessor="+accessor.String())
+	}
+	return nil
+}
+
+// Login exchanges a role ID and secret ID for a token of the role's
+// service user. Each login uses up one of the secret ID's uses; the last
+// use removes it. Every failure is ErrAppRoleLoginFailed, so that callers
+// cannot tell which half was wrong.
+func (s *AppRoleService) Login(roleID, secretID string) (*model.LoginResponse, *model.AppRole, error) {
+	var role model.AppRole
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role_id = ?", roleID).First(&role).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: unknown role ID", ErrAppRoleLoginFailed)
+			}
+			return fmt.Errorf("failed to get role: %w", err)
+		}
+
+		var record model.AppRoleSecretID
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("app_role_id = ? AND secret_id_hash = ?", role.ID, singleUseHash(secretID)).
+			First(&record).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: unknown secret ID", ErrAppRoleLoginFailed)
+			}
+			return fmt.Errorf("failed to get secret ID: %w", err)
+		}
+		if record.ExpiresAt != nil && time.Now().After(*record.ExpiresAt) {
+			return fmt.Errorf("%w: secret ID %s expired", ErrAppRoleLoginFailed, record.ID)
+		}
+
+		if record.NumUses > 0 && record.UseCount+1 >= record.NumUses {
+			if err := tx.Delete(&record).Error; err != nil {
+				return fmt.Errorf("failed to use secret ID: %w", err)
+			}
+			return nil
+		}
+		err = tx.Model(&record).Updates(map[string]interface{}{
+			"use_count":    gorm.Expr("use_count + 1"),
+			"last_used_at": time.Now(),
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to use secret ID: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, err := s.authService.userService.GetUserByID(role.UserID)
+	if err != nil {
+		return nil, &role, fmt.Errorf("%w: the role's service user is disabled", ErrAppRoleLoginFailed)
+	}
+	token, expiresAt, err := s.authService.generateToken(user.ID, model.AuthMethodAppRole)
+	if err != nil {
+		return nil, &role, fmt.Errorf("failed to generate token: %w", err)
+	}
+	return &model.LoginResponse{
+		Token:     token,
+		ExpiresAt: expiresAt,
+		User:      *user,
+	}, &role, nil
+}
+
+// Job removes expired secret IDs
+func (s *AppRoleService) Job(interval time.Duration) *Job {
+	return &Job{
+		Name:        "expired_approle_secret_ids",
+		Description: "Remove expired AppRole secret IDs",
+		Interval:    interval,
+		Run:         s.purge,
+	}
+}
+
+func (s *AppRoleService) purge(ctx context.Context) (int64, string, error) {
+	result := s.db.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&model.AppRoleSecretID{})
+	if result.Error != nil {
+		return 0, "", fmt.Errorf("failed to remove secret IDs: %w", result.Error)
+	}
+	return result.RowsAffected, fmt.Sprintf("removed %d expired secret IDs", result.RowsAffected), nil
+}
+
+func (s *AppRoleService) checkPolicies(policyIDs []uuid.UUID) error {
+	exist, err := policiesExist(s.db, policyIDs)
+	if err != nil {
+		return err
+	}
+	if !exist {
+		return fmt.Errorf("%w: policies names a policy that does not exist", ErrAppRoleInvalid)
+	}
+	return nil
+}
+
+// createServiceUser creates the user a machine role signs in as. It has
+// no usable password, and its email derives from the role ID so that a
+// deleted role's name can be reused.
+func createServiceUser(tx *gorm.DB, method string, roleID uuid.UUID, name string) (*model.User, error) {
+	password, err := oidcRandom()
+	if err != nil {
+		return nil, err
+	}
+	user := &model.User{Email: method + "-" + roleID.String() + "@" + method + ".invalid", Password: password, FirstName: method, LastName: name, IsActive: true}
+	if err := NewUserService(tx).CreateUser(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// policiesExist reports whether every policy in policyIDs exists and is
+// active
+func policiesExist(db *gorm.DB, policyIDs []uuid.UUID) (bool, error) {
+	if len(policyIDs) == 0 {
+		return true, nil
+	}
+	var found int64
+	if err := db.Model(&model.Policy{}).Where("id IN ? AND is_active = ?", policyIDs, true).Count(&found).Error; err != nil {
+		return false, fmt.Errorf("failed to verify policies: %w", err)
+	}
+	return found == int64(len(policyIDs)), nil
+}
+
+var (
+	ErrAppRoleNotFound         = errors.New("AppRole not found")
+	ErrAppRoleExists           = errors.New("an AppRole with this name already exists")
+	ErrAppRoleInvalid          = errors.New("invalid AppRole")
+	ErrAppRoleSecretIDNotFound = errors.New("secret ID not found")
+	ErrAppRoleLoginFailed      = errors.New("invalid role ID or secret ID")
+)
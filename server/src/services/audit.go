@@ -1,20 +1,96 @@
 package services
 
 import (
+	"github.com/skygenesisenterprise/aether-vault/server/src/buildinfo"
 	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"regexp"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type AuditService struct {
-	db *gorm.DB
+	db       *gorm.DB
+	hmacKey  []byte
+	feed     *EventFeed
+	enricher *AuditEnricher
 }
 
-func NewAuditService(db *gorm.DB) *AuditService {
-	return &AuditService{db: db}
+func NewAuditService(db *gorm.DB, hmacKey []byte) *AuditService {
+	return &AuditService{db: db, hmacKey: hmacKey}
+}
+
+// UseFeed announces stored entries of interest on the live event feed
+func (s *AuditService) UseFeed(feed *EventFeed) {
+	s.feed = feed
+}
+
+// UseEnricher runs the enrichment pipeline on every entry before it is
+// chained
+func (s *AuditService) UseEnricher(enricher *AuditEnricher) {
+	s.enricher = enricher
+}
+
+// HMAC returns a keyed hash of value so audit entries can be correlated
+// without storing sensitive data
+func (s *AuditService) HMAC(value []byte) string {
+	mac := hmac.New(sha256.New, s.hmacKey)
+	mac.Write(value)
+	return "hmac-sha256:" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// SecretIdentifiers returns audit details holding HMACs of a secret's
+// path and value, never the plaintext
+func (s *AuditService) SecretIdentifiers(name, value string) string {
+	identifiers := map[string]string{}
+	if name != "" {
+		identifiers["name_hmac"] = s.HMAC([]byte(name))
+	}
+	if value != "" {
+		identifiers["value_hmac"] = s.HMAC([]byte(value))
+	}
+
+	encoded, err := json.Marshal(identifiers)
+	if err != nil {
+		return ""
+	}
+	return string(encoded)
+}
+
+// GetAuditLogsByHash finds entries whose details contain an HMAC produced
+// by this service
+func (s *AuditService) GetAuditLogsByHash(hash string, limit, offset int) ([]model.AuditLog, error) {
+	var logs []model.AuditLog
+	if !auditHashPattern.MatchString(hash) {
+		return nil, ErrInvalidAuditHash
+	}
+
+	if err := s.db.Where("details LIKE ? OR params_hash = ?", "%"+hash+"%", hash).
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&logs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get audit logs: %w", err)
+	}
+
+	return logs, nil
+}
+
+// LogRequest stores a fully populated request audit entry
+func (s *AuditService) LogRequest(auditLog *model.AuditLog) error {
+	if auditLog.CreatedAt.IsZero() {
+		auditLog.CreatedAt = time.Now()
+	}
+
+	return s.append(auditLog)
 }
 
 func (s *AuditService) LogAction(userID uuid.UUID, action, resource, resourceID string, success bool, details string) error {
@@ -28,11 +104,7 @@ func (s *AuditService) LogAction(userID uuid.UUID, action, resource, resourceID
 		CreatedAt:  time.Now(),
 	}
 
-	if err := s.db.Create(auditLog).Error; err != nil {
-		return fmt.Errorf("failed to create audit log: %w", err)
-	}
-
-	return nil
+	return s.append(auditLog)
 }
 
 func (s *AuditService) LogAnonymousAction(action, resource, resourceID, ipAddress, userAgent string, success bool, details string) error {
@@ -48,13 +120,109 @@ func (s *AuditService) LogAnonymousAction(action, resource, resourceID, ipAddres
 		CreatedAt:  time.Now(),
 	}
 
-	if err := s.db.Create(auditLog).Error; err != nil {
+	return s.append(auditLog)
+}
+
+// append links the entry into the hash chain and stores it. The chain head
+// row is locked for the transaction so concurrent writers, including other
+// cluster nodes, get consecutive sequence numbers.
+func (s *AuditService) append(auditLog *model.AuditLog) error {
+	// The database keeps microseconds; hash what will be read back
+	auditLog.CreatedAt = auditLog.CreatedAt.UTC().Truncate(time.Microsecond)
+	// Enrichment may query the database; it runs before the chain head
+	// is locked
+	s.enricher.Enrich(auditLog)
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.AuditChainHead{ID: 1}).Error; err != nil {
+			return err
+		}
+
+		var head model.AuditChainHead
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&head, "id = ?", 1).Error; err != nil {
+			return err
+		}
+
+		if auditLog.ID == uuid.Nil {
+			auditLog.ID = uuid.New()
+		}
+		auditLog.Sequence = head.Sequence + 1
+		auditLog.PrevHash = head.Hash
+		if auditLog.Build == "" {
+			auditLog.Build = buildinfo.String()
+		}
+		auditLog.Hash = AuditEntryHash(auditLog)
+
+		if err := tx.Create(auditLog).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&head).Updates(map[string]interface{}{
+			"sequence": auditLog.Sequence,
+			"hash":     auditLog.Hash,
+		}).Error
+	})
+	if err != nil {
 		return fmt.Errorf("failed to create audit log: %w", err)
 	}
 
+	s.feed.RecordAudit(auditLog)
 	return nil
 }
 
+// AuditEntryHash is the chain hash of an entry: SHA-256 over its fields
+// and the previous entry's hash, in a fixed JSON encoding
+func AuditEntryHash(auditLog *model.AuditLog) string {
+	encoded, _ := json.Marshal(struct {
+		Sequence   int64      `json:"seq"`
+		PrevHash   string     `json:"prev"`
+		ID         uuid.UUID  `json:"id"`
+		UserID     *uuid.UUID `json:"user_id"`
+		Action     string     `json:"action"`
+		Resource   string     `json:"resource"`
+		ResourceID *string    `json:"resource_id"`
+		IPAddress  string     `json:"ip"`
+		UserAgent  string     `json:"ua"`
+		Success    bool       `json:"success"`
+		Details    string     `json:"details"`
+		RequestID  string     `json:"request_id"`
+		Method     string     `json:"method"`
+		Route      string     `json:"route"`
+		StatusCode int        `json:"status"`
+		LatencyMs  int64      `json:"latency_ms"`
+		ParamsHash string     `json:"params_hash"`
+		Build      string     `json:"build,omitempty"`
+		Enrichment string     `json:"enrichment,omitempty"`
+		WorkloadID *uuid.UUID `json:"workload_id,omitempty"`
+		CreatedAt  string     `json:"created_at"`
+	}{
+		Sequence:   auditLog.Sequence,
+		PrevHash:   auditLog.PrevHash,
+		ID:         auditLog.ID,
+		UserID:     auditLog.UserID,
+		Action:     auditLog.Action,
+		Resource:   auditLog.Resource,
+		ResourceID: auditLog.ResourceID,
+		IPAddress:  auditLog.IPAddress,
+		UserAgent:  auditLog.UserAgent,
+		Success:    auditLog.Success,
+		Details:    auditLog.Details,
+		RequestID:  auditLog.RequestID,
+		Method:     auditLog.Method,
+		Route:      auditLog.Route,
+		StatusCode: auditLog.StatusCode,
+		LatencyMs:  auditLog.LatencyMs,
+		ParamsHash: auditLog.ParamsHash,
+		Build:      auditLog.Build,
+		Enrichment: auditLog.Enrichment,
+		WorkloadID: auditLog.WorkloadID,
+		CreatedAt:  auditLog.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
+}
+
 func (s *AuditService) GetAuditLogs(userID *uuid.UUID, limit, offset int) ([]model.AuditLog, error) {
 	var logs []model.AuditLog
 	query := s.db.Order("created_at DESC")
@@ -92,3 +260,9 @@ func (s *AuditService) CleanupOldLogs(retentionDays int) error {
 
 	return nil
 }
+
+var auditHashPattern = regexp.MustCompile(`^hmac-sha256:[0-9a-f]{64}$`)
+
+var (
+	ErrInvalidAuditHash = errors.New("invalid audit hash")
+)
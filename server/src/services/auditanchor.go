@@ -0,0 +1,232 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-vault/server/src/config"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+)
+
+const (
+	auditVerifyBatch     = 1000
+	auditVerifyMaxErrors = 50
+)
+
+// AuditAnchorService anchors the head of the audit hash chain to an
+// external write-once sink and verifies the stored chain against those
+// anchors. Rewriting history then requires rewriting the sink as well.
+type AuditAnchorService struct {
+	db   *gorm.DB
+	sink AnchorSink
+}
+
+// NewAuditAnchorService builds the service; without a configured sink it
+// still verifies the chain itself
+func NewAuditAnchorService(db *gorm.DB, cfg config.AuditAnchorConfig) (*AuditAnchorService, error) {
+	service := &AuditAnchorService{db: db}
+	if cfg.Sink != "" {
+		sink, err := NewAnchorSink(cfg)
+		if err != nil {
+			return nil, err
+		}
+		service.sink = sink
+	}
+	return service, nil
+}
+
+func (s *AuditAnchorService) Enabled() bool {
+	return s.sink != nil
+}
+
+// Anchor writes the current chain head to the sink. When the head has not
+// moved since the last anchor, that anchor is returned instead.
+func (s *AuditAnchorService) Anchor() (*model.AuditAnchor, error) {
+	if s.sink == nil {
+		return nil, ErrAuditAnchorDisabled
+	}
+
+	var head model.AuditChainHead
+	if err := s.db.First(&head, "id = ?", 1).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAuditChainEmpty
+		}
+		return nil, fmt.Errorf("failed to read audit chain head: %w", err)
+	}
+
+	var latest model.AuditAnchor
+	err := s.db.Order("sequence DESC").First(&latest).Error
+	if err == nil && latest.Sequence == head.Sequence {
+		return &latest, nil
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get latest audit anchor: %w", err)
+	}
+
+	record := &model.AuditAnchorRecord{
+		Sequence:   head.Sequence,
+		Hash:       head.Hash,
+		AnchoredAt: time.Now().UTC(),
+	}
+	location, err := s.sink.Put(record)
+	if err != nil {
+		return nil, err
+	}
+
+	anchor := &model.AuditAnchor{
+		Sequence: record.Sequence,
+		Hash:     record.Hash,
+		Location: location,
+	}
+	if err := s.db.Create(anchor).Error; err != nil {
+		return nil, fmt.Errorf("failed to record audit anchor: %w", err)
+	}
+
+	return anchor, nil
+}
+
+func (s *AuditAnchorService) GetAnchors(limit, offset int) ([]model.AuditAnchor, error) {
+	var anchors []model.AuditAnchor
+	if err := s.db.Order("sequence DESC").Limit(limit).Offset(offset).Find(&anchors).Error; err != nil {
+		return nil, fmt.Errorf("failed to get audit anchors: %w", err)
+	}
+	return anchors, nil
+}
+
+// Start anchors the chain head every interval
+func (s *AuditAnchorService) Start(interval time.Duration) {
+	if s.sink == nil {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			anchor, err := s.Anchor()
+			if errors.Is(err, ErrAuditChainEmpty) {
+				continue
+			}
+			if err != nil {
+				log.Printf("⚠️  Failed to anchor audit chain: %v", err)
+				continue
+			}
+			log.Printf("⚓ Audit chain anchored at entry %d (%s)", anchor.Sequence, anchor.Location)
+		}
+	}()
+}
+
+// Verify recomputes every stored chain entry, checks that each links to
+// its predecessor and compares the chain with the anchors read back from
+// the sink. Anchors are taken from the sink, not the database, so deleting
+// anchor rows hides nothing.
+func (s *AuditAnchorService) Verify() (*model.AuditVerifyReport, error) {
+	report := &model.AuditVerifyReport{Valid: true, Anchors: []model.AuditAnchorCheck{}}
+	var firstError int64 = -1
+	fail := func(sequence int64, format string, args ...interface{}) {
+		report.Valid = false
+		if firstError < 0 || sequence < firstError {
+			firstError = sequence
+		}
+		if len(report.Errors) < auditVerifyMaxErrors {
+			report.Errors = append(report.Errors, fmt.Sprintf(format, args...))
+		}
+	}
+
+	anchors := map[int64][]model.AuditAnchorRecord{}
+	if s.sink != nil {
+		records, err := s.sink.List()
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			anchors[record.Sequence] = append(anchors[record.Sequence], record)
+		}
+	}
+
+	var previous *model.AuditLog
+	for {
+		var batch []model.AuditLog
+		query := s.db.Where("sequence > ?", 0).Order("sequence").Limit(auditVerifyBatch)
+		if previous != nil {
+			query = query.Where("sequence > ?", previous.Sequence)
+		}
+		if err := query.Find(&batch).Error; err != nil {
+			return nil, fmt.Errorf("failed to read audit chain: %w", err)
+		}
+
+		for i := range batch {
+			entry := &batch[i]
+			report.EntriesChecked++
+			if previous == nil {
+				report.FirstSequence = entry.Sequence
+			} else {
+				if entry.Sequence != previous.Sequence+1 {
+					fail(previous.Sequence+1, "entries %d to %d are missing", previous.Sequence+1, entry.Sequence-1)
+				}
+				if entry.PrevHash != previous.Hash {
+					fail(entry.Sequence, "entry %d does not link to entry %d", entry.Sequence, previous.Sequence)
+				}
+			}
+			if AuditEntryHash(entry) != entry.Hash {
+				fail(entry.Sequence, "entry %d (%s) does not match its hash", entry.Sequence, entry.ID)
+			}
+
+			for _, record := range anchors[entry.Sequence] {
+				check := model.AuditAnchorCheck{Sequence: record.Sequence, Location: record.Location, Status: "ok"}
+				if record.Hash != entry.Hash {
+					check.Status = "mismatch"
+					fail(entry.Sequence, "entry %d differs from its anchor at %s", entry.Sequence, record.Location)
+				}
+				report.Anchors = append(report.Anchors, check)
+			}
+			delete(anchors, entry.Sequence)
+
+			previous = entry
+		}
+
+		if len(batch) < auditVerifyBatch {
+			break
+		}
+	}
+	if previous != nil {
+		report.LastSequence = previous.Sequence
+	}
+
+	var head model.AuditChainHead
+	if err := s.db.First(&head, "id = ?", 1).Error; err == nil && head.Sequence > report.LastSequence {
+		fail(report.LastSequence+1, "chain head is at entry %d but entries after %d are missing", head.Sequence, report.LastSequence)
+	}
+
+	// Anchors left over point at entries that are no longer stored:
+	// before the first entry they were pruned by retention, after the
+	// last one the chain was truncated
+	for sequence, records := range anchors {
+		for _, record := range records {
+			check := model.AuditAnchorCheck{Sequence: sequence, Location: record.Location, Status: "pruned"}
+			if sequence > report.LastSequence {
+				check.Status = "missing"
+				fail(sequence, "anchored entry %d is missing; the chain was truncated", sequence)
+			}
+			report.Anchors = append(report.Anchors, check)
+		}
+	}
+	sort.Slice(report.Anchors, func(i, j int) bool { return report.Anchors[i].Sequence < report.Anchors[j].Sequence })
+
+	for _, check := range report.Anchors {
+		if check.Status == "ok" && (firstError < 0 || check.Sequence < firstError) {
+			report.VerifiedThrough = check.Sequence
+		}
+	}
+
+	return report, nil
+}
+
+var (
+	ErrAuditAnchorDisabled = errors.New("audit anchoring is not configured (audit.anchor.sink)")
+	ErrAuditChainEmpty     = errors.New("audit chain has no entries yet")
+)
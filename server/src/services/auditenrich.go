@@ -0,0 +1,465 @@
+package services
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/netip"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/config"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+)
+
+const (
+	auditUserNameTTL         = 5 * time.Minute
+	threatIntelCheckInterval = time.Minute
+)
+
+// AuditProcessor adds context to an audit entry. It writes its findings
+// into enrichment, which also holds what earlier processors found, and
+// must not fail the entry: a lookup that cannot be made is skipped.
+type AuditProcessor interface {
+	Name() string
+	Process(entry *model.AuditLog, enrichment map[string]interface{})
+}
+
+// AuditProcessorFactory builds a processor from the enrichment settings
+type AuditProcessorFactory func(db *gorm.DB, cfg config.AuditEnrichmentConfig) (AuditProcessor, error)
+
+var auditProcessors = map[string]AuditProcessorFactory{
+	"geoip":        newGeoIPProcessor,
+	"container":    newContainerProcessor,
+	"user":         newUserNameProcessor,
+	"threat_intel": newThreatIntelProcessor,
+}
+
+// RegisterAuditProcessor makes a processor available to the
+// audit.enrichment.processors setting
+func RegisterAuditProcessor(name string, factory AuditProcessorFactory) {
+	auditProcessors[name] = factory
+}
+
+// AuditEnricher runs the configured processors in order on each entry
+type AuditEnricher struct {
+	processors []AuditProcessor
+}
+
+// NewAuditEnricher returns nil when no processor is configured. A nil
+// AuditEnricher leaves entries untouched.
+func NewAuditEnricher(db *gorm.DB, cfg config.AuditEnrichmentConfig) (*AuditEnricher, error) {
+	enricher := &AuditEnricher{}
+	for _, name := range strings.Split(cfg.Processors, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		factory, ok := auditProcessors[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown audit enrichment processor %q", name)
+		}
+		processor, err := factory(db, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure audit enrichment processor %s: %w", name, err)
+		}
+		enricher.processors = append(enricher.processors, processor)
+	}
+
+	if len(enricher.processors) == 0 {
+		return nil, nil
+	}
+	return enricher, nil
+}
+
+// Processors lists the processor names in pipeline order
+func (e *AuditEnricher) Processors() []string {
+	if e == nil {
+		return nil
+	}
+	names := make([]string, 0, len(e.processors))
+	for _, processor := range e.processors {
+		names = append(names, processor.Name())
+	}
+	return names
+}
+
+// Enrich fills entry.Enrichment unless the entry already carries one
+func (e *AuditEnricher) Enrich(entry *model.AuditLog) {
+	if e == nil || entry.Enrichment != "" {
+		return
+	}
+
+	enrichment := map[string]interface{}{}
+	for _, processor := range e.processors {
+		processor.Process(entry, enrichment)
+	}
+	if len(enrichment) == 0 {
+		return
+	}
+
+	encoded, err := json.Marshal(enrichment)
+	if err != nil {
+		log.Printf("⚠️  Failed to encode audit enrichment: %v", err)
+		return
+	}
+	entry.Enrichment = string(encoded)
+}
+
+// clientAddr returns the entry's client address when it is a public one
+func clientAddr(entry *model.AuditLog) (netip.Addr, bool) {
+	addr, err := netip.ParseAddr(entry.IPAddress)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsUnspecified() {
+		return netip.Addr{}, false
+	}
+	return addr, true
+}
+
+// parseNetwork reads a CIDR, a first-last range or a single address
+func parseNetwork(value string) (netip.Addr, netip.Addr, error) {
+	if first, last, ok := strings.Cut(value, "-"); ok {
+		start, err := netip.ParseAddr(strings.TrimSpace(first))
+		if err != nil {
+			return netip.Addr{}, netip.Addr{}, err
+		}
+		end, err := netip.ParseAddr(strings.TrimSpace(last))
+		if err != nil {
+			return netip.Addr{}, netip.Addr{}, err
+		}
+		if end.Less(start) || start.Is4() != end.Is4() {
+			return netip.Addr{}, netip.Addr{}, fmt.Errorf("invalid range %q", value)
+		}
+		return start, end, nil
+	}
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return netip.Addr{}, netip.Addr{}, err
+		}
+		return prefix.Masked().Addr(), lastAddr(prefix.Masked()), nil
+	}
+	addr, err := netip.ParseAddr(value)
+	return addr, addr, err
+}
+
+func lastAddr(prefix netip.Prefix) netip.Addr {
+	bytes := prefix.Addr().AsSlice()
+	for bit := prefix.Bits(); bit < len(bytes)*8; bit++ {
+		bytes[bit/8] |= 1 << (7 - bit%8)
+	}
+	addr, _ := netip.AddrFromSlice(bytes)
+	return addr
+}
+
+// geoIPProcessor looks client addresses up in a range table loaded from
+// CSV, such as the free country and city exports of IP geolocation
+// vendors
+type geoIPProcessor struct {
+	ranges []geoRange
+}
+
+type geoRange struct {
+	start, end netip.Addr
+	location   map[string]string
+}
+
+func newGeoIPProcessor(_ *gorm.DB, cfg config.AuditEnrichmentConfig) (AuditProcessor, error) {
+	file, err := os.Open(cfg.GeoIPDatabase)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	processor := &geoIPProcessor{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 2 {
+			return nil, fmt.Errorf("line %d: expected network,country[,region[,city]]", line)
+		}
+		start, end, err := parseNetwork(strings.TrimSpace(record[0]))
+		if err != nil {
+			// Tolerate a header row
+			if line == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		location := map[string]string{}
+		for i, key := range []string{"country", "region", "city"} {
+			if i+1 < len(record) && strings.TrimSpace(record[i+1]) != "" {
+				location[key] = strings.TrimSpace(record[i+1])
+			}
+		}
+		processor.ranges = append(processor.ranges, geoRange{start: start, end: end, location: location})
+	}
+
+	sort.Slice(processor.ranges, func(i, j int) bool { return processor.ranges[i].start.Less(processor.ranges[j].start) })
+	return processor, nil
+}
+
+func (p *geoIPProcessor) Name() string { return "geoip" }
+
+func (p *geoIPProcessor) Process(entry *model.AuditLog, enrichment map[string]interface{}) {
+	addr, ok := clientAddr(entry)
+	if !ok {
+		return
+	}
+
+	// The last range starting at or before addr is the only candidate
+	i := sort.Search(len(p.ranges), func(i int) bool { return addr.Less(p.ranges[i].start) }) - 1
+	if i < 0 || p.ranges[i].end.Less(addr) {
+		return
+	}
+	enrichment["geo"] = p.ranges[i].location
+}
+
+// containerProcessor records where this server runs. The values are read
+// once; they identify the node that wrote the entry in a cluster.
+type containerProcessor struct {
+	container map[string]string
+}
+
+var containerIDPattern = regexp.MustCompile(`[0-9a-f]{64}`)
+
+func newContainerProcessor(_ *gorm.DB, _ config.AuditEnrichmentConfig) (AuditProcessor, error) {
+	container := map[string]string{}
+	if hostname, err := os.Hostname(); err == nil {
+		container["hostname"] = hostname
+	}
+	for key, variables := range map[string][]string{
+		"pod":       {"KUBERNETES_POD_NAME", "POD_NAME"},
+		"namespace": {"KUBERNETES_NAMESPACE", "POD_NAMESPACE"},
+		"node":      {"KUBERNETES_NODE_NAME", "NODE_NAME"},
+		"image":     {"CONTAINER_IMAGE"},
+	} {
+		for _, variable := range variables {
+			if value := os.Getenv(variable); value != "" {
+				container[key] = value
+				break
+			}
+		}
+	}
+	// cgroup paths of Docker, containerd and CRI-O end in the container ID
+	for _, path := range []string{"/proc/self/cgroup", "/proc/self/mountinfo"} {
+		if data, err := os.ReadFile(path); err == nil {
+			if id := containerIDPattern.Find(data); id != nil {
+				container["id"] = string(id)
+				break
+			}
+		}
+	}
+
+	return &containerProcessor{container: container}, nil
+}
+
+func (p *containerProcessor) Name() string { return "container" }
+
+func (p *containerProcessor) Process(_ *model.AuditLog, enrichment map[string]interface{}) {
+	if len(p.container) > 0 {
+		enrichment["container"] = p.container
+	}
+}
+
+// userNameProcessor adds the display name of the acting user, so exported
+// entries read without a join against the users table
+type userNameProcessor struct {
+	db    *gorm.DB
+	names *ttlCache
+}
+
+func newUserNameProcessor(db *gorm.DB, _ config.AuditEnrichmentConfig) (AuditProcessor, error) {
+	if db == nil {
+		return nil, errors.New("requires a database")
+	}
+	names := newTTLCache(auditUserNameTTL)
+	names.clearOnWrite(db, "audit_user_names", "users")
+	return &userNameProcessor{db: db, names: names}, nil
+}
+
+func (p *userNameProcessor) Name() string { return "user" }
+
+func (p *userNameProcessor) Process(entry *model.AuditLog, enrichment map[string]interface{}) {
+	if entry.UserID == nil || *entry.UserID == uuid.Nil {
+		return
+	}
+
+	key := entry.UserID.String()
+	if cached, ok := p.names.get(key); ok {
+		if name := cached.(string); name != "" {
+			enrichment["user"] = map[string]string{"display_name": name}
+		}
+		return
+	}
+
+	var user model.User
+	if err := p.db.Unscoped().Select("first_name", "last_name", "email").First(&user, "id = ?", *entry.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			p.names.put(key, "", time.Time{})
+		}
+		return
+	}
+	name := strings.TrimSpace(user.FirstName + " " + user.LastName)
+	if name == "" {
+		name = user.Email
+	}
+	p.names.put(key, name, time.Time{})
+	if name != "" {
+		enrichment["user"] = map[string]string{"display_name": name}
+	}
+}
+
+// threatIntelProcessor tags client addresses found in a threat-intel
+// feed. The feed file is reread when its modification time changes.
+type threatIntelProcessor struct {
+	path string
+
+	mu        sync.RWMutex
+	entries   []threatEntry
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+type threatEntry struct {
+	start, end netip.Addr
+	tags       []string
+}
+
+func newThreatIntelProcessor(_ *gorm.DB, cfg config.AuditEnrichmentConfig) (AuditProcessor, error) {
+	processor := &threatIntelProcessor{path: cfg.ThreatIntelFile}
+	if err := processor.reload(); err != nil {
+		return nil, err
+	}
+	return processor, nil
+}
+
+func (p *threatIntelProcessor) Name() string { return "threat_intel" }
+
+func (p *threatIntelProcessor) Process(entry *model.AuditLog, enrichment map[string]interface{}) {
+	addr, ok := clientAddr(entry)
+	if !ok {
+		return
+	}
+	p.refresh()
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	seen := map[string]bool{}
+	var tags []string
+	for _, threat := range p.entries {
+		if addr.Less(threat.start) || threat.end.Less(addr) {
+			continue
+		}
+		for _, tag := range threat.tags {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	if len(tags) > 0 {
+		sort.Strings(tags)
+		enrichment["threat_tags"] = tags
+	}
+}
+
+// refresh rereads the feed at most every threatIntelCheckInterval, and
+// only when the file changed; a broken update keeps the previous feed
+func (p *threatIntelProcessor) refresh() {
+	p.mu.RLock()
+	due := time.Since(p.checkedAt) >= threatIntelCheckInterval
+	p.mu.RUnlock()
+	if !due {
+		return
+	}
+	if err := p.reload(); err != nil {
+		log.Printf("⚠️  Failed to reload threat intel feed %s: %v", p.path, err)
+	}
+}
+
+func (p *threatIntelProcessor) reload() error {
+	defer p.markChecked()
+
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return err
+	}
+	p.mu.RLock()
+	unchanged := info.ModTime().Equal(p.modTime)
+	p.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	entries, err := readThreatFeed(p.path)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.entries = entries
+	p.modTime = info.ModTime()
+	return nil
+}
+
+func (p *threatIntelProcessor) markChecked() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.checkedAt = time.Now()
+}
+
+func readThreatFeed(path string) ([]threatEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []threatEntry
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: expected network tag[,tag...]", line)
+		}
+		start, end, err := parseNetwork(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		var tags []string
+		for _, tag := range strings.Split(strings.Join(fields[1:], ","), ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+		entries = append(entries, threatEntry{start: start, end: end, tags: tags})
+	}
+	return entries, scanner.Err()
+}
@@ -0,0 +1,301 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-vault/server/src/buildinfo"
+	"github.com/skygenesisenterprise/aether-vault/server/src/config"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	auditOTLPCursor = "otlp"
+	auditOTLPScope  = "aether-vault/audit"
+
+	// OpenTelemetry severity numbers
+	otlpSeverityInfo = 9
+	otlpSeverityWarn = 13
+)
+
+// AuditExportService ships audit entries to an OpenTelemetry collector as
+// OTLP/HTTP JSON log records. Entries go out in chain order; the cursor
+// only advances once the collector accepted a batch, so delivery is at
+// least once and each record carries its sequence and hash for dedup.
+type AuditExportService struct {
+	db       *gorm.DB
+	config   config.AuditOTLPConfig
+	headers  map[string]string
+	resource otlpResource
+	client   *http.Client
+}
+
+func NewAuditExportService(db *gorm.DB, cfg config.AuditOTLPConfig) *AuditExportService {
+	headers := map[string]string{}
+	for _, pair := range strings.Split(cfg.Headers, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		if ok && strings.TrimSpace(key) != "" {
+			headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+
+	node := cfg.Node
+	if node == "" {
+		node, _ = os.Hostname()
+	}
+	attributes := []otlpAttribute{
+		stringAttribute("service.name", "aether-vault"),
+		stringAttribute("service.version", buildinfo.Version),
+		stringAttribute("vcs.ref.head.revision", buildinfo.Get().GitCommit),
+	}
+	if cfg.Namespace != "" {
+		attributes = append(attributes, stringAttribute("service.namespace", cfg.Namespace))
+	}
+	if cfg.Cluster != "" {
+		attributes = append(attributes, stringAttribute("vault.cluster", cfg.Cluster))
+	}
+	if node != "" {
+		attributes = append(attributes, stringAttribute("service.instance.id", node))
+	}
+
+	return &AuditExportService{
+		db:       db,
+		config:   cfg,
+		headers:  headers,
+		resource: otlpResource{Attributes: attributes},
+		client:   &http.Client{Timeout: time.Duration(cfg.Timeout) * time.Second},
+	}
+}
+
+// Job exports new entries every interval on the job leader
+func (s *AuditExportService) Job() *Job {
+	return &Job{
+		Name:        "audit_otlp_export",
+		Description: "Ship new audit entries to the OTLP collector",
+		Interval:    time.Duration(s.config.Interval) * time.Second,
+		Disabled:    s.config.Endpoint == "",
+		Run:         s.export,
+	}
+}
+
+// export sends batches until it catches up with the chain head. The first
+// run starts at the current head rather than replaying the whole history.
+func (s *AuditExportService) export(ctx context.Context) (int64, string, error) {
+	cursor, err := s.cursor()
+	if err != nil {
+		return 0, "", err
+	}
+
+	var exported int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return exported, "", err
+		}
+
+		var entries []model.AuditLog
+		if err := s.db.Where("sequence > ?", cursor).Order("sequence").Limit(s.config.BatchSize).Find(&entries).Error; err != nil {
+			return exported, "", fmt.Errorf("failed to read audit logs: %w", err)
+		}
+		if len(entries) == 0 {
+			break
+		}
+
+		if err := s.send(ctx, entries); err != nil {
+			return exported, "", err
+		}
+		cursor = entries[len(entries)-1].Sequence
+		if err := s.db.Save(&model.AuditExportCursor{Name: auditOTLPCursor, Sequence: cursor}).Error; err != nil {
+			return exported, "", fmt.Errorf("failed to store audit export cursor: %w", err)
+		}
+		exported += int64(len(entries))
+
+		if len(entries) < s.config.BatchSize {
+			break
+		}
+	}
+
+	return exported, fmt.Sprintf("exported %d audit entries up to sequence %d", exported, cursor), nil
+}
+
+// cursor returns the last exported sequence, starting at the chain head
+// the first time
+func (s *AuditExportService) cursor() (int64, error) {
+	var cursor model.AuditExportCursor
+	err := s.db.Where("name = ?", auditOTLPCursor).First(&cursor).Error
+	if err == nil {
+		return cursor.Sequence, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, fmt.Errorf("failed to get audit export cursor: %w", err)
+	}
+
+	var head model.AuditChainHead
+	if err := s.db.First(&head, "id = ?", 1).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, fmt.Errorf("failed to read audit chain head: %w", err)
+	}
+	cursor = model.AuditExportCursor{Name: auditOTLPCursor, Sequence: head.Sequence}
+	if err := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&cursor).Error; err != nil {
+		return 0, fmt.Errorf("failed to store audit export cursor: %w", err)
+	}
+	return head.Sequence, nil
+}
+
+func (s *AuditExportService) send(ctx context.Context, entries []model.AuditLog) error {
+	records := make([]otlpLogRecord, 0, len(entries))
+	for i := range entries {
+		records = append(records, auditLogRecord(&entries[i]))
+	}
+	body, err := json.Marshal(otlpLogsRequest{ResourceLogs: []otlpResourceLogs{{
+		Resource:  s.resource,
+		ScopeLogs: []otlpScopeLogs{{Scope: otlpScope{Name: auditOTLPScope}, LogRecords: records}},
+	}}})
+	if err != nil {
+		return fmt.Errorf("failed to encode audit export: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build audit export request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range s.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send audit export: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("collector rejected audit export: %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// auditLogRecord maps an entry to a log record; attribute names follow
+// the OpenTelemetry semantic conventions where one exists
+func auditLogRecord(entry *model.AuditLog) otlpLogRecord {
+	attributes := []otlpAttribute{
+		stringAttribute("vault.audit.id", entry.ID.String()),
+		intAttribute("vault.audit.sequence", entry.Sequence),
+		stringAttribute("vault.audit.hash", entry.Hash),
+		stringAttribute("vault.audit.prev_hash", entry.PrevHash),
+		stringAttribute("vault.audit.action", entry.Action),
+		boolAttribute("vault.audit.success", entry.Success),
+	}
+	optional := []struct{ key, value string }{
+		{"vault.audit.resource", entry.Resource},
+		{"client.address", entry.IPAddress},
+		{"user_agent.original", entry.UserAgent},
+		{"vault.audit.details", entry.Details},
+		{"vault.request.id", entry.RequestID},
+		{"http.request.method", entry.Method},
+		{"http.route", entry.Route},
+		{"vault.audit.params_hash", entry.ParamsHash},
+		{"vault.audit.build", entry.Build},
+		{"vault.audit.enrichment", entry.Enrichment},
+	}
+	if entry.ResourceID != nil {
+		optional = append(optional, struct{ key, value string }{"vault.audit.resource_id", *entry.ResourceID})
+	}
+	if entry.WorkloadID != nil {
+		optional = append(optional, struct{ key, value string }{"vault.audit.workload_id", entry.WorkloadID.String()})
+	}
+	if entry.UserID != nil {
+		optional = append(optional, struct{ key, value string }{"enduser.id", entry.UserID.String()})
+	}
+	for _, attribute := range optional {
+		if attribute.value != "" {
+			attributes = append(attributes, stringAttribute(attribute.key, attribute.value))
+		}
+	}
+	if entry.StatusCode != 0 {
+		attributes = append(attributes, intAttribute("http.response.status_code", int64(entry.StatusCode)))
+		attributes = append(attributes, intAttribute("vault.request.latency_ms", entry.LatencyMs))
+	}
+
+	severity, severityText := otlpSeverityInfo, "INFO"
+	if !entry.Success {
+		severity, severityText = otlpSeverityWarn, "WARN"
+	}
+	timestamp := strconv.FormatInt(entry.CreatedAt.UnixNano(), 10)
+
+	return otlpLogRecord{
+		TimeUnixNano:         timestamp,
+		ObservedTimeUnixNano: strconv.FormatInt(time.Now().UnixNano(), 10),
+		SeverityNumber:       severity,
+		SeverityText:         severityText,
+		Body:                 otlpValue{StringValue: &entry.Action},
+		Attributes:           attributes,
+	}
+}
+
+// OTLP/HTTP JSON encoding of ExportLogsServiceRequest; 64-bit integers are
+// strings as the protobuf JSON mapping requires
+type otlpLogsRequest struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+type otlpResourceLogs struct {
+	Resource  otlpResource    `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeLogs struct {
+	Scope      otlpScope       `json:"scope"`
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano         string          `json:"timeUnixNano"`
+	ObservedTimeUnixNano string          `json:"observedTimeUnixNano"`
+	SeverityNumber       int             `json:"severityNumber"`
+	SeverityText         string          `json:"severityText"`
+	Body                 otlpValue       `json:"body"`
+	Attributes           []otlpAttribute `json:"attributes"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+}
+
+func stringAttribute(key, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpValue{StringValue: &value}}
+}
+
+func intAttribute(key string, value int64) otlpAttribute {
+	encoded := strconv.FormatInt(value, 10)
+	return otlpAttribute{Key: key, Value: otlpValue{IntValue: &encoded}}
+}
+
+func boolAttribute(key string, value bool) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpValue{BoolValue: &value}}
+}
@@ -11,14 +11,30 @@ import (
 )
 
 type AuthService struct {
-	userService *UserService
-	config      *config.JWTConfig
+	userService   *UserService
+	config        *config.JWTConfig
+	notifications *NotificationService
+	impersonation *ImpersonationService
+	ldap          *LDAPAuthService
+	tokens        *TokenService
 }
 
-func NewAuthService(userService *UserService, config *config.JWTConfig) *AuthService {
+// Session is who a token authenticates. Impersonation is set for tokens an
+// administrator minted to act as UserID, WorkloadID for tokens a runtime
+// bound to the build it runs. Accessor names the token's record once a
+// token store is in use.
+type Session struct {
+	UserID        uuid.UUID
+	Impersonation *model.Impersonation
+	WorkloadID    *uuid.UUID
+	Accessor      *uuid.UUID
+}
+
+func NewAuthService(userService *UserService, config *config.JWTConfig, notifications *NotificationService) *AuthService {
 	return &AuthService{
-		userService: userService,
-		config:      config,
+		userService:   userService,
+		config:        config,
+		notifications: notifications,
 	}
 }
 
@@ -29,10 +45,18 @@ func (s *AuthService) Login(email, password string) (*model.LoginResponse, error
 	}
 
 	if !s.userService.ValidatePassword(user, password) {
+		if s.notifications != nil {
+			s.notifications.SecurityAlert(user.ID, "Failed sign-in attempt", "Someone tried to sign in to your account with an incorrect password.")
+			s.notifications.RecordAuthFailure(user.ID, user.Email)
+		}
 		return nil, ErrInvalidCredentials
 	}
 
-	token, expiresAt, err := s.generateToken(user.ID)
+	if s.notifications != nil {
+		s.notifications.ClearAuthFailures(user.ID)
+	}
+
+	token, expiresAt, err := s.generateToken(user.ID, model.AuthMethodPassword)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate token: %w", err)
 	}
@@ -46,7 +70,56 @@ func (s *AuthService) Login(email, password string) (*model.LoginResponse, error
 	return response, nil
 }
 
+// UseImpersonation accepts tokens of live impersonation sessions
+func (s *AuthService) UseImpersonation(impersonation *ImpersonationService) {
+	s.impersonation = impersonation
+}
+
+// UseTokens records every token issued from now on, and accepts only
+// tokens with a live record
+func (s *AuthService) UseTokens(tokens *TokenService) {
+	s.tokens = tokens
+}
+
+// UseLDAP enables LoginLDAP against the directory ldap is configured for
+func (s *AuthService) UseLDAP(ldap *LDAPAuthService) {
+	s.ldap = ldap
+}
+
+// LoginLDAP signs in with a directory username and password
+func (s *AuthService) LoginLDAP(username, password string) (*model.LoginResponse, error) {
+	if s.ldap == nil {
+		return nil, ErrLDAPDisabled
+	}
+
+	user, err := s.ldap.Authenticate(username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	token, expiresAt, err := s.generateToken(user.ID, model.AuthMethodLDAP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return &model.LoginResponse{
+		Token:     token,
+		ExpiresAt: expiresAt,
+		User:      *user,
+	}, nil
+}
+
 func (s *AuthService) ValidateToken(tokenString string) (*uuid.UUID, error) {
+	session, err := s.Authenticate(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	return &session.UserID, nil
+}
+
+// Authenticate validates a token and resolves its session. Impersonation
+// tokens are only accepted while their session is active.
+func (s *AuthService) Authenticate(tokenString string) (*Session, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
@@ -69,7 +142,36 @@ func (s *AuthService) ValidateToken(tokenString string) (*uuid.UUID, error) {
 			return nil, fmt.Errorf("invalid user ID format: %w", err)
 		}
 
-		return &userID, nil
+		session := &Session{UserID: userID}
+		if impersonationID, ok := claims["imp"].(string); ok {
+			if s.impersonation == nil {
+				return nil, fmt.Errorf("impersonation tokens are not accepted")
+			}
+			session.Impersonation, err = s.impersonation.activeSession(impersonationID, userID)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if workloadID, ok := claims["wl"].(string); ok {
+			id, err := uuid.Parse(workloadID)
+			if err != nil {
+				return nil, fmt.Errorf("invalid workload ID format: %w", err)
+			}
+			session.WorkloadID = &id
+		}
+		if s.tokens != nil {
+			jti, _ := claims["jti"].(string)
+			accessor, err := uuid.Parse(jti)
+			if err != nil {
+				return nil, fmt.Errorf("token has no record in the token store")
+			}
+			if _, err := s.tokens.active(accessor, userID); err != nil {
+				return nil, err
+			}
+			session.Accessor = &accessor
+		}
+
+		return session, nil
 	}
 
 	return nil, fmt.Errorf("invalid token")
@@ -89,20 +191,65 @@ func (s *AuthService) GetSession(userID uuid.UUID) (*model.SessionResponse, erro
 	}, nil
 }
 
-func (s *AuthService) generateToken(userID uuid.UUID) (string, time.Time, error) {
-	expiresAt := time.Now().Add(time.Duration(s.config.Expiration) * time.Second)
+// Logout revokes the token with the given accessor, with its children.
+// Without a token store there is nothing to revoke.
+func (s *AuthService) Logout(accessor *uuid.UUID) error {
+	if s.tokens == nil || accessor == nil {
+		return nil
+	}
+	_, err := revokeTokens(s.tokens.db, []uuid.UUID{*accessor})
+	return err
+}
+
+// generateToken issues a login token, valid for the configured expiration
+// and renewable up to the configured max TTL
+func (s *AuthService) generateToken(userID uuid.UUID, method string) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(time.Duration(s.config.Expiration) * time.Second)
+	maxExpiresAt := now.Add(time.Duration(s.config.MaxTTL) * time.Second)
+	if maxExpiresAt.Before(expiresAt) {
+		maxExpiresAt = expiresAt
+	}
+
+	token, err := s.signToken(userID, method, nil, expiresAt, maxExpiresAt, nil)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// generateImpersonationToken mints a token acting as the impersonated
+// user. The admin is named as actor (RFC 8693) and the session in imp.
+// The token is a child of the admin's token, when known, so revoking that
+// ends it too.
+func (s *AuthService) generateImpersonationToken(impersonation *model.Impersonation, parentID *uuid.UUID) (string, error) {
+	return s.signToken(impersonation.UserID, model.AuthMethodImpersonation, parentID, impersonation.ExpiresAt, impersonation.ExpiresAt, jwt.MapClaims{
+		"imp": impersonation.ID.String(),
+		"act": map[string]interface{}{"sub": impersonation.AdminID.String()},
+	})
+}
 
+// signToken records a token in the token store, when there is one, and
+// signs its JWT. Recorded tokens name their record in jti and carry their
+// max TTL as exp, since the record decides when they expire.
+func (s *AuthService) signToken(userID uuid.UUID, method string, parentID *uuid.UUID, expiresAt, maxExpiresAt time.Time, extra jwt.MapClaims) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id": userID.String(),
 		"exp":     expiresAt.Unix(),
 		"iat":     time.Now().Unix(),
 	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(s.config.Secret))
-	if err != nil {
-		return "", time.Time{}, err
+	for name, value := range extra {
+		claims[name] = value
+	}
+	if s.tokens != nil {
+		record, err := s.tokens.create(userID, method, parentID, expiresAt, maxExpiresAt)
+		if err != nil {
+			return "", err
+		}
+		claims["jti"] = record.ID.String()
+		claims["exp"] = maxExpiresAt.Unix()
 	}
 
-	return tokenString, expiresAt, nil
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(s.config.Secret))
 }
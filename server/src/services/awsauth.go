@@ -0,0 +1,503 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/config"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+)
+
+// awsServerIDHeader carries aws.server_id in signed login requests
+const awsServerIDHeader = "X-Aether-Vault-Server-ID"
+
+var awsAccountID = regexp.MustCompile(`^[0-9]{12}$`)
+
+// AWSAuthService signs workloads on AWS in with a signed
+// sts:GetCallerIdentity request. The vault never sees the workload's
+// credentials: it forwards the request to STS, which answers with the
+// identity that signed it, and matches that identity to the role asked
+// for.
+type AWSAuthService struct {
+	db           *gorm.DB
+	authService  *AuthService
+	auditService *AuditService
+	cfg          config.AWSConfig
+	client       *http.Client
+}
+
+// AWSCallerIdentity is who signed a login request. PrincipalARN is the
+// IAM user or role, with assumed-role sessions mapped to their role.
+type AWSCallerIdentity struct {
+	Account      string
+	ARN          string
+	UserID       string
+	PrincipalARN string
+}
+
+func NewAWSAuthService(db *gorm.DB, cfg config.AWSConfig, authService *AuthService, auditService *AuditService) *AWSAuthService {
+	return &AWSAuthService{
+		db:           db,
+		authService:  authService,
+		auditService: auditService,
+		cfg:          cfg,
+		client:       &http.Client{Timeout: time.Duration(cfg.Timeout) * time.Second},
+	}
+}
+
+func (s *AWSAuthService) CreateRole(req *model.CreateAWSRoleRequest, userID uuid.UUID) (*model.AWSRole, error) {
+	if !machineRoleName.MatchString(req.Name) {
+		return nil, fmt.Errorf("%w: names use lowercase letters, digits, - and _", ErrAWSRoleInvalid)
+	}
+	role := &model.AWSRole{
+		ID:                    uuid.New(),
+		Name:                  req.Name,
+		BoundAccountIDs:       req.BoundAccountIDs,
+		BoundIAMPrincipalARNs: req.BoundIAMPrincipalARNs,
+		BoundTags:             req.BoundTags,
+		Policies:              uniqueUserIDs(req.Policies),
+		CreatedBy:             userID,
+	}
+	if err := s.checkRole(role); err != nil {
+		return nil, err
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.AWSRole{}).Where("name = ?", req.Name).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to check role name: %w", err)
+		}
+		if count > 0 {
+			return ErrAWSRoleExists
+		}
+
+		user, err := createServiceUser(tx, model.AuthMethodAWS, role.ID, role.Name)
+		if err != nil {
+			return err
+		}
+		role.UserID = user.ID
+		if err := tx.Create(role).Error; err != nil {
+			return fmt.Errorf("failed to create role: %w", err)
+		}
+		return syncSourcePolicies(tx, user.ID, model.AuthMethodAWS, role.Policies)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "aws_role_created", "aws_role", role.ID.String(), true, "name="+role.Name)
+	}
+	return role, nil
+}
+
+func (s *AWSAuthService) GetRoles() ([]model.AWSRole, error) {
+	var roles []model.AWSRole
+	if err := s.db.Order("name").Find(&roles).Error; err != nil {
+		return nil, fmt.Errorf("failed to get roles: %w", err)
+	}
+	return roles, nil
+}
+
+func (s *AWSAuthService) GetRole(name string) (*model.AWSRole, error) {
+	var role model.AWSRole
+	if err := s.db.Where("name = ?", name).First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAWSRoleNotFound
+		}
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+	return &role, nil
+}
+
+func (s *AWSAuthService) UpdateRole(name string, req *model.UpdateAWSRoleRequest, userID uuid.UUID) (*model.AWSRole, error) {
+	role, err := s.GetRole(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.BoundAccountIDs != nil {
+		role.BoundAccountIDs = *req.BoundAccountIDs
+	}
+	if req.BoundIAMPrincipalARNs != nil {
+		role.BoundIAMPrincipalARNs = *req.BoundIAMPrincipalARNs
+	}
+	if req.BoundTags != nil {
+		role.BoundTags = *req.BoundTags
+	}
+	if req.Policies != nil {
+		role.Policies = uniqueUserIDs(*req.Policies)
+	}
+	if err := s.checkRole(role); err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(role).Error; err != nil {
+			return fmt.Errorf("failed to update role: %w", err)
+		}
+		return syncSourcePolicies(tx, role.UserID, model.AuthMethodAWS, role.Policies)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "aws_role_updated", "aws_role", role.ID.String(), true, "name="+role.Name)
+	}
+	return role, nil
+}
+
+// DeleteRole removes the role and its service user. Tokens already issued
+// to the role lose its policies at once.
+func (s *AWSAuthService) DeleteRole(name string, userID uuid.UUID) error {
+	role, err := s.GetRole(name)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND source = ?", role.UserID, model.AuthMethodAWS).Delete(&model.PolicyAssignment{}).Error; err != nil {
+			return fmt.Errorf("failed to detach policies: %w", err)
+		}
+		if err := tx.Where("id = ?", role.UserID).Delete(&model.User{}).Error; err != nil {
+			return fmt.Errorf("failed to delete service user: %w", err)
+		}
+		if err := tx.Delete(role).Error; err != nil {
+			return fmt.Errorf("failed to delete role: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "aws_role_deleted", "aws_role", role.ID.String(), true, "name="+role.Name)
+	}
+	return nil
+}
+
+// Login has STS verify the signed request and returns a token of the
+// role's service user, which carries only the role's policies
+func (s *AWSAuthService) Login(req *model.AWSLoginRequest) (*model.LoginResponse, *AWSCallerIdentity, error) {
+	if !s.cfg.Enabled {
+		return nil, nil, ErrAWSDisabled
+	}
+	role, err := s.GetRole(req.Role)
+	if errors.Is(err, ErrAWSRoleNotFound) {
+		return nil, nil, fmt.Errorf("%w: unknown role %s", ErrAWSLoginFailed, req.Role)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	identity, err := s.callerIdentity(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !awsBound(role.BoundAccountIDs, identity.Account) || !awsBound(role.BoundIAMPrincipalARNs, identity.PrincipalARN) {
+		return nil, identity, fmt.Errorf("%w: %s is not bound to role %s", ErrAWSLoginFailed, identity.PrincipalARN, role.Name)
+	}
+	if len(role.BoundTags) > 0 {
+		tags, err := s.principalTags(identity.PrincipalARN)
+		if err != nil {
+			return nil, identity, err
+		}
+		for key, value := range role.BoundTags {
+			if actual, ok := tags[key]; !ok || actual != value {
+				return nil, identity, fmt.Errorf("%w: %s does not carry tag %s=%s", ErrAWSLoginFailed, identity.PrincipalARN, key, value)
+			}
+		}
+	}
+
+	user, err := s.authService.userService.GetUserByID(role.UserID)
+	if err != nil {
+		return nil, identity, fmt.Errorf("%w: the role's service user is disabled", ErrAWSLoginFailed)
+	}
+	token, expiresAt, err := s.authService.generateToken(user.ID, model.AuthMethodAWS)
+	if err != nil {
+		return nil, identity, fmt.Errorf("failed to generate token: %w", err)
+	}
+	return &model.LoginResponse{
+		Token:     token,
+		ExpiresAt: expiresAt,
+		User:      *user,
+	}, identity, nil
+}
+
+// callerIdentity checks the signed request is a bare GetCallerIdentity
+// call to the configured endpoint, so it cannot be used for anything
+// else, and forwards it to STS
+func (s *AWSAuthService) callerIdentity(req *model.AWSLoginRequest) (*AWSCallerIdentity, error) {
+	if !strings.EqualFold(req.IAMHTTPRequestMethod, http.MethodPost) {
+		return nil, fmt.Errorf("%w: the signed request must be a POST", ErrAWSLoginFailed)
+	}
+	rawURL, err := base64.StdEncoding.DecodeString(req.IAMRequestURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: iam_request_url is not base64", ErrAWSLoginFailed)
+	}
+	body, err := base64.StdEncoding.DecodeString(req.IAMRequestBody)
+	if err != nil {
+		return nil, fmt.Errorf("%w: iam_request_body is not base64", ErrAWSLoginFailed)
+	}
+	rawHeaders, err := base64.StdEncoding.DecodeString(req.IAMRequestHeaders)
+	if err != nil {
+		return nil, fmt.Errorf("%w: iam_request_headers is not base64", ErrAWSLoginFailed)
+	}
+	var headers map[string][]string
+	if err := json.Unmarshal(rawHeaders, &headers); err != nil {
+		return nil, fmt.Errorf("%w: iam_request_headers is not a JSON object of header lists", ErrAWSLoginFailed)
+	}
+
+	endpoint, err := url.Parse(s.cfg.STSEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid STS endpoint: %w", err)
+	}
+	target, err := url.Parse(string(rawURL))
+	if err != nil || target.Scheme != endpoint.Scheme || !strings.EqualFold(target.Host, endpoint.Host) || strings.Trim(target.Path, "/") != "" || target.RawQuery != "" {
+		return nil, fmt.Errorf("%w: the signed request is not addressed to %s", ErrAWSLoginFailed, s.cfg.STSEndpoint)
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil || form.Get("Action") != "GetCallerIdentity" {
+		return nil, fmt.Errorf("%w: the signed request is not a GetCallerIdentity call", ErrAWSLoginFailed)
+	}
+	for key := range form {
+		if key != "Action" && key != "Version" {
+			return nil, fmt.Errorf("%w: the signed request carries unexpected parameter %s", ErrAWSLoginFailed, key)
+		}
+	}
+
+	request, err := http.NewRequest(http.MethodPost, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build STS request: %w", err)
+	}
+	for name, values := range headers {
+		// net/http sets these itself from the URL and body
+		if strings.EqualFold(name, "Host") || strings.EqualFold(name, "Content-Length") {
+			continue
+		}
+		for _, value := range values {
+			request.Header.Add(name, value)
+		}
+	}
+	authorization := request.Header.Get("Authorization")
+	if authorization == "" {
+		return nil, fmt.Errorf("%w: the request is not signed", ErrAWSLoginFailed)
+	}
+	if s.cfg.ServerID != "" {
+		if request.Header.Get(awsServerIDHeader) != s.cfg.ServerID || !awsSignedHeader(authorization, awsServerIDHeader) {
+			return nil, fmt.Errorf("%w: the request must sign %s: %s", ErrAWSLoginFailed, awsServerIDHeader, s.cfg.ServerID)
+		}
+	}
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAWSUnavailable, err)
+	}
+	defer response.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAWSUnavailable, err)
+	}
+	switch {
+	case response.StatusCode == http.StatusBadRequest || response.StatusCode == http.StatusForbidden:
+		return nil, fmt.Errorf("%w: STS refused the request: %s", ErrAWSLoginFailed, awsErrorCode(data))
+	case response.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("%w: STS returned %d", ErrAWSUnavailable, response.StatusCode)
+	}
+
+	var result struct {
+		Arn     string `xml:"GetCallerIdentityResult>Arn"`
+		UserID  string `xml:"GetCallerIdentityResult>UserId"`
+		Account string `xml:"GetCallerIdentityResult>Account"`
+	}
+	if err := xml.Unmarshal(data, &result); err != nil || result.Arn == "" || result.Account == "" {
+		return nil, fmt.Errorf("%w: unreadable GetCallerIdentity response", ErrAWSUnavailable)
+	}
+	return &AWSCallerIdentity{
+		Account:      result.Account,
+		ARN:          result.Arn,
+		UserID:       result.UserID,
+		PrincipalARN: awsPrincipalARN(result.Arn),
+	}, nil
+}
+
+// principalTags lists the tags of an IAM user or role with the vault's
+// own credentials
+func (s *AWSAuthService) principalTags(principalARN string) (map[string]string, error) {
+	resource := ""
+	if parts := strings.SplitN(principalARN, ":", 6); len(parts) == 6 {
+		resource = parts[5]
+	}
+	var action, parameter string
+	switch {
+	case strings.HasPrefix(resource, "role/"):
+		action, parameter = "ListRoleTags", "RoleName"
+	case strings.HasPrefix(resource, "user/"):
+		action, parameter = "ListUserTags", "UserName"
+	default:
+		return nil, fmt.Errorf("%w: %s carries no IAM tags", ErrAWSLoginFailed, principalARN)
+	}
+	name := resource[strings.LastIndex(resource, "/")+1:]
+
+	tags := make(map[string]string)
+	marker := ""
+	for {
+		query := url.Values{"Action": {action}, parameter: {name}, "Version": {"2010-05-08"}}
+		if marker != "" {
+			query.Set("Marker", marker)
+		}
+		request, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(s.cfg.IAMEndpoint, "/")+"/?"+awsQueryEscape(query), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build IAM request: %w", err)
+		}
+		if err := signAWSRequest(request, nil, s.cfg.IAMRegion, "iam"); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrAWSUnavailable, err)
+		}
+
+		response, err := s.client.Do(request)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrAWSUnavailable, err)
+		}
+		data, err := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+		response.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrAWSUnavailable, err)
+		}
+		if response.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("%w: IAM %s returned %d: %s", ErrAWSUnavailable, action, response.StatusCode, awsErrorCode(data))
+		}
+
+		// The result element is named after the action, so match it by
+		// position rather than by name
+		var page struct {
+			Result struct {
+				Tags []struct {
+					Key   string `xml:"Key"`
+					Value string `xml:"Value"`
+				} `xml:"Tags>member"`
+				IsTruncated bool   `xml:"IsTruncated"`
+				Marker      string `xml:"Marker"`
+			} `xml:",any"`
+			Metadata struct{} `xml:"ResponseMetadata"`
+		}
+		if err := xml.Unmarshal(data, &page); err != nil {
+			return nil, fmt.Errorf("%w: unreadable %s response", ErrAWSUnavailable, action)
+		}
+		for _, tag := range page.Result.Tags {
+			tags[tag.Key] = tag.Value
+		}
+		if !page.Result.IsTruncated || page.Result.Marker == "" {
+			return tags, nil
+		}
+		marker = page.Result.Marker
+	}
+}
+
+func (s *AWSAuthService) checkRole(role *model.AWSRole) error {
+	if len(role.BoundAccountIDs) == 0 && len(role.BoundIAMPrincipalARNs) == 0 {
+		return fmt.Errorf("%w: bind bound_account_ids, bound_iam_principal_arns or both", ErrAWSRoleInvalid)
+	}
+	for _, account := range role.BoundAccountIDs {
+		if !awsAccountID.MatchString(account) {
+			return fmt.Errorf("%w: %q is not a 12-digit account ID", ErrAWSRoleInvalid, account)
+		}
+	}
+	for _, arn := range role.BoundIAMPrincipalARNs {
+		if !strings.HasPrefix(arn, "arn:") || strings.Contains(strings.TrimSuffix(arn, "*"), "*") {
+			return fmt.Errorf("%w: %q is not an ARN, optionally ending in *", ErrAWSRoleInvalid, arn)
+		}
+	}
+	for key := range role.BoundTags {
+		if strings.TrimSpace(key) == "" {
+			return fmt.Errorf("%w: tag keys must not be blank", ErrAWSRoleInvalid)
+		}
+	}
+	exist, err := policiesExist(s.db, role.Policies)
+	if err != nil {
+		return err
+	}
+	if !exist {
+		return fmt.Errorf("%w: policies names a policy that does not exist", ErrAWSRoleInvalid)
+	}
+	return nil
+}
+
+// awsPrincipalARN maps an assumed-role session ARN,
+// arn:aws:sts::<account>:assumed-role/<role>/<session>, to the ARN of its
+// role; other ARNs are returned unchanged. STS does not report the role's
+// path, so the role ARN carries none.
+func awsPrincipalARN(arn string) string {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) != 6 || parts[2] != "sts" || !strings.HasPrefix(parts[5], "assumed-role/") {
+		return arn
+	}
+	segments := strings.Split(parts[5], "/")
+	if len(segments) < 3 {
+		return arn
+	}
+	return fmt.Sprintf("arn:%s:iam::%s:role/%s", parts[1], parts[4], segments[1])
+}
+
+// awsBound reports whether value is in bound, where an empty list matches
+// anything and entries ending in * match by prefix
+func awsBound(bound []string, value string) bool {
+	if len(bound) == 0 {
+		return true
+	}
+	for _, candidate := range bound {
+		if candidate == value || (strings.HasSuffix(candidate, "*") && strings.HasPrefix(value, strings.TrimSuffix(candidate, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// awsSignedHeader reports whether a SigV4 Authorization header signs the
+// named header
+func awsSignedHeader(authorization, name string) bool {
+	for _, field := range strings.Split(authorization, ",") {
+		field = strings.TrimSpace(field)
+		if index := strings.Index(field, "SignedHeaders="); index >= 0 {
+			for _, signed := range strings.Split(field[index+len("SignedHeaders="):], ";") {
+				if strings.EqualFold(signed, name) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// awsErrorCode reads the code of an AWS query API error response
+func awsErrorCode(data []byte) string {
+	var response struct {
+		Code string `xml:"Error>Code"`
+	}
+	if err := xml.Unmarshal(data, &response); err != nil || response.Code == "" {
+		return "unknown error"
+	}
+	return response.Code
+}
+
+var (
+	ErrAWSDisabled     = errors.New("AWS login is not configured")
+	ErrAWSRoleNotFound = errors.New("AWS role not found")
+	ErrAWSRoleExists   = errors.New("an AWS role with this name already exists")
+	ErrAWSRoleInvalid  = errors.New("invalid AWS role")
+	ErrAWSLoginFailed  = errors.New("AWS login failed")
+	ErrAWSUnavailable  = errors.New("AWS unavailable")
+)
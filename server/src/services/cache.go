@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ttlCache is a read-through cache for rows that are read far more often
+// than written. Writes made through this instance's database handle clear
+// it once they commit; writes by other instances are picked up when
+// entries expire, so ttl bounds how stale a read can be.
+type ttlCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]ttlEntry
+	hits    int64
+	misses  int64
+	warmed  int
+}
+
+type ttlEntry struct {
+	value     interface{}
+	expiresAt time.Time
+}
+
+// newTTLCache returns a cache, or nil when ttl disables caching. A nil
+// cache misses every lookup and ignores stores.
+func newTTLCache(ttl time.Duration) *ttlCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &ttlCache{ttl: ttl, entries: make(map[string]ttlEntry)}
+}
+
+func (c *ttlCache) get(key string) (interface{}, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	return entry.value, true
+}
+
+// put stores value until the cache TTL, or until expiresAt when that is
+// sooner and not zero
+func (c *ttlCache) put(key string, value interface{}, expiresAt time.Time) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	deadline := now.Add(c.ttl)
+	if !expiresAt.IsZero() && expiresAt.Before(deadline) {
+		deadline = expiresAt
+	}
+	for cachedKey, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, cachedKey)
+		}
+	}
+	c.entries[key] = ttlEntry{value: value, expiresAt: deadline}
+}
+
+func (c *ttlCache) clear() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]ttlEntry)
+}
+
+func (c *ttlCache) markWarmed(n int) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.warmed += n
+}
+
+func (c *ttlCache) stats() (entries int, hits, misses int64, warmed int) {
+	if c == nil {
+		return 0, 0, 0, 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries), c.hits, c.misses, c.warmed
+}
+
+// clearOnWrite clears the cache after every create, update or delete on
+// one of the tables, and after raw statements that mention them. A write
+// inside a transaction clears it when the transaction commits, since
+// until then concurrent readers still load and cache the old rows; this
+// needs the database opened on a NewCachePool.
+func (c *ttlCache) clearOnWrite(db *gorm.DB, name string, tables ...string) {
+	if c == nil || db == nil {
+		return
+	}
+
+	touches := func(tx *gorm.DB) bool {
+		for _, table := range tables {
+			if tx.Statement.Table == table || strings.Contains(tx.Statement.SQL.String(), table) {
+				return true
+			}
+		}
+		return false
+	}
+	clear := func(tx *gorm.DB) {
+		if !touches(tx) {
+			return
+		}
+		if pending, ok := tx.Statement.ConnPool.(*cacheTx); ok {
+			pending.clearOnCommit(c)
+			return
+		}
+		c.clear()
+	}
+
+	callbackName := "cache:" + name
+	db.Callback().Create().After("gorm:create").Register(callbackName, clear)
+	db.Callback().Update().After("gorm:update").Register(callbackName, clear)
+	db.Callback().Delete().After("gorm:delete").Register(callbackName, clear)
+	db.Callback().Raw().After("gorm:raw").Register(callbackName, clear)
+}
+
+// NewCachePool wraps the database pool so that transactions clear the
+// caches they wrote to when they commit. gorm has no commit callback.
+func NewCachePool(db *sql.DB) gorm.ConnPool {
+	return &cachePool{DB: db}
+}
+
+type cachePool struct {
+	*sql.DB
+}
+
+func (p *cachePool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	tx, err := p.DB.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &cacheTx{Tx: tx, db: p.DB}, nil
+}
+
+// GetDBConn lets gorm's DB() reach the wrapped pool
+func (p *cachePool) GetDBConn() (*sql.DB, error) {
+	return p.DB, nil
+}
+
+// cacheTx is a transaction that remembers the caches its writes touched
+type cacheTx struct {
+	*sql.Tx
+	db *sql.DB
+
+	mu     sync.Mutex
+	caches map[*ttlCache]bool
+}
+
+func (t *cacheTx) clearOnCommit(c *ttlCache) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.caches == nil {
+		t.caches = make(map[*ttlCache]bool)
+	}
+	t.caches[c] = true
+}
+
+func (t *cacheTx) Commit() error {
+	err := t.Tx.Commit()
+	t.mu.Lock()
+	caches := t.caches
+	t.caches = nil
+	t.mu.Unlock()
+	if err == nil {
+		for c := range caches {
+			c.clear()
+		}
+	}
+	return err
+}
+
+func (t *cacheTx) Rollback() error {
+	t.mu.Lock()
+	t.caches = nil
+	t.mu.Unlock()
+	return t.Tx.Rollback()
+}
+
+func (t *cacheTx) GetDBConn() (*sql.DB, error) {
+	return t.db, nil
+}
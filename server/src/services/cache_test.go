@@ -0,0 +1,54 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+)
+
+func TestCacheClearsWhenTransactionCommits(t *testing.T) {
+	db := newStubDB(t, stubTables{})
+	cache := newTTLCache(time.Minute)
+	cache.clearOnWrite(db, "test", "policies")
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&model.Policy{ID: uuid.New(), Name: "ops"}).Error; err != nil {
+			return err
+		}
+		// A concurrent reader still sees the old rows and caches them
+		cache.put("policies", "before the write", time.Time{})
+		if _, ok := cache.get("policies"); !ok {
+			t.Error("cache cleared before the transaction committed")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("transaction failed: %v", err)
+	}
+	if cached, ok := cache.get("policies"); ok {
+		t.Errorf("cache still holds %v after the commit", cached)
+	}
+
+	// Writes outside a transaction are visible at once
+	cache.put("policies", "before the write", time.Time{})
+	if err := db.Exec(`UPDATE "policies" SET name = 'ops'`).Error; err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	if _, ok := cache.get("policies"); ok {
+		t.Error("cache survived a write outside a transaction")
+	}
+
+	// A rolled back write leaves the cache alone
+	cache.put("policies", "unchanged", time.Time{})
+	db.Transaction(func(tx *gorm.DB) error {
+		tx.Create(&model.Policy{ID: uuid.New(), Name: "ops"})
+		return errors.New("roll back")
+	})
+	if _, ok := cache.get("policies"); !ok {
+		t.Error("rolled back transaction cleared the cache")
+	}
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-vault/server/src/config"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Access kinds recorded for cache warm-up
+const (
+	// AccessKindSecret keys are secret IDs
+	AccessKindSecret = "secret"
+	// AccessKindPolicy keys are IDs of users whose policies were evaluated
+	AccessKindPolicy = "policy"
+)
+
+// AccessStats counts cache reads in memory and periodically adds them to
+// the database, so the next start knows which entries are hot
+type AccessStats struct {
+	db     *gorm.DB
+	window time.Duration
+
+	mu      sync.Mutex
+	pending map[accessKey]*pendingAccess
+}
+
+type accessKey struct {
+	kind string
+	key  string
+}
+
+type pendingAccess struct {
+	hits int64
+	last time.Time
+}
+
+// NewAccessStats keeps statistics for accesses within window
+func NewAccessStats(db *gorm.DB, window time.Duration) *AccessStats {
+	return &AccessStats{
+		db:      db,
+		window:  window,
+		pending: make(map[accessKey]*pendingAccess),
+	}
+}
+
+// Record counts one access. It is safe to call on a nil AccessStats.
+func (a *AccessStats) Record(kind, key string) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	k := accessKey{kind: kind, key: key}
+	entry, ok := a.pending[k]
+	if !ok {
+		entry = &pendingAccess{}
+		a.pending[k] = entry
+	}
+	entry.hits++
+	entry.last = time.Now()
+}
+
+// Start flushes the counters every interval
+func (a *AccessStats) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := a.Flush(); err != nil {
+				log.Printf("⚠️  Failed to persist cache access statistics: %v", err)
+			}
+		}
+	}()
+}
+
+// Flush adds the pending counters to the stored ones and drops keys not
+// accessed within the window
+func (a *AccessStats) Flush() error {
+	a.mu.Lock()
+	pending := a.pending
+	a.pending = make(map[accessKey]*pendingAccess)
+	a.mu.Unlock()
+
+	if len(pending) > 0 {
+		rows := make([]model.CacheAccessStat, 0, len(pending))
+		for k, entry := range pending {
+			rows = append(rows, model.CacheAccessStat{Kind: k.kind, Key: k.key, Hits: entry.hits, LastAccessAt: entry.last})
+		}
+		if err := a.db.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "kind"}, {Name: "key"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"hits":           gorm.Expr("cache_access_stats.hits + excluded.hits"),
+				"last_access_at": gorm.Expr("GREATEST(cache_access_stats.last_access_at, excluded.last_access_at)"),
+			}),
+		}).CreateInBatches(rows, 500).Error; err != nil {
+			return fmt.Errorf("failed to store access statistics: %w", err)
+		}
+	}
+
+	if err := a.db.Where("last_access_at < ?", time.Now().Add(-a.window)).Delete(&model.CacheAccessStat{}).Error; err != nil {
+		return fmt.Errorf("failed to prune access statistics: %w", err)
+	}
+	return nil
+}
+
+// Top returns the most accessed keys of a kind within the window
+func (a *AccessStats) Top(kind string, limit int) ([]string, error) {
+	var keys []string
+	if err := a.db.Model(&model.CacheAccessStat{}).
+		Where("kind = ? AND last_access_at >= ?", kind, time.Now().Add(-a.window)).
+		Order("hits DESC").Limit(limit).Pluck("key", &keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to get access statistics: %w", err)
+	}
+	return keys, nil
+}
+
+// CacheWarmupService pre-loads caches after startup from the access
+// statistics of earlier runs, so the first requests after a deploy do not
+// all miss
+type CacheWarmupService struct {
+	stats  *AccessStats
+	config config.CacheConfig
+
+	mu         sync.Mutex
+	targets    []*warmupTarget
+	startedAt  *time.Time
+	finishedAt *time.Time
+}
+
+type warmupTarget struct {
+	name  string
+	kind  string
+	warm  func(keys []string) (int, error)
+	cache *ttlCache
+}
+
+func NewCacheWarmupService(stats *AccessStats, config config.CacheConfig) *CacheWarmupService {
+	return &CacheWarmupService{stats: stats, config: config}
+}
+
+// register adds a cache. Caches with an access kind are warmed with the
+// hottest keys of that kind; the others are loaded whole.
+func (s *CacheWarmupService) register(name, kind string, cache *ttlCache, warm func(keys []string) (int, error)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.targets = append(s.targets, &warmupTarget{name: name, kind: kind, warm: warm, cache: cache})
+}
+
+// RegisterSecrets warms the secret metadata cache
+func (s *CacheWarmupService) RegisterSecrets(secretService *SecretService) {
+	s.register("secret_metadata", AccessKindSecret, secretService.rowCache, secretService.warmCache)
+}
+
+// RegisterPolicies warms the per-user policy cache
+func (s *CacheWarmupService) RegisterPolicies(policyService *PolicyService) {
+	s.register("policies", AccessKindPolicy, policyService.cache, policyService.warmCache)
+}
+
+// RegisterJWKS warms the OIDC key set
+func (s *CacheWarmupService) RegisterJWKS(oidcService *OIDCService) {
+	s.register("jwks", "", oidcService.jwksCache, func([]string) (int, error) {
+		if _, err := oidcService.JWKS(); err != nil {
+			return 0, err
+		}
+		return 1, nil
+	})
+}
+
+// Warm loads every registered cache. A failing cache is logged and
+// skipped; it fills on demand instead.
+func (s *CacheWarmupService) Warm() {
+	s.mu.Lock()
+	started := time.Now()
+	s.startedAt = &started
+	targets := append([]*warmupTarget(nil), s.targets...)
+	s.mu.Unlock()
+
+	for _, target := range targets {
+		var keys []string
+		if target.kind != "" {
+			var err error
+			if keys, err = s.stats.Top(target.kind, s.config.WarmupLimit); err != nil {
+				log.Printf("⚠️  Cache warm-up of %s failed: %v", target.name, err)
+				continue
+			}
+			if len(keys) == 0 {
+				continue
+			}
+		}
+
+		loaded, err := target.warm(keys)
+		if err != nil {
+			log.Printf("⚠️  Cache warm-up of %s failed: %v", target.name, err)
+			continue
+		}
+		target.cache.markWarmed(loaded)
+	}
+
+	s.mu.Lock()
+	finished := time.Now()
+	s.finishedAt = &finished
+	s.mu.Unlock()
+	log.Printf("🔥 Cache warm-up finished in %s", finished.Sub(started).Round(time.Millisecond))
+}
+
+// Status reports every registered cache. It is safe to call on a nil
+// CacheWarmupService.
+func (s *CacheWarmupService) Status() model.CacheWarmupStatus {
+	if s == nil {
+		return model.CacheWarmupStatus{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := model.CacheWarmupStatus{StartedAt: s.startedAt, FinishedAt: s.finishedAt}
+	for _, target := range s.targets {
+		entries, hits, misses, warmed := target.cache.stats()
+		status.Caches = append(status.Caches, model.CacheStatus{
+			Name:    target.name,
+			Entries: entries,
+			Hits:    hits,
+			Misses:  misses,
+			Warmed:  warmed,
+		})
+	}
+	return status
+}
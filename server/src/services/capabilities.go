@@ -0,0 +1,87 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+)
+
+// routeActions are the actions route access checks ask for
+var routeActions = []string{"read", "create", "update", "delete"}
+
+// Capabilities reports the actions userID may take on path and which of
+// their policies allow or deny each. Actions beyond the route ones count
+// when a rule matching the path names them.
+func (s *PolicyService) Capabilities(userID uuid.UUID, path string, centralAdmin bool) (*model.TokenCapabilities, error) {
+	path = strings.Trim(strings.TrimSpace(path), "/")
+	if path == "" {
+		return nil, ErrPolicyPathRequired
+	}
+
+	policies, err := s.GetPoliciesByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	actions := append([]string(nil), routeActions...)
+	for _, policy := range policies {
+		rules, err := ParsePolicyRules(policy.Rules)
+		if err != nil {
+			continue
+		}
+		for _, rule := range rules {
+			if !matchesAny(rule.Resources, path, matchPolicyResource) {
+				continue
+			}
+			for _, action := range rule.Actions {
+				if action != "*" && !containsString(actions, action) {
+					actions = append(actions, action)
+				}
+			}
+		}
+	}
+
+	// Only the impersonation grant is checked without the admin bypass
+	explicit := strings.HasPrefix(path, ImpersonationPolicy+"/")
+	capabilities := &model.TokenCapabilities{
+		Path:         path,
+		Capabilities: []string{},
+		CentralAdmin: centralAdmin,
+		Policies:     []model.PolicyCapabilities{},
+	}
+	for _, action := range actions {
+		if (centralAdmin && !explicit) || s.decide(policies, path, action, nil, uuid.Nil, "") {
+			capabilities.Capabilities = append(capabilities.Capabilities, action)
+		}
+	}
+
+	for _, policy := range policies {
+		granted := model.PolicyCapabilities{ID: policy.ID, Name: policy.Name}
+		for _, action := range actions {
+			switch s.evaluatePolicy(policy.Rules, path, action, nil) {
+			case model.PolicyEffectAllow:
+				granted.Allowed = append(granted.Allowed, action)
+			case model.PolicyEffectDeny:
+				granted.Denied = append(granted.Denied, action)
+			}
+		}
+		if len(granted.Allowed) > 0 || len(granted.Denied) > 0 {
+			capabilities.Policies = append(capabilities.Policies, granted)
+		}
+	}
+
+	return capabilities, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+var ErrPolicyPathRequired = errors.New("path is required")
@@ -0,0 +1,652 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/config"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+)
+
+const (
+	chatCallbackPath    = "/api/v1/integrations/chat/callback"
+	slackRequestMaxSkew = 5 * time.Minute
+	chatMaxFacts        = 10
+)
+
+// ApprovalHandler applies an approve/deny decision taken from a chat
+// message. actor describes who decided, as reported by the chat provider.
+type ApprovalHandler func(requestID string, approved bool, actor string) error
+
+// ChatService posts routed events to Slack and Microsoft Teams channels and
+// turns approve/deny buttons on approval requests back into decisions
+type ChatService struct {
+	db           *gorm.DB
+	auditService *AuditService
+	singleUse    *SingleUseService
+	cfg          config.ChatConfig
+	callbackKey  []byte
+	publicURL    string
+	client       *http.Client
+	handlers     map[string]ApprovalHandler
+	mutex        sync.RWMutex
+}
+
+func NewChatService(db *gorm.DB, auditService *AuditService, singleUse *SingleUseService, cfg config.ChatConfig, callbackKey []byte, publicURL string) *ChatService {
+	return &ChatService{
+		db:           db,
+		auditService: auditService,
+		singleUse:    singleUse,
+		cfg:          cfg,
+		callbackKey:  callbackKey,
+		publicURL:    strings.TrimRight(publicURL, "/"),
+		client:       &http.Client{Timeout: 10 * time.Second},
+		handlers:     make(map[string]ApprovalHandler),
+	}
+}
+
+// ApprovalRequestedEvent builds the event that asks chat channels to
+// approve or deny request requestID of the given kind. Kinds must have a
+// handler registered with RegisterApprovalHandler.
+func ApprovalRequestedEvent(kind, requestID, summary, requester string) *model.Event {
+	return model.NewEvent(model.EventApprovalRequested, map[string]interface{}{
+		"kind":       kind,
+		"request_id": requestID,
+		"summary":    summary,
+		"requester":  requester,
+	})
+}
+
+func (s *ChatService) RegisterApprovalHandler(kind string, handler ApprovalHandler) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.handlers[kind] = handler
+}
+
+func (s *ChatService) CreateChannel(req *model.CreateChatChannelRequest, userID uuid.UUID) (*model.ChatChannel, error) {
+	if err := validateChatWebhookURL(req.WebhookURL); err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := s.db.Model(&model.ChatChannel{}).Where("name = ?", req.Name).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("failed to check chat channel: %w", err)
+	}
+	if count > 0 {
+		return nil, ErrChatChannelExists
+	}
+
+	channel := &model.ChatChannel{
+		Name:       req.Name,
+		Provider:   req.Provider,
+		WebhookURL: req.WebhookURL,
+		Enabled:    true,
+		CreatedBy:  userID,
+	}
+	for i := range req.Routes {
+		route, err := newChatRoute(&req.Routes[i])
+		if err != nil {
+			return nil, err
+		}
+		channel.Routes = append(channel.Routes, *route)
+	}
+
+	if err := s.db.Create(channel).Error; err != nil {
+		return nil, fmt.Errorf("failed to create chat channel: %w", err)
+	}
+	channel.WebhookHost = webhookHost(channel.WebhookURL)
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "chat_channel_created", "chat_channel", channel.ID.String(), true, fmt.Sprintf("provider=%s", channel.Provider))
+	}
+
+	return channel, nil
+}
+
+func (s *ChatService) GetChannels() ([]model.ChatChannel, error) {
+	var channels []model.ChatChannel
+	if err := s.db.Preload("Routes").Order("name").Find(&channels).Error; err != nil {
+		return nil, fmt.Errorf("failed to get chat channels: %w", err)
+	}
+
+	for i := range channels {
+		channels[i].WebhookHost = webhookHost(channels[i].WebhookURL)
+	}
+
+	return channels, nil
+}
+
+func (s *ChatService) GetChannel(id uuid.UUID) (*model.ChatChannel, error) {
+	var channel model.ChatChannel
+	if err := s.db.Preload("Routes").First(&channel, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrChatChannelNotFound
+		}
+		return nil, fmt.Errorf("failed to get chat channel: %w", err)
+	}
+	channel.WebhookHost = webhookHost(channel.WebhookURL)
+
+	return &channel, nil
+}
+
+func (s *ChatService) DeleteChannel(id uuid.UUID, userID uuid.UUID) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&model.ChatChannel{}, "id = ?", id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrChatChannelNotFound
+		}
+		return tx.Delete(&model.ChatRoute{}, "channel_id = ?", id).Error
+	})
+	if err != nil {
+		if errors.Is(err, ErrChatChannelNotFound) {
+			return err
+		}
+		return fmt.Errorf("failed to delete chat channel: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "chat_channel_deleted", "chat_channel", id.String(), true, "")
+	}
+
+	return nil
+}
+
+func (s *ChatService) AddRoute(channelID uuid.UUID, req *model.ChatRouteRequest, userID uuid.UUID) (*model.ChatRoute, error) {
+	if _, err := s.GetChannel(channelID); err != nil {
+		return nil, err
+	}
+
+	route, err := newChatRoute(req)
+	if err != nil {
+		return nil, err
+	}
+	route.ChannelID = channelID
+
+	if err := s.db.Create(route).Error; err != nil {
+		return nil, fmt.Errorf("failed to create chat route: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "chat_route_added", "chat_channel", channelID.String(), true, fmt.Sprintf("pattern=%s min_severity=%s", route.EventPattern, route.MinSeverity))
+	}
+
+	return route, nil
+}
+
+func (s *ChatService) DeleteRoute(channelID, routeID uuid.UUID, userID uuid.UUID) error {
+	result := s.db.Delete(&model.ChatRoute{}, "id = ? AND channel_id = ?", routeID, channelID)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete chat route: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrChatRouteNotFound
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "chat_route_deleted", "chat_channel", channelID.String(), true, routeID.String())
+	}
+
+	return nil
+}
+
+// TestChannel posts a test message to the channel regardless of its routes
+func (s *ChatService) TestChannel(id uuid.UUID) error {
+	channel, err := s.GetChannel(id)
+	if err != nil {
+		return err
+	}
+
+	return s.post(channel, model.NewEvent("vault.test", map[string]interface{}{
+		"summary": "Aether Vault can post to this channel",
+	}))
+}
+
+// HandleEvent is the NotificationService sink: it posts the event to every
+// enabled channel with a matching route
+func (s *ChatService) HandleEvent(event *model.Event) error {
+	var channels []model.ChatChannel
+	if err := s.db.Preload("Routes").Where("enabled = ?", true).Find(&channels).Error; err != nil {
+		return fmt.Errorf("failed to get chat channels: %w", err)
+	}
+
+	var errs []error
+	for i := range channels {
+		if !chatRoutesMatch(channels[i].Routes, event) {
+			continue
+		}
+		if err := s.post(&channels[i], event); err != nil {
+			errs = append(errs, fmt.Errorf("chat channel %s: %w", channels[i].Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// PeekDecision verifies a callback token without acting on it
+func (s *ChatService) PeekDecision(token string) (*model.ChatDecision, error) {
+	claims, err := s.verifyToken(token)
+	if err != nil {
+		return nil, err
+	}
+	used, err := s.singleUse.Consumed(model.SingleUsePurposeChatApproval, claims.decisionKey())
+	if err != nil {
+		return nil, err
+	}
+	if used {
+		return nil, ErrChatCallbackUsed
+	}
+
+	return &model.ChatDecision{Kind: claims.Kind, RequestID: claims.RequestID, Approved: claims.Approve}, nil
+}
+
+// Decide verifies a callback token and hands the decision to the handler
+// registered for its kind. A request is decided once: the approve and deny
+// buttons of every channel share one single-use entry, released again when
+// the handler fails.
+func (s *ChatService) Decide(token, actor, ipAddress, userAgent string) (*model.ChatDecision, error) {
+	claims, err := s.verifyToken(token)
+	if err != nil {
+		if s.auditService != nil {
+			s.auditService.LogAnonymousAction("chat_approval_rejected", "approval", "", ipAddress, userAgent, false, err.Error())
+		}
+		return nil, err
+	}
+
+	s.mutex.RLock()
+	handler, ok := s.handlers[claims.Kind]
+	s.mutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrChatApprovalUnknownKind, claims.Kind)
+	}
+
+	decision := &model.ChatDecision{Kind: claims.Kind, RequestID: claims.RequestID, Approved: claims.Approve, Actor: actor}
+	err = s.singleUse.Consume(model.SingleUsePurposeChatApproval, claims.decisionKey(), time.Unix(claims.ExpiresAt, 0), actor, func(tx *gorm.DB) error {
+		return handler(claims.RequestID, claims.Approve, actor)
+	})
+	if errors.Is(err, ErrTokenConsumed) {
+		err = ErrChatCallbackUsed
+	}
+
+	if s.auditService != nil {
+		details := fmt.Sprintf("approved=%t actor=%s channel=%s", claims.Approve, actor, claims.ChannelID)
+		if err != nil {
+			details += " error=" + err.Error()
+		}
+		s.auditService.LogAnonymousAction("chat_approval_decided", "approval", claims.Kind+"/"+claims.RequestID, ipAddress, userAgent, err == nil, details)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return decision, nil
+}
+
+// SlackInteractive reports whether Slack buttons are handled through the
+// interactivity endpoint rather than by opening the callback page
+func (s *ChatService) SlackInteractive() bool {
+	return s.cfg.SlackSigningSecret != ""
+}
+
+// VerifySlackRequest checks X-Slack-Signature over the raw request body
+func (s *ChatService) VerifySlackRequest(timestamp, signature string, body []byte) error {
+	if !s.SlackInteractive() {
+		return ErrChatSlackDisabled
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrChatSignatureInvalid
+	}
+	if skew := time.Since(time.Unix(seconds, 0)); skew > slackRequestMaxSkew || skew < -slackRequestMaxSkew {
+		return ErrChatSignatureInvalid
+	}
+
+	mac := hmac.New(sha256.New, []byte(s.cfg.SlackSigningSecret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrChatSignatureInvalid
+	}
+
+	return nil
+}
+
+// RespondSlack replaces the original Slack message through its
+// response_url, which must point at Slack
+func (s *ChatService) RespondSlack(responseURL, text string) error {
+	parsed, err := url.Parse(responseURL)
+	if err != nil || parsed.Scheme != "https" || (parsed.Hostname() != "slack.com" && !strings.HasSuffix(parsed.Hostname(), ".slack.com")) {
+		return fmt.Errorf("%w: response_url is not a Slack URL", ErrChatSignatureInvalid)
+	}
+
+	return s.postJSON(responseURL, map[string]interface{}{
+		"replace_original": true,
+		"text":             text,
+	})
+}
+
+func (s *ChatService) post(channel *model.ChatChannel, event *model.Event) error {
+	var actions []chatAction
+	if event.Type == model.EventApprovalRequested {
+		var err error
+		if actions, err = s.approvalActions(channel, event); err != nil {
+			return err
+		}
+	}
+
+	var payload interface{}
+	switch channel.Provider {
+	case model.ChatProviderSlack:
+		payload = slackMessage(event, actions, s.SlackInteractive())
+	case model.ChatProviderTeams:
+		payload = teamsMessage(event, actions)
+	default:
+		return fmt.Errorf("%w: %s", ErrChatProviderInvalid, channel.Provider)
+	}
+
+	return s.postJSON(channel.WebhookURL, payload)
+}
+
+func (s *ChatService) postJSON(target string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode chat message: %w", err)
+	}
+
+	resp, err := s.client.Post(target, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNotificationDelivery, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%w: chat webhook returned %s", ErrNotificationDelivery, resp.Status)
+	}
+
+	return nil
+}
+
+type chatAction struct {
+	label   string
+	approve bool
+	token   string
+	url     string
+}
+
+func (s *ChatService) approvalActions(channel *model.ChatChannel, event *model.Event) ([]chatAction, error) {
+	kind, _ := event.Data["kind"].(string)
+	requestID, _ := event.Data["request_id"].(string)
+	if kind == "" || requestID == "" {
+		return nil, nil
+	}
+	if s.publicURL == "" {
+		return nil, ErrChatPublicURLRequired
+	}
+
+	expiresAt := time.Now().Add(time.Duration(s.cfg.CallbackTTL) * time.Second).Unix()
+	var actions []chatAction
+	for _, approve := range []bool{true, false} {
+		token := s.signToken(&chatCallbackClaims{
+			Kind:      kind,
+			RequestID: requestID,
+			Approve:   approve,
+			ChannelID: channel.ID,
+			ExpiresAt: expiresAt,
+		})
+		label := "Deny"
+		if approve {
+			label = "Approve"
+		}
+		actions = append(actions, chatAction{
+			label:   label,
+			approve: approve,
+			token:   token,
+			url:     s.publicURL + chatCallbackPath + "?token=" + url.QueryEscape(token),
+		})
+	}
+
+	return actions, nil
+}
+
+// chatCallbackClaims is the signed content of an approve/deny button
+type chatCallbackClaims struct {
+	Kind      string    `json:"k"`
+	RequestID string    `json:"r"`
+	Approve   bool      `json:"a"`
+	ChannelID uuid.UUID `json:"c"`
+	ExpiresAt int64     `json:"e"`
+}
+
+// decisionKey identifies the request a button decides, whichever button and
+// channel it came from
+func (c *chatCallbackClaims) decisionKey() string {
+	return c.Kind + "/" + c.RequestID
+}
+
+func (s *ChatService) signToken(claims *chatCallbackClaims) string {
+	payload, _ := json.Marshal(claims)
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+
+	mac := hmac.New(sha256.New, s.callbackKey)
+	mac.Write([]byte(encoded))
+
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s *ChatService) verifyToken(token string) (*chatCallbackClaims, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrChatCallbackInvalid
+	}
+
+	mac := hmac.New(sha256.New, s.callbackKey)
+	mac.Write([]byte(encoded))
+	expected := base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return nil, ErrChatCallbackInvalid
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrChatCallbackInvalid
+	}
+
+	var claims chatCallbackClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrChatCallbackInvalid
+	}
+	if time.Now().Unix() > claims.ExpiresAt {
+		return nil, ErrChatCallbackExpired
+	}
+
+	return &claims, nil
+}
+
+func newChatRoute(req *model.ChatRouteRequest) (*model.ChatRoute, error) {
+	if !eventPattern.MatchString(req.EventPattern) {
+		return nil, fmt.Errorf("%w: event_pattern must be *, an event type or a prefix such as security.*", ErrChatRouteInvalid)
+	}
+
+	severity := req.MinSeverity
+	if severity == "" {
+		severity = model.EventSeverityInfo
+	}
+
+	return &model.ChatRoute{EventPattern: req.EventPattern, MinSeverity: severity}, nil
+}
+
+func chatRoutesMatch(routes []model.ChatRoute, event *model.Event) bool {
+	for _, route := range routes {
+		if eventMatches(route.EventPattern, route.MinSeverity, event) {
+			return true
+		}
+	}
+	return false
+}
+
+func validateChatWebhookURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "https" && parsed.Scheme != "http") {
+		return fmt.Errorf("%w: webhook_url must be an http(s) URL", ErrChatChannelInvalid)
+	}
+	return nil
+}
+
+func webhookHost(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return parsed.Host
+}
+
+// chatFacts lists the event data as sorted key/value pairs, leaving out the
+// fields already shown in the title
+func chatFacts(event *model.Event) [][2]string {
+	keys := make([]string, 0, len(event.Data))
+	for key := range event.Data {
+		if key == "summary" {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if len(keys) > chatMaxFacts {
+		keys = keys[:chatMaxFacts]
+	}
+
+	facts := make([][2]string, 0, len(keys))
+	for _, key := range keys {
+		facts = append(facts, [2]string{key, fmt.Sprintf("%v", event.Data[key])})
+	}
+	return facts
+}
+
+func slackEscape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+func slackMessage(event *model.Event, actions []chatAction, interactive bool) map[string]interface{} {
+	title := eventTitle(event)
+	blocks := []map[string]interface{}{
+		{
+			"type": "section",
+			"text": map[string]string{"type": "mrkdwn", "text": fmt.Sprintf("*[%s] %s*", strings.ToUpper(string(event.Severity)), slackEscape(title))},
+		},
+	}
+
+	if facts := chatFacts(event); len(facts) > 0 {
+		fields := make([]map[string]string, 0, len(facts))
+		for _, fact := range facts {
+			fields = append(fields, map[string]string{"type": "mrkdwn", "text": fmt.Sprintf("*%s*\n%s", slackEscape(fact[0]), slackEscape(fact[1]))})
+		}
+		blocks = append(blocks, map[string]interface{}{"type": "section", "fields": fields})
+	}
+
+	blocks = append(blocks, map[string]interface{}{
+		"type":     "context",
+		"elements": []map[string]string{{"type": "mrkdwn", "text": fmt.Sprintf("%s · %s", event.Type, event.Timestamp.Format(time.RFC3339))}},
+	})
+
+	if len(actions) > 0 {
+		elements := make([]map[string]interface{}, 0, len(actions))
+		for _, action := range actions {
+			button := map[string]interface{}{
+				"type":      "button",
+				"text":      map[string]string{"type": "plain_text", "text": action.label},
+				"action_id": "vault_deny",
+				"style":     "danger",
+			}
+			if action.approve {
+				button["action_id"] = "vault_approve"
+				button["style"] = "primary"
+			}
+			if interactive {
+				button["value"] = action.token
+			} else {
+				button["url"] = action.url
+			}
+			elements = append(elements, button)
+		}
+		blocks = append(blocks, map[string]interface{}{"type": "actions", "elements": elements})
+	}
+
+	return map[string]interface{}{"text": title, "blocks": blocks}
+}
+
+func teamsMessage(event *model.Event, actions []chatAction) map[string]interface{} {
+	color := "Default"
+	if event.Severity.Rank() > 0 {
+		color = "Attention"
+	}
+
+	body := []map[string]interface{}{
+		{"type": "TextBlock", "text": eventTitle(event), "weight": "Bolder", "size": "Medium", "wrap": true, "color": color},
+	}
+	if facts := chatFacts(event); len(facts) > 0 {
+		items := make([]map[string]string, 0, len(facts))
+		for _, fact := range facts {
+			items = append(items, map[string]string{"title": fact[0], "value": fact[1]})
+		}
+		body = append(body, map[string]interface{}{"type": "FactSet", "facts": items})
+	}
+	body = append(body, map[string]interface{}{
+		"type": "TextBlock", "text": fmt.Sprintf("%s · %s · %s", event.Severity, event.Type, event.Timestamp.Format(time.RFC3339)), "isSubtle": true, "size": "Small", "wrap": true,
+	})
+
+	card := map[string]interface{}{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"body":    body,
+	}
+	if len(actions) > 0 {
+		items := make([]map[string]string, 0, len(actions))
+		for _, action := range actions {
+			items = append(items, map[string]string{"type": "Action.OpenUrl", "title": action.label, "url": action.url})
+		}
+		card["actions"] = items
+	}
+
+	return map[string]interface{}{
+		"type": "message",
+		"attachments": []map[string]interface{}{
+			{"contentType": "application/vnd.microsoft.card.adaptive", "content": card},
+		},
+	}
+}
+
+var (
+	ErrChatChannelNotFound     = errors.New("chat channel not found")
+	ErrChatChannelExists       = errors.New("a chat channel with this name already exists")
+	ErrChatChannelInvalid      = errors.New("invalid chat channel")
+	ErrChatProviderInvalid     = errors.New("unsupported chat provider")
+	ErrChatRouteNotFound       = errors.New("chat route not found")
+	ErrChatRouteInvalid        = errors.New("invalid chat route")
+	ErrChatCallbackInvalid     = errors.New("invalid approval callback")
+	ErrChatCallbackExpired     = errors.New("approval callback has expired")
+	ErrChatCallbackUsed        = errors.New("approval request has already been decided")
+	ErrChatApprovalUnknownKind = errors.New("no handler for approval kind")
+	ErrChatPublicURLRequired   = errors.New("server.public_url is required for chat approvals")
+	ErrChatSlackDisabled       = errors.New("slack interactivity is not configured")
+	ErrChatSignatureInvalid    = errors.New("invalid slack request signature")
+)
@@ -0,0 +1,343 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// ControlGroupPath prefixes the poll path of control group requests
+	ControlGroupPath = "/api/v1/control-groups/"
+
+	defaultControlGroupTTL   = 3600
+	maxControlGroupTTL       = 7 * 86400
+	maxControlGroupApprovals = 16
+)
+
+// ControlGroupService parks secret reads that policy rules put under a
+// control group until enough members of the rule's team approve them.
+// Approvers are the team's direct members other than the requester.
+type ControlGroupService struct {
+	db            *gorm.DB
+	policyService *PolicyService
+	auditService  *AuditService
+	notifications *NotificationService
+}
+
+// ControlGroupPendingError is returned for a read that waits on approval
+type ControlGroupPendingError struct {
+	Requirement model.ControlGroupRequirement
+}
+
+func (e *ControlGroupPendingError) Error() string {
+	return fmt.Sprintf("%s: %d of %d approvals", ErrControlGroupPending, e.Requirement.Approvals, e.Requirement.Required)
+}
+
+func (e *ControlGroupPendingError) Unwrap() error {
+	return ErrControlGroupPending
+}
+
+func NewControlGroupService(db *gorm.DB, policyService *PolicyService, auditService *AuditService, notifications *NotificationService) *ControlGroupService {
+	return &ControlGroupService{
+		db:            db,
+		policyService: policyService,
+		auditService:  auditService,
+		notifications: notifications,
+	}
+}
+
+// UseControlGroups makes value reads wait for the approvals the reader's
+// policy rules require
+func (s *SecretService) UseControlGroups(controlGroups *ControlGroupService) {
+	s.controlGroups = controlGroups
+}
+
+// requireControlGroup checks the approval requirement of reading secret
+func (s *SecretService) requireControlGroup(secret *model.Secret, userID uuid.UUID) error {
+	if s.controlGroups == nil {
+		return nil
+	}
+	return s.controlGroups.Require(userID, secret)
+}
+
+// Require lets userID read secret if their policy rules put no control
+// group on it or an approved request of theirs covers it. Otherwise it
+// parks the read in a request, opening one and notifying the approvers
+// unless one is pending, and returns a *ControlGroupPendingError.
+func (s *ControlGroupService) Require(userID uuid.UUID, secret *model.Secret) error {
+	rule, err := s.policyService.ControlGroup(userID, SecretPolicyPath(secret), "read", SplitTags(secret.Tags))
+	if err != nil {
+		return fmt.Errorf("failed to check policies: %w", err)
+	}
+	if rule == nil {
+		return nil
+	}
+
+	// a request made under a stricter rule still counts
+	var request model.ControlGroupRequest
+	err = s.db.Preload("Approvals").
+		Where("requester_id = ? AND secret_id = ? AND team_id = ? AND required >= ? AND status IN ? AND expires_at > ?",
+			userID, secret.ID, rule.TeamID, rule.Approvals, []string{model.ControlGroupStatusPending, model.ControlGroupStatusApproved}, time.Now()).
+		Order("created_at DESC").First(&request).Error
+	switch {
+	case err == nil && request.Status == model.ControlGroupStatusApproved:
+		return nil
+	case err == nil:
+		return &ControlGroupPendingError{Requirement: requirement(&request)}
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return fmt.Errorf("failed to get control group request: %w", err)
+	}
+
+	request = model.ControlGroupRequest{
+		RequesterID: userID,
+		SecretID:    secret.ID,
+		TeamID:      rule.TeamID,
+		Required:    rule.Approvals,
+		TTL:         rule.TTL,
+		Status:      model.ControlGroupStatusPending,
+		ExpiresAt:   time.Now().Add(time.Duration(rule.TTL) * time.Second),
+	}
+	if err := s.db.Create(&request).Error; err != nil {
+		return fmt.Errorf("failed to create control group request: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "control_group_requested", "control_group", request.ID.String(), true, fmt.Sprintf("secret=%s; team=%s; required=%d", secret.ID, rule.TeamID, rule.Approvals))
+	}
+	s.announce(&request, secret)
+
+	return &ControlGroupPendingError{Requirement: requirement(&request)}
+}
+
+// Get returns a request to its requester or one of its approvers
+func (s *ControlGroupService) Get(id, userID uuid.UUID) (*model.ControlGroupRequest, error) {
+	request, err := s.request(s.db, id)
+	if err != nil {
+		return nil, err
+	}
+	if request.RequesterID != userID {
+		approver, err := s.isApprover(s.db, request, userID)
+		if err != nil {
+			return nil, err
+		}
+		if !approver {
+			return nil, ErrControlGroupRequestNotFound
+		}
+	}
+	return reportExpiry(request), nil
+}
+
+// GetMine lists the caller's own requests, newest first
+func (s *ControlGroupService) GetMine(userID uuid.UUID) ([]model.ControlGroupRequest, error) {
+	requests := []model.ControlGroupRequest{}
+	if err := s.db.Preload("Approvals").Where("requester_id = ?", userID).Order("created_at DESC").Find(&requests).Error; err != nil {
+		return nil, fmt.Errorf("failed to get control group requests: %w", err)
+	}
+	for i := range requests {
+		reportExpiry(&requests[i])
+	}
+	return requests, nil
+}
+
+// GetPending lists the pending requests waiting on the caller's approval,
+// oldest first
+func (s *ControlGroupService) GetPending(userID uuid.UUID) ([]model.ControlGroupRequest, error) {
+	teams := s.db.Model(&model.OrgMembership{}).Select("scope_id").Where("scope = ? AND user_id = ?", model.OrgScopeTeam, userID)
+	approved := s.db.Model(&model.ControlGroupApproval{}).Select("request_id").Where("approver_id = ?", userID)
+
+	requests := []model.ControlGroupRequest{}
+	err := s.db.Preload("Approvals").
+		Where("team_id IN (?) AND requester_id <> ? AND status = ? AND expires_at > ? AND id NOT IN (?)",
+			teams, userID, model.ControlGroupStatusPending, time.Now(), approved).
+		Order("created_at ASC").Find(&requests).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get control group requests: %w", err)
+	}
+	return requests, nil
+}
+
+// Approve records the approval of approverID, approving the request once
+// it has as many as it requires. The approval then lasts the request's
+// TTL.
+func (s *ControlGroupService) Approve(id, approverID uuid.UUID) (*model.ControlGroupRequest, error) {
+	var request *model.ControlGroupRequest
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if request, err = s.decidable(tx, id, approverID); err != nil {
+			return err
+		}
+		for _, approval := range request.Approvals {
+			if approval.ApproverID == approverID {
+				return ErrControlGroupAlreadyApproved
+			}
+		}
+
+		approval := model.ControlGroupApproval{RequestID: request.ID, ApproverID: approverID}
+		if err := tx.Create(&approval).Error; err != nil {
+			return fmt.Errorf("failed to record approval: %w", err)
+		}
+		request.Approvals = append(request.Approvals, approval)
+
+		if len(request.Approvals) < request.Required {
+			return nil
+		}
+		now := time.Now()
+		request.Status = model.ControlGroupStatusApproved
+		request.DecidedAt = &now
+		request.DecidedBy = &approverID
+		request.ExpiresAt = now.Add(time.Duration(request.TTL) * time.Second)
+		return tx.Model(request).Select("status", "decided_at", "decided_by", "expires_at").Updates(request).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(approverID, "control_group_approved", "control_group", id.String(), true, fmt.Sprintf("approvals=%d/%d; status=%s", len(request.Approvals), request.Required, request.Status))
+	}
+	return request, nil
+}
+
+// Deny rejects the request on behalf of approverID; the requester has to
+// read again to open a new one
+func (s *ControlGroupService) Deny(id, approverID uuid.UUID) (*model.ControlGroupRequest, error) {
+	var request *model.ControlGroupRequest
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if request, err = s.decidable(tx, id, approverID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		request.Status = model.ControlGroupStatusDenied
+		request.DecidedAt = &now
+		request.DecidedBy = &approverID
+		return tx.Model(request).Select("status", "decided_at", "decided_by").Updates(request).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(approverID, "control_group_denied", "control_group", id.String(), true, fmt.Sprintf("requester=%s", request.RequesterID))
+	}
+	return request, nil
+}
+
+// decidable locks a pending request for approverID to decide on
+func (s *ControlGroupService) decidable(tx *gorm.DB, id, approverID uuid.UUID) (*model.ControlGroupRequest, error) {
+	var request model.ControlGroupRequest
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&request).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrControlGroupRequestNotFound
+		}
+		return nil, fmt.Errorf("failed to get control group request: %w", err)
+	}
+	if err := tx.Where("request_id = ?", id).Find(&request.Approvals).Error; err != nil {
+		return nil, fmt.Errorf("failed to get approvals: %w", err)
+	}
+
+	if request.RequesterID == approverID {
+		return nil, ErrControlGroupSelfApproval
+	}
+	approver, err := s.isApprover(tx, &request, approverID)
+	if err != nil {
+		return nil, err
+	}
+	if !approver {
+		return nil, ErrControlGroupRequestNotFound
+	}
+	if reportExpiry(&request).Status != model.ControlGroupStatusPending {
+		return nil, fmt.Errorf("%w: request is %s", ErrControlGroupNotPending, request.Status)
+	}
+	return &request, nil
+}
+
+func (s *ControlGroupService) request(tx *gorm.DB, id uuid.UUID) (*model.ControlGroupRequest, error) {
+	var request model.ControlGroupRequest
+	if err := tx.Preload("Approvals").Where("id = ?", id).First(&request).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrControlGroupRequestNotFound
+		}
+		return nil, fmt.Errorf("failed to get control group request: %w", err)
+	}
+	return &request, nil
+}
+
+func (s *ControlGroupService) isApprover(tx *gorm.DB, request *model.ControlGroupRequest, userID uuid.UUID) (bool, error) {
+	if userID == request.RequesterID {
+		return false, nil
+	}
+	var count int64
+	if err := tx.Model(&model.OrgMembership{}).Where("scope = ? AND scope_id = ? AND user_id = ?", model.OrgScopeTeam, request.TeamID, userID).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check team membership: %w", err)
+	}
+	return count > 0, nil
+}
+
+// announce emails the approvers of a new request and publishes it as a
+// control_group.requested event
+func (s *ControlGroupService) announce(request *model.ControlGroupRequest, secret *model.Secret) {
+	if s.notifications == nil {
+		return
+	}
+
+	var approvers []uuid.UUID
+	if err := s.db.Model(&model.OrgMembership{}).Where("scope = ? AND scope_id = ? AND user_id <> ?", model.OrgScopeTeam, request.TeamID, request.RequesterID).Pluck("user_id", &approvers).Error; err != nil {
+		log.Printf("⚠️  Failed to get approvers of control group request %s: %v", request.ID, err)
+	}
+	var requester model.User
+	if err := s.db.Select("id", "email").Where("id = ?", request.RequesterID).First(&requester).Error; err != nil {
+		requester.Email = request.RequesterID.String()
+	}
+
+	summary := fmt.Sprintf("read secret %q (%d approvals required)", secret.Name, request.Required)
+	s.notifications.ApprovalRequested(approvers, request.ID.String(), summary, requester.Email)
+
+	go func() {
+		if err := s.notifications.Publish(model.NewEvent(model.EventControlGroupRequested, map[string]interface{}{
+			"request_id":   request.ID,
+			"requester_id": request.RequesterID,
+			"secret_id":    request.SecretID,
+			"team_id":      request.TeamID,
+			"required":     request.Required,
+			"expires_at":   request.ExpiresAt,
+		})); err != nil {
+			log.Printf("⚠️  Failed to publish control group request %s: %v", request.ID, err)
+		}
+	}()
+}
+
+// reportExpiry marks a request past its expiry as expired
+func reportExpiry(request *model.ControlGroupRequest) *model.ControlGroupRequest {
+	if request.Status != model.ControlGroupStatusDenied && !time.Now().Before(request.ExpiresAt) {
+		request.Status = model.ControlGroupStatusExpired
+	}
+	return request
+}
+
+func requirement(request *model.ControlGroupRequest) model.ControlGroupRequirement {
+	return model.ControlGroupRequirement{
+		RequestID: request.ID,
+		Status:    request.Status,
+		Approvals: len(request.Approvals),
+		Required:  request.Required,
+		ExpiresAt: request.ExpiresAt,
+		PollPath:  ControlGroupPath + request.ID.String(),
+	}
+}
+
+var (
+	ErrControlGroupPending         = errors.New("the read waits for control group approval")
+	ErrControlGroupRequestNotFound = errors.New("control group request not found")
+	ErrControlGroupNotPending      = errors.New("control group request is no longer pending")
+	ErrControlGroupSelfApproval    = errors.New("requesters cannot approve their own request")
+	ErrControlGroupAlreadyApproved = errors.New("already approved by this user")
+)
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// controlGroupFixture stores a secret under a policy whose reads need two
+// approvals from a team, with no request made yet
+func controlGroupFixture(t *testing.T) *secretFixture {
+	t.Helper()
+	rules := fmt.Sprintf(`[{"effect":"allow","resources":["secrets/*"],"actions":["read"],"control_group":{"team_id":%q,"approvals":2,"ttl":3600}}]`, uuid.NewString())
+	f := newSecretFixture(t, rules)
+	f.secrets.UseControlGroups(NewControlGroupService(f.secrets.db, f.policies, nil, nil))
+	return f
+}
+
+func TestValueReadsWaitForControlGroup(t *testing.T) {
+	for name, read := range valueReads(nil) {
+		t.Run(name, func(t *testing.T) {
+			err := read(controlGroupFixture(t))
+			var pending *ControlGroupPendingError
+			if !errors.As(err, &pending) {
+				t.Fatalf("got error %v, want a *ControlGroupPendingError", err)
+			}
+			if pending.Requirement.Required != 2 {
+				t.Errorf("got %d required approvals, want 2", pending.Requirement.Required)
+			}
+		})
+	}
+}
@@ -0,0 +1,424 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// dataKeyPrefix starts every value sealed under a data key, followed by
+	// the key version: aev-dek:3:<base64>. Values without it were sealed
+	// directly with the master key.
+	dataKeyPrefix = "aev-dek:"
+
+	// dataKeyRefresh bounds how long a rotation on another instance takes
+	// to reach new writes here
+	dataKeyRefresh = 30 * time.Second
+
+	dataKeyBatchSize = 100
+)
+
+// dataKeyRing holds the unwrapped data keys by version
+type dataKeyRing struct {
+	mu       sync.RWMutex
+	keys     map[int]dataKey
+	current  int
+	loadedAt time.Time
+}
+
+type dataKey struct {
+	id  uuid.UUID
+	key []byte
+}
+
+type dataKeyVersion struct {
+	dataKey
+	version int
+}
+
+// reencryptTarget is a column of values sealed by encrypt. Tables with a
+// wrapped key hold values sealed under tenant keys too; those are skipped.
+type reencryptTarget struct {
+	name    string
+	model   interface{}
+	column  string
+	wrapped bool
+}
+
+// EnableDataKeys makes the master key wrap data keys instead of sealing
+// values itself, creating the first data key on a fresh database. Values
+// sealed by the master key stay readable until the re-encryption job
+// moves them.
+func (s *SecretService) EnableDataKeys() error {
+	s.dataKeys = &dataKeyRing{}
+	if err := s.loadDataKeys(); err != nil {
+		s.dataKeys = nil
+		return err
+	}
+	if s.currentDataKey() != nil {
+		return nil
+	}
+
+	// Another instance starting at the same time may create it first
+	_, createErr := s.createDataKey(nil)
+	if err := s.loadDataKeys(); err != nil {
+		s.dataKeys = nil
+		return err
+	}
+	if s.currentDataKey() == nil {
+		s.dataKeys = nil
+		return createErr
+	}
+	return nil
+}
+
+// GetDataKeys lists the data keys, newest first
+func (s *SecretService) GetDataKeys() ([]model.DataKey, error) {
+	keys := []model.DataKey{}
+	if err := s.db.Order("version DESC").Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to get data keys: %w", err)
+	}
+	return keys, nil
+}
+
+// RotateDataKey creates a data key for new values and retires the current
+// one, which keeps decrypting until the re-encryption job has moved every
+// value off it
+func (s *SecretService) RotateDataKey(actorID uuid.UUID) (*model.DataKey, error) {
+	if s.dataKeys == nil {
+		return nil, ErrDataKeysDisabled
+	}
+
+	key, err := s.createDataKey(&actorID)
+	if err != nil {
+		if s.auditService != nil {
+			s.auditService.LogAction(actorID, "data_key_rotated", "data_key", "", false, err.Error())
+		}
+		return nil, err
+	}
+	if err := s.loadDataKeys(); err != nil {
+		return nil, err
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(actorID, "data_key_rotated", "data_key", key.ID.String(), true, fmt.Sprintf("version=%d", key.Version))
+	}
+	return key, nil
+}
+
+// ReencryptJob moves values sealed under retired data keys or the bare
+// master key to the current data key
+func (s *SecretService) ReencryptJob(interval time.Duration) *Job {
+	return &Job{
+		Name:        "data_key_reencrypt",
+		Description: "Re-encrypt values under retired data keys with the current one",
+		Interval:    interval,
+		Disabled:    s.dataKeys == nil,
+		Run:         s.reencrypt,
+	}
+}
+
+// createDataKey stores a new data key as the active one, retiring the
+// previous one in the same transaction
+func (s *SecretService) createDataKey(actorID *uuid.UUID) (*model.DataKey, error) {
+	material := make([]byte, 32)
+	if _, err := io.ReadFull(keyReader, material); err != nil {
+		return nil, err
+	}
+
+	key := &model.DataKey{ID: uuid.New(), Status: model.DataKeyStatusActive, CreatedBy: actorID}
+	wrapped, err := sealWithDataKey(s.cryptoKey, key.ID, material)
+	if err != nil {
+		return nil, err
+	}
+	key.WrappedKey = wrapped
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Locking the active key serializes concurrent rotations
+		var active []model.DataKey
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("status = ?", model.DataKeyStatusActive).Find(&active).Error; err != nil {
+			return err
+		}
+
+		var latest int
+		if err := tx.Model(&model.DataKey{}).Select("COALESCE(MAX(version), 0)").Scan(&latest).Error; err != nil {
+			return err
+		}
+		key.Version = latest + 1
+
+		if err := tx.Model(&model.DataKey{}).Where("status = ?", model.DataKeyStatusActive).
+			Updates(map[string]interface{}{"status": model.DataKeyStatusRetired, "retired_at": time.Now()}).Error; err != nil {
+			return err
+		}
+		return tx.Create(key).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create data key: %w", err)
+	}
+	return key, nil
+}
+
+// loadDataKeys unwraps every data key. A key the master key cannot unwrap
+// means a wrong encryption_key, so loading fails rather than skipping it.
+func (s *SecretService) loadDataKeys() error {
+	var rows []model.DataKey
+	if err := s.db.Order("version").Find(&rows).Error; err != nil {
+		return fmt.Errorf("failed to load data keys: %w", err)
+	}
+
+	keys := make(map[int]dataKey, len(rows))
+	current := 0
+	for _, row := range rows {
+		material, err := openWithDataKey(s.cryptoKey, row.ID, row.WrappedKey)
+		if err != nil {
+			return fmt.Errorf("failed to unwrap data key %d: %w", row.Version, err)
+		}
+		keys[row.Version] = dataKey{id: row.ID, key: material}
+		if row.Status == model.DataKeyStatusActive {
+			current = row.Version
+		}
+	}
+
+	s.dataKeys.mu.Lock()
+	defer s.dataKeys.mu.Unlock()
+	s.dataKeys.keys = keys
+	s.dataKeys.current = current
+	s.dataKeys.loadedAt = time.Now()
+	return nil
+}
+
+// currentDataKey returns the key new values are sealed with and its
+// version, reloading the ring when it may miss a rotation
+func (s *SecretService) currentDataKey() *dataKeyVersion {
+	s.dataKeys.mu.RLock()
+	stale := time.Since(s.dataKeys.loadedAt) >= dataKeyRefresh
+	s.dataKeys.mu.RUnlock()
+	if stale {
+		if err := s.loadDataKeys(); err != nil {
+			log.Printf("⚠️  Failed to refresh data keys: %v", err)
+		}
+	}
+
+	s.dataKeys.mu.RLock()
+	defer s.dataKeys.mu.RUnlock()
+	key, ok := s.dataKeys.keys[s.dataKeys.current]
+	if !ok {
+		return nil
+	}
+	return &dataKeyVersion{dataKey: key, version: s.dataKeys.current}
+}
+
+// sealDataKey encrypts plaintext under the current data key
+func (s *SecretService) sealDataKey(plaintext string) (string, error) {
+	current := s.currentDataKey()
+	if current == nil {
+		return "", ErrDataKeyNotFound
+	}
+	ciphertext, err := sealWithDataKey(current.key, current.id, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	return dataKeyPrefix + strconv.Itoa(current.version) + ":" + ciphertext, nil
+}
+
+// openDataKey decrypts a value sealed by sealDataKey, reloading the ring
+// once for versions created on another instance
+func (s *SecretService) openDataKey(value string) (string, error) {
+	versionPart, ciphertext, found := strings.Cut(strings.TrimPrefix(value, dataKeyPrefix), ":")
+	version, err := strconv.Atoi(versionPart)
+	if !found || err != nil {
+		return "", fmt.Errorf("%w: malformed data key reference", ErrSecretTampered)
+	}
+	if s.dataKeys == nil {
+		return "", ErrDataKeyNotFound
+	}
+
+	s.dataKeys.mu.RLock()
+	key, ok := s.dataKeys.keys[version]
+	s.dataKeys.mu.RUnlock()
+	if !ok {
+		if err := s.loadDataKeys(); err != nil {
+			return "", err
+		}
+		s.dataKeys.mu.RLock()
+		key, ok = s.dataKeys.keys[version]
+		s.dataKeys.mu.RUnlock()
+		if !ok {
+			return "", ErrDataKeyNotFound
+		}
+	}
+
+	plaintext, err := openWithDataKey(key.key, key.id, ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// reencrypt walks every column of server-sealed values and re-seals the
+// ones not under the current data key. Each row is only replaced if it
+// still holds the value read, so concurrent writes win.
+func (s *SecretService) reencrypt(ctx context.Context) (int64, string, error) {
+	current := s.currentDataKey()
+	if current == nil {
+		return 0, "", ErrDataKeyNotFound
+	}
+	prefix := dataKeyPrefix + strconv.Itoa(current.version) + ":"
+
+	moved, failed, err := s.reencryptSecrets(ctx, prefix)
+	if err != nil {
+		return moved, "", err
+	}
+
+	targets := []reencryptTarget{
+		{name: "secret version", model: &model.SecretVersion{}, column: "value", wrapped: true},
+		{name: "secret publication", model: &model.SecretPublication{}, column: "value", wrapped: true},
+		{name: "secret validation", model: &model.SecretValidation{}, column: "value", wrapped: true},
+		{name: "TOTP seed", model: &model.TOTP{}, column: "secret"},
+		{name: "OIDC signing key", model: &model.OIDCKey{}, column: "private_key"},
+		{name: "PKI issuer key", model: &model.PKIIssuer{}, column: "private_key"},
+		{name: "SSH CA key", model: &model.SSHCAKey{}, column: "private_key"},
+	}
+	for _, target := range targets {
+		done, skipped, err := s.reencryptColumn(ctx, target, prefix)
+		moved += done
+		failed += skipped
+		if err != nil {
+			return moved, "", err
+		}
+	}
+
+	summary := fmt.Sprintf("re-encrypted %d values with data key %d", moved, current.version)
+	if failed > 0 {
+		summary += fmt.Sprintf("; %d could not be decrypted and were left as they are", failed)
+	}
+	return moved, summary, nil
+}
+
+// reencryptSecrets re-seals secret rows and their checksums. Quarantined
+// rows and rows failing their checksum are left for the scrub job.
+func (s *SecretService) reencryptSecrets(ctx context.Context, prefix string) (int64, int64, error) {
+	var moved, failed int64
+	var last string
+	for {
+		if err := ctx.Err(); err != nil {
+			return moved, failed, err
+		}
+
+		var batch []model.Secret
+		query := s.db.Unscoped().Where("(wrapped_key IS NULL OR wrapped_key = '') AND quarantined_at IS NULL AND value NOT LIKE ?", prefix+"%").
+			Order("id").Limit(dataKeyBatchSize)
+		if last != "" {
+			query = query.Where("id > ?", last)
+		}
+		if err := query.Find(&batch).Error; err != nil {
+			return moved, failed, fmt.Errorf("failed to read secrets: %w", err)
+		}
+
+		for i := range batch {
+			secret := &batch[i]
+			if s.integrity != nil && secret.Checksum != "" && !s.integrity.Verify(secret) {
+				failed++
+				continue
+			}
+			plaintext, err := s.decrypt(secret.Value)
+			if err != nil {
+				log.Printf("⚠️  Failed to re-encrypt secret %s: %v", secret.ID, err)
+				failed++
+				continue
+			}
+			sealed, err := s.encrypt(plaintext)
+			if err != nil {
+				return moved, failed, err
+			}
+
+			previous := secret.Value
+			secret.Value = sealed
+			updates := map[string]interface{}{"value": sealed}
+			if s.integrity != nil && secret.Checksum != "" {
+				updates["checksum"] = s.integrity.Sum(secret)
+			}
+			result := s.db.Unscoped().Model(&model.Secret{}).Where("id = ? AND value = ?", secret.ID, previous).UpdateColumns(updates)
+			if result.Error != nil {
+				return moved, failed, fmt.Errorf("failed to store re-encrypted secret: %w", result.Error)
+			}
+			moved += result.RowsAffected
+		}
+
+		if len(batch) < dataKeyBatchSize {
+			return moved, failed, nil
+		}
+		last = batch[len(batch)-1].ID.String()
+	}
+}
+
+// reencryptColumn re-seals one column of values that carry no checksum
+func (s *SecretService) reencryptColumn(ctx context.Context, target reencryptTarget, prefix string) (int64, int64, error) {
+	var moved, failed int64
+	var last string
+	for {
+		if err := ctx.Err(); err != nil {
+			return moved, failed, err
+		}
+
+		// OIDC key IDs are not UUIDs
+		var batch []struct {
+			ID    string
+			Value string
+		}
+		query := s.db.Unscoped().Model(target.model).Select("id, "+target.column+" AS value").
+			Where(target.column+" NOT LIKE ?", prefix+"%").Order("id").Limit(dataKeyBatchSize)
+		if target.wrapped {
+			query = query.Where("(wrapped_key IS NULL OR wrapped_key = '')")
+		}
+		if last != "" {
+			query = query.Where("id > ?", last)
+		}
+		if err := query.Scan(&batch).Error; err != nil {
+			return moved, failed, fmt.Errorf("failed to read %ss: %w", target.name, err)
+		}
+
+		for _, row := range batch {
+			plaintext, err := s.decrypt(row.Value)
+			if err != nil {
+				log.Printf("⚠️  Failed to re-encrypt %s %s: %v", target.name, row.ID, err)
+				failed++
+				continue
+			}
+			sealed, err := s.encrypt(plaintext)
+			if err != nil {
+				return moved, failed, err
+			}
+
+			result := s.db.Unscoped().Model(target.model).Where("id = ? AND "+target.column+" = ?", row.ID, row.Value).
+				UpdateColumn(target.column, sealed)
+			if result.Error != nil {
+				return moved, failed, fmt.Errorf("failed to store re-encrypted %s: %w", target.name, result.Error)
+			}
+			moved += result.RowsAffected
+		}
+
+		if len(batch) < dataKeyBatchSize {
+			return moved, failed, nil
+		}
+		last = batch[len(batch)-1].ID
+	}
+}
+
+var (
+	ErrDataKeyNotFound  = errors.New("data key not found")
+	ErrDataKeysDisabled = errors.New("data keys are not enabled")
+)
@@ -0,0 +1,385 @@
+package services
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/config"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+)
+
+// Email template names. Each template defines a "subject" and a "body".
+const (
+	EmailTemplateTest              = "test"
+	EmailTemplateReminder          = "reminder"
+	EmailTemplateSecurityAlert     = "security_alert"
+	EmailTemplateApprovalRequested = "approval_requested"
+	EmailTemplateAccessRequested   = "access_requested"
+	EmailTemplateUnusedSecrets     = "unused_secrets"
+)
+
+const (
+	smtpDialTimeout    = 10 * time.Second
+	smtpSessionTimeout = 30 * time.Second
+)
+
+var defaultEmailTemplates = map[string]string{
+	EmailTemplateTest: `{{define "subject"}}Test message{{end}}
+{{define "body"}}This is a test message from Aether Vault, sent at {{.Now}}.
+
+If you received it, email notifications are configured correctly.
+{{end}}`,
+
+	EmailTemplateReminder: `{{define "subject"}}{{.Summary}}{{end}}
+{{define "body"}}{{.Summary}}.
+
+Secret:   {{.SecretName}} ({{.SecretID}})
+Reminder: {{.Kind}}
+Due:      {{.DueAt}}
+
+Acknowledge or snooze this reminder with ID {{.ReminderID}}.
+{{end}}`,
+
+	EmailTemplateSecurityAlert: `{{define "subject"}}Security alert: {{.Event}}{{end}}
+{{define "body"}}Aether Vault detected the following on your account at {{.Now}}:
+
+  {{.Event}}
+{{if .Details}}
+{{.Details}}
+{{end}}
+If this was not you, change your password and review your recent activity.
+{{end}}`,
+
+	EmailTemplateApprovalRequested: `{{define "subject"}}Approval requested: {{.Summary}}{{end}}
+{{define "body"}}{{.Requester}} requested approval:
+
+  {{.Summary}}
+
+Request ID: {{.RequestID}}
+{{end}}`,
+
+	EmailTemplateAccessRequested: `{{define "subject"}}Access requested: {{.Resource}}{{end}}
+{{define "body"}}{{.Requester}} requested access to {{.Resource}}.
+{{if .Reason}}
+Reason: {{.Reason}}
+{{end}}
+Request ID: {{.RequestID}}
+{{end}}`,
+
+	EmailTemplateUnusedSecrets: `{{define "subject"}}{{len .Secrets}} secrets unused for {{.Days}} days{{end}}
+{{define "body"}}These secrets you own have not been read in the last {{.Days}} days:
+{{range .Secrets}}
+  {{.Name}} ({{.ID}}), last read {{if .LastAccessedAt}}{{.LastAccessedAt.UTC.Format "2006-01-02"}}{{else}}never{{end}}
+{{- end}}
+
+Delete the ones you no longer need.
+{{end}}`,
+}
+
+// EmailNotifier renders templated messages and sends them over SMTP,
+// honouring each user's notification preferences
+type EmailNotifier struct {
+	db        *gorm.DB
+	cfg       config.SMTPConfig
+	templates map[string]*template.Template
+}
+
+// NewEmailNotifier loads the built-in templates and any overrides from
+// cfg.TemplatesDir, where <name>.tmpl replaces the template called name
+func NewEmailNotifier(db *gorm.DB, cfg config.SMTPConfig) (*EmailNotifier, error) {
+	templates := make(map[string]*template.Template)
+	for name, text := range defaultEmailTemplates {
+		parsed, err := parseEmailTemplate(name, text)
+		if err != nil {
+			return nil, err
+		}
+		templates[name] = parsed
+	}
+
+	if cfg.TemplatesDir != "" {
+		files, err := filepath.Glob(filepath.Join(cfg.TemplatesDir, "*.tmpl"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list email templates: %w", err)
+		}
+		for _, file := range files {
+			text, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read email template: %w", err)
+			}
+			name := strings.TrimSuffix(filepath.Base(file), ".tmpl")
+			parsed, err := parseEmailTemplate(name, string(text))
+			if err != nil {
+				return nil, err
+			}
+			templates[name] = parsed
+		}
+	}
+
+	return &EmailNotifier{
+		db:        db,
+		cfg:       cfg,
+		templates: templates,
+	}, nil
+}
+
+func (n *EmailNotifier) Enabled() bool {
+	return n.cfg.Host != ""
+}
+
+// Send renders a template and delivers it to every recipient
+func (n *EmailNotifier) Send(to []string, templateName string, data map[string]interface{}) error {
+	if !n.Enabled() {
+		return ErrNotificationChannelDisabled
+	}
+	if len(to) == 0 {
+		return nil
+	}
+
+	subject, body, err := n.render(templateName, data)
+	if err != nil {
+		return err
+	}
+
+	message, err := n.compose(to, subject, body)
+	if err != nil {
+		return err
+	}
+
+	return n.deliver(to, message)
+}
+
+// NotifyUser emails a user unless they opted out of the category or email
+// is not configured. Users without an email address are skipped.
+func (n *EmailNotifier) NotifyUser(userID uuid.UUID, category model.NotificationCategory, templateName string, data map[string]interface{}) error {
+	if !n.Enabled() {
+		return nil
+	}
+
+	var user model.User
+	if err := n.db.Select("id", "email").First(&user, "id = ?", userID).Error; err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.Email == "" {
+		return nil
+	}
+
+	enabled, err := n.categoryEnabled(userID, category)
+	if err != nil || !enabled {
+		return err
+	}
+
+	return n.Send([]string{user.Email}, templateName, data)
+}
+
+// SendTest sends the test template to a single address
+func (n *EmailNotifier) SendTest(to string) error {
+	return n.Send([]string{to}, EmailTemplateTest, nil)
+}
+
+func (n *EmailNotifier) GetPreferences(userID uuid.UUID) (*model.NotificationPreferencesResponse, error) {
+	var preferences []model.NotificationPreference
+	if err := n.db.Where("user_id = ?", userID).Find(&preferences).Error; err != nil {
+		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+
+	response := &model.NotificationPreferencesResponse{Email: make(map[model.NotificationCategory]bool)}
+	for _, category := range model.NotificationCategories {
+		response.Email[category] = true
+	}
+	for _, preference := range preferences {
+		response.Email[preference.Category] = preference.Email
+	}
+
+	return response, nil
+}
+
+func (n *EmailNotifier) UpdatePreferences(userID uuid.UUID, req *model.UpdateNotificationPreferencesRequest) (*model.NotificationPreferencesResponse, error) {
+	for category := range req.Email {
+		if !validNotificationCategory(category) {
+			return nil, fmt.Errorf("%w: %s", ErrNotificationCategoryInvalid, category)
+		}
+	}
+
+	err := n.db.Transaction(func(tx *gorm.DB) error {
+		for category, enabled := range req.Email {
+			var preference model.NotificationPreference
+			err := tx.Where("user_id = ? AND category = ?", userID, category).First(&preference).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				preference = model.NotificationPreference{UserID: userID, Category: category, Email: enabled}
+				if err := tx.Create(&preference).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&preference).Update("email", enabled).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update notification preferences: %w", err)
+	}
+
+	return n.GetPreferences(userID)
+}
+
+func (n *EmailNotifier) categoryEnabled(userID uuid.UUID, category model.NotificationCategory) (bool, error) {
+	var preference model.NotificationPreference
+	err := n.db.Where("user_id = ? AND category = ?", userID, category).First(&preference).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get notification preference: %w", err)
+	}
+
+	return preference.Email, nil
+}
+
+func (n *EmailNotifier) render(templateName string, data map[string]interface{}) (string, string, error) {
+	tmpl, ok := n.templates[templateName]
+	if !ok {
+		return "", "", fmt.Errorf("%w: %s", ErrEmailTemplateNotFound, templateName)
+	}
+
+	values := map[string]interface{}{"Now": time.Now().UTC().Format(time.RFC1123)}
+	for key, value := range data {
+		values[key] = value
+	}
+
+	var subject, body bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&subject, "subject", values); err != nil {
+		return "", "", fmt.Errorf("failed to render email subject: %w", err)
+	}
+	if err := tmpl.ExecuteTemplate(&body, "body", values); err != nil {
+		return "", "", fmt.Errorf("failed to render email body: %w", err)
+	}
+
+	return "[Aether Vault] " + strings.TrimSpace(subject.String()), strings.TrimLeft(body.String(), "\n"), nil
+}
+
+func (n *EmailNotifier) compose(to []string, subject, body string) ([]byte, error) {
+	for _, value := range append([]string{subject}, to...) {
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, fmt.Errorf("%w: header contains a line break", ErrNotificationDelivery)
+		}
+	}
+
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", n.cfg.From)
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&message, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&message, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&message, "Message-ID: <%s@%s>\r\n", uuid.New(), n.cfg.Host)
+	message.WriteString("MIME-Version: 1.0\r\n")
+	message.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	message.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	return message.Bytes(), nil
+}
+
+// deliver runs one SMTP session. With security "starttls" the relay must
+// offer STARTTLS, "tls" connects with implicit TLS (usually port 465) and
+// "none" sends in clear text for local relays only.
+func (n *EmailNotifier) deliver(to []string, message []byte) error {
+	address := net.JoinHostPort(n.cfg.Host, strconv.Itoa(n.cfg.Port))
+	tlsConfig := &tls.Config{ServerName: n.cfg.Host, MinVersion: tls.VersionTLS12}
+	dialer := &net.Dialer{Timeout: smtpDialTimeout}
+
+	var conn net.Conn
+	var err error
+	if n.cfg.Security == config.SMTPSecurityTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", address)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNotificationDelivery, err)
+	}
+	conn.SetDeadline(time.Now().Add(smtpSessionTimeout))
+
+	client, err := smtp.NewClient(conn, n.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("%w: %v", ErrNotificationDelivery, err)
+	}
+	defer client.Close()
+
+	if n.cfg.Security == config.SMTPSecurityStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("%w: relay does not offer STARTTLS", ErrNotificationDelivery)
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("%w: %v", ErrNotificationDelivery, err)
+		}
+	}
+
+	if n.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", n.cfg.Username, n.cfg.Password, n.cfg.Host)); err != nil {
+			return fmt.Errorf("%w: %v", ErrNotificationDelivery, err)
+		}
+	}
+
+	if err := client.Mail(n.cfg.From); err != nil {
+		return fmt.Errorf("%w: %v", ErrNotificationDelivery, err)
+	}
+	for _, recipient := range to {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("%w: %v", ErrNotificationDelivery, err)
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNotificationDelivery, err)
+	}
+	if _, err := writer.Write(message); err != nil {
+		return fmt.Errorf("%w: %v", ErrNotificationDelivery, err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("%w: %v", ErrNotificationDelivery, err)
+	}
+
+	return client.Quit()
+}
+
+func parseEmailTemplate(name, text string) (*template.Template, error) {
+	parsed, err := template.New(name).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse email template %s: %w", name, err)
+	}
+	if parsed.Lookup("subject") == nil || parsed.Lookup("body") == nil {
+		return nil, fmt.Errorf("email template %s must define \"subject\" and \"body\"", name)
+	}
+	return parsed, nil
+}
+
+func validNotificationCategory(category model.NotificationCategory) bool {
+	for _, known := range model.NotificationCategories {
+		if known == category {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	ErrEmailTemplateNotFound       = errors.New("email template not found")
+	ErrNotificationCategoryInvalid = errors.New("unknown notification category")
+)
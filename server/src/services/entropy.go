@@ -0,0 +1,467 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-vault/server/src/config"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+)
+
+const (
+	// entropySampleSize is how much each source contributes per reseed
+	entropySampleSize = 32
+
+	// entropyMinDistinct is the fewest distinct byte values a sample may
+	// hold; random samples fall below it with negligible probability
+	entropyMinDistinct = 8
+
+	// entropyRetryInterval is how soon a failing source is tried again
+	entropyRetryInterval = 5 * time.Second
+
+	// entropyBlockWait is how long a read under the block policy waits
+	// for failing sources to recover before it fails
+	entropyBlockWait = 2 * entropyRetryInterval
+
+	// entropyChunkSize bounds one HKDF expansion, which cannot exceed 255
+	// hash blocks
+	entropyChunkSize = 4096
+
+	entropyInfo = "aether-vault entropy"
+)
+
+// keyReader is the random source for vault key material: data keys,
+// Shamir shares, CA and signing keys. Start points it at the mixed reader;
+// everything else reads crypto/rand, so a failing source cannot hold up
+// TLS handshakes or token signing.
+var keyReader io.Reader = rand.Reader
+
+// EntropySource supplies random bytes from outside the operating system
+type EntropySource interface {
+	// Name identifies the source in status and logs, without credentials
+	Name() string
+	io.Reader
+}
+
+// NewEntropySource returns the source for a URI:
+//
+//	tpm:///dev/tpmrm0                  TPM 2.0 random number generator, via TPM2_GetRandom
+//	file:///dev/hwrng                  hardware RNG device, or any file streaming random bytes
+//	https://entropy.example.com/bytes  API answering GET with raw random bytes
+func NewEntropySource(uri string, headers map[string]string) (EntropySource, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrEntropySourceInvalid, err)
+	}
+
+	switch parsed.Scheme {
+	case "tpm":
+		return &tpmEntropySource{device: parsed.Path}, nil
+	case "file":
+		return &fileEntropySource{path: parsed.Path}, nil
+	case "https", "http":
+		return &httpEntropySource{
+			endpoint: uri,
+			name:     parsed.Scheme + "://" + parsed.Host + parsed.Path,
+			headers:  headers,
+			client:   &http.Client{Timeout: 10 * time.Second},
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown scheme %q", ErrEntropySourceInvalid, parsed.Scheme)
+	}
+}
+
+// EntropyService augments the randomness vault keys are generated from
+// with external sources. Every read mixes fresh operating system
+// randomness with a seed that the sources reseed periodically, through
+// HKDF-SHA256, so the output is never weaker than the operating system's
+// alone. Sources run continuous health tests; a failing one is left out of
+// reseeds, and under the block policy key generation waits a bounded time
+// for it to recover, then fails.
+type EntropyService struct {
+	sources  []*entropySourceState
+	policy   string
+	interval time.Duration
+	// system is the operating system's generator
+	system io.Reader
+	// blockWait bounds how long a read waits under the block policy
+	blockWait time.Duration
+
+	mu         sync.Mutex
+	recovered  *sync.Cond
+	seed       []byte
+	counter    uint64
+	reseededAt time.Time
+}
+
+type entropySourceState struct {
+	source      EntropySource
+	healthy     bool
+	failures    int64
+	lastError   string
+	lastSuccess time.Time
+	// last is the previous sample, for the repetition test
+	last []byte
+}
+
+func NewEntropyService(cfg config.EntropyConfig) (*EntropyService, error) {
+	headers := map[string]string{}
+	for _, pair := range strings.Split(cfg.Headers, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		if ok && strings.TrimSpace(key) != "" {
+			headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+
+	s := &EntropyService{
+		policy:    cfg.FailurePolicy,
+		interval:  time.Duration(cfg.ReseedInterval) * time.Second,
+		system:    rand.Reader,
+		blockWait: entropyBlockWait,
+	}
+	s.recovered = sync.NewCond(&s.mu)
+	for _, uri := range strings.Split(cfg.Sources, ",") {
+		uri = strings.TrimSpace(uri)
+		if uri == "" {
+			continue
+		}
+		source, err := NewEntropySource(uri, headers)
+		if err != nil {
+			return nil, err
+		}
+		s.sources = append(s.sources, &entropySourceState{source: source})
+	}
+
+	return s, nil
+}
+
+// Enabled reports whether any source is configured
+func (s *EntropyService) Enabled() bool {
+	return s != nil && len(s.sources) > 0
+}
+
+// Start seeds from the sources, installs the service as the reader vault
+// keys are generated from and keeps reseeding in the background. Under the block policy it
+// fails when a source is unhealthy from the start, rather than letting
+// the first random read hang.
+func (s *EntropyService) Start() error {
+	if !s.Enabled() {
+		return nil
+	}
+
+	seed := make([]byte, sha256.Size)
+	if _, err := io.ReadFull(s.system, seed); err != nil {
+		return fmt.Errorf("failed to read system entropy: %w", err)
+	}
+	s.seed = seed
+
+	s.reseed()
+	if s.Status().Degraded {
+		if s.policy == model.EntropyPolicyBlock {
+			return ErrEntropyUnhealthy
+		}
+		log.Printf("⚠️  Entropy sources degraded; mixing in the healthy ones only")
+	}
+
+	keyReader = s
+
+	go func() {
+		for {
+			interval := s.interval
+			if s.Status().Degraded && interval > entropyRetryInterval {
+				interval = entropyRetryInterval
+			}
+			time.Sleep(interval)
+			s.reseed()
+		}
+	}()
+
+	return nil
+}
+
+// Read fills p with HKDF output keyed by the current seed over fresh
+// system randomness and a counter, so outputs never repeat even if the
+// operating system's generator does. Under the block policy it fails with
+// ErrEntropyUnhealthy when the sources do not recover within blockWait.
+func (s *EntropyService) Read(p []byte) (int, error) {
+	s.mu.Lock()
+	if s.policy == model.EntropyPolicyBlock && !s.healthyLocked() {
+		// sync.Cond has no timed wait; wake the waiters at the deadline
+		deadline := time.Now().Add(s.blockWait)
+		timer := time.AfterFunc(s.blockWait, func() {
+			s.mu.Lock()
+			s.recovered.Broadcast()
+			s.mu.Unlock()
+		})
+		for !s.healthyLocked() && time.Now().Before(deadline) {
+			s.recovered.Wait()
+		}
+		timer.Stop()
+		if !s.healthyLocked() {
+			s.mu.Unlock()
+			return 0, ErrEntropyUnhealthy
+		}
+	}
+	seed := s.seed
+	counter := s.counter
+	s.counter += uint64(len(p)/entropyChunkSize) + 1
+	s.mu.Unlock()
+
+	input := make([]byte, sha256.Size+8)
+	for written := 0; written < len(p); counter++ {
+		if _, err := io.ReadFull(s.system, input[:sha256.Size]); err != nil {
+			return written, fmt.Errorf("failed to read system entropy: %w", err)
+		}
+		binary.BigEndian.PutUint64(input[sha256.Size:], counter)
+
+		n := min(len(p)-written, entropyChunkSize)
+		output, err := hkdf.Key(sha256.New, input, seed, entropyInfo, n)
+		if err != nil {
+			return written, fmt.Errorf("failed to mix entropy: %w", err)
+		}
+		written += copy(p[written:], output)
+	}
+
+	return len(p), nil
+}
+
+// reseed folds a health-tested sample from every source into the seed
+func (s *EntropyService) reseed() {
+	var input []byte
+	for _, state := range s.sources {
+		sample := make([]byte, entropySampleSize)
+		_, err := io.ReadFull(state.source, sample)
+		if err == nil {
+			err = checkEntropySample(sample, state.last)
+		}
+
+		s.mu.Lock()
+		if err != nil {
+			if state.healthy || state.failures == 0 {
+				log.Printf("⚠️  Entropy source %s failed: %v", state.source.Name(), err)
+			}
+			state.healthy = false
+			state.failures++
+			state.lastError = err.Error()
+		} else {
+			if !state.healthy && state.failures > 0 {
+				log.Printf("✅ Entropy source %s recovered", state.source.Name())
+			}
+			state.healthy = true
+			state.lastError = ""
+			state.lastSuccess = time.Now()
+			state.last = sample
+			input = append(input, sample...)
+		}
+		s.mu.Unlock()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(input) > 0 {
+		seed, err := hkdf.Extract(sha256.New, input, s.seed)
+		if err == nil {
+			s.seed = seed
+			s.reseededAt = time.Now()
+		}
+	}
+	s.recovered.Broadcast()
+}
+
+func (s *EntropyService) healthyLocked() bool {
+	for _, state := range s.sources {
+		if !state.healthy {
+			return false
+		}
+	}
+	return true
+}
+
+// Status reports the sources' health. It is safe to call on a nil
+// EntropyService.
+func (s *EntropyService) Status() model.EntropyStatus {
+	status := model.EntropyStatus{Sources: []model.EntropySourceStatus{}}
+	if !s.Enabled() {
+		return status
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status.Enabled = true
+	status.FailurePolicy = s.policy
+	status.Degraded = !s.healthyLocked()
+	status.Blocking = status.Degraded && s.policy == model.EntropyPolicyBlock
+	if !s.reseededAt.IsZero() {
+		reseededAt := s.reseededAt
+		status.ReseededAt = &reseededAt
+	}
+	for _, state := range s.sources {
+		source := model.EntropySourceStatus{
+			Name:      state.source.Name(),
+			Healthy:   state.healthy,
+			Failures:  state.failures,
+			LastError: state.lastError,
+		}
+		if !state.lastSuccess.IsZero() {
+			lastSuccess := state.lastSuccess
+			source.LastSuccessAt = &lastSuccess
+		}
+		status.Sources = append(status.Sources, source)
+	}
+
+	return status
+}
+
+// checkEntropySample runs continuous health tests in the spirit of NIST
+// SP 800-90B on one sample: a stuck or repeating source, or one whose
+// output is far from uniform, fails
+func checkEntropySample(sample, previous []byte) error {
+	if previous != nil && bytes.Equal(sample, previous) {
+		return fmt.Errorf("%w: repeated output", ErrEntropyHealthTest)
+	}
+
+	var seen [256]bool
+	distinct := 0
+	for _, b := range sample {
+		if !seen[b] {
+			seen[b] = true
+			distinct++
+		}
+	}
+	if distinct == 1 {
+		return fmt.Errorf("%w: stuck output", ErrEntropyHealthTest)
+	}
+	if distinct < entropyMinDistinct {
+		return fmt.Errorf("%w: %d distinct bytes in %d", ErrEntropyHealthTest, distinct, len(sample))
+	}
+	return nil
+}
+
+type fileEntropySource struct {
+	path string
+}
+
+func (s *fileEntropySource) Name() string {
+	return "file://" + s.path
+}
+
+func (s *fileEntropySource) Read(p []byte) (int, error) {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	return io.ReadFull(file, p)
+}
+
+// TPM 2.0 command framing for TPM2_GetRandom
+const (
+	tpmTagNoSessions  = 0x8001
+	tpmCommandRandom  = 0x0000017b
+	tpmHeaderSize     = 10
+	tpmMaxRandomBytes = 32
+)
+
+type tpmEntropySource struct {
+	device string
+}
+
+func (s *tpmEntropySource) Name() string {
+	return "tpm://" + s.device
+}
+
+func (s *tpmEntropySource) Read(p []byte) (int, error) {
+	device, err := os.OpenFile(s.device, os.O_RDWR, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer device.Close()
+
+	command := make([]byte, tpmHeaderSize+2)
+	response := make([]byte, 4096)
+	filled := 0
+	for filled < len(p) {
+		binary.BigEndian.PutUint16(command[0:], tpmTagNoSessions)
+		binary.BigEndian.PutUint32(command[2:], uint32(len(command)))
+		binary.BigEndian.PutUint32(command[6:], tpmCommandRandom)
+		binary.BigEndian.PutUint16(command[10:], uint16(min(len(p)-filled, tpmMaxRandomBytes)))
+		if _, err := device.Write(command); err != nil {
+			return filled, fmt.Errorf("failed to send TPM2_GetRandom: %w", err)
+		}
+
+		n, err := device.Read(response)
+		if err != nil {
+			return filled, fmt.Errorf("failed to read TPM response: %w", err)
+		}
+		if n < tpmHeaderSize+2 {
+			return filled, fmt.Errorf("short TPM response")
+		}
+		if code := binary.BigEndian.Uint32(response[6:]); code != 0 {
+			return filled, fmt.Errorf("TPM2_GetRandom failed with code 0x%x", code)
+		}
+		size := int(binary.BigEndian.Uint16(response[tpmHeaderSize:]))
+		if size == 0 || tpmHeaderSize+2+size > n {
+			return filled, fmt.Errorf("malformed TPM response")
+		}
+		filled += copy(p[filled:], response[tpmHeaderSize+2:tpmHeaderSize+2+size])
+	}
+
+	return filled, nil
+}
+
+type httpEntropySource struct {
+	endpoint string
+	name     string
+	headers  map[string]string
+	client   *http.Client
+}
+
+func (s *httpEntropySource) Name() string {
+	return s.name
+}
+
+func (s *httpEntropySource) Read(p []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodGet, s.endpoint, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/octet-stream")
+	for key, value := range s.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("entropy API returned %s", resp.Status)
+	}
+
+	n, err := io.ReadFull(resp.Body, p)
+	if err != nil {
+		return n, fmt.Errorf("entropy API returned %d bytes, need %d", n, len(p))
+	}
+	return n, nil
+}
+
+var (
+	ErrEntropySourceInvalid = errors.New("invalid entropy source")
+	ErrEntropyHealthTest    = errors.New("entropy health test failed")
+	ErrEntropyUnhealthy     = errors.New("entropy sources are unhealthy and the failure policy is block")
+)
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-vault/server/src/config"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+)
+
+type failingEntropySource struct{}
+
+func (failingEntropySource) Name() string {
+	return "test://failing"
+}
+
+func (failingEntropySource) Read([]byte) (int, error) {
+	return 0, errors.New("source down")
+}
+
+// blockedEntropyService has one failing source under the block policy
+func blockedEntropyService() *EntropyService {
+	s := &EntropyService{
+		policy:    model.EntropyPolicyBlock,
+		system:    rand.Reader,
+		blockWait: 50 * time.Millisecond,
+		seed:      make([]byte, 32),
+		sources:   []*entropySourceState{{source: failingEntropySource{}}},
+	}
+	s.recovered = sync.NewCond(&s.mu)
+	return s
+}
+
+func TestEntropyStartLeavesCryptoRandAlone(t *testing.T) {
+	system := rand.Reader
+	t.Cleanup(func() { keyReader = system })
+
+	s, err := NewEntropyService(config.EntropyConfig{
+		Sources:        "file:///dev/urandom",
+		ReseedInterval: 60,
+		FailurePolicy:  model.EntropyPolicyBlock,
+	})
+	if err != nil {
+		t.Fatalf("failed to create entropy service: %v", err)
+	}
+	if err := s.Start(); err != nil {
+		t.Fatalf("failed to start entropy service: %v", err)
+	}
+
+	if rand.Reader != system {
+		t.Error("Start replaced crypto/rand.Reader")
+	}
+	if keyReader != io.Reader(s) {
+		t.Error("Start did not install the mixed reader for key generation")
+	}
+}
+
+func TestEntropyBlockPolicyWaitIsBounded(t *testing.T) {
+	s := blockedEntropyService()
+
+	started := time.Now()
+	_, err := s.Read(make([]byte, 32))
+	if !errors.Is(err, ErrEntropyUnhealthy) {
+		t.Fatalf("got error %v, want ErrEntropyUnhealthy", err)
+	}
+	if waited := time.Since(started); waited > time.Second {
+		t.Errorf("read waited %s for failing sources, want about %s", waited, s.blockWait)
+	}
+}
+
+func TestEntropyBlockPolicyResumesOnRecovery(t *testing.T) {
+	s := blockedEntropyService()
+	s.blockWait = 5 * time.Second
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		s.mu.Lock()
+		s.sources[0].healthy = true
+		s.recovered.Broadcast()
+		s.mu.Unlock()
+	}()
+
+	if _, err := s.Read(make([]byte, 32)); err != nil {
+		t.Fatalf("read after the source recovered failed: %v", err)
+	}
+}
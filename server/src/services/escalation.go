@@ -0,0 +1,570 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/config"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+)
+
+const (
+	pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+	opsgenieAPIURL     = "https://api.opsgenie.com"
+	escalationSource   = "aether-vault"
+	// Opsgenie truncates alert messages beyond this length
+	opsgenieMaxMessage = 130
+)
+
+// EscalationService opens PagerDuty and Opsgenie incidents for routed
+// events, deduplicated by the event's dedup key, and resolves them when the
+// condition is cleared or stops recurring
+type EscalationService struct {
+	db           *gorm.DB
+	auditService *AuditService
+	cfg          config.EscalationConfig
+	client       *http.Client
+	// serializes incident state changes so concurrent events with the same
+	// dedup key open one incident
+	mutex sync.Mutex
+}
+
+func NewEscalationService(db *gorm.DB, auditService *AuditService, cfg config.EscalationConfig) *EscalationService {
+	return &EscalationService{
+		db:           db,
+		auditService: auditService,
+		cfg:          cfg,
+		client:       &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *EscalationService) CreateTarget(req *model.CreateEscalationTargetRequest, userID uuid.UUID) (*model.EscalationTarget, error) {
+	if req.APIURL != "" {
+		if parsed, err := url.Parse(req.APIURL); err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+			return nil, fmt.Errorf("%w: api_url must be an https URL", ErrEscalationTargetInvalid)
+		}
+	}
+
+	var count int64
+	if err := s.db.Model(&model.EscalationTarget{}).Where("name = ?", req.Name).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("failed to check escalation target: %w", err)
+	}
+	if count > 0 {
+		return nil, ErrEscalationTargetExists
+	}
+
+	target := &model.EscalationTarget{
+		Name:       req.Name,
+		Provider:   req.Provider,
+		RoutingKey: req.RoutingKey,
+		APIURL:     strings.TrimRight(req.APIURL, "/"),
+		Enabled:    true,
+		CreatedBy:  userID,
+	}
+	for i := range req.Rules {
+		rule, err := newEscalationRule(&req.Rules[i])
+		if err != nil {
+			return nil, err
+		}
+		target.Rules = append(target.Rules, *rule)
+	}
+
+	if err := s.db.Create(target).Error; err != nil {
+		return nil, fmt.Errorf("failed to create escalation target: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "escalation_target_created", "escalation_target", target.ID.String(), true, fmt.Sprintf("provider=%s", target.Provider))
+	}
+
+	return target, nil
+}
+
+func (s *EscalationService) GetTargets() ([]model.EscalationTarget, error) {
+	var targets []model.EscalationTarget
+	if err := s.db.Preload("Rules").Order("name").Find(&targets).Error; err != nil {
+		return nil, fmt.Errorf("failed to get escalation targets: %w", err)
+	}
+	return targets, nil
+}
+
+func (s *EscalationService) GetTarget(id uuid.UUID) (*model.EscalationTarget, error) {
+	var target model.EscalationTarget
+	if err := s.db.Preload("Rules").First(&target, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEscalationTargetNotFound
+		}
+		return nil, fmt.Errorf("failed to get escalation target: %w", err)
+	}
+	return &target, nil
+}
+
+// DeleteTarget removes the target and its rules. Incidents it still has
+// open are left for the provider to close.
+func (s *EscalationService) DeleteTarget(id uuid.UUID, userID uuid.UUID) error {
+	if _, err := s.GetTarget(id); err != nil {
+		return err
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("target_id = ?", id).Delete(&model.EscalationRule{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.EscalationTarget{}, "id = ?", id).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete escalation target: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "escalation_target_deleted", "escalation_target", id.String(), true, "")
+	}
+
+	return nil
+}
+
+func (s *EscalationService) AddRule(targetID uuid.UUID, req *model.EscalationRuleRequest, userID uuid.UUID) (*model.EscalationRule, error) {
+	if _, err := s.GetTarget(targetID); err != nil {
+		return nil, err
+	}
+
+	rule, err := newEscalationRule(req)
+	if err != nil {
+		return nil, err
+	}
+	rule.TargetID = targetID
+
+	if err := s.db.Create(rule).Error; err != nil {
+		return nil, fmt.Errorf("failed to create escalation rule: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "escalation_rule_created", "escalation_target", targetID.String(), true, fmt.Sprintf("pattern=%s min_severity=%s", rule.EventPattern, rule.MinSeverity))
+	}
+
+	return rule, nil
+}
+
+func (s *EscalationService) DeleteRule(targetID, ruleID uuid.UUID, userID uuid.UUID) error {
+	result := s.db.Where("id = ? AND target_id = ?", ruleID, targetID).Delete(&model.EscalationRule{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete escalation rule: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrEscalationRuleNotFound
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "escalation_rule_deleted", "escalation_target", targetID.String(), true, ruleID.String())
+	}
+
+	return nil
+}
+
+// TestTarget triggers a test incident on the target and resolves it
+// straight away
+func (s *EscalationService) TestTarget(id uuid.UUID) error {
+	target, err := s.GetTarget(id)
+	if err != nil {
+		return err
+	}
+
+	event := model.NewEvent("vault.test", map[string]interface{}{
+		"summary": "Aether Vault escalation test",
+	})
+	dedupKey := "vault_test:" + target.ID.String()
+	if err := s.trigger(target, event, dedupKey); err != nil {
+		return err
+	}
+	return s.resolve(target, dedupKey)
+}
+
+// GetIncidents lists incidents, newest first, optionally by status
+func (s *EscalationService) GetIncidents(status string, limit, offset int) ([]model.Incident, error) {
+	query := s.db.Preload("Target").Order("last_seen_at DESC").Limit(limit).Offset(offset)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var incidents []model.Incident
+	if err := query.Find(&incidents).Error; err != nil {
+		return nil, fmt.Errorf("failed to get incidents: %w", err)
+	}
+	return incidents, nil
+}
+
+// ResolveIncident resolves an open incident at its provider on behalf of
+// a user
+func (s *EscalationService) ResolveIncident(id uuid.UUID, userID uuid.UUID) (*model.Incident, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var incident model.Incident
+	if err := s.db.Preload("Target").First(&incident, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrIncidentNotFound
+		}
+		return nil, fmt.Errorf("failed to get incident: %w", err)
+	}
+	if incident.Status != model.IncidentStatusTriggered {
+		return nil, ErrIncidentResolved
+	}
+
+	if err := s.resolveIncident(&incident, "user:"+userID.String()); err != nil {
+		return nil, err
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "incident_resolved", "incident", incident.ID.String(), true, incident.DedupKey)
+	}
+
+	return &incident, nil
+}
+
+// HandleEvent is the NotificationService sink: matching events open an
+// incident per target and dedup key, and condition.cleared resolves them
+func (s *EscalationService) HandleEvent(event *model.Event) error {
+	if event.Type == model.EventConditionCleared {
+		dedupKey, _ := event.Data["dedup_key"].(string)
+		reason, _ := event.Data["reason"].(string)
+		return s.resolveCondition(dedupKey, "condition cleared: "+reason)
+	}
+
+	var targets []model.EscalationTarget
+	if err := s.db.Preload("Rules").Where("enabled = ?", true).Find(&targets).Error; err != nil {
+		return fmt.Errorf("failed to get escalation targets: %w", err)
+	}
+
+	dedupKey := eventDedupKey(event)
+	var errs []error
+	for i := range targets {
+		if !escalationRulesMatch(targets[i].Rules, event) {
+			continue
+		}
+		if err := s.open(&targets[i], event, dedupKey); err != nil {
+			errs = append(errs, fmt.Errorf("escalation target %s: %w", targets[i].Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Start resolves incidents whose condition has not recurred within
+// resolve_after, checking every interval
+func (s *EscalationService) Start(interval time.Duration) {
+	if s.cfg.ResolveAfter <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if resolved, err := s.ResolveStale(); err != nil {
+				log.Printf("⚠️  Failed to resolve stale incidents: %v", err)
+			} else if resolved > 0 {
+				log.Printf("🔔 Resolved %d incidents that stopped recurring", resolved)
+			}
+		}
+	}()
+}
+
+// ResolveStale resolves open incidents last seen more than resolve_after
+// seconds ago
+func (s *EscalationService) ResolveStale() (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	cutoff := time.Now().Add(-time.Duration(s.cfg.ResolveAfter) * time.Second)
+	var incidents []model.Incident
+	if err := s.db.Preload("Target").Where("status = ? AND last_seen_at < ?", model.IncidentStatusTriggered, cutoff).Find(&incidents).Error; err != nil {
+		return 0, fmt.Errorf("failed to get stale incidents: %w", err)
+	}
+
+	resolved := 0
+	var errs []error
+	for i := range incidents {
+		if err := s.resolveIncident(&incidents[i], fmt.Sprintf("no recurrence for %ds", s.cfg.ResolveAfter)); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		resolved++
+	}
+
+	return resolved, errors.Join(errs...)
+}
+
+// open triggers an incident unless one is already open for the dedup key,
+// in which case the occurrence is only counted
+func (s *EscalationService) open(target *model.EscalationTarget, event *model.Event, dedupKey string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var incident model.Incident
+	err := s.db.Where("target_id = ? AND dedup_key = ? AND status = ?", target.ID, dedupKey, model.IncidentStatusTriggered).First(&incident).Error
+	if err == nil {
+		return s.db.Model(&incident).Updates(map[string]interface{}{
+			"occurrences":  gorm.Expr("occurrences + 1"),
+			"last_seen_at": time.Now(),
+		}).Error
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to get incident: %w", err)
+	}
+
+	// Nothing is recorded when delivery fails so the next occurrence
+	// retries it
+	if err := s.trigger(target, event, dedupKey); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	incident = model.Incident{
+		TargetID:    target.ID,
+		DedupKey:    dedupKey,
+		EventType:   event.Type,
+		Severity:    event.Severity,
+		Summary:     eventTitle(event),
+		Status:      model.IncidentStatusTriggered,
+		Occurrences: 1,
+		TriggeredAt: now,
+		LastSeenAt:  now,
+	}
+	if err := s.db.Create(&incident).Error; err != nil {
+		return fmt.Errorf("failed to record incident: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAnonymousAction("incident_triggered", "incident", incident.ID.String(), "", "", true, fmt.Sprintf("target=%s dedup_key=%s", target.Name, dedupKey))
+	}
+
+	return nil
+}
+
+func (s *EscalationService) resolveCondition(dedupKey, resolvedBy string) error {
+	if dedupKey == "" {
+		return nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var incidents []model.Incident
+	if err := s.db.Preload("Target").Where("dedup_key = ? AND status = ?", dedupKey, model.IncidentStatusTriggered).Find(&incidents).Error; err != nil {
+		return fmt.Errorf("failed to get incidents: %w", err)
+	}
+
+	var errs []error
+	for i := range incidents {
+		if err := s.resolveIncident(&incidents[i], resolvedBy); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// resolveIncident closes the incident at its provider and records it. The
+// caller holds the mutex. Incidents whose target was deleted are only
+// marked resolved.
+func (s *EscalationService) resolveIncident(incident *model.Incident, resolvedBy string) error {
+	if incident.Target != nil {
+		if err := s.resolve(incident.Target, incident.DedupKey); err != nil {
+			return fmt.Errorf("escalation target %s: %w", incident.Target.Name, err)
+		}
+	}
+
+	now := time.Now()
+	incident.Status = model.IncidentStatusResolved
+	incident.ResolvedAt = &now
+	incident.ResolvedBy = resolvedBy
+	if err := s.db.Model(incident).Updates(map[string]interface{}{
+		"status":      incident.Status,
+		"resolved_at": now,
+		"resolved_by": resolvedBy,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to update incident: %w", err)
+	}
+
+	return nil
+}
+
+func (s *EscalationService) trigger(target *model.EscalationTarget, event *model.Event, dedupKey string) error {
+	switch target.Provider {
+	case model.EscalationProviderPagerDuty:
+		return s.postJSON(s.pagerDutyURL(target), "", map[string]interface{}{
+			"routing_key":  target.RoutingKey,
+			"event_action": "trigger",
+			"dedup_key":    dedupKey,
+			"payload": map[string]interface{}{
+				"summary":        eventTitle(event),
+				"source":         escalationSource,
+				"severity":       pagerDutySeverity(event.Severity),
+				"timestamp":      event.Timestamp.Format(time.RFC3339),
+				"class":          string(event.Type),
+				"custom_details": event.Data,
+			},
+		})
+	case model.EscalationProviderOpsgenie:
+		message := eventTitle(event)
+		if len(message) > opsgenieMaxMessage {
+			message = message[:opsgenieMaxMessage]
+		}
+		return s.postJSON(s.opsgenieURL(target)+"/v2/alerts", target.RoutingKey, map[string]interface{}{
+			"message":  message,
+			"alias":    dedupKey,
+			"source":   escalationSource,
+			"priority": opsgeniePriority(event.Severity),
+			"tags":     []string{string(event.Type)},
+			"details":  opsgenieDetails(event.Data),
+		})
+	default:
+		return fmt.Errorf("%w: %s", ErrEscalationProviderInvalid, target.Provider)
+	}
+}
+
+func (s *EscalationService) resolve(target *model.EscalationTarget, dedupKey string) error {
+	switch target.Provider {
+	case model.EscalationProviderPagerDuty:
+		return s.postJSON(s.pagerDutyURL(target), "", map[string]interface{}{
+			"routing_key":  target.RoutingKey,
+			"event_action": "resolve",
+			"dedup_key":    dedupKey,
+		})
+	case model.EscalationProviderOpsgenie:
+		return s.postJSON(s.opsgenieURL(target)+"/v2/alerts/"+url.PathEscape(dedupKey)+"/close?identifierType=alias", target.RoutingKey, map[string]interface{}{
+			"source": escalationSource,
+		})
+	default:
+		return fmt.Errorf("%w: %s", ErrEscalationProviderInvalid, target.Provider)
+	}
+}
+
+func (s *EscalationService) postJSON(target, genieKey string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode incident event: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build incident request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if genieKey != "" {
+		req.Header.Set("Authorization", "GenieKey "+genieKey)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNotificationDelivery, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%w: provider returned %s", ErrNotificationDelivery, resp.Status)
+	}
+
+	return nil
+}
+
+func (s *EscalationService) pagerDutyURL(target *model.EscalationTarget) string {
+	if target.APIURL != "" {
+		return target.APIURL
+	}
+	return pagerDutyEventsURL
+}
+
+func (s *EscalationService) opsgenieURL(target *model.EscalationTarget) string {
+	if target.APIURL != "" {
+		return target.APIURL
+	}
+	return opsgenieAPIURL
+}
+
+func newEscalationRule(req *model.EscalationRuleRequest) (*model.EscalationRule, error) {
+	if !eventPattern.MatchString(req.EventPattern) {
+		return nil, fmt.Errorf("%w: event_pattern must be *, an event type or a prefix such as security.*", ErrEscalationRuleInvalid)
+	}
+
+	severity := req.MinSeverity
+	if severity == "" {
+		severity = model.EventSeverityCritical
+	}
+
+	return &model.EscalationRule{EventPattern: req.EventPattern, MinSeverity: severity}, nil
+}
+
+func escalationRulesMatch(rules []model.EscalationRule, event *model.Event) bool {
+	for _, rule := range rules {
+		if eventMatches(rule.EventPattern, rule.MinSeverity, event) {
+			return true
+		}
+	}
+	return false
+}
+
+// eventDedupKey identifies the condition an event reports: its dedup_key
+// when the producer set one, otherwise its type, narrowed to the user it
+// concerns
+func eventDedupKey(event *model.Event) string {
+	if key, ok := event.Data["dedup_key"].(string); ok && key != "" {
+		return key
+	}
+	if userID, ok := event.Data["user_id"]; ok {
+		return fmt.Sprintf("%s:%v", event.Type, userID)
+	}
+	return string(event.Type)
+}
+
+func pagerDutySeverity(severity model.EventSeverity) string {
+	switch severity {
+	case model.EventSeverityCritical:
+		return "critical"
+	case model.EventSeverityWarning:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+func opsgeniePriority(severity model.EventSeverity) string {
+	switch severity {
+	case model.EventSeverityCritical:
+		return "P1"
+	case model.EventSeverityWarning:
+		return "P3"
+	default:
+		return "P5"
+	}
+}
+
+// opsgenieDetails flattens event data, since Opsgenie only accepts string
+// detail values
+func opsgenieDetails(data map[string]interface{}) map[string]string {
+	details := make(map[string]string, len(data))
+	for key, value := range data {
+		details[key] = fmt.Sprintf("%v", value)
+	}
+	return details
+}
+
+var (
+	ErrEscalationTargetNotFound  = errors.New("escalation target not found")
+	ErrEscalationTargetExists    = errors.New("an escalation target with this name already exists")
+	ErrEscalationTargetInvalid   = errors.New("invalid escalation target")
+	ErrEscalationProviderInvalid = errors.New("unsupported escalation provider")
+	ErrEscalationRuleNotFound    = errors.New("escalation rule not found")
+	ErrEscalationRuleInvalid     = errors.New("invalid escalation rule")
+	ErrIncidentNotFound          = errors.New("incident not found")
+	ErrIncidentResolved          = errors.New("incident is already resolved")
+)
@@ -0,0 +1,479 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"golang.org/x/crypto/pbkdf2"
+	"gorm.io/gorm"
+)
+
+const (
+	// keyRecoveryTTL is how long officers have to submit their shares
+	// once a recovery was started
+	keyRecoveryTTL = 30 * time.Minute
+
+	// Must match package/cli/internal/e2e so officers open their shares
+	// with their CLI identity
+	envelopeAlgorithm = "X25519-HKDF-SHA256-AES256GCM"
+	envelopeWrapInfo  = "aether-vault e2e key wrap v1"
+
+	// escrowFingerprintIterations makes guessing the master key from a
+	// stored fingerprint as slow as from a TOTP backup
+	escrowFingerprintIterations = 600000
+)
+
+// EscrowService splits the master encryption key into recovery shares,
+// each sealed for a recovery officer's registered public key, so that a
+// quorum of officers can rebuild it after every operator copy is lost.
+// The server has no unseal step; these shares exist only for recovery.
+//
+// A recovery collects decrypted shares in memory on one instance and
+// never stores them; the rebuilt key is handed back sealed for the
+// initiator's public key.
+type EscrowService struct {
+	db             *gorm.DB
+	masterKey      string
+	teamKeyService *TeamKeyService
+	auditService   *AuditService
+
+	mu       sync.Mutex
+	recovery *keyRecovery
+}
+
+type keyRecovery struct {
+	status    model.KeyRecoveryStatus
+	recipient string
+	shares    map[uuid.UUID][]byte
+}
+
+func NewEscrowService(db *gorm.DB, masterKey string, teamKeyService *TeamKeyService, auditService *AuditService) *EscrowService {
+	return &EscrowService{
+		db:             db,
+		masterKey:      masterKey,
+		teamKeyService: teamKeyService,
+		auditService:   auditService,
+	}
+}
+
+// CreateEscrow splits the running master key for the given officers, any
+// threshold of whom can recover it, and replaces the previous escrow.
+// Every officer must have registered a public key.
+func (s *EscrowService) CreateEscrow(req *model.CreateEscrowRequest, userID uuid.UUID) (*model.KeyEscrowResponse, error) {
+	officers := make([]uuid.UUID, 0, len(req.OfficerIDs))
+	seen := make(map[uuid.UUID]bool, len(req.OfficerIDs))
+	for _, officerID := range req.OfficerIDs {
+		if !seen[officerID] {
+			seen[officerID] = true
+			officers = append(officers, officerID)
+		}
+	}
+	if req.Threshold < 2 || req.Threshold > len(officers) {
+		return nil, fmt.Errorf("%w: need at least 2 and at most %d", ErrEscrowThresholdInvalid, len(officers))
+	}
+
+	keys := make([]*model.UserKey, 0, len(officers))
+	for _, officerID := range officers {
+		key, err := s.teamKeyService.GetKey(officerID)
+		if err != nil {
+			if errors.Is(err, ErrUserKeyNotFound) {
+				return nil, fmt.Errorf("%w: %s", ErrEscrowOfficerNoKey, officerID)
+			}
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	shares, err := splitSecret([]byte(s.masterKey), len(officers), req.Threshold)
+	if err != nil {
+		return nil, fmt.Errorf("failed to split master key: %w", err)
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate fingerprint salt: %w", err)
+	}
+	escrow := &model.KeyEscrow{
+		ID:             uuid.New(),
+		Threshold:      req.Threshold,
+		Shares:         len(officers),
+		KeyFingerprint: escrowKeyFingerprint([]byte(s.masterKey), salt),
+		KeySalt:        hex.EncodeToString(salt),
+		CreatedBy:      userID,
+	}
+	rows := make([]model.EscrowShare, 0, len(officers))
+	for i, share := range shares {
+		sealed, err := sealEnvelope([]byte(base64.RawURLEncoding.EncodeToString(share)), keys[i].PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to seal recovery share: %w", err)
+		}
+		rows = append(rows, model.EscrowShare{
+			ID:         uuid.New(),
+			EscrowID:   escrow.ID,
+			OfficerID:  officers[i],
+			KeyID:      keys[i].KeyID,
+			ShareIndex: int(share[0]),
+			Envelope:   sealed,
+		})
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&model.EscrowShare{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("1 = 1").Delete(&model.KeyEscrow{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(escrow).Error; err != nil {
+			return err
+		}
+		return tx.Create(&rows).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store key escrow: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "key_escrow_created", "key_escrow", escrow.ID.String(), true, fmt.Sprintf("threshold=%d shares=%d", escrow.Threshold, escrow.Shares))
+	}
+
+	return s.GetEscrow()
+}
+
+// GetEscrow describes the current escrow without any share
+func (s *EscrowService) GetEscrow() (*model.KeyEscrowResponse, error) {
+	escrow, err := s.currentEscrow()
+	if err != nil {
+		return nil, err
+	}
+
+	var shares []model.EscrowShare
+	if err := s.db.Where("escrow_id = ?", escrow.ID).Order("share_index").Find(&shares).Error; err != nil {
+		return nil, fmt.Errorf("failed to get escrow shares: %w", err)
+	}
+
+	response := &model.KeyEscrowResponse{KeyEscrow: *escrow, Officers: make([]model.EscrowOfficer, 0, len(shares))}
+	for _, share := range shares {
+		response.Officers = append(response.Officers, model.EscrowOfficer{OfficerID: share.OfficerID, KeyID: share.KeyID, Index: share.ShareIndex})
+	}
+	return response, nil
+}
+
+// GetShare returns the officer's own sealed share
+func (s *EscrowService) GetShare(officerID uuid.UUID) (*model.EscrowShare, error) {
+	escrow, err := s.currentEscrow()
+	if err != nil {
+		return nil, err
+	}
+
+	var share model.EscrowShare
+	if err := s.db.Where("escrow_id = ? AND officer_id = ?", escrow.ID, officerID).First(&share).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEscrowNotOfficer
+		}
+		return nil, fmt.Errorf("failed to get escrow share: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(officerID, "key_escrow_share_read", "key_escrow", escrow.ID.String(), true, fmt.Sprintf("index=%d", share.ShareIndex))
+	}
+
+	return &share, nil
+}
+
+// StartRecovery opens a recovery the officers then submit shares to. The
+// rebuilt key will be sealed for the initiator's registered public key.
+func (s *EscrowService) StartRecovery(userID uuid.UUID) (*model.KeyRecoveryStatus, error) {
+	escrow, err := s.currentEscrow()
+	if err != nil {
+		return nil, err
+	}
+	key, err := s.teamKeyService.GetKey(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.activeRecoveryLocked() != nil {
+		return nil, ErrRecoveryInProgress
+	}
+
+	s.recovery = &keyRecovery{
+		status: model.KeyRecoveryStatus{
+			ID:          uuid.New(),
+			EscrowID:    escrow.ID,
+			InitiatedBy: userID,
+			Threshold:   escrow.Threshold,
+			Submitted:   []uuid.UUID{},
+			ExpiresAt:   time.Now().Add(keyRecoveryTTL),
+		},
+		recipient: key.PublicKey,
+		shares:    make(map[uuid.UUID][]byte),
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "key_recovery_started", "key_escrow", escrow.ID.String(), true, "recovery="+s.recovery.status.ID.String())
+	}
+
+	status := s.recovery.status
+	return &status, nil
+}
+
+// SubmitShare adds an officer's decrypted share. Once the threshold is
+// reached the key is rebuilt, checked against the escrow fingerprint and
+// sealed for the initiator; a mismatch discards the recovery.
+func (s *EscrowService) SubmitShare(officerID uuid.UUID, encoded string) (*model.KeyRecoveryStatus, error) {
+	share, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(share) < 2 {
+		return nil, ErrRecoveryShareInvalid
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	recovery := s.activeRecoveryLocked()
+	if recovery == nil {
+		return nil, ErrRecoveryNotFound
+	}
+
+	var officer model.EscrowShare
+	if err := s.db.Where("escrow_id = ? AND officer_id = ?", recovery.status.EscrowID, officerID).First(&officer).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEscrowNotOfficer
+		}
+		return nil, fmt.Errorf("failed to get escrow share: %w", err)
+	}
+	if int(share[0]) != officer.ShareIndex {
+		return nil, ErrRecoveryShareInvalid
+	}
+	if _, ok := recovery.shares[officerID]; ok {
+		return nil, ErrRecoveryShareSubmitted
+	}
+
+	recovery.shares[officerID] = share
+	recovery.status.Submitted = append(recovery.status.Submitted, officerID)
+	if s.auditService != nil {
+		s.auditService.LogAction(officerID, "key_recovery_share_submitted", "key_escrow", recovery.status.EscrowID.String(), true, fmt.Sprintf("recovery=%s submitted=%d/%d", recovery.status.ID, len(recovery.shares), recovery.status.Threshold))
+	}
+
+	if len(recovery.shares) >= recovery.status.Threshold {
+		if err := s.completeLocked(recovery); err != nil {
+			return nil, err
+		}
+	}
+
+	status := recovery.status
+	return &status, nil
+}
+
+func (s *EscrowService) completeLocked(recovery *keyRecovery) error {
+	shares := make([][]byte, 0, len(recovery.shares))
+	for _, share := range recovery.shares {
+		shares = append(shares, share)
+	}
+	recovery.shares = nil
+
+	escrow, err := s.currentEscrow()
+	if err != nil {
+		s.recovery = nil
+		return err
+	}
+
+	key, err := combineShares(shares)
+	if err == nil {
+		err = checkEscrowFingerprint(escrow, key)
+	}
+	if err != nil {
+		s.recovery = nil
+		if s.auditService != nil {
+			s.auditService.LogAction(recovery.status.InitiatedBy, "key_recovery_failed", "key_escrow", escrow.ID.String(), false, "recovery="+recovery.status.ID.String())
+		}
+		return ErrRecoveryMismatch
+	}
+
+	sealed, err := sealEnvelope(key, recovery.recipient)
+	if err != nil {
+		s.recovery = nil
+		return fmt.Errorf("failed to seal recovered key: %w", err)
+	}
+	recovery.status.Complete = true
+	recovery.status.RecoveredKey = sealed
+
+	if s.auditService != nil {
+		s.auditService.LogAction(recovery.status.InitiatedBy, "key_recovery_completed", "key_escrow", escrow.ID.String(), true, "recovery="+recovery.status.ID.String())
+	}
+	return nil
+}
+
+// GetRecovery returns the current recovery, including a completed one
+// until it expires
+func (s *EscrowService) GetRecovery() (*model.KeyRecoveryStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.recovery == nil || time.Now().After(s.recovery.status.ExpiresAt) {
+		s.recovery = nil
+		return nil, ErrRecoveryNotFound
+	}
+	status := s.recovery.status
+	return &status, nil
+}
+
+// CancelRecovery discards the current recovery and any submitted shares
+func (s *EscrowService) CancelRecovery(userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.recovery == nil {
+		return ErrRecoveryNotFound
+	}
+	id := s.recovery.status.ID
+	s.recovery = nil
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "key_recovery_cancelled", "key_escrow", "", true, "recovery="+id.String())
+	}
+	return nil
+}
+
+// activeRecoveryLocked returns the recovery still collecting shares
+func (s *EscrowService) activeRecoveryLocked() *keyRecovery {
+	if s.recovery == nil || time.Now().After(s.recovery.status.ExpiresAt) {
+		s.recovery = nil
+		return nil
+	}
+	if s.recovery.status.Complete {
+		return nil
+	}
+	return s.recovery
+}
+
+func (s *EscrowService) currentEscrow() (*model.KeyEscrow, error) {
+	var escrow model.KeyEscrow
+	if err := s.db.Order("created_at DESC").First(&escrow).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEscrowNotFound
+		}
+		return nil, fmt.Errorf("failed to get key escrow: %w", err)
+	}
+	return &escrow, nil
+}
+
+// escrowKeyFingerprint derives the fingerprint with PBKDF2 under a random
+// per-escrow salt, so a leaked escrow row cannot be used to cheaply test
+// guesses of the master key
+func escrowKeyFingerprint(key, salt []byte) string {
+	return hex.EncodeToString(pbkdf2.Key(key, salt, escrowFingerprintIterations, 32, sha256.New))
+}
+
+func checkEscrowFingerprint(escrow *model.KeyEscrow, key []byte) error {
+	salt, err := hex.DecodeString(escrow.KeySalt)
+	if err != nil || len(salt) == 0 {
+		return ErrRecoveryMismatch
+	}
+	if subtle.ConstantTimeCompare([]byte(escrowKeyFingerprint(key, salt)), []byte(escrow.KeyFingerprint)) != 1 {
+		return ErrRecoveryMismatch
+	}
+	return nil
+}
+
+// sealEnvelope encrypts plaintext for one X25519 public key in the CLI's
+// client envelope format
+func sealEnvelope(plaintext []byte, publicKey string) (string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(publicKey)
+	if err != nil {
+		return "", ErrUserKeyInvalid
+	}
+	recipient, err := ecdh.X25519().NewPublicKey(raw)
+	if err != nil {
+		return "", ErrUserKeyInvalid
+	}
+
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+	ciphertext, err := sealGCM(dataKey, plaintext, []byte(ClientEnvelopePrefix))
+	if err != nil {
+		return "", err
+	}
+
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	shared, err := ephemeral.ECDH(recipient)
+	if err != nil {
+		return "", err
+	}
+	salt := append(ephemeral.PublicKey().Bytes(), recipient.Bytes()...)
+	kek, err := hkdf.Key(sha256.New, shared, salt, envelopeWrapInfo, 32)
+	if err != nil {
+		return "", err
+	}
+	keyID := publicKeyID(recipient.Bytes())
+	wrapped, err := sealGCM(kek, dataKey, []byte(keyID))
+	if err != nil {
+		return "", err
+	}
+
+	encoded, err := json.Marshal(map[string]interface{}{
+		"alg": envelopeAlgorithm,
+		"ct":  base64.RawURLEncoding.EncodeToString(ciphertext),
+		"recipients": []map[string]string{{
+			"kid": keyID,
+			"epk": base64.RawURLEncoding.EncodeToString(ephemeral.PublicKey().Bytes()),
+			"wk":  base64.RawURLEncoding.EncodeToString(wrapped),
+		}},
+	})
+	if err != nil {
+		return "", err
+	}
+	return ClientEnvelopePrefix + base64.RawURLEncoding.EncodeToString(encoded), nil
+}
+
+// sealGCM encrypts with AES-256-GCM, prefixing the random nonce
+func sealGCM(key, plaintext, additional []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, additional), nil
+}
+
+var (
+	ErrEscrowNotFound         = errors.New("no key escrow has been created")
+	ErrEscrowThresholdInvalid = errors.New("invalid recovery threshold")
+	ErrEscrowOfficerNoKey     = errors.New("recovery officer has not registered a public key")
+	ErrEscrowNotOfficer       = errors.New("user is not a recovery officer")
+	ErrRecoveryInProgress     = errors.New("a key recovery is already in progress")
+	ErrRecoveryNotFound       = errors.New("no key recovery is in progress")
+	ErrRecoveryShareInvalid   = errors.New("invalid recovery share")
+	ErrRecoveryShareSubmitted = errors.New("officer already submitted a share")
+	ErrRecoveryMismatch       = errors.New("submitted shares do not rebuild the escrowed key; recovery discarded")
+)
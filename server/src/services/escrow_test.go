@@ -0,0 +1,49 @@
+package services
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+)
+
+func TestEscrowFingerprintIsSalted(t *testing.T) {
+	key := []byte("master-key")
+	salt := []byte("0123456789abcdef")
+	escrow := &model.KeyEscrow{
+		KeyFingerprint: escrowKeyFingerprint(key, salt),
+		KeySalt:        hex.EncodeToString(salt),
+	}
+
+	if escrow.KeyFingerprint == escrowKeyFingerprint(key, []byte("fedcba9876543210")) {
+		t.Error("fingerprint does not depend on the salt")
+	}
+	if err := checkEscrowFingerprint(escrow, key); err != nil {
+		t.Errorf("rebuilt key rejected: %v", err)
+	}
+	if err := checkEscrowFingerprint(escrow, []byte("other-key")); err != ErrRecoveryMismatch {
+		t.Errorf("wrong key got %v, want ErrRecoveryMismatch", err)
+	}
+	if err := checkEscrowFingerprint(&model.KeyEscrow{KeyFingerprint: escrow.KeyFingerprint}, key); err != ErrRecoveryMismatch {
+		t.Errorf("unsalted escrow got %v, want ErrRecoveryMismatch", err)
+	}
+}
+
+func TestEscrowResponseOmitsFingerprint(t *testing.T) {
+	encoded, err := json.Marshal(&model.KeyEscrowResponse{KeyEscrow: model.KeyEscrow{
+		ID:             uuid.New(),
+		KeyFingerprint: "fingerprint-value",
+		KeySalt:        "salt-value",
+	}})
+	if err != nil {
+		t.Fatalf("failed to encode escrow: %v", err)
+	}
+	for _, leaked := range []string{"fingerprint-value", "salt-value"} {
+		if strings.Contains(string(encoded), leaked) {
+			t.Errorf("escrow response contains %q: %s", leaked, encoded)
+		}
+	}
+}
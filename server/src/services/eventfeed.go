@@ -0,0 +1,217 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+)
+
+const (
+	// feedSubscriberBuffer events queue per subscriber; a subscriber that
+	// falls further behind misses events and is told how many
+	feedSubscriberBuffer = 256
+	// feedMaxSubscribers caps concurrent feed connections per instance
+	feedMaxSubscribers = 64
+)
+
+// auditFeedClasses maps the audited actions announced on the feed to
+// their class. Reads are left out; the feed is for changes.
+var auditFeedClasses = map[string]model.FeedClass{
+	"login_success":         model.FeedClassAuth,
+	"login_failed":          model.FeedClassAuth,
+	"logout":                model.FeedClassAuth,
+	"identity_token_issued": model.FeedClassAuth,
+
+	"secret_created":          model.FeedClassSecret,
+	"secret_updated":          model.FeedClassSecret,
+	"secret_deleted":          model.FeedClassSecret,
+	"secret_quarantined":      model.FeedClassSecret,
+	"secret_envelope_updated": model.FeedClassSecret,
+
+	"key_escrow_created":           model.FeedClassSeal,
+	"key_recovery_started":         model.FeedClassSeal,
+	"key_recovery_share_submitted": model.FeedClassSeal,
+	"key_recovery_completed":       model.FeedClassSeal,
+	"key_recovery_failed":          model.FeedClassSeal,
+	"key_recovery_cancelled":       model.FeedClassSeal,
+	"tenant_key_registered":        model.FeedClassSeal,
+	"tenant_key_rotated":           model.FeedClassSeal,
+	"tenant_key_rewrapped":         model.FeedClassSeal,
+	"tenant_key_rewrap_failed":     model.FeedClassSeal,
+
+	"job_triggered": model.FeedClassJob,
+}
+
+// EventFeed fans live admin events out to subscribers such as the web UI
+// dashboard. Delivery is best effort and local to this instance: a slow
+// subscriber drops events rather than holding up the request that caused
+// them.
+type EventFeed struct {
+	mu          sync.Mutex
+	subscribers map[*FeedSubscription]struct{}
+}
+
+func NewEventFeed() *EventFeed {
+	return &EventFeed{subscribers: make(map[*FeedSubscription]struct{})}
+}
+
+// FeedSubscription receives the events of its classes on Events until
+// Close
+type FeedSubscription struct {
+	Events  <-chan *model.FeedEvent
+	Classes []model.FeedClass
+
+	feed    *EventFeed
+	events  chan *model.FeedEvent
+	classes map[model.FeedClass]bool
+	dropped atomic.Int64
+	once    sync.Once
+}
+
+// Subscribe starts a subscription to classes, every class when empty
+func (f *EventFeed) Subscribe(classes []model.FeedClass) (*FeedSubscription, error) {
+	if len(classes) == 0 {
+		classes = model.FeedClasses
+	}
+	wanted := make(map[model.FeedClass]bool, len(classes))
+	for _, class := range classes {
+		if !isFeedClass(class) {
+			return nil, fmt.Errorf("%w: %s", ErrFeedClassUnknown, class)
+		}
+		wanted[class] = true
+	}
+
+	events := make(chan *model.FeedEvent, feedSubscriberBuffer)
+	subscription := &FeedSubscription{
+		Events:  events,
+		feed:    f,
+		events:  events,
+		classes: wanted,
+	}
+	for _, class := range model.FeedClasses {
+		if wanted[class] {
+			subscription.Classes = append(subscription.Classes, class)
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.subscribers) >= feedMaxSubscribers {
+		return nil, ErrFeedFull
+	}
+	f.subscribers[subscription] = struct{}{}
+	return subscription, nil
+}
+
+// Close ends the subscription and closes Events
+func (s *FeedSubscription) Close() {
+	s.once.Do(func() {
+		s.feed.mu.Lock()
+		delete(s.feed.subscribers, s)
+		close(s.events)
+		s.feed.mu.Unlock()
+	})
+}
+
+// TakeDropped returns how many events were dropped since the last call
+func (s *FeedSubscription) TakeDropped() int64 {
+	return s.dropped.Swap(0)
+}
+
+// Publish delivers event to every subscriber of its class without
+// blocking. It is safe to call on a nil EventFeed.
+func (f *EventFeed) Publish(event *model.FeedEvent) {
+	if f == nil {
+		return
+	}
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for subscription := range f.subscribers {
+		if !subscription.classes[event.Class] {
+			continue
+		}
+		select {
+		case subscription.events <- event:
+		default:
+			subscription.dropped.Add(1)
+		}
+	}
+}
+
+// RecordAudit announces an audit entry whose action belongs to a class
+func (f *EventFeed) RecordAudit(entry *model.AuditLog) {
+	if f == nil {
+		return
+	}
+	class, ok := auditFeedClasses[entry.Action]
+	if !ok {
+		return
+	}
+
+	success := entry.Success
+	event := &model.FeedEvent{
+		ID:        entry.ID,
+		Class:     class,
+		Type:      entry.Action,
+		Timestamp: entry.CreatedAt,
+		ActorID:   entry.UserID,
+		Resource:  entry.Resource,
+		Success:   &success,
+		Details:   entry.Details,
+	}
+	if entry.ResourceID != nil {
+		event.ResourceID = *entry.ResourceID
+	}
+	if entry.IPAddress != "" {
+		event.Data = map[string]interface{}{"ip_address": entry.IPAddress}
+	}
+	f.Publish(event)
+}
+
+// HandleEvent is a notification sink announcing published events:
+// authentication failures and seal tampering under their classes, the
+// rest as alerts
+func (f *EventFeed) HandleEvent(event *model.Event) error {
+	class := model.FeedClassAlert
+	switch event.Type {
+	case model.EventAuthFailures:
+		class = model.FeedClassAuth
+	case model.EventSealTampering:
+		class = model.FeedClassSeal
+	}
+
+	f.Publish(&model.FeedEvent{
+		ID:        event.ID,
+		Class:     class,
+		Type:      string(event.Type),
+		Timestamp: event.Timestamp,
+		Data:      event.Data,
+	})
+	return nil
+}
+
+func isFeedClass(class model.FeedClass) bool {
+	for _, known := range model.FeedClasses {
+		if class == known {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	ErrFeedClassUnknown = errors.New("unknown event class")
+	ErrFeedFull         = errors.New("too many event feed subscribers")
+)
@@ -0,0 +1,266 @@
+package services
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// expiryRefresh is how often timers scheduled by other instances, or
+	// left by a restart, are loaded
+	expiryRefresh = 30 * time.Second
+	// expiryHorizon bounds the timers held in memory; later ones are
+	// loaded by a refresh once they come close
+	expiryHorizon = 2 * expiryRefresh
+	// expiryClaim is how long a firing instance owns a timer. The handler
+	// must finish within it, or the timer fires again.
+	expiryClaim = time.Minute
+)
+
+// ExpiryHandler expires an item. It may run more than once for the same
+// item, so it must tolerate one already gone.
+type ExpiryHandler func(ctx context.Context, item string) error
+
+// ExpiryScheduler fires expiry handlers when their time comes, instead of
+// waiting for a periodic scan. Timers are kept in a min-heap in memory and
+// persisted so they survive restarts; each instance loads the timers due
+// soon, and a firing claims its row first so one instance runs it.
+type ExpiryScheduler struct {
+	db *gorm.DB
+
+	mu       sync.Mutex
+	timers   expiryHeap
+	index    map[expiryKey]*expiryTimer
+	handlers map[string]ExpiryHandler
+	wake     chan struct{}
+}
+
+func NewExpiryScheduler(db *gorm.DB) *ExpiryScheduler {
+	return &ExpiryScheduler{
+		db:       db,
+		index:    make(map[expiryKey]*expiryTimer),
+		handlers: make(map[string]ExpiryHandler),
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Handle registers the handler of a kind of timer; call it before Start
+func (s *ExpiryScheduler) Handle(kind string, handler ExpiryHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[kind] = handler
+}
+
+// Schedule sets the timer of an item, replacing any earlier one. It is
+// safe to call on a nil ExpiryScheduler.
+func (s *ExpiryScheduler) Schedule(kind, item string, at time.Time) error {
+	if s == nil {
+		return nil
+	}
+
+	timer := &model.ExpiryTimer{Kind: kind, Item: item, FireAt: at}
+	if err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "kind"}, {Name: "item"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"fire_at": at, "attempts": 0}),
+	}).Create(timer).Error; err != nil {
+		return fmt.Errorf("failed to schedule expiry: %w", err)
+	}
+
+	if at.Before(time.Now().Add(expiryHorizon)) {
+		s.push(expiryKey{kind, item}, at)
+	}
+	return nil
+}
+
+// Cancel drops the timer of an item. It is safe to call on a nil
+// ExpiryScheduler.
+func (s *ExpiryScheduler) Cancel(kind, item string) error {
+	if s == nil {
+		return nil
+	}
+
+	if err := s.db.Where("kind = ? AND item = ?", kind, item).Delete(&model.ExpiryTimer{}).Error; err != nil {
+		return fmt.Errorf("failed to cancel expiry: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if timer, ok := s.index[expiryKey{kind, item}]; ok {
+		heap.Remove(&s.timers, timer.position)
+		delete(s.index, timer.expiryKey)
+	}
+	return nil
+}
+
+// Pending returns how many timers are held in memory
+func (s *ExpiryScheduler) Pending() int {
+	if s == nil {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.timers)
+}
+
+// Start loads the timers due soon and fires each at its time
+func (s *ExpiryScheduler) Start() {
+	if err := s.load(); err != nil {
+		log.Printf("⚠️  Failed to load expiry timers: %v", err)
+	}
+
+	go func() {
+		refresh := time.NewTicker(expiryRefresh)
+		defer refresh.Stop()
+		for {
+			wait := expiryRefresh
+			s.mu.Lock()
+			if len(s.timers) > 0 {
+				wait = time.Until(s.timers[0].at)
+			}
+			s.mu.Unlock()
+
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-s.wake:
+			case <-refresh.C:
+				if err := s.load(); err != nil {
+					log.Printf("⚠️  Failed to load expiry timers: %v", err)
+				}
+			}
+			timer.Stop()
+
+			s.fireDue()
+		}
+	}()
+}
+
+// load adds the persisted timers due within the horizon
+func (s *ExpiryScheduler) load() error {
+	var timers []model.ExpiryTimer
+	if err := s.db.Where("fire_at < ?", time.Now().Add(expiryHorizon)).Find(&timers).Error; err != nil {
+		return err
+	}
+	for _, timer := range timers {
+		s.push(expiryKey{timer.Kind, timer.Item}, timer.FireAt)
+	}
+	return nil
+}
+
+func (s *ExpiryScheduler) push(key expiryKey, at time.Time) {
+	s.mu.Lock()
+	if timer, ok := s.index[key]; ok {
+		timer.at = at
+		heap.Fix(&s.timers, timer.position)
+	} else {
+		timer := &expiryTimer{expiryKey: key, at: at}
+		heap.Push(&s.timers, timer)
+		s.index[key] = timer
+	}
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *ExpiryScheduler) fireDue() {
+	now := time.Now()
+	s.mu.Lock()
+	var due []expiryKey
+	for len(s.timers) > 0 && !s.timers[0].at.After(now) {
+		timer := heap.Pop(&s.timers).(*expiryTimer)
+		delete(s.index, timer.expiryKey)
+		due = append(due, timer.expiryKey)
+	}
+	s.mu.Unlock()
+
+	for _, key := range due {
+		go s.fire(key)
+	}
+}
+
+// fire claims a due timer, runs its handler and removes the timer once the
+// handler succeeded. A failed or interrupted handler runs again when the
+// claim lapses.
+func (s *ExpiryScheduler) fire(key expiryKey) {
+	s.mu.Lock()
+	handler, ok := s.handlers[key.kind]
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	claimedUntil := now.Add(expiryClaim).Truncate(time.Microsecond)
+	result := s.db.Model(&model.ExpiryTimer{}).
+		Where("kind = ? AND item = ? AND fire_at <= ?", key.kind, key.item, now).
+		Updates(map[string]interface{}{"fire_at": claimedUntil, "attempts": gorm.Expr("attempts + 1")})
+	if result.Error != nil {
+		log.Printf("⚠️  Failed to claim %s expiry %s: %v", key.kind, key.item, result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		// Cancelled, moved later, or claimed by another instance
+		return
+	}
+
+	ctx, cancel := context.WithDeadline(context.Background(), claimedUntil)
+	err := handler(ctx, key.item)
+	cancel()
+	if err != nil {
+		log.Printf("⚠️  Failed to expire %s %s: %v", key.kind, key.item, err)
+		s.push(key, claimedUntil)
+		return
+	}
+
+	// A timer moved while the handler ran is kept
+	if err := s.db.Where("kind = ? AND item = ? AND fire_at = ?", key.kind, key.item, claimedUntil).Delete(&model.ExpiryTimer{}).Error; err != nil {
+		log.Printf("⚠️  Failed to remove %s expiry %s: %v", key.kind, key.item, err)
+	}
+}
+
+type expiryKey struct {
+	kind string
+	item string
+}
+
+type expiryTimer struct {
+	expiryKey
+	at       time.Time
+	position int
+}
+
+// expiryHeap orders timers by fire time, earliest first
+type expiryHeap []*expiryTimer
+
+func (h expiryHeap) Len() int           { return len(h) }
+func (h expiryHeap) Less(i, j int) bool { return h[i].at.Before(h[j].at) }
+func (h expiryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].position = i
+	h[j].position = j
+}
+
+func (h *expiryHeap) Push(x interface{}) {
+	timer := x.(*expiryTimer)
+	timer.position = len(*h)
+	*h = append(*h, timer)
+}
+
+func (h *expiryHeap) Pop() interface{} {
+	old := *h
+	timer := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return timer
+}
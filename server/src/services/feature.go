@@ -0,0 +1,205 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/config"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Feature flags gating experimental subsystems
+const (
+	FeatureNamespaces  = "namespaces"
+	FeatureReplication = "replication"
+	FeaturePlugins     = "plugins"
+)
+
+// featureFlagRefresh is how often instances re-read overrides. An override
+// reaches every instance within this interval.
+const featureFlagRefresh = 15 * time.Second
+
+var featureDescriptions = map[string]string{
+	FeatureNamespaces:  "Multi-tenant namespaces, tenant keys and team envelopes",
+	FeatureReplication: "Cross-cluster replication (not yet available in this build)",
+	FeaturePlugins:     "External secret engine plugins (not yet available in this build)",
+}
+
+// FeatureFlagService decides whether a flagged subsystem is enabled. The
+// configuration sets each flag; an override stored in the database wins
+// until it is removed. Without a database only the configuration applies.
+type FeatureFlagService struct {
+	db           *gorm.DB
+	auditService *AuditService
+	configured   map[string]bool
+
+	mu        sync.RWMutex
+	overrides map[string]model.FeatureFlagOverride
+}
+
+func NewFeatureFlagService(db *gorm.DB, auditService *AuditService, cfg config.FeaturesConfig) *FeatureFlagService {
+	return &FeatureFlagService{
+		db:           db,
+		auditService: auditService,
+		configured: map[string]bool{
+			FeatureNamespaces:  cfg.Namespaces,
+			FeatureReplication: cfg.Replication,
+			FeaturePlugins:     cfg.Plugins,
+		},
+		overrides: make(map[string]model.FeatureFlagOverride),
+	}
+}
+
+// Start loads the overrides and keeps refreshing them in the background
+func (s *FeatureFlagService) Start() {
+	if s.db == nil {
+		return
+	}
+	if err := s.refresh(); err != nil {
+		log.Printf("⚠️  Failed to load feature flag overrides: %v", err)
+	}
+	go func() {
+		ticker := time.NewTicker(featureFlagRefresh)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := s.refresh(); err != nil {
+				log.Printf("⚠️  Failed to refresh feature flag overrides: %v", err)
+			}
+		}
+	}()
+}
+
+func (s *FeatureFlagService) refresh() error {
+	var rows []model.FeatureFlagOverride
+	if err := s.db.Find(&rows).Error; err != nil {
+		return fmt.Errorf("failed to get feature flag overrides: %w", err)
+	}
+
+	overrides := make(map[string]model.FeatureFlagOverride, len(rows))
+	for _, row := range rows {
+		overrides[row.Name] = row
+	}
+	s.mu.Lock()
+	s.overrides = overrides
+	s.mu.Unlock()
+	return nil
+}
+
+// Enabled reports whether a flag is on. A nil FeatureFlagService enables
+// everything, as before flags existed.
+func (s *FeatureFlagService) Enabled(name string) bool {
+	if s == nil {
+		return true
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if override, ok := s.overrides[name]; ok {
+		return override.Enabled
+	}
+	return s.configured[name]
+}
+
+// Snapshot returns the effective value of every flag
+func (s *FeatureFlagService) Snapshot() map[string]bool {
+	flags := make(map[string]bool, len(s.configured))
+	for name := range s.configured {
+		flags[name] = s.Enabled(name)
+	}
+	return flags
+}
+
+// GetFlags lists every flag by name
+func (s *FeatureFlagService) GetFlags() []model.FeatureFlagStatus {
+	names := make([]string, 0, len(s.configured))
+	for name := range s.configured {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	flags := make([]model.FeatureFlagStatus, 0, len(names))
+	for _, name := range names {
+		flags = append(flags, s.status(name))
+	}
+	return flags
+}
+
+func (s *FeatureFlagService) status(name string) model.FeatureFlagStatus {
+	status := model.FeatureFlagStatus{
+		Name:        name,
+		Description: featureDescriptions[name],
+		Enabled:     s.Enabled(name),
+		Configured:  s.configured[name],
+	}
+	s.mu.RLock()
+	if override, ok := s.overrides[name]; ok {
+		status.Override = &override
+	}
+	s.mu.RUnlock()
+	return status
+}
+
+// SetOverride turns a flag on or off for every instance
+func (s *FeatureFlagService) SetOverride(name string, enabled bool, userID uuid.UUID) (*model.FeatureFlagStatus, error) {
+	if _, ok := s.configured[name]; !ok {
+		return nil, ErrFeatureFlagNotFound
+	}
+	if s.db == nil {
+		return nil, ErrFeatureFlagsReadOnly
+	}
+
+	override := model.FeatureFlagOverride{Name: name, Enabled: enabled, UpdatedBy: &userID}
+	if err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_by", "updated_at"}),
+	}).Create(&override).Error; err != nil {
+		return nil, fmt.Errorf("failed to store feature flag override: %w", err)
+	}
+
+	s.mu.Lock()
+	s.overrides[name] = override
+	s.mu.Unlock()
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "feature_flag_overridden", "feature_flag", name, true, fmt.Sprintf("enabled=%t", enabled))
+	}
+
+	status := s.status(name)
+	return &status, nil
+}
+
+// ClearOverride returns a flag to its configured value
+func (s *FeatureFlagService) ClearOverride(name string, userID uuid.UUID) (*model.FeatureFlagStatus, error) {
+	if _, ok := s.configured[name]; !ok {
+		return nil, ErrFeatureFlagNotFound
+	}
+	if s.db == nil {
+		return nil, ErrFeatureFlagsReadOnly
+	}
+
+	if err := s.db.Where("name = ?", name).Delete(&model.FeatureFlagOverride{}).Error; err != nil {
+		return nil, fmt.Errorf("failed to delete feature flag override: %w", err)
+	}
+
+	s.mu.Lock()
+	delete(s.overrides, name)
+	s.mu.Unlock()
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "feature_flag_reset", "feature_flag", name, true, "")
+	}
+
+	status := s.status(name)
+	return &status, nil
+}
+
+var (
+	ErrFeatureFlagNotFound  = errors.New("feature flag not found")
+	ErrFeatureFlagsReadOnly = errors.New("feature flag overrides need a database")
+)
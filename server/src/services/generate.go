@@ -0,0 +1,316 @@
+package services
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	_ "embed"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+)
+
+const (
+	GenerateTypeBytes      = "bytes"
+	GenerateTypeUUID       = "uuid"
+	GenerateTypePassword   = "password"
+	GenerateTypePassphrase = "passphrase"
+	GenerateTypeKeypair    = "keypair"
+
+	defaultRandomBytes  = 32
+	maxRandomBytes      = 1024
+	defaultPassword     = 24
+	maxPasswordLength   = 256
+	defaultPassphrase   = 8
+	maxPassphraseWords  = 64
+	defaultRSABits      = 3072
+	defaultKeyAlgorithm = "ed25519"
+)
+
+//go:embed diceware_words.txt
+var dicewareWordList string
+
+var dicewareWords = strings.Fields(dicewareWordList)
+
+// passwordClasses are the characters generated passwords draw from; every
+// password has at least one of each
+var passwordClasses = []string{
+	"ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	"abcdefghijklmnopqrstuvwxyz",
+	"0123456789",
+	"!#$%&*+-.:=?@^_~",
+}
+
+// GeneratorService produces random material and keypairs server-side so
+// private keys never have to be generated on a client machine.
+type GeneratorService struct {
+	secretService  *SecretService
+	auditService   *AuditService
+	passwordPolicy *PasswordPolicyService
+}
+
+func NewGeneratorService(secretService *SecretService, auditService *AuditService) *GeneratorService {
+	return &GeneratorService{
+		secretService: secretService,
+		auditService:  auditService,
+	}
+}
+
+func (s *GeneratorService) Generate(req *model.GenerateRequest, userID uuid.UUID) (*model.GenerateResponse, error) {
+	var (
+		response *model.GenerateResponse
+		err      error
+	)
+
+	switch req.Type {
+	case GenerateTypeBytes:
+		response, err = s.generateBytes(req)
+	case GenerateTypeUUID:
+		response = &model.GenerateResponse{Type: req.Type, Value: uuid.NewString(), EntropyBits: 122}
+	case GenerateTypePassword:
+		response, err = s.generatePassword(req)
+	case GenerateTypePassphrase:
+		response, err = s.generatePassphrase(req)
+	case GenerateTypeKeypair:
+		response, err = s.generateKeypair(req)
+	default:
+		return nil, fmt.Errorf("%w: unknown type %q", ErrGenerateInvalid, req.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if s.passwordPolicy != nil && (req.Type == GenerateTypePassword || req.Type == GenerateTypePassphrase) {
+		if err := s.passwordPolicy.EnforceGenerated(response.Value); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.StoreAs != "" {
+		if err := s.store(req, response, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.auditService != nil {
+		details := fmt.Sprintf("type=%s stored=%t", req.Type, req.StoreAs != "")
+		s.auditService.LogAction(userID, "material_generated", "generate", "", true, details)
+	}
+
+	return response, nil
+}
+
+// store saves the sensitive half of the response as a secret and strips
+// it from the response
+func (s *GeneratorService) store(req *model.GenerateRequest, response *model.GenerateResponse, userID uuid.UUID) error {
+	if s.secretService == nil {
+		return ErrGenerateStoreUnavailable
+	}
+
+	secretType := model.SecretTypeOther
+	value := response.Value
+	if req.Type == GenerateTypeKeypair {
+		secretType = model.SecretTypeCertificate
+		value = response.PrivateKey
+	}
+
+	secret := &model.Secret{
+		Name:        req.StoreAs,
+		Description: fmt.Sprintf("Generated %s", req.Type),
+		Value:       value,
+		Type:        secretType,
+		IsActive:    true,
+	}
+
+	if err := s.secretService.CreateSecret(secret, userID); err != nil {
+		return err
+	}
+
+	response.SecretID = &secret.ID
+	response.Value = ""
+	response.PrivateKey = ""
+
+	return nil
+}
+
+func (s *GeneratorService) generateBytes(req *model.GenerateRequest) (*model.GenerateResponse, error) {
+	length := req.Length
+	if length == 0 {
+		length = defaultRandomBytes
+	}
+	if length < 1 || length > maxRandomBytes {
+		return nil, fmt.Errorf("%w: length must be between 1 and %d", ErrGenerateInvalid, maxRandomBytes)
+	}
+
+	buf := make([]byte, length)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("failed to read random bytes: %w", err)
+	}
+
+	var value string
+	switch req.Encoding {
+	case "", "base64":
+		value = base64.StdEncoding.EncodeToString(buf)
+	case "hex":
+		value = hex.EncodeToString(buf)
+	default:
+		return nil, fmt.Errorf("%w: unknown encoding %q", ErrGenerateInvalid, req.Encoding)
+	}
+
+	return &model.GenerateResponse{Type: req.Type, Value: value, EntropyBits: length * 8}, nil
+}
+
+// generatePassword draws a password with every character class, at least
+// as long as the password policy asks for
+func (s *GeneratorService) generatePassword(req *model.GenerateRequest) (*model.GenerateResponse, error) {
+	length := req.Length
+	if length == 0 {
+		length = defaultPassword
+		if s.passwordPolicy != nil && s.passwordPolicy.MinLength() > length {
+			length = s.passwordPolicy.MinLength()
+		}
+	}
+	if length < len(passwordClasses) || length > maxPasswordLength {
+		return nil, fmt.Errorf("%w: length must be between %d and %d", ErrGenerateInvalid, len(passwordClasses), maxPasswordLength)
+	}
+
+	alphabet := strings.Join(passwordClasses, "")
+	value := make([]byte, length)
+	for i := range value {
+		// the first characters come one from each class, the rest from
+		// all of them, and a shuffle hides which were which
+		pool := alphabet
+		if i < len(passwordClasses) {
+			pool = passwordClasses[i]
+		}
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(pool))))
+		if err != nil {
+			return nil, fmt.Errorf("failed to pick character: %w", err)
+		}
+		value[i] = pool[n.Int64()]
+	}
+	for i := len(value) - 1; i > 0; i-- {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to shuffle password: %w", err)
+		}
+		j := n.Int64()
+		value[i], value[j] = value[j], value[i]
+	}
+
+	entropy := int(float64(length) * math.Log2(float64(len(alphabet))))
+
+	return &model.GenerateResponse{Type: req.Type, Value: string(value), EntropyBits: entropy}, nil
+}
+
+func (s *GeneratorService) generatePassphrase(req *model.GenerateRequest) (*model.GenerateResponse, error) {
+	words := req.Words
+	if words == 0 {
+		words = defaultPassphrase
+	}
+	if words < 1 || words > maxPassphraseWords {
+		return nil, fmt.Errorf("%w: words must be between 1 and %d", ErrGenerateInvalid, maxPassphraseWords)
+	}
+
+	separator := req.Separator
+	if separator == "" {
+		separator = "-"
+	}
+
+	max := big.NewInt(int64(len(dicewareWords)))
+	chosen := make([]string, words)
+	for i := range chosen {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pick word: %w", err)
+		}
+		chosen[i] = dicewareWords[n.Int64()]
+	}
+
+	entropy := int(float64(words) * math.Log2(float64(len(dicewareWords))))
+
+	return &model.GenerateResponse{Type: req.Type, Value: strings.Join(chosen, separator), EntropyBits: entropy}, nil
+}
+
+func (s *GeneratorService) generateKeypair(req *model.GenerateRequest) (*model.GenerateResponse, error) {
+	algorithm := strings.ToLower(req.Algorithm)
+	if algorithm == "" {
+		algorithm = defaultKeyAlgorithm
+	}
+
+	var (
+		private interface{}
+		public  interface{}
+	)
+
+	switch algorithm {
+	case "rsa":
+		bits := req.Bits
+		if bits == 0 {
+			bits = defaultRSABits
+		}
+		if bits != 2048 && bits != 3072 && bits != 4096 {
+			return nil, fmt.Errorf("%w: rsa bits must be 2048, 3072 or 4096", ErrGenerateInvalid)
+		}
+		key, err := rsa.GenerateKey(keyReader, bits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate rsa key: %w", err)
+		}
+		private, public = key, &key.PublicKey
+	case "ecdsa":
+		var curve elliptic.Curve
+		switch strings.ToLower(req.Curve) {
+		case "", "p256", "p-256":
+			curve = elliptic.P256()
+		case "p384", "p-384":
+			curve = elliptic.P384()
+		case "p521", "p-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("%w: unknown curve %q", ErrGenerateInvalid, req.Curve)
+		}
+		key, err := ecdsa.GenerateKey(curve, keyReader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate ecdsa key: %w", err)
+		}
+		private, public = key, &key.PublicKey
+	case "ed25519":
+		pub, key, err := ed25519.GenerateKey(keyReader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate ed25519 key: %w", err)
+		}
+		private, public = key, pub
+	default:
+		return nil, fmt.Errorf("%w: unknown algorithm %q", ErrGenerateInvalid, req.Algorithm)
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode private key: %w", err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode public key: %w", err)
+	}
+
+	return &model.GenerateResponse{
+		Type:       req.Type,
+		PublicKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})),
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER})),
+	}, nil
+}
+
+var (
+	ErrGenerateInvalid          = errors.New("invalid generate request")
+	ErrGenerateStoreUnavailable = errors.New("secret storage is unavailable")
+)
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+)
+
+const (
+	// Impersonation is for debugging sessions, not standing access
+	impersonationDefaultTTL = 15 * time.Minute
+	impersonationMaxTTL     = time.Hour
+
+	// impersonationMinReason is the shortest reason accepted, so that the
+	// audit trail says something
+	impersonationMinReason = 10
+
+	// ImpersonationPolicy is the policy path granting impersonation; the
+	// user ID is appended, so policies can allow sys/impersonate/* or
+	// single users. The central admin needs it like everyone else.
+	ImpersonationPolicy = "sys/impersonate"
+)
+
+// ImpersonationService lets administrators act as another user for a
+// limited time. Every session needs an explicit policy grant and a reason,
+// and is audited under both users.
+type ImpersonationService struct {
+	db            *gorm.DB
+	authService   *AuthService
+	userService   *UserService
+	policyService *PolicyService
+	auditService  *AuditService
+	notifications *NotificationService
+}
+
+func NewImpersonationService(db *gorm.DB, authService *AuthService, userService *UserService, policyService *PolicyService, auditService *AuditService, notifications *NotificationService) *ImpersonationService {
+	return &ImpersonationService{
+		db:            db,
+		authService:   authService,
+		userService:   userService,
+		policyService: policyService,
+		auditService:  auditService,
+		notifications: notifications,
+	}
+}
+
+// Start opens a session in which adminID acts as req.UserID and mints its
+// token, as a child of the admin's token parentID when there is one
+func (s *ImpersonationService) Start(req *model.StartImpersonationRequest, adminID uuid.UUID, parentID *uuid.UUID) (*model.ImpersonationResponse, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if len(reason) < impersonationMinReason {
+		return nil, fmt.Errorf("%w: at least %d characters", ErrImpersonationReason, impersonationMinReason)
+	}
+	ttl := impersonationDefaultTTL
+	if req.TTL > 0 {
+		ttl = time.Duration(req.TTL) * time.Second
+	}
+	if ttl > impersonationMaxTTL {
+		return nil, fmt.Errorf("%w: at most %d seconds", ErrImpersonationTTLTooLong, int(impersonationMaxTTL.Seconds()))
+	}
+	if req.UserID == adminID {
+		return nil, ErrImpersonationSelf
+	}
+
+	admin, err := s.userService.GetUserByID(adminID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.userService.GetUserByID(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if IsCentralAdmin(target) {
+		return nil, ErrImpersonationForbidden
+	}
+
+	allowed := false
+	if s.policyService != nil {
+		allowed, err = s.policyService.CheckAccess(adminID, ImpersonationPolicy+"/"+target.ID.String(), "create")
+		if err != nil {
+			return nil, fmt.Errorf("failed to check policies: %w", err)
+		}
+	}
+	if !allowed {
+		if s.auditService != nil {
+			s.auditService.LogAction(adminID, "impersonation_denied", "user", target.ID.String(), false, fmt.Sprintf("reason=%s", reason))
+		}
+		return nil, ErrImpersonationDenied
+	}
+
+	impersonation := &model.Impersonation{
+		AdminID:    admin.ID,
+		AdminEmail: admin.Email,
+		UserID:     target.ID,
+		UserEmail:  target.Email,
+		Reason:     reason,
+		Notified:   req.Notify && s.notifications != nil,
+		ExpiresAt:  time.Now().Add(ttl),
+	}
+	if err := s.db.Create(impersonation).Error; err != nil {
+		return nil, fmt.Errorf("failed to create impersonation: %w", err)
+	}
+
+	token, err := s.authService.generateImpersonationToken(impersonation, parentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	if s.auditService != nil {
+		details := fmt.Sprintf("impersonation=%s; user=%s; expires=%s; reason=%s", impersonation.ID, target.Email, impersonation.ExpiresAt.Format(time.RFC3339), reason)
+		s.auditService.LogAction(admin.ID, "impersonation_started", "user", target.ID.String(), true, details)
+		s.auditService.LogAction(target.ID, "impersonated", "user", admin.ID.String(), true, fmt.Sprintf("impersonation=%s; admin=%s; reason=%s", impersonation.ID, admin.Email, reason))
+	}
+	s.announce(impersonation)
+
+	return &model.ImpersonationResponse{
+		Token:         token,
+		ExpiresAt:     impersonation.ExpiresAt,
+		Impersonation: *impersonation,
+	}, nil
+}
+
+// announce publishes the session as a security event and, when asked,
+// tells the impersonated user
+func (s *ImpersonationService) announce(impersonation *model.Impersonation) {
+	if s.notifications == nil {
+		return
+	}
+
+	if impersonation.Notified {
+		s.notifications.SecurityAlert(impersonation.UserID, "Administrator access by "+impersonation.AdminEmail,
+			fmt.Sprintf("%s is acting as you until %s. Reason: %s", impersonation.AdminEmail, impersonation.ExpiresAt.Format(time.RFC1123), impersonation.Reason))
+	}
+
+	go func() {
+		if err := s.notifications.Publish(model.NewEvent(model.EventImpersonationStarted, map[string]interface{}{
+			"impersonation_id": impersonation.ID,
+			"admin_id":         impersonation.AdminID,
+			"admin_email":      impersonation.AdminEmail,
+			"user_id":          impersonation.UserID,
+			"user_email":       impersonation.UserEmail,
+			"reason":           impersonation.Reason,
+			"expires_at":       impersonation.ExpiresAt,
+		})); err != nil {
+			log.Printf("⚠️  Failed to publish impersonation %s: %v", impersonation.ID, err)
+		}
+	}()
+}
+
+// End stops a session ahead of its expiry; its tokens are rejected from
+// then on
+func (s *ImpersonationService) End(id uuid.UUID, endedBy uuid.UUID) (*model.Impersonation, error) {
+	now := time.Now()
+	result := s.db.Model(&model.Impersonation{}).
+		Where("id = ? AND ended_at IS NULL AND expires_at > ?", id, now).
+		Updates(map[string]interface{}{"ended_at": now, "ended_by": endedBy})
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to end impersonation: %w", result.Error)
+	}
+
+	var impersonation model.Impersonation
+	if err := s.db.First(&impersonation, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrImpersonationNotFound
+		}
+		return nil, fmt.Errorf("failed to get impersonation: %w", err)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrImpersonationEnded
+	}
+
+	if s.auditService != nil {
+		details := fmt.Sprintf("impersonation=%s; admin=%s; user=%s", impersonation.ID, impersonation.AdminEmail, impersonation.UserEmail)
+		s.auditService.LogAction(endedBy, "impersonation_ended", "user", impersonation.UserID.String(), true, details)
+	}
+	return &impersonation, nil
+}
+
+// GetImpersonations lists sessions newest first, only live ones when
+// activeOnly is set
+func (s *ImpersonationService) GetImpersonations(activeOnly bool) ([]model.Impersonation, error) {
+	query := s.db.Order("created_at DESC").Limit(100)
+	if activeOnly {
+		query = query.Where("ended_at IS NULL AND expires_at > ?", time.Now())
+	}
+
+	impersonations := []model.Impersonation{}
+	if err := query.Find(&impersonations).Error; err != nil {
+		return nil, fmt.Errorf("failed to get impersonations: %w", err)
+	}
+	return impersonations, nil
+}
+
+// activeSession returns the live session a token names for userID
+func (s *ImpersonationService) activeSession(id string, userID uuid.UUID) (*model.Impersonation, error) {
+	impersonationID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid impersonation ID format: %w", err)
+	}
+
+	var impersonation model.Impersonation
+	if err := s.db.First(&impersonation, "id = ?", impersonationID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrImpersonationNotFound
+		}
+		return nil, fmt.Errorf("failed to get impersonation: %w", err)
+	}
+	if impersonation.UserID != userID || !impersonation.Active(time.Now()) {
+		return nil, ErrImpersonationEnded
+	}
+	return &impersonation, nil
+}
+
+var (
+	ErrImpersonationReason     = errors.New("impersonation needs a reason")
+	ErrImpersonationTTLTooLong = errors.New("impersonation TTL too long")
+	ErrImpersonationSelf       = errors.New("cannot impersonate yourself")
+	ErrImpersonationForbidden  = errors.New("the central administrator cannot be impersonated")
+	ErrImpersonationDenied     = errors.New("no policy allows impersonating this user")
+	ErrImpersonationNested     = errors.New("cannot impersonate while impersonating")
+	ErrImpersonationNotFound   = errors.New("impersonation not found")
+	ErrImpersonationEnded      = errors.New("impersonation has ended")
+)
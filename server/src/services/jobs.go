@@ -0,0 +1,413 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/config"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const leaderLease = "leader"
+
+// Job is a background maintenance task. Run reports how many items it
+// processed and a short description of what it did.
+type Job struct {
+	Name        string
+	Description string
+	// Interval between scheduled runs; zero runs the job only on demand
+	Interval time.Duration
+	// Disabled jobs refuse to run, e.g. audit archival without a directory
+	Disabled bool
+	Run      func(ctx context.Context) (int64, string, error)
+}
+
+// JobService schedules maintenance jobs. Instances sharing a database
+// elect a leader through a lease row; only the leader runs scheduled jobs.
+// Every run, scheduled or on demand, also holds a per-job lease so a job
+// never runs twice at once.
+type JobService struct {
+	db           *gorm.DB
+	auditService *AuditService
+	feed         *EventFeed
+	instance     string
+	leaseTTL     time.Duration
+	historyLimit int
+
+	mu     sync.Mutex
+	jobs   []*Job
+	leader bool
+}
+
+func NewJobService(db *gorm.DB, auditService *AuditService, cfg config.JobsConfig) *JobService {
+	return &JobService{
+		db:           db,
+		auditService: auditService,
+		instance:     instanceName(),
+		leaseTTL:     time.Duration(cfg.LeaseTTL) * time.Second,
+		historyLimit: cfg.HistoryLimit,
+	}
+}
+
+// UseFeed announces job starts and results on the live event feed
+func (s *JobService) UseFeed(feed *EventFeed) {
+	s.feed = feed
+}
+
+// Register adds a job; call it before Start
+func (s *JobService) Register(job *Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, job)
+}
+
+// Start campaigns for leadership and, while leader, runs due jobs
+func (s *JobService) Start() {
+	go func() {
+		ticker := time.NewTicker(s.leaseTTL / 3)
+		defer ticker.Stop()
+		s.schedule()
+		for range ticker.C {
+			s.schedule()
+		}
+	}()
+}
+
+func (s *JobService) schedule() {
+	leader, err := s.acquireLease(leaderLease)
+	if err != nil {
+		log.Printf("⚠️  Failed to renew job leadership: %v", err)
+		leader = false
+	}
+
+	s.mu.Lock()
+	if leader != s.leader {
+		if leader {
+			log.Printf("🧹 Instance %s is now running maintenance jobs", s.instance)
+		} else {
+			log.Printf("🧹 Instance %s is no longer running maintenance jobs", s.instance)
+		}
+	}
+	s.leader = leader
+	jobs := append([]*Job(nil), s.jobs...)
+	s.mu.Unlock()
+
+	if !leader {
+		return
+	}
+
+	for _, job := range jobs {
+		if job.Disabled || job.Interval <= 0 {
+			continue
+		}
+
+		last, err := s.lastRun(job.Name)
+		if err != nil {
+			log.Printf("⚠️  Failed to check job %s: %v", job.Name, err)
+			continue
+		}
+		if last != nil && time.Since(last.StartedAt) < job.Interval {
+			continue
+		}
+
+		run, err := s.begin(job, model.JobTriggerSchedule, nil)
+		if errors.Is(err, ErrJobRunning) {
+			continue
+		}
+		if err != nil {
+			log.Printf("⚠️  Failed to start job %s: %v", job.Name, err)
+			continue
+		}
+		go s.execute(job, run)
+	}
+}
+
+// RunNow starts a job immediately on this instance and returns its run
+// while the job continues in the background
+func (s *JobService) RunNow(name string, userID uuid.UUID) (*model.JobRun, error) {
+	job := s.job(name)
+	if job == nil {
+		return nil, ErrJobNotFound
+	}
+	if job.Disabled {
+		return nil, ErrJobDisabled
+	}
+
+	run, err := s.begin(job, model.JobTriggerManual, &userID)
+	if err != nil {
+		return nil, err
+	}
+	go s.execute(job, run)
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "job_triggered", "job", name, true, run.ID.String())
+	}
+
+	return run, nil
+}
+
+// GetJobs lists registered jobs with their latest run and the current leader
+func (s *JobService) GetJobs() (*model.JobsResponse, error) {
+	s.mu.Lock()
+	jobs := append([]*Job(nil), s.jobs...)
+	isLeader := s.leader
+	s.mu.Unlock()
+
+	response := &model.JobsResponse{Instance: s.instance, IsLeader: isLeader, Jobs: []model.JobStatus{}}
+
+	var lease model.JobLease
+	if err := s.db.Where("name = ? AND expires_at > ?", leaderLease, time.Now()).First(&lease).Error; err == nil {
+		response.Leader = lease.Holder
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get job leader: %w", err)
+	}
+
+	for _, job := range jobs {
+		status := model.JobStatus{
+			Name:        job.Name,
+			Description: job.Description,
+			Enabled:     !job.Disabled,
+			Interval:    int(job.Interval / time.Second),
+		}
+
+		last, err := s.lastRun(job.Name)
+		if err != nil {
+			return nil, err
+		}
+		status.LastRun = last
+		if !job.Disabled && job.Interval > 0 {
+			next := time.Now()
+			if last != nil && last.StartedAt.Add(job.Interval).After(next) {
+				next = last.StartedAt.Add(job.Interval)
+			}
+			status.NextRunAt = &next
+		}
+
+		response.Jobs = append(response.Jobs, status)
+	}
+
+	return response, nil
+}
+
+// GetRuns returns a job's run history, newest first
+func (s *JobService) GetRuns(name string, limit, offset int) ([]model.JobRun, error) {
+	if s.job(name) == nil {
+		return nil, ErrJobNotFound
+	}
+
+	var runs []model.JobRun
+	if err := s.db.Where("job = ?", name).Order("started_at DESC").Limit(limit).Offset(offset).Find(&runs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get job runs: %w", err)
+	}
+	return runs, nil
+}
+
+// begin takes the job's lease and records the run. A run still marked
+// running when the lease is free was interrupted by a crash.
+func (s *JobService) begin(job *Job, trigger model.JobTrigger, triggeredBy *uuid.UUID) (*model.JobRun, error) {
+	acquired, err := s.acquireLease(jobLease(job.Name))
+	if err != nil {
+		return nil, err
+	}
+	if !acquired {
+		return nil, ErrJobRunning
+	}
+
+	now := time.Now()
+	if err := s.db.Model(&model.JobRun{}).
+		Where("job = ? AND status = ?", job.Name, model.JobRunStatusRunning).
+		Updates(map[string]interface{}{"status": model.JobRunStatusFailed, "error": "interrupted", "finished_at": now}).Error; err != nil {
+		s.releaseLease(jobLease(job.Name))
+		return nil, fmt.Errorf("failed to close interrupted job runs: %w", err)
+	}
+
+	run := &model.JobRun{
+		Job:         job.Name,
+		Trigger:     trigger,
+		Status:      model.JobRunStatusRunning,
+		Instance:    s.instance,
+		TriggeredBy: triggeredBy,
+		StartedAt:   now,
+	}
+	if err := s.db.Create(run).Error; err != nil {
+		s.releaseLease(jobLease(job.Name))
+		return nil, fmt.Errorf("failed to record job run: %w", err)
+	}
+
+	s.feed.Publish(&model.FeedEvent{
+		Class:      model.FeedClassJob,
+		Type:       "job_started",
+		ActorID:    triggeredBy,
+		Resource:   "job",
+		ResourceID: job.Name,
+		Data: map[string]interface{}{
+			"run_id":   run.ID,
+			"trigger":  run.Trigger,
+			"instance": run.Instance,
+		},
+	})
+
+	return run, nil
+}
+
+// execute runs the job, renewing its lease until it finishes
+func (s *JobService) execute(job *Job, run *model.JobRun) {
+	ctx, cancel := context.WithCancel(context.Background())
+	heartbeat := make(chan struct{})
+	go func() {
+		defer close(heartbeat)
+		ticker := time.NewTicker(s.leaseTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				// Losing the lease means another instance may start the
+				// job; stop rather than run it twice
+				if held, err := s.acquireLease(jobLease(job.Name)); err != nil || !held {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	processed, detail, err := job.Run(ctx)
+	cancel()
+	<-heartbeat
+	defer s.releaseLease(jobLease(job.Name))
+
+	finished := time.Now()
+	updates := map[string]interface{}{
+		"status":      model.JobRunStatusSucceeded,
+		"processed":   processed,
+		"detail":      detail,
+		"finished_at": finished,
+	}
+	if err != nil {
+		updates["status"] = model.JobRunStatusFailed
+		updates["error"] = err.Error()
+		log.Printf("⚠️  Job %s failed: %v", job.Name, err)
+	} else if processed > 0 {
+		log.Printf("🧹 Job %s: %s", job.Name, detail)
+	}
+	if dbErr := s.db.Model(&model.JobRun{}).Where("id = ?", run.ID).Updates(updates).Error; dbErr != nil {
+		log.Printf("⚠️  Failed to record job %s result: %v", job.Name, dbErr)
+	}
+
+	if s.auditService != nil {
+		details := detail
+		if err != nil {
+			details = err.Error()
+		}
+		s.auditService.LogAnonymousAction("job_run", "job", job.Name, "", "", err == nil, details)
+	}
+
+	succeeded := err == nil
+	event := &model.FeedEvent{
+		Class:      model.FeedClassJob,
+		Type:       "job_finished",
+		Resource:   "job",
+		ResourceID: job.Name,
+		Success:    &succeeded,
+		Details:    detail,
+		Data: map[string]interface{}{
+			"run_id":      run.ID,
+			"status":      updates["status"],
+			"processed":   processed,
+			"duration_ms": finished.Sub(run.StartedAt).Milliseconds(),
+		},
+	}
+	if err != nil {
+		event.Data["error"] = err.Error()
+	}
+	s.feed.Publish(event)
+
+	s.pruneHistory(job.Name)
+}
+
+// acquireLease takes or renews the named lease for this instance
+func (s *JobService) acquireLease(name string) (bool, error) {
+	if err := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.JobLease{Name: name}).Error; err != nil {
+		return false, fmt.Errorf("failed to create job lease: %w", err)
+	}
+
+	now := time.Now()
+	result := s.db.Model(&model.JobLease{}).
+		Where("name = ? AND (holder = ? OR expires_at < ?)", name, s.instance, now).
+		Updates(map[string]interface{}{"holder": s.instance, "expires_at": now.Add(s.leaseTTL)})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to acquire job lease: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (s *JobService) releaseLease(name string) {
+	if err := s.db.Model(&model.JobLease{}).
+		Where("name = ? AND holder = ?", name, s.instance).
+		Update("expires_at", time.Time{}).Error; err != nil {
+		log.Printf("⚠️  Failed to release job lease %s: %v", name, err)
+	}
+}
+
+func (s *JobService) lastRun(name string) (*model.JobRun, error) {
+	var run model.JobRun
+	if err := s.db.Where("job = ?", name).Order("started_at DESC").First(&run).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get last job run: %w", err)
+	}
+	return &run, nil
+}
+
+// pruneHistory keeps the newest historyLimit runs of a job
+func (s *JobService) pruneHistory(name string) {
+	keep := s.db.Model(&model.JobRun{}).Select("id").Where("job = ?", name).Order("started_at DESC").Limit(s.historyLimit)
+	if err := s.db.Where("job = ? AND id NOT IN (?)", name, keep).Delete(&model.JobRun{}).Error; err != nil {
+		log.Printf("⚠️  Failed to prune job %s history: %v", name, err)
+	}
+}
+
+func (s *JobService) job(name string) *Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, job := range s.jobs {
+		if job.Name == name {
+			return job
+		}
+	}
+	return nil
+}
+
+func jobLease(name string) string {
+	return "job:" + name
+}
+
+// instanceName identifies this process in leases and run records
+func instanceName() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "vault"
+	}
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return host + "-" + hex.EncodeToString(suffix)
+}
+
+var (
+	ErrJobNotFound = errors.New("job not found")
+	ErrJobRunning  = errors.New("job is already running")
+	ErrJobDisabled = errors.New("job is disabled by configuration")
+)
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// KeyWrapper wraps and unwraps data keys with an external key encryption
+// key that never leaves its KMS
+type KeyWrapper interface {
+	Wrap(plaintext []byte) (string, error)
+	Unwrap(wrapped string) ([]byte, error)
+}
+
+// NewKeyWrapper returns the wrapper for a tenant key URI:
+//
+//	file:///etc/vault/tenant.key   32-byte key (raw, hex or base64) on a mounted volume or HSM export
+//	https://kms.example.com/keys/a  HTTP KMS exposing POST {uri}/wrap and {uri}/unwrap
+func NewKeyWrapper(keyURI string) (KeyWrapper, error) {
+	parsed, err := url.Parse(keyURI)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKMSKeyURIInvalid, err)
+	}
+
+	switch parsed.Scheme {
+	case "file":
+		return &fileKeyWrapper{path: parsed.Path}, nil
+	case "https", "http":
+		return &httpKeyWrapper{
+			endpoint: strings.TrimSuffix(keyURI, "/"),
+			client:   &http.Client{Timeout: 10 * time.Second},
+		}, nil
+	case "arn", "aws-kms", "gcp-kms", "azure-kv":
+		return nil, fmt.Errorf("%w: %s", ErrKMSProviderUnsupported, parsed.Scheme)
+	default:
+		return nil, fmt.Errorf("%w: unknown scheme %q", ErrKMSKeyURIInvalid, parsed.Scheme)
+	}
+}
+
+type fileKeyWrapper struct {
+	path string
+}
+
+func (w *fileKeyWrapper) aead() (cipher.AEAD, error) {
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKMSUnavailable, err)
+	}
+
+	key := bytes.TrimSpace(data)
+	if len(key) != 32 {
+		if decoded, err := hex.DecodeString(string(key)); err == nil && len(decoded) == 32 {
+			key = decoded
+		} else if decoded, err := base64.StdEncoding.DecodeString(string(key)); err == nil && len(decoded) == 32 {
+			key = decoded
+		} else {
+			return nil, fmt.Errorf("%w: key file must hold 32 bytes", ErrKMSKeyURIInvalid)
+		}
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (w *fileKeyWrapper) Wrap(plaintext []byte) (string, error) {
+	gcm, err := w.aead()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, plaintext, nil)), nil
+}
+
+func (w *fileKeyWrapper) Unwrap(wrapped string) ([]byte, error) {
+	gcm, err := w.aead()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(wrapped)
+	if err != nil || len(data) < gcm.NonceSize() {
+		return nil, ErrKMSUnwrapFailed
+	}
+
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, ErrKMSUnwrapFailed
+	}
+	return plaintext, nil
+}
+
+type httpKeyWrapper struct {
+	endpoint string
+	client   *http.Client
+}
+
+func (w *httpKeyWrapper) call(operation string, request, response interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.client.Post(w.endpoint+"/"+operation, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrKMSUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("%w: %s returned %d", ErrKMSUnavailable, operation, resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("kms %s returned %d", operation, resp.StatusCode)
+	}
+
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(response)
+}
+
+func (w *httpKeyWrapper) Wrap(plaintext []byte) (string, error) {
+	var response struct {
+		Ciphertext string `json:"ciphertext"`
+	}
+	if err := w.call("wrap", map[string]string{"plaintext": base64.StdEncoding.EncodeToString(plaintext)}, &response); err != nil {
+		return "", err
+	}
+	if response.Ciphertext == "" {
+		return "", errors.New("kms returned an empty ciphertext")
+	}
+	return response.Ciphertext, nil
+}
+
+func (w *httpKeyWrapper) Unwrap(wrapped string) ([]byte, error) {
+	var response struct {
+		Plaintext string `json:"plaintext"`
+	}
+	if err := w.call("unwrap", map[string]string{"ciphertext": wrapped}, &response); err != nil {
+		return nil, err
+	}
+
+	plaintext, err := base64.StdEncoding.DecodeString(response.Plaintext)
+	if err != nil {
+		return nil, ErrKMSUnwrapFailed
+	}
+	return plaintext, nil
+}
+
+var (
+	ErrKMSKeyURIInvalid       = errors.New("invalid tenant key URI")
+	ErrKMSProviderUnsupported = errors.New("KMS provider is not supported by this build")
+	ErrKMSUnavailable         = errors.New("tenant KMS is unavailable")
+	ErrKMSUnwrapFailed        = errors.New("tenant KMS could not unwrap the data key")
+)
@@ -0,0 +1,469 @@
+package services
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/config"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+)
+
+const kubernetesKeysTTL = time.Hour
+
+// KubernetesAuthService signs pods in with their service account token.
+// The token is checked with the cluster's TokenReview API, or against the
+// cluster's key set when one is configured; the namespace and service
+// account it names must be bound to the role asked for.
+type KubernetesAuthService struct {
+	db           *gorm.DB
+	authService  *AuthService
+	auditService *AuditService
+	cfg          config.KubernetesConfig
+	client       *http.Client
+	keys         *ttlCache
+}
+
+// KubernetesServiceAccount is who a verified token belongs to
+type KubernetesServiceAccount struct {
+	Namespace string
+	Name      string
+	UID       string
+}
+
+func NewKubernetesAuthService(db *gorm.DB, cfg config.KubernetesConfig, authService *AuthService, auditService *AuditService) (*KubernetesAuthService, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.CAFile != "" {
+		bundle, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Kubernetes CA file: %w", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("Kubernetes CA file %s holds no PEM certificates", cfg.CAFile)
+		}
+	}
+
+	return &KubernetesAuthService{
+		db:           db,
+		authService:  authService,
+		auditService: auditService,
+		cfg:          cfg,
+		client: &http.Client{
+			Timeout:   time.Duration(cfg.Timeout) * time.Second,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+		keys: newTTLCache(kubernetesKeysTTL),
+	}, nil
+}
+
+func (s *KubernetesAuthService) CreateRole(req *model.CreateKubernetesRoleRequest, userID uuid.UUID) (*model.KubernetesRole, error) {
+	if !machineRoleName.MatchString(req.Name) {
+		return nil, fmt.Errorf("%w: names use lowercase letters, digits, - and _", ErrKubernetesRoleInvalid)
+	}
+	role := &model.KubernetesRole{
+		ID:                       uuid.New(),
+		Name:                     req.Name,
+		BoundServiceAccountNames: req.BoundServiceAccountNames,
+		BoundNamespaces:          req.BoundNamespaces,
+		Audience:                 req.Audience,
+		Policies:                 uniqueUserIDs(req.Policies),
+		CreatedBy:                userID,
+	}
+	if err := s.checkRole(role); err != nil {
+		return nil, err
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.KubernetesRole{}).Where("name = ?", req.Name).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to check role name: %w", err)
+		}
+		if count > 0 {
+			return ErrKubernetesRoleExists
+		}
+
+		user, err := createServiceUser(tx, model.AuthMethodKubernetes, role.ID, role.Name)
+		if err != nil {
+			return err
+		}
+		role.UserID = user.ID
+		if err := tx.Create(role).Error; err != nil {
+			return fmt.Errorf("failed to create role: %w", err)
+		}
+		return syncSourcePolicies(tx, user.ID, model.AuthMethodKubernetes, role.Policies)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "kubernetes_role_created", "kubernetes_role", role.ID.String(), true, "name="+role.Name)
+	}
+	return role, nil
+}
+
+func (s *KubernetesAuthService) GetRoles() ([]model.KubernetesRole, error) {
+	var roles []model.KubernetesRole
+	if err := s.db.Order("name").Find(&roles).Error; err != nil {
+		return nil, fmt.Errorf("failed to get roles: %w", err)
+	}
+	return roles, nil
+}
+
+func (s *KubernetesAuthService) GetRole(name string) (*model.KubernetesRole, error) {
+	var role model.KubernetesRole
+	if err := s.db.Where("name = ?", name).First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrKubernetesRoleNotFound
+		}
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+	return &role, nil
+}
+
+func (s *KubernetesAuthService) UpdateRole(name string, req *model.UpdateKubernetesRoleRequest, userID uuid.UUID) (*model.KubernetesRole, error) {
+	role, err := s.GetRole(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.BoundServiceAccountNames != nil {
+		role.BoundServiceAccountNames = *req.BoundServiceAccountNames
+	}
+	if req.BoundNamespaces != nil {
+		role.BoundNamespaces = *req.BoundNamespaces
+	}
+	if req.Audience != nil {
+		role.Audience = *req.Audience
+	}
+	if req.Policies != nil {
+		role.Policies = uniqueUserIDs(*req.Policies)
+	}
+	if err := s.checkRole(role); err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(role).Error; err != nil {
+			return fmt.Errorf("failed to update role: %w", err)
+		}
+		return syncSourcePolicies(tx, role.UserID, model.AuthMethodKubernetes, role.Policies)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "kubernetes_role_updated", "kubernetes_role", role.ID.String(), true, "name="+role.Name)
+	}
+	return role, nil
+}
+
+// DeleteRole removes the role and its service user. Tokens already issued
+// to the role lose its policies at once.
+func (s *KubernetesAuthService) DeleteRole(name string, userID uuid.UUID) error {
+	role, err := s.GetRole(name)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND source = ?", role.UserID, model.AuthMethodKubernetes).Delete(&model.PolicyAssignment{}).Error; err != nil {
+			return fmt.Errorf("failed to detach policies: %w", err)
+		}
+		if err := tx.Where("id = ?", role.UserID).Delete(&model.User{}).Error; err != nil {
+			return fmt.Errorf("failed to delete service user: %w", err)
+		}
+		if err := tx.Delete(role).Error; err != nil {
+			return fmt.Errorf("failed to delete role: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "kubernetes_role_deleted", "kubernetes_role", role.ID.String(), true, "name="+role.Name)
+	}
+	return nil
+}
+
+// Login verifies a service account token and returns a token of the
+// role's service user, which carries only the role's policies
+func (s *KubernetesAuthService) Login(roleName, raw string) (*model.LoginResponse, *KubernetesServiceAccount, error) {
+	if !s.cfg.Enabled {
+		return nil, nil, ErrKubernetesDisabled
+	}
+	role, err := s.GetRole(roleName)
+	if errors.Is(err, ErrKubernetesRoleNotFound) {
+		return nil, nil, fmt.Errorf("%w: unknown role %s", ErrKubernetesLoginFailed, roleName)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	audience := role.Audience
+	if audience == "" {
+		audience = s.cfg.Audience
+	}
+	var account *KubernetesServiceAccount
+	if s.cfg.JWKSURL != "" {
+		account, err = s.verifyToken(raw, audience)
+	} else {
+		account, err = s.reviewToken(raw, audience)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if !kubernetesBound(role.BoundNamespaces, account.Namespace) || !kubernetesBound(role.BoundServiceAccountNames, account.Name) {
+		return nil, account, fmt.Errorf("%w: %s/%s is not bound to role %s", ErrKubernetesLoginFailed, account.Namespace, account.Name, role.Name)
+	}
+
+	user, err := s.authService.userService.GetUserByID(role.UserID)
+	if err != nil {
+		return nil, account, fmt.Errorf("%w: the role's service user is disabled", ErrKubernetesLoginFailed)
+	}
+	token, expiresAt, err := s.authService.generateToken(user.ID, model.AuthMethodKubernetes)
+	if err != nil {
+		return nil, account, fmt.Errorf("failed to generate token: %w", err)
+	}
+	return &model.LoginResponse{
+		Token:     token,
+		ExpiresAt: expiresAt,
+		User:      *user,
+	}, account, nil
+}
+
+// reviewToken asks the API server whether the token is valid and whose it
+// is
+func (s *KubernetesAuthService) reviewToken(raw, audience string) (*KubernetesServiceAccount, error) {
+	spec := map[string]interface{}{"token": raw}
+	if audience != "" {
+		spec["audiences"] = []string{audience}
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"apiVersion": "authentication.k8s.io/v1",
+		"kind":       "TokenReview",
+		"spec":       spec,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode token review: %w", err)
+	}
+
+	request, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(s.cfg.Host, "/")+"/apis/authentication.k8s.io/v1/tokenreviews", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build token review: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	reviewer, err := s.reviewerToken(raw)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Authorization", "Bearer "+reviewer)
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKubernetesUnavailable, err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK && response.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("%w: token review returned %d", ErrKubernetesUnavailable, response.StatusCode)
+	}
+
+	var review struct {
+		Status struct {
+			Authenticated bool   `json:"authenticated"`
+			Error         string `json:"error"`
+			User          struct {
+				Username string `json:"username"`
+				UID      string `json:"uid"`
+			} `json:"user"`
+		} `json:"status"`
+	}
+	if err := json.NewDecoder(io.LimitReader(response.Body, 1<<20)).Decode(&review); err != nil {
+		return nil, fmt.Errorf("%w: invalid token review: %v", ErrKubernetesUnavailable, err)
+	}
+	if !review.Status.Authenticated {
+		return nil, fmt.Errorf("%w: token review refused the token: %s", ErrKubernetesLoginFailed, review.Status.Error)
+	}
+
+	// Service account users are system:serviceaccount:<namespace>:<name>
+	parts := strings.Split(review.Status.User.Username, ":")
+	if len(parts) != 4 || parts[0] != "system" || parts[1] != "serviceaccount" {
+		return nil, fmt.Errorf("%w: %s is not a service account", ErrKubernetesLoginFailed, review.Status.User.Username)
+	}
+	return &KubernetesServiceAccount{Namespace: parts[2], Name: parts[3], UID: review.Status.User.UID}, nil
+}
+
+// reviewerToken is read on every review, since projected tokens are
+// rotated on disk
+func (s *KubernetesAuthService) reviewerToken(raw string) (string, error) {
+	if s.cfg.TokenReviewerJWTFile == "" {
+		return raw, nil
+	}
+	token, err := os.ReadFile(s.cfg.TokenReviewerJWTFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token reviewer JWT: %w", err)
+	}
+	return strings.TrimSpace(string(token)), nil
+}
+
+// verifyToken checks the token against the cluster's key set, for
+// clusters the vault cannot send TokenReviews to
+func (s *KubernetesAuthService) verifyToken(raw, audience string) (*KubernetesServiceAccount, error) {
+	options := []jwt.ParserOption{jwt.WithValidMethods(oidcSigningMethod), jwt.WithExpirationRequired(), jwt.WithLeeway(time.Minute)}
+	if s.cfg.Issuer != "" {
+		options = append(options, jwt.WithIssuer(s.cfg.Issuer))
+	}
+	if audience != "" {
+		options = append(options, jwt.WithAudience(audience))
+	}
+	token, err := jwt.Parse(raw, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return s.verificationKey(kid)
+	}, options...)
+	if err != nil {
+		if errors.Is(err, ErrKubernetesUnavailable) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrKubernetesLoginFailed, err)
+	}
+
+	// Projected tokens carry {"kubernetes.io": {"namespace": ...,
+	// "serviceaccount": {"name": ..., "uid": ...}}}
+	var claims struct {
+		Kubernetes struct {
+			Namespace      string `json:"namespace"`
+			ServiceAccount struct {
+				Name string `json:"name"`
+				UID  string `json:"uid"`
+			} `json:"serviceaccount"`
+		} `json:"kubernetes.io"`
+	}
+	encoded, err := json.Marshal(token.Claims)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token claims: %w", err)
+	}
+	if err := json.Unmarshal(encoded, &claims); err != nil || claims.Kubernetes.Namespace == "" || claims.Kubernetes.ServiceAccount.Name == "" {
+		return nil, fmt.Errorf("%w: the token names no service account", ErrKubernetesLoginFailed)
+	}
+	return &KubernetesServiceAccount{
+		Namespace: claims.Kubernetes.Namespace,
+		Name:      claims.Kubernetes.ServiceAccount.Name,
+		UID:       claims.Kubernetes.ServiceAccount.UID,
+	}, nil
+}
+
+// verificationKey returns the cluster key with the given ID, refetching
+// the key set once for an unknown ID since keys rotate
+func (s *KubernetesAuthService) verificationKey(kid string) (interface{}, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		keys, cached := s.keys.get("jwks")
+		if !cached || attempt > 0 {
+			set, err := s.fetchKeys()
+			if err != nil {
+				return nil, err
+			}
+			keys = set
+			s.keys.put("jwks", keys, time.Time{})
+		}
+		for _, key := range keys.([]oidcJSONWebKey) {
+			if key.Use != "" && key.Use != "sig" {
+				continue
+			}
+			if key.KeyID == kid || kid == "" {
+				return parseJSONWebKey(key)
+			}
+		}
+		if !cached {
+			break
+		}
+	}
+	return nil, fmt.Errorf("no signing key %q", kid)
+}
+
+func (s *KubernetesAuthService) fetchKeys() ([]oidcJSONWebKey, error) {
+	request, err := http.NewRequest(http.MethodGet, s.cfg.JWKSURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build key set request: %w", err)
+	}
+	// The API server only serves its key set to authenticated callers
+	// unless anonymous discovery is enabled
+	if s.cfg.TokenReviewerJWTFile != "" {
+		reviewer, err := s.reviewerToken("")
+		if err != nil {
+			return nil, err
+		}
+		request.Header.Set("Authorization", "Bearer "+reviewer)
+	}
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKubernetesUnavailable, err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s returned %d", ErrKubernetesUnavailable, s.cfg.JWKSURL, response.StatusCode)
+	}
+	var set struct {
+		Keys []oidcJSONWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(io.LimitReader(response.Body, 1<<20)).Decode(&set); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrKubernetesUnavailable, s.cfg.JWKSURL, err)
+	}
+	return set.Keys, nil
+}
+
+func (s *KubernetesAuthService) checkRole(role *model.KubernetesRole) error {
+	for _, bound := range [][]string{role.BoundServiceAccountNames, role.BoundNamespaces} {
+		if len(bound) == 0 {
+			return fmt.Errorf("%w: bound_service_account_names and bound_namespaces must not be empty", ErrKubernetesRoleInvalid)
+		}
+		for _, value := range bound {
+			if strings.TrimSpace(value) == "" {
+				return fmt.Errorf("%w: bound names must not be blank", ErrKubernetesRoleInvalid)
+			}
+		}
+	}
+	exist, err := policiesExist(s.db, role.Policies)
+	if err != nil {
+		return err
+	}
+	if !exist {
+		return fmt.Errorf("%w: policies names a policy that does not exist", ErrKubernetesRoleInvalid)
+	}
+	return nil
+}
+
+// kubernetesBound reports whether value is in bound, where "*" matches
+// anything
+func kubernetesBound(bound []string, value string) bool {
+	for _, candidate := range bound {
+		if candidate == "*" || candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	ErrKubernetesDisabled     = errors.New("Kubernetes login is not configured")
+	ErrKubernetesRoleNotFound = errors.New("Kubernetes role not found")
+	ErrKubernetesRoleExists   = errors.New("a Kubernetes role with this name already exists")
+	ErrKubernetesRoleInvalid  = errors.New("invalid Kubernetes role")
+	ErrKubernetesLoginFailed  = errors.New("Kubernetes login failed")
+	ErrKubernetesUnavailable  = errors.New("Kubernetes API unavailable")
+)
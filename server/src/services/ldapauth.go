@@ -0,0 +1,359 @@
+package services
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/config"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+)
+
+// LDAPAuthService checks passwords against an LDAP directory or Active
+// Directory. Users are found with a service account, then bound as to
+// verify the password; directory groups map to policies the same way
+// OIDC provider groups do. Idle connections are pooled.
+type LDAPAuthService struct {
+	db            *gorm.DB
+	userService   *UserService
+	auditService  *AuditService
+	cfg           config.LDAPConfig
+	tlsConfig     *tls.Config
+	groupPolicies map[string][]uuid.UUID
+	userLinks     map[string]uuid.UUID
+	pool          chan *ldap.Conn
+}
+
+// NewLDAPAuthService loads the CA bundle and group mapping of cfg; it does
+// not contact the directory until the first login
+func NewLDAPAuthService(db *gorm.DB, cfg config.LDAPConfig, userService *UserService, auditService *AuditService) (*LDAPAuthService, error) {
+	parsed, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid LDAP URL: %w", err)
+	}
+	tlsConfig := &tls.Config{ServerName: parsed.Hostname(), MinVersion: tls.VersionTLS12, InsecureSkipVerify: cfg.InsecureSkipVerify}
+	if cfg.CAFile != "" {
+		bundle, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read LDAP CA file: %w", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("LDAP CA file %s holds no PEM certificates", cfg.CAFile)
+		}
+	}
+
+	// Group names compare case-insensitively, as directories do
+	groupPolicies := make(map[string][]uuid.UUID)
+	if cfg.GroupPolicies != "" {
+		for _, pair := range strings.Split(cfg.GroupPolicies, ",") {
+			group, policyID, _ := strings.Cut(pair, "=")
+			id, err := uuid.Parse(strings.TrimSpace(policyID))
+			if err != nil {
+				return nil, fmt.Errorf("invalid LDAP group policy %q: %w", strings.TrimSpace(pair), err)
+			}
+			group = strings.ToLower(strings.TrimSpace(group))
+			groupPolicies[group] = append(groupPolicies[group], id)
+		}
+	}
+
+	// Usernames compare case-insensitively too
+	userLinks := make(map[string]uuid.UUID)
+	if cfg.UserLinks != "" {
+		for _, pair := range strings.Split(cfg.UserLinks, ",") {
+			username, userID, _ := strings.Cut(pair, "=")
+			id, err := uuid.Parse(strings.TrimSpace(userID))
+			if err != nil {
+				return nil, fmt.Errorf("invalid LDAP user link %q: %w", strings.TrimSpace(pair), err)
+			}
+			userLinks[strings.ToLower(strings.TrimSpace(username))] = id
+		}
+	}
+
+	return &LDAPAuthService{
+		db:            db,
+		userService:   userService,
+		auditService:  auditService,
+		cfg:           cfg,
+		tlsConfig:     tlsConfig,
+		groupPolicies: groupPolicies,
+		userLinks:     userLinks,
+		pool:          make(chan *ldap.Conn, cfg.PoolSize),
+	}, nil
+}
+
+type ldapProfile struct {
+	Username  string
+	DN        string
+	Email     string
+	FirstName string
+	LastName  string
+	Groups    []string
+}
+
+// Authenticate checks username and password against the directory and
+// returns the vault user they sign in as, with the policies of their
+// groups attached
+func (s *LDAPAuthService) Authenticate(username, password string) (*model.User, error) {
+	username = strings.TrimSpace(username)
+	// An empty password would be an unauthenticated bind, which many
+	// directories accept for any DN
+	if username == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	conn, err := s.acquire()
+	if err != nil {
+		return nil, err
+	}
+	profile, err := s.verify(conn, username, password)
+	s.release(conn, err == nil || errors.Is(err, ErrInvalidCredentials))
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.resolveUser(profile)
+	if err != nil {
+		return nil, err
+	}
+	if err := syncSourcePolicies(s.db, user.ID, model.AuthMethodLDAP, groupPolicies(s.groupPolicies, profile.Groups)); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// verify finds the user's entry and groups as the service account, then
+// binds as the user to check the password
+func (s *LDAPAuthService) verify(conn *ldap.Conn, username, password string) (*ldapProfile, error) {
+	if err := s.bindService(conn); err != nil {
+		return nil, err
+	}
+
+	attributes := []string{s.cfg.EmailAttribute, s.cfg.FirstNameAttribute, s.cfg.LastNameAttribute}
+	if s.cfg.GroupFilter == "" {
+		attributes = append(attributes, "memberOf")
+	}
+	filter := strings.ReplaceAll(s.cfg.UserFilter, "{{username}}", ldap.EscapeFilter(username))
+	result, err := conn.Search(ldap.NewSearchRequest(s.cfg.UserBaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, s.cfg.Timeout, false, filter, attributes, nil))
+	if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		return nil, fmt.Errorf("%w: user search failed: %v", ErrLDAPUnavailable, err)
+	}
+	if result == nil || len(result.Entries) == 0 {
+		return nil, fmt.Errorf("%w: no LDAP entry for %s", ErrInvalidCredentials, username)
+	}
+	if len(result.Entries) > 1 {
+		return nil, fmt.Errorf("%w: %s matches several LDAP entries", ErrInvalidCredentials, username)
+	}
+	entry := result.Entries[0]
+
+	profile := &ldapProfile{
+		Username:  strings.ToLower(username),
+		DN:        entry.DN,
+		Email:     entry.GetAttributeValue(s.cfg.EmailAttribute),
+		FirstName: entry.GetAttributeValue(s.cfg.FirstNameAttribute),
+		LastName:  entry.GetAttributeValue(s.cfg.LastNameAttribute),
+	}
+	if s.cfg.GroupFilter == "" {
+		for _, groupDN := range entry.GetAttributeValues("memberOf") {
+			if name := ldapCommonName(groupDN); name != "" {
+				profile.Groups = append(profile.Groups, strings.ToLower(name))
+			}
+		}
+	} else if profile.Groups, err = s.searchGroups(conn, profile); err != nil {
+		return nil, err
+	}
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, fmt.Errorf("%w: wrong LDAP password for %s", ErrInvalidCredentials, username)
+		}
+		return nil, fmt.Errorf("%w: user bind failed: %v", ErrLDAPUnavailable, err)
+	}
+	return profile, nil
+}
+
+func (s *LDAPAuthService) searchGroups(conn *ldap.Conn, profile *ldapProfile) ([]string, error) {
+	baseDN := s.cfg.GroupBaseDN
+	if baseDN == "" {
+		baseDN = s.cfg.UserBaseDN
+	}
+	filter := strings.NewReplacer("{{dn}}", ldap.EscapeFilter(profile.DN), "{{username}}", ldap.EscapeFilter(profile.Username)).Replace(s.cfg.GroupFilter)
+	result, err := conn.Search(ldap.NewSearchRequest(baseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, s.cfg.Timeout, false, filter, []string{s.cfg.GroupAttribute}, nil))
+	if err != nil {
+		return nil, fmt.Errorf("%w: group search failed: %v", ErrLDAPUnavailable, err)
+	}
+
+	var groups []string
+	for _, entry := range result.Entries {
+		if name := entry.GetAttributeValue(s.cfg.GroupAttribute); name != "" {
+			groups = append(groups, strings.ToLower(name))
+		}
+	}
+	return groups, nil
+}
+
+func (s *LDAPAuthService) bindService(conn *ldap.Conn) error {
+	// Pooled connections may still be bound as the last user
+	var err error
+	if s.cfg.BindDN == "" {
+		err = conn.UnauthenticatedBind("")
+	} else {
+		err = conn.Bind(s.cfg.BindDN, s.cfg.BindPassword)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: service bind failed: %v", ErrLDAPUnavailable, err)
+	}
+	return nil
+}
+
+// acquire takes an idle connection from the pool or dials a new one
+func (s *LDAPAuthService) acquire() (*ldap.Conn, error) {
+	for {
+		select {
+		case conn := <-s.pool:
+			if !conn.IsClosing() {
+				return conn, nil
+			}
+			conn.Close()
+		default:
+			return s.dial()
+		}
+	}
+}
+
+// release returns a healthy connection to the pool and closes the rest
+func (s *LDAPAuthService) release(conn *ldap.Conn, healthy bool) {
+	if !healthy || conn.IsClosing() {
+		conn.Close()
+		return
+	}
+	select {
+	case s.pool <- conn:
+	default:
+		conn.Close()
+	}
+}
+
+func (s *LDAPAuthService) dial() (*ldap.Conn, error) {
+	timeout := time.Duration(s.cfg.Timeout) * time.Second
+	conn, err := ldap.DialURL(s.cfg.URL, ldap.DialWithDialer(&net.Dialer{Timeout: timeout}), ldap.DialWithTLSConfig(s.tlsConfig))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrLDAPUnavailable, err)
+	}
+	conn.SetTimeout(timeout)
+	if s.cfg.StartTLS {
+		if err := conn.StartTLS(s.tlsConfig); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("%w: StartTLS failed: %v", ErrLDAPUnavailable, err)
+		}
+	}
+	return conn, nil
+}
+
+// resolveUser returns the vault user linked to the directory user,
+// linking one by email or creating one on first login
+func (s *LDAPAuthService) resolveUser(profile *ldapProfile) (*model.User, error) {
+	var identity model.AuthIdentity
+	err := s.db.Where("method = ? AND provider = ? AND subject = ?", model.AuthMethodLDAP, model.AuthMethodLDAP, profile.Username).First(&identity).Error
+	if err == nil {
+		user, err := s.userService.GetUserByID(identity.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: the linked user is disabled", ErrLDAPUserNotLinked)
+		}
+		if err := s.db.Model(&identity).Update("last_login_at", time.Now()).Error; err != nil {
+			return nil, fmt.Errorf("failed to record login: %w", err)
+		}
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get identity: %w", err)
+	}
+
+	// An existing user is linked only where an admin mapped the username
+	// to it; the entry's email is whatever the directory lets its user set
+	var user *model.User
+	if userID, ok := s.userLinks[profile.Username]; ok {
+		user, err = s.userService.GetUserByID(userID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: the user linked to %s does not exist or is disabled", ErrLDAPUserNotLinked, profile.Username)
+		}
+		if IsCentralAdmin(user) {
+			return nil, fmt.Errorf("%w: the central admin signs in with a password", ErrLDAPUserNotLinked)
+		}
+	} else {
+		if profile.Email == "" {
+			return nil, fmt.Errorf("%w: the LDAP entry has no %s", ErrLDAPUserNotLinked, s.cfg.EmailAttribute)
+		}
+		_, err := s.userService.GetUserByEmail(profile.Email)
+		switch {
+		case err == nil:
+			return nil, fmt.Errorf("%w: a vault user has the email %s; an admin must link it through ldap.user_links", ErrLDAPUserNotLinked, profile.Email)
+		case !errors.Is(err, ErrUserNotFound):
+			return nil, err
+		case !s.cfg.AutoCreateUsers:
+			return nil, fmt.Errorf("%w: %s is not linked to a vault user", ErrLDAPUserNotLinked, profile.Username)
+		}
+	}
+
+	now := time.Now()
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if user == nil {
+			// The user has no password of their own; they sign in through
+			// the directory
+			password, err := oidcRandom()
+			if err != nil {
+				return err
+			}
+			user = &model.User{Email: profile.Email, Password: password, FirstName: profile.FirstName, LastName: profile.LastName, IsActive: true}
+			if err := NewUserService(tx).CreateUser(user); err != nil {
+				return err
+			}
+		}
+		return tx.Create(&model.AuthIdentity{
+			Method:      model.AuthMethodLDAP,
+			Provider:    model.AuthMethodLDAP,
+			Subject:     profile.Username,
+			UserID:      user.ID,
+			LastLoginAt: &now,
+		}).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to link identity: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(user.ID, "identity_linked", "user", user.ID.String(), true, "method=ldap; subject="+profile.Username+"; dn="+profile.DN)
+	}
+	return user, nil
+}
+
+// ldapCommonName returns the first CN of a DN, such as a memberOf value
+func ldapCommonName(dn string) string {
+	parsed, err := ldap.ParseDN(dn)
+	if err != nil {
+		return ""
+	}
+	for _, rdn := range parsed.RDNs {
+		for _, attribute := range rdn.Attributes {
+			if strings.EqualFold(attribute.Type, "cn") {
+				return attribute.Value
+			}
+		}
+	}
+	return ""
+}
+
+var (
+	ErrLDAPDisabled      = errors.New("LDAP login is not configured")
+	ErrLDAPUnavailable   = errors.New("LDAP directory unavailable")
+	ErrLDAPUserNotLinked = errors.New("no vault user for the LDAP user")
+)
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/config"
+)
+
+func TestLDAPResolveUserLinksOnlyThroughMapping(t *testing.T) {
+	userID := uuid.New()
+	existing := func(email string) []map[string]driver.Value {
+		return []map[string]driver.Value{{"id": userID.String(), "email": email, "is_active": true}}
+	}
+
+	tests := []struct {
+		name       string
+		users      []map[string]driver.Value
+		userLinks  string
+		autoCreate bool
+		wantErr    error
+		wantUser   *uuid.UUID
+	}{
+		{
+			name:    "email of an existing user",
+			users:   existing("victim@example.com"),
+			wantErr: ErrLDAPUserNotLinked,
+		},
+		{
+			name:       "email of an existing user with auto create",
+			users:      existing("victim@example.com"),
+			autoCreate: true,
+			wantErr:    ErrLDAPUserNotLinked,
+		},
+		{
+			name:      "mapped by an admin",
+			users:     existing("jdoe@example.com"),
+			userLinks: "JDoe=" + userID.String(),
+			wantUser:  &userID,
+		},
+		{
+			name:      "mapped to the central admin",
+			users:     existing("admin@aether-vault.local"),
+			userLinks: "jdoe=" + userID.String(),
+			wantErr:   ErrLDAPUserNotLinked,
+		},
+		{
+			name:      "mapped to a missing user",
+			userLinks: "jdoe=" + userID.String(),
+			wantErr:   ErrLDAPUserNotLinked,
+		},
+		{
+			name:    "unknown email without auto create",
+			wantErr: ErrLDAPUserNotLinked,
+		},
+		{
+			name:       "unknown email with auto create",
+			autoCreate: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newStubDB(t, stubTables{"users": tt.users})
+			service, err := NewLDAPAuthService(db, config.LDAPConfig{
+				URL:             "ldaps://ldap.example.com",
+				UserLinks:       tt.userLinks,
+				AutoCreateUsers: tt.autoCreate,
+			}, NewUserService(db), nil)
+			if err != nil {
+				t.Fatalf("failed to create LDAP service: %v", err)
+			}
+
+			user, err := service.resolveUser(&ldapProfile{Username: "jdoe", Email: "victim@example.com"})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got user %v, error %v; want %v", user, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveUser failed: %v", err)
+			}
+			if tt.wantUser != nil && user.ID != *tt.wantUser {
+				t.Errorf("got user %s, want %s", user.ID, *tt.wantUser)
+			}
+			if tt.wantUser == nil && user.Email != "victim@example.com" {
+				t.Errorf("created user has email %q", user.Email)
+			}
+		})
+	}
+}
+
+// The admin mapping must name users by ID, as group_policies names policies
+func TestLDAPUserLinksRejectsMalformedPairs(t *testing.T) {
+	db := newStubDB(t, stubTables{})
+	for _, links := range []string{"jdoe", "jdoe=not-a-uuid"} {
+		if _, err := NewLDAPAuthService(db, config.LDAPConfig{URL: "ldaps://ldap.example.com", UserLinks: links}, NewUserService(db), nil); err == nil {
+			t.Errorf("user_links %q was accepted", links)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+)
+
+// Ended leases are kept this long so their holders are told "ended"
+// rather than "not found"
+const leasePurgeGrace = 7 * 24 * time.Hour
+
+// LeaseIssuer is a service that hands out leased items. Revoke ends an
+// item, early or because its lease expired; it may run more than once for
+// the same item. Renew, when set, moves the item's own expiry along with
+// its lease. Either may be nil when the item has nothing to end or move.
+type LeaseIssuer struct {
+	Revoke func(ctx context.Context, item string) error
+	Renew  func(item string, expiresAt time.Time) error
+}
+
+// LeaseService tracks the leases of issued items, renews them within their
+// maximum TTL and revokes them through their issuer when they expire
+type LeaseService struct {
+	db           *gorm.DB
+	auditService *AuditService
+	expiry       *ExpiryScheduler
+
+	mu      sync.RWMutex
+	issuers map[string]LeaseIssuer
+}
+
+func NewLeaseService(db *gorm.DB, auditService *AuditService) *LeaseService {
+	return &LeaseService{
+		db:           db,
+		auditService: auditService,
+		issuers:      make(map[string]LeaseIssuer),
+	}
+}
+
+// UseExpiry revokes each lease as soon as it expires
+func (s *LeaseService) UseExpiry(scheduler *ExpiryScheduler) {
+	s.expiry = scheduler
+	scheduler.Handle(model.ExpiryKindLease, s.expireLease)
+}
+
+// RegisterIssuer registers the service behind leases of an issuer; call it
+// before the expiry scheduler starts
+func (s *LeaseService) RegisterIssuer(name string, issuer LeaseIssuer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.issuers[name] = issuer
+}
+
+// Issue leases an item to a user for the resolved TTL. A renewable lease
+// can be extended until the resolved maximum TTL has passed since now.
+func (s *LeaseService) Issue(issuer, item string, userID uuid.UUID, namespaceID *uuid.UUID, resolution *model.TTLResolution, renewable bool) (*model.Lease, error) {
+	if _, ok := s.issuer(issuer); !ok {
+		return nil, fmt.Errorf("%w: %s", ErrLeaseIssuerUnknown, issuer)
+	}
+
+	lease := &model.Lease{
+		Issuer:       issuer,
+		Item:         item,
+		UserID:       userID,
+		NamespaceID:  namespaceID,
+		TTL:          resolution.Effective,
+		Renewable:    renewable,
+		ExpiresAt:    *resolution.ExpiresAt,
+		MaxExpiresAt: resolution.ExpiresAt.Add(time.Duration(resolution.MaxTTL-resolution.Effective) * time.Second),
+	}
+	if err := s.db.Create(lease).Error; err != nil {
+		return nil, fmt.Errorf("failed to create lease: %w", err)
+	}
+
+	if err := s.expiry.Schedule(model.ExpiryKindLease, lease.ID.String(), lease.ExpiresAt); err != nil {
+		log.Printf("⚠️  Failed to schedule lease %s expiry: %v", lease.ID, err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "lease_issued", "lease", lease.ID.String(), true,
+			fmt.Sprintf("issuer=%s; item=%s; expires_at=%s", issuer, item, lease.ExpiresAt.Format(time.RFC3339)))
+	}
+	return lease, nil
+}
+
+// GetLeases returns the active leases held by a user, soonest to expire
+// first
+func (s *LeaseService) GetLeases(userID uuid.UUID) ([]model.Lease, error) {
+	var leases []model.Lease
+	if err := s.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("expires_at ASC").Find(&leases).Error; err != nil {
+		return nil, fmt.Errorf("failed to get leases: %w", err)
+	}
+	return leases, nil
+}
+
+// Renew extends a lease by increment from now, or by its original TTL
+// when increment is zero. The new expiry never passes the lease's maximum.
+func (s *LeaseService) Renew(req *model.RenewLeaseRequest, userID uuid.UUID) (*model.Lease, error) {
+	lease, err := s.holderLease(req.LeaseID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if !lease.Active(now) {
+		return nil, ErrLeaseEnded
+	}
+	if !lease.Renewable {
+		return nil, ErrLeaseNotRenewable
+	}
+
+	increment := lease.TTL
+	if req.Increment > 0 {
+		increment = req.Increment
+	}
+	expiresAt := now.Add(time.Duration(increment) * time.Second)
+	if expiresAt.After(lease.MaxExpiresAt) {
+		expiresAt = lease.MaxExpiresAt
+	}
+
+	issuer, ok := s.issuer(lease.Issuer)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrLeaseIssuerUnknown, lease.Issuer)
+	}
+	if issuer.Renew != nil {
+		if err := issuer.Renew(lease.Item, expiresAt); err != nil {
+			return nil, err
+		}
+	}
+
+	result := s.db.Model(&model.Lease{}).Where("id = ? AND revoked_at IS NULL", lease.ID).
+		Updates(map[string]interface{}{"expires_at": expiresAt, "renewed_at": now})
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to renew lease: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrLeaseEnded
+	}
+	lease.ExpiresAt = expiresAt
+	lease.RenewedAt = &now
+
+	if err := s.expiry.Schedule(model.ExpiryKindLease, lease.ID.String(), lease.ExpiresAt); err != nil {
+		log.Printf("⚠️  Failed to schedule lease %s expiry: %v", lease.ID, err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "lease_renewed", "lease", lease.ID.String(), true,
+			fmt.Sprintf("expires_at=%s", lease.ExpiresAt.Format(time.RFC3339)))
+	}
+	return lease, nil
+}
+
+// Revoke ends a lease now, revoking its item through the issuer
+func (s *LeaseService) Revoke(leaseID uuid.UUID, userID uuid.UUID) (*model.Lease, error) {
+	lease, err := s.holderLease(leaseID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if lease.RevokedAt != nil {
+		return nil, ErrLeaseEnded
+	}
+
+	if err := s.revoke(context.Background(), lease, &userID); err != nil {
+		return nil, err
+	}
+	if err := s.expiry.Cancel(model.ExpiryKindLease, lease.ID.String()); err != nil {
+		log.Printf("⚠️  Failed to cancel lease %s expiry: %v", lease.ID, err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "lease_revoked", "lease", lease.ID.String(), true,
+			fmt.Sprintf("issuer=%s; item=%s", lease.Issuer, lease.Item))
+	}
+	return lease, nil
+}
+
+// expireLease revokes a lease whose time has come. A lease renewed or
+// revoked in the meantime is left alone.
+func (s *LeaseService) expireLease(ctx context.Context, item string) error {
+	id, err := uuid.Parse(item)
+	if err != nil {
+		return nil
+	}
+
+	var lease model.Lease
+	if err := s.db.WithContext(ctx).Where("id = ?", id).First(&lease).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to get lease: %w", err)
+	}
+	if lease.RevokedAt != nil || time.Now().Before(lease.ExpiresAt) {
+		return nil
+	}
+
+	if err := s.revoke(ctx, &lease, nil); err != nil {
+		return err
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(lease.UserID, "lease_expired", "lease", lease.ID.String(), true,
+			fmt.Sprintf("issuer=%s; item=%s", lease.Issuer, lease.Item))
+	}
+	return nil
+}
+
+// revoke has the issuer end the item, then marks the lease revoked. A
+// failed revocation leaves the lease open so it can be retried.
+func (s *LeaseService) revoke(ctx context.Context, lease *model.Lease, actorID *uuid.UUID) error {
+	issuer, ok := s.issuer(lease.Issuer)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrLeaseIssuerUnknown, lease.Issuer)
+	}
+	if issuer.Revoke != nil {
+		if err := issuer.Revoke(ctx, lease.Item); err != nil {
+			return fmt.Errorf("failed to revoke %s %s: %w", lease.Issuer, lease.Item, err)
+		}
+	}
+
+	now := time.Now()
+	if err := s.db.WithContext(ctx).Model(&model.Lease{}).Where("id = ? AND revoked_at IS NULL", lease.ID).
+		Updates(map[string]interface{}{"revoked_at": now, "revoked_by": actorID}).Error; err != nil {
+		return fmt.Errorf("failed to revoke lease: %w", err)
+	}
+	lease.RevokedAt = &now
+	lease.RevokedBy = actorID
+	return nil
+}
+
+// Job removes leases that ended more than the purge grace period ago
+func (s *LeaseService) Job(interval time.Duration) *Job {
+	return &Job{
+		Name:        "expired_leases",
+		Description: "Remove leases revoked or expired more than a week ago",
+		Interval:    interval,
+		Run: func(ctx context.Context) (int64, string, error) {
+			result := s.db.WithContext(ctx).Where("revoked_at IS NOT NULL AND revoked_at < ?", time.Now().Add(-leasePurgeGrace)).Delete(&model.Lease{})
+			if result.Error != nil {
+				return 0, "", fmt.Errorf("failed to remove ended leases: %w", result.Error)
+			}
+			return result.RowsAffected, fmt.Sprintf("removed %d ended leases", result.RowsAffected), nil
+		},
+	}
+}
+
+// holderLease returns a lease held by userID; other users' leases are
+// reported as not found
+func (s *LeaseService) holderLease(id uuid.UUID, userID uuid.UUID) (*model.Lease, error) {
+	var lease model.Lease
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&lease).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrLeaseNotFound
+		}
+		return nil, fmt.Errorf("failed to get lease: %w", err)
+	}
+	return &lease, nil
+}
+
+func (s *LeaseService) issuer(name string) (LeaseIssuer, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	issuer, ok := s.issuers[name]
+	return issuer, ok
+}
+
+var (
+	ErrLeaseNotFound      = errors.New("lease not found")
+	ErrLeaseEnded         = errors.New("lease has been revoked or has expired")
+	ErrLeaseNotRenewable  = errors.New("lease is not renewable")
+	ErrLeaseIssuerUnknown = errors.New("no service issues this kind of lease")
+	ErrLeasesUnavailable  = errors.New("leases need the database")
+)
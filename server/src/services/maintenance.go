@@ -0,0 +1,206 @@
+package services
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-vault/server/src/config"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+)
+
+const (
+	// Expired share links are wiped at once but kept this long so their
+	// links still report "expired" rather than "not found"
+	sharePurgeGrace = 7 * 24 * time.Hour
+
+	auditArchiveBatch      = 1000
+	auditArchiveMaxEntries = 100000
+)
+
+// RegisterMaintenanceJobs registers the built-in garbage collection and
+// compaction jobs
+func (s *JobService) RegisterMaintenanceJobs(cfg config.JobsConfig) {
+	s.Register(&Job{
+		Name:        "expired_shares",
+		Description: "Wipe expired share links and remove them after a grace period",
+		Interval:    time.Duration(cfg.ExpiredSharesInterval) * time.Second,
+		Run:         s.purgeExpiredShares,
+	})
+
+	retention := time.Duration(cfg.SecretRetentionDays) * 24 * time.Hour
+	s.Register(&Job{
+		Name:        "secret_compaction",
+		Description: fmt.Sprintf("Permanently remove secrets and TOTP entries deleted more than %d days ago", cfg.SecretRetentionDays),
+		Interval:    time.Duration(cfg.SecretCompactionInterval) * time.Second,
+		Run: func(ctx context.Context) (int64, string, error) {
+			return s.compactDeletedSecrets(retention)
+		},
+	})
+
+	s.Register(&Job{
+		Name:        "orphan_cleanup",
+		Description: "Remove share links, TOTP entries and reminders whose secret or owner is gone",
+		Interval:    time.Duration(cfg.OrphanCleanupInterval) * time.Second,
+		Run:         s.cleanupOrphans,
+	})
+
+	archiveDir := cfg.AuditArchiveDir
+	archiveRetention := time.Duration(cfg.AuditRetentionDays) * 24 * time.Hour
+	archiveDescription := fmt.Sprintf("Move audit entries older than %d days to %s", cfg.AuditRetentionDays, archiveDir)
+	if archiveDir == "" {
+		archiveDescription = "Move old audit entries to files (set jobs.audit_archive_dir to enable)"
+	}
+	s.Register(&Job{
+		Name:        "audit_archive",
+		Description: archiveDescription,
+		Interval:    time.Duration(cfg.AuditArchiveInterval) * time.Second,
+		Disabled:    archiveDir == "",
+		Run: func(ctx context.Context) (int64, string, error) {
+			return s.archiveAudit(ctx, archiveDir, archiveRetention)
+		},
+	})
+}
+
+func (s *JobService) purgeExpiredShares(ctx context.Context) (int64, string, error) {
+	now := time.Now()
+
+	wiped := s.db.Model(&model.ShareLink{}).
+		Where("consumed_at IS NULL AND expires_at < ? AND ciphertext <> ?", now, "").
+		Update("ciphertext", "")
+	if wiped.Error != nil {
+		return 0, "", fmt.Errorf("failed to wipe expired share links: %w", wiped.Error)
+	}
+
+	removed := s.db.Where("expires_at < ?", now.Add(-sharePurgeGrace)).Delete(&model.ShareLink{})
+	if removed.Error != nil {
+		return wiped.RowsAffected, "", fmt.Errorf("failed to remove expired share links: %w", removed.Error)
+	}
+
+	return wiped.RowsAffected + removed.RowsAffected,
+		fmt.Sprintf("wiped %d expired share links, removed %d", wiped.RowsAffected, removed.RowsAffected), nil
+}
+
+func (s *JobService) compactDeletedSecrets(retention time.Duration) (int64, string, error) {
+	cutoff := time.Now().Add(-retention)
+
+	secrets := s.db.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).Delete(&model.Secret{})
+	if secrets.Error != nil {
+		return 0, "", fmt.Errorf("failed to compact secrets: %w", secrets.Error)
+	}
+
+	totps := s.db.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).Delete(&model.TOTP{})
+	if totps.Error != nil {
+		return secrets.RowsAffected, "", fmt.Errorf("failed to compact TOTP entries: %w", totps.Error)
+	}
+
+	return secrets.RowsAffected + totps.RowsAffected,
+		fmt.Sprintf("removed %d secrets and %d TOTP entries", secrets.RowsAffected, totps.RowsAffected), nil
+}
+
+func (s *JobService) cleanupOrphans(ctx context.Context) (int64, string, error) {
+	activeSecrets := s.db.Model(&model.Secret{}).Select("id")
+	activeUsers := s.db.Model(&model.User{}).Select("id")
+
+	shares := s.db.Where("(secret_id IS NOT NULL AND secret_id NOT IN (?)) OR user_id NOT IN (?)", activeSecrets, activeUsers).Delete(&model.ShareLink{})
+	if shares.Error != nil {
+		return 0, "", fmt.Errorf("failed to remove orphaned share links: %w", shares.Error)
+	}
+
+	totps := s.db.Where("user_id NOT IN (?)", activeUsers).Delete(&model.TOTP{})
+	if totps.Error != nil {
+		return shares.RowsAffected, "", fmt.Errorf("failed to remove orphaned TOTP entries: %w", totps.Error)
+	}
+
+	reminders := s.db.Where("secret_id NOT IN (?)", activeSecrets).Delete(&model.Reminder{})
+	if reminders.Error != nil {
+		return shares.RowsAffected + totps.RowsAffected, "", fmt.Errorf("failed to remove orphaned reminders: %w", reminders.Error)
+	}
+
+	// Versions go with the secret once compaction removed it for good
+	storedSecrets := s.db.Unscoped().Model(&model.Secret{}).Select("id")
+	versions := s.db.Where("secret_id NOT IN (?)", storedSecrets).Delete(&model.SecretVersion{})
+	if versions.Error != nil {
+		return shares.RowsAffected + totps.RowsAffected + reminders.RowsAffected, "", fmt.Errorf("failed to remove orphaned secret versions: %w", versions.Error)
+	}
+
+	return shares.RowsAffected + totps.RowsAffected + reminders.RowsAffected + versions.RowsAffected,
+		fmt.Sprintf("removed %d share links, %d TOTP entries, %d reminders and %d secret versions", shares.RowsAffected, totps.RowsAffected, reminders.RowsAffected, versions.RowsAffected), nil
+}
+
+// archiveAudit writes audit entries older than retention to a gzipped
+// JSON-lines file, then deletes exactly the entries written. Verification
+// reports anchors before the first remaining entry as pruned.
+func (s *JobService) archiveAudit(ctx context.Context, dir string, retention time.Duration) (int64, string, error) {
+	cutoff := time.Now().Add(-retention)
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return 0, "", fmt.Errorf("failed to create audit archive directory: %w", err)
+	}
+	name := filepath.Join(dir, fmt.Sprintf("audit-%s.jsonl.gz", time.Now().UTC().Format("20060102T150405Z")))
+	file, err := os.OpenFile(name+".tmp", os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to create audit archive: %w", err)
+	}
+	defer os.Remove(name + ".tmp")
+	defer file.Close()
+
+	writer := gzip.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+
+	var archived int64
+	var last *model.AuditLog
+	for archived < auditArchiveMaxEntries {
+		if err := ctx.Err(); err != nil {
+			return 0, "", err
+		}
+
+		var batch []model.AuditLog
+		query := s.db.Where("created_at < ?", cutoff).Order("created_at, id").Limit(auditArchiveBatch)
+		if last != nil {
+			query = query.Where("created_at > ? OR (created_at = ? AND id > ?)", last.CreatedAt, last.CreatedAt, last.ID)
+		}
+		if err := query.Find(&batch).Error; err != nil {
+			return 0, "", fmt.Errorf("failed to read audit entries: %w", err)
+		}
+
+		for i := range batch {
+			if err := encoder.Encode(&batch[i]); err != nil {
+				return 0, "", fmt.Errorf("failed to write audit archive: %w", err)
+			}
+		}
+		archived += int64(len(batch))
+		if len(batch) > 0 {
+			last = &batch[len(batch)-1]
+		}
+		if len(batch) < auditArchiveBatch {
+			break
+		}
+	}
+
+	if archived == 0 {
+		return 0, "no audit entries past retention", nil
+	}
+
+	if err := writer.Close(); err != nil {
+		return 0, "", fmt.Errorf("failed to write audit archive: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		return 0, "", fmt.Errorf("failed to write audit archive: %w", err)
+	}
+	if err := os.Rename(name+".tmp", name); err != nil {
+		return 0, "", fmt.Errorf("failed to finalize audit archive: %w", err)
+	}
+
+	// Only what reached the archive file is deleted
+	deleted := s.db.Where("created_at < ? AND (created_at < ? OR (created_at = ? AND id <= ?))", cutoff, last.CreatedAt, last.CreatedAt, last.ID).Delete(&model.AuditLog{})
+	if deleted.Error != nil {
+		return 0, "", fmt.Errorf("audit entries archived to %s but not deleted: %w", name, deleted.Error)
+	}
+
+	return deleted.RowsAffected, fmt.Sprintf("archived %d audit entries to %s", deleted.RowsAffected, filepath.Base(name)), nil
+}
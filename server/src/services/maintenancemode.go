@@ -0,0 +1,119 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maintenanceRefresh is how often instances re-read the maintenance switch
+const maintenanceRefresh = 5 * time.Second
+
+// MaintenanceService holds the read-only maintenance switch. It is stored
+// in the database so it reaches every instance within maintenanceRefresh;
+// without a database it only applies to this instance.
+type MaintenanceService struct {
+	db           *gorm.DB
+	auditService *AuditService
+
+	mu    sync.RWMutex
+	state model.MaintenanceMode
+}
+
+func NewMaintenanceService(db *gorm.DB, auditService *AuditService) *MaintenanceService {
+	return &MaintenanceService{
+		db:           db,
+		auditService: auditService,
+		state:        model.MaintenanceMode{ID: 1},
+	}
+}
+
+// Start loads the switch and keeps refreshing it in the background
+func (s *MaintenanceService) Start() {
+	if s.db == nil {
+		return
+	}
+	if err := s.refresh(); err != nil {
+		log.Printf("⚠️  Failed to load maintenance mode: %v", err)
+	}
+	go func() {
+		ticker := time.NewTicker(maintenanceRefresh)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := s.refresh(); err != nil {
+				log.Printf("⚠️  Failed to refresh maintenance mode: %v", err)
+			}
+		}
+	}()
+}
+
+func (s *MaintenanceService) refresh() error {
+	var rows []model.MaintenanceMode
+	if err := s.db.Where("id = ?", 1).Limit(1).Find(&rows).Error; err != nil {
+		return fmt.Errorf("failed to get maintenance mode: %w", err)
+	}
+
+	state := model.MaintenanceMode{ID: 1}
+	if len(rows) > 0 {
+		state = rows[0]
+	}
+	s.mu.Lock()
+	s.state = state
+	s.mu.Unlock()
+	return nil
+}
+
+// ReadOnly reports whether writes are rejected. It is safe to call on a
+// nil MaintenanceService.
+func (s *MaintenanceService) ReadOnly() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.state.Enabled
+}
+
+// Status returns the switch as last read
+func (s *MaintenanceService) Status() model.MaintenanceMode {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.state
+}
+
+// Set turns read-only mode on or off for every instance
+func (s *MaintenanceService) Set(req *model.SetMaintenanceRequest, userID uuid.UUID) (*model.MaintenanceMode, error) {
+	state := model.MaintenanceMode{ID: 1, Enabled: *req.Enabled, UpdatedBy: &userID, UpdatedAt: time.Now()}
+	if state.Enabled {
+		state.Reason = req.Reason
+	}
+
+	if s.db != nil {
+		if err := s.db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"enabled", "reason", "updated_by", "updated_at"}),
+		}).Create(&state).Error; err != nil {
+			return nil, fmt.Errorf("failed to store maintenance mode: %w", err)
+		}
+	}
+
+	s.mu.Lock()
+	s.state = state
+	s.mu.Unlock()
+
+	if s.auditService != nil {
+		action := "maintenance_disabled"
+		if state.Enabled {
+			action = "maintenance_enabled"
+		}
+		s.auditService.LogAction(userID, action, "system", "maintenance", true, state.Reason)
+	}
+
+	return &state, nil
+}
@@ -0,0 +1,373 @@
+package services
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+)
+
+const (
+	// MFAVerifyPath is where step-up challenges send the caller
+	MFAVerifyPath = "/api/v1/auth/mfa/verify"
+
+	mfaIssuer    = "Aether Vault"
+	mfaAlgorithm = "SHA1"
+	mfaDigits    = 6
+	mfaPeriod    = 30
+	// maxMFAMaxAge bounds the max_age of policy rules; a verification older
+	// than a day is no step-up
+	maxMFAMaxAge = 86400
+	// mfaEnrollLoginAge is how recent the login enrolling a factor has to
+	// be for users under step-up rules
+	mfaEnrollLoginAge = 10 * time.Minute
+)
+
+// mfaEnrollLoginMethods are the primary logins that may enroll a factor;
+// machine logins and tokens minted from another token may not
+var mfaEnrollLoginMethods = map[string]bool{
+	model.AuthMethodPassword: true,
+	model.AuthMethodLDAP:     true,
+	model.AuthMethodOIDC:     true,
+}
+
+// MFAService enrolls the TOTP factor of each user and records step-up
+// verifications on the tokens they are made with, for policy rules that
+// require a recent one
+type MFAService struct {
+	db            *gorm.DB
+	totpService   *TOTPService
+	secretService *SecretService
+	policyService *PolicyService
+	auditService  *AuditService
+	notifications *NotificationService
+}
+
+// StepUpRequiredError is returned when a policy rule requires a more
+// recent verification than the calling token has
+type StepUpRequiredError struct {
+	Requirement model.MFARequirement
+}
+
+func (e *StepUpRequiredError) Error() string {
+	return fmt.Sprintf("%s within the last %d seconds", ErrMFARequired, e.Requirement.MaxAge)
+}
+
+func (e *StepUpRequiredError) Unwrap() error {
+	return ErrMFARequired
+}
+
+func NewMFAService(db *gorm.DB, totpService *TOTPService, secretService *SecretService, policyService *PolicyService, auditService *AuditService, notifications *NotificationService) *MFAService {
+	return &MFAService{
+		db:            db,
+		totpService:   totpService,
+		secretService: secretService,
+		policyService: policyService,
+		auditService:  auditService,
+		notifications: notifications,
+	}
+}
+
+// UseStepUp makes value reads meet the mfa requirements of the reader's
+// policy rules
+func (s *SecretService) UseStepUp(mfa *MFAService) {
+	s.stepUp = mfa
+}
+
+// requireStepUp checks the step-up requirement of reading secret
+func (s *SecretService) requireStepUp(secret *model.Secret, userID uuid.UUID, accessor *uuid.UUID) error {
+	if s.stepUp == nil {
+		return nil
+	}
+	return s.stepUp.Require(userID, accessor, SecretPolicyPath(secret), "read", SplitTags(secret.Tags))
+}
+
+// Enroll starts enrollment with a new seed, replacing one that was never
+// confirmed. A confirmed factor has to be removed first. Users under
+// step-up rules enroll with the token of a fresh primary login, so a
+// stolen token cannot enroll a factor of its own and pass step-up with it.
+func (s *MFAService) Enroll(userID uuid.UUID, accessor *uuid.UUID) (*model.MFAEnrollResponse, error) {
+	if err := s.requireFreshLogin(userID, accessor); err != nil {
+		if s.auditService != nil {
+			s.auditService.LogAction(userID, "mfa_enrollment_started", "mfa", userID.String(), false, err.Error())
+		}
+		return nil, err
+	}
+
+	var user model.User
+	if err := s.db.Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	seed, err := s.totpService.generateSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate MFA seed: %w", err)
+	}
+	sealed, err := s.secretService.encrypt(seed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt MFA seed: %w", err)
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var existing model.UserMFA
+		err := tx.Where("user_id = ?", userID).First(&existing).Error
+		switch {
+		case err == nil && existing.ConfirmedAt != nil:
+			return ErrMFAAlreadyEnrolled
+		case err == nil:
+			if err := tx.Delete(&existing).Error; err != nil {
+				return fmt.Errorf("failed to replace MFA enrollment: %w", err)
+			}
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return fmt.Errorf("failed to get MFA enrollment: %w", err)
+		}
+		if err := tx.Create(&model.UserMFA{UserID: userID, Secret: sealed}).Error; err != nil {
+			return fmt.Errorf("failed to enroll MFA: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "mfa_enrollment_started", "mfa", userID.String(), true, "method="+model.MFAMethodTOTP)
+	}
+
+	query := url.Values{}
+	query.Set("secret", seed)
+	query.Set("issuer", mfaIssuer)
+	query.Set("algorithm", mfaAlgorithm)
+	query.Set("digits", fmt.Sprint(mfaDigits))
+	query.Set("period", fmt.Sprint(mfaPeriod))
+	otpauth := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + mfaIssuer + ":" + user.Email,
+		RawQuery: query.Encode(),
+	}
+
+	return &model.MFAEnrollResponse{Secret: seed, OTPAuthURL: otpauth.String()}, nil
+}
+
+// Confirm completes enrollment with a code from the new seed
+func (s *MFAService) Confirm(userID uuid.UUID, code string) error {
+	factor, err := s.factor(userID)
+	if err != nil {
+		return err
+	}
+	if factor.ConfirmedAt != nil {
+		return ErrMFAAlreadyEnrolled
+	}
+	if err := s.check(factor, code); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := s.db.Model(factor).Update("confirmed_at", now).Error; err != nil {
+		return fmt.Errorf("failed to confirm MFA: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "mfa_enrolled", "mfa", userID.String(), true, "method="+model.MFAMethodTOTP)
+	}
+	if s.notifications != nil {
+		s.notifications.SecurityAlert(userID, "MFA factor enrolled", "A TOTP factor was added to your account. If this was not you, remove it and change your password.")
+	}
+	return nil
+}
+
+// requireFreshLogin lets users under step-up rules through only with the
+// token of a primary login made within mfaEnrollLoginAge
+func (s *MFAService) requireFreshLogin(userID uuid.UUID, accessor *uuid.UUID) error {
+	applies, err := s.policyService.HasMFARules(userID)
+	if err != nil {
+		return fmt.Errorf("failed to check policies: %w", err)
+	}
+	if !applies {
+		return nil
+	}
+	if accessor == nil {
+		return ErrMFAFreshLoginRequired
+	}
+
+	var token model.Token
+	if err := s.db.Select("method", "parent_id", "created_at").Where("id = ? AND user_id = ?", *accessor, userID).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrMFAFreshLoginRequired
+		}
+		return fmt.Errorf("failed to get token: %w", err)
+	}
+	// Renewals keep created_at, so it is when the login happened
+	if token.ParentID != nil || !mfaEnrollLoginMethods[token.Method] || time.Since(token.CreatedAt) > mfaEnrollLoginAge {
+		return ErrMFAFreshLoginRequired
+	}
+	return nil
+}
+
+// Remove deletes the user's factor, which takes a valid code once it is
+// confirmed
+func (s *MFAService) Remove(userID uuid.UUID, code string) error {
+	factor, err := s.factor(userID)
+	if err != nil {
+		return err
+	}
+	if factor.ConfirmedAt != nil {
+		if err := s.check(factor, code); err != nil {
+			return err
+		}
+	}
+
+	if err := s.db.Delete(factor).Error; err != nil {
+		return fmt.Errorf("failed to remove MFA: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "mfa_removed", "mfa", userID.String(), true, "")
+	}
+	return nil
+}
+
+// Verify checks a code from the user's confirmed factor and records the
+// verification on the calling token, where step-up requirements look for
+// it
+func (s *MFAService) Verify(userID uuid.UUID, accessor *uuid.UUID, code string) (*model.MFAVerifyResponse, error) {
+	if accessor == nil {
+		return nil, ErrTokenNotFound
+	}
+	factor, err := s.factor(userID)
+	if err != nil {
+		return nil, err
+	}
+	if factor.ConfirmedAt == nil {
+		return nil, ErrMFANotEnrolled
+	}
+	if err := s.check(factor, code); err != nil {
+		if s.auditService != nil {
+			s.auditService.LogAction(userID, "mfa_verified", "token", accessor.String(), false, err.Error())
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	result := s.db.Model(&model.Token{}).Where("id = ? AND user_id = ?", *accessor, userID).Update("mfa_verified_at", now)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to record verification: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrTokenNotFound
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "mfa_verified", "token", accessor.String(), true, "method="+model.MFAMethodTOTP)
+	}
+	return &model.MFAVerifyResponse{VerifiedAt: now}, nil
+}
+
+// Require returns a *StepUpRequiredError unless the calling token was
+// verified recently enough for action on resource
+func (s *MFAService) Require(userID uuid.UUID, accessor *uuid.UUID, resource, action string, tags []string) error {
+	maxAge, err := s.policyService.MFAMaxAge(userID, resource, action, tags)
+	if err != nil {
+		return fmt.Errorf("failed to check policies: %w", err)
+	}
+	if maxAge == 0 {
+		return nil
+	}
+
+	if accessor != nil {
+		var token model.Token
+		if err := s.db.Select("mfa_verified_at").Where("id = ? AND user_id = ?", *accessor, userID).First(&token).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to get token: %w", err)
+		}
+		if token.MFAVerifiedAt != nil && time.Since(*token.MFAVerifiedAt) <= time.Duration(maxAge)*time.Second {
+			return nil
+		}
+	}
+
+	enrolled, err := s.enrolled(userID)
+	if err != nil {
+		return err
+	}
+	return &StepUpRequiredError{Requirement: model.MFARequirement{
+		Methods:    []string{model.MFAMethodTOTP},
+		MaxAge:     maxAge,
+		VerifyPath: MFAVerifyPath,
+		Enrolled:   enrolled,
+	}}
+}
+
+func (s *MFAService) factor(userID uuid.UUID) (*model.UserMFA, error) {
+	var factor model.UserMFA
+	if err := s.db.Where("user_id = ?", userID).First(&factor).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMFANotEnrolled
+		}
+		return nil, fmt.Errorf("failed to get MFA enrollment: %w", err)
+	}
+	return &factor, nil
+}
+
+func (s *MFAService) enrolled(userID uuid.UUID) (bool, error) {
+	var count int64
+	if err := s.db.Model(&model.UserMFA{}).Where("user_id = ? AND confirmed_at IS NOT NULL", userID).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to get MFA enrollment: %w", err)
+	}
+	return count > 0, nil
+}
+
+// check compares code with the current and adjacent periods of factor,
+// skipping periods at or before the last accepted one so a code cannot be
+// replayed. Failures count towards the same lockout as TOTP verifications.
+func (s *MFAService) check(factor *model.UserMFA, code string) error {
+	if s.totpService.isRateLimited(factor.UserID) {
+		return ErrTOTPRateLimited
+	}
+
+	seed, err := s.secretService.decrypt(factor.Secret)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt MFA seed: %w", err)
+	}
+
+	current := time.Now().Unix() / mfaPeriod
+	for _, step := range []int64{current, current - 1, current + 1} {
+		if step <= factor.LastStep {
+			continue
+		}
+		expected, err := s.totpService.generateTOTPCode(seed, mfaAlgorithm, mfaDigits, mfaPeriod, time.Unix(step*mfaPeriod, 0))
+		if err != nil {
+			return fmt.Errorf("failed to generate TOTP code: %w", err)
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) != 1 {
+			continue
+		}
+
+		// Of two requests racing with the same code, only one moves the step
+		result := s.db.Model(&model.UserMFA{}).Where("id = ? AND last_step < ?", factor.ID, step).Update("last_step", step)
+		if result.Error != nil {
+			return fmt.Errorf("failed to record MFA code: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			break
+		}
+		factor.LastStep = step
+		s.totpService.clearFailures(factor.UserID)
+		return nil
+	}
+
+	s.totpService.recordFailure(factor.UserID)
+	return ErrMFACodeInvalid
+}
+
+var (
+	ErrMFARequired           = errors.New("a recent MFA verification is required")
+	ErrMFANotEnrolled        = errors.New("no MFA factor is enrolled")
+	ErrMFAAlreadyEnrolled    = errors.New("an MFA factor is already enrolled")
+	ErrMFACodeInvalid        = errors.New("invalid MFA code")
+	ErrMFAFreshLoginRequired = errors.New("enrolling an MFA factor requires a sign-in within the last 10 minutes")
+)
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+)
+
+func TestMFACodeIsNotAcceptedTwice(t *testing.T) {
+	db := newStubDB(t, stubTables{})
+	secrets := NewSecretService(db, "test-key", "test-salt", 1, nil, nil, nil, nil, nil)
+	totp := NewTOTPService(db, secrets, nil)
+	mfa := NewMFAService(db, totp, secrets, NewPolicyService(db, nil), nil, nil)
+
+	seed, err := totp.generateSecret()
+	if err != nil {
+		t.Fatalf("failed to generate seed: %v", err)
+	}
+	sealed, err := secrets.encrypt(seed)
+	if err != nil {
+		t.Fatalf("failed to encrypt seed: %v", err)
+	}
+	factor := &model.UserMFA{ID: uuid.New(), UserID: uuid.New(), Secret: sealed}
+	code, err := totp.generateTOTPCode(seed, mfaAlgorithm, mfaDigits, mfaPeriod, time.Now())
+	if err != nil {
+		t.Fatalf("failed to generate code: %v", err)
+	}
+
+	if err := mfa.check(factor, code); err != nil {
+		t.Fatalf("first use of the code failed: %v", err)
+	}
+	if factor.LastStep == 0 {
+		t.Fatal("accepted code did not record its step")
+	}
+	if err := mfa.check(factor, code); !errors.Is(err, ErrMFACodeInvalid) {
+		t.Fatalf("replayed code got %v, want ErrMFACodeInvalid", err)
+	}
+
+	// A code from the period before the last accepted one is as stale
+	previous, err := totp.generateTOTPCode(seed, mfaAlgorithm, mfaDigits, mfaPeriod, time.Now().Add(-mfaPeriod*time.Second))
+	if err != nil {
+		t.Fatalf("failed to generate code: %v", err)
+	}
+	if previous != code {
+		if err := mfa.check(factor, previous); !errors.Is(err, ErrMFACodeInvalid) {
+			t.Fatalf("code from an earlier period got %v, want ErrMFACodeInvalid", err)
+		}
+	}
+}
+
+func TestMFAEnrollRequiresFreshLogin(t *testing.T) {
+	cases := map[string]struct {
+		token map[string]driver.Value
+		want  error
+	}{
+		"fresh password login": {map[string]driver.Value{"method": model.AuthMethodPassword, "created_at": time.Now().Add(-time.Minute)}, nil},
+		"stale login":          {map[string]driver.Value{"method": model.AuthMethodPassword, "created_at": time.Now().Add(-time.Hour)}, ErrMFAFreshLoginRequired},
+		"child token":          {map[string]driver.Value{"method": model.AuthMethodPassword, "parent_id": uuid.NewString(), "created_at": time.Now()}, ErrMFAFreshLoginRequired},
+		"machine login":        {map[string]driver.Value{"method": model.AuthMethodAppRole, "created_at": time.Now()}, ErrMFAFreshLoginRequired},
+		"unknown token":        {nil, ErrMFAFreshLoginRequired},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			userID := uuid.New()
+			tables := stubTables{
+				"users": {{"id": userID.String(), "email": "user@example.com"}},
+				"policies": {{
+					"id":        uuid.NewString(),
+					"user_id":   userID.String(),
+					"name":      "step-up",
+					"rules":     stepUpRules,
+					"is_active": true,
+				}},
+			}
+			if tc.token != nil {
+				tables["tokens"] = []map[string]driver.Value{tc.token}
+			}
+			db := newStubDB(t, tables)
+			secrets := NewSecretService(db, "test-key", "test-salt", 1, nil, nil, nil, nil, nil)
+			mfa := NewMFAService(db, NewTOTPService(db, secrets, nil), secrets, NewPolicyService(db, nil), nil, nil)
+
+			accessor := uuid.New()
+			_, err := mfa.Enroll(userID, &accessor)
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("got error %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
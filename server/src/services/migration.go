@@ -0,0 +1,370 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/config"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// migrationPhaseRefresh is how often instances re-read migration phases.
+// A phase change reaches every instance within this interval.
+const migrationPhaseRefresh = 15 * time.Second
+
+// OnlineMigrationSpec describes a schema change on a large table that is
+// rolled out without downtime. The new schema is added by AutoMigrate as
+// usual; code on the write and read paths consults Flags to decide which
+// schema to use, and the backfill job copies existing rows in between:
+//
+//	pending → dual_write → backfill → dual_read → complete
+type OnlineMigrationSpec struct {
+	Name        string
+	Description string
+	Table       string
+	// Backfill converts up to limit rows after cursor within tx. It returns
+	// the key of the last row it read and how many rows it read; fewer
+	// than limit means the end of the table was reached.
+	Backfill func(tx *gorm.DB, cursor string, limit int) (next string, scanned int, err error)
+	// Remaining optionally counts the rows still to convert
+	Remaining func(db *gorm.DB) (int64, error)
+}
+
+// BackfillColumns builds a Backfill that walks table in key order and sets
+// columns on each batch, e.g.
+//
+//	BackfillColumns("secrets", "id", map[string]interface{}{"name_key": gorm.Expr("lower(name)")})
+//
+// Soft-deleted rows are included.
+func BackfillColumns(table, key string, updates map[string]interface{}) func(tx *gorm.DB, cursor string, limit int) (string, int, error) {
+	return func(tx *gorm.DB, cursor string, limit int) (string, int, error) {
+		var keys []string
+		query := tx.Table(table).Order(key).Limit(limit)
+		if cursor != "" {
+			query = query.Where(key+" > ?", cursor)
+		}
+		if err := query.Pluck(key, &keys).Error; err != nil {
+			return cursor, 0, fmt.Errorf("failed to read %s: %w", table, err)
+		}
+		if len(keys) == 0 {
+			return cursor, 0, nil
+		}
+
+		if err := tx.Table(table).Where(key+" IN ?", keys).Updates(updates).Error; err != nil {
+			return cursor, 0, fmt.Errorf("failed to backfill %s: %w", table, err)
+		}
+		return keys[len(keys)-1], len(keys), nil
+	}
+}
+
+// MigrationService tracks online migrations. Phases live in the database
+// so every instance switches schema together; each instance caches them
+// and refreshes every migrationPhaseRefresh.
+type MigrationService struct {
+	db           *gorm.DB
+	auditService *AuditService
+	batchSize    int
+
+	mu     sync.RWMutex
+	specs  []*OnlineMigrationSpec
+	phases map[string]model.MigrationPhase
+}
+
+func NewMigrationService(db *gorm.DB, auditService *AuditService, cfg config.JobsConfig) *MigrationService {
+	return &MigrationService{
+		db:           db,
+		auditService: auditService,
+		batchSize:    cfg.OnlineMigrationBatchSize,
+		phases:       make(map[string]model.MigrationPhase),
+	}
+}
+
+// Register adds a migration, recording it as pending the first time
+func (s *MigrationService) Register(spec *OnlineMigrationSpec) error {
+	row := &model.OnlineMigration{Name: spec.Name, Phase: model.MigrationPhasePending}
+	if err := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(row).Error; err != nil {
+		return fmt.Errorf("failed to register migration %s: %w", spec.Name, err)
+	}
+	if err := s.db.Where("name = ?", spec.Name).First(row).Error; err != nil {
+		return fmt.Errorf("failed to get migration %s: %w", spec.Name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.specs = append(s.specs, spec)
+	s.phases[spec.Name] = row.Phase
+	return nil
+}
+
+// Start refreshes the cached phases in the background
+func (s *MigrationService) Start() {
+	go func() {
+		ticker := time.NewTicker(migrationPhaseRefresh)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := s.refresh(); err != nil {
+				log.Printf("⚠️  Failed to refresh migration phases: %v", err)
+			}
+		}
+	}()
+}
+
+func (s *MigrationService) refresh() error {
+	var rows []model.OnlineMigration
+	if err := s.db.Find(&rows).Error; err != nil {
+		return fmt.Errorf("failed to get migrations: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, row := range rows {
+		if _, ok := s.phases[row.Name]; ok {
+			s.phases[row.Name] = row.Phase
+		}
+	}
+	return nil
+}
+
+// Flags returns which schema the read and write paths use. Unknown
+// migrations, and a nil MigrationService, keep to the old schema.
+func (s *MigrationService) Flags(name string) model.MigrationFlags {
+	if s == nil {
+		return migrationFlags(model.MigrationPhasePending)
+	}
+	s.mu.RLock()
+	phase, ok := s.phases[name]
+	s.mu.RUnlock()
+	if !ok {
+		phase = model.MigrationPhasePending
+	}
+	return migrationFlags(phase)
+}
+
+func migrationFlags(phase model.MigrationPhase) model.MigrationFlags {
+	step := phaseIndex(phase)
+	return model.MigrationFlags{
+		WriteOld: step < phaseIndex(model.MigrationPhaseComplete),
+		WriteNew: step >= phaseIndex(model.MigrationPhaseDualWrite),
+		ReadNew:  step >= phaseIndex(model.MigrationPhaseDualRead),
+	}
+}
+
+func phaseIndex(phase model.MigrationPhase) int {
+	for i, known := range model.MigrationPhases {
+		if known == phase {
+			return i
+		}
+	}
+	return -1
+}
+
+// GetMigrations lists registered migrations with their progress
+func (s *MigrationService) GetMigrations() ([]model.OnlineMigrationStatus, error) {
+	s.mu.RLock()
+	specs := append([]*OnlineMigrationSpec(nil), s.specs...)
+	s.mu.RUnlock()
+
+	statuses := make([]model.OnlineMigrationStatus, 0, len(specs))
+	for _, spec := range specs {
+		status, err := s.status(spec)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, *status)
+	}
+	return statuses, nil
+}
+
+func (s *MigrationService) GetMigration(name string) (*model.OnlineMigrationStatus, error) {
+	spec := s.spec(name)
+	if spec == nil {
+		return nil, ErrMigrationNotFound
+	}
+	return s.status(spec)
+}
+
+func (s *MigrationService) status(spec *OnlineMigrationSpec) (*model.OnlineMigrationStatus, error) {
+	var row model.OnlineMigration
+	if err := s.db.Where("name = ?", spec.Name).First(&row).Error; err != nil {
+		return nil, fmt.Errorf("failed to get migration %s: %w", spec.Name, err)
+	}
+
+	status := &model.OnlineMigrationStatus{
+		OnlineMigration: row,
+		Description:     spec.Description,
+		Table:           spec.Table,
+		Flags:           migrationFlags(row.Phase),
+	}
+	if spec.Remaining != nil && row.BackfilledAt == nil {
+		remaining, err := spec.Remaining(s.db)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count rows to backfill: %w", err)
+		}
+		status.Remaining = &remaining
+	}
+	return status, nil
+}
+
+// SetPhase moves a migration to phase. Moving forward skips no phase,
+// starts the backfill only once every instance is dual-writing, and
+// switches reads only after the backfill finished. Moving back to pending
+// discards backfill progress, since the new schema stops being written.
+func (s *MigrationService) SetPhase(name string, phase model.MigrationPhase, userID uuid.UUID) (*model.OnlineMigrationStatus, error) {
+	spec := s.spec(name)
+	if spec == nil {
+		return nil, ErrMigrationNotFound
+	}
+	target := phaseIndex(phase)
+	if target < 0 {
+		return nil, ErrMigrationPhaseInvalid
+	}
+
+	var row model.OnlineMigration
+	if err := s.db.Where("name = ?", name).First(&row).Error; err != nil {
+		return nil, fmt.Errorf("failed to get migration %s: %w", name, err)
+	}
+	current := phaseIndex(row.Phase)
+
+	switch {
+	case target == current:
+		return s.status(spec)
+	case target > current+1:
+		return nil, ErrMigrationPhaseSkipped
+	case phase == model.MigrationPhaseBackfill && time.Since(row.UpdatedAt) < 2*migrationPhaseRefresh:
+		return nil, ErrMigrationNotSettled
+	case phase == model.MigrationPhaseDualRead && row.BackfilledAt == nil:
+		return nil, ErrMigrationNotBackfilled
+	}
+
+	updates := map[string]interface{}{"phase": phase, "updated_by": userID, "error": ""}
+	if phase == model.MigrationPhasePending {
+		updates["cursor"] = ""
+		updates["processed"] = 0
+		updates["backfilled_at"] = nil
+	}
+	result := s.db.Model(&model.OnlineMigration{}).Where("name = ? AND phase = ?", name, row.Phase).Updates(updates)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to update migration %s: %w", name, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrMigrationPhaseChanged
+	}
+
+	s.mu.Lock()
+	s.phases[name] = phase
+	s.mu.Unlock()
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "migration_phase_changed", "migration", name, true, fmt.Sprintf("%s -> %s", row.Phase, phase))
+	}
+
+	return s.status(spec)
+}
+
+// BackfillJob converts the rows of migrations in the backfill phase
+func (s *MigrationService) BackfillJob(interval time.Duration) *Job {
+	return &Job{
+		Name:        "online_migration_backfill",
+		Description: "Backfill rows for online schema migrations in the backfill phase",
+		Interval:    interval,
+		Run:         s.backfill,
+	}
+}
+
+func (s *MigrationService) backfill(ctx context.Context) (int64, string, error) {
+	s.mu.RLock()
+	specs := append([]*OnlineMigrationSpec(nil), s.specs...)
+	s.mu.RUnlock()
+
+	var total int64
+	var finished int
+	for _, spec := range specs {
+		processed, done, err := s.backfillMigration(ctx, spec)
+		total += processed
+		if err != nil {
+			if !errors.Is(err, context.Canceled) {
+				s.db.Model(&model.OnlineMigration{}).Where("name = ?", spec.Name).Update("error", err.Error())
+			}
+			return total, "", fmt.Errorf("migration %s: %w", spec.Name, err)
+		}
+		if done {
+			finished++
+		}
+	}
+
+	return total, fmt.Sprintf("backfilled %d rows, finished %d migrations", total, finished), nil
+}
+
+// backfillMigration runs batches until the table is done, the phase
+// changes or ctx ends. Each batch commits with its cursor, so an
+// interrupted backfill resumes where it stopped.
+func (s *MigrationService) backfillMigration(ctx context.Context, spec *OnlineMigrationSpec) (int64, bool, error) {
+	var processed int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return processed, false, err
+		}
+
+		var done, stopped bool
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			var row model.OnlineMigration
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("name = ?", spec.Name).First(&row).Error; err != nil {
+				return fmt.Errorf("failed to get migration: %w", err)
+			}
+			if row.Phase != model.MigrationPhaseBackfill || row.BackfilledAt != nil {
+				stopped = true
+				return nil
+			}
+
+			next, scanned, err := spec.Backfill(tx, row.Cursor, s.batchSize)
+			if err != nil {
+				return err
+			}
+			processed += int64(scanned)
+
+			updates := map[string]interface{}{
+				"cursor":    next,
+				"processed": gorm.Expr("processed + ?", scanned),
+				"error":     "",
+			}
+			if scanned < s.batchSize {
+				done = true
+				updates["backfilled_at"] = time.Now()
+			}
+			return tx.Model(&model.OnlineMigration{}).Where("name = ?", spec.Name).Updates(updates).Error
+		})
+		if err != nil {
+			return processed, false, err
+		}
+		if stopped || done {
+			return processed, done, nil
+		}
+	}
+}
+
+func (s *MigrationService) spec(name string) *OnlineMigrationSpec {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, spec := range s.specs {
+		if spec.Name == name {
+			return spec
+		}
+	}
+	return nil
+}
+
+var (
+	ErrMigrationNotFound      = errors.New("migration not found")
+	ErrMigrationPhaseInvalid  = errors.New("unknown migration phase")
+	ErrMigrationPhaseSkipped  = errors.New("migrations advance one phase at a time")
+	ErrMigrationNotSettled    = errors.New("dual writes started too recently; wait until every instance has picked them up")
+	ErrMigrationNotBackfilled = errors.New("the backfill has not finished")
+	ErrMigrationPhaseChanged  = errors.New("the migration phase changed concurrently")
+)
@@ -0,0 +1,302 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var mountPathPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// mountEngines lists the engine types this build can serve at a new
+// path. TOTP exists only at its built-in mount; the other engines are
+// reserved names without an implementation yet.
+var mountEngines = map[model.MountType]bool{
+	model.MountTypeKV: true,
+}
+
+// MountService keeps the mount table: which secrets engine is enabled at
+// which path, with per-mount options
+type MountService struct {
+	db               *gorm.DB
+	namespaceService *NamespaceService
+	auditService     *AuditService
+}
+
+func NewMountService(db *gorm.DB, namespaceService *NamespaceService, auditService *AuditService) *MountService {
+	return &MountService{
+		db:               db,
+		namespaceService: namespaceService,
+		auditService:     auditService,
+	}
+}
+
+// EnsureBuiltins creates the mounts behind the original /secrets and
+// /totp routes
+func (s *MountService) EnsureBuiltins() error {
+	builtins := []model.Mount{
+		{Path: model.DefaultKVMount, Type: model.MountTypeKV, Description: "Default key/value secrets", Builtin: true},
+		{Path: model.DefaultTOTPMount, Type: model.MountTypeTOTP, Description: "TOTP codes", Builtin: true},
+	}
+	if err := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&builtins).Error; err != nil {
+		return fmt.Errorf("failed to create built-in mounts: %w", err)
+	}
+	return nil
+}
+
+func (s *MountService) GetMounts() ([]model.Mount, error) {
+	var mounts []model.Mount
+	if err := s.db.Order("path").Find(&mounts).Error; err != nil {
+		return nil, fmt.Errorf("failed to get mounts: %w", err)
+	}
+	return mounts, nil
+}
+
+func (s *MountService) GetMount(path string) (*model.Mount, error) {
+	var mount model.Mount
+	if err := s.db.Where("path = ?", path).First(&mount).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMountNotFound
+		}
+		return nil, fmt.Errorf("failed to get mount: %w", err)
+	}
+	return &mount, nil
+}
+
+// EnableMount enables an engine at a new path
+func (s *MountService) EnableMount(req *model.EnableMountRequest, userID uuid.UUID) (*model.Mount, error) {
+	path := strings.Trim(strings.ToLower(req.Path), "/")
+	if !mountPathPattern.MatchString(path) {
+		return nil, ErrMountInvalidPath
+	}
+	if req.Type == model.MountTypeTOTP {
+		return nil, fmt.Errorf("%w: totp is only served at its built-in mount", ErrMountTypeUnavailable)
+	}
+	if !mountEngines[req.Type] {
+		return nil, fmt.Errorf("%w: %s", ErrMountTypeUnavailable, req.Type)
+	}
+	if req.NamespaceID != nil {
+		if _, err := s.namespaceService.GetNamespaceByID(*req.NamespaceID); err != nil {
+			return nil, err
+		}
+	}
+
+	mount := &model.Mount{
+		Path:        path,
+		Type:        req.Type,
+		Description: req.Description,
+		NamespaceID: req.NamespaceID,
+		CreatedBy:   &userID,
+	}
+	if err := applyMountOptions(mount, req.Options); err != nil {
+		return nil, err
+	}
+
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(mount)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to enable mount: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrMountExists
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "mount_enabled", "mount", path, true, fmt.Sprintf("type=%s versioning=%t", mount.Type, mount.Versioning))
+	}
+
+	return s.GetMount(path)
+}
+
+// TuneMount changes a mount's description and options. Turning
+// versioning off keeps the versions already stored.
+func (s *MountService) TuneMount(path string, req *model.TuneMountRequest, userID uuid.UUID) (*model.Mount, error) {
+	mount, err := s.GetMount(path)
+	if err != nil {
+		return nil, err
+	}
+	if req.Description != nil {
+		mount.Description = *req.Description
+	}
+	if err := applyMountOptions(mount, req.Options); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Save(mount).Error; err != nil {
+		return nil, fmt.Errorf("failed to tune mount: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "mount_tuned", "mount", path, true, fmt.Sprintf("default_ttl=%d max_ttl=%d default_lease_ttl=%d max_lease_ttl=%d versioning=%t", mount.DefaultTTL, mount.MaxTTL, mount.DefaultLeaseTTL, mount.MaxLeaseTTL, mount.Versioning))
+	}
+
+	return mount, nil
+}
+
+// DisableMount removes an empty mount. Built-in mounts cannot be disabled.
+func (s *MountService) DisableMount(path string, userID uuid.UUID) error {
+	mount, err := s.GetMount(path)
+	if err != nil {
+		return err
+	}
+	if mount.Builtin {
+		return ErrMountBuiltin
+	}
+
+	var secrets int64
+	if err := s.db.Model(&model.Secret{}).Where("mount = ?", path).Count(&secrets).Error; err != nil {
+		return fmt.Errorf("failed to count mount secrets: %w", err)
+	}
+	if secrets > 0 {
+		return fmt.Errorf("%w: %d secrets", ErrMountNotEmpty, secrets)
+	}
+
+	if err := s.db.Where("path = ?", path).Delete(&model.Mount{}).Error; err != nil {
+		return fmt.Errorf("failed to disable mount: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "mount_disabled", "mount", path, true, "type="+string(mount.Type))
+	}
+
+	return nil
+}
+
+func applyMountOptions(mount *model.Mount, options model.MountOptions) error {
+	if options.DefaultTTL != nil {
+		mount.DefaultTTL = *options.DefaultTTL
+	}
+	if options.MaxTTL != nil {
+		mount.MaxTTL = *options.MaxTTL
+	}
+	if options.DefaultLeaseTTL != nil {
+		mount.DefaultLeaseTTL = *options.DefaultLeaseTTL
+	}
+	if options.MaxLeaseTTL != nil {
+		mount.MaxLeaseTTL = *options.MaxLeaseTTL
+	}
+	if options.Versioning != nil {
+		mount.Versioning = *options.Versioning
+	}
+
+	if mount.DefaultTTL < 0 || mount.MaxTTL < 0 || mount.DefaultLeaseTTL < 0 || mount.MaxLeaseTTL < 0 {
+		return fmt.Errorf("%w: TTLs cannot be negative", ErrMountOptionsInvalid)
+	}
+	if mount.MaxTTL > 0 && mount.DefaultTTL > mount.MaxTTL {
+		return fmt.Errorf("%w: default_ttl exceeds max_ttl", ErrMountOptionsInvalid)
+	}
+	if mount.MaxLeaseTTL > 0 && mount.DefaultLeaseTTL > mount.MaxLeaseTTL {
+		return fmt.Errorf("%w: default_lease_ttl exceeds max_lease_ttl", ErrMountOptionsInvalid)
+	}
+	return nil
+}
+
+// resolveSecretTTL decides a secret's expiry in mount: the requested ttl
+// or expires_at, else the mount's default_ttl, else its max_ttl. A request
+// beyond max_ttl is rejected rather than silently shortened.
+func resolveSecretTTL(mount *model.Mount, ttl *int, expiresAt *time.Time, now time.Time) (*model.TTLResolution, error) {
+	resolution := &model.TTLResolution{DefaultTTL: mount.DefaultTTL, MaxTTL: mount.MaxTTL}
+
+	switch {
+	case ttl != nil && expiresAt != nil:
+		return nil, fmt.Errorf("%w: set either ttl or expires_at", ErrMountTTLInvalid)
+	case ttl != nil:
+		if *ttl < 1 {
+			return nil, fmt.Errorf("%w: ttl must be a positive number of seconds", ErrMountTTLInvalid)
+		}
+		requested := *ttl
+		resolution.Requested = &requested
+		resolution.Source = model.TTLSourceRequest
+	case expiresAt != nil:
+		requested := int(expiresAt.Sub(now).Seconds())
+		if requested < 0 {
+			requested = 0
+		}
+		resolution.Requested = &requested
+		resolution.Source = model.TTLSourceRequest
+	case mount.DefaultTTL > 0:
+		resolution.Effective = mount.DefaultTTL
+		resolution.Source = model.TTLSourceMountDefault
+	case mount.MaxTTL > 0:
+		resolution.Effective = mount.MaxTTL
+		resolution.Source = model.TTLSourceMountMax
+	default:
+		resolution.Source = model.TTLSourceNone
+		return resolution, nil
+	}
+
+	if resolution.Requested != nil {
+		if mount.MaxTTL > 0 && *resolution.Requested > mount.MaxTTL {
+			return nil, fmt.Errorf("%w: requested %ds, max_ttl of mount %s is %ds", ErrMountTTLExceeded, *resolution.Requested, mount.Path, mount.MaxTTL)
+		}
+		resolution.Effective = *resolution.Requested
+	}
+
+	if expiresAt == nil {
+		resolved := now.Add(time.Duration(resolution.Effective) * time.Second)
+		expiresAt = &resolved
+	}
+	resolution.ExpiresAt = expiresAt
+	return resolution, nil
+}
+
+// resolveLeaseTTL decides how long access leased out of mount lasts: the
+// requested ttl, else the mount's default_lease_ttl, else the server
+// default. The mount's max_lease_ttl and the server maximum both apply.
+func resolveLeaseTTL(mount *model.Mount, ttl int, serverDefault, serverMax time.Duration, now time.Time) (*model.TTLResolution, error) {
+	resolution := &model.TTLResolution{
+		DefaultTTL: int(serverDefault.Seconds()),
+		MaxTTL:     int(serverMax.Seconds()),
+		Source:     model.TTLSourceServer,
+	}
+	if mount != nil {
+		if mount.DefaultLeaseTTL > 0 {
+			resolution.DefaultTTL = mount.DefaultLeaseTTL
+			resolution.Source = model.TTLSourceMountDefault
+		}
+		if mount.MaxLeaseTTL > 0 && mount.MaxLeaseTTL < resolution.MaxTTL {
+			resolution.MaxTTL = mount.MaxLeaseTTL
+		}
+	}
+	if resolution.DefaultTTL > resolution.MaxTTL {
+		resolution.DefaultTTL = resolution.MaxTTL
+	}
+
+	resolution.Effective = resolution.DefaultTTL
+	if ttl < 0 {
+		return nil, fmt.Errorf("%w: ttl cannot be negative", ErrMountTTLInvalid)
+	}
+	if ttl > 0 {
+		requested := ttl
+		resolution.Requested = &requested
+		resolution.Source = model.TTLSourceRequest
+		if requested > resolution.MaxTTL {
+			return nil, fmt.Errorf("%w: requested %ds, max_lease_ttl is %ds", ErrMountTTLExceeded, requested, resolution.MaxTTL)
+		}
+		resolution.Effective = requested
+	}
+
+	expiresAt := now.Add(time.Duration(resolution.Effective) * time.Second)
+	resolution.ExpiresAt = &expiresAt
+	return resolution, nil
+}
+
+var (
+	ErrMountNotFound        = errors.New("mount not found")
+	ErrMountExists          = errors.New("a mount already exists at this path")
+	ErrMountInvalidPath     = errors.New("mount paths are a single lowercase segment such as team-payments")
+	ErrMountTypeUnavailable = errors.New("secrets engine is not available in this build")
+	ErrMountOptionsInvalid  = errors.New("invalid mount options")
+	ErrMountBuiltin         = errors.New("built-in mounts cannot be disabled")
+	ErrMountNotEmpty        = errors.New("mount still holds secrets")
+	ErrMountWrongType       = errors.New("mount does not serve this engine")
+	ErrMountTTLExceeded     = errors.New("TTL exceeds the mount's maximum")
+	ErrMountTTLInvalid      = errors.New("invalid TTL")
+	ErrMountNamespace       = errors.New("secrets in this mount belong to the mount's namespace")
+)
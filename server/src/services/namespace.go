@@ -0,0 +1,215 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// namespaceRolePermissions defines the built-in delegated roles. Central
+// admins bypass these checks entirely.
+var namespaceRolePermissions = map[model.NamespaceRole][]model.NamespacePermission{
+	model.NamespaceRoleAdmin: {
+		model.NamespacePermissionSecretsRead,
+		model.NamespacePermissionSecretsWrite,
+		model.NamespacePermissionAuditRead,
+		model.NamespacePermissionRolesManage,
+		model.NamespacePermissionKeysManage,
+	},
+	model.NamespaceRoleSecretsWriter: {
+		model.NamespacePermissionSecretsRead,
+		model.NamespacePermissionSecretsWrite,
+	},
+	model.NamespaceRoleAuditor: {
+		model.NamespacePermissionAuditRead,
+	},
+}
+
+var namespaceNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*(/[a-z0-9][a-z0-9_-]*)*$`)
+
+type NamespaceService struct {
+	db           *gorm.DB
+	auditService *AuditService
+}
+
+func NewNamespaceService(db *gorm.DB, auditService *AuditService) *NamespaceService {
+	return &NamespaceService{
+		db:           db,
+		auditService: auditService,
+	}
+}
+
+func (s *NamespaceService) CreateNamespace(req *model.CreateNamespaceRequest, userID uuid.UUID) (*model.Namespace, error) {
+	name := strings.Trim(strings.ToLower(req.Name), "/")
+	if !namespaceNamePattern.MatchString(name) {
+		return nil, ErrNamespaceInvalidName
+	}
+
+	namespace := &model.Namespace{
+		Name:        name,
+		Description: req.Description,
+		CreatedBy:   userID,
+	}
+
+	if err := s.db.Create(namespace).Error; err != nil {
+		return nil, fmt.Errorf("failed to create namespace: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "namespace_created", "namespace", namespace.ID.String(), true, name)
+	}
+
+	return namespace, nil
+}
+
+func (s *NamespaceService) GetNamespaces() ([]model.Namespace, error) {
+	var namespaces []model.Namespace
+	if err := s.db.Order("name").Find(&namespaces).Error; err != nil {
+		return nil, fmt.Errorf("failed to get namespaces: %w", err)
+	}
+
+	return namespaces, nil
+}
+
+func (s *NamespaceService) GetNamespaceByID(id uuid.UUID) (*model.Namespace, error) {
+	var namespace model.Namespace
+	if err := s.db.Where("id = ?", id).First(&namespace).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNamespaceNotFound
+		}
+		return nil, fmt.Errorf("failed to get namespace: %w", err)
+	}
+
+	return &namespace, nil
+}
+
+// GetRoles lists the built-in namespace roles and their permissions
+func (s *NamespaceService) GetRoles() []model.NamespaceRoleInfo {
+	roles := []model.NamespaceRole{
+		model.NamespaceRoleAdmin,
+		model.NamespaceRoleSecretsWriter,
+		model.NamespaceRoleAuditor,
+	}
+
+	info := make([]model.NamespaceRoleInfo, 0, len(roles))
+	for _, role := range roles {
+		info = append(info, model.NamespaceRoleInfo{
+			Role:        role,
+			Permissions: namespaceRolePermissions[role],
+		})
+	}
+	return info
+}
+
+func (s *NamespaceService) GetRoleBindings(namespaceID uuid.UUID) ([]model.NamespaceRoleBinding, error) {
+	var bindings []model.NamespaceRoleBinding
+	if err := s.db.Where("namespace_id = ?", namespaceID).Order("role, created_at").Find(&bindings).Error; err != nil {
+		return nil, fmt.Errorf("failed to get role bindings: %w", err)
+	}
+
+	return bindings, nil
+}
+
+// AssignRole grants role in a namespace to every user in userIDs, or to
+// none of them if any user does not exist.
+func (s *NamespaceService) AssignRole(namespaceID uuid.UUID, role model.NamespaceRole, userIDs []uuid.UUID, actorID uuid.UUID) (int64, error) {
+	if _, ok := namespaceRolePermissions[role]; !ok {
+		return 0, ErrNamespaceInvalidRole
+	}
+
+	userIDs = uniqueUserIDs(userIDs)
+
+	var assigned int64
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.Namespace{}).Where("id = ?", namespaceID).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to get namespace: %w", err)
+		}
+		if count == 0 {
+			return ErrNamespaceNotFound
+		}
+
+		if err := tx.Model(&model.User{}).Where("id IN ?", userIDs).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to verify users: %w", err)
+		}
+		if count != int64(len(userIDs)) {
+			return ErrUserNotFound
+		}
+
+		bindings := make([]model.NamespaceRoleBinding, 0, len(userIDs))
+		for _, userID := range userIDs {
+			bindings = append(bindings, model.NamespaceRoleBinding{
+				NamespaceID: namespaceID,
+				UserID:      userID,
+				Role:        role,
+				GrantedBy:   actorID,
+			})
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&bindings)
+		if result.Error != nil {
+			return fmt.Errorf("failed to assign role: %w", result.Error)
+		}
+		assigned = result.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(actorID, "namespace_role_assigned", "namespace", namespaceID.String(), true, fmt.Sprintf("role=%s users=%d", role, len(userIDs)))
+	}
+
+	return assigned, nil
+}
+
+func (s *NamespaceService) RevokeRole(namespaceID uuid.UUID, role model.NamespaceRole, userID uuid.UUID, actorID uuid.UUID) error {
+	result := s.db.Where("namespace_id = ? AND user_id = ? AND role = ?", namespaceID, userID, role).Delete(&model.NamespaceRoleBinding{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke role: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNamespaceBindingNotFound
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(actorID, "namespace_role_revoked", "namespace", namespaceID.String(), true, fmt.Sprintf("role=%s user=%s", role, userID))
+	}
+
+	return nil
+}
+
+// HasPermission reports whether any of the user's roles in the namespace
+// grants permission
+func (s *NamespaceService) HasPermission(userID, namespaceID uuid.UUID, permission model.NamespacePermission) (bool, error) {
+	var roles []model.NamespaceRole
+	if err := s.db.Model(&model.NamespaceRoleBinding{}).
+		Where("namespace_id = ? AND user_id = ?", namespaceID, userID).
+		Pluck("role", &roles).Error; err != nil {
+		return false, fmt.Errorf("failed to get role bindings: %w", err)
+	}
+
+	for _, role := range roles {
+		for _, granted := range namespaceRolePermissions[role] {
+			if granted == permission {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+var (
+	ErrNamespaceNotFound        = errors.New("namespace not found")
+	ErrNamespaceInvalidName     = errors.New("namespace names are lowercase path segments such as team/payments")
+	ErrNamespaceInvalidRole     = errors.New("unknown namespace role")
+	ErrNamespaceBindingNotFound = errors.New("role binding not found")
+)
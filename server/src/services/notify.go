@@ -0,0 +1,314 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/config"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+)
+
+const (
+	securityAlertWindow = time.Hour
+	// Repeats of a raised condition are published at most this often,
+	// enough to keep its incident from resolving as stale
+	conditionRepeatWindow = time.Minute
+)
+
+// eventPattern is the syntax of routing rules: "*", an exact event type or
+// a prefix such as "security.*"
+var eventPattern = regexp.MustCompile(`^(\*|[a-z_]+(\.[a-z_]+)*(\.\*)?)$`)
+
+// EventSink receives every published event, e.g. chat integrations
+type EventSink func(event *model.Event) error
+
+// NotificationService publishes events to the configured webhook and any
+// registered sinks, and raises security alerts over events and email
+type NotificationService struct {
+	cfg     config.NotificationsConfig
+	emails  *EmailNotifier
+	client  *http.Client
+	sinks   []EventSink
+	alerted map[string]time.Time
+	// failed sign-ins per account within the escalation window
+	failures map[uuid.UUID]*authFailures
+	mutex    sync.Mutex
+}
+
+type authFailures struct {
+	count int
+	since time.Time
+}
+
+func NewNotificationService(cfg config.NotificationsConfig, emails *EmailNotifier) *NotificationService {
+	return &NotificationService{
+		cfg:      cfg,
+		emails:   emails,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		alerted:  make(map[string]time.Time),
+		failures: make(map[uuid.UUID]*authFailures),
+	}
+}
+
+func (s *NotificationService) WebhookEnabled() bool {
+	return s.cfg.WebhookURL != ""
+}
+
+// AddSink registers a consumer for every event published from now on
+func (s *NotificationService) AddSink(sink EventSink) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.sinks = append(s.sinks, sink)
+}
+
+// Publish delivers the event to the webhook, when configured, and to every
+// sink. It returns the failures of all of them joined together.
+func (s *NotificationService) Publish(event *model.Event) error {
+	s.mutex.Lock()
+	sinks := append([]EventSink(nil), s.sinks...)
+	s.mutex.Unlock()
+
+	var errs []error
+	if s.WebhookEnabled() {
+		if err := s.deliverWebhook(event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, sink := range sinks {
+		if err := sink(event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// SecurityAlert tells a user about a security event on their account by
+// email and publishes it as a security.alert event. Both happen in the
+// background, at most once per user and event per hour.
+func (s *NotificationService) SecurityAlert(userID uuid.UUID, event, details string) {
+	if !s.allow(userID.String()+"/"+event, securityAlertWindow) {
+		return
+	}
+
+	go func() {
+		if s.emails != nil {
+			if err := s.emails.NotifyUser(userID, model.NotificationCategorySecurity, EmailTemplateSecurityAlert, map[string]interface{}{
+				"Event":   event,
+				"Details": details,
+			}); err != nil {
+				log.Printf("⚠️  Failed to email security alert to user %s: %v", userID, err)
+			}
+		}
+
+		if err := s.Publish(model.NewEvent(model.EventSecurityAlert, map[string]interface{}{
+			"user_id": userID,
+			"event":   event,
+			"details": details,
+		})); err != nil {
+			log.Printf("⚠️  Failed to publish security alert for user %s: %v", userID, err)
+		}
+	}()
+}
+
+// ApprovalRequested emails approvers that a request waits for their
+// decision, in the background. Approvers who opted out of approval emails
+// are skipped.
+func (s *NotificationService) ApprovalRequested(approvers []uuid.UUID, requestID, summary, requester string) {
+	if s.emails == nil || len(approvers) == 0 {
+		return
+	}
+
+	go func() {
+		for _, approverID := range approvers {
+			if err := s.emails.NotifyUser(approverID, model.NotificationCategoryApprovals, EmailTemplateApprovalRequested, map[string]interface{}{
+				"Summary":   summary,
+				"Requester": requester,
+				"RequestID": requestID,
+			}); err != nil {
+				log.Printf("⚠️  Failed to email approval request %s to user %s: %v", requestID, approverID, err)
+			}
+		}
+	}()
+}
+
+// RaiseCondition publishes an event for an ongoing condition named by the
+// event's dedup_key, such as repeated sign-in failures. Incident
+// integrations keep one incident open per condition until ClearCondition
+// is called or it stops recurring.
+func (s *NotificationService) RaiseCondition(event *model.Event) {
+	dedupKey, _ := event.Data["dedup_key"].(string)
+	if !s.allow("condition/"+dedupKey, conditionRepeatWindow) {
+		return
+	}
+
+	go func() {
+		if err := s.Publish(event); err != nil {
+			log.Printf("⚠️  Failed to publish %s: %v", event.Type, err)
+		}
+	}()
+}
+
+// ClearCondition publishes condition.cleared for a condition raised with
+// RaiseCondition
+func (s *NotificationService) ClearCondition(dedupKey, reason string) {
+	s.mutex.Lock()
+	delete(s.alerted, "condition/"+dedupKey)
+	s.mutex.Unlock()
+
+	go func() {
+		if err := s.Publish(model.NewEvent(model.EventConditionCleared, map[string]interface{}{
+			"dedup_key": dedupKey,
+			"reason":    reason,
+		})); err != nil {
+			log.Printf("⚠️  Failed to publish cleared condition %s: %v", dedupKey, err)
+		}
+	}()
+}
+
+// RecordAuthFailure counts a failed sign-in and raises
+// security.auth_failures once the account reaches the configured threshold
+// within the window
+func (s *NotificationService) RecordAuthFailure(userID uuid.UUID, email string) {
+	threshold := s.cfg.Escalation.AuthFailureThreshold
+	if threshold <= 0 {
+		return
+	}
+	window := time.Duration(s.cfg.Escalation.AuthFailureWindow) * time.Second
+
+	s.mutex.Lock()
+	for other, failures := range s.failures {
+		if time.Since(failures.since) >= window {
+			delete(s.failures, other)
+		}
+	}
+	failures, ok := s.failures[userID]
+	if !ok {
+		failures = &authFailures{since: time.Now()}
+		s.failures[userID] = failures
+	}
+	failures.count++
+	count := failures.count
+	s.mutex.Unlock()
+
+	if count < threshold {
+		return
+	}
+
+	s.RaiseCondition(model.NewEvent(model.EventAuthFailures, map[string]interface{}{
+		"summary":        fmt.Sprintf("%d failed sign-ins for %s", count, email),
+		"dedup_key":      authFailuresDedupKey(userID),
+		"user_id":        userID,
+		"failures":       count,
+		"window_seconds": s.cfg.Escalation.AuthFailureWindow,
+	}))
+}
+
+// ClearAuthFailures resets the count after a successful sign-in and clears
+// the condition if it had been raised
+func (s *NotificationService) ClearAuthFailures(userID uuid.UUID) {
+	s.mutex.Lock()
+	failures, ok := s.failures[userID]
+	delete(s.failures, userID)
+	s.mutex.Unlock()
+
+	if ok && s.cfg.Escalation.AuthFailureThreshold > 0 && failures.count >= s.cfg.Escalation.AuthFailureThreshold {
+		s.ClearCondition(authFailuresDedupKey(userID), "successful sign-in")
+	}
+}
+
+// allow reports whether key may fire again, recording it if so. Entries
+// older than the longest window are pruned.
+func (s *NotificationService) allow(key string, window time.Duration) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if last, ok := s.alerted[key]; ok && time.Since(last) < window {
+		return false
+	}
+	s.alerted[key] = time.Now()
+	for other, last := range s.alerted {
+		if time.Since(last) >= securityAlertWindow {
+			delete(s.alerted, other)
+		}
+	}
+	return true
+}
+
+// deliverWebhook POSTs the event as JSON. When a webhook secret is
+// configured the body is signed with HMAC-SHA256 in X-Vault-Signature.
+func (s *NotificationService) deliverWebhook(event *model.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.cfg.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Vault-Event", string(event.Type))
+	req.Header.Set("X-Vault-Delivery", event.ID.String())
+	if s.cfg.WebhookSecret != "" {
+		mac := hmac.New(sha256.New, []byte(s.cfg.WebhookSecret))
+		mac.Write(body)
+		req.Header.Set("X-Vault-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNotificationDelivery, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%w: webhook returned %s", ErrNotificationDelivery, resp.Status)
+	}
+
+	return nil
+}
+
+// eventMatches reports whether a routing rule selects the event
+func eventMatches(pattern string, minSeverity model.EventSeverity, event *model.Event) bool {
+	if event.Severity.Rank() < minSeverity.Rank() {
+		return false
+	}
+	if pattern == "*" || pattern == string(event.Type) {
+		return true
+	}
+	return strings.HasSuffix(pattern, ".*") && strings.HasPrefix(string(event.Type), strings.TrimSuffix(pattern, "*"))
+}
+
+func authFailuresDedupKey(userID uuid.UUID) string {
+	return "auth_failures:" + userID.String()
+}
+
+// eventTitle is the one-line description of an event shown by chat and
+// incident integrations
+func eventTitle(event *model.Event) string {
+	if summary, ok := event.Data["summary"].(string); ok && summary != "" {
+		return summary
+	}
+	if detail, ok := event.Data["event"].(string); ok && detail != "" {
+		return detail
+	}
+	return string(event.Type)
+}
+
+var (
+	ErrNotificationChannelDisabled = errors.New("notification channel is not configured")
+	ErrNotificationDelivery        = errors.New("notification delivery failed")
+)
@@ -0,0 +1,410 @@
+package services
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/config"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+)
+
+const oidcSigningAlgorithm = "RS256"
+
+var (
+	oidcReservedClaims = map[string]bool{
+		"iss": true, "sub": true, "aud": true, "exp": true, "iat": true, "nbf": true, "jti": true,
+	}
+	oidcTemplateVariable = regexp.MustCompile(`\{\{\s*([a-z_.]+)\s*\}\}`)
+)
+
+// OIDCService lets the vault act as an OIDC provider: it signs identity
+// tokens describing the authenticated user and publishes a JWKS so
+// downstream services can verify them.
+type OIDCService struct {
+	db            *gorm.DB
+	secretService *SecretService
+	userService   *UserService
+	auditService  *AuditService
+	config        *config.OIDCConfig
+	mutex         sync.Mutex
+	jwksCache     *ttlCache
+}
+
+func NewOIDCService(db *gorm.DB, secretService *SecretService, userService *UserService, auditService *AuditService, config *config.OIDCConfig) *OIDCService {
+	return &OIDCService{
+		db:            db,
+		secretService: secretService,
+		userService:   userService,
+		auditService:  auditService,
+		config:        config,
+	}
+}
+
+// ConfiguredIssuer returns the issuer set in configuration, if any
+func (s *OIDCService) ConfiguredIssuer() string {
+	return strings.TrimRight(s.config.Issuer, "/")
+}
+
+func (s *OIDCService) Discovery(issuer string) *model.OIDCDiscoveryResponse {
+	return &model.OIDCDiscoveryResponse{
+		Issuer:                           issuer,
+		JWKSURI:                          issuer + "/.well-known/keys",
+		ResponseTypesSupported:           []string{"id_token"},
+		SubjectTypesSupported:            []string{"public"},
+		IDTokenSigningAlgValuesSupported: []string{oidcSigningAlgorithm},
+	}
+}
+
+func (s *OIDCService) CreateRole(req *model.CreateOIDCRoleRequest, userID uuid.UUID) (*model.OIDCRole, error) {
+	if req.Template != "" {
+		if _, err := renderClaimsTemplate(req.Template, map[string]string{}); err != nil {
+			return nil, err
+		}
+	}
+
+	role := &model.OIDCRole{
+		Name:      req.Name,
+		ClientID:  req.ClientID,
+		TTL:       req.TTL,
+		Template:  req.Template,
+		CreatedBy: userID,
+	}
+	if role.ClientID == "" {
+		role.ClientID = uuid.NewString()
+	}
+	if role.TTL <= 0 {
+		role.TTL = s.config.DefaultTokenTTL
+	}
+	if time.Duration(role.TTL)*time.Second > s.verificationTTL() {
+		return nil, fmt.Errorf("%w: ttl exceeds the key verification window", ErrOIDCInvalidRole)
+	}
+
+	if err := s.db.Create(role).Error; err != nil {
+		return nil, fmt.Errorf("failed to create oidc role: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "oidc_role_created", "oidc_role", role.ID.String(), true, role.Name)
+	}
+
+	return role, nil
+}
+
+func (s *OIDCService) GetRoles() ([]model.OIDCRole, error) {
+	var roles []model.OIDCRole
+	if err := s.db.Order("name").Find(&roles).Error; err != nil {
+		return nil, fmt.Errorf("failed to get oidc roles: %w", err)
+	}
+
+	return roles, nil
+}
+
+// IssueToken signs an identity token for userID using the named role
+func (s *OIDCService) IssueToken(roleName string, userID uuid.UUID, issuer string) (*model.IdentityTokenResponse, error) {
+	var role model.OIDCRole
+	if err := s.db.Where("name = ?", roleName).First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOIDCRoleNotFound
+		}
+		return nil, fmt.Errorf("failed to get oidc role: %w", err)
+	}
+
+	user, err := s.userService.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	if role.Template != "" {
+		rendered, err := renderClaimsTemplate(role.Template, identityVariables(user))
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range rendered {
+			claims[name] = value
+		}
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(time.Duration(role.TTL) * time.Second)
+	claims["iss"] = issuer
+	claims["sub"] = user.ID.String()
+	claims["aud"] = role.ClientID
+	claims["iat"] = now.Unix()
+	claims["nbf"] = now.Unix()
+	claims["exp"] = expiresAt.Unix()
+	claims["jti"] = uuid.NewString()
+
+	key, signer, err := s.signingKey()
+	if err != nil {
+		return nil, err
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = key.ID
+
+	signed, err := token.SignedString(signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign identity token: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "identity_token_issued", "oidc_role", role.ID.String(), true, fmt.Sprintf("kid=%s aud=%s", key.ID, role.ClientID))
+	}
+
+	return &model.IdentityTokenResponse{
+		Token:     signed,
+		ClientID:  role.ClientID,
+		TTL:       role.TTL,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// SignClaims signs claims with the active key so anyone holding the JWKS
+// can verify them
+func (s *OIDCService) SignClaims(claims jwt.MapClaims) (string, error) {
+	key, signer, err := s.signingKey()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = key.ID
+
+	signed, err := token.SignedString(signer)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign claims: %w", err)
+	}
+	return signed, nil
+}
+
+// EnableCache caches the key set for ttl, or until the first key in it
+// expires
+func (s *OIDCService) EnableCache(ttl time.Duration) {
+	s.jwksCache = newTTLCache(ttl)
+	s.jwksCache.clearOnWrite(s.db, "jwks", "oidc_keys")
+}
+
+// JWKS returns every public key that may still have signed a valid token
+func (s *OIDCService) JWKS() (*model.JSONWebKeySet, error) {
+	if cached, ok := s.jwksCache.get("jwks"); ok {
+		set := cached.(*model.JSONWebKeySet)
+		return &model.JSONWebKeySet{Keys: append([]model.JSONWebKey(nil), set.Keys...)}, nil
+	}
+
+	var keys []model.OIDCKey
+	if err := s.db.Where("expires_at IS NULL OR expires_at > ?", time.Now()).Order("created_at DESC").Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to get oidc keys: %w", err)
+	}
+
+	set := &model.JSONWebKeySet{Keys: make([]model.JSONWebKey, 0, len(keys))}
+	for _, key := range keys {
+		block, _ := pem.Decode([]byte(key.PublicKey))
+		if block == nil {
+			return nil, fmt.Errorf("invalid public key for kid %s", key.ID)
+		}
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key: %w", err)
+		}
+		public, ok := parsed.(*rsa.PublicKey)
+		if !ok {
+			continue
+		}
+
+		set.Keys = append(set.Keys, model.JSONWebKey{
+			KeyType:   "RSA",
+			KeyID:     key.ID,
+			Use:       "sig",
+			Algorithm: key.Algorithm,
+			Modulus:   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+			Exponent:  base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+		})
+	}
+
+	var firstExpiry time.Time
+	for _, key := range keys {
+		if key.ExpiresAt != nil && (firstExpiry.IsZero() || key.ExpiresAt.Before(firstExpiry)) {
+			firstExpiry = *key.ExpiresAt
+		}
+	}
+	s.jwksCache.put("jwks", &model.JSONWebKeySet{Keys: append([]model.JSONWebKey(nil), set.Keys...)}, firstExpiry)
+
+	return set, nil
+}
+
+// RotateKey creates a new signing key. Previous keys stop signing now and
+// remain in the JWKS for the verification window.
+func (s *OIDCService) RotateKey(userID *uuid.UUID) (*model.OIDCKey, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key, _, err := s.rotateLocked()
+	if err != nil {
+		return nil, err
+	}
+
+	if s.auditService != nil && userID != nil {
+		s.auditService.LogAction(*userID, "oidc_key_rotated", "oidc_key", key.ID, true, "")
+	}
+
+	return key, nil
+}
+
+// signingKey returns the active signing key, rotating first when none
+// exists or the current one is older than the rotation period
+func (s *OIDCService) signingKey() (*model.OIDCKey, *rsa.PrivateKey, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var key model.OIDCKey
+	err := s.db.Where("active_until IS NULL").Order("created_at DESC").First(&key).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, fmt.Errorf("failed to get signing key: %w", err)
+	}
+
+	rotation := time.Duration(s.config.KeyRotationPeriod) * time.Hour
+	if errors.Is(err, gorm.ErrRecordNotFound) || (rotation > 0 && time.Since(key.CreatedAt) > rotation) {
+		return s.rotateLocked()
+	}
+
+	decrypted, err := s.secretService.decrypt(key.PrivateKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decrypt signing key: %w", err)
+	}
+	private, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(decrypted))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse signing key: %w", err)
+	}
+
+	return &key, private, nil
+}
+
+func (s *OIDCService) rotateLocked() (*model.OIDCKey, *rsa.PrivateKey, error) {
+	private, err := rsa.GenerateKey(keyReader, 2048)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+
+	privatePEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(private)})
+	publicDER, err := x509.MarshalPKIXPublicKey(&private.PublicKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode public key: %w", err)
+	}
+
+	encrypted, err := s.secretService.encrypt(string(privatePEM))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encrypt signing key: %w", err)
+	}
+
+	key := &model.OIDCKey{
+		ID:         uuid.NewString(),
+		Algorithm:  oidcSigningAlgorithm,
+		PrivateKey: encrypted,
+		PublicKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})),
+	}
+
+	now := time.Now()
+	retireAt := now.Add(s.verificationTTL())
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.OIDCKey{}).Where("active_until IS NULL").
+			Updates(map[string]interface{}{"active_until": now, "expires_at": retireAt}).Error; err != nil {
+			return fmt.Errorf("failed to retire signing keys: %w", err)
+		}
+		if err := tx.Create(key).Error; err != nil {
+			return fmt.Errorf("failed to store signing key: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return key, private, nil
+}
+
+func (s *OIDCService) verificationTTL() time.Duration {
+	return time.Duration(s.config.VerificationTTL) * time.Hour
+}
+
+func identityVariables(user *model.User) map[string]string {
+	return map[string]string{
+		"identity.id":         user.ID.String(),
+		"identity.email":      user.Email,
+		"identity.first_name": user.FirstName,
+		"identity.last_name":  user.LastName,
+		"identity.name":       strings.TrimSpace(user.FirstName + " " + user.LastName),
+	}
+}
+
+// renderClaimsTemplate parses a JSON object claims template and replaces
+// {{identity.*}} placeholders in its string values. An empty variables map
+// only validates the template.
+func renderClaimsTemplate(template string, variables map[string]string) (map[string]interface{}, error) {
+	var claims map[string]interface{}
+	if err := json.Unmarshal([]byte(template), &claims); err != nil {
+		return nil, fmt.Errorf("%w: template must be a JSON object", ErrOIDCInvalidRole)
+	}
+
+	known := identityVariables(&model.User{})
+	var renderErr error
+
+	var render func(value interface{}) interface{}
+	render = func(value interface{}) interface{} {
+		switch v := value.(type) {
+		case string:
+			return oidcTemplateVariable.ReplaceAllStringFunc(v, func(match string) string {
+				name := oidcTemplateVariable.FindStringSubmatch(match)[1]
+				if _, ok := known[name]; !ok {
+					renderErr = fmt.Errorf("%w: unknown template variable %s", ErrOIDCInvalidRole, name)
+					return match
+				}
+				return variables[name]
+			})
+		case map[string]interface{}:
+			for key, nested := range v {
+				v[key] = render(nested)
+			}
+			return v
+		case []interface{}:
+			for i, nested := range v {
+				v[i] = render(nested)
+			}
+			return v
+		default:
+			return v
+		}
+	}
+
+	for name, value := range claims {
+		if oidcReservedClaims[name] {
+			return nil, fmt.Errorf("%w: claim %s is reserved", ErrOIDCInvalidRole, name)
+		}
+		claims[name] = render(value)
+	}
+
+	if renderErr != nil {
+		return nil, renderErr
+	}
+
+	return claims, nil
+}
+
+var (
+	ErrOIDCRoleNotFound = errors.New("oidc role not found")
+	ErrOIDCInvalidRole  = errors.New("invalid oidc role")
+)
@@ -0,0 +1,766 @@
+package services
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	oidcLoginStateTTL = 10 * time.Minute
+	oidcMetadataTTL   = time.Hour
+)
+
+var (
+	// oidcLoginClaims are the user fields claim mappings may name, and the
+	// standard claims they default to
+	oidcLoginClaims = map[string]string{
+		"email":      "email",
+		"first_name": "given_name",
+		"last_name":  "family_name",
+		"groups":     "groups",
+	}
+	oidcProviderName  = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+	oidcSigningMethod = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+)
+
+// OIDCAuthService signs users in with external OIDC providers through the
+// authorization code flow with PKCE. Provider groups map to policies,
+// which are attached on each login and detached once the user leaves the
+// group. OIDCService is the other direction: the vault as a provider.
+type OIDCAuthService struct {
+	db            *gorm.DB
+	secretService *SecretService
+	userService   *UserService
+	authService   *AuthService
+	auditService  *AuditService
+	client        *http.Client
+	metadata      *ttlCache
+}
+
+func NewOIDCAuthService(db *gorm.DB, secretService *SecretService, userService *UserService, authService *AuthService, auditService *AuditService) *OIDCAuthService {
+	return &OIDCAuthService{
+		db:            db,
+		secretService: secretService,
+		userService:   userService,
+		authService:   authService,
+		auditService:  auditService,
+		client:        &http.Client{Timeout: 10 * time.Second},
+		metadata:      newTTLCache(oidcMetadataTTL),
+	}
+}
+
+// oidcProviderMetadata is the part of a discovery document login uses
+type oidcProviderMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type oidcJSONWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+	Curve   string `json:"crv"`
+	X       string `json:"x"`
+	Y       string `json:"y"`
+}
+
+type oidcTokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// oidcProfile is what login reads from the ID token
+type oidcProfile struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	FirstName     string
+	LastName      string
+	Groups        []string
+}
+
+func (s *OIDCAuthService) CreateProvider(req *model.CreateOIDCProviderRequest, userID uuid.UUID) (*model.OIDCProvider, error) {
+	if !oidcProviderName.MatchString(req.Name) {
+		return nil, fmt.Errorf("%w: names are lowercase letters, digits, '-' and '_'", ErrOIDCProviderInvalid)
+	}
+	if err := s.checkMappings(req.ClaimMappings, req.GroupPolicies); err != nil {
+		return nil, err
+	}
+	// The issuer must answer discovery before users are sent to it
+	if _, err := s.providerMetadata(req.Issuer); err != nil {
+		return nil, err
+	}
+
+	provider := &model.OIDCProvider{
+		Name:            req.Name,
+		Issuer:          req.Issuer,
+		ClientID:        req.ClientID,
+		Scopes:          req.Scopes,
+		RedirectURIs:    req.RedirectURIs,
+		ClaimMappings:   req.ClaimMappings,
+		GroupPolicies:   req.GroupPolicies,
+		AutoCreateUsers: req.AutoCreateUsers,
+		IsActive:        true,
+		CreatedBy:       userID,
+	}
+	if err := s.setClientSecret(provider, req.ClientSecret); err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := s.db.Model(&model.OIDCProvider{}).Where("name = ?", req.Name).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("failed to check provider name: %w", err)
+	}
+	if count > 0 {
+		return nil, ErrOIDCProviderExists
+	}
+	if err := s.db.Create(provider).Error; err != nil {
+		return nil, fmt.Errorf("failed to create provider: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "oidc_provider_created", "oidc_provider", provider.ID.String(), true, "name="+provider.Name+"; issuer="+provider.Issuer)
+	}
+	return provider, nil
+}
+
+func (s *OIDCAuthService) GetProviders() ([]model.OIDCProvider, error) {
+	var providers []model.OIDCProvider
+	if err := s.db.Order("name").Find(&providers).Error; err != nil {
+		return nil, fmt.Errorf("failed to get providers: %w", err)
+	}
+	return providers, nil
+}
+
+func (s *OIDCAuthService) UpdateProvider(name string, req *model.UpdateOIDCProviderRequest, userID uuid.UUID) (*model.OIDCProvider, error) {
+	provider, err := s.provider(name, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ClientID != nil {
+		provider.ClientID = *req.ClientID
+	}
+	if req.ClientSecret != nil {
+		if err := s.setClientSecret(provider, *req.ClientSecret); err != nil {
+			return nil, err
+		}
+	}
+	if req.Scopes != nil {
+		provider.Scopes = *req.Scopes
+	}
+	if req.RedirectURIs != nil {
+		provider.RedirectURIs = *req.RedirectURIs
+	}
+	if req.ClaimMappings != nil {
+		provider.ClaimMappings = *req.ClaimMappings
+	}
+	if req.GroupPolicies != nil {
+		provider.GroupPolicies = *req.GroupPolicies
+	}
+	if req.AutoCreateUsers != nil {
+		provider.AutoCreateUsers = *req.AutoCreateUsers
+	}
+	if req.IsActive != nil {
+		provider.IsActive = *req.IsActive
+	}
+	if err := s.checkMappings(provider.ClaimMappings, provider.GroupPolicies); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Save(provider).Error; err != nil {
+		return nil, fmt.Errorf("failed to update provider: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "oidc_provider_updated", "oidc_provider", provider.ID.String(), true, "name="+provider.Name)
+	}
+	return provider, nil
+}
+
+// DeleteProvider removes a provider along with the identities linked
+// through it and the policies its logins attached
+func (s *OIDCAuthService) DeleteProvider(name string, userID uuid.UUID) error {
+	provider, err := s.provider(name, false)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("method = ? AND provider = ?", model.AuthMethodOIDC, provider.Name).Delete(&model.AuthIdentity{}).Error; err != nil {
+			return fmt.Errorf("failed to remove identities: %w", err)
+		}
+		if err := tx.Where("source = ?", oidcPolicySource(provider)).Delete(&model.PolicyAssignment{}).Error; err != nil {
+			return fmt.Errorf("failed to detach policies: %w", err)
+		}
+		if err := tx.Where("provider_id = ?", provider.ID).Delete(&model.OIDCLoginState{}).Error; err != nil {
+			return fmt.Errorf("failed to remove login states: %w", err)
+		}
+		if err := tx.Delete(provider).Error; err != nil {
+			return fmt.Errorf("failed to delete provider: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "oidc_provider_deleted", "oidc_provider", provider.ID.String(), true, "name="+provider.Name)
+	}
+	return nil
+}
+
+// AuthURL starts a login: it returns the provider URL to send the user
+// to, which returns them to redirectURI with a code and the state
+func (s *OIDCAuthService) AuthURL(name, redirectURI string) (*model.OIDCAuthURLResponse, error) {
+	provider, err := s.provider(name, true)
+	if err != nil {
+		return nil, err
+	}
+	allowed := false
+	for _, uri := range provider.RedirectURIs {
+		allowed = allowed || uri == redirectURI
+	}
+	if !allowed {
+		return nil, ErrOIDCRedirectURIInvalid
+	}
+	metadata, err := s.providerMetadata(provider.Issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := oidcRandom()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := oidcRandom()
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := oidcRandom()
+	if err != nil {
+		return nil, err
+	}
+
+	loginState := &model.OIDCLoginState{
+		State:        state,
+		ProviderID:   provider.ID,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		RedirectURI:  redirectURI,
+		ExpiresAt:    time.Now().Add(oidcLoginStateTTL),
+	}
+	if err := s.db.Where("expires_at < ?", time.Now()).Delete(&model.OIDCLoginState{}).Error; err != nil {
+		return nil, fmt.Errorf("failed to remove expired login states: %w", err)
+	}
+	if err := s.db.Create(loginState).Error; err != nil {
+		return nil, fmt.Errorf("failed to store login state: %w", err)
+	}
+
+	authURL, err := url.Parse(metadata.AuthorizationEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid authorization endpoint", ErrOIDCProviderUnavailable)
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", provider.ClientID)
+	query.Set("redirect_uri", redirectURI)
+	query.Set("scope", strings.Join(append([]string{"openid"}, provider.Scopes...), " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+	authURL.RawQuery = query.Encode()
+
+	return &model.OIDCAuthURLResponse{AuthURL: authURL.String(), State: state, ExpiresAt: loginState.ExpiresAt}, nil
+}
+
+// Callback completes a login started by AuthURL: it redeems the code,
+// verifies the ID token, resolves the vault user, refreshes their group
+// policies and issues a vault token
+func (s *OIDCAuthService) Callback(name, state, code string) (*model.LoginResponse, error) {
+	provider, err := s.provider(name, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// Deleting the state claims it, so each state logs in once
+	var loginState model.OIDCLoginState
+	result := s.db.Clauses(clause.Returning{}).Where("state = ? AND provider_id = ?", state, provider.ID).Delete(&loginState)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to claim login state: %w", result.Error)
+	}
+	if result.RowsAffected == 0 || time.Now().After(loginState.ExpiresAt) {
+		return nil, ErrOIDCStateInvalid
+	}
+
+	metadata, err := s.providerMetadata(provider.Issuer)
+	if err != nil {
+		return nil, err
+	}
+	rawIDToken, err := s.redeemCode(provider, metadata, code, &loginState)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := s.verifyIDToken(provider, metadata, rawIDToken, loginState.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.resolveUser(provider, profile)
+	if err != nil {
+		return nil, err
+	}
+	if err := syncSourcePolicies(s.db, user.ID, oidcPolicySource(provider), groupPolicies(provider.GroupPolicies, profile.Groups)); err != nil {
+		return nil, err
+	}
+
+	token, expiresAt, err := s.authService.generateToken(user.ID, model.AuthMethodOIDC)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	return &model.LoginResponse{Token: token, ExpiresAt: expiresAt, User: *user}, nil
+}
+
+func (s *OIDCAuthService) redeemCode(provider *model.OIDCProvider, metadata *oidcProviderMetadata, code string, loginState *model.OIDCLoginState) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", loginState.RedirectURI)
+	form.Set("code_verifier", loginState.CodeVerifier)
+	// Confidential clients authenticate with HTTP basic auth, public ones
+	// name themselves in the form
+	if provider.ClientSecret == "" {
+		form.Set("client_id", provider.ClientID)
+	}
+
+	request, err := http.NewRequest(http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid token endpoint", ErrOIDCProviderUnavailable)
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	if provider.ClientSecret != "" {
+		secret, err := s.secretService.decrypt(provider.ClientSecret)
+		if err != nil {
+			return "", fmt.Errorf("failed to decrypt client secret: %w", err)
+		}
+		request.SetBasicAuth(url.QueryEscape(provider.ClientID), url.QueryEscape(secret))
+	}
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrOIDCProviderUnavailable, err)
+	}
+	defer response.Body.Close()
+
+	var tokens oidcTokenResponse
+	if err := json.NewDecoder(io.LimitReader(response.Body, 1<<20)).Decode(&tokens); err != nil {
+		return "", fmt.Errorf("%w: unreadable token response (status %d)", ErrOIDCProviderUnavailable, response.StatusCode)
+	}
+	if tokens.Error != "" {
+		return "", fmt.Errorf("%w: %s %s", ErrOIDCLoginFailed, tokens.Error, tokens.ErrorDescription)
+	}
+	if response.StatusCode != http.StatusOK || tokens.IDToken == "" {
+		return "", fmt.Errorf("%w: no ID token in the token response (status %d)", ErrOIDCLoginFailed, response.StatusCode)
+	}
+	return tokens.IDToken, nil
+}
+
+func (s *OIDCAuthService) verifyIDToken(provider *model.OIDCProvider, metadata *oidcProviderMetadata, raw, nonce string) (*oidcProfile, error) {
+	token, err := jwt.Parse(raw, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return s.verificationKey(metadata.JWKSURI, kid)
+	}, jwt.WithValidMethods(oidcSigningMethod), jwt.WithIssuer(provider.Issuer), jwt.WithAudience(provider.ClientID), jwt.WithExpirationRequired(), jwt.WithLeeway(time.Minute))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCLoginFailed, err)
+	}
+	claims := token.Claims.(jwt.MapClaims)
+
+	if claimNonce, _ := claims["nonce"].(string); claimNonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrOIDCLoginFailed)
+	}
+	// A token for several audiences must have been issued to this client
+	if audience, _ := claims.GetAudience(); len(audience) > 1 {
+		if azp, _ := claims["azp"].(string); azp != provider.ClientID {
+			return nil, fmt.Errorf("%w: token issued to another client", ErrOIDCLoginFailed)
+		}
+	}
+
+	claim := func(field string) string {
+		if name, ok := provider.ClaimMappings[field]; ok && name != "" {
+			return name
+		}
+		return oidcLoginClaims[field]
+	}
+	profile := &oidcProfile{
+		Email:     claimString(claims[claim("email")]),
+		FirstName: claimString(claims[claim("first_name")]),
+		LastName:  claimString(claims[claim("last_name")]),
+		Groups:    claimStrings(claims[claim("groups")]),
+	}
+	profile.Subject, _ = claims.GetSubject()
+	if profile.Subject == "" {
+		return nil, fmt.Errorf("%w: the ID token has no subject", ErrOIDCLoginFailed)
+	}
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		profile.EmailVerified = verified
+	case string:
+		profile.EmailVerified = verified == "true"
+	}
+	return profile, nil
+}
+
+// resolveUser returns the user linked to the provider subject. The first
+// login links the user with the same email when the provider has
+// verified it, or creates one when the provider allows.
+func (s *OIDCAuthService) resolveUser(provider *model.OIDCProvider, profile *oidcProfile) (*model.User, error) {
+	var identity model.AuthIdentity
+	err := s.db.Where("method = ? AND provider = ? AND subject = ?", model.AuthMethodOIDC, provider.Name, profile.Subject).First(&identity).Error
+	if err == nil {
+		user, err := s.userService.GetUserByID(identity.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: the linked user is disabled", ErrOIDCUserNotLinked)
+		}
+		if err := s.db.Model(&identity).Update("last_login_at", time.Now()).Error; err != nil {
+			return nil, fmt.Errorf("failed to record login: %w", err)
+		}
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get identity: %w", err)
+	}
+
+	if profile.Email == "" {
+		return nil, fmt.Errorf("%w: the ID token has no email", ErrOIDCUserNotLinked)
+	}
+	user, err := s.userService.GetUserByEmail(profile.Email)
+	switch {
+	case err == nil:
+		if !profile.EmailVerified {
+			return nil, fmt.Errorf("%w: the provider has not verified %s", ErrOIDCUserNotLinked, profile.Email)
+		}
+		if IsCentralAdmin(user) {
+			return nil, fmt.Errorf("%w: the central admin signs in with a password", ErrOIDCUserNotLinked)
+		}
+	case errors.Is(err, ErrUserNotFound):
+		if !provider.AutoCreateUsers {
+			return nil, fmt.Errorf("%w: no user has the email %s", ErrOIDCUserNotLinked, profile.Email)
+		}
+		user = nil
+	default:
+		return nil, err
+	}
+
+	now := time.Now()
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if user == nil {
+			// The user has no password of their own; they sign in through
+			// the provider
+			password, err := oidcRandom()
+			if err != nil {
+				return err
+			}
+			user = &model.User{Email: profile.Email, Password: password, FirstName: profile.FirstName, LastName: profile.LastName, IsActive: true}
+			if err := NewUserService(tx).CreateUser(user); err != nil {
+				return err
+			}
+		}
+		return tx.Create(&model.AuthIdentity{
+			Method:      model.AuthMethodOIDC,
+			Provider:    provider.Name,
+			Subject:     profile.Subject,
+			UserID:      user.ID,
+			LastLoginAt: &now,
+		}).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to link identity: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(user.ID, "identity_linked", "user", user.ID.String(), true, "method=oidc; provider="+provider.Name+"; subject="+profile.Subject)
+	}
+	return user, nil
+}
+
+// providerMetadata fetches, and caches, the issuer's discovery document
+func (s *OIDCAuthService) providerMetadata(issuer string) (*oidcProviderMetadata, error) {
+	if cached, ok := s.metadata.get("issuer:" + issuer); ok {
+		return cached.(*oidcProviderMetadata), nil
+	}
+
+	var metadata oidcProviderMetadata
+	if err := s.getJSON(strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", &metadata); err != nil {
+		return nil, err
+	}
+	if metadata.Issuer != issuer {
+		return nil, fmt.Errorf("%w: discovery names issuer %q", ErrOIDCProviderUnavailable, metadata.Issuer)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: incomplete discovery document", ErrOIDCProviderUnavailable)
+	}
+
+	s.metadata.put("issuer:"+issuer, &metadata, time.Time{})
+	return &metadata, nil
+}
+
+// verificationKey returns the provider key with the given ID. An unknown
+// ID refetches the key set once, since providers publish new keys ahead
+// of rotating to them.
+func (s *OIDCAuthService) verificationKey(jwksURI, kid string) (interface{}, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		keys, cached := s.metadata.get("jwks:" + jwksURI)
+		if !cached || attempt > 0 {
+			var set struct {
+				Keys []oidcJSONWebKey `json:"keys"`
+			}
+			if err := s.getJSON(jwksURI, &set); err != nil {
+				return nil, err
+			}
+			keys = set.Keys
+			s.metadata.put("jwks:"+jwksURI, keys, time.Time{})
+		}
+		for _, key := range keys.([]oidcJSONWebKey) {
+			if key.Use != "" && key.Use != "sig" {
+				continue
+			}
+			if key.KeyID == kid || kid == "" {
+				return parseJSONWebKey(key)
+			}
+		}
+		if !cached {
+			break
+		}
+	}
+	return nil, fmt.Errorf("no signing key %q", kid)
+}
+
+func (s *OIDCAuthService) getJSON(target string, out interface{}) error {
+	response, err := s.client.Get(target)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrOIDCProviderUnavailable, err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s returned %d", ErrOIDCProviderUnavailable, target, response.StatusCode)
+	}
+	if err := json.NewDecoder(io.LimitReader(response.Body, 1<<20)).Decode(out); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrOIDCProviderUnavailable, target, err)
+	}
+	return nil
+}
+
+func (s *OIDCAuthService) provider(name string, active bool) (*model.OIDCProvider, error) {
+	var provider model.OIDCProvider
+	query := s.db.Where("name = ?", name)
+	if active {
+		query = query.Where("is_active = ?", true)
+	}
+	if err := query.First(&provider).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOIDCProviderNotFound
+		}
+		return nil, fmt.Errorf("failed to get provider: %w", err)
+	}
+	return &provider, nil
+}
+
+func (s *OIDCAuthService) setClientSecret(provider *model.OIDCProvider, secret string) error {
+	if secret == "" {
+		provider.ClientSecret = ""
+		return nil
+	}
+	encrypted, err := s.secretService.encrypt(secret)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt client secret: %w", err)
+	}
+	provider.ClientSecret = encrypted
+	return nil
+}
+
+func (s *OIDCAuthService) checkMappings(mappings map[string]string, groups map[string][]uuid.UUID) error {
+	for field := range mappings {
+		if _, ok := oidcLoginClaims[field]; !ok {
+			return fmt.Errorf("%w: unknown claim mapping %q; use email, first_name, last_name or groups", ErrOIDCProviderInvalid, field)
+		}
+	}
+
+	var policyIDs []uuid.UUID
+	for _, ids := range groups {
+		policyIDs = append(policyIDs, ids...)
+	}
+	policyIDs = uniqueUserIDs(policyIDs)
+	if len(policyIDs) == 0 {
+		return nil
+	}
+	var found int64
+	if err := s.db.Model(&model.Policy{}).Where("id IN ? AND is_active = ?", policyIDs, true).Count(&found).Error; err != nil {
+		return fmt.Errorf("failed to verify policies: %w", err)
+	}
+	if found != int64(len(policyIDs)) {
+		return fmt.Errorf("%w: group_policies names a policy that does not exist", ErrOIDCProviderInvalid)
+	}
+	return nil
+}
+
+// syncSourcePolicies makes the policies assigned to userID by source
+// exactly policyIDs, leaving assignments from elsewhere alone
+func syncSourcePolicies(db *gorm.DB, userID uuid.UUID, source string, policyIDs []uuid.UUID) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		stale := tx.Where("user_id = ? AND source = ?", userID, source)
+		if len(policyIDs) > 0 {
+			stale = stale.Where("policy_id NOT IN ?", policyIDs)
+		}
+		if err := stale.Delete(&model.PolicyAssignment{}).Error; err != nil {
+			return fmt.Errorf("failed to detach policies: %w", err)
+		}
+		if len(policyIDs) == 0 {
+			return nil
+		}
+
+		var active []uuid.UUID
+		if err := tx.Model(&model.Policy{}).Where("id IN ? AND is_active = ?", policyIDs, true).Pluck("id", &active).Error; err != nil {
+			return fmt.Errorf("failed to get policies: %w", err)
+		}
+		if len(active) == 0 {
+			return nil
+		}
+		assignments := make([]model.PolicyAssignment, 0, len(active))
+		for _, id := range active {
+			assignments = append(assignments, model.PolicyAssignment{PolicyID: id, UserID: userID, AssignedBy: userID, Source: source})
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&assignments).Error; err != nil {
+			return fmt.Errorf("failed to attach policies: %w", err)
+		}
+		return nil
+	})
+}
+
+// groupPolicies returns the policies mapped to any of groups
+func groupPolicies(mapping map[string][]uuid.UUID, groups []string) []uuid.UUID {
+	var policyIDs []uuid.UUID
+	for _, group := range groups {
+		policyIDs = append(policyIDs, mapping[group]...)
+	}
+	return uniqueUserIDs(policyIDs)
+}
+
+func oidcPolicySource(provider *model.OIDCProvider) string {
+	return model.AuthMethodOIDC + ":" + provider.Name
+}
+
+func parseJSONWebKey(key oidcJSONWebKey) (interface{}, error) {
+	decode := func(value string) (*big.Int, error) {
+		raw, err := base64.RawURLEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q: %w", key.KeyID, err)
+		}
+		return new(big.Int).SetBytes(raw), nil
+	}
+
+	switch key.KeyType {
+	case "RSA":
+		n, err := decode(key.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(key.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch key.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", key.Curve)
+		}
+		x, err := decode(key.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(key.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", key.KeyType)
+	}
+}
+
+func claimString(value interface{}) string {
+	s, _ := value.(string)
+	return s
+}
+
+// claimStrings reads a claim holding a list of strings, or one string
+func claimStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+func oidcRandom() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random value: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+var (
+	ErrOIDCProviderNotFound    = errors.New("OIDC provider not found")
+	ErrOIDCProviderExists      = errors.New("an OIDC provider with this name already exists")
+	ErrOIDCProviderInvalid     = errors.New("invalid OIDC provider")
+	ErrOIDCProviderUnavailable = errors.New("OIDC provider unavailable")
+	ErrOIDCRedirectURIInvalid  = errors.New("redirect_uri is not registered for this provider")
+	ErrOIDCStateInvalid        = errors.New("login state is unknown, used or expired")
+	ErrOIDCLoginFailed         = errors.New("OIDC login failed")
+	ErrOIDCUserNotLinked       = errors.New("no vault user for this identity")
+)
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// oidcTestProvider serves discovery, a key set and a token endpoint that
+// answers every code with an ID token carrying claims
+func oidcTestProvider(t *testing.T, key *rsa.PrivateKey, claims func(issuer string) jwt.MapClaims) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 server.URL,
+			"authorization_endpoint": server.URL + "/authorize",
+			"token_endpoint":         server.URL + "/token",
+			"jwks_uri":               server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims(server.URL))
+		token.Header["kid"] = "test"
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Errorf("failed to sign ID token: %v", err)
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": signed})
+	})
+	return server
+}
+
+func TestOIDCCallbackChecksStateAndNonce(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+		noState   bool
+		nonce     interface{}
+		wantErr   error
+	}{
+		{name: "unknown state", noState: true, nonce: "nonce-1", wantErr: ErrOIDCStateInvalid},
+		{name: "expired state", expiresAt: time.Now().Add(-time.Second), nonce: "nonce-1", wantErr: ErrOIDCStateInvalid},
+		{name: "nonce of another login", nonce: "nonce-2", wantErr: ErrOIDCLoginFailed},
+		{name: "no nonce", wantErr: ErrOIDCLoginFailed},
+		{name: "nonce not a string", nonce: 1, wantErr: ErrOIDCLoginFailed},
+		// Past both checks the login fails for want of an email
+		{name: "matching state and nonce", nonce: "nonce-1", wantErr: ErrOIDCUserNotLinked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := oidcTestProvider(t, key, func(issuer string) jwt.MapClaims {
+				claims := jwt.MapClaims{"iss": issuer, "aud": "vault", "sub": "user-1", "exp": time.Now().Add(time.Minute).Unix()}
+				if tt.nonce != nil {
+					claims["nonce"] = tt.nonce
+				}
+				return claims
+			})
+
+			providerID := uuid.New()
+			tables := stubTables{
+				// gorm splits the OIDC initialism when naming these tables
+				"o_id_c_providers": {{
+					"id":        providerID.String(),
+					"name":      "corp",
+					"issuer":    server.URL,
+					"client_id": "vault",
+					"is_active": true,
+				}},
+			}
+			if !tt.noState {
+				expiresAt := tt.expiresAt
+				if expiresAt.IsZero() {
+					expiresAt = time.Now().Add(oidcLoginStateTTL)
+				}
+				tables["o_id_c_login_states"] = []map[string]driver.Value{{
+					"state":         "state-1",
+					"provider_id":   providerID.String(),
+					"nonce":         "nonce-1",
+					"code_verifier": "verifier",
+					"redirect_uri":  "https://vault.example.com/callback",
+					"expires_at":    expiresAt,
+				}}
+			}
+			db := newStubDB(t, tables)
+			service := NewOIDCAuthService(db, nil, NewUserService(db), nil, nil)
+
+			_, err := service.Callback("corp", "state-1", "code")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,676 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// orgRoleRank orders roles; a role includes every role ranked below it
+var orgRoleRank = map[model.OrgRole]int{
+	model.OrgRoleReader: 1,
+	model.OrgRoleWriter: 2,
+	model.OrgRoleAdmin:  3,
+	model.OrgRoleOwner:  4,
+}
+
+var orgNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// OrgService manages the organization → team → project hierarchy and the
+// roles users hold in it. Roles apply downwards: an admin of a team is an
+// admin of each of its projects. Secrets in a project are readable by its
+// readers and writable by its writers, in addition to what policies and
+// namespace roles grant. Central admins act as owners everywhere.
+type OrgService struct {
+	db           *gorm.DB
+	auditService *AuditService
+}
+
+func NewOrgService(db *gorm.DB, auditService *AuditService) *OrgService {
+	return &OrgService{
+		db:           db,
+		auditService: auditService,
+	}
+}
+
+// UseOrganizations lets project roles grant access to the secrets of
+// their projects
+func (s *SecretService) UseOrganizations(orgs *OrgService) {
+	s.orgs = orgs
+}
+
+// checkProject lets userID place a secret in the project when they hold
+// the writer role on it
+func (s *SecretService) checkProject(userID, projectID uuid.UUID) error {
+	if s.orgs == nil {
+		return ErrProjectNotFound
+	}
+	if _, err := s.orgs.authorize(userID, model.OrgScopeProject, projectID, model.OrgRoleWriter); err != nil {
+		if errors.Is(err, ErrOrgRoleRequired) {
+			return ErrSecretProjectDenied
+		}
+		return err
+	}
+	return nil
+}
+
+// orgRef is one entity of the hierarchy
+type orgRef struct {
+	scope model.OrgScope
+	id    uuid.UUID
+}
+
+func (s *OrgService) CreateOrganization(req *model.CreateOrgEntityRequest, userID uuid.UUID) (*model.Organization, error) {
+	name, err := orgName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	organization := &model.Organization{
+		Name:        name,
+		Description: req.Description,
+		CreatedBy:   userID,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.requireFreeName(tx.Model(&model.Organization{}), name); err != nil {
+			return err
+		}
+		if err := tx.Create(organization).Error; err != nil {
+			return fmt.Errorf("failed to create organization: %w", err)
+		}
+		// The creator owns the organization
+		return tx.Create(&model.OrgMembership{
+			Scope:     model.OrgScopeOrganization,
+			ScopeID:   organization.ID,
+			UserID:    userID,
+			Role:      model.OrgRoleOwner,
+			GrantedBy: userID,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit(userID, "organization_created", model.OrgScopeOrganization, organization.ID, name)
+	return organization, nil
+}
+
+// GetOrganizations lists the organizations the user holds a role on;
+// central admins see them all
+func (s *OrgService) GetOrganizations(userID uuid.UUID) ([]model.Organization, error) {
+	query := s.db.Order("name")
+	if !s.isCentralAdmin(userID) {
+		query = query.Where("id IN (?)", s.db.Model(&model.OrgMembership{}).
+			Select("scope_id").Where("user_id = ? AND scope = ?", userID, model.OrgScopeOrganization))
+	}
+
+	var organizations []model.Organization
+	if err := query.Find(&organizations).Error; err != nil {
+		return nil, fmt.Errorf("failed to get organizations: %w", err)
+	}
+	return organizations, nil
+}
+
+// GetMemberships lists the roles the user holds, at every level
+func (s *OrgService) GetMemberships(userID uuid.UUID) ([]model.OrgMembership, error) {
+	var memberships []model.OrgMembership
+	if err := s.db.Where("user_id = ?", userID).Order("scope, created_at").Find(&memberships).Error; err != nil {
+		return nil, fmt.Errorf("failed to get memberships: %w", err)
+	}
+	return memberships, nil
+}
+
+func (s *OrgService) GetOrganization(id uuid.UUID, userID uuid.UUID) (*model.Organization, error) {
+	if _, err := s.authorize(userID, model.OrgScopeOrganization, id, model.OrgRoleReader); err != nil {
+		return nil, err
+	}
+	var organization model.Organization
+	if err := s.first(&organization, id, ErrOrganizationNotFound); err != nil {
+		return nil, err
+	}
+	return &organization, nil
+}
+
+func (s *OrgService) UpdateOrganization(id uuid.UUID, req *model.UpdateOrgEntityRequest, userID uuid.UUID) (*model.Organization, error) {
+	if _, err := s.authorize(userID, model.OrgScopeOrganization, id, model.OrgRoleAdmin); err != nil {
+		return nil, err
+	}
+	var organization model.Organization
+	if err := s.first(&organization, id, ErrOrganizationNotFound); err != nil {
+		return nil, err
+	}
+	if err := s.update(&organization, s.db.Model(&model.Organization{}), id, &organization.Name, &organization.Description, req); err != nil {
+		return nil, err
+	}
+
+	s.audit(userID, "organization_updated", model.OrgScopeOrganization, id, organization.Name)
+	return &organization, nil
+}
+
+// DeleteOrganization deletes an organization without teams, with its
+// memberships
+func (s *OrgService) DeleteOrganization(id uuid.UUID, userID uuid.UUID) error {
+	if _, err := s.authorize(userID, model.OrgScopeOrganization, id, model.OrgRoleOwner); err != nil {
+		return err
+	}
+	if err := s.requireEmpty(s.db.Model(&model.Team{}).Where("organization_id = ?", id), "teams"); err != nil {
+		return err
+	}
+	if err := s.delete(&model.Organization{}, model.OrgScopeOrganization, id); err != nil {
+		return err
+	}
+
+	s.audit(userID, "organization_deleted", model.OrgScopeOrganization, id, "")
+	return nil
+}
+
+func (s *OrgService) CreateTeam(organizationID uuid.UUID, req *model.CreateOrgEntityRequest, userID uuid.UUID) (*model.Team, error) {
+	if _, err := s.authorize(userID, model.OrgScopeOrganization, organizationID, model.OrgRoleAdmin); err != nil {
+		return nil, err
+	}
+	name, err := orgName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireFreeName(s.db.Model(&model.Team{}).Where("organization_id = ?", organizationID), name); err != nil {
+		return nil, err
+	}
+
+	team := &model.Team{
+		OrganizationID: organizationID,
+		Name:           name,
+		Description:    req.Description,
+		CreatedBy:      userID,
+	}
+	if err := s.db.Create(team).Error; err != nil {
+		return nil, fmt.Errorf("failed to create team: %w", err)
+	}
+
+	s.audit(userID, "team_created", model.OrgScopeTeam, team.ID, "organization="+organizationID.String()+"; name="+name)
+	return team, nil
+}
+
+func (s *OrgService) GetTeams(organizationID uuid.UUID, userID uuid.UUID) ([]model.Team, error) {
+	if _, err := s.authorize(userID, model.OrgScopeOrganization, organizationID, model.OrgRoleReader); err != nil {
+		return nil, err
+	}
+	var teams []model.Team
+	if err := s.db.Where("organization_id = ?", organizationID).Order("name").Find(&teams).Error; err != nil {
+		return nil, fmt.Errorf("failed to get teams: %w", err)
+	}
+	return teams, nil
+}
+
+func (s *OrgService) GetTeam(id uuid.UUID, userID uuid.UUID) (*model.Team, error) {
+	if _, err := s.authorize(userID, model.OrgScopeTeam, id, model.OrgRoleReader); err != nil {
+		return nil, err
+	}
+	var team model.Team
+	if err := s.first(&team, id, ErrTeamNotFound); err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+func (s *OrgService) UpdateTeam(id uuid.UUID, req *model.UpdateOrgEntityRequest, userID uuid.UUID) (*model.Team, error) {
+	if _, err := s.authorize(userID, model.OrgScopeTeam, id, model.OrgRoleAdmin); err != nil {
+		return nil, err
+	}
+	var team model.Team
+	if err := s.first(&team, id, ErrTeamNotFound); err != nil {
+		return nil, err
+	}
+	siblings := s.db.Model(&model.Team{}).Where("organization_id = ?", team.OrganizationID)
+	if err := s.update(&team, siblings, id, &team.Name, &team.Description, req); err != nil {
+		return nil, err
+	}
+
+	s.audit(userID, "team_updated", model.OrgScopeTeam, id, team.Name)
+	return &team, nil
+}
+
+// DeleteTeam deletes a team without projects, with its memberships
+func (s *OrgService) DeleteTeam(id uuid.UUID, userID uuid.UUID) error {
+	if _, err := s.authorize(userID, model.OrgScopeTeam, id, model.OrgRoleOwner); err != nil {
+		return err
+	}
+	if err := s.requireEmpty(s.db.Model(&model.Project{}).Where("team_id = ?", id), "projects"); err != nil {
+		return err
+	}
+	if err := s.delete(&model.Team{}, model.OrgScopeTeam, id); err != nil {
+		return err
+	}
+
+	s.audit(userID, "team_deleted", model.OrgScopeTeam, id, "")
+	return nil
+}
+
+func (s *OrgService) CreateProject(teamID uuid.UUID, req *model.CreateOrgEntityRequest, userID uuid.UUID) (*model.Project, error) {
+	if _, err := s.authorize(userID, model.OrgScopeTeam, teamID, model.OrgRoleAdmin); err != nil {
+		return nil, err
+	}
+	name, err := orgName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireFreeName(s.db.Model(&model.Project{}).Where("team_id = ?", teamID), name); err != nil {
+		return nil, err
+	}
+
+	project := &model.Project{
+		TeamID:      teamID,
+		Name:        name,
+		Description: req.Description,
+		CreatedBy:   userID,
+	}
+	if err := s.db.Create(project).Error; err != nil {
+		return nil, fmt.Errorf("failed to create project: %w", err)
+	}
+
+	s.audit(userID, "project_created", model.OrgScopeProject, project.ID, "team="+teamID.String()+"; name="+name)
+	return project, nil
+}
+
+func (s *OrgService) GetProjects(teamID uuid.UUID, userID uuid.UUID) ([]model.Project, error) {
+	if _, err := s.authorize(userID, model.OrgScopeTeam, teamID, model.OrgRoleReader); err != nil {
+		return nil, err
+	}
+	var projects []model.Project
+	if err := s.db.Where("team_id = ?", teamID).Order("name").Find(&projects).Error; err != nil {
+		return nil, fmt.Errorf("failed to get projects: %w", err)
+	}
+	return projects, nil
+}
+
+func (s *OrgService) GetProject(id uuid.UUID, userID uuid.UUID) (*model.Project, error) {
+	if _, err := s.authorize(userID, model.OrgScopeProject, id, model.OrgRoleReader); err != nil {
+		return nil, err
+	}
+	var project model.Project
+	if err := s.first(&project, id, ErrProjectNotFound); err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+func (s *OrgService) UpdateProject(id uuid.UUID, req *model.UpdateOrgEntityRequest, userID uuid.UUID) (*model.Project, error) {
+	if _, err := s.authorize(userID, model.OrgScopeProject, id, model.OrgRoleAdmin); err != nil {
+		return nil, err
+	}
+	var project model.Project
+	if err := s.first(&project, id, ErrProjectNotFound); err != nil {
+		return nil, err
+	}
+	siblings := s.db.Model(&model.Project{}).Where("team_id = ?", project.TeamID)
+	if err := s.update(&project, siblings, id, &project.Name, &project.Description, req); err != nil {
+		return nil, err
+	}
+
+	s.audit(userID, "project_updated", model.OrgScopeProject, id, project.Name)
+	return &project, nil
+}
+
+// DeleteProject deletes a project holding no secrets, with its
+// memberships
+func (s *OrgService) DeleteProject(id uuid.UUID, userID uuid.UUID) error {
+	if _, err := s.authorize(userID, model.OrgScopeProject, id, model.OrgRoleOwner); err != nil {
+		return err
+	}
+	if err := s.requireEmpty(s.db.Model(&model.Secret{}).Where("project_id = ?", id), "secrets"); err != nil {
+		return err
+	}
+	if err := s.delete(&model.Project{}, model.OrgScopeProject, id); err != nil {
+		return err
+	}
+
+	s.audit(userID, "project_deleted", model.OrgScopeProject, id, "")
+	return nil
+}
+
+// GetMembers lists the roles held on the entity itself, not those
+// inherited from above it
+func (s *OrgService) GetMembers(scope model.OrgScope, id uuid.UUID, userID uuid.UUID) ([]model.OrgMembership, error) {
+	if _, err := s.authorize(userID, scope, id, model.OrgRoleReader); err != nil {
+		return nil, err
+	}
+	var memberships []model.OrgMembership
+	if err := s.db.Where("scope = ? AND scope_id = ?", scope, id).Order("created_at").Find(&memberships).Error; err != nil {
+		return nil, fmt.Errorf("failed to get members: %w", err)
+	}
+	return memberships, nil
+}
+
+// SetMember gives a user a role on the entity, replacing the role they
+// held there. Admins manage members; only owners grant or take away owner.
+func (s *OrgService) SetMember(scope model.OrgScope, id uuid.UUID, req *model.SetOrgMemberRequest, userID uuid.UUID) (*model.OrgMembership, error) {
+	if _, ok := orgRoleRank[req.Role]; !ok {
+		return nil, ErrOrgInvalidRole
+	}
+	actorRole, err := s.authorize(userID, scope, id, model.OrgRoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	membership := &model.OrgMembership{
+		Scope:     scope,
+		ScopeID:   id,
+		UserID:    req.UserID,
+		Role:      req.Role,
+		GrantedBy: userID,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.User{}).Where("id = ?", req.UserID).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to verify user: %w", err)
+		}
+		if count == 0 {
+			return ErrUserNotFound
+		}
+
+		current, err := s.membership(tx, scope, id, req.UserID)
+		if err != nil {
+			return err
+		}
+		if (req.Role == model.OrgRoleOwner || (current != nil && current.Role == model.OrgRoleOwner)) && actorRole != model.OrgRoleOwner {
+			return fmt.Errorf("%w: owner on the %s", ErrOrgRoleRequired, scope)
+		}
+		if current != nil && current.Role == model.OrgRoleOwner && req.Role != model.OrgRoleOwner {
+			if err := s.requireAnotherOwner(tx, scope, id); err != nil {
+				return err
+			}
+		}
+
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "scope"}, {Name: "scope_id"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"role", "granted_by", "updated_at"}),
+		}).Create(membership).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit(userID, "org_member_set", scope, id, fmt.Sprintf("user=%s role=%s", req.UserID, req.Role))
+	return membership, nil
+}
+
+// RemoveMember takes away the role a user holds on the entity. An
+// organization keeps at least one owner.
+func (s *OrgService) RemoveMember(scope model.OrgScope, id uuid.UUID, memberID uuid.UUID, userID uuid.UUID) error {
+	actorRole, err := s.authorize(userID, scope, id, model.OrgRoleAdmin)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		current, err := s.membership(tx, scope, id, memberID)
+		if err != nil {
+			return err
+		}
+		if current == nil {
+			return ErrOrgMemberNotFound
+		}
+		if current.Role == model.OrgRoleOwner {
+			if actorRole != model.OrgRoleOwner {
+				return fmt.Errorf("%w: owner on the %s", ErrOrgRoleRequired, scope)
+			}
+			if err := s.requireAnotherOwner(tx, scope, id); err != nil {
+				return err
+			}
+		}
+		if err := tx.Delete(current).Error; err != nil {
+			return fmt.Errorf("failed to remove member: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.audit(userID, "org_member_removed", scope, id, "user="+memberID.String())
+	return nil
+}
+
+// Role returns the highest role the user holds on the entity or on those
+// above it, or "" when they hold none
+func (s *OrgService) Role(userID uuid.UUID, scope model.OrgScope, id uuid.UUID) (model.OrgRole, error) {
+	chain, err := s.chain(scope, id)
+	if err != nil {
+		return "", err
+	}
+
+	query := s.db.Model(&model.OrgMembership{}).Where("user_id = ?", userID)
+	conditions := s.db.Where("scope = ? AND scope_id = ?", chain[0].scope, chain[0].id)
+	for _, ref := range chain[1:] {
+		conditions = conditions.Or("scope = ? AND scope_id = ?", ref.scope, ref.id)
+	}
+
+	var roles []model.OrgRole
+	if err := query.Where(conditions).Pluck("role", &roles).Error; err != nil {
+		return "", fmt.Errorf("failed to get memberships: %w", err)
+	}
+
+	var best model.OrgRole
+	for _, role := range roles {
+		if orgRoleRank[role] > orgRoleRank[best] {
+			best = role
+		}
+	}
+	return best, nil
+}
+
+// HasProjectRole reports whether the user holds at least min on the
+// project, directly or through its team or organization
+func (s *OrgService) HasProjectRole(userID, projectID uuid.UUID, min model.OrgRole) (bool, error) {
+	role, err := s.Role(userID, model.OrgScopeProject, projectID)
+	if errors.Is(err, ErrProjectNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return orgRoleRank[role] >= orgRoleRank[min], nil
+}
+
+// authorize returns the user's role on the entity when it is at least
+// min. Users holding no role there are told the entity does not exist.
+func (s *OrgService) authorize(userID uuid.UUID, scope model.OrgScope, id uuid.UUID, min model.OrgRole) (model.OrgRole, error) {
+	role, err := s.Role(userID, scope, id)
+	if err != nil {
+		return "", err
+	}
+	if s.isCentralAdmin(userID) {
+		return model.OrgRoleOwner, nil
+	}
+	if role == "" {
+		return "", orgNotFound(scope)
+	}
+	if orgRoleRank[role] < orgRoleRank[min] {
+		return "", fmt.Errorf("%w: %s on the %s", ErrOrgRoleRequired, min, scope)
+	}
+	return role, nil
+}
+
+// chain returns the entity and the entities above it, nearest first
+func (s *OrgService) chain(scope model.OrgScope, id uuid.UUID) ([]orgRef, error) {
+	chain := []orgRef{{scope, id}}
+	switch scope {
+	case model.OrgScopeProject:
+		var project model.Project
+		if err := s.first(&project, id, ErrProjectNotFound); err != nil {
+			return nil, err
+		}
+		parents, err := s.chain(model.OrgScopeTeam, project.TeamID)
+		if err != nil {
+			return nil, err
+		}
+		return append(chain, parents...), nil
+	case model.OrgScopeTeam:
+		var team model.Team
+		if err := s.first(&team, id, ErrTeamNotFound); err != nil {
+			return nil, err
+		}
+		return append(chain, orgRef{model.OrgScopeOrganization, team.OrganizationID}), nil
+	case model.OrgScopeOrganization:
+		if err := s.first(&model.Organization{}, id, ErrOrganizationNotFound); err != nil {
+			return nil, err
+		}
+		return chain, nil
+	default:
+		return nil, fmt.Errorf("unknown scope %q", scope)
+	}
+}
+
+func (s *OrgService) first(dest interface{}, id uuid.UUID, notFound error) error {
+	if err := s.db.Where("id = ?", id).First(dest).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return notFound
+		}
+		return fmt.Errorf("failed to load: %w", err)
+	}
+	return nil
+}
+
+// update applies req to the entity loaded into name and description;
+// siblings scopes the names the new name must not collide with
+func (s *OrgService) update(entity interface{}, siblings *gorm.DB, id uuid.UUID, name, description *string, req *model.UpdateOrgEntityRequest) error {
+	updates := map[string]interface{}{}
+	if req.Name != nil {
+		newName, err := orgName(*req.Name)
+		if err != nil {
+			return err
+		}
+		if newName != *name {
+			if err := s.requireFreeName(siblings, newName); err != nil {
+				return err
+			}
+			updates["name"] = newName
+			*name = newName
+		}
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+		*description = *req.Description
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	if err := s.db.Model(entity).Where("id = ?", id).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to update: %w", err)
+	}
+	return nil
+}
+
+func (s *OrgService) delete(entity interface{}, scope model.OrgScope, id uuid.UUID) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("scope = ? AND scope_id = ?", scope, id).Delete(&model.OrgMembership{}).Error; err != nil {
+			return fmt.Errorf("failed to remove members: %w", err)
+		}
+		if err := tx.Where("id = ?", id).Delete(entity).Error; err != nil {
+			return fmt.Errorf("failed to delete %s: %w", scope, err)
+		}
+		return nil
+	})
+}
+
+func (s *OrgService) requireFreeName(siblings *gorm.DB, name string) error {
+	var count int64
+	if err := siblings.Where("name = ?", name).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check name: %w", err)
+	}
+	if count > 0 {
+		return ErrOrgNameTaken
+	}
+	return nil
+}
+
+func (s *OrgService) requireEmpty(children *gorm.DB, what string) error {
+	var count int64
+	if err := children.Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to count %s: %w", what, err)
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: it still has %d %s", ErrOrgNotEmpty, count, what)
+	}
+	return nil
+}
+
+func (s *OrgService) membership(tx *gorm.DB, scope model.OrgScope, id, userID uuid.UUID) (*model.OrgMembership, error) {
+	var membership model.OrgMembership
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("scope = ? AND scope_id = ? AND user_id = ?", scope, id, userID).First(&membership).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get membership: %w", err)
+	}
+	return &membership, nil
+}
+
+// requireAnotherOwner keeps the last owner of an organization from
+// leaving it ownerless; teams and projects may have none
+func (s *OrgService) requireAnotherOwner(tx *gorm.DB, scope model.OrgScope, id uuid.UUID) error {
+	if scope != model.OrgScopeOrganization {
+		return nil
+	}
+	var count int64
+	if err := tx.Model(&model.OrgMembership{}).
+		Where("scope = ? AND scope_id = ? AND role = ?", scope, id, model.OrgRoleOwner).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to count owners: %w", err)
+	}
+	if count <= 1 {
+		return ErrOrgLastOwner
+	}
+	return nil
+}
+
+func (s *OrgService) isCentralAdmin(userID uuid.UUID) bool {
+	var user model.User
+	if err := s.db.Where("id = ?", userID).First(&user).Error; err != nil {
+		return false
+	}
+	return IsCentralAdmin(&user)
+}
+
+func (s *OrgService) audit(userID uuid.UUID, action string, scope model.OrgScope, id uuid.UUID, details string) {
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, action, string(scope), id.String(), true, details)
+	}
+}
+
+func orgName(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !orgNamePattern.MatchString(name) {
+		return "", ErrOrgInvalidName
+	}
+	return name, nil
+}
+
+func orgNotFound(scope model.OrgScope) error {
+	switch scope {
+	case model.OrgScopeTeam:
+		return ErrTeamNotFound
+	case model.OrgScopeProject:
+		return ErrProjectNotFound
+	default:
+		return ErrOrganizationNotFound
+	}
+}
+
+var (
+	ErrOrganizationNotFound = errors.New("organization not found")
+	ErrTeamNotFound         = errors.New("team not found")
+	ErrProjectNotFound      = errors.New("project not found")
+	ErrOrgMemberNotFound    = errors.New("member not found")
+	ErrOrgInvalidName       = errors.New("names are lowercase letters, digits, '-' and '_'")
+	ErrOrgInvalidRole       = errors.New("unknown role; use owner, admin, writer or reader")
+	ErrOrgNameTaken         = errors.New("the name is already taken")
+	ErrOrgNotEmpty          = errors.New("cannot delete")
+	ErrOrgRoleRequired      = errors.New("insufficient role")
+	ErrOrgLastOwner         = errors.New("an organization needs at least one owner")
+)
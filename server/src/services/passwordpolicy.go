@@ -0,0 +1,199 @@
+package services
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/skygenesisenterprise/aether-vault/server/src/config"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+)
+
+// PasswordPolicyService holds user passwords, and optionally generated
+// ones, to the configured policy
+type PasswordPolicyService struct {
+	cfg    config.PasswordPolicyConfig
+	client *http.Client
+}
+
+// PasswordPolicyError lists every rule a password breaks
+type PasswordPolicyError struct {
+	Violations []string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrPasswordPolicy, strings.Join(e.Violations, "; "))
+}
+
+func (e *PasswordPolicyError) Unwrap() error {
+	return ErrPasswordPolicy
+}
+
+func NewPasswordPolicyService(cfg config.PasswordPolicyConfig) *PasswordPolicyService {
+	return &PasswordPolicyService{
+		cfg:    cfg,
+		client: &http.Client{Timeout: time.Duration(cfg.Timeout) * time.Second},
+	}
+}
+
+// UsePasswordPolicy sizes generated passwords to the policy and, when it
+// covers generated values, holds passwords and passphrases to it
+func (s *GeneratorService) UsePasswordPolicy(policy *PasswordPolicyService) {
+	s.passwordPolicy = policy
+}
+
+// Test checks password against every rule of the policy, the breach check
+// included. inputs are the user's own details, which weaken a password
+// that contains them.
+func (s *PasswordPolicyService) Test(password string, inputs []string) *model.PasswordPolicyResult {
+	result := s.checkRules(password, inputs)
+
+	if s.cfg.BreachCheck {
+		count, err := s.breachCount(password)
+		switch {
+		case err != nil:
+			if s.cfg.BreachFailClosed {
+				result.Violations = append(result.Violations, "the breach check is unavailable")
+			}
+		case count > 0:
+			result.BreachChecked = true
+			result.Breached = count
+			result.Violations = append(result.Violations, fmt.Sprintf("has appeared in %d data breaches", count))
+		default:
+			result.BreachChecked = true
+		}
+	}
+
+	result.Valid = len(result.Violations) == 0
+	return result
+}
+
+// Enforce returns a PasswordPolicyError if password fails Test
+func (s *PasswordPolicyService) Enforce(password string, inputs []string) error {
+	if result := s.Test(password, inputs); !result.Valid {
+		return &PasswordPolicyError{Violations: result.Violations}
+	}
+	return nil
+}
+
+// EnforceGenerated applies the length, character class and strength rules
+// to a generated value, when the policy covers generated values. Values
+// are random and never leave the vault for a breach lookup.
+func (s *PasswordPolicyService) EnforceGenerated(value string) error {
+	if !s.cfg.ApplyToGenerated {
+		return nil
+	}
+	if result := s.checkRules(value, nil); len(result.Violations) > 0 {
+		return &PasswordPolicyError{Violations: result.Violations}
+	}
+	return nil
+}
+
+// MinLength is the shortest password the policy accepts
+func (s *PasswordPolicyService) MinLength() int {
+	return s.cfg.MinLength
+}
+
+func (s *PasswordPolicyService) checkRules(password string, inputs []string) *model.PasswordPolicyResult {
+	result := &model.PasswordPolicyResult{Violations: []string{}}
+
+	length := len([]rune(password))
+	if length < s.cfg.MinLength {
+		result.Violations = append(result.Violations, fmt.Sprintf("must be at least %d characters", s.cfg.MinLength))
+	}
+	if length > s.cfg.MaxLength {
+		result.Violations = append(result.Violations, fmt.Sprintf("must be at most %d characters", s.cfg.MaxLength))
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r), unicode.IsSymbol(r), unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if s.cfg.RequireUppercase && !upper {
+		result.Violations = append(result.Violations, "must contain an uppercase letter")
+	}
+	if s.cfg.RequireLowercase && !lower {
+		result.Violations = append(result.Violations, "must contain a lowercase letter")
+	}
+	if s.cfg.RequireDigit && !digit {
+		result.Violations = append(result.Violations, "must contain a digit")
+	}
+	if s.cfg.RequireSymbol && !symbol {
+		result.Violations = append(result.Violations, "must contain a symbol")
+	}
+
+	result.Strength, result.GuessesLog10 = passwordStrength(password, inputs)
+	if result.Strength < s.cfg.MinStrength {
+		result.Violations = append(result.Violations, fmt.Sprintf("is too easy to guess (strength %d, at least %d required)", result.Strength, s.cfg.MinStrength))
+	}
+
+	return result
+}
+
+// breachCount asks the range API how often password appears in breaches.
+// Only the first five characters of its SHA-1 are sent, and padding hides
+// how many suffixes come back.
+func (s *PasswordPolicyService) breachCount(password string) (int, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	endpoint := s.cfg.BreachEndpoint
+	if !strings.HasSuffix(endpoint, "/") {
+		endpoint += "/"
+	}
+	request, err := http.NewRequest(http.MethodGet, endpoint+prefix, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to build breach lookup: %w", err)
+	}
+	request.Header.Set("Add-Padding", "true")
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrBreachCheckUnavailable, err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("%w: status %d", ErrBreachCheckUnavailable, response.StatusCode)
+	}
+
+	scanner := bufio.NewScanner(io.LimitReader(response.Body, 4<<20))
+	for scanner.Scan() {
+		candidate, count, found := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !found || !strings.EqualFold(candidate, suffix) {
+			continue
+		}
+		// padding entries carry a count of zero
+		n, err := strconv.Atoi(count)
+		if err != nil {
+			return 0, fmt.Errorf("%w: malformed count %q", ErrBreachCheckUnavailable, count)
+		}
+		return n, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrBreachCheckUnavailable, err)
+	}
+	return 0, nil
+}
+
+var (
+	ErrPasswordPolicy         = errors.New("password does not meet the password policy")
+	ErrBreachCheckUnavailable = errors.New("breach check unavailable")
+)
@@ -0,0 +1,327 @@
+package services
+
+import (
+	_ "embed"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// The strength estimator follows zxcvbn: it finds the cheapest way to
+// build the password out of dictionary words, repeats, sequences,
+// keyboard runs and years, charges each piece the guesses an attacker
+// enumerating such pieces would need, and scores the total on zxcvbn's
+// 0-4 scale.
+
+//go:embed common_passwords.txt
+var commonPasswordList string
+
+// strengthWords ranks every dictionary word by how early an attacker would
+// try it: common passwords in list order, then the diceware words
+var strengthWords = rankStrengthWords()
+
+const (
+	// strengthMaxRunes caps the part of a password that is analysed;
+	// anything longer scores on its first runes alone
+	strengthMaxRunes = 256
+	minStrengthMatch = 3
+	minKeyboardRun   = 4
+)
+
+var keyboardRows = []string{"`1234567890-=", "qwertyuiop[]\\", "asdfghjkl;'", "zxcvbnm,./"}
+
+var leetSubstitutions = map[rune]rune{
+	'0': 'o', '1': 'l', '3': 'e', '4': 'a', '5': 's', '7': 't', '@': 'a', '$': 's', '!': 'i', '|': 'l', '+': 't',
+}
+
+func rankStrengthWords() map[string]int {
+	ranks := make(map[string]int)
+	rank := 0
+	for _, list := range [][]string{strings.Fields(commonPasswordList), dicewareWords} {
+		for _, word := range list {
+			rank++
+			if _, seen := ranks[word]; !seen {
+				ranks[word] = rank
+			}
+		}
+	}
+	return ranks
+}
+
+// strengthMatch covers runes [start, end) of the password at a cost of
+// bits
+type strengthMatch struct {
+	start, end int
+	bits       float64
+}
+
+// passwordStrength scores password from 0 to 4 and returns the log10 of
+// the guesses the score rests on. inputs are details of the user, such as
+// their email and names, which count as the first words an attacker tries.
+func passwordStrength(password string, inputs []string) (int, float64) {
+	runes := []rune(password)
+	if len(runes) > strengthMaxRunes {
+		runes = runes[:strengthMaxRunes]
+	}
+	if len(runes) == 0 {
+		return 0, 0
+	}
+
+	words := userWords(inputs)
+	matches := dictionaryMatches(runes, words)
+	matches = append(matches, repeatMatches(runes)...)
+	matches = append(matches, sequenceMatches(runes)...)
+	matches = append(matches, keyboardMatches(runes)...)
+	matches = append(matches, yearMatches(runes)...)
+
+	// best[i] is the cheapest cover of the first i runes, in bits, where
+	// every match after the first costs a bit for where it goes. Runes no
+	// match covers cost ten guesses each, as in zxcvbn.
+	bruteBits := math.Log2(10)
+	best := make([]float64, len(runes)+1)
+	pieces := make([]int, len(runes)+1)
+	for i := 1; i <= len(runes); i++ {
+		best[i] = best[i-1] + bruteBits
+		pieces[i] = pieces[i-1]
+		for _, match := range matches {
+			if match.end != i {
+				continue
+			}
+			cost := best[match.start] + match.bits
+			if pieces[match.start] > 0 {
+				cost++
+			}
+			if cost < best[i] {
+				best[i] = cost
+				pieces[i] = pieces[match.start] + 1
+			}
+		}
+	}
+
+	guessesLog10 := best[len(runes)] * math.Log10(2)
+	guessesLog10 = math.Round(guessesLog10*100) / 100
+	switch {
+	case guessesLog10 < 3:
+		return 0, guessesLog10
+	case guessesLog10 < 6:
+		return 1, guessesLog10
+	case guessesLog10 < 8:
+		return 2, guessesLog10
+	case guessesLog10 < 10:
+		return 3, guessesLog10
+	default:
+		return 4, guessesLog10
+	}
+}
+
+// userWords splits the user's details into words ranked ahead of every
+// dictionary word
+func userWords(inputs []string) map[string]bool {
+	words := make(map[string]bool)
+	for _, input := range inputs {
+		input = strings.ToLower(input)
+		if len([]rune(input)) >= minStrengthMatch {
+			words[input] = true
+		}
+		for _, field := range strings.FieldsFunc(input, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+			if len([]rune(field)) >= minStrengthMatch {
+				words[field] = true
+			}
+		}
+	}
+	return words
+}
+
+// dictionaryMatches finds dictionary and user words, also spelt with
+// capitals or leet substitutions
+func dictionaryMatches(runes []rune, userWords map[string]bool) []strengthMatch {
+	lower := make([]rune, len(runes))
+	plain := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+		plain[i] = lower[i]
+		if substitute, ok := leetSubstitutions[lower[i]]; ok {
+			plain[i] = substitute
+		}
+	}
+
+	var matches []strengthMatch
+	for i := range runes {
+		for j := i + minStrengthMatch; j <= len(runes); j++ {
+			for _, candidate := range [][]rune{lower[i:j], plain[i:j]} {
+				word := string(candidate)
+				rank, known := strengthWords[word]
+				if userWords[word] {
+					rank, known = 1, true
+				}
+				if !known {
+					continue
+				}
+				bits := math.Log2(float64(rank)) + 1
+				bits += capitalBits(runes[i:j])
+				for k := i; k < j; k++ {
+					if candidate[k-i] != lower[k] {
+						bits++
+					}
+				}
+				matches = append(matches, strengthMatch{start: i, end: j, bits: bits})
+			}
+		}
+	}
+	return matches
+}
+
+// capitalBits is what capitalising a word adds: a bit for the usual
+// first-letter or all-caps forms, two per capital otherwise
+func capitalBits(word []rune) float64 {
+	upper := 0
+	for _, r := range word {
+		if unicode.IsUpper(r) {
+			upper++
+		}
+	}
+	switch {
+	case upper == 0:
+		return 0
+	case upper == len(word), upper == 1 && unicode.IsUpper(word[0]):
+		return 1
+	default:
+		return float64(2 * upper)
+	}
+}
+
+func repeatMatches(runes []rune) []strengthMatch {
+	var matches []strengthMatch
+	for i := 0; i < len(runes); {
+		j := i + 1
+		for j < len(runes) && runes[j] == runes[i] {
+			j++
+		}
+		if j-i >= minStrengthMatch {
+			bits := math.Log2(float64(cardinality(runes[i:i+1]))) + math.Log2(float64(j-i))
+			matches = append(matches, strengthMatch{start: i, end: j, bits: bits})
+		}
+		i = j
+	}
+	return matches
+}
+
+// sequenceMatches finds runs such as abc, 987 or ACE that step through
+// letters or digits by a constant amount
+func sequenceMatches(runes []rune) []strengthMatch {
+	var matches []strengthMatch
+	for i := 0; i+minStrengthMatch <= len(runes); {
+		step := runes[i+1] - runes[i]
+		if step == 0 || step < -2 || step > 2 || !sameClass(runes[i], runes[i+1]) {
+			i++
+			continue
+		}
+		j := i + 2
+		for j < len(runes) && runes[j]-runes[j-1] == step && sameClass(runes[j], runes[i]) {
+			j++
+		}
+		if j-i >= minStrengthMatch {
+			start := 26.0
+			if unicode.IsDigit(runes[i]) {
+				start = 10
+			}
+			if strings.ContainsRune("aA01zZ9", runes[i]) {
+				start = 2
+			}
+			bits := math.Log2(start) + math.Log2(float64(j-i))
+			if step < 0 {
+				bits++
+			}
+			matches = append(matches, strengthMatch{start: i, end: j, bits: bits})
+			i = j - 1
+			continue
+		}
+		i++
+	}
+	return matches
+}
+
+// keyboardMatches finds runs of neighbouring keys along a row, either way
+func keyboardMatches(runes []rune) []strengthMatch {
+	var matches []strengthMatch
+	for _, row := range keyboardRows {
+		for i := 0; i < len(runes); {
+			j := i + 1
+			direction := 0
+			for j < len(runes) {
+				step := strings.IndexRune(row, unicode.ToLower(runes[j])) - strings.IndexRune(row, unicode.ToLower(runes[j-1]))
+				if strings.IndexRune(row, unicode.ToLower(runes[j-1])) < 0 || (step != 1 && step != -1) || (direction != 0 && step != direction) {
+					break
+				}
+				direction = step
+				j++
+			}
+			if j-i >= minKeyboardRun {
+				bits := math.Log2(47) + math.Log2(float64(j-i)) + capitalBits(runes[i:j])
+				matches = append(matches, strengthMatch{start: i, end: j, bits: bits})
+				i = j
+				continue
+			}
+			i++
+		}
+	}
+	return matches
+}
+
+// yearMatches finds years from 1900 to 2049
+func yearMatches(runes []rune) []strengthMatch {
+	var matches []strengthMatch
+	for i := 0; i+4 <= len(runes); i++ {
+		year := string(runes[i : i+4])
+		if (strings.HasPrefix(year, "19") || strings.HasPrefix(year, "20")) && isDigits(year) && year < "2050" {
+			matches = append(matches, strengthMatch{start: i, end: i + 4, bits: math.Log2(150)})
+		}
+	}
+	return matches
+}
+
+// cardinality is the size of the character classes runes draw from
+func cardinality(runes []rune) int {
+	var lower, upper, digit, symbol, other bool
+	for _, r := range runes {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r < unicode.MaxASCII:
+			symbol = true
+		default:
+			other = true
+		}
+	}
+
+	size := 0
+	for _, class := range []struct {
+		present bool
+		size    int
+	}{{lower, 26}, {upper, 26}, {digit, 10}, {symbol, 33}, {other, 100}} {
+		if class.present {
+			size += class.size
+		}
+	}
+	if size < 10 {
+		size = 10
+	}
+	return size
+}
+
+func sameClass(a, b rune) bool {
+	return (unicode.IsLower(a) && unicode.IsLower(b)) || (unicode.IsUpper(a) && unicode.IsUpper(b)) || (unicode.IsDigit(a) && unicode.IsDigit(b))
+}
+
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return value != ""
+}
@@ -0,0 +1,828 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/config"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+)
+
+const (
+	// PKIIssuePolicy is the policy path granting certificate issuance; the
+	// role name is appended, so policies can allow pki/issue/* or single
+	// roles
+	PKIIssuePolicy = "pki/issue"
+
+	// Lifetimes of vault-hosted CAs when the request sets none
+	pkiRootDefaultTTL         = 10 * 365 * 24 * time.Hour
+	pkiIntermediateDefaultTTL = 5 * 365 * 24 * time.Hour
+
+	// pkiClockSkew backdates certificates so that peers whose clocks lag
+	// accept them at once
+	pkiClockSkew = 30 * time.Second
+)
+
+var pkiDNSName = regexp.MustCompile(`^(\*\.)?([a-z0-9]([a-z0-9-]*[a-z0-9])?\.)*[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+// PKIService hosts certificate authorities whose keys are sealed in the
+// database and issues short-lived certificates from them under roles that
+// constrain names, key types and lifetimes. Issued certificates are
+// recorded so they can be revoked; each issuer publishes a CRL.
+type PKIService struct {
+	db            *gorm.DB
+	secretService *SecretService
+	userService   *UserService
+	policyService *PolicyService
+	auditService  *AuditService
+	config        *config.PKIConfig
+	publicURL     string
+	// crlMutex serialises CRL rebuilds within this instance
+	crlMutex sync.Mutex
+}
+
+func NewPKIService(db *gorm.DB, secretService *SecretService, userService *UserService, policyService *PolicyService, auditService *AuditService, config *config.PKIConfig, publicURL string) *PKIService {
+	return &PKIService{
+		db:            db,
+		secretService: secretService,
+		userService:   userService,
+		policyService: policyService,
+		auditService:  auditService,
+		config:        config,
+		publicURL:     strings.TrimRight(publicURL, "/"),
+	}
+}
+
+// CreateRoot generates a self-signed root CA
+func (s *PKIService) CreateRoot(req *model.CreatePKIIssuerRequest, userID uuid.UUID) (*model.PKIIssuer, error) {
+	if req.Parent != "" {
+		return nil, fmt.Errorf("%w: a root has no parent", ErrPKIInvalidIssuer)
+	}
+	return s.createIssuer(req, nil, userID)
+}
+
+// CreateIntermediate generates an intermediate CA signed by req.Parent
+func (s *PKIService) CreateIntermediate(req *model.CreatePKIIssuerRequest, userID uuid.UUID) (*model.PKIIssuer, error) {
+	if req.Parent == "" {
+		return nil, fmt.Errorf("%w: parent is required", ErrPKIInvalidIssuer)
+	}
+	parent, err := s.getIssuer(req.Parent)
+	if err != nil {
+		return nil, err
+	}
+	return s.createIssuer(req, parent, userID)
+}
+
+func (s *PKIService) createIssuer(req *model.CreatePKIIssuerRequest, parent *model.PKIIssuer, userID uuid.UUID) (*model.PKIIssuer, error) {
+	keyType, keyBits, err := pkiKeyParams(req.KeyType, req.KeyBits)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPKIInvalidIssuer, err)
+	}
+	var existing int64
+	if err := s.db.Model(&model.PKIIssuer{}).Where("name = ?", req.Name).Count(&existing).Error; err != nil {
+		return nil, fmt.Errorf("failed to check pki issuers: %w", err)
+	}
+	if existing > 0 {
+		return nil, ErrPKIIssuerExists
+	}
+
+	now := time.Now()
+	ttl := pkiRootDefaultTTL
+	if parent != nil {
+		ttl = pkiIntermediateDefaultTTL
+	}
+	if req.TTL > 0 {
+		ttl = time.Duration(req.TTL) * time.Second
+	}
+	notAfter := now.Add(ttl)
+
+	key, err := generatePKIKey(keyType, keyBits)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := pkiSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: req.CommonName},
+		NotBefore:             now.Add(-pkiClockSkew),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	signerCert, signer := template, crypto.Signer(key)
+	if parent != nil {
+		signerCert, signer, err = s.issuerKey(parent)
+		if err != nil {
+			return nil, err
+		}
+		if !now.Before(parent.NotAfter) {
+			return nil, ErrPKIIssuerExpired
+		}
+		// A CA cannot outlive the CA that signed it
+		if notAfter.After(parent.NotAfter) {
+			notAfter = parent.NotAfter
+		}
+		template.CRLDistributionPoints = s.crlDistributionPoints(parent.Name)
+	}
+	template.NotAfter = notAfter
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signerCert, key.Public(), signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign issuer certificate: %w", err)
+	}
+
+	privatePEM, err := encodePKIKey(key)
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := s.secretService.encrypt(privatePEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt issuer key: %w", err)
+	}
+
+	issuer := &model.PKIIssuer{
+		Name:         req.Name,
+		CommonName:   req.CommonName,
+		KeyType:      keyType,
+		KeyBits:      keyBits,
+		SerialNumber: pkiSerialString(serial),
+		Certificate:  string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		PrivateKey:   encrypted,
+		NotBefore:    template.NotBefore,
+		NotAfter:     notAfter,
+		CreatedBy:    userID,
+	}
+	if parent != nil {
+		issuer.ParentID = &parent.ID
+	}
+
+	if err := s.db.Create(issuer).Error; err != nil {
+		return nil, fmt.Errorf("failed to create pki issuer: %w", err)
+	}
+
+	if s.auditService != nil {
+		details := fmt.Sprintf("name=%s; cn=%s; key=%s; expires=%s", issuer.Name, issuer.CommonName, keyType, notAfter.Format(time.RFC3339))
+		if parent != nil {
+			details += "; parent=" + parent.Name
+		}
+		s.auditService.LogAction(userID, "pki_issuer_created", "pki_issuer", issuer.ID.String(), true, details)
+	}
+
+	return issuer, nil
+}
+
+func (s *PKIService) GetIssuers() ([]model.PKIIssuer, error) {
+	var issuers []model.PKIIssuer
+	if err := s.db.Order("name").Find(&issuers).Error; err != nil {
+		return nil, fmt.Errorf("failed to get pki issuers: %w", err)
+	}
+
+	return issuers, nil
+}
+
+// IssuerPEM returns the issuer's certificate followed by those of its
+// parents, up to the root
+func (s *PKIService) IssuerPEM(name string) (string, error) {
+	issuer, err := s.getIssuer(name)
+	if err != nil {
+		return "", err
+	}
+	chain, err := s.chain(issuer)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(chain, ""), nil
+}
+
+// CRL returns the issuer's DER-encoded CRL, re-signing it once half of its
+// lifetime has passed
+func (s *PKIService) CRL(name string) ([]byte, error) {
+	issuer, err := s.getIssuer(name)
+	if err != nil {
+		return nil, err
+	}
+
+	lifetime := s.crlLifetime()
+	if len(issuer.CRL) > 0 && issuer.CRLUpdatedAt != nil && time.Since(*issuer.CRLUpdatedAt) < lifetime/2 {
+		return issuer.CRL, nil
+	}
+	return s.rebuildCRL(issuer)
+}
+
+func (s *PKIService) CreateRole(req *model.CreatePKIRoleRequest, userID uuid.UUID) (*model.PKIRole, error) {
+	issuer, err := s.getIssuer(req.Issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	domains := make([]string, 0, len(req.AllowedDomains))
+	for _, domain := range req.AllowedDomains {
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		if domain == "" || strings.HasPrefix(domain, "*") || !pkiDNSName.MatchString(domain) {
+			return nil, fmt.Errorf("%w: invalid allowed domain %q", ErrPKIInvalidRole, domain)
+		}
+		domains = append(domains, domain)
+	}
+	if len(domains) == 0 {
+		return nil, fmt.Errorf("%w: allowed_domains is required", ErrPKIInvalidRole)
+	}
+	if !req.AllowSubdomains && !req.AllowBareDomains {
+		return nil, fmt.Errorf("%w: allow_subdomains or allow_bare_domains is required", ErrPKIInvalidRole)
+	}
+
+	for _, keyType := range req.AllowedKeyTypes {
+		if _, _, err := pkiKeyParams(keyType, 0); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrPKIInvalidRole, err)
+		}
+	}
+
+	usages := req.Usages
+	if len(usages) == 0 {
+		usages = []string{model.PKIUsageServer}
+	}
+	for _, usage := range usages {
+		if usage != model.PKIUsageServer && usage != model.PKIUsageClient {
+			return nil, fmt.Errorf("%w: unknown usage %q", ErrPKIInvalidRole, usage)
+		}
+	}
+
+	if req.TTL < 0 || req.MaxTTL < 0 {
+		return nil, fmt.Errorf("%w: ttls must not be negative", ErrPKIInvalidRole)
+	}
+	if req.MaxTTL > s.config.MaxTTL {
+		return nil, fmt.Errorf("%w: max_ttl exceeds %d seconds", ErrPKIInvalidRole, s.config.MaxTTL)
+	}
+	if req.MaxTTL > 0 && req.TTL > req.MaxTTL {
+		return nil, fmt.Errorf("%w: ttl exceeds max_ttl", ErrPKIInvalidRole)
+	}
+
+	role := &model.PKIRole{
+		Name:             req.Name,
+		IssuerID:         issuer.ID,
+		AllowedDomains:   strings.Join(domains, ","),
+		AllowSubdomains:  req.AllowSubdomains,
+		AllowBareDomains: req.AllowBareDomains,
+		AllowIPSANs:      req.AllowIPSANs,
+		AllowedKeyTypes:  strings.Join(req.AllowedKeyTypes, ","),
+		Usages:           strings.Join(usages, ","),
+		TTL:              req.TTL,
+		MaxTTL:           req.MaxTTL,
+		CreatedBy:        userID,
+	}
+
+	var existing int64
+	if err := s.db.Model(&model.PKIRole{}).Where("name = ?", req.Name).Count(&existing).Error; err != nil {
+		return nil, fmt.Errorf("failed to check pki roles: %w", err)
+	}
+	if existing > 0 {
+		return nil, ErrPKIRoleExists
+	}
+	if err := s.db.Create(role).Error; err != nil {
+		return nil, fmt.Errorf("failed to create pki role: %w", err)
+	}
+	fillPKIRole(role)
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "pki_role_created", "pki_role", role.ID.String(), true, fmt.Sprintf("name=%s; issuer=%s; domains=%s", role.Name, issuer.Name, role.AllowedDomains))
+	}
+
+	return role, nil
+}
+
+func (s *PKIService) GetRoles() ([]model.PKIRole, error) {
+	var roles []model.PKIRole
+	if err := s.db.Order("name").Find(&roles).Error; err != nil {
+		return nil, fmt.Errorf("failed to get pki roles: %w", err)
+	}
+
+	for i := range roles {
+		fillPKIRole(&roles[i])
+	}
+
+	return roles, nil
+}
+
+// DeleteRole stops issuance under a role; certificates already issued stay
+// valid until they expire or are revoked
+func (s *PKIService) DeleteRole(name string, userID uuid.UUID) error {
+	role, err := s.getRole(name)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Delete(&model.PKIRole{}, "id = ?", role.ID).Error; err != nil {
+		return fmt.Errorf("failed to delete pki role: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "pki_role_deleted", "pki_role", role.ID.String(), true, fmt.Sprintf("name=%s", role.Name))
+	}
+
+	return nil
+}
+
+// Issue generates a key pair and a certificate for it under the named
+// role. The caller needs create on pki/issue/<role>, unless they are the
+// central admin.
+func (s *PKIService) Issue(roleName string, req *model.IssueCertificateRequest, userID uuid.UUID) (*model.IssueCertificateResponse, error) {
+	role, err := s.getRole(roleName)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userService.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	allowed := IsCentralAdmin(user)
+	if !allowed && s.policyService != nil {
+		allowed, err = s.policyService.CheckAccess(userID, PKIIssuePolicy+"/"+role.Name, "create")
+		if err != nil {
+			return nil, fmt.Errorf("failed to check policies: %w", err)
+		}
+	}
+	if !allowed {
+		if s.auditService != nil {
+			s.auditService.LogAction(userID, "pki_issue_denied", "pki_role", role.ID.String(), false, fmt.Sprintf("cn=%s", req.CommonName))
+		}
+		return nil, ErrPKIIssueDenied
+	}
+
+	commonName := strings.ToLower(strings.TrimSpace(req.CommonName))
+	dnsNames := []string{commonName}
+	for _, name := range req.AltNames {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "" && !containsString(dnsNames, name) {
+			dnsNames = append(dnsNames, name)
+		}
+	}
+	for _, name := range dnsNames {
+		if !pkiRoleAllowsName(role, name) {
+			return nil, fmt.Errorf("%w: %q is not allowed by role %s", ErrPKINameNotAllowed, name, role.Name)
+		}
+	}
+
+	var ips []net.IP
+	for _, value := range req.IPSANs {
+		ip := net.ParseIP(strings.TrimSpace(value))
+		if ip == nil {
+			return nil, fmt.Errorf("%w: invalid IP SAN %q", ErrPKIInvalidRequest, value)
+		}
+		if !role.AllowIPSANs {
+			return nil, fmt.Errorf("%w: role %s does not allow IP SANs", ErrPKINameNotAllowed, role.Name)
+		}
+		ips = append(ips, ip)
+	}
+
+	keyType := req.KeyType
+	if keyType == "" {
+		keyType = model.PKIKeyTypeRSA
+		if len(role.KeyTypeList) > 0 {
+			keyType = role.KeyTypeList[0]
+		}
+	}
+	if len(role.KeyTypeList) > 0 && !containsString(role.KeyTypeList, keyType) {
+		return nil, fmt.Errorf("%w: key type %s is not allowed by role %s", ErrPKIInvalidRequest, keyType, role.Name)
+	}
+	keyType, keyBits, err := pkiKeyParams(keyType, req.KeyBits)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPKIInvalidRequest, err)
+	}
+
+	ttl, err := s.certificateTTL(role, req.TTL)
+	if err != nil {
+		return nil, err
+	}
+
+	var issuer model.PKIIssuer
+	if err := s.db.Where("id = ?", role.IssuerID).First(&issuer).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPKIIssuerNotFound
+		}
+		return nil, fmt.Errorf("failed to get pki issuer: %w", err)
+	}
+	now := time.Now()
+	if !now.Before(issuer.NotAfter) {
+		return nil, ErrPKIIssuerExpired
+	}
+	notAfter := now.Add(ttl)
+	if notAfter.After(issuer.NotAfter) {
+		notAfter = issuer.NotAfter
+	}
+
+	issuerCert, signer, err := s.issuerKey(&issuer)
+	if err != nil {
+		return nil, err
+	}
+	key, err := generatePKIKey(keyType, keyBits)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := pkiSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: commonName},
+		DNSNames:              dnsNames,
+		IPAddresses:           ips,
+		NotBefore:             now.Add(-pkiClockSkew),
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		CRLDistributionPoints: s.crlDistributionPoints(issuer.Name),
+	}
+	if keyType == model.PKIKeyTypeRSA {
+		template.KeyUsage |= x509.KeyUsageKeyEncipherment
+	}
+	for _, usage := range role.UsageList {
+		switch usage {
+		case model.PKIUsageServer:
+			template.ExtKeyUsage = append(template.ExtKeyUsage, x509.ExtKeyUsageServerAuth)
+		case model.PKIUsageClient:
+			template.ExtKeyUsage = append(template.ExtKeyUsage, x509.ExtKeyUsageClientAuth)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, issuerCert, key.Public(), signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign certificate: %w", err)
+	}
+	privatePEM, err := encodePKIKey(key)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := s.chain(&issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	certificate := &model.PKICertificate{
+		SerialNumber: pkiSerialString(serial),
+		IssuerID:     issuer.ID,
+		Role:         role.Name,
+		CommonName:   commonName,
+		Certificate:  string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		NotAfter:     notAfter,
+		IssuedBy:     userID,
+	}
+	if err := s.db.Create(certificate).Error; err != nil {
+		return nil, fmt.Errorf("failed to record certificate: %w", err)
+	}
+
+	if s.auditService != nil {
+		details := fmt.Sprintf("serial=%s; cn=%s; sans=%s; expires=%s", certificate.SerialNumber, commonName, strings.Join(dnsNames[1:], ","), notAfter.Format(time.RFC3339))
+		s.auditService.LogAction(userID, "pki_certificate_issued", "pki_role", role.ID.String(), true, details)
+	}
+
+	return &model.IssueCertificateResponse{
+		Certificate:    certificate.Certificate,
+		IssuingCA:      chain[0],
+		CAChain:        chain,
+		PrivateKey:     privatePEM,
+		PrivateKeyType: keyType,
+		SerialNumber:   certificate.SerialNumber,
+		Expiration:     notAfter,
+	}, nil
+}
+
+// Revoke revokes an issued certificate and re-signs its issuer's CRL.
+// Revoking a revoked certificate changes nothing.
+func (s *PKIService) Revoke(serialNumber string, userID uuid.UUID) (*model.PKICertificate, error) {
+	serial := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(serialNumber), ":", ""))
+
+	var certificate model.PKICertificate
+	if err := s.db.Where("serial_number = ?", serial).First(&certificate).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPKICertificateNotFound
+		}
+		return nil, fmt.Errorf("failed to get certificate: %w", err)
+	}
+	if certificate.RevokedAt != nil {
+		return &certificate, nil
+	}
+
+	now := time.Now()
+	if err := s.db.Model(&certificate).Updates(map[string]interface{}{"revoked_at": now, "revoked_by": userID}).Error; err != nil {
+		return nil, fmt.Errorf("failed to revoke certificate: %w", err)
+	}
+	certificate.RevokedAt = &now
+	certificate.RevokedBy = &userID
+
+	var issuer model.PKIIssuer
+	if err := s.db.Where("id = ?", certificate.IssuerID).First(&issuer).Error; err != nil {
+		return nil, fmt.Errorf("failed to get pki issuer: %w", err)
+	}
+	if _, err := s.rebuildCRL(&issuer); err != nil {
+		return nil, err
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "pki_certificate_revoked", "pki_certificate", certificate.SerialNumber, true, fmt.Sprintf("cn=%s; issuer=%s", certificate.CommonName, issuer.Name))
+	}
+
+	return &certificate, nil
+}
+
+// rebuildCRL signs a CRL listing the issuer's revoked certificates that
+// have not expired yet, and stores it
+func (s *PKIService) rebuildCRL(issuer *model.PKIIssuer) ([]byte, error) {
+	s.crlMutex.Lock()
+	defer s.crlMutex.Unlock()
+
+	var revoked []model.PKICertificate
+	now := time.Now()
+	if err := s.db.Where("issuer_id = ? AND revoked_at IS NOT NULL AND not_after > ?", issuer.ID, now).
+		Order("revoked_at").Find(&revoked).Error; err != nil {
+		return nil, fmt.Errorf("failed to get revoked certificates: %w", err)
+	}
+
+	entries := make([]x509.RevocationListEntry, 0, len(revoked))
+	for _, certificate := range revoked {
+		serial, ok := new(big.Int).SetString(certificate.SerialNumber, 16)
+		if !ok {
+			return nil, fmt.Errorf("invalid serial number %s", certificate.SerialNumber)
+		}
+		entries = append(entries, x509.RevocationListEntry{SerialNumber: serial, RevocationTime: *certificate.RevokedAt})
+	}
+
+	issuerCert, signer, err := s.issuerKey(issuer)
+	if err != nil {
+		return nil, err
+	}
+	number := issuer.CRLNumber + 1
+	crl, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:                    big.NewInt(number),
+		ThisUpdate:                now,
+		NextUpdate:                now.Add(s.crlLifetime()),
+		RevokedCertificateEntries: entries,
+	}, issuerCert, signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign crl: %w", err)
+	}
+
+	// Another instance may have re-signed meanwhile; the CRL number only
+	// moves forward, so the first to store it wins
+	result := s.db.Model(&model.PKIIssuer{}).Where("id = ? AND crl_number = ?", issuer.ID, issuer.CRLNumber).
+		Updates(map[string]interface{}{"crl": crl, "crl_number": number, "crl_updated_at": now})
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to store crl: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		var current model.PKIIssuer
+		if err := s.db.Where("id = ?", issuer.ID).First(&current).Error; err != nil {
+			return nil, fmt.Errorf("failed to get pki issuer: %w", err)
+		}
+		*issuer = current
+		return current.CRL, nil
+	}
+
+	issuer.CRL = crl
+	issuer.CRLNumber = number
+	issuer.CRLUpdatedAt = &now
+	return crl, nil
+}
+
+// certificateTTL resolves the requested lifetime against the role and the
+// configured bounds
+func (s *PKIService) certificateTTL(role *model.PKIRole, requested int) (time.Duration, error) {
+	maxTTL := s.config.MaxTTL
+	if role.MaxTTL > 0 {
+		maxTTL = role.MaxTTL
+	}
+	ttl := requested
+	if ttl <= 0 {
+		ttl = role.TTL
+	}
+	if ttl <= 0 {
+		ttl = min(s.config.DefaultTTL, maxTTL)
+	}
+	if ttl > maxTTL {
+		return 0, fmt.Errorf("%w: ttl exceeds %d seconds", ErrPKIInvalidRequest, maxTTL)
+	}
+	return time.Duration(ttl) * time.Second, nil
+}
+
+func (s *PKIService) crlLifetime() time.Duration {
+	return time.Duration(s.config.CRLLifetime) * time.Hour
+}
+
+// crlDistributionPoints points certificates at their issuer's CRL; without
+// a public URL there is no address to point at
+func (s *PKIService) crlDistributionPoints(issuerName string) []string {
+	if s.publicURL == "" {
+		return nil
+	}
+	return []string{s.publicURL + "/api/v1/pki/issuers/" + issuerName + "/crl"}
+}
+
+// issuerKey parses the issuer's certificate and decrypts its private key
+func (s *PKIService) issuerKey(issuer *model.PKIIssuer) (*x509.Certificate, crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(issuer.Certificate))
+	if block == nil {
+		return nil, nil, fmt.Errorf("invalid certificate for issuer %s", issuer.Name)
+	}
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse issuer certificate: %w", err)
+	}
+
+	decrypted, err := s.secretService.decrypt(issuer.PrivateKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decrypt issuer key: %w", err)
+	}
+	block, _ = pem.Decode([]byte(decrypted))
+	if block == nil {
+		return nil, nil, fmt.Errorf("invalid private key for issuer %s", issuer.Name)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse issuer key: %w", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, nil, fmt.Errorf("issuer key for %s cannot sign", issuer.Name)
+	}
+
+	return certificate, signer, nil
+}
+
+// chain returns the PEM certificates from issuer up to its root
+func (s *PKIService) chain(issuer *model.PKIIssuer) ([]string, error) {
+	chain := []string{issuer.Certificate}
+	for current := issuer; current.ParentID != nil; {
+		var parent model.PKIIssuer
+		if err := s.db.Where("id = ?", *current.ParentID).First(&parent).Error; err != nil {
+			return nil, fmt.Errorf("failed to get parent of pki issuer %s: %w", current.Name, err)
+		}
+		chain = append(chain, parent.Certificate)
+		current = &parent
+	}
+	return chain, nil
+}
+
+func (s *PKIService) getIssuer(name string) (*model.PKIIssuer, error) {
+	var issuer model.PKIIssuer
+	if err := s.db.Where("name = ?", name).First(&issuer).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPKIIssuerNotFound
+		}
+		return nil, fmt.Errorf("failed to get pki issuer: %w", err)
+	}
+	return &issuer, nil
+}
+
+func (s *PKIService) getRole(name string) (*model.PKIRole, error) {
+	var role model.PKIRole
+	if err := s.db.Where("name = ?", name).First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPKIRoleNotFound
+		}
+		return nil, fmt.Errorf("failed to get pki role: %w", err)
+	}
+	fillPKIRole(&role)
+	return &role, nil
+}
+
+func fillPKIRole(role *model.PKIRole) {
+	role.DomainList = splitPKIList(role.AllowedDomains)
+	role.KeyTypeList = splitPKIList(role.AllowedKeyTypes)
+	role.UsageList = splitPKIList(role.Usages)
+}
+
+func splitPKIList(list string) []string {
+	if list == "" {
+		return []string{}
+	}
+	return strings.Split(list, ",")
+}
+
+// pkiRoleAllowsName reports whether the role may issue for a DNS name: one
+// of its domains itself with bare domains allowed, or below one of them
+// with subdomains allowed
+func pkiRoleAllowsName(role *model.PKIRole, name string) bool {
+	if !pkiDNSName.MatchString(name) {
+		return false
+	}
+	for _, domain := range role.DomainList {
+		if name == domain && role.AllowBareDomains {
+			return true
+		}
+		if strings.HasSuffix(name, "."+domain) && role.AllowSubdomains {
+			return true
+		}
+	}
+	return false
+}
+
+// pkiKeyParams validates a key type and size, filling in the default size
+func pkiKeyParams(keyType string, bits int) (string, int, error) {
+	switch keyType {
+	case model.PKIKeyTypeRSA, "":
+		if bits == 0 {
+			bits = 2048
+		}
+		if bits != 2048 && bits != 3072 && bits != 4096 {
+			return "", 0, fmt.Errorf("rsa keys are 2048, 3072 or 4096 bits")
+		}
+		return model.PKIKeyTypeRSA, bits, nil
+	case model.PKIKeyTypeEC:
+		if bits == 0 {
+			bits = 256
+		}
+		if bits != 256 && bits != 384 {
+			return "", 0, fmt.Errorf("ec keys are 256 or 384 bits")
+		}
+		return model.PKIKeyTypeEC, bits, nil
+	case model.PKIKeyTypeEd25519:
+		if bits != 0 {
+			return "", 0, fmt.Errorf("ed25519 keys have a fixed size")
+		}
+		return model.PKIKeyTypeEd25519, 0, nil
+	default:
+		return "", 0, fmt.Errorf("unknown key type %q", keyType)
+	}
+}
+
+func generatePKIKey(keyType string, bits int) (crypto.Signer, error) {
+	var key crypto.Signer
+	var err error
+	switch keyType {
+	case model.PKIKeyTypeRSA:
+		key, err = rsa.GenerateKey(keyReader, bits)
+	case model.PKIKeyTypeEC:
+		curve := elliptic.P256()
+		if bits == 384 {
+			curve = elliptic.P384()
+		}
+		key, err = ecdsa.GenerateKey(curve, keyReader)
+	case model.PKIKeyTypeEd25519:
+		_, key, err = ed25519.GenerateKey(keyReader)
+	default:
+		return nil, fmt.Errorf("unknown key type %q", keyType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate %s key: %w", keyType, err)
+	}
+	return key, nil
+}
+
+func encodePKIKey(key crypto.Signer) (string, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode private key: %w", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+}
+
+// pkiSerialNumber returns a random, positive 128-bit serial number
+func pkiSerialNumber() (*big.Int, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+	return serial.Add(serial, big.NewInt(1)), nil
+}
+
+func pkiSerialString(serial *big.Int) string {
+	return hex.EncodeToString(serial.Bytes())
+}
+
+var (
+	ErrPKIUnavailable         = errors.New("pki engine unavailable")
+	ErrPKIIssuerNotFound      = errors.New("pki issuer not found")
+	ErrPKIIssuerExists        = errors.New("a pki issuer with this name already exists")
+	ErrPKIIssuerExpired       = errors.New("pki issuer has expired")
+	ErrPKIInvalidIssuer       = errors.New("invalid pki issuer")
+	ErrPKIRoleNotFound        = errors.New("pki role not found")
+	ErrPKIRoleExists          = errors.New("a pki role with this name already exists")
+	ErrPKIInvalidRole         = errors.New("invalid pki role")
+	ErrPKIInvalidRequest      = errors.New("invalid certificate request")
+	ErrPKINameNotAllowed      = errors.New("name not allowed")
+	ErrPKIIssueDenied         = errors.New("certificate issuance denied")
+	ErrPKICertificateNotFound = errors.New("certificate not found")
+)
@@ -1,19 +1,30 @@
 package services
 
 import (
+	"encoding/json"
 	"github.com/skygenesisenterprise/aether-vault/server/src/model"
 	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PolicyService struct {
-	db *gorm.DB
+	db           *gorm.DB
+	auditService *AuditService
+	cache        *ttlCache
+	accessStats  *AccessStats
+	canaries     *policyCanaries
 }
 
-func NewPolicyService(db *gorm.DB) *PolicyService {
-	return &PolicyService{db: db}
+func NewPolicyService(db *gorm.DB, auditService *AuditService) *PolicyService {
+	return &PolicyService{db: db, auditService: auditService}
 }
 
 func (s *PolicyService) CreatePolicy(policy *model.Policy, userID uuid.UUID) error {
@@ -26,15 +37,60 @@ func (s *PolicyService) CreatePolicy(policy *model.Policy, userID uuid.UUID) err
 	return nil
 }
 
+// EnableCache caches each user's effective policies for ttl and records
+// lookups in stats for the startup warm-up
+func (s *PolicyService) EnableCache(ttl time.Duration, stats *AccessStats) {
+	s.cache = newTTLCache(ttl)
+	s.cache.clearOnWrite(s.db, "policies", "policies", "policy_assignments")
+	s.accessStats = stats
+}
+
 func (s *PolicyService) GetPoliciesByUserID(userID uuid.UUID) ([]model.Policy, error) {
+	s.accessStats.Record(AccessKindPolicy, userID.String())
+	if cached, ok := s.cache.get(userID.String()); ok {
+		return append([]model.Policy(nil), cached.([]model.Policy)...), nil
+	}
+
+	policies, err := s.loadPolicies(userID)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.put(userID.String(), policies, time.Time{})
+
+	return append([]model.Policy(nil), policies...), nil
+}
+
+func (s *PolicyService) loadPolicies(userID uuid.UUID) ([]model.Policy, error) {
 	var policies []model.Policy
-	if err := s.db.Where("user_id = ? AND is_active = ?", userID, true).Find(&policies).Error; err != nil {
+	assigned := s.db.Model(&model.PolicyAssignment{}).Select("policy_id").Where("user_id = ?", userID)
+	if err := s.db.Where("is_active = ? AND (user_id = ? OR id IN (?))", true, userID, assigned).Find(&policies).Error; err != nil {
 		return nil, fmt.Errorf("failed to get policies: %w", err)
 	}
 
 	return policies, nil
 }
 
+// warmCache loads the effective policies of the given user IDs
+func (s *PolicyService) warmCache(keys []string) (int, error) {
+	if s.cache == nil {
+		return 0, nil
+	}
+	loaded := 0
+	for _, key := range keys {
+		userID, err := uuid.Parse(key)
+		if err != nil {
+			continue
+		}
+		policies, err := s.loadPolicies(userID)
+		if err != nil {
+			return loaded, err
+		}
+		s.cache.put(key, policies, time.Time{})
+		loaded++
+	}
+	return loaded, nil
+}
+
 func (s *PolicyService) GetPolicyByID(id uuid.UUID, userID uuid.UUID) (*model.Policy, error) {
 	var policy model.Policy
 	if err := s.db.Where("id = ? AND user_id = ? AND is_active = ?", id, userID, true).First(&policy).Error; err != nil {
@@ -63,25 +119,477 @@ func (s *PolicyService) DeletePolicy(id uuid.UUID, userID uuid.UUID) error {
 	return nil
 }
 
+// CheckAccess reports whether the user's policies allow action on resource.
+// A matching deny rule in any policy wins over every allow.
 func (s *PolicyService) CheckAccess(userID uuid.UUID, resource, action string) (bool, error) {
 	policies, err := s.GetPoliciesByUserID(userID)
 	if err != nil {
 		return false, err
 	}
 
+	allowed := s.decide(policies, resource, action, nil, uuid.Nil, "")
+	s.shadowEvaluate(userID, policies, resource, action, nil, allowed)
+
+	return allowed, nil
+}
+
+// CheckTaggedAccess is CheckAccess for a resource carrying tags, such as a
+// secret. Rules with tags only match resources that carry all of them.
+func (s *PolicyService) CheckTaggedAccess(userID uuid.UUID, resource, action string, tags []string) (bool, error) {
+	policies, err := s.GetPoliciesByUserID(userID)
+	if err != nil {
+		return false, err
+	}
+
+	allowed := s.decide(policies, resource, action, tags, uuid.Nil, "")
+	s.shadowEvaluate(userID, policies, resource, action, tags, allowed)
+
+	return allowed, nil
+}
+
+// decide applies policies to action on resource, with the rules of the
+// policy override replaced by rules when it is set
+func (s *PolicyService) decide(policies []model.Policy, resource, action string, tags []string, override uuid.UUID, rules string) bool {
+	allowed := false
 	for _, policy := range policies {
-		if s.evaluatePolicy(policy.Rules, resource, action) {
-			return true, nil
+		policyRules := policy.Rules
+		if override != uuid.Nil && policy.ID == override {
+			policyRules = rules
+		}
+		switch s.evaluatePolicy(policyRules, resource, action, tags) {
+		case model.PolicyEffectDeny:
+			return false
+		case model.PolicyEffectAllow:
+			allowed = true
+		}
+	}
+
+	return allowed
+}
+
+// evaluatePolicy returns the effect of the rules on resource and action, or
+// "" when no rule matches. Rules that fail to parse grant nothing, and rules
+// with tags match nothing without tags.
+func (s *PolicyService) evaluatePolicy(rules, resource, action string, tags []string) string {
+	parsed, err := ParsePolicyRules(rules)
+	if err != nil {
+		return ""
+	}
+
+	effect := ""
+	for _, rule := range parsed {
+		if !matchesAny(rule.Resources, resource, matchPolicyResource) || !matchesAny(rule.Actions, action, matchPolicyAction) {
+			continue
+		}
+		if len(rule.Tags) > 0 && !MatchTags(rule.Tags, tags) {
+			continue
+		}
+		if rule.Effect == model.PolicyEffectDeny {
+			return model.PolicyEffectDeny
+		}
+		effect = model.PolicyEffectAllow
+	}
+
+	return effect
+}
+
+// MFAMaxAge returns how recent a step-up verification action on resource
+// needs, in seconds, or 0 when it needs none. Every matching allow rule
+// with mfa applies, so the strictest one wins even when another rule
+// grants the same access without it.
+func (s *PolicyService) MFAMaxAge(userID uuid.UUID, resource, action string, tags []string) (int, error) {
+	policies, err := s.GetPoliciesByUserID(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	maxAge := 0
+	for _, policy := range policies {
+		parsed, err := ParsePolicyRules(policy.Rules)
+		if err != nil {
+			continue
+		}
+		for _, rule := range parsed {
+			if rule.MFA == nil || !matchesAny(rule.Resources, resource, matchPolicyResource) || !matchesAny(rule.Actions, action, matchPolicyAction) {
+				continue
+			}
+			if len(rule.Tags) > 0 && !MatchTags(rule.Tags, tags) {
+				continue
+			}
+			if maxAge == 0 || rule.MFA.MaxAge < maxAge {
+				maxAge = rule.MFA.MaxAge
+			}
+		}
+	}
+
+	return maxAge, nil
+}
+
+// HasMFARules reports whether any of the user's policies has a rule with
+// mfa, i.e. whether step-up verification applies to them at all
+func (s *PolicyService) HasMFARules(userID uuid.UUID) (bool, error) {
+	policies, err := s.GetPoliciesByUserID(userID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, policy := range policies {
+		parsed, err := ParsePolicyRules(policy.Rules)
+		if err != nil {
+			continue
+		}
+		for _, rule := range parsed {
+			if rule.MFA != nil {
+				return true, nil
+			}
 		}
 	}
 
 	return false, nil
 }
 
-func (s *PolicyService) evaluatePolicy(rules, resource, action string) bool {
-	return true
+// ControlGroup returns the approval requirement of action on resource, or
+// nil when it needs none. When several matching allow rules have a
+// control_group, the one asking for the most approvals applies.
+func (s *PolicyService) ControlGroup(userID uuid.UUID, resource, action string, tags []string) (*model.PolicyRuleControlGroup, error) {
+	policies, err := s.GetPoliciesByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var strictest *model.PolicyRuleControlGroup
+	for _, policy := range policies {
+		parsed, err := ParsePolicyRules(policy.Rules)
+		if err != nil {
+			continue
+		}
+		for _, rule := range parsed {
+			if rule.ControlGroup == nil || !matchesAny(rule.Resources, resource, matchPolicyResource) || !matchesAny(rule.Actions, action, matchPolicyAction) {
+				continue
+			}
+			if len(rule.Tags) > 0 && !MatchTags(rule.Tags, tags) {
+				continue
+			}
+			if strictest == nil || rule.ControlGroup.Approvals > strictest.Approvals {
+				group := *rule.ControlGroup
+				strictest = &group
+			}
+		}
+	}
+
+	if strictest != nil && strictest.TTL == 0 {
+		strictest.TTL = defaultControlGroupTTL
+	}
+	return strictest, nil
+}
+
+// ParsePolicyRules decodes and validates a policy rules document
+func ParsePolicyRules(rules string) ([]model.PolicyRule, error) {
+	var parsed []model.PolicyRule
+	if err := json.Unmarshal([]byte(rules), &parsed); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPolicyRulesInvalid, err)
+	}
+
+	for i, rule := range parsed {
+		if rule.Effect != model.PolicyEffectAllow && rule.Effect != model.PolicyEffectDeny {
+			return nil, fmt.Errorf("%w: rule %d has effect %q", ErrPolicyRulesInvalid, i, rule.Effect)
+		}
+		if len(rule.Resources) == 0 || len(rule.Actions) == 0 {
+			return nil, fmt.Errorf("%w: rule %d needs resources and actions", ErrPolicyRulesInvalid, i)
+		}
+		for _, tag := range rule.Tags {
+			if !tagPattern.MatchString(tag) {
+				return nil, fmt.Errorf("%w: rule %d has invalid tag %q", ErrPolicyRulesInvalid, i, tag)
+			}
+		}
+		if rule.MFA != nil {
+			if rule.Effect != model.PolicyEffectAllow {
+				return nil, fmt.Errorf("%w: rule %d: only allow rules may require mfa", ErrPolicyRulesInvalid, i)
+			}
+			if rule.MFA.MaxAge < 1 || rule.MFA.MaxAge > maxMFAMaxAge {
+				return nil, fmt.Errorf("%w: rule %d: mfa max_age must be between 1 and %d seconds", ErrPolicyRulesInvalid, i, maxMFAMaxAge)
+			}
+		}
+		if rule.ControlGroup != nil {
+			if rule.Effect != model.PolicyEffectAllow {
+				return nil, fmt.Errorf("%w: rule %d: only allow rules may require a control group", ErrPolicyRulesInvalid, i)
+			}
+			if rule.ControlGroup.TeamID == uuid.Nil {
+				return nil, fmt.Errorf("%w: rule %d: control_group needs a team_id", ErrPolicyRulesInvalid, i)
+			}
+			if rule.ControlGroup.Approvals < 1 || rule.ControlGroup.Approvals > maxControlGroupApprovals {
+				return nil, fmt.Errorf("%w: rule %d: control_group approvals must be between 1 and %d", ErrPolicyRulesInvalid, i, maxControlGroupApprovals)
+			}
+			if rule.ControlGroup.TTL < 0 || rule.ControlGroup.TTL > maxControlGroupTTL {
+				return nil, fmt.Errorf("%w: rule %d: control_group ttl must be between 1 and %d seconds", ErrPolicyRulesInvalid, i, maxControlGroupTTL)
+			}
+		}
+	}
+
+	return parsed, nil
+}
+
+func matchesAny(patterns []string, value string, match func(pattern, value string) bool) bool {
+	for _, pattern := range patterns {
+		if match(pattern, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchPolicyResource(pattern, resource string) bool {
+	if pattern == "*" || pattern == resource {
+		return true
+	}
+	if strings.HasSuffix(pattern, "/*") {
+		prefix := strings.TrimSuffix(pattern, "*")
+		return strings.HasPrefix(resource, prefix) || resource == strings.TrimSuffix(prefix, "/")
+	}
+	matched, _ := path.Match(pattern, resource)
+	return matched
+}
+
+func matchPolicyAction(pattern, action string) bool {
+	return pattern == "*" || pattern == action
+}
+
+func (s *PolicyService) CreatePolicyTemplate(req *model.CreatePolicyTemplateRequest, userID uuid.UUID) (*model.PolicyTemplate, error) {
+	variables := templateVariables(req.Rules)
+	if len(variables) == 0 {
+		return nil, fmt.Errorf("%w: rules contain no {{variables}}", ErrPolicyTemplateInvalid)
+	}
+
+	template := &model.PolicyTemplate{
+		Name:        req.Name,
+		Description: req.Description,
+		Rules:       req.Rules,
+		Variables:   strings.Join(variables, ","),
+		CreatedBy:   userID,
+	}
+
+	if err := s.db.Create(template).Error; err != nil {
+		return nil, fmt.Errorf("failed to create policy template: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "policy_template_created", "policy_template", template.ID.String(), true, template.Name)
+	}
+
+	return template, nil
+}
+
+func (s *PolicyService) GetPolicyTemplates() ([]model.PolicyTemplate, error) {
+	var templates []model.PolicyTemplate
+	if err := s.db.Order("name").Find(&templates).Error; err != nil {
+		return nil, fmt.Errorf("failed to get policy templates: %w", err)
+	}
+
+	return templates, nil
+}
+
+// InstantiatePolicyTemplate renders a template into a new policy owned by
+// userID and, when req.UserIDs is set, attaches it to those users in the
+// same transaction.
+func (s *PolicyService) InstantiatePolicyTemplate(templateID uuid.UUID, req *model.InstantiatePolicyTemplateRequest, userID uuid.UUID) (*model.Policy, error) {
+	var template model.PolicyTemplate
+	if err := s.db.Where("id = ?", templateID).First(&template).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrPolicyTemplateNotFound
+		}
+		return nil, fmt.Errorf("failed to get policy template: %w", err)
+	}
+
+	rules, err := renderPolicyTemplate(template.Rules, req.Variables)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := ParsePolicyRules(rules); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPolicyTemplateInvalid, err)
+	}
+
+	name := req.Name
+	if name == "" {
+		values := []string{template.Name}
+		for _, variable := range strings.Split(template.Variables, ",") {
+			values = append(values, req.Variables[variable])
+		}
+		name = strings.Join(values, "-")
+	}
+
+	policy := &model.Policy{
+		UserID:      userID,
+		Name:        name,
+		Description: fmt.Sprintf("Instantiated from template %s", template.Name),
+		Rules:       rules,
+		IsActive:    true,
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(policy).Error; err != nil {
+			return fmt.Errorf("failed to create policy: %w", err)
+		}
+		if len(req.UserIDs) > 0 {
+			if _, err := s.attachPolicy(tx, policy.ID, req.UserIDs, userID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "policy_template_instantiated", "policy", policy.ID.String(), true, template.Name)
+	}
+
+	return policy, nil
+}
+
+// AttachPolicy assigns a policy to every user in userIDs, or to none of
+// them if any user does not exist. Existing assignments are kept.
+func (s *PolicyService) AttachPolicy(policyID uuid.UUID, userIDs []uuid.UUID, actorID uuid.UUID) (int64, error) {
+	var attached int64
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		attached, err = s.attachPolicy(tx, policyID, userIDs, actorID)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(actorID, "policy_attached", "policy", policyID.String(), true, fmt.Sprintf("users=%d attached=%d", len(userIDs), attached))
+	}
+
+	return attached, nil
+}
+
+// DetachPolicy removes a policy from every user in userIDs atomically.
+func (s *PolicyService) DetachPolicy(policyID uuid.UUID, userIDs []uuid.UUID, actorID uuid.UUID) (int64, error) {
+	var detached int64
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.requirePolicy(tx, policyID); err != nil {
+			return err
+		}
+
+		result := tx.Where("policy_id = ? AND user_id IN ?", policyID, uniqueUserIDs(userIDs)).Delete(&model.PolicyAssignment{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to detach policy: %w", result.Error)
+		}
+		detached = result.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(actorID, "policy_detached", "policy", policyID.String(), true, fmt.Sprintf("users=%d detached=%d", len(userIDs), detached))
+	}
+
+	return detached, nil
+}
+
+func (s *PolicyService) attachPolicy(tx *gorm.DB, policyID uuid.UUID, userIDs []uuid.UUID, actorID uuid.UUID) (int64, error) {
+	if err := s.requirePolicy(tx, policyID); err != nil {
+		return 0, err
+	}
+
+	userIDs = uniqueUserIDs(userIDs)
+
+	var found int64
+	if err := tx.Model(&model.User{}).Where("id IN ?", userIDs).Count(&found).Error; err != nil {
+		return 0, fmt.Errorf("failed to verify users: %w", err)
+	}
+	if found != int64(len(userIDs)) {
+		return 0, ErrPolicyAssigneeNotFound
+	}
+
+	assignments := make([]model.PolicyAssignment, 0, len(userIDs))
+	for _, id := range userIDs {
+		assignments = append(assignments, model.PolicyAssignment{
+			PolicyID:   policyID,
+			UserID:     id,
+			AssignedBy: actorID,
+		})
+	}
+
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&assignments)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to attach policy: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+func (s *PolicyService) requirePolicy(tx *gorm.DB, policyID uuid.UUID) error {
+	var count int64
+	if err := tx.Model(&model.Policy{}).Where("id = ? AND is_active = ?", policyID, true).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to get policy: %w", err)
+	}
+	if count == 0 {
+		return ErrPolicyNotFound
+	}
+	return nil
+}
+
+var templateVariablePattern = regexp.MustCompile(`\{\{\s*([a-zA-Z_][a-zA-Z0-9_]*)\s*\}\}`)
+
+// templateVariables returns the sorted, unique variable names in rules
+func templateVariables(rules string) []string {
+	seen := make(map[string]bool)
+	var variables []string
+	for _, match := range templateVariablePattern.FindAllStringSubmatch(rules, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			variables = append(variables, match[1])
+		}
+	}
+	sort.Strings(variables)
+	return variables
+}
+
+// renderPolicyTemplate substitutes every {{variable}}, failing on any
+// variable without a value
+func renderPolicyTemplate(rules string, values map[string]string) (string, error) {
+	var missing []string
+	rendered := templateVariablePattern.ReplaceAllStringFunc(rules, func(match string) string {
+		name := templateVariablePattern.FindStringSubmatch(match)[1]
+		value, ok := values[name]
+		if !ok || value == "" {
+			missing = append(missing, name)
+			return match
+		}
+		return value
+	})
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("%w: missing variables %s", ErrPolicyTemplateInvalid, strings.Join(missing, ", "))
+	}
+
+	return rendered, nil
+}
+
+func uniqueUserIDs(userIDs []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(userIDs))
+	unique := make([]uuid.UUID, 0, len(userIDs))
+	for _, id := range userIDs {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
 }
 
 var (
-	ErrPolicyNotFound = fmt.Errorf("policy not found")
+	ErrPolicyNotFound         = fmt.Errorf("policy not found")
+	ErrPolicyTemplateNotFound = fmt.Errorf("policy template not found")
+	ErrPolicyTemplateInvalid  = fmt.Errorf("invalid policy template")
+	ErrPolicyAssigneeNotFound = fmt.Errorf("one or more users not found")
+	ErrPolicyRulesInvalid     = fmt.Errorf("invalid policy rules")
 )
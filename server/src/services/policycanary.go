@@ -0,0 +1,313 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	policyCanaryDefaultWindow = 24 * time.Hour
+	policyCanaryMaxWindow     = 7 * 24 * time.Hour
+
+	// policyCanaryRefresh bounds how long a canary started or ended on
+	// another instance takes to be seen here
+	policyCanaryRefresh = 15 * time.Second
+
+	// policyDivergenceQueue is how many divergences may wait for the
+	// database; more are dropped rather than slowing access checks down
+	policyDivergenceQueue = 1024
+
+	policyDivergenceReportLimit = 200
+)
+
+// policyCanaries holds the active canaries by policy ID and the queue of
+// divergences to record
+type policyCanaries struct {
+	mu       sync.RWMutex
+	active   map[uuid.UUID]model.PolicyCanary
+	loadedAt time.Time
+	queue    chan model.PolicyDivergence
+}
+
+// StartCanaries enables shadow evaluation of policy canaries, recording
+// divergences in the background
+func (s *PolicyService) StartCanaries() {
+	s.canaries = &policyCanaries{queue: make(chan model.PolicyDivergence, policyDivergenceQueue)}
+	go s.recordDivergences()
+}
+
+// shadowEvaluate re-runs an access check with the rules of each active
+// canary among the user's policies, and queues the outcomes that differ
+// from the enforced one
+func (s *PolicyService) shadowEvaluate(userID uuid.UUID, policies []model.Policy, resource, action string, tags []string, enforced bool) {
+	if s.canaries == nil {
+		return
+	}
+	active := s.activeCanaries()
+	if len(active) == 0 {
+		return
+	}
+
+	now := time.Now()
+	for _, policy := range policies {
+		canary, ok := active[policy.ID]
+		if !ok || !canary.Active(now) {
+			continue
+		}
+		shadow := s.decide(policies, resource, action, tags, policy.ID, canary.Rules)
+		if shadow == enforced {
+			continue
+		}
+
+		select {
+		case s.canaries.queue <- model.PolicyDivergence{
+			CanaryID:  canary.ID,
+			UserID:    userID,
+			Resource:  resource,
+			Action:    action,
+			Enforced:  enforced,
+			Shadow:    shadow,
+			Count:     1,
+			FirstSeen: now,
+			LastSeen:  now,
+		}:
+		default:
+		}
+	}
+}
+
+// activeCanaries returns the active canaries, reloading them when stale
+func (s *PolicyService) activeCanaries() map[uuid.UUID]model.PolicyCanary {
+	s.canaries.mu.RLock()
+	active, loadedAt := s.canaries.active, s.canaries.loadedAt
+	s.canaries.mu.RUnlock()
+	if time.Since(loadedAt) < policyCanaryRefresh {
+		return active
+	}
+	return s.reloadCanaries()
+}
+
+// reloadCanaries reads the active canaries from the database. On failure
+// the previous set is kept, so access checks never depend on it.
+func (s *PolicyService) reloadCanaries() map[uuid.UUID]model.PolicyCanary {
+	if s.canaries == nil {
+		return nil
+	}
+
+	var canaries []model.PolicyCanary
+	err := s.db.Where("status = ? AND ends_at > ?", model.PolicyCanaryActive, time.Now()).Find(&canaries).Error
+
+	s.canaries.mu.Lock()
+	defer s.canaries.mu.Unlock()
+	s.canaries.loadedAt = time.Now()
+	if err != nil {
+		log.Printf("⚠️  Failed to load policy canaries: %v", err)
+		return s.canaries.active
+	}
+	active := make(map[uuid.UUID]model.PolicyCanary, len(canaries))
+	for _, canary := range canaries {
+		active[canary.PolicyID] = canary
+	}
+	s.canaries.active = active
+	return active
+}
+
+// recordDivergences stores queued divergences, counting repeats of the
+// same user, resource and action on one row
+func (s *PolicyService) recordDivergences() {
+	for divergence := range s.canaries.queue {
+		err := s.db.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "canary_id"}, {Name: "user_id"}, {Name: "resource"}, {Name: "action"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"count":     gorm.Expr("policy_divergences.count + 1"),
+				"enforced":  divergence.Enforced,
+				"shadow":    divergence.Shadow,
+				"last_seen": divergence.LastSeen,
+			}),
+		}).Create(&divergence).Error
+		if err != nil {
+			log.Printf("⚠️  Failed to record policy divergence: %v", err)
+		}
+	}
+}
+
+// StartCanary shadow evaluates new rules for a policy for a window,
+// without enforcing them
+func (s *PolicyService) StartCanary(policyID uuid.UUID, req *model.StartPolicyCanaryRequest, actorID uuid.UUID) (*model.PolicyCanary, error) {
+	if s.canaries == nil {
+		return nil, ErrPolicyCanaryUnavailable
+	}
+	if _, err := ParsePolicyRules(req.Rules); err != nil {
+		return nil, err
+	}
+	window := policyCanaryDefaultWindow
+	if req.Duration > 0 {
+		window = time.Duration(req.Duration) * time.Second
+	}
+	if window > policyCanaryMaxWindow {
+		return nil, fmt.Errorf("%w: at most %d seconds", ErrPolicyCanaryWindow, int(policyCanaryMaxWindow.Seconds()))
+	}
+
+	now := time.Now()
+	canary := &model.PolicyCanary{
+		PolicyID:  policyID,
+		Rules:     req.Rules,
+		Status:    model.PolicyCanaryActive,
+		StartedBy: actorID,
+		EndsAt:    now.Add(window),
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.requirePolicy(tx, policyID); err != nil {
+			return err
+		}
+
+		var running int64
+		if err := tx.Model(&model.PolicyCanary{}).
+			Where("policy_id = ? AND status = ? AND ends_at > ?", policyID, model.PolicyCanaryActive, now).
+			Count(&running).Error; err != nil {
+			return fmt.Errorf("failed to get policy canaries: %w", err)
+		}
+		if running > 0 {
+			return ErrPolicyCanaryRunning
+		}
+
+		// A canary whose window ended without promotion is superseded
+		if err := tx.Model(&model.PolicyCanary{}).
+			Where("policy_id = ? AND status = ?", policyID, model.PolicyCanaryActive).
+			Updates(map[string]interface{}{"status": model.PolicyCanaryExpired, "completed_at": now}).Error; err != nil {
+			return fmt.Errorf("failed to expire policy canaries: %w", err)
+		}
+
+		if err := tx.Create(canary).Error; err != nil {
+			return fmt.Errorf("failed to create policy canary: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.reloadCanaries()
+
+	if s.auditService != nil {
+		s.auditService.LogAction(actorID, "policy_canary_started", "policy", policyID.String(), true, fmt.Sprintf("canary=%s; ends_at=%s", canary.ID, canary.EndsAt.Format(time.RFC3339)))
+	}
+	return canary, nil
+}
+
+// GetCanaryReport analyses the latest canary of a policy: how many
+// distinct requests it would newly allow or deny, and the most frequent
+func (s *PolicyService) GetCanaryReport(policyID uuid.UUID) (*model.PolicyCanaryReport, error) {
+	var canary model.PolicyCanary
+	if err := s.db.Where("policy_id = ?", policyID).Order("created_at DESC").First(&canary).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPolicyCanaryNotFound
+		}
+		return nil, fmt.Errorf("failed to get policy canary: %w", err)
+	}
+	if canary.Status == model.PolicyCanaryActive && !canary.Active(time.Now()) {
+		canary.Status = model.PolicyCanaryExpired
+	}
+
+	report := &model.PolicyCanaryReport{Canary: canary, Divergences: []model.PolicyDivergence{}}
+
+	var totals []struct {
+		Shadow bool
+		Total  int64
+	}
+	if err := s.db.Model(&model.PolicyDivergence{}).Select("shadow, COUNT(*) AS total").
+		Where("canary_id = ?", canary.ID).Group("shadow").Scan(&totals).Error; err != nil {
+		return nil, fmt.Errorf("failed to count policy divergences: %w", err)
+	}
+	for _, total := range totals {
+		if total.Shadow {
+			report.WouldAllow = total.Total
+		} else {
+			report.WouldDeny = total.Total
+		}
+	}
+
+	if err := s.db.Where("canary_id = ?", canary.ID).Order("count DESC, last_seen DESC").
+		Limit(policyDivergenceReportLimit).Find(&report.Divergences).Error; err != nil {
+		return nil, fmt.Errorf("failed to get policy divergences: %w", err)
+	}
+	return report, nil
+}
+
+// PromoteCanary makes the rules of a policy's canary its enforced rules.
+// A canary whose window has ended can still be promoted.
+func (s *PolicyService) PromoteCanary(policyID uuid.UUID, actorID uuid.UUID) (*model.PolicyCanary, error) {
+	var canary model.PolicyCanary
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.completeCanary(tx, policyID, model.PolicyCanaryPromoted, actorID, &canary); err != nil {
+			return err
+		}
+		if err := tx.Model(&model.Policy{}).Where("id = ?", policyID).Update("rules", canary.Rules).Error; err != nil {
+			return fmt.Errorf("failed to update policy: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.reloadCanaries()
+
+	if s.auditService != nil {
+		s.auditService.LogAction(actorID, "policy_canary_promoted", "policy", policyID.String(), true, fmt.Sprintf("canary=%s", canary.ID))
+	}
+	return &canary, nil
+}
+
+// CancelCanary stops a policy's canary and keeps the current rules
+func (s *PolicyService) CancelCanary(policyID uuid.UUID, actorID uuid.UUID) (*model.PolicyCanary, error) {
+	var canary model.PolicyCanary
+	if err := s.completeCanary(s.db, policyID, model.PolicyCanaryCancelled, actorID, &canary); err != nil {
+		return nil, err
+	}
+	s.reloadCanaries()
+
+	if s.auditService != nil {
+		s.auditService.LogAction(actorID, "policy_canary_cancelled", "policy", policyID.String(), true, fmt.Sprintf("canary=%s", canary.ID))
+	}
+	return &canary, nil
+}
+
+// completeCanary moves the open canary of a policy to status inside tx
+func (s *PolicyService) completeCanary(tx *gorm.DB, policyID uuid.UUID, status model.PolicyCanaryStatus, actorID uuid.UUID, canary *model.PolicyCanary) error {
+	if err := tx.Where("policy_id = ? AND status = ?", policyID, model.PolicyCanaryActive).
+		Order("created_at DESC").First(canary).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPolicyCanaryNotFound
+		}
+		return fmt.Errorf("failed to get policy canary: %w", err)
+	}
+
+	now := time.Now()
+	result := tx.Model(&model.PolicyCanary{}).
+		Where("id = ? AND status = ?", canary.ID, model.PolicyCanaryActive).
+		Updates(map[string]interface{}{"status": status, "completed_by": actorID, "completed_at": now})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update policy canary: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrPolicyCanaryNotFound
+	}
+	canary.Status = status
+	canary.CompletedBy = &actorID
+	canary.CompletedAt = &now
+	return nil
+}
+
+var (
+	ErrPolicyCanaryNotFound    = errors.New("no open canary for this policy")
+	ErrPolicyCanaryRunning     = errors.New("policy already has a running canary")
+	ErrPolicyCanaryWindow      = errors.New("canary window too long")
+	ErrPolicyCanaryUnavailable = errors.New("policy canaries need the database")
+)
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+)
+
+// ProviderService backs the endpoints used by the Terraform provider and
+// the Ansible lookup plugin. Secrets are addressed by name so that
+// configuration can declare them, writes are idempotent, and reads return
+// a content hash for drift detection.
+type ProviderService struct {
+	db            *gorm.DB
+	secretService *SecretService
+	hashKey       []byte
+}
+
+func NewProviderService(db *gorm.DB, secretService *SecretService) *ProviderService {
+	// A keyed hash, unlike the stored value hash, cannot be used to guess
+	// the value offline
+	hashKey := sha256.Sum256(append([]byte("aether-vault-provider-content:"), secretService.cryptoKey...))
+
+	return &ProviderService{
+		db:            db,
+		secretService: secretService,
+		hashKey:       hashKey[:],
+	}
+}
+
+// UpsertSecret brings the named secret to the requested state. A request
+// matching the stored state writes nothing, so rotation timestamps and
+// audit logs only move when something changed.
+func (s *ProviderService) UpsertSecret(req *model.ProviderSecretRequest, userID uuid.UUID) (*model.ProviderUpsertResponse, error) {
+	existing, err := s.findByName(userID, req.NamespaceID, req.Name)
+	if err != nil && !errors.Is(err, ErrSecretNotFound) {
+		return nil, err
+	}
+
+	if existing == nil {
+		secret := &model.Secret{
+			Name:            req.Name,
+			Description:     req.Description,
+			Value:           req.Value,
+			Type:            req.Type,
+			Tags:            req.Tags,
+			ExpiresAt:       req.ExpiresAt,
+			NamespaceID:     req.NamespaceID,
+			ClientEncrypted: req.ClientEncrypted,
+			IsActive:        true,
+		}
+		if err := s.secretService.CreateSecret(secret, userID); err != nil {
+			return nil, err
+		}
+		return &model.ProviderUpsertResponse{ProviderSecretResponse: s.response(secret), Created: true, Changed: true}, nil
+	}
+
+	if err := s.secretService.checkIntegrity(existing); err != nil {
+		return nil, err
+	}
+	if s.matches(existing, req) {
+		return &model.ProviderUpsertResponse{ProviderSecretResponse: s.response(existing)}, nil
+	}
+
+	updates := &model.UpdateSecretRequest{
+		Description:     &req.Description,
+		Type:            &req.Type,
+		Tags:            &req.Tags,
+		ExpiresAt:       req.ExpiresAt,
+		ClientEncrypted: &req.ClientEncrypted,
+	}
+	if s.secretService.hashValue(req.Value) != existing.ValueHash || req.ClientEncrypted != existing.ClientEncrypted {
+		updates.Value = &req.Value
+	}
+	updated, err := s.secretService.UpdateSecret(existing.ID, updates, userID)
+	if err != nil {
+		return nil, err
+	}
+	// UpdateSecret only sets an expiry; clearing it needs its own write
+	if req.ExpiresAt == nil && updated.ExpiresAt != nil {
+		if updated, err = s.clearExpiry(updated.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return &model.ProviderUpsertResponse{ProviderSecretResponse: s.response(updated), Changed: true}, nil
+}
+
+// ReadSecret returns a secret by import ID, with its value when asked
+func (s *ProviderService) ReadSecret(importID string, includeValue bool, userID uuid.UUID, accessor *uuid.UUID) (*model.ProviderSecretResponse, error) {
+	secret, err := s.resolve(importID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if includeValue {
+		// Goes through the regular read path for decryption, step-up and
+		// auditing
+		if secret, err = s.secretService.GetSecretByID(secret.ID, userID, accessor); err != nil {
+			return nil, err
+		}
+		response := s.response(secret)
+		response.Value = secret.Value
+		return &response, nil
+	}
+
+	if err := s.secretService.checkIntegrity(secret); err != nil {
+		return nil, err
+	}
+	response := s.response(secret)
+	return &response, nil
+}
+
+// DeleteSecret deletes a secret by import ID. Deleting a secret that is
+// already gone succeeds, so a retried destroy does not fail.
+func (s *ProviderService) DeleteSecret(importID string, userID uuid.UUID) error {
+	secret, err := s.resolve(importID, userID)
+	if err != nil {
+		if errors.Is(err, ErrSecretNotFound) {
+			return nil
+		}
+		return err
+	}
+	return s.secretService.DeleteSecret(secret.ID, userID)
+}
+
+// resolve accepts the import ID forms: a secret UUID, <name> for a
+// personal secret, or <namespace UUID>:<name>
+func (s *ProviderService) resolve(importID string, userID uuid.UUID) (*model.Secret, error) {
+	if id, err := uuid.Parse(importID); err == nil {
+		var secret model.Secret
+		if err := s.db.Where("id = ? AND user_id = ? AND is_active = ?", id, userID, true).First(&secret).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrSecretNotFound
+			}
+			return nil, fmt.Errorf("failed to get secret: %w", err)
+		}
+		return &secret, nil
+	}
+
+	if prefix, name, ok := strings.Cut(importID, ":"); ok {
+		if namespaceID, err := uuid.Parse(prefix); err == nil {
+			return s.findByName(userID, &namespaceID, name)
+		}
+	}
+	return s.findByName(userID, nil, importID)
+}
+
+func (s *ProviderService) findByName(userID uuid.UUID, namespaceID *uuid.UUID, name string) (*model.Secret, error) {
+	query := s.db.Where("user_id = ? AND mount = ? AND name = ? AND is_active = ?", userID, model.DefaultKVMount, name, true)
+	if namespaceID != nil {
+		query = query.Where("namespace_id = ?", *namespaceID)
+	} else {
+		query = query.Where("namespace_id IS NULL")
+	}
+
+	var secrets []model.Secret
+	if err := query.Limit(2).Find(&secrets).Error; err != nil {
+		return nil, fmt.Errorf("failed to get secret: %w", err)
+	}
+	switch len(secrets) {
+	case 0:
+		return nil, ErrSecretNotFound
+	case 1:
+		return &secrets[0], nil
+	default:
+		return nil, ErrProviderSecretAmbiguous
+	}
+}
+
+func (s *ProviderService) matches(secret *model.Secret, req *model.ProviderSecretRequest) bool {
+	if (secret.ExpiresAt == nil) != (req.ExpiresAt == nil) {
+		return false
+	}
+	if secret.ExpiresAt != nil && secret.ExpiresAt.UnixMicro() != req.ExpiresAt.UnixMicro() {
+		return false
+	}
+	// Tags are stored sorted; an invalid list never matches and fails the write
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		return false
+	}
+	return secret.Description == req.Description &&
+		secret.Type == req.Type &&
+		secret.Tags == tags &&
+		secret.ClientEncrypted == req.ClientEncrypted &&
+		secret.ValueHash == s.secretService.hashValue(req.Value)
+}
+
+func (s *ProviderService) clearExpiry(id uuid.UUID) (*model.Secret, error) {
+	var secret model.Secret
+	if err := s.db.Where("id = ?", id).First(&secret).Error; err != nil {
+		return nil, fmt.Errorf("failed to get secret: %w", err)
+	}
+
+	// The checksum covers expires_at
+	secret.ExpiresAt = nil
+	s.secretService.stampChecksum(&secret)
+	if err := s.db.Model(&secret).Updates(map[string]interface{}{
+		"expires_at": nil,
+		"checksum":   secret.Checksum,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to update secret: %w", err)
+	}
+	return &secret, nil
+}
+
+func (s *ProviderService) response(secret *model.Secret) model.ProviderSecretResponse {
+	return model.ProviderSecretResponse{
+		ImportID:        secret.ID.String(),
+		ID:              secret.ID,
+		Name:            secret.Name,
+		Description:     secret.Description,
+		Type:            secret.Type,
+		Tags:            secret.Tags,
+		ExpiresAt:       secret.ExpiresAt,
+		NamespaceID:     secret.NamespaceID,
+		ClientEncrypted: secret.ClientEncrypted,
+		ContentHash:     s.contentHash(secret),
+		RotatedAt:       secret.RotatedAt,
+		UpdatedAt:       secret.UpdatedAt,
+	}
+}
+
+// contentHash covers every field a provider declares, with the value
+// represented by its stored hash
+func (s *ProviderService) contentHash(secret *model.Secret) string {
+	mac := hmac.New(sha256.New, s.hashKey)
+	field := func(value string) {
+		var length [8]byte
+		binary.BigEndian.PutUint64(length[:], uint64(len(value)))
+		mac.Write(length[:])
+		mac.Write([]byte(value))
+	}
+
+	field("v1")
+	field(secret.Name)
+	if secret.NamespaceID != nil {
+		field(secret.NamespaceID.String())
+	} else {
+		field("")
+	}
+	field(secret.Description)
+	field(string(secret.Type))
+	field(secret.Tags)
+	if secret.ExpiresAt != nil {
+		field(strconv.FormatInt(secret.ExpiresAt.UnixMicro(), 10))
+	} else {
+		field("")
+	}
+	field(strconv.FormatBool(secret.ClientEncrypted))
+	field(secret.ValueHash)
+
+	return "hmac-sha256:" + hex.EncodeToString(mac.Sum(nil))
+}
+
+var (
+	ErrProviderSecretAmbiguous = errors.New("more than one secret has this name; import it by ID")
+)
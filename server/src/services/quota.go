@@ -0,0 +1,529 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/config"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// quotaFlushInterval bounds how long request counts stay in memory
+	quotaFlushInterval = time.Minute
+	// quotaTokenWindow is how far back issued identity tokens are counted
+	quotaTokenWindow = 24 * time.Hour
+	// quotaForecastWindow is the span of samples growth is averaged over
+	quotaForecastWindow = 7 * 24 * time.Hour
+	// quotaForecastMinSpan is the shortest span a forecast is made from
+	quotaForecastMinSpan = time.Hour
+)
+
+// QuotaService measures per-namespace usage, keeps samples of it for
+// dashboards and capacity forecasts, and warns when a namespace nears a
+// soft quota. Quotas never reject requests.
+type QuotaService struct {
+	db            *gorm.DB
+	auditService  *AuditService
+	notifications *NotificationService
+	config        config.QuotasConfig
+
+	mu      sync.Mutex
+	pending map[uuid.UUID]int64
+}
+
+func NewQuotaService(db *gorm.DB, auditService *AuditService, notifications *NotificationService, cfg config.QuotasConfig) *QuotaService {
+	return &QuotaService{
+		db:            db,
+		auditService:  auditService,
+		notifications: notifications,
+		config:        cfg,
+		pending:       make(map[uuid.UUID]int64),
+	}
+}
+
+// RecordRequest counts one request to a namespace. It is safe to call on
+// a nil QuotaService.
+func (s *QuotaService) RecordRequest(namespaceID uuid.UUID) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.pending[namespaceID]++
+	s.mu.Unlock()
+}
+
+// Start writes the request counters every quotaFlushInterval
+func (s *QuotaService) Start() {
+	go func() {
+		ticker := time.NewTicker(quotaFlushInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := s.Flush(); err != nil {
+				log.Printf("⚠️  Failed to persist namespace request counts: %v", err)
+			}
+		}
+	}()
+}
+
+// Flush adds the requests counted since the last flush to the current
+// minute
+func (s *QuotaService) Flush() error {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = make(map[uuid.UUID]int64)
+	s.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	minute := time.Now().UTC().Truncate(time.Minute)
+	rows := make([]model.NamespaceRequestCount, 0, len(pending))
+	for namespaceID, requests := range pending {
+		rows = append(rows, model.NamespaceRequestCount{NamespaceID: namespaceID, Minute: minute, Requests: requests})
+	}
+	if err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "namespace_id"}, {Name: "minute"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"requests": gorm.Expr("namespace_request_counts.requests + excluded.requests")}),
+	}).CreateInBatches(rows, 500).Error; err != nil {
+		return fmt.Errorf("failed to store namespace request counts: %w", err)
+	}
+	return nil
+}
+
+// GetQuota returns a namespace's soft quotas
+func (s *QuotaService) GetQuota(namespaceID uuid.UUID) (*model.NamespaceQuota, error) {
+	var quota model.NamespaceQuota
+	if err := s.db.First(&quota, "namespace_id = ?", namespaceID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrQuotaNotFound
+		}
+		return nil, fmt.Errorf("failed to get namespace quota: %w", err)
+	}
+	return &quota, nil
+}
+
+// SetQuota replaces a namespace's soft quotas
+func (s *QuotaService) SetQuota(namespaceID uuid.UUID, req *model.SetNamespaceQuotaRequest, userID uuid.UUID) (*model.NamespaceQuota, error) {
+	if _, err := s.namespace(namespaceID); err != nil {
+		return nil, err
+	}
+
+	quota := model.NamespaceQuota{
+		NamespaceID:    namespaceID,
+		MaxSecrets:     req.MaxSecrets,
+		MaxTokens:      req.MaxTokens,
+		MaxLeases:      req.MaxLeases,
+		MaxRequestRate: req.MaxRequestRate,
+		WarnPercent:    req.WarnPercent,
+		UpdatedBy:      &userID,
+	}
+	if err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "namespace_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"max_secrets", "max_tokens", "max_leases", "max_request_rate", "warn_percent", "updated_by", "updated_at"}),
+	}).Create(&quota).Error; err != nil {
+		return nil, fmt.Errorf("failed to store namespace quota: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "namespace_quota_set", "namespace", namespaceID.String(), true,
+			fmt.Sprintf("secrets=%d tokens=%d leases=%d requests=%d warn_percent=%d", quota.MaxSecrets, quota.MaxTokens, quota.MaxLeases, quota.MaxRequestRate, quota.WarnPercent))
+	}
+
+	return s.GetQuota(namespaceID)
+}
+
+// DeleteQuota removes a namespace's soft quotas; raised warnings clear
+// at the next check
+func (s *QuotaService) DeleteQuota(namespaceID uuid.UUID, userID uuid.UUID) error {
+	result := s.db.Delete(&model.NamespaceQuota{}, "namespace_id = ?", namespaceID)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete namespace quota: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrQuotaNotFound
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "namespace_quota_deleted", "namespace", namespaceID.String(), true, "")
+	}
+	return nil
+}
+
+// Usage measures one namespace against its quotas
+func (s *QuotaService) Usage(namespaceID uuid.UUID) (*model.NamespaceUsage, error) {
+	namespace, err := s.namespace(namespaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	samples, err := s.measure([]uuid.UUID{namespaceID})
+	if err != nil {
+		return nil, err
+	}
+	quotas, err := s.quotas([]uuid.UUID{namespaceID})
+	if err != nil {
+		return nil, err
+	}
+	baselines, err := s.baselines([]uuid.UUID{namespaceID})
+	if err != nil {
+		return nil, err
+	}
+
+	usage := s.usage(namespace, samples[namespaceID], quotas[namespaceID], baselines[namespaceID])
+	return &usage, nil
+}
+
+// Dashboard measures every namespace against its quotas
+func (s *QuotaService) Dashboard() ([]model.NamespaceUsage, error) {
+	var namespaces []model.Namespace
+	if err := s.db.Order("name").Find(&namespaces).Error; err != nil {
+		return nil, fmt.Errorf("failed to get namespaces: %w", err)
+	}
+
+	samples, err := s.measure(nil)
+	if err != nil {
+		return nil, err
+	}
+	quotas, err := s.quotas(nil)
+	if err != nil {
+		return nil, err
+	}
+	baselines, err := s.baselines(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	dashboard := make([]model.NamespaceUsage, 0, len(namespaces))
+	for i := range namespaces {
+		id := namespaces[i].ID
+		dashboard = append(dashboard, s.usage(&namespaces[i], samples[id], quotas[id], baselines[id]))
+	}
+	return dashboard, nil
+}
+
+// History returns a namespace's samples of the last days days, oldest
+// first
+func (s *QuotaService) History(namespaceID uuid.UUID, days int) (*model.NamespaceUsageHistory, error) {
+	if _, err := s.namespace(namespaceID); err != nil {
+		return nil, err
+	}
+	if days < 1 || days > s.config.HistoryDays {
+		days = s.config.HistoryDays
+	}
+
+	samples := []model.NamespaceUsageSample{}
+	if err := s.db.Where("namespace_id = ? AND sampled_at >= ?", namespaceID, time.Now().AddDate(0, 0, -days)).
+		Order("sampled_at").Find(&samples).Error; err != nil {
+		return nil, fmt.Errorf("failed to get namespace usage history: %w", err)
+	}
+	return &model.NamespaceUsageHistory{NamespaceID: namespaceID, Days: days, Samples: samples}, nil
+}
+
+// Job samples every namespace each check interval on the job leader
+func (s *QuotaService) Job() *Job {
+	return &Job{
+		Name:        "namespace_quota_check",
+		Description: "Sample namespace usage and warn about namespaces nearing their soft quotas",
+		Interval:    time.Duration(s.config.CheckInterval) * time.Second,
+		Run:         s.check,
+	}
+}
+
+// check stores a sample per namespace, raises a warning for each quota
+// newly crossed and clears the ones usage fell back under
+func (s *QuotaService) check(ctx context.Context) (int64, string, error) {
+	var namespaces []model.Namespace
+	if err := s.db.Find(&namespaces).Error; err != nil {
+		return 0, "", fmt.Errorf("failed to get namespaces: %w", err)
+	}
+
+	samples, err := s.measure(nil)
+	if err != nil {
+		return 0, "", err
+	}
+	quotas, err := s.quotas(nil)
+	if err != nil {
+		return 0, "", err
+	}
+	var alerts []model.NamespaceQuotaAlert
+	if err := s.db.Find(&alerts).Error; err != nil {
+		return 0, "", fmt.Errorf("failed to get namespace quota alerts: %w", err)
+	}
+	raised := make(map[string]bool, len(alerts))
+	for _, alert := range alerts {
+		raised[quotaDedupKey(alert.NamespaceID, alert.Metric)] = true
+	}
+
+	rows := make([]model.NamespaceUsageSample, 0, len(namespaces))
+	var warned, cleared int
+	for i := range namespaces {
+		if err := ctx.Err(); err != nil {
+			return 0, "", err
+		}
+		namespace := &namespaces[i]
+		sample := samples[namespace.ID]
+		if sample == nil {
+			sample = &model.NamespaceUsageSample{NamespaceID: namespace.ID, SampledAt: time.Now().UTC()}
+		}
+		rows = append(rows, *sample)
+
+		usage := s.usage(namespace, sample, quotas[namespace.ID], nil)
+		for _, metric := range usage.Metrics {
+			key := quotaDedupKey(namespace.ID, metric.Metric)
+			switch {
+			case metric.Warning && !raised[key]:
+				if err := s.db.Create(&model.NamespaceQuotaAlert{NamespaceID: namespace.ID, Metric: metric.Metric, RaisedAt: time.Now()}).Error; err != nil {
+					return 0, "", fmt.Errorf("failed to store namespace quota alert: %w", err)
+				}
+				s.warn(namespace, metric)
+				warned++
+			case !metric.Warning && raised[key]:
+				if err := s.db.Delete(&model.NamespaceQuotaAlert{}, "namespace_id = ? AND metric = ?", namespace.ID, metric.Metric).Error; err != nil {
+					return 0, "", fmt.Errorf("failed to delete namespace quota alert: %w", err)
+				}
+				if s.notifications != nil {
+					s.notifications.ClearCondition(key, "usage is below the warning threshold")
+				}
+				cleared++
+			}
+			delete(raised, key)
+		}
+	}
+	// Whatever is left belongs to deleted namespaces
+	for key := range raised {
+		if s.notifications != nil {
+			s.notifications.ClearCondition(key, "namespace deleted")
+		}
+	}
+	if err := s.db.Where("namespace_id NOT IN (?)", s.db.Model(&model.Namespace{}).Select("id")).Delete(&model.NamespaceQuotaAlert{}).Error; err != nil {
+		return 0, "", fmt.Errorf("failed to delete namespace quota alerts: %w", err)
+	}
+
+	if len(rows) > 0 {
+		if err := s.db.CreateInBatches(rows, 500).Error; err != nil {
+			return 0, "", fmt.Errorf("failed to store namespace usage samples: %w", err)
+		}
+	}
+	if err := s.db.Where("sampled_at < ?", time.Now().AddDate(0, 0, -s.config.HistoryDays)).Delete(&model.NamespaceUsageSample{}).Error; err != nil {
+		return 0, "", fmt.Errorf("failed to prune namespace usage samples: %w", err)
+	}
+	if err := s.db.Where("minute < ?", time.Now().Add(-s.requestWindow()-time.Minute)).Delete(&model.NamespaceRequestCount{}).Error; err != nil {
+		return 0, "", fmt.Errorf("failed to prune namespace request counts: %w", err)
+	}
+
+	return int64(len(rows)), fmt.Sprintf("sampled %d namespaces, %d quota warnings raised, %d cleared", len(rows), warned, cleared), nil
+}
+
+// warn publishes namespace.quota_warning for a crossed quota
+func (s *QuotaService) warn(namespace *model.Namespace, metric model.MetricUsage) {
+	if s.notifications == nil {
+		return
+	}
+	data := map[string]interface{}{
+		"summary":      fmt.Sprintf("Namespace %s uses %.0f%% of its %s quota (%g of %d)", namespace.Name, *metric.Percent, metric.Metric, metric.Used, metric.Limit),
+		"dedup_key":    quotaDedupKey(namespace.ID, metric.Metric),
+		"namespace_id": namespace.ID,
+		"namespace":    namespace.Name,
+		"metric":       metric.Metric,
+		"used":         metric.Used,
+		"limit":        metric.Limit,
+		"percent":      *metric.Percent,
+	}
+	if metric.Forecast != nil && metric.Forecast.LimitReachedAt != nil {
+		data["limit_reached_at"] = metric.Forecast.LimitReachedAt
+	}
+	s.notifications.RaiseCondition(model.NewEvent(model.EventNamespaceQuotaWarning, data))
+}
+
+// usage compares a sample with its quota. A baseline sample from the
+// start of the forecast window adds a growth forecast per metric.
+func (s *QuotaService) usage(namespace *model.Namespace, sample *model.NamespaceUsageSample, quota *model.NamespaceQuota, baseline *model.NamespaceUsageSample) model.NamespaceUsage {
+	if sample == nil {
+		sample = &model.NamespaceUsageSample{NamespaceID: namespace.ID, SampledAt: time.Now().UTC()}
+	}
+	warnPercent := s.config.WarnPercent
+	if quota != nil && quota.WarnPercent > 0 {
+		warnPercent = quota.WarnPercent
+	}
+
+	usage := model.NamespaceUsage{NamespaceID: namespace.ID, Name: namespace.Name, Quota: quota, MeasuredAt: sample.SampledAt}
+	for _, metric := range model.QuotaMetrics {
+		entry := model.MetricUsage{Metric: metric, Used: sample.Value(metric), Limit: quota.Limit(metric)}
+		if entry.Limit > 0 {
+			percent := entry.Used * 100 / float64(entry.Limit)
+			entry.Percent = &percent
+			entry.Warning = percent >= float64(warnPercent)
+		}
+		if baseline != nil {
+			entry.Forecast = forecast(baseline.Value(metric), baseline.SampledAt, entry.Used, sample.SampledAt, entry.Limit)
+		}
+		usage.Metrics = append(usage.Metrics, entry)
+	}
+	return usage
+}
+
+// forecast extrapolates linearly from a past value to the current one
+func forecast(past float64, pastAt time.Time, current float64, currentAt time.Time, limit int) *model.QuotaForecast {
+	span := currentAt.Sub(pastAt)
+	if span < quotaForecastMinSpan {
+		return nil
+	}
+
+	growth := (current - past) / span.Hours() * 24
+	result := &model.QuotaForecast{GrowthPerDay: growth}
+	if limit > 0 && growth > 0 && current < float64(limit) {
+		reachedAt := currentAt.Add(time.Duration((float64(limit) - current) / growth * float64(24*time.Hour)))
+		result.LimitReachedAt = &reachedAt
+	}
+	return result
+}
+
+// measure takes a sample of the given namespaces, or of every namespace
+// with any usage when ids is nil
+func (s *QuotaService) measure(ids []uuid.UUID) (map[uuid.UUID]*model.NamespaceUsageSample, error) {
+	if err := s.Flush(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	samples := make(map[uuid.UUID]*model.NamespaceUsageSample)
+	sample := func(id uuid.UUID) *model.NamespaceUsageSample {
+		if samples[id] == nil {
+			samples[id] = &model.NamespaceUsageSample{NamespaceID: id, SampledAt: now}
+		}
+		return samples[id]
+	}
+	scoped := func(query *gorm.DB, column string) *gorm.DB {
+		if ids != nil {
+			return query.Where(column+" IN ?", ids)
+		}
+		return query
+	}
+
+	type count struct {
+		NamespaceID uuid.UUID
+		Total       int64
+	}
+
+	var secrets []count
+	if err := scoped(s.db.Table("secrets").
+		Select("namespace_id, COUNT(*) AS total").
+		Where("deleted_at IS NULL AND is_active = ? AND namespace_id IS NOT NULL", true), "namespace_id").
+		Group("namespace_id").Scan(&secrets).Error; err != nil {
+		return nil, fmt.Errorf("failed to count namespace secrets: %w", err)
+	}
+	for _, row := range secrets {
+		sample(row.NamespaceID).Secrets = row.Total
+	}
+
+	var leases []count
+	if err := scoped(s.db.Table("share_links").
+		Select("secrets.namespace_id, COUNT(*) AS total").
+		Joins("JOIN secrets ON secrets.id = share_links.secret_id").
+		Where("secrets.namespace_id IS NOT NULL AND share_links.consumed_at IS NULL AND share_links.expires_at > ?", now), "secrets.namespace_id").
+		Group("secrets.namespace_id").Scan(&leases).Error; err != nil {
+		return nil, fmt.Errorf("failed to count namespace leases: %w", err)
+	}
+	for _, row := range leases {
+		sample(row.NamespaceID).Leases = row.Total
+	}
+
+	var tokens []count
+	if err := scoped(s.db.Table("audit_logs").
+		Select("namespace_role_bindings.namespace_id, COUNT(DISTINCT audit_logs.id) AS total").
+		Joins("JOIN namespace_role_bindings ON namespace_role_bindings.user_id = audit_logs.user_id").
+		Where("audit_logs.action = ? AND audit_logs.success = ? AND audit_logs.created_at > ?", "identity_token_issued", true, now.Add(-quotaTokenWindow)), "namespace_role_bindings.namespace_id").
+		Group("namespace_role_bindings.namespace_id").Scan(&tokens).Error; err != nil {
+		return nil, fmt.Errorf("failed to count namespace tokens: %w", err)
+	}
+	for _, row := range tokens {
+		sample(row.NamespaceID).Tokens = row.Total
+	}
+
+	window := s.requestWindow()
+	var requests []count
+	if err := scoped(s.db.Model(&model.NamespaceRequestCount{}).
+		Select("namespace_id, SUM(requests) AS total").
+		Where("minute >= ?", now.Add(-window)), "namespace_id").
+		Group("namespace_id").Scan(&requests).Error; err != nil {
+		return nil, fmt.Errorf("failed to count namespace requests: %w", err)
+	}
+	for _, row := range requests {
+		sample(row.NamespaceID).RequestRate = float64(row.Total) / window.Minutes()
+	}
+
+	for _, id := range ids {
+		sample(id)
+	}
+	return samples, nil
+}
+
+// quotas returns the quotas of the given namespaces, or all when ids is nil
+func (s *QuotaService) quotas(ids []uuid.UUID) (map[uuid.UUID]*model.NamespaceQuota, error) {
+	query := s.db
+	if ids != nil {
+		query = query.Where("namespace_id IN ?", ids)
+	}
+	var rows []model.NamespaceQuota
+	if err := query.Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get namespace quotas: %w", err)
+	}
+
+	quotas := make(map[uuid.UUID]*model.NamespaceQuota, len(rows))
+	for i := range rows {
+		quotas[rows[i].NamespaceID] = &rows[i]
+	}
+	return quotas, nil
+}
+
+// baselines returns each namespace's oldest sample in the forecast window
+func (s *QuotaService) baselines(ids []uuid.UUID) (map[uuid.UUID]*model.NamespaceUsageSample, error) {
+	query := s.db.Table("namespace_usage_samples").
+		Select("DISTINCT ON (namespace_id) *").
+		Where("sampled_at >= ?", time.Now().Add(-quotaForecastWindow))
+	if ids != nil {
+		query = query.Where("namespace_id IN ?", ids)
+	}
+	var rows []model.NamespaceUsageSample
+	if err := query.Order("namespace_id, sampled_at").Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get namespace usage samples: %w", err)
+	}
+
+	baselines := make(map[uuid.UUID]*model.NamespaceUsageSample, len(rows))
+	for i := range rows {
+		baselines[rows[i].NamespaceID] = &rows[i]
+	}
+	return baselines, nil
+}
+
+func (s *QuotaService) namespace(id uuid.UUID) (*model.Namespace, error) {
+	var namespace model.Namespace
+	if err := s.db.First(&namespace, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNamespaceNotFound
+		}
+		return nil, fmt.Errorf("failed to get namespace: %w", err)
+	}
+	return &namespace, nil
+}
+
+func (s *QuotaService) requestWindow() time.Duration {
+	return time.Duration(s.config.RequestWindow) * time.Second
+}
+
+func quotaDedupKey(namespaceID uuid.UUID, metric model.QuotaMetric) string {
+	return "namespace_quota/" + namespaceID.String() + "/" + string(metric)
+}
+
+var (
+	ErrQuotaNotFound = errors.New("namespace has no quota")
+)
@@ -0,0 +1,480 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+)
+
+const reminderScanBatchSize = 200
+
+// ReminderService scans secrets against reminder policies and notifies
+// owners and subscribers about upcoming expiry and overdue rotation
+type ReminderService struct {
+	db            *gorm.DB
+	notifications *NotificationService
+	emails        *EmailNotifier
+	auditService  *AuditService
+	repeat        time.Duration
+}
+
+func NewReminderService(db *gorm.DB, notifications *NotificationService, emails *EmailNotifier, auditService *AuditService, repeat time.Duration) *ReminderService {
+	return &ReminderService{
+		db:            db,
+		notifications: notifications,
+		emails:        emails,
+		auditService:  auditService,
+		repeat:        repeat,
+	}
+}
+
+func (s *ReminderService) CreatePolicy(req *model.CreateReminderPolicyRequest, userID uuid.UUID) (*model.ReminderPolicy, error) {
+	pattern := strings.Trim(req.PathPattern, "/")
+	if pattern == "" {
+		return nil, fmt.Errorf("%w: path_pattern is required", ErrReminderPolicyInvalid)
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrReminderPolicyInvalid, err)
+	}
+	if req.ExpiryWarnDays == 0 && req.RotationDays == 0 {
+		return nil, fmt.Errorf("%w: set expiry_warn_days, rotation_days or both", ErrReminderPolicyInvalid)
+	}
+
+	var count int64
+	if err := s.db.Model(&model.ReminderPolicy{}).Where("path_pattern = ?", pattern).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("failed to check reminder policy: %w", err)
+	}
+	if count > 0 {
+		return nil, ErrReminderPolicyExists
+	}
+
+	policy := &model.ReminderPolicy{
+		PathPattern:    pattern,
+		Description:    req.Description,
+		ExpiryWarnDays: req.ExpiryWarnDays,
+		RotationDays:   req.RotationDays,
+		Webhook:        req.Webhook == nil || *req.Webhook,
+		NotifyOwner:    req.NotifyOwner == nil || *req.NotifyOwner,
+		Emails:         strings.Join(req.Emails, ","),
+		CreatedBy:      userID,
+	}
+
+	if err := s.db.Create(policy).Error; err != nil {
+		return nil, fmt.Errorf("failed to create reminder policy: %w", err)
+	}
+	policy.EmailList = splitEmails(policy.Emails)
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "reminder_policy_created", "reminder_policy", policy.ID.String(), true, fmt.Sprintf("pattern=%s", pattern))
+	}
+
+	return policy, nil
+}
+
+func (s *ReminderService) GetPolicies() ([]model.ReminderPolicy, error) {
+	var policies []model.ReminderPolicy
+	if err := s.db.Order("path_pattern").Find(&policies).Error; err != nil {
+		return nil, fmt.Errorf("failed to get reminder policies: %w", err)
+	}
+
+	for i := range policies {
+		policies[i].EmailList = splitEmails(policies[i].Emails)
+	}
+
+	return policies, nil
+}
+
+func (s *ReminderService) DeletePolicy(id uuid.UUID, userID uuid.UUID) error {
+	result := s.db.Delete(&model.ReminderPolicy{}, "id = ?", id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete reminder policy: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrReminderPolicyNotFound
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "reminder_policy_deleted", "reminder_policy", id.String(), true, "")
+	}
+
+	return nil
+}
+
+// GetReminders lists reminders on the user's secrets. An empty status
+// returns every reminder that is not resolved.
+func (s *ReminderService) GetReminders(userID uuid.UUID, status model.ReminderStatus) ([]model.Reminder, error) {
+	query := s.db.Joins("JOIN secrets ON secrets.id = reminders.secret_id").
+		Where("secrets.user_id = ?", userID)
+	if status != "" {
+		query = query.Where("reminders.status = ?", status)
+	} else {
+		query = query.Where("reminders.status <> ?", model.ReminderStatusResolved)
+	}
+
+	var reminders []model.Reminder
+	if err := query.Order("reminders.due_at").Find(&reminders).Error; err != nil {
+		return nil, fmt.Errorf("failed to get reminders: %w", err)
+	}
+
+	return reminders, nil
+}
+
+// Acknowledge silences a reminder for its current due date. A new due date,
+// such as the next rotation, opens a new reminder.
+func (s *ReminderService) Acknowledge(id uuid.UUID, userID uuid.UUID) (*model.Reminder, error) {
+	reminder, err := s.getOwnedReminder(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if reminder.Status == model.ReminderStatusResolved {
+		return nil, ErrReminderClosed
+	}
+
+	now := time.Now()
+	if err := s.db.Model(reminder).Updates(map[string]interface{}{
+		"status":          model.ReminderStatusAcknowledged,
+		"acknowledged_by": userID,
+		"acknowledged_at": now,
+		"snoozed_until":   nil,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to acknowledge reminder: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "reminder_acknowledged", "secret", reminder.SecretID.String(), true, fmt.Sprintf("kind=%s", reminder.Kind))
+	}
+
+	return s.getOwnedReminder(id, userID)
+}
+
+// Snooze holds back an open reminder for the given number of hours
+func (s *ReminderService) Snooze(id uuid.UUID, hours int, userID uuid.UUID) (*model.Reminder, error) {
+	reminder, err := s.getOwnedReminder(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if reminder.Status != model.ReminderStatusOpen {
+		return nil, ErrReminderClosed
+	}
+
+	until := time.Now().Add(time.Duration(hours) * time.Hour)
+	if err := s.db.Model(reminder).Update("snoozed_until", until).Error; err != nil {
+		return nil, fmt.Errorf("failed to snooze reminder: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "reminder_snoozed", "secret", reminder.SecretID.String(), true, fmt.Sprintf("kind=%s hours=%d", reminder.Kind, hours))
+	}
+
+	return s.getOwnedReminder(id, userID)
+}
+
+// Start scans immediately and then every interval for the life of the
+// process
+func (s *ReminderService) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if notified, err := s.Scan(time.Now()); err != nil {
+				log.Printf("⚠️  Reminder scan failed: %v", err)
+			} else if notified > 0 {
+				log.Printf("🔔 Sent %d secret reminders", notified)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// Scan opens reminders for secrets that are due, resolves reminders that
+// no longer apply and sends notifications. It returns how many reminders
+// were delivered.
+func (s *ReminderService) Scan(now time.Time) (int, error) {
+	policies, err := s.GetPolicies()
+	if err != nil {
+		return 0, err
+	}
+
+	// Longer patterns are more specific
+	sort.SliceStable(policies, func(i, j int) bool {
+		return len(policies[i].PathPattern) > len(policies[j].PathPattern)
+	})
+
+	var tracked []model.Reminder
+	if err := s.db.Where("status <> ?", model.ReminderStatusResolved).Find(&tracked).Error; err != nil {
+		return 0, fmt.Errorf("failed to load reminders: %w", err)
+	}
+	bySecret := make(map[uuid.UUID][]model.Reminder)
+	for _, reminder := range tracked {
+		bySecret[reminder.SecretID] = append(bySecret[reminder.SecretID], reminder)
+	}
+
+	notified := 0
+	var secrets []model.Secret
+	err = s.db.Select("id", "user_id", "name", "expires_at", "rotated_at", "created_at").
+		Where("is_active = ?", true).
+		FindInBatches(&secrets, reminderScanBatchSize, func(tx *gorm.DB, batch int) error {
+			for i := range secrets {
+				secret := &secrets[i]
+				policy := matchReminderPolicy(policies, secret.Name)
+
+				for _, kind := range []model.ReminderKind{model.ReminderKindExpiry, model.ReminderKindRotation} {
+					reminder, err := s.track(secret, policy, kind, reminderDue(policy, secret, kind, now), bySecret[secret.ID])
+					if err != nil {
+						return err
+					}
+					if reminder == nil || !s.shouldNotify(reminder, now) {
+						continue
+					}
+
+					if err := s.notify(reminder, policy, secret, now); err != nil {
+						log.Printf("⚠️  Failed to send %s reminder for secret %s: %v", kind, secret.ID, err)
+						continue
+					}
+					notified++
+				}
+
+				delete(bySecret, secret.ID)
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return notified, fmt.Errorf("failed to scan secrets: %w", err)
+	}
+
+	// Whatever is left belongs to deleted or deactivated secrets
+	var orphaned []uuid.UUID
+	for secretID := range bySecret {
+		orphaned = append(orphaned, secretID)
+	}
+	if len(orphaned) > 0 {
+		if err := s.db.Model(&model.Reminder{}).
+			Where("secret_id IN ? AND status <> ?", orphaned, model.ReminderStatusResolved).
+			Update("status", model.ReminderStatusResolved).Error; err != nil {
+			return notified, fmt.Errorf("failed to resolve reminders: %w", err)
+		}
+	}
+
+	return notified, nil
+}
+
+// track returns the reminder for due, creating or reopening it, and
+// resolves reminders of the same kind that point at another due date
+func (s *ReminderService) track(secret *model.Secret, policy *model.ReminderPolicy, kind model.ReminderKind, due *time.Time, existing []model.Reminder) (*model.Reminder, error) {
+	var current *model.Reminder
+	var stale []uuid.UUID
+	for i := range existing {
+		if existing[i].Kind != kind {
+			continue
+		}
+		if due != nil && existing[i].DueAt.Equal(*due) {
+			current = &existing[i]
+			continue
+		}
+		stale = append(stale, existing[i].ID)
+	}
+
+	if len(stale) > 0 {
+		if err := s.db.Model(&model.Reminder{}).Where("id IN ?", stale).
+			Update("status", model.ReminderStatusResolved).Error; err != nil {
+			return nil, fmt.Errorf("failed to resolve reminders: %w", err)
+		}
+	}
+
+	if due == nil || current != nil {
+		return current, nil
+	}
+
+	var reminder model.Reminder
+	err := s.db.Where("secret_id = ? AND kind = ? AND due_at = ?", secret.ID, kind, *due).First(&reminder).Error
+	if err == nil {
+		if err := s.db.Model(&reminder).Updates(map[string]interface{}{
+			"status":    model.ReminderStatusOpen,
+			"policy_id": policy.ID,
+		}).Error; err != nil {
+			return nil, fmt.Errorf("failed to reopen reminder: %w", err)
+		}
+		return &reminder, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get reminder: %w", err)
+	}
+
+	reminder = model.Reminder{
+		SecretID:   secret.ID,
+		Kind:       kind,
+		DueAt:      *due,
+		PolicyID:   policy.ID,
+		SecretName: secret.Name,
+		Status:     model.ReminderStatusOpen,
+	}
+	if err := s.db.Create(&reminder).Error; err != nil {
+		return nil, fmt.Errorf("failed to create reminder: %w", err)
+	}
+
+	return &reminder, nil
+}
+
+func (s *ReminderService) shouldNotify(reminder *model.Reminder, now time.Time) bool {
+	if reminder.Status != model.ReminderStatusOpen {
+		return false
+	}
+	if reminder.SnoozedUntil != nil {
+		return !now.Before(*reminder.SnoozedUntil)
+	}
+	return reminder.LastNotifiedAt == nil || now.Sub(*reminder.LastNotifiedAt) >= s.repeat
+}
+
+func (s *ReminderService) notify(reminder *model.Reminder, policy *model.ReminderPolicy, secret *model.Secret, now time.Time) error {
+	eventType := model.EventSecretRotationOverdue
+	summary := fmt.Sprintf("Secret %s is overdue for rotation", secret.Name)
+	if reminder.Kind == model.ReminderKindExpiry {
+		eventType = model.EventSecretExpiring
+		summary = fmt.Sprintf("Secret %s expires soon", secret.Name)
+		if !reminder.DueAt.After(now) {
+			eventType = model.EventSecretExpired
+			summary = fmt.Sprintf("Secret %s has expired", secret.Name)
+		}
+	}
+
+	event := model.NewEvent(eventType, map[string]interface{}{
+		"reminder_id": reminder.ID,
+		"secret_id":   secret.ID,
+		"secret_name": secret.Name,
+		"kind":        reminder.Kind,
+		"due_at":      reminder.DueAt,
+		"policy_id":   policy.ID,
+	})
+
+	var errs []error
+	if policy.Webhook {
+		if err := s.notifications.Publish(event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	data := map[string]interface{}{
+		"Summary":    summary,
+		"SecretName": secret.Name,
+		"SecretID":   secret.ID,
+		"Kind":       reminder.Kind,
+		"DueAt":      reminder.DueAt.UTC().Format(time.RFC3339),
+		"ReminderID": reminder.ID,
+	}
+	if policy.NotifyOwner {
+		if err := s.emails.NotifyUser(secret.UserID, model.NotificationCategoryReminders, EmailTemplateReminder, data); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(policy.EmailList) > 0 && s.emails.Enabled() {
+		if err := s.emails.Send(policy.EmailList, EmailTemplateReminder, data); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	if err := s.db.Model(reminder).Updates(map[string]interface{}{
+		"last_notified_at": now,
+		"notify_count":     gorm.Expr("notify_count + 1"),
+		"snoozed_until":    nil,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to record reminder delivery: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(secret.UserID, "reminder_sent", "secret", secret.ID.String(), true, fmt.Sprintf("kind=%s event=%s", reminder.Kind, eventType))
+	}
+
+	return nil
+}
+
+func (s *ReminderService) getOwnedReminder(id uuid.UUID, userID uuid.UUID) (*model.Reminder, error) {
+	var reminder model.Reminder
+	if err := s.db.Joins("JOIN secrets ON secrets.id = reminders.secret_id").
+		Where("reminders.id = ? AND secrets.user_id = ?", id, userID).
+		First(&reminder).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReminderNotFound
+		}
+		return nil, fmt.Errorf("failed to get reminder: %w", err)
+	}
+
+	return &reminder, nil
+}
+
+// matchReminderPolicy returns the most specific policy covering name;
+// policies must already be sorted longest pattern first
+func matchReminderPolicy(policies []model.ReminderPolicy, name string) *model.ReminderPolicy {
+	name = strings.Trim(name, "/")
+	for i := range policies {
+		if matchTemplatePattern(policies[i].PathPattern, name) {
+			return &policies[i]
+		}
+	}
+	return nil
+}
+
+// reminderDue returns the due date a reminder of kind should track, or nil
+// when the secret is not (yet) due. Due dates are truncated to the second so
+// they compare equal after a database round trip.
+func reminderDue(policy *model.ReminderPolicy, secret *model.Secret, kind model.ReminderKind, now time.Time) *time.Time {
+	if policy == nil {
+		return nil
+	}
+
+	var due time.Time
+	switch kind {
+	case model.ReminderKindExpiry:
+		if policy.ExpiryWarnDays == 0 || secret.ExpiresAt == nil {
+			return nil
+		}
+		due = *secret.ExpiresAt
+		if now.Before(due.AddDate(0, 0, -policy.ExpiryWarnDays)) {
+			return nil
+		}
+	case model.ReminderKindRotation:
+		if policy.RotationDays == 0 {
+			return nil
+		}
+		rotatedAt := secret.CreatedAt
+		if secret.RotatedAt != nil {
+			rotatedAt = *secret.RotatedAt
+		}
+		due = rotatedAt.AddDate(0, 0, policy.RotationDays)
+		if now.Before(due) {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	due = due.UTC().Truncate(time.Second)
+	return &due
+}
+
+func splitEmails(emails string) []string {
+	if emails == "" {
+		return []string{}
+	}
+	return strings.Split(emails, ",")
+}
+
+var (
+	ErrReminderNotFound       = errors.New("reminder not found")
+	ErrReminderClosed         = errors.New("reminder is no longer open")
+	ErrReminderPolicyNotFound = errors.New("reminder policy not found")
+	ErrReminderPolicyExists   = errors.New("a reminder policy already covers this path pattern")
+	ErrReminderPolicyInvalid  = errors.New("invalid reminder policy")
+)
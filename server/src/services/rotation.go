@@ -0,0 +1,488 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/config"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	// RotatorPostgres changes the password of the role in a database
+	// credential value
+	RotatorPostgres = "postgres"
+
+	// minRotationPeriod keeps a misconfigured secret from rotating on
+	// every job run
+	minRotationPeriod = 60
+	// rotationBatchSize bounds the rotations of one job run
+	rotationBatchSize = 100
+)
+
+// secretRotator makes the next value of a secret from the current one. It
+// changes the credential wherever it is checked before returning it.
+type secretRotator func(ctx context.Context, secret *model.Secret, value string) (string, error)
+
+// SecretRotationService rotates secrets whose rotation_period has passed
+// with the rotator they name, and announces secrets about to expire or
+// overdue for a rotation nobody automates. Rotators are the built-in
+// "postgres" and the plugins configured under rotation.plugins.
+type SecretRotationService struct {
+	db            *gorm.DB
+	secretService *SecretService
+	notifications *NotificationService
+	auditService  *AuditService
+	config        config.RotationConfig
+	rotators      map[string]secretRotator
+}
+
+func NewSecretRotationService(db *gorm.DB, secretService *SecretService, notifications *NotificationService, auditService *AuditService, cfg *config.RotationConfig) *SecretRotationService {
+	s := &SecretRotationService{
+		db:            db,
+		secretService: secretService,
+		notifications: notifications,
+		auditService:  auditService,
+		config:        *cfg,
+		rotators: map[string]secretRotator{
+			RotatorPostgres: rotatePostgresPassword,
+		},
+	}
+	if cfg.Plugins != "" {
+		for _, plugin := range strings.Split(cfg.Plugins, ",") {
+			name, target, ok := strings.Cut(plugin, "=")
+			if !ok {
+				continue
+			}
+			s.rotators[strings.TrimSpace(name)] = s.pluginRotator(strings.TrimSpace(target))
+		}
+	}
+	return s
+}
+
+// UseRotation lets secrets name the rotators of rotation
+func (s *SecretService) UseRotation(rotation *SecretRotationService) {
+	s.rotation = rotation
+}
+
+// Rotators returns the names secrets may use as rotator
+func (s *SecretRotationService) Rotators() []string {
+	names := make([]string, 0, len(s.rotators))
+	for name := range s.rotators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// checkRotation validates the rotation settings of a secret
+func (s *SecretService) checkRotation(period int, rotator string, clientEncrypted, checkoutRequired bool) error {
+	if period != 0 && period < minRotationPeriod {
+		return fmt.Errorf("%w: rotation_period must be at least %d seconds", ErrSecretRotationInvalid, minRotationPeriod)
+	}
+	// Check-in rotates the value, which takes a rotator
+	if checkoutRequired && rotator == "" {
+		return fmt.Errorf("%w: checkout_required needs a rotator", ErrSecretRotationInvalid)
+	}
+	if rotator == "" {
+		return nil
+	}
+	if period == 0 {
+		return fmt.Errorf("%w: a rotator needs a rotation_period", ErrSecretRotationInvalid)
+	}
+	// The server cannot read, let alone replace, a client-encrypted value
+	if clientEncrypted {
+		return fmt.Errorf("%w: client-encrypted secrets cannot be rotated by the server", ErrSecretRotationInvalid)
+	}
+	if s.rotation == nil {
+		return fmt.Errorf("%w: automatic rotation is not available", ErrSecretRotationInvalid)
+	}
+	if _, ok := s.rotation.rotators[rotator]; !ok {
+		return fmt.Errorf("%w: unknown rotator %q (use %s)", ErrSecretRotationInvalid, rotator, strings.Join(s.rotation.Rotators(), ", "))
+	}
+	return nil
+}
+
+// planRotation sets when secret is next due for rotation, counted from its
+// last value change
+func planRotation(secret *model.Secret) {
+	secret.NextRotationAt = nil
+	if secret.RotationPeriod > 0 && secret.RotatedAt != nil {
+		next := secret.RotatedAt.Add(time.Duration(secret.RotationPeriod) * time.Second)
+		secret.NextRotationAt = &next
+	}
+}
+
+// GetExpiring lists the user's secrets in mount that expire or are due
+// for rotation within the next window, soonest first
+func (s *SecretService) GetExpiring(userID uuid.UUID, mount string, window time.Duration) ([]model.SecretExpiration, error) {
+	now := time.Now()
+	horizon := now.Add(window)
+
+	var secrets []model.Secret
+	if err := s.db.Where("user_id = ? AND mount = ? AND is_active = ?", userID, mount, true).
+		Where("((expires_at IS NOT NULL AND expires_at <= ?) OR (next_rotation_at IS NOT NULL AND next_rotation_at <= ?))", horizon, horizon).
+		Find(&secrets).Error; err != nil {
+		return nil, fmt.Errorf("failed to get expiring secrets: %w", err)
+	}
+
+	expiring := make([]model.SecretExpiration, 0, len(secrets))
+	for _, secret := range secrets {
+		expiring = append(expiring, model.SecretExpiration{
+			ID:              secret.ID,
+			Name:            secret.Name,
+			Mount:           secret.Mount,
+			Type:            secret.Type,
+			ExpiresAt:       secret.ExpiresAt,
+			Expired:         secret.ExpiresAt != nil && !secret.ExpiresAt.After(now),
+			RotationPeriod:  secret.RotationPeriod,
+			Rotator:         secret.Rotator,
+			NextRotationAt:  secret.NextRotationAt,
+			RotationOverdue: secret.NextRotationAt != nil && !secret.NextRotationAt.After(now),
+			RotationError:   secret.RotationError,
+		})
+	}
+	sort.Slice(expiring, func(i, j int) bool {
+		return nextDue(expiring[i]).Before(nextDue(expiring[j]))
+	})
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "secrets_listed", "secret", "", true, "expiring within "+window.String())
+	}
+
+	return expiring, nil
+}
+
+// nextDue returns the earlier of a secret's expiry and rotation date
+func nextDue(expiration model.SecretExpiration) time.Time {
+	due := expiration.ExpiresAt
+	if due == nil || (expiration.NextRotationAt != nil && expiration.NextRotationAt.Before(*due)) {
+		due = expiration.NextRotationAt
+	}
+	if due == nil {
+		return time.Time{}
+	}
+	return *due
+}
+
+// Job rotates due secrets and announces expiring and overdue ones every
+// interval on the job leader
+func (s *SecretRotationService) Job(interval time.Duration) *Job {
+	return &Job{
+		Name:        "secret_rotation",
+		Description: "Rotate secrets past their rotation period and flag secrets about to expire",
+		Interval:    interval,
+		Run:         s.run,
+	}
+}
+
+func (s *SecretRotationService) run(ctx context.Context) (int64, string, error) {
+	now := time.Now()
+
+	rotated, failed, err := s.rotateDue(ctx, now)
+	if err != nil {
+		return rotated, "", err
+	}
+	flagged, err := s.flagDue(ctx, now)
+	if err != nil {
+		return rotated + flagged, "", err
+	}
+
+	return rotated + flagged, fmt.Sprintf("rotated %d secrets (%d failed), flagged %d", rotated, failed, flagged), nil
+}
+
+// rotateDue rotates the secrets whose rotator is due. A failed rotation
+// is recorded on the secret and tried again after retry_interval. Checked
+// out secrets wait for their check-in, which rotates them anyway.
+func (s *SecretRotationService) rotateDue(ctx context.Context, now time.Time) (int64, int64, error) {
+	var due []model.Secret
+	if err := s.db.WithContext(ctx).
+		Where("is_active = ? AND rotator <> '' AND next_rotation_at <= ? AND quarantined_at IS NULL", true, now).
+		Where("NOT EXISTS (SELECT 1 FROM secret_checkouts WHERE secret_checkouts.secret_id = secrets.id AND secret_checkouts.active)").
+		Order("next_rotation_at").Limit(rotationBatchSize).Find(&due).Error; err != nil {
+		return 0, 0, fmt.Errorf("failed to get secrets due for rotation: %w", err)
+	}
+
+	var rotated, failed int64
+	for i := range due {
+		if err := ctx.Err(); err != nil {
+			return rotated, failed, err
+		}
+		if err := s.rotate(ctx, &due[i]); err != nil {
+			failed++
+			if err := s.recordFailure(&due[i], err, now); err != nil {
+				return rotated, failed, err
+			}
+			continue
+		}
+		rotated++
+	}
+	return rotated, failed, nil
+}
+
+// RotateNow rotates a secret out of schedule, as check-in does. A failure
+// is recorded as the job records it, so the job tries again.
+func (s *SecretRotationService) RotateNow(ctx context.Context, id uuid.UUID) error {
+	var secret model.Secret
+	if err := s.db.WithContext(ctx).Where("id = ? AND is_active = ?", id, true).First(&secret).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSecretNotFound
+		}
+		return fmt.Errorf("failed to get secret: %w", err)
+	}
+	if secret.QuarantinedAt != nil {
+		return ErrSecretQuarantined
+	}
+	if err := s.rotate(ctx, &secret); err != nil {
+		if recordErr := s.recordFailure(&secret, err, time.Now()); recordErr != nil {
+			log.Printf("⚠️  %v", recordErr)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *SecretRotationService) rotate(ctx context.Context, secret *model.Secret) error {
+	rotator, ok := s.rotators[secret.Rotator]
+	if !ok {
+		return fmt.Errorf("rotator %q is not configured", secret.Rotator)
+	}
+	current, err := s.secretService.openSecret(secret)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+
+	value, err := rotator(ctx, secret, current)
+	if err != nil {
+		return err
+	}
+	if value == "" || value == current {
+		return errors.New("rotator returned no new value")
+	}
+
+	if _, err := s.secretService.UpdateSecret(secret.ID, &model.UpdateSecretRequest{Value: &value}, secret.UserID); err != nil {
+		// The credential already changed where it is checked; only the
+		// rotator's side knows the new value now
+		log.Printf("🚨 Secret %s was rotated by %s but the new value could not be stored: %v", secret.ID, secret.Rotator, err)
+		return fmt.Errorf("the credential changed but the new value could not be stored: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAnonymousAction("secret_rotated", "secret", secret.ID.String(), "", "", true, "rotator="+secret.Rotator)
+	}
+	if err := s.notifications.Publish(model.NewEvent(model.EventSecretRotated, map[string]interface{}{
+		"secret_id":   secret.ID,
+		"secret_name": secret.Name,
+		"mount":       secret.Mount,
+		"rotator":     secret.Rotator,
+	})); err != nil {
+		log.Printf("⚠️  Failed to publish rotation of secret %s: %v", secret.ID, err)
+	}
+	return nil
+}
+
+func (s *SecretRotationService) recordFailure(secret *model.Secret, cause error, now time.Time) error {
+	retryAt := now.Add(time.Duration(s.config.RetryInterval) * time.Second)
+	if err := s.db.Model(&model.Secret{}).Where("id = ?", secret.ID).UpdateColumns(map[string]interface{}{
+		"rotation_error":   cause.Error(),
+		"next_rotation_at": retryAt,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to record rotation failure: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAnonymousAction("secret_rotated", "secret", secret.ID.String(), "", "", false, "rotator="+secret.Rotator+"; error="+cause.Error())
+	}
+	if err := s.notifications.Publish(model.NewEvent(model.EventSecretRotationFailed, map[string]interface{}{
+		"secret_id":   secret.ID,
+		"secret_name": secret.Name,
+		"mount":       secret.Mount,
+		"rotator":     secret.Rotator,
+		"error":       cause.Error(),
+		"retry_at":    retryAt,
+	})); err != nil {
+		log.Printf("⚠️  Failed to publish rotation failure of secret %s: %v", secret.ID, err)
+	}
+	return nil
+}
+
+// flagDue announces each expiry within expiry_warning and each overdue
+// rotation without a rotator once per due date. Announcements that fail
+// to publish are tried again on the next run.
+func (s *SecretRotationService) flagDue(ctx context.Context, now time.Time) (int64, error) {
+	var flagged int64
+	var errs []error
+
+	if s.config.ExpiryWarning > 0 {
+		var expiring []model.Secret
+		if err := s.db.WithContext(ctx).
+			Where("is_active = ? AND expires_at <= ?", true, now.Add(time.Duration(s.config.ExpiryWarning)*time.Second)).
+			Where("(expiry_warned_for IS NULL OR expiry_warned_for <> expires_at)").
+			Find(&expiring).Error; err != nil {
+			return 0, fmt.Errorf("failed to get expiring secrets: %w", err)
+		}
+		for _, secret := range expiring {
+			eventType := model.EventSecretExpiring
+			if !secret.ExpiresAt.After(now) {
+				eventType = model.EventSecretExpired
+			}
+			if err := s.flag(&secret, eventType, "expiry_warned_for", *secret.ExpiresAt); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			flagged++
+		}
+	}
+
+	var overdue []model.Secret
+	if err := s.db.WithContext(ctx).
+		Where("is_active = ? AND rotator = '' AND next_rotation_at <= ?", true, now).
+		Where("(rotation_warned_for IS NULL OR rotation_warned_for <> next_rotation_at)").
+		Find(&overdue).Error; err != nil {
+		return flagged, fmt.Errorf("failed to get secrets overdue for rotation: %w", err)
+	}
+	for _, secret := range overdue {
+		if err := s.flag(&secret, model.EventSecretRotationOverdue, "rotation_warned_for", *secret.NextRotationAt); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		flagged++
+	}
+
+	return flagged, errors.Join(errs...)
+}
+
+// flag publishes eventType for secret and records due in column so it is
+// not announced again
+func (s *SecretRotationService) flag(secret *model.Secret, eventType model.EventType, column string, due time.Time) error {
+	if err := s.notifications.Publish(model.NewEvent(eventType, map[string]interface{}{
+		"secret_id":   secret.ID,
+		"secret_name": secret.Name,
+		"mount":       secret.Mount,
+		"due_at":      due,
+	})); err != nil {
+		return fmt.Errorf("failed to publish %s for secret %s: %w", eventType, secret.ID, err)
+	}
+	if err := s.db.Model(&model.Secret{}).Where("id = ?", secret.ID).UpdateColumn(column, due).Error; err != nil {
+		return fmt.Errorf("failed to record %s for secret %s: %w", eventType, secret.ID, err)
+	}
+	return nil
+}
+
+// rotatePostgresPassword logs in with the credentials of a JSON value,
+// sets a new password on that role and returns the value carrying it
+func rotatePostgresPassword(ctx context.Context, secret *model.Secret, value string) (string, error) {
+	creds, dsn, err := postgresCredentials(value)
+	if err != nil {
+		return "", err
+	}
+	var fields map[string]interface{}
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.UseNumber()
+	if err := decoder.Decode(&fields); err != nil {
+		return "", errNoDatabaseCredentials
+	}
+
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	password := base64.RawURLEncoding.EncodeToString(buf)
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		return "", fmt.Errorf("login failed: %w", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return "", fmt.Errorf("login failed: %w", err)
+	}
+	defer sqlDB.Close()
+
+	// ALTER ROLE takes no bind parameters; the password is URL-safe base64
+	// and cannot break out of its literal
+	statement := fmt.Sprintf("ALTER ROLE %s WITH PASSWORD '%s'", quotePostgresIdentifier(creds.Username), password)
+	if _, err := sqlDB.ExecContext(ctx, statement); err != nil {
+		return "", fmt.Errorf("failed to change the password: %w", err)
+	}
+
+	fields["password"] = password
+	encoded, err := json.Marshal(fields)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode the new value: %w", err)
+	}
+	return string(encoded), nil
+}
+
+func quotePostgresIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// pluginRotator posts the secret to a rotator plugin, which changes the
+// credential upstream and answers {"value": "<new value>"}
+func (s *SecretRotationService) pluginRotator(target string) secretRotator {
+	return func(ctx context.Context, secret *model.Secret, value string) (string, error) {
+		body, err := json.Marshal(map[string]interface{}{
+			"secret_id": secret.ID,
+			"name":      secret.Name,
+			"mount":     secret.Mount,
+			"type":      secret.Type,
+			"metadata":  secret.Metadata,
+			"value":     value,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to encode plugin request: %w", err)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, time.Duration(s.config.PluginTimeout)*time.Second)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+		if err != nil {
+			return "", errors.New("invalid plugin url")
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if s.config.PluginToken != "" {
+			req.Header.Set("Authorization", "Bearer "+s.config.PluginToken)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			var urlErr *url.Error
+			if errors.As(err, &urlErr) {
+				err = urlErr.Err
+			}
+			return "", fmt.Errorf("plugin request failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			return "", fmt.Errorf("plugin answered %d", resp.StatusCode)
+		}
+		var answer struct {
+			Value string `json:"value"`
+		}
+		if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&answer); err != nil {
+			return "", errors.New("plugin answer is not a JSON object")
+		}
+		return answer.Value, nil
+	}
+}
+
+var ErrSecretRotationInvalid = errors.New("invalid rotation settings")
@@ -0,0 +1,384 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	sandboxDefaultTTL = 24 * time.Hour
+	sandboxMaxTTL     = 7 * 24 * time.Hour
+	// sandboxScrubbedValue replaces every value in scrubbed sandboxes
+	sandboxScrubbedValue = "sandbox-scrubbed"
+	sandboxRandomBytes   = 32
+)
+
+// SandboxService clones a namespace's role bindings, mounts, quota and
+// secret structure into a short-lived sandbox namespace, for testing
+// policy changes and app configuration against realistic structure.
+// Values are scrubbed or randomized, never copied.
+type SandboxService struct {
+	db               *gorm.DB
+	namespaceService *NamespaceService
+	secretService    *SecretService
+	auditService     *AuditService
+	expiry           *ExpiryScheduler
+}
+
+func NewSandboxService(db *gorm.DB, namespaceService *NamespaceService, secretService *SecretService, auditService *AuditService) *SandboxService {
+	return &SandboxService{
+		db:               db,
+		namespaceService: namespaceService,
+		secretService:    secretService,
+		auditService:     auditService,
+	}
+}
+
+// UseExpiry destroys each sandbox as soon as it expires rather than on the
+// next sandbox_expiry run
+func (s *SandboxService) UseExpiry(scheduler *ExpiryScheduler) {
+	s.expiry = scheduler
+	scheduler.Handle(model.ExpiryKindSandbox, s.expireSandbox)
+}
+
+// CreateSandbox clones sourceID into a new sandbox namespace. The caller
+// becomes namespace-admin of the sandbox in addition to the cloned
+// bindings; namespace-bound mounts are cloned under a suffixed path.
+func (s *SandboxService) CreateSandbox(sourceID uuid.UUID, req *model.CreateSandboxRequest, userID uuid.UUID) (*model.SandboxResponse, error) {
+	source, err := s.namespaceService.GetNamespaceByID(sourceID)
+	if err != nil {
+		return nil, err
+	}
+	if source.SandboxOf != nil {
+		return nil, ErrSandboxNested
+	}
+
+	ttl := sandboxDefaultTTL
+	if req.TTL > 0 {
+		ttl = time.Duration(req.TTL) * time.Second
+	}
+	if ttl > sandboxMaxTTL {
+		return nil, fmt.Errorf("%w: requested %ds, maximum %ds", ErrSandboxTTLTooLong, req.TTL, int(sandboxMaxTTL.Seconds()))
+	}
+
+	values := req.Values
+	if values == "" {
+		values = model.SandboxValuesScrubbed
+	}
+	if values != model.SandboxValuesScrubbed && values != model.SandboxValuesRandom {
+		return nil, ErrSandboxInvalidValues
+	}
+
+	id := uuid.New()
+	suffix := strings.ReplaceAll(id.String(), "-", "")[:8]
+	name := strings.Trim(strings.ToLower(req.Name), "/")
+	if name == "" {
+		name = source.Name + "/sandbox-" + suffix
+	}
+	if !namespaceNamePattern.MatchString(name) {
+		return nil, ErrNamespaceInvalidName
+	}
+
+	expiresAt := time.Now().Add(ttl)
+	sandbox := &model.Namespace{
+		ID:          id,
+		Name:        name,
+		Description: fmt.Sprintf("Sandbox of %s", source.Name),
+		CreatedBy:   userID,
+		SandboxOf:   &source.ID,
+		ExpiresAt:   &expiresAt,
+	}
+	response := &model.SandboxResponse{SourceID: source.ID, ExpiresAt: expiresAt, Mounts: []string{}}
+	if !req.SkipSecrets {
+		response.Values = values
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(sandbox)
+		if result.Error != nil {
+			return fmt.Errorf("failed to create sandbox: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrSandboxNameTaken
+		}
+
+		bindings, err := s.cloneBindings(tx, source.ID, sandbox.ID, userID)
+		if err != nil {
+			return err
+		}
+		response.Bindings = bindings
+
+		mounts, err := s.cloneMounts(tx, source.ID, sandbox.ID, suffix, userID)
+		if err != nil {
+			return err
+		}
+		for _, path := range mounts {
+			response.Mounts = append(response.Mounts, path)
+		}
+		sort.Strings(response.Mounts)
+
+		var quota model.NamespaceQuota
+		if err := tx.First(&quota, "namespace_id = ?", source.ID).Error; err == nil {
+			quota.NamespaceID = sandbox.ID
+			quota.UpdatedBy = &userID
+			if err := tx.Create(&quota).Error; err != nil {
+				return fmt.Errorf("failed to clone namespace quota: %w", err)
+			}
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to get namespace quota: %w", err)
+		}
+
+		if req.SkipSecrets {
+			return nil
+		}
+		cloned, err := s.cloneSecrets(tx, source.ID, sandbox.ID, mounts, values)
+		if err != nil {
+			return err
+		}
+		response.Secrets = cloned
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	response.Namespace = *sandbox
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "namespace_sandbox_created", "namespace", sandbox.ID.String(), true,
+			fmt.Sprintf("source=%s name=%s values=%s secrets=%d bindings=%d mounts=%d expires_at=%s", source.ID, name, response.Values, response.Secrets, response.Bindings, len(response.Mounts), expiresAt.UTC().Format(time.RFC3339)))
+	}
+
+	if err := s.expiry.Schedule(model.ExpiryKindSandbox, sandbox.ID.String(), expiresAt); err != nil {
+		log.Printf("⚠️  Failed to schedule sandbox %s expiry: %v", sandbox.ID, err)
+	}
+
+	return response, nil
+}
+
+// GetSandboxes lists the live sandboxes of a namespace
+func (s *SandboxService) GetSandboxes(sourceID uuid.UUID) ([]model.Namespace, error) {
+	if _, err := s.namespaceService.GetNamespaceByID(sourceID); err != nil {
+		return nil, err
+	}
+
+	sandboxes := []model.Namespace{}
+	if err := s.db.Where("sandbox_of = ?", sourceID).Order("expires_at").Find(&sandboxes).Error; err != nil {
+		return nil, fmt.Errorf("failed to get sandboxes: %w", err)
+	}
+	return sandboxes, nil
+}
+
+// DestroySandbox removes a sandbox and everything in it before it expires
+func (s *SandboxService) DestroySandbox(id uuid.UUID, userID uuid.UUID) error {
+	sandbox, err := s.namespaceService.GetNamespaceByID(id)
+	if err != nil {
+		return err
+	}
+	if sandbox.SandboxOf == nil {
+		return ErrNotSandbox
+	}
+
+	if err := s.destroy(sandbox); err != nil {
+		return err
+	}
+	if err := s.expiry.Cancel(model.ExpiryKindSandbox, id.String()); err != nil {
+		log.Printf("⚠️  Failed to cancel sandbox %s expiry: %v", id, err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "namespace_sandbox_destroyed", "namespace", id.String(), true, "name="+sandbox.Name)
+	}
+	return nil
+}
+
+// Job destroys expired sandboxes every interval on the job leader
+func (s *SandboxService) Job(interval time.Duration) *Job {
+	return &Job{
+		Name:        "sandbox_expiry",
+		Description: "Destroy namespace sandboxes past their expiry",
+		Interval:    interval,
+		Run:         s.expire,
+	}
+}
+
+func (s *SandboxService) expire(ctx context.Context) (int64, string, error) {
+	var expired []model.Namespace
+	if err := s.db.Where("sandbox_of IS NOT NULL AND expires_at < ?", time.Now()).Find(&expired).Error; err != nil {
+		return 0, "", fmt.Errorf("failed to get expired sandboxes: %w", err)
+	}
+
+	var destroyed int64
+	for i := range expired {
+		if err := ctx.Err(); err != nil {
+			return destroyed, "", err
+		}
+		if err := s.destroyExpired(&expired[i]); err != nil {
+			return destroyed, "", err
+		}
+		destroyed++
+	}
+
+	return destroyed, fmt.Sprintf("destroyed %d expired sandboxes", destroyed), nil
+}
+
+// expireSandbox is the expiry timer of one sandbox
+func (s *SandboxService) expireSandbox(ctx context.Context, item string) error {
+	var sandbox model.Namespace
+	if err := s.db.WithContext(ctx).Where("id = ? AND sandbox_of IS NOT NULL", item).First(&sandbox).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to get sandbox: %w", err)
+	}
+	if sandbox.ExpiresAt != nil && sandbox.ExpiresAt.After(time.Now()) {
+		return s.expiry.Schedule(model.ExpiryKindSandbox, item, *sandbox.ExpiresAt)
+	}
+	return s.destroyExpired(&sandbox)
+}
+
+func (s *SandboxService) destroyExpired(sandbox *model.Namespace) error {
+	if err := s.destroy(sandbox); err != nil {
+		return err
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAnonymousAction("namespace_sandbox_expired", "namespace", sandbox.ID.String(), "", "", true, "name="+sandbox.Name)
+	}
+	return nil
+}
+
+// destroy permanently deletes a sandbox's secrets, with their versions and
+// share links, its mounts, bindings, keys and quota, then the namespace
+func (s *SandboxService) destroy(sandbox *model.Namespace) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		secrets := tx.Unscoped().Model(&model.Secret{}).Select("id").Where("namespace_id = ?", sandbox.ID)
+		for _, step := range []struct {
+			what  string
+			model interface{}
+			query string
+			arg   interface{}
+		}{
+			{"secret versions", &model.SecretVersion{}, "secret_id IN (?)", secrets},
+			{"share links", &model.ShareLink{}, "secret_id IN (?)", secrets},
+			{"secret access statistics", &model.SecretAccessStat{}, "secret_id IN (?)", secrets},
+			{"secrets", &model.Secret{}, "namespace_id = ?", sandbox.ID},
+			{"mounts", &model.Mount{}, "namespace_id = ?", sandbox.ID},
+			{"role bindings", &model.NamespaceRoleBinding{}, "namespace_id = ?", sandbox.ID},
+			{"tenant key", &model.TenantKey{}, "namespace_id = ?", sandbox.ID},
+			{"quota", &model.NamespaceQuota{}, "namespace_id = ?", sandbox.ID},
+			{"quota alerts", &model.NamespaceQuotaAlert{}, "namespace_id = ?", sandbox.ID},
+			{"usage samples", &model.NamespaceUsageSample{}, "namespace_id = ?", sandbox.ID},
+			{"request counts", &model.NamespaceRequestCount{}, "namespace_id = ?", sandbox.ID},
+			{"namespace", &model.Namespace{}, "id = ?", sandbox.ID},
+		} {
+			if err := tx.Unscoped().Where(step.query, step.arg).Delete(step.model).Error; err != nil {
+				return fmt.Errorf("failed to delete sandbox %s: %w", step.what, err)
+			}
+		}
+		return nil
+	})
+}
+
+// cloneBindings copies the source's role bindings and makes the caller
+// namespace-admin
+func (s *SandboxService) cloneBindings(tx *gorm.DB, sourceID, sandboxID, userID uuid.UUID) (int, error) {
+	var bindings []model.NamespaceRoleBinding
+	if err := tx.Where("namespace_id = ?", sourceID).Find(&bindings).Error; err != nil {
+		return 0, fmt.Errorf("failed to get role bindings: %w", err)
+	}
+
+	clones := make([]model.NamespaceRoleBinding, 0, len(bindings)+1)
+	for _, binding := range bindings {
+		clones = append(clones, model.NamespaceRoleBinding{NamespaceID: sandboxID, UserID: binding.UserID, Role: binding.Role, GrantedBy: userID})
+	}
+	clones = append(clones, model.NamespaceRoleBinding{NamespaceID: sandboxID, UserID: userID, Role: model.NamespaceRoleAdmin, GrantedBy: userID})
+
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&clones)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to clone role bindings: %w", result.Error)
+	}
+	return int(result.RowsAffected), nil
+}
+
+// cloneMounts copies the source's namespace-bound mounts to
+// <path>-<suffix> and returns the new paths by source path
+func (s *SandboxService) cloneMounts(tx *gorm.DB, sourceID, sandboxID uuid.UUID, suffix string, userID uuid.UUID) (map[string]string, error) {
+	var mounts []model.Mount
+	if err := tx.Where("namespace_id = ?", sourceID).Order("path").Find(&mounts).Error; err != nil {
+		return nil, fmt.Errorf("failed to get mounts: %w", err)
+	}
+
+	paths := make(map[string]string, len(mounts))
+	for _, mount := range mounts {
+		base := mount.Path
+		if len(base) > 63-len(suffix)-1 {
+			base = base[:63-len(suffix)-1]
+		}
+		clone := mount
+		clone.Path = base + "-" + suffix
+		clone.Description = fmt.Sprintf("Sandbox of %s", mount.Path)
+		clone.NamespaceID = &sandboxID
+		clone.CreatedBy = &userID
+		clone.CreatedAt = time.Time{}
+		clone.UpdatedAt = time.Time{}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&clone)
+		if result.Error != nil {
+			return nil, fmt.Errorf("failed to clone mount %s: %w", mount.Path, result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrMountExists, clone.Path)
+		}
+		paths[mount.Path] = clone.Path
+	}
+	return paths, nil
+}
+
+// cloneSecrets copies the source's active secrets with replacement values.
+// Secrets in cloned mounts move to the clone; others stay in their mount.
+func (s *SandboxService) cloneSecrets(tx *gorm.DB, sourceID, sandboxID uuid.UUID, mounts map[string]string, values string) (int, error) {
+	var secrets []model.Secret
+	if err := tx.Where("namespace_id = ? AND is_active = ? AND quarantined_at IS NULL", sourceID, true).Find(&secrets).Error; err != nil {
+		return 0, fmt.Errorf("failed to get secrets: %w", err)
+	}
+
+	for i := range secrets {
+		value := sandboxScrubbedValue
+		if values == model.SandboxValuesRandom {
+			buf := make([]byte, sandboxRandomBytes)
+			if _, err := rand.Read(buf); err != nil {
+				return 0, fmt.Errorf("failed to generate sandbox value: %w", err)
+			}
+			value = base64.RawURLEncoding.EncodeToString(buf)
+		}
+
+		mount := secrets[i].Mount
+		if clone, ok := mounts[mount]; ok {
+			mount = clone
+		}
+		if _, err := s.secretService.CloneSecret(tx, &secrets[i], sandboxID, mount, value); err != nil {
+			return 0, err
+		}
+	}
+	return len(secrets), nil
+}
+
+var (
+	ErrSandboxNested        = errors.New("a sandbox cannot be cloned")
+	ErrSandboxTTLTooLong    = errors.New("sandbox ttl exceeds the maximum")
+	ErrSandboxInvalidValues = errors.New("values must be scrubbed or random")
+	ErrSandboxNameTaken     = errors.New("a namespace with that name already exists")
+	ErrNotSandbox           = errors.New("namespace is not a sandbox")
+)
@@ -10,87 +10,390 @@ import (
 	"fmt"
 	"github.com/skygenesisenterprise/aether-vault/server/src/model"
 	"io"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"golang.org/x/crypto/pbkdf2"
 	"gorm.io/gorm"
 )
 
+// ClientEnvelopePrefix starts every value sealed by a client in
+// zero-knowledge mode, e.g. `vault secret create --client-encrypt`
+const ClientEnvelopePrefix = "aev-e2e:v1:"
+
 type SecretService struct {
-	db           *gorm.DB
-	cryptoKey    []byte
-	kdfSalt      []byte
-	kdfIter      int
-	auditService *AuditService
+	db              *gorm.DB
+	cryptoKey       []byte
+	kdfSalt         []byte
+	kdfIter         int
+	auditService    *AuditService
+	templateService *TemplateService
+	tenantKeys      *TenantKeyService
+	notifications   *NotificationService
+	integrity       *SecretIntegrity
+	rowCache        *ttlCache
+	accessStats     *AccessStats
+	accessTracker   *SecretAccessService
+	mounts          *MountService
+	quotas          *QuotaService
+	validators      *SecretValidatorService
+	expiry          *ExpiryScheduler
+	policies        *PolicyService
+	namespaces      *NamespaceService
+	dataKeys        *dataKeyRing
+	leases          *LeaseService
+	rotation        *SecretRotationService
+	checkouts       *SecretCheckoutService
+	orgs            *OrgService
+	stepUp          *MFAService
+	controlGroups   *ControlGroupService
 }
 
-func NewSecretService(db *gorm.DB, encryptionKey string, kdfSalt string, kdfIter int, auditService *AuditService) *SecretService {
+func NewSecretService(db *gorm.DB, encryptionKey string, kdfSalt string, kdfIter int, auditService *AuditService, templateService *TemplateService, tenantKeys *TenantKeyService, notifications *NotificationService, integrity *SecretIntegrity) *SecretService {
 	salt := []byte(kdfSalt)
 	key := pbkdf2.Key([]byte(encryptionKey), salt, kdfIter, 32, sha256.New)
 
 	return &SecretService{
-		db:           db,
-		cryptoKey:    key,
-		kdfSalt:      salt,
-		kdfIter:      kdfIter,
-		auditService: auditService,
+		db:              db,
+		cryptoKey:       key,
+		kdfSalt:         salt,
+		kdfIter:         kdfIter,
+		auditService:    auditService,
+		templateService: templateService,
+		tenantKeys:      tenantKeys,
+		notifications:   notifications,
+		integrity:       integrity,
+	}
+}
+
+// EnableCache caches secret rows, still encrypted, for ttl and records
+// reads in stats for the startup warm-up
+func (s *SecretService) EnableCache(ttl time.Duration, stats *AccessStats) {
+	s.rowCache = newTTLCache(ttl)
+	s.rowCache.clearOnWrite(s.db, "secrets", "secrets")
+	s.accessStats = stats
+}
+
+// TrackAccess records every value read in tracker
+func (s *SecretService) TrackAccess(tracker *SecretAccessService) {
+	s.accessTracker = tracker
+}
+
+// UseQuotas counts reads of namespace secrets as requests to the namespace
+func (s *SecretService) UseQuotas(quotas *QuotaService) {
+	s.quotas = quotas
+}
+
+// UseMounts applies the options of each secret's KV mount
+func (s *SecretService) UseMounts(mounts *MountService) {
+	s.mounts = mounts
+}
+
+// kvMount returns the KV mount at path. Without a mount table every
+// secret lives in the default mount with no options.
+func (s *SecretService) kvMount(path string) (*model.Mount, error) {
+	if path == "" {
+		path = model.DefaultKVMount
+	}
+	if s.mounts == nil {
+		if path != model.DefaultKVMount {
+			return nil, ErrMountNotFound
+		}
+		return &model.Mount{Path: path, Type: model.MountTypeKV}, nil
+	}
+
+	mount, err := s.mounts.GetMount(path)
+	if err != nil {
+		return nil, err
+	}
+	if mount.Type != model.MountTypeKV {
+		return nil, ErrMountWrongType
 	}
+	return mount, nil
 }
 
 func (s *SecretService) CreateSecret(secret *model.Secret, userID uuid.UUID) error {
-	encryptedValue, err := s.encrypt(secret.Value)
+	mount, err := s.kvMount(secret.Mount)
 	if err != nil {
-		return fmt.Errorf("failed to encrypt secret: %w", err)
+		return err
+	}
+	secret.Mount = mount.Path
+	if mount.NamespaceID != nil {
+		if secret.NamespaceID == nil {
+			secret.NamespaceID = mount.NamespaceID
+		} else if *secret.NamespaceID != *mount.NamespaceID {
+			return ErrMountNamespace
+		}
+	}
+	resolution, err := resolveSecretTTL(mount, secret.RequestedTTL, secret.ExpiresAt, time.Now())
+	if err != nil {
+		return err
 	}
+	secret.ExpiresAt = resolution.ExpiresAt
 
-	valueHash := s.hashValue(secret.Value)
+	if secret.ClientEncrypted {
+		if !strings.HasPrefix(secret.Value, ClientEnvelopePrefix) {
+			return ErrSecretEnvelopeInvalid
+		}
+	} else if s.templateService != nil {
+		if err := s.templateService.ValidateSecret(secret.Name, secret.Value); err != nil {
+			return err
+		}
+	}
+	if err := validateFields(secret.Fields, secret.Value, secret.ClientEncrypted); err != nil {
+		return err
+	}
+	if err := validateSecretPath(secret.Name); err != nil {
+		return err
+	}
+	if secret.Tags, err = normalizeTags(secret.Tags); err != nil {
+		return err
+	}
+	if err := validateMetadata(secret.Metadata); err != nil {
+		return err
+	}
+	if err := s.checkRotation(secret.RotationPeriod, secret.Rotator, secret.ClientEncrypted, secret.CheckoutRequired); err != nil {
+		return err
+	}
+
+	if secret.NamespaceID != nil {
+		if s.tenantKeys == nil {
+			return ErrNamespaceNotFound
+		}
+		allowed, err := s.tenantKeys.CanWrite(userID, *secret.NamespaceID)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return ErrSecretNamespaceDenied
+		}
+	}
+	if secret.ProjectID != nil {
+		if err := s.checkProject(userID, *secret.ProjectID); err != nil {
+			return err
+		}
+	}
+
+	// Values under validated paths wait for their validators, hidden
+	// until they pass
+	var validators []model.SecretValidator
+	if !secret.ClientEncrypted {
+		validators, err = s.validators.Match(secret.Name)
+		if err != nil {
+			return err
+		}
+	}
+	if len(validators) > 0 {
+		secret.IsActive = false
+	}
+
+	plaintext := secret.Value
+	valueHash := s.hashValue(plaintext)
+	identifiers := s.auditIdentifiers(secret.Name, plaintext)
+
+	if err := s.sealSecret(secret, plaintext); err != nil {
+		return fmt.Errorf("failed to encrypt secret: %w", err)
+	}
 
-	secret.Value = encryptedValue
 	secret.ValueHash = valueHash
+	rotatedAt := time.Now()
+	secret.RotatedAt = &rotatedAt
+	planRotation(secret)
 	secret.UserID = userID
+	secret.Version = 1
+	if secret.ID == uuid.Nil {
+		secret.ID = uuid.New()
+	}
+	s.stampChecksum(secret)
 
-	if err := s.db.Create(secret).Error; err != nil {
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(secret).Error; err != nil {
+			return err
+		}
+		return s.replaceFields(tx, secret.ID, secret.Fields)
+	})
+	if err != nil {
 		return fmt.Errorf("failed to create secret: %w", err)
 	}
 
+	secret.TTL = resolution
+
 	if s.auditService != nil {
-		s.auditService.LogAction(userID, "secret_created", "secret", secret.ID.String(), true, "")
+		s.auditService.LogAction(userID, "secret_created", "secret", secret.ID.String(), true, identifiers)
+	}
+
+	if len(validators) > 0 {
+		secret.Validation, err = s.startValidation(secret, "create", plaintext, userID)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-func (s *SecretService) GetSecretByID(id uuid.UUID, userID uuid.UUID) (*model.Secret, error) {
-	var secret model.Secret
-	if err := s.db.Where("id = ? AND user_id = ? AND is_active = ?", id, userID, true).First(&secret).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrSecretNotFound
-		}
-		return nil, fmt.Errorf("failed to get secret: %w", err)
+// CloneSecret copies a secret's metadata into another namespace and mount
+// with a new value, inside tx. The copy keeps the owner and is sealed like
+// any server-side secret; the caller has authorized the clone, so neither
+// namespace permissions nor templates are checked. Rotation settings stay
+// behind, so the copy never rotates the credential of its source.
+func (s *SecretService) CloneSecret(tx *gorm.DB, source *model.Secret, namespaceID uuid.UUID, mount, value string) (*model.Secret, error) {
+	now := time.Now()
+	clone := &model.Secret{
+		ID:          uuid.New(),
+		UserID:      source.UserID,
+		Name:        source.Name,
+		Description: source.Description,
+		Type:        source.Type,
+		Tags:        source.Tags,
+		Metadata:    source.Metadata,
+		NamespaceID: &namespaceID,
+		Mount:       mount,
+		Version:     1,
+		ExpiresAt:   source.ExpiresAt,
+		RotatedAt:   &now,
+		IsActive:    true,
+		ValueHash:   s.hashValue(value),
+	}
+	if err := s.sealSecret(clone, value); err != nil {
+		return nil, fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+	s.stampChecksum(clone)
+
+	if err := tx.Create(clone).Error; err != nil {
+		return nil, fmt.Errorf("failed to clone secret: %w", err)
 	}
+	return clone, nil
+}
 
-	decryptedValue, err := s.decrypt(secret.Value)
+// GetSecretByID opens one of the user's secrets. Reads go through
+// requireValueRead, so the token accessor names needs a recent step-up
+// verification, and the user an approved control group request, where
+// the user's policy rules ask for them.
+func (s *SecretService) GetSecretByID(id uuid.UUID, userID uuid.UUID, accessor *uuid.UUID) (*model.Secret, error) {
+	secret, err := s.secretRow(id)
+	if err != nil {
+		return nil, err
+	}
+	if secret.UserID != userID || !secret.IsActive {
+		return nil, ErrSecretNotFound
+	}
+	if err := s.requireValueRead(&secret, userID, accessor); err != nil {
+		return nil, err
+	}
+	s.accessStats.Record(AccessKindSecret, id.String())
+	s.accessTracker.Record(id)
+	if secret.NamespaceID != nil {
+		s.quotas.RecordRequest(*secret.NamespaceID)
+	}
+
+	decryptedValue, err := s.openSecret(&secret)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decrypt secret: %w", err)
 	}
 
 	secret.Value = decryptedValue
+	secret.Fields, err = s.secretFields(id)
+	if err != nil {
+		return nil, err
+	}
 
 	if s.auditService != nil {
-		s.auditService.LogAction(userID, "secret_accessed", "secret", secret.ID.String(), true, "")
+		s.auditService.LogAction(userID, "secret_accessed", "secret", secret.ID.String(), true, s.auditIdentifiers(secret.Name, secret.Value))
 	}
 
 	return &secret, nil
 }
 
-func (s *SecretService) GetSecretsByUserID(userID uuid.UUID) ([]model.Secret, error) {
+// requireValueRead is the gate every read handing userID the value of
+// secret goes through: the step-up verification, control group approval
+// and checkout its policy rules and flags require. Refused reads are
+// audited.
+func (s *SecretService) requireValueRead(secret *model.Secret, userID uuid.UUID, accessor *uuid.UUID) error {
+	err := s.requireStepUp(secret, userID, accessor)
+	if err == nil {
+		err = s.requireControlGroup(secret, userID)
+	}
+	if err == nil {
+		err = s.requireCheckout(secret, userID)
+	}
+	if err != nil && s.auditService != nil {
+		s.auditService.LogAction(userID, "secret_accessed", "secret", secret.ID.String(), false, err.Error())
+	}
+	return err
+}
+
+// secretRow returns a copy of the stored row, from the cache when possible
+func (s *SecretService) secretRow(id uuid.UUID) (model.Secret, error) {
+	if cached, ok := s.rowCache.get(id.String()); ok {
+		return cached.(model.Secret), nil
+	}
+
+	var secret model.Secret
+	if err := s.db.Where("id = ? AND is_active = ?", id, true).First(&secret).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return secret, ErrSecretNotFound
+		}
+		return secret, fmt.Errorf("failed to get secret: %w", err)
+	}
+	s.putSecretRow(secret)
+	return secret, nil
+}
+
+func (s *SecretService) putSecretRow(secret model.Secret) {
+	var expiresAt time.Time
+	if secret.ExpiresAt != nil {
+		expiresAt = *secret.ExpiresAt
+	}
+	s.rowCache.put(secret.ID.String(), secret, expiresAt)
+}
+
+// warmCache loads the rows of the given secret IDs into the cache
+func (s *SecretService) warmCache(keys []string) (int, error) {
+	if s.rowCache == nil {
+		return 0, nil
+	}
+	ids := make([]uuid.UUID, 0, len(keys))
+	for _, key := range keys {
+		if id, err := uuid.Parse(key); err == nil {
+			ids = append(ids, id)
+		}
+	}
+
 	var secrets []model.Secret
-	if err := s.db.Where("user_id = ? AND is_active = ?", userID, true).Find(&secrets).Error; err != nil {
+	if err := s.db.Where("id IN ? AND is_active = ?", ids, true).Find(&secrets).Error; err != nil {
+		return 0, fmt.Errorf("failed to get secrets: %w", err)
+	}
+	for _, secret := range secrets {
+		s.putSecretRow(secret)
+	}
+	return len(secrets), nil
+}
+
+// GetSecretsByUserID lists the user's secrets in one KV mount, only those
+// whose tags match every pattern in tags when it is set
+func (s *SecretService) GetSecretsByUserID(userID uuid.UUID, mount string, tags []string) ([]model.Secret, error) {
+	var secrets []model.Secret
+	if err := s.db.Where("user_id = ? AND mount = ? AND is_active = ?", userID, mount, true).Find(&secrets).Error; err != nil {
 		return nil, fmt.Errorf("failed to get secrets: %w", err)
 	}
+	if len(tags) > 0 {
+		matching := secrets[:0]
+		for _, secret := range secrets {
+			if MatchTags(tags, SplitTags(secret.Tags)) {
+				matching = append(matching, secret)
+			}
+		}
+		secrets = matching
+	}
 
 	for i := range secrets {
-		decryptedValue, err := s.decrypt(secrets[i].Value)
+		decryptedValue, err := s.openSecret(&secrets[i])
+		// Quarantined rows are listed, flagged by quarantined_at, but not opened
+		if errors.Is(err, ErrSecretQuarantined) {
+			continue
+		}
 		if err != nil {
 			return nil, fmt.Errorf("failed to decrypt secret: %w", err)
 		}
@@ -113,53 +416,333 @@ func (s *SecretService) UpdateSecret(id uuid.UUID, updates *model.UpdateSecretRe
 		return nil, fmt.Errorf("failed to get secret: %w", err)
 	}
 
+	// An update would re-sign a row that failed verification
+	if err := s.checkIntegrity(&secret); err != nil {
+		return nil, err
+	}
+
+	mount, err := s.kvMount(secret.Mount)
+	if err != nil {
+		return nil, err
+	}
+	// Without a new expiry the current one stands, even if the mount's
+	// limits changed since
+	resolution := &model.TTLResolution{
+		DefaultTTL: mount.DefaultTTL,
+		MaxTTL:     mount.MaxTTL,
+		Source:     model.TTLSourceUnchanged,
+		ExpiresAt:  secret.ExpiresAt,
+	}
+	if secret.ExpiresAt != nil {
+		if remaining := int(time.Until(*secret.ExpiresAt).Seconds()); remaining > 0 {
+			resolution.Effective = remaining
+		}
+	}
+	if updates.TTL != nil || updates.ExpiresAt != nil {
+		resolution, err = resolveSecretTTL(mount, updates.TTL, updates.ExpiresAt, time.Now())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if updates.Name != nil {
+		if err := validateSecretPath(*updates.Name); err != nil {
+			return nil, err
+		}
+	}
+	var tags string
+	if updates.Tags != nil {
+		if tags, err = normalizeTags(*updates.Tags); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateMetadata(updates.Metadata); err != nil {
+		return nil, err
+	}
+
+	clientEncrypted := secret.ClientEncrypted
+	if updates.ClientEncrypted != nil && *updates.ClientEncrypted != clientEncrypted {
+		if updates.Value == nil {
+			return nil, fmt.Errorf("%w: a new value is required to change client_encrypted", ErrSecretEnvelopeInvalid)
+		}
+		clientEncrypted = *updates.ClientEncrypted
+	}
+	if clientEncrypted && updates.Value != nil && !strings.HasPrefix(*updates.Value, ClientEnvelopePrefix) {
+		return nil, ErrSecretEnvelopeInvalid
+	}
+	rotationPeriod, rotator := secret.RotationPeriod, secret.Rotator
+	if updates.RotationPeriod != nil {
+		rotationPeriod = *updates.RotationPeriod
+	}
+	if updates.Rotator != nil {
+		rotator = *updates.Rotator
+	}
+	checkoutRequired := secret.CheckoutRequired
+	if updates.CheckoutRequired != nil {
+		checkoutRequired = *updates.CheckoutRequired
+	}
+	if err := s.checkRotation(rotationPeriod, rotator, clientEncrypted, checkoutRequired); err != nil {
+		return nil, err
+	}
+	projectID := secret.ProjectID
+	if updates.ProjectID != nil {
+		projectID = nil
+		if *updates.ProjectID != uuid.Nil {
+			if err := s.checkProject(userID, *updates.ProjectID); err != nil {
+				return nil, err
+			}
+			projectID = updates.ProjectID
+		}
+	}
+
+	// Templates cannot see inside client-encrypted values
+	if s.templateService != nil && !clientEncrypted && (updates.Name != nil || updates.Value != nil) {
+		name := secret.Name
+		if updates.Name != nil {
+			name = *updates.Name
+		}
+
+		var value string
+		if updates.Value != nil {
+			value = *updates.Value
+		} else {
+			decryptedValue, err := s.openSecret(&secret)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decrypt secret: %w", err)
+			}
+			value = decryptedValue
+		}
+
+		if err := s.templateService.ValidateSecret(name, value); err != nil {
+			return nil, err
+		}
+	}
+
+	// Field rules need a JSON object to apply to, now and after the
+	// update
+	if updates.Fields != nil || updates.Value != nil {
+		var fields []model.SecretField
+		if updates.Fields != nil {
+			fields = *updates.Fields
+		} else if fields, err = s.secretFields(secret.ID); err != nil {
+			return nil, err
+		}
+		if len(fields) > 0 {
+			var value string
+			if updates.Value != nil {
+				value = *updates.Value
+			} else if value, err = s.openSecret(&secret); err != nil {
+				return nil, fmt.Errorf("failed to decrypt secret: %w", err)
+			}
+			if err := validateFields(fields, value, clientEncrypted); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// A value scheduled for later is held until activate_at; the rest of
+	// the update applies now, including the mode of the current value
+	var scheduledValue *string
+	scheduledEncrypted := clientEncrypted
+	if updates.ActivateAt != nil {
+		if updates.Value == nil {
+			return nil, fmt.Errorf("%w: a new value is required", ErrSecretPublicationInvalid)
+		}
+		if !updates.ActivateAt.After(time.Now()) {
+			return nil, fmt.Errorf("%w: must be in the future", ErrSecretPublicationInvalid)
+		}
+		if s.expiry == nil {
+			return nil, ErrSecretPublicationUnavailable
+		}
+		name := secret.Name
+		if updates.Name != nil {
+			name = *updates.Name
+		}
+		validators, err := s.validators.Match(name)
+		if err != nil {
+			return nil, err
+		}
+		if len(validators) > 0 {
+			return nil, ErrSecretPublicationValidated
+		}
+		scheduledValue = updates.Value
+		deferred := *updates
+		deferred.Value = nil
+		updates = &deferred
+		clientEncrypted = secret.ClientEncrypted
+	}
+
+	// A new value under a validated path is held until its validators
+	// pass; the rest of the update applies now
+	var heldValue *string
+	if updates.Value != nil && !clientEncrypted && s.hashValue(*updates.Value) != secret.ValueHash {
+		name := secret.Name
+		if updates.Name != nil {
+			name = *updates.Name
+		}
+		validators, err := s.validators.Match(name)
+		if err != nil {
+			return nil, err
+		}
+		if len(validators) > 0 {
+			heldValue = updates.Value
+			deferred := *updates
+			deferred.Value = nil
+			updates = &deferred
+			clientEncrypted = secret.ClientEncrypted
+		}
+	}
+
 	if updates.Name != nil {
 		secret.Name = *updates.Name
 	}
 	if updates.Description != nil {
 		secret.Description = *updates.Description
 	}
+	var previous *model.SecretVersion
 	if updates.Value != nil {
-		encryptedValue, err := s.encrypt(*updates.Value)
+		previous, err = s.setValue(&secret, mount, *updates.Value, userID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to encrypt secret: %w", err)
+			return nil, err
 		}
-		secret.Value = encryptedValue
-		secret.ValueHash = s.hashValue(*updates.Value)
 	}
 	if updates.Type != nil {
 		secret.Type = *updates.Type
 	}
 	if updates.Tags != nil {
-		secret.Tags = *updates.Tags
+		secret.Tags = tags
 	}
-	if updates.ExpiresAt != nil {
-		secret.ExpiresAt = updates.ExpiresAt
+	if updates.Metadata != nil {
+		secret.Metadata = updates.Metadata
+		if len(secret.Metadata) == 0 {
+			secret.Metadata = nil
+		}
 	}
+	secret.ExpiresAt = resolution.ExpiresAt
 	if updates.IsActive != nil {
 		secret.IsActive = *updates.IsActive
 	}
+	if updates.MaxVersions != nil {
+		secret.MaxVersions = *updates.MaxVersions
+	}
+	if updates.RotationPeriod != nil || updates.Rotator != nil {
+		secret.RotationPeriod, secret.Rotator = rotationPeriod, rotator
+		secret.RotationError = ""
+		planRotation(&secret)
+	}
+	secret.CheckoutRequired = checkoutRequired
+	secret.ProjectID = projectID
+	secret.ClientEncrypted = clientEncrypted
+	s.stampChecksum(&secret)
 
-	if err := s.db.Save(&secret).Error; err != nil {
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.keepVersion(tx, &secret, previous); err != nil {
+			return err
+		}
+		if updates.Fields != nil {
+			if err := s.replaceFields(tx, secret.ID, *updates.Fields); err != nil {
+				return err
+			}
+			secret.Fields = *updates.Fields
+		}
+		return tx.Save(&secret).Error
+	})
+	if err != nil {
 		return nil, fmt.Errorf("failed to update secret: %w", err)
 	}
 
-	decryptedValue, err := s.decrypt(secret.Value)
+	decryptedValue, err := s.openSecret(&secret)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decrypt secret: %w", err)
 	}
 	secret.Value = decryptedValue
+	secret.TTL = resolution
 
 	if s.auditService != nil {
-		s.auditService.LogAction(userID, "secret_updated", "secret", secret.ID.String(), true, "")
+		s.auditService.LogAction(userID, "secret_updated", "secret", secret.ID.String(), true, s.auditIdentifiers(secret.Name, secret.Value))
+	}
+
+	if heldValue != nil {
+		secret.Validation, err = s.startValidation(&secret, "update", *heldValue, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if scheduledValue != nil {
+		secret.Publication, err = s.schedulePublication(&secret, *scheduledValue, scheduledEncrypted, *updates.ActivateAt, userID)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return &secret, nil
 }
 
+// setValue seals a new value into secret. A changed value bumps the
+// version; in versioned mounts the previous value is returned to be kept.
+func (s *SecretService) setValue(secret *model.Secret, mount *model.Mount, value string, userID uuid.UUID) (*model.SecretVersion, error) {
+	var previous *model.SecretVersion
+	if valueHash := s.hashValue(value); valueHash != secret.ValueHash {
+		if mount.Versioning {
+			previous = &model.SecretVersion{
+				ID:              uuid.New(),
+				SecretID:        secret.ID,
+				Version:         secret.Version,
+				Value:           secret.Value,
+				WrappedKey:      secret.WrappedKey,
+				ValueHash:       secret.ValueHash,
+				ClientEncrypted: secret.ClientEncrypted,
+				CreatedAt:       secret.CreatedAt,
+				ReplacedAt:      time.Now(),
+				ReplacedBy:      &userID,
+			}
+			if secret.RotatedAt != nil {
+				previous.CreatedAt = *secret.RotatedAt
+			}
+		}
+		rotatedAt := time.Now()
+		secret.RotatedAt = &rotatedAt
+		secret.RotationError = ""
+		planRotation(secret)
+		secret.ValueHash = valueHash
+		secret.Version++
+	}
+	if err := s.sealSecret(secret, value); err != nil {
+		return nil, fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+	return previous, nil
+}
+
+// keepVersion stores the value a write replaced, if any, and drops the
+// oldest ones beyond the secret's MaxVersions
+func (s *SecretService) keepVersion(tx *gorm.DB, secret *model.Secret, previous *model.SecretVersion) error {
+	if previous != nil {
+		if err := tx.Create(previous).Error; err != nil {
+			return err
+		}
+	}
+	if secret.MaxVersions <= 0 {
+		return nil
+	}
+
+	var stale []int
+	if err := tx.Model(&model.SecretVersion{}).Where("secret_id = ?", secret.ID).
+		Order("version DESC").Offset(secret.MaxVersions).Pluck("version", &stale).Error; err != nil {
+		return err
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+	return tx.Where("secret_id = ? AND version IN ?", secret.ID, stale).Delete(&model.SecretVersion{}).Error
+}
+
 func (s *SecretService) DeleteSecret(id uuid.UUID, userID uuid.UUID) error {
-	if err := s.db.Where("id = ? AND user_id = ?", id, userID).Delete(&model.Secret{}).Error; err != nil {
-		return fmt.Errorf("failed to delete secret: %w", err)
+	result := s.db.Where("id = ? AND user_id = ?", id, userID).Delete(&model.Secret{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete secret: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrSecretNotFound
 	}
 
 	if s.auditService != nil {
@@ -169,7 +752,202 @@ func (s *SecretService) DeleteSecret(id uuid.UUID, userID uuid.UUID) error {
 	return nil
 }
 
+// MountOf returns the path of the mount holding a secret
+func (s *SecretService) MountOf(id uuid.UUID) (string, error) {
+	secret, err := s.secretRow(id)
+	if err != nil {
+		return "", err
+	}
+	return secret.Mount, nil
+}
+
+// GetVersions lists the previous values kept for a secret, newest first
+func (s *SecretService) GetVersions(id uuid.UUID, userID uuid.UUID) ([]model.SecretVersion, error) {
+	secret, err := s.secretRow(id)
+	if err != nil {
+		return nil, err
+	}
+	if secret.UserID != userID {
+		return nil, ErrSecretNotFound
+	}
+
+	versions := []model.SecretVersion{}
+	if err := s.db.Where("secret_id = ?", id).Order("version DESC").Find(&versions).Error; err != nil {
+		return nil, fmt.Errorf("failed to get secret versions: %w", err)
+	}
+	return versions, nil
+}
+
+// GetVersionValue opens a previous value of a secret, through the same
+// gate as the current one
+func (s *SecretService) GetVersionValue(id uuid.UUID, version int, userID uuid.UUID, accessor *uuid.UUID) (*model.SecretVersionValue, error) {
+	secret, err := s.secretRow(id)
+	if err != nil {
+		return nil, err
+	}
+	if secret.UserID != userID {
+		return nil, ErrSecretNotFound
+	}
+	if err := s.requireValueRead(&secret, userID, accessor); err != nil {
+		return nil, err
+	}
+
+	var row model.SecretVersion
+	if err := s.db.Where("secret_id = ? AND version = ?", id, version).First(&row).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSecretVersionNotFound
+		}
+		return nil, fmt.Errorf("failed to get secret version: %w", err)
+	}
+
+	// Previous values are sealed like the row was at the time; open them
+	// with the secret's namespace key
+	sealed := secret
+	sealed.Value = row.Value
+	sealed.WrappedKey = row.WrappedKey
+	value, err := s.unsealSecret(&sealed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret version: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "secret_version_accessed", "secret", id.String(), true, fmt.Sprintf("version=%d", version))
+	}
+
+	return &model.SecretVersionValue{SecretVersion: row, Value: value}, nil
+}
+
+// RollbackSecret makes a previous value current again. The restored value
+// is written as a new version, so the one it replaces is kept in turn, and
+// goes through the same templates and validators as any update.
+func (s *SecretService) RollbackSecret(id uuid.UUID, version int, userID uuid.UUID) (*model.RollbackSecretResponse, error) {
+	secret, err := s.secretRow(id)
+	if err != nil {
+		return nil, err
+	}
+	if secret.UserID != userID {
+		return nil, ErrSecretNotFound
+	}
+	if err := s.checkIntegrity(&secret); err != nil {
+		return nil, err
+	}
+
+	var row model.SecretVersion
+	if err := s.db.Where("secret_id = ? AND version = ?", id, version).First(&row).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSecretVersionNotFound
+		}
+		return nil, fmt.Errorf("failed to get secret version: %w", err)
+	}
+	if row.ValueHash == secret.ValueHash {
+		return nil, ErrSecretVersionCurrent
+	}
+
+	sealed := secret
+	sealed.Value = row.Value
+	sealed.WrappedKey = row.WrappedKey
+	value, err := s.unsealSecret(&sealed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret version: %w", err)
+	}
+
+	updated, err := s.UpdateSecret(id, &model.UpdateSecretRequest{Value: &value, ClientEncrypted: &row.ClientEncrypted}, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "secret_rolled_back", "secret", id.String(), true, fmt.Sprintf("restored_version=%d; version=%d", version, updated.Version))
+	}
+
+	return &model.RollbackSecretResponse{Secret: *updated, RestoredVersion: version}, nil
+}
+
+// auditIdentifiers returns HMACed identifiers for audit details
+func (s *SecretService) auditIdentifiers(name, value string) string {
+	if s.auditService == nil {
+		return ""
+	}
+	return s.auditService.SecretIdentifiers(name, value)
+}
+
+// sealSecret encrypts plaintext into secret.Value, under the namespace's
+// tenant key when it has one and the master key otherwise
+func (s *SecretService) sealSecret(secret *model.Secret, plaintext string) error {
+	if secret.NamespaceID != nil && s.tenantKeys != nil {
+		ciphertext, wrappedKey, err := s.tenantKeys.Seal(*secret.NamespaceID, []byte(plaintext))
+		if err == nil {
+			secret.Value = ciphertext
+			secret.WrappedKey = wrappedKey
+			return nil
+		}
+		if !errors.Is(err, ErrTenantKeyNotFound) {
+			return err
+		}
+	}
+
+	encrypted, err := s.encrypt(plaintext)
+	if err != nil {
+		return err
+	}
+	secret.Value = encrypted
+	secret.WrappedKey = ""
+	return nil
+}
+
+// openSecret decrypts secret.Value with whichever key sealed it. A row
+// whose checksum does not match is quarantined instead of decrypted, and a
+// value that fails authentication raises security.seal_tampering.
+func (s *SecretService) openSecret(secret *model.Secret) (string, error) {
+	if err := s.checkIntegrity(secret); err != nil {
+		return "", err
+	}
+
+	plaintext, err := s.unsealSecret(secret)
+	if errors.Is(err, ErrSecretTampered) && s.notifications != nil {
+		data := map[string]interface{}{
+			"summary":   fmt.Sprintf("Ciphertext of secret %s failed authentication", secret.ID),
+			"dedup_key": "seal_tampering:" + secret.ID.String(),
+			"secret_id": secret.ID,
+		}
+		if secret.NamespaceID != nil {
+			data["namespace_id"] = *secret.NamespaceID
+		}
+		s.notifications.RaiseCondition(model.NewEvent(model.EventSealTampering, data))
+	}
+	return plaintext, err
+}
+
+// stampChecksum signs the row as it is about to be written
+func (s *SecretService) stampChecksum(secret *model.Secret) {
+	if s.integrity != nil {
+		secret.Checksum = s.integrity.Sum(secret)
+	}
+}
+
+func (s *SecretService) unsealSecret(secret *model.Secret) (string, error) {
+	if secret.WrappedKey == "" {
+		return s.decrypt(secret.Value)
+	}
+
+	if secret.NamespaceID == nil || s.tenantKeys == nil {
+		return "", ErrTenantKeyNotFound
+	}
+
+	plaintext, err := s.tenantKeys.Open(*secret.NamespaceID, secret.Value, secret.WrappedKey)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// encrypt seals plaintext under the current data key, or directly with
+// the master key when data keys are not enabled
 func (s *SecretService) encrypt(plaintext string) (string, error) {
+	if s.dataKeys != nil {
+		return s.sealDataKey(plaintext)
+	}
+
 	block, err := aes.NewCipher(s.cryptoKey)
 	if err != nil {
 		return "", err
@@ -190,6 +968,10 @@ func (s *SecretService) encrypt(plaintext string) (string, error) {
 }
 
 func (s *SecretService) decrypt(ciphertext string) (string, error) {
+	if strings.HasPrefix(ciphertext, dataKeyPrefix) {
+		return s.openDataKey(ciphertext)
+	}
+
 	data, err := base64.StdEncoding.DecodeString(ciphertext)
 	if err != nil {
 		return "", err
@@ -213,7 +995,7 @@ func (s *SecretService) decrypt(ciphertext string) (string, error) {
 	nonce, ciphertext_bytes := data[:nonceSize], data[nonceSize:]
 	plaintext, err := gcm.Open(nil, nonce, ciphertext_bytes, nil)
 	if err != nil {
-		return "", err
+		return "", fmt.Errorf("%w: %v", ErrSecretTampered, err)
 	}
 
 	return string(plaintext), nil
@@ -225,6 +1007,20 @@ func (s *SecretService) hashValue(value string) string {
 }
 
 var (
-	ErrSecretNotFound = errors.New("secret not found")
-	ErrSecretExpired  = errors.New("secret has expired")
+	ErrSecretNotFound        = errors.New("secret not found")
+	ErrBundlePrefixRequired  = errors.New("bundle prefix is required")
+	ErrSecretVersionNotFound = errors.New("secret version not found")
+	ErrSecretVersionCurrent  = errors.New("secret version is already current")
+	ErrSecretExpired         = errors.New("secret has expired")
+
+	ErrSecretNamespaceDenied = errors.New("no write access to namespace")
+	ErrSecretProjectDenied   = errors.New("no write access to project")
+	// ErrSecretTampered means the ciphertext was modified or sealed with a
+	// different key
+	ErrSecretTampered = errors.New("secret ciphertext failed authentication")
+
+	// ErrSecretClientEncrypted means the operation needs a plaintext the
+	// server cannot see
+	ErrSecretClientEncrypted = errors.New("secret is encrypted client-side")
+	ErrSecretEnvelopeInvalid = errors.New("client_encrypted value must be an envelope sealed by the client")
 )
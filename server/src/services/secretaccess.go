@@ -0,0 +1,175 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// secretAccessFlushInterval bounds how often a secret's access row is
+// rewritten, however often the secret is read
+const secretAccessFlushInterval = time.Minute
+
+// SecretAccessService tracks when each secret was last read and reports
+// secrets nobody reads any more, for cleanup campaigns
+type SecretAccessService struct {
+	db            *gorm.DB
+	auditService  *AuditService
+	notifications *NotificationService
+	emails        *EmailNotifier
+
+	mu      sync.Mutex
+	pending map[uuid.UUID]*pendingAccess
+}
+
+func NewSecretAccessService(db *gorm.DB, auditService *AuditService, notifications *NotificationService, emails *EmailNotifier) *SecretAccessService {
+	return &SecretAccessService{
+		db:            db,
+		auditService:  auditService,
+		notifications: notifications,
+		emails:        emails,
+		pending:       make(map[uuid.UUID]*pendingAccess),
+	}
+}
+
+// Record counts one read. It is safe to call on a nil SecretAccessService.
+func (s *SecretAccessService) Record(secretID uuid.UUID) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.pending[secretID]
+	if !ok {
+		entry = &pendingAccess{}
+		s.pending[secretID] = entry
+	}
+	entry.hits++
+	entry.last = time.Now()
+}
+
+// Start writes the counters every secretAccessFlushInterval
+func (s *SecretAccessService) Start() {
+	go func() {
+		ticker := time.NewTicker(secretAccessFlushInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := s.Flush(); err != nil {
+				log.Printf("⚠️  Failed to persist secret access statistics: %v", err)
+			}
+		}
+	}()
+}
+
+// Flush adds the counters collected since the last flush to the database
+func (s *SecretAccessService) Flush() error {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = make(map[uuid.UUID]*pendingAccess)
+	s.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	rows := make([]model.SecretAccessStat, 0, len(pending))
+	for secretID, entry := range pending {
+		rows = append(rows, model.SecretAccessStat{SecretID: secretID, Reads: entry.hits, LastAccessedAt: entry.last})
+	}
+	if err := s.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "secret_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"reads":            gorm.Expr("secret_access_stats.reads + excluded.reads"),
+			"last_accessed_at": gorm.Expr("GREATEST(secret_access_stats.last_accessed_at, excluded.last_accessed_at)"),
+		}),
+	}).CreateInBatches(rows, 500).Error; err != nil {
+		return fmt.Errorf("failed to store secret access statistics: %w", err)
+	}
+	return nil
+}
+
+// UnusedSecrets lists active secrets not read in the last days days,
+// oldest access first. Secrets never read count from their creation.
+// A nil userID reports every owner's secrets.
+func (s *SecretAccessService) UnusedSecrets(userID *uuid.UUID, days int) (*model.UnusedSecretsReport, error) {
+	if err := s.Flush(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	cutoff := now.AddDate(0, 0, -days)
+	query := s.db.Table("secrets").
+		Select("secrets.id, secrets.name, secrets.user_id, secrets.namespace_id, secrets.type, secrets.created_at, secret_access_stats.last_accessed_at, COALESCE(secret_access_stats.reads, 0) AS reads").
+		Joins("LEFT JOIN secret_access_stats ON secret_access_stats.secret_id = secrets.id").
+		Where("secrets.deleted_at IS NULL AND secrets.is_active = ?", true).
+		Where("COALESCE(secret_access_stats.last_accessed_at, secrets.created_at) < ?", cutoff).
+		Order("COALESCE(secret_access_stats.last_accessed_at, secrets.created_at)")
+	if userID != nil {
+		query = query.Where("secrets.user_id = ?", *userID)
+	}
+
+	secrets := []model.UnusedSecret{}
+	if err := query.Scan(&secrets).Error; err != nil {
+		return nil, fmt.Errorf("failed to get unused secrets: %w", err)
+	}
+
+	return &model.UnusedSecretsReport{Days: days, GeneratedAt: now.UTC(), Secrets: secrets}, nil
+}
+
+// NotifyOwners tells each owner which of their secrets went unused, by
+// event and by email under the reminders category
+func (s *SecretAccessService) NotifyOwners(days int, userID uuid.UUID) (*model.UnusedSecretsReport, error) {
+	report, err := s.UnusedSecrets(nil, days)
+	if err != nil {
+		return nil, err
+	}
+
+	byOwner := make(map[uuid.UUID][]model.UnusedSecret)
+	var owners []uuid.UUID
+	for _, secret := range report.Secrets {
+		if _, ok := byOwner[secret.UserID]; !ok {
+			owners = append(owners, secret.UserID)
+		}
+		byOwner[secret.UserID] = append(byOwner[secret.UserID], secret)
+	}
+
+	for _, owner := range owners {
+		secrets := byOwner[owner]
+		ids := make([]uuid.UUID, 0, len(secrets))
+		for _, secret := range secrets {
+			ids = append(ids, secret.ID)
+		}
+
+		if s.notifications != nil {
+			if err := s.notifications.Publish(model.NewEvent(model.EventSecretsUnused, map[string]interface{}{
+				"owner_id":   owner,
+				"days":       days,
+				"secret_ids": ids,
+			})); err != nil {
+				log.Printf("⚠️  Failed to publish unused secrets for %s: %v", owner, err)
+			}
+		}
+		if s.emails != nil {
+			if err := s.emails.NotifyUser(owner, model.NotificationCategoryReminders, EmailTemplateUnusedSecrets, map[string]interface{}{
+				"Days":    days,
+				"Secrets": secrets,
+			}); err != nil {
+				log.Printf("⚠️  Failed to email unused secrets to %s: %v", owner, err)
+			}
+		}
+	}
+	report.Owners = len(owners)
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "unused_secrets_notified", "secret", "", true, fmt.Sprintf("days=%d secrets=%d owners=%d", days, len(report.Secrets), len(owners)))
+	}
+
+	return report, nil
+}
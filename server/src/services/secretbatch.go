@@ -0,0 +1,254 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+)
+
+// secretBatchLimit caps the operations of one batch, which all hold the
+// same transaction open
+const secretBatchLimit = 100
+
+// Batch runs operations on the secrets of one KV mount in a single
+// transaction, in order. Each operation runs in its own savepoint so that
+// every failure is reported, but the batch only commits when all of them
+// succeed. Audit entries are written once the outcome is known.
+func (s *SecretService) Batch(mount string, operations []model.SecretBatchOperation, userID uuid.UUID, accessor *uuid.UUID) (*model.SecretBatchResponse, error) {
+	if len(operations) > secretBatchLimit {
+		return nil, fmt.Errorf("%w: at most %d operations", ErrSecretBatchInvalid, secretBatchLimit)
+	}
+	for i, operation := range operations {
+		if err := checkBatchOperation(operation); err != nil {
+			return nil, fmt.Errorf("%w: operation %d: %v", ErrSecretBatchInvalid, i, err)
+		}
+	}
+
+	response := &model.SecretBatchResponse{Results: make([]model.SecretBatchResult, len(operations))}
+	// identifiers are the audit details of each operation, computed while
+	// the plaintext is at hand
+	identifiers := make([]string, len(operations))
+	failed := false
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// The scoped service writes through tx, caches nothing that may be
+		// rolled back and leaves auditing to the end of the batch
+		scoped := *s
+		scoped.db = tx
+		scoped.rowCache = nil
+		scoped.auditService = nil
+
+		for i, operation := range operations {
+			result := &response.Results[i]
+			result.Index = i
+			result.Op = operation.Op
+			result.ID = operation.ID
+
+			result.Err = tx.Transaction(func(tx *gorm.DB) error {
+				item := scoped
+				item.db = tx
+				return item.runBatchOperation(mount, operation, userID, accessor, result, func(name, value string) {
+					identifiers[i] = s.auditIdentifiers(name, value)
+				})
+			})
+			if result.Err != nil {
+				failed = true
+			}
+		}
+		if failed {
+			return ErrSecretBatchRolledBack
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, ErrSecretBatchRolledBack) {
+		return nil, fmt.Errorf("failed to run secret batch: %w", err)
+	}
+	response.Committed = err == nil
+
+	if !response.Committed {
+		for i := range response.Results {
+			result := &response.Results[i]
+			result.Secret = nil
+			result.Value = nil
+			if result.Err == nil {
+				result.Err = ErrSecretBatchRolledBack
+			}
+		}
+		if s.auditService != nil {
+			s.auditService.LogAction(userID, "secrets_batch", "secret", "", false, batchSummary(mount, response))
+		}
+		return response, nil
+	}
+
+	// Rows read or written inside the transaction may have been cached by
+	// concurrent readers before it committed
+	s.rowCache.clear()
+
+	if s.auditService != nil {
+		for i, result := range response.Results {
+			resourceID := ""
+			if result.ID != nil {
+				resourceID = result.ID.String()
+			}
+			s.auditService.LogAction(userID, batchAuditAction[result.Op], "secret", resourceID, true, identifiers[i])
+		}
+		s.auditService.LogAction(userID, "secrets_batch", "secret", "", true, batchSummary(mount, response))
+	}
+
+	return response, nil
+}
+
+// runBatchOperation applies one operation through the scoped service s
+// and fills its result. identify records the audit identifiers of the
+// secret the operation touched.
+func (s *SecretService) runBatchOperation(mount string, operation model.SecretBatchOperation, userID uuid.UUID, accessor *uuid.UUID, result *model.SecretBatchResult, identify func(name, value string)) error {
+	if operation.Op == model.SecretBatchCreate {
+		req := operation.Create
+		if !req.ClientEncrypted {
+			validators, err := s.validators.Match(req.Name)
+			if err != nil {
+				return err
+			}
+			if len(validators) > 0 {
+				return ErrSecretBatchValidated
+			}
+		}
+
+		secret := &model.Secret{
+			Name:            req.Name,
+			Description:     req.Description,
+			Value:           req.Value,
+			Type:            req.Type,
+			Tags:            req.Tags,
+			Metadata:        req.Metadata,
+			ExpiresAt:       req.ExpiresAt,
+			RequestedTTL:    req.TTL,
+			NamespaceID:     req.NamespaceID,
+			Mount:           mount,
+			IsActive:        true,
+			ClientEncrypted: req.ClientEncrypted,
+			MaxVersions:     req.MaxVersions,
+			Fields:          req.Fields,
+		}
+		identify(req.Name, req.Value)
+		if err := s.CreateSecret(secret, userID); err != nil {
+			return err
+		}
+		result.ID = &secret.ID
+		result.Secret = secret
+		return nil
+	}
+
+	// Secrets outside the mount are not found, as on the routes by ID
+	row, err := s.secretRow(*operation.ID)
+	if err != nil {
+		return err
+	}
+	if row.Mount != mount {
+		return ErrSecretNotFound
+	}
+
+	switch operation.Op {
+	case model.SecretBatchUpdate:
+		updates := operation.Update
+		if updates.ActivateAt != nil {
+			return ErrSecretBatchScheduled
+		}
+		if updates.Value != nil {
+			name := row.Name
+			if updates.Name != nil {
+				name = *updates.Name
+			}
+			validators, err := s.validators.Match(name)
+			if err != nil {
+				return err
+			}
+			if len(validators) > 0 {
+				return ErrSecretBatchValidated
+			}
+		}
+
+		secret, err := s.UpdateSecret(*operation.ID, updates, userID)
+		if err != nil {
+			return err
+		}
+		identify(secret.Name, secret.Value)
+		result.Secret = secret
+
+	case model.SecretBatchRead:
+		secret, err := s.ReadSecretValue(*operation.ID, userID, accessor)
+		if err != nil {
+			return err
+		}
+		identify(secret.Name, secret.Value)
+		result.Value = &model.SecretValueResponse{
+			ID:              secret.ID,
+			Name:            secret.Name,
+			Value:           secret.Value,
+			ExpiresAt:       secret.ExpiresAt,
+			ClientEncrypted: secret.ClientEncrypted,
+			WithheldFields:  secret.WithheldFields,
+		}
+
+	case model.SecretBatchDelete:
+		if err := s.DeleteSecret(*operation.ID, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkBatchOperation checks an operation carries what its op needs
+func checkBatchOperation(operation model.SecretBatchOperation) error {
+	switch operation.Op {
+	case model.SecretBatchCreate:
+		if operation.Create == nil {
+			return errors.New("create is required")
+		}
+		if operation.Create.Name == "" || operation.Create.Value == "" || operation.Create.Type == "" {
+			return errors.New("create needs name, value and type")
+		}
+	case model.SecretBatchUpdate:
+		if operation.ID == nil || operation.Update == nil {
+			return errors.New("id and update are required")
+		}
+	case model.SecretBatchRead, model.SecretBatchDelete:
+		if operation.ID == nil {
+			return errors.New("id is required")
+		}
+	default:
+		return fmt.Errorf("unknown op %q", operation.Op)
+	}
+	return nil
+}
+
+var batchAuditAction = map[string]string{
+	model.SecretBatchCreate: "secret_created",
+	model.SecretBatchUpdate: "secret_updated",
+	model.SecretBatchRead:   "secret_accessed",
+	model.SecretBatchDelete: "secret_deleted",
+}
+
+// batchSummary describes a batch for its audit entry, without values
+func batchSummary(mount string, response *model.SecretBatchResponse) string {
+	failed := 0
+	for _, result := range response.Results {
+		if result.Err != nil && !errors.Is(result.Err, ErrSecretBatchRolledBack) {
+			failed++
+		}
+	}
+	return "mount=" + mount + "; operations=" + strconv.Itoa(len(response.Results)) + "; failed=" + strconv.Itoa(failed)
+}
+
+var (
+	ErrSecretBatchInvalid = errors.New("invalid secret batch")
+	// ErrSecretBatchValidated means the write would wait for validators,
+	// which run after the request and cannot join its transaction
+	ErrSecretBatchValidated  = errors.New("values under validated paths cannot be written in a batch")
+	ErrSecretBatchScheduled  = errors.New("scheduled values cannot be written in a batch")
+	ErrSecretBatchRolledBack = errors.New("rolled back: another operation of the batch failed")
+)
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// GetBundle returns the user's secrets named "<prefix>/<key>" in a KV
+// mount, keyed by the rest of their name. With since set to a revision
+// returned earlier only the keys written or deleted after it are returned,
+// so a client holding a cached bundle can catch up cheaply. Each value is
+// read through requireValueRead, so one secret needing a step-up
+// verification the token accessor names lacks, or a control group
+// approval, fails the whole bundle.
+func (s *SecretService) GetBundle(userID uuid.UUID, mount, prefix string, since int64, accessor *uuid.UUID) (*model.SecretBundle, error) {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return nil, ErrBundlePrefixRequired
+	}
+
+	// Deleted rows are included so deletions move the revision and show
+	// up in deltas
+	query := s.db.Unscoped().
+		Where("user_id = ? AND mount = ? AND name LIKE ?", userID, mount, likeEscaper.Replace(prefix)+"/%")
+	if since > 0 {
+		after := time.UnixMicro(since)
+		query = query.Where("updated_at > ? OR deleted_at > ?", after, after)
+	}
+
+	var rows []model.Secret
+	if err := query.Order("updated_at").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get secret bundle: %w", err)
+	}
+
+	bundle := &model.SecretBundle{
+		Prefix:   prefix,
+		Revision: since,
+		Delta:    since > 0,
+		Since:    since,
+		Secrets:  map[string]string{},
+	}
+	deleted := map[string]bool{}
+	for i := range rows {
+		row := &rows[i]
+		bundle.Revision = max(bundle.Revision, row.UpdatedAt.UnixMicro())
+		if row.DeletedAt.Valid {
+			bundle.Revision = max(bundle.Revision, row.DeletedAt.Time.UnixMicro())
+		}
+
+		key := strings.TrimPrefix(row.Name, prefix+"/")
+		if row.DeletedAt.Valid || !row.IsActive {
+			deleted[key] = true
+			continue
+		}
+
+		// Checkout secrets are only read through a checkout
+		if row.CheckoutRequired {
+			continue
+		}
+		if err := s.requireValueRead(row, userID, accessor); err != nil {
+			return nil, err
+		}
+		value, err := s.openSecret(row)
+		// Quarantined rows are left out rather than failing the bundle
+		if errors.Is(err, ErrSecretQuarantined) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt secret: %w", err)
+		}
+		bundle.Secrets[key] = value
+		s.accessTracker.Record(row.ID)
+	}
+
+	// A key deleted and written again since the revision is only live
+	if bundle.Delta {
+		for key := range deleted {
+			if _, ok := bundle.Secrets[key]; !ok {
+				bundle.Deleted = append(bundle.Deleted, key)
+			}
+		}
+		sort.Strings(bundle.Deleted)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "secret_bundle_read", "secret", "", true, fmt.Sprintf("mount=%s prefix=%s since=%d keys=%d", mount, prefix, since, len(bundle.Secrets)))
+	}
+
+	return bundle, nil
+}
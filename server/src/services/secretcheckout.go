@@ -0,0 +1,307 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultCheckoutTTL = time.Hour
+	maxCheckoutTTL     = 24 * time.Hour
+)
+
+// SecretCheckoutService gives one user at a time the value of a secret
+// marked checkout_required, such as a root password. Checking the secret
+// in, whether by the holder, an admin or the checkout's expiry, rotates
+// it with its rotator so the value the holder saw stops working.
+type SecretCheckoutService struct {
+	db            *gorm.DB
+	secretService *SecretService
+	rotation      *SecretRotationService
+	auditService  *AuditService
+	expiry        *ExpiryScheduler
+}
+
+func NewSecretCheckoutService(db *gorm.DB, secretService *SecretService, rotation *SecretRotationService, auditService *AuditService) *SecretCheckoutService {
+	return &SecretCheckoutService{
+		db:            db,
+		secretService: secretService,
+		rotation:      rotation,
+		auditService:  auditService,
+	}
+}
+
+// UseCheckouts keeps the values of checkout_required secrets from anyone
+// but the holder of their checkout
+func (s *SecretService) UseCheckouts(checkouts *SecretCheckoutService) {
+	s.checkouts = checkouts
+}
+
+// UseExpiry checks each checkout in when it expires
+func (s *SecretCheckoutService) UseExpiry(scheduler *ExpiryScheduler) {
+	s.expiry = scheduler
+	scheduler.Handle(model.ExpiryKindSecretCheckout, s.expireCheckout)
+}
+
+// requireCheckout refuses the value of a checkout_required secret to
+// everyone but the holder of its checkout
+func (s *SecretService) requireCheckout(secret *model.Secret, userID uuid.UUID) error {
+	if !secret.CheckoutRequired {
+		return nil
+	}
+	if s.checkouts == nil {
+		return ErrSecretCheckoutRequired
+	}
+	checkout, err := s.checkouts.active(secret.ID)
+	if errors.Is(err, ErrSecretNotCheckedOut) {
+		return ErrSecretCheckoutRequired
+	}
+	if err != nil {
+		return err
+	}
+	if checkout.UserID != userID || !time.Now().Before(checkout.ExpiresAt) {
+		return ErrSecretCheckoutRequired
+	}
+	return nil
+}
+
+// Checkout checks the secret out to userID and returns its value. The
+// holder checking out again gets the value under the checkout they hold.
+func (s *SecretCheckoutService) Checkout(id uuid.UUID, req *model.CheckoutSecretRequest, userID uuid.UUID, accessor *uuid.UUID) (*model.CheckoutSecretResponse, error) {
+	secret, err := s.readable(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !secret.CheckoutRequired {
+		return nil, ErrSecretCheckoutNotRequired
+	}
+
+	ttl := defaultCheckoutTTL
+	if req.TTL > 0 {
+		ttl = time.Duration(req.TTL) * time.Second
+	}
+	if ttl > maxCheckoutTTL {
+		return nil, fmt.Errorf("%w: ttl must be at most %d seconds", ErrSecretCheckoutInvalid, int(maxCheckoutTTL.Seconds()))
+	}
+
+	checkout, created, err := s.acquire(secret, userID, ttl, req.Reason)
+	if err != nil {
+		return nil, err
+	}
+
+	read, err := s.secretService.ReadSecretValue(id, userID, accessor)
+	if err != nil {
+		if created {
+			// Nothing was handed out; give the secret back without a
+			// rotation
+			if releaseErr := s.db.Delete(&model.SecretCheckout{}, "id = ?", checkout.ID).Error; releaseErr != nil {
+				log.Printf("⚠️  Failed to release checkout %s: %v", checkout.ID, releaseErr)
+			}
+		}
+		return nil, err
+	}
+
+	if created {
+		if err := s.expiry.Schedule(model.ExpiryKindSecretCheckout, checkout.ID.String(), checkout.ExpiresAt); err != nil {
+			log.Printf("⚠️  Failed to schedule checkout %s expiry: %v", checkout.ID, err)
+		}
+		if s.auditService != nil {
+			details := fmt.Sprintf("checkout=%s expires_at=%s", checkout.ID.String(), checkout.ExpiresAt.Format(time.RFC3339))
+			if req.Reason != "" {
+				details += "; reason=" + req.Reason
+			}
+			s.auditService.LogAction(userID, "secret_checked_out", "secret", id.String(), true, details)
+		}
+	}
+
+	return &model.CheckoutSecretResponse{SecretCheckout: *checkout, Value: read.Value}, nil
+}
+
+// acquire creates the checkout of secret for userID, or returns the one
+// userID already holds. A checkout past its expiry that the scheduler has
+// not checked in yet is checked in first.
+func (s *SecretCheckoutService) acquire(secret *model.Secret, userID uuid.UUID, ttl time.Duration, reason string) (*model.SecretCheckout, bool, error) {
+	for attempt := 0; ; attempt++ {
+		now := time.Now()
+		active := true
+		checkout := &model.SecretCheckout{
+			SecretID:     secret.ID,
+			UserID:       userID,
+			Active:       &active,
+			CheckedOutAt: now,
+			ExpiresAt:    now.Add(ttl),
+			Reason:       reason,
+		}
+		createErr := s.db.Create(checkout).Error
+		if createErr == nil {
+			return checkout, true, nil
+		}
+
+		// The unique index refused a second active checkout
+		holder, err := s.active(secret.ID)
+		if errors.Is(err, ErrSecretNotCheckedOut) && attempt == 0 {
+			continue
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to check out secret: %w", createErr)
+		}
+		if holder.UserID == userID && now.Before(holder.ExpiresAt) {
+			return holder, false, nil
+		}
+		if !now.Before(holder.ExpiresAt) && attempt == 0 {
+			if _, err := s.checkin(holder, nil, false, "expired"); err != nil && !errors.Is(err, ErrSecretNotCheckedOut) {
+				return nil, false, err
+			}
+			continue
+		}
+
+		if s.auditService != nil {
+			s.auditService.LogAction(userID, "secret_checked_out", "secret", secret.ID.String(), false, "held by "+holder.UserID.String())
+		}
+		return nil, false, fmt.Errorf("%w until %s", ErrSecretCheckedOut, holder.ExpiresAt.Format(time.RFC3339))
+	}
+}
+
+// GetCheckout returns the active checkout of a secret the user may read,
+// or nil when it is not checked out
+func (s *SecretCheckoutService) GetCheckout(id uuid.UUID, userID uuid.UUID) (*model.SecretCheckout, error) {
+	if _, err := s.readable(id, userID); err != nil {
+		return nil, err
+	}
+	checkout, err := s.active(id)
+	if errors.Is(err, ErrSecretNotCheckedOut) {
+		return nil, nil
+	}
+	return checkout, err
+}
+
+// Checkin returns the secret userID holds and rotates it
+func (s *SecretCheckoutService) Checkin(id uuid.UUID, reason string, userID uuid.UUID) (*model.SecretCheckout, error) {
+	checkout, err := s.active(id)
+	if err != nil {
+		return nil, err
+	}
+	if checkout.UserID != userID {
+		return nil, fmt.Errorf("%w to you", ErrSecretNotCheckedOut)
+	}
+	return s.checkin(checkout, &userID, false, reason)
+}
+
+// ForceCheckin takes the secret back from whoever holds it and rotates it
+func (s *SecretCheckoutService) ForceCheckin(id uuid.UUID, reason string, adminID uuid.UUID) (*model.SecretCheckout, error) {
+	checkout, err := s.active(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.checkin(checkout, &adminID, true, reason)
+}
+
+// checkin ends checkout and rotates its secret. A failed rotation does not
+// undo the check-in: it is recorded on the checkout and the secret, and
+// the rotation job tries again.
+func (s *SecretCheckoutService) checkin(checkout *model.SecretCheckout, by *uuid.UUID, forced bool, reason string) (*model.SecretCheckout, error) {
+	now := time.Now()
+	result := s.db.Model(&model.SecretCheckout{}).
+		Where("id = ? AND active = ?", checkout.ID, true).
+		Updates(map[string]interface{}{
+			"active":        nil,
+			"checked_in_at": now,
+			"checked_in_by": by,
+			"forced":        forced,
+		})
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to check in secret: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		// Checked in concurrently, by its holder, an admin or its expiry
+		return nil, ErrSecretNotCheckedOut
+	}
+	checkout.Active, checkout.CheckedInAt, checkout.CheckedInBy, checkout.Forced = nil, &now, by, forced
+
+	if err := s.expiry.Cancel(model.ExpiryKindSecretCheckout, checkout.ID.String()); err != nil {
+		log.Printf("⚠️  Failed to cancel checkout %s expiry: %v", checkout.ID, err)
+	}
+
+	if err := s.rotation.RotateNow(context.Background(), checkout.SecretID); err != nil {
+		checkout.RotationError = err.Error()
+		if err := s.db.Model(&model.SecretCheckout{}).Where("id = ?", checkout.ID).Update("rotation_error", checkout.RotationError).Error; err != nil {
+			log.Printf("⚠️  Failed to record rotation failure of checkout %s: %v", checkout.ID, err)
+		}
+	}
+
+	if s.auditService != nil {
+		details := fmt.Sprintf("checkout=%s holder=%s forced=%t rotated=%t", checkout.ID.String(), checkout.UserID.String(), forced, checkout.RotationError == "")
+		if reason != "" {
+			details += "; reason=" + reason
+		}
+		if by != nil {
+			s.auditService.LogAction(*by, "secret_checked_in", "secret", checkout.SecretID.String(), true, details)
+		} else {
+			s.auditService.LogAnonymousAction("secret_checked_in", "secret", checkout.SecretID.String(), "", "", true, details)
+		}
+	}
+	return checkout, nil
+}
+
+// expireCheckout checks in a checkout whose time is up
+func (s *SecretCheckoutService) expireCheckout(ctx context.Context, item string) error {
+	id, err := uuid.Parse(item)
+	if err != nil {
+		return nil
+	}
+	var checkout model.SecretCheckout
+	if err := s.db.WithContext(ctx).Where("id = ? AND active = ?", id, true).First(&checkout).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to get checkout: %w", err)
+	}
+	if _, err := s.checkin(&checkout, nil, false, "expired"); err != nil && !errors.Is(err, ErrSecretNotCheckedOut) {
+		return err
+	}
+	return nil
+}
+
+func (s *SecretCheckoutService) active(secretID uuid.UUID) (*model.SecretCheckout, error) {
+	var checkout model.SecretCheckout
+	if err := s.db.Where("secret_id = ? AND active = ?", secretID, true).First(&checkout).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSecretNotCheckedOut
+		}
+		return nil, fmt.Errorf("failed to get checkout: %w", err)
+	}
+	return &checkout, nil
+}
+
+// readable returns the secret when userID owns it or may read it
+func (s *SecretCheckoutService) readable(id uuid.UUID, userID uuid.UUID) (*model.Secret, error) {
+	secret, err := s.secretService.secretRow(id)
+	if err != nil {
+		return nil, err
+	}
+	if secret.UserID != userID {
+		allowed, err := s.secretService.canRead(userID, &secret)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, ErrSecretNotFound
+		}
+	}
+	return &secret, nil
+}
+
+var (
+	ErrSecretCheckoutRequired    = errors.New("secret must be checked out before it is read")
+	ErrSecretCheckoutNotRequired = errors.New("secret does not use checkouts; read it directly")
+	ErrSecretCheckedOut          = errors.New("secret is checked out by another user")
+	ErrSecretNotCheckedOut       = errors.New("secret is not checked out")
+	ErrSecretCheckoutInvalid     = errors.New("invalid checkout")
+)
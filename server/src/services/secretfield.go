@@ -0,0 +1,219 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+)
+
+// UseFieldPolicies lets namespace members with secrets:read, and users
+// whose policies grant read on a secret, read its value filtered by the
+// per-field rules
+func (s *SecretService) UseFieldPolicies(policies *PolicyService, namespaces *NamespaceService) {
+	s.policies = policies
+	s.namespaces = namespaces
+}
+
+// validateFields checks field rules against the value they will apply to:
+// only JSON objects have fields, and the server must be able to read them
+func validateFields(fields []model.SecretField, value string, clientEncrypted bool) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	if clientEncrypted {
+		return fmt.Errorf("%w: client-encrypted values have no readable fields", ErrSecretFieldsInvalid)
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(value), &object); err != nil {
+		return fmt.Errorf("%w: the value must be a JSON object", ErrSecretFieldsInvalid)
+	}
+
+	seen := make(map[string]bool, len(fields))
+	for i := range fields {
+		field := &fields[i]
+		field.Name = strings.TrimSpace(field.Name)
+		if field.Name == "" || seen[field.Name] {
+			return fmt.Errorf("%w: field names must be unique and not empty", ErrSecretFieldsInvalid)
+		}
+		seen[field.Name] = true
+
+		switch field.Sensitivity {
+		case "":
+			field.Sensitivity = model.SecretFieldInternal
+		case model.SecretFieldPublic, model.SecretFieldInternal, model.SecretFieldRestricted:
+		default:
+			return fmt.Errorf("%w: unknown sensitivity %q", ErrSecretFieldsInvalid, field.Sensitivity)
+		}
+		field.Policy = strings.Trim(strings.TrimSpace(field.Policy), "/")
+	}
+	return nil
+}
+
+// secretFields returns the field rules of a secret
+func (s *SecretService) secretFields(id uuid.UUID) ([]model.SecretField, error) {
+	fields := []model.SecretField{}
+	if err := s.db.Where("secret_id = ?", id).Order("name").Find(&fields).Error; err != nil {
+		return nil, fmt.Errorf("failed to get secret fields: %w", err)
+	}
+	return fields, nil
+}
+
+// replaceFields swaps the field rules of a secret inside tx
+func (s *SecretService) replaceFields(tx *gorm.DB, id uuid.UUID, fields []model.SecretField) error {
+	if err := tx.Where("secret_id = ?", id).Delete(&model.SecretField{}).Error; err != nil {
+		return err
+	}
+	for i := range fields {
+		fields[i].ID = uuid.Nil
+		fields[i].SecretID = id
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return tx.Create(&fields).Error
+}
+
+// ReadSecretValue opens a secret for its owner, for a member of its
+// namespace with secrets:read, or for a user whose policies grant read on
+// it. Readers other than the owner only get the fields their policies
+// allow; the owner always reads the whole value. Policy rules with mfa
+// matching the secret, even the owner's, need a recent verification on the
+// token accessor names, and those with a control_group an approved request.
+func (s *SecretService) ReadSecretValue(id uuid.UUID, userID uuid.UUID, accessor *uuid.UUID) (*model.Secret, error) {
+	secret, err := s.secretRow(id)
+	if err != nil {
+		return nil, err
+	}
+	owner := secret.UserID == userID
+	if !owner {
+		allowed, err := s.canRead(userID, &secret)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, ErrSecretNotFound
+		}
+	}
+	if err := s.requireValueRead(&secret, userID, accessor); err != nil {
+		return nil, err
+	}
+	s.accessStats.Record(AccessKindSecret, id.String())
+	s.accessTracker.Record(id)
+	if secret.NamespaceID != nil {
+		s.quotas.RecordRequest(*secret.NamespaceID)
+	}
+
+	value, err := s.openSecret(&secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+	secret.Fields, err = s.secretFields(id)
+	if err != nil {
+		return nil, err
+	}
+
+	details := s.auditIdentifiers(secret.Name, value)
+	if !owner && len(secret.Fields) > 0 {
+		value, secret.WithheldFields, err = s.filterFields(userID, value, secret.Fields)
+		if err != nil {
+			if s.auditService != nil {
+				s.auditService.LogAction(userID, "secret_accessed", "secret", secret.ID.String(), false, err.Error())
+			}
+			return nil, err
+		}
+		if len(secret.WithheldFields) > 0 {
+			details = strings.TrimPrefix(details+"; withheld="+strings.Join(secret.WithheldFields, ","), "; ")
+		}
+	}
+	secret.Value = value
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "secret_accessed", "secret", secret.ID.String(), true, details)
+	}
+
+	return &secret, nil
+}
+
+// canRead reports whether userID, who does not own secret, may read it:
+// through a namespace role granting secrets:read, a reader role on the
+// secret's project, or a policy granting read on its path or its tags
+func (s *SecretService) canRead(userID uuid.UUID, secret *model.Secret) (bool, error) {
+	if secret.NamespaceID != nil && s.namespaces != nil {
+		allowed, err := s.namespaces.HasPermission(userID, *secret.NamespaceID, model.NamespacePermissionSecretsRead)
+		if err != nil || allowed {
+			return allowed, err
+		}
+	}
+	if secret.ProjectID != nil && s.orgs != nil {
+		allowed, err := s.orgs.HasProjectRole(userID, *secret.ProjectID, model.OrgRoleReader)
+		if err != nil || allowed {
+			return allowed, err
+		}
+	}
+	if s.policies == nil {
+		return false, nil
+	}
+	return s.policies.CheckTaggedAccess(userID, SecretPolicyPath(secret), "read", SplitTags(secret.Tags))
+}
+
+// filterFields drops the fields of a JSON value userID may not read and
+// returns their names. A value that is no longer an object is withheld
+// entirely rather than guessed at.
+func (s *SecretService) filterFields(userID uuid.UUID, value string, fields []model.SecretField) (string, []string, error) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(value), &object); err != nil {
+		return "", nil, ErrSecretFieldsUnreadable
+	}
+
+	var withheld []string
+	for _, field := range fields {
+		if _, ok := object[field.Name]; !ok {
+			continue
+		}
+		readable, err := s.fieldReadable(userID, field)
+		if err != nil {
+			return "", nil, err
+		}
+		if !readable {
+			delete(object, field.Name)
+			withheld = append(withheld, field.Name)
+		}
+	}
+	if len(withheld) == 0 {
+		return value, nil, nil
+	}
+
+	filtered, err := json.Marshal(object)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to encode secret fields: %w", err)
+	}
+	return string(filtered), withheld, nil
+}
+
+// fieldReadable reports whether a reader other than the owner may read a
+// field: a named policy must grant read, and restricted fields need one
+func (s *SecretService) fieldReadable(userID uuid.UUID, field model.SecretField) (bool, error) {
+	if field.Policy == "" {
+		return field.Sensitivity != model.SecretFieldRestricted, nil
+	}
+	if s.policies == nil {
+		return false, nil
+	}
+	allowed, err := s.policies.CheckAccess(userID, field.Policy, "read")
+	if err != nil {
+		return false, fmt.Errorf("failed to check policies: %w", err)
+	}
+	return allowed, nil
+}
+
+var (
+	ErrSecretFieldsInvalid = errors.New("invalid secret fields")
+	// ErrSecretFieldsUnreadable means a value with field rules is not a
+	// JSON object, so the rules cannot be applied
+	ErrSecretFieldsUnreadable = errors.New("secret value cannot be filtered by field")
+)
@@ -0,0 +1,325 @@
+package services
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/hkdf"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+)
+
+// importInfo separates the AES key derived for imports from any other use
+// of an ECDH exchange with the import key
+const importInfo = "aether-vault import v1"
+
+// SecretImportService creates secrets from values encrypted on the client
+// to a key pair the vault publishes, so the plaintext is never visible to
+// TLS-terminating proxies between the client and the vault. The private
+// halves are sealed like any other key material the vault holds.
+type SecretImportService struct {
+	db            *gorm.DB
+	secretService *SecretService
+	auditService  *AuditService
+}
+
+func NewSecretImportService(db *gorm.DB, secretService *SecretService, auditService *AuditService) *SecretImportService {
+	return &SecretImportService{
+		db:            db,
+		secretService: secretService,
+		auditService:  auditService,
+	}
+}
+
+// CurrentKey returns the current import key, creating the first one on
+// demand
+func (s *SecretImportService) CurrentKey(userID uuid.UUID) (*model.ImportKeyResponse, error) {
+	key, err := s.currentKey()
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		key, err = s.createKey(s.db, userID)
+		if err != nil {
+			// Another instance may have created it first; the unique
+			// index refused ours
+			if existing, lookupErr := s.currentKey(); lookupErr == nil {
+				key, err = existing, nil
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return importKeyResponse(key), nil
+}
+
+// RotateKey retires the current import key and creates its successor.
+// Imports still encrypted to the retired key are refused and must be
+// encrypted again to the new one.
+func (s *SecretImportService) RotateKey(userID uuid.UUID) (*model.ImportKeyResponse, error) {
+	var key *model.ImportKey
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.ImportKey{}).Where("is_current = ?", true).Updates(map[string]interface{}{
+			"is_current": nil,
+			"retired_at": time.Now(),
+		}).Error; err != nil {
+			return fmt.Errorf("failed to retire import key: %w", err)
+		}
+		var err error
+		key, err = s.createKey(tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "import_key_rotated", "import_key", key.ID.String(), true, "")
+	}
+	return importKeyResponse(key), nil
+}
+
+// Import opens the value of req and creates the secret in mount as
+// CreateSecret does
+func (s *SecretImportService) Import(req *model.ImportSecretRequest, mount string, userID uuid.UUID) (*model.Secret, error) {
+	var key model.ImportKey
+	if err := s.db.Where("id = ?", req.KeyID).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrImportKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to get import key: %w", err)
+	}
+	if key.IsCurrent == nil {
+		return nil, ErrImportKeyRetired
+	}
+
+	value, err := s.open(&key, req)
+	if err != nil {
+		if s.auditService != nil {
+			s.auditService.LogAction(userID, "secret_imported", "secret", "", false, "key="+key.ID.String()+"; algorithm="+req.Algorithm)
+		}
+		return nil, err
+	}
+
+	secret := &model.Secret{
+		Name:             req.Name,
+		Description:      req.Description,
+		Value:            string(value),
+		Type:             req.Type,
+		Tags:             req.Tags,
+		Metadata:         req.Metadata,
+		ExpiresAt:        req.ExpiresAt,
+		RequestedTTL:     req.TTL,
+		NamespaceID:      req.NamespaceID,
+		ProjectID:        req.ProjectID,
+		Mount:            mount,
+		IsActive:         true,
+		MaxVersions:      req.MaxVersions,
+		RotationPeriod:   req.RotationPeriod,
+		Rotator:          req.Rotator,
+		CheckoutRequired: req.CheckoutRequired,
+	}
+	if err := s.secretService.CreateSecret(secret, userID); err != nil {
+		return nil, err
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "secret_imported", "secret", secret.ID.String(), true, "key="+key.ID.String()+"; algorithm="+req.Algorithm)
+	}
+	return secret, nil
+}
+
+// open recovers the AES key the client chose or derived and opens the
+// value with it. Every failure reads the same, so the endpoint cannot be
+// used as an oracle on the wrapped key.
+func (s *SecretImportService) open(key *model.ImportKey, req *model.ImportSecretRequest) ([]byte, error) {
+	encryptedKey, err := base64.StdEncoding.DecodeString(req.EncryptedKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: encrypted_key is not base64", ErrImportInvalid)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(req.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("%w: ciphertext is not base64", ErrImportInvalid)
+	}
+
+	var aesKey []byte
+	switch req.Algorithm {
+	case model.ImportAlgorithmRSA:
+		private, err := s.privateKey(key.RSAPrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		rsaKey, ok := private.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("import key is not an RSA key")
+		}
+		aesKey, err = rsa.DecryptOAEP(sha256.New(), nil, rsaKey, encryptedKey, nil)
+		if err != nil {
+			return nil, ErrImportDecryptFailed
+		}
+	case model.ImportAlgorithmECDH:
+		private, err := s.privateKey(key.ECDHPrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		ecdsaKey, ok := private.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("import key is not an EC key")
+		}
+		ecdhKey, err := ecdsaKey.ECDH()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load import key: %w", err)
+		}
+		peer, err := parseECDHPublicKey(encryptedKey)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrImportInvalid, err)
+		}
+		shared, err := ecdhKey.ECDH(peer)
+		if err != nil {
+			return nil, ErrImportDecryptFailed
+		}
+		aesKey, err = hkdf.Key(sha256.New, shared, nil, importInfo, 32)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive import key: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown algorithm %q", ErrImportInvalid, req.Algorithm)
+	}
+	if len(aesKey) != 32 {
+		return nil, ErrImportDecryptFailed
+	}
+
+	block, err := aes.NewCipher(aesKey)
+	if err != nil {
+		return nil, ErrImportDecryptFailed
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, ErrImportDecryptFailed
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, ErrImportDecryptFailed
+	}
+	// The key ID as additional data keeps a value sealed for one import
+	// key from being replayed against another
+	value, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], []byte(key.ID.String()))
+	if err != nil {
+		return nil, ErrImportDecryptFailed
+	}
+	return value, nil
+}
+
+func (s *SecretImportService) currentKey() (*model.ImportKey, error) {
+	var key model.ImportKey
+	if err := s.db.Where("is_current = ?", true).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get import key: %w", err)
+	}
+	return &key, nil
+}
+
+// createKey generates an RSA-4096 and a P-256 key pair as the new current
+// import key, inside tx
+func (s *SecretImportService) createKey(tx *gorm.DB, userID uuid.UUID) (*model.ImportKey, error) {
+	rsaKey, err := generatePKIKey(model.PKIKeyTypeRSA, 4096)
+	if err != nil {
+		return nil, err
+	}
+	ecKey, err := generatePKIKey(model.PKIKeyTypeEC, 256)
+	if err != nil {
+		return nil, err
+	}
+
+	current := true
+	key := &model.ImportKey{IsCurrent: &current, CreatedBy: userID}
+	if key.RSAPublicKey, key.RSAPrivateKey, err = s.sealKeyPair(rsaKey); err != nil {
+		return nil, err
+	}
+	if key.ECDHPublicKey, key.ECDHPrivateKey, err = s.sealKeyPair(ecKey); err != nil {
+		return nil, err
+	}
+	if err := tx.Create(key).Error; err != nil {
+		return nil, fmt.Errorf("failed to create import key: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "import_key_created", "import_key", key.ID.String(), true, "")
+	}
+	return key, nil
+}
+
+// sealKeyPair returns the PEM public key and the sealed PEM private key
+func (s *SecretImportService) sealKeyPair(key crypto.Signer) (string, string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode public key: %w", err)
+	}
+	privatePEM, err := encodePKIKey(key)
+	if err != nil {
+		return "", "", err
+	}
+	sealed, err := s.secretService.encrypt(privatePEM)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encrypt import key: %w", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), sealed, nil
+}
+
+func (s *SecretImportService) privateKey(sealed string) (interface{}, error) {
+	decrypted, err := s.secretService.decrypt(sealed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt import key: %w", err)
+	}
+	block, _ := pem.Decode([]byte(decrypted))
+	if block == nil {
+		return nil, errors.New("invalid import private key")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse import key: %w", err)
+	}
+	return key, nil
+}
+
+// parseECDHPublicKey accepts a P-256 public key as PKIX DER, as OpenSSL
+// writes it, or as an uncompressed point, as WebCrypto exports it raw
+func parseECDHPublicKey(data []byte) (*ecdh.PublicKey, error) {
+	if parsed, err := x509.ParsePKIXPublicKey(data); err == nil {
+		ecdsaKey, ok := parsed.(*ecdsa.PublicKey)
+		if !ok {
+			return nil, errors.New("encrypted_key is not an EC public key")
+		}
+		return ecdsaKey.ECDH()
+	}
+	key, err := ecdh.P256().NewPublicKey(data)
+	if err != nil {
+		return nil, errors.New("encrypted_key is not a P-256 public key")
+	}
+	return key, nil
+}
+
+func importKeyResponse(key *model.ImportKey) *model.ImportKeyResponse {
+	return &model.ImportKeyResponse{
+		ImportKey:  *key,
+		Algorithms: []string{model.ImportAlgorithmRSA, model.ImportAlgorithmECDH},
+	}
+}
+
+var (
+	ErrImportKeyNotFound   = errors.New("import key not found")
+	ErrImportKeyRetired    = errors.New("import key has been retired; encrypt the value to the current key")
+	ErrImportInvalid       = errors.New("invalid import")
+	ErrImportDecryptFailed = errors.New("the value could not be decrypted with the import key")
+)
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+)
+
+const (
+	defaultSecretLeaseTTL = time.Hour
+	maxSecretLeaseTTL     = 24 * time.Hour
+)
+
+// UseLeases lets value reads ask for a lease. A static value cannot be
+// taken back, so its lease revokes nothing: it tells the reader how long
+// to keep the value and records who holds a copy.
+func (s *SecretService) UseLeases(leases *LeaseService) {
+	s.leases = leases
+	leases.RegisterIssuer(model.LeaseIssuerSecret, LeaseIssuer{})
+}
+
+// LeaseRead leases a value just read to userID for ttl seconds, or the
+// mount's default_lease_ttl when ttl is zero
+func (s *SecretService) LeaseRead(secret *model.Secret, userID uuid.UUID, ttl int) (*model.Lease, error) {
+	if s.leases == nil {
+		return nil, ErrLeasesUnavailable
+	}
+	mount, err := s.kvMount(secret.Mount)
+	if err != nil {
+		return nil, err
+	}
+	resolution, err := resolveLeaseTTL(mount, ttl, defaultSecretLeaseTTL, maxSecretLeaseTTL, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return s.leases.Issue(model.LeaseIssuerSecret, secret.ID.String(), userID, secret.NamespaceID, resolution, true)
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+)
+
+// validateSecretPath checks a secret name is a slash-delimited path such as
+// "apps/payments/prod/db", with no empty, "." or ".." segments
+func validateSecretPath(name string) error {
+	if name == "" {
+		return fmt.Errorf("%w: name is required", ErrSecretPathInvalid)
+	}
+	for _, segment := range strings.Split(name, "/") {
+		switch segment {
+		case "":
+			return fmt.Errorf("%w: %q has an empty segment", ErrSecretPathInvalid, name)
+		case ".", "..":
+			return fmt.Errorf("%w: %q has a relative segment", ErrSecretPathInvalid, name)
+		}
+	}
+	return nil
+}
+
+// ListKeys returns the keys below prefix in a KV mount. depth limits how
+// many segments below prefix a key spells out, deeper secrets showing as
+// a folder "<segment>/"; zero lists every secret in full. The user sees
+// their own secrets, and others' where their namespace role grants
+// secrets:read or their policies grant list.
+func (s *SecretService) ListKeys(userID uuid.UUID, mount, prefix string, depth int) (*model.SecretKeyList, error) {
+	prefix = strings.Trim(prefix, "/")
+	if depth < 0 {
+		return nil, fmt.Errorf("%w: depth must not be negative", ErrSecretPathInvalid)
+	}
+
+	query := s.db.Select("id", "user_id", "name", "mount", "tags", "namespace_id").
+		Where("mount = ? AND is_active = ?", mount, true)
+	if prefix != "" {
+		query = query.Where("name LIKE ?", likeEscaper.Replace(prefix)+"/%")
+	}
+	var rows []model.Secret
+	if err := query.Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to list secrets: %w", err)
+	}
+
+	list := &model.SecretKeyList{Mount: mount, Prefix: prefix, Depth: depth, Keys: []string{}}
+	seen := map[string]bool{}
+	namespaces, projects := map[uuid.UUID]bool{}, map[uuid.UUID]bool{}
+	for i := range rows {
+		row := &rows[i]
+		if row.UserID != userID {
+			allowed, err := s.canList(userID, row, namespaces, projects)
+			if err != nil {
+				return nil, err
+			}
+			if !allowed {
+				continue
+			}
+		}
+
+		key := row.Name
+		if prefix != "" {
+			key = strings.TrimPrefix(key, prefix+"/")
+		}
+		if depth > 0 {
+			if segments := strings.SplitN(key, "/", depth+1); len(segments) > depth {
+				key = strings.Join(segments[:depth], "/") + "/"
+			}
+		}
+		if !seen[key] {
+			seen[key] = true
+			list.Keys = append(list.Keys, key)
+		}
+	}
+	sort.Strings(list.Keys)
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "secrets_listed", "secret", "", true, "mount="+mount+"; prefix="+prefix)
+	}
+
+	return list, nil
+}
+
+// canList reports whether userID may see the key of a secret they do not
+// own. namespaces and projects remember the namespace permissions and
+// project roles already checked.
+func (s *SecretService) canList(userID uuid.UUID, secret *model.Secret, namespaces, projects map[uuid.UUID]bool) (bool, error) {
+	if secret.NamespaceID != nil && s.namespaces != nil {
+		allowed, checked := namespaces[*secret.NamespaceID]
+		if !checked {
+			var err error
+			allowed, err = s.namespaces.HasPermission(userID, *secret.NamespaceID, model.NamespacePermissionSecretsRead)
+			if err != nil {
+				return false, err
+			}
+			namespaces[*secret.NamespaceID] = allowed
+		}
+		if allowed {
+			return true, nil
+		}
+	}
+	if secret.ProjectID != nil && s.orgs != nil {
+		allowed, checked := projects[*secret.ProjectID]
+		if !checked {
+			var err error
+			allowed, err = s.orgs.HasProjectRole(userID, *secret.ProjectID, model.OrgRoleReader)
+			if err != nil {
+				return false, err
+			}
+			projects[*secret.ProjectID] = allowed
+		}
+		if allowed {
+			return true, nil
+		}
+	}
+	if s.policies == nil {
+		return false, nil
+	}
+	return s.policies.CheckTaggedAccess(userID, SecretPolicyPath(secret), "list", SplitTags(secret.Tags))
+}
+
+var ErrSecretPathInvalid = errors.New("invalid secret path")
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UseExpiry publishes values written with activate_at when their time
+// comes
+func (s *SecretService) UseExpiry(scheduler *ExpiryScheduler) {
+	s.expiry = scheduler
+	scheduler.Handle(model.ExpiryKindSecretPublication, s.publishScheduled)
+}
+
+// schedulePublication holds a value, sealed like the secret itself, until
+// activateAt
+func (s *SecretService) schedulePublication(secret *model.Secret, plaintext string, clientEncrypted bool, activateAt time.Time, userID uuid.UUID) (*model.SecretPublication, error) {
+	sealed := model.Secret{NamespaceID: secret.NamespaceID}
+	if err := s.sealSecret(&sealed, plaintext); err != nil {
+		return nil, fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+
+	publication := &model.SecretPublication{
+		SecretID:        secret.ID,
+		Status:          model.SecretPublicationScheduled,
+		Value:           sealed.Value,
+		WrappedKey:      sealed.WrappedKey,
+		ClientEncrypted: clientEncrypted,
+		ActivateAt:      activateAt,
+		RequestedBy:     userID,
+	}
+	if err := s.db.Create(publication).Error; err != nil {
+		return nil, fmt.Errorf("failed to schedule secret value: %w", err)
+	}
+	if err := s.expiry.Schedule(model.ExpiryKindSecretPublication, publication.ID.String(), activateAt); err != nil {
+		s.db.Delete(publication)
+		return nil, fmt.Errorf("failed to schedule secret value: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "secret_publication_scheduled", "secret", secret.ID.String(), true, fmt.Sprintf("publication=%s; activate_at=%s", publication.ID, activateAt.Format(time.RFC3339)))
+	}
+	return publication, nil
+}
+
+// ResumePublications re-arms the timers of values still scheduled, in case
+// one was lost
+func (s *SecretService) ResumePublications() (int, error) {
+	var publications []model.SecretPublication
+	if err := s.db.Select("id", "activate_at").Where("status = ?", model.SecretPublicationScheduled).Find(&publications).Error; err != nil {
+		return 0, fmt.Errorf("failed to get scheduled publications: %w", err)
+	}
+	for _, publication := range publications {
+		if err := s.expiry.Schedule(model.ExpiryKindSecretPublication, publication.ID.String(), publication.ActivateAt); err != nil {
+			return 0, err
+		}
+	}
+	return len(publications), nil
+}
+
+// publishScheduled makes a scheduled value the current version of its
+// secret. Values of deleted secrets are dropped; a quarantined secret fails
+// the publication.
+func (s *SecretService) publishScheduled(ctx context.Context, item string) error {
+	id, err := uuid.Parse(item)
+	if err != nil {
+		return nil
+	}
+
+	var publication model.SecretPublication
+	if err := s.db.First(&publication, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to get publication: %w", err)
+	}
+	if publication.Status != model.SecretPublicationScheduled {
+		return nil
+	}
+
+	var secret model.Secret
+	if err := s.db.First(&secret, "id = ?", publication.SecretID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return s.completePublication(s.db, &publication, model.SecretPublicationCancelled)
+		}
+		return fmt.Errorf("failed to get secret: %w", err)
+	}
+
+	sealed := model.Secret{ID: secret.ID, NamespaceID: secret.NamespaceID, Value: publication.Value, WrappedKey: publication.WrappedKey}
+	plaintext, err := s.unsealSecret(&sealed)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt scheduled value: %w", err)
+	}
+
+	status := model.SecretPublicationPublished
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The row may have changed since the value was scheduled
+		locked := &model.Secret{}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(locked, "id = ?", secret.ID).Error; err != nil {
+			return err
+		}
+		if err := s.checkIntegrity(locked); err != nil {
+			if errors.Is(err, ErrSecretQuarantined) {
+				status = model.SecretPublicationFailed
+				return s.completePublication(tx, &publication, status)
+			}
+			return err
+		}
+
+		mount, err := s.kvMount(locked.Mount)
+		if err != nil {
+			return err
+		}
+		previous, err := s.setValue(locked, mount, plaintext, publication.RequestedBy)
+		if err != nil {
+			return err
+		}
+		locked.ClientEncrypted = publication.ClientEncrypted
+		s.stampChecksum(locked)
+
+		if err := s.keepVersion(tx, locked, previous); err != nil {
+			return err
+		}
+		if err := tx.Save(locked).Error; err != nil {
+			return err
+		}
+		publication.Version = locked.Version
+		return s.completePublication(tx, &publication, status)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish secret value: %w", err)
+	}
+
+	if s.auditService != nil {
+		details := fmt.Sprintf("publication=%s; version=%d", publication.ID, publication.Version)
+		s.auditService.LogAction(publication.RequestedBy, "secret_published", "secret", secret.ID.String(), status == model.SecretPublicationPublished, details)
+	}
+	return nil
+}
+
+// completePublication records the outcome of a scheduled value and drops
+// its ciphertext
+func (s *SecretService) completePublication(tx *gorm.DB, publication *model.SecretPublication, status model.SecretPublicationStatus) error {
+	now := time.Now()
+	publication.Status = status
+	publication.CompletedAt = &now
+	return tx.Model(&model.SecretPublication{}).
+		Where("id = ? AND status = ?", publication.ID, model.SecretPublicationScheduled).
+		Updates(map[string]interface{}{
+			"status":       status,
+			"version":      publication.Version,
+			"completed_at": now,
+			"value":        "",
+			"wrapped_key":  "",
+		}).Error
+}
+
+// GetPublications lists the scheduled and past publications of one of the
+// user's secrets, newest first
+func (s *SecretService) GetPublications(id uuid.UUID, userID uuid.UUID) ([]model.SecretPublication, error) {
+	secret, err := s.secretRow(id)
+	if err != nil {
+		return nil, err
+	}
+	if secret.UserID != userID {
+		return nil, ErrSecretNotFound
+	}
+
+	publications := []model.SecretPublication{}
+	if err := s.db.Where("secret_id = ?", id).Order("activate_at DESC").Limit(50).Find(&publications).Error; err != nil {
+		return nil, fmt.Errorf("failed to get publications: %w", err)
+	}
+	return publications, nil
+}
+
+// CancelPublication drops a value that has not been published yet
+func (s *SecretService) CancelPublication(id, publicationID uuid.UUID, userID uuid.UUID) (*model.SecretPublication, error) {
+	secret, err := s.secretRow(id)
+	if err != nil {
+		return nil, err
+	}
+	if secret.UserID != userID {
+		return nil, ErrSecretNotFound
+	}
+
+	var publication model.SecretPublication
+	if err := s.db.First(&publication, "id = ? AND secret_id = ?", publicationID, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSecretPublicationNotFound
+		}
+		return nil, fmt.Errorf("failed to get publication: %w", err)
+	}
+	if publication.Status != model.SecretPublicationScheduled {
+		return nil, ErrSecretPublicationDone
+	}
+
+	result := s.db.Model(&model.SecretPublication{}).
+		Where("id = ? AND status = ?", publication.ID, model.SecretPublicationScheduled).
+		Updates(map[string]interface{}{"status": model.SecretPublicationCancelled, "completed_at": time.Now(), "value": "", "wrapped_key": ""})
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to cancel publication: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrSecretPublicationDone
+	}
+	if err := s.expiry.Cancel(model.ExpiryKindSecretPublication, publication.ID.String()); err != nil {
+		log.Printf("⚠️  Failed to cancel publication %s timer: %v", publication.ID, err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "secret_publication_cancelled", "secret", id.String(), true, fmt.Sprintf("publication=%s", publication.ID))
+	}
+
+	publication.Status = model.SecretPublicationCancelled
+	return &publication, nil
+}
+
+var (
+	ErrSecretPublicationInvalid     = errors.New("invalid activate_at")
+	ErrSecretPublicationValidated   = errors.New("values under validated paths cannot be scheduled")
+	ErrSecretPublicationUnavailable = errors.New("scheduled values need the database-backed scheduler")
+	ErrSecretPublicationNotFound    = errors.New("publication not found")
+	ErrSecretPublicationDone        = errors.New("publication is no longer scheduled")
+)
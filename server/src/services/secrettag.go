@@ -0,0 +1,108 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+)
+
+// A tag is a label such as "pci" or a key:value pair such as
+// "team:payments". Patterns in policies and filters may use path.Match
+// wildcards, e.g. "env:*".
+var tagPattern = regexp.MustCompile(`^[A-Za-z0-9*?][A-Za-z0-9_.*?/-]*(:[A-Za-z0-9_.*?/@-]+)?$`)
+
+var metadataKeyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+const (
+	secretTagLimit           = 32
+	secretMetadataLimit      = 64
+	secretMetadataValueLimit = 1024
+)
+
+// SplitTags returns the tags of a stored, comma-separated tag list
+func SplitTags(tags string) []string {
+	var list []string
+	for _, tag := range strings.Split(tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			list = append(list, tag)
+		}
+	}
+	return list
+}
+
+// normalizeTags validates a comma-separated tag list and returns it sorted
+// and without duplicates. Wildcards are for patterns, not tags.
+func normalizeTags(tags string) (string, error) {
+	list := SplitTags(tags)
+	if len(list) > secretTagLimit {
+		return "", fmt.Errorf("%w: at most %d tags", ErrSecretTagsInvalid, secretTagLimit)
+	}
+
+	seen := make(map[string]bool, len(list))
+	unique := make([]string, 0, len(list))
+	for _, tag := range list {
+		if !tagPattern.MatchString(tag) || strings.ContainsAny(tag, "*?") {
+			return "", fmt.Errorf("%w: %q", ErrSecretTagsInvalid, tag)
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			unique = append(unique, tag)
+		}
+	}
+	sort.Strings(unique)
+	return strings.Join(unique, ","), nil
+}
+
+// validateMetadata checks the free-form metadata of a secret. Metadata is
+// stored in clear, so it must not hold anything secret.
+func validateMetadata(metadata map[string]string) error {
+	if len(metadata) > secretMetadataLimit {
+		return fmt.Errorf("%w: at most %d keys", ErrSecretMetadataInvalid, secretMetadataLimit)
+	}
+	for key, value := range metadata {
+		if !metadataKeyPattern.MatchString(key) {
+			return fmt.Errorf("%w: invalid key %q", ErrSecretMetadataInvalid, key)
+		}
+		if len(value) > secretMetadataValueLimit {
+			return fmt.Errorf("%w: value of %q is longer than %d bytes", ErrSecretMetadataInvalid, key, secretMetadataValueLimit)
+		}
+	}
+	return nil
+}
+
+// MatchTags reports whether tags satisfy every pattern
+func MatchTags(patterns, tags []string) bool {
+	for _, pattern := range patterns {
+		found := false
+		for _, tag := range tags {
+			if matched, _ := path.Match(pattern, tag); matched {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// SecretPolicyPath is the resource policies name a secret by: its route
+// without the ID, e.g. "secrets/db/password" in the default mount and
+// "mounts/team-kv/secrets/db/password" in others
+func SecretPolicyPath(secret *model.Secret) string {
+	if secret.Mount == "" || secret.Mount == model.DefaultKVMount {
+		return "secrets/" + secret.Name
+	}
+	return "mounts/" + secret.Mount + "/secrets/" + secret.Name
+}
+
+var (
+	ErrSecretTagsInvalid     = errors.New("invalid secret tags")
+	ErrSecretMetadataInvalid = errors.New("invalid secret metadata")
+)
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UseValidators holds writes under validated paths until their validators
+// pass
+func (s *SecretService) UseValidators(validators *SecretValidatorService) {
+	s.validators = validators
+}
+
+// startValidation records a held write, sealed like the secret itself, and
+// checks it in the background. A newer write supersedes a held one.
+func (s *SecretService) startValidation(secret *model.Secret, operation, plaintext string, userID uuid.UUID) (*model.SecretValidation, error) {
+	sealed := model.Secret{NamespaceID: secret.NamespaceID}
+	if err := s.sealSecret(&sealed, plaintext); err != nil {
+		return nil, fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+
+	validation := &model.SecretValidation{
+		SecretID:    secret.ID,
+		Status:      model.SecretValidationPending,
+		Operation:   operation,
+		Value:       sealed.Value,
+		WrappedKey:  sealed.WrappedKey,
+		RequestedBy: userID,
+		ResultList:  []model.SecretValidatorResult{},
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.SecretValidation{}).
+			Where("secret_id = ? AND status = ?", secret.ID, model.SecretValidationPending).
+			Update("status", model.SecretValidationSuperseded).Error; err != nil {
+			return err
+		}
+		return tx.Create(validation).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to hold secret for validation: %w", err)
+	}
+
+	go s.runValidation(validation.ID)
+	return validation, nil
+}
+
+// ResumeValidations restarts the checks of writes still held when the
+// server stopped
+func (s *SecretService) ResumeValidations() (int, error) {
+	var ids []uuid.UUID
+	if err := s.db.Model(&model.SecretValidation{}).Where("status = ?", model.SecretValidationPending).Pluck("id", &ids).Error; err != nil {
+		return 0, fmt.Errorf("failed to get pending validations: %w", err)
+	}
+	for _, id := range ids {
+		go s.runValidation(id)
+	}
+	return len(ids), nil
+}
+
+func (s *SecretService) runValidation(id uuid.UUID) {
+	if err := s.completeValidation(id); err != nil {
+		log.Printf("⚠️  Failed to validate secret write %s: %v", id, err)
+	}
+}
+
+// completeValidation runs the validators of a held write, then applies it
+// when every one passed or discards it otherwise
+func (s *SecretService) completeValidation(id uuid.UUID) error {
+	var validation model.SecretValidation
+	if err := s.db.First(&validation, "id = ?", id).Error; err != nil {
+		return fmt.Errorf("failed to get validation: %w", err)
+	}
+	if validation.Status != model.SecretValidationPending {
+		return nil
+	}
+
+	var secret model.Secret
+	if err := s.db.First(&secret, "id = ?", validation.SecretID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return s.db.Model(&validation).Where("status = ?", model.SecretValidationPending).
+				Updates(map[string]interface{}{"status": model.SecretValidationSuperseded, "value": "", "wrapped_key": ""}).Error
+		}
+		return fmt.Errorf("failed to get secret: %w", err)
+	}
+
+	sealed := model.Secret{ID: secret.ID, NamespaceID: secret.NamespaceID, Value: validation.Value, WrappedKey: validation.WrappedKey}
+	plaintext, err := s.unsealSecret(&sealed)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt held value: %w", err)
+	}
+
+	var results []model.SecretValidatorResult
+	validators, err := s.validators.Match(secret.Name)
+	if err != nil {
+		return err
+	}
+	if len(validators) > 0 {
+		results = s.validators.Check(context.Background(), validators, plaintext)
+	}
+
+	status := model.SecretValidationPassed
+	var failures []string
+	for _, result := range results {
+		if !result.Passed {
+			status = model.SecretValidationFailed
+			failures = append(failures, fmt.Sprintf("%s: %s", result.Kind, result.Message))
+		}
+	}
+	encoded, err := json.Marshal(results)
+	if err != nil {
+		return fmt.Errorf("failed to encode validation results: %w", err)
+	}
+
+	applied := false
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Only a write that is still the latest one completes
+		result := tx.Model(&model.SecretValidation{}).
+			Where("id = ? AND status = ?", id, model.SecretValidationPending).
+			Updates(map[string]interface{}{
+				"status":       status,
+				"results":      string(encoded),
+				"completed_at": time.Now(),
+				"value":        "",
+				"wrapped_key":  "",
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		applied = true
+		return s.applyValidation(tx, &validation, status, plaintext)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to complete validation: %w", err)
+	}
+	if !applied {
+		return nil
+	}
+
+	if s.auditService != nil {
+		if status == model.SecretValidationPassed {
+			s.auditService.LogAction(validation.RequestedBy, "secret_validation_passed", "secret", secret.ID.String(), true, fmt.Sprintf("%s; validators=%d", validation.Operation, len(results)))
+		} else {
+			s.auditService.LogAction(validation.RequestedBy, "secret_validation_failed", "secret", secret.ID.String(), false, fmt.Sprintf("%s; %s", validation.Operation, strings.Join(failures, "; ")))
+		}
+	}
+	return nil
+}
+
+// applyValidation activates a held create or stores a held update when it
+// passed; a failed create is removed and a failed update leaves the
+// current value in place
+func (s *SecretService) applyValidation(tx *gorm.DB, validation *model.SecretValidation, status model.SecretValidationStatus, plaintext string) error {
+	// The row may have changed while the validators ran
+	secret := &model.Secret{}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(secret, "id = ?", validation.SecretID).Error; err != nil {
+		return err
+	}
+
+	if validation.Operation == "create" {
+		if status != model.SecretValidationPassed {
+			return tx.Delete(secret).Error
+		}
+		secret.IsActive = true
+		s.stampChecksum(secret)
+		return tx.Save(secret).Error
+	}
+
+	if status != model.SecretValidationPassed {
+		return nil
+	}
+	if err := s.checkIntegrity(secret); err != nil {
+		return err
+	}
+	mount, err := s.kvMount(secret.Mount)
+	if err != nil {
+		return err
+	}
+	previous, err := s.setValue(secret, mount, plaintext, validation.RequestedBy)
+	if err != nil {
+		return err
+	}
+	secret.ClientEncrypted = false
+	s.stampChecksum(secret)
+
+	if err := s.keepVersion(tx, secret, previous); err != nil {
+		return err
+	}
+	return tx.Save(secret).Error
+}
+
+// GetValidations lists the validation runs of one of the user's secrets,
+// newest first. Secrets whose held create failed are still reported.
+func (s *SecretService) GetValidations(id uuid.UUID, userID uuid.UUID) ([]model.SecretValidation, error) {
+	var count int64
+	if err := s.db.Unscoped().Model(&model.Secret{}).Where("id = ? AND user_id = ?", id, userID).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("failed to get secret: %w", err)
+	}
+	if count == 0 {
+		return nil, ErrSecretNotFound
+	}
+
+	validations := []model.SecretValidation{}
+	if err := s.db.Where("secret_id = ?", id).Order("created_at DESC").Limit(50).Find(&validations).Error; err != nil {
+		return nil, fmt.Errorf("failed to get validations: %w", err)
+	}
+	for i := range validations {
+		validations[i].ResultList = []model.SecretValidatorResult{}
+		if validations[i].Results != "" {
+			if err := json.Unmarshal([]byte(validations[i].Results), &validations[i].ResultList); err != nil {
+				return nil, fmt.Errorf("failed to decode validation results: %w", err)
+			}
+		}
+	}
+	return validations, nil
+}
@@ -0,0 +1,296 @@
+package services
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-vault/server/src/config"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Known-answer vectors. AES-GCM is test case 4 of the GCM specification
+// (McGrew & Viega), Ed25519 is test 2 of RFC 8032 section 7.1.
+const (
+	katAESGCMKey        = "feffe9928665731c6d6a8f9467308308"
+	katAESGCMNonce      = "cafebabefacedbaddecaf888"
+	katAESGCMAAD        = "feedfacedeadbeeffeedfacedeadbeefabaddad2"
+	katAESGCMPlaintext  = "d9313225f88406e5a55909c5aff5269a86a7a9531534f7da2e4c303d8a318a721c3c0c95956809532fcf0e2449a6b525b16aedf5aa0de657ba637b39"
+	katAESGCMCiphertext = "42831ec2217774244b7221b784d0d49ce3aa212f2c02a4e035c17e2329aca12e21d514b25466931c7d8f6a5aac84aa051ba30b396a0aac973d58e091" +
+		"5bc94fbc3221a5db94fae95ae7121a47"
+
+	katEd25519Seed      = "4ccd089b28ff96da9db6c346ec114e0f5b8a319f35aba624da8cf6ed4fb8a6fb"
+	katEd25519PublicKey = "3d4017c3e843895a92b70aa74d1b7ebc9c982ccf2ec4968cc0cd55f12af4660c"
+	katEd25519Message   = "72"
+	katEd25519Signature = "92a009a9f0d4cab8720e820b5f642540a2b27b5416503f8fb3762223ebdb69da085ac1e43e15996e458f3613d0f11d8c387b2eaeb4302aeeb00d291612bb0c00"
+)
+
+const (
+	selfTestRNGSampleSize = 2500
+	minKDFIterations      = 100000
+	minSecretLength       = 32
+)
+
+// SelfTestService runs the startup self-test: cryptographic known-answer
+// tests, an RNG health check, a database schema check and config sanity
+// checks.
+type SelfTestService struct {
+	db     *gorm.DB
+	cfg    *config.Config
+	models []interface{}
+}
+
+func NewSelfTestService(db *gorm.DB, cfg *config.Config, models []interface{}) *SelfTestService {
+	return &SelfTestService{
+		db:     db,
+		cfg:    cfg,
+		models: models,
+	}
+}
+
+// Run executes every check and returns the report; it never stops early so
+// operators see all failures at once
+func (s *SelfTestService) Run() *model.SelfTestReport {
+	report := &model.SelfTestReport{Passed: true}
+
+	run := func(name string, check func() (string, error)) {
+		start := time.Now()
+		detail, err := check()
+		result := model.SelfTestCheck{
+			Name:     name,
+			Passed:   err == nil,
+			Detail:   detail,
+			Duration: time.Since(start).Round(time.Microsecond).String(),
+		}
+		if err != nil {
+			result.Detail = err.Error()
+			report.Passed = false
+		}
+		report.Checks = append(report.Checks, result)
+	}
+
+	run("aes-gcm-kat", s.checkAESGCM)
+	run("ed25519-kat", s.checkEd25519)
+	run("rng-health", s.checkRNG)
+	run("db-schema", func() (string, error) {
+		checksum, detail, err := s.checkSchema()
+		report.SchemaChecksum = checksum
+		return detail, err
+	})
+	run("config", s.checkConfig)
+
+	report.CompletedAt = time.Now()
+	return report
+}
+
+func (s *SelfTestService) checkAESGCM() (string, error) {
+	key, _ := hex.DecodeString(katAESGCMKey)
+	nonce, _ := hex.DecodeString(katAESGCMNonce)
+	aad, _ := hex.DecodeString(katAESGCMAAD)
+	plaintext, _ := hex.DecodeString(katAESGCMPlaintext)
+	expected, _ := hex.DecodeString(katAESGCMCiphertext)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", fmt.Errorf("failed to create GCM: %w", err)
+	}
+
+	ciphertext := gcm.Seal(nil, nonce, plaintext, aad)
+	if !bytes.Equal(ciphertext, expected) {
+		return "", ErrKnownAnswerMismatch
+	}
+
+	decrypted, err := gcm.Open(nil, nonce, ciphertext, aad)
+	if err != nil || !bytes.Equal(decrypted, plaintext) {
+		return "", ErrKnownAnswerMismatch
+	}
+
+	ciphertext[0] ^= 0x01
+	if _, err := gcm.Open(nil, nonce, ciphertext, aad); err == nil {
+		return "", errors.New("tampered ciphertext was accepted")
+	}
+
+	return "encrypt, decrypt and tamper detection match", nil
+}
+
+func (s *SelfTestService) checkEd25519() (string, error) {
+	seed, _ := hex.DecodeString(katEd25519Seed)
+	publicKey, _ := hex.DecodeString(katEd25519PublicKey)
+	message, _ := hex.DecodeString(katEd25519Message)
+	expected, _ := hex.DecodeString(katEd25519Signature)
+
+	privateKey := ed25519.NewKeyFromSeed(seed)
+	if !bytes.Equal(privateKey.Public().(ed25519.PublicKey), publicKey) {
+		return "", ErrKnownAnswerMismatch
+	}
+
+	signature := ed25519.Sign(privateKey, message)
+	if !bytes.Equal(signature, expected) {
+		return "", ErrKnownAnswerMismatch
+	}
+
+	if !ed25519.Verify(publicKey, message, signature) {
+		return "", errors.New("valid signature was rejected")
+	}
+
+	if ed25519.Verify(publicKey, append(message, 0x00), signature) {
+		return "", errors.New("signature over a different message was accepted")
+	}
+
+	return "key derivation, sign and verify match", nil
+}
+
+// checkRNG draws two samples and applies a repetition check and the
+// FIPS 140-1 monobit bounds scaled to the sample size
+func (s *SelfTestService) checkRNG() (string, error) {
+	first := make([]byte, selfTestRNGSampleSize)
+	second := make([]byte, selfTestRNGSampleSize)
+	if _, err := io.ReadFull(rand.Reader, first); err != nil {
+		return "", fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	if _, err := io.ReadFull(rand.Reader, second); err != nil {
+		return "", fmt.Errorf("failed to read random bytes: %w", err)
+	}
+
+	if bytes.Equal(first[:32], second[:32]) {
+		return "", errors.New("random generator returned a repeated block")
+	}
+
+	ones := 0
+	run := 1
+	longestRun := 1
+	for i, b := range first {
+		ones += bits.OnesCount8(b)
+		if i > 0 && b == first[i-1] {
+			run++
+			if run > longestRun {
+				longestRun = run
+			}
+		} else {
+			run = 1
+		}
+	}
+
+	// 20000 bits must contain between 9725 and 10275 ones
+	if ones <= 9725 || ones >= 10275 {
+		return "", fmt.Errorf("monobit test failed: %d ones in %d bits", ones, selfTestRNGSampleSize*8)
+	}
+
+	if longestRun > 4 {
+		return "", fmt.Errorf("repetition test failed: %d identical consecutive bytes", longestRun)
+	}
+
+	return fmt.Sprintf("%d ones in %d bits", ones, selfTestRNGSampleSize*8), nil
+}
+
+// checkSchema hashes the schema expected by the models and verifies every
+// expected column exists in the connected database
+func (s *SelfTestService) checkSchema() (string, string, error) {
+	if s.db == nil {
+		return "", "skipped: no database connection", nil
+	}
+
+	cache := &sync.Map{}
+	var expected []string
+	var missing []string
+
+	for _, m := range s.models {
+		parsed, err := schema.Parse(m, cache, s.db.NamingStrategy)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to parse model schema: %w", err)
+		}
+
+		columnTypes, err := s.db.Migrator().ColumnTypes(m)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to read columns for %s: %w", parsed.Table, err)
+		}
+
+		actual := make(map[string]bool, len(columnTypes))
+		for _, column := range columnTypes {
+			actual[column.Name()] = true
+		}
+
+		for _, field := range parsed.Fields {
+			if field.DBName == "" {
+				continue
+			}
+			column := parsed.Table + "." + field.DBName
+			expected = append(expected, column+" "+string(field.DataType))
+			if !actual[field.DBName] {
+				missing = append(missing, column)
+			}
+		}
+	}
+
+	sort.Strings(expected)
+	sum := sha256.Sum256([]byte(strings.Join(expected, "\n")))
+	checksum := "sha256:" + hex.EncodeToString(sum[:])
+
+	if len(missing) > 0 {
+		return checksum, "", fmt.Errorf("missing columns: %s", strings.Join(missing, ", "))
+	}
+
+	return checksum, fmt.Sprintf("%d tables, %d columns", len(s.models), len(expected)), nil
+}
+
+func (s *SelfTestService) checkConfig() (string, error) {
+	var problems []string
+	production := s.cfg.Server.Environment == "production"
+
+	if len(s.cfg.Security.EncryptionKey) < minSecretLength {
+		problems = append(problems, fmt.Sprintf("encryption key is shorter than %d characters", minSecretLength))
+	}
+	if len(s.cfg.JWT.Secret) < minSecretLength {
+		problems = append(problems, fmt.Sprintf("JWT secret is shorter than %d characters", minSecretLength))
+	}
+	if s.cfg.JWT.Secret != "" && s.cfg.JWT.Secret == s.cfg.Security.EncryptionKey {
+		problems = append(problems, "JWT secret and encryption key must differ")
+	}
+	if s.cfg.Security.KDFIterations < minKDFIterations {
+		problems = append(problems, fmt.Sprintf("KDF iterations below %d", minKDFIterations))
+	}
+	if s.cfg.JWT.Expiration <= 0 {
+		problems = append(problems, "JWT expiration must be positive")
+	}
+
+	if production {
+		if s.cfg.Database.SSLMode == "disable" {
+			problems = append(problems, "database sslmode is disabled")
+		}
+		if s.cfg.Server.PublicURL != "" {
+			if parsed, err := url.Parse(s.cfg.Server.PublicURL); err != nil || parsed.Scheme != "https" {
+				problems = append(problems, "public URL must use https")
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return "", errors.New(strings.Join(problems, "; "))
+	}
+
+	return "configuration is sane", nil
+}
+
+var (
+	ErrKnownAnswerMismatch = errors.New("output does not match known answer")
+)
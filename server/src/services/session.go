@@ -0,0 +1,221 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SessionPolicy is the policy path granting termination ("delete") of
+// other users' sessions. Everyone may end their own.
+const SessionPolicy = "users/sessions"
+
+const (
+	// sessionActivityInterval is how often a session's last activity is
+	// written while its token is in use
+	sessionActivityInterval = time.Minute
+	// sessionTouchLimit bounds the in-memory record of recent writes
+	sessionTouchLimit = 10000
+)
+
+// SessionService tracks which device each token is used from. Sessions
+// live and die with their token: revoking a token removes its session,
+// and ending a session revokes its token.
+type SessionService struct {
+	db            *gorm.DB
+	userService   *UserService
+	policyService *PolicyService
+	auditService  *AuditService
+	notifications *NotificationService
+
+	// touched is when each token's session was last written
+	touched    map[uuid.UUID]time.Time
+	touchMutex sync.Mutex
+}
+
+func NewSessionService(db *gorm.DB, userService *UserService, policyService *PolicyService, auditService *AuditService, notifications *NotificationService) *SessionService {
+	return &SessionService{
+		db:            db,
+		userService:   userService,
+		policyService: policyService,
+		auditService:  auditService,
+		notifications: notifications,
+		touched:       make(map[uuid.UUID]time.Time),
+	}
+}
+
+// Touch records a request of the token with the given accessor from
+// device, starting its session on the first one. Writes are spaced
+// sessionActivityInterval apart per token, across instances.
+func (s *SessionService) Touch(accessor, userID uuid.UUID, device model.SessionDevice) error {
+	now := time.Now()
+	if !s.due(accessor, now) {
+		return nil
+	}
+
+	var token model.Token
+	if err := s.db.Select("method").Where("id = ? AND user_id = ?", accessor, userID).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to get token: %w", err)
+	}
+
+	session := &model.Session{
+		UserID:            userID,
+		TokenID:           accessor,
+		Method:            token.Method,
+		DeviceFingerprint: device.Fingerprint,
+		IPAddress:         device.IPAddress,
+		UserAgent:         device.UserAgent,
+		LastActivityAt:    now,
+	}
+	// Other instances space their writes apart too, but do not know of
+	// this one's; a session written within the interval is left alone
+	err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "token_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"device_fingerprint", "ip_address", "user_agent", "last_activity_at"}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Lt{Column: clause.Column{Table: "sessions", Name: "last_activity_at"}, Value: now.Add(-sessionActivityInterval)},
+		}},
+	}).Create(session).Error
+	if err != nil {
+		s.forget(accessor)
+		return fmt.Errorf("failed to record session: %w", err)
+	}
+	return nil
+}
+
+// List returns the user's sessions with a live token, most recently
+// active first, marking the one of the current accessor
+func (s *SessionService) List(userID uuid.UUID, current *uuid.UUID) ([]model.Session, error) {
+	sessions := []model.Session{}
+	live := s.db.Model(&model.Token{}).Select("id").Where("expires_at > ?", time.Now())
+	if err := s.db.Where("user_id = ? AND token_id IN (?)", userID, live).Order("last_activity_at DESC").Find(&sessions).Error; err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+	for i := range sessions {
+		sessions[i].Current = current != nil && sessions[i].TokenID == *current
+	}
+	return sessions, nil
+}
+
+// Revoke ends a session by revoking its token and the token's children,
+// if userID may
+func (s *SessionService) Revoke(id, userID uuid.UUID) error {
+	var session model.Session
+	if err := s.db.Where("id = ?", id).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSessionNotFound
+		}
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+	if err := s.authorize(&session, userID); err != nil {
+		return err
+	}
+
+	revoked, err := revokeTokens(s.db, []uuid.UUID{session.TokenID})
+	if err != nil {
+		return err
+	}
+	s.forget(session.TokenID)
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "session_revoked", "session", session.ID.String(), true, fmt.Sprintf("user=%s; device=%s; revoked=%d", session.UserID, session.DeviceFingerprint, revoked))
+	}
+	return nil
+}
+
+// TerminateAll revokes every token of a user, for an account that may be
+// compromised. Tokens the user created for others, such as impersonation
+// tokens, go with them.
+func (s *SessionService) TerminateAll(userID, actorID uuid.UUID) (*model.TerminateSessionsResponse, error) {
+	if _, err := s.userService.GetUserByID(userID); err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	var tokens []uuid.UUID
+	if err := s.db.Model(&model.Token{}).Where("user_id = ?", userID).Pluck("id", &tokens).Error; err != nil {
+		return nil, fmt.Errorf("failed to get tokens: %w", err)
+	}
+	var revoked int64
+	if len(tokens) > 0 {
+		var err error
+		revoked, err = revokeTokens(s.db, tokens)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, token := range tokens {
+		s.forget(token)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(actorID, "sessions_terminated", "user", userID.String(), true, fmt.Sprintf("revoked=%d", revoked))
+	}
+	if s.notifications != nil && actorID != userID {
+		s.notifications.SecurityAlert(userID, "All sessions terminated", "An administrator signed your account out everywhere. Sign in again to continue.")
+	}
+	return &model.TerminateSessionsResponse{Revoked: revoked}, nil
+}
+
+// authorize lets users end their own sessions, and the central admin and
+// holders of SessionPolicy everyone's
+func (s *SessionService) authorize(session *model.Session, userID uuid.UUID) error {
+	if session.UserID == userID {
+		return nil
+	}
+	user, err := s.userService.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if IsCentralAdmin(user) {
+		return nil
+	}
+	if s.policyService != nil {
+		allowed, err := s.policyService.CheckAccess(userID, SessionPolicy, "delete")
+		if err != nil {
+			return fmt.Errorf("failed to check policies: %w", err)
+		}
+		if allowed {
+			return nil
+		}
+	}
+	return ErrSessionNotFound
+}
+
+// due reports whether the session of accessor should be written at now,
+// and notes that it will be
+func (s *SessionService) due(accessor uuid.UUID, now time.Time) bool {
+	s.touchMutex.Lock()
+	defer s.touchMutex.Unlock()
+
+	if last, ok := s.touched[accessor]; ok && now.Sub(last) < sessionActivityInterval {
+		return false
+	}
+	if len(s.touched) >= sessionTouchLimit {
+		for id, last := range s.touched {
+			if now.Sub(last) >= sessionActivityInterval {
+				delete(s.touched, id)
+			}
+		}
+	}
+	s.touched[accessor] = now
+	return true
+}
+
+func (s *SessionService) forget(accessor uuid.UUID) {
+	s.touchMutex.Lock()
+	defer s.touchMutex.Unlock()
+	delete(s.touched, accessor)
+}
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+)
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+)
+
+func TestSessionTouchSkipsRecentActivity(t *testing.T) {
+	db := newStubDB(t, stubTables{"tokens": {{"method": driver.Value(model.AuthMethodPassword)}}})
+	var writes []string
+	db.Callback().Create().After("gorm:create").Register("test:sessions", func(tx *gorm.DB) {
+		writes = append(writes, tx.Statement.SQL.String())
+	})
+	sessions := NewSessionService(db, nil, nil, nil, nil)
+
+	accessor, userID := uuid.New(), uuid.New()
+	for i := 0; i < 3; i++ {
+		if err := sessions.Touch(accessor, userID, model.SessionDevice{Fingerprint: "laptop"}); err != nil {
+			t.Fatalf("touch failed: %v", err)
+		}
+	}
+
+	if len(writes) != 1 {
+		t.Fatalf("got %d session writes for requests within a minute, want 1", len(writes))
+	}
+	// Another instance may have written the session a moment ago
+	if !strings.Contains(writes[0], `DO UPDATE SET`) || !strings.Contains(writes[0], `WHERE "sessions"."last_activity_at" <`) {
+		t.Errorf("session upsert does not skip recently active sessions: %s", writes[0])
+	}
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Shamir secret sharing over GF(2^8), one polynomial per secret byte. A
+// share is its x coordinate (1..255) followed by one y byte per secret
+// byte.
+
+// splitSecret cuts secret into shares of which any threshold rebuild it
+func splitSecret(secret []byte, shares, threshold int) ([][]byte, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("secret is empty")
+	}
+	if threshold < 2 || threshold > shares || shares > 255 {
+		return nil, fmt.Errorf("need 2 <= threshold <= shares <= 255, got %d of %d", threshold, shares)
+	}
+
+	out := make([][]byte, shares)
+	for i := range out {
+		out[i] = make([]byte, len(secret)+1)
+		out[i][0] = byte(i + 1)
+	}
+
+	coefficients := make([]byte, threshold)
+	for b, value := range secret {
+		coefficients[0] = value
+		if _, err := io.ReadFull(keyReader, coefficients[1:]); err != nil {
+			return nil, err
+		}
+		for i := range out {
+			out[i][b+1] = gfEvaluate(coefficients, out[i][0])
+		}
+	}
+
+	return out, nil
+}
+
+// combineShares rebuilds the secret by Lagrange interpolation at x = 0.
+// Any threshold distinct shares give the secret; fewer give noise.
+func combineShares(shares [][]byte) ([]byte, error) {
+	if len(shares) < 2 {
+		return nil, errors.New("at least two shares are required")
+	}
+	length := len(shares[0])
+	seen := make(map[byte]bool, len(shares))
+	for _, share := range shares {
+		if len(share) != length || length < 2 {
+			return nil, errors.New("shares have different lengths")
+		}
+		if share[0] == 0 || seen[share[0]] {
+			return nil, errors.New("shares must have distinct, non-zero indexes")
+		}
+		seen[share[0]] = true
+	}
+
+	secret := make([]byte, length-1)
+	for b := range secret {
+		var value byte
+		for i, share := range shares {
+			basis := byte(1)
+			for j, other := range shares {
+				if i == j {
+					continue
+				}
+				// l_i(0) = prod x_j / (x_j - x_i); subtraction is xor
+				basis = gfMul(basis, gfDiv(other[0], other[0]^share[0]))
+			}
+			value ^= gfMul(share[b+1], basis)
+		}
+		secret[b] = value
+	}
+
+	return secret, nil
+}
+
+// gfEvaluate evaluates the polynomial with the given coefficients, lowest
+// degree first, at x
+func gfEvaluate(coefficients []byte, x byte) byte {
+	var result byte
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		result = gfMul(result, x) ^ coefficients[i]
+	}
+	return result
+}
+
+// gfMul multiplies in GF(2^8) with the AES polynomial x^8+x^4+x^3+x+1
+func gfMul(a, b byte) byte {
+	var product byte
+	for b > 0 {
+		if b&1 == 1 {
+			product ^= a
+		}
+		carry := a & 0x80
+		a <<= 1
+		if carry != 0 {
+			a ^= 0x1b
+		}
+		b >>= 1
+	}
+	return product
+}
+
+// gfDiv divides by b != 0, using b^254 = b^-1
+func gfDiv(a, b byte) byte {
+	inverse := byte(1)
+	for i := 0; i < 254; i++ {
+		inverse = gfMul(inverse, b)
+	}
+	return gfMul(a, inverse)
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestShamirSplitCombine(t *testing.T) {
+	secret := []byte("aether-vault master key, 32 byte")
+
+	tests := []struct {
+		name      string
+		shares    int
+		threshold int
+		pick      []int
+		want      bool
+	}{
+		{name: "threshold shares", shares: 3, threshold: 2, pick: []int{0, 2}, want: true},
+		{name: "all shares", shares: 5, threshold: 3, pick: []int{0, 1, 2, 3, 4}, want: true},
+		{name: "any order", shares: 5, threshold: 3, pick: []int{4, 1, 3}, want: true},
+		{name: "shares equal threshold", shares: 4, threshold: 4, pick: []int{3, 2, 1, 0}, want: true},
+		{name: "maximum shares", shares: 255, threshold: 2, pick: []int{0, 254}, want: true},
+		{name: "below threshold", shares: 5, threshold: 3, pick: []int{0, 4}, want: false},
+		{name: "one short of all", shares: 4, threshold: 4, pick: []int{0, 1, 2}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shares, err := splitSecret(secret, tt.shares, tt.threshold)
+			if err != nil {
+				t.Fatalf("split failed: %v", err)
+			}
+			if len(shares) != tt.shares {
+				t.Fatalf("got %d shares, want %d", len(shares), tt.shares)
+			}
+			for i, share := range shares {
+				if int(share[0]) != i+1 || len(share) != len(secret)+1 {
+					t.Fatalf("share %d has index %d and length %d", i, share[0], len(share))
+				}
+			}
+
+			picked := make([][]byte, 0, len(tt.pick))
+			for _, i := range tt.pick {
+				picked = append(picked, shares[i])
+			}
+			got, err := combineShares(picked)
+			if err != nil {
+				t.Fatalf("combine failed: %v", err)
+			}
+			if bytes.Equal(got, secret) != tt.want {
+				t.Errorf("combining %v of %d (threshold %d) rebuilt the secret: %v, want %v", tt.pick, tt.shares, tt.threshold, !tt.want, tt.want)
+			}
+		})
+	}
+}
+
+func TestShamirRejectsInvalidInput(t *testing.T) {
+	splits := []struct {
+		name      string
+		secret    []byte
+		shares    int
+		threshold int
+	}{
+		{name: "empty secret", secret: nil, shares: 3, threshold: 2},
+		{name: "threshold of one", secret: []byte("key"), shares: 3, threshold: 1},
+		{name: "threshold above shares", secret: []byte("key"), shares: 2, threshold: 3},
+		{name: "too many shares", secret: []byte("key"), shares: 256, threshold: 2},
+	}
+	for _, tt := range splits {
+		if _, err := splitSecret(tt.secret, tt.shares, tt.threshold); err == nil {
+			t.Errorf("split with %s succeeded", tt.name)
+		}
+	}
+
+	shares, err := splitSecret([]byte("key"), 3, 2)
+	if err != nil {
+		t.Fatalf("split failed: %v", err)
+	}
+	combines := []struct {
+		name   string
+		shares [][]byte
+	}{
+		{name: "one share", shares: [][]byte{shares[0]}},
+		{name: "duplicate index", shares: [][]byte{shares[0], shares[0]}},
+		{name: "zero index", shares: [][]byte{{0, 1, 2, 3}, shares[1]}},
+		{name: "different lengths", shares: [][]byte{shares[0], shares[1][:3]}},
+		{name: "index only", shares: [][]byte{{1}, {2}}},
+	}
+	for _, tt := range combines {
+		if _, err := combineShares(tt.shares); err == nil {
+			t.Errorf("combine with %s succeeded", tt.name)
+		}
+	}
+}
@@ -0,0 +1,424 @@
+package services
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultShareTTL = 24 * time.Hour
+	maxShareTTL     = 7 * 24 * time.Hour
+	shareAlgorithm  = "AES-256-GCM"
+)
+
+// ShareService issues view-limited links for secret values. The value is
+// sealed with a random key that is only returned inside the link's URL
+// fragment, so the server keeps nothing able to decrypt it. A link may be
+// held closed until an approver, designated by the shares/approvals
+// policy, approves it.
+type ShareService struct {
+	db            *gorm.DB
+	secretService *SecretService
+	auditService  *AuditService
+	singleUse     *SingleUseService
+	expiry        *ExpiryScheduler
+	leases        *LeaseService
+}
+
+func NewShareService(db *gorm.DB, secretService *SecretService, auditService *AuditService, singleUse *SingleUseService) *ShareService {
+	return &ShareService{
+		db:            db,
+		secretService: secretService,
+		auditService:  auditService,
+		singleUse:     singleUse,
+	}
+}
+
+// UseExpiry wipes each link's value as soon as it expires rather than on
+// the next expired_shares run
+func (s *ShareService) UseExpiry(scheduler *ExpiryScheduler) {
+	s.expiry = scheduler
+	scheduler.Handle(model.ExpiryKindShare, s.expireShare)
+}
+
+// UseLeases issues a renewable lease with every link, bounded by the
+// mount's max_lease_ttl; revoking it wipes the link at once
+func (s *ShareService) UseLeases(leases *LeaseService) {
+	s.leases = leases
+	leases.RegisterIssuer(model.LeaseIssuerShare, LeaseIssuer{
+		Revoke: s.revokeShare,
+		Renew:  s.extendShare,
+	})
+}
+
+func (s *ShareService) CreateShare(req *model.CreateShareRequest, userID uuid.UUID, accessor *uuid.UUID, baseURL string) (*model.CreateShareResponse, error) {
+	value := req.Value
+	var mount *model.Mount
+	var namespaceID *uuid.UUID
+	if req.SecretID != nil {
+		secret, err := s.secretService.GetSecretByID(*req.SecretID, userID, accessor)
+		if err != nil {
+			return nil, err
+		}
+		// A share link is a lease on the secret, bounded by its mount
+		mount, err = s.secretService.kvMount(secret.Mount)
+		if err != nil {
+			return nil, err
+		}
+		// The recipient could not open the envelope; grant them the data
+		// key with `vault secret grant` instead
+		if secret.ClientEncrypted {
+			return nil, ErrSecretClientEncrypted
+		}
+		value = secret.Value
+		namespaceID = secret.NamespaceID
+	}
+
+	if value == "" {
+		return nil, ErrShareEmptyValue
+	}
+
+	resolution, err := resolveLeaseTTL(mount, req.TTL, defaultShareTTL, maxShareTTL, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, key, err := sealShareValue(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt share: %w", err)
+	}
+
+	share := &model.ShareLink{
+		UserID:     userID,
+		SecretID:   req.SecretID,
+		Ciphertext: ciphertext,
+		ExpiresAt:  *resolution.ExpiresAt,
+		MaxViews:   req.MaxViews,
+	}
+	if share.MaxViews == 0 {
+		share.MaxViews = 1
+	}
+	if req.RequireApproval {
+		share.ApprovalStatus = model.ShareApprovalPending
+	}
+
+	if err := s.db.Create(share).Error; err != nil {
+		return nil, fmt.Errorf("failed to create share: %w", err)
+	}
+
+	if s.auditService != nil {
+		details := fmt.Sprintf("expires_at=%s max_views=%d require_approval=%t", share.ExpiresAt.Format(time.RFC3339), share.MaxViews, req.RequireApproval)
+		if req.SecretID != nil {
+			details += fmt.Sprintf(" secret_id=%s", req.SecretID.String())
+		}
+		s.auditService.LogAction(userID, "share_created", "share", share.ID.String(), true, details)
+	}
+
+	if err := s.expiry.Schedule(model.ExpiryKindShare, share.ID.String(), share.ExpiresAt); err != nil {
+		log.Printf("⚠️  Failed to schedule share %s expiry: %v", share.ID, err)
+	}
+
+	response := &model.CreateShareResponse{
+		ID:             share.ID,
+		URL:            fmt.Sprintf("%s/api/v1/share/%s#%s", strings.TrimRight(baseURL, "/"), share.ID.String(), key),
+		ExpiresAt:      share.ExpiresAt,
+		MaxViews:       share.MaxViews,
+		ApprovalStatus: share.ApprovalStatus,
+		TTL:            resolution,
+	}
+	if s.leases != nil {
+		lease, err := s.leases.Issue(model.LeaseIssuerShare, share.ID.String(), userID, namespaceID, resolution, true)
+		if err != nil {
+			log.Printf("⚠️  Failed to lease share %s: %v", share.ID, err)
+		} else {
+			response.Lease = lease.Info(time.Now())
+		}
+	}
+	return response, nil
+}
+
+// ConsumeShare returns the sealed value and counts the view, wiping the
+// value from the database with the last one. Each view is a single-use
+// token, so concurrent readers never get more views than the link allows,
+// whichever instance serves them.
+func (s *ShareService) ConsumeShare(id uuid.UUID, ipAddress, userAgent string) (*model.ShareViewResponse, error) {
+	for {
+		share, err := s.getShare(id)
+		if err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		if share.ConsumedAt != nil {
+			s.logConsumption(share.ID, ipAddress, userAgent, false, "already consumed")
+			return nil, ErrShareConsumed
+		}
+		if now.After(share.ExpiresAt) {
+			s.logConsumption(share.ID, ipAddress, userAgent, false, "expired")
+			return nil, ErrShareExpired
+		}
+		switch share.ApprovalStatus {
+		case model.ShareApprovalPending:
+			s.logConsumption(share.ID, ipAddress, userAgent, false, "pending approval")
+			return nil, ErrSharePendingApproval
+		case model.ShareApprovalDenied:
+			s.logConsumption(share.ID, ipAddress, userAgent, false, "denied")
+			return nil, ErrShareDenied
+		}
+
+		view := share.Views + 1
+		last := view >= share.MaxViews
+		err = s.singleUse.Consume(model.SingleUsePurposeShare, shareViewToken(id, view), share.ExpiresAt, ipAddress, func(tx *gorm.DB) error {
+			updates := map[string]interface{}{"views": view}
+			if last {
+				updates["consumed_at"] = now
+				updates["consumed_ip"] = ipAddress
+				updates["ciphertext"] = ""
+			}
+			result := tx.Model(&model.ShareLink{}).
+				Where("id = ? AND views = ? AND consumed_at IS NULL", id, share.Views).
+				Updates(updates)
+			if result.Error != nil {
+				return fmt.Errorf("failed to consume share: %w", result.Error)
+			}
+			if result.RowsAffected == 0 {
+				return ErrShareConsumed
+			}
+			return nil
+		})
+		if errors.Is(err, ErrTokenConsumed) || errors.Is(err, ErrShareConsumed) {
+			// Another reader took this view; a later one may be left
+			if !last {
+				continue
+			}
+			s.logConsumption(share.ID, ipAddress, userAgent, false, "already consumed")
+			return nil, ErrShareConsumed
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		s.logConsumption(share.ID, ipAddress, userAgent, true, fmt.Sprintf("view=%d/%d", view, share.MaxViews))
+		if last {
+			if err := s.expiry.Cancel(model.ExpiryKindShare, share.ID.String()); err != nil {
+				log.Printf("⚠️  Failed to cancel share %s expiry: %v", share.ID, err)
+			}
+		}
+
+		return &model.ShareViewResponse{
+			Ciphertext:     share.Ciphertext,
+			Algorithm:      shareAlgorithm,
+			ExpiresAt:      share.ExpiresAt,
+			ViewsRemaining: share.MaxViews - view,
+		}, nil
+	}
+}
+
+// GetPendingApprovals lists the unexpired links awaiting approval, oldest
+// first
+func (s *ShareService) GetPendingApprovals() ([]model.ShareLink, error) {
+	var shares []model.ShareLink
+	if err := s.db.Where("approval_status = ? AND expires_at > ?", model.ShareApprovalPending, time.Now()).
+		Order("created_at ASC").Find(&shares).Error; err != nil {
+		return nil, fmt.Errorf("failed to get pending shares: %w", err)
+	}
+	return shares, nil
+}
+
+// DecideShare approves or denies a link awaiting approval. Creators cannot
+// decide on their own links. A denied link is wiped at once.
+func (s *ShareService) DecideShare(id, approverID uuid.UUID, approve bool, reason string) (*model.ShareLink, error) {
+	action := "share_denied"
+	status := model.ShareApprovalDenied
+	if approve {
+		action = "share_approved"
+		status = model.ShareApprovalApproved
+	}
+
+	share, err := s.getShare(id)
+	if err != nil {
+		return nil, err
+	}
+	fail := func(err error) (*model.ShareLink, error) {
+		if s.auditService != nil {
+			s.auditService.LogAction(approverID, action, "share", id.String(), false, err.Error())
+		}
+		return nil, err
+	}
+	if share.ApprovalStatus != model.ShareApprovalPending {
+		return fail(ErrShareNotPending)
+	}
+	if share.UserID == approverID {
+		return fail(ErrShareSelfApproval)
+	}
+	now := time.Now()
+	if now.After(share.ExpiresAt) {
+		return fail(ErrShareExpired)
+	}
+
+	updates := map[string]interface{}{
+		"approval_status": status,
+		"decided_by":      approverID,
+		"decided_at":      now,
+	}
+	if !approve {
+		updates["ciphertext"] = ""
+	}
+	result := s.db.Model(&model.ShareLink{}).
+		Where("id = ? AND approval_status = ?", id, model.ShareApprovalPending).
+		Updates(updates)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to decide share: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		// Another approver decided first
+		return fail(ErrShareNotPending)
+	}
+
+	if s.auditService != nil {
+		details := fmt.Sprintf("owner=%s", share.UserID.String())
+		if reason != "" {
+			details += "; reason=" + reason
+		}
+		s.auditService.LogAction(approverID, action, "share", id.String(), true, details)
+	}
+	if !approve {
+		if err := s.expiry.Cancel(model.ExpiryKindShare, id.String()); err != nil {
+			log.Printf("⚠️  Failed to cancel share %s expiry: %v", id, err)
+		}
+	}
+
+	share.ApprovalStatus, share.DecidedBy, share.DecidedAt = status, &approverID, &now
+	return share, nil
+}
+
+func (s *ShareService) getShare(id uuid.UUID) (*model.ShareLink, error) {
+	var share model.ShareLink
+	if err := s.db.Where("id = ?", id).First(&share).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrShareNotFound
+		}
+		return nil, fmt.Errorf("failed to get share: %w", err)
+	}
+	return &share, nil
+}
+
+// expireShare wipes the value of an unconsumed link; the row stays for
+// the purge grace period so the link reports "expired"
+func (s *ShareService) expireShare(ctx context.Context, item string) error {
+	id, err := uuid.Parse(item)
+	if err != nil {
+		return nil
+	}
+	return s.db.WithContext(ctx).Model(&model.ShareLink{}).
+		Where("id = ? AND consumed_at IS NULL AND expires_at <= ? AND ciphertext <> ?", id, time.Now(), "").
+		Update("ciphertext", "").Error
+}
+
+// revokeShare wipes an unconsumed link before its time; the link then
+// reports "expired"
+func (s *ShareService) revokeShare(ctx context.Context, item string) error {
+	id, err := uuid.Parse(item)
+	if err != nil {
+		return nil
+	}
+	now := time.Now()
+	if err := s.db.WithContext(ctx).Model(&model.ShareLink{}).
+		Where("id = ? AND consumed_at IS NULL AND ciphertext <> ?", id, "").
+		Updates(map[string]interface{}{"ciphertext": "", "expires_at": now}).Error; err != nil {
+		return fmt.Errorf("failed to revoke share: %w", err)
+	}
+	if err := s.expiry.Cancel(model.ExpiryKindShare, item); err != nil {
+		log.Printf("⚠️  Failed to cancel share %s expiry: %v", item, err)
+	}
+	return nil
+}
+
+// extendShare moves the expiry of a link that is still unconsumed
+func (s *ShareService) extendShare(item string, expiresAt time.Time) error {
+	id, err := uuid.Parse(item)
+	if err != nil {
+		return ErrShareNotFound
+	}
+	result := s.db.Model(&model.ShareLink{}).
+		Where("id = ? AND consumed_at IS NULL AND ciphertext <> ? AND expires_at > ?", id, "", time.Now()).
+		Update("expires_at", expiresAt)
+	if result.Error != nil {
+		return fmt.Errorf("failed to extend share: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		// Consumed or wiped; there is nothing left to keep alive
+		return ErrLeaseEnded
+	}
+	if err := s.expiry.Schedule(model.ExpiryKindShare, item, expiresAt); err != nil {
+		log.Printf("⚠️  Failed to schedule share %s expiry: %v", item, err)
+	}
+	return nil
+}
+
+// shareViewToken keys a view in the single-use registry; the first view is
+// keyed by the link ID alone, as single-view links always were
+func shareViewToken(id uuid.UUID, view int) string {
+	if view == 1 {
+		return id.String()
+	}
+	return fmt.Sprintf("%s/%d", id.String(), view)
+}
+
+func (s *ShareService) logConsumption(id uuid.UUID, ipAddress, userAgent string, success bool, details string) {
+	if s.auditService != nil {
+		s.auditService.LogAnonymousAction("share_consumed", "share", id.String(), ipAddress, userAgent, success, details)
+	}
+}
+
+// sealShareValue encrypts a value with a fresh key and returns the
+// ciphertext (nonce-prefixed) and the key, both base64url encoded.
+func sealShareValue(plaintext string) (string, string, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", "", err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", "", err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", "", err
+	}
+
+	ciphertext := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.RawURLEncoding.EncodeToString(ciphertext), base64.RawURLEncoding.EncodeToString(key), nil
+}
+
+var (
+	ErrShareNotFound   = errors.New("share not found")
+	ErrShareExpired    = errors.New("share has expired")
+	ErrShareConsumed   = errors.New("share has already been viewed")
+	ErrShareEmptyValue = errors.New("share value cannot be empty")
+
+	ErrSharePendingApproval = errors.New("share is awaiting approval")
+	ErrShareDenied          = errors.New("share was denied")
+	ErrShareNotPending      = errors.New("share is not awaiting approval")
+	ErrShareSelfApproval    = errors.New("share links cannot be approved by their creator")
+)
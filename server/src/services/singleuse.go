@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// singleUseGrace keeps consumption records past their token's expiry so a
+// token checked against a slightly fast clock is still refused
+const singleUseGrace = time.Hour
+
+// SingleUseService is the registry of consumed one-time tokens shared by
+// share links and chat approval callbacks. Consumption inserts a row keyed
+// by the token's hash; the database lets exactly one insert win, even
+// across HA instances, and holds concurrent ones until the winner commits
+// or rolls back.
+type SingleUseService struct {
+	db *gorm.DB
+}
+
+func NewSingleUseService(db *gorm.DB) *SingleUseService {
+	return &SingleUseService{db: db}
+}
+
+// Consume marks token as used and runs apply in the same transaction. When
+// apply fails the consumption is rolled back and the token can be used
+// again; when the token was already used, apply does not run and
+// ErrTokenConsumed is returned.
+func (s *SingleUseService) Consume(purpose, token string, expiresAt time.Time, consumer string, apply func(tx *gorm.DB) error) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		record := &model.SingleUseToken{
+			Purpose:    purpose,
+			TokenHash:  singleUseHash(token),
+			Consumer:   consumer,
+			ConsumedAt: time.Now(),
+			ExpiresAt:  expiresAt,
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+		if result.Error != nil {
+			return fmt.Errorf("failed to record token consumption: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrTokenConsumed
+		}
+		return apply(tx)
+	})
+}
+
+// Consumed reports whether token has been used, without using it
+func (s *SingleUseService) Consumed(purpose, token string) (bool, error) {
+	var count int64
+	if err := s.db.Model(&model.SingleUseToken{}).Where("purpose = ? AND token_hash = ?", purpose, singleUseHash(token)).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check token: %w", err)
+	}
+	return count > 0, nil
+}
+
+// Job removes the records of tokens that have expired
+func (s *SingleUseService) Job(interval time.Duration) *Job {
+	return &Job{
+		Name:        "single_use_tokens",
+		Description: "Remove consumption records of expired one-time tokens",
+		Interval:    interval,
+		Run:         s.purge,
+	}
+}
+
+func (s *SingleUseService) purge(ctx context.Context) (int64, string, error) {
+	result := s.db.WithContext(ctx).Where("expires_at < ?", time.Now().Add(-singleUseGrace)).Delete(&model.SingleUseToken{})
+	if result.Error != nil {
+		return 0, "", fmt.Errorf("failed to remove expired token records: %w", result.Error)
+	}
+	return result.RowsAffected, fmt.Sprintf("removed %d expired token records", result.RowsAffected), nil
+}
+
+func singleUseHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+var (
+	ErrTokenConsumed = errors.New("token has already been used")
+)
@@ -0,0 +1,610 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/config"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"golang.org/x/crypto/ssh"
+	"gorm.io/gorm"
+)
+
+const (
+	// SSHSignPolicy and SSHCredsPolicy are the policy paths granting
+	// certificates and one-time passwords; the role name is appended
+	SSHSignPolicy  = "ssh/sign"
+	SSHCredsPolicy = "ssh/creds"
+
+	// sshAnyUser in a role's allowed users admits every principal
+	sshAnyUser = "*"
+)
+
+// sshExtensions are the certificate extensions OpenSSH understands
+var sshExtensions = []string{
+	"permit-X11-forwarding",
+	"permit-agent-forwarding",
+	"permit-port-forwarding",
+	"permit-pty",
+	"permit-user-rc",
+}
+
+// SSHService brokers SSH access. It signs users' public keys with a CA key
+// sealed in the database, which hosts trust once, and hands out one-time
+// passwords that a helper on the target host checks back with the vault.
+// Both are granted per role through policies and audited.
+type SSHService struct {
+	db            *gorm.DB
+	secretService *SecretService
+	userService   *UserService
+	policyService *PolicyService
+	auditService  *AuditService
+	singleUse     *SingleUseService
+	config        *config.SSHConfig
+}
+
+func NewSSHService(db *gorm.DB, secretService *SecretService, userService *UserService, policyService *PolicyService, auditService *AuditService, singleUse *SingleUseService, config *config.SSHConfig) *SSHService {
+	return &SSHService{
+		db:            db,
+		secretService: secretService,
+		userService:   userService,
+		policyService: policyService,
+		auditService:  auditService,
+		singleUse:     singleUse,
+		config:        config,
+	}
+}
+
+// CreateCA generates the signing key. There is only one; replacing it
+// would lock out every host that trusts it, so a second call fails.
+func (s *SSHService) CreateCA(req *model.CreateSSHCARequest, userID uuid.UUID) (*model.SSHCAKey, error) {
+	keyType := req.KeyType
+	if keyType == "" {
+		keyType = model.PKIKeyTypeEd25519
+	}
+	keyBits := 0
+	if keyType == model.PKIKeyTypeRSA {
+		keyBits = 4096
+	}
+	keyType, keyBits, err := pkiKeyParams(keyType, keyBits)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSSHInvalidRequest, err)
+	}
+
+	var existing int64
+	if err := s.db.Model(&model.SSHCAKey{}).Count(&existing).Error; err != nil {
+		return nil, fmt.Errorf("failed to check ssh ca: %w", err)
+	}
+	if existing > 0 {
+		return nil, ErrSSHCAExists
+	}
+
+	key, err := generatePKIKey(keyType, keyBits)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := ssh.NewPublicKey(key.Public())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode ssh public key: %w", err)
+	}
+	privatePEM, err := encodePKIKey(key)
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := s.secretService.encrypt(privatePEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt ssh ca key: %w", err)
+	}
+
+	ca := &model.SSHCAKey{
+		KeyType:    keyType,
+		PublicKey:  strings.TrimSpace(string(ssh.MarshalAuthorizedKey(publicKey))),
+		PrivateKey: encrypted,
+		CreatedBy:  userID,
+	}
+	if err := s.db.Create(ca).Error; err != nil {
+		return nil, fmt.Errorf("failed to create ssh ca: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "ssh_ca_created", "ssh_ca", ca.ID.String(), true, fmt.Sprintf("key=%s; fingerprint=%s", keyType, ssh.FingerprintSHA256(publicKey)))
+	}
+
+	return ca, nil
+}
+
+// CAPublicKey returns the CA's public key in authorized_keys format, for
+// sshd's TrustedUserCAKeys
+func (s *SSHService) CAPublicKey() (string, error) {
+	ca, err := s.getCA()
+	if err != nil {
+		return "", err
+	}
+	return ca.PublicKey + "\n", nil
+}
+
+func (s *SSHService) CreateRole(req *model.CreateSSHRoleRequest, userID uuid.UUID) (*model.SSHRole, error) {
+	if req.Type != model.SSHRoleTypeCA && req.Type != model.SSHRoleTypeOTP {
+		return nil, fmt.Errorf("%w: type must be %s or %s", ErrSSHInvalidRole, model.SSHRoleTypeCA, model.SSHRoleTypeOTP)
+	}
+
+	users := make([]string, 0, len(req.AllowedUsers))
+	for _, user := range req.AllowedUsers {
+		user = strings.TrimSpace(user)
+		if user == "" || strings.ContainsAny(user, ", ") {
+			return nil, fmt.Errorf("%w: invalid allowed user %q", ErrSSHInvalidRole, user)
+		}
+		users = append(users, user)
+	}
+	if len(users) == 0 {
+		return nil, fmt.Errorf("%w: allowed_users is required", ErrSSHInvalidRole)
+	}
+	if req.DefaultUser != "" && !sshUserAllowed(users, req.DefaultUser) {
+		return nil, fmt.Errorf("%w: default_user is not an allowed user", ErrSSHInvalidRole)
+	}
+
+	role := &model.SSHRole{
+		Name:         req.Name,
+		Type:         req.Type,
+		AllowedUsers: strings.Join(users, ","),
+		DefaultUser:  req.DefaultUser,
+		TTL:          req.TTL,
+		MaxTTL:       req.MaxTTL,
+		CreatedBy:    userID,
+	}
+
+	switch req.Type {
+	case model.SSHRoleTypeCA:
+		for _, extension := range req.Extensions {
+			if !containsString(sshExtensions, extension) {
+				return nil, fmt.Errorf("%w: unknown extension %q", ErrSSHInvalidRole, extension)
+			}
+		}
+		if len(req.CIDRList) > 0 || req.Port != 0 {
+			return nil, fmt.Errorf("%w: cidr_list and port apply to otp roles", ErrSSHInvalidRole)
+		}
+		if req.TTL < 0 || req.MaxTTL < 0 {
+			return nil, fmt.Errorf("%w: ttls must not be negative", ErrSSHInvalidRole)
+		}
+		if req.MaxTTL > s.config.MaxTTL {
+			return nil, fmt.Errorf("%w: max_ttl exceeds %d seconds", ErrSSHInvalidRole, s.config.MaxTTL)
+		}
+		if req.MaxTTL > 0 && req.TTL > req.MaxTTL {
+			return nil, fmt.Errorf("%w: ttl exceeds max_ttl", ErrSSHInvalidRole)
+		}
+		role.Extensions = strings.Join(req.Extensions, ",")
+	case model.SSHRoleTypeOTP:
+		if len(req.Extensions) > 0 || req.TTL != 0 || req.MaxTTL != 0 {
+			return nil, fmt.Errorf("%w: extensions and ttls apply to ca roles", ErrSSHInvalidRole)
+		}
+		cidrs := make([]string, 0, len(req.CIDRList))
+		for _, cidr := range req.CIDRList {
+			_, network, err := net.ParseCIDR(strings.TrimSpace(cidr))
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid cidr %q", ErrSSHInvalidRole, cidr)
+			}
+			cidrs = append(cidrs, network.String())
+		}
+		if len(cidrs) == 0 {
+			return nil, fmt.Errorf("%w: cidr_list is required", ErrSSHInvalidRole)
+		}
+		if req.Port < 0 || req.Port > 65535 {
+			return nil, fmt.Errorf("%w: invalid port", ErrSSHInvalidRole)
+		}
+		role.CIDRs = strings.Join(cidrs, ",")
+		role.Port = req.Port
+		if role.Port == 0 {
+			role.Port = 22
+		}
+	}
+
+	var existing int64
+	if err := s.db.Model(&model.SSHRole{}).Where("name = ?", req.Name).Count(&existing).Error; err != nil {
+		return nil, fmt.Errorf("failed to check ssh roles: %w", err)
+	}
+	if existing > 0 {
+		return nil, ErrSSHRoleExists
+	}
+	if err := s.db.Create(role).Error; err != nil {
+		return nil, fmt.Errorf("failed to create ssh role: %w", err)
+	}
+	fillSSHRole(role)
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "ssh_role_created", "ssh_role", role.ID.String(), true, fmt.Sprintf("name=%s; type=%s; users=%s", role.Name, role.Type, role.AllowedUsers))
+	}
+
+	return role, nil
+}
+
+func (s *SSHService) GetRoles() ([]model.SSHRole, error) {
+	var roles []model.SSHRole
+	if err := s.db.Order("name").Find(&roles).Error; err != nil {
+		return nil, fmt.Errorf("failed to get ssh roles: %w", err)
+	}
+
+	for i := range roles {
+		fillSSHRole(&roles[i])
+	}
+
+	return roles, nil
+}
+
+// DeleteRole stops signing and password issuance under a role;
+// certificates already signed stay valid until they expire
+func (s *SSHService) DeleteRole(name string, userID uuid.UUID) error {
+	role, err := s.getRole(name)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Delete(&model.SSHRole{}, "id = ?", role.ID).Error; err != nil {
+		return fmt.Errorf("failed to delete ssh role: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "ssh_role_deleted", "ssh_role", role.ID.String(), true, fmt.Sprintf("name=%s", role.Name))
+	}
+
+	return nil
+}
+
+// Sign issues a user certificate for req.PublicKey under a ca role. The
+// caller needs create on ssh/sign/<role>, unless they are the central
+// admin.
+func (s *SSHService) Sign(roleName string, req *model.SignSSHKeyRequest, userID uuid.UUID) (*model.SignSSHKeyResponse, error) {
+	role, err := s.getRole(roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role.Type != model.SSHRoleTypeCA {
+		return nil, fmt.Errorf("%w: role %s does not sign keys", ErrSSHInvalidRequest, role.Name)
+	}
+	user, err := s.authorize(role, SSHSignPolicy, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(req.PublicKey))
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid public key", ErrSSHInvalidRequest)
+	}
+	if _, ok := publicKey.(*ssh.Certificate); ok {
+		return nil, fmt.Errorf("%w: public key is a certificate", ErrSSHInvalidRequest)
+	}
+
+	principals := req.ValidPrincipals
+	if len(principals) == 0 && role.DefaultUser != "" {
+		principals = []string{role.DefaultUser}
+	}
+	if len(principals) == 0 {
+		return nil, fmt.Errorf("%w: valid_principals is required, role %s has no default user", ErrSSHInvalidRequest, role.Name)
+	}
+	for _, principal := range principals {
+		if principal == sshAnyUser || !sshUserAllowed(role.UserList, principal) {
+			return nil, fmt.Errorf("%w: principal %q is not allowed by role %s", ErrSSHNotAllowed, principal, role.Name)
+		}
+	}
+
+	extensions := req.Extensions
+	if len(extensions) == 0 {
+		extensions = role.ExtensionList
+	}
+	permissions := make(map[string]string, len(extensions))
+	for _, extension := range extensions {
+		if !containsString(role.ExtensionList, extension) {
+			return nil, fmt.Errorf("%w: extension %q is not allowed by role %s", ErrSSHNotAllowed, extension, role.Name)
+		}
+		permissions[extension] = ""
+	}
+
+	ttl, err := s.certificateTTL(role, req.TTL)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := s.caSigner()
+	if err != nil {
+		return nil, err
+	}
+	var serialBytes [8]byte
+	if _, err := rand.Read(serialBytes[:]); err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	certificate := &ssh.Certificate{
+		Key:             publicKey,
+		Serial:          binary.BigEndian.Uint64(serialBytes[:]),
+		CertType:        ssh.UserCert,
+		KeyId:           fmt.Sprintf("vault:%s:%s", user.Email, role.Name),
+		ValidPrincipals: principals,
+		ValidAfter:      uint64(now.Add(-pkiClockSkew).Unix()),
+		ValidBefore:     uint64(expiresAt.Unix()),
+		Permissions:     ssh.Permissions{Extensions: permissions},
+	}
+	if err := certificate.SignCert(rand.Reader, signer); err != nil {
+		return nil, fmt.Errorf("failed to sign ssh certificate: %w", err)
+	}
+	serial := strconv.FormatUint(certificate.Serial, 10)
+
+	if s.auditService != nil {
+		details := fmt.Sprintf("serial=%s; principals=%s; key=%s; expires=%s", serial, strings.Join(principals, ","), ssh.FingerprintSHA256(publicKey), expiresAt.Format(time.RFC3339))
+		s.auditService.LogAction(userID, "ssh_certificate_signed", "ssh_role", role.ID.String(), true, details)
+	}
+
+	return &model.SignSSHKeyResponse{
+		SerialNumber:    serial,
+		SignedKey:       strings.TrimSpace(string(ssh.MarshalAuthorizedKey(certificate))),
+		ValidPrincipals: principals,
+		ExpiresAt:       expiresAt,
+	}, nil
+}
+
+// Credential issues a one-time password for req.Username on the host at
+// req.IP under an otp role. The caller needs create on ssh/creds/<role>,
+// unless they are the central admin.
+func (s *SSHService) Credential(roleName string, req *model.SSHCredentialRequest, userID uuid.UUID) (*model.SSHCredentialResponse, error) {
+	role, err := s.getRole(roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role.Type != model.SSHRoleTypeOTP {
+		return nil, fmt.Errorf("%w: role %s does not issue one-time passwords", ErrSSHInvalidRequest, role.Name)
+	}
+	if _, err := s.authorize(role, SSHCredsPolicy, userID); err != nil {
+		return nil, err
+	}
+
+	username := req.Username
+	if username == "" {
+		username = role.DefaultUser
+	}
+	if username == "" {
+		return nil, fmt.Errorf("%w: username is required, role %s has no default user", ErrSSHInvalidRequest, role.Name)
+	}
+	if username == sshAnyUser || !sshUserAllowed(role.UserList, username) {
+		return nil, fmt.Errorf("%w: user %q is not allowed by role %s", ErrSSHNotAllowed, username, role.Name)
+	}
+
+	ip := net.ParseIP(strings.TrimSpace(req.IP))
+	if ip == nil {
+		return nil, fmt.Errorf("%w: invalid ip %q", ErrSSHInvalidRequest, req.IP)
+	}
+	inRange := false
+	for _, cidr := range role.CIDRList {
+		if _, network, err := net.ParseCIDR(cidr); err == nil && network.Contains(ip) {
+			inRange = true
+			break
+		}
+	}
+	if !inRange {
+		return nil, fmt.Errorf("%w: ip %s is outside the networks of role %s", ErrSSHNotAllowed, ip, role.Name)
+	}
+
+	secret := make([]byte, 16)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate one-time password: %w", err)
+	}
+	key := hex.EncodeToString(secret)
+
+	otp := &model.SSHOTP{
+		Hash:      singleUseHash(key),
+		Role:      role.Name,
+		Username:  username,
+		IP:        ip.String(),
+		IssuedBy:  userID,
+		ExpiresAt: time.Now().Add(time.Duration(s.config.OTPTTL) * time.Second),
+	}
+	if err := s.db.Create(otp).Error; err != nil {
+		return nil, fmt.Errorf("failed to store one-time password: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "ssh_otp_issued", "ssh_role", role.ID.String(), true, fmt.Sprintf("otp=%s; user=%s; ip=%s", otp.ID, username, otp.IP))
+	}
+
+	return &model.SSHCredentialResponse{
+		Key:       key,
+		KeyType:   model.SSHRoleTypeOTP,
+		Username:  username,
+		IP:        otp.IP,
+		Port:      role.Port,
+		ExpiresAt: otp.ExpiresAt,
+	}, nil
+}
+
+// VerifyOTP uses a one-time password on behalf of the host helper and
+// tells it whom the password admits. Unknown, expired and used passwords
+// are indistinguishable to the caller.
+func (s *SSHService) VerifyOTP(key, ipAddress string) (*model.VerifySSHOTPResponse, error) {
+	var otp model.SSHOTP
+	if err := s.db.Where("hash = ?", singleUseHash(key)).First(&otp).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSSHOTPInvalid
+		}
+		return nil, fmt.Errorf("failed to get one-time password: %w", err)
+	}
+	now := time.Now()
+	if !now.Before(otp.ExpiresAt) {
+		return nil, ErrSSHOTPInvalid
+	}
+
+	err := s.singleUse.Consume(model.SingleUsePurposeSSHOTP, key, otp.ExpiresAt, ipAddress, func(tx *gorm.DB) error {
+		return tx.Model(&model.SSHOTP{}).Where("id = ?", otp.ID).Update("used_at", now).Error
+	})
+	if errors.Is(err, ErrTokenConsumed) {
+		if s.auditService != nil {
+			s.auditService.LogAction(otp.IssuedBy, "ssh_otp_reused", "ssh_otp", otp.ID.String(), false, fmt.Sprintf("user=%s; ip=%s; from=%s", otp.Username, otp.IP, ipAddress))
+		}
+		return nil, ErrSSHOTPInvalid
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to use one-time password: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(otp.IssuedBy, "ssh_otp_used", "ssh_otp", otp.ID.String(), true, fmt.Sprintf("user=%s; ip=%s; from=%s", otp.Username, otp.IP, ipAddress))
+	}
+
+	return &model.VerifySSHOTPResponse{
+		Username: otp.Username,
+		IP:       otp.IP,
+		Role:     otp.Role,
+	}, nil
+}
+
+// Job removes one-time passwords that have been used or have expired
+func (s *SSHService) Job(interval time.Duration) *Job {
+	return &Job{
+		Name:        "expired_ssh_otps",
+		Description: "Remove used and expired SSH one-time passwords",
+		Interval:    interval,
+		Run:         s.purgeOTPs,
+	}
+}
+
+func (s *SSHService) purgeOTPs(ctx context.Context) (int64, string, error) {
+	result := s.db.WithContext(ctx).Where("used_at IS NOT NULL OR expires_at < ?", time.Now()).Delete(&model.SSHOTP{})
+	if result.Error != nil {
+		return 0, "", fmt.Errorf("failed to remove ssh one-time passwords: %w", result.Error)
+	}
+	return result.RowsAffected, fmt.Sprintf("removed %d ssh one-time passwords", result.RowsAffected), nil
+}
+
+// authorize checks the caller's grant on <policy>/<role>, auditing refusals
+func (s *SSHService) authorize(role *model.SSHRole, policy string, userID uuid.UUID) (*model.User, error) {
+	user, err := s.userService.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	allowed := IsCentralAdmin(user)
+	if !allowed && s.policyService != nil {
+		allowed, err = s.policyService.CheckAccess(userID, policy+"/"+role.Name, "create")
+		if err != nil {
+			return nil, fmt.Errorf("failed to check policies: %w", err)
+		}
+	}
+	if !allowed {
+		if s.auditService != nil {
+			s.auditService.LogAction(userID, "ssh_access_denied", "ssh_role", role.ID.String(), false, fmt.Sprintf("policy=%s/%s", policy, role.Name))
+		}
+		return nil, ErrSSHDenied
+	}
+	return user, nil
+}
+
+// certificateTTL resolves the requested lifetime against the role and the
+// configured bounds
+func (s *SSHService) certificateTTL(role *model.SSHRole, requested int) (time.Duration, error) {
+	maxTTL := s.config.MaxTTL
+	if role.MaxTTL > 0 {
+		maxTTL = role.MaxTTL
+	}
+	ttl := requested
+	if ttl <= 0 {
+		ttl = role.TTL
+	}
+	if ttl <= 0 {
+		ttl = min(s.config.DefaultTTL, maxTTL)
+	}
+	if ttl > maxTTL {
+		return 0, fmt.Errorf("%w: ttl exceeds %d seconds", ErrSSHInvalidRequest, maxTTL)
+	}
+	return time.Duration(ttl) * time.Second, nil
+}
+
+// caSigner decrypts the CA key. RSA keys sign with SHA-512, since OpenSSH
+// refuses SHA-1 ssh-rsa signatures.
+func (s *SSHService) caSigner() (ssh.Signer, error) {
+	ca, err := s.getCA()
+	if err != nil {
+		return nil, err
+	}
+	decrypted, err := s.secretService.decrypt(ca.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt ssh ca key: %w", err)
+	}
+	block, _ := pem.Decode([]byte(decrypted))
+	if block == nil {
+		return nil, fmt.Errorf("invalid ssh ca private key")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ssh ca key: %w", err)
+	}
+	cryptoSigner, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("ssh ca key cannot sign")
+	}
+	signer, err := ssh.NewSignerFromSigner(cryptoSigner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ssh ca key: %w", err)
+	}
+	if _, ok := cryptoSigner.(*rsa.PrivateKey); ok {
+		algorithmSigner, ok := signer.(ssh.AlgorithmSigner)
+		if !ok {
+			return nil, fmt.Errorf("ssh ca key cannot choose a signature algorithm")
+		}
+		return ssh.NewSignerWithAlgorithms(algorithmSigner, []string{ssh.KeyAlgoRSASHA512})
+	}
+	return signer, nil
+}
+
+func (s *SSHService) getCA() (*model.SSHCAKey, error) {
+	var ca model.SSHCAKey
+	if err := s.db.First(&ca).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSSHCANotFound
+		}
+		return nil, fmt.Errorf("failed to get ssh ca: %w", err)
+	}
+	return &ca, nil
+}
+
+func (s *SSHService) getRole(name string) (*model.SSHRole, error) {
+	var role model.SSHRole
+	if err := s.db.Where("name = ?", name).First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSSHRoleNotFound
+		}
+		return nil, fmt.Errorf("failed to get ssh role: %w", err)
+	}
+	fillSSHRole(&role)
+	return &role, nil
+}
+
+func fillSSHRole(role *model.SSHRole) {
+	role.UserList = splitPKIList(role.AllowedUsers)
+	role.ExtensionList = splitPKIList(role.Extensions)
+	role.CIDRList = splitPKIList(role.CIDRs)
+}
+
+func sshUserAllowed(allowed []string, user string) bool {
+	return containsString(allowed, sshAnyUser) || containsString(allowed, user)
+}
+
+var (
+	ErrSSHUnavailable    = errors.New("ssh engine unavailable")
+	ErrSSHCANotFound     = errors.New("ssh ca has not been created")
+	ErrSSHCAExists       = errors.New("the ssh ca already exists")
+	ErrSSHRoleNotFound   = errors.New("ssh role not found")
+	ErrSSHRoleExists     = errors.New("an ssh role with this name already exists")
+	ErrSSHInvalidRole    = errors.New("invalid ssh role")
+	ErrSSHInvalidRequest = errors.New("invalid ssh request")
+	ErrSSHNotAllowed     = errors.New("not allowed by ssh role")
+	ErrSSHDenied         = errors.New("ssh access denied")
+	ErrSSHOTPInvalid     = errors.New("invalid or expired one-time password")
+)
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// A policy whose reads need a step-up verification the token has never made
+const stepUpRules = `[{"effect":"allow","resources":["secrets/*"],"actions":["read"],"mfa":{"max_age":300}}]`
+
+func TestValueReadsRequireStepUp(t *testing.T) {
+	accessor := uuid.New()
+	for name, read := range valueReads(&accessor) {
+		t.Run(name, func(t *testing.T) {
+			err := read(newSecretFixture(t, stepUpRules))
+			var stepUp *StepUpRequiredError
+			if !errors.As(err, &stepUp) {
+				t.Fatalf("got error %v, want a *StepUpRequiredError", err)
+			}
+			if stepUp.Requirement.MaxAge != 300 {
+				t.Errorf("got max_age %d, want 300", stepUp.Requirement.MaxAge)
+			}
+		})
+	}
+}
+
+func TestValueReadsPassFreshStepUp(t *testing.T) {
+	f := newSecretFixture(t, stepUpRules)
+	accessor := uuid.New()
+	f.tables["tokens"] = []map[string]driver.Value{{"mfa_verified_at": time.Now().Add(-time.Minute)}}
+
+	// Past the gate the stub has no version row to open
+	_, err := f.secrets.GetVersionValue(f.secretID, 1, f.userID, &accessor)
+	if !errors.Is(err, ErrSecretVersionNotFound) {
+		t.Fatalf("got error %v, want ErrSecretVersionNotFound", err)
+	}
+}
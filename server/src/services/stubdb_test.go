@@ -0,0 +1,281 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// stubTables answers every query on a table with all of its rows; WHERE
+// clauses are ignored, so fixtures hold only the rows a test reads, except
+// for column IN (...) lists, which filter on the listed values. Selects
+// naming their columns get only those. Deletes returning rows return the
+// table's rows. Writes change nothing and
+// report one row affected.
+type stubTables map[string][]map[string]driver.Value
+
+var (
+	stubTable  = regexp.MustCompile(`(?i)\bFROM "?(\w+)"?`)
+	stubInList = regexp.MustCompile(`"?(\w+)"? IN \((\$\d+(?:,\$\d+)*)\)`)
+	stubSelect = regexp.MustCompile(`(?i)^SELECT ((?:"\w+"\.)?"\w+"(?:,(?:"\w+"\.)?"\w+")*) FROM`)
+)
+
+// newStubDB opens gorm on tables without a database server, through the
+// same cache pool as the server
+func newStubDB(t *testing.T, tables stubTables) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: NewCachePool(sql.OpenDB(&stubConnector{tables: tables}))}), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("failed to open stub database: %v", err)
+	}
+	return db
+}
+
+type stubConnector struct {
+	mu     sync.Mutex
+	tables stubTables
+}
+
+func (c *stubConnector) Connect(context.Context) (driver.Conn, error) {
+	return &stubConn{connector: c}, nil
+}
+
+func (c *stubConnector) Driver() driver.Driver {
+	return stubDriver{}
+}
+
+type stubDriver struct{}
+
+func (stubDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("stub database opens through its connector")
+}
+
+type stubConn struct {
+	connector *stubConnector
+}
+
+func (c *stubConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("stub database does not prepare statements")
+}
+
+func (c *stubConn) Close() error {
+	return nil
+}
+
+func (c *stubConn) Begin() (driver.Tx, error) {
+	return stubTx{}, nil
+}
+
+func (c *stubConn) CheckNamedValue(*driver.NamedValue) error {
+	return nil
+}
+
+func (c *stubConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+
+func (c *stubConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	statement := strings.ToUpper(strings.TrimSpace(query))
+	returning := strings.HasPrefix(statement, "DELETE") && strings.Contains(statement, "RETURNING")
+	if !strings.HasPrefix(statement, "SELECT") && !returning {
+		return &stubRows{}, nil
+	}
+	match := stubTable.FindStringSubmatch(query)
+	if match == nil {
+		return &stubRows{}, nil
+	}
+
+	c.connector.mu.Lock()
+	rows := filterStubRows(c.connector.tables[match[1]], query, args)
+	c.connector.mu.Unlock()
+
+	if strings.Contains(strings.ToLower(query), "count(") {
+		return &stubRows{columns: []string{"count"}, rows: [][]driver.Value{{int64(len(rows))}}}, nil
+	}
+	if len(rows) == 0 {
+		return &stubRows{}, nil
+	}
+
+	columns := make([]string, 0, len(rows[0]))
+	if selected := stubSelect.FindStringSubmatch(strings.TrimSpace(query)); selected != nil {
+		for _, column := range strings.Split(selected[1], ",") {
+			parts := strings.Split(column, ".")
+			columns = append(columns, strings.Trim(parts[len(parts)-1], `"`))
+		}
+	} else {
+		for column := range rows[0] {
+			columns = append(columns, column)
+		}
+		sort.Strings(columns)
+	}
+	result := &stubRows{columns: columns}
+	for _, row := range rows {
+		values := make([]driver.Value, len(columns))
+		for i, column := range columns {
+			values[i] = row[column]
+		}
+		result.rows = append(result.rows, values)
+	}
+	return result, nil
+}
+
+// filterStubRows keeps the rows whose column is in each IN list of query
+func filterStubRows(rows []map[string]driver.Value, query string, args []driver.NamedValue) []map[string]driver.Value {
+	for _, list := range stubInList.FindAllStringSubmatch(query, -1) {
+		if len(rows) == 0 {
+			break
+		}
+		if _, ok := rows[0][list[1]]; !ok {
+			continue
+		}
+		values := make(map[string]bool)
+		for _, placeholder := range strings.Split(list[2], ",") {
+			ordinal, _ := strconv.Atoi(strings.TrimPrefix(placeholder, "$"))
+			for _, arg := range args {
+				if arg.Ordinal == ordinal {
+					values[fmt.Sprint(arg.Value)] = true
+				}
+			}
+		}
+		var kept []map[string]driver.Value
+		for _, row := range rows {
+			if values[fmt.Sprint(row[list[1]])] {
+				kept = append(kept, row)
+			}
+		}
+		rows = kept
+	}
+	return rows
+}
+
+type stubTx struct{}
+
+func (stubTx) Commit() error {
+	return nil
+}
+
+func (stubTx) Rollback() error {
+	return nil
+}
+
+type stubRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *stubRows) Columns() []string {
+	return r.columns
+}
+
+func (r *stubRows) Close() error {
+	return nil
+}
+
+func (r *stubRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+// secretFixture is one secret of userID under a single policy of userID,
+// read through a secret service with step-up checks enabled
+type secretFixture struct {
+	secrets  *SecretService
+	policies *PolicyService
+	tables   stubTables
+	userID   uuid.UUID
+	secretID uuid.UUID
+}
+
+func newSecretFixture(t *testing.T, rules string) *secretFixture {
+	t.Helper()
+	userID, secretID := uuid.New(), uuid.New()
+	tables := stubTables{
+		"secrets": {{
+			"id":         secretID.String(),
+			"user_id":    userID.String(),
+			"name":       "app/db",
+			"mount":      model.DefaultKVMount,
+			"type":       "generic",
+			"is_active":  true,
+			"updated_at": time.Now(),
+		}},
+		"policies": {{
+			"id":        uuid.NewString(),
+			"user_id":   userID.String(),
+			"name":      "gated",
+			"rules":     rules,
+			"is_active": true,
+		}},
+	}
+	db := newStubDB(t, tables)
+
+	policies := NewPolicyService(db, nil)
+	secrets := NewSecretService(db, "test-key", "test-salt", 1, nil, nil, nil, nil, nil)
+	secrets.UseStepUp(NewMFAService(db, nil, secrets, policies, nil, nil))
+	return &secretFixture{secrets: secrets, policies: policies, tables: tables, userID: userID, secretID: secretID}
+}
+
+// valueReads are the paths that open a secret's value, each reading the
+// fixture's secret with the given token accessor
+func valueReads(accessor *uuid.UUID) map[string]func(f *secretFixture) error {
+	return map[string]func(f *secretFixture) error{
+		"GetSecretByID": func(f *secretFixture) error {
+			_, err := f.secrets.GetSecretByID(f.secretID, f.userID, accessor)
+			return err
+		},
+		"ReadSecretValue": func(f *secretFixture) error {
+			_, err := f.secrets.ReadSecretValue(f.secretID, f.userID, accessor)
+			return err
+		},
+		"GetBundle": func(f *secretFixture) error {
+			_, err := f.secrets.GetBundle(f.userID, model.DefaultKVMount, "app", 0, accessor)
+			return err
+		},
+		"GetVersionValue": func(f *secretFixture) error {
+			_, err := f.secrets.GetVersionValue(f.secretID, 1, f.userID, accessor)
+			return err
+		},
+		"RenderConnectionString": func(f *secretFixture) error {
+			_, err := f.secrets.RenderConnectionString(f.secretID, f.userID, accessor, "postgres", "url")
+			return err
+		},
+		"ProviderService.ReadSecret": func(f *secretFixture) error {
+			_, err := NewProviderService(f.secrets.db, f.secrets).ReadSecret(f.secretID.String(), true, f.userID, accessor)
+			return err
+		},
+		"ShareService.CreateShare": func(f *secretFixture) error {
+			req := &model.CreateShareRequest{SecretID: &f.secretID}
+			_, err := NewShareService(f.secrets.db, f.secrets, nil, nil).CreateShare(req, f.userID, accessor, "https://vault.example.com")
+			return err
+		},
+		"SecretCheckoutService.Checkout": func(f *secretFixture) error {
+			f.tables["secrets"][0]["checkout_required"] = true
+			checkouts := NewSecretCheckoutService(f.secrets.db, f.secrets, nil, nil)
+			f.secrets.UseCheckouts(checkouts)
+			_, err := checkouts.Checkout(f.secretID, &model.CheckoutSecretRequest{}, f.userID, accessor)
+			return err
+		},
+	}
+}
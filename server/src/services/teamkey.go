@@ -0,0 +1,322 @@
+package services
+
+import (
+	"crypto/ecdh"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TeamKeyService stores users' X25519 public keys and lets namespace
+// members exchange the envelopes of client-encrypted secrets. Wrapping and
+// unwrapping happen in the CLI; the server only checks that each envelope
+// is wrapped for exactly the namespace's current readers.
+type TeamKeyService struct {
+	db               *gorm.DB
+	namespaceService *NamespaceService
+	secretService    *SecretService
+	auditService     *AuditService
+}
+
+func NewTeamKeyService(db *gorm.DB, namespaceService *NamespaceService, secretService *SecretService, auditService *AuditService) *TeamKeyService {
+	return &TeamKeyService{
+		db:               db,
+		namespaceService: namespaceService,
+		secretService:    secretService,
+		auditService:     auditService,
+	}
+}
+
+// RegisterKey sets the user's public key, replacing any earlier one.
+// Envelopes wrapped for the old key show up in rewrap plans.
+func (s *TeamKeyService) RegisterKey(userID uuid.UUID, req *model.RegisterUserKeyRequest) (*model.UserKey, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(req.PublicKey))
+	if err != nil {
+		return nil, fmt.Errorf("%w: not unpadded base64url", ErrUserKeyInvalid)
+	}
+	public, err := ecdh.X25519().NewPublicKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUserKeyInvalid, err)
+	}
+
+	key := &model.UserKey{
+		UserID:    userID,
+		PublicKey: base64.RawURLEncoding.EncodeToString(public.Bytes()),
+		KeyID:     publicKeyID(public.Bytes()),
+	}
+
+	previous, err := s.GetKey(userID)
+	if err != nil && !errors.Is(err, ErrUserKeyNotFound) {
+		return nil, err
+	}
+
+	if err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"public_key", "key_id", "updated_at"}),
+	}).Create(key).Error; err != nil {
+		return nil, fmt.Errorf("failed to register key: %w", err)
+	}
+
+	if s.auditService != nil {
+		details := "key_id=" + key.KeyID
+		if previous != nil && previous.KeyID != key.KeyID {
+			details += " replaces=" + previous.KeyID
+		}
+		s.auditService.LogAction(userID, "user_key_registered", "user", userID.String(), true, details)
+	}
+
+	return s.GetKey(userID)
+}
+
+func (s *TeamKeyService) GetKey(userID uuid.UUID) (*model.UserKey, error) {
+	var key model.UserKey
+	if err := s.db.Where("user_id = ?", userID).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to get key: %w", err)
+	}
+	return &key, nil
+}
+
+// GetMemberKeys lists every user whose namespace roles grant secrets:read,
+// with their public key when they have registered one
+func (s *TeamKeyService) GetMemberKeys(namespaceID uuid.UUID) ([]model.TeamMemberKey, error) {
+	if _, err := s.namespaceService.GetNamespaceByID(namespaceID); err != nil {
+		return nil, err
+	}
+
+	var roles []model.NamespaceRole
+	for role, permissions := range namespaceRolePermissions {
+		for _, permission := range permissions {
+			if permission == model.NamespacePermissionSecretsRead {
+				roles = append(roles, role)
+			}
+		}
+	}
+
+	var userIDs []uuid.UUID
+	if err := s.db.Model(&model.NamespaceRoleBinding{}).
+		Where("namespace_id = ? AND role IN ?", namespaceID, roles).
+		Distinct().Pluck("user_id", &userIDs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get namespace members: %w", err)
+	}
+
+	var keys []model.UserKey
+	if len(userIDs) > 0 {
+		if err := s.db.Where("user_id IN ?", userIDs).Find(&keys).Error; err != nil {
+			return nil, fmt.Errorf("failed to get member keys: %w", err)
+		}
+	}
+	byUser := make(map[uuid.UUID]model.UserKey, len(keys))
+	for _, key := range keys {
+		byUser[key.UserID] = key
+	}
+
+	members := make([]model.TeamMemberKey, 0, len(userIDs))
+	for _, userID := range userIDs {
+		member := model.TeamMemberKey{UserID: userID}
+		if key, ok := byUser[userID]; ok {
+			member.KeyID = key.KeyID
+			member.PublicKey = key.PublicKey
+		}
+		members = append(members, member)
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].UserID.String() < members[j].UserID.String() })
+
+	return members, nil
+}
+
+// GetRewrapPlan compares each client-encrypted secret in the namespace
+// with the current members. Added members need a grant; removed members
+// and replaced keys need the value re-sealed under a new data key.
+func (s *TeamKeyService) GetRewrapPlan(namespaceID uuid.UUID) (*model.TeamRewrapPlanResponse, error) {
+	members, err := s.GetMemberKeys(namespaceID)
+	if err != nil {
+		return nil, err
+	}
+	memberKeys := make(map[string]bool)
+	for _, member := range members {
+		if member.KeyID != "" {
+			memberKeys[member.KeyID] = true
+		}
+	}
+
+	var secrets []model.Secret
+	if err := s.db.Where("namespace_id = ? AND client_encrypted = ? AND quarantined_at IS NULL", namespaceID, true).
+		Order("name").Find(&secrets).Error; err != nil {
+		return nil, fmt.Errorf("failed to get secrets: %w", err)
+	}
+
+	plan := &model.TeamRewrapPlanResponse{NamespaceID: namespaceID, Members: members, Secrets: []model.TeamRewrapSecret{}}
+	for i := range secrets {
+		value, err := s.secretService.openSecret(&secrets[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to open secret %s: %w", secrets[i].ID, err)
+		}
+		recipients, err := envelopeRecipients(value)
+		if err != nil {
+			return nil, fmt.Errorf("secret %s: %w", secrets[i].ID, err)
+		}
+
+		entry := model.TeamRewrapSecret{SecretID: secrets[i].ID, Name: secrets[i].Name, Grant: []string{}, Revoke: []string{}}
+		present := make(map[string]bool, len(recipients))
+		for _, keyID := range recipients {
+			present[keyID] = true
+			if !memberKeys[keyID] {
+				entry.Revoke = append(entry.Revoke, keyID)
+			}
+		}
+		for keyID := range memberKeys {
+			if !present[keyID] {
+				entry.Grant = append(entry.Grant, keyID)
+			}
+		}
+		sort.Strings(entry.Grant)
+
+		if len(entry.Grant) > 0 || len(entry.Revoke) > 0 {
+			plan.Secrets = append(plan.Secrets, entry)
+		}
+	}
+
+	return plan, nil
+}
+
+// GetEnvelope returns a client-encrypted secret's envelope to a namespace
+// reader, who unwraps the data key with their own private key
+func (s *TeamKeyService) GetEnvelope(namespaceID, secretID, userID uuid.UUID) (*model.SecretValueResponse, error) {
+	secret, err := s.clientSecret(namespaceID, secretID)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := s.secretService.openSecret(secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "secret_envelope_accessed", "secret", secret.ID.String(), true, "namespace="+namespaceID.String())
+	}
+
+	return &model.SecretValueResponse{
+		ID:              secret.ID,
+		Name:            secret.Name,
+		Value:           value,
+		ExpiresAt:       secret.ExpiresAt,
+		ClientEncrypted: true,
+	}, nil
+}
+
+// StoreEnvelope replaces the envelope of a client-encrypted secret after
+// a grant or re-seal. Every recipient must be a current namespace reader.
+func (s *TeamKeyService) StoreEnvelope(namespaceID, secretID uuid.UUID, req *model.StoreEnvelopeRequest, userID uuid.UUID) error {
+	recipients, err := envelopeRecipients(req.Value)
+	if err != nil {
+		return err
+	}
+	if len(recipients) == 0 {
+		return fmt.Errorf("%w: envelope has no recipients", ErrSecretEnvelopeInvalid)
+	}
+
+	members, err := s.GetMemberKeys(namespaceID)
+	if err != nil {
+		return err
+	}
+	memberKeys := make(map[string]bool)
+	for _, member := range members {
+		memberKeys[member.KeyID] = member.KeyID != ""
+	}
+	for _, keyID := range recipients {
+		if !memberKeys[keyID] {
+			return fmt.Errorf("%w: %s", ErrEnvelopeRecipientNotMember, keyID)
+		}
+	}
+
+	secret, err := s.clientSecret(namespaceID, secretID)
+	if err != nil {
+		return err
+	}
+	if err := s.secretService.checkIntegrity(secret); err != nil {
+		return err
+	}
+
+	if err := s.secretService.sealSecret(secret, req.Value); err != nil {
+		return fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+	// A new envelope is not a rotation; RotatedAt is left alone
+	secret.ValueHash = s.secretService.hashValue(req.Value)
+	s.secretService.stampChecksum(secret)
+
+	if err := s.db.Save(secret).Error; err != nil {
+		return fmt.Errorf("failed to update secret: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "secret_envelope_updated", "secret", secret.ID.String(), true, "recipients="+strings.Join(recipients, ","))
+	}
+
+	return nil
+}
+
+func (s *TeamKeyService) clientSecret(namespaceID, secretID uuid.UUID) (*model.Secret, error) {
+	var secret model.Secret
+	if err := s.db.Where("id = ? AND namespace_id = ? AND client_encrypted = ? AND is_active = ?", secretID, namespaceID, true, true).
+		First(&secret).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSecretNotFound
+		}
+		return nil, fmt.Errorf("failed to get secret: %w", err)
+	}
+	return &secret, nil
+}
+
+// envelopeRecipients reads the recipient key IDs of a client envelope.
+// They name public keys only, so the server can inspect them.
+func envelopeRecipients(value string) ([]string, error) {
+	if !strings.HasPrefix(value, ClientEnvelopePrefix) {
+		return nil, ErrSecretEnvelopeInvalid
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(value, ClientEnvelopePrefix))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSecretEnvelopeInvalid, err)
+	}
+
+	var envelope struct {
+		Recipients []struct {
+			KeyID string `json:"kid"`
+		} `json:"recipients"`
+	}
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSecretEnvelopeInvalid, err)
+	}
+
+	ids := make([]string, 0, len(envelope.Recipients))
+	for _, recipient := range envelope.Recipients {
+		ids = append(ids, recipient.KeyID)
+	}
+	return ids, nil
+}
+
+// publicKeyID matches the CLI's key fingerprint: the first 8 bytes of the
+// key's SHA-256, hex encoded
+func publicKeyID(public []byte) string {
+	sum := sha256.Sum256(public)
+	return hex.EncodeToString(sum[:8])
+}
+
+var (
+	ErrUserKeyNotFound            = errors.New("user has not registered a public key")
+	ErrUserKeyInvalid             = errors.New("public key must be a 32-byte X25519 key")
+	ErrEnvelopeRecipientNotMember = errors.New("envelope is wrapped for a key that is not a namespace reader")
+)
@@ -0,0 +1,261 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+)
+
+// TemplateService stores secret templates and validates secret values
+// written under the path patterns they cover.
+type TemplateService struct {
+	db           *gorm.DB
+	auditService *AuditService
+}
+
+// TemplateValidationError lists every way a value fails its template.
+type TemplateValidationError struct {
+	Template   *model.SecretTemplate
+	Violations []string
+}
+
+func (e *TemplateValidationError) Error() string {
+	return fmt.Sprintf("secret does not match template %q: %s", e.Template.PathPattern, strings.Join(e.Violations, "; "))
+}
+
+func NewTemplateService(db *gorm.DB, auditService *AuditService) *TemplateService {
+	return &TemplateService{
+		db:           db,
+		auditService: auditService,
+	}
+}
+
+func (s *TemplateService) CreateTemplate(req *model.CreateTemplateRequest, userID uuid.UUID) (*model.SecretTemplate, error) {
+	pattern := strings.Trim(strings.TrimSpace(req.PathPattern), "/")
+	if pattern == "" {
+		return nil, ErrTemplateInvalid
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("%w: bad path pattern: %v", ErrTemplateInvalid, err)
+	}
+
+	seen := make(map[string]bool)
+	for _, field := range req.Fields {
+		if field.Name == "" {
+			return nil, fmt.Errorf("%w: field name is required", ErrTemplateInvalid)
+		}
+		if seen[field.Name] {
+			return nil, fmt.Errorf("%w: duplicate field %q", ErrTemplateInvalid, field.Name)
+		}
+		seen[field.Name] = true
+
+		switch field.Type {
+		case model.TemplateFieldString, model.TemplateFieldNumber, model.TemplateFieldInteger, model.TemplateFieldBoolean:
+		default:
+			return nil, fmt.Errorf("%w: field %q has unsupported type %q", ErrTemplateInvalid, field.Name, field.Type)
+		}
+
+		if field.Pattern != "" {
+			if _, err := regexp.Compile(field.Pattern); err != nil {
+				return nil, fmt.Errorf("%w: field %q has invalid pattern: %v", ErrTemplateInvalid, field.Name, err)
+			}
+		}
+	}
+
+	fields, err := json.Marshal(req.Fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode template fields: %w", err)
+	}
+
+	template := &model.SecretTemplate{
+		PathPattern: pattern,
+		Description: req.Description,
+		Fields:      string(fields),
+		CreatedBy:   userID,
+		FieldList:   req.Fields,
+	}
+
+	if err := s.db.Create(template).Error; err != nil {
+		return nil, fmt.Errorf("failed to create template: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "template_created", "template", template.ID.String(), true, pattern)
+	}
+
+	return template, nil
+}
+
+func (s *TemplateService) GetTemplates() ([]model.SecretTemplate, error) {
+	var templates []model.SecretTemplate
+	if err := s.db.Order("path_pattern").Find(&templates).Error; err != nil {
+		return nil, fmt.Errorf("failed to get templates: %w", err)
+	}
+
+	for i := range templates {
+		if err := s.decodeFields(&templates[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return templates, nil
+}
+
+func (s *TemplateService) DeleteTemplate(id uuid.UUID, userID uuid.UUID) error {
+	result := s.db.Where("id = ?", id).Delete(&model.SecretTemplate{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete template: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrTemplateNotFound
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "template_deleted", "template", id.String(), true, "")
+	}
+
+	return nil
+}
+
+// MatchTemplate returns the most specific template covering a secret
+// path, or ErrTemplateNotFound when none applies.
+func (s *TemplateService) MatchTemplate(secretPath string) (*model.SecretTemplate, error) {
+	templates, err := s.GetTemplates()
+	if err != nil {
+		return nil, err
+	}
+
+	secretPath = strings.Trim(secretPath, "/")
+
+	// Longer patterns are more specific
+	sort.SliceStable(templates, func(i, j int) bool {
+		return len(templates[i].PathPattern) > len(templates[j].PathPattern)
+	})
+
+	for i := range templates {
+		if matchTemplatePattern(templates[i].PathPattern, secretPath) {
+			return &templates[i], nil
+		}
+	}
+
+	return nil, ErrTemplateNotFound
+}
+
+// ValidateSecret checks a secret value against the template matching its
+// path. Secrets outside every template are accepted unchanged.
+func (s *TemplateService) ValidateSecret(secretPath, value string) error {
+	template, err := s.MatchTemplate(secretPath)
+	if err != nil {
+		if errors.Is(err, ErrTemplateNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &data); err != nil {
+		return &TemplateValidationError{
+			Template:   template,
+			Violations: []string{"value must be a JSON object"},
+		}
+	}
+
+	var violations []string
+	known := make(map[string]bool)
+
+	for _, field := range template.FieldList {
+		known[field.Name] = true
+
+		raw, exists := data[field.Name]
+		if !exists || raw == nil {
+			if field.Required {
+				violations = append(violations, fmt.Sprintf("%s is required", field.Name))
+			}
+			continue
+		}
+
+		if problem := validateTemplateField(field, raw); problem != "" {
+			violations = append(violations, problem)
+		}
+	}
+
+	for name := range data {
+		if !known[name] {
+			violations = append(violations, fmt.Sprintf("%s is not defined by the template", name))
+		}
+	}
+
+	if len(violations) > 0 {
+		sort.Strings(violations)
+		return &TemplateValidationError{
+			Template:   template,
+			Violations: violations,
+		}
+	}
+
+	return nil
+}
+
+func (s *TemplateService) decodeFields(template *model.SecretTemplate) error {
+	if err := json.Unmarshal([]byte(template.Fields), &template.FieldList); err != nil {
+		return fmt.Errorf("failed to decode template fields: %w", err)
+	}
+	return nil
+}
+
+// matchTemplatePattern matches "prefix/*" against everything below prefix
+// and any other pattern as a path glob.
+func matchTemplatePattern(pattern, secretPath string) bool {
+	if strings.HasSuffix(pattern, "/*") {
+		prefix := strings.TrimSuffix(pattern, "*")
+		if strings.HasPrefix(secretPath, prefix) && len(secretPath) > len(prefix) {
+			return true
+		}
+	}
+
+	matched, err := path.Match(pattern, secretPath)
+	return err == nil && matched
+}
+
+func validateTemplateField(field model.TemplateField, raw interface{}) string {
+	switch field.Type {
+	case model.TemplateFieldString:
+		value, ok := raw.(string)
+		if !ok {
+			return fmt.Sprintf("%s must be a string", field.Name)
+		}
+		if field.Pattern != "" {
+			if matched, _ := regexp.MatchString(field.Pattern, value); !matched {
+				return fmt.Sprintf("%s does not match pattern %s", field.Name, field.Pattern)
+			}
+		}
+	case model.TemplateFieldNumber:
+		if _, ok := raw.(float64); !ok {
+			return fmt.Sprintf("%s must be a number", field.Name)
+		}
+	case model.TemplateFieldInteger:
+		value, ok := raw.(float64)
+		if !ok || value != math.Trunc(value) {
+			return fmt.Sprintf("%s must be an integer", field.Name)
+		}
+	case model.TemplateFieldBoolean:
+		if _, ok := raw.(bool); !ok {
+			return fmt.Sprintf("%s must be a boolean", field.Name)
+		}
+	}
+	return ""
+}
+
+var (
+	ErrTemplateNotFound = errors.New("template not found")
+	ErrTemplateInvalid  = errors.New("invalid template")
+)
@@ -0,0 +1,587 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultTenantKeyCacheTTL = 300
+	maxTenantKeyCacheTTL     = 86400
+	tenantRewrapBatchSize    = 100
+)
+
+// TenantKeyService manages namespace-owned key encryption keys (BYOK) and
+// the per-secret data keys they wrap
+type TenantKeyService struct {
+	db               *gorm.DB
+	namespaceService *NamespaceService
+	auditService     *AuditService
+	integrity        *SecretIntegrity
+	newWrapper       func(keyURI string) (KeyWrapper, error)
+	cache            map[string]cachedDataKey
+	mutex            sync.Mutex
+}
+
+type cachedDataKey struct {
+	key       []byte
+	expiresAt time.Time
+}
+
+func NewTenantKeyService(db *gorm.DB, namespaceService *NamespaceService, auditService *AuditService, integrity *SecretIntegrity) *TenantKeyService {
+	return &TenantKeyService{
+		db:               db,
+		namespaceService: namespaceService,
+		auditService:     auditService,
+		integrity:        integrity,
+		newWrapper:       NewKeyWrapper,
+		cache:            make(map[string]cachedDataKey),
+	}
+}
+
+func (s *TenantKeyService) RegisterKey(namespaceID uuid.UUID, req *model.RegisterTenantKeyRequest, actorID uuid.UUID) (*model.TenantKey, error) {
+	if _, err := s.namespaceService.GetNamespaceByID(namespaceID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.GetKey(namespaceID); err == nil {
+		return nil, ErrTenantKeyExists
+	} else if !errors.Is(err, ErrTenantKeyNotFound) {
+		return nil, err
+	}
+
+	failover := req.FailoverMode
+	if failover == "" {
+		failover = model.TenantKeyFailoverClosed
+	}
+	if failover != model.TenantKeyFailoverClosed && failover != model.TenantKeyFailoverCached {
+		return nil, fmt.Errorf("%w: failover_mode must be %s or %s", ErrTenantKeyInvalid, model.TenantKeyFailoverClosed, model.TenantKeyFailoverCached)
+	}
+
+	cacheTTL := req.CacheTTL
+	if cacheTTL == 0 {
+		cacheTTL = defaultTenantKeyCacheTTL
+	}
+	if cacheTTL < 0 || cacheTTL > maxTenantKeyCacheTTL {
+		return nil, fmt.Errorf("%w: cache_ttl must be between 1 and %d seconds", ErrTenantKeyInvalid, maxTenantKeyCacheTTL)
+	}
+
+	// Refuse keys the server cannot use right now
+	if err := s.probe(req.KeyURI); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	key := &model.TenantKey{
+		NamespaceID:   namespaceID,
+		KeyURI:        req.KeyURI,
+		Version:       1,
+		Status:        model.TenantKeyStatusActive,
+		FailoverMode:  failover,
+		CacheTTL:      cacheTTL,
+		LastCheckedAt: &now,
+		CreatedBy:     actorID,
+	}
+
+	if err := s.db.Create(key).Error; err != nil {
+		return nil, fmt.Errorf("failed to register tenant key: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(actorID, "tenant_key_registered", "namespace", namespaceID.String(), true, fmt.Sprintf("failover=%s", failover))
+	}
+
+	return key, nil
+}
+
+func (s *TenantKeyService) GetKey(namespaceID uuid.UUID) (*model.TenantKey, error) {
+	var key model.TenantKey
+	if err := s.db.Where("namespace_id = ?", namespaceID).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTenantKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to get tenant key: %w", err)
+	}
+
+	return &key, nil
+}
+
+// CheckKey round-trips a probe through the tenant's KMS and records the
+// resulting status
+func (s *TenantKeyService) CheckKey(namespaceID uuid.UUID) (*model.TenantKey, error) {
+	key, err := s.GetKey(namespaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{"last_checked_at": now, "last_error": ""}
+	if err := s.probe(key.KeyURI); err != nil {
+		updates["last_error"] = err.Error()
+		if key.Status == model.TenantKeyStatusActive {
+			updates["status"] = model.TenantKeyStatusUnavailable
+		}
+	} else if key.Status == model.TenantKeyStatusUnavailable {
+		updates["status"] = model.TenantKeyStatusActive
+	}
+
+	if err := s.db.Model(key).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("failed to update tenant key status: %w", err)
+	}
+
+	return s.GetKey(namespaceID)
+}
+
+// RotateKey switches the namespace to a new external key and re-wraps every
+// data key in the background. Values are never re-encrypted.
+func (s *TenantKeyService) RotateKey(namespaceID uuid.UUID, req *model.RotateTenantKeyRequest, actorID uuid.UUID) (*model.TenantKey, error) {
+	key, err := s.GetKey(namespaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if key.Status == model.TenantKeyStatusRewrapping {
+		return nil, ErrTenantKeyBusy
+	}
+	if req.KeyURI == key.KeyURI {
+		return nil, fmt.Errorf("%w: new key_uri must differ from the current one", ErrTenantKeyInvalid)
+	}
+
+	if err := s.probe(req.KeyURI); err != nil {
+		return nil, err
+	}
+
+	var total int64
+	if err := s.db.Model(&model.Secret{}).Where("namespace_id = ? AND wrapped_key <> ''", namespaceID).Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count secrets: %w", err)
+	}
+
+	if err := s.db.Model(key).Updates(map[string]interface{}{
+		"previous_key_uri": key.KeyURI,
+		"key_uri":          req.KeyURI,
+		"version":          key.Version + 1,
+		"status":           model.TenantKeyStatusRewrapping,
+		"rewrap_total":     total,
+		"rewrap_done":      0,
+		"last_error":       "",
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to rotate tenant key: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(actorID, "tenant_key_rotated", "namespace", namespaceID.String(), true, fmt.Sprintf("version=%d secrets=%d", key.Version+1, total))
+	}
+
+	go s.rewrap(namespaceID, actorID)
+
+	return s.GetKey(namespaceID)
+}
+
+// ResumeRewrap restarts a re-wrap job that stopped on a KMS error; it
+// finishes immediately when nothing is left to re-wrap
+func (s *TenantKeyService) ResumeRewrap(namespaceID uuid.UUID, actorID uuid.UUID) (*model.TenantKey, error) {
+	key, err := s.GetKey(namespaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if key.Status == model.TenantKeyStatusRewrapping || key.PreviousKeyURI == "" {
+		return nil, ErrTenantKeyNoRewrap
+	}
+
+	if err := s.db.Model(key).Updates(map[string]interface{}{"status": model.TenantKeyStatusRewrapping, "last_error": ""}).Error; err != nil {
+		return nil, fmt.Errorf("failed to resume re-wrap: %w", err)
+	}
+
+	go s.rewrap(namespaceID, actorID)
+
+	return s.GetKey(namespaceID)
+}
+
+// ResumeInterrupted restarts re-wrap jobs left running by a previous
+// process and returns how many were restarted
+func (s *TenantKeyService) ResumeInterrupted() (int, error) {
+	var keys []model.TenantKey
+	if err := s.db.Where("status = ?", model.TenantKeyStatusRewrapping).Find(&keys).Error; err != nil {
+		return 0, fmt.Errorf("failed to list tenant keys: %w", err)
+	}
+
+	for _, key := range keys {
+		go s.rewrap(key.NamespaceID, key.CreatedBy)
+	}
+
+	return len(keys), nil
+}
+
+func (s *TenantKeyService) rewrap(namespaceID uuid.UUID, actorID uuid.UUID) {
+	fail := func(err error) {
+		s.db.Model(&model.TenantKey{}).Where("namespace_id = ?", namespaceID).Updates(map[string]interface{}{
+			"status":     model.TenantKeyStatusUnavailable,
+			"last_error": err.Error(),
+		})
+		if s.auditService != nil {
+			s.auditService.LogAction(actorID, "tenant_key_rewrap_failed", "namespace", namespaceID.String(), false, err.Error())
+		}
+	}
+
+	key, err := s.GetKey(namespaceID)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	previous, err := s.newWrapper(key.PreviousKeyURI)
+	if err != nil {
+		fail(err)
+		return
+	}
+	current, err := s.newWrapper(key.KeyURI)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	prefix := versionPrefix(key.Version)
+	for {
+		var secrets []model.Secret
+		// Quarantined rows are left alone; re-signing them would hide the
+		// tampering the scrub job found
+		if err := s.db.Where("namespace_id = ? AND wrapped_key <> '' AND wrapped_key NOT LIKE ? AND quarantined_at IS NULL", namespaceID, prefix+"%").
+			Limit(tenantRewrapBatchSize).Find(&secrets).Error; err != nil {
+			fail(fmt.Errorf("failed to load secrets: %w", err))
+			return
+		}
+		if len(secrets) == 0 {
+			break
+		}
+
+		for _, secret := range secrets {
+			if s.integrity != nil && secret.Checksum != "" && !s.integrity.Verify(&secret) {
+				fail(fmt.Errorf("secret %s failed its integrity check; resume once the secret_scrub job has quarantined it", secret.ID))
+				return
+			}
+
+			version, wrapped, err := splitWrappedKey(secret.WrappedKey)
+			if err != nil || version != key.Version-1 {
+				fail(fmt.Errorf("secret %s has a data key from an unknown key version", secret.ID))
+				return
+			}
+
+			dataKey, err := previous.Unwrap(wrapped)
+			if err != nil {
+				fail(err)
+				return
+			}
+
+			rewrapped, err := current.Wrap(dataKey)
+			if err != nil {
+				fail(err)
+				return
+			}
+
+			updates := map[string]interface{}{"wrapped_key": prefix + rewrapped}
+			if s.integrity != nil {
+				secret.WrappedKey = prefix + rewrapped
+				updates["checksum"] = s.integrity.Sum(&secret)
+			}
+			if err := s.db.Model(&model.Secret{}).Where("id = ?", secret.ID).
+				UpdateColumns(updates).Error; err != nil {
+				fail(fmt.Errorf("failed to store re-wrapped key: %w", err))
+				return
+			}
+			s.db.Model(&model.TenantKey{}).Where("namespace_id = ?", namespaceID).
+				UpdateColumn("rewrap_done", gorm.Expr("rewrap_done + 1"))
+		}
+	}
+
+	// Previous values kept by versioned mounts carry data keys too
+	namespaceSecrets := s.db.Unscoped().Model(&model.Secret{}).Select("id").Where("namespace_id = ?", namespaceID)
+	for {
+		var versions []model.SecretVersion
+		if err := s.db.Where("secret_id IN (?) AND wrapped_key <> '' AND wrapped_key NOT LIKE ?", namespaceSecrets, prefix+"%").
+			Limit(tenantRewrapBatchSize).Find(&versions).Error; err != nil {
+			fail(fmt.Errorf("failed to load secret versions: %w", err))
+			return
+		}
+		if len(versions) == 0 {
+			break
+		}
+
+		for _, version := range versions {
+			keyVersion, wrapped, err := splitWrappedKey(version.WrappedKey)
+			if err != nil || keyVersion != key.Version-1 {
+				fail(fmt.Errorf("version %d of secret %s has a data key from an unknown key version", version.Version, version.SecretID))
+				return
+			}
+
+			dataKey, err := previous.Unwrap(wrapped)
+			if err != nil {
+				fail(err)
+				return
+			}
+
+			rewrapped, err := current.Wrap(dataKey)
+			if err != nil {
+				fail(err)
+				return
+			}
+
+			if err := s.db.Model(&model.SecretVersion{}).Where("id = ?", version.ID).
+				UpdateColumn("wrapped_key", prefix+rewrapped).Error; err != nil {
+				fail(fmt.Errorf("failed to store re-wrapped key: %w", err))
+				return
+			}
+		}
+	}
+
+	now := time.Now()
+	s.db.Model(&model.TenantKey{}).Where("namespace_id = ?", namespaceID).Updates(map[string]interface{}{
+		"status":          model.TenantKeyStatusActive,
+		"last_checked_at": now,
+	})
+
+	if s.auditService != nil {
+		s.auditService.LogAction(actorID, "tenant_key_rewrapped", "namespace", namespaceID.String(), true, fmt.Sprintf("version=%d", key.Version))
+	}
+}
+
+// CanWrite reports whether the user may store secrets in the namespace
+func (s *TenantKeyService) CanWrite(userID, namespaceID uuid.UUID) (bool, error) {
+	return s.namespaceService.HasPermission(userID, namespaceID, model.NamespacePermissionSecretsWrite)
+}
+
+// Seal encrypts plaintext under a fresh data key wrapped by the namespace's
+// tenant key. It returns ErrTenantKeyNotFound when the namespace has none.
+func (s *TenantKeyService) Seal(namespaceID uuid.UUID, plaintext []byte) (string, string, error) {
+	key, err := s.GetKey(namespaceID)
+	if err != nil {
+		return "", "", err
+	}
+
+	wrapper, err := s.newWrapper(key.KeyURI)
+	if err != nil {
+		return "", "", err
+	}
+
+	dataKey := make([]byte, 32)
+	if _, err := io.ReadFull(keyReader, dataKey); err != nil {
+		return "", "", err
+	}
+
+	wrapped, err := wrapper.Wrap(dataKey)
+	if err != nil {
+		s.markUnavailable(key, err)
+		return "", "", err
+	}
+	wrappedKey := versionPrefix(key.Version) + wrapped
+
+	ciphertext, err := sealWithDataKey(dataKey, namespaceID, plaintext)
+	if err != nil {
+		return "", "", err
+	}
+
+	if key.FailoverMode == model.TenantKeyFailoverCached {
+		s.remember(wrappedKey, dataKey, key.CacheTTL)
+	}
+
+	return ciphertext, wrappedKey, nil
+}
+
+// Open decrypts a value sealed by Seal, unwrapping its data key through the
+// tenant's KMS or, in cached failover mode, from memory
+func (s *TenantKeyService) Open(namespaceID uuid.UUID, ciphertext, wrappedKey string) ([]byte, error) {
+	key, err := s.GetKey(namespaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey, cached := s.recall(wrappedKey)
+	if !cached {
+		version, wrapped, err := splitWrappedKey(wrappedKey)
+		if err != nil {
+			return nil, err
+		}
+
+		// During a re-wrap some data keys are still wrapped by the
+		// previous key
+		keyURI := key.KeyURI
+		switch version {
+		case key.Version:
+		case key.Version - 1:
+			if key.PreviousKeyURI == "" {
+				return nil, ErrTenantKeyVersion
+			}
+			keyURI = key.PreviousKeyURI
+		default:
+			return nil, ErrTenantKeyVersion
+		}
+
+		wrapper, err := s.newWrapper(keyURI)
+		if err != nil {
+			return nil, err
+		}
+
+		dataKey, err = wrapper.Unwrap(wrapped)
+		if err != nil {
+			s.markUnavailable(key, err)
+			return nil, err
+		}
+
+		if key.FailoverMode == model.TenantKeyFailoverCached {
+			s.remember(wrappedKey, dataKey, key.CacheTTL)
+		}
+	}
+
+	return openWithDataKey(dataKey, namespaceID, ciphertext)
+}
+
+func (s *TenantKeyService) probe(keyURI string) error {
+	wrapper, err := s.newWrapper(keyURI)
+	if err != nil {
+		return err
+	}
+
+	probe := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, probe); err != nil {
+		return err
+	}
+
+	wrapped, err := wrapper.Wrap(probe)
+	if err != nil {
+		return err
+	}
+
+	unwrapped, err := wrapper.Unwrap(wrapped)
+	if err != nil {
+		return err
+	}
+	if string(unwrapped) != string(probe) {
+		return ErrKMSUnwrapFailed
+	}
+
+	return nil
+}
+
+func (s *TenantKeyService) markUnavailable(key *model.TenantKey, cause error) {
+	if !errors.Is(cause, ErrKMSUnavailable) || key.Status != model.TenantKeyStatusActive {
+		return
+	}
+
+	s.db.Model(key).Updates(map[string]interface{}{
+		"status":     model.TenantKeyStatusUnavailable,
+		"last_error": cause.Error(),
+	})
+}
+
+func (s *TenantKeyService) remember(wrappedKey string, dataKey []byte, ttl int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	for cachedKey, entry := range s.cache {
+		if now.After(entry.expiresAt) {
+			delete(s.cache, cachedKey)
+		}
+	}
+
+	s.cache[wrappedKey] = cachedDataKey{key: dataKey, expiresAt: now.Add(time.Duration(ttl) * time.Second)}
+}
+
+func (s *TenantKeyService) recall(wrappedKey string) ([]byte, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entry, exists := s.cache[wrappedKey]
+	if !exists || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.key, true
+}
+
+func versionPrefix(version int) string {
+	return "v" + strconv.Itoa(version) + ":"
+}
+
+func splitWrappedKey(wrappedKey string) (int, string, error) {
+	versionPart, wrapped, found := strings.Cut(wrappedKey, ":")
+	if !found || !strings.HasPrefix(versionPart, "v") {
+		return 0, "", ErrTenantKeyVersion
+	}
+
+	version, err := strconv.Atoi(strings.TrimPrefix(versionPart, "v"))
+	if err != nil {
+		return 0, "", ErrTenantKeyVersion
+	}
+
+	return version, wrapped, nil
+}
+
+// sealWithDataKey encrypts with AES-GCM, binding the ciphertext to its
+// namespace so it cannot be moved to another tenant
+func sealWithDataKey(dataKey []byte, namespaceID uuid.UUID, plaintext []byte) (string, error) {
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, plaintext, namespaceID[:])), nil
+}
+
+func openWithDataKey(dataKey []byte, namespaceID uuid.UUID, ciphertext string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], namespaceID[:])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSecretTampered, err)
+	}
+	return plaintext, nil
+}
+
+var (
+	ErrTenantKeyNotFound = errors.New("namespace has no tenant key")
+	ErrTenantKeyExists   = errors.New("namespace already has a tenant key; rotate it instead")
+	ErrTenantKeyInvalid  = errors.New("invalid tenant key configuration")
+	ErrTenantKeyBusy     = errors.New("tenant key re-wrap is in progress")
+	ErrTenantKeyNoRewrap = errors.New("no interrupted re-wrap to resume")
+	ErrTenantKeyVersion  = errors.New("data key was wrapped by an unknown tenant key version")
+)
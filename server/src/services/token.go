@@ -0,0 +1,236 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+)
+
+// TokenAccessorPolicy is the policy path granting lookup ("read") and
+// revocation ("delete") of other users' tokens by accessor. Everyone may
+// look up and revoke their own.
+const TokenAccessorPolicy = "auth/token/accessors"
+
+// TokenService keeps the server-side record of every issued token, which
+// is what makes tokens renewable and revocable before their JWT expires
+type TokenService struct {
+	db            *gorm.DB
+	userService   *UserService
+	policyService *PolicyService
+	auditService  *AuditService
+}
+
+func NewTokenService(db *gorm.DB, userService *UserService, policyService *PolicyService, auditService *AuditService) *TokenService {
+	return &TokenService{
+		db:            db,
+		userService:   userService,
+		policyService: policyService,
+		auditService:  auditService,
+	}
+}
+
+// create records a new token of userID. The token carries no policies of
+// its own; access is decided by the user's policies at request time.
+func (s *TokenService) create(userID uuid.UUID, method string, parentID *uuid.UUID, expiresAt, maxExpiresAt time.Time) (*model.Token, error) {
+	now := time.Now()
+	token := &model.Token{
+		UserID:       userID,
+		ParentID:     parentID,
+		Method:       method,
+		TTL:          int(expiresAt.Sub(now).Round(time.Second).Seconds()),
+		ExpiresAt:    expiresAt,
+		MaxExpiresAt: maxExpiresAt,
+	}
+	if err := s.db.Create(token).Error; err != nil {
+		return nil, fmt.Errorf("failed to record token: %w", err)
+	}
+	return token, nil
+}
+
+// active returns the record of a token of userID that is still accepted
+func (s *TokenService) active(accessor, userID uuid.UUID) (*model.Token, error) {
+	var token model.Token
+	if err := s.db.Where("id = ? AND user_id = ?", accessor, userID).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTokenRevoked
+		}
+		return nil, fmt.Errorf("failed to get token: %w", err)
+	}
+	if !time.Now().Before(token.ExpiresAt) {
+		return nil, ErrTokenRevoked
+	}
+	return &token, nil
+}
+
+// Lookup returns the token with the given accessor, if userID may see it
+func (s *TokenService) Lookup(accessor, userID uuid.UUID) (*model.Token, error) {
+	token, err := s.get(accessor)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(token, userID, "read"); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// Renew extends the token by its TTL, or by increment seconds when that is
+// shorter, but never past its max TTL
+func (s *TokenService) Renew(accessor uuid.UUID, increment int, userID uuid.UUID) (*model.Token, error) {
+	token, err := s.active(accessor, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !token.Renewable() {
+		return nil, ErrTokenNotRenewable
+	}
+
+	extension := time.Duration(token.TTL) * time.Second
+	if increment > 0 && time.Duration(increment)*time.Second < extension {
+		extension = time.Duration(increment) * time.Second
+	}
+	now := time.Now()
+	expiresAt := now.Add(extension)
+	if expiresAt.After(token.MaxExpiresAt) {
+		expiresAt = token.MaxExpiresAt
+	}
+	// A shorter increment must not cut the token's remaining lifetime
+	if expiresAt.After(token.ExpiresAt) {
+		token.ExpiresAt = expiresAt
+	}
+	token.RenewedAt = &now
+	if err := s.db.Model(token).Updates(map[string]interface{}{"expires_at": token.ExpiresAt, "renewed_at": now}).Error; err != nil {
+		return nil, fmt.Errorf("failed to renew token: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "token_renewed", "token", token.ID.String(), true, "expires_at="+token.ExpiresAt.Format(time.RFC3339))
+	}
+	return token, nil
+}
+
+// Revoke revokes the token with the given accessor and every token
+// created with it, if userID may
+func (s *TokenService) Revoke(accessor, userID uuid.UUID) error {
+	token, err := s.get(accessor)
+	if err != nil {
+		return err
+	}
+	if err := s.authorize(token, userID, "delete"); err != nil {
+		return err
+	}
+
+	revoked, err := revokeTokens(s.db, []uuid.UUID{token.ID})
+	if err != nil {
+		return err
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "token_revoked", "token", token.ID.String(), true, fmt.Sprintf("user=%s; revoked=%d", token.UserID, revoked))
+	}
+	return nil
+}
+
+func (s *TokenService) Job(interval time.Duration) *Job {
+	return &Job{
+		Name:        "expired_tokens",
+		Description: "Remove expired tokens and revoke their children",
+		Interval:    interval,
+		Run:         s.purge,
+	}
+}
+
+// purge removes expired tokens. Children of an expired token are revoked
+// with it, however long they had left.
+func (s *TokenService) purge(ctx context.Context) (int64, string, error) {
+	var expired []uuid.UUID
+	if err := s.db.WithContext(ctx).Model(&model.Token{}).Where("expires_at < ?", time.Now()).Pluck("id", &expired).Error; err != nil {
+		return 0, "", fmt.Errorf("failed to find expired tokens: %w", err)
+	}
+	if len(expired) == 0 {
+		return 0, "no expired tokens", nil
+	}
+
+	revoked, err := revokeTokens(s.db.WithContext(ctx), expired)
+	if err != nil {
+		return 0, "", err
+	}
+	return revoked, fmt.Sprintf("revoked %d tokens: %d expired and their children", revoked, len(expired)), nil
+}
+
+func (s *TokenService) get(accessor uuid.UUID) (*model.Token, error) {
+	var token model.Token
+	if err := s.db.Where("id = ?", accessor).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTokenNotFound
+		}
+		return nil, fmt.Errorf("failed to get token: %w", err)
+	}
+	return &token, nil
+}
+
+// authorize lets users at their own tokens, and the central admin and
+// holders of TokenAccessorPolicy at everyone's
+func (s *TokenService) authorize(token *model.Token, userID uuid.UUID, action string) error {
+	if token.UserID == userID {
+		return nil
+	}
+	user, err := s.userService.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if IsCentralAdmin(user) {
+		return nil
+	}
+	if s.policyService != nil {
+		allowed, err := s.policyService.CheckAccess(userID, TokenAccessorPolicy, action)
+		if err != nil {
+			return fmt.Errorf("failed to check policies: %w", err)
+		}
+		if allowed {
+			return nil
+		}
+	}
+	// Other users' tokens are reported missing rather than forbidden, so
+	// accessors cannot be probed
+	return ErrTokenNotFound
+}
+
+// revokeTokens deletes the given tokens and all their descendants, with
+// their sessions, in one transaction, returning how many were removed
+func revokeTokens(db *gorm.DB, ids []uuid.UUID) (int64, error) {
+	var revoked int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		all := append([]uuid.UUID(nil), ids...)
+		for frontier := ids; len(frontier) > 0; {
+			var children []uuid.UUID
+			if err := tx.Model(&model.Token{}).Where("parent_id IN ?", frontier).Pluck("id", &children).Error; err != nil {
+				return fmt.Errorf("failed to find child tokens: %w", err)
+			}
+			all = append(all, children...)
+			frontier = children
+		}
+
+		if err := tx.Where("token_id IN ?", all).Delete(&model.Session{}).Error; err != nil {
+			return fmt.Errorf("failed to end sessions: %w", err)
+		}
+		result := tx.Where("id IN ?", all).Delete(&model.Token{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to revoke tokens: %w", result.Error)
+		}
+		revoked = result.RowsAffected
+		return nil
+	})
+	return revoked, err
+}
+
+var (
+	ErrTokenNotFound     = errors.New("token not found")
+	ErrTokenRevoked      = errors.New("token has been revoked or has expired")
+	ErrTokenNotRenewable = errors.New("token has reached its max TTL")
+)
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func TestRevokeTokensCascadesToChildren(t *testing.T) {
+	// root -> child -> grandchild, root -> sibling; other -> other-child
+	ids := map[string]uuid.UUID{}
+	for _, name := range []string{"root", "child", "grandchild", "sibling", "other", "other-child"} {
+		ids[name] = uuid.New()
+	}
+	parents := map[string]string{"child": "root", "grandchild": "child", "sibling": "root", "other-child": "other"}
+
+	tests := []struct {
+		name   string
+		revoke []string
+		want   []string
+	}{
+		{name: "leaf", revoke: []string{"grandchild"}, want: []string{"grandchild"}},
+		{name: "middle", revoke: []string{"child"}, want: []string{"child", "grandchild"}},
+		{name: "root", revoke: []string{"root"}, want: []string{"root", "child", "grandchild", "sibling"}},
+		{name: "two trees", revoke: []string{"sibling", "other"}, want: []string{"sibling", "other", "other-child"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tokens []map[string]driver.Value
+			for name, id := range ids {
+				var parent driver.Value
+				if parentName, ok := parents[name]; ok {
+					parent = ids[parentName].String()
+				}
+				tokens = append(tokens, map[string]driver.Value{"id": id.String(), "parent_id": parent})
+			}
+			db := newStubDB(t, stubTables{"tokens": tokens})
+
+			deleted := map[string][]string{}
+			db.Callback().Delete().After("gorm:delete").Register("test:revoked", func(tx *gorm.DB) {
+				for _, v := range tx.Statement.Vars {
+					deleted[tx.Statement.Table] = append(deleted[tx.Statement.Table], fmt.Sprint(v))
+				}
+			})
+
+			revoke := make([]uuid.UUID, 0, len(tt.revoke))
+			for _, name := range tt.revoke {
+				revoke = append(revoke, ids[name])
+			}
+			if _, err := revokeTokens(db, revoke); err != nil {
+				t.Fatalf("revoke failed: %v", err)
+			}
+
+			want := make([]string, 0, len(tt.want))
+			for _, name := range tt.want {
+				want = append(want, ids[name].String())
+			}
+			sort.Strings(want)
+			for _, table := range []string{"tokens", "sessions"} {
+				got := append([]string(nil), deleted[table]...)
+				sort.Strings(got)
+				if strings.Join(got, ",") != strings.Join(want, ",") {
+					t.Errorf("deleted %s %v, want %v", table, got, want)
+				}
+			}
+		})
+	}
+}
@@ -2,25 +2,62 @@ package services
 
 import (
 	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
 	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"hash"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"golang.org/x/crypto/pbkdf2"
 	"gorm.io/gorm"
 )
 
+const (
+	totpMaxFailedVerifications = 5
+	totpVerificationWindow     = 5 * time.Minute
+	totpBackupVersion          = 1
+	totpBackupIterations       = 600000
+)
+
 type TOTPService struct {
-	db           *gorm.DB
-	auditService *AuditService
+	db            *gorm.DB
+	secretService *SecretService
+	auditService  *AuditService
+
+	// Failed verification attempts per user
+	failures     map[uuid.UUID][]time.Time
+	failureMutex sync.Mutex
+}
+
+type totpBackupEntry struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Secret      string `json:"secret"`
+	Algorithm   string `json:"algorithm"`
+	Digits      int    `json:"digits"`
+	Period      int    `json:"period"`
 }
 
-func NewTOTPService(db *gorm.DB, auditService *AuditService) *TOTPService {
+func NewTOTPService(db *gorm.DB, secretService *SecretService, auditService *AuditService) *TOTPService {
 	return &TOTPService{
-		db:           db,
-		auditService: auditService,
+		db:            db,
+		secretService: secretService,
+		auditService:  auditService,
+		failures:      make(map[uuid.UUID][]time.Time),
 	}
 }
 
@@ -45,6 +82,10 @@ func (s *TOTPService) CreateTOTP(totp *model.TOTP, userID uuid.UUID) error {
 
 	totp.UserID = userID
 
+	if err := s.sealSeed(totp); err != nil {
+		return err
+	}
+
 	if err := s.db.Create(totp).Error; err != nil {
 		return fmt.Errorf("failed to create TOTP: %w", err)
 	}
@@ -96,7 +137,12 @@ func (s *TOTPService) GenerateCode(id uuid.UUID, userID uuid.UUID) (*model.TOTPG
 		return nil, fmt.Errorf("failed to get TOTP: %w", err)
 	}
 
-	code, err := s.generateTOTPCode(totp.Secret, totp.Algorithm, totp.Digits, totp.Period)
+	seed, err := s.openSeed(&totp)
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := s.generateTOTPCode(seed, totp.Algorithm, totp.Digits, totp.Period, time.Now())
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate TOTP code: %w", err)
 	}
@@ -117,6 +163,205 @@ func (s *TOTPService) GenerateCode(id uuid.UUID, userID uuid.UUID) (*model.TOTPG
 	return response, nil
 }
 
+// VerifyCode checks a code against the current and adjacent periods.
+// Users are locked out after too many failures within the window.
+func (s *TOTPService) VerifyCode(id uuid.UUID, userID uuid.UUID, code string) (bool, error) {
+	if s.isRateLimited(userID) {
+		if s.auditService != nil {
+			s.auditService.LogAction(userID, "totp_verify_rate_limited", "totp", id.String(), false, "")
+		}
+		return false, ErrTOTPRateLimited
+	}
+
+	var totp model.TOTP
+	if err := s.db.Where("id = ? AND user_id = ? AND is_active = ?", id, userID, true).First(&totp).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, ErrTOTPNotFound
+		}
+		return false, fmt.Errorf("failed to get TOTP: %w", err)
+	}
+
+	seed, err := s.openSeed(&totp)
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	period := time.Duration(totp.Period) * time.Second
+	valid := false
+	for _, offset := range []time.Duration{0, -period, period} {
+		expected, err := s.generateTOTPCode(seed, totp.Algorithm, totp.Digits, totp.Period, now.Add(offset))
+		if err != nil {
+			return false, fmt.Errorf("failed to generate TOTP code: %w", err)
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			valid = true
+			break
+		}
+	}
+
+	if valid {
+		s.clearFailures(userID)
+	} else {
+		s.recordFailure(userID)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "totp_code_verified", "totp", totp.ID.String(), valid, "")
+	}
+
+	return valid, nil
+}
+
+// ExportTOTPs bundles every active seed of a user, re-encrypted under a
+// key derived from the passphrase instead of the vault key.
+func (s *TOTPService) ExportTOTPs(req *model.TOTPExportRequest, actorID uuid.UUID) (*model.TOTPBackup, error) {
+	var totps []model.TOTP
+	if err := s.db.Where("user_id = ? AND is_active = ?", req.UserID, true).Find(&totps).Error; err != nil {
+		return nil, fmt.Errorf("failed to get TOTPs: %w", err)
+	}
+
+	entries := make([]totpBackupEntry, 0, len(totps))
+	for i := range totps {
+		seed, err := s.openSeed(&totps[i])
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, totpBackupEntry{
+			Name:        totps[i].Name,
+			Description: totps[i].Description,
+			Secret:      seed,
+			Algorithm:   totps[i].Algorithm,
+			Digits:      totps[i].Digits,
+			Period:      totps[i].Period,
+		})
+	}
+
+	plaintext, err := json.Marshal(entries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode backup: %w", err)
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	gcm, err := backupCipher(req.Passphrase, salt, totpBackupIterations)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, plaintext, []byte(req.UserID.String()))
+
+	if s.auditService != nil {
+		s.auditService.LogAction(actorID, "totp_exported", "totp", req.UserID.String(), true, fmt.Sprintf("count=%d", len(entries)))
+	}
+
+	return &model.TOTPBackup{
+		Version:    totpBackupVersion,
+		KDF:        "pbkdf2-sha256",
+		Iterations: totpBackupIterations,
+		Salt:       base64.StdEncoding.EncodeToString(salt),
+		Ciphertext: base64.StdEncoding.EncodeToString(sealed),
+		Count:      len(entries),
+		CreatedAt:  time.Now().UTC(),
+	}, nil
+}
+
+// ImportTOTPs restores a backup for a user. The backup only opens for the
+// user it was exported from.
+func (s *TOTPService) ImportTOTPs(req *model.TOTPImportRequest, actorID uuid.UUID) (int, error) {
+	backup := req.Backup
+	if backup.Version != totpBackupVersion || backup.KDF != "pbkdf2-sha256" {
+		return 0, ErrTOTPBackupInvalid
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(backup.Salt)
+	if err != nil {
+		return 0, ErrTOTPBackupInvalid
+	}
+	sealed, err := base64.StdEncoding.DecodeString(backup.Ciphertext)
+	if err != nil {
+		return 0, ErrTOTPBackupInvalid
+	}
+
+	gcm, err := backupCipher(req.Passphrase, salt, backup.Iterations)
+	if err != nil {
+		return 0, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return 0, ErrTOTPBackupInvalid
+	}
+
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], []byte(req.UserID.String()))
+	if err != nil {
+		return 0, ErrTOTPBackupInvalid
+	}
+
+	var entries []totpBackupEntry
+	if err := json.Unmarshal(plaintext, &entries); err != nil {
+		return 0, ErrTOTPBackupInvalid
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for _, entry := range entries {
+			totp := &model.TOTP{
+				UserID:      req.UserID,
+				Name:        entry.Name,
+				Description: entry.Description,
+				Secret:      entry.Secret,
+				Algorithm:   entry.Algorithm,
+				Digits:      entry.Digits,
+				Period:      entry.Period,
+				IsActive:    true,
+			}
+			if err := s.sealSeed(totp); err != nil {
+				return err
+			}
+			if err := tx.Create(totp).Error; err != nil {
+				return fmt.Errorf("failed to import TOTP: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(actorID, "totp_imported", "totp", req.UserID.String(), true, fmt.Sprintf("count=%d", len(entries)))
+	}
+
+	return len(entries), nil
+}
+
+// EncryptLegacySeeds encrypts seeds stored before encryption at rest
+func (s *TOTPService) EncryptLegacySeeds() (int, error) {
+	var totps []model.TOTP
+	if err := s.db.Unscoped().Where("encrypted = ?", false).Find(&totps).Error; err != nil {
+		return 0, fmt.Errorf("failed to get TOTPs: %w", err)
+	}
+
+	for i := range totps {
+		if err := s.sealSeed(&totps[i]); err != nil {
+			return i, err
+		}
+		if err := s.db.Unscoped().Model(&totps[i]).Updates(map[string]interface{}{
+			"secret":    totps[i].Secret,
+			"encrypted": true,
+		}).Error; err != nil {
+			return i, fmt.Errorf("failed to encrypt TOTP seed: %w", err)
+		}
+	}
+
+	return len(totps), nil
+}
+
 func (s *TOTPService) DeleteTOTP(id uuid.UUID, userID uuid.UUID) error {
 	if err := s.db.Where("id = ? AND user_id = ?", id, userID).Delete(&model.TOTP{}).Error; err != nil {
 		return fmt.Errorf("failed to delete TOTP: %w", err)
@@ -137,10 +382,133 @@ func (s *TOTPService) generateSecret() (string, error) {
 	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(secret), nil
 }
 
-func (s *TOTPService) generateTOTPCode(secret, algorithm string, digits, period int) (string, error) {
-	return "123456", nil
+// generateTOTPCode computes an RFC 6238 code for the period containing at
+func (s *TOTPService) generateTOTPCode(secret, algorithm string, digits, period int, at time.Time) (string, error) {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return "", fmt.Errorf("invalid TOTP secret: %w", err)
+	}
+
+	var newHash func() hash.Hash
+	switch strings.ToUpper(algorithm) {
+	case "", "SHA1":
+		newHash = sha1.New
+	case "SHA256":
+		newHash = sha256.New
+	case "SHA512":
+		newHash = sha512.New
+	default:
+		return "", fmt.Errorf("unsupported TOTP algorithm: %s", algorithm)
+	}
+
+	if period <= 0 {
+		period = 30
+	}
+	if digits <= 0 || digits > 10 {
+		digits = 6
+	}
+
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(at.Unix()/int64(period)))
+
+	mac := hmac.New(newHash, key)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint64(1)
+	for i := 0; i < digits; i++ {
+		modulo *= 10
+	}
+
+	return fmt.Sprintf("%0*d", digits, uint64(value)%modulo), nil
+}
+
+// sealSeed encrypts a plaintext seed with the vault encryption layer
+func (s *TOTPService) sealSeed(totp *model.TOTP) error {
+	if s.secretService == nil {
+		return ErrTOTPEncryptionUnavailable
+	}
+
+	encrypted, err := s.secretService.encrypt(totp.Secret)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt TOTP seed: %w", err)
+	}
+
+	totp.Secret = encrypted
+	totp.Encrypted = true
+	return nil
+}
+
+// openSeed returns the plaintext seed of a stored TOTP
+func (s *TOTPService) openSeed(totp *model.TOTP) (string, error) {
+	if !totp.Encrypted {
+		return totp.Secret, nil
+	}
+	if s.secretService == nil {
+		return "", ErrTOTPEncryptionUnavailable
+	}
+
+	seed, err := s.secretService.decrypt(totp.Secret)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt TOTP seed: %w", err)
+	}
+	return seed, nil
+}
+
+func (s *TOTPService) isRateLimited(userID uuid.UUID) bool {
+	s.failureMutex.Lock()
+	defer s.failureMutex.Unlock()
+
+	cutoff := time.Now().Add(-totpVerificationWindow)
+	recent := s.failures[userID][:0]
+	for _, failure := range s.failures[userID] {
+		if failure.After(cutoff) {
+			recent = append(recent, failure)
+		}
+	}
+
+	if len(recent) == 0 {
+		delete(s.failures, userID)
+		return false
+	}
+	s.failures[userID] = recent
+
+	return len(recent) >= totpMaxFailedVerifications
+}
+
+func (s *TOTPService) recordFailure(userID uuid.UUID) {
+	s.failureMutex.Lock()
+	defer s.failureMutex.Unlock()
+	s.failures[userID] = append(s.failures[userID], time.Now())
+}
+
+func (s *TOTPService) clearFailures(userID uuid.UUID) {
+	s.failureMutex.Lock()
+	defer s.failureMutex.Unlock()
+	delete(s.failures, userID)
+}
+
+func backupCipher(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	if iterations < 100000 {
+		return nil, ErrTOTPBackupInvalid
+	}
+
+	key := pbkdf2.Key([]byte(passphrase), salt, iterations, 32, sha256.New)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
 }
 
 var (
-	ErrTOTPNotFound = errors.New("TOTP not found")
+	ErrTOTPNotFound              = errors.New("TOTP not found")
+	ErrTOTPRateLimited           = errors.New("too many failed TOTP verification attempts")
+	ErrTOTPBackupInvalid         = errors.New("TOTP backup is invalid or the passphrase is wrong")
+	ErrTOTPEncryptionUnavailable = errors.New("TOTP seed encryption is unavailable")
 )
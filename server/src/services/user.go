@@ -60,6 +60,21 @@ func (s *UserService) ValidatePassword(user *model.User, password string) bool {
 	return err == nil
 }
 
+// SetPassword replaces the user's password with a hash of password
+func (s *UserService) SetPassword(user *model.User, password string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	if err := s.db.Model(user).Update("password", string(hashedPassword)).Error; err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	user.Password = string(hashedPassword)
+
+	return nil
+}
+
 func (s *UserService) UpdateUser(user *model.User) error {
 	if err := s.db.Save(user).Error; err != nil {
 		return fmt.Errorf("failed to update user: %w", err)
@@ -78,6 +93,12 @@ func (s *UserService) GetDB() *gorm.DB {
 	return s.db
 }
 
+// IsCentralAdmin reports whether user is the built-in administrator, who
+// passes every policy check
+func IsCentralAdmin(user *model.User) bool {
+	return user.Email == "admin@aether-vault.local"
+}
+
 var (
 	ErrUserNotFound       = errors.New("user not found")
 	ErrInvalidCredentials = errors.New("invalid credentials")
@@ -0,0 +1,440 @@
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	defaultValidatorTimeout = 10
+	maxValidatorTimeout     = 60
+)
+
+// validatorPlaceholder matches {{value}} and {{field}} in validator config
+var validatorPlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// validatorCheck runs one kind of check against a candidate value
+type validatorCheck func(ctx context.Context, config map[string]string, value string) error
+
+// SecretValidatorService stores validators and runs the checks they
+// describe. Checks reach out to other systems, so they run off the write
+// path; SecretService holds the write until they finish.
+type SecretValidatorService struct {
+	db           *gorm.DB
+	auditService *AuditService
+	checks       map[model.SecretValidatorKind]validatorCheck
+}
+
+func NewSecretValidatorService(db *gorm.DB, auditService *AuditService) *SecretValidatorService {
+	return &SecretValidatorService{
+		db:           db,
+		auditService: auditService,
+		checks: map[model.SecretValidatorKind]validatorCheck{
+			model.SecretValidatorPostgres: checkPostgresLogin,
+			model.SecretValidatorHTTP:     checkHTTPCredential,
+			model.SecretValidatorPEM:      checkPEMChain,
+		},
+	}
+}
+
+func (s *SecretValidatorService) CreateValidator(req *model.CreateSecretValidatorRequest, userID uuid.UUID) (*model.SecretValidator, error) {
+	pattern := strings.Trim(strings.TrimSpace(req.PathPattern), "/")
+	if pattern == "" {
+		return nil, fmt.Errorf("%w: path pattern is required", ErrValidatorInvalid)
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("%w: bad path pattern: %v", ErrValidatorInvalid, err)
+	}
+	if _, ok := s.checks[req.Kind]; !ok {
+		return nil, fmt.Errorf("%w: unsupported kind %q (use postgres, http or pem)", ErrValidatorInvalid, req.Kind)
+	}
+	if req.Kind == model.SecretValidatorHTTP && req.Config["url"] == "" {
+		return nil, fmt.Errorf("%w: http validators need a url", ErrValidatorInvalid)
+	}
+	timeout := req.Timeout
+	if timeout == 0 {
+		timeout = defaultValidatorTimeout
+	}
+	if timeout < 1 || timeout > maxValidatorTimeout {
+		return nil, fmt.Errorf("%w: timeout must be between 1 and %d seconds", ErrValidatorInvalid, maxValidatorTimeout)
+	}
+
+	config := req.Config
+	if config == nil {
+		config = map[string]string{}
+	}
+	encoded, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode validator config: %w", err)
+	}
+
+	validator := &model.SecretValidator{
+		PathPattern: pattern,
+		Kind:        req.Kind,
+		Description: req.Description,
+		Config:      string(encoded),
+		Timeout:     timeout,
+		CreatedBy:   userID,
+		ConfigMap:   config,
+	}
+	if err := s.db.Create(validator).Error; err != nil {
+		return nil, fmt.Errorf("failed to create validator: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "secret_validator_created", "secret_validator", validator.ID.String(), true, fmt.Sprintf("%s %s", validator.Kind, pattern))
+	}
+
+	return validator, nil
+}
+
+func (s *SecretValidatorService) GetValidators() ([]model.SecretValidator, error) {
+	var validators []model.SecretValidator
+	if err := s.db.Order("path_pattern").Order("created_at").Find(&validators).Error; err != nil {
+		return nil, fmt.Errorf("failed to get validators: %w", err)
+	}
+
+	for i := range validators {
+		if err := json.Unmarshal([]byte(validators[i].Config), &validators[i].ConfigMap); err != nil {
+			return nil, fmt.Errorf("failed to decode validator config: %w", err)
+		}
+	}
+
+	return validators, nil
+}
+
+func (s *SecretValidatorService) DeleteValidator(id uuid.UUID, userID uuid.UUID) error {
+	result := s.db.Where("id = ?", id).Delete(&model.SecretValidator{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete validator: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrValidatorNotFound
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "secret_validator_deleted", "secret_validator", id.String(), true, "")
+	}
+
+	return nil
+}
+
+// Match returns every validator covering a secret path. It is safe to
+// call on a nil SecretValidatorService.
+func (s *SecretValidatorService) Match(secretPath string) ([]model.SecretValidator, error) {
+	if s == nil {
+		return nil, nil
+	}
+
+	validators, err := s.GetValidators()
+	if err != nil {
+		return nil, err
+	}
+
+	secretPath = strings.Trim(secretPath, "/")
+	var matched []model.SecretValidator
+	for _, validator := range validators {
+		if matchTemplatePattern(validator.PathPattern, secretPath) {
+			matched = append(matched, validator)
+		}
+	}
+	return matched, nil
+}
+
+// Check runs each validator against value and reports every result
+func (s *SecretValidatorService) Check(ctx context.Context, validators []model.SecretValidator, value string) []model.SecretValidatorResult {
+	results := make([]model.SecretValidatorResult, 0, len(validators))
+	for _, validator := range validators {
+		result := model.SecretValidatorResult{
+			ValidatorID: validator.ID,
+			Kind:        validator.Kind,
+			PathPattern: validator.PathPattern,
+		}
+
+		started := time.Now()
+		err := s.checkOne(ctx, validator, value)
+		result.DurationMs = time.Since(started).Milliseconds()
+		if err != nil {
+			result.Message = err.Error()
+		} else {
+			result.Passed = true
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func (s *SecretValidatorService) checkOne(ctx context.Context, validator model.SecretValidator, value string) error {
+	check, ok := s.checks[validator.Kind]
+	if !ok {
+		return fmt.Errorf("unsupported validator kind %q", validator.Kind)
+	}
+
+	config, err := renderValidatorConfig(validator.ConfigMap, value)
+	if err != nil {
+		return err
+	}
+
+	timeout := validator.Timeout
+	if timeout <= 0 {
+		timeout = defaultValidatorTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	return check(ctx, config, value)
+}
+
+// renderValidatorConfig fills {{value}} and, for JSON object values,
+// {{field}} placeholders. Unknown fields are an error, not an empty string.
+func renderValidatorConfig(config map[string]string, value string) (map[string]string, error) {
+	var fields map[string]interface{}
+	_ = json.Unmarshal([]byte(value), &fields)
+
+	rendered := make(map[string]string, len(config))
+	var missing string
+	for key, raw := range config {
+		rendered[key] = validatorPlaceholder.ReplaceAllStringFunc(raw, func(match string) string {
+			name := validatorPlaceholder.FindStringSubmatch(match)[1]
+			if name == "value" {
+				return value
+			}
+			field, ok := fields[name]
+			if !ok || field == nil {
+				missing = name
+				return ""
+			}
+			if text, ok := field.(string); ok {
+				return text
+			}
+			encoded, _ := json.Marshal(field)
+			return string(encoded)
+		})
+	}
+	if missing != "" {
+		return nil, fmt.Errorf("value has no field %q", missing)
+	}
+	return rendered, nil
+}
+
+// checkPostgresLogin connects with the "dsn" setting, or the database
+// credentials in a JSON value, and pings the server
+func checkPostgresLogin(ctx context.Context, config map[string]string, value string) error {
+	dsn := config["dsn"]
+	if dsn == "" {
+		var err error
+		if _, dsn, err = postgresCredentials(value); err != nil {
+			if errors.Is(err, errNoDatabaseCredentials) {
+				return errors.New("value is not a database credential object and no dsn is configured")
+			}
+			return err
+		}
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		return fmt.Errorf("login failed: %w", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("login failed: %w", err)
+	}
+	defer sqlDB.Close()
+
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return fmt.Errorf("login failed: %w", err)
+	}
+	return nil
+}
+
+var errNoDatabaseCredentials = errors.New("value is not a database credential object")
+
+// postgresCredentials reads the database credentials in a JSON value and
+// renders the keyword DSN that logs in with them
+func postgresCredentials(value string) (*databaseCredentials, string, error) {
+	var creds databaseCredentials
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.UseNumber()
+	if err := decoder.Decode(&creds); err != nil || creds.Host == "" {
+		return nil, "", errNoDatabaseCredentials
+	}
+	if creds.Username == "" {
+		creds.Username = creds.User
+	}
+	if creds.Database == "" {
+		creds.Database = creds.DBName
+	}
+	port := defaultDatabasePorts["postgres"]
+	if creds.Port != "" {
+		parsed, err := strconv.Atoi(creds.Port.String())
+		if err != nil {
+			return nil, "", errors.New("port is not a number")
+		}
+		port = parsed
+	}
+	return &creds, postgresKeywordDSN(&creds, port), nil
+}
+
+// checkHTTPCredential calls "url" with the value in "header" (default
+// Authorization: Bearer {{value}}) and expects "expect_status" (any 2xx
+// by default)
+func checkHTTPCredential(ctx context.Context, config map[string]string, value string) error {
+	method := strings.ToUpper(config["method"])
+	if method == "" {
+		method = http.MethodGet
+	}
+	req, err := http.NewRequestWithContext(ctx, method, config["url"], nil)
+	if err != nil {
+		return errors.New("invalid url")
+	}
+
+	header := config["header"]
+	if header == "" {
+		header = "Authorization"
+	}
+	headerValue, ok := config["header_value"]
+	if !ok {
+		headerValue = "Bearer " + value
+	}
+	if headerValue != "" {
+		req.Header.Set(header, headerValue)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// The URL may carry the secret, so only the cause is reported
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if expected := config["expect_status"]; expected != "" {
+		if strconv.Itoa(resp.StatusCode) != expected {
+			return fmt.Errorf("expected status %s, got %d", expected, resp.StatusCode)
+		}
+		return nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("endpoint answered %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// checkPEMChain parses the certificates in the value (or its "field"),
+// checks their validity period and that each is signed by the next.
+// "require_key" demands a matching private key, "roots_file" and
+// "hostname" verify the chain against trusted roots.
+func checkPEMChain(ctx context.Context, config map[string]string, value string) error {
+	data := value
+	if field := config["field"]; field != "" {
+		var fields map[string]interface{}
+		if err := json.Unmarshal([]byte(value), &fields); err != nil {
+			return errors.New("value is not a JSON object")
+		}
+		text, ok := fields[field].(string)
+		if !ok {
+			return fmt.Errorf("value has no string field %q", field)
+		}
+		data = text
+	}
+
+	var certificates []*x509.Certificate
+	var certPEM, keyPEM []byte
+	rest := []byte(data)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		switch {
+		case block.Type == "CERTIFICATE":
+			certificate, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return fmt.Errorf("certificate %d is invalid: %v", len(certificates), err)
+			}
+			certificates = append(certificates, certificate)
+			certPEM = append(certPEM, pem.EncodeToMemory(block)...)
+		case strings.HasSuffix(block.Type, "PRIVATE KEY"):
+			keyPEM = pem.EncodeToMemory(block)
+		}
+	}
+	if len(certificates) == 0 {
+		return errors.New("no PEM certificate found")
+	}
+
+	now := time.Now()
+	for i, certificate := range certificates {
+		if now.Before(certificate.NotBefore) {
+			return fmt.Errorf("certificate %d (%s) is not valid before %s", i, certificate.Subject.CommonName, certificate.NotBefore.Format(time.RFC3339))
+		}
+		if now.After(certificate.NotAfter) {
+			return fmt.Errorf("certificate %d (%s) expired at %s", i, certificate.Subject.CommonName, certificate.NotAfter.Format(time.RFC3339))
+		}
+		if i > 0 {
+			if err := certificates[i-1].CheckSignatureFrom(certificate); err != nil {
+				return fmt.Errorf("certificate %d is not signed by certificate %d: %v", i-1, i, err)
+			}
+		}
+	}
+
+	if config["require_key"] == "true" {
+		if keyPEM == nil {
+			return errors.New("no private key found")
+		}
+		if _, err := tls.X509KeyPair(certPEM, keyPEM); err != nil {
+			return fmt.Errorf("private key does not match the certificate: %v", err)
+		}
+	}
+
+	if config["roots_file"] != "" || config["hostname"] != "" {
+		options := x509.VerifyOptions{DNSName: config["hostname"], Intermediates: x509.NewCertPool()}
+		for _, intermediate := range certificates[1:] {
+			options.Intermediates.AddCert(intermediate)
+		}
+		if rootsFile := config["roots_file"]; rootsFile != "" {
+			roots, err := os.ReadFile(rootsFile)
+			if err != nil {
+				return fmt.Errorf("failed to read roots: %v", err)
+			}
+			options.Roots = x509.NewCertPool()
+			if !options.Roots.AppendCertsFromPEM(roots) {
+				return fmt.Errorf("no certificates found in %s", rootsFile)
+			}
+		}
+		if _, err := certificates[0].Verify(options); err != nil {
+			return fmt.Errorf("chain does not verify: %v", err)
+		}
+	}
+
+	return nil
+}
+
+var (
+	ErrValidatorNotFound = errors.New("validator not found")
+	ErrValidatorInvalid  = errors.New("invalid validator")
+)
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+)
+
+const (
+	maxProvenanceRefLength = 2048
+	maxWorkloadListLimit   = 500
+)
+
+var (
+	imageDigestPattern = regexp.MustCompile(`^sha(256|512):[0-9a-f]{64,128}$`)
+	gitSHAPattern      = regexp.MustCompile(`^[0-9a-f]{7,64}$`)
+)
+
+// WorkloadService records which build of which service authenticated, so
+// audit entries can be traced back to an image, a commit and its
+// provenance
+type WorkloadService struct {
+	db           *gorm.DB
+	authService  *AuthService
+	auditService *AuditService
+}
+
+func NewWorkloadService(db *gorm.DB, authService *AuthService, auditService *AuditService) *WorkloadService {
+	return &WorkloadService{
+		db:           db,
+		authService:  authService,
+		auditService: auditService,
+	}
+}
+
+// Attach records the provenance a runtime reported for userID and returns
+// a token bound to it in place of tokenString
+func (s *WorkloadService) Attach(req *model.AttachWorkloadRequest, userID uuid.UUID, tokenString string) (*model.AttachWorkloadResponse, error) {
+	workload, err := s.record(req, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, expiresAt, err := s.authService.bindWorkload(tokenString, workload.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(userID, "workload_attached", "workload", workload.ID.String(), true,
+			fmt.Sprintf("service=%s; image_digest=%s; git_sha=%s", workload.Service, workload.ImageDigest, workload.GitSHA))
+	}
+
+	return &model.AttachWorkloadResponse{Token: token, ExpiresAt: expiresAt, Workload: *workload}, nil
+}
+
+// record finds or creates the workload matching a report
+func (s *WorkloadService) record(req *model.AttachWorkloadRequest, userID uuid.UUID) (*model.Workload, error) {
+	workload := model.Workload{
+		UserID:        userID,
+		Service:       strings.TrimSpace(req.Service),
+		Environment:   strings.TrimSpace(req.Environment),
+		ImageDigest:   strings.ToLower(strings.TrimSpace(req.ImageDigest)),
+		GitSHA:        strings.ToLower(strings.TrimSpace(req.GitSHA)),
+		ProvenanceRef: strings.TrimSpace(req.ProvenanceRef),
+	}
+	switch {
+	case workload.Service == "":
+		return nil, fmt.Errorf("%w: service is required", ErrWorkloadInvalid)
+	case workload.ImageDigest == "" && workload.GitSHA == "" && workload.ProvenanceRef == "":
+		return nil, fmt.Errorf("%w: report at least one of image_digest, git_sha and provenance_ref", ErrWorkloadInvalid)
+	case workload.ImageDigest != "" && !imageDigestPattern.MatchString(workload.ImageDigest):
+		return nil, fmt.Errorf("%w: image_digest must look like sha256:<hex>", ErrWorkloadInvalid)
+	case workload.GitSHA != "" && !gitSHAPattern.MatchString(workload.GitSHA):
+		return nil, fmt.Errorf("%w: git_sha must be a hexadecimal commit hash", ErrWorkloadInvalid)
+	case len(workload.ProvenanceRef) > maxProvenanceRefLength:
+		return nil, fmt.Errorf("%w: provenance_ref is longer than %d characters", ErrWorkloadInvalid, maxProvenanceRefLength)
+	}
+
+	now := time.Now()
+	err := s.db.Where(&model.Workload{
+		UserID:        workload.UserID,
+		Service:       workload.Service,
+		Environment:   workload.Environment,
+		ImageDigest:   workload.ImageDigest,
+		GitSHA:        workload.GitSHA,
+		ProvenanceRef: workload.ProvenanceRef,
+	}, "UserID", "Service", "Environment", "ImageDigest", "GitSHA", "ProvenanceRef").
+		Assign(model.Workload{LastSeenAt: now}).
+		FirstOrCreate(&workload).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to record workload: %w", err)
+	}
+	return &workload, nil
+}
+
+// GetWorkloads lists the workloads matching filter, most recently seen
+// first
+func (s *WorkloadService) GetWorkloads(filter model.WorkloadFilter, limit int) ([]model.Workload, error) {
+	if limit <= 0 || limit > maxWorkloadListLimit {
+		limit = maxWorkloadListLimit
+	}
+
+	query := s.db.Order("last_seen_at DESC").Limit(limit)
+	if filter.Service != "" {
+		query = query.Where("service = ?", filter.Service)
+	}
+	if filter.ImageDigest != "" {
+		query = query.Where("image_digest = ?", strings.ToLower(filter.ImageDigest))
+	}
+	if filter.GitSHA != "" {
+		// Short and full hashes of the same commit both match
+		query = query.Where("git_sha LIKE ?", strings.ToLower(filter.GitSHA)+"%")
+	}
+
+	var workloads []model.Workload
+	if err := query.Find(&workloads).Error; err != nil {
+		return nil, fmt.Errorf("failed to get workloads: %w", err)
+	}
+	return workloads, nil
+}
+
+func (s *WorkloadService) GetWorkload(id uuid.UUID) (*model.Workload, error) {
+	var workload model.Workload
+	if err := s.db.Where("id = ?", id).First(&workload).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWorkloadNotFound
+		}
+		return nil, fmt.Errorf("failed to get workload: %w", err)
+	}
+	return &workload, nil
+}
+
+// GetAuditLogs returns the audit entries of requests made by a workload
+func (s *WorkloadService) GetAuditLogs(workloadID uuid.UUID, limit, offset int) ([]model.AuditLog, error) {
+	var logs []model.AuditLog
+	if err := s.db.Where("workload_id = ?", workloadID).Order("created_at DESC").Limit(limit).Offset(offset).Find(&logs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get audit logs: %w", err)
+	}
+	return logs, nil
+}
+
+// bindWorkload mints a copy of a valid token, with the same claims and
+// expiry, naming the workload in wl
+func (s *AuthService) bindWorkload(tokenString string, workloadID uuid.UUID) (string, time.Time, error) {
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(s.config.Secret), nil
+	}); err != nil {
+		return "", time.Time{}, err
+	}
+
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil {
+		return "", time.Time{}, fmt.Errorf("token has no expiry")
+	}
+	expiresAt := exp.Time
+	// A recorded token's copy shares its record, and expires with it
+	if jti, ok := claims["jti"].(string); ok && s.tokens != nil {
+		accessor, err := uuid.Parse(jti)
+		if err != nil {
+			return "", time.Time{}, fmt.Errorf("invalid token ID format: %w", err)
+		}
+		record, err := s.tokens.get(accessor)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		expiresAt = record.ExpiresAt
+	}
+	claims["wl"] = workloadID.String()
+	claims["iat"] = time.Now().Unix()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.config.Secret))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to generate token: %w", err)
+	}
+	return token, expiresAt, nil
+}
+
+var (
+	ErrWorkloadInvalid      = errors.New("invalid workload provenance")
+	ErrWorkloadNotFound     = errors.New("workload not found")
+	ErrWorkloadsUnavailable = errors.New("workload provenance needs the database")
+)
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skygenesisenterprise/aether-vault/server/src/config"
+	"github.com/skygenesisenterprise/aether-vault/server/src/model"
+	"gorm.io/gorm"
+)
+
+// wrapTokenPrefix starts every wrapping token, so that leaked tokens are
+// easy to recognize in logs and scanners
+const wrapTokenPrefix = "aevw."
+
+// WrappingService hands out responses through single-use wrapping tokens.
+// A response is sealed with a fresh key that only the token carries, so
+// the stored row cannot be opened without the token and the token cannot
+// be redeemed twice.
+type WrappingService struct {
+	db           *gorm.DB
+	auditService *AuditService
+	singleUse    *SingleUseService
+	maxTTL       time.Duration
+}
+
+func NewWrappingService(db *gorm.DB, auditService *AuditService, singleUse *SingleUseService, cfg *config.WrappingConfig) *WrappingService {
+	return &WrappingService{
+		db:           db,
+		auditService: auditService,
+		singleUse:    singleUse,
+		maxTTL:       time.Duration(cfg.MaxTTL) * time.Second,
+	}
+}
+
+// ParseTTL reads a wrap_ttl such as "120s", "5m" or "120" (seconds) and
+// checks it against the configured maximum
+func (s *WrappingService) ParseTTL(value string) (time.Duration, error) {
+	ttl, err := time.ParseDuration(value)
+	if err != nil {
+		seconds, convErr := strconv.Atoi(value)
+		if convErr != nil {
+			return 0, fmt.Errorf("%w: %q is not a duration", ErrWrapTTLInvalid, value)
+		}
+		ttl = time.Duration(seconds) * time.Second
+	}
+	if ttl < time.Second {
+		return 0, fmt.Errorf("%w: must be at least 1s", ErrWrapTTLInvalid)
+	}
+	if ttl > s.maxTTL {
+		return 0, fmt.Errorf("%w: must not exceed %s", ErrWrapTTLInvalid, s.maxTTL)
+	}
+	return ttl, nil
+}
+
+// Wrap stores response for ttl and returns the token that redeems it
+func (s *WrappingService) Wrap(creatorID uuid.UUID, path string, response []byte, ttl time.Duration) (*model.WrapInfo, error) {
+	ciphertext, key, err := sealShareValue(string(response))
+	if err != nil {
+		return nil, fmt.Errorf("failed to seal response: %w", err)
+	}
+
+	wrapped := &model.WrappedResponse{
+		ID:         uuid.New(),
+		CreatorID:  creatorID,
+		CreatePath: path,
+		Ciphertext: ciphertext,
+		ExpiresAt:  time.Now().Add(ttl),
+	}
+	if err := s.db.Create(wrapped).Error; err != nil {
+		return nil, fmt.Errorf("failed to store wrapped response: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.LogAction(creatorID, "response_wrapped", "wrapping", wrapped.ID.String(), true, "path="+path+"; ttl="+ttl.String())
+	}
+
+	return &model.WrapInfo{
+		Token:        wrapTokenPrefix + wrapped.ID.String() + "." + key,
+		Accessor:     wrapped.ID,
+		TTL:          int(ttl.Seconds()),
+		CreationTime: wrapped.CreatedAt,
+		CreationPath: path,
+		ExpiresAt:    wrapped.ExpiresAt,
+	}, nil
+}
+
+// Unwrap redeems a wrapping token once and returns the response it
+// wrapped. The token's key is checked before it is consumed, so a guessed
+// accessor cannot burn someone else's token.
+func (s *WrappingService) Unwrap(token, ipAddress, userAgent string) (*model.UnwrapResponse, error) {
+	id, key, ok := parseWrapToken(token)
+	if !ok {
+		return nil, ErrWrapTokenInvalid
+	}
+
+	var wrapped model.WrappedResponse
+	if err := s.db.Where("id = ?", id).First(&wrapped).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWrapTokenInvalid
+		}
+		return nil, fmt.Errorf("failed to get wrapped response: %w", err)
+	}
+
+	if wrapped.UnwrappedAt != nil {
+		s.logUnwrap(id, ipAddress, userAgent, false, "already unwrapped")
+		return nil, ErrWrapTokenUsed
+	}
+	now := time.Now()
+	if now.After(wrapped.ExpiresAt) {
+		s.logUnwrap(id, ipAddress, userAgent, false, "expired")
+		return nil, ErrWrapTokenExpired
+	}
+	data, err := openShareValue(wrapped.Ciphertext, key)
+	if err != nil {
+		s.logUnwrap(id, ipAddress, userAgent, false, "wrong key")
+		return nil, ErrWrapTokenInvalid
+	}
+
+	err = s.singleUse.Consume(model.SingleUsePurposeUnwrap, id.String(), wrapped.ExpiresAt, ipAddress, func(tx *gorm.DB) error {
+		result := tx.Model(&model.WrappedResponse{}).
+			Where("id = ? AND unwrapped_at IS NULL", id).
+			Updates(map[string]interface{}{
+				"unwrapped_at": now,
+				"unwrapped_ip": ipAddress,
+				"ciphertext":   "",
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to unwrap response: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrWrapTokenUsed
+		}
+		return nil
+	})
+	if errors.Is(err, ErrTokenConsumed) || errors.Is(err, ErrWrapTokenUsed) {
+		s.logUnwrap(id, ipAddress, userAgent, false, "already unwrapped")
+		return nil, ErrWrapTokenUsed
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.logUnwrap(id, ipAddress, userAgent, true, "path="+wrapped.CreatePath)
+	return &model.UnwrapResponse{
+		Accessor:     id,
+		CreationPath: wrapped.CreatePath,
+		Data:         data,
+	}, nil
+}
+
+// Job removes wrapped responses that were unwrapped or have expired
+func (s *WrappingService) Job(interval time.Duration) *Job {
+	return &Job{
+		Name:        "expired_wrapped_responses",
+		Description: "Remove unwrapped and expired wrapped responses",
+		Interval:    interval,
+		Run:         s.purge,
+	}
+}
+
+func (s *WrappingService) purge(ctx context.Context) (int64, string, error) {
+	result := s.db.WithContext(ctx).Where("unwrapped_at IS NOT NULL OR expires_at < ?", time.Now()).Delete(&model.WrappedResponse{})
+	if result.Error != nil {
+		return 0, "", fmt.Errorf("failed to remove wrapped responses: %w", result.Error)
+	}
+	return result.RowsAffected, fmt.Sprintf("removed %d wrapped responses", result.RowsAffected), nil
+}
+
+func (s *WrappingService) logUnwrap(id uuid.UUID, ipAddress, userAgent string, success bool, details string) {
+	if s.auditService != nil {
+		s.auditService.LogAnonymousAction("response_unwrapped", "wrapping", id.String(), ipAddress, userAgent, success, details)
+	}
+}
+
+// parseWrapToken splits "aevw.<accessor>.<key>"
+func parseWrapToken(token string) (uuid.UUID, string, bool) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(token), wrapTokenPrefix)
+	if !ok {
+		return uuid.Nil, "", false
+	}
+	accessor, key, ok := strings.Cut(rest, ".")
+	if !ok || key == "" {
+		return uuid.Nil, "", false
+	}
+	id, err := uuid.Parse(accessor)
+	if err != nil {
+		return uuid.Nil, "", false
+	}
+	return id, key, true
+}
+
+// openShareValue reverses sealShareValue
+func openShareValue(ciphertext, key string) ([]byte, error) {
+	sealed, err := base64.RawURLEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	rawKey, err := base64.RawURLEncoding.DecodeString(key)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(rawKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	return gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+}
+
+var (
+	ErrWrapTTLInvalid   = errors.New("invalid wrap_ttl")
+	ErrWrapTokenInvalid = errors.New("invalid wrapping token")
+	ErrWrapTokenExpired = errors.New("wrapping token has expired")
+	ErrWrapTokenUsed    = errors.New("wrapping token has already been used")
+)